@@ -17,16 +17,23 @@
 package cmd
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	humanize "github.com/dustin/go-humanize"
 	"github.com/gorilla/mux"
+	"github.com/minio/minio-go/pkg/policy"
 	"github.com/minio/minio/pkg/auth"
 	"github.com/minio/minio/pkg/madmin"
 )
@@ -47,6 +54,25 @@ const (
 	mgmtLockOlderThan mgmtQueryKey = "older-than"
 	mgmtClientToken   mgmtQueryKey = "clientToken"
 	mgmtForceStart    mgmtQueryKey = "forceStart"
+	mgmtObject        mgmtQueryKey = "object"
+	mgmtStatus        mgmtQueryKey = "status"
+	mgmtMaxEntries    mgmtQueryKey = "max-entries"
+	mgmtMarker        mgmtQueryKey = "marker"
+	mgmtPolicyName    mgmtQueryKey = "name"
+	mgmtPolicyVersion mgmtQueryKey = "version"
+	mgmtProfilerType  mgmtQueryKey = "profilerType"
+	mgmtSize          mgmtQueryKey = "size"
+	mgmtConcurrent    mgmtQueryKey = "concurrent"
+	mgmtDuration      mgmtQueryKey = "duration"
+	mgmtAutotune      mgmtQueryKey = "autotune"
+	mgmtFormat        mgmtQueryKey = "format"
+	mgmtUser          mgmtQueryKey = "user"
+	mgmtTokenID       mgmtQueryKey = "id"
+	mgmtSuffix        mgmtQueryKey = "suffix"
+	mgmtContentType   mgmtQueryKey = "content-type"
+	mgmtMetadata      mgmtQueryKey = "metadata"
+	mgmtZone          mgmtQueryKey = "zone"
+	mgmtBandwidth     mgmtQueryKey = "bandwidth"
 )
 
 var (
@@ -79,7 +105,8 @@ func (a adminAPIHandlers) VersionHandler(w http.ResponseWriter, r *http.Request)
 
 // ServiceStatusHandler - GET /minio/admin/v1/service
 // ----------
-// Returns server version and uptime.
+// Returns server version, uptime and, in distributed setup, the
+// clock skew of all peers.
 func (a adminAPIHandlers) ServiceStatusHandler(w http.ResponseWriter, r *http.Request) {
 	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
 	if adminAPIErr != ErrNone {
@@ -106,6 +133,14 @@ func (a adminAPIHandlers) ServiceStatusHandler(w http.ResponseWriter, r *http.Re
 	serverStatus := madmin.ServiceStatus{
 		ServerVersion: serverVersion,
 		Uptime:        uptime,
+		ReadOnly:      globalIsReadOnly,
+		Maintenance:   isMaintenanceMode(),
+	}
+
+	// Report clock skew between nodes, since nodes with skewed
+	// clocks refuse to talk to each other.
+	if globalIsDistXL {
+		serverStatus.ClockSkew = getPeerClockSkews(globalAdminPeers)
 	}
 
 	// Marshal API response
@@ -158,6 +193,150 @@ func (a adminAPIHandlers) ServiceStopNRestartHandler(w http.ResponseWriter, r *h
 	sendServiceCmd(globalAdminPeers, serviceSig)
 }
 
+// SetMaintenanceModeHandler - PUT /minio/admin/v1/maintenance?status=on
+// - status is a mandatory query parameter, either "on" or "off"
+// ----------
+// Turns maintenance mode on or off on all servers, in maintenance
+// mode write requests are rejected with SlowDown so that the cluster
+// can be drained. Maintenance mode is not retained across restarts.
+func (a adminAPIHandlers) SetMaintenanceModeHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	var enable bool
+	switch r.URL.Query().Get(string(mgmtStatus)) {
+	case "on":
+		enable = true
+	case "off":
+		enable = false
+	default:
+		writeErrorResponseJSON(w, ErrInvalidRequest, r.URL)
+		return
+	}
+
+	if err := setPeersMaintenanceMode(globalAdminPeers, enable); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// StartProfilingHandler - POST /minio/admin/v1/profile/start?profilerType=cpu,heap
+// - profilerType is a mandatory comma separated list of profilers
+// ----------
+// Starts the given profilers on all servers, valid profilers are cpu,
+// heap, block and mutex. Profilers started earlier are stopped and
+// their profiles are discarded. Returns whether profiling was started
+// on each server.
+func (a adminAPIHandlers) StartProfilingHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	profilers, err := parseProfilers(r.URL.Query().Get(string(mgmtProfilerType)))
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	errs := startPeersProfiling(globalAdminPeers, profilers)
+	results := make([]nodeSummary, len(errs))
+	for i, err := range errs {
+		results[i].Name = globalAdminPeers[i].addr
+		if err != nil {
+			errorIf(err, "Unable to start profiling on %s", globalAdminPeers[i].addr)
+			results[i].ErrSet = true
+			results[i].ErrMsg = err.Error()
+		}
+	}
+
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, resultsJSON)
+}
+
+// DownloadProfilingHandler - POST /minio/admin/v1/profile/stop
+// ----------
+// Stops profiling on all servers and returns a zip archive with the
+// profile of each profiler as <server>/<profiler>.pprof, for servers
+// which failed to return their profiles <server>/error.txt holds the
+// reason.
+func (a adminAPIHandlers) DownloadProfilingHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	profiles, errs := stopPeersProfiling(globalAdminPeers)
+	var failed int
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == len(errs) {
+		// Profiling was never started, or was already stopped.
+		writeErrorResponseJSON(w, toAdminAPIErrCode(errs[0]), r.URL)
+		return
+	}
+
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for i, peer := range globalAdminPeers {
+		// Host names may contain ':' which is not allowed on
+		// some file systems.
+		node := strings.Replace(peer.addr, ":", "_", -1)
+		if errs[i] != nil {
+			errorIf(errs[i], "Unable to stop profiling on %s", peer.addr)
+			if err := writeZipEntry(zipWriter, node+"/error.txt", []byte(errs[i].Error())); err != nil {
+				writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+				return
+			}
+			continue
+		}
+		for profiler, data := range profiles[i] {
+			if err := writeZipEntry(zipWriter, node+"/"+profiler+".pprof", data); err != nil {
+				writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+				return
+			}
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="profile.zip"`)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// writeZipEntry - adds a file with the given contents to the zip archive.
+func writeZipEntry(zipWriter *zip.Writer, name string, data []byte) error {
+	entry, err := zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: UTCNow(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(data)
+	return err
+}
+
 // ServerProperties holds some server information such as, version, region
 // uptime, etc..
 type ServerProperties struct {
@@ -197,13 +376,52 @@ type ServerHTTPStats struct {
 	SuccessDELETEStats ServerHTTPMethodStats `json:"successDELETEs"`
 }
 
+// ServerHealOnReadStats holds the number of objects queued for healing
+// after reads found them missing or corrupted on some disks.
+type ServerHealOnReadStats struct {
+	Queued  uint64 `json:"queued"`
+	Healed  uint64 `json:"healed"`
+	Failed  uint64 `json:"failed"`
+	Dropped uint64 `json:"dropped"`
+}
+
+// ServerTmpSweepStats holds the number of entries and bytes removed
+// from the temporary upload area, left by crashes or failed uploads.
+type ServerTmpSweepStats struct {
+	Sweeps         uint64 `json:"sweeps"`
+	RemovedEntries uint64 `json:"removedEntries"`
+	ReclaimedBytes uint64 `json:"reclaimedBytes"`
+}
+
+// ServerDiskInfo holds the state and usage of a disk of the server,
+// healing is set while a running heal sequence repairs objects on it.
+type ServerDiskInfo struct {
+	Endpoint string `json:"endpoint"`
+	State    string `json:"state"`
+	Total    uint64 `json:"total"`
+	Used     uint64 `json:"used"`
+	Healing  bool   `json:"healing"`
+}
+
+// ServerGatewayInfo holds the health of the backend of a gateway.
+type ServerGatewayInfo struct {
+	Backend string `json:"backend"`
+	Online  bool   `json:"online"`
+	Error   string `json:"error,omitempty"`
+}
+
 // ServerInfoData holds storage, connections and other
 // information of a given server.
 type ServerInfoData struct {
-	StorageInfo StorageInfo      `json:"storage"`
-	ConnStats   ServerConnStats  `json:"network"`
-	HTTPStats   ServerHTTPStats  `json:"http"`
-	Properties  ServerProperties `json:"server"`
+	StorageInfo     StorageInfo               `json:"storage"`
+	Disks           []ServerDiskInfo          `json:"disks"`
+	Gateway         *ServerGatewayInfo        `json:"gateway,omitempty"`
+	ConnStats       ServerConnStats           `json:"network"`
+	HTTPStats       ServerHTTPStats           `json:"http"`
+	HealOnReadStats ServerHealOnReadStats     `json:"healOnRead"`
+	TmpSweepStats   ServerTmpSweepStats       `json:"tmpSweep"`
+	NotifyTargets   []ServerNotifyTargetStats `json:"notifyTargets"`
+	Properties      ServerProperties          `json:"server"`
 }
 
 // ServerInfo holds server information result of one node
@@ -213,6 +431,162 @@ type ServerInfo struct {
 	Data  *ServerInfoData `json:"data"`
 }
 
+// getLocalServerInfoData - returns the server info of this server.
+func getLocalServerInfoData() (sid ServerInfoData, e error) {
+	if globalBootTime.IsZero() {
+		return sid, errServerNotInitialized
+	}
+
+	// Build storage info
+	objLayer := newObjectLayerFn()
+	if objLayer == nil {
+		return sid, errServerNotInitialized
+	}
+	storage := objLayer.StorageInfo()
+
+	var arns []string
+	if globalEventNotifier != nil {
+		for queueArn := range globalEventNotifier.GetAllExternalTargets() {
+			arns = append(arns, queueArn)
+		}
+	}
+
+	var gateway *ServerGatewayInfo
+	if globalGatewayName != "" {
+		gateway = &ServerGatewayInfo{Backend: globalGatewayName, Online: true}
+		if err := checkGatewayBackend(objLayer); err != nil {
+			gateway.Online = false
+			gateway.Error = err.Error()
+		}
+	}
+
+	return ServerInfoData{
+		StorageInfo:     storage,
+		Disks:           getLocalDisksInfo(globalEndpoints, storage),
+		Gateway:         gateway,
+		ConnStats:       globalConnStats.toServerConnStats(),
+		HTTPStats:       globalHTTPStats.toServerHTTPStats(),
+		HealOnReadStats: globalHealOnRead.toServerHealOnReadStats(),
+		TmpSweepStats:   globalTmpSweepStats.toServerTmpSweepStats(),
+		NotifyTargets:   globalNotifyTargetMetrics.List(),
+		Properties: ServerProperties{
+			Uptime:   UTCNow().Sub(globalBootTime),
+			Version:  Version,
+			CommitID: CommitID,
+			SQSARN:   arns,
+			Region:   globalServerConfig.GetRegion(),
+		},
+	}, nil
+}
+
+// getLocalDisksInfo - returns the state and usage of the local disks
+// among endpoints. States of erasure coded disks are taken from the
+// drives of the storage info, disks which cannot be read are offline.
+func getLocalDisksInfo(endpoints EndpointList, storage StorageInfo) []ServerDiskInfo {
+	states := make(map[string]string)
+	for _, set := range storage.Backend.Sets {
+		for _, drive := range set {
+			states[drive.Endpoint] = drive.State
+		}
+	}
+	// Local drives are named by their path in heal results.
+	healing := globalAllHealState.healingDisks()
+
+	disks := []ServerDiskInfo{}
+	for i, endpoint := range endpoints {
+		if !endpoint.IsLocal {
+			continue
+		}
+		disk := ServerDiskInfo{
+			Endpoint: endpoints.GetString(i),
+			State:    madmin.DriveStateOk,
+		}
+		if state, ok := states[disk.Endpoint]; ok {
+			disk.State = state
+		}
+		if info, err := getDiskInfo(endpoint.Path); err != nil {
+			disk.State = madmin.DriveStateOffline
+		} else {
+			disk.Total = info.Total
+			disk.Used = info.Total - info.Free
+		}
+		disk.Healing = healing[disk.Endpoint] || healing[endpoint.Path]
+		disks = append(disks, disk)
+	}
+	return disks
+}
+
+// SpeedTestHandler - POST /minio/admin/v1/speedtest?size=64MiB&concurrent=32&duration=10s&autotune=true
+// - all query parameters are optional
+// ---------
+// Uploads objects of size with concurrent uploads on every server for
+// duration, then downloads them for duration, and returns the
+// throughput of each server and of all servers. With autotune the
+// test is repeated with twice the concurrency as long as uploads get
+// faster, the round with the fastest uploads is returned.
+func (a adminAPIHandlers) SpeedTestHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	vars := r.URL.Query()
+	size := int64(defaultSpeedTestSize)
+	if v := vars.Get(string(mgmtSize)); v != "" {
+		value, err := humanize.ParseBytes(v)
+		if err != nil || value == 0 || value > maxSpeedTestSize {
+			writeErrorResponseJSON(w, ErrInvalidQueryParams, r.URL)
+			return
+		}
+		size = int64(value)
+	}
+	concurrent := defaultSpeedTestConcurrent
+	if v := vars.Get(string(mgmtConcurrent)); v != "" {
+		var err error
+		if concurrent, err = strconv.Atoi(v); err != nil || concurrent <= 0 || concurrent > maxSpeedTestConcurrent {
+			writeErrorResponseJSON(w, ErrInvalidQueryParams, r.URL)
+			return
+		}
+	}
+	duration := defaultSpeedTestDuration
+	if v := vars.Get(string(mgmtDuration)); v != "" {
+		var err error
+		if duration, err = time.ParseDuration(v); err != nil || duration <= 0 || duration > maxSpeedTestDuration {
+			writeErrorResponseJSON(w, ErrInvalidDuration, r.URL)
+			return
+		}
+	}
+	autotune := vars.Get(string(mgmtAutotune)) == "true"
+
+	if !atomic.CompareAndSwapInt32(&speedTestCoordinating, 0, 1) {
+		writeErrorResponseJSON(w, ErrAdminSpeedTestRunning, r.URL)
+		return
+	}
+	defer atomic.StoreInt32(&speedTestCoordinating, 0)
+
+	var report speedTestReport
+	if autotune {
+		report = autotuneSpeedTest(globalAdminPeers, size, concurrent, duration)
+	} else {
+		results, errs := speedTestPeers(globalAdminPeers, size, concurrent, duration)
+		report = newSpeedTestReport(globalAdminPeers, size, concurrent, duration, results, errs)
+	}
+	jsonBytes, err := json.Marshal(report)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal speed test results into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
 // ServerInfoHandler - GET /minio/admin/v1/info
 // ----------
 // Get server information
@@ -253,6 +627,20 @@ func (a adminAPIHandlers) ServerInfoHandler(w http.ResponseWriter, r *http.Reque
 
 	wg.Wait()
 
+	// Heal sequences run on the server they were started on, mark
+	// the drives of other servers they repair too.
+	healing := globalAllHealState.healingDisks()
+	for _, info := range reply {
+		if info.Data == nil {
+			continue
+		}
+		for i := range info.Data.Disks {
+			if healing[info.Data.Disks[i].Endpoint] {
+				info.Data.Disks[i].Healing = true
+			}
+		}
+	}
+
 	// Marshal API response
 	jsonBytes, err := json.Marshal(reply)
 	if err != nil {
@@ -384,112 +772,382 @@ func (a adminAPIHandlers) ClearLocksHandler(w http.ResponseWriter, r *http.Reque
 	writeSuccessResponseJSON(w, jsonBytes)
 }
 
-// extractHealInitParams - Validates params for heal init API.
-func extractHealInitParams(r *http.Request) (bucket, objPrefix string,
-	hs madmin.HealOpts, clientToken string, forceStart bool,
-	err APIErrorCode) {
-
-	vars := mux.Vars(r)
-	bucket = vars[string(mgmtBucket)]
-	objPrefix = vars[string(mgmtPrefix)]
-
-	if bucket == "" {
-		if objPrefix != "" {
-			// Bucket is required if object-prefix is given
-			err = ErrHealMissingBucket
-			return
-		}
-	} else if !IsValidBucketName(bucket) {
-		err = ErrInvalidBucketName
+// ListLockLeasesHandler - GET /minio/admin/v1/locks/leases?bucket=mybucket&prefix=myprefix&older-than=10s
+// - bucket is a mandatory query parameter
+// - prefix and older-than are optional query parameters
+// ---------
+// Lists locks granted by the lock servers on a given bucket, prefix
+// and duration it was held for, including locks held by servers which
+// are no longer reachable.
+func (a adminAPIHandlers) ListLockLeasesHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
 		return
 	}
 
-	// empty prefix is valid.
-	if !IsValidObjectPrefix(objPrefix) {
-		err = ErrInvalidObjectName
+	vars := r.URL.Query()
+	bucket, prefix, duration, adminAPIErr := validateLockQueryParams(vars)
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
 		return
 	}
 
-	qParms := r.URL.Query()
-	if len(qParms[string(mgmtClientToken)]) > 0 {
-		clientToken = qParms[string(mgmtClientToken)][0]
-	}
-	if _, ok := qParms[string(mgmtForceStart)]; ok {
-		forceStart = true
+	leases, err := listPeerLockLeases(globalAdminPeers, bucket, prefix, duration)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to fetch lock leases from remote nodes.")
+		return
 	}
 
-	// ignore body if clientToken is provided
-	if clientToken == "" {
-		jerr := json.NewDecoder(r.Body).Decode(&hs)
-		if jerr != nil {
-			errorIf(jerr, "Error parsing body JSON")
-			err = ErrRequestBodyParse
-			return
-		}
+	jsonBytes, err := json.Marshal(leases)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal lock leases into json.")
+		return
 	}
 
-	err = ErrNone
-	return
+	writeSuccessResponseJSON(w, jsonBytes)
 }
 
-// HealHandler - POST /minio/admin/v1/heal/
-// -----------
-// Start heal processing and return heal status items.
-//
-// On a successful heal sequence start, a unique client token is
-// returned. Subsequent requests to this endpoint providing the client
-// token will receive heal status records from the running heal
-// sequence.
-//
-// If no client token is provided, and a heal sequence is in progress
-// an error is returned with information about the running heal
-// sequence. However, if the force-start flag is provided, the server
-// aborts the running heal sequence and starts a new one.
-func (a adminAPIHandlers) HealHandler(w http.ResponseWriter, r *http.Request) {
-	// Get object layer instance.
-	objLayer := newObjectLayerFn()
-	if objLayer == nil {
-		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+// ClearLockLeasesHandler - DELETE /minio/admin/v1/locks/leases?bucket=mybucket&prefix=myprefix&older-than=10s
+// - bucket is a mandatory query parameter
+// - prefix and older-than are optional query parameters
+// ---------
+// Force-releases locks granted by the lock servers on a given bucket,
+// prefix and duration it was held for, irrespective of their owner.
+func (a adminAPIHandlers) ClearLockLeasesHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
 		return
 	}
 
-	// Validate request signature.
-	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	vars := r.URL.Query()
+	bucket, prefix, duration, adminAPIErr := validateLockQueryParams(vars)
 	if adminAPIErr != ErrNone {
 		writeErrorResponseJSON(w, adminAPIErr, r.URL)
 		return
 	}
 
-	// Check if this setup has an erasure coded backend.
-	if !globalIsXL {
-		writeErrorResponseJSON(w, ErrHealNotImplemented, r.URL)
+	leases, err := listPeerLockLeases(globalAdminPeers, bucket, prefix, duration)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to fetch lock leases from remote nodes.")
 		return
 	}
 
-	bucket, objPrefix, hs, clientToken, forceStart, apiErr := extractHealInitParams(r)
-	if apiErr != ErrNone {
-		writeErrorResponseJSON(w, apiErr, r.URL)
+	jsonBytes, err := json.Marshal(leases)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal lock leases into json.")
 		return
 	}
 
-	// Helper function to fetch client address - we use the
-	// X-forwarded-for header if one is present.
-	getClientAddress := func() string {
-		addr := r.RemoteAddr
-		fwdFor := r.Header.Get("X-Forwarded-For")
-		if fwdFor == "" {
-			return addr
+	// ForceUnlock is broadcast to all lock servers, release
+	// every <bucket, object> pair only once.
+	released := make(map[nsParam]struct{})
+	for _, lease := range leases {
+		param := nsParam{volume: lease.Bucket, path: lease.Object}
+		if _, ok := released[param]; ok {
+			continue
 		}
-		return fwdFor
+		released[param] = struct{}{}
+		globalNSMutex.ForceUnlock(lease.Bucket, lease.Object)
 	}
 
-	type healResp struct {
-		respBytes []byte
-		errCode   APIErrorCode
-		errBody   string
+	// Reply with list of lock leases released, as json.
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// SetObjectQuarantineHandler - PUT /minio/admin/v1/quarantine?bucket=mybucket&object=myobject&status=cleared
+// - bucket, object and status are mandatory query parameters
+// - status is either "pending" or "cleared"
+// ---------
+// Sets the quarantine status of an object, objects pending
+// validation cannot be downloaded until they are cleared.
+func (a adminAPIHandlers) SetObjectQuarantineHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
 	}
 
-	// Define a closure to start sending whitespace to client
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	vars := r.URL.Query()
+	bucket := vars.Get(string(mgmtBucket))
+	object := vars.Get(string(mgmtObject))
+	status := vars.Get(string(mgmtStatus))
+	if !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+	if !IsValidObjectName(object) {
+		writeErrorResponseJSON(w, ErrInvalidObjectName, r.URL)
+		return
+	}
+	if status != quarantinePending && status != quarantineCleared {
+		writeErrorResponseJSON(w, ErrInvalidRequest, r.URL)
+		return
+	}
+
+	if _, err := setObjectQuarantine(objectAPI, bucket, object, status); err != nil {
+		errorIf(err, "Failed to set quarantine status of %s/%s.", bucket, object)
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// ListRecentObjectsHandler - GET /minio/admin/v1/recent-objects?bucket=mybucket&max-entries=100
+// - bucket is a mandatory query parameter
+// - max-entries is an optional query parameter
+// ---------
+// Lists the latest uploads to a bucket from its recency feed,
+// newest first, without listing the bucket.
+func (a adminAPIHandlers) ListRecentObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil || globalRecentObjects == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	vars := r.URL.Query()
+	bucket := vars.Get(string(mgmtBucket))
+	if !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+
+	maxEntries := recentObjectsFeedSize
+	if v := vars.Get(string(mgmtMaxEntries)); v != "" {
+		var err error
+		if maxEntries, err = strconv.Atoi(v); err != nil || maxEntries <= 0 {
+			writeErrorResponseJSON(w, ErrInvalidMaxKeys, r.URL)
+			return
+		}
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	entries, err := globalRecentObjects.List(objectAPI, bucket, maxEntries)
+	if err != nil {
+		errorIf(err, "Failed to read recency feed of bucket %s.", bucket)
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(entries)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal recency feed into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// SearchObjectsHandler - GET /minio/admin/v1/search?bucket=mybucket&prefix=photos/&suffix=.jpg&content-type=image/*&metadata=color:red
+// - bucket is a mandatory query parameter
+// - prefix, suffix, content-type, marker and max-entries are optional query parameters
+// - metadata is an optional query parameter, repeated for each user metadata filter
+// ---------
+// Searches the objects of a bucket in the object index, without
+// listing the bucket once it is indexed. Only the server credentials
+// are accepted, which may get all objects, so results are not filtered.
+func (a adminAPIHandlers) SearchObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	vars := r.URL.Query()
+	bucket := vars.Get(string(mgmtBucket))
+	if !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+
+	if globalObjectIndex == nil {
+		writeErrorResponseJSON(w, ErrAdminObjectIndexDisabled, r.URL)
+		return
+	}
+
+	query := objectSearchQuery{
+		Prefix:      vars.Get(string(mgmtPrefix)),
+		Suffix:      vars.Get(string(mgmtSuffix)),
+		ContentType: vars.Get(string(mgmtContentType)),
+		Marker:      vars.Get(string(mgmtMarker)),
+	}
+	for _, filter := range vars[string(mgmtMetadata)] {
+		i := strings.Index(filter, ":")
+		if i <= 0 {
+			writeErrorResponseJSON(w, ErrAdminInvalidSearchQuery, r.URL)
+			return
+		}
+		if query.Metadata == nil {
+			query.Metadata = make(map[string]string)
+		}
+		query.Metadata[filter[:i]] = filter[i+1:]
+	}
+	if v := vars.Get(string(mgmtMaxEntries)); v != "" {
+		var err error
+		if query.MaxKeys, err = strconv.Atoi(v); err != nil || query.MaxKeys <= 0 {
+			writeErrorResponseJSON(w, ErrInvalidMaxKeys, r.URL)
+			return
+		}
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	result, err := globalObjectIndex.Search(objectAPI, bucket, query)
+	if err != nil {
+		errorIf(err, "Failed to search objects of bucket %s.", bucket)
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal search result into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// extractHealInitParams - Validates params for heal init API.
+func extractHealInitParams(r *http.Request) (bucket, objPrefix string,
+	hs madmin.HealOpts, clientToken string, forceStart bool,
+	err APIErrorCode) {
+
+	vars := mux.Vars(r)
+	bucket = vars[string(mgmtBucket)]
+	objPrefix = vars[string(mgmtPrefix)]
+
+	if bucket == "" {
+		if objPrefix != "" {
+			// Bucket is required if object-prefix is given
+			err = ErrHealMissingBucket
+			return
+		}
+	} else if !IsValidBucketName(bucket) {
+		err = ErrInvalidBucketName
+		return
+	}
+
+	// empty prefix is valid.
+	if !IsValidObjectPrefix(objPrefix) {
+		err = ErrInvalidObjectName
+		return
+	}
+
+	qParms := r.URL.Query()
+	if len(qParms[string(mgmtClientToken)]) > 0 {
+		clientToken = qParms[string(mgmtClientToken)][0]
+	}
+	if _, ok := qParms[string(mgmtForceStart)]; ok {
+		forceStart = true
+	}
+
+	// ignore body if clientToken is provided
+	if clientToken == "" {
+		jerr := json.NewDecoder(r.Body).Decode(&hs)
+		if jerr != nil {
+			errorIf(jerr, "Error parsing body JSON")
+			err = ErrRequestBodyParse
+			return
+		}
+	}
+
+	err = ErrNone
+	return
+}
+
+// HealHandler - POST /minio/admin/v1/heal/
+// -----------
+// Start heal processing and return heal status items.
+//
+// On a successful heal sequence start, a unique client token is
+// returned. Subsequent requests to this endpoint providing the client
+// token will receive heal status records from the running heal
+// sequence.
+//
+// If no client token is provided, and a heal sequence is in progress
+// an error is returned with information about the running heal
+// sequence. However, if the force-start flag is provided, the server
+// aborts the running heal sequence and starts a new one.
+func (a adminAPIHandlers) HealHandler(w http.ResponseWriter, r *http.Request) {
+	// Get object layer instance.
+	objLayer := newObjectLayerFn()
+	if objLayer == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Check if this setup has an erasure coded backend.
+	if !globalIsXL {
+		writeErrorResponseJSON(w, ErrHealNotImplemented, r.URL)
+		return
+	}
+
+	bucket, objPrefix, hs, clientToken, forceStart, apiErr := extractHealInitParams(r)
+	if apiErr != ErrNone {
+		writeErrorResponseJSON(w, apiErr, r.URL)
+		return
+	}
+
+	// Helper function to fetch client address - we use the
+	// X-forwarded-for header if one is present.
+	getClientAddress := func() string {
+		addr := r.RemoteAddr
+		fwdFor := r.Header.Get("X-Forwarded-For")
+		if fwdFor == "" {
+			return addr
+		}
+		return fwdFor
+	}
+
+	type healResp struct {
+		respBytes []byte
+		errCode   APIErrorCode
+		errBody   string
+	}
+
+	// Define a closure to start sending whitespace to client
 	// after 10s unless a response item comes in
 	keepConnLive := func(w http.ResponseWriter, respCh chan healResp) {
 		ticker := time.NewTicker(time.Second * 10)
@@ -558,268 +1216,1669 @@ func (a adminAPIHandlers) HealHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// GetConfigHandler - GET /minio/admin/v1/config
-// Get config.json of this minio setup.
-func (a adminAPIHandlers) GetConfigHandler(w http.ResponseWriter, r *http.Request) {
+// ListDriveHealsHandler - GET /minio/admin/v1/heal-drives
+// ---------
+// Returns the progress of healing the sets of drives which replaced
+// failed drives while this server is running.
+func (a adminAPIHandlers) ListDriveHealsHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(globalDriveHealState.List())
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal drive heal status into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// Returns the zones of the object layer, false if the server has a
+// single zone.
+func getXLZones(objectAPI ObjectLayer) (*xlZones, bool) {
+	z, ok := unwrapObjectLayer(objectAPI).(*xlZones)
+	return z, ok
+}
+
+// Parses the zone query parameter, the position of a zone on the
+// command line starting at 1, into the index of the zone.
+func parseDecommissionZone(r *http.Request) (int, error) {
+	zone, err := strconv.Atoi(r.URL.Query().Get(string(mgmtZone)))
+	if err != nil {
+		return 0, errInvalidDecommissionZone
+	}
+	return zone - 1, nil
+}
+
+// StartDecommissionHandler - POST /minio/admin/v1/decommission?zone=2&bandwidth=104857600
+// - zone is a mandatory query parameter
+// - bandwidth is an optional query parameter
+// ---------
+// Starts moving all objects off a zone in the background, the zone
+// receives no new objects. Draining is limited to bandwidth bytes per
+// second if given, a zone being drained is resumed with the new
+// bandwidth. Returns the progress of draining the zone.
+func (a adminAPIHandlers) StartDecommissionHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	z, ok := getXLZones(objectAPI)
+	if !ok {
+		writeErrorResponseJSON(w, ErrAdminDecommissionNotSupported, r.URL)
+		return
+	}
+
+	index, err := parseDecommissionZone(r)
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+	var bandwidth int64
+	if v := r.URL.Query().Get(string(mgmtBandwidth)); v != "" {
+		if bandwidth, err = strconv.ParseInt(v, 10, 64); err != nil || bandwidth < 0 {
+			writeErrorResponseJSON(w, ErrInvalidQueryParams, r.URL)
+			return
+		}
+	}
+
+	if err = checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	status, err := z.StartDecommission(index, bandwidth)
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	// Stop placing new objects on the zone on all peers.
+	if err = reloadPeersDecommission(globalAdminPeers); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(status)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal decommission status into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// GetDecommissionStatusHandler - GET /minio/admin/v1/decommission
+// ---------
+// Returns the progress of draining all zones being decommissioned, a
+// complete zone can be removed from the command line of all servers.
+func (a adminAPIHandlers) GetDecommissionStatusHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	z, ok := getXLZones(objectAPI)
+	if !ok {
+		writeErrorResponseJSON(w, ErrAdminDecommissionNotSupported, r.URL)
+		return
+	}
+
+	statuses, err := z.DecommissionStatus()
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(statuses)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal decommission status into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// CancelDecommissionHandler - DELETE /minio/admin/v1/decommission?zone=2
+// - zone is a mandatory query parameter
+// ---------
+// Stops draining a zone, or releases a drained zone. The zone receives
+// new objects again, objects already moved stay on the other zones.
+func (a adminAPIHandlers) CancelDecommissionHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	z, ok := getXLZones(objectAPI)
+	if !ok {
+		writeErrorResponseJSON(w, ErrAdminDecommissionNotSupported, r.URL)
+		return
+	}
+
+	index, err := parseDecommissionZone(r)
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	if err = checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	if err = z.CancelDecommission(index); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	// Place new objects on the zone again on all peers.
+	if err = reloadPeersDecommission(globalAdminPeers); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetConfigHandler - GET /minio/admin/v1/config
+// Get config.json of this minio setup.
+func (a adminAPIHandlers) GetConfigHandler(w http.ResponseWriter, r *http.Request) {
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// check if objectLayer is initialized, if not return.
+	if newObjectLayerFn() == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Take a read lock on minio/config.json. NB minio is a
+	// reserved bucket name and wouldn't conflict with normal
+	// object operations.
+	configLock := globalNSMutex.NewNSLock(minioReservedBucket, minioConfigFile)
+	if configLock.GetRLock(globalObjectTimeout) != nil {
+		writeErrorResponseJSON(w, ErrOperationTimedOut, r.URL)
+		return
+	}
+	defer configLock.RUnlock()
+
+	// Get config.json - in distributed mode, the configuration
+	// occurring on a quorum of the servers is returned.
+	configBytes, err := getPeerConfig(globalAdminPeers)
+	if err != nil {
+		errorIf(err, "Failed to get config from peers")
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, configBytes)
+}
+
+// toAdminAPIErrCode - converts errXLWriteQuorum error to admin API
+// specific error.
+func toAdminAPIErrCode(err error) APIErrorCode {
+	switch err {
+	case errXLWriteQuorum:
+		return ErrAdminConfigNoQuorum
+	case errManagedPolicyNotFound:
+		return ErrAdminNoSuchManagedPolicy
+	case errInvalidManagedPolicy:
+		return ErrAdminInvalidManagedPolicy
+	case errManagedPolicyBuiltIn:
+		return ErrAdminManagedPolicyBuiltIn
+	case errManagedPolicyInUse:
+		return ErrAdminManagedPolicyInUse
+	case errInvalidBrowserTokenRequest:
+		return ErrAdminInvalidBrowserToken
+	case errInvalidBrowserTokenRevocation:
+		return ErrAdminInvalidBrowserTokenRevocation
+	case errInvalidProfiler:
+		return ErrAdminInvalidProfiler
+	case errProfilerNotStarted:
+		return ErrAdminProfilerNotStarted
+	case errSpeedTestRunning:
+		return ErrAdminSpeedTestRunning
+	case errInvalidDecommissionZone:
+		return ErrAdminInvalidDecommissionZone
+	case errNoSuchDecommission:
+		return ErrAdminNoSuchDecommission
+	}
+	return toAPIErrorCode(err)
+}
+
+// SetConfigResult - represents detailed results of a set-config
+// operation.
+type nodeSummary struct {
+	Name   string `json:"name"`
+	ErrSet bool   `json:"errSet"`
+	ErrMsg string `json:"errMsg"`
+}
+
+type setConfigResult struct {
+	NodeResults []nodeSummary `json:"nodeResults"`
+	Status      bool          `json:"status"`
+}
+
+// writeSetConfigResponse - writes setConfigResult value as json
+// depending on the status.
+func writeSetConfigResponse(w http.ResponseWriter, peers adminPeers,
+	errs []error, status bool, reqURL *url.URL) {
+
+	var nodeResults []nodeSummary
+	// Build nodeResults based on error values received during
+	// set-config operation.
+	for i := range errs {
+		nodeResults = append(nodeResults, nodeSummary{
+			Name:   peers[i].addr,
+			ErrSet: errs[i] != nil,
+			ErrMsg: fmt.Sprintf("%v", errs[i]),
+		})
+
+	}
+
+	result := setConfigResult{
+		Status:      status,
+		NodeResults: nodeResults,
+	}
+
+	// The following elaborate json encoding is to avoid escaping
+	// '<', '>' in <nil>. Note: json.Encoder.Encode() adds a
+	// gratuitous "\n".
+	var resultBuf bytes.Buffer
+	enc := json.NewEncoder(&resultBuf)
+	enc.SetEscapeHTML(false)
+	jsonErr := enc.Encode(result)
+	if jsonErr != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(jsonErr), reqURL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, resultBuf.Bytes())
+	return
+}
+
+// SetConfigHandler - PUT /minio/admin/v1/config
+func (a adminAPIHandlers) SetConfigHandler(w http.ResponseWriter, r *http.Request) {
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Read configuration bytes from request body.
+	configBuf := make([]byte, maxConfigJSONSize+1)
+	n, err := io.ReadFull(r.Body, configBuf)
+	if err == nil {
+		// More than maxConfigSize bytes were available
+		writeErrorResponse(w, ErrAdminConfigTooLarge, r.URL)
+		return
+	}
+	if err != io.ErrUnexpectedEOF {
+		errorIf(err, "Failed to read config from request body.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	configBytes := configBuf[:n]
+
+	// Validate JSON provided in the request body: check the
+	// client has not sent JSON objects with duplicate keys.
+	if err = checkDupJSONKeys(string(configBytes)); err != nil {
+		errorIf(err, "config contains duplicate JSON entries.")
+		writeErrorResponse(w, ErrAdminConfigBadJSON, r.URL)
+		return
+	}
+
+	var config serverConfig
+	err = json.Unmarshal(configBytes, &config)
+	if err != nil {
+		errorIf(err, "Failed to unmarshal JSON configuration", err)
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// If credentials for the server are provided via environment,
+	// then credentials in the provided configuration must match.
+	if globalIsEnvCreds {
+		creds := globalServerConfig.GetCredential()
+		if config.Credential.AccessKey != creds.AccessKey ||
+			config.Credential.SecretKey != creds.SecretKey {
+			writeErrorResponseJSON(w, ErrAdminCredentialsMismatch, r.URL)
+			return
+		}
+	}
+
+	// Write config received from request onto a temporary file on
+	// all nodes.
+	tmpFileName := fmt.Sprintf(minioConfigTmpFormat, mustGetUUID())
+	errs := writeTmpConfigPeers(globalAdminPeers, tmpFileName, configBytes)
+
+	// Check if the operation succeeded in quorum or more nodes.
+	rErr := reduceWriteQuorumErrs(errs, nil, len(globalAdminPeers)/2+1)
+	if rErr != nil {
+		writeSetConfigResponse(w, globalAdminPeers, errs, false, r.URL)
+		return
+	}
+
+	// Take a lock on minio/config.json. NB minio is a reserved
+	// bucket name and wouldn't conflict with normal object
+	// operations.
+	configLock := globalNSMutex.NewNSLock(minioReservedBucket, minioConfigFile)
+	if configLock.GetLock(globalObjectTimeout) != nil {
+		writeErrorResponseJSON(w, ErrOperationTimedOut, r.URL)
+		return
+	}
+	defer configLock.Unlock()
+
+	globalServerConfigMu.RLock()
+	oldConfig := *globalServerConfig
+	globalServerConfigMu.RUnlock()
+
+	// The change is recorded before it is committed, config.json is
+	// not changed unless the change log has it.
+	if err := recordChange(objectAPI, getChangeActor(r), changeTypeConfig, "", oldConfig, config); err != nil {
+		errorIf(err, "Unable to record config change.")
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Rename the temporary config file to config.json
+	errs = commitConfigPeers(globalAdminPeers, tmpFileName)
+	rErr = reduceWriteQuorumErrs(errs, nil, len(globalAdminPeers)/2+1)
+	if rErr != nil {
+		writeSetConfigResponse(w, globalAdminPeers, errs, false, r.URL)
+		return
+	}
+
+	// serverMux (cmd/server-mux.go) implements graceful shutdown,
+	// where all listeners are closed and process restart/shutdown
+	// happens after 5s or completion of all ongoing http
+	// requests, whichever is earlier.
+	writeSetConfigResponse(w, globalAdminPeers, errs, true, r.URL)
+
+	// Restart all node for the modified config to take effect.
+	sendServiceCmd(globalAdminPeers, serviceRestart)
+}
+
+// ConfigCredsHandler - POST /minio/admin/v1/config/credential
+// ----------
+// Update credentials in a minio server. In a distributed setup,
+// update all the servers in the cluster.
+func (a adminAPIHandlers) UpdateCredentialsHandler(w http.ResponseWriter,
+	r *http.Request) {
+
+	// Authenticate request
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Avoid setting new credentials when they are already passed
+	// by the environment.
+	if globalIsEnvCreds {
+		writeErrorResponse(w, ErrMethodNotAllowed, r.URL)
+		return
+	}
+
+	// Decode request body
+	var req madmin.SetCredsReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		errorIf(err, "Error parsing body JSON")
+		writeErrorResponseJSON(w, ErrRequestBodyParse, r.URL)
+		return
+	}
+
+	creds, err := auth.CreateCredentials(req.AccessKey, req.SecretKey)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Take a lock on minio/config.json. Prevents concurrent
+	// config file updates.
+	configLock := globalNSMutex.NewNSLock(minioReservedBucket, minioConfigFile)
+	if configLock.GetLock(globalObjectTimeout) != nil {
+		writeErrorResponseJSON(w, ErrOperationTimedOut, r.URL)
+		return
+	}
+	defer configLock.Unlock()
+
+	// Acquire lock before updating global configuration.
+	globalServerConfigMu.Lock()
+	defer globalServerConfigMu.Unlock()
+
+	// Notify all other Minio peers to update credentials
+	updateErrs := updateCredsOnPeers(creds)
+	for peer, err := range updateErrs {
+		errorIf(err, "Unable to update credentials on peer %s.", peer)
+	}
+
+	// Update local credentials in memory.
+	prevCred := globalServerConfig.SetCredential(creds)
+	if err = globalServerConfig.Save(); err != nil {
+		writeErrorResponse(w, ErrInternalError, r.URL)
+		return
+	}
+	if err := recordChange(newObjectLayerFn(), getChangeActor(r), changeTypeCredentials, "", prevCred, creds); err != nil {
+		errorIf(err, "Unable to record credentials change.")
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// At this stage, the operation is successful, return 200 OK
+	w.WriteHeader(http.StatusOK)
+}
+
+// ListChangeLogHandler - GET /minio/admin/v1/change-log?bucket=mybucket&marker=id&max-entries=100
+// - bucket is an optional query parameter
+// - marker is an optional query parameter
+// - max-entries is an optional query parameter
+// ---------
+// Lists recorded changes of bucket policies, credentials and the
+// server configuration, oldest first. Only changes of bucket are
+// listed if given, marker lists changes after the entry with that ID.
+func (a adminAPIHandlers) ListChangeLogHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	vars := r.URL.Query()
+	bucket := vars.Get(string(mgmtBucket))
+	if bucket != "" && !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+
+	maxEntries := maxChangeLogEntries
+	if v := vars.Get(string(mgmtMaxEntries)); v != "" {
+		var err error
+		if maxEntries, err = strconv.Atoi(v); err != nil || maxEntries <= 0 {
+			writeErrorResponseJSON(w, ErrInvalidMaxKeys, r.URL)
+			return
+		}
+		if maxEntries > maxChangeLogEntries {
+			maxEntries = maxChangeLogEntries
+		}
+	}
+
+	entries, nextMarker, err := listChangeLog(objectAPI, bucket, vars.Get(string(mgmtMarker)), maxEntries)
+	if err != nil {
+		errorIf(err, "Failed to list change log.")
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	result := listChangeLogResult{
+		Entries:     entries,
+		IsTruncated: nextMarker != "",
+		NextMarker:  nextMarker,
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal change log into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// GetAttestationKeysHandler - GET /minio/admin/v1/attestation-key
+// ---------
+// Returns the public keys verifying signed object manifests, those of
+// rotated keys included.
+func (a adminAPIHandlers) GetAttestationKeysHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	keys, err := getAttestationPublicKeys(objectAPI)
+	if err != nil {
+		errorIf(err, "Unable to load attestation keys.")
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(keys)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal attestation keys into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// RotateAttestationKeyHandler - POST /minio/admin/v1/attestation-key
+// ---------
+// Signs new object manifests with a new key, manifests signed before
+// still verify with the public key of the old one.
+func (a adminAPIHandlers) RotateAttestationKeyHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if err := rotateAttestationKey(objectAPI); err != nil {
+		errorIf(err, "Unable to rotate attestation key.")
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// TraceHandler - GET /minio/admin/v1/trace
+// ---------
+// Streams traces of all requests served by this server as JSON, one
+// trace per line, until the client disconnects.
+func (a adminAPIHandlers) TraceHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	traceCh := globalTrace.Subscribe()
+	defer globalTrace.Unsubscribe(traceCh)
+
+	// Proxies might buffer the connection, this MIME type tells
+	// them to avoid buffering.
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	writeTraces(w, r, traceCh)
+}
+
+// ForceDeleteBucketHandler - POST /minio/admin/v1/force-delete-bucket?bucket=mybucket
+// - bucket is a mandatory query parameter
+// ---------
+// Starts deleting all objects and incomplete uploads of a bucket,
+// then the bucket, in the background. Returns the progress of the
+// deletion, a running deletion is not started again.
+func (a adminAPIHandlers) ForceDeleteBucketHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	bucket := r.URL.Query().Get(string(mgmtBucket))
+	if !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Get host and port from Request.RemoteAddr.
+	host, port, _ := net.SplitHostPort(r.RemoteAddr)
+	status := globalForceDeleteState.Start(objectAPI, bucket, bgEventInfo{
+		ReqParams: extractReqParams(r),
+		UserAgent: r.UserAgent(),
+		Host:      host,
+		Port:      port,
+	})
+
+	jsonBytes, err := json.Marshal(status)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal bucket deletion status into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// ForceDeleteBucketStatusHandler - GET /minio/admin/v1/force-delete-bucket?bucket=mybucket
+// - bucket is a mandatory query parameter
+// ---------
+// Returns the progress of deleting a bucket with its contents.
+func (a adminAPIHandlers) ForceDeleteBucketStatusHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	bucket := r.URL.Query().Get(string(mgmtBucket))
+	if !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+
+	status, ok := globalForceDeleteState.Get(bucket)
+	if !ok {
+		writeErrorResponseJSON(w, ErrAdminNoSuchBucketDeletion, r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(status)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal bucket deletion status into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// SetBucketReplicationHandler - PUT /minio/admin/v1/replication?bucket=mybucket
+// - bucket is a mandatory query parameter
+// ---------
+// Sets the remote S3 target new uploads and deletions of a bucket
+// are replicated to, the target bucket must exist.
+func (a adminAPIHandlers) SetBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil || globalBucketReplication == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	bucket := r.URL.Query().Get(string(mgmtBucket))
+	if !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	var cfg replicationConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		errorIf(err, "Error parsing body JSON")
+		writeErrorResponseJSON(w, ErrRequestBodyParse, r.URL)
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		writeErrorResponseJSON(w, ErrAdminInvalidReplicationConfig, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	if err := checkReplicationTarget(cfg); err != nil {
+		errorIf(err, "Unable to access replication target %s/%s.", cfg.Endpoint, cfg.TargetBucket)
+		writeErrorResponseJSON(w, ErrAdminInvalidReplicationConfig, r.URL)
+		return
+	}
+
+	var oldCfg interface{}
+	if cfg, ok := globalBucketReplication.Get(bucket); ok {
+		oldCfg = cfg
+	}
+	if err := saveReplicationConfig(bucket, cfg, objectAPI); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketConfig(bucket, bucketReplicationConfig)
+
+	if err := recordChange(objectAPI, getChangeActor(r), changeTypeReplication, bucket, oldCfg, cfg); err != nil {
+		errorIf(err, "Unable to record replication change of bucket %s.", bucket)
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetBucketReplicationStatusHandler - GET /minio/admin/v1/replication?bucket=mybucket
+// - bucket is an optional query parameter
+// ---------
+// Returns the replication target of a bucket, or of all replicated
+// buckets, with the number and age of changes not replicated yet.
+func (a adminAPIHandlers) GetBucketReplicationStatusHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil || globalBucketReplication == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	var statuses []bucketReplicationStatus
+	if bucket := r.URL.Query().Get(string(mgmtBucket)); bucket != "" {
+		if !IsValidBucketName(bucket) {
+			writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+			return
+		}
+		status, ok, err := globalBucketReplication.Status(objectAPI, bucket)
+		if err != nil {
+			errorIf(err, "Failed to read replication queue of bucket %s.", bucket)
+			writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		if !ok {
+			writeErrorResponseJSON(w, ErrAdminNoSuchReplication, r.URL)
+			return
+		}
+		statuses = []bucketReplicationStatus{status}
+	} else {
+		var err error
+		if statuses, err = globalBucketReplication.List(objectAPI); err != nil {
+			errorIf(err, "Failed to read replication queues.")
+			writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+
+	jsonBytes, err := json.Marshal(statuses)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal replication status into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// RemoveBucketReplicationHandler - DELETE /minio/admin/v1/replication?bucket=mybucket
+// - bucket is a mandatory query parameter
+// ---------
+// Stops replicating a bucket, changes not replicated yet are dropped.
+func (a adminAPIHandlers) RemoveBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil || globalBucketReplication == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	bucket := r.URL.Query().Get(string(mgmtBucket))
+	if !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	oldCfg, ok := globalBucketReplication.Get(bucket)
+	if !ok {
+		writeErrorResponseJSON(w, ErrAdminNoSuchReplication, r.URL)
+		return
+	}
+
+	if err := removeReplicationConfig(bucket, objectAPI); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketConfig(bucket, bucketReplicationConfig)
+
+	if err := recordChange(objectAPI, getChangeActor(r), changeTypeReplication, bucket, oldCfg, nil); err != nil {
+		errorIf(err, "Unable to record replication change of bucket %s.", bucket)
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// SetBucketTieringHandler - PUT /minio/admin/v1/tiering?bucket=mybucket
+// - bucket is a mandatory query parameter
+// ---------
+// Sets the remote S3 target cold objects of a bucket are moved to and
+// the rules selecting them, the target bucket must exist. The target
+// cannot be changed while objects are on the remote tier.
+func (a adminAPIHandlers) SetBucketTieringHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil || globalBucketTiering == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	bucket := r.URL.Query().Get(string(mgmtBucket))
+	if !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	var cfg tieringConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		errorIf(err, "Error parsing body JSON")
+		writeErrorResponseJSON(w, ErrRequestBodyParse, r.URL)
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		writeErrorResponseJSON(w, ErrAdminInvalidTieringConfig, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	if err := checkTieringTarget(cfg); err != nil {
+		errorIf(err, "Unable to access tiering target %s/%s.", cfg.Endpoint, cfg.TargetBucket)
+		writeErrorResponseJSON(w, ErrAdminInvalidTieringConfig, r.URL)
+		return
+	}
+
+	var oldCfg interface{}
+	if old, ok := globalBucketTiering.Get(bucket); ok {
+		oldCfg = old
+		if !old.sameTarget(cfg) {
+			inUse, err := hasTierStubs(unwrapObjectLayer(objectAPI), bucket)
+			if err != nil {
+				writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+				return
+			}
+			if inUse {
+				writeErrorResponseJSON(w, ErrAdminTieringInUse, r.URL)
+				return
+			}
+		}
+	}
+	if err := saveTieringConfig(bucket, cfg, objectAPI); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketConfig(bucket, bucketTieringConfig)
+
+	if err := recordChange(objectAPI, getChangeActor(r), changeTypeTiering, bucket, oldCfg, cfg); err != nil {
+		errorIf(err, "Unable to record tiering change of bucket %s.", bucket)
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetBucketTieringStatusHandler - GET /minio/admin/v1/tiering?bucket=mybucket
+// - bucket is an optional query parameter
+// ---------
+// Returns the remote tier and rules of a bucket, or of all tiered
+// buckets, with the objects transitioned and restored by this server.
+func (a adminAPIHandlers) GetBucketTieringStatusHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	if newObjectLayerFn() == nil || globalBucketTiering == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	var statuses []bucketTieringStatus
+	if bucket := r.URL.Query().Get(string(mgmtBucket)); bucket != "" {
+		if !IsValidBucketName(bucket) {
+			writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+			return
+		}
+		status, ok := globalBucketTiering.Status(bucket)
+		if !ok {
+			writeErrorResponseJSON(w, ErrAdminNoSuchTiering, r.URL)
+			return
+		}
+		statuses = []bucketTieringStatus{status}
+	} else {
+		statuses = globalBucketTiering.List()
+	}
+
+	jsonBytes, err := json.Marshal(statuses)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal tiering status into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// RemoveBucketTieringHandler - DELETE /minio/admin/v1/tiering?bucket=mybucket
+// - bucket is a mandatory query parameter
+// ---------
+// Stops tiering a bucket, refused while objects of the bucket are on
+// the remote tier.
+func (a adminAPIHandlers) RemoveBucketTieringHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil || globalBucketTiering == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	bucket := r.URL.Query().Get(string(mgmtBucket))
+	if !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	oldCfg, ok := globalBucketTiering.Get(bucket)
+	if !ok {
+		writeErrorResponseJSON(w, ErrAdminNoSuchTiering, r.URL)
+		return
+	}
+
+	inUse, err := hasTierStubs(unwrapObjectLayer(objectAPI), bucket)
+	if err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if inUse {
+		writeErrorResponseJSON(w, ErrAdminTieringInUse, r.URL)
+		return
+	}
+
+	if err = removeBucketConfig(bucket, bucketTieringConfig, objectAPI); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketConfig(bucket, bucketTieringConfig)
+
+	if err := recordChange(objectAPI, getChangeActor(r), changeTypeTiering, bucket, oldCfg, nil); err != nil {
+		errorIf(err, "Unable to record tiering change of bucket %s.", bucket)
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetAccessStatsHandler - GET /minio/admin/v1/access-stats?bucket=mybucket
+// - bucket is an optional query parameter
+// ---------
+// Returns the requests served for every bucket, or only for bucket, and
+// the bytes they transferred, summed over all servers.
+func (a adminAPIHandlers) GetAccessStatsHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	if globalAccessStats == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	bucket := r.URL.Query().Get(string(mgmtBucket))
+	if bucket != "" && !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(getAccessStatsReport(globalAdminPeers, bucket))
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal access statistics into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// GetIntegrityReportHandler - GET /minio/admin/v1/integrity-report?format=csv
+// - format is an optional query parameter, csv (default) or json
+// ---------
+// Returns the objects found with missing, corrupted or offline shards
+// by the last pass of the integrity scanner of every server, as CSV or
+// as JSON lines.
+func (a adminAPIHandlers) GetIntegrityReportHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	format := r.URL.Query().Get(string(mgmtFormat))
+	if format == "" {
+		format = integrityReportCSV
+	}
+	if format != integrityReportCSV && format != integrityReportJSON {
+		writeErrorResponseJSON(w, ErrAdminInvalidIntegrityReportFormat, r.URL)
+		return
+	}
+
+	reports, err := loadIntegrityScanReports(objectAPI)
+	if err != nil {
+		errorIf(err, "Failed to load integrity scan reports.")
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if len(reports) == 0 {
+		writeErrorResponseJSON(w, ErrAdminNoIntegrityReport, r.URL)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = writeIntegrityReport(&buf, format, reports); err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to write integrity report.")
+		return
+	}
+
+	if format == integrityReportJSON {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "text/csv")
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="integrity-report.`+format+`"`)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// ListMetadataBackupsHandler - GET /minio/admin/v1/metadata-backup
+// ---------
+// Returns the metadata backup settings and the snapshots saved in the
+// backup bucket, oldest first.
+func (a adminAPIHandlers) ListMetadataBackupsHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if globalMetadataBackupBucket == "" {
+		writeErrorResponseJSON(w, ErrAdminMetadataBackupDisabled, r.URL)
+		return
+	}
+
+	backups, err := listMetadataBackups(objectAPI, globalMetadataBackupBucket)
+	if err != nil {
+		errorIf(err, "Failed to list metadata snapshots.")
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(metadataBackupStatus{
+		Bucket:   globalMetadataBackupBucket,
+		Interval: globalMetadataBackupInterval,
+		Keep:     globalMetadataBackupKeep,
+		Backups:  backups,
+	})
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal metadata snapshots into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// CreateMetadataBackupHandler - POST /minio/admin/v1/metadata-backup
+// ---------
+// Takes a metadata snapshot right away, older snapshots beyond the
+// number kept are deleted.
+func (a adminAPIHandlers) CreateMetadataBackupHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if globalMetadataBackupBucket == "" {
+		writeErrorResponseJSON(w, ErrAdminMetadataBackupDisabled, r.URL)
+		return
+	}
+
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	backup, err := createMetadataBackup(objectAPI, globalMetadataBackupBucket)
+	if err != nil {
+		errorIf(err, "Failed to back up metadata to bucket %s.", globalMetadataBackupBucket)
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	errorIf(pruneMetadataBackups(objectAPI, globalMetadataBackupBucket, globalMetadataBackupKeep),
+		"Unable to delete old metadata snapshots of bucket %s.", globalMetadataBackupBucket)
+
+	jsonBytes, err := json.Marshal(backup)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal metadata snapshot into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// RestoreMetadataBackupHandler - POST /minio/admin/v1/metadata-backup/restore?name=snapshot
+// - name is a mandatory query parameter
+// ---------
+// Recreates buckets missing since the snapshot was taken and restores
+// the configs of all buckets of the snapshot.
+func (a adminAPIHandlers) RestoreMetadataBackupHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if globalMetadataBackupBucket == "" {
+		writeErrorResponseJSON(w, ErrAdminMetadataBackupDisabled, r.URL)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if _, ok := parseMetadataBackupName(name); !ok {
+		writeErrorResponseJSON(w, ErrAdminNoSuchMetadataBackup, r.URL)
+		return
+	}
+
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	result, err := restoreMetadataBackup(objectAPI, globalMetadataBackupBucket, name)
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			writeErrorResponseJSON(w, ErrAdminNoSuchMetadataBackup, r.URL)
+			return
+		}
+		errorIf(err, "Failed to restore metadata snapshot %s.", name)
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	if err := recordChange(objectAPI, getChangeActor(r), changeTypeMetadata, "", nil, result); err != nil {
+		errorIf(err, "Unable to record restore of metadata snapshot %s.", name)
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal metadata restore result into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// ListManagedPoliciesHandler - GET /minio/admin/v1/policies?name=mypolicy&version=2
+// - name and version are optional query parameters
+// ---------
+// Lists built-in and stored managed policies with the buckets they are
+// attached to. When a name is given returns the statements of a
+// version of the policy, the default version if none is given.
+func (a adminAPIHandlers) ListManagedPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	var result interface{}
+	vars := r.URL.Query()
+	if name := vars.Get(string(mgmtPolicyName)); name != "" {
+		version := 0
+		if value := vars.Get(string(mgmtPolicyVersion)); value != "" {
+			var err error
+			if version, err = strconv.Atoi(value); err != nil || version <= 0 {
+				writeErrorResponseJSON(w, ErrAdminNoSuchManagedPolicy, r.URL)
+				return
+			}
+		}
+		p, err := loadManagedPolicy(objectAPI, name)
+		if err != nil {
+			writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+			return
+		}
+		v, ok := p.getVersion(version)
+		if !ok {
+			writeErrorResponseJSON(w, ErrAdminNoSuchManagedPolicy, r.URL)
+			return
+		}
+		result = v
+	} else {
+		policies, err := listManagedPolicies(objectAPI)
+		if err != nil {
+			errorIf(err, "Failed to list managed policies.")
+			writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+			return
+		}
+		result = policies
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal managed policies into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// PutManagedPolicyHandler - PUT /minio/admin/v1/policies?name=mypolicy
+// ---------
+// Creates a managed policy or adds a new version to it, the new version
+// becomes the default version and applies to all attached buckets. The
+// body is a bucket policy with resources in arn:aws:s3:::${bucket}.
+func (a adminAPIHandlers) PutManagedPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	name := r.URL.Query().Get(string(mgmtPolicyName))
+	if !validManagedPolicyNameRegexp.MatchString(name) {
+		writeErrorResponseJSON(w, ErrAdminInvalidManagedPolicy, r.URL)
+		return
+	}
+
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	var bucketPolicy policy.BucketAccessPolicy
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxAccessPolicySize)).Decode(&bucketPolicy); err != nil {
+		writeErrorResponseJSON(w, ErrAdminInvalidManagedPolicy, r.URL)
+		return
+	}
+
+	p, err := putManagedPolicy(objectAPI, getChangeActor(r), name, bucketPolicy.Statements)
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(p)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal managed policy into json.")
+		return
+	}
 
-	// Validate request signature.
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// SetManagedPolicyVersionHandler - PUT /minio/admin/v1/policies/default?name=mypolicy&version=1
+// ---------
+// Makes an existing version the default version of a managed policy
+// and applies it to all attached buckets, e.g. to roll back a change.
+func (a adminAPIHandlers) SetManagedPolicyVersionHandler(w http.ResponseWriter, r *http.Request) {
 	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
 	if adminAPIErr != ErrNone {
 		writeErrorResponseJSON(w, adminAPIErr, r.URL)
 		return
 	}
 
-	// check if objectLayer is initialized, if not return.
-	if newObjectLayerFn() == nil {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
 		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
 		return
 	}
 
-	// Take a read lock on minio/config.json. NB minio is a
-	// reserved bucket name and wouldn't conflict with normal
-	// object operations.
-	configLock := globalNSMutex.NewNSLock(minioReservedBucket, minioConfigFile)
-	if configLock.GetRLock(globalObjectTimeout) != nil {
-		writeErrorResponseJSON(w, ErrOperationTimedOut, r.URL)
+	vars := r.URL.Query()
+	name := vars.Get(string(mgmtPolicyName))
+	version, err := strconv.Atoi(vars.Get(string(mgmtPolicyVersion)))
+	if err != nil || version <= 0 {
+		writeErrorResponseJSON(w, ErrAdminNoSuchManagedPolicy, r.URL)
 		return
 	}
-	defer configLock.RUnlock()
 
-	// Get config.json - in distributed mode, the configuration
-	// occurring on a quorum of the servers is returned.
-	configBytes, err := getPeerConfig(globalAdminPeers)
-	if err != nil {
-		errorIf(err, "Failed to get config from peers")
+	if err = checkServerWritable(); err != nil {
 		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
 		return
 	}
 
-	writeSuccessResponseJSON(w, configBytes)
-}
-
-// toAdminAPIErrCode - converts errXLWriteQuorum error to admin API
-// specific error.
-func toAdminAPIErrCode(err error) APIErrorCode {
-	switch err {
-	case errXLWriteQuorum:
-		return ErrAdminConfigNoQuorum
+	if _, err = setManagedPolicyVersion(objectAPI, getChangeActor(r), name, version); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
 	}
-	return toAPIErrorCode(err)
-}
-
-// SetConfigResult - represents detailed results of a set-config
-// operation.
-type nodeSummary struct {
-	Name   string `json:"name"`
-	ErrSet bool   `json:"errSet"`
-	ErrMsg string `json:"errMsg"`
-}
 
-type setConfigResult struct {
-	NodeResults []nodeSummary `json:"nodeResults"`
-	Status      bool          `json:"status"`
+	writeSuccessResponseHeadersOnly(w)
 }
 
-// writeSetConfigResponse - writes setConfigResult value as json
-// depending on the status.
-func writeSetConfigResponse(w http.ResponseWriter, peers adminPeers,
-	errs []error, status bool, reqURL *url.URL) {
-
-	var nodeResults []nodeSummary
-	// Build nodeResults based on error values received during
-	// set-config operation.
-	for i := range errs {
-		nodeResults = append(nodeResults, nodeSummary{
-			Name:   peers[i].addr,
-			ErrSet: errs[i] != nil,
-			ErrMsg: fmt.Sprintf("%v", errs[i]),
-		})
+// RemoveManagedPolicyHandler - DELETE /minio/admin/v1/policies?name=mypolicy
+// ---------
+// Deletes a managed policy with all its versions, policies attached to
+// buckets cannot be deleted.
+func (a adminAPIHandlers) RemoveManagedPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
 
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
 	}
 
-	result := setConfigResult{
-		Status:      status,
-		NodeResults: nodeResults,
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
 	}
 
-	// The following elaborate json encoding is to avoid escaping
-	// '<', '>' in <nil>. Note: json.Encoder.Encode() adds a
-	// gratuitous "\n".
-	var resultBuf bytes.Buffer
-	enc := json.NewEncoder(&resultBuf)
-	enc.SetEscapeHTML(false)
-	jsonErr := enc.Encode(result)
-	if jsonErr != nil {
-		writeErrorResponseJSON(w, toAPIErrorCode(jsonErr), reqURL)
+	name := r.URL.Query().Get(string(mgmtPolicyName))
+	if err := removeManagedPolicy(objectAPI, name); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
 		return
 	}
 
-	writeSuccessResponseJSON(w, resultBuf.Bytes())
-	return
+	writeSuccessResponseHeadersOnly(w)
 }
 
-// SetConfigHandler - PUT /minio/admin/v1/config
-func (a adminAPIHandlers) SetConfigHandler(w http.ResponseWriter, r *http.Request) {
+// IssueBrowserTokenHandler - POST /minio/admin/v1/browser-tokens
+// ---------
+// Issues browser tokens of a user allowed the managed policies granted
+// in the request only, e.g. to hand out read-only dashboard access.
+func (a adminAPIHandlers) IssueBrowserTokenHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
 
-	// Get current object layer instance.
 	objectAPI := newObjectLayerFn()
 	if objectAPI == nil {
 		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
 		return
 	}
 
-	// Validate request signature.
-	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
-	if adminAPIErr != ErrNone {
-		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+	var req browserTokenRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBrowserTokenRequestSize)).Decode(&req); err != nil {
+		writeErrorResponseJSON(w, ErrAdminInvalidBrowserToken, r.URL)
 		return
 	}
 
-	// Read configuration bytes from request body.
-	configBuf := make([]byte, maxConfigJSONSize+1)
-	n, err := io.ReadFull(r.Body, configBuf)
-	if err == nil {
-		// More than maxConfigSize bytes were available
-		writeErrorResponse(w, ErrAdminConfigTooLarge, r.URL)
+	token, err := issueBrowserToken(objectAPI, req)
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
 		return
 	}
-	if err != io.ErrUnexpectedEOF {
-		errorIf(err, "Failed to read config from request body.")
-		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+
+	jsonBytes, err := json.Marshal(token)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal browser token into json.")
 		return
 	}
 
-	configBytes := configBuf[:n]
+	writeSuccessResponseJSON(w, jsonBytes)
+}
 
-	// Validate JSON provided in the request body: check the
-	// client has not sent JSON objects with duplicate keys.
-	if err = checkDupJSONKeys(string(configBytes)); err != nil {
-		errorIf(err, "config contains duplicate JSON entries.")
-		writeErrorResponse(w, ErrAdminConfigBadJSON, r.URL)
+// RevokeBrowserTokensHandler - DELETE /minio/admin/v1/browser-tokens?user=myuser&id=tokenid
+// ---------
+// Revokes all browser tokens issued to a user until now, or the refresh
+// token with the ID, on all servers within a minute.
+func (a adminAPIHandlers) RevokeBrowserTokensHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
 		return
 	}
 
-	var config serverConfig
-	err = json.Unmarshal(configBytes, &config)
-	if err != nil {
-		errorIf(err, "Failed to unmarshal JSON configuration", err)
-		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
 		return
 	}
 
-	// If credentials for the server are provided via environment,
-	// then credentials in the provided configuration must match.
-	if globalIsEnvCreds {
-		creds := globalServerConfig.GetCredential()
-		if config.Credential.AccessKey != creds.AccessKey ||
-			config.Credential.SecretKey != creds.SecretKey {
-			writeErrorResponseJSON(w, ErrAdminCredentialsMismatch, r.URL)
-			return
-		}
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
 	}
 
-	// Write config received from request onto a temporary file on
-	// all nodes.
-	tmpFileName := fmt.Sprintf(minioConfigTmpFormat, mustGetUUID())
-	errs := writeTmpConfigPeers(globalAdminPeers, tmpFileName, configBytes)
-
-	// Check if the operation succeeded in quorum or more nodes.
-	rErr := reduceWriteQuorumErrs(errs, nil, len(globalAdminPeers)/2+1)
-	if rErr != nil {
-		writeSetConfigResponse(w, globalAdminPeers, errs, false, r.URL)
+	vars := r.URL.Query()
+	if err := revokeBrowserTokens(objectAPI, vars.Get(string(mgmtUser)), vars.Get(string(mgmtTokenID))); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
 		return
 	}
 
-	// Take a lock on minio/config.json. NB minio is a reserved
-	// bucket name and wouldn't conflict with normal object
-	// operations.
-	configLock := globalNSMutex.NewNSLock(minioReservedBucket, minioConfigFile)
-	if configLock.GetLock(globalObjectTimeout) != nil {
-		writeErrorResponseJSON(w, ErrOperationTimedOut, r.URL)
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// AttachManagedPolicyHandler - PUT /minio/admin/v1/policies/attach?name=mypolicy&bucket=mybucket
+// ---------
+// Attaches a managed policy to a bucket, the bucket policy is replaced
+// by the default version of the managed policy and follows its changes.
+func (a adminAPIHandlers) AttachManagedPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
 		return
 	}
-	defer configLock.Unlock()
 
-	// Rename the temporary config file to config.json
-	errs = commitConfigPeers(globalAdminPeers, tmpFileName)
-	rErr = reduceWriteQuorumErrs(errs, nil, len(globalAdminPeers)/2+1)
-	if rErr != nil {
-		writeSetConfigResponse(w, globalAdminPeers, errs, false, r.URL)
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
 		return
 	}
 
-	// serverMux (cmd/server-mux.go) implements graceful shutdown,
-	// where all listeners are closed and process restart/shutdown
-	// happens after 5s or completion of all ongoing http
-	// requests, whichever is earlier.
-	writeSetConfigResponse(w, globalAdminPeers, errs, true, r.URL)
-
-	// Restart all node for the modified config to take effect.
-	sendServiceCmd(globalAdminPeers, serviceRestart)
-}
-
-// ConfigCredsHandler - POST /minio/admin/v1/config/credential
-// ----------
-// Update credentials in a minio server. In a distributed setup,
-// update all the servers in the cluster.
-func (a adminAPIHandlers) UpdateCredentialsHandler(w http.ResponseWriter,
-	r *http.Request) {
+	vars := r.URL.Query()
+	name := vars.Get(string(mgmtPolicyName))
+	bucket := vars.Get(string(mgmtBucket))
+	if !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
 
-	// Authenticate request
-	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
-	if adminAPIErr != ErrNone {
-		writeErrorResponse(w, adminAPIErr, r.URL)
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
 		return
 	}
 
-	// Avoid setting new credentials when they are already passed
-	// by the environment.
-	if globalIsEnvCreds {
-		writeErrorResponse(w, ErrMethodNotAllowed, r.URL)
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponseJSON(w, toAPIErrorCode(err), r.URL)
 		return
 	}
 
-	// Decode request body
-	var req madmin.SetCredsReq
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		errorIf(err, "Error parsing body JSON")
-		writeErrorResponseJSON(w, ErrRequestBodyParse, r.URL)
+	if err := attachManagedPolicy(objectAPI, getChangeActor(r), name, bucket); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
 		return
 	}
 
-	creds, err := auth.CreateCredentials(req.AccessKey, req.SecretKey)
-	if err != nil {
-		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// DetachManagedPolicyHandler - DELETE /minio/admin/v1/policies/attach?bucket=mybucket
+// ---------
+// Detaches the managed policy of a bucket and removes its bucket policy.
+func (a adminAPIHandlers) DetachManagedPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, globalServerConfig.GetRegion())
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
 		return
 	}
 
-	// Take a lock on minio/config.json. Prevents concurrent
-	// config file updates.
-	configLock := globalNSMutex.NewNSLock(minioReservedBucket, minioConfigFile)
-	if configLock.GetLock(globalObjectTimeout) != nil {
-		writeErrorResponseJSON(w, ErrOperationTimedOut, r.URL)
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
 		return
 	}
-	defer configLock.Unlock()
 
-	// Acquire lock before updating global configuration.
-	globalServerConfigMu.Lock()
-	defer globalServerConfigMu.Unlock()
+	bucket := r.URL.Query().Get(string(mgmtBucket))
+	if !IsValidBucketName(bucket) {
+		writeErrorResponseJSON(w, ErrInvalidBucketName, r.URL)
+		return
+	}
 
-	// Notify all other Minio peers to update credentials
-	updateErrs := updateCredsOnPeers(creds)
-	for peer, err := range updateErrs {
-		errorIf(err, "Unable to update credentials on peer %s.", peer)
+	if err := checkServerWritable(); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
 	}
 
-	// Update local credentials in memory.
-	globalServerConfig.SetCredential(creds)
-	if err = globalServerConfig.Save(); err != nil {
-		writeErrorResponse(w, ErrInternalError, r.URL)
+	if err := detachManagedPolicy(objectAPI, getChangeActor(r), bucket); err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
 		return
 	}
 
-	// At this stage, the operation is successful, return 200 OK
-	w.WriteHeader(http.StatusOK)
+	writeSuccessResponseHeadersOnly(w)
 }
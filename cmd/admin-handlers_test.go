@@ -17,7 +17,9 @@
 package cmd
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -26,10 +28,14 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	humanize "github.com/dustin/go-humanize"
 	router "github.com/gorilla/mux"
 	"github.com/minio/minio/pkg/auth"
 	"github.com/minio/minio/pkg/errors"
@@ -229,7 +235,7 @@ func (atb *adminXLTestBed) GenerateHealTestData(t *testing.T) {
 			t.Fatalf("mp new error: %v", err)
 		}
 
-		_, err = atb.objLayer.PutObjectPart(bucketName, objName,
+		_, err = atb.objLayer.PutObjectPart(context.Background(), bucketName, objName,
 			uploadID, 3, mustGetHashReader(t, bytes.NewReader(
 				[]byte("hello")), int64(len("hello")), "", ""))
 		if err != nil {
@@ -561,6 +567,90 @@ func TestServiceSetCreds(t *testing.T) {
 	}
 }
 
+// Test for change log listing management REST API.
+func TestListChangeLogHandler(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	globalIsChangeLog = true
+	defer func() { globalIsChangeLog = false }()
+
+	// Initialize admin peers to make admin RPC calls.
+	globalMinioAddr = "127.0.0.1:9000"
+	initGlobalAdminPeers(mustGetNewEndpointList("http://127.0.0.1:9000/d1"))
+
+	// Change credentials to record a change.
+	credentials := globalServerConfig.GetCredential()
+	body, err := json.Marshal(madmin.SetCredsReq{AccessKey: "minio", SecretKey: "minio123"})
+	if err != nil {
+		t.Fatalf("JSONify err: %v", err)
+	}
+	req, err := getServiceCmdRequest(setCreds, credentials, body)
+	if err != nil {
+		t.Fatalf("Failed to build set credentials request %v", err)
+	}
+	rec := httptest.NewRecorder()
+	adminTestBed.mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected to receive %d status code but received %d", http.StatusOK, rec.Code)
+	}
+
+	testCases := []struct {
+		queryVal       url.Values
+		expectedStatus int
+		expectedCount  int
+	}{
+		// Test 1 - all changes.
+		{url.Values{}, http.StatusOK, 1},
+		// Test 2 - credentials are not a bucket change.
+		{url.Values{string(mgmtBucket): []string{"mybucket"}}, http.StatusOK, 0},
+		// Test 3 - invalid bucket name.
+		{url.Values{string(mgmtBucket): []string{`invalid\\Bucket`}}, http.StatusBadRequest, 0},
+		// Test 4 - invalid max entries.
+		{url.Values{string(mgmtMaxEntries): []string{"-1"}}, http.StatusBadRequest, 0},
+	}
+	for i, test := range testCases {
+		req, err = newTestRequest("GET", "/minio/admin/v1/change-log?"+test.queryVal.Encode(), 0, nil)
+		if err != nil {
+			t.Fatalf("Test %d - Failed to construct list change log request - %v", i+1, err)
+		}
+
+		cred := globalServerConfig.GetCredential()
+		if err = signRequestV4(req, cred.AccessKey, cred.SecretKey); err != nil {
+			t.Fatalf("Test %d - Failed to sign list change log request - %v", i+1, err)
+		}
+		rec = httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		if test.expectedStatus != rec.Code {
+			t.Fatalf("Test %d - Expected HTTP status code %d but received %d", i+1, test.expectedStatus, rec.Code)
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		var result madmin.ChangeLogResult
+		if err = json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatalf("Test %d - Failed to decode change log - %v", i+1, err)
+		}
+		if len(result.Entries) != test.expectedCount {
+			t.Fatalf("Test %d - Expected %d changes but received %d", i+1, test.expectedCount, len(result.Entries))
+		}
+		for _, entry := range result.Entries {
+			if entry.Type != changeTypeCredentials || entry.Actor.AccessKey != credentials.AccessKey {
+				t.Errorf("Test %d - Unexpected change %#v", i+1, entry)
+			}
+			for _, diff := range entry.Diff {
+				if diff.Key == "secretKey" && diff.New != redactedValue {
+					t.Errorf("Test %d - Expected secret key to be redacted, got %s", i+1, diff.New)
+				}
+			}
+		}
+	}
+}
+
 // mkLockQueryVal - helper function to build lock query param.
 func mkLockQueryVal(bucket, prefix, durationStr string) url.Values {
 	qVal := url.Values{}
@@ -927,6 +1017,366 @@ func TestAdminServerInfo(t *testing.T) {
 		if serverInfo.Data.Properties.Region != globalMinioDefaultRegion {
 			t.Errorf("Expected %s, got %s", globalMinioDefaultRegion, serverInfo.Data.Properties.Region)
 		}
+		if len(serverInfo.Data.Disks) != len(adminTestBed.xlDirs) || serverInfo.Data.Gateway != nil {
+			t.Fatalf("Expected %d disks and no gateway, got %+v", len(adminTestBed.xlDirs), serverInfo.Data)
+		}
+		for _, disk := range serverInfo.Data.Disks {
+			if disk.State != madmin.DriveStateOk || disk.Total == 0 || disk.Used > disk.Total || disk.Healing {
+				t.Errorf("Unexpected disk %+v", disk)
+			}
+		}
+	}
+
+	getServerInfoData := func() *ServerInfoData {
+		rec := httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		results := []ServerInfo{}
+		if err = json.NewDecoder(rec.Body).Decode(&results); err != nil {
+			t.Fatalf("Failed to decode server info result json %v", err)
+		}
+		if len(results) != 1 || results[0].Data == nil {
+			t.Fatalf("Unexpected server info %+v", results)
+		}
+		return results[0].Data
+	}
+
+	// Wiped disks are reported without format.
+	removeRoots(adminTestBed.xlDirs[:1])
+	if disk := getServerInfoData().Disks[0]; disk.State != madmin.DriveStateMissing {
+		t.Errorf("Expected disk without format, got %+v", disk)
+	}
+
+	// Gateways report the health of their backend and no disks.
+	defer func(objectAPI ObjectLayer) {
+		globalObjectAPI = objectAPI
+		globalGatewayName = ""
+	}(globalObjectAPI)
+	globalGatewayName = "test"
+	globalEndpoints = nil
+	globalObjectAPI = healthTestGateway{err: fmt.Errorf("connection refused")}
+	data := getServerInfoData()
+	if len(data.Disks) != 0 || data.Gateway == nil || data.Gateway.Backend != "test" ||
+		data.Gateway.Online || data.Gateway.Error != "connection refused" {
+		t.Errorf("Unexpected gateway server info %+v %+v", data, data.Gateway)
+	}
+	globalObjectAPI = healthTestGateway{}
+	if data = getServerInfoData(); data.Gateway == nil || !data.Gateway.Online {
+		t.Errorf("Expected online gateway, got %+v", data.Gateway)
+	}
+}
+
+// Tests that only the drives repaired by a running heal sequence are
+// reported as healing.
+func TestGetLocalDisksInfoHealing(t *testing.T) {
+	dirs, err := getRandomDisks(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(dirs)
+	endpoints := mustGetNewEndpointList(dirs...)
+
+	savedHealSeqMap := globalAllHealState.healSeqMap
+	defer func() { globalAllHealState.healSeqMap = savedHealSeqMap }()
+
+	testCases := []struct {
+		dryRun   bool
+		expected []bool
+	}{
+		{false, []bool{true, false}},
+		{true, []bool{false, false}},
+	}
+	for i, testCase := range testCases {
+		h := newHealSequence("bucket", "", "", len(dirs), madmin.HealOpts{DryRun: testCase.dryRun}, false)
+		globalAllHealState.healSeqMap = map[string]*healSequence{h.path: h}
+
+		var item madmin.HealResultItem
+		item.Before.Drives = []madmin.HealDriveInfo{
+			{Endpoint: dirs[0], State: madmin.DriveStateMissing},
+			{Endpoint: dirs[1], State: madmin.DriveStateOk},
+		}
+		item.After.Drives = []madmin.HealDriveInfo{
+			{Endpoint: dirs[0], State: madmin.DriveStateOk},
+			{Endpoint: dirs[1], State: madmin.DriveStateOk},
+		}
+		if err = h.pushHealResultItem(item); err != nil {
+			t.Fatal(err)
+		}
+
+		disks := getLocalDisksInfo(endpoints, StorageInfo{})
+		for j, disk := range disks {
+			if disk.Healing != testCase.expected[j] {
+				t.Errorf("Test %d: Expected disk %s healing to be %v", i+1, disk.Endpoint, testCase.expected[j])
+			}
+		}
+
+		// Drives of ended sequences are not healing anymore.
+		h.currentStatus.Summary = healFinishedStatus
+		for _, disk := range getLocalDisksInfo(endpoints, StorageInfo{}) {
+			if disk.Healing {
+				t.Errorf("Test %d: Expected disk %s not to be healing", i+1, disk.Endpoint)
+			}
+		}
+	}
+}
+
+// TestProfilingHandlers - test for StartProfilingHandler and
+// DownloadProfilingHandler.
+func TestProfilingHandlers(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	globalMinioAddr = "127.0.0.1:9000"
+	initGlobalAdminPeers(mustGetNewEndpointList("http://127.0.0.1:9000/d1"))
+
+	startProfiling := func(profilers string) *httptest.ResponseRecorder {
+		queryVal := url.Values{}
+		queryVal.Set(string(mgmtProfilerType), profilers)
+		req, err := buildAdminRequest(queryVal, http.MethodPost, "/profile/start", 0, nil)
+		if err != nil {
+			t.Fatalf("Failed to construct start profiling request - %v", err)
+		}
+		rec := httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		return rec
+	}
+	stopProfiling := func() *httptest.ResponseRecorder {
+		req, err := buildAdminRequest(url.Values{}, http.MethodPost, "/profile/stop", 0, nil)
+		if err != nil {
+			t.Fatalf("Failed to construct stop profiling request - %v", err)
+		}
+		rec := httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Invalid profilers are rejected.
+	for _, profilers := range []string{"", "cpu,trace", "goroutine"} {
+		rec := startProfiling(profilers)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: Expected %d but got %d", profilers, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	// Stopping without starting fails.
+	if rec := stopProfiling(); rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected %d but got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	rec := startProfiling("cpu,mutex,cpu")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected to succeed but failed with %d: %s", rec.Code, rec.Body)
+	}
+	var results []madmin.NodeSummary
+	if err = json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Name != globalAdminPeers[0].addr || results[0].ErrSet {
+		t.Fatalf("Unexpected start profiling results %+v", results)
+	}
+
+	rec = stopProfiling()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected to succeed but failed with %d: %s", rec.Code, rec.Body)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/zip" {
+		t.Errorf("Expected content type application/zip but got %s", contentType)
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range zipReader.File {
+		names = append(names, file.Name)
+		if file.UncompressedSize64 == 0 {
+			t.Errorf("Expected %s to hold a profile", file.Name)
+		}
+	}
+	sort.Strings(names)
+	expected := []string{"localhost_9000/cpu.pprof", "localhost_9000/mutex.pprof"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v but got %v", expected, names)
+	}
+
+	// Profiling is already stopped.
+	if rec = stopProfiling(); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected %d but got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+// TestSpeedTestHandler - test for SpeedTestHandler.
+func TestSpeedTestHandler(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	globalMinioAddr = "127.0.0.1:9000"
+	initGlobalAdminPeers(mustGetNewEndpointList("http://127.0.0.1:9000/d1"))
+
+	speedTest := func(size, concurrent, duration, autotune string) *httptest.ResponseRecorder {
+		queryVal := url.Values{}
+		queryVal.Set(string(mgmtSize), size)
+		queryVal.Set(string(mgmtConcurrent), concurrent)
+		queryVal.Set(string(mgmtDuration), duration)
+		queryVal.Set(string(mgmtAutotune), autotune)
+		req, err := buildAdminRequest(queryVal, http.MethodPost, "/speedtest", 0, nil)
+		if err != nil {
+			t.Fatalf("Failed to construct speed test request - %v", err)
+		}
+		rec := httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Invalid parameters are rejected.
+	for _, args := range [][3]string{
+		{"0", "2", "10ms"},
+		{"2GiB", "2", "10ms"},
+		{"1KiB", "0", "10ms"},
+		{"1KiB", "2", "1h"},
+		{"1KiB", "2", "abc"},
+	} {
+		if rec := speedTest(args[0], args[1], args[2], ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("%v: Expected %d but got %d", args, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	for _, autotune := range []string{"false", "true"} {
+		rec := speedTest("1KiB", "2", "50ms", autotune)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected to succeed but failed with %d: %s", rec.Code, rec.Body)
+		}
+		var result madmin.SpeedTestResult
+		if err = json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Size != humanize.KiByte || result.Duration != 50*time.Millisecond ||
+			len(result.Servers) != 1 || result.Servers[0].Err != "" ||
+			result.PUT.ObjectsPerSec == 0 || result.GET.ObjectsPerSec == 0 ||
+			result.PUT != result.Servers[0].PUT || result.GET != result.Servers[0].GET {
+			t.Fatalf("Unexpected speed test result %+v", result)
+		}
+		if autotune == "false" && result.Concurrent != 2 {
+			t.Fatalf("Expected a concurrency of 2 but got %d", result.Concurrent)
+		}
+	}
+
+	// The objects of the test are removed.
+	for _, xlDir := range adminTestBed.xlDirs {
+		filepath.Walk(filepath.Join(xlDir, minioMetaBucket, speedTestPrefix), func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				t.Errorf("Expected the speed test objects to be removed but found %s", path)
+			}
+			return nil
+		})
+	}
+}
+
+// Tests the speed test writes to all zones of a server.
+func TestSpeedTestZones(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	disks, err := getRandomDisks(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	obj, err := newXLZones([]zoneEndpoints{
+		{mustGetNewEndpointList(disks[:4]...), 1, 4},
+		{mustGetNewEndpointList(disks[4:]...), 1, 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layers := getSpeedTestLayers(obj); len(layers) != 2 {
+		t.Fatalf("Expected both zones to be tested but got %d", len(layers))
+	}
+
+	result, err := runSpeedTest(obj, humanize.KiByte, 4, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Uploads == 0 || result.Downloads == 0 {
+		t.Fatalf("Unexpected speed test result %+v", result)
+	}
+	for _, disk := range disks {
+		filepath.Walk(filepath.Join(disk, minioMetaBucket, speedTestPrefix), func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				t.Errorf("Expected the speed test objects to be removed but found %s", path)
+			}
+			return nil
+		})
+	}
+}
+
+func TestGetAccessStatsHandler(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	globalMinioAddr = "127.0.0.1:9000"
+	initGlobalAdminPeers(mustGetNewEndpointList("http://127.0.0.1:9000/d1"))
+
+	defer func(a *accessStats) { globalAccessStats = a }(globalAccessStats)
+	globalAccessStats = newAccessStats()
+	globalAccessStats.Record("bucket1", http.MethodGet, http.StatusOK, 0, 10)
+	globalAccessStats.Record("bucket2", http.MethodPut, http.StatusOK, 20, 0)
+
+	getAccessStats := func(bucket string) *httptest.ResponseRecorder {
+		queryVal := url.Values{}
+		if bucket != "" {
+			queryVal.Set(string(mgmtBucket), bucket)
+		}
+		req, err := buildAdminRequest(queryVal, http.MethodGet, "/access-stats", 0, nil)
+		if err != nil {
+			t.Fatalf("Failed to construct access statistics request - %v", err)
+		}
+		rec := httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := getAccessStats("b"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected %d for an invalid bucket but got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	testCases := []struct {
+		bucket  string
+		buckets []string
+	}{
+		{"", []string{"bucket1", "bucket2"}},
+		{"bucket2", []string{"bucket2"}},
+		{"bucket3", nil},
+	}
+	for i, testCase := range testCases {
+		rec := getAccessStats(testCase.bucket)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Test %d: Expected to succeed but failed with %d: %s", i+1, rec.Code, rec.Body)
+		}
+		var stats madmin.AccessStats
+		if err = json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+			t.Fatal(err)
+		}
+		if len(stats.Buckets) != len(testCase.buckets) || len(stats.FailedServers) != 0 {
+			t.Fatalf("Test %d: Unexpected access statistics %+v", i+1, stats)
+		}
+		for j, bucket := range testCase.buckets {
+			if stats.Buckets[j].Bucket != bucket {
+				t.Errorf("Test %d: Expected bucket %s but got %s", i+1, bucket, stats.Buckets[j].Bucket)
+			}
+		}
 	}
 }
 
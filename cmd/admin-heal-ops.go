@@ -119,6 +119,25 @@ func (ahs *allHealState) getHealSequence(path string) (h *healSequence, exists b
 	return h, exists
 }
 
+// healingDisks - returns the endpoints of the drives repaired by
+// the running heal sequences.
+func (ahs *allHealState) healingDisks() map[string]bool {
+	ahs.Lock()
+	defer ahs.Unlock()
+	disks := make(map[string]bool)
+	for _, h := range ahs.healSeqMap {
+		if h.hasEnded() {
+			continue
+		}
+		h.currentStatus.updateLock.RLock()
+		for endpoint := range h.healedDisks {
+			disks[endpoint] = true
+		}
+		h.currentStatus.updateLock.RUnlock()
+	}
+	return disks
+}
+
 // LaunchNewHealSequence - launches a background routine that performs
 // healing according to the healSequence argument. For each heal
 // sequence, state is stored in the `globalAllHealState`, which is a
@@ -309,6 +328,10 @@ type healSequence struct {
 
 	// the last result index sent to client
 	lastSentResultIndex int64
+
+	// endpoints of the drives repaired so far, protected by
+	// currentStatus.updateLock
+	healedDisks map[string]bool
 }
 
 // NewHealSequence - creates healSettings, assumes bucket and
@@ -333,6 +356,7 @@ func newHealSequence(bucket, objPrefix, clientAddr string,
 		},
 		traverseAndHealDoneCh: make(chan error),
 		stopSignalCh:          make(chan struct{}),
+		healedDisks:           make(map[string]bool),
 	}
 }
 
@@ -427,6 +451,19 @@ func (h *healSequence) pushHealResultItem(r madmin.HealResultItem) error {
 	// append to results
 	h.currentStatus.Items = append(h.currentStatus.Items, r)
 
+	// Remember the drives the item was missing or corrupt on and
+	// which got repaired.
+	if !h.settings.DryRun {
+		for i, before := range r.Before.Drives {
+			if before.State != madmin.DriveStateMissing && before.State != madmin.DriveStateCorrupt {
+				continue
+			}
+			if i < len(r.After.Drives) && r.After.Drives[i].State == madmin.DriveStateOk && r.After.Drives[i].Endpoint != "" {
+				h.healedDisks[r.After.Drives[i].Endpoint] = true
+			}
+		}
+	}
+
 	// release lock
 	h.currentStatus.updateLock.Unlock()
 
@@ -0,0 +1,156 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Profilers supported by the profiling admin API.
+const (
+	profilerCPU   = "cpu"
+	profilerHeap  = "heap"
+	profilerBlock = "block"
+	profilerMutex = "mutex"
+)
+
+var (
+	errProfilerNotStarted = errors.New("Profiling is not started")
+	errInvalidProfiler    = errors.New("Invalid profiler type")
+)
+
+// parseProfilers - parses a comma separated list of profilers,
+// duplicates are removed.
+func parseProfilers(value string) ([]string, error) {
+	seen := make(map[string]bool)
+	var profilers []string
+	for _, name := range strings.Split(value, ",") {
+		switch name {
+		case profilerCPU, profilerHeap, profilerBlock, profilerMutex:
+		default:
+			return nil, errInvalidProfiler
+		}
+		if !seen[name] {
+			seen[name] = true
+			profilers = append(profilers, name)
+		}
+	}
+	sort.Strings(profilers)
+	return profilers, nil
+}
+
+// startProfilerInMemory - starts the named profiler, the returned
+// function stops it and returns the profile in pprof format.
+func startProfilerInMemory(name string) (func() ([]byte, error), error) {
+	var buf bytes.Buffer
+	switch name {
+	case profilerCPU:
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return nil, err
+		}
+		return func() ([]byte, error) {
+			pprof.StopCPUProfile()
+			return buf.Bytes(), nil
+		}, nil
+	case profilerHeap:
+		// Heap allocations are always sampled, the profile is
+		// written when stopped.
+		return func() ([]byte, error) {
+			err := pprof.Lookup("heap").WriteTo(&buf, 0)
+			return buf.Bytes(), err
+		}, nil
+	case profilerBlock:
+		runtime.SetBlockProfileRate(1)
+		return func() ([]byte, error) {
+			defer runtime.SetBlockProfileRate(0)
+			err := pprof.Lookup("block").WriteTo(&buf, 0)
+			return buf.Bytes(), err
+		}, nil
+	case profilerMutex:
+		runtime.SetMutexProfileFraction(1)
+		return func() ([]byte, error) {
+			defer runtime.SetMutexProfileFraction(0)
+			err := pprof.Lookup("mutex").WriteTo(&buf, 0)
+			return buf.Bytes(), err
+		}, nil
+	}
+	return nil, errInvalidProfiler
+}
+
+// profiling - profilers running on this server, they are started and
+// stopped together.
+type profiling struct {
+	mu    sync.Mutex
+	stops map[string]func() ([]byte, error)
+}
+
+// Start - starts the profilers, profilers still running from an
+// earlier start are stopped and their profiles are discarded.
+func (p *profiling) Start(profilers []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopAll()
+	stops := make(map[string]func() ([]byte, error))
+	for _, name := range profilers {
+		stop, err := startProfilerInMemory(name)
+		if err != nil {
+			p.stops = stops
+			p.stopAll()
+			return err
+		}
+		stops[name] = stop
+	}
+	p.stops = stops
+	return nil
+}
+
+// Stop - stops the profilers, returns the profile of each profiler.
+func (p *profiling) Stop() (map[string][]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.stops) == 0 {
+		return nil, errProfilerNotStarted
+	}
+	return p.stopAll()
+}
+
+// stopAll - stops all profilers, returns their profiles and the first
+// error encountered.
+func (p *profiling) stopAll() (map[string][]byte, error) {
+	profiles := make(map[string][]byte)
+	var firstErr error
+	for name, stop := range p.stops {
+		data, err := stop()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		profiles[name] = data
+	}
+	p.stops = nil
+	return profiles, firstErr
+}
+
+// Profilers started through the profiling admin API.
+var globalProfiling = &profiling{}
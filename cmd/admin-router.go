@@ -30,6 +30,17 @@ const (
 type adminAPIHandlers struct {
 }
 
+// registerGatewayAdminRouter - registers the admin APIs supported in
+// gateway mode.
+func registerGatewayAdminRouter(mux *router.Router) {
+	adminAPI := adminAPIHandlers{}
+	adminRouter := mux.NewRoute().PathPrefix(adminAPIPathPrefix).Subrouter()
+	adminRouter.Methods(http.MethodGet).Path("/version").HandlerFunc(auditAPI(adminAPI.VersionHandler))
+
+	adminV1Router := adminRouter.PathPrefix("/v1").Subrouter()
+	adminV1Router.Methods(http.MethodGet).Path("/info").HandlerFunc(auditAPI(adminAPI.ServerInfoHandler))
+}
+
 // registerAdminRouter - Add handler functions for each service REST API routes.
 func registerAdminRouter(mux *router.Router) {
 
@@ -38,41 +49,136 @@ func registerAdminRouter(mux *router.Router) {
 	adminRouter := mux.NewRoute().PathPrefix(adminAPIPathPrefix).Subrouter()
 
 	// Version handler
-	adminRouter.Methods(http.MethodGet).Path("/version").HandlerFunc(adminAPI.VersionHandler)
+	adminRouter.Methods(http.MethodGet).Path("/version").HandlerFunc(auditAPI(adminAPI.VersionHandler))
 
 	adminV1Router := adminRouter.PathPrefix("/v1").Subrouter()
 
 	/// Service operations
 
 	// Service status
-	adminV1Router.Methods(http.MethodGet).Path("/service").HandlerFunc(adminAPI.ServiceStatusHandler)
+	adminV1Router.Methods(http.MethodGet).Path("/service").HandlerFunc(auditAPI(adminAPI.ServiceStatusHandler))
 
 	// Service restart and stop - TODO
-	adminV1Router.Methods(http.MethodPost).Path("/service").HandlerFunc(adminAPI.ServiceStopNRestartHandler)
+	adminV1Router.Methods(http.MethodPost).Path("/service").HandlerFunc(auditAPI(adminAPI.ServiceStopNRestartHandler))
+
+	// Maintenance mode on and off
+	adminV1Router.Methods(http.MethodPut).Path("/maintenance").HandlerFunc(auditAPI(adminAPI.SetMaintenanceModeHandler))
+
+	// Start profiling on all servers
+	adminV1Router.Methods(http.MethodPost).Path("/profile/start").HandlerFunc(auditAPI(adminAPI.StartProfilingHandler))
+	// Stop profiling and download the profiles of all servers
+	adminV1Router.Methods(http.MethodPost).Path("/profile/stop").HandlerFunc(auditAPI(adminAPI.DownloadProfilingHandler))
+
+	// Stream live request traces
+	adminV1Router.Methods(http.MethodGet).Path("/trace").HandlerFunc(auditAPI(adminAPI.TraceHandler))
+
+	// Benchmark uploads and downloads of all servers
+	adminV1Router.Methods(http.MethodPost).Path("/speedtest").HandlerFunc(auditAPI(adminAPI.SpeedTestHandler))
 
 	// Info operations
-	adminV1Router.Methods(http.MethodGet).Path("/info").HandlerFunc(adminAPI.ServerInfoHandler)
+	adminV1Router.Methods(http.MethodGet).Path("/info").HandlerFunc(auditAPI(adminAPI.ServerInfoHandler))
 
 	/// Lock operations
 
 	// List Locks
-	adminV1Router.Methods(http.MethodGet).Path("/locks").HandlerFunc(adminAPI.ListLocksHandler)
+	adminV1Router.Methods(http.MethodGet).Path("/locks").HandlerFunc(auditAPI(adminAPI.ListLocksHandler))
 	// Clear locks
-	adminV1Router.Methods(http.MethodDelete).Path("/locks").HandlerFunc(adminAPI.ClearLocksHandler)
+	adminV1Router.Methods(http.MethodDelete).Path("/locks").HandlerFunc(auditAPI(adminAPI.ClearLocksHandler))
+	// List lock leases held on lock servers
+	adminV1Router.Methods(http.MethodGet).Path("/locks/leases").HandlerFunc(auditAPI(adminAPI.ListLockLeasesHandler))
+	// Force-release lock leases held on lock servers
+	adminV1Router.Methods(http.MethodDelete).Path("/locks/leases").HandlerFunc(auditAPI(adminAPI.ClearLockLeasesHandler))
+
+	/// Object operations
+
+	// Set quarantine status of an object
+	adminV1Router.Methods(http.MethodPut).Path("/quarantine").HandlerFunc(auditAPI(adminAPI.SetObjectQuarantineHandler))
+	// Get public keys verifying signed object manifests
+	adminV1Router.Methods(http.MethodGet).Path("/attestation-key").HandlerFunc(auditAPI(adminAPI.GetAttestationKeysHandler))
+	// Sign new object manifests with a new key
+	adminV1Router.Methods(http.MethodPost).Path("/attestation-key").HandlerFunc(auditAPI(adminAPI.RotateAttestationKeyHandler))
+	// Search objects by name and metadata
+	adminV1Router.Methods(http.MethodGet).Path("/search").HandlerFunc(auditAPI(adminAPI.SearchObjectsHandler))
+
+	/// Bucket operations
+
+	// List latest uploads to a bucket
+	adminV1Router.Methods(http.MethodGet).Path("/recent-objects").HandlerFunc(auditAPI(adminAPI.ListRecentObjectsHandler))
+	// Delete a bucket with its contents
+	adminV1Router.Methods(http.MethodPost).Path("/force-delete-bucket").HandlerFunc(auditAPI(adminAPI.ForceDeleteBucketHandler))
+	// Progress of deleting a bucket with its contents
+	adminV1Router.Methods(http.MethodGet).Path("/force-delete-bucket").HandlerFunc(auditAPI(adminAPI.ForceDeleteBucketStatusHandler))
+	// Set replication target of a bucket
+	adminV1Router.Methods(http.MethodPut).Path("/replication").HandlerFunc(auditAPI(adminAPI.SetBucketReplicationHandler))
+	// Replication status and lag of buckets
+	adminV1Router.Methods(http.MethodGet).Path("/replication").HandlerFunc(auditAPI(adminAPI.GetBucketReplicationStatusHandler))
+	// Stop replicating a bucket
+	adminV1Router.Methods(http.MethodDelete).Path("/replication").HandlerFunc(auditAPI(adminAPI.RemoveBucketReplicationHandler))
+	// Set remote tier and transition rules of a bucket
+	adminV1Router.Methods(http.MethodPut).Path("/tiering").HandlerFunc(auditAPI(adminAPI.SetBucketTieringHandler))
+	// Tiering status of buckets
+	adminV1Router.Methods(http.MethodGet).Path("/tiering").HandlerFunc(auditAPI(adminAPI.GetBucketTieringStatusHandler))
+	// Stop tiering a bucket
+	adminV1Router.Methods(http.MethodDelete).Path("/tiering").HandlerFunc(auditAPI(adminAPI.RemoveBucketTieringHandler))
+	// Requests and bytes transferred per bucket
+	adminV1Router.Methods(http.MethodGet).Path("/access-stats").HandlerFunc(auditAPI(adminAPI.GetAccessStatsHandler))
+	// List metadata snapshots
+	adminV1Router.Methods(http.MethodGet).Path("/metadata-backup").HandlerFunc(auditAPI(adminAPI.ListMetadataBackupsHandler))
+	// Take a metadata snapshot now
+	adminV1Router.Methods(http.MethodPost).Path("/metadata-backup").HandlerFunc(auditAPI(adminAPI.CreateMetadataBackupHandler))
+	// Restore bucket configs from a metadata snapshot
+	adminV1Router.Methods(http.MethodPost).Path("/metadata-backup/restore").HandlerFunc(auditAPI(adminAPI.RestoreMetadataBackupHandler))
+
+	/// Managed policy operations
+
+	// List managed policies, or get a version of one
+	adminV1Router.Methods(http.MethodGet).Path("/policies").HandlerFunc(auditAPI(adminAPI.ListManagedPoliciesHandler))
+	// Create a managed policy or add a new version
+	adminV1Router.Methods(http.MethodPut).Path("/policies").HandlerFunc(auditAPI(adminAPI.PutManagedPolicyHandler))
+	// Delete a managed policy
+	adminV1Router.Methods(http.MethodDelete).Path("/policies").HandlerFunc(auditAPI(adminAPI.RemoveManagedPolicyHandler))
+	// Set the default version of a managed policy
+	adminV1Router.Methods(http.MethodPut).Path("/policies/default").HandlerFunc(auditAPI(adminAPI.SetManagedPolicyVersionHandler))
+	// Attach a managed policy to a bucket
+	adminV1Router.Methods(http.MethodPut).Path("/policies/attach").HandlerFunc(auditAPI(adminAPI.AttachManagedPolicyHandler))
+	// Detach the managed policy of a bucket
+	adminV1Router.Methods(http.MethodDelete).Path("/policies/attach").HandlerFunc(auditAPI(adminAPI.DetachManagedPolicyHandler))
+
+	/// Browser token operations
+
+	// Issue browser tokens of a user allowed managed policies only
+	adminV1Router.Methods(http.MethodPost).Path("/browser-tokens").HandlerFunc(auditAPI(adminAPI.IssueBrowserTokenHandler))
+	// Revoke the browser tokens of a user or a refresh token
+	adminV1Router.Methods(http.MethodDelete).Path("/browser-tokens").HandlerFunc(auditAPI(adminAPI.RevokeBrowserTokensHandler))
 
 	/// Heal operations
 
 	// Heal processing endpoint.
-	adminV1Router.Methods(http.MethodPost).Path("/heal/").HandlerFunc(adminAPI.HealHandler)
-	adminV1Router.Methods(http.MethodPost).Path("/heal/{bucket}").HandlerFunc(adminAPI.HealHandler)
-	adminV1Router.Methods(http.MethodPost).Path("/heal/{bucket}/{prefix:.*}").HandlerFunc(adminAPI.HealHandler)
+	adminV1Router.Methods(http.MethodPost).Path("/heal/").HandlerFunc(auditAPI(adminAPI.HealHandler))
+	adminV1Router.Methods(http.MethodPost).Path("/heal/{bucket}").HandlerFunc(auditAPI(adminAPI.HealHandler))
+	adminV1Router.Methods(http.MethodPost).Path("/heal/{bucket}/{prefix:.*}").HandlerFunc(auditAPI(adminAPI.HealHandler))
+	// Progress of healing replaced drives
+	adminV1Router.Methods(http.MethodGet).Path("/heal-drives").HandlerFunc(auditAPI(adminAPI.ListDriveHealsHandler))
+	// Objects below full redundancy found by the integrity scanner
+	adminV1Router.Methods(http.MethodGet).Path("/integrity-report").HandlerFunc(auditAPI(adminAPI.GetIntegrityReportHandler))
+
+	/// Decommission operations
+
+	// Start moving all objects off a zone
+	adminV1Router.Methods(http.MethodPost).Path("/decommission").HandlerFunc(auditAPI(adminAPI.StartDecommissionHandler))
+	// Progress of draining zones
+	adminV1Router.Methods(http.MethodGet).Path("/decommission").HandlerFunc(auditAPI(adminAPI.GetDecommissionStatusHandler))
+	// Stop draining a zone
+	adminV1Router.Methods(http.MethodDelete).Path("/decommission").HandlerFunc(auditAPI(adminAPI.CancelDecommissionHandler))
 
 	/// Config operations
 
 	// Update credentials
-	adminV1Router.Methods(http.MethodPut).Path("/config/credential").HandlerFunc(adminAPI.UpdateCredentialsHandler)
+	adminV1Router.Methods(http.MethodPut).Path("/config/credential").HandlerFunc(auditAPI(adminAPI.UpdateCredentialsHandler))
 	// Get config
-	adminV1Router.Methods(http.MethodGet).Path("/config").HandlerFunc(adminAPI.GetConfigHandler)
+	adminV1Router.Methods(http.MethodGet).Path("/config").HandlerFunc(auditAPI(adminAPI.GetConfigHandler))
 	// Set config
-	adminV1Router.Methods(http.MethodPut).Path("/config").HandlerFunc(adminAPI.SetConfigHandler)
+	adminV1Router.Methods(http.MethodPut).Path("/config").HandlerFunc(auditAPI(adminAPI.SetConfigHandler))
+	// List changes of bucket policies, credentials and config
+	adminV1Router.Methods(http.MethodGet).Path("/change-log").HandlerFunc(auditAPI(adminAPI.ListChangeLogHandler))
 }
@@ -29,6 +29,7 @@ import (
 
 	"github.com/minio/minio-go/pkg/set"
 	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/madmin"
 )
 
 const (
@@ -36,10 +37,18 @@ const (
 	signalServiceRPC  = "Admin.SignalService"
 	reInitFormatRPC   = "Admin.ReInitFormat"
 	listLocksRPC      = "Admin.ListLocks"
+	listLockLeasesRPC = "Admin.ListLockLeases"
 	serverInfoDataRPC = "Admin.ServerInfoData"
+	serverTimeRPC     = "Admin.ServerTime"
+	maintenanceRPC    = "Admin.SetMaintenanceMode"
 	getConfigRPC      = "Admin.GetConfig"
 	writeTmpConfigRPC = "Admin.WriteTmpConfig"
 	commitConfigRPC   = "Admin.CommitConfig"
+	startProfilingRPC = "Admin.StartProfiling"
+	stopProfilingRPC  = "Admin.StopProfiling"
+	speedTestRPC      = "Admin.SpeedTest"
+	accessStatsRPC    = "Admin.AccessStats"
+	decommissionRPC   = "Admin.ReloadDecommission"
 )
 
 // localAdminClient - represents admin operation to be executed locally.
@@ -58,10 +67,18 @@ type adminCmdRunner interface {
 	SignalService(s serviceSignal) error
 	ReInitFormat(dryRun bool) error
 	ListLocks(bucket, prefix string, duration time.Duration) ([]VolumeLockInfo, error)
+	ListLockLeases(bucket, prefix string, duration time.Duration) ([]LockLeaseInfo, error)
 	ServerInfoData() (ServerInfoData, error)
+	ServerTime() (time.Time, error)
+	SetMaintenanceMode(enable bool) error
 	GetConfig() ([]byte, error)
 	WriteTmpConfig(tmpFileName string, configBytes []byte) error
 	CommitConfig(tmpFileName string) error
+	StartProfiling(profilers []string) error
+	StopProfiling() (map[string][]byte, error)
+	SpeedTest(size int64, concurrent int, duration time.Duration) (speedTestResult, error)
+	AccessStats(bucket string) (map[string]bucketAccessStats, error)
+	ReloadDecommission() error
 }
 
 var errUnsupportedSignal = fmt.Errorf("unsupported signal: only restart and stop signals are supported")
@@ -92,6 +109,11 @@ func (lc localAdminClient) ListLocks(bucket, prefix string, duration time.Durati
 	return listLocksInfo(bucket, prefix, duration), nil
 }
 
+// ListLockLeases - Fetches locks granted by the local lock server.
+func (lc localAdminClient) ListLockLeases(bucket, prefix string, duration time.Duration) ([]LockLeaseInfo, error) {
+	return listLockLeases(bucket, prefix, duration), nil
+}
+
 func (rc remoteAdminClient) SignalService(s serviceSignal) (err error) {
 	switch s {
 	case serviceRestart, serviceStop:
@@ -126,36 +148,23 @@ func (rc remoteAdminClient) ListLocks(bucket, prefix string, duration time.Durat
 	return reply.VolLocks, nil
 }
 
-// ServerInfoData - Returns the server info of this server.
-func (lc localAdminClient) ServerInfoData() (sid ServerInfoData, e error) {
-	if globalBootTime.IsZero() {
-		return sid, errServerNotInitialized
-	}
-
-	// Build storage info
-	objLayer := newObjectLayerFn()
-	if objLayer == nil {
-		return sid, errServerNotInitialized
+// ListLockLeases - Sends list lock leases command to remote server via RPC.
+func (rc remoteAdminClient) ListLockLeases(bucket, prefix string, duration time.Duration) ([]LockLeaseInfo, error) {
+	listArgs := ListLocksQuery{
+		Bucket:   bucket,
+		Prefix:   prefix,
+		Duration: duration,
 	}
-	storage := objLayer.StorageInfo()
-
-	var arns []string
-	for queueArn := range globalEventNotifier.GetAllExternalTargets() {
-		arns = append(arns, queueArn)
+	var reply ListLockLeasesReply
+	if err := rc.Call(listLockLeasesRPC, &listArgs, &reply); err != nil {
+		return nil, err
 	}
+	return reply.Leases, nil
+}
 
-	return ServerInfoData{
-		StorageInfo: storage,
-		ConnStats:   globalConnStats.toServerConnStats(),
-		HTTPStats:   globalHTTPStats.toServerHTTPStats(),
-		Properties: ServerProperties{
-			Uptime:   UTCNow().Sub(globalBootTime),
-			Version:  Version,
-			CommitID: CommitID,
-			SQSARN:   arns,
-			Region:   globalServerConfig.GetRegion(),
-		},
-	}, nil
+// ServerInfoData - Returns the server info of this server.
+func (lc localAdminClient) ServerInfoData() (sid ServerInfoData, e error) {
+	return getLocalServerInfoData()
 }
 
 // ServerInfo - returns the server info of the server to which the RPC call is made.
@@ -170,6 +179,110 @@ func (rc remoteAdminClient) ServerInfoData() (sid ServerInfoData, e error) {
 	return reply.ServerInfoData, nil
 }
 
+// ServerTime - returns the current time of the local server.
+func (lc localAdminClient) ServerTime() (time.Time, error) {
+	return UTCNow(), nil
+}
+
+// ServerTime - returns the current time of the remote server.
+func (rc remoteAdminClient) ServerTime() (time.Time, error) {
+	args := AuthRPCArgs{}
+	reply := ServerTimeReply{}
+	if err := rc.Call(serverTimeRPC, &args, &reply); err != nil {
+		return time.Time{}, err
+	}
+	return reply.ServerTime, nil
+}
+
+// SetMaintenanceMode - turns maintenance mode of the local server on or off.
+func (lc localAdminClient) SetMaintenanceMode(enable bool) error {
+	setMaintenanceMode(enable)
+	return nil
+}
+
+// SetMaintenanceMode - turns maintenance mode of the remote server on or off.
+func (rc remoteAdminClient) SetMaintenanceMode(enable bool) error {
+	args := MaintenanceModeArgs{Enable: enable}
+	reply := AuthRPCReply{}
+	return rc.Call(maintenanceRPC, &args, &reply)
+}
+
+// StartProfiling - starts the given profilers on the local server.
+func (lc localAdminClient) StartProfiling(profilers []string) error {
+	return globalProfiling.Start(profilers)
+}
+
+// StartProfiling - starts the given profilers on the remote server.
+func (rc remoteAdminClient) StartProfiling(profilers []string) error {
+	args := StartProfilingArgs{Profilers: profilers}
+	reply := AuthRPCReply{}
+	return rc.Call(startProfilingRPC, &args, &reply)
+}
+
+// StopProfiling - stops profiling on the local server, returns the
+// collected profiles by profiler.
+func (lc localAdminClient) StopProfiling() (map[string][]byte, error) {
+	return globalProfiling.Stop()
+}
+
+// StopProfiling - stops profiling on the remote server, returns the
+// collected profiles by profiler.
+func (rc remoteAdminClient) StopProfiling() (map[string][]byte, error) {
+	args := AuthRPCArgs{}
+	reply := StopProfilingReply{}
+	if err := rc.Call(stopProfilingRPC, &args, &reply); err != nil {
+		return nil, err
+	}
+	return reply.Profiles, nil
+}
+
+// SpeedTest - runs a round of the speed test on the local server.
+func (lc localAdminClient) SpeedTest(size int64, concurrent int, duration time.Duration) (speedTestResult, error) {
+	return localSpeedTest(size, concurrent, duration)
+}
+
+// SpeedTest - runs a round of the speed test on the remote server.
+func (rc remoteAdminClient) SpeedTest(size int64, concurrent int, duration time.Duration) (speedTestResult, error) {
+	args := SpeedTestArgs{Size: size, Concurrent: concurrent, Duration: duration}
+	reply := SpeedTestReply{}
+	if err := rc.Call(speedTestRPC, &args, &reply); err != nil {
+		return speedTestResult{}, err
+	}
+	return reply.Result, nil
+}
+
+// AccessStats - returns the access statistics of the local server.
+func (lc localAdminClient) AccessStats(bucket string) (map[string]bucketAccessStats, error) {
+	if globalAccessStats == nil {
+		return nil, errServerNotInitialized
+	}
+	return globalAccessStats.Get(bucket), nil
+}
+
+// AccessStats - returns the access statistics of the remote server.
+func (rc remoteAdminClient) AccessStats(bucket string) (map[string]bucketAccessStats, error) {
+	args := AccessStatsArgs{Bucket: bucket}
+	reply := AccessStatsReply{}
+	if err := rc.Call(accessStatsRPC, &args, &reply); err != nil {
+		return nil, err
+	}
+	return reply.Buckets, nil
+}
+
+// ReloadDecommission - loads the zones being drained on the local
+// server.
+func (lc localAdminClient) ReloadDecommission() error {
+	return reloadDecommission()
+}
+
+// ReloadDecommission - loads the zones being drained on the remote
+// server.
+func (rc remoteAdminClient) ReloadDecommission() error {
+	args := AuthRPCArgs{}
+	reply := AuthRPCReply{}
+	return rc.Call(decommissionRPC, &args, &reply)
+}
+
 // GetConfig - returns config.json of the local server.
 func (lc localAdminClient) GetConfig() ([]byte, error) {
 	if globalServerConfig == nil {
@@ -338,6 +451,119 @@ func sendServiceCmd(cps adminPeers, cmd serviceSignal) {
 	errs[0] = invokeServiceCmd(cps[0], cmd)
 }
 
+// setPeersMaintenanceMode - turns maintenance mode on or off on all
+// peer servers, returns the first error encountered.
+func setPeersMaintenanceMode(peers adminPeers, enable bool) error {
+	errs := make([]error, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+			errs[idx] = peer.cmdRunner.SetMaintenanceMode(enable)
+		}(i, peer)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			errorIf(err, "Unable to set maintenance mode on %s", peers[i].addr)
+			return err
+		}
+	}
+	return nil
+}
+
+// startPeersProfiling - starts the given profilers on all peer
+// servers, returns the error of each peer.
+func startPeersProfiling(peers adminPeers, profilers []string) []error {
+	errs := make([]error, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+			errs[idx] = peer.cmdRunner.StartProfiling(profilers)
+		}(i, peer)
+	}
+	wg.Wait()
+	return errs
+}
+
+// stopPeersProfiling - stops profiling on all peer servers, returns
+// the profiles and the error of each peer.
+func stopPeersProfiling(peers adminPeers) ([]map[string][]byte, []error) {
+	profiles := make([]map[string][]byte, len(peers))
+	errs := make([]error, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+			profiles[idx], errs[idx] = peer.cmdRunner.StopProfiling()
+		}(i, peer)
+	}
+	wg.Wait()
+	return profiles, errs
+}
+
+// speedTestPeers - runs a round of the speed test on all peer servers
+// at the same time, returns the result and the error of each peer.
+func speedTestPeers(peers adminPeers, size int64, concurrent int, duration time.Duration) ([]speedTestResult, []error) {
+	results := make([]speedTestResult, len(peers))
+	errs := make([]error, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+			results[idx], errs[idx] = peer.cmdRunner.SpeedTest(size, concurrent, duration)
+		}(i, peer)
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// getPeerAccessStats - returns the access statistics and the error of
+// each peer server.
+func getPeerAccessStats(peers adminPeers, bucket string) ([]map[string]bucketAccessStats, []error) {
+	stats := make([]map[string]bucketAccessStats, len(peers))
+	errs := make([]error, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+			stats[idx], errs[idx] = peer.cmdRunner.AccessStats(bucket)
+		}(i, peer)
+	}
+	wg.Wait()
+	return stats, errs
+}
+
+// reloadPeersDecommission - loads the zones being drained on all peer
+// servers, returns the first error encountered.
+func reloadPeersDecommission(peers adminPeers) error {
+	errs := make([]error, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+			errs[idx] = peer.cmdRunner.ReloadDecommission()
+		}(i, peer)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			errorIf(err, "Unable to reload the decommissioned zones on %s", peers[i].addr)
+			return err
+		}
+	}
+	return nil
+}
+
 // listPeerLocksInfo - fetch list of locks held on the given bucket,
 // matching prefix held longer than duration from all peer servers.
 func listPeerLocksInfo(peers adminPeers, bucket, prefix string, duration time.Duration) ([]VolumeLockInfo, error) {
@@ -388,6 +614,41 @@ func listPeerLocksInfo(peers adminPeers, bucket, prefix string, duration time.Du
 	return groupedLockInfos, nil
 }
 
+// listPeerLockLeases - fetches locks granted by the lock servers of all
+// peers, matching bucket, prefix and held longer than duration. Unlike
+// listPeerLocksInfo this includes locks whose owner is no longer alive.
+func listPeerLockLeases(peers adminPeers, bucket, prefix string, duration time.Duration) ([]LockLeaseInfo, error) {
+	// Used to aggregate lock leases from all nodes.
+	allLeases := make([][]LockLeaseInfo, len(peers))
+	errs := make([]error, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, remotePeer adminPeer) {
+			defer wg.Done()
+			allLeases[idx], errs[idx] = remotePeer.cmdRunner.ListLockLeases(bucket, prefix, duration)
+		}(i, peer)
+	}
+	wg.Wait()
+
+	// Summarizing errors received for ListLockLeases RPC across all
+	// nodes. N B the possible unavailability of quorum in errors
+	// applies only to distributed setup.
+	errCount, err := reduceErrs(errs, []error{})
+	if err != nil {
+		if errCount >= (len(peers)/2 + 1) {
+			return nil, err
+		}
+		return nil, InsufficientReadQuorum{}
+	}
+
+	leases := []LockLeaseInfo{}
+	for _, peerLeases := range allLeases {
+		leases = append(leases, peerLeases...)
+	}
+	return leases, nil
+}
+
 // uptimeSlice - used to sort uptimes in chronological order.
 type uptimeSlice []struct {
 	err    error
@@ -460,6 +721,51 @@ func getPeerUptimes(peers adminPeers) (time.Duration, error) {
 	return latestUptime, nil
 }
 
+// getPeerClockSkews - returns the clock offset of every peer relative to
+// this server. The time reported by a peer is compared against the
+// local time at the midpoint of the RPC round trip, so network latency
+// does not show up as skew.
+func getPeerClockSkews(peers adminPeers) []madmin.PeerClockSkew {
+	skews := make([]madmin.PeerClockSkew, len(peers))
+
+	wg := sync.WaitGroup{}
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+			skews[idx].Addr = peer.addr
+			start := UTCNow()
+			serverTime, err := peer.cmdRunner.ServerTime()
+			if err != nil && err.Error() == errServerTimeMismatch.Error() {
+				// The peer refused the RPC because the clocks differ
+				// by more than rpcSkewTimeAllowed, by how much is
+				// not known.
+				skews[idx].Exceeded = true
+				return
+			}
+			if err != nil {
+				skews[idx].Error = err.Error()
+				return
+			}
+			end := UTCNow()
+			skew := serverTime.Sub(start.Add(end.Sub(start) / 2))
+			skews[idx].Skew = skew
+			skews[idx].Exceeded = skew > rpcSkewTimeAllowed || -skew > rpcSkewTimeAllowed
+		}(i, peer)
+	}
+	wg.Wait()
+
+	// Refused RPCs are logged by the RPC client already.
+	for _, skew := range skews {
+		if skew.Exceeded && skew.Skew != 0 {
+			errorIf(errServerTimeMismatch, "Clock of %s is off by %s, more than the allowed %s. Please synchronize the clocks of all servers (e.g. using NTP).",
+				skew.Addr, skew.Skew, rpcSkewTimeAllowed)
+		}
+	}
+
+	return skews
+}
+
 // getPeerConfig - Fetches config.json from all nodes in the setup and
 // returns the one that occurs in a majority of them.
 func getPeerConfig(peers adminPeers) ([]byte, error) {
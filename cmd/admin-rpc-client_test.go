@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 )
 
 var (
@@ -258,3 +259,64 @@ func TestGetValidServerConfig(t *testing.T) {
 		t.Errorf("Expected to fail due to lack of quorum but received %v", err)
 	}
 }
+
+// skewedAdminClient - admin client whose clock is off by skew.
+type skewedAdminClient struct {
+	localAdminClient
+	skew time.Duration
+}
+
+func (sc skewedAdminClient) ServerTime() (time.Time, error) {
+	return UTCNow().Add(sc.skew), nil
+}
+
+// refusingAdminClient - admin client of a peer refusing RPCs of this
+// server because their clocks differ too much.
+type refusingAdminClient struct {
+	localAdminClient
+}
+
+func (rc refusingAdminClient) ServerTime() (time.Time, error) {
+	return time.Time{}, errServerTimeMismatch
+}
+
+// Tests clock skew detection between peers.
+func TestGetPeerClockSkews(t *testing.T) {
+	peers := adminPeers{
+		{addr: "localhost:9000", cmdRunner: localAdminClient{}, isLocal: true},
+		{addr: "server2:9000", cmdRunner: skewedAdminClient{skew: time.Minute}},
+		{addr: "server3:9000", cmdRunner: skewedAdminClient{skew: -time.Hour}},
+		{addr: "server4:9000", cmdRunner: refusingAdminClient{}},
+	}
+
+	testCases := []struct {
+		minSkew  time.Duration
+		maxSkew  time.Duration
+		exceeded bool
+	}{
+		{-time.Second, time.Second, false},
+		{time.Minute - time.Second, time.Minute + time.Second, true},
+		{-time.Hour - time.Second, -time.Hour + time.Second, true},
+		{0, 0, true},
+	}
+
+	skews := getPeerClockSkews(peers)
+	if len(skews) != len(testCases) {
+		t.Fatalf("Expected %d results, got %d", len(testCases), len(skews))
+	}
+	for i, testCase := range testCases {
+		skew := skews[i]
+		if skew.Addr != peers[i].addr {
+			t.Errorf("Test %d: Expected addr %s, got %s", i+1, peers[i].addr, skew.Addr)
+		}
+		if skew.Skew < testCase.minSkew || skew.Skew > testCase.maxSkew {
+			t.Errorf("Test %d: Expected skew between %s and %s, got %s", i+1, testCase.minSkew, testCase.maxSkew, skew.Skew)
+		}
+		if skew.Exceeded != testCase.exceeded {
+			t.Errorf("Test %d: Expected exceeded %v, got %v", i+1, testCase.exceeded, skew.Exceeded)
+		}
+		if skew.Error != "" {
+			t.Errorf("Test %d: Unexpected error %s", i+1, skew.Error)
+		}
+	}
+}
@@ -56,12 +56,70 @@ type ListLocksReply struct {
 	VolLocks []VolumeLockInfo
 }
 
+// ListLockLeasesReply - wraps ListLockLeases response over RPC.
+type ListLockLeasesReply struct {
+	AuthRPCReply
+	Leases []LockLeaseInfo
+}
+
 // ServerInfoDataReply - wraps the server info response over RPC.
 type ServerInfoDataReply struct {
 	AuthRPCReply
 	ServerInfoData ServerInfoData
 }
 
+// ServerTimeReply - wraps the current server time over RPC.
+type ServerTimeReply struct {
+	AuthRPCReply
+	ServerTime time.Time
+}
+
+// MaintenanceModeArgs - turns maintenance mode on or off.
+type MaintenanceModeArgs struct {
+	AuthRPCArgs
+	Enable bool
+}
+
+// StartProfilingArgs - profilers to be started.
+type StartProfilingArgs struct {
+	AuthRPCArgs
+	Profilers []string
+}
+
+// StopProfilingReply - wraps the collected profiles over RPC.
+type StopProfilingReply struct {
+	AuthRPCReply
+	Profiles map[string][]byte
+}
+
+// SpeedTestArgs - parameters of a round of the speed test.
+type SpeedTestArgs struct {
+	AuthRPCArgs
+	Size       int64
+	Concurrent int
+	Duration   time.Duration
+}
+
+// SpeedTestReply - wraps the result of a round of the speed test over
+// RPC.
+type SpeedTestReply struct {
+	AuthRPCReply
+	Result speedTestResult
+}
+
+// AccessStatsArgs - bucket to return the access statistics of, all
+// buckets if empty.
+type AccessStatsArgs struct {
+	AuthRPCArgs
+	Bucket string
+}
+
+// AccessStatsReply - wraps the access statistics of a server over RPC.
+type AccessStatsReply struct {
+	AuthRPCReply
+	Buckets map[string]bucketAccessStats
+}
+
 // ConfigReply - wraps the server config response over RPC.
 type ConfigReply struct {
 	AuthRPCReply
@@ -103,41 +161,104 @@ func (s *adminCmd) ListLocks(query *ListLocksQuery, reply *ListLocksReply) error
 	return nil
 }
 
+// ListLockLeases - lists locks granted by the lock server of this server instance.
+func (s *adminCmd) ListLockLeases(query *ListLocksQuery, reply *ListLockLeasesReply) error {
+	if err := query.IsAuthenticated(); err != nil {
+		return err
+	}
+	leases := listLockLeases(query.Bucket, query.Prefix, query.Duration)
+	*reply = ListLockLeasesReply{Leases: leases}
+	return nil
+}
+
 // ServerInfo - returns the server info when object layer was initialized on this server.
 func (s *adminCmd) ServerInfoData(args *AuthRPCArgs, reply *ServerInfoDataReply) error {
 	if err := args.IsAuthenticated(); err != nil {
 		return err
 	}
 
-	if globalBootTime.IsZero() {
-		return errServerNotInitialized
+	serverInfoData, err := getLocalServerInfoData()
+	if err != nil {
+		return err
 	}
+	reply.ServerInfoData = serverInfoData
+	return nil
+}
 
-	// Build storage info
-	objLayer := newObjectLayerFn()
-	if objLayer == nil {
+// ServerTime - returns the current time of this server, used to
+// detect clock skew between nodes.
+func (s *adminCmd) ServerTime(args *AuthRPCArgs, reply *ServerTimeReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	reply.ServerTime = UTCNow()
+	return nil
+}
+
+// SetMaintenanceMode - turns maintenance mode of this server on or off.
+func (s *adminCmd) SetMaintenanceMode(args *MaintenanceModeArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	setMaintenanceMode(args.Enable)
+	return nil
+}
+
+// StartProfiling - starts the given profilers on this server.
+func (s *adminCmd) StartProfiling(args *StartProfilingArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return globalProfiling.Start(args.Profilers)
+}
+
+// SpeedTest - runs a round of the speed test on this server.
+func (s *adminCmd) SpeedTest(args *SpeedTestArgs, reply *SpeedTestReply) (err error) {
+	if err = args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	reply.Result, err = localSpeedTest(args.Size, args.Concurrent, args.Duration)
+	return err
+}
+
+// AccessStats - returns the access statistics of this server.
+func (s *adminCmd) AccessStats(args *AccessStatsArgs, reply *AccessStatsReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+	if globalAccessStats == nil {
 		return errServerNotInitialized
 	}
-	storageInfo := objLayer.StorageInfo()
 
-	var arns []string
-	for queueArn := range globalEventNotifier.GetAllExternalTargets() {
-		arns = append(arns, queueArn)
+	reply.Buckets = globalAccessStats.Get(args.Bucket)
+	return nil
+}
+
+// ReloadDecommission - loads the zones being drained on this server.
+func (s *adminCmd) ReloadDecommission(args *AuthRPCArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
 	}
 
-	reply.ServerInfoData = ServerInfoData{
-		Properties: ServerProperties{
-			Uptime:   UTCNow().Sub(globalBootTime),
-			Version:  Version,
-			CommitID: CommitID,
-			Region:   globalServerConfig.GetRegion(),
-			SQSARN:   arns,
-		},
-		StorageInfo: storageInfo,
-		ConnStats:   globalConnStats.toServerConnStats(),
-		HTTPStats:   globalHTTPStats.toServerHTTPStats(),
+	return reloadDecommission()
+}
+
+// StopProfiling - stops profiling on this server, returns the
+// collected profiles.
+func (s *adminCmd) StopProfiling(args *AuthRPCArgs, reply *StopProfilingReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
 	}
 
+	profiles, err := globalProfiling.Stop()
+	if err != nil {
+		return err
+	}
+	reply.Profiles = profiles
 	return nil
 }
 
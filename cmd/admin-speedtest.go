@@ -0,0 +1,289 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/hash"
+)
+
+// Defaults and limits of the speed test admin API.
+const (
+	defaultSpeedTestSize       = 64 * humanize.MiByte
+	defaultSpeedTestConcurrent = 32
+	defaultSpeedTestDuration   = 10 * time.Second
+
+	maxSpeedTestSize       = 1 * humanize.GiByte
+	maxSpeedTestConcurrent = 1024
+	maxSpeedTestDuration   = 5 * time.Minute
+
+	// Rounds of doubling the concurrency, and the improvement of
+	// the throughput of uploads required to keep doubling it.
+	maxSpeedTestRounds      = 6
+	speedTestMinImprovement = 0.025
+)
+
+// Objects of speed tests are uploaded below this prefix of the meta
+// bucket, of every zone new objects are placed on, they are removed when
+// the test ends.
+const speedTestPrefix = "speedtest"
+
+var errSpeedTestRunning = errors.New("A speed test is already running")
+
+// speedTestResult - objects and bytes transferred by one server in a
+// round of the speed test, and the time spent uploading and
+// downloading them.
+type speedTestResult struct {
+	Uploads         int64
+	UploadedBytes   int64
+	PUTTime         time.Duration
+	Downloads       int64
+	DownloadedBytes int64
+	GETTime         time.Duration
+}
+
+// speedTestStats - throughput of uploads or downloads.
+type speedTestStats struct {
+	ThroughputPerSec uint64 `json:"throughputPerSec"`
+	ObjectsPerSec    uint64 `json:"objectsPerSec"`
+}
+
+func newSpeedTestStats(objects, bytes int64, elapsed time.Duration) speedTestStats {
+	if elapsed <= 0 {
+		return speedTestStats{}
+	}
+	return speedTestStats{
+		ThroughputPerSec: uint64(float64(bytes) / elapsed.Seconds()),
+		ObjectsPerSec:    uint64(float64(objects) / elapsed.Seconds()),
+	}
+}
+
+// serverSpeedTest - throughput of one server.
+type serverSpeedTest struct {
+	Addr string         `json:"addr"`
+	PUT  speedTestStats `json:"PUT"`
+	GET  speedTestStats `json:"GET"`
+	Err  string         `json:"err,omitempty"`
+}
+
+// speedTestReport - throughput of all servers at the concurrency of
+// the best round of a speed test.
+type speedTestReport struct {
+	Size       int64             `json:"size"`
+	Concurrent int               `json:"concurrent"`
+	Duration   time.Duration     `json:"duration"`
+	PUT        speedTestStats    `json:"PUT"`
+	GET        speedTestStats    `json:"GET"`
+	Servers    []serverSpeedTest `json:"servers"`
+}
+
+// newSpeedTestReport - sums the throughput of the servers in a round,
+// servers which failed count as zero.
+func newSpeedTestReport(peers adminPeers, size int64, concurrent int, duration time.Duration,
+	results []speedTestResult, errs []error) speedTestReport {
+
+	report := speedTestReport{
+		Size:       size,
+		Concurrent: concurrent,
+		Duration:   duration,
+		Servers:    make([]serverSpeedTest, len(results)),
+	}
+	for i, result := range results {
+		report.Servers[i].Addr = peers[i].addr
+		if errs[i] != nil {
+			errorIf(errs[i], "Unable to run the speed test on %s", peers[i].addr)
+			report.Servers[i].Err = errs[i].Error()
+			continue
+		}
+		report.Servers[i].PUT = newSpeedTestStats(result.Uploads, result.UploadedBytes, result.PUTTime)
+		report.Servers[i].GET = newSpeedTestStats(result.Downloads, result.DownloadedBytes, result.GETTime)
+		report.PUT.ThroughputPerSec += report.Servers[i].PUT.ThroughputPerSec
+		report.PUT.ObjectsPerSec += report.Servers[i].PUT.ObjectsPerSec
+		report.GET.ThroughputPerSec += report.Servers[i].GET.ThroughputPerSec
+		report.GET.ObjectsPerSec += report.Servers[i].GET.ObjectsPerSec
+	}
+	return report
+}
+
+// Set while this server runs a round of a speed test, and while it
+// coordinates a speed test of all servers.
+var (
+	speedTestRunning      int32
+	speedTestCoordinating int32
+)
+
+// localSpeedTest - runs a round of the speed test on this server.
+func localSpeedTest(size int64, concurrent int, duration time.Duration) (speedTestResult, error) {
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return speedTestResult{}, errServerNotInitialized
+	}
+	if err := checkServerWritable(); err != nil {
+		return speedTestResult{}, err
+	}
+	return runSpeedTest(objAPI, size, concurrent, duration)
+}
+
+// getSpeedTestLayers - returns the object layers objects of speed tests
+// are written to. The meta bucket of a server with several zones is
+// only written to the first zone, the zones new objects are placed on
+// are written to directly instead.
+func getSpeedTestLayers(objAPI ObjectLayer) []ObjectLayer {
+	z, ok := unwrapObjectLayer(objAPI).(*xlZones)
+	if !ok {
+		return []ObjectLayer{objAPI}
+	}
+	var layers []ObjectLayer
+	for _, zone := range z.getAvailableZones() {
+		layers = append(layers, zone)
+	}
+	return layers
+}
+
+// runSpeedTest - uploads objects of size with concurrent uploads for
+// duration, then downloads them with concurrent downloads for duration.
+// Workers are spread over the zones of the server. The uploaded objects
+// are removed afterwards.
+func runSpeedTest(objAPI ObjectLayer, size int64, concurrent int, duration time.Duration) (result speedTestResult, err error) {
+	if !atomic.CompareAndSwapInt32(&speedTestRunning, 0, 1) {
+		return result, errSpeedTestRunning
+	}
+	defer atomic.StoreInt32(&speedTestRunning, 0)
+
+	layers := getSpeedTestLayers(objAPI)
+	workerLayer := func(worker int) ObjectLayer {
+		return layers[worker%len(layers)]
+	}
+
+	// Random content defeats compression and deduplication below.
+	content := make([]byte, size)
+	rand.New(rand.NewSource(time.Now().UnixNano())).Read(content)
+
+	prefix := pathJoin(speedTestPrefix, mustGetUUID())
+	objectName := func(worker, index int) string {
+		return fmt.Sprintf("%s/%d.%d", prefix, worker, index)
+	}
+
+	var mu sync.Mutex
+	setErr := func(e error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err == nil {
+			err = e
+		}
+	}
+
+	// Uploads, the objects of each worker are counted to download and
+	// remove them.
+	uploaded := make([]int, concurrent)
+	defer func() {
+		for worker, count := range uploaded {
+			for index := 0; index < count; index++ {
+				errorIf(workerLayer(worker).DeleteObject(minioMetaBucket, objectName(worker, index)),
+					"Unable to remove speed test object %s.", objectName(worker, index))
+			}
+		}
+	}()
+
+	start := UTCNow()
+	deadline := start.Add(duration)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrent; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for UTCNow().Before(deadline) {
+				reader, e := hash.NewReader(bytes.NewReader(content), size, "", "")
+				if e == nil {
+					_, e = workerLayer(worker).PutObject(minioMetaBucket, objectName(worker, uploaded[worker]), reader, nil)
+				}
+				if e != nil {
+					setErr(e)
+					return
+				}
+				uploaded[worker]++
+			}
+		}(worker)
+	}
+	wg.Wait()
+	result.PUTTime = UTCNow().Sub(start)
+	for _, count := range uploaded {
+		result.Uploads += int64(count)
+	}
+	result.UploadedBytes = result.Uploads * size
+	if err != nil {
+		return result, err
+	}
+
+	// Downloads, each worker reads its own objects in turn.
+	downloaded := make([]int, concurrent)
+	start = UTCNow()
+	deadline = start.Add(duration)
+	for worker := 0; worker < concurrent; worker++ {
+		if uploaded[worker] == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for index := 0; UTCNow().Before(deadline); index = (index + 1) % uploaded[worker] {
+				if e := workerLayer(worker).GetObject(minioMetaBucket, objectName(worker, index), 0, size, ioutil.Discard, ""); e != nil {
+					setErr(e)
+					return
+				}
+				downloaded[worker]++
+			}
+		}(worker)
+	}
+	wg.Wait()
+	result.GETTime = UTCNow().Sub(start)
+	for _, count := range downloaded {
+		result.Downloads += int64(count)
+	}
+	result.DownloadedBytes = result.Downloads * size
+	return result, err
+}
+
+// autotuneSpeedTest - runs rounds of the speed test on all servers at
+// the same time, doubling the concurrency of each server until the
+// throughput of uploads improves by less than 2.5%. Returns the round
+// with the highest throughput of uploads.
+func autotuneSpeedTest(peers adminPeers, size int64, concurrent int, duration time.Duration) speedTestReport {
+	var best speedTestReport
+	for round := 0; round < maxSpeedTestRounds && concurrent <= maxSpeedTestConcurrent; round++ {
+		results, errs := speedTestPeers(peers, size, concurrent, duration)
+		report := newSpeedTestReport(peers, size, concurrent, duration, results, errs)
+		if round > 0 && float64(report.PUT.ThroughputPerSec) < float64(best.PUT.ThroughputPerSec)*(1+speedTestMinImprovement) {
+			if report.PUT.ThroughputPerSec > best.PUT.ThroughputPerSec {
+				best = report
+			}
+			break
+		}
+		best = report
+		concurrent *= 2
+	}
+	return best
+}
@@ -0,0 +1,208 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Traces queued per client, traces are dropped for clients falling
+// further behind.
+const traceQueueSize = 1000
+
+// Value replacing credentials in traced headers and queries.
+const traceRedacted = "*REDACTED*"
+
+// traceInfo - trace of a single request served by this server.
+type traceInfo struct {
+	Time          time.Time   `json:"time"`
+	Method        string      `json:"method"`
+	Path          string      `json:"path"`
+	RawQuery      string      `json:"rawQuery,omitempty"`
+	ReqHeaders    http.Header `json:"requestHeaders"`
+	StatusCode    int         `json:"statusCode"`
+	RespHeaders   http.Header `json:"responseHeaders"`
+	Latency       int64       `json:"latencyNs"`
+	BytesReceived int64       `json:"bytesReceived"`
+	BytesSent     int64       `json:"bytesSent"`
+}
+
+// tracePubSub - publishes request traces to all trace clients.
+type tracePubSub struct {
+	sync.RWMutex
+	subs map[chan traceInfo]struct{}
+
+	// Number of subscribers, read without locking on every request.
+	numSubs int32
+}
+
+func newTracePubSub() *tracePubSub {
+	return &tracePubSub{subs: make(map[chan traceInfo]struct{})}
+}
+
+// Subscribe - returns a channel receiving all traces published.
+func (ps *tracePubSub) Subscribe() chan traceInfo {
+	ch := make(chan traceInfo, traceQueueSize)
+
+	ps.Lock()
+	defer ps.Unlock()
+
+	ps.subs[ch] = struct{}{}
+	atomic.AddInt32(&ps.numSubs, 1)
+	return ch
+}
+
+// Unsubscribe - stops publishing traces to ch.
+func (ps *tracePubSub) Unsubscribe(ch chan traceInfo) {
+	ps.Lock()
+	defer ps.Unlock()
+
+	if _, ok := ps.subs[ch]; ok {
+		delete(ps.subs, ch)
+		atomic.AddInt32(&ps.numSubs, -1)
+	}
+}
+
+// HasSubscribers - returns true if any client is tracing.
+func (ps *tracePubSub) HasSubscribers() bool {
+	return atomic.LoadInt32(&ps.numSubs) > 0
+}
+
+// Publish - sends the trace to all subscribers without blocking.
+func (ps *tracePubSub) Publish(info traceInfo) {
+	ps.RLock()
+	defer ps.RUnlock()
+
+	for ch := range ps.subs {
+		select {
+		case ch <- info:
+		default:
+		}
+	}
+}
+
+// Global request traces, published only while clients are tracing.
+var globalTrace = newTracePubSub()
+
+// redactHeaders - returns a copy of the headers without credentials.
+func redactHeaders(h http.Header) http.Header {
+	redacted := make(http.Header, len(h))
+	for k, v := range h {
+		redacted[k] = v
+	}
+	for _, k := range []string{"Authorization", "Cookie", "X-Amz-Security-Token"} {
+		if _, ok := redacted[k]; ok {
+			redacted.Set(k, traceRedacted)
+		}
+	}
+	return redacted
+}
+
+// redactQuery - returns the query without presigned signatures and tokens.
+func redactQuery(rawQuery string) string {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+	redacted := false
+	for _, k := range []string{"X-Amz-Signature", "Signature", "X-Amz-Security-Token", "token"} {
+		if _, ok := query[k]; ok {
+			query.Set(k, traceRedacted)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return rawQuery
+	}
+	return query.Encode()
+}
+
+// traceHandler - publishes a trace of every request while clients
+// are tracing, requests are served as is otherwise.
+type traceHandler struct {
+	handler http.Handler
+}
+
+func setTraceHandler(h http.Handler) http.Handler {
+	return traceHandler{handler: h}
+}
+
+func (h traceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !globalTrace.HasSubscribers() {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	info := traceInfo{
+		Time:       UTCNow(),
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RawQuery:   redactQuery(r.URL.RawQuery),
+		ReqHeaders: redactHeaders(r.Header),
+	}
+	if r.Host != "" {
+		info.ReqHeaders.Set("Host", r.Host)
+	}
+	var body *countingReadCloser
+	if r.Body != nil {
+		body = &countingReadCloser{ReadCloser: r.Body}
+		r.Body = body
+	}
+	ww := &countingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+	h.handler.ServeHTTP(ww, r)
+
+	info.StatusCode = ww.statusCode
+	info.RespHeaders = redactHeaders(ww.Header())
+	info.Latency = int64(UTCNow().Sub(info.Time))
+	if body != nil {
+		info.BytesReceived = body.n
+	}
+	info.BytesSent = ww.n
+	globalTrace.Publish(info)
+}
+
+// writeTraces - streams traces as JSON lines to w until the client
+// disconnects, whitespace is sent to keep idle connections alive.
+func writeTraces(w http.ResponseWriter, r *http.Request, traceCh <-chan traceInfo) {
+	enc := json.NewEncoder(w)
+	keepAliveTicker := time.NewTicker(globalSNSConnAlive)
+	defer keepAliveTicker.Stop()
+
+	for {
+		select {
+		case info := <-traceCh:
+			if err := enc.Encode(info); err != nil {
+				return
+			}
+		case <-keepAliveTicker.C:
+			if _, err := w.Write([]byte(" ")); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-globalServiceDoneCh:
+			return
+		}
+		w.(http.Flusher).Flush()
+	}
+}
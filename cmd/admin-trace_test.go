@@ -0,0 +1,127 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Tests that credentials are not traced.
+func TestTraceRedact(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "AWS4-HMAC-SHA256 Credential=minio/20180301/us-east-1/s3/aws4_request, SignedHeaders=host, Signature=abcd")
+	h.Set("Content-Type", "application/xml")
+	redacted := redactHeaders(h)
+	if redacted.Get("Authorization") != traceRedacted || redacted.Get("Content-Type") != "application/xml" {
+		t.Errorf("Unexpected redacted headers %v", redacted)
+	}
+	if h.Get("Authorization") == traceRedacted {
+		t.Error("Expected the request headers to be left untouched")
+	}
+
+	testCases := []struct {
+		rawQuery string
+		redacted string
+	}{
+		{"prefix=photos&delimiter=%2F", "prefix=photos&delimiter=%2F"},
+		{"X-Amz-Credential=minio&X-Amz-Signature=abcd", "X-Amz-Credential=minio&X-Amz-Signature=%2AREDACTED%2A"},
+		{"AWSAccessKeyId=minio&Signature=abcd", "AWSAccessKeyId=minio&Signature=%2AREDACTED%2A"},
+	}
+	for i, testCase := range testCases {
+		if redacted := redactQuery(testCase.rawQuery); redacted != testCase.redacted {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.redacted, redacted)
+		}
+	}
+}
+
+// Tests streaming traces of requests to admin clients.
+func TestAdminTraceHandler(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	server := httptest.NewServer(setTraceHandler(adminTestBed.mux))
+	defer server.Close()
+
+	cred := globalServerConfig.GetCredential()
+	newRequest := func(path string) *http.Request {
+		req, rerr := newTestRequest("GET", server.URL+path, 0, nil)
+		if rerr != nil {
+			t.Fatalf("Failed to construct request - %v", rerr)
+		}
+		if rerr = signRequestV4(req, cred.AccessKey, cred.SecretKey); rerr != nil {
+			t.Fatalf("Failed to sign request - %v", rerr)
+		}
+		return req
+	}
+
+	resp, err := http.DefaultClient.Do(newRequest("/minio/admin/v1/trace"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status code - got %d but expected %d", resp.StatusCode, http.StatusOK)
+	}
+	if !globalTrace.HasSubscribers() {
+		t.Fatal("Expected the trace client to be subscribed")
+	}
+
+	versionResp, err := http.DefaultClient.Do(newRequest("/minio/admin/version"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	versionResp.Body.Close()
+
+	traceCh := make(chan traceInfo, 1)
+	go func() {
+		var info traceInfo
+		if derr := json.NewDecoder(resp.Body).Decode(&info); derr == nil {
+			traceCh <- info
+		}
+	}()
+	select {
+	case info := <-traceCh:
+		if info.Method != "GET" || info.Path != "/minio/admin/version" || info.StatusCode != http.StatusOK {
+			t.Errorf("Unexpected trace %#v", info)
+		}
+		if info.ReqHeaders.Get("Authorization") != traceRedacted || info.RespHeaders.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected trace headers %v %v", info.ReqHeaders, info.RespHeaders)
+		}
+		if info.Latency <= 0 || info.BytesSent == 0 {
+			t.Errorf("Unexpected trace %#v", info)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No trace received")
+	}
+
+	// Anonymous clients are not allowed to trace.
+	anonResp, err := http.Get(server.URL + "/minio/admin/v1/trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anonResp.Body.Close()
+	if anonResp.StatusCode == http.StatusOK {
+		t.Errorf("Expected anonymous trace request to fail")
+	}
+}
@@ -44,3 +44,22 @@ type DeleteObjectsRequest struct {
 	// List of objects to be deleted
 	Objects []ObjectIdentifier `xml:"Object"`
 }
+
+// DownloadManifestRequest - xml carrying the object key names to be
+// downloaded, a Minio extension.
+type DownloadManifestRequest struct {
+	// Validity of the presigned URLs in seconds.
+	Expires int64
+	// Element to also issue a single URL downloading all objects as
+	// a zip archive.
+	Archive bool
+	// List of objects to be downloaded
+	Objects []ObjectIdentifier `xml:"Object"`
+}
+
+// GetObjectsRequest - xml carrying the object key names to be read in a
+// single response, a Minio extension.
+type GetObjectsRequest struct {
+	// List of objects to be read
+	Objects []ObjectIdentifier `xml:"Object"`
+}
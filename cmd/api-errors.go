@@ -65,6 +65,7 @@ const (
 	ErrInvalidCopyPartRangeSource
 	ErrInvalidMaxKeys
 	ErrInvalidMaxUploads
+	ErrInvalidMaxBuckets
 	ErrInvalidMaxParts
 	ErrInvalidPartNumberMarker
 	ErrInvalidRequestBody
@@ -137,6 +138,23 @@ const (
 	ErrMissingSSECustomerKeyMD5
 	ErrSSECustomerKeyMD5Mismatch
 
+	// Server-Side-Encryption (with KMS managed keys) related API errors.
+	ErrKMSNotConfigured
+	ErrInvalidEncryptionMethod
+	ErrKMSKeyIDNotSupported
+
+	// Temporary credentials related API errors.
+	ErrInvalidSessionToken
+	ErrExpiredSessionToken
+	ErrSTSNotEnabled
+	ErrSTSInvalidAction
+	ErrSTSMissingParameter
+	ErrSTSInvalidDuration
+	ErrSTSInvalidIdentity
+	ErrSTSInvalidIdentityToken
+	ErrSTSNoPolicy
+	ErrSTSIdentityProviderError
+
 	// Bucket notification related errors.
 	ErrEventNotification
 	ErrARNNotification
@@ -148,9 +166,27 @@ const (
 	ErrFilterValueInvalid
 	ErrOverlappingConfigs
 	ErrUnsupportedNotification
+	ErrInvalidNotificationTemplate
+	ErrUnsupportedNotificationTemplate
 
 	// S3 extended errors.
 	ErrContentSHA256Mismatch
+	ErrContentChecksumMismatch
+	ErrInvalidChecksumTrailer
+	ErrObjectLocked
+	ErrObjectLockConfigurationNotFound
+	ErrNoSuchObjectLockConfiguration
+	ErrMissingObjectLockConfiguration
+	ErrInvalidObjectLockConfiguration
+	ErrInvalidObjectRetention
+	ErrPastObjectLockRetainDate
+	ErrInvalidEncodingMethod
+	ErrIncorrectContinuationToken
+	ErrNoSuchWebsiteConfiguration
+	ErrInvalidWebsiteConfiguration
+	ErrNoSuchCORSConfiguration
+	ErrInvalidCORSConfiguration
+	ErrCORSNotAllowed
 
 	// Add new extended error codes here.
 
@@ -167,6 +203,22 @@ const (
 	ErrOperationTimedOut
 	ErrPartsSizeUnequal
 	ErrInvalidRequest
+	ErrObjectQuarantined
+	ErrServerReadOnly
+	ErrNoSuchAttestation
+	ErrAttestationInvalid
+	ErrInvalidRenamePrefix
+	ErrNoSuchRenamePrefix
+	ErrInvalidDownloadManifest
+	ErrInvalidGetObjects
+	ErrInvalidSummaryPrefix
+	ErrPrefixSummaryNotReady
+	ErrPrefixSummaryNotIndexed
+	ErrBucketPolicyManaged
+	ErrClientDisconnected
+	ErrNoSuchMetadataDefaults
+	ErrInvalidMetadataDefaults
+	ErrInvalidMaxPrefixes
 
 	// Minio storage class error codes
 	ErrInvalidStorageClass
@@ -183,6 +235,32 @@ const (
 	ErrAdminConfigBadJSON
 	ErrAdminCredentialsMismatch
 	ErrInsecureClientRequest
+	ErrAdminClientCertRequired
+	ErrAdminNoSuchBucketDeletion
+	ErrAdminInvalidReplicationConfig
+	ErrAdminNoSuchReplication
+	ErrAdminInvalidTieringConfig
+	ErrAdminNoSuchTiering
+	ErrAdminTieringInUse
+	ErrAdminMetadataBackupDisabled
+	ErrAdminNoSuchMetadataBackup
+	ErrAdminInvalidMetadataBackup
+	ErrAdminNoSuchManagedPolicy
+	ErrAdminInvalidManagedPolicy
+	ErrAdminManagedPolicyBuiltIn
+	ErrAdminManagedPolicyInUse
+	ErrAdminInvalidBrowserToken
+	ErrAdminInvalidBrowserTokenRevocation
+	ErrAdminInvalidProfiler
+	ErrAdminProfilerNotStarted
+	ErrAdminSpeedTestRunning
+	ErrAdminInvalidIntegrityReportFormat
+	ErrAdminNoIntegrityReport
+	ErrAdminObjectIndexDisabled
+	ErrAdminInvalidSearchQuery
+	ErrAdminDecommissionNotSupported
+	ErrAdminInvalidDecommissionZone
+	ErrAdminNoSuchDecommission
 	ErrObjectTampered
 	ErrHealNotImplemented
 	ErrHealNoSuchProcess
@@ -225,6 +303,11 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Argument max-uploads must be an integer between 0 and 2147483647",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidMaxBuckets: {
+		Code:           "InvalidArgument",
+		Description:    "Argument max-buckets must be an integer between 1 and 2147483647",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidMaxKeys: {
 		Code:           "InvalidArgument",
 		Description:    "Argument maxKeys must be an integer between 0 and 2147483647",
@@ -611,6 +694,16 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Minio server does not support Topic or Cloud Function based notifications.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidNotificationTemplate: {
+		Code:           "InvalidArgument",
+		Description:    "The notification template is not a valid Go template.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrUnsupportedNotificationTemplate: {
+		Code:           "InvalidArgument",
+		Description:    "Notification templates are not supported by elasticsearch, redis, postgresql and mysql destinations.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidCopyPartRange: {
 		Code:           "InvalidArgument",
 		Description:    "The x-amz-copy-source-range value must be of the form bytes=first-last where first and last are the zero-based offsets of the first and last bytes to copy",
@@ -666,6 +759,71 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    errSSEKeyMD5Mismatch.Error(),
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrKMSNotConfigured: {
+		Code:           "NotImplemented",
+		Description:    errKMSNotConfigured.Error(),
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrInvalidEncryptionMethod: {
+		Code:           "InvalidArgument",
+		Description:    errInvalidEncryptionMethod.Error(),
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrKMSKeyIDNotSupported: {
+		Code:           "NotImplemented",
+		Description:    errKMSKeyIDNotSupported.Error(),
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrInvalidSessionToken: {
+		Code:           "InvalidToken",
+		Description:    "The provided token is malformed or otherwise invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrExpiredSessionToken: {
+		Code:           "ExpiredToken",
+		Description:    "The provided token has expired.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSTSNotEnabled: {
+		Code:           "NotImplemented",
+		Description:    "No identity provider is configured to issue temporary credentials",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrSTSInvalidAction: {
+		Code:           "InvalidAction",
+		Description:    "The action or operation requested is invalid. Verify that the action is typed correctly.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSTSMissingParameter: {
+		Code:           "MissingParameter",
+		Description:    "A required parameter for the specified action is not supplied.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSTSInvalidDuration: {
+		Code:           "InvalidParameterValue",
+		Description:    "DurationSeconds must be between 900 and 43200",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSTSInvalidIdentity: {
+		Code:           "AccessDenied",
+		Description:    "Invalid username or password",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrSTSInvalidIdentityToken: {
+		Code:           "InvalidIdentityToken",
+		Description:    "The web identity token that was passed could not be validated.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSTSNoPolicy: {
+		Code:           "AccessDenied",
+		Description:    "No policy is mapped to the groups of the user",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrSTSIdentityProviderError: {
+		Code:           "IDPCommunicationError",
+		Description:    "The identity provider could not be reached",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
 
 	/// S3 extensions.
 	ErrContentSHA256Mismatch: {
@@ -673,6 +831,86 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "The provided 'x-amz-content-sha256' header does not match what was computed.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrContentChecksumMismatch: {
+		Code:           "BadDigest",
+		Description:    "The checksum sent in the trailer does not match what was computed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidChecksumTrailer: {
+		Code:           "InvalidRequest",
+		Description:    "The 'x-amz-trailer' header must name one of x-amz-checksum-crc32, x-amz-checksum-crc32c, x-amz-checksum-sha1 or x-amz-checksum-sha256.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectLocked: {
+		Code:           "AccessDenied",
+		Description:    "Access Denied because object protected by object lock.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrObjectLockConfigurationNotFound: {
+		Code:           "ObjectLockConfigurationNotFoundError",
+		Description:    "Object Lock configuration does not exist for this bucket.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrNoSuchObjectLockConfiguration: {
+		Code:           "NoSuchObjectLockConfiguration",
+		Description:    "The specified object does not have a ObjectLock configuration.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrMissingObjectLockConfiguration: {
+		Code:           "InvalidRequest",
+		Description:    "Bucket is missing ObjectLockConfiguration.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidObjectLockConfiguration: {
+		Code:           "InvalidArgument",
+		Description:    "Object lock cannot be disabled, a default retention needs a mode and either days or years.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidObjectRetention: {
+		Code:           "InvalidArgument",
+		Description:    "Retention needs a mode of GOVERNANCE or COMPLIANCE and a retain until date, legal hold a status of ON or OFF.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrPastObjectLockRetainDate: {
+		Code:           "InvalidArgument",
+		Description:    "The retain until date must be in the future.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidEncodingMethod: {
+		Code:           "InvalidArgument",
+		Description:    "Invalid Encoding Method specified in Request",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrIncorrectContinuationToken: {
+		Code:           "InvalidArgument",
+		Description:    "The continuation token provided is incorrect",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchWebsiteConfiguration: {
+		Code:           "NoSuchWebsiteConfiguration",
+		Description:    "The specified bucket does not have a website configuration",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidWebsiteConfiguration: {
+		Code:           "InvalidArgument",
+		Description:    "The website configuration needs an index document suffix without slashes and a valid error document key.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchCORSConfiguration: {
+		Code:           "NoSuchCORSConfiguration",
+		Description:    "The CORS configuration does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidCORSConfiguration: {
+		Code:           "InvalidRequest",
+		Description:    "The CORS configuration needs 1 to 100 rules, each with allowed origins and supported methods.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrCORSNotAllowed: {
+		Code:           "AccessForbidden",
+		Description:    "CORSResponse: This CORS request is not allowed. This is usually because the evalution of Origin, request method / Access-Control-Request-Method or Access-Control-Request-Headers are not whitelisted by the resource's CORS spec.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
 
 	/// Minio extensions.
 	ErrStorageFull: {
@@ -685,6 +923,86 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "The request body failed to parse.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrObjectQuarantined: {
+		Code:           "XMinioObjectQuarantined",
+		Description:    "The object is quarantined pending validation and cannot be downloaded until it is cleared.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrServerReadOnly: {
+		Code:           "XMinioServerReadOnly",
+		Description:    "The server is in read-only mode, write requests are not allowed.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrNoSuchAttestation: {
+		Code:           "XMinioNoSuchAttestation",
+		Description:    "The object has no signed manifest matching its current content.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAttestationInvalid: {
+		Code:           "XMinioAttestationInvalid",
+		Description:    "The signed manifest of the object does not verify.",
+		HTTPStatusCode: http.StatusInternalServerError,
+	},
+	ErrInvalidRenamePrefix: {
+		Code:           "XMinioInvalidRenamePrefix",
+		Description:    "The prefix and target must be valid, non-empty prefixes not containing each other.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchRenamePrefix: {
+		Code:           "XMinioNoSuchRenamePrefix",
+		Description:    "The prefix was not renamed by this server.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidDownloadManifest: {
+		Code:           "XMinioInvalidDownloadManifest",
+		Description:    "The download manifest must list between 1 and 1000 valid object names, and expire within 7 days.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidGetObjects: {
+		Code:           "XMinioInvalidGetObjects",
+		Description:    "The request must list between 1 and 1000 valid object names.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSummaryPrefix: {
+		Code:           "XMinioInvalidSummaryPrefix",
+		Description:    "Summaries are only available for prefixes ending with a slash.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrPrefixSummaryNotReady: {
+		Code:           "XMinioPrefixSummaryNotReady",
+		Description:    "The bucket was not crawled yet, please retry later.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrBucketPolicyManaged: {
+		Code:           "XMinioBucketPolicyManaged",
+		Description:    "The bucket policy is set by an attached managed policy, detach it first.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrPrefixSummaryNotIndexed: {
+		Code:           "XMinioPrefixSummaryNotIndexed",
+		Description:    "The bucket has more prefixes than are indexed, no summary is available for this prefix.",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrClientDisconnected: {
+		Code:           "XMinioClientDisconnected",
+		Description:    "The client disconnected before the request was done.",
+		HTTPStatusCode: 499, // Client Closed Request, not sent to the client but recorded in traces.
+	},
+	ErrNoSuchMetadataDefaults: {
+		Code:           "XMinioNoSuchMetadataDefaults",
+		Description:    "The metadata defaults configuration does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidMetadataDefaults: {
+		Code:           "XMinioInvalidMetadataDefaults",
+		Description:    "The metadata defaults configuration needs at least one default, up to 1000 content types by unique extensions without dots and valid header values.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidMaxPrefixes: {
+		Code:           "InvalidArgument",
+		Description:    "Argument max-prefixes must be an integer between 0 and 2147483647",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrObjectExistsAsDirectory: {
 		Code:           "XMinioObjectExistsAsDirectory",
 		Description:    "Object name already exists as a directory.",
@@ -761,6 +1079,136 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Cannot respond to plain-text request from TLS-encrypted server",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrAdminClientCertRequired: {
+		Code:           "XMinioAdminClientCertRequired",
+		Description:    "A valid TLS client certificate is required for admin requests",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrAdminNoSuchBucketDeletion: {
+		Code:           "XMinioAdminNoSuchBucketDeletion",
+		Description:    "The bucket was not force deleted by this server",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminInvalidReplicationConfig: {
+		Code:           "XMinioAdminInvalidReplicationConfig",
+		Description:    "The replication configuration is invalid or the target bucket is not accessible",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminNoSuchReplication: {
+		Code:           "XMinioAdminNoSuchReplication",
+		Description:    "The bucket is not replicated",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminInvalidTieringConfig: {
+		Code:           "XMinioAdminInvalidTieringConfig",
+		Description:    "The tiering configuration is invalid or the target bucket is not accessible",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminNoSuchTiering: {
+		Code:           "XMinioAdminNoSuchTiering",
+		Description:    "The bucket is not tiered",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminTieringInUse: {
+		Code:           "XMinioAdminTieringInUse",
+		Description:    "Objects of the bucket are still on the remote tier, remove all rules and wait for them to be restored",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrAdminMetadataBackupDisabled: {
+		Code:           "XMinioAdminMetadataBackupDisabled",
+		Description:    "Metadata backups are not enabled, set MINIO_METADATA_BACKUP_BUCKET to enable them",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrAdminNoSuchMetadataBackup: {
+		Code:           "XMinioAdminNoSuchMetadataBackup",
+		Description:    "The metadata snapshot does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminInvalidMetadataBackup: {
+		Code:           "XMinioAdminInvalidMetadataBackup",
+		Description:    "The metadata snapshot is not signed by this deployment",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminNoSuchManagedPolicy: {
+		Code:           "XMinioAdminNoSuchManagedPolicy",
+		Description:    "The managed policy or policy version does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminInvalidManagedPolicy: {
+		Code:           "XMinioAdminInvalidManagedPolicy",
+		Description:    "The managed policy is invalid, all resources must be in arn:aws:s3:::${bucket}",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminManagedPolicyBuiltIn: {
+		Code:           "XMinioAdminManagedPolicyBuiltIn",
+		Description:    "Built-in managed policies cannot be changed",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrAdminManagedPolicyInUse: {
+		Code:           "XMinioAdminManagedPolicyInUse",
+		Description:    "The managed policy is attached to buckets, detach it first",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrAdminInvalidBrowserToken: {
+		Code:           "XMinioAdminInvalidBrowserToken",
+		Description:    "A user, managed policies granted on bucket patterns and a duration from one minute to 30 days are required",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidBrowserTokenRevocation: {
+		Code:           "XMinioAdminInvalidBrowserTokenRevocation",
+		Description:    "A user or a token ID to revoke is required",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidProfiler: {
+		Code:           "XMinioAdminInvalidProfiler",
+		Description:    "Invalid profiler type, valid types are cpu, heap, block and mutex",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminProfilerNotStarted: {
+		Code:           "XMinioAdminProfilerNotStarted",
+		Description:    "Profiling is not started",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminSpeedTestRunning: {
+		Code:           "XMinioAdminSpeedTestRunning",
+		Description:    "A speed test is already running",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrAdminInvalidIntegrityReportFormat: {
+		Code:           "XMinioAdminInvalidIntegrityReportFormat",
+		Description:    "Unsupported integrity report format, use csv or json",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminNoIntegrityReport: {
+		Code:           "XMinioAdminNoIntegrityReport",
+		Description:    "No integrity scan has finished yet, set MINIO_INTEGRITY_SCAN=on to enable the scanner",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminObjectIndexDisabled: {
+		Code:           "XMinioAdminObjectIndexDisabled",
+		Description:    "Object index is not enabled, set MINIO_OBJECT_INDEX=on to search objects",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrAdminInvalidSearchQuery: {
+		Code:           "XMinioAdminInvalidSearchQuery",
+		Description:    "Metadata filters must be of the form key:value",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminDecommissionNotSupported: {
+		Code:           "XMinioAdminDecommissionNotSupported",
+		Description:    "Only servers with more than one zone can decommission a zone",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrAdminInvalidDecommissionZone: {
+		Code:           "XMinioAdminInvalidDecommissionZone",
+		Description:    "The zone does not exist or is the first zone, which holds the config of the server",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminNoSuchDecommission: {
+		Code:           "XMinioAdminNoSuchDecommission",
+		Description:    "The zone is not decommissioned",
+		HTTPStatusCode: http.StatusNotFound,
+	},
 	ErrOperationTimedOut: {
 		Code:           "XMinioServerTimedOut",
 		Description:    "A timeout occurred while trying to lock a resource",
@@ -841,6 +1289,8 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 	switch err {
 	case errSignatureMismatch:
 		apiErr = ErrSignatureDoesNotMatch
+	case errChecksumMismatch:
+		apiErr = ErrContentChecksumMismatch
 	case errDataTooLarge:
 		apiErr = ErrEntityTooLarge
 	case errDataTooSmall:
@@ -849,6 +1299,24 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrAdminInvalidAccessKey
 	case auth.ErrInvalidSecretKeyLength:
 		apiErr = ErrAdminInvalidSecretKey
+	case errServerReadOnly:
+		apiErr = ErrServerReadOnly
+	case errServerMaintenance, errGatewayWriteNotVisible:
+		apiErr = ErrSlowDown
+	case errNoSuchAttestation:
+		apiErr = ErrNoSuchAttestation
+	case errAttestationInvalid:
+		apiErr = ErrAttestationInvalid
+	case errMetadataBackupInvalid:
+		apiErr = ErrAdminInvalidMetadataBackup
+	case errUsageNotReady:
+		apiErr = ErrPrefixSummaryNotReady
+	case errPrefixNotIndexed:
+		apiErr = ErrPrefixSummaryNotIndexed
+	case errBucketPolicyManaged:
+		apiErr = ErrBucketPolicyManaged
+	case errClientDisconnected:
+		apiErr = ErrClientDisconnected
 	}
 
 	if apiErr != ErrNone {
@@ -869,6 +1337,12 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		return ErrMissingSSECustomerKeyMD5
 	case errSSEKeyMD5Mismatch:
 		return ErrSSECustomerKeyMD5Mismatch
+	case errKMSNotConfigured:
+		return ErrKMSNotConfigured
+	case errInvalidEncryptionMethod:
+		return ErrInvalidEncryptionMethod
+	case errKMSKeyIDNotSupported:
+		return ErrKMSKeyIDNotSupported
 	case errObjectTampered:
 		return ErrObjectTampered
 	case errEncryptedObject:
@@ -888,6 +1362,10 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrIncompleteBody
 	case ObjectExistsAsDirectory:
 		apiErr = ErrObjectExistsAsDirectory
+	case ObjectQuarantined:
+		apiErr = ErrObjectQuarantined
+	case ObjectLocked:
+		apiErr = ErrObjectLocked
 	case PrefixAccessDenied:
 		apiErr = ErrAccessDenied
 	case BucketNameInvalid:
@@ -51,6 +51,23 @@ func getListObjectsV2Args(values url.Values) (prefix, token, startAfter, delimit
 	return
 }
 
+// Parse service url queries for ListBuckets, a Minio extension. A
+// maxBuckets of zero lists all buckets.
+func getListBucketsArgs(values url.Values) (prefix, marker string, maxBuckets int, err APIErrorCode) {
+	prefix = values.Get("prefix")
+	marker = values.Get("marker")
+	if values.Get("max-buckets") != "" {
+		var perr error
+		if maxBuckets, perr = strconv.Atoi(values.Get("max-buckets")); perr != nil || maxBuckets <= 0 {
+			return "", "", 0, ErrInvalidMaxBuckets
+		}
+		if maxBuckets > maxBucketList {
+			maxBuckets = maxBucketList
+		}
+	}
+	return prefix, marker, maxBuckets, ErrNone
+}
+
 // Parse bucket url queries for ?uploads
 func getBucketMultipartResources(values url.Values) (prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int, encodingType string) {
 	prefix = values.Get("prefix")
@@ -16,7 +16,12 @@
 
 package cmd
 
-import "net/http"
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"time"
+)
 
 // Represents additional fields necessary for ErrPartTooSmall S3 error.
 type completeMultipartAPIError struct {
@@ -41,16 +46,65 @@ type completeMultipartAPIError struct {
 // error. So we construct a new type which lies well within the scope
 // of this function.
 func writePartSmallErrorResponse(w http.ResponseWriter, r *http.Request, err PartTooSmall) {
+	statusCode, encodedErrorResponse := encodePartSmallErrorResponse(r, err)
 
+	// respond with 400 bad request.
+	w.WriteHeader(statusCode)
+	// Write error body.
+	w.Write(encodedErrorResponse)
+	w.(http.Flusher).Flush()
+}
+
+// encodePartSmallErrorResponse - returns the status code and the
+// encoded error response of a part too small.
+func encodePartSmallErrorResponse(r *http.Request, err PartTooSmall) (int, []byte) {
 	apiError := getAPIError(toAPIErrorCode(err))
 	// Generate complete multipart error response.
 	errorResponse := getAPIErrorResponse(apiError, r.URL.Path)
 	cmpErrResp := completeMultipartAPIError{err.PartSize, int64(5242880), err.PartNumber, err.PartETag, errorResponse}
-	encodedErrorResponse := encodeResponse(cmpErrResp)
+	return apiError.HTTPStatusCode, encodeResponse(cmpErrResp)
+}
 
-	// respond with 400 bad request.
-	w.WriteHeader(apiError.HTTPStatusCode)
-	// Write error body.
-	w.Write(encodedErrorResponse)
+// keepCompleteMultipartAlive - once a multipart upload takes longer
+// than interval to complete, starts a 200 OK response with the XML
+// header and sends whitespace every interval until doneCh is closed,
+// like S3 does, so that idle timeouts of clients and load balancers
+// do not expire while large uploads complete. The returned channel
+// tells whether the response was started, the outcome of the upload
+// must then be written with writeCompleteMultipartBody, errors
+// included.
+func keepCompleteMultipartAlive(w http.ResponseWriter, interval time.Duration, doneCh <-chan struct{}) <-chan bool {
+	startedCh := make(chan bool, 1)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		started := false
+		for {
+			select {
+			case <-ticker.C:
+				if !started {
+					started = true
+					setCommonHeaders(w)
+					w.Header().Set("Content-Type", string(mimeXML))
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(xml.Header))
+				}
+				// Send whitespace and keep connection open
+				w.Write([]byte(" "))
+				w.(http.Flusher).Flush()
+			case <-doneCh:
+				startedCh <- started
+				return
+			}
+		}
+	}()
+	return startedCh
+}
+
+// writeCompleteMultipartBody - writes an encoded response in the body
+// of a response started by keepCompleteMultipartAlive.
+func writeCompleteMultipartBody(w http.ResponseWriter, encodedResponse []byte) {
+	w.Write(bytes.TrimPrefix(encodedResponse, []byte(xml.Header)))
 	w.(http.Flusher).Flush()
 }
@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"encoding/base64"
 	"encoding/xml"
 	"net/http"
 	"net/url"
@@ -30,8 +31,12 @@ const (
 	maxObjectList     = 1000                       // Limit number of objects in a listObjectsResponse.
 	maxUploadsList    = 1000                       // Limit number of uploads in a listUploadsResponse.
 	maxPartsList      = 1000                       // Limit number of parts in a listPartsResponse.
+	maxBucketList     = 1000                       // Limit number of buckets in a paginated listBucketsResponse.
 )
 
+// Only encoding type of object keys in list responses.
+const encodingTypeURL = "url"
+
 // LocationResponse - format for location response.
 type LocationResponse struct {
 	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LocationConstraint" json:"-"`
@@ -130,6 +135,124 @@ type ListPartsResponse struct {
 	Parts []Part `xml:"Part"`
 }
 
+// ObjectPart container for the metadata of a part of a completed object.
+type ObjectPart struct {
+	PartNumber int
+	ETag       string
+	Size       int64
+}
+
+// ObjectPartsResponse - format for object parts response, a Minio
+// extension listing the parts of a completed multipart object.
+type ObjectPartsResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ObjectParts" json:"-"`
+
+	Bucket string
+	Key    string
+	ETag   string
+	Size   int64
+
+	// List of parts, in the order they make up the object.
+	Parts []ObjectPart `xml:"Part"`
+}
+
+// RenamePrefixResponse - format for rename prefix response, a Minio
+// extension reporting the progress of moving all objects of a prefix.
+type RenamePrefixResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ RenamePrefixResult" json:"-"`
+
+	Bucket string
+	Prefix string
+	Target string
+
+	// One of running, finished or failed.
+	Status    string
+	StartTime string
+	EndTime   string `xml:",omitempty"`
+
+	ObjectsMoved   int64
+	BytesMoved     int64
+	ObjectsSkipped int64
+	Error          string `xml:",omitempty"`
+}
+
+// PrefixSummaryResponse - format for prefix summary response, a Minio
+// extension reporting the number and total size of objects of a prefix.
+type PrefixSummaryResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ PrefixSummaryResult" json:"-"`
+
+	Bucket  string
+	Prefix  string
+	Objects int64
+	Size    int64
+
+	// Time the bucket was last crawled.
+	LastUpdated string
+}
+
+// ObjectAgeRange - number and total size of the objects of an age range
+// of a bucket statistics response.
+type ObjectAgeRange struct {
+	// Upper bound of the range in days, omitted for the range of the
+	// oldest objects.
+	MaxAgeDays int `xml:",omitempty"`
+	Objects    int64
+	Size       int64
+}
+
+// PrefixStats - number and total size of the objects of a top-level
+// prefix of a bucket statistics response.
+type PrefixStats struct {
+	Prefix  string
+	Objects int64
+	Size    int64
+}
+
+// BucketStatsResponse - format for bucket statistics response, a Minio
+// extension reporting the age distribution of the objects of a bucket
+// and its largest top-level prefixes.
+type BucketStatsResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ BucketStatsResult" json:"-"`
+
+	Bucket  string
+	Objects int64
+	Size    int64
+
+	// Modification times of the oldest and the newest object.
+	FirstObjectTime string `xml:",omitempty"`
+	LastObjectTime  string `xml:",omitempty"`
+
+	AgeRanges   []ObjectAgeRange `xml:"AgeRange"`
+	TopPrefixes []PrefixStats    `xml:"TopPrefix"`
+
+	// Time the bucket was last crawled.
+	LastUpdated string
+}
+
+// DownloadManifestObject container for the presigned URL of an object
+// of a download manifest.
+type DownloadManifestObject struct {
+	Key  string
+	ETag string
+	Size int64
+	URL  string
+}
+
+// DownloadManifestResponse - format for download manifest response, a
+// Minio extension listing presigned URLs to download many objects.
+type DownloadManifestResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ DownloadManifestResult" json:"-"`
+
+	Bucket string
+	// Time the URLs of the manifest expire.
+	Expiration string
+	// URL downloading all found objects as a zip archive, if requested.
+	ArchiveURL string `xml:",omitempty"`
+
+	Objects []DownloadManifestObject `xml:"Object"`
+	Errors  []DeleteError            `xml:"Error"`
+}
+
 // ListMultipartUploadsResponse - format for list multipart uploads response.
 type ListMultipartUploadsResponse struct {
 	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListMultipartUploadsResult" json:"-"`
@@ -162,6 +285,14 @@ type ListBucketsResponse struct {
 	Buckets struct {
 		Buckets []Bucket `xml:"Bucket"`
 	} // Buckets are nested
+
+	// Minio extension, only set when buckets are filtered or
+	// paginated by the request.
+	Prefix      string `xml:",omitempty"`
+	Marker      string `xml:",omitempty"`
+	MaxBuckets  int    `xml:",omitempty"`
+	IsTruncated bool   `xml:",omitempty"`
+	NextMarker  string `xml:",omitempty"`
 }
 
 // Upload container for in progress multipart upload
@@ -192,8 +323,9 @@ type Object struct {
 	ETag         string
 	Size         int64
 
-	// Owner of the object.
-	Owner Owner
+	// Owner of the object, omitted by ListObjectsV2 unless
+	// fetch-owner is set.
+	Owner *Owner `xml:"Owner,omitempty"`
 
 	// The class of storage used to store the object.
 	StorageClass string
@@ -269,7 +401,7 @@ type PostResponse struct {
 
 // getLocation get URL location.
 func getLocation(r *http.Request) string {
-	return path.Clean(r.URL.Path) // Clean any trailing slashes.
+	return globalURLPrefix + path.Clean(r.URL.Path) // Clean any trailing slashes.
 }
 
 // returns "https" if the tls boolean is true, "http" otherwise.
@@ -287,7 +419,7 @@ func getObjectLocation(host, proto, bucket, object string) string {
 	}
 	u := url.URL{
 		Host:   host,
-		Path:   path.Join(slashSeparator, bucket, object),
+		Path:   globalURLPrefix + path.Join(slashSeparator, bucket, object),
 		Scheme: proto,
 	}
 	return u.String()
@@ -314,8 +446,25 @@ func generateListBucketsResponse(buckets []BucketInfo) ListBucketsResponse {
 	return data
 }
 
+// filterListBuckets - returns the buckets with the prefix sorting after
+// the marker, at most maxBuckets of them unless it is zero. Buckets are
+// sorted by name. Also returns whether more buckets are left.
+func filterListBuckets(buckets []BucketInfo, prefix, marker string, maxBuckets int) ([]BucketInfo, bool) {
+	var filtered []BucketInfo
+	for _, bucket := range buckets {
+		if !hasPrefix(bucket.Name, prefix) || bucket.Name <= marker {
+			continue
+		}
+		if maxBuckets > 0 && len(filtered) == maxBuckets {
+			return filtered, true
+		}
+		filtered = append(filtered, bucket)
+	}
+	return filtered, false
+}
+
 // generates an ListObjectsV1 response for the said bucket with other enumerated options.
-func generateListObjectsV1Response(bucket, prefix, marker, delimiter string, maxKeys int, resp ListObjectsInfo) ListObjectsResponse {
+func generateListObjectsV1Response(bucket, prefix, marker, delimiter, encodingType string, maxKeys int, resp ListObjectsInfo) ListObjectsResponse {
 	var contents []Object
 	var prefixes []CommonPrefix
 	var owner = Owner{}
@@ -327,30 +476,30 @@ func generateListObjectsV1Response(bucket, prefix, marker, delimiter string, max
 		if object.Name == "" {
 			continue
 		}
-		content.Key = object.Name
+		content.Key = s3EncodeName(object.Name, encodingType)
 		content.LastModified = object.ModTime.UTC().Format(timeFormatAMZLong)
 		if object.ETag != "" {
 			content.ETag = "\"" + object.ETag + "\""
 		}
 		content.Size = object.Size
-		content.StorageClass = globalMinioDefaultStorageClass
-		content.Owner = owner
+		content.StorageClass = getStorageClass(object.UserDefined)
+		content.Owner = &owner
 		contents = append(contents, content)
 	}
-	// TODO - support EncodingType in xml decoding
 	data.Name = bucket
 	data.Contents = contents
 
-	data.Prefix = prefix
-	data.Marker = marker
-	data.Delimiter = delimiter
+	data.EncodingType = encodingType
+	data.Prefix = s3EncodeName(prefix, encodingType)
+	data.Marker = s3EncodeName(marker, encodingType)
+	data.Delimiter = s3EncodeName(delimiter, encodingType)
 	data.MaxKeys = maxKeys
 
-	data.NextMarker = resp.NextMarker
+	data.NextMarker = s3EncodeName(resp.NextMarker, encodingType)
 	data.IsTruncated = resp.IsTruncated
 	for _, prefix := range resp.Prefixes {
 		var prefixItem = CommonPrefix{}
-		prefixItem.Prefix = prefix
+		prefixItem.Prefix = s3EncodeName(prefix, encodingType)
 		prefixes = append(prefixes, prefixItem)
 	}
 	data.CommonPrefixes = prefixes
@@ -358,14 +507,14 @@ func generateListObjectsV1Response(bucket, prefix, marker, delimiter string, max
 }
 
 // generates an ListObjectsV2 response for the said bucket with other enumerated options.
-func generateListObjectsV2Response(bucket, prefix, token, nextToken, startAfter, delimiter string, fetchOwner, isTruncated bool, maxKeys int, objects []ObjectInfo, prefixes []string) ListObjectsV2Response {
+func generateListObjectsV2Response(bucket, prefix, token, nextToken, startAfter, delimiter, encodingType string, fetchOwner, isTruncated bool, maxKeys int, objects []ObjectInfo, prefixes []string) ListObjectsV2Response {
 	var contents []Object
 	var commonPrefixes []CommonPrefix
-	var owner = Owner{}
+	var owner *Owner
 	var data = ListObjectsV2Response{}
 
 	if fetchOwner {
-		owner.ID = globalMinioDefaultOwnerID
+		owner = &Owner{ID: globalMinioDefaultOwnerID}
 	}
 
 	for _, object := range objects {
@@ -373,30 +522,30 @@ func generateListObjectsV2Response(bucket, prefix, token, nextToken, startAfter,
 		if object.Name == "" {
 			continue
 		}
-		content.Key = object.Name
+		content.Key = s3EncodeName(object.Name, encodingType)
 		content.LastModified = object.ModTime.UTC().Format(timeFormatAMZLong)
 		if object.ETag != "" {
 			content.ETag = "\"" + object.ETag + "\""
 		}
 		content.Size = object.Size
-		content.StorageClass = globalMinioDefaultStorageClass
+		content.StorageClass = getStorageClass(object.UserDefined)
 		content.Owner = owner
 		contents = append(contents, content)
 	}
-	// TODO - support EncodingType in xml decoding
 	data.Name = bucket
 	data.Contents = contents
 
-	data.StartAfter = startAfter
-	data.Delimiter = delimiter
-	data.Prefix = prefix
+	data.EncodingType = encodingType
+	data.StartAfter = s3EncodeName(startAfter, encodingType)
+	data.Delimiter = s3EncodeName(delimiter, encodingType)
+	data.Prefix = s3EncodeName(prefix, encodingType)
 	data.MaxKeys = maxKeys
 	data.ContinuationToken = token
 	data.NextContinuationToken = nextToken
 	data.IsTruncated = isTruncated
 	for _, prefix := range prefixes {
 		var prefixItem = CommonPrefix{}
-		prefixItem.Prefix = prefix
+		prefixItem.Prefix = s3EncodeName(prefix, encodingType)
 		commonPrefixes = append(commonPrefixes, prefixItem)
 	}
 	data.CommonPrefixes = commonPrefixes
@@ -404,6 +553,49 @@ func generateListObjectsV2Response(bucket, prefix, token, nextToken, startAfter,
 	return data
 }
 
+// s3EncodeName - encodes names of objects and prefixes in list
+// responses if the url encoding type was requested. Like S3 every
+// byte but letters, digits and "-_.~/" is percent encoded, so that
+// keys with characters invalid in XML can be listed.
+func s3EncodeName(name, encodingType string) string {
+	if encodingType != encodingTypeURL {
+		return name
+	}
+	const hex = "0123456789ABCDEF"
+	var buf []byte
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			buf = append(buf, c)
+		case c == '-' || c == '_' || c == '.' || c == '~' || c == '/':
+			buf = append(buf, c)
+		default:
+			buf = append(buf, '%', hex[c>>4], hex[c&15])
+		}
+	}
+	return string(buf)
+}
+
+// encodeContinuationToken - returns the opaque continuation token of
+// ListObjectsV2 responses for the marker of the next listing.
+func encodeContinuationToken(marker string) string {
+	if marker == "" {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString([]byte(marker))
+}
+
+// decodeContinuationToken - returns the marker of a continuation token
+// of a previous ListObjectsV2 response.
+func decodeContinuationToken(token string) (string, error) {
+	marker, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return "", err
+	}
+	return string(marker), nil
+}
+
 // generates CopyObjectResponse from etag and lastModified time.
 func generateCopyObjectResponse(etag string, lastModified time.Time) CopyObjectResponse {
 	return CopyObjectResponse{
@@ -467,6 +659,112 @@ func generateListPartsResponse(partsInfo ListPartsInfo) ListPartsResponse {
 	return listPartsResponse
 }
 
+// generates ObjectPartsResponse from ObjectInfo, objects not uploaded
+// with multipart are reported as a single part.
+func generateObjectPartsResponse(objInfo ObjectInfo) ObjectPartsResponse {
+	objectPartsResponse := ObjectPartsResponse{
+		Bucket: objInfo.Bucket,
+		Key:    objInfo.Name,
+		ETag:   "\"" + objInfo.ETag + "\"",
+		Size:   objInfo.Size,
+	}
+
+	if len(objInfo.Parts) == 0 {
+		objectPartsResponse.Parts = []ObjectPart{{
+			PartNumber: 1,
+			ETag:       "\"" + objInfo.ETag + "\"",
+			Size:       objInfo.Size,
+		}}
+		return objectPartsResponse
+	}
+
+	objectPartsResponse.Parts = make([]ObjectPart, len(objInfo.Parts))
+	for index, part := range objInfo.Parts {
+		objectPartsResponse.Parts[index] = ObjectPart{
+			PartNumber: part.Number,
+			ETag:       "\"" + part.ETag + "\"",
+			Size:       part.Size,
+		}
+	}
+	return objectPartsResponse
+}
+
+// generates RenamePrefixResponse from the progress of renaming a prefix.
+func generateRenamePrefixResponse(status renamePrefixStatus) RenamePrefixResponse {
+	renamePrefixResponse := RenamePrefixResponse{
+		Bucket:         status.Bucket,
+		Prefix:         status.Prefix,
+		Target:         status.Target,
+		Status:         status.Status,
+		StartTime:      status.StartTime.UTC().Format(timeFormatAMZLong),
+		ObjectsMoved:   status.ObjectsMoved,
+		BytesMoved:     status.BytesMoved,
+		ObjectsSkipped: status.ObjectsSkipped,
+		Error:          status.Error,
+	}
+	if !status.EndTime.IsZero() {
+		renamePrefixResponse.EndTime = status.EndTime.UTC().Format(timeFormatAMZLong)
+	}
+	return renamePrefixResponse
+}
+
+// generates PrefixSummaryResponse from the usage of a prefix.
+func generatePrefixSummaryResponse(bucket, prefix string, usage prefixUsage, lastUpdate time.Time) PrefixSummaryResponse {
+	return PrefixSummaryResponse{
+		Bucket:      bucket,
+		Prefix:      prefix,
+		Objects:     usage.Objects,
+		Size:        usage.Size,
+		LastUpdated: lastUpdate.UTC().Format(timeFormatAMZLong),
+	}
+}
+
+// generates BucketStatsResponse from the statistics of a bucket, with
+// at most maxPrefixes top-level prefixes.
+func generateBucketStatsResponse(bucket string, stats bucketStats, maxPrefixes int) BucketStatsResponse {
+	bucketStatsResponse := BucketStatsResponse{
+		Bucket:      bucket,
+		Objects:     stats.Objects,
+		Size:        stats.Size,
+		LastUpdated: stats.LastUpdated.UTC().Format(timeFormatAMZLong),
+	}
+	if !stats.FirstObjectTime.IsZero() {
+		bucketStatsResponse.FirstObjectTime = stats.FirstObjectTime.UTC().Format(timeFormatAMZLong)
+		bucketStatsResponse.LastObjectTime = stats.LastObjectTime.UTC().Format(timeFormatAMZLong)
+	}
+	for i, usage := range stats.Ages {
+		ageRange := ObjectAgeRange{Objects: usage.Objects, Size: usage.Size}
+		if i < len(bucketStatsAgeRanges) {
+			ageRange.MaxAgeDays = int(bucketStatsAgeRanges[i] / (24 * time.Hour))
+		}
+		bucketStatsResponse.AgeRanges = append(bucketStatsResponse.AgeRanges, ageRange)
+	}
+	for i, prefix := range stats.TopPrefixes {
+		if i == maxPrefixes {
+			break
+		}
+		bucketStatsResponse.TopPrefixes = append(bucketStatsResponse.TopPrefixes, PrefixStats{
+			Prefix:  prefix.Prefix,
+			Objects: prefix.Objects,
+			Size:    prefix.Size,
+		})
+	}
+	return bucketStatsResponse
+}
+
+// generates DownloadManifestResponse from the objects found, with an
+// URL of each, and the errors of the others.
+func generateDownloadManifestResponse(bucket string, expiration time.Time, archiveURL string,
+	objects []DownloadManifestObject, errs []DeleteError) DownloadManifestResponse {
+	return DownloadManifestResponse{
+		Bucket:     bucket,
+		Expiration: expiration.UTC().Format(timeFormatAMZLong),
+		ArchiveURL: archiveURL,
+		Objects:    objects,
+		Errors:     errs,
+	}
+}
+
 // generates ListMultipartUploadsResponse for given bucket and ListMultipartsInfo.
 func generateListMultipartUploadsResponse(bucket string, multipartsInfo ListMultipartsInfo) ListMultipartUploadsResponse {
 	listMultipartUploadsResponse := ListMultipartUploadsResponse{}
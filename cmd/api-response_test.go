@@ -72,3 +72,42 @@ func TestGetURLScheme(t *testing.T) {
 		t.Errorf("Expected %s, got %s", httpsScheme, gotScheme)
 	}
 }
+
+// Tests encoding names of list responses.
+func TestS3EncodeName(t *testing.T) {
+	testCases := []struct {
+		name, encodingType, expected string
+	}{
+		{"a/b.txt", "", "a/b.txt"},
+		{"a b\x01", "", "a b\x01"},
+		{"a/b-c_d.e~f", "url", "a/b-c_d.e~f"},
+		{"a b+c", "url", "a%20b%2Bc"},
+		{"\x01&<>", "url", "%01%26%3C%3E"},
+		{"日本", "url", "%E6%97%A5%E6%9C%AC"},
+	}
+	for i, testCase := range testCases {
+		if actual := s3EncodeName(testCase.name, testCase.encodingType); actual != testCase.expected {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expected, actual)
+		}
+	}
+}
+
+// Tests continuation tokens of ListObjectsV2 carry the marker.
+func TestContinuationToken(t *testing.T) {
+	if token := encodeContinuationToken(""); token != "" {
+		t.Errorf("Expected no token for an empty marker, got %q", token)
+	}
+	for _, marker := range []string{"object", "a/b c/日本"} {
+		token := encodeContinuationToken(marker)
+		if token == marker {
+			t.Errorf("Expected token of %q to be opaque", marker)
+		}
+		decoded, err := decodeContinuationToken(token)
+		if err != nil || decoded != marker {
+			t.Errorf("Expected %q, got %q, %v", marker, decoded, err)
+		}
+	}
+	if _, err := decodeContinuationToken("not-a-token"); err == nil {
+		t.Errorf("Expected invalid token to fail")
+	}
+}
@@ -34,8 +34,8 @@ func registerAPIRouter(mux *router.Router) {
 	// API Router
 	apiRouter := mux.NewRoute().PathPrefix("/").Subrouter()
 	var routers []*router.Router
-	if globalDomainName != "" {
-		routers = append(routers, apiRouter.Host("{bucket:.+}."+globalDomainName).Subrouter())
+	for _, domainName := range globalDomainNames {
+		routers = append(routers, apiRouter.Host("{bucket:.+}."+domainName).Subrouter())
 	}
 	routers = append(routers, apiRouter.PathPrefix("/{bucket}").Subrouter())
 
@@ -55,8 +55,20 @@ func registerAPIRouter(mux *router.Router) {
 		bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(httpTraceAll(api.NewMultipartUploadHandler)).Queries("uploads", "")
 		// AbortMultipartUpload
 		bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(httpTraceAll(api.AbortMultipartUploadHandler)).Queries("uploadId", "{uploadId:.*}")
+		// GetObjectParts - Minio extension
+		bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(httpTraceAll(api.GetObjectPartsHandler)).Queries("parts", "")
+		// GetObjectRetention
+		bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(httpTraceAll(api.GetObjectRetentionHandler)).Queries("retention", "")
+		// GetObjectLegalHold
+		bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(httpTraceAll(api.GetObjectLegalHoldHandler)).Queries("legal-hold", "")
+		// GetObjectAttestation
+		bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(httpTraceAll(api.GetObjectAttestationHandler)).Queries("attestation", "")
 		// GetObject
 		bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(httpTraceHdrs(api.GetObjectHandler))
+		// PutObjectRetention
+		bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(httpTraceAll(api.PutObjectRetentionHandler)).Queries("retention", "")
+		// PutObjectLegalHold
+		bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(httpTraceAll(api.PutObjectLegalHoldHandler)).Queries("legal-hold", "")
 		// CopyObject
 		bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/|%2F).*?").HandlerFunc(httpTraceAll(api.CopyObjectHandler))
 		// PutObject
@@ -69,30 +81,70 @@ func registerAPIRouter(mux *router.Router) {
 		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.GetBucketLocationHandler)).Queries("location", "")
 		// GetBucketPolicy
 		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.GetBucketPolicyHandler)).Queries("policy", "")
+		// GetBucketObjectLockConfig
+		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.GetBucketObjectLockConfigHandler)).Queries("object-lock", "")
+		// GetBucketWebsite
+		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.GetBucketWebsiteHandler)).Queries("website", "")
+		// GetBucketCors
+		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.GetBucketCorsHandler)).Queries("cors", "")
+		// GetBucketMetadataDefaults
+		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.GetBucketMetadataDefaultsHandler)).Queries("metadata-defaults", "")
 		// GetBucketNotification
 		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.GetBucketNotificationHandler)).Queries("notification", "")
 		// ListenBucketNotification
 		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.ListenBucketNotificationHandler)).Queries("events", "{events:.*}")
 		// ListMultipartUploads
 		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.ListMultipartUploadsHandler)).Queries("uploads", "")
+		// GetPrefixSummary - Minio extension
+		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.GetPrefixSummaryHandler)).Queries("summary", "")
+		// GetBucketStats - Minio extension
+		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.GetBucketStatsHandler)).Queries("stats", "")
+		// GetBucketArchive - Minio extension
+		bucket.Methods("GET").HandlerFunc(httpTraceHdrs(api.GetBucketArchiveHandler)).Queries("archive", "")
+		// GetRenamePrefix - Minio extension
+		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.GetRenamePrefixHandler)).Queries("rename", "")
+		// ListObjectsFast - Minio extension
+		bucket.Methods("GET").HandlerFunc(httpTraceHdrs(api.ListObjectsFastHandler)).Queries("fast-list", "")
 		// ListObjectsV2
 		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.ListObjectsV2Handler)).Queries("list-type", "2")
 		// ListObjectsV1 (Legacy)
 		bucket.Methods("GET").HandlerFunc(httpTraceAll(api.ListObjectsV1Handler))
 		// PutBucketPolicy
 		bucket.Methods("PUT").HandlerFunc(httpTraceAll(api.PutBucketPolicyHandler)).Queries("policy", "")
+		// PutBucketObjectLockConfig
+		bucket.Methods("PUT").HandlerFunc(httpTraceAll(api.PutBucketObjectLockConfigHandler)).Queries("object-lock", "")
+		// PutBucketWebsite
+		bucket.Methods("PUT").HandlerFunc(httpTraceAll(api.PutBucketWebsiteHandler)).Queries("website", "")
+		// PutBucketCors
+		bucket.Methods("PUT").HandlerFunc(httpTraceAll(api.PutBucketCorsHandler)).Queries("cors", "")
+		// PutBucketMetadataDefaults
+		bucket.Methods("PUT").HandlerFunc(httpTraceAll(api.PutBucketMetadataDefaultsHandler)).Queries("metadata-defaults", "")
 		// PutBucketNotification
 		bucket.Methods("PUT").HandlerFunc(httpTraceAll(api.PutBucketNotificationHandler)).Queries("notification", "")
 		// PutBucket
 		bucket.Methods("PUT").HandlerFunc(httpTraceAll(api.PutBucketHandler))
+		// HeadPrefixSummary - Minio extension
+		bucket.Methods("HEAD").HandlerFunc(httpTraceAll(api.HeadPrefixSummaryHandler)).Queries("summary", "")
 		// HeadBucket
 		bucket.Methods("HEAD").HandlerFunc(httpTraceAll(api.HeadBucketHandler))
 		// PostPolicy
 		bucket.Methods("POST").HeadersRegexp("Content-Type", "multipart/form-data*").HandlerFunc(httpTraceAll(api.PostPolicyBucketHandler))
 		// DeleteMultipleObjects
 		bucket.Methods("POST").HandlerFunc(httpTraceAll(api.DeleteMultipleObjectsHandler)).Queries("delete", "")
+		// DownloadManifest - Minio extension
+		bucket.Methods("POST").HandlerFunc(httpTraceAll(api.DownloadManifestHandler)).Queries("download-manifest", "")
+		// GetObjects - Minio extension
+		bucket.Methods("POST").HandlerFunc(httpTraceHdrs(api.GetObjectsHandler)).Queries("get-objects", "")
+		// RenamePrefix - Minio extension
+		bucket.Methods("POST").HandlerFunc(httpTraceAll(api.RenamePrefixHandler)).Queries("rename", "")
 		// DeleteBucketPolicy
 		bucket.Methods("DELETE").HandlerFunc(httpTraceAll(api.DeleteBucketPolicyHandler)).Queries("policy", "")
+		// DeleteBucketWebsite
+		bucket.Methods("DELETE").HandlerFunc(httpTraceAll(api.DeleteBucketWebsiteHandler)).Queries("website", "")
+		// DeleteBucketCors
+		bucket.Methods("DELETE").HandlerFunc(httpTraceAll(api.DeleteBucketCorsHandler)).Queries("cors", "")
+		// DeleteBucketMetadataDefaults
+		bucket.Methods("DELETE").HandlerFunc(httpTraceAll(api.DeleteBucketMetadataDefaultsHandler)).Queries("metadata-defaults", "")
 		// DeleteBucket
 		bucket.Methods("DELETE").HandlerFunc(httpTraceAll(api.DeleteBucketHandler))
 	}
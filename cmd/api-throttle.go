@@ -0,0 +1,322 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// Environment variable setting the maximum number of S3 API
+	// requests served at once.
+	apiRequestsMaxEnv = "MINIO_API_REQUESTS_MAX"
+
+	// Environment variable setting how long requests wait for their
+	// turn before they are rejected with SlowDown.
+	apiRequestsDeadlineEnv = "MINIO_API_REQUESTS_DEADLINE"
+
+	// Environment variables setting the sustained rate and the burst
+	// of S3 API requests of a single client IP.
+	apiRequestsPerIPEnv      = "MINIO_API_REQUESTS_PER_IP"
+	apiRequestsPerIPBurstEnv = "MINIO_API_REQUESTS_PER_IP_BURST"
+
+	// Environment variable setting the comma separated IPs and CIDR
+	// ranges of reverse proxies trusted to report the client IP.
+	apiTrustedProxiesEnv = "MINIO_API_TRUSTED_PROXIES"
+
+	// Requests wait this long for their turn by default.
+	defaultAPIRequestsDeadline = 10 * time.Second
+
+	// Token buckets of idle clients are looked for this often.
+	apiClientPruneInterval = time.Minute
+)
+
+// Parses a non-negative number of requests, 0 is unlimited.
+func parseAPIRequestsMax(value string) (int, error) {
+	requestsMax, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if requestsMax < 0 {
+		return 0, errors.New("maximum number of requests cannot be negative")
+	}
+	return requestsMax, nil
+}
+
+// Parses the time requests wait for their turn.
+func parseAPIRequestsDeadline(value string) (time.Duration, error) {
+	deadline, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if deadline <= 0 {
+		return 0, errors.New("deadline must be positive")
+	}
+	return deadline, nil
+}
+
+// Parses a non-negative rate of requests per second, 0 is unlimited.
+func parseAPIRequestsPerIP(value string) (float64, error) {
+	perIP, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if perIP < 0 || math.IsInf(perIP, 0) || math.IsNaN(perIP) {
+		return 0, errors.New("rate of requests must be a non-negative number")
+	}
+	return perIP, nil
+}
+
+// Parses comma separated IPs and CIDR ranges of trusted proxies.
+func parseAPITrustedProxies(value string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if !strings.Contains(field, "/") {
+			ip := net.ParseIP(field)
+			if ip == nil {
+				return nil, errors.New("invalid IP address " + field)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(field)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies, nil
+}
+
+// Sets the limits of S3 API requests from the environment.
+func handleAPIThrottleEnv() {
+	var err error
+	if value := os.Getenv(apiRequestsMaxEnv); value != "" {
+		globalAPIRequestsMax, err = parseAPIRequestsMax(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", apiRequestsMaxEnv)
+	}
+	if value := os.Getenv(apiRequestsDeadlineEnv); value != "" {
+		globalAPIRequestsDeadline, err = parseAPIRequestsDeadline(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", apiRequestsDeadlineEnv)
+	}
+	if value := os.Getenv(apiRequestsPerIPEnv); value != "" {
+		globalAPIRequestsPerIP, err = parseAPIRequestsPerIP(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", apiRequestsPerIPEnv)
+	}
+	// Burst defaults to the requests of one second, at least one.
+	globalAPIRequestsPerIPBurst = int(math.Ceil(globalAPIRequestsPerIP))
+	if value := os.Getenv(apiRequestsPerIPBurstEnv); value != "" {
+		globalAPIRequestsPerIPBurst, err = strconv.Atoi(value)
+		if err == nil && globalAPIRequestsPerIPBurst <= 0 {
+			err = errors.New("burst must be positive")
+		}
+		fatalIf(err, "Invalid value set in environment variable %s.", apiRequestsPerIPBurstEnv)
+	}
+	if globalAPIRequestsPerIPBurst < 1 {
+		globalAPIRequestsPerIPBurst = 1
+	}
+	if value := os.Getenv(apiTrustedProxiesEnv); value != "" {
+		globalAPITrustedProxies, err = parseAPITrustedProxies(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", apiTrustedProxiesEnv)
+	}
+}
+
+// Returns the IP a request is throttled by. Headers reporting the
+// client IP are easily spoofed, they are only honored on requests from
+// trusted proxies, otherwise the IP of the connection is used.
+func getAPIClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !isTrustedProxy(ip, trustedProxies) {
+		return ip
+	}
+	if realIP := r.Header.Get("X-Real-Ip"); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	// Addresses appended by the trusted proxies are skipped, the ones
+	// before them are set by the client.
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		forwardedIP := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(forwardedIP) == nil {
+			break
+		}
+		ip = forwardedIP
+		if !isTrustedProxy(forwardedIP, trustedProxies) {
+			break
+		}
+	}
+	return ip
+}
+
+// Returns the key of the token bucket of a client IP. IPv6 clients
+// usually get a whole /64 network, they are throttled per network so
+// that they cannot evade the limit, and grow the token buckets kept,
+// by rotating their address.
+func getAPIClientKey(ip string) string {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil || parsedIP.To4() != nil {
+		return ip
+	}
+	return parsedIP.Mask(net.CIDRMask(64, 8*net.IPv6len)).String() + "/64"
+}
+
+// Returns true if the IP is one of the trusted proxies.
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return false
+	}
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(parsedIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// apiClientLimiter - token bucket of a client IP or IPv6 network.
+type apiClientLimiter struct {
+	*rate.Limiter
+	lastSeen time.Time
+}
+
+// Throttles S3 API requests, each client IP is limited by a token
+// bucket and the number of requests served at once is limited. Requests
+// wait for their turn until the deadline, then SlowDown is returned.
+// Internal, admin and browser requests below the reserved bucket path
+// are not throttled.
+type apiThrottleHandler struct {
+	handler  http.Handler
+	deadline time.Duration
+
+	// Slots of requests served at once, nil if unlimited.
+	requests chan struct{}
+
+	// Token buckets of client IPs, nil if unlimited. Buckets of
+	// clients idle for idleTimeout are full again, dropping them
+	// does not change how clients are throttled.
+	perIP          rate.Limit
+	perIPBurst     int
+	trustedProxies []*net.IPNet
+	idleTimeout    time.Duration
+	mu             sync.Mutex
+	clients        map[string]*apiClientLimiter
+	lastPrune      time.Time
+}
+
+func setAPIThrottleHandler(h http.Handler) http.Handler {
+	if globalAPIRequestsMax == 0 && globalAPIRequestsPerIP == 0 {
+		return h
+	}
+	t := &apiThrottleHandler{
+		handler:  h,
+		deadline: globalAPIRequestsDeadline,
+	}
+	if globalAPIRequestsMax > 0 {
+		t.requests = make(chan struct{}, globalAPIRequestsMax)
+	}
+	if globalAPIRequestsPerIP > 0 {
+		t.perIP = rate.Limit(globalAPIRequestsPerIP)
+		t.perIPBurst = globalAPIRequestsPerIPBurst
+		t.trustedProxies = globalAPITrustedProxies
+		t.idleTimeout = time.Duration(float64(t.perIPBurst) / globalAPIRequestsPerIP * float64(time.Second))
+		t.clients = make(map[string]*apiClientLimiter)
+		t.lastPrune = UTCNow()
+	}
+	return t
+}
+
+// Returns the token bucket of the client key, buckets of idle clients
+// are dropped now and then.
+func (t *apiThrottleHandler) getClientLimiter(key string) *rate.Limiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := UTCNow()
+	if now.Sub(t.lastPrune) > apiClientPruneInterval {
+		t.pruneClients(now)
+		t.lastPrune = now
+	}
+
+	client, ok := t.clients[key]
+	if !ok {
+		client = &apiClientLimiter{Limiter: rate.NewLimiter(t.perIP, t.perIPBurst)}
+		t.clients[key] = client
+	}
+	client.lastSeen = now
+	return client.Limiter
+}
+
+// Drops the token buckets of clients idle since their bucket filled up
+// again, must be called with the lock held.
+func (t *apiThrottleHandler) pruneClients(now time.Time) {
+	for key, client := range t.clients {
+		if now.Sub(client.lastSeen) >= t.idleTimeout {
+			delete(t.clients, key)
+		}
+	}
+}
+
+func (t *apiThrottleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if hasPrefix(r.URL.Path, minioReservedBucketPath+slashSeparator) {
+		t.handler.ServeHTTP(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), t.deadline)
+	defer cancel()
+
+	// Wait errors out at once when the token bucket cannot fill
+	// up before the deadline.
+	if t.clients != nil {
+		if err := t.getClientLimiter(getAPIClientKey(getAPIClientIP(r, t.trustedProxies))).Wait(ctx); err != nil {
+			writeErrorResponse(w, ErrSlowDown, r.URL)
+			return
+		}
+	}
+
+	if t.requests != nil {
+		select {
+		case t.requests <- struct{}{}:
+			defer func() { <-t.requests }()
+		case <-ctx.Done():
+			writeErrorResponse(w, ErrSlowDown, r.URL)
+			return
+		}
+	}
+
+	t.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,203 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// Tests parsing limits of S3 API requests.
+func TestParseAPIThrottle(t *testing.T) {
+	if _, err := parseAPIRequestsMax("-1"); err == nil {
+		t.Error("Expected negative maximum to fail")
+	}
+	if requestsMax, err := parseAPIRequestsMax("100"); err != nil || requestsMax != 100 {
+		t.Errorf("Expected 100, got %d, %v", requestsMax, err)
+	}
+	if _, err := parseAPIRequestsDeadline("0s"); err == nil {
+		t.Error("Expected zero deadline to fail")
+	}
+	if deadline, err := parseAPIRequestsDeadline("2s"); err != nil || deadline != 2*time.Second {
+		t.Errorf("Expected 2s, got %s, %v", deadline, err)
+	}
+	for _, value := range []string{"-1", "NaN", "+Inf", "fast"} {
+		if _, err := parseAPIRequestsPerIP(value); err == nil {
+			t.Errorf("Expected rate %q to fail", value)
+		}
+	}
+	if perIP, err := parseAPIRequestsPerIP("0.5"); err != nil || perIP != 0.5 {
+		t.Errorf("Expected 0.5, got %f, %v", perIP, err)
+	}
+}
+
+// Tests the client IP headers are only honored from trusted proxies.
+func TestGetAPIClientIP(t *testing.T) {
+	if _, err := parseAPITrustedProxies("10.0.0.1,proxy"); err == nil {
+		t.Error("Expected invalid proxy to fail")
+	}
+	trustedProxies, err := parseAPITrustedProxies("10.0.0.1, 192.168.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		remoteAddr string
+		realIP     string
+		forwarded  string
+		expected   string
+	}{
+		// Headers of clients are ignored.
+		{"203.0.113.1:1234", "198.51.100.1", "", "203.0.113.1"},
+		{"203.0.113.1:1234", "", "198.51.100.1", "203.0.113.1"},
+		// Headers of trusted proxies are honored.
+		{"10.0.0.1:1234", "198.51.100.1", "", "198.51.100.1"},
+		{"192.168.1.1:1234", "", "198.51.100.1", "198.51.100.1"},
+		// Addresses spoofed before the ones of the proxies are skipped.
+		{"10.0.0.1:1234", "", "1.2.3.4, 198.51.100.1, 192.168.1.1", "198.51.100.1"},
+		// Proxies not reporting the client are throttled themselves.
+		{"10.0.0.1:1234", "", "", "10.0.0.1"},
+	}
+	for i, testCase := range testCases {
+		req := httptest.NewRequest("GET", "/bucket/object", nil)
+		req.RemoteAddr = testCase.remoteAddr
+		if testCase.realIP != "" {
+			req.Header.Set("X-Real-Ip", testCase.realIP)
+		}
+		if testCase.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", testCase.forwarded)
+		}
+		if ip := getAPIClientIP(req, trustedProxies); ip != testCase.expected {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expected, ip)
+		}
+	}
+
+	// IPv6 clients are throttled per /64 network.
+	for ip, expected := range map[string]string{
+		"203.0.113.1":            "203.0.113.1",
+		"2001:db8:1:2:3:4:5:6":   "2001:db8:1:2::/64",
+		"2001:db8:1:2::ffff":     "2001:db8:1:2::/64",
+		"::ffff:203.0.113.1":     "::ffff:203.0.113.1",
+		"not-an-ip-from-a-proxy": "not-an-ip-from-a-proxy",
+	} {
+		if key := getAPIClientKey(ip); key != expected {
+			t.Errorf("Expected key %s of %s, got %s", expected, ip, key)
+		}
+	}
+}
+
+// Tests requests beyond the limits are rejected with SlowDown.
+func TestAPIThrottleHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("unable initialize config file, %s", err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	defer func() {
+		globalAPIRequestsMax = 0
+		globalAPIRequestsDeadline = defaultAPIRequestsDeadline
+		globalAPIRequestsPerIP = 0
+		globalAPIRequestsPerIPBurst = 0
+	}()
+
+	serve := func(h http.Handler, url, remoteAddr string) int {
+		req := httptest.NewRequest("GET", url, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	// Unlimited by default.
+	if h := setAPIThrottleHandler(okHandler); h == nil {
+		t.Fatal("Expected a handler")
+	} else if _, ok := h.(*apiThrottleHandler); ok {
+		t.Fatal("Expected requests not to be throttled by default")
+	}
+
+	// Token bucket of a client IP.
+	globalAPIRequestsPerIP = 0.001
+	globalAPIRequestsPerIPBurst = 2
+	globalAPIRequestsDeadline = 100 * time.Millisecond
+	h := setAPIThrottleHandler(okHandler)
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusServiceUnavailable} {
+		if code := serve(h, "/bucket/object", "10.0.0.1:1234"); code != expected {
+			t.Errorf("Request %d: Expected %d, got %d", i+1, expected, code)
+		}
+	}
+	if code := serve(h, "/bucket/object", "10.0.0.2:1234"); code != http.StatusOK {
+		t.Errorf("Expected other clients not to be throttled, got %d", code)
+	}
+	req := httptest.NewRequest("GET", "/bucket/object", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Real-Ip", "10.0.0.3")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected clients not to evade throttling with X-Real-Ip, got %d", rec.Code)
+	}
+	if code := serve(h, minioReservedBucketPath+"/admin/v1/info", "10.0.0.1:1234"); code != http.StatusOK {
+		t.Errorf("Expected admin requests not to be throttled, got %d", code)
+	}
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusServiceUnavailable} {
+		if code := serve(h, "/bucket/object", fmt.Sprintf("[2001:db8::%d]:1234", i+1)); code != expected {
+			t.Errorf("IPv6 request %d: Expected %d, got %d", i+1, expected, code)
+		}
+	}
+
+	// Token buckets are dropped once they are full again.
+	throttle := h.(*apiThrottleHandler)
+	throttle.mu.Lock()
+	throttle.pruneClients(UTCNow())
+	if len(throttle.clients) != 3 {
+		t.Errorf("Expected 3 token buckets in use, got %d", len(throttle.clients))
+	}
+	throttle.pruneClients(UTCNow().Add(throttle.idleTimeout))
+	if len(throttle.clients) != 0 {
+		t.Errorf("Expected idle token buckets to be dropped, got %d", len(throttle.clients))
+	}
+	throttle.mu.Unlock()
+
+	// Requests served at once.
+	globalAPIRequestsPerIP = 0
+	globalAPIRequestsMax = 1
+	started, release := make(chan struct{}), make(chan struct{})
+	h = setAPIThrottleHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bucket/slow" {
+			close(started)
+			<-release
+		}
+	}))
+	done := make(chan int)
+	go func() { done <- serve(h, "/bucket/slow", "10.0.0.1:1234") }()
+	<-started
+	if code := serve(h, "/bucket/object", "10.0.0.2:1234"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected request beyond the maximum to be rejected, got %d", code)
+	}
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("Expected slow request to succeed, got %d", code)
+	}
+	if code := serve(h, "/bucket/object", "10.0.0.2:1234"); code != http.StatusOK {
+		t.Errorf("Expected request to be served once a slot is free, got %d", code)
+	}
+}
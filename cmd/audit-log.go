@@ -0,0 +1,432 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	router "github.com/gorilla/mux"
+)
+
+const (
+	// Environment variables configuring audit logging.
+	auditLogFileEnv        = "MINIO_AUDIT_LOG_FILE"
+	auditLogFileMaxSizeEnv = "MINIO_AUDIT_LOG_FILE_MAX_SIZE"
+	auditLogWebhookEnv     = "MINIO_AUDIT_LOG_WEBHOOK"
+
+	// Audit log files are rotated once they grow beyond this size.
+	defaultAuditLogFileMaxSize = 100 * humanize.MiByte
+
+	// Current version of audit records.
+	auditEntryVersion = "1"
+
+	// Records queued per target, records are dropped when a
+	// target falls this far behind.
+	auditQueueSize = 10000
+
+	// Timeout of a single request to an audit webhook.
+	auditWebhookTimeout = 10 * time.Second
+)
+
+// auditCaller - identity of the client sending a request.
+type auditCaller struct {
+	AccessKey  string `json:"accessKey,omitempty"`
+	RemoteHost string `json:"remoteHost,omitempty"`
+	UserAgent  string `json:"userAgent,omitempty"`
+}
+
+// auditEntry - audit record of a single request.
+type auditEntry struct {
+	Version       string      `json:"version"`
+	Time          time.Time   `json:"time"`
+	RequestID     string      `json:"requestID,omitempty"`
+	API           string      `json:"api,omitempty"`
+	Method        string      `json:"method"`
+	Path          string      `json:"path"`
+	Bucket        string      `json:"bucket,omitempty"`
+	Object        string      `json:"object,omitempty"`
+	Caller        auditCaller `json:"caller"`
+	StatusCode    int         `json:"statusCode"`
+	Duration      int64       `json:"durationNs"`
+	BytesReceived int64       `json:"bytesReceived"`
+	BytesSent     int64       `json:"bytesSent"`
+}
+
+// auditTarget - destination of audit records.
+type auditTarget interface {
+	Send(entry auditEntry) error
+	Close() error
+}
+
+// fileAuditTarget - writes audit records as JSON lines to a local
+// file, the file is renamed with a timestamp suffix and a new one
+// started when it grows beyond maxSize.
+type fileAuditTarget struct {
+	path    string
+	maxSize int64
+
+	file *os.File
+	size int64
+}
+
+// newFileAuditTarget - opens the audit log file at path for appending.
+func newFileAuditTarget(path string, maxSize int64) (*fileAuditTarget, error) {
+	t := &fileAuditTarget{path: path, maxSize: maxSize}
+	if err := t.open(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *fileAuditTarget) open() error {
+	file, err := os.OpenFile(t.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	t.file, t.size = file, fi.Size()
+	return nil
+}
+
+// rotate - moves the current file aside and starts a new one.
+func (t *fileAuditTarget) rotate() error {
+	if err := t.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(t.path, t.path+"."+UTCNow().Format("2006-01-02T15-04-05.000")); err != nil {
+		return err
+	}
+	return t.open()
+}
+
+// Send - appends the record to the file.
+func (t *fileAuditTarget) Send(entry auditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if t.maxSize > 0 && t.size > 0 && t.size+int64(len(data)) > t.maxSize {
+		if err = t.rotate(); err != nil {
+			return fmt.Errorf("Unable to rotate audit log %s: %v", t.path, err)
+		}
+	}
+	n, err := t.file.Write(data)
+	t.size += int64(n)
+	return err
+}
+
+// Close - closes the file.
+func (t *fileAuditTarget) Close() error {
+	return t.file.Close()
+}
+
+// httpAuditTarget - posts audit records as JSON to an HTTP endpoint.
+type httpAuditTarget struct {
+	endpoint string
+	client   *http.Client
+}
+
+// newHTTPAuditTarget - returns a target posting records to endpoint.
+func newHTTPAuditTarget(endpoint string) *httpAuditTarget {
+	return &httpAuditTarget{
+		endpoint: endpoint,
+		client: &http.Client{
+			Transport: NewCustomHTTPTransport(),
+			Timeout:   auditWebhookTimeout,
+		},
+	}
+}
+
+// Send - posts the record to the endpoint.
+func (t *httpAuditTarget) Send(entry auditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Post(t.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Audit webhook %s returned %s", t.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// Close - nothing to do for HTTP endpoints.
+func (t *httpAuditTarget) Close() error {
+	return nil
+}
+
+// auditLogger - sends audit records to all targets asynchronously, a
+// slow target neither delays requests nor the other targets.
+type auditLogger struct {
+	// Number of records dropped since last reported, kept first
+	// for 64-bit alignment of atomic operations.
+	dropped uint64
+
+	queues []chan auditEntry
+}
+
+// newAuditLogger - starts sending records to targets.
+func newAuditLogger(targets ...auditTarget) *auditLogger {
+	l := &auditLogger{}
+	for _, target := range targets {
+		queue := make(chan auditEntry, auditQueueSize)
+		l.queues = append(l.queues, queue)
+		go l.run(target, queue)
+	}
+	return l
+}
+
+func (l *auditLogger) run(target auditTarget, queue <-chan auditEntry) {
+	defer target.Close()
+	for entry := range queue {
+		if dropped := atomic.SwapUint64(&l.dropped, 0); dropped > 0 {
+			errorIf(fmt.Errorf("%d audit records dropped", dropped), "Audit log targets are too slow.")
+		}
+		errorIf(target.Send(entry), "Unable to send audit record %s.", entry.RequestID)
+	}
+}
+
+// Log - queues the record for all targets.
+func (l *auditLogger) Log(entry auditEntry) {
+	for _, queue := range l.queues {
+		select {
+		case queue <- entry:
+		default:
+			atomic.AddUint64(&l.dropped, 1)
+		}
+	}
+}
+
+// initAuditLogger - returns the audit logger configured in the
+// environment, nil if audit logging is not configured.
+func initAuditLogger() (*auditLogger, error) {
+	var targets []auditTarget
+
+	if path := os.Getenv(auditLogFileEnv); path != "" {
+		maxSize := uint64(defaultAuditLogFileMaxSize)
+		if size := os.Getenv(auditLogFileMaxSizeEnv); size != "" {
+			var err error
+			if maxSize, err = humanize.ParseBytes(size); err != nil {
+				return nil, fmt.Errorf("Invalid value ‘%s’ in %s: %v", size, auditLogFileMaxSizeEnv, err)
+			}
+		}
+		target, err := newFileAuditTarget(path, int64(maxSize))
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	if endpoint := os.Getenv(auditLogWebhookEnv); endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != httpScheme && u.Scheme != httpsScheme) {
+			return nil, fmt.Errorf("Invalid URL ‘%s’ in %s", endpoint, auditLogWebhookEnv)
+		}
+		targets = append(targets, newHTTPAuditTarget(endpoint))
+	}
+
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	return newAuditLogger(targets...), nil
+}
+
+// Global audit logger, only set when audit logging is configured.
+var globalAuditLogger *auditLogger
+
+type auditEntryContextKey struct{}
+
+// getAuditEntry - returns the audit record of the request, nil if
+// requests are not audited.
+func getAuditEntry(r *http.Request) *auditEntry {
+	entry, _ := r.Context().Value(auditEntryContextKey{}).(*auditEntry)
+	return entry
+}
+
+// getHandlerName - returns the API name of a handler function, for
+// example "PutObject" for objectAPIHandlers.PutObjectHandler.
+func getHandlerName(f http.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "."); i != -1 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, "Handler")
+}
+
+// auditAPIHandler - records the API name, bucket and object of
+// requests served by f in their audit record.
+func auditAPIHandler(api string, f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if entry := getAuditEntry(r); entry != nil {
+			vars := router.Vars(r)
+			entry.API = api
+			entry.Bucket, entry.Object = vars["bucket"], vars["object"]
+		}
+		f(w, r)
+	}
+}
+
+// auditAPI - records the API served by f, named after the handler function.
+func auditAPI(f http.HandlerFunc) http.HandlerFunc {
+	return auditAPIHandler(getHandlerName(f), f)
+}
+
+// getRequestAccessKey - returns the access key a request claims to be
+// signed with, empty for anonymous or browser requests.
+func getRequestAccessKey(r *http.Request) string {
+	switch getRequestAuthType(r) {
+	case authTypeSigned, authTypeStreamingSigned:
+		if sv, s3Err := parseSignV4(r.Header.Get("Authorization")); s3Err == ErrNone {
+			return sv.Credential.accessKey
+		}
+	case authTypePresigned:
+		if ch, s3Err := parseCredentialHeader("Credential=" + r.URL.Query().Get("X-Amz-Credential")); s3Err == ErrNone {
+			return ch.accessKey
+		}
+	case authTypeSignedV2:
+		// Authorization = "AWS" + " " + AWSAccessKeyId + ":" + Signature
+		v2Auth := strings.TrimPrefix(r.Header.Get("Authorization"), signV2Algorithm+" ")
+		if i := strings.Index(v2Auth, ":"); i != -1 {
+			return v2Auth[:i]
+		}
+	case authTypePresignedV2:
+		return r.URL.Query().Get("AWSAccessKeyId")
+	}
+	return ""
+}
+
+// countingReadCloser - counts the bytes read from a request body.
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// countingResponseWriter - records the status code and the bytes
+// written of a response.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	n           int64
+}
+
+func (w *countingResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *countingResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.statusCode, w.wroteHeader = statusCode, true
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *countingResponseWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *countingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// auditHandler - sends an audit record of every request to the audit
+// logger, internal RPC and browser asset requests are not audited.
+type auditHandler struct {
+	handler http.Handler
+}
+
+func setAuditHandler(h http.Handler) http.Handler {
+	return auditHandler{handler: h}
+}
+
+func (h auditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if globalAuditLogger == nil {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	entry := &auditEntry{
+		Version: auditEntryVersion,
+		Time:    UTCNow(),
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Caller: auditCaller{
+			AccessKey:  getRequestAccessKey(r),
+			RemoteHost: getSourceIPAddress(r),
+			UserAgent:  r.UserAgent(),
+		},
+	}
+	var body *countingReadCloser
+	if r.Body != nil {
+		body = &countingReadCloser{ReadCloser: r.Body}
+		r.Body = body
+	}
+	ww := &countingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+	h.handler.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), auditEntryContextKey{}, entry)))
+
+	// Only audited routes set the API name, everything else below
+	// the reserved bucket path is internal.
+	if entry.API == "" && hasPrefix(r.URL.Path, minioReservedBucketPath+slashSeparator) {
+		return
+	}
+	entry.RequestID = ww.Header().Get(responseRequestIDKey)
+	entry.StatusCode = ww.statusCode
+	entry.Duration = int64(UTCNow().Sub(entry.Time))
+	if body != nil {
+		entry.BytesReceived = body.n
+	}
+	entry.BytesSent = ww.n
+	globalAuditLogger.Log(*entry)
+}
@@ -0,0 +1,270 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	router "github.com/gorilla/mux"
+)
+
+// testAuditTarget - collects audit records sent to it.
+type testAuditTarget struct {
+	entries chan auditEntry
+}
+
+func (t testAuditTarget) Send(entry auditEntry) error {
+	t.entries <- entry
+	return nil
+}
+
+func (t testAuditTarget) Close() error {
+	return nil
+}
+
+// Tests API names derived from handler functions.
+func TestGetHandlerName(t *testing.T) {
+	api := objectAPIHandlers{}
+	adminAPI := adminAPIHandlers{}
+	testCases := []struct {
+		f    http.HandlerFunc
+		name string
+	}{
+		{api.PutObjectHandler, "PutObject"},
+		{api.CompleteMultipartUploadHandler, "CompleteMultipartUpload"},
+		{adminAPI.ServiceStatusHandler, "ServiceStatus"},
+	}
+	for i, testCase := range testCases {
+		if name := getHandlerName(testCase.f); name != testCase.name {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.name, name)
+		}
+	}
+}
+
+// Tests that the access key is read from all kinds of signatures.
+func TestGetRequestAccessKey(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer os.RemoveAll(rootPath)
+
+	cred := globalServerConfig.GetCredential()
+	newRequest := func(sign func(*http.Request) error) *http.Request {
+		req, rerr := newTestRequest(http.MethodGet, "http://127.0.0.1:9000/bucket/object", 0, nil)
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+		if sign != nil {
+			if rerr = sign(req); rerr != nil {
+				t.Fatal(rerr)
+			}
+		}
+		return req
+	}
+
+	testCases := []struct {
+		req       *http.Request
+		accessKey string
+	}{
+		{newRequest(nil), ""},
+		{newRequest(func(req *http.Request) error { return signRequestV4(req, cred.AccessKey, cred.SecretKey) }), cred.AccessKey},
+		{newRequest(func(req *http.Request) error { return signRequestV2(req, cred.AccessKey, cred.SecretKey) }), cred.AccessKey},
+		{newRequest(func(req *http.Request) error { return preSignV4(req, cred.AccessKey, cred.SecretKey, 60) }), cred.AccessKey},
+		{newRequest(func(req *http.Request) error { return preSignV2(req, cred.AccessKey, cred.SecretKey, 60) }), cred.AccessKey},
+	}
+	for i, testCase := range testCases {
+		if accessKey := getRequestAccessKey(testCase.req); accessKey != testCase.accessKey {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.accessKey, accessKey)
+		}
+	}
+}
+
+// Tests that requests are recorded with their API, status and size.
+func TestAuditHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer os.RemoveAll(rootPath)
+
+	target := testAuditTarget{entries: make(chan auditEntry, 10)}
+	globalAuditLogger = newAuditLogger(target)
+	defer func() { globalAuditLogger = nil }()
+
+	mux := router.NewRouter()
+	mux.Methods(http.MethodPut).Path("/{bucket}/{object:.+}").HandlerFunc(auditAPIHandler("PutObject", func(w http.ResponseWriter, r *http.Request) {
+		setCommonHeaders(w)
+		data, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(data[:2])
+	}))
+	h := setAuditHandler(mux)
+
+	testCases := []struct {
+		method     string
+		path       string
+		api        string
+		statusCode int
+		audited    bool
+	}{
+		{http.MethodPut, "/bucket/dir/object", "PutObject", http.StatusCreated, true},
+		// Not routed, yet recorded.
+		{http.MethodPut, "/bucket", "", http.StatusNotFound, true},
+		// Internal requests are not recorded.
+		{http.MethodPost, minioReservedBucketPath + storageRPCPath, "", http.StatusNotFound, false},
+	}
+	for i, testCase := range testCases {
+		req := httptest.NewRequest(testCase.method, testCase.path, bytes.NewReader([]byte("hello")))
+		req.Header.Set("User-Agent", "audit-test")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if !testCase.audited {
+			select {
+			case entry := <-target.entries:
+				t.Errorf("Test %d: Unexpected audit record %#v", i+1, entry)
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+
+		var entry auditEntry
+		select {
+		case entry = <-target.entries:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Test %d: No audit record sent", i+1)
+		}
+		if entry.API != testCase.api || entry.StatusCode != testCase.statusCode || entry.Path != testCase.path {
+			t.Errorf("Test %d: Unexpected audit record %#v", i+1, entry)
+		}
+		if entry.Caller.UserAgent != "audit-test" || entry.Duration <= 0 {
+			t.Errorf("Test %d: Unexpected audit record %#v", i+1, entry)
+		}
+		if testCase.api != "" {
+			if entry.Bucket != "bucket" || entry.Object != "dir/object" {
+				t.Errorf("Test %d: Expected bucket/dir/object, got %s/%s", i+1, entry.Bucket, entry.Object)
+			}
+			if entry.BytesReceived != 5 || entry.BytesSent != 2 {
+				t.Errorf("Test %d: Expected 5 bytes received and 2 sent, got %d and %d", i+1, entry.BytesReceived, entry.BytesSent)
+			}
+			if entry.RequestID == "" || entry.RequestID != rec.Header().Get(responseRequestIDKey) {
+				t.Errorf("Test %d: Expected request ID %s, got %s", i+1, rec.Header().Get(responseRequestIDKey), entry.RequestID)
+			}
+		}
+	}
+}
+
+// Tests that the audit log file is rotated once it grows too large.
+func TestFileAuditTarget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-audit-log")
+	if err != nil {
+		t.Fatalf("Unable create temp directory. %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	target, err := newFileAuditTarget(path, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+
+	for i := 0; i < 20; i++ {
+		if err = target.Send(auditEntry{Version: auditEntryVersion, API: "PutObject", Path: "/bucket/object"}); err != nil {
+			t.Fatalf("Unable to write audit record. %v", err)
+		}
+		// Rotated files are named after the time of rotation.
+		time.Sleep(time.Millisecond)
+	}
+
+	files, err := filepath.Glob(path + "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 2 {
+		t.Fatalf("Expected the audit log to be rotated, got %v", files)
+	}
+
+	var records int
+	for _, file := range files {
+		fi, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() > 1024 {
+			t.Errorf("Expected %s to be at most 1024 bytes, got %d", file, fi.Size())
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry auditEntry
+			if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Errorf("Invalid audit record in %s. %v", file, err)
+			}
+			records++
+		}
+		f.Close()
+	}
+	if records != 20 {
+		t.Errorf("Expected 20 audit records, got %d", records)
+	}
+}
+
+// Tests posting audit records to an HTTP endpoint.
+func TestHTTPAuditTarget(t *testing.T) {
+	entries := make(chan auditEntry, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var entry auditEntry
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		entries <- entry
+	}))
+	defer server.Close()
+
+	target := newHTTPAuditTarget(server.URL)
+	if err := target.Send(auditEntry{Version: auditEntryVersion, RequestID: "3L137"}); err != nil {
+		t.Fatalf("Unable to post audit record. %v", err)
+	}
+	if entry := <-entries; entry.RequestID != "3L137" {
+		t.Fatalf("Expected request ID 3L137, got %s", entry.RequestID)
+	}
+
+	target = newHTTPAuditTarget(server.URL + "/unavailable")
+	if err := target.Send(auditEntry{}); err == nil {
+		t.Fatal("Expected an error for an unavailable endpoint")
+	}
+}
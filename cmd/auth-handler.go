@@ -60,8 +60,9 @@ func isRequestPostPolicySignatureV4(r *http.Request) bool {
 
 // Verify if the request has AWS Streaming Signature Version '4'. This is only valid for 'PUT' operation.
 func isRequestSignStreamingV4(r *http.Request) bool {
-	return r.Header.Get("x-amz-content-sha256") == streamingContentSHA256 &&
-		r.Method == http.MethodPut
+	contentSHA256 := r.Header.Get("x-amz-content-sha256")
+	return (contentSHA256 == streamingContentSHA256 || contentSHA256 == streamingContentSHA256Trailer ||
+		contentSHA256 == streamingUnsignedPayloadTrailer) && r.Method == http.MethodPut
 }
 
 // Authorization type.
@@ -102,11 +103,24 @@ func getRequestAuthType(r *http.Request) authType {
 	return authTypeUnknown
 }
 
+// isReqVerifiedClientCert - returns true if the request carries a
+// TLS client certificate verified against the admin client CAs.
+func isReqVerifiedClientCert(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+}
+
 // checkAdminRequestAuthType checks whether the request is a valid signature V2 or V4 request.
-// It does not accept presigned or JWT or anonymous requests.
+// It does not accept presigned or JWT or anonymous requests, nor requests signed
+// with temporary credentials. When admin client CAs are configured the request
+// must also carry a verified client certificate.
 func checkAdminRequestAuthType(r *http.Request, region string) APIErrorCode {
+	if globalAdminClientCAs != nil && !isReqVerifiedClientCert(r) {
+		errorIf(errors.New(getAPIError(ErrAdminClientCertRequired).Description), "%s", dumpRequest(r))
+		return ErrAdminClientCertRequired
+	}
+
 	s3Err := ErrAccessDenied
-	if getRequestAuthType(r) == authTypeSigned { // we only support V4 (no presign)
+	if getRequestAuthType(r) == authTypeSigned && getReqSessionToken(r) == "" { // we only support V4 (no presign)
 		s3Err = isReqAuthenticated(r, region)
 	}
 	if s3Err != ErrNone {
@@ -115,6 +129,9 @@ func checkAdminRequestAuthType(r *http.Request, region string) APIErrorCode {
 	return s3Err
 }
 
+// checkRequestAuthType checks whether the request is signed or allowed
+// anonymously by the bucket policy. Requests signed with temporary
+// credentials must also be allowed by the policies granted to them.
 func checkRequestAuthType(r *http.Request, bucket, policyAction, region string) APIErrorCode {
 	reqAuthType := getRequestAuthType(r)
 
@@ -124,20 +141,22 @@ func checkRequestAuthType(r *http.Request, bucket, policyAction, region string)
 		s3Error := isReqAuthenticatedV2(r)
 		if s3Error != ErrNone {
 			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
+			return s3Error
 		}
-		return s3Error
+		return enforceSessionPolicy(r, bucket, policyAction)
 	case authTypeSigned, authTypePresigned:
 		s3Error := isReqAuthenticated(r, region)
 		if s3Error != ErrNone {
 			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
+			return s3Error
 		}
-		return s3Error
+		return enforceSessionPolicy(r, bucket, policyAction)
 	}
 
 	if reqAuthType == authTypeAnonymous && policyAction != "" {
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
 		sourceIP := getSourceIPAddress(r)
-		resource, err := getResource(r.URL.Path, r.Host, globalDomainName)
+		resource, err := getResource(r.URL.Path, r.Host, globalDomainNames)
 		if err != nil {
 			return ErrInternalError
 		}
@@ -149,8 +168,41 @@ func checkRequestAuthType(r *http.Request, bucket, policyAction, region string)
 	return ErrAccessDenied
 }
 
+// checkRequestSignature - checks the request is signed with valid
+// credentials without enforcing any policy, for handlers checking the
+// access to every object of the request with checkRequestObjectAccess.
+func checkRequestSignature(r *http.Request, region string) APIErrorCode {
+	switch getRequestAuthType(r) {
+	case authTypePresignedV2, authTypeSignedV2:
+		return isReqAuthenticatedV2(r)
+	case authTypeSigned, authTypePresigned:
+		return isReqAuthenticated(r, region)
+	}
+	return ErrAccessDenied
+}
+
+// checkRequestObjectAccess - checks an authenticated request is allowed
+// the action on an object other than the one of the request, like the
+// source of a copy. Anonymous requests are checked against the bucket
+// policy of the object, requests signed with temporary credentials
+// against the policies granted to them.
+func checkRequestObjectAccess(r *http.Request, bucket, object, action string) APIErrorCode {
+	if getRequestAuthType(r) == authTypeAnonymous {
+		return enforceBucketPolicy(bucket, action, pathJoin(slashSeparator+bucket, object),
+			r.Referer(), getSourceIPAddress(r), r.URL.Query())
+	}
+	if token := getReqSessionToken(r); token != "" {
+		conditions := getConditionKeyMap(r.Referer(), getSourceIPAddress(r), r.URL.Query())
+		return enforceSessionTokenPolicy(token, action, bucket, pathJoin(bucket, object), conditions)
+	}
+	return ErrNone
+}
+
 // Verify if request has valid AWS Signature Version '2'.
 func isReqAuthenticatedV2(r *http.Request) (s3Error APIErrorCode) {
+	if !globalIsSignatureV2 {
+		return ErrSignatureVersionNotSupported
+	}
 	if isRequestSignatureV2(r) {
 		return doesSignV2Match(r)
 	}
@@ -230,8 +282,12 @@ var supportedS3AuthTypes = map[authType]struct{}{
 	authTypeStreamingSigned: {},
 }
 
-// Validate if the authType is valid and supported.
+// Validate if the authType is valid and supported, signature V2 is
+// supported unless turned off.
 func isSupportedS3AuthType(aType authType) bool {
+	if aType == authTypeSignedV2 || aType == authTypePresignedV2 {
+		return globalIsSignatureV2
+	}
 	_, ok := supportedS3AuthTypes[aType]
 	return ok
 }
@@ -18,6 +18,8 @@ package cmd
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"io"
 	"net/http"
 	"net/url"
@@ -387,3 +389,40 @@ func TestCheckAdminRequestAuthType(t *testing.T) {
 		}
 	}
 }
+
+// Tests that admin requests require a verified client certificate
+// once admin client CAs are configured.
+func TestCheckAdminRequestClientCert(t *testing.T) {
+	path, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("unable initialize config file, %s", err)
+	}
+	defer os.RemoveAll(path)
+
+	globalAdminClientCAs = x509.NewCertPool()
+	defer func() { globalAdminClientCAs = nil }()
+
+	verifiedReq := mustNewSignedRequest("GET", "http://127.0.0.1:9000", 0, nil, t)
+	verifiedReq.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{&x509.Certificate{}}},
+	}
+	unverifiedReq := mustNewSignedRequest("GET", "http://127.0.0.1:9000", 0, nil, t)
+	unverifiedReq.TLS = &tls.ConnectionState{}
+	unsignedReq := mustNewRequest("GET", "http://127.0.0.1:9000", 0, nil, t)
+	unsignedReq.TLS = verifiedReq.TLS
+
+	testCases := []struct {
+		Request *http.Request
+		ErrCode APIErrorCode
+	}{
+		{Request: mustNewSignedRequest("GET", "http://127.0.0.1:9000", 0, nil, t), ErrCode: ErrAdminClientCertRequired},
+		{Request: unverifiedReq, ErrCode: ErrAdminClientCertRequired},
+		{Request: unsignedReq, ErrCode: ErrAccessDenied},
+		{Request: verifiedReq, ErrCode: ErrNone},
+	}
+	for i, testCase := range testCases {
+		if s3Error := checkAdminRequestAuthType(testCase.Request, globalServerConfig.GetRegion()); s3Error != testCase.ErrCode {
+			t.Errorf("Test %d: Unexpected s3error returned wanted %d, got %d", i, testCase.ErrCode, s3Error)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"sync"
+	"time"
+)
+
+// TCP keepalive period of RPC connections, keeps idle connections
+// between servers open and detects servers which went away.
+const defaultRPCKeepAlive = 30 * time.Second
+
+// Backoff between dials of a server after failed dials, doubled on
+// every failure up to the cap.
+const (
+	rpcDialBackoffUnit = 100 * time.Millisecond
+	rpcDialBackoffCap  = 5 * time.Second
+)
+
+// getRPCDialBackoff - returns how long to wait before dialing again
+// after the given number of consecutive failed dials.
+func getRPCDialBackoff(failures int) time.Duration {
+	backoff := rpcDialBackoffUnit
+	for i := 1; i < failures && backoff < rpcDialBackoffCap; i++ {
+		backoff *= 2
+	}
+	if backoff > rpcDialBackoffCap {
+		backoff = rpcDialBackoffCap
+	}
+	return backoff
+}
+
+// rpcClientPool - authenticated RPC clients shared by all callers of
+// the same service of a server. Calls of a client are multiplexed on
+// its connection, which is only dialed again after it was closed.
+type rpcClientPool struct {
+	mu      sync.Mutex
+	clients map[authConfig]*AuthRPCClient
+}
+
+// newRPCClientPool - returns an empty RPC client pool.
+func newRPCClientPool() *rpcClientPool {
+	return &rpcClientPool{clients: make(map[authConfig]*AuthRPCClient)}
+}
+
+// get - returns the client of the pool for config, creating it if
+// needed. Clients of the same service with other credentials are
+// closed and removed, they were made before the credentials changed.
+// Callers must not close clients of the pool.
+func (p *rpcClientPool) get(config authConfig) *AuthRPCClient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[config]; ok {
+		return client
+	}
+	for cfg, client := range p.clients {
+		if cfg.serverAddr == config.serverAddr && cfg.serviceEndpoint == config.serviceEndpoint {
+			client.Close()
+			delete(p.clients, cfg)
+		}
+	}
+	client := newAuthRPCClient(config)
+	p.clients[config] = client
+	return client
+}
+
+// Pool of RPC clients used for calls to other servers made outside
+// of long lived peer clients, like lock and credential updates.
+var globalRPCClientPool = newRPCClientPool()
@@ -0,0 +1,109 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// Tests that callers of the same service share a client.
+func TestRPCClientPool(t *testing.T) {
+	pool := newRPCClientPool()
+	config := authConfig{
+		accessKey:       "accesskey",
+		secretKey:       "secretkey",
+		serverAddr:      "localhost:9000",
+		serviceEndpoint: "/minio/lock",
+		serviceName:     lockServiceName,
+	}
+	client := pool.get(config)
+	if pool.get(config) != client {
+		t.Fatal("Expected the client of the same service to be shared")
+	}
+
+	other := config
+	other.serviceEndpoint = "/minio/admin"
+	if pool.get(other) == client {
+		t.Fatal("Expected another client for another service")
+	}
+
+	// Clients made with old credentials are replaced.
+	newCreds := config
+	newCreds.secretKey = "newsecretkey"
+	if pool.get(newCreds) == client {
+		t.Fatal("Expected another client for new credentials")
+	}
+	if _, ok := pool.clients[config]; ok {
+		t.Fatal("Expected the client with old credentials to be removed")
+	}
+	if len(pool.clients) != 2 {
+		t.Fatalf("Expected 2 clients in the pool, got %d", len(pool.clients))
+	}
+}
+
+// Tests the backoff between dials of a server.
+func TestGetRPCDialBackoff(t *testing.T) {
+	testCases := []struct {
+		failures int
+		backoff  time.Duration
+	}{
+		{1, rpcDialBackoffUnit},
+		{2, 2 * rpcDialBackoffUnit},
+		{4, 8 * rpcDialBackoffUnit},
+		{100, rpcDialBackoffCap},
+	}
+	for i, testCase := range testCases {
+		if backoff := getRPCDialBackoff(testCase.failures); backoff != testCase.backoff {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.backoff, backoff)
+		}
+	}
+}
+
+// Tests that a server which failed to be dialed is not dialed again
+// until the backoff has passed.
+func TestAuthRPCClientDialBackoff(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	cred := globalServerConfig.GetCredential()
+	client := newAuthRPCClient(authConfig{
+		accessKey:       cred.AccessKey,
+		secretKey:       cred.SecretKey,
+		serverAddr:      "localhost:1",
+		serviceEndpoint: "/minio/lock",
+		serviceName:     lockServiceName,
+	})
+	if err = client.Login(); err == nil {
+		t.Fatal("Expected dialing a closed port to fail")
+	}
+	if err2 := client.Login(); err2 != err || client.dialFailures != 1 {
+		t.Fatalf("Expected the failed dial not to be retried, got %v after %d dials", err2, client.dialFailures)
+	}
+
+	client.retryDialAt = UTCNow()
+	if err = client.Login(); err == nil || client.dialFailures != 2 {
+		t.Fatalf("Expected the server to be dialed again, got %v after %d dials", err, client.dialFailures)
+	}
+	if backoff := client.retryDialAt.Sub(UTCNow()); backoff > 2*rpcDialBackoffUnit {
+		t.Fatalf("Expected a backoff of at most %s, got %s", 2*rpcDialBackoffUnit, backoff)
+	}
+}
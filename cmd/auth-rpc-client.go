@@ -66,6 +66,12 @@ type AuthRPCClient struct {
 	config       authConfig  // Authentication configuration information.
 	authToken    string      // Authentication token.
 	version      semVersion  // RPC version.
+
+	// Last failed dial, the server is not dialed again
+	// before retryDialAt.
+	dialErr      error
+	dialFailures int
+	retryDialAt  time.Time
 }
 
 // newAuthRPCClient - returns a JWT based authenticated (go) rpc client, which does automatic reconnect.
@@ -125,19 +131,37 @@ func (authClient *AuthRPCClient) Login() (err error) {
 			}
 		)
 
+		// Fail fast while backing off from failed dials, calls
+		// to a server which is down would otherwise all wait for
+		// the dial timeout.
+		if authClient.dialErr != nil && UTCNow().Before(authClient.retryDialAt) {
+			return authClient.dialErr
+		}
+
 		// Re-dial after we have disconnected or if its a fresh run.
 		var rpcClient *rpc.Client
 		rpcClient, err = rpcDial(authClient.config.serverAddr, authClient.config.serviceEndpoint, authClient.config.secureConn)
 		if err != nil {
+			authClient.dialFailures++
+			authClient.dialErr = err
+			authClient.retryDialAt = UTCNow().Add(getRPCDialBackoff(authClient.dialFailures))
 			return err
 		}
+		authClient.dialErr = nil
+		authClient.dialFailures = 0
 
 		if err = rpcClient.Call(loginMethod, &loginArgs, &LoginRPCReply{}); err != nil {
+			rpcClient.Close()
 			// gob doesn't provide any typed errors for us to reflect
 			// upon, this is the only way to return proper error.
 			if strings.Contains(err.Error(), "gob: wrong type") {
 				return errRPCAPIVersionUnsupported
 			}
+			if err.Error() == errServerTimeMismatch.Error() {
+				errorIf(errServerTimeMismatch, "Unable to login to %s, clocks differ by more than %s. Please synchronize the clocks of all servers (e.g. using NTP).",
+					authClient.config.serverAddr, rpcSkewTimeAllowed)
+				return errServerTimeMismatch
+			}
 			return err
 		}
 
@@ -238,7 +262,8 @@ func rpcDial(serverAddr, serviceEndpoint string, secureConn bool) (netRPCClient
 		return nil, errInvalidArgument
 	}
 	d := &net.Dialer{
-		Timeout: defaultDialTimeout,
+		Timeout:   defaultDialTimeout,
+		KeepAlive: defaultRPCKeepAlive,
 	}
 	var conn net.Conn
 	if secureConn {
@@ -0,0 +1,188 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Stages of the server startup, in the order they are run.
+const (
+	bootStageStarting      = "starting"
+	bootStageMigrateFormat = "migrating disk formats"
+	bootStageLoadFormat    = "loading disk formats"
+	bootStageConnectDisks  = "connecting disks"
+	bootStagePolicies      = "loading bucket policies"
+	bootStageNotifications = "loading bucket notifications"
+	bootStageReady         = "ready"
+)
+
+const (
+	// Path the startup progress is served at, it is served while
+	// the object layer is initialized.
+	bootHealthPath = minioReservedBucketPath + "/health/boot"
+
+	// Maximum number of buckets whose metadata is loaded at once,
+	// all disks are loaded at once.
+	bootBucketConcurrency = 32
+
+	// Progress of a stage is printed on the console at most this often.
+	bootProgressPrintInterval = 5 * time.Second
+)
+
+// BootStatus - progress of the server startup.
+type BootStatus struct {
+	Stage string `json:"stage"`
+
+	// Tasks of the stage done so far, disks or buckets.
+	Done    int `json:"done"`
+	Total   int `json:"total"`
+	Percent int `json:"percent"`
+
+	// Time since the server started, in seconds.
+	Elapsed float64 `json:"elapsed"`
+}
+
+// bootProgress - tracks the stage of the server startup and the
+// progress within the stage. Stages are no longer updated once the
+// server is ready, as they run again when metadata is reloaded.
+type bootProgress struct {
+	mu        sync.Mutex
+	start     time.Time
+	stage     string
+	done      int
+	total     int
+	lastPrint time.Time
+}
+
+func newBootProgress() *bootProgress {
+	return &bootProgress{start: UTCNow(), stage: bootStageStarting}
+}
+
+// setStage - starts a stage of total tasks.
+func (p *bootProgress) setStage(stage string, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stage == bootStageReady {
+		return
+	}
+	p.stage = stage
+	p.done = 0
+	p.total = total
+	p.lastPrint = time.Time{}
+}
+
+// advance - marks a task of the stage done and prints the progress on
+// the console when the last print is old enough or the stage is done.
+func (p *bootProgress) advance() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stage == bootStageReady {
+		return
+	}
+	p.done++
+	now := UTCNow()
+	if p.done < p.total && now.Sub(p.lastPrint) < bootProgressPrintInterval {
+		return
+	}
+	p.lastPrint = now
+	status := p.status()
+	log.Printf("Startup: %s %d%% (%d/%d, elapsed %s)\n", status.Stage, status.Percent, status.Done, status.Total,
+		now.Sub(p.start).Round(time.Second))
+}
+
+// finish - marks the server ready.
+func (p *bootProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stage = bootStageReady
+	p.done = 0
+	p.total = 0
+}
+
+// Status - returns the current progress of the startup.
+func (p *bootProgress) Status() BootStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status()
+}
+
+func (p *bootProgress) status() BootStatus {
+	status := BootStatus{
+		Stage:   p.stage,
+		Done:    p.done,
+		Total:   p.total,
+		Elapsed: UTCNow().Sub(p.start).Seconds(),
+	}
+	switch {
+	case p.stage == bootStageReady:
+		status.Percent = 100
+	case p.total > 0:
+		status.Percent = p.done * 100 / p.total
+	}
+	return status
+}
+
+// runBootTasks - runs count tasks of a startup stage with at most
+// concurrency tasks at once, a concurrency of zero runs all at once.
+// Returns the error of each task at its index.
+func runBootTasks(stage string, count, concurrency int, task func(index int) error) []error {
+	globalBootProgress.setStage(stage, count)
+	if concurrency <= 0 || concurrency > count {
+		concurrency = count
+	}
+
+	errs := make([]error, count)
+	indexCh := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexCh {
+				errs[index] = task(index)
+				globalBootProgress.advance()
+			}
+		}()
+	}
+	for index := 0; index < count; index++ {
+		indexCh <- index
+	}
+	close(indexCh)
+	wg.Wait()
+	return errs
+}
+
+// bootHealthHandler - serves the progress of the server startup, with
+// status 503 until the server is ready to serve requests. Anonymous
+// requests are allowed, for load balancers to check.
+func bootHealthHandler(w http.ResponseWriter, r *http.Request) {
+	status := globalBootProgress.Status()
+	data, err := json.Marshal(status)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if status.Stage != bootStageReady {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(data)
+}
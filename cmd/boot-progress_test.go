@@ -0,0 +1,116 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	router "github.com/gorilla/mux"
+)
+
+// Tests startup tasks run in parallel up to the concurrency and report
+// progress.
+func TestRunBootTasks(t *testing.T) {
+	defer func(p *bootProgress) { globalBootProgress = p }(globalBootProgress)
+	globalBootProgress = newBootProgress()
+
+	errTask := errors.New("task failed")
+	var mu sync.Mutex
+	var running, maxRunning int
+	errs := runBootTasks(bootStagePolicies, 20, 4, func(index int) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		if index%5 == 0 {
+			return errTask
+		}
+		return nil
+	})
+	if maxRunning > 4 {
+		t.Errorf("Expected at most 4 tasks at once, got %d", maxRunning)
+	}
+	for index, err := range errs {
+		if (index%5 == 0) != (err == errTask) {
+			t.Errorf("Task %d: Unexpected error %v", index, err)
+		}
+	}
+
+	status := globalBootProgress.Status()
+	if status.Stage != bootStagePolicies || status.Done != 20 || status.Total != 20 || status.Percent != 100 {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	if errs = runBootTasks(bootStageNotifications, 0, 4, nil); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+}
+
+// Tests the startup progress is served until the server is ready.
+func TestBootHealthHandler(t *testing.T) {
+	defer func(p *bootProgress) { globalBootProgress = p }(globalBootProgress)
+	globalBootProgress = newBootProgress()
+
+	mux := router.NewRouter()
+	registerHealthRouter(mux)
+	handler := setReservedBucketHandler(mux)
+
+	check := func(expectedCode int, expected BootStatus) {
+		req, err := http.NewRequest(http.MethodGet, bootHealthPath, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != expectedCode {
+			t.Fatalf("Expected status %d, got %d", expectedCode, rec.Code)
+		}
+		var status BootStatus
+		if err = json.NewDecoder(rec.Body).Decode(&status); err != nil {
+			t.Fatal(err)
+		}
+		if status.Stage != expected.Stage || status.Done != expected.Done || status.Total != expected.Total || status.Percent != expected.Percent {
+			t.Errorf("Expected status %+v, got %+v", expected, status)
+		}
+	}
+
+	check(http.StatusServiceUnavailable, BootStatus{Stage: bootStageStarting})
+
+	globalBootProgress.setStage(bootStageConnectDisks, 32)
+	for i := 0; i < 8; i++ {
+		globalBootProgress.advance()
+	}
+	check(http.StatusServiceUnavailable, BootStatus{Stage: bootStageConnectDisks, Done: 8, Total: 32, Percent: 25})
+
+	// Stages run again at runtime are not reported once ready.
+	globalBootProgress.finish()
+	globalBootProgress.setStage(bootStageLoadFormat, 32)
+	globalBootProgress.advance()
+	check(http.StatusOK, BootStatus{Stage: bootStageReady, Percent: 100})
+}
@@ -95,8 +95,8 @@ func updateCredsOnPeers(creds auth.Credentials) map[string]error {
 				return
 			}
 
-			// Initialize client
-			client := newAuthRPCClient(authConfig{
+			// Get the client of the peer.
+			client := globalRPCClientPool.get(authConfig{
 				accessKey:       serverCred.AccessKey,
 				secretKey:       serverCred.SecretKey,
 				serverAddr:      peers[ix],
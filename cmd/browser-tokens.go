@@ -0,0 +1,355 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+)
+
+// The browser is logged in with a short-lived access token, sent with
+// every request, and a refresh token exchanged for new access tokens
+// until it expires. Refresh tokens are revoked by ID, along with the
+// access tokens issued with them, all tokens of a user by user with the
+// admin API. Revocations are saved to
+// minioMetaBucket and apply to all servers within
+// browserTokenRevocationsCacheTTL. All tokens are revoked when the
+// server credentials are changed.
+
+const (
+	// Environment variable setting the expiry of browser access
+	// tokens, e.g. "1h".
+	browserTokenExpiryEnv = "MINIO_BROWSER_TOKEN_EXPIRY"
+
+	// Environment variable setting the expiry of browser refresh
+	// tokens, the longest a browser stays logged in.
+	browserRefreshExpiryEnv = "MINIO_BROWSER_REFRESH_EXPIRY"
+
+	// Default expiry of browser refresh tokens is one week.
+	defaultBrowserRefreshExpiry = 7 * 24 * time.Hour
+
+	// Browser tokens expire after at most 30 days, revocations are
+	// kept as long.
+	maxBrowserTokenExpiry = 30 * 24 * time.Hour
+
+	// Revoked browser tokens, persisted under minioMetaBucket.
+	browserTokenRevocationsPath = "config/browser-token-revocations.json"
+
+	// Revocations are cached for this long, revoked tokens are
+	// rejected by all servers within as long.
+	browserTokenRevocationsCacheTTL = time.Minute
+
+	// Maximum size of requests issuing browser tokens.
+	maxBrowserTokenRequestSize = 64 * 1024
+)
+
+var (
+	errInvalidBrowserTokenExpiry     = errors.New("browser token expiry must be between one minute and 30 days")
+	errInvalidRefreshToken           = errors.New("Invalid refresh token, please login again")
+	errInvalidBrowserTokenRequest    = errors.New("invalid browser token request")
+	errInvalidBrowserTokenRevocation = errors.New("no user or token ID to revoke")
+)
+
+// Parses the expiry of browser tokens.
+func parseBrowserTokenExpiry(value string) (time.Duration, error) {
+	expiry, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if expiry < time.Minute || expiry > maxBrowserTokenExpiry {
+		return 0, errInvalidBrowserTokenExpiry
+	}
+	return expiry, nil
+}
+
+// Sets the expiry of browser access and refresh tokens from the
+// environment.
+func handleBrowserTokenEnv() {
+	var err error
+	if value := os.Getenv(browserTokenExpiryEnv); value != "" {
+		globalBrowserTokenExpiry, err = parseBrowserTokenExpiry(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", browserTokenExpiryEnv)
+	}
+	if value := os.Getenv(browserRefreshExpiryEnv); value != "" {
+		globalBrowserRefreshExpiry, err = parseBrowserTokenExpiry(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", browserRefreshExpiryEnv)
+	}
+}
+
+// browserRefreshClaims - claims of a browser refresh token, the subject
+// is the user. Refresh tokens without grants are issued to the server
+// credentials, whose access key is the user.
+type browserRefreshClaims struct {
+	Grants []sessionGrant `json:"grants,omitempty"`
+	jwtgo.StandardClaims
+}
+
+// Returns the key refresh tokens are signed with, which must not sign
+// access tokens.
+func browserRefreshKeyFunc(token *jwtgo.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwtgo.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	}
+	return getServerHMAC("browser-refresh-token"), nil
+}
+
+// newBrowserRefreshToken - returns a refresh token of the user with
+// the policies granted, the server credentials if grants are empty,
+// valid for expiry. The ID of the token revokes it.
+func newBrowserRefreshToken(user string, grants []sessionGrant, expiry time.Duration) (token, id string, err error) {
+	key, err := browserRefreshKeyFunc(&jwtgo.Token{Method: jwtgo.SigningMethodHS512})
+	if err != nil {
+		return "", "", err
+	}
+	id = mustGetUUID()
+	now := UTCNow()
+	token, err = jwtgo.NewWithClaims(jwtgo.SigningMethodHS512, browserRefreshClaims{
+		Grants: grants,
+		StandardClaims: jwtgo.StandardClaims{
+			Id:        id,
+			Subject:   user,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(expiry).Unix(),
+		},
+	}).SignedString(key)
+	return token, id, err
+}
+
+// parseBrowserRefreshToken - verifies a refresh token is valid and not
+// revoked, and returns its claims.
+func parseBrowserRefreshToken(objAPI ObjectLayer, token string) (*browserRefreshClaims, error) {
+	claims := &browserRefreshClaims{}
+	if _, err := jwtgo.ParseWithClaims(token, claims, browserRefreshKeyFunc); err != nil {
+		return nil, errInvalidRefreshToken
+	}
+	if len(claims.Grants) == 0 && claims.Subject != globalServerConfig.GetCredential().AccessKey {
+		return nil, errInvalidRefreshToken
+	}
+	if globalBrowserTokenRevocations.isRevoked(objAPI, claims.Subject, claims.Id, "", claims.IssuedAt) {
+		return nil, errInvalidRefreshToken
+	}
+	return claims, nil
+}
+
+// browserTokenRequest - user and policies of browser tokens issued with
+// the admin API, e.g. to hand out read-only access to dashboards. The
+// duration defaults to the expiry of refresh tokens.
+type browserTokenRequest struct {
+	User     string         `json:"user"`
+	Grants   []sessionGrant `json:"grants"`
+	Duration time.Duration  `json:"duration"`
+}
+
+// browserToken - tokens issued with the admin API, the browser logs in
+// with the refresh token until it expires or its ID is revoked.
+type browserToken struct {
+	ID           string    `json:"id"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// issueBrowserToken - returns browser tokens of a user allowed the
+// managed policies granted only.
+func issueBrowserToken(objAPI ObjectLayer, req browserTokenRequest) (browserToken, error) {
+	if req.Duration == 0 {
+		req.Duration = globalBrowserRefreshExpiry
+	}
+	if req.User == "" || len(req.Grants) == 0 || req.Duration < time.Minute || req.Duration > maxBrowserTokenExpiry {
+		return browserToken{}, errInvalidBrowserTokenRequest
+	}
+	for _, grant := range req.Grants {
+		if grant.Bucket == "" {
+			return browserToken{}, errInvalidBrowserTokenRequest
+		}
+		if _, err := loadManagedPolicy(objAPI, grant.Policy); err != nil {
+			return browserToken{}, err
+		}
+	}
+
+	expiry := globalBrowserTokenExpiry
+	if req.Duration < expiry {
+		expiry = req.Duration
+	}
+	refreshToken, id, err := newBrowserRefreshToken(req.User, req.Grants, req.Duration)
+	if err != nil {
+		return browserToken{}, err
+	}
+	_, token, _, err := newChildSessionCredentials(req.User, req.Grants, id, expiry)
+	if err != nil {
+		return browserToken{}, err
+	}
+	return browserToken{
+		ID:           id,
+		Token:        token,
+		RefreshToken: refreshToken,
+		Expiry:       UTCNow().Add(req.Duration),
+	}, nil
+}
+
+// browserTokenRevocations - times browser tokens were revoked at.
+type browserTokenRevocations struct {
+	// Tokens of a user issued until the time are revoked.
+	Users map[string]time.Time `json:"users"`
+	// Tokens revoked by ID.
+	Tokens map[string]time.Time `json:"tokens"`
+}
+
+func newBrowserTokenRevocations() browserTokenRevocations {
+	return browserTokenRevocations{
+		Users:  make(map[string]time.Time),
+		Tokens: make(map[string]time.Time),
+	}
+}
+
+// isRevoked - returns whether the token of a user issued at issuedAt,
+// in seconds since the epoch, is revoked, by its ID or by the ID of the
+// refresh token it was issued with.
+func (r browserTokenRevocations) isRevoked(user, id, parent string, issuedAt int64) bool {
+	if revokedAt, ok := r.Users[user]; ok && issuedAt <= revokedAt.Unix() {
+		return true
+	}
+	for _, tokenID := range []string{id, parent} {
+		if _, ok := r.Tokens[tokenID]; ok && tokenID != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// prune - removes revocations of tokens which have all expired.
+func (r browserTokenRevocations) prune(now time.Time) {
+	for user, revokedAt := range r.Users {
+		if now.Sub(revokedAt) > maxBrowserTokenExpiry {
+			delete(r.Users, user)
+		}
+	}
+	for id, revokedAt := range r.Tokens {
+		if now.Sub(revokedAt) > maxBrowserTokenExpiry {
+			delete(r.Tokens, id)
+		}
+	}
+}
+
+// Loads the revoked browser tokens, none if nothing was revoked yet.
+func loadBrowserTokenRevocations(objAPI ObjectLayer) (browserTokenRevocations, error) {
+	r := newBrowserTokenRevocations()
+	if _, err := loadManagedPolicyJSON(objAPI, browserTokenRevocationsPath, &r); err != nil {
+		return r, err
+	}
+	if r.Users == nil {
+		r.Users = make(map[string]time.Time)
+	}
+	if r.Tokens == nil {
+		r.Tokens = make(map[string]time.Time)
+	}
+	return r, nil
+}
+
+// revokeBrowserTokens - revokes all browser tokens issued to user until
+// now if user is not empty, and the token with the ID if id is not empty.
+func revokeBrowserTokens(objAPI ObjectLayer, user, id string) error {
+	if user == "" && id == "" {
+		return errInvalidBrowserTokenRevocation
+	}
+
+	revocationsLock := globalNSMutex.NewNSLock(minioMetaBucket, browserTokenRevocationsPath)
+	if err := revocationsLock.GetLock(globalOperationTimeout); err != nil {
+		return err
+	}
+	defer revocationsLock.Unlock()
+
+	r, err := loadBrowserTokenRevocations(objAPI)
+	if err != nil {
+		return err
+	}
+	now := UTCNow()
+	if user != "" {
+		r.Users[user] = now
+	}
+	if id != "" {
+		r.Tokens[id] = now
+	}
+	r.prune(now)
+	if err = saveManagedPolicyJSON(objAPI, browserTokenRevocationsPath, r); err != nil {
+		return err
+	}
+	globalBrowserTokenRevocations.set(r)
+	return nil
+}
+
+// browserTokenRevocationCache - revoked browser tokens, reloaded once
+// they are cached for longer than browserTokenRevocationsCacheTTL.
+type browserTokenRevocationCache struct {
+	mu          sync.Mutex
+	revocations browserTokenRevocations
+	loaded      time.Time
+	loading     bool // Set while a request reloads the revocations.
+}
+
+func newBrowserTokenRevocationCache() *browserTokenRevocationCache {
+	return &browserTokenRevocationCache{revocations: newBrowserTokenRevocations()}
+}
+
+func (c *browserTokenRevocationCache) set(r browserTokenRevocations) {
+	c.mu.Lock()
+	c.revocations = r
+	c.loaded = UTCNow()
+	c.mu.Unlock()
+}
+
+// isRevoked - returns whether the token of a user issued at issuedAt is
+// revoked. Revocations are not reloaded while the object layer is not
+// initialized, and never by gateways, which do not serve the admin API
+// revoking tokens. A single request reloads them, others check the
+// cached revocations meanwhile. Revocations which fail to load are
+// retried after browserTokenRevocationsCacheTTL, the browser stays
+// usable to check the storage meanwhile.
+func (c *browserTokenRevocationCache) isRevoked(objAPI ObjectLayer, user, id, parent string, issuedAt int64) bool {
+	c.mu.Lock()
+	reload := objAPI != nil && globalGatewayName == "" && !c.loading &&
+		UTCNow().Sub(c.loaded) >= browserTokenRevocationsCacheTTL
+	if reload {
+		c.loading = true
+	}
+	revocations := c.revocations
+	c.mu.Unlock()
+
+	if reload {
+		start := UTCNow()
+		r, err := loadBrowserTokenRevocations(objAPI)
+		errorIf(err, "Unable to load revoked browser tokens.")
+
+		c.mu.Lock()
+		// Revocations set meanwhile are newer than the ones loaded.
+		if !c.loaded.After(start) {
+			if err == nil {
+				c.revocations = r
+			}
+			c.loaded = UTCNow()
+		}
+		revocations = c.revocations
+		c.loading = false
+		c.mu.Unlock()
+	}
+	return revocations.isRevoked(user, id, parent, issuedAt)
+}
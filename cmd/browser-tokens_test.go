@@ -0,0 +1,156 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests parsing the expiry of browser tokens.
+func TestParseBrowserTokenExpiry(t *testing.T) {
+	testCases := []struct {
+		value  string
+		expiry time.Duration
+		valid  bool
+	}{
+		{"1h", time.Hour, true},
+		{"720h", maxBrowserTokenExpiry, true},
+		{"30s", 0, false},
+		{"721h", 0, false},
+		{"one day", 0, false},
+	}
+	for i, testCase := range testCases {
+		expiry, err := parseBrowserTokenExpiry(testCase.value)
+		if testCase.valid && (err != nil || expiry != testCase.expiry) {
+			t.Errorf("Test %d: Expected %s, got %s, %v", i+1, testCase.expiry, expiry, err)
+		}
+		if !testCase.valid && err == nil {
+			t.Errorf("Test %d: Expected %s to be invalid", i+1, testCase.value)
+		}
+	}
+}
+
+// Tests revoking browser tokens by user and by ID.
+func TestBrowserTokenRevocations(t *testing.T) {
+	now := UTCNow()
+	r := newBrowserTokenRevocations()
+	r.Users["alice"] = now
+	r.Tokens["token1"] = now
+	r.Users["bob"] = now.Add(-maxBrowserTokenExpiry - time.Minute)
+	r.Tokens["token2"] = now.Add(-maxBrowserTokenExpiry - time.Minute)
+
+	testCases := []struct {
+		user, id, parent string
+		issuedAt         time.Time
+		revoked          bool
+	}{
+		{"alice", "", "", now.Add(-time.Hour), true},
+		{"alice", "", "", now, true},
+		{"alice", "", "", now.Add(time.Second), false},
+		{"carol", "token1", "", now.Add(-time.Hour), true},
+		{"carol", "token3", "", now.Add(-time.Hour), false},
+		{"carol", "", "", now.Add(-time.Hour), false},
+		// Access tokens are revoked with their refresh token.
+		{"carol", "token4", "token1", now.Add(-time.Hour), true},
+		{"carol", "token4", "token3", now.Add(-time.Hour), false},
+	}
+	for i, testCase := range testCases {
+		if revoked := r.isRevoked(testCase.user, testCase.id, testCase.parent, testCase.issuedAt.Unix()); revoked != testCase.revoked {
+			t.Errorf("Test %d: Expected revoked %v, got %v", i+1, testCase.revoked, revoked)
+		}
+	}
+
+	r.prune(now)
+	if len(r.Users) != 1 || len(r.Tokens) != 1 {
+		t.Errorf("Expected revocations of expired tokens to be pruned, got %+v", r)
+	}
+}
+
+// Wrapper for calling browser token tests for both XL multiple disks and single node setup.
+func TestBrowserTokens(t *testing.T) {
+	initNSLock(false)
+	ExecObjectLayerTest(t, testBrowserTokens)
+}
+
+// Tests issuing browser tokens restricted to managed policies, and
+// revoking them.
+func testBrowserTokens(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	globalBrowserTokenRevocations = newBrowserTokenRevocationCache()
+	defer func() { globalBrowserTokenRevocations = newBrowserTokenRevocationCache() }()
+
+	grants := []sessionGrant{{Policy: "readonly", Bucket: "reports-*"}}
+	invalidRequests := []browserTokenRequest{
+		{Grants: grants},
+		{User: "dashboard"},
+		{User: "dashboard", Grants: []sessionGrant{{Policy: "readonly"}}},
+		{User: "dashboard", Grants: grants, Duration: maxBrowserTokenExpiry + time.Hour},
+	}
+	for i, req := range invalidRequests {
+		if _, err := issueBrowserToken(obj, req); err != errInvalidBrowserTokenRequest {
+			t.Errorf("%s: Test %d: Expected request to be invalid, got %v", instanceType, i+1, err)
+		}
+	}
+	if _, err := issueBrowserToken(obj, browserTokenRequest{User: "dashboard", Grants: []sessionGrant{{Policy: "missing", Bucket: "*"}}}); err != errManagedPolicyNotFound {
+		t.Errorf("%s: Expected missing policy to be rejected, got %v", instanceType, err)
+	}
+
+	token, err := issueBrowserToken(obj, browserTokenRequest{User: "dashboard", Grants: grants, Duration: time.Hour})
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	claims, errCode := parseSessionToken(token.Token)
+	if errCode != ErrNone || claims.User != "dashboard" || len(claims.Grants) != 1 {
+		t.Fatalf("%s: Unexpected token claims %+v, %v", instanceType, claims, errCode)
+	}
+	refreshClaims, err := parseBrowserRefreshToken(obj, token.RefreshToken)
+	if err != nil || refreshClaims.Id != token.ID || refreshClaims.ExpiresAt != token.Expiry.Unix() {
+		t.Fatalf("%s: Unexpected refresh token claims %+v, %v", instanceType, refreshClaims, err)
+	}
+
+	if err = revokeBrowserTokens(obj, "", ""); err != errInvalidBrowserTokenRevocation {
+		t.Errorf("%s: Expected revocation to be invalid, got %v", instanceType, err)
+	}
+	if err = revokeBrowserTokens(obj, "", token.ID); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = parseBrowserRefreshToken(obj, token.RefreshToken); err != errInvalidRefreshToken {
+		t.Errorf("%s: Expected revoked refresh token to be rejected, got %v", instanceType, err)
+	}
+	if claims.Parent != token.ID || !globalBrowserTokenRevocations.isRevoked(obj, claims.User, claims.Id, claims.Parent, claims.IssuedAt) {
+		t.Errorf("%s: Expected access token of revoked refresh token to be rejected, got %+v", instanceType, claims)
+	}
+
+	// Revocations are loaded by other servers.
+	globalBrowserTokenRevocations = newBrowserTokenRevocationCache()
+	other, err := issueBrowserToken(obj, browserTokenRequest{User: "dashboard", Grants: grants})
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !globalBrowserTokenRevocations.isRevoked(obj, "dashboard", token.ID, "", other.Expiry.Unix()) {
+		t.Errorf("%s: Expected saved revocation to be loaded", instanceType)
+	}
+	if _, err = parseBrowserRefreshToken(obj, other.RefreshToken); err != nil {
+		t.Errorf("%s: Expected other refresh token to be valid, got %v", instanceType, err)
+	}
+	if err = revokeBrowserTokens(obj, "dashboard", ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = parseBrowserRefreshToken(obj, other.RefreshToken); err != errInvalidRefreshToken {
+		t.Errorf("%s: Expected refresh token of revoked user to be rejected, got %v", instanceType, err)
+	}
+}
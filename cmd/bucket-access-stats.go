@@ -0,0 +1,307 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	router "github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+const (
+	// Interval between persisting the access statistics of this server.
+	accessStatsPersistInterval = 5 * time.Minute
+
+	// Access statistics of every server are persisted below this
+	// prefix of the meta bucket, in a file named after the server.
+	accessStatsPrefix = "config/access-stats"
+
+	// Current version of the persisted access statistics.
+	accessStatsVersion = "1"
+
+	// Maximum number of buckets tracked, requests to further buckets
+	// are not counted until buckets which do not exist are dropped.
+	maxAccessStatsBuckets = 10000
+)
+
+// bucketAccessStats - requests served for a bucket and the bytes they
+// transferred. HEAD requests are counted as GET, POST requests as PUT.
+type bucketAccessStats struct {
+	Bucket        string    `json:"bucket,omitempty"`
+	GET           uint64    `json:"GET"`
+	PUT           uint64    `json:"PUT"`
+	DELETE        uint64    `json:"DELETE"`
+	Errors        uint64    `json:"errors"`
+	BytesReceived uint64    `json:"bytesReceived"`
+	BytesSent     uint64    `json:"bytesSent"`
+	LastAccess    time.Time `json:"lastAccess"`
+}
+
+// add - adds the requests and bytes of other.
+func (s *bucketAccessStats) add(other bucketAccessStats) {
+	s.GET += other.GET
+	s.PUT += other.PUT
+	s.DELETE += other.DELETE
+	s.Errors += other.Errors
+	s.BytesReceived += other.BytesReceived
+	s.BytesSent += other.BytesSent
+	if other.LastAccess.After(s.LastAccess) {
+		s.LastAccess = other.LastAccess
+	}
+}
+
+// accessStatsFile - access statistics persisted by a server.
+type accessStatsFile struct {
+	Version string                       `json:"version"`
+	Buckets map[string]bucketAccessStats `json:"buckets"`
+}
+
+// accessStats - requests served by this server per bucket since the
+// statistics were first persisted.
+type accessStats struct {
+	sync.Mutex
+	buckets map[string]*bucketAccessStats
+}
+
+func newAccessStats() *accessStats {
+	return &accessStats{buckets: make(map[string]*bucketAccessStats)}
+}
+
+// Global access statistics, only initialized by the server.
+var globalAccessStats *accessStats
+
+// Record - counts a request for bucket with its status code and the
+// bytes received and sent.
+func (a *accessStats) Record(bucket, method string, statusCode int, received, sent int64) {
+	a.Lock()
+	defer a.Unlock()
+
+	stats, ok := a.buckets[bucket]
+	if !ok {
+		if len(a.buckets) >= maxAccessStatsBuckets {
+			return
+		}
+		stats = &bucketAccessStats{}
+		a.buckets[bucket] = stats
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		stats.GET++
+	case http.MethodPut, http.MethodPost:
+		stats.PUT++
+	case http.MethodDelete:
+		stats.DELETE++
+	}
+	if statusCode >= http.StatusBadRequest {
+		stats.Errors++
+	}
+	stats.BytesReceived += uint64(received)
+	stats.BytesSent += uint64(sent)
+	stats.LastAccess = UTCNow()
+}
+
+// Remove - drops the statistics of a bucket, used when the bucket is
+// deleted.
+func (a *accessStats) Remove(bucket string) {
+	a.Lock()
+	defer a.Unlock()
+
+	delete(a.buckets, bucket)
+}
+
+// Get - returns the statistics of all buckets, or only of bucket if
+// it is not empty.
+func (a *accessStats) Get(bucket string) map[string]bucketAccessStats {
+	a.Lock()
+	defer a.Unlock()
+
+	buckets := make(map[string]bucketAccessStats)
+	for name, stats := range a.buckets {
+		if bucket == "" || name == bucket {
+			buckets[name] = *stats
+		}
+	}
+	return buckets
+}
+
+// getAccessStatsPath - returns the path of the access statistics of
+// this server in the meta bucket.
+func getAccessStatsPath() string {
+	server := strings.Replace(GetLocalPeer(globalEndpoints), ":", "_", -1)
+	return path.Join(accessStatsPrefix, server+".json")
+}
+
+// Load - loads the persisted statistics of this server, counted on
+// top of the requests served since it started.
+func (a *accessStats) Load(objAPI ObjectLayer) error {
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, getAccessStatsPath(), 0, -1, &buffer, "")
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil
+		}
+		return err
+	}
+
+	file := accessStatsFile{}
+	if err = json.Unmarshal(buffer.Bytes(), &file); err != nil {
+		return errors.Trace(err)
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	for bucket, stats := range file.Buckets {
+		if _, ok := a.buckets[bucket]; !ok {
+			a.buckets[bucket] = &bucketAccessStats{}
+		}
+		a.buckets[bucket].add(stats)
+	}
+	return nil
+}
+
+// Persist - drops the statistics of buckets which do not exist, deleted
+// through other servers or never created, and persists the rest.
+func (a *accessStats) Persist(objAPI ObjectLayer) error {
+	bucketsInfo, err := objAPI.ListBuckets()
+	if err != nil {
+		return err
+	}
+	buckets := make(map[string]bool, len(bucketsInfo))
+	for _, bucketInfo := range bucketsInfo {
+		buckets[bucketInfo.Name] = true
+	}
+
+	a.Lock()
+	for bucket := range a.buckets {
+		if !buckets[bucket] {
+			delete(a.buckets, bucket)
+		}
+	}
+	a.Unlock()
+
+	buf, err := json.Marshal(accessStatsFile{
+		Version: accessStatsVersion,
+		Buckets: a.Get(""),
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	hashReader, err := hash.NewReader(bytes.NewReader(buf), int64(len(buf)), "", getSHA256Hash(buf))
+	if err != nil {
+		return err
+	}
+	_, err = objAPI.PutObject(minioMetaBucket, getAccessStatsPath(), hashReader, nil)
+	return err
+}
+
+// Start a routine persisting the access statistics periodically.
+func startAccessStatsPersister(a *accessStats, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-globalServiceDoneCh:
+				return
+			case <-ticker.C:
+				if objAPI := newObjectLayerFn(); objAPI != nil {
+					errorIf(a.Persist(objAPI), "Unable to persist access statistics.")
+				}
+			}
+		}
+	}()
+}
+
+// mergeAccessStats - sums the statistics of all servers, ordered by
+// bucket.
+func mergeAccessStats(serverStats []map[string]bucketAccessStats) []bucketAccessStats {
+	merged := make(map[string]*bucketAccessStats)
+	for _, buckets := range serverStats {
+		for bucket, stats := range buckets {
+			if _, ok := merged[bucket]; !ok {
+				merged[bucket] = &bucketAccessStats{Bucket: bucket}
+			}
+			merged[bucket].add(stats)
+		}
+	}
+	list := make([]bucketAccessStats, 0, len(merged))
+	for _, stats := range merged {
+		list = append(list, *stats)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Bucket < list[j].Bucket
+	})
+	return list
+}
+
+// accessStatsReport - access statistics of all servers, servers which
+// failed to report are listed and not counted.
+type accessStatsReport struct {
+	Buckets       []bucketAccessStats `json:"buckets"`
+	FailedServers []string            `json:"failedServers,omitempty"`
+}
+
+// getAccessStatsReport - collects the statistics of all buckets, or
+// only of bucket if it is not empty, from all peers.
+func getAccessStatsReport(peers adminPeers, bucket string) accessStatsReport {
+	serverStats, errs := getPeerAccessStats(peers, bucket)
+	report := accessStatsReport{}
+	for i, err := range errs {
+		if err != nil {
+			errorIf(err, "Unable to get access statistics of %s", peers[i].addr)
+			report.FailedServers = append(report.FailedServers, peers[i].addr)
+		}
+	}
+	report.Buckets = mergeAccessStats(serverStats)
+	return report
+}
+
+// accessStatsAPI - counts requests served by f in the access statistics
+// of their bucket.
+func accessStatsAPI(f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bucket := router.Vars(r)["bucket"]
+		if globalAccessStats == nil || bucket == "" || !IsValidBucketName(bucket) {
+			f(w, r)
+			return
+		}
+
+		var body *countingReadCloser
+		if r.Body != nil {
+			body = &countingReadCloser{ReadCloser: r.Body}
+			r.Body = body
+		}
+		ww := &countingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		f(ww, r)
+
+		var received int64
+		if body != nil {
+			received = body.n
+		}
+		globalAccessStats.Record(bucket, r.Method, ww.statusCode, received, ww.n)
+	}
+}
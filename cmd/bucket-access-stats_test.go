@@ -0,0 +1,153 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	router "github.com/gorilla/mux"
+)
+
+// Tests counting requests per method and status in the statistics of
+// their bucket.
+func TestAccessStatsRecord(t *testing.T) {
+	a := newAccessStats()
+	a.Record("bucket", http.MethodGet, http.StatusOK, 0, 10)
+	a.Record("bucket", http.MethodHead, http.StatusNotFound, 0, 0)
+	a.Record("bucket", http.MethodPut, http.StatusOK, 20, 0)
+	a.Record("bucket", http.MethodPost, http.StatusOK, 5, 3)
+	a.Record("bucket", http.MethodDelete, http.StatusNoContent, 0, 0)
+	a.Record("other", http.MethodGet, http.StatusForbidden, 0, 1)
+
+	stats := a.Get("bucket")["bucket"]
+	if stats.GET != 2 || stats.PUT != 2 || stats.DELETE != 1 || stats.Errors != 1 ||
+		stats.BytesReceived != 25 || stats.BytesSent != 13 || stats.LastAccess.IsZero() {
+		t.Fatalf("Unexpected statistics %+v", stats)
+	}
+	if all := a.Get(""); len(all) != 2 || all["other"].Errors != 1 {
+		t.Fatalf("Unexpected statistics of all buckets %+v", all)
+	}
+
+	a.Remove("bucket")
+	if _, ok := a.Get("")["bucket"]; ok {
+		t.Fatal("Expected no statistics of removed bucket")
+	}
+}
+
+// Tests that the statistics of all servers are summed per bucket.
+func TestMergeAccessStats(t *testing.T) {
+	now := UTCNow()
+	merged := mergeAccessStats([]map[string]bucketAccessStats{
+		{
+			"b": {GET: 1, BytesSent: 10, LastAccess: now.Add(-time.Minute)},
+			"a": {PUT: 2, BytesReceived: 20, LastAccess: now},
+		},
+		nil,
+		{
+			"b": {GET: 3, DELETE: 1, BytesSent: 5, LastAccess: now},
+		},
+	})
+
+	expected := []bucketAccessStats{
+		{Bucket: "a", PUT: 2, BytesReceived: 20, LastAccess: now},
+		{Bucket: "b", GET: 4, DELETE: 1, BytesSent: 15, LastAccess: now},
+	}
+	if len(merged) != len(expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, merged)
+	}
+	for i := range expected {
+		if merged[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], merged[i])
+		}
+	}
+}
+
+// Tests that requests served through accessStatsAPI are counted with
+// the bytes of their body and response.
+func TestAccessStatsAPI(t *testing.T) {
+	defer func(a *accessStats) { globalAccessStats = a }(globalAccessStats)
+	globalAccessStats = newAccessStats()
+
+	mux := router.NewRouter()
+	mux.Methods(http.MethodPut).Path("/{bucket}/{object:.+}").HandlerFunc(
+		accessStatsAPI(func(w http.ResponseWriter, r *http.Request) {
+			ioutil.ReadAll(r.Body)
+			w.Write([]byte("ok"))
+		}))
+	mux.Methods(http.MethodGet).Path("/{bucket}").HandlerFunc(
+		accessStatsAPI(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPut, "/bucket/object", strings.NewReader("hello")),
+		httptest.NewRequest(http.MethodGet, "/bucket", nil),
+		httptest.NewRequest(http.MethodGet, "/b", nil),
+	} {
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	all := globalAccessStats.Get("")
+	if len(all) != 1 {
+		t.Fatalf("Expected only valid buckets to be counted, got %+v", all)
+	}
+	stats := all["bucket"]
+	if stats.GET != 1 || stats.PUT != 1 || stats.Errors != 1 || stats.BytesReceived != 5 || stats.BytesSent != 2 {
+		t.Fatalf("Unexpected statistics %+v", stats)
+	}
+}
+
+// Wrapper for calling access statistics persistence tests for both XL
+// multiple disks and single node setup.
+func TestAccessStatsPersist(t *testing.T) {
+	ExecObjectLayerTest(t, testAccessStatsPersist)
+}
+
+// Tests that persisted statistics are loaded, and that the statistics
+// of buckets which do not exist are dropped.
+func testAccessStatsPersist(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	a := newAccessStats()
+	a.Record(bucket, http.MethodGet, http.StatusOK, 0, 10)
+	a.Record("unknown", http.MethodGet, http.StatusNotFound, 0, 0)
+	if err := a.Persist(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, ok := a.Get("")["unknown"]; ok {
+		t.Fatalf("%s: Expected statistics of unknown bucket to be dropped", instanceType)
+	}
+
+	loaded := newAccessStats()
+	loaded.Record(bucket, http.MethodPut, http.StatusOK, 5, 0)
+	if err := loaded.Load(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	all := loaded.Get("")
+	if len(all) != 1 || all[bucket].GET != 1 || all[bucket].PUT != 1 ||
+		all[bucket].BytesSent != 10 || all[bucket].BytesReceived != 5 {
+		t.Fatalf("%s: Unexpected loaded statistics %+v", instanceType, all)
+	}
+}
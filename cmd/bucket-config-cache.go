@@ -0,0 +1,198 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"sync"
+
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+// Config files of buckets cached in memory by every server, peers
+// reload them whenever they are changed.
+var cachedBucketConfigFiles = []string{
+	bucketReplicationConfig,
+	bucketTieringConfig,
+	bucketObjectLockConfig,
+	bucketWebsiteConfig,
+	bucketCORSConfig,
+	bucketMetadataDefaultsConfig,
+}
+
+// Returns the path of a config file of a bucket.
+func getBucketConfigPath(bucket, configFile string) string {
+	return path.Join(bucketConfigPrefix, bucket, configFile)
+}
+
+// Loads a config file of a bucket into cfg, returns false if the
+// bucket has no such config.
+func loadBucketConfig(bucket, configFile string, cfg interface{}, objAPI ObjectLayer) (ok bool, err error) {
+	var buffer bytes.Buffer
+	err = objAPI.GetObject(minioMetaBucket, getBucketConfigPath(bucket, configFile), 0, -1, &buffer, "")
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err = json.Unmarshal(buffer.Bytes(), cfg); err != nil {
+		return false, errors.Trace(err)
+	}
+	return true, nil
+}
+
+// Persists a config file of a bucket to object layer.
+func saveBucketConfig(bucket, configFile string, cfg interface{}, objAPI ObjectLayer) error {
+	buf, err := json.Marshal(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	hashReader, err := hash.NewReader(bytes.NewReader(buf), int64(len(buf)), "", getSHA256Hash(buf))
+	if err != nil {
+		return err
+	}
+	_, err = objAPI.PutObject(minioMetaBucket, getBucketConfigPath(bucket, configFile), hashReader, nil)
+	return err
+}
+
+// Removes a config file of a bucket.
+func removeBucketConfig(bucket, configFile string, objAPI ObjectLayer) error {
+	err := objAPI.DeleteObject(minioMetaBucket, getBucketConfigPath(bucket, configFile))
+	if err != nil && !isErrObjectNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// bucketConfigCache - configs saved as the same config file of all
+// buckets having one. Configs are decoded into the pointers returned
+// by newConfig, typed caches convert them.
+type bucketConfigCache struct {
+	sync.RWMutex
+	configFile string
+	newConfig  func() interface{}
+	configs    map[string]interface{}
+}
+
+func newBucketConfigCache(configFile string, newConfig func() interface{}) *bucketConfigCache {
+	return &bucketConfigCache{
+		configFile: configFile,
+		newConfig:  newConfig,
+		configs:    make(map[string]interface{}),
+	}
+}
+
+// Init - loads the configs of all buckets.
+func (c *bucketConfigCache) Init(objAPI ObjectLayer) error {
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		if err = c.Refresh(objAPI, bucket.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Refresh - loads the config of a bucket after it was changed by any
+// server.
+func (c *bucketConfigCache) Refresh(objAPI ObjectLayer, bucket string) error {
+	cfg := c.newConfig()
+	ok, err := loadBucketConfig(bucket, c.configFile, cfg, objAPI)
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if !ok {
+		delete(c.configs, bucket)
+		return nil
+	}
+	c.configs[bucket] = cfg
+	return nil
+}
+
+// Remove - drops the config of a bucket.
+func (c *bucketConfigCache) Remove(bucket string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.configs, bucket)
+}
+
+// Sets the config of a bucket on this server, cfg must be of the type
+// returned by newConfig.
+func (c *bucketConfigCache) set(bucket string, cfg interface{}) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.configs[bucket] = cfg
+}
+
+// Returns the config of a bucket, false if the bucket has none.
+func (c *bucketConfigCache) get(bucket string) (interface{}, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	cfg, ok := c.configs[bucket]
+	return cfg, ok
+}
+
+// Returns a copy of the configs of all buckets.
+func (c *bucketConfigCache) list() map[string]interface{} {
+	c.RLock()
+	defer c.RUnlock()
+
+	configs := make(map[string]interface{}, len(c.configs))
+	for bucket, cfg := range c.configs {
+		configs[bucket] = cfg
+	}
+	return configs
+}
+
+// bucketConfigs - in-memory configs of buckets reloaded by peers.
+type bucketConfigs interface {
+	Refresh(objAPI ObjectLayer, bucket string) error
+	Remove(bucket string)
+}
+
+// getBucketConfigs - returns the in-memory configs saved as configFile,
+// nil if they are not initialized as on gateways.
+func getBucketConfigs(configFile string) bucketConfigs {
+	switch {
+	case configFile == bucketReplicationConfig && globalBucketReplication != nil:
+		return globalBucketReplication
+	case configFile == bucketTieringConfig && globalBucketTiering != nil:
+		return globalBucketTiering
+	case configFile == bucketObjectLockConfig && globalBucketObjectLock != nil:
+		return globalBucketObjectLock
+	case configFile == bucketWebsiteConfig && globalBucketWebsite != nil:
+		return globalBucketWebsite
+	case configFile == bucketCORSConfig && globalBucketCORS != nil:
+		return globalBucketCORS
+	case configFile == bucketMetadataDefaultsConfig && globalBucketMetadataDefaults != nil:
+		return globalBucketMetadataDefaults
+	}
+	return nil
+}
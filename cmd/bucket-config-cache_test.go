@@ -0,0 +1,70 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"testing"
+)
+
+// Wrapper for calling bucket config cache tests for both XL multiple
+// disks and single node setup.
+func TestBucketConfigCache(t *testing.T) {
+	ExecObjectLayerTest(t, testBucketConfigCache)
+}
+
+// Tests loading bucket configs and reloading them as peers do.
+func testBucketConfigCache(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	defer func() { globalBucketWebsite = nil }()
+
+	bucket := "bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	cfg := websiteConfig{IndexDocument: "index.html"}
+	if err := saveWebsiteConfig(bucket, cfg, obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	globalBucketWebsite = newBucketWebsite()
+	if err := globalBucketWebsite.Init(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	cfg.Version = bucketWebsiteVersion
+	if loaded, ok := globalBucketWebsite.Get(bucket); !ok || loaded != cfg {
+		t.Fatalf("%s: Expected config %+v, got %+v", instanceType, cfg, loaded)
+	}
+
+	// Peers drop the config once it is removed.
+	if err := removeBucketConfig(bucket, bucketWebsiteConfig, obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	bms := &localBucketMetaState{ObjectAPI: func() ObjectLayer { return obj }}
+	if err := bms.UpdateBucketConfig(&SetBucketConfigPeerArgs{Bucket: bucket, ConfigFile: bucketWebsiteConfig}); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, ok := globalBucketWebsite.Get(bucket); ok {
+		t.Fatalf("%s: Expected removed config to be dropped", instanceType)
+	}
+
+	// Configs not initialized on this server are ignored.
+	if err := bms.UpdateBucketConfig(&SetBucketConfigPeerArgs{Bucket: bucket, ConfigFile: bucketCORSConfig}); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if configs := getBucketConfigs("unknown.json"); configs != nil {
+		t.Fatalf("%s: Expected no configs of an unknown config file", instanceType)
+	}
+}
@@ -0,0 +1,151 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Maximum size of CORS configurations sent by clients.
+const maxCORSConfigSize = 64 * 1024
+
+// PutBucketCorsHandler - PUT /bucket?cors
+// ----------
+// Sets the CORS rules of the bucket, cross origin requests of the
+// bucket are only allowed by these rules from then on.
+func (api objectAPIHandlers) PutBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := mux.Vars(r)["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+	if globalBucketCORS == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:PutBucketCORS", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	var corsConfiguration CORSConfiguration
+	if err := xmlDecoder(r.Body, &corsConfiguration, maxCORSConfigSize); err != nil {
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+	cfg, err := parseCORSConfiguration(corsConfiguration)
+	if err != nil {
+		writeErrorResponse(w, ErrInvalidCORSConfiguration, r.URL)
+		return
+	}
+
+	if err = saveCORSConfig(bucket, cfg, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	globalBucketCORS.Set(bucket, cfg)
+
+	// Notify all peers (including self) to reload the config.
+	S3PeersUpdateBucketConfig(bucket, bucketCORSConfig)
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetBucketCorsHandler - GET /bucket?cors
+// ----------
+// Returns the CORS configuration of the bucket.
+func (api objectAPIHandlers) GetBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := mux.Vars(r)["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+	if globalBucketCORS == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetBucketCORS", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	cfg, ok := globalBucketCORS.Get(bucket)
+	if !ok {
+		writeErrorResponse(w, ErrNoSuchCORSConfiguration, r.URL)
+		return
+	}
+	writeSuccessResponseXML(w, encodeResponse(cfg.toCORSConfiguration()))
+}
+
+// DeleteBucketCorsHandler - DELETE /bucket?cors
+// ----------
+// Removes the CORS configuration of the bucket, cross origin requests
+// of the bucket are allowed from any origin again.
+func (api objectAPIHandlers) DeleteBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := mux.Vars(r)["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+	if globalBucketCORS == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	// Deleting the CORS configuration needs the same permission as
+	// setting it, like S3.
+	if s3Error := checkRequestAuthType(r, bucket, "s3:PutBucketCORS", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	if err := removeBucketConfig(bucket, bucketCORSConfig, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	globalBucketCORS.Remove(bucket)
+
+	// Notify all peers (including self) to reload the config.
+	S3PeersUpdateBucketConfig(bucket, bucketCORSConfig)
+
+	writeSuccessNoContent(w)
+}
@@ -0,0 +1,288 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/minio/minio/pkg/wildcard"
+)
+
+const (
+	// CORS config of a bucket, persisted under the bucket config prefix.
+	bucketCORSConfig = "cors.json"
+
+	// Current version of CORS configs.
+	bucketCORSVersion = "1"
+
+	// Maximum number of rules of a CORS configuration.
+	maxCORSRules = 100
+)
+
+// CORSConfiguration - CORS configuration of a bucket, set and returned
+// by the cors subresource.
+type CORSConfiguration struct {
+	XMLName   xml.Name   `xml:"CORSConfiguration"`
+	CORSRules []CORSRule `xml:"CORSRule"`
+}
+
+// CORSRule - origins and methods allowed to make cross origin requests
+// of a bucket, with the headers they may send and read.
+type CORSRule struct {
+	ID             string   `xml:"ID,omitempty" json:"id,omitempty"`
+	AllowedOrigins []string `xml:"AllowedOrigin" json:"allowedOrigins"`
+	AllowedMethods []string `xml:"AllowedMethod" json:"allowedMethods"`
+	AllowedHeaders []string `xml:"AllowedHeader,omitempty" json:"allowedHeaders,omitempty"`
+	ExposeHeaders  []string `xml:"ExposeHeader,omitempty" json:"exposeHeaders,omitempty"`
+	MaxAgeSeconds  int      `xml:"MaxAgeSeconds,omitempty" json:"maxAgeSeconds,omitempty"`
+}
+
+// Methods CORS rules may allow.
+var corsAllowedMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPut:    true,
+	http.MethodHead:   true,
+	http.MethodPost:   true,
+	http.MethodDelete: true,
+}
+
+// Validate - checks the origins, methods and headers of the rule.
+func (rule CORSRule) Validate() error {
+	if len(rule.ID) > 255 {
+		return fmt.Errorf("Rule ID is longer than 255 characters")
+	}
+	if len(rule.AllowedOrigins) == 0 || len(rule.AllowedMethods) == 0 {
+		return fmt.Errorf("Rule needs at least one allowed origin and method")
+	}
+	for _, method := range rule.AllowedMethods {
+		if !corsAllowedMethods[method] {
+			return fmt.Errorf("Unsupported method %q", method)
+		}
+	}
+	for _, patterns := range [][]string{rule.AllowedOrigins, rule.AllowedHeaders} {
+		for _, pattern := range patterns {
+			if pattern == "" || strings.Count(pattern, "*") > 1 {
+				return fmt.Errorf("Origins and headers must be non-empty with at most one wildcard")
+			}
+		}
+	}
+	if rule.MaxAgeSeconds < 0 {
+		return fmt.Errorf("Max age must not be negative")
+	}
+	return nil
+}
+
+// matchOrigin - returns the allowed origin of the rule matching origin.
+func (rule CORSRule) matchOrigin(origin string) (string, bool) {
+	for _, allowedOrigin := range rule.AllowedOrigins {
+		if wildcard.MatchSimple(allowedOrigin, origin) {
+			return allowedOrigin, true
+		}
+	}
+	return "", false
+}
+
+// allowsMethod - returns true if the rule allows requests with method.
+func (rule CORSRule) allowsMethod(method string) bool {
+	for _, allowedMethod := range rule.AllowedMethods {
+		if allowedMethod == method {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeader - returns true if the rule allows requests to send the
+// header, header names are case insensitive.
+func (rule CORSRule) allowsHeader(header string) bool {
+	for _, allowedHeader := range rule.AllowedHeaders {
+		if wildcard.MatchSimple(strings.ToLower(allowedHeader), strings.ToLower(header)) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsConfig - CORS config of a bucket, only buckets with a CORS
+// configuration have one.
+type corsConfig struct {
+	Version string     `json:"version"`
+	Rules   []CORSRule `json:"rules"`
+}
+
+// Validate - checks the number of rules and each rule of the config.
+func (c corsConfig) Validate() error {
+	if len(c.Rules) == 0 || len(c.Rules) > maxCORSRules {
+		return fmt.Errorf("CORS configuration needs between 1 and %d rules", maxCORSRules)
+	}
+	for _, rule := range c.Rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseCORSConfiguration - converts a CORS configuration set by a
+// client into the config of a bucket.
+func parseCORSConfiguration(corsConfiguration CORSConfiguration) (cfg corsConfig, err error) {
+	cfg.Rules = corsConfiguration.CORSRules
+	return cfg, cfg.Validate()
+}
+
+// toCORSConfiguration - converts the config of a bucket into the CORS
+// configuration returned to clients.
+func (c corsConfig) toCORSConfiguration() CORSConfiguration {
+	return CORSConfiguration{CORSRules: c.Rules}
+}
+
+// match - returns the first rule allowing requests from origin with
+// method, and the allowed origin of the rule.
+func (c corsConfig) match(origin, method string) (CORSRule, string, bool) {
+	for _, rule := range c.Rules {
+		allowedOrigin, ok := rule.matchOrigin(origin)
+		if ok && rule.allowsMethod(method) {
+			return rule, allowedOrigin, true
+		}
+	}
+	return CORSRule{}, "", false
+}
+
+// Persists the CORS config of a bucket to object layer.
+func saveCORSConfig(bucket string, cfg corsConfig, objAPI ObjectLayer) error {
+	cfg.Version = bucketCORSVersion
+	return saveBucketConfig(bucket, bucketCORSConfig, cfg, objAPI)
+}
+
+// bucketCORS - CORS configs of all buckets with a CORS configuration.
+type bucketCORS struct {
+	*bucketConfigCache
+}
+
+func newBucketCORS() *bucketCORS {
+	return &bucketCORS{newBucketConfigCache(bucketCORSConfig, func() interface{} { return &corsConfig{} })}
+}
+
+// Global CORS configs of buckets, only initialized by the server,
+// gateways keep allowing cross origin requests from any origin.
+var globalBucketCORS *bucketCORS
+
+// Set - sets the CORS config of a bucket on this server.
+func (b *bucketCORS) Set(bucket string, cfg corsConfig) {
+	b.set(bucket, &cfg)
+}
+
+// Get - returns the CORS config of a bucket, false if the bucket has
+// no CORS configuration.
+func (b *bucketCORS) Get(bucket string) (corsConfig, bool) {
+	if b == nil {
+		return corsConfig{}, false
+	}
+	cfg, ok := b.get(bucket)
+	if !ok {
+		return corsConfig{}, false
+	}
+	return *cfg.(*corsConfig), true
+}
+
+// getCORSRequestBucket - returns the bucket of a request, before the
+// URL prefix is stripped and for virtual host style requests as well.
+func getCORSRequestBucket(r *http.Request) string {
+	urlPath := r.URL.Path
+	if globalURLPrefix != "" && hasPrefix(urlPath, globalURLPrefix+slashSeparator) {
+		urlPath = strings.TrimPrefix(urlPath, globalURLPrefix)
+	}
+	resource, err := getResource(urlPath, r.Host, globalDomainNames)
+	if err != nil {
+		return ""
+	}
+	bucket, _ := urlPath2BucketObjectName(&url.URL{Path: resource})
+	return bucket
+}
+
+// setCORSAllowOrigin - sets the headers allowing the origin, matched by
+// the allowed origin of a rule, to read the response.
+func setCORSAllowOrigin(header http.Header, origin, allowedOrigin string) {
+	if allowedOrigin == "*" {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	header.Set("Access-Control-Allow-Credentials", "true")
+}
+
+// serveCORSPreflight - answers a preflight request according to the
+// CORS config of the bucket, requests not allowed by any rule are
+// rejected.
+func serveCORSPreflight(w http.ResponseWriter, r *http.Request, cfg corsConfig) {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+
+	origin := r.Header.Get("Origin")
+	rule, allowedOrigin, ok := cfg.match(origin, r.Header.Get("Access-Control-Request-Method"))
+	var requestHeaders []string
+	for _, requestHeader := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		requestHeader = strings.TrimSpace(requestHeader)
+		if requestHeader == "" {
+			continue
+		}
+		if ok && !rule.allowsHeader(requestHeader) {
+			ok = false
+		}
+		requestHeaders = append(requestHeaders, requestHeader)
+	}
+	if !ok {
+		writeErrorResponse(w, ErrCORSNotAllowed, r.URL)
+		return
+	}
+
+	setCORSAllowOrigin(header, origin, allowedOrigin)
+	header.Set("Access-Control-Allow-Methods", strings.Join(rule.AllowedMethods, ", "))
+	if len(requestHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(requestHeaders, ", "))
+	}
+	if rule.MaxAgeSeconds > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(rule.MaxAgeSeconds))
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// setCORSResponseHeaders - sets the CORS headers of the response to a
+// cross origin request allowed by the CORS config of the bucket.
+// Responses to requests not allowed by any rule have no CORS headers,
+// browsers do not let the origin read them.
+func setCORSResponseHeaders(w http.ResponseWriter, r *http.Request, cfg corsConfig) {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+
+	origin := r.Header.Get("Origin")
+	rule, allowedOrigin, ok := cfg.match(origin, r.Method)
+	if !ok {
+		return
+	}
+	setCORSAllowOrigin(header, origin, allowedOrigin)
+	if len(rule.ExposeHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(rule.ExposeHeaders, ", "))
+	}
+}
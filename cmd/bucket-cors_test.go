@@ -0,0 +1,223 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/minio/minio/pkg/auth"
+)
+
+// Tests validating CORS configurations of clients.
+func TestParseCORSConfiguration(t *testing.T) {
+	rule := func(origin, method, header string) string {
+		s := `<CORSRule><AllowedOrigin>` + origin + `</AllowedOrigin><AllowedMethod>` + method + `</AllowedMethod>`
+		if header != "" {
+			s += `<AllowedHeader>` + header + `</AllowedHeader>`
+		}
+		return s + `</CORSRule>`
+	}
+	testCases := []struct {
+		config  string
+		success bool
+	}{
+		{rule("*", "GET", ""), true},
+		{rule("https://*.example.com", "PUT", "x-amz-*") + rule("http://localhost:8080", "DELETE", "*"), true},
+		{"", false},
+		{rule("*", "PATCH", ""), false},
+		{rule("https://*.*.example.com", "GET", ""), false},
+		{rule("*", "GET", "x-*-*"), false},
+		{`<CORSRule><AllowedMethod>GET</AllowedMethod></CORSRule>`, false},
+		{`<CORSRule><AllowedOrigin>*</AllowedOrigin><AllowedMethod>GET</AllowedMethod><MaxAgeSeconds>-1</MaxAgeSeconds></CORSRule>`, false},
+	}
+	for i, testCase := range testCases {
+		var corsConfiguration CORSConfiguration
+		if err := xml.Unmarshal([]byte(`<CORSConfiguration>`+testCase.config+`</CORSConfiguration>`), &corsConfiguration); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if _, err := parseCORSConfiguration(corsConfiguration); testCase.success != (err == nil) {
+			t.Fatalf("Test %d: Expected success %v but got %v", i+1, testCase.success, err)
+		}
+	}
+}
+
+// Tests answering cross origin requests of buckets by their CORS rules.
+func TestCorsHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed: %v", err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	defer func() { globalBucketCORS = nil }()
+	globalBucketCORS = newBucketCORS()
+	globalBucketCORS.Set("bucket", corsConfig{Rules: []CORSRule{
+		{
+			AllowedOrigins: []string{"https://*.example.com"},
+			AllowedMethods: []string{http.MethodGet, http.MethodPut},
+			AllowedHeaders: []string{"x-amz-*", "Content-Type"},
+			ExposeHeaders:  []string{"ETag"},
+			MaxAgeSeconds:  600,
+		},
+		{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{http.MethodGet},
+		},
+	}})
+
+	handler := setCorsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		method         string
+		path           string
+		header         http.Header
+		statusCode     int
+		expectedHeader http.Header
+	}{
+		// Preflight requests allowed by the first rule.
+		{http.MethodOptions, "/bucket/object",
+			http.Header{"Origin": {"https://www.example.com"}, "Access-Control-Request-Method": {"PUT"}, "Access-Control-Request-Headers": {"X-Amz-Date, content-type"}},
+			http.StatusOK,
+			http.Header{
+				"Access-Control-Allow-Origin":      {"https://www.example.com"},
+				"Access-Control-Allow-Credentials": {"true"},
+				"Access-Control-Allow-Methods":     {"GET, PUT"},
+				"Access-Control-Allow-Headers":     {"X-Amz-Date, content-type"},
+				"Access-Control-Max-Age":           {"600"},
+			}},
+		// Preflight requests allowed by the wildcard rule.
+		{http.MethodOptions, "/bucket",
+			http.Header{"Origin": {"https://other.org"}, "Access-Control-Request-Method": {"GET"}},
+			http.StatusOK,
+			http.Header{"Access-Control-Allow-Origin": {"*"}, "Access-Control-Allow-Methods": {"GET"}}},
+		// Preflight requests not allowed by any rule.
+		{http.MethodOptions, "/bucket/object",
+			http.Header{"Origin": {"https://other.org"}, "Access-Control-Request-Method": {"PUT"}},
+			http.StatusForbidden, nil},
+		{http.MethodOptions, "/bucket/object",
+			http.Header{"Origin": {"https://www.example.com"}, "Access-Control-Request-Method": {"PUT"}, "Access-Control-Request-Headers": {"Authorization"}},
+			http.StatusForbidden, nil},
+		// Actual requests get the headers of the matching rule.
+		{http.MethodPut, "/bucket/object",
+			http.Header{"Origin": {"https://www.example.com"}},
+			http.StatusOK,
+			http.Header{"Access-Control-Allow-Origin": {"https://www.example.com"}, "Access-Control-Expose-Headers": {"ETag"}}},
+		{http.MethodDelete, "/bucket/object",
+			http.Header{"Origin": {"https://www.example.com"}},
+			http.StatusOK,
+			http.Header{"Access-Control-Allow-Origin": nil}},
+		// Buckets without CORS rules allow any origin.
+		{http.MethodOptions, "/other-bucket/object",
+			http.Header{"Origin": {"https://other.org"}, "Access-Control-Request-Method": {"DELETE"}},
+			http.StatusOK,
+			http.Header{"Access-Control-Allow-Origin": {"https://other.org"}}},
+	}
+
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, "http://localhost:9000"+testCase.path, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		req.Header = testCase.header
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != testCase.statusCode {
+			t.Fatalf("Test %d: Expected status %d, got %d", i+1, testCase.statusCode, rec.Code)
+		}
+		for name, value := range testCase.expectedHeader {
+			if !reflect.DeepEqual(rec.Header()[name], value) {
+				t.Fatalf("Test %d: Expected header %s to be %v, got %v", i+1, name, value, rec.Header()[name])
+			}
+		}
+	}
+}
+
+// Tests the CORS configuration APIs.
+func TestAPIBucketCorsHandlers(t *testing.T) {
+	defer func() { globalBucketCORS = nil }()
+	ExecObjectLayerAPITest(t, testAPIBucketCorsHandlers, []string{"BucketCors"})
+}
+
+func testAPIBucketCorsHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	globalBucketCORS = newBucketCORS()
+
+	doRequest := func(method, urlStr string, body []byte) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader(body), credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	expectStatus := func(rec *httptest.ResponseRecorder, status int, msg string) {
+		if rec.Code != status {
+			t.Fatalf("%s: %s: Expected the response status to be `%d`, but instead found `%d`: %s", instanceType, msg, status, rec.Code, rec.Body.String())
+		}
+	}
+
+	expectStatus(doRequest("GET", getBucketCorsURL("", bucketName), nil), http.StatusNotFound, "get config")
+	expectStatus(doRequest("PUT", getBucketCorsURL("", bucketName), []byte(`<CORSConfiguration></CORSConfiguration>`)),
+		http.StatusBadRequest, "empty config")
+
+	config := []byte(`<CORSConfiguration><CORSRule><ID>web</ID><AllowedOrigin>https://*.example.com</AllowedOrigin>` +
+		`<AllowedMethod>GET</AllowedMethod><AllowedMethod>PUT</AllowedMethod><AllowedHeader>*</AllowedHeader>` +
+		`<ExposeHeader>ETag</ExposeHeader><MaxAgeSeconds>3000</MaxAgeSeconds></CORSRule></CORSConfiguration>`)
+	expectStatus(doRequest("PUT", getBucketCorsURL("", bucketName), config), http.StatusOK, "put config")
+	if _, ok := globalBucketCORS.Get(bucketName); !ok {
+		t.Fatalf("%s: Expected the config to be set", instanceType)
+	}
+	rec := doRequest("GET", getBucketCorsURL("", bucketName), nil)
+	expectStatus(rec, http.StatusOK, "get config")
+	var corsConfiguration CORSConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &corsConfiguration); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	expected := []CORSRule{{
+		ID:             "web",
+		AllowedOrigins: []string{"https://*.example.com"},
+		AllowedMethods: []string{"GET", "PUT"},
+		AllowedHeaders: []string{"*"},
+		ExposeHeaders:  []string{"ETag"},
+		MaxAgeSeconds:  3000,
+	}}
+	if !reflect.DeepEqual(corsConfiguration.CORSRules, expected) {
+		t.Fatalf("%s: Expected %+v but got %+v", instanceType, expected, corsConfiguration.CORSRules)
+	}
+
+	// The config is loaded again by peers.
+	globalBucketCORS = newBucketCORS()
+	if err := globalBucketCORS.Init(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if cfg, ok := globalBucketCORS.Get(bucketName); !ok || !reflect.DeepEqual(cfg.Rules, expected) {
+		t.Fatalf("%s: Unexpected config %+v after reload", instanceType, cfg)
+	}
+
+	expectStatus(doRequest("DELETE", getBucketCorsURL("", bucketName), nil), http.StatusNoContent, "delete config")
+	expectStatus(doRequest("GET", getBucketCorsURL("", bucketName), nil), http.StatusNotFound, "get deleted config")
+}
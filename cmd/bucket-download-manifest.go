@@ -0,0 +1,142 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"archive/zip"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// Maximum number of keys in a single download manifest, same as
+	// the limit of a multi-object delete.
+	maxDownloadManifestKeys = maxObjectList
+
+	// Maximum size of the download manifest request XML.
+	maxDownloadManifestSize = 1024 * 1024
+
+	// Validity of presigned URLs when the request does not ask for one.
+	defaultDownloadManifestExpiry = 1 * time.Hour
+
+	// Longest validity of presigned URLs, as for AWS S3.
+	maxDownloadManifestExpiry = 7 * 24 * time.Hour
+)
+
+// Returns the validity of the presigned URLs of a download manifest,
+// the default validity is used when none is given.
+func getDownloadManifestExpiry(expires int64) (time.Duration, bool) {
+	if expires == 0 {
+		return defaultDownloadManifestExpiry, true
+	}
+	expiry := time.Duration(expires) * time.Second
+	if expires < 0 || expiry > maxDownloadManifestExpiry {
+		return 0, false
+	}
+	return expiry, true
+}
+
+// Returns true if the list of keys of a download manifest is non-empty,
+// not too long and contains valid object names only.
+func isDownloadManifestValid(keys []ObjectIdentifier) bool {
+	if len(keys) == 0 || len(keys) > maxDownloadManifestKeys {
+		return false
+	}
+	for _, key := range keys {
+		if !IsValidObjectName(key.ObjectName) {
+			return false
+		}
+	}
+	return true
+}
+
+// Looks up all objects of a download manifest in parallel, returns the
+// info of found objects and the error of others at the same index.
+func getDownloadManifestObjects(objectAPI ObjectLayer, bucket string, keys []ObjectIdentifier) ([]ObjectInfo, []error) {
+	var wg = &sync.WaitGroup{}
+	objInfos := make([]ObjectInfo, len(keys))
+	errs := make([]error, len(keys))
+	for index, key := range keys {
+		wg.Add(1)
+		go func(i int, object string) {
+			defer wg.Done()
+			objInfos[i], errs[i] = objectAPI.GetObjectInfo(bucket, object)
+		}(index, key.ObjectName)
+	}
+	wg.Wait()
+	return objInfos, errs
+}
+
+// Returns the URL of a download manifest archive of the keys, the
+// archive is served by GetBucketArchiveHandler.
+func getDownloadManifestArchiveURL(host, bucket string, keys []ObjectIdentifier, expiry time.Duration) string {
+	queryValues := url.Values{}
+	queryValues.Set("archive", "")
+	for _, key := range keys {
+		queryValues.Add("key", key.ObjectName)
+	}
+	return presignedURL(host, bucket, "", queryValues, int64(expiry/time.Second))
+}
+
+// Returns true if the object is encrypted with SSE-C, archives cannot
+// carry the customer keys needed to decrypt it.
+func isArchiveObjectSSEC(objectAPI ObjectLayer, objInfo ObjectInfo) bool {
+	return objectAPI.IsEncryptionSupported() && objInfo.IsEncrypted() && !isSSES3Encrypted(objInfo.UserDefined)
+}
+
+// Writes a zip archive of the objects to the writer, objects are
+// stored under their key. Objects encrypted with SSE-S3 are decrypted
+// as by GetObject. Objects missing or encrypted with SSE-C by now fail
+// the archive.
+func writeObjectsArchive(objectAPI ObjectLayer, bucket string, keys []string, writer io.Writer) error {
+	archive := zip.NewWriter(writer)
+	for _, key := range keys {
+		objInfo, err := objectAPI.GetObjectInfo(bucket, key)
+		if err != nil {
+			return err
+		}
+		if isArchiveObjectSSEC(objectAPI, objInfo) {
+			return errEncryptedObject
+		}
+		size, err := getWebObjectSize(objectAPI, objInfo)
+		if err != nil {
+			return err
+		}
+		header := &zip.FileHeader{
+			Name:               key,
+			Method:             zip.Deflate,
+			UncompressedSize64: uint64(size),
+		}
+		header.SetModTime(objInfo.ModTime)
+		fileWriter, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if err = getWebObject(objectAPI, bucket, objInfo, fileWriter); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// Returns the fully qualified location of a presigned URL, which is
+// issued without scheme.
+func getPresignedLocation(location string) string {
+	return getURLScheme(globalIsSSL) + "://" + location
+}
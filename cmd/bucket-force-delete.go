@@ -0,0 +1,211 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+)
+
+const (
+	// Status of deleting a bucket with its contents.
+	forceDeleteRunning  = "running"
+	forceDeleteFinished = "finished"
+	forceDeleteFailed   = "failed"
+)
+
+// forceDeleteStatus - progress of deleting a bucket with its contents.
+type forceDeleteStatus struct {
+	Bucket         string    `json:"bucket"`
+	Status         string    `json:"status"`
+	StartTime      time.Time `json:"startTime"`
+	EndTime        time.Time `json:"endTime"`
+	ObjectsDeleted int64     `json:"objectsDeleted"`
+	BytesDeleted   int64     `json:"bytesDeleted"`
+	UploadsAborted int64     `json:"uploadsAborted"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// bgEventInfo - client information reported in the events of objects
+// changed in the background on behalf of a request.
+type bgEventInfo struct {
+	ReqParams map[string]string
+	UserAgent string
+	Host      string
+	Port      string
+}
+
+// forceDeleteState - buckets being deleted with their contents by this
+// server, finished and failed deletions are kept until restarted.
+type forceDeleteState struct {
+	sync.Mutex
+	buckets map[string]*forceDeleteStatus
+}
+
+func newForceDeleteState() *forceDeleteState {
+	return &forceDeleteState{buckets: make(map[string]*forceDeleteStatus)}
+}
+
+// Global state of force deleted buckets.
+var globalForceDeleteState = newForceDeleteState()
+
+// Start - starts deleting bucket with its contents in the background
+// unless already running. Failed deletions are retried from where
+// they stopped since deleted objects are not listed again.
+func (s *forceDeleteState) Start(objAPI ObjectLayer, bucket string, eventInfo bgEventInfo) forceDeleteStatus {
+	s.Lock()
+	defer s.Unlock()
+
+	if status, ok := s.buckets[bucket]; ok && status.Status == forceDeleteRunning {
+		return *status
+	}
+	status := &forceDeleteStatus{
+		Bucket:    bucket,
+		Status:    forceDeleteRunning,
+		StartTime: UTCNow(),
+	}
+	s.buckets[bucket] = status
+	go s.run(objAPI, bucket, eventInfo)
+	return *status
+}
+
+// Get - returns the progress of deleting bucket, false if it was
+// never force deleted by this server.
+func (s *forceDeleteState) Get(bucket string) (forceDeleteStatus, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	status, ok := s.buckets[bucket]
+	if !ok {
+		return forceDeleteStatus{}, false
+	}
+	return *status, true
+}
+
+// update - applies fn to the progress of deleting bucket.
+func (s *forceDeleteState) update(bucket string, fn func(status *forceDeleteStatus)) {
+	s.Lock()
+	defer s.Unlock()
+
+	if status, ok := s.buckets[bucket]; ok {
+		fn(status)
+	}
+}
+
+func (s *forceDeleteState) run(objAPI ObjectLayer, bucket string, eventInfo bgEventInfo) {
+	err := forceDeleteBucket(objAPI, bucket, eventInfo, s.update)
+	errorIf(err, "Unable to force delete bucket %s.", bucket)
+	s.update(bucket, func(status *forceDeleteStatus) {
+		status.EndTime = UTCNow()
+		if err != nil {
+			status.Status = forceDeleteFailed
+			status.Error = errors.Cause(err).Error()
+			return
+		}
+		status.Status = forceDeleteFinished
+	})
+}
+
+// forceDeleteBucket - deletes all objects and their incomplete uploads,
+// then the bucket. Deletion stops when the server stops accepting
+// writes and is retried by starting it again.
+func forceDeleteBucket(objAPI ObjectLayer, bucket string, eventInfo bgEventInfo, update func(string, func(*forceDeleteStatus))) error {
+	marker := ""
+	for {
+		if err := checkServerWritable(); err != nil {
+			return err
+		}
+
+		result, err := objAPI.ListObjects(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			return err
+		}
+		for _, objInfo := range result.Objects {
+			// Locked objects cannot be deleted, the bucket is kept.
+			if err = checkObjectLock(objAPI, bucket, objInfo.Name, nil); err != nil {
+				return err
+			}
+			uploadsAborted, err := abortObjectUploads(objAPI, bucket, objInfo.Name)
+			if err != nil {
+				return err
+			}
+			if err = objAPI.DeleteObject(bucket, objInfo.Name); err != nil && !isErrObjectNotFound(err) {
+				return err
+			}
+			removeObjectAttestation(objAPI, bucket, objInfo.Name)
+
+			// Notify object deleted event.
+			eventNotify(eventData{
+				Type:   ObjectRemovedDelete,
+				Bucket: bucket,
+				ObjInfo: ObjectInfo{
+					Name: objInfo.Name,
+				},
+				ReqParams: eventInfo.ReqParams,
+				UserAgent: eventInfo.UserAgent,
+				Host:      eventInfo.Host,
+				Port:      eventInfo.Port,
+			})
+
+			size := objInfo.Size
+			update(bucket, func(status *forceDeleteStatus) {
+				status.ObjectsDeleted++
+				status.BytesDeleted += size
+				status.UploadsAborted += int64(uploadsAborted)
+			})
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+		if marker == "" && len(result.Objects) > 0 {
+			marker = result.Objects[len(result.Objects)-1].Name
+		}
+	}
+
+	return objAPI.DeleteBucket(bucket)
+}
+
+// abortObjectUploads - aborts all incomplete uploads of an object.
+func abortObjectUploads(objAPI ObjectLayer, bucket, object string) (aborted int, err error) {
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		result, err := objAPI.ListMultipartUploads(bucket, object, keyMarker, uploadIDMarker, "", maxUploadsList)
+		if err != nil {
+			return aborted, err
+		}
+		for _, upload := range result.Uploads {
+			if upload.Object != object {
+				continue
+			}
+			err = objAPI.AbortMultipartUpload(bucket, upload.Object, upload.UploadID)
+			if err != nil {
+				if _, ok := errors.Cause(err).(InvalidUploadID); ok {
+					continue
+				}
+				return aborted, err
+			}
+			aborted++
+		}
+		if !result.IsTruncated {
+			return aborted, nil
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+)
+
+// Wrapper for calling force delete tests for both XL multiple disks and single node setup.
+func TestForceDeleteBucket(t *testing.T) {
+	ExecObjectLayerTest(t, testForceDeleteBucket)
+}
+
+// Tests deleting a bucket with objects and incomplete uploads.
+func testForceDeleteBucket(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	data := []byte("hello")
+	for _, object := range []string{"object1", "dir/object2", "dir/object3"} {
+		_, err := obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+	for _, object := range []string{"object1", "new/object4"} {
+		if _, err := obj.NewMultipartUpload(bucket, object, nil); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+
+	state := newForceDeleteState()
+
+	// Deletion fails while the server does not accept writes.
+	globalIsReadOnly = true
+	state.Start(obj, bucket, bgEventInfo{})
+	status := waitForceDelete(state, bucket)
+	globalIsReadOnly = false
+	if status.Status != forceDeleteFailed || status.Error != errServerReadOnly.Error() {
+		t.Fatalf("%s: Expected deletion to fail, got %#v", instanceType, status)
+	}
+
+	// Retrying deletes all contents, then the bucket.
+	state.Start(obj, bucket, bgEventInfo{})
+	status = waitForceDelete(state, bucket)
+	if status.Status != forceDeleteFinished {
+		t.Fatalf("%s: Expected deletion to finish, got %#v", instanceType, status)
+	}
+	if status.ObjectsDeleted != 3 || status.BytesDeleted != 3*int64(len(data)) || status.UploadsAborted != 1 {
+		t.Fatalf("%s: Unexpected progress %#v", instanceType, status)
+	}
+	if _, err := obj.GetBucketInfo(bucket); err == nil {
+		t.Fatalf("%s: Expected bucket to be deleted", instanceType)
+	} else if _, ok := errors.Cause(err).(BucketNotFound); !ok {
+		t.Fatalf("%s: Unexpected error %v", instanceType, err)
+	}
+
+	// Uploads of objects never completed are removed as well, they
+	// do not show up again once the bucket is created again.
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	result, err := obj.ListMultipartUploads(bucket, "new/object4", "", "", "", maxUploadsList)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Uploads) != 0 {
+		t.Fatalf("%s: Expected uploads to be removed, got %v", instanceType, result.Uploads)
+	}
+
+	if _, ok := state.Get("unknown"); ok {
+		t.Fatalf("%s: Expected no progress of a bucket never deleted", instanceType)
+	}
+}
+
+// waitForceDelete - waits until deleting bucket is no longer running.
+func waitForceDelete(state *forceDeleteState, bucket string) forceDeleteStatus {
+	for {
+		status, _ := state.Get(bucket)
+		if status.Status != forceDeleteRunning {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,177 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/textproto"
+	"path"
+	"strconv"
+)
+
+const (
+	// Maximum number of keys fetched by a single GetObjects request,
+	// same as the limit of a multi-object delete.
+	maxGetObjectsKeys = maxObjectList
+
+	// Maximum size of the GetObjects request XML.
+	maxGetObjectsSize = 1024 * 1024
+
+	// Header of every part of a GetObjects response carrying the URL
+	// encoded key of the object, and the code of the error of objects
+	// which could not be read.
+	getObjectsKeyHeader       = "X-Minio-Key"
+	getObjectsErrorCodeHeader = "X-Minio-Error-Code"
+)
+
+// Returns true if the list of keys of a GetObjects request is non-empty,
+// not too long and contains valid object names only.
+func isGetObjectsValid(keys []ObjectIdentifier) bool {
+	if len(keys) == 0 || len(keys) > maxGetObjectsKeys {
+		return false
+	}
+	for _, key := range keys {
+		if !IsValidObjectName(key.ObjectName) {
+			return false
+		}
+	}
+	return true
+}
+
+// Writes a part of a GetObjects response carrying the error of an
+// object in place of its content.
+func writeGetObjectsError(mw *multipart.Writer, bucket, object string, errorCode APIErrorCode) error {
+	apiError := getAPIError(errorCode)
+	header := textproto.MIMEHeader{}
+	header.Set(getObjectsKeyHeader, s3EncodeName(object, encodingTypeURL))
+	header.Set(getObjectsErrorCodeHeader, apiError.Code)
+	header.Set("Content-Type", string(mimeXML))
+	partWriter, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = partWriter.Write(encodeResponse(getAPIErrorResponse(apiError, path.Join(slashSeparator, bucket, object))))
+	return err
+}
+
+// Writes a part of a GetObjects response with the content of an
+// object, encrypted objects are decrypted as by GetObject.
+func writeGetObjectsObject(mw *multipart.Writer, r *http.Request, objectAPI ObjectLayer, bucket, object string, objInfo ObjectInfo) error {
+	header := textproto.MIMEHeader{}
+	header.Set(getObjectsKeyHeader, s3EncodeName(object, encodingTypeURL))
+	header.Set("Content-Length", strconv.FormatInt(objInfo.Size, 10))
+	header.Set("Last-Modified", objInfo.ModTime.UTC().Format(http.TimeFormat))
+	if objInfo.ETag != "" {
+		header.Set("ETag", "\""+objInfo.ETag+"\"")
+	}
+	if objInfo.ContentType != "" {
+		header.Set("Content-Type", objInfo.ContentType)
+	}
+	if objInfo.ContentEncoding != "" {
+		header.Set("Content-Encoding", objInfo.ContentEncoding)
+	}
+
+	partWriter, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	var writer io.Writer = partWriter
+	length := objInfo.Size
+	if objectAPI.IsEncryptionSupported() {
+		if sseS3 := isSSES3Encrypted(objInfo.UserDefined); sseS3 || IsSSECustomerRequest(r.Header) {
+			length = objInfo.EncryptedSize()
+			if sseS3 {
+				writer, err = newKMSDecryptWriter(partWriter, globalKMS, 0, objInfo.UserDefined)
+			} else {
+				writer, err = DecryptRequestWithSequenceNumber(partWriter, r, 0, objInfo.UserDefined)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err = objectAPI.GetObject(bucket, object, 0, length, writer, objInfo.ETag); err != nil {
+		return err
+	}
+	if closer, ok := writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Streams the objects of a GetObjects request as parts of a
+// multipart/mixed response, in the order they were requested. Objects
+// which cannot be read, or which the policies of the request do not
+// allow reading, are sent as parts carrying their error. The response
+// ends early when an object fails once its part is started.
+func writeGetObjectsResponse(w http.ResponseWriter, r *http.Request, objectAPI ObjectLayer, bucket string, keys []ObjectIdentifier) {
+	// Get host and port from Request.RemoteAddr.
+	host, port, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host, port = "", ""
+	}
+
+	mw := multipart.NewWriter(w)
+	setCommonHeaders(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	w.WriteHeader(http.StatusOK)
+
+	for _, key := range keys {
+		object := key.ObjectName
+		if s3Error := checkRequestObjectAccess(r, bucket, object, "s3:GetObject"); s3Error != ErrNone {
+			if err = writeGetObjectsError(mw, bucket, object, s3Error); err != nil {
+				errorIf(err, "Unable to write to client.")
+				return
+			}
+			continue
+		}
+
+		objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+		apiErr := toAPIErrorCode(err)
+		if err == nil && objectAPI.IsEncryptionSupported() {
+			apiErr, _ = DecryptObjectInfo(&objInfo, r.Header)
+		}
+		if apiErr != ErrNone {
+			if err = writeGetObjectsError(mw, bucket, object, apiErr); err != nil {
+				errorIf(err, "Unable to write to client.")
+				return
+			}
+			continue
+		}
+
+		if err = writeGetObjectsObject(mw, r, objectAPI, bucket, object, objInfo); err != nil {
+			errorIf(err, "Unable to write %s/%s to client.", bucket, object)
+			return
+		}
+
+		// Notify object accessed via a GetObjects request.
+		eventNotify(eventData{
+			Type:      ObjectAccessedGet,
+			Bucket:    bucket,
+			ObjInfo:   objInfo,
+			ReqParams: extractReqParams(r),
+			UserAgent: r.UserAgent(),
+			Host:      host,
+			Port:      port,
+		})
+	}
+	errorIf(mw.Close(), "Unable to write to client.")
+}
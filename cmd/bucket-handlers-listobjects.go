@@ -17,7 +17,9 @@
 package cmd
 
 import (
+	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -68,12 +70,27 @@ func (api objectAPIHandlers) ListObjectsV2Handler(w http.ResponseWriter, r *http
 	}
 
 	// Extract all the listObjectsV2 query params to their native values.
-	prefix, token, startAfter, delimiter, fetchOwner, maxKeys, _ := getListObjectsV2Args(r.URL.Query())
+	prefix, token, startAfter, delimiter, fetchOwner, maxKeys, encodingType := getListObjectsV2Args(r.URL.Query())
+	if encodingType != "" && encodingType != encodingTypeURL {
+		writeErrorResponse(w, ErrInvalidEncodingMethod, r.URL)
+		return
+	}
+
+	// Continuation tokens are opaque to clients, they carry the marker
+	// of the object layer.
+	var continuationToken string
+	if token != "" {
+		var err error
+		if continuationToken, err = decodeContinuationToken(token); err != nil {
+			writeErrorResponse(w, ErrIncorrectContinuationToken, r.URL)
+			return
+		}
+	}
 
 	// In ListObjectsV2 'continuation-token' is the marker.
-	marker := token
+	marker := continuationToken
 	// Check if 'continuation-token' is empty.
-	if token == "" {
+	if marker == "" {
 		// Then we need to use 'start-after' as marker instead.
 		marker = startAfter
 	}
@@ -88,13 +105,14 @@ func (api objectAPIHandlers) ListObjectsV2Handler(w http.ResponseWriter, r *http
 	// Inititate a list objects operation based on the input params.
 	// On success would return back ListObjectsInfo object to be
 	// marshalled into S3 compatible XML header.
-	listObjectsV2Info, err := objectAPI.ListObjectsV2(bucket, prefix, marker, delimiter, maxKeys, fetchOwner, startAfter)
+	listObjectsV2Info, err := objectAPI.ListObjectsV2(bucket, prefix, continuationToken, delimiter, maxKeys, fetchOwner, startAfter)
 	if err != nil {
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
 
-	response := generateListObjectsV2Response(bucket, prefix, token, listObjectsV2Info.NextContinuationToken, startAfter, delimiter, fetchOwner, listObjectsV2Info.IsTruncated, maxKeys, listObjectsV2Info.Objects, listObjectsV2Info.Prefixes)
+	nextToken := encodeContinuationToken(listObjectsV2Info.NextContinuationToken)
+	response := generateListObjectsV2Response(bucket, prefix, token, nextToken, startAfter, delimiter, encodingType, fetchOwner, listObjectsV2Info.IsTruncated, maxKeys, listObjectsV2Info.Objects, listObjectsV2Info.Prefixes)
 
 	// Write success response.
 	writeSuccessResponseXML(w, encodeResponse(response))
@@ -116,13 +134,24 @@ func (api objectAPIHandlers) ListObjectsV1Handler(w http.ResponseWriter, r *http
 		return
 	}
 
+	// Website requests of the bucket root are served the index
+	// document instead of a listing.
+	if website, ok := getWebsiteConfigForRequest(r, bucket); ok && r.URL.RawQuery == "" {
+		serveWebsiteIndexDocument(w, r, objectAPI, bucket, "", website)
+		return
+	}
+
 	if s3Error := checkRequestAuthType(r, bucket, "s3:ListBucket", globalServerConfig.GetRegion()); s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
 
 	// Extract all the litsObjectsV1 query params to their native values.
-	prefix, marker, delimiter, maxKeys, _ := getListObjectsV1Args(r.URL.Query())
+	prefix, marker, delimiter, maxKeys, encodingType := getListObjectsV1Args(r.URL.Query())
+	if encodingType != "" && encodingType != encodingTypeURL {
+		writeErrorResponse(w, ErrInvalidEncodingMethod, r.URL)
+		return
+	}
 
 	// Validate the maxKeys lowerbound. When maxKeys > 1000, S3 returns 1000 but
 	// does not throw an error.
@@ -143,8 +172,106 @@ func (api objectAPIHandlers) ListObjectsV1Handler(w http.ResponseWriter, r *http
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
-	response := generateListObjectsV1Response(bucket, prefix, marker, delimiter, maxKeys, listObjectsInfo)
+	response := generateListObjectsV1Response(bucket, prefix, marker, delimiter, encodingType, maxKeys, listObjectsInfo)
 
 	// Write success response.
 	writeSuccessResponseXML(w, encodeResponse(response))
 }
+
+// fastListObject - an object of a fast list batch.
+type fastListObject struct {
+	Name         string    `json:"name"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	ContentType  string    `json:"contentType"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// fastListBatch - a batch of a fast list response, written as a single
+// line of JSON. The last batch is not truncated, or carries the error
+// the listing failed with. Interrupted listings are resumed by passing
+// the next marker of the last batch received as start-after.
+type fastListBatch struct {
+	Objects     []fastListObject `json:"objects"`
+	IsTruncated bool             `json:"isTruncated"`
+	NextMarker  string           `json:"nextMarker,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// Returns a fast list batch of a page of listed objects.
+func newFastListBatch(lo ListObjectsInfo) fastListBatch {
+	batch := fastListBatch{
+		Objects:     make([]fastListObject, 0, len(lo.Objects)),
+		IsTruncated: lo.IsTruncated,
+		NextMarker:  lo.NextMarker,
+	}
+	for _, object := range lo.Objects {
+		batch.Objects = append(batch.Objects, fastListObject{
+			Name:         object.Name,
+			Size:         object.Size,
+			ETag:         object.ETag,
+			ContentType:  object.ContentType,
+			LastModified: object.ModTime.UTC(),
+		})
+	}
+	return batch
+}
+
+// ListObjectsFastHandler - GET Bucket ?fast-list, a Minio extension
+// ----------
+// Lists all objects of the bucket under the prefix recursively, with
+// their metadata, in a single streamed response. Objects are written
+// in batches of lines of JSON as soon as they are listed, clients do
+// not have to request every page of 1000 keys by themselves.
+func (api objectAPIHandlers) ListObjectsFastHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:ListBucket", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	marker := r.URL.Query().Get("start-after")
+	if s3Error := validateListObjectsArgs(prefix, marker, "", maxObjectList); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Errors of the first page are returned as the response status.
+	lo, err := objectAPI.ListObjects(bucket, prefix, marker, "", maxObjectList)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	setCommonHeaders(w)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	for {
+		batch := newFastListBatch(lo)
+		if batch.IsTruncated {
+			if lo, err = objectAPI.ListObjects(bucket, prefix, lo.NextMarker, "", maxObjectList); err != nil {
+				batch.IsTruncated = false
+				batch.Error = err.Error()
+			}
+		}
+		if encoder.Encode(batch) != nil {
+			// Client went away.
+			return
+		}
+		w.(http.Flusher).Flush()
+		if !batch.IsTruncated {
+			return
+		}
+	}
+}
@@ -19,6 +19,7 @@ package cmd
 import (
 	"encoding/base64"
 	"encoding/xml"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
@@ -26,8 +27,10 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	mux "github.com/gorilla/mux"
 	"github.com/minio/minio-go/pkg/policy"
@@ -39,9 +42,10 @@ import (
 // http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
 // Enforces bucket policies for a bucket for a given tatusaction.
 func enforceBucketPolicy(bucket, action, resource, referer, sourceIP string, queryParams url.Values) (s3Error APIErrorCode) {
-	// Verify if bucket actually exists
-	objAPI := newObjectLayerFn()
-	if err := checkBucketExist(bucket, objAPI); err != nil {
+	// Fetch cached bucket policy, verifies if bucket actually exists
+	// when the policy is not cached.
+	p, err := globalBucketPolicyCache.get(newObjectLayerFn(), bucket)
+	if err != nil {
 		err = errors.Cause(err)
 		switch err.(type) {
 		case BucketNameInvalid:
@@ -56,11 +60,7 @@ func enforceBucketPolicy(bucket, action, resource, referer, sourceIP string, que
 		return ErrInternalError
 	}
 
-	// Fetch bucket policy, if policy is not set return access denied.
-	p, err := objAPI.GetBucketPolicy(bucket)
-	if err != nil {
-		return ErrAccessDenied
-	}
+	// If policy is not set return access denied.
 	if reflect.DeepEqual(p, emptyBucketPolicy) {
 		return ErrAccessDenied
 	}
@@ -68,7 +68,17 @@ func enforceBucketPolicy(bucket, action, resource, referer, sourceIP string, que
 	// Construct resource in 'arn:aws:s3:::examplebucket/object' format.
 	arn := bucketARNPrefix + strings.TrimSuffix(strings.TrimPrefix(resource, "/"), "/")
 
-	// Get conditions for policy verification.
+	// Validate action, resource and conditions with current policy statements.
+	conditionKeyMap := getConditionKeyMap(referer, sourceIP, queryParams)
+	if !bucketPolicyEvalStatements(action, arn, conditionKeyMap, p.Statements) {
+		return ErrAccessDenied
+	}
+	return ErrNone
+}
+
+// getConditionKeyMap - returns the conditions of a request policies
+// are evaluated with.
+func getConditionKeyMap(referer, sourceIP string, queryParams url.Values) policy.ConditionKeyMap {
 	conditionKeyMap := make(policy.ConditionKeyMap)
 	for queryParam := range queryParams {
 		conditionKeyMap[queryParam] = set.CreateStringSet(queryParams.Get(queryParam))
@@ -80,18 +90,13 @@ func enforceBucketPolicy(bucket, action, resource, referer, sourceIP string, que
 	}
 	// Add request source Ip to conditionKeyMap.
 	conditionKeyMap["ip"] = set.CreateStringSet(sourceIP)
-
-	// Validate action, resource and conditions with current policy statements.
-	if !bucketPolicyEvalStatements(action, arn, conditionKeyMap, p.Statements) {
-		return ErrAccessDenied
-	}
-	return ErrNone
+	return conditionKeyMap
 }
 
 // Check if the action is allowed on the bucket/prefix.
 func isBucketActionAllowed(action, bucket, prefix string, objectAPI ObjectLayer) bool {
 
-	bp, err := objectAPI.GetBucketPolicy(bucket)
+	bp, err := globalBucketPolicyCache.get(objectAPI, bucket)
 	if err != nil {
 		return false
 	}
@@ -104,6 +109,61 @@ func isBucketActionAllowed(action, bucket, prefix string, objectAPI ObjectLayer)
 	return bucketPolicyEvalStatements(action, resource, conditionKeyMap, bp.Statements)
 }
 
+// bucketPolicyGrantsAccess - returns true if any statement allows an
+// action on bucket or on some of its objects under conditions.
+func bucketPolicyGrantsAccess(bucket string, conditions policy.ConditionKeyMap, statements []policy.Statement) bool {
+	for _, statement := range statements {
+		if statement.Effect != "Allow" || !bucketPolicyConditionMatch(conditions, statement) {
+			continue
+		}
+		for resource := range statement.Resources {
+			if !strings.HasPrefix(resource, bucketARNPrefix) {
+				continue
+			}
+			resourceBucket := strings.SplitN(strings.TrimPrefix(resource, bucketARNPrefix), slashSeparator, 2)[0]
+			if resourceMatch(resourceBucket, bucket) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getListBucketsFilter - returns which buckets are listed to requests
+// denied ListBuckets. Anonymous requests are shown the buckets their
+// bucket policy grants access to, requests signed with temporary
+// credentials the buckets their managed policies grant access to.
+func getListBucketsFilter(r *http.Request, objectAPI ObjectLayer) (func(bucket string) bool, APIErrorCode) {
+	conditions := getConditionKeyMap(r.Referer(), getSourceIPAddress(r), nil)
+	switch getRequestAuthType(r) {
+	case authTypeAnonymous:
+		return func(bucket string) bool {
+			p, err := globalBucketPolicyCache.getListed(objectAPI, bucket)
+			if err != nil {
+				errorIf(err, "Unable to read policy of bucket %s.", bucket)
+				return false
+			}
+			return bucketPolicyGrantsAccess(bucket, conditions, p.Statements)
+		}, ErrNone
+	case authTypeSigned, authTypePresigned:
+		// The signature was verified, temporary credentials are
+		// denied ListBuckets by their session policy.
+		token := getReqSessionToken(r)
+		if token == "" {
+			break
+		}
+		claims, errCode := parseSessionToken(token)
+		if errCode != ErrNone {
+			return nil, errCode
+		}
+		return func(bucket string) bool {
+			granted, err := claims.grantsAccess(objectAPI, bucket, conditions)
+			return err == nil && granted
+		}, ErrNone
+	}
+	return nil, ErrAccessDenied
+}
+
 // GetBucketLocationHandler - GET Bucket location.
 // -------------------------
 // This operation returns bucket location.
@@ -203,6 +263,11 @@ func (api objectAPIHandlers) ListMultipartUploadsHandler(w http.ResponseWriter,
 }
 
 // ListBucketsHandler - GET Service.
+//
+// With ?prefix, ?marker and ?max-buckets, a Minio extension, only
+// buckets with the prefix sorting after the marker are listed, at most
+// max-buckets of them. Truncated responses carry the marker of the
+// next page.
 // -----------
 // This implementation of the GET operation returns a list of all buckets
 // owned by the authenticated sender of the request.
@@ -219,26 +284,76 @@ func (api objectAPIHandlers) ListBucketsHandler(w http.ResponseWriter, r *http.R
 		// Clients like boto3 send listBuckets() call signed with region that is configured.
 		s3Error = checkRequestAuthType(r, "", "", globalServerConfig.GetRegion())
 	}
+	var isListed func(bucket string) bool
+	if s3Error == ErrAccessDenied {
+		isListed, s3Error = getListBucketsFilter(r, objectAPI)
+	}
 	if s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
+	prefix, marker, maxBuckets, s3Error := getListBucketsArgs(r.URL.Query())
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
 	// Invoke the list buckets.
 	bucketsInfo, err := objectAPI.ListBuckets()
 	if err != nil {
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
+	if isListed != nil {
+		listed := bucketsInfo[:0]
+		for _, bucketInfo := range bucketsInfo {
+			if isListed(bucketInfo.Name) {
+				listed = append(listed, bucketInfo)
+			}
+		}
+		bucketsInfo = listed
+	}
+	bucketsInfo, truncated := filterListBuckets(bucketsInfo, prefix, marker, maxBuckets)
 
 	// Generate response.
 	response := generateListBucketsResponse(bucketsInfo)
+	response.Prefix = prefix
+	response.Marker = marker
+	response.MaxBuckets = maxBuckets
+	if truncated {
+		response.IsTruncated = true
+		response.NextMarker = bucketsInfo[len(bucketsInfo)-1].Name
+	}
 	encodedSuccessResponse := encodeResponse(response)
 
 	// Write response.
 	writeSuccessResponseXML(w, encodedSuccessResponse)
 }
 
+// checkMultiDeleteAccess - returns an error if the request may not
+// delete object. Anonymous requests and requests signed with temporary
+// credentials denied access to the bucket are checked against the
+// bucket policy or the session policy of each object.
+func checkMultiDeleteAccess(r *http.Request, bucket, object string, authError APIErrorCode) error {
+	if authError != ErrAccessDenied {
+		return nil
+	}
+	if getRequestAuthType(r) == authTypeAnonymous || getReqSessionToken(r) != "" {
+		if checkRequestObjectAccess(r, bucket, object, "s3:DeleteObject") == ErrNone {
+			return nil
+		}
+	}
+	return PrefixAccessDenied{
+		Bucket: bucket,
+		Object: object,
+	}
+}
+
 // DeleteMultipleObjectsHandler - deletes multiple objects.
+//
+// With ?dry-run, a Minio extension, nothing is deleted. The response
+// lists the objects which would be deleted and the errors, such as
+// AccessDenied from bucket policies, deleting the others runs into.
 func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bucket := vars["bucket"]
@@ -291,31 +406,56 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 		return
 	}
 
+	_, dryRun := r.URL.Query()["dry-run"]
+
 	var wg = &sync.WaitGroup{} // Allocate a new wait group.
 	var dErrs = make([]error, len(deleteObjects.Objects))
 
-	// Delete all requested objects in parallel.
+	// Check access to all requested objects in parallel.
 	for index, object := range deleteObjects.Objects {
 		wg.Add(1)
 		go func(i int, obj ObjectIdentifier) {
 			defer wg.Done()
 			// If the request is denied access, each item
 			// should be marked as 'AccessDenied'
-			if authError == ErrAccessDenied {
-				dErrs[i] = PrefixAccessDenied{
-					Bucket: bucket,
-					Object: obj.ObjectName,
-				}
+			if dErrs[i] = checkMultiDeleteAccess(r, bucket, obj.ObjectName, authError); dErrs[i] != nil {
 				return
 			}
-			dErr := objectAPI.DeleteObject(bucket, obj.ObjectName)
-			if dErr != nil {
-				dErrs[i] = dErr
+			if dErrs[i] = checkObjectLock(objectAPI, bucket, obj.ObjectName, r); dErrs[i] != nil {
+				return
+			}
+			if dryRun {
+				_, dErrs[i] = objectAPI.GetObjectInfo(bucket, obj.ObjectName)
 			}
 		}(index, object)
 	}
 	wg.Wait()
 
+	if !dryRun {
+		// Delete the remaining objects in a single batch.
+		var indexes []int
+		var objects []string
+		for index, object := range deleteObjects.Objects {
+			if dErrs[index] == nil {
+				indexes = append(indexes, index)
+				objects = append(objects, object.ObjectName)
+			}
+		}
+		if len(objects) > 0 {
+			errs, err := objectAPI.DeleteObjects(bucket, objects)
+			for i, index := range indexes {
+				if err != nil {
+					dErrs[index] = err
+				} else {
+					dErrs[index] = errs[i]
+				}
+				if dErrs[index] == nil {
+					removeObjectAttestation(objectAPI, bucket, objects[i])
+				}
+			}
+		}
+	}
+
 	// Collect deleted objects and errors if any.
 	var deletedObjects []ObjectIdentifier
 	var deleteErrors []DeleteError
@@ -347,6 +487,10 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 	// Write success response.
 	writeSuccessResponseXML(w, encodedSuccessResponse)
 
+	if dryRun {
+		return
+	}
+
 	// Get host and port from Request.RemoteAddr failing which
 	// fill them with empty strings.
 	host, port, err := net.SplitHostPort(r.RemoteAddr)
@@ -370,6 +514,314 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 	}
 }
 
+// RenamePrefixHandler - POST Bucket rename prefix, a Minio extension
+// ----------
+// This implementation of the POST operation starts moving all objects
+// of the prefix to the target prefix in the background, objects are
+// copied on the server and the originals deleted. Returns the progress
+// of the rename, a running rename is not started again. Renaming is
+// not atomic, a stopped rename resumes when the servers restart or
+// when it is started again with the same target. Requests signed with
+// temporary credentials only move the objects they may access, their
+// renames only resume when started again.
+func (api objectAPIHandlers) RenamePrefixHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Access is checked per object while moving them.
+	if s3Error := checkRequestSignature(r, globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	target := r.URL.Query().Get("target")
+	if !isRenamePrefixValid(prefix, target) {
+		writeErrorResponse(w, ErrInvalidRenamePrefix, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Get host and port from Request.RemoteAddr.
+	host, port, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host, port = "", ""
+	}
+
+	// Moving an object requires permissions to read and delete it and
+	// to create its new name. Requests signed with the server
+	// credentials may access all objects.
+	var checkAccess renamePrefixAccessFunc
+	if getReqSessionToken(r) != "" {
+		checkAccess = func(object, action string) APIErrorCode {
+			return checkRequestObjectAccess(r, bucket, object, action)
+		}
+	}
+	status, err := globalRenamePrefixState.Start(objectAPI, bucket, prefix, target, bgEventInfo{
+		ReqParams: extractReqParams(r),
+		UserAgent: r.UserAgent(),
+		Host:      host,
+		Port:      port,
+	}, checkAccess)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	encodedSuccessResponse := encodeResponse(generateRenamePrefixResponse(status))
+
+	// Write success response.
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+// GetRenamePrefixHandler - GET Bucket rename prefix, a Minio extension
+// ----------
+// This implementation of the GET operation returns the progress of
+// moving all objects of the prefix, started on any server.
+func (api objectAPIHandlers) GetRenamePrefixHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:ListBucket", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	status, ok, err := globalRenamePrefixState.Get(objectAPI, bucket, r.URL.Query().Get("prefix"))
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if !ok {
+		writeErrorResponse(w, ErrNoSuchRenamePrefix, r.URL)
+		return
+	}
+
+	encodedSuccessResponse := encodeResponse(generateRenamePrefixResponse(status))
+
+	// Write success response.
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+// DownloadManifestHandler - POST Bucket download manifest, a Minio extension
+// ----------
+// This implementation of the POST operation returns presigned URLs of
+// the objects listed in the request, and optionally a single URL of a
+// zip archive of them, so browsers can download many objects without
+// asking the server for each. URLs are issued with the server
+// credentials, hence the request must be signed and URLs are only
+// issued for objects the request is allowed to read.
+func (api objectAPIHandlers) DownloadManifestHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Access is checked per object below.
+	if s3Error := checkRequestSignature(r, globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Unmarshal list of keys to be downloaded.
+	manifest := &DownloadManifestRequest{}
+	if err := xml.NewDecoder(io.LimitReader(r.Body, maxDownloadManifestSize)).Decode(manifest); err != nil {
+		errorIf(err, "Unable to unmarshal download manifest request XML.")
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+	expiry, ok := getDownloadManifestExpiry(manifest.Expires)
+	if !ok || !isDownloadManifestValid(manifest.Objects) {
+		writeErrorResponse(w, ErrInvalidDownloadManifest, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Objects the request may not read are neither looked up nor
+	// signed, their existence is not revealed either.
+	var allowed []ObjectIdentifier
+	var manifestErrors []DeleteError
+	for _, object := range manifest.Objects {
+		if s3Error := checkRequestObjectAccess(r, bucket, object.ObjectName, "s3:GetObject"); s3Error != ErrNone {
+			manifestErrors = append(manifestErrors, DeleteError{
+				Code:    errorCodeResponse[s3Error].Code,
+				Message: errorCodeResponse[s3Error].Description,
+				Key:     object.ObjectName,
+			})
+			continue
+		}
+		allowed = append(allowed, object)
+	}
+
+	expiration := UTCNow().Add(expiry)
+	objInfos, errs := getDownloadManifestObjects(objectAPI, bucket, allowed)
+
+	// Collect found objects and errors if any.
+	var objects []DownloadManifestObject
+	var found []ObjectIdentifier
+	for index, err := range errs {
+		object := allowed[index]
+		if err != nil {
+			manifestErrors = append(manifestErrors, DeleteError{
+				Code:    errorCodeResponse[toAPIErrorCode(err)].Code,
+				Message: errorCodeResponse[toAPIErrorCode(err)].Description,
+				Key:     object.ObjectName,
+			})
+			continue
+		}
+		objects = append(objects, DownloadManifestObject{
+			Key:  object.ObjectName,
+			ETag: "\"" + objInfos[index].ETag + "\"",
+			Size: objInfos[index].Size,
+			URL:  getPresignedLocation(presignedGet(r.Host, bucket, object.ObjectName, int64(expiry/time.Second))),
+		})
+		found = append(found, object)
+	}
+
+	var archiveURL string
+	if manifest.Archive && len(found) > 0 {
+		archiveURL = getPresignedLocation(getDownloadManifestArchiveURL(r.Host, bucket, found, expiry))
+	}
+
+	response := generateDownloadManifestResponse(bucket, expiration, archiveURL, objects, manifestErrors)
+	encodedSuccessResponse := encodeResponse(response)
+
+	// Write success response.
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+// GetBucketArchiveHandler - GET Bucket archive, a Minio extension
+// ----------
+// This implementation of the GET operation streams a zip archive of
+// the objects named by the key query parameters. It serves the archive
+// URLs of download manifests, anonymous requests are allowed when the
+// bucket policy allows reading every object. Objects encrypted with
+// SSE-S3 are decrypted, objects encrypted with SSE-C fail the archive
+// since its URL cannot carry their customer keys.
+func (api objectAPIHandlers) GetBucketArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	keys := r.URL.Query()["key"]
+	var objects []ObjectIdentifier
+	for _, key := range keys {
+		objects = append(objects, ObjectIdentifier{ObjectName: key})
+	}
+	if !isDownloadManifestValid(objects) {
+		writeErrorResponse(w, ErrInvalidDownloadManifest, r.URL)
+		return
+	}
+
+	if getRequestAuthType(r) != authTypeAnonymous {
+		if s3Error := checkRequestAuthType(r, bucket, "s3:GetObject", globalServerConfig.GetRegion()); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+	}
+	// Every object of the archive must be readable by the request.
+	for _, key := range keys {
+		if s3Error := checkRequestObjectAccess(r, bucket, key, "s3:GetObject"); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+	}
+
+	// Verify all objects exist and can be decrypted before the
+	// response is started.
+	objInfos, errs := getDownloadManifestObjects(objectAPI, bucket, objects)
+	for i, err := range errs {
+		if err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		if isArchiveObjectSSEC(objectAPI, objInfos[i]) {
+			writeErrorResponse(w, ErrSSEEncryptedObject, r.URL)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.zip\"", bucket))
+
+	// Response is already started, errors can only end it early.
+	errorIf(writeObjectsArchive(objectAPI, bucket, keys, w), "Unable to write archive of bucket %s.", bucket)
+}
+
+// GetObjectsHandler - POST Bucket get objects, a Minio extension
+// ----------
+// This implementation of the POST operation streams the objects listed
+// in the request as parts of a single multipart/mixed response, saving
+// a request per object for clients reading many small objects. Objects
+// which cannot be read are sent as parts carrying their error,
+// anonymous requests may read objects the bucket policy allows.
+func (api objectAPIHandlers) GetObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if getRequestAuthType(r) != authTypeAnonymous {
+		if s3Error := checkRequestAuthType(r, bucket, "s3:GetObject", globalServerConfig.GetRegion()); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+	}
+
+	// Unmarshal list of keys to be read.
+	request := &GetObjectsRequest{}
+	if err := xml.NewDecoder(io.LimitReader(r.Body, maxGetObjectsSize)).Decode(request); err != nil {
+		errorIf(err, "Unable to unmarshal get objects request XML.")
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+	if !isGetObjectsValid(request.Objects) {
+		writeErrorResponse(w, ErrInvalidGetObjects, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Response is started, errors of objects are sent in their parts.
+	writeGetObjectsResponse(w, r, objectAPI, bucket, request.Objects)
+}
+
 // PutBucketHandler - PUT Bucket
 // ----------
 // This implementation of the PUT operation creates a new bucket for authenticated request
@@ -404,6 +856,17 @@ func (api objectAPIHandlers) PutBucketHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Object lock may be enabled when the bucket is created.
+	lockEnabled, err := strconv.ParseBool(r.Header.Get(amzBucketObjectLockEnabled))
+	if err != nil && r.Header.Get(amzBucketObjectLockEnabled) != "" {
+		writeErrorResponse(w, ErrInvalidRequest, r.URL)
+		return
+	}
+	if lockEnabled && globalBucketObjectLock == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
 	bucketLock := globalNSMutex.NewNSLock(bucket, "")
 	if err := bucketLock.GetLock(globalObjectTimeout); err != nil {
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
@@ -412,12 +875,23 @@ func (api objectAPIHandlers) PutBucketHandler(w http.ResponseWriter, r *http.Req
 	defer bucketLock.Unlock()
 
 	// Proceed to creating a bucket.
-	err := objectAPI.MakeBucketWithLocation(bucket, "")
+	err = objectAPI.MakeBucketWithLocation(bucket, "")
 	if err != nil {
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
 
+	if lockEnabled {
+		if err = saveObjectLockConfig(bucket, objectLockConfig{}, objectAPI); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		globalBucketObjectLock.Set(bucket, objectLockConfig{})
+
+		// Notify all peers (including self) to reload the config.
+		S3PeersUpdateBucketConfig(bucket, bucketObjectLockConfig)
+	}
+
 	// Make sure to add Location information here only for bucket
 	w.Header().Set("Location", getLocation(r))
 
@@ -443,7 +917,7 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 		writeErrorResponse(w, ErrMissingContentLength, r.URL)
 		return
 	}
-	resource, err := getResource(r.URL.Path, r.Host, globalDomainName)
+	resource, err := getResource(r.URL.Path, r.Host, globalDomainNames)
 	if err != nil {
 		writeErrorResponse(w, ErrInvalidRequest, r.URL)
 		return
@@ -501,6 +975,10 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 	object := formValues.Get("Key")
 
 	successRedirect := formValues.Get("success_action_redirect")
+	if successRedirect == "" {
+		// Older clients use the deprecated redirect field.
+		successRedirect = formValues.Get("redirect")
+	}
 	successStatus := formValues.Get("success_action_status")
 	var redirectURL *url.URL
 	if successRedirect != "" {
@@ -536,6 +1014,16 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	// Policies signed with temporary credentials only allow uploads
+	// their session policy allows.
+	if token := formValues.Get(amzSecurityToken); token != "" {
+		conditions := getConditionKeyMap(r.Referer(), getSourceIPAddress(r), r.URL.Query())
+		if apiErr = enforceSessionTokenPolicy(token, "s3:PutObject", bucket, bucket+"/"+object, conditions); apiErr != ErrNone {
+			writeErrorResponse(w, apiErr, r.URL)
+			return
+		}
+	}
+
 	// Ensure that the object size is within expected range, also the file size
 	// should not exceed the maximum single Put size (5 GiB)
 	lengthRange := postPolicyForm.Conditions.ContentLengthRange
@@ -558,6 +1046,17 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 		writeErrorResponse(w, ErrInternalError, r.URL)
 		return
 	}
+	if apiErr = extractObjectLockFromHeader(formValues, bucket, metadata); apiErr != ErrNone {
+		writeErrorResponse(w, apiErr, r.URL)
+		return
+	}
+	applyMetadataDefaults(bucket, object, metadata)
+
+	// Locked objects cannot be overwritten.
+	if err = checkObjectLock(objectAPI, bucket, object, r); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
 
 	hashReader, err := hash.NewReader(fileBody, fileSize, "", "")
 	if err != nil {
@@ -571,6 +1070,7 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
+	errorIf(attestObject(objectAPI, bucket, object, objInfo.ETag), "Unable to attest object %s/%s.", bucket, object)
 
 	port := r.Header.Get("X-Forward-Proto")
 	location := getObjectLocation(r.Host, port, bucket, object)
@@ -596,7 +1096,7 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 
 	if successRedirect != "" {
 		// Replace raw query params..
-		redirectURL.RawQuery = getRedirectPostRawQuery(objInfo)
+		redirectURL.RawQuery = getRedirectPostRawQuery(redirectURL.Query(), objInfo)
 		writeRedirectSeeOther(w, redirectURL.String())
 		return
 	}
@@ -618,6 +1118,157 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 	}
 }
 
+// Headers of prefix summary responses.
+const (
+	minioObjectCount       = "X-Minio-Object-Count"
+	minioTotalSize         = "X-Minio-Total-Size"
+	minioSummaryLastUpdate = "X-Minio-Summary-Last-Updated"
+)
+
+// getPrefixSummary - returns the usage of the prefix of a summary
+// request from the usage index, and when it was last updated.
+func getPrefixSummary(objectAPI ObjectLayer, r *http.Request, bucket string) (prefix string, usage prefixUsage, lastUpdate time.Time, s3Error APIErrorCode) {
+	if s3Error = checkRequestAuthType(r, bucket, "s3:ListBucket", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		return prefix, usage, lastUpdate, s3Error
+	}
+
+	prefix = r.URL.Query().Get("prefix")
+	if prefix != "" && (!IsValidObjectPrefix(prefix) || !hasSuffix(prefix, slashSeparator)) {
+		return prefix, usage, lastUpdate, ErrInvalidSummaryPrefix
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		return prefix, usage, lastUpdate, toAPIErrorCode(err)
+	}
+
+	if globalUsageCrawler == nil {
+		return prefix, usage, lastUpdate, ErrPrefixSummaryNotReady
+	}
+	usage, lastUpdate, err := globalUsageCrawler.Summary(bucket, prefix)
+	if err != nil {
+		return prefix, usage, lastUpdate, toAPIErrorCode(err)
+	}
+	return prefix, usage, lastUpdate, ErrNone
+}
+
+// GetPrefixSummaryHandler - GET Bucket prefix summary, a Minio extension
+// ----------
+// This implementation of the GET operation returns the number and total
+// size of all objects of a prefix, or of the bucket if no prefix is
+// given, from the usage index. The index is updated by crawling all
+// buckets periodically.
+func (api objectAPIHandlers) GetPrefixSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	prefix, usage, lastUpdate, s3Error := getPrefixSummary(objectAPI, r, bucket)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	encodedSuccessResponse := encodeResponse(generatePrefixSummaryResponse(bucket, prefix, usage, lastUpdate))
+
+	// Write success response.
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+// HeadPrefixSummaryHandler - HEAD Bucket prefix summary, a Minio extension
+// ----------
+// Same as GetPrefixSummaryHandler, the summary is returned in response
+// headers only.
+func (api objectAPIHandlers) HeadPrefixSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponseHeadersOnly(w, ErrServerNotInitialized)
+		return
+	}
+
+	_, usage, lastUpdate, s3Error := getPrefixSummary(objectAPI, r, bucket)
+	if s3Error != ErrNone {
+		writeErrorResponseHeadersOnly(w, s3Error)
+		return
+	}
+
+	w.Header().Set(minioObjectCount, strconv.FormatInt(usage.Objects, 10))
+	w.Header().Set(minioTotalSize, strconv.FormatInt(usage.Size, 10))
+	w.Header().Set(minioSummaryLastUpdate, lastUpdate.UTC().Format(http.TimeFormat))
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// Number of top-level prefixes of bucket statistics responses, unless
+// requested otherwise.
+const defaultBucketStatsPrefixes = 10
+
+// GetBucketStatsHandler - GET Bucket statistics, a Minio extension
+// ----------
+// This implementation of the GET operation returns the age distribution
+// of the objects of a bucket, the modification times of its oldest and
+// newest object and its largest top-level prefixes, at most max-prefixes
+// of them. The statistics are updated by crawling all buckets
+// periodically and persisted, they are available after restarts before
+// the bucket is crawled again.
+func (api objectAPIHandlers) GetBucketStatsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:ListBucket", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	maxPrefixes := defaultBucketStatsPrefixes
+	if v := r.URL.Query().Get("max-prefixes"); v != "" {
+		var err error
+		if maxPrefixes, err = strconv.Atoi(v); err != nil || maxPrefixes < 0 {
+			writeErrorResponse(w, ErrInvalidMaxPrefixes, r.URL)
+			return
+		}
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	var stats bucketStats
+	ok := false
+	if globalUsageCrawler != nil {
+		stats, ok = globalUsageCrawler.Stats(bucket)
+	}
+	if !ok {
+		var err error
+		if stats, ok, err = loadBucketStats(bucket, objectAPI); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+	if !ok {
+		writeErrorResponse(w, ErrPrefixSummaryNotReady, r.URL)
+		return
+	}
+
+	encodedSuccessResponse := encodeResponse(generateBucketStatsResponse(bucket, stats, maxPrefixes))
+
+	// Write success response.
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
 // HeadBucketHandler - HEAD Bucket
 // ----------
 // This operation is useful to determine if a bucket exists.
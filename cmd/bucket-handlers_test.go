@@ -17,14 +17,27 @@
 package cmd
 
 import (
+	"archive/zip"
 	"bytes"
+	"encoding/json"
 	"encoding/xml"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/minio/minio-go/pkg/policy"
+	"github.com/minio/minio-go/pkg/set"
 	"github.com/minio/minio/pkg/auth"
 )
 
@@ -580,18 +593,42 @@ func testListBucketsHandler(obj ObjectLayer, instanceType, bucketName string, ap
 		}
 	}
 
-	// Test for Anonymous/unsigned http request.
-	// ListBucketsHandler doesn't support bucket policies, setting the policies shouldn't make a difference.
-	anonReq, err := newTestRequest("GET", getListBucketURL(""), 0, nil)
-
-	if err != nil {
-		t.Fatalf("Minio %s: Failed to create an anonymous request.", instanceType)
+	// Test for Anonymous/unsigned http request, only buckets whose
+	// policy grants access are listed.
+	listAnonymous := func() []string {
+		anonReq, err := newTestRequest("GET", getListBucketURL(""), 0, nil)
+		if err != nil {
+			t.Fatalf("Minio %s: Failed to create an anonymous request.", instanceType)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, anonReq)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Minio %s: Expected anonymous list to succeed, got %d", instanceType, rec.Code)
+		}
+		var response ListBucketsResponse
+		if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Minio %s: %v", instanceType, err)
+		}
+		var buckets []string
+		for _, bucket := range response.Buckets.Buckets {
+			buckets = append(buckets, bucket.Name)
+		}
+		return buckets
+	}
+	if buckets := listAnonymous(); len(buckets) != 0 {
+		t.Fatalf("Minio %s: Expected no buckets listed anonymously, got %v", instanceType, buckets)
+	}
+	bp := policy.BucketAccessPolicy{
+		Version:    "1.0",
+		Statements: []policy.Statement{getWriteOnlyObjectStatement(bucketName, "")},
+	}
+	if err := obj.SetBucketPolicy(bucketName, bp); err != nil {
+		t.Fatalf("Minio %s: %v", instanceType, err)
+	}
+	globalBucketPolicyCache.invalidate(bucketName)
+	if buckets := listAnonymous(); len(buckets) != 1 || buckets[0] != bucketName {
+		t.Fatalf("Minio %s: Expected only %s listed anonymously, got %v", instanceType, bucketName, buckets)
 	}
-
-	// ExecObjectLayerAPIAnonTest - Calls the HTTP API handler using the anonymous request, validates the ErrAccessDeniedResponse,
-	// sets the bucket policy using the policy statement generated from `getWriteOnlyObjectStatement` so that the
-	// unsigned request goes through and its validated again.
-	ExecObjectLayerAPIAnonTest(t, obj, "ListBucketsHandler", "", "", instanceType, apiRouter, anonReq, getWriteOnlyObjectStatement)
 
 	// HTTP request for testing when `objectLayer` is set to `nil`.
 	// There is no need to use an existing bucket and valid input for creating the request
@@ -608,6 +645,132 @@ func testListBucketsHandler(obj ObjectLayer, instanceType, bucketName string, ap
 	ExecObjectLayerAPINilTest(t, "", "", instanceType, apiRouter, nilReq)
 }
 
+// Wrapper for calling paginated ListBuckets HTTP handler tests for both XL multiple disks and single node setup.
+func TestListBucketsPaginatedHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testListBucketsPaginatedHandler, []string{"ListBuckets"})
+}
+
+// testListBucketsPaginatedHandler - Tests filtering and paginating the list of buckets.
+func testListBucketsPaginatedHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	for _, bucket := range []string{"tenant-a", "tenant-b", "tenant-c", "tenant-d", "tenant-e"} {
+		if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+			t.Fatalf("%s: Failed to make bucket %s: <ERROR> %v", instanceType, bucket, err)
+		}
+	}
+
+	testCases := []struct {
+		query              url.Values
+		expectedRespStatus int
+		expectedBuckets    []string
+		expectedNextMarker string
+	}{
+		// Test case - 1.
+		// Filter by prefix.
+		{
+			query:              url.Values{"prefix": {"tenant-"}},
+			expectedRespStatus: http.StatusOK,
+			expectedBuckets:    []string{"tenant-a", "tenant-b", "tenant-c", "tenant-d", "tenant-e"},
+		},
+		// Test case - 2.
+		// First page.
+		{
+			query:              url.Values{"prefix": {"tenant-"}, "max-buckets": {"2"}},
+			expectedRespStatus: http.StatusOK,
+			expectedBuckets:    []string{"tenant-a", "tenant-b"},
+			expectedNextMarker: "tenant-b",
+		},
+		// Test case - 3.
+		// Next page.
+		{
+			query:              url.Values{"prefix": {"tenant-"}, "marker": {"tenant-b"}, "max-buckets": {"2"}},
+			expectedRespStatus: http.StatusOK,
+			expectedBuckets:    []string{"tenant-c", "tenant-d"},
+			expectedNextMarker: "tenant-d",
+		},
+		// Test case - 4.
+		// Last page is not truncated.
+		{
+			query:              url.Values{"prefix": {"tenant-"}, "marker": {"tenant-d"}, "max-buckets": {"2"}},
+			expectedRespStatus: http.StatusOK,
+			expectedBuckets:    []string{"tenant-e"},
+		},
+		// Test case - 5.
+		// Marker without prefix lists all buckets after it.
+		{
+			query:              url.Values{"marker": {"tenant-c"}},
+			expectedRespStatus: http.StatusOK,
+			expectedBuckets:    []string{"tenant-d", "tenant-e"},
+		},
+		// Test case - 6.
+		// No matching buckets.
+		{
+			query:              url.Values{"prefix": {"unknown"}},
+			expectedRespStatus: http.StatusOK,
+		},
+		// Test case - 7.
+		// Invalid max-buckets.
+		{
+			query:              url.Values{"max-buckets": {"0"}},
+			expectedRespStatus: http.StatusBadRequest,
+		},
+		// Test case - 8.
+		{
+			query:              url.Values{"max-buckets": {"ten"}},
+			expectedRespStatus: http.StatusBadRequest,
+		},
+	}
+
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("GET", makeTestTargetURL("", "", "", testCase.query),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request for ListBucketsHandler: <ERROR> %v", i+1, instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		var response ListBucketsResponse
+		if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Test %d: %s: Failed to decode the response: <ERROR> %v", i+1, instanceType, err)
+		}
+		var buckets []string
+		for _, bucket := range response.Buckets.Buckets {
+			// The randomly named bucket of the test instance may
+			// sort anywhere, only the tenant buckets are checked.
+			if bucket.Name == bucketName {
+				continue
+			}
+			buckets = append(buckets, bucket.Name)
+		}
+		if !reflect.DeepEqual(buckets, testCase.expectedBuckets) {
+			t.Errorf("Test %d: %s: Expected buckets %v, got %v", i+1, instanceType, testCase.expectedBuckets, buckets)
+		}
+		if response.IsTruncated != (testCase.expectedNextMarker != "") || response.NextMarker != testCase.expectedNextMarker {
+			t.Errorf("Test %d: %s: Expected next marker `%s`, got truncated %v with `%s`", i+1, instanceType,
+				testCase.expectedNextMarker, response.IsTruncated, response.NextMarker)
+		}
+	}
+
+	// Responses to requests without the extension are unchanged.
+	rec := httptest.NewRecorder()
+	req, err := newTestSignedRequestV4("GET", getListBucketURL(""), 0, nil, credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatalf("%s: Failed to create HTTP request for ListBucketsHandler: <ERROR> %v", instanceType, err)
+	}
+	apiRouter.ServeHTTP(rec, req)
+	if bytes.Contains(rec.Body.Bytes(), []byte("IsTruncated")) || bytes.Contains(rec.Body.Bytes(), []byte("MaxBuckets")) {
+		t.Errorf("%s: Unexpected pagination in response %s", instanceType, rec.Body.String())
+	}
+}
+
 // Wrapper for calling DeleteMultipleObjects HTTP handler tests for both XL multiple disks and single node setup.
 func TestAPIDeleteMultipleObjectsHandler(t *testing.T) {
 	ExecObjectLayerAPITest(t, testAPIDeleteMultipleObjectsHandler, []string{"DeleteMultipleObjects"})
@@ -798,6 +961,87 @@ func testAPIDeleteMultipleObjectsHandler(obj ObjectLayer, instanceType, bucketNa
 	ExecObjectLayerAPINilTest(t, nilBucket, nilObject, instanceType, apiRouter, nilReq)
 }
 
+// Wrapper for calling DeleteMultipleObjects dry run tests for both XL multiple disks and single node setup.
+func TestAPIDeleteMultipleObjectsDryRun(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIDeleteMultipleObjectsDryRun, []string{"DeleteMultipleObjects"})
+}
+
+func testAPIDeleteMultipleObjectsDryRun(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	contentBytes := []byte("hello")
+	for _, objectName := range []string{"public/object", "private/object"} {
+		_, err := obj.PutObject(bucketName, objectName, mustGetHashReader(t, bytes.NewBuffer(contentBytes), int64(len(contentBytes)), "", ""), nil)
+		if err != nil {
+			t.Fatalf("Minio %s: Error uploading object: <ERROR> %v", instanceType, err)
+		}
+	}
+
+	// Anonymous users may delete objects below public/.
+	bucketPolicy := policy.BucketAccessPolicy{
+		Version:    "2012-10-17",
+		Statements: policy.SetPolicy(nil, policy.BucketPolicyWriteOnly, bucketName, "public"),
+	}
+	if err := persistAndNotifyBucketPolicyChange(bucketName, false, bucketPolicy, obj); err != nil {
+		t.Fatalf("Minio %s: %v", instanceType, err)
+	}
+
+	deleteRequest := encodeResponse(DeleteObjectsRequest{Objects: []ObjectIdentifier{
+		{"public/object"}, {"private/object"}, {"public/missing"},
+	}})
+	accessDenied := DeleteError{
+		Code:    errorCodeResponse[ErrAccessDenied].Code,
+		Message: errorCodeResponse[ErrAccessDenied].Description,
+		Key:     "private/object",
+	}
+
+	testCases := []struct {
+		accessKey       string
+		secretKey       string
+		expectedDeleted []ObjectIdentifier
+		expectedErrors  []DeleteError
+	}{
+		{credentials.AccessKey, credentials.SecretKey, []ObjectIdentifier{{"public/object"}, {"private/object"}, {"public/missing"}}, nil},
+		{"", "", []ObjectIdentifier{{"public/object"}, {"public/missing"}}, []DeleteError{accessDenied}},
+	}
+
+	queryValue := url.Values{}
+	queryValue.Set("delete", "")
+	queryValue.Set("dry-run", "")
+	dryRunURL := makeTestTargetURL("", bucketName, "", queryValue)
+
+	for i, testCase := range testCases {
+		var req *http.Request
+		var err error
+		if testCase.accessKey != "" {
+			req, err = newTestSignedRequestV4("POST", dryRunURL, int64(len(deleteRequest)),
+				bytes.NewReader(deleteRequest), testCase.accessKey, testCase.secretKey)
+		} else {
+			req, err = newTestRequest("POST", dryRunURL, int64(len(deleteRequest)), bytes.NewReader(deleteRequest))
+		}
+		if err != nil {
+			t.Fatalf("Test %d: Minio %s: Failed to create HTTP request: <ERROR> %v", i+1, instanceType, err)
+		}
+
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Test %d: Minio %s: Expected the response status to be `200`, but instead found `%d`", i+1, instanceType, rec.Code)
+		}
+		expectedContent := encodeResponse(generateMultiDeleteResponse(false, testCase.expectedDeleted, testCase.expectedErrors))
+		if !bytes.Equal(expectedContent, rec.Body.Bytes()) {
+			t.Errorf("Test %d: Minio %s: Expected %s, got %s", i+1, instanceType, expectedContent, rec.Body.Bytes())
+		}
+	}
+
+	// Nothing is deleted by a dry run.
+	for _, objectName := range []string{"public/object", "private/object"} {
+		if _, err := obj.GetObjectInfo(bucketName, objectName); err != nil {
+			t.Errorf("Minio %s: Expected %s not to be deleted, got %v", instanceType, objectName, err)
+		}
+	}
+}
+
 func TestIsBucketActionAllowed(t *testing.T) {
 	ExecObjectLayerAPITest(t, testIsBucketActionAllowedHandler, []string{"BucketLocation"})
 }
@@ -825,3 +1069,976 @@ func testIsBucketActionAllowedHandler(obj ObjectLayer, instanceType, bucketName
 
 	}
 }
+
+// Wrapper for calling RenamePrefix HTTP handler tests for both XL multiple disks and single node setup.
+func TestAPIRenamePrefixHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIRenamePrefixHandler, []string{"RenamePrefix"})
+}
+
+func testAPIRenamePrefixHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	contentBytes := []byte("hello")
+	objectNames := []string{"photos/a.jpg", "photos/2018/b.jpg", "photos/2018/c.jpg", "other/d.jpg"}
+	for i, objectName := range objectNames {
+		_, err := obj.PutObject(bucketName, objectName, mustGetHashReader(t, bytes.NewBuffer(contentBytes), int64(len(contentBytes)), "", ""), nil)
+		if err != nil {
+			t.Fatalf("Put Object %d:  Error uploading object: <ERROR> %v", i, err)
+		}
+	}
+
+	doRequest := func(method, prefix, target, accessKey string) (*httptest.ResponseRecorder, RenamePrefixResponse) {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, getRenamePrefixURL("", bucketName, prefix, target),
+			0, nil, accessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for RenamePrefix: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		var response RenamePrefixResponse
+		if rec.Code == http.StatusOK {
+			if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("%s: Failed to parse RenamePrefix response: <ERROR> %v", instanceType, err)
+			}
+		}
+		return rec, response
+	}
+
+	testCases := []struct {
+		method             string
+		prefix             string
+		target             string
+		accessKey          string
+		expectedRespStatus int
+	}{
+		// Test case - 1.
+		// Target inside the prefix.
+		{"POST", "photos/", "photos/old/", credentials.AccessKey, http.StatusBadRequest},
+		// Test case - 2.
+		// Prefix inside the target.
+		{"POST", "photos/2018/", "photos/", credentials.AccessKey, http.StatusBadRequest},
+		// Test case - 3.
+		// Missing target.
+		{"POST", "photos/", "", credentials.AccessKey, http.StatusBadRequest},
+		// Test case - 4.
+		// Invalid access key.
+		{"POST", "photos/", "images/", "Invalid-AccessID", http.StatusForbidden},
+		// Test case - 5.
+		// Prefix never renamed.
+		{"GET", "photos/", "", credentials.AccessKey, http.StatusNotFound},
+	}
+	for i, testCase := range testCases {
+		rec, _ := doRequest(testCase.method, testCase.prefix, testCase.target, testCase.accessKey)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Errorf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+	}
+
+	rec, response := doRequest("POST", "photos/", "images/", credentials.AccessKey)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+	}
+	for response.Status == renamePrefixRunning {
+		time.Sleep(10 * time.Millisecond)
+		rec, response = doRequest("GET", "photos/", "", credentials.AccessKey)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+		}
+	}
+	if response.Status != renamePrefixFinished || response.ObjectsMoved != 3 || response.BytesMoved != 3*int64(len(contentBytes)) {
+		t.Fatalf("%s: Unexpected rename progress %#v", instanceType, response)
+	}
+
+	result, err := obj.ListObjects(bucketName, "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	var listed []string
+	for _, objInfo := range result.Objects {
+		listed = append(listed, objInfo.Name)
+	}
+	expected := []string{"images/2018/b.jpg", "images/2018/c.jpg", "images/a.jpg", "other/d.jpg"}
+	if !reflect.DeepEqual(listed, expected) {
+		t.Fatalf("%s: Expected objects %v, got %v", instanceType, expected, listed)
+	}
+
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucketName, "images/a.jpg", 0, int64(len(contentBytes)), &buffer, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), contentBytes) {
+		t.Fatalf("%s: Expected moved object content %s, got %s", instanceType, contentBytes, buffer.Bytes())
+	}
+
+	// Progress is persisted, it is found after a restart.
+	globalRenamePrefixState = newRenamePrefixState()
+	rec, response = doRequest("GET", "photos/", "", credentials.AccessKey)
+	if rec.Code != http.StatusOK || response.Status != renamePrefixFinished || response.ObjectsMoved != 3 {
+		t.Fatalf("%s: Expected persisted rename progress, got %d %#v", instanceType, rec.Code, response)
+	}
+
+	// Renames running when the server stopped are resumed.
+	if err = saveRenamePrefixStatus(obj, renamePrefixStatus{
+		Bucket:       bucketName,
+		Prefix:       "other/",
+		Target:       "archive/",
+		Status:       renamePrefixRunning,
+		StartTime:    UTCNow(),
+		ObjectsMoved: 1,
+	}); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = globalRenamePrefixState.Resume(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	for response.Status = renamePrefixRunning; response.Status == renamePrefixRunning; {
+		time.Sleep(10 * time.Millisecond)
+		rec, response = doRequest("GET", "other/", "", credentials.AccessKey)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+		}
+	}
+	if response.Status != renamePrefixFinished || response.ObjectsMoved != 2 {
+		t.Fatalf("%s: Unexpected resumed rename progress %#v", instanceType, response)
+	}
+	if _, err = obj.GetObjectInfo(bucketName, "archive/d.jpg"); err != nil {
+		t.Fatalf("%s: Expected resumed rename to move other/d.jpg, got %v", instanceType, err)
+	}
+
+	// Temporary credentials only move the objects they may read and
+	// delete to names they may create.
+	statements := []policy.Statement{
+		{
+			Actions:   set.CreateStringSet("s3:GetObject", "s3:DeleteObject"),
+			Effect:    "Allow",
+			Principal: policy.User{AWS: set.CreateStringSet("*")},
+			Resources: set.CreateStringSet(bucketARNPrefix + managedPolicyBucketVar + "/images/2018/*"),
+		},
+		{
+			Actions:   set.CreateStringSet("s3:PutObject"),
+			Effect:    "Allow",
+			Principal: policy.User{AWS: set.CreateStringSet("*")},
+			Resources: set.CreateStringSet(bucketARNPrefix + managedPolicyBucketVar + "/moved/*"),
+		},
+	}
+	if _, err = putManagedPolicy(obj, auditCaller{AccessKey: "minio"}, "move-2018", statements); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	sessionCred, sessionToken, _, err := newSessionCredentials("mover", []sessionGrant{{Policy: "move-2018", Bucket: bucketName}}, time.Hour)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	req, err := newTestRequest("POST", getRenamePrefixURL("", bucketName, "images/", "moved/"), 0, nil)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	req.Header.Set("X-Amz-Security-Token", sessionToken)
+	if err = signRequestV4(req, sessionCred.AccessKey, sessionCred.SecretKey); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	rec = httptest.NewRecorder()
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+	}
+	for response.Status = renamePrefixRunning; response.Status == renamePrefixRunning; {
+		time.Sleep(10 * time.Millisecond)
+		rec, response = doRequest("GET", "images/", "", credentials.AccessKey)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+		}
+	}
+	if response.Status != renamePrefixFinished || response.ObjectsMoved != 2 || response.ObjectsSkipped != 1 {
+		t.Fatalf("%s: Unexpected restricted rename progress %#v", instanceType, response)
+	}
+	if _, err = obj.GetObjectInfo(bucketName, "images/a.jpg"); err != nil {
+		t.Fatalf("%s: Expected images/a.jpg to be left in place, got %v", instanceType, err)
+	}
+	if _, err = obj.GetObjectInfo(bucketName, "moved/2018/b.jpg"); err != nil {
+		t.Fatalf("%s: Expected images/2018/b.jpg to be moved, got %v", instanceType, err)
+	}
+
+	// Restricted renames running when the server stopped fail.
+	if err = saveRenamePrefixStatus(obj, renamePrefixStatus{
+		Bucket:     bucketName,
+		Prefix:     "images/",
+		Target:     "moved/",
+		Status:     renamePrefixRunning,
+		StartTime:  UTCNow(),
+		Restricted: true,
+	}); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	globalRenamePrefixState = newRenamePrefixState()
+	if err = globalRenamePrefixState.Resume(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	rec, response = doRequest("GET", "images/", "", credentials.AccessKey)
+	if rec.Code != http.StatusOK || response.Status != renamePrefixFailed {
+		t.Fatalf("%s: Expected the restricted rename to fail, got %d %#v", instanceType, rec.Code, response)
+	}
+	if _, err = obj.GetObjectInfo(bucketName, "images/a.jpg"); err != nil {
+		t.Fatalf("%s: Expected images/a.jpg to be left in place, got %v", instanceType, err)
+	}
+
+	// Progress is removed with the bucket.
+	deleteBucketMetadata(bucketName, obj)
+	if statuses, err := listRenamePrefixStatuses(obj, bucketName); err != nil || len(statuses) != 0 {
+		t.Fatalf("%s: Expected no rename progress after removing the bucket, got %v %v", instanceType, statuses, err)
+	}
+}
+
+// Wrapper for calling prefix summary HTTP handler tests for both XL multiple disks and single node setup.
+func TestAPIPrefixSummaryHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIPrefixSummaryHandler, []string{"PrefixSummary"})
+}
+
+func testAPIPrefixSummaryHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	contentBytes := []byte("hello")
+	for i, objectName := range []string{"photos/a.jpg", "photos/2018/b.jpg", "other/c.jpg"} {
+		_, err := obj.PutObject(bucketName, objectName, mustGetHashReader(t, bytes.NewBuffer(contentBytes), int64(len(contentBytes)), "", ""), nil)
+		if err != nil {
+			t.Fatalf("Put Object %d:  Error uploading object: <ERROR> %v", i, err)
+		}
+	}
+
+	globalUsageCrawler = newUsageCrawler()
+	defer func() { globalUsageCrawler = nil }()
+
+	doRequest := func(method, prefix, accessKey string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, getPrefixSummaryURL("", bucketName, prefix),
+			0, nil, accessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for PrefixSummary: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Buckets not crawled yet have no summary.
+	if rec := doRequest("GET", "photos/", credentials.AccessKey); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusServiceUnavailable, rec.Code)
+	}
+	if err := globalUsageCrawler.Crawl(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	testCases := []struct {
+		method             string
+		prefix             string
+		accessKey          string
+		expectedRespStatus int
+		expectedObjects    int64
+	}{
+		// Test case - 1.
+		// Summary of a prefix.
+		{"GET", "photos/", credentials.AccessKey, http.StatusOK, 2},
+		// Test case - 2.
+		// Summary of the whole bucket.
+		{"GET", "", credentials.AccessKey, http.StatusOK, 3},
+		// Test case - 3.
+		// Summary in headers only.
+		{"HEAD", "photos/2018/", credentials.AccessKey, http.StatusOK, 1},
+		// Test case - 4.
+		// Prefix without objects.
+		{"GET", "unknown/", credentials.AccessKey, http.StatusOK, 0},
+		// Test case - 5.
+		// Prefix not ending with a slash.
+		{"GET", "photos", credentials.AccessKey, http.StatusBadRequest, 0},
+		// Test case - 6.
+		// Invalid access key.
+		{"GET", "photos/", "Invalid-AccessID", http.StatusForbidden, 0},
+	}
+	for i, testCase := range testCases {
+		rec := doRequest(testCase.method, testCase.prefix, testCase.accessKey)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		if testCase.method == "HEAD" {
+			if rec.Header().Get(minioObjectCount) != strconv.FormatInt(testCase.expectedObjects, 10) ||
+				rec.Header().Get(minioTotalSize) != strconv.FormatInt(testCase.expectedObjects*int64(len(contentBytes)), 10) {
+				t.Errorf("Test %d: %s: Unexpected summary headers %v", i+1, instanceType, rec.Header())
+			}
+			continue
+		}
+		var response PrefixSummaryResponse
+		if err := xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Test %d: %s: Failed to parse PrefixSummary response: <ERROR> %v", i+1, instanceType, err)
+		}
+		if response.Prefix != testCase.prefix || response.Objects != testCase.expectedObjects ||
+			response.Size != testCase.expectedObjects*int64(len(contentBytes)) {
+			t.Errorf("Test %d: %s: Unexpected summary %#v", i+1, instanceType, response)
+		}
+	}
+}
+
+// Wrapper for calling bucket statistics HTTP handler tests for both XL multiple disks and single node setup.
+func TestAPIBucketStatsHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIBucketStatsHandler, []string{"BucketStats"})
+}
+
+func testAPIBucketStatsHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	for i, objectName := range []string{"photos/a.jpg", "photos/2018/b.jpg", "other/c.jpg", "d.jpg"} {
+		contentBytes := bytes.Repeat([]byte("a"), i+1)
+		_, err := obj.PutObject(bucketName, objectName, mustGetHashReader(t, bytes.NewBuffer(contentBytes), int64(len(contentBytes)), "", ""), nil)
+		if err != nil {
+			t.Fatalf("Put Object %d:  Error uploading object: <ERROR> %v", i, err)
+		}
+	}
+
+	globalUsageCrawler = newUsageCrawler()
+	defer func() { globalUsageCrawler = nil }()
+
+	doRequest := func(maxPrefixes, accessKey string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("GET", getBucketStatsURL("", bucketName, maxPrefixes),
+			0, nil, accessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for BucketStats: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Buckets not crawled yet have no statistics.
+	if rec := doRequest("", credentials.AccessKey); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusServiceUnavailable, rec.Code)
+	}
+	if err := globalUsageCrawler.Crawl(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	testCases := []struct {
+		maxPrefixes        string
+		accessKey          string
+		expectedRespStatus int
+		expectedPrefixes   []PrefixStats
+	}{
+		// Test case - 1.
+		// Top prefixes by size.
+		{"", credentials.AccessKey, http.StatusOK, []PrefixStats{{"other/", 1, 3}, {"photos/", 2, 3}}},
+		// Test case - 2.
+		// Limited number of top prefixes.
+		{"1", credentials.AccessKey, http.StatusOK, []PrefixStats{{"other/", 1, 3}}},
+		// Test case - 3.
+		// Invalid number of top prefixes.
+		{"-1", credentials.AccessKey, http.StatusBadRequest, nil},
+		// Test case - 4.
+		// Invalid access key.
+		{"", "Invalid-AccessID", http.StatusForbidden, nil},
+	}
+	for i, testCase := range testCases {
+		rec := doRequest(testCase.maxPrefixes, testCase.accessKey)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var response BucketStatsResponse
+		if err := xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Test %d: %s: Failed to parse BucketStats response: <ERROR> %v", i+1, instanceType, err)
+		}
+		if response.Objects != 4 || response.Size != 10 || response.FirstObjectTime == "" {
+			t.Errorf("Test %d: %s: Unexpected statistics %#v", i+1, instanceType, response)
+		}
+		if len(response.AgeRanges) != len(bucketStatsAgeRanges)+1 || response.AgeRanges[0] != (ObjectAgeRange{MaxAgeDays: 1, Objects: 4, Size: 10}) {
+			t.Errorf("Test %d: %s: Unexpected age ranges %v", i+1, instanceType, response.AgeRanges)
+		}
+		if !reflect.DeepEqual(response.TopPrefixes, testCase.expectedPrefixes) {
+			t.Errorf("Test %d: %s: Expected top prefixes %v, got %v", i+1, instanceType, testCase.expectedPrefixes, response.TopPrefixes)
+		}
+	}
+
+	// Invalid number of top prefixes is reported as such.
+	rec := doRequest("-1", credentials.AccessKey)
+	var errResponse APIErrorResponse
+	if err := xml.Unmarshal(rec.Body.Bytes(), &errResponse); err != nil {
+		t.Fatalf("%s: Failed to parse error response: <ERROR> %v", instanceType, err)
+	}
+	if errResponse.Message != getAPIError(ErrInvalidMaxPrefixes).Description {
+		t.Errorf("%s: Expected error message %q, got %q", instanceType, getAPIError(ErrInvalidMaxPrefixes).Description, errResponse.Message)
+	}
+
+	// Persisted statistics are served until the bucket is crawled again.
+	globalUsageCrawler = newUsageCrawler()
+	if rec := doRequest("", credentials.AccessKey); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+	}
+}
+
+// Wrapper for calling DownloadManifest HTTP handler tests for both XL multiple disks and single node setup.
+func TestAPIDownloadManifestHandler(t *testing.T) {
+	defer func(kms KMS) { globalKMS = kms }(globalKMS)
+	ExecObjectLayerAPITest(t, testAPIDownloadManifestHandler, []string{"DownloadManifest", "GetObject"})
+}
+
+func testAPIDownloadManifestHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	contents := map[string][]byte{
+		"photos/a.jpg": []byte("hello"),
+		"photos/b.jpg": []byte("world!"),
+	}
+	for objectName, contentBytes := range contents {
+		_, err := obj.PutObject(bucketName, objectName, mustGetHashReader(t, bytes.NewBuffer(contentBytes), int64(len(contentBytes)), "", ""), nil)
+		if err != nil {
+			t.Fatalf("%s: Error uploading object %s: <ERROR> %v", instanceType, objectName, err)
+		}
+	}
+
+	doRequest := func(manifest DownloadManifestRequest, accessKey string) (*httptest.ResponseRecorder, DownloadManifestResponse) {
+		manifestBytes, err := xml.Marshal(manifest)
+		if err != nil {
+			t.Fatalf("%s: Failed to marshal download manifest request: <ERROR> %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("POST", getDownloadManifestURL("", bucketName),
+			int64(len(manifestBytes)), bytes.NewReader(manifestBytes), accessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for DownloadManifest: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		var response DownloadManifestResponse
+		if rec.Code == http.StatusOK {
+			if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("%s: Failed to parse DownloadManifest response: <ERROR> %v", instanceType, err)
+			}
+		}
+		return rec, response
+	}
+
+	doGet := func(location string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest("GET", location, 0, nil)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		req.Header.Del("x-amz-content-sha256")
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	testCases := []struct {
+		manifest           DownloadManifestRequest
+		accessKey          string
+		expectedRespStatus int
+	}{
+		// Test case - 1.
+		// No objects.
+		{DownloadManifestRequest{}, credentials.AccessKey, http.StatusBadRequest},
+		// Test case - 2.
+		// Expiry beyond 7 days.
+		{DownloadManifestRequest{Expires: 8 * 24 * 3600, Objects: []ObjectIdentifier{{"photos/a.jpg"}}}, credentials.AccessKey, http.StatusBadRequest},
+		// Test case - 3.
+		// Invalid access key.
+		{DownloadManifestRequest{Objects: []ObjectIdentifier{{"photos/a.jpg"}}}, "Invalid-AccessID", http.StatusForbidden},
+	}
+	for i, testCase := range testCases {
+		rec, _ := doRequest(testCase.manifest, testCase.accessKey)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Errorf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+	}
+
+	rec, response := doRequest(DownloadManifestRequest{
+		Expires: 600,
+		Archive: true,
+		Objects: []ObjectIdentifier{{"photos/a.jpg"}, {"photos/missing.jpg"}, {"photos/b.jpg"}},
+	}, credentials.AccessKey)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+	}
+	if len(response.Objects) != 2 || len(response.Errors) != 1 || response.Errors[0].Key != "photos/missing.jpg" {
+		t.Fatalf("%s: Unexpected download manifest %#v", instanceType, response)
+	}
+
+	// Every presigned URL downloads its object.
+	for _, object := range response.Objects {
+		rec = doGet(object.URL)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+		}
+		if !bytes.Equal(rec.Body.Bytes(), contents[object.Key]) {
+			t.Fatalf("%s: Expected object content %s, got %s", instanceType, contents[object.Key], rec.Body.Bytes())
+		}
+	}
+
+	// The archive URL downloads all found objects.
+	rec = doGet(response.ArchiveURL)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("%s: Failed to read archive: <ERROR> %v", instanceType, err)
+	}
+	if len(archive.File) != 2 {
+		t.Fatalf("%s: Expected 2 files in archive, got %d", instanceType, len(archive.File))
+	}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		data, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		if !bytes.Equal(data, contents[file.Name]) {
+			t.Fatalf("%s: Expected archived content %s, got %s", instanceType, contents[file.Name], data)
+		}
+	}
+
+	// Objects encrypted with SSE-S3 are archived decrypted.
+	globalKMS = newTestKMS()
+	encrypted := []byte("encrypted photo")
+	metadata := make(map[string]string)
+	reader, err := newKMSEncryptReader(bytes.NewReader(encrypted), globalKMS, metadata)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = obj.PutObject(bucketName, "photos/sse-s3.jpg", mustGetHashReader(t, reader, encryptedSize(int64(len(encrypted))), "", ""), metadata); err != nil {
+		t.Fatalf("%s: Error uploading object: <ERROR> %v", instanceType, err)
+	}
+	rec = doGet(getDownloadManifestArchiveURL("", bucketName, []ObjectIdentifier{{"photos/sse-s3.jpg"}}, time.Hour))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+	}
+	archive, err = zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil || len(archive.File) != 1 {
+		t.Fatalf("%s: Failed to read archive: <ERROR> %v", instanceType, err)
+	}
+	fileReader, err := archive.File[0].Open()
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	data, err := ioutil.ReadAll(fileReader)
+	fileReader.Close()
+	if err != nil || !bytes.Equal(data, encrypted) {
+		t.Fatalf("%s: Expected archived content %s, got %s: <ERROR> %v", instanceType, encrypted, data, err)
+	}
+
+	// Objects encrypted with SSE-C cannot be archived.
+	metadata = make(map[string]string)
+	reader, err = newEncryptReader(bytes.NewReader(encrypted), make([]byte, 32), metadata)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = obj.PutObject(bucketName, "photos/sse-c.jpg", mustGetHashReader(t, reader, encryptedSize(int64(len(encrypted))), "", ""), metadata); err != nil {
+		t.Fatalf("%s: Error uploading object: <ERROR> %v", instanceType, err)
+	}
+	rec = doGet(getDownloadManifestArchiveURL("", bucketName, []ObjectIdentifier{{"photos/a.jpg"}, {"photos/sse-c.jpg"}}, time.Hour))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusBadRequest, rec.Code)
+	}
+
+	// A tampered archive URL is refused.
+	rec = doGet(response.ArchiveURL + "&key=photos%2Fother.jpg")
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusForbidden, rec.Code)
+	}
+
+	// Temporary credentials only get URLs of objects they may read.
+	statements := []policy.Statement{
+		{
+			Actions:   set.CreateStringSet("s3:GetObject"),
+			Effect:    "Allow",
+			Principal: policy.User{AWS: set.CreateStringSet("*")},
+			Resources: set.CreateStringSet(bucketARNPrefix + managedPolicyBucketVar + "/photos/a.jpg"),
+		},
+	}
+	if _, err = putManagedPolicy(obj, auditCaller{AccessKey: "minio"}, "read-a", statements); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	sessionCred, sessionToken, _, err := newSessionCredentials("viewer", []sessionGrant{{Policy: "read-a", Bucket: bucketName}}, time.Hour)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	manifestBytes, err := xml.Marshal(DownloadManifestRequest{
+		Archive: true,
+		Objects: []ObjectIdentifier{{"photos/a.jpg"}, {"photos/b.jpg"}, {"photos/missing.jpg"}},
+	})
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	req, err := newTestRequest("POST", getDownloadManifestURL("", bucketName), int64(len(manifestBytes)), bytes.NewReader(manifestBytes))
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	req.Header.Set("X-Amz-Security-Token", sessionToken)
+	if err = signRequestV4(req, sessionCred.AccessKey, sessionCred.SecretKey); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	rec = httptest.NewRecorder()
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+	}
+	response = DownloadManifestResponse{}
+	if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(response.Objects) != 1 || response.Objects[0].Key != "photos/a.jpg" {
+		t.Fatalf("%s: Expected only photos/a.jpg to be signed, got %#v", instanceType, response.Objects)
+	}
+	// Denied objects are reported alike whether they exist or not.
+	if len(response.Errors) != 2 || response.Errors[0].Code != "AccessDenied" || response.Errors[1].Code != "AccessDenied" {
+		t.Fatalf("%s: Expected denied objects to be reported, got %#v", instanceType, response.Errors)
+	}
+}
+
+// Wrapper for calling GetObjects HTTP handler tests for both XL multiple disks and single node setup.
+func TestAPIGetObjectsHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIGetObjectsHandler, []string{"GetObjects"})
+}
+
+func testAPIGetObjectsHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	contents := map[string][]byte{
+		"images/a.png":   []byte("hello"),
+		"images/b c.png": []byte("world!"),
+	}
+	for objectName, contentBytes := range contents {
+		_, err := obj.PutObject(bucketName, objectName, mustGetHashReader(t, bytes.NewBuffer(contentBytes), int64(len(contentBytes)), "", ""), nil)
+		if err != nil {
+			t.Fatalf("%s: Error uploading object %s: <ERROR> %v", instanceType, objectName, err)
+		}
+	}
+
+	doRequest := func(request GetObjectsRequest, accessKey string, anonymous bool) *httptest.ResponseRecorder {
+		requestBytes, err := xml.Marshal(request)
+		if err != nil {
+			t.Fatalf("%s: Failed to marshal get objects request: <ERROR> %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		var req *http.Request
+		if anonymous {
+			req, err = newTestRequest("POST", getGetObjectsURL("", bucketName), int64(len(requestBytes)), bytes.NewReader(requestBytes))
+		} else {
+			req, err = newTestSignedRequestV4("POST", getGetObjectsURL("", bucketName),
+				int64(len(requestBytes)), bytes.NewReader(requestBytes), accessKey, credentials.SecretKey)
+		}
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for GetObjects: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Returns the key, error code and content of every part.
+	readParts := func(rec *httptest.ResponseRecorder) (keys, codes []string, data [][]byte) {
+		mediaType, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+		if err != nil || mediaType != "multipart/mixed" {
+			t.Fatalf("%s: Unexpected content type %s: <ERROR> %v", instanceType, rec.Header().Get("Content-Type"), err)
+		}
+		reader := multipart.NewReader(rec.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return keys, codes, data
+			}
+			if err != nil {
+				t.Fatalf("%s: Failed to read part: <ERROR> %v", instanceType, err)
+			}
+			partData, err := ioutil.ReadAll(part)
+			if err != nil {
+				t.Fatalf("%s: Failed to read part: <ERROR> %v", instanceType, err)
+			}
+			key, err := url.QueryUnescape(part.Header.Get("X-Minio-Key"))
+			if err != nil {
+				t.Fatalf("%s: Invalid key %s: <ERROR> %v", instanceType, part.Header.Get("X-Minio-Key"), err)
+			}
+			keys = append(keys, key)
+			codes = append(codes, part.Header.Get("X-Minio-Error-Code"))
+			data = append(data, partData)
+		}
+	}
+
+	testCases := []struct {
+		request            GetObjectsRequest
+		accessKey          string
+		expectedRespStatus int
+	}{
+		// Test case - 1.
+		// No objects.
+		{GetObjectsRequest{}, credentials.AccessKey, http.StatusBadRequest},
+		// Test case - 2.
+		// Invalid object name.
+		{GetObjectsRequest{Objects: []ObjectIdentifier{{"images/a.png"}, {""}}}, credentials.AccessKey, http.StatusBadRequest},
+		// Test case - 3.
+		// Invalid access key.
+		{GetObjectsRequest{Objects: []ObjectIdentifier{{"images/a.png"}}}, "Invalid-AccessID", http.StatusForbidden},
+	}
+	for i, testCase := range testCases {
+		rec := doRequest(testCase.request, testCase.accessKey, false)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Errorf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+	}
+
+	// Objects are sent in the requested order, missing objects as errors.
+	request := GetObjectsRequest{Objects: []ObjectIdentifier{{"images/b c.png"}, {"images/missing.png"}, {"images/a.png"}}}
+	rec := doRequest(request, credentials.AccessKey, false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+	}
+	keys, codes, data := readParts(rec)
+	if !reflect.DeepEqual(keys, []string{"images/b c.png", "images/missing.png", "images/a.png"}) {
+		t.Fatalf("%s: Unexpected keys %v", instanceType, keys)
+	}
+	if !reflect.DeepEqual(codes, []string{"", "NoSuchKey", ""}) {
+		t.Fatalf("%s: Unexpected error codes %v", instanceType, codes)
+	}
+	for i, key := range keys {
+		if codes[i] == "" && !bytes.Equal(data[i], contents[key]) {
+			t.Fatalf("%s: Expected object content %s, got %s", instanceType, contents[key], data[i])
+		}
+	}
+
+	// Anonymous requests are refused every object without a bucket policy.
+	rec = doRequest(request, "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+	}
+	if _, codes, _ = readParts(rec); !reflect.DeepEqual(codes, []string{"AccessDenied", "AccessDenied", "AccessDenied"}) {
+		t.Fatalf("%s: Unexpected error codes of anonymous request %v", instanceType, codes)
+	}
+
+	// The bucket policy is evaluated per object with the conditions of
+	// the request.
+	statement := getReadOnlyObjectStatement(bucketName, "images/a")
+	statement.Conditions = policy.ConditionMap{
+		"StringLike": policy.ConditionKeyMap{"aws:Referer": set.CreateStringSet("https://example.com/*")},
+	}
+	if err := obj.SetBucketPolicy(bucketName, policy.BucketAccessPolicy{Version: "1.0", Statements: []policy.Statement{statement}}); err != nil {
+		t.Fatalf("%s: Failed to set bucket policy: <ERROR> %v", instanceType, err)
+	}
+	for _, referer := range []string{"", "https://example.com/gallery"} {
+		requestBytes, err := xml.Marshal(request)
+		if err != nil {
+			t.Fatalf("%s: Failed to marshal get objects request: <ERROR> %v", instanceType, err)
+		}
+		req, err := newTestRequest("POST", getGetObjectsURL("", bucketName), int64(len(requestBytes)), bytes.NewReader(requestBytes))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for GetObjects: <ERROR> %v", instanceType, err)
+		}
+		req.Header.Set("Referer", referer)
+		rec = httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		expected := []string{"AccessDenied", "AccessDenied", "AccessDenied"}
+		if referer != "" {
+			expected = []string{"AccessDenied", "AccessDenied", ""}
+		}
+		if _, codes, _ = readParts(rec); !reflect.DeepEqual(codes, expected) {
+			t.Fatalf("%s: Unexpected error codes of anonymous request with referer %q: %v", instanceType, referer, codes)
+		}
+	}
+}
+
+// Wrapper for calling ListObjectsV2 HTTP handler tests for both XL multiple disks and single node setup.
+func TestAPIListObjectsV2Handler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIListObjectsV2Handler, []string{"ListObjectsV2"})
+}
+
+func testAPIListObjectsV2Handler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	contentBytes := []byte("hello")
+	for i, objectName := range []string{"a", "b c", "d/e"} {
+		_, err := obj.PutObject(bucketName, objectName, mustGetHashReader(t, bytes.NewBuffer(contentBytes), int64(len(contentBytes)), "", ""), nil)
+		if err != nil {
+			t.Fatalf("Put Object %d:  Error uploading object: <ERROR> %v", i, err)
+		}
+	}
+
+	list := func(query url.Values) (*httptest.ResponseRecorder, ListObjectsV2Response) {
+		query.Set("list-type", "2")
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("GET", makeTestTargetURL("", bucketName, "", query),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for ListObjectsV2: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		var response ListObjectsV2Response
+		if rec.Code == http.StatusOK {
+			if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("%s: Failed to parse ListObjectsV2 response: <ERROR> %v", instanceType, err)
+			}
+		}
+		return rec, response
+	}
+
+	// List one object at a time with continuation tokens.
+	var keys []string
+	var token string
+	for {
+		query := url.Values{"max-keys": {"1"}, "encoding-type": {"url"}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		rec, response := list(query)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+		}
+		if response.KeyCount != 1 || response.EncodingType != "url" || response.ContinuationToken != token {
+			t.Fatalf("%s: Unexpected response %#v", instanceType, response)
+		}
+		if response.Contents[0].Owner != nil {
+			t.Errorf("%s: Expected no owner without fetch-owner", instanceType)
+		}
+		keys = append(keys, response.Contents[0].Key)
+		if !response.IsTruncated {
+			break
+		}
+		token = response.NextContinuationToken
+	}
+	if strings.Join(keys, ",") != "a,b%20c,d/e" {
+		t.Errorf("%s: Unexpected keys %v", instanceType, keys)
+	}
+
+	// start-after skips to the given key, fetch-owner returns owners.
+	_, response := list(url.Values{"start-after": {"a"}, "fetch-owner": {"true"}, "delimiter": {"/"}})
+	if response.StartAfter != "a" || len(response.Contents) != 1 || response.Contents[0].Key != "b c" ||
+		len(response.CommonPrefixes) != 1 || response.CommonPrefixes[0].Prefix != "d/" || response.KeyCount != 2 {
+		t.Errorf("%s: Unexpected response %#v", instanceType, response)
+	}
+	if response.Contents[0].Owner == nil || response.Contents[0].Owner.ID != globalMinioDefaultOwnerID {
+		t.Errorf("%s: Expected owner with fetch-owner", instanceType)
+	}
+
+	// Invalid continuation tokens and encoding types are rejected.
+	for _, query := range []url.Values{{"continuation-token": {"a"}}, {"encoding-type": {"base64"}}} {
+		if rec, _ := list(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestAPIListObjectsFastHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIListObjectsFastHandler, []string{"ListObjectsFast"})
+}
+
+func testAPIListObjectsFastHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	// More objects than fit in a single batch, some of them nested.
+	var objectNames []string
+	for i := 0; i < maxObjectList+4; i++ {
+		objectNames = append(objectNames, fmt.Sprintf("dir%d/object%04d", i%2, i))
+	}
+	contentBytes := []byte("hello")
+	for i, objectName := range objectNames {
+		_, err := obj.PutObject(bucketName, objectName, mustGetHashReader(t, bytes.NewBuffer(contentBytes), int64(len(contentBytes)), "", ""),
+			map[string]string{"content-type": "text/plain"})
+		if err != nil {
+			t.Fatalf("Put Object %d:  Error uploading object: <ERROR> %v", i, err)
+		}
+	}
+	sort.Strings(objectNames)
+
+	list := func(bucket string, query url.Values) (*httptest.ResponseRecorder, []fastListBatch) {
+		query.Set("fast-list", "")
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("GET", makeTestTargetURL("", bucket, "", query),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for ListObjectsFast: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		var batches []fastListBatch
+		if rec.Code == http.StatusOK {
+			decoder := json.NewDecoder(rec.Body)
+			for decoder.More() {
+				var batch fastListBatch
+				if err = decoder.Decode(&batch); err != nil {
+					t.Fatalf("%s: Failed to parse ListObjectsFast response: <ERROR> %v", instanceType, err)
+				}
+				batches = append(batches, batch)
+			}
+		}
+		return rec, batches
+	}
+
+	testCases := []struct {
+		query    url.Values
+		expected []string
+	}{
+		{url.Values{}, objectNames},
+		{url.Values{"prefix": {"dir1/"}}, objectNames[len(objectNames)/2:]},
+		{url.Values{"start-after": {objectNames[9]}}, objectNames[10:]},
+	}
+	for i, testCase := range testCases {
+		rec, batches := list(bucketName, testCase.query)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Test %d: Expected the response status to be `%d`, but instead found `%d`", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+		var names []string
+		for j, batch := range batches {
+			if batch.Error != "" || batch.IsTruncated != (j < len(batches)-1) {
+				t.Fatalf("%s: Test %d: Unexpected batch %d %v %s", instanceType, i+1, j, batch.IsTruncated, batch.Error)
+			}
+			for _, object := range batch.Objects {
+				if object.Size != int64(len(contentBytes)) || object.ETag == "" || object.ContentType != "text/plain" || object.LastModified.IsZero() {
+					t.Fatalf("%s: Test %d: Unexpected object %#v", instanceType, i+1, object)
+				}
+				names = append(names, object.Name)
+			}
+		}
+		if !reflect.DeepEqual(names, testCase.expected) {
+			t.Errorf("%s: Test %d: Expected %d objects, got %d", instanceType, i+1, len(testCase.expected), len(names))
+		}
+	}
+
+	// Errors listing the first batch are returned as the response status.
+	if rec, _ := list("non-existent-bucket", url.Values{}); rec.Code != http.StatusNotFound {
+		t.Errorf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusNotFound, rec.Code)
+	}
+}
+
+// Tests finding buckets a bucket policy grants any access to.
+func TestBucketPolicyGrantsAccess(t *testing.T) {
+	statement := func(effect string, resources ...string) policy.Statement {
+		return policy.Statement{
+			Effect:    effect,
+			Actions:   set.CreateStringSet("s3:GetObject"),
+			Principal: policy.User{AWS: set.CreateStringSet("*")},
+			Resources: set.CreateStringSet(resources...),
+		}
+	}
+	ipStatement := statement("Allow", "arn:aws:s3:::bucket/*")
+	ipStatement.Conditions = policy.ConditionMap{
+		"IpAddress": policy.ConditionKeyMap{"aws:SourceIp": set.CreateStringSet("10.0.0.0/8")},
+	}
+	testCases := []struct {
+		statements []policy.Statement
+		sourceIP   string
+		granted    bool
+	}{
+		{nil, "", false},
+		{[]policy.Statement{statement("Allow", "arn:aws:s3:::bucket")}, "", true},
+		{[]policy.Statement{statement("Allow", "arn:aws:s3:::bucket/public/*")}, "", true},
+		{[]policy.Statement{statement("Allow", "arn:aws:s3:::buck*/*")}, "", true},
+		{[]policy.Statement{statement("Allow", "arn:aws:s3:::other/*")}, "", false},
+		{[]policy.Statement{statement("Deny", "arn:aws:s3:::bucket/*")}, "", false},
+		{[]policy.Statement{ipStatement}, "10.1.2.3", true},
+		{[]policy.Statement{ipStatement}, "192.168.1.1", false},
+	}
+	for i, testCase := range testCases {
+		conditions := getConditionKeyMap("", testCase.sourceIP, nil)
+		if granted := bucketPolicyGrantsAccess("bucket", conditions, testCase.statements); granted != testCase.granted {
+			t.Errorf("Test %d: Expected %v but got %v", i+1, testCase.granted, granted)
+		}
+	}
+}
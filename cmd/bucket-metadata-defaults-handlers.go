@@ -0,0 +1,151 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Maximum size of metadata defaults configurations sent by clients.
+const maxMetadataDefaultsConfigSize = 256 * 1024
+
+// PutBucketMetadataDefaultsHandler - PUT /bucket?metadata-defaults
+// ----------
+// Sets the content types by extension, cache control, content
+// disposition and storage class of objects uploaded to the bucket
+// without them.
+func (api objectAPIHandlers) PutBucketMetadataDefaultsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := mux.Vars(r)["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+	if globalBucketMetadataDefaults == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:PutBucketMetadataDefaults", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	var configuration MetadataDefaultsConfiguration
+	if err := xmlDecoder(r.Body, &configuration, maxMetadataDefaultsConfigSize); err != nil {
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+	cfg, err := parseMetadataDefaultsConfiguration(configuration)
+	if err != nil {
+		writeErrorResponse(w, ErrInvalidMetadataDefaults, r.URL)
+		return
+	}
+
+	if err = saveMetadataDefaultsConfig(bucket, cfg, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	globalBucketMetadataDefaults.Set(bucket, cfg)
+
+	// Notify all peers (including self) to reload the config.
+	S3PeersUpdateBucketConfig(bucket, bucketMetadataDefaultsConfig)
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetBucketMetadataDefaultsHandler - GET /bucket?metadata-defaults
+// ----------
+// Returns the metadata defaults configuration of the bucket.
+func (api objectAPIHandlers) GetBucketMetadataDefaultsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := mux.Vars(r)["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+	if globalBucketMetadataDefaults == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetBucketMetadataDefaults", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	cfg, ok := globalBucketMetadataDefaults.Get(bucket)
+	if !ok {
+		writeErrorResponse(w, ErrNoSuchMetadataDefaults, r.URL)
+		return
+	}
+	writeSuccessResponseXML(w, encodeResponse(cfg.toMetadataDefaultsConfiguration()))
+}
+
+// DeleteBucketMetadataDefaultsHandler - DELETE /bucket?metadata-defaults
+// ----------
+// Removes the metadata defaults configuration of the bucket, objects
+// are stored with the metadata of their uploads only from then on.
+func (api objectAPIHandlers) DeleteBucketMetadataDefaultsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := mux.Vars(r)["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+	if globalBucketMetadataDefaults == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	// Deleting the configuration needs the same permission as setting it.
+	if s3Error := checkRequestAuthType(r, bucket, "s3:PutBucketMetadataDefaults", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	if err := removeBucketConfig(bucket, bucketMetadataDefaultsConfig, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	globalBucketMetadataDefaults.Remove(bucket)
+
+	// Notify all peers (including self) to reload the config.
+	S3PeersUpdateBucketConfig(bucket, bucketMetadataDefaultsConfig)
+
+	writeSuccessNoContent(w)
+}
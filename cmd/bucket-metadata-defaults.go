@@ -0,0 +1,221 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// Metadata defaults of a bucket, persisted under the bucket config
+	// prefix.
+	bucketMetadataDefaultsConfig = "metadata-defaults.json"
+
+	// Current version of metadata defaults configs.
+	bucketMetadataDefaultsVersion = "1"
+
+	// Maximum number of content types of a metadata defaults
+	// configuration.
+	maxMetadataDefaultsContentTypes = 1000
+
+	// Maximum length of default header values.
+	maxMetadataDefaultsValueLength = 1024
+
+	// Content type many clients send for any file, replaced by the
+	// content type of the extension of the object.
+	genericContentType = "application/octet-stream"
+)
+
+// MetadataDefaultsConfiguration - headers set on objects uploaded to a
+// bucket without them, set and returned by the metadata-defaults
+// subresource.
+type MetadataDefaultsConfiguration struct {
+	XMLName            xml.Name             `xml:"MetadataDefaultsConfiguration"`
+	ContentTypes       []ContentTypeDefault `xml:"ContentType"`
+	CacheControl       string               `xml:"CacheControl,omitempty"`
+	ContentDisposition string               `xml:"ContentDisposition,omitempty"`
+	StorageClass       string               `xml:"StorageClass,omitempty"`
+}
+
+// ContentTypeDefault - content type of objects with a file name
+// extension, the extension is given without the leading dot.
+type ContentTypeDefault struct {
+	Extension string `xml:"Extension"`
+	Type      string `xml:"Type"`
+}
+
+// metadataDefaultsConfig - metadata defaults of a bucket, only buckets
+// with a metadata defaults configuration have one.
+type metadataDefaultsConfig struct {
+	Version string `json:"version"`
+
+	// Content types by lower case extension without the leading dot.
+	ContentTypes       map[string]string `json:"contentTypes,omitempty"`
+	CacheControl       string            `json:"cacheControl,omitempty"`
+	ContentDisposition string            `json:"contentDisposition,omitempty"`
+
+	// Storage class of objects uploaded without one, its parity is
+	// used on erasure coded disks.
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+// isValidMetadataDefaultsValue - returns true if value can be sent as
+// a header.
+func isValidMetadataDefaultsValue(value string) bool {
+	return len(value) <= maxMetadataDefaultsValueLength && !strings.ContainsAny(value, "\r\n\x00")
+}
+
+// Validate - checks the content types and header values of the config.
+func (c metadataDefaultsConfig) Validate() error {
+	if len(c.ContentTypes) == 0 && c.CacheControl == "" && c.ContentDisposition == "" && c.StorageClass == "" {
+		return fmt.Errorf("Metadata defaults configuration needs at least one default")
+	}
+	if len(c.ContentTypes) > maxMetadataDefaultsContentTypes {
+		return fmt.Errorf("Metadata defaults configuration has more than %d content types", maxMetadataDefaultsContentTypes)
+	}
+	for extension, contentType := range c.ContentTypes {
+		if extension == "" || extension != strings.ToLower(extension) || strings.ContainsAny(extension, "./") {
+			return fmt.Errorf("Invalid extension %q", extension)
+		}
+		if _, _, err := mime.ParseMediaType(contentType); err != nil || !isValidMetadataDefaultsValue(contentType) {
+			return fmt.Errorf("Invalid content type %q", contentType)
+		}
+	}
+	if !isValidMetadataDefaultsValue(c.CacheControl) || !isValidMetadataDefaultsValue(c.ContentDisposition) {
+		return fmt.Errorf("Invalid header value")
+	}
+	if c.StorageClass != "" && !isValidStorageClassMeta(c.StorageClass) {
+		return fmt.Errorf("Invalid storage class %q", c.StorageClass)
+	}
+	return nil
+}
+
+// parseMetadataDefaultsConfiguration - converts a metadata defaults
+// configuration set by a client into the config of a bucket.
+// Extensions are case insensitive and may not be listed twice.
+func parseMetadataDefaultsConfiguration(configuration MetadataDefaultsConfiguration) (cfg metadataDefaultsConfig, err error) {
+	if len(configuration.ContentTypes) > 0 {
+		cfg.ContentTypes = make(map[string]string, len(configuration.ContentTypes))
+	}
+	for _, contentType := range configuration.ContentTypes {
+		extension := strings.ToLower(strings.TrimSpace(contentType.Extension))
+		if _, ok := cfg.ContentTypes[extension]; ok {
+			return cfg, fmt.Errorf("Extension %q is listed twice", extension)
+		}
+		cfg.ContentTypes[extension] = strings.TrimSpace(contentType.Type)
+	}
+	cfg.CacheControl = strings.TrimSpace(configuration.CacheControl)
+	cfg.ContentDisposition = strings.TrimSpace(configuration.ContentDisposition)
+	cfg.StorageClass = strings.TrimSpace(configuration.StorageClass)
+	return cfg, cfg.Validate()
+}
+
+// toMetadataDefaultsConfiguration - converts the config of a bucket
+// into the metadata defaults configuration returned to clients, with
+// content types sorted by extension.
+func (c metadataDefaultsConfig) toMetadataDefaultsConfiguration() MetadataDefaultsConfiguration {
+	configuration := MetadataDefaultsConfiguration{
+		CacheControl:       c.CacheControl,
+		ContentDisposition: c.ContentDisposition,
+		StorageClass:       c.StorageClass,
+	}
+	for extension, contentType := range c.ContentTypes {
+		configuration.ContentTypes = append(configuration.ContentTypes, ContentTypeDefault{
+			Extension: extension,
+			Type:      contentType,
+		})
+	}
+	sort.Slice(configuration.ContentTypes, func(i, j int) bool {
+		return configuration.ContentTypes[i].Extension < configuration.ContentTypes[j].Extension
+	})
+	return configuration
+}
+
+// apply - sets the defaults missing from the metadata of an object
+// uploaded to the bucket, headers sent with the upload, including the
+// storage class, are kept. A
+// generic content type is replaced by the content type of the
+// extension of the object as well.
+func (c metadataDefaultsConfig) apply(object string, metadata map[string]string) {
+	contentType := strings.ToLower(strings.TrimSpace(metadata["content-type"]))
+	if contentType == "" || contentType == genericContentType {
+		extension := strings.ToLower(strings.TrimPrefix(path.Ext(object), "."))
+		if defaultType, ok := c.ContentTypes[extension]; ok && extension != "" {
+			metadata["content-type"] = defaultType
+		}
+	}
+	if _, ok := metadata["cache-control"]; !ok && c.CacheControl != "" {
+		metadata["cache-control"] = c.CacheControl
+	}
+	if _, ok := metadata["content-disposition"]; !ok && c.ContentDisposition != "" {
+		metadata["content-disposition"] = c.ContentDisposition
+	}
+	if _, ok := metadata[amzStorageClass]; !ok && c.StorageClass != "" {
+		metadata[amzStorageClass] = c.StorageClass
+	}
+}
+
+// Persists the metadata defaults config of a bucket to object layer.
+func saveMetadataDefaultsConfig(bucket string, cfg metadataDefaultsConfig, objAPI ObjectLayer) error {
+	cfg.Version = bucketMetadataDefaultsVersion
+	return saveBucketConfig(bucket, bucketMetadataDefaultsConfig, cfg, objAPI)
+}
+
+// bucketMetadataDefaults - metadata defaults configs of all buckets
+// with a metadata defaults configuration.
+type bucketMetadataDefaults struct {
+	*bucketConfigCache
+}
+
+func newBucketMetadataDefaults() *bucketMetadataDefaults {
+	return &bucketMetadataDefaults{newBucketConfigCache(bucketMetadataDefaultsConfig, func() interface{} { return &metadataDefaultsConfig{} })}
+}
+
+// Global metadata defaults configs of buckets, only initialized by the
+// server, gateways keep the metadata of uploads as sent.
+var globalBucketMetadataDefaults *bucketMetadataDefaults
+
+// Set - sets the metadata defaults config of a bucket on this server.
+func (b *bucketMetadataDefaults) Set(bucket string, cfg metadataDefaultsConfig) {
+	b.set(bucket, &cfg)
+}
+
+// Get - returns the metadata defaults config of a bucket, false if the
+// bucket has no metadata defaults configuration.
+func (b *bucketMetadataDefaults) Get(bucket string) (metadataDefaultsConfig, bool) {
+	if b == nil {
+		return metadataDefaultsConfig{}, false
+	}
+	cfg, ok := b.get(bucket)
+	if !ok {
+		return metadataDefaultsConfig{}, false
+	}
+	return *cfg.(*metadataDefaultsConfig), true
+}
+
+// applyMetadataDefaults - sets the metadata defaults of the bucket
+// missing from the metadata of an object uploaded to it.
+func applyMetadataDefaults(bucket, object string, metadata map[string]string) {
+	if cfg, ok := globalBucketMetadataDefaults.Get(bucket); ok {
+		cfg.apply(object, metadata)
+	}
+}
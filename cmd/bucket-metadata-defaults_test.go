@@ -0,0 +1,167 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/minio/minio/pkg/auth"
+)
+
+// Tests validating metadata defaults configurations of clients.
+func TestParseMetadataDefaultsConfiguration(t *testing.T) {
+	contentType := func(extension, typ string) string {
+		return `<ContentType><Extension>` + extension + `</Extension><Type>` + typ + `</Type></ContentType>`
+	}
+	testCases := []struct {
+		config  string
+		success bool
+	}{
+		{contentType("html", "text/html; charset=utf-8"), true},
+		{contentType("CSS", "text/css") + `<CacheControl>max-age=3600</CacheControl>`, true},
+		{`<ContentDisposition>attachment</ContentDisposition>`, true},
+		{`<StorageClass>REDUCED_REDUNDANCY</StorageClass>`, true},
+		{`<StorageClass>GLACIER</StorageClass>`, false},
+		{"", false},
+		{contentType("", "text/html"), false},
+		{contentType(".html", "text/html"), false},
+		{contentType("html", ""), false},
+		{contentType("html", "text/html") + contentType("HTML", "text/plain"), false},
+		{`<CacheControl>max-age=3600&#13;&#10;X-Injected: true</CacheControl>`, false},
+	}
+	for i, testCase := range testCases {
+		var configuration MetadataDefaultsConfiguration
+		if err := xml.Unmarshal([]byte(`<MetadataDefaultsConfiguration>`+testCase.config+`</MetadataDefaultsConfiguration>`), &configuration); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if _, err := parseMetadataDefaultsConfiguration(configuration); testCase.success != (err == nil) {
+			t.Fatalf("Test %d: Expected success %v but got %v", i+1, testCase.success, err)
+		}
+	}
+}
+
+// Tests that defaults are only set on uploads without the headers.
+func TestMetadataDefaultsApply(t *testing.T) {
+	cfg := metadataDefaultsConfig{
+		ContentTypes: map[string]string{"html": "text/html", "js": "application/javascript"},
+		CacheControl: "max-age=3600",
+	}
+	testCases := []struct {
+		object   string
+		metadata map[string]string
+		expected map[string]string
+	}{
+		{"index.html", map[string]string{},
+			map[string]string{"content-type": "text/html", "cache-control": "max-age=3600"}},
+		{"app/main.JS", map[string]string{"content-type": "application/octet-stream"},
+			map[string]string{"content-type": "application/javascript", "cache-control": "max-age=3600"}},
+		{"index.html", map[string]string{"content-type": "text/plain", "cache-control": "no-cache"},
+			map[string]string{"content-type": "text/plain", "cache-control": "no-cache"}},
+		{"image.png", map[string]string{"content-type": "application/octet-stream"},
+			map[string]string{"content-type": "application/octet-stream", "cache-control": "max-age=3600"}},
+		{"html", map[string]string{},
+			map[string]string{"cache-control": "max-age=3600"}},
+	}
+	for i, testCase := range testCases {
+		cfg.apply(testCase.object, testCase.metadata)
+		if !reflect.DeepEqual(testCase.metadata, testCase.expected) {
+			t.Errorf("Test %d: Expected %v but got %v", i+1, testCase.expected, testCase.metadata)
+		}
+	}
+
+	// The storage class of uploads is kept, uploads without one get
+	// the default.
+	cfg = metadataDefaultsConfig{StorageClass: reducedRedundancyStorageClass}
+	metadata := map[string]string{}
+	if cfg.apply("object", metadata); metadata[amzStorageClass] != reducedRedundancyStorageClass {
+		t.Errorf("Expected the default storage class, got %v", metadata)
+	}
+	metadata = map[string]string{amzStorageClass: standardStorageClass}
+	if cfg.apply("object", metadata); metadata[amzStorageClass] != standardStorageClass {
+		t.Errorf("Expected the storage class of the upload, got %v", metadata)
+	}
+}
+
+// Tests the metadata defaults configuration APIs.
+func TestAPIBucketMetadataDefaultsHandlers(t *testing.T) {
+	defer func() { globalBucketMetadataDefaults = nil }()
+	ExecObjectLayerAPITest(t, testAPIBucketMetadataDefaultsHandlers, []string{"BucketMetadataDefaults"})
+}
+
+func testAPIBucketMetadataDefaultsHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	globalBucketMetadataDefaults = newBucketMetadataDefaults()
+
+	doRequest := func(method, urlStr string, body []byte) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader(body), credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	expectStatus := func(rec *httptest.ResponseRecorder, status int, msg string) {
+		if rec.Code != status {
+			t.Fatalf("%s: %s: Expected the response status to be `%d`, but instead found `%d`: %s", instanceType, msg, status, rec.Code, rec.Body.String())
+		}
+	}
+
+	expectStatus(doRequest("GET", getBucketMetadataDefaultsURL("", bucketName), nil), http.StatusNotFound, "get config")
+	expectStatus(doRequest("PUT", getBucketMetadataDefaultsURL("", bucketName),
+		[]byte(`<MetadataDefaultsConfiguration></MetadataDefaultsConfiguration>`)), http.StatusBadRequest, "empty config")
+
+	config := []byte(`<MetadataDefaultsConfiguration>` +
+		`<ContentType><Extension>js</Extension><Type>application/javascript</Type></ContentType>` +
+		`<ContentType><Extension>CSS</Extension><Type>text/css</Type></ContentType>` +
+		`<CacheControl>max-age=3600</CacheControl></MetadataDefaultsConfiguration>`)
+	expectStatus(doRequest("PUT", getBucketMetadataDefaultsURL("", bucketName), config), http.StatusOK, "put config")
+	if _, ok := globalBucketMetadataDefaults.Get(bucketName); !ok {
+		t.Fatalf("%s: Expected the config to be set", instanceType)
+	}
+	rec := doRequest("GET", getBucketMetadataDefaultsURL("", bucketName), nil)
+	expectStatus(rec, http.StatusOK, "get config")
+	var configuration MetadataDefaultsConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &configuration); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	expected := []ContentTypeDefault{
+		{Extension: "css", Type: "text/css"},
+		{Extension: "js", Type: "application/javascript"},
+	}
+	if !reflect.DeepEqual(configuration.ContentTypes, expected) || configuration.CacheControl != "max-age=3600" {
+		t.Fatalf("%s: Unexpected configuration %+v", instanceType, configuration)
+	}
+
+	// The config is loaded again by peers.
+	globalBucketMetadataDefaults = newBucketMetadataDefaults()
+	if err := globalBucketMetadataDefaults.Init(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if cfg, ok := globalBucketMetadataDefaults.Get(bucketName); !ok || cfg.ContentTypes["css"] != "text/css" {
+		t.Fatalf("%s: Unexpected config %+v after reload", instanceType, cfg)
+	}
+
+	expectStatus(doRequest("DELETE", getBucketMetadataDefaultsURL("", bucketName), nil), http.StatusNoContent, "delete config")
+	expectStatus(doRequest("GET", getBucketMetadataDefaultsURL("", bucketName), nil), http.StatusNotFound, "get deleted config")
+}
@@ -28,6 +28,12 @@ type BucketMetaState interface {
 	// Updates bucket policy
 	UpdateBucketPolicy(args *SetBucketPolicyPeerArgs) error
 
+	// Updates a bucket config file
+	UpdateBucketConfig(args *SetBucketConfigPeerArgs) error
+
+	// Invalidates cached object metadata
+	InvalidateObjectMetadata(args *InvalidateObjectMetadataPeerArgs) error
+
 	// Sends event
 	SendEvent(args *EventArgs) error
 }
@@ -77,9 +83,32 @@ func (lc *localBucketMetaState) UpdateBucketPolicy(args *SetBucketPolicyPeerArgs
 	if objAPI == nil {
 		return errServerNotInitialized
 	}
+	defer globalBucketPolicyCache.invalidate(args.Bucket)
 	return objAPI.RefreshBucketPolicy(args.Bucket)
 }
 
+// localBucketMetaState.UpdateBucketConfig - reloads in-memory global
+// bucket configs saved as the config file.
+func (lc *localBucketMetaState) UpdateBucketConfig(args *SetBucketConfigPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+	configs := getBucketConfigs(args.ConfigFile)
+	if configs == nil {
+		return nil
+	}
+	return configs.Refresh(objAPI, args.Bucket)
+}
+
+// localBucketMetaState.InvalidateObjectMetadata - drops cached object
+// info of the objects changed by a peer.
+func (lc *localBucketMetaState) InvalidateObjectMetadata(args *InvalidateObjectMetadataPeerArgs) error {
+	globalXLMetadataCache.invalidate(args.Bucket, args.Objects)
+	return nil
+}
+
 // localBucketMetaState.SendEvent - sends event to local event notifier via
 // `globalEventNotifier`
 func (lc *localBucketMetaState) SendEvent(args *EventArgs) error {
@@ -118,6 +147,20 @@ func (rc *remoteBucketMetaState) UpdateBucketPolicy(args *SetBucketPolicyPeerArg
 	return rc.Call("S3.SetBucketPolicyPeer", args, &reply)
 }
 
+// remoteBucketMetaState.UpdateBucketConfig - sends bucket config
+// change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketConfig(args *SetBucketConfigPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketConfigPeer", args, &reply)
+}
+
+// remoteBucketMetaState.InvalidateObjectMetadata - sends changed
+// objects to remote peer via RPC call.
+func (rc *remoteBucketMetaState) InvalidateObjectMetadata(args *InvalidateObjectMetadataPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.InvalidateObjectMetadataPeer", args, &reply)
+}
+
 // remoteBucketMetaState.SendEvent - sends event for bucket listener to remote
 // peer via RPC call.
 func (rc *remoteBucketMetaState) SendEvent(args *EventArgs) error {
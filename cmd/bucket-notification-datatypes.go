@@ -47,6 +47,10 @@ type ServiceConfig struct {
 type queueConfig struct {
 	ServiceConfig
 	QueueARN string `xml:"Queue"`
+
+	// Minio extension, Go template rendering the payload sent to
+	// the queue instead of the S3 event.
+	Template string `xml:"Template,omitempty"`
 }
 
 // Topic SNS configuration, this is a compliance field not used by minio yet.
@@ -123,6 +127,15 @@ func (eventName EventName) String() string {
 	}
 }
 
+// isObjectCreatedEvent - returns true if the event reports an upload.
+func isObjectCreatedEvent(eventName EventName) bool {
+	switch eventName {
+	case ObjectCreatedPut, ObjectCreatedPost, ObjectCreatedCopy, ObjectCreatedCompleteMultipartUpload:
+		return true
+	}
+	return false
+}
+
 // Indentity represents the accessKey who caused the event.
 type identity struct {
 	PrincipalID string `json:"principalId"`
@@ -0,0 +1,114 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"text/template"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Maximum size of a notification template.
+const maxNotificationTemplateSize = 16 * 1024
+
+// Field of notification log entries holding the templated payload.
+const notificationPayloadKey = "Payload"
+
+// notificationPayload - event payload rendered from the template
+// of a queue configuration, sent to targets as is.
+type notificationPayload []byte
+
+// notificationTemplateData - data available to notification templates.
+type notificationTemplateData struct {
+	EventType string
+	Key       string
+	Event     NotificationEvent
+	Records   []NotificationEvent
+}
+
+// Functions available to notification templates.
+var notificationTemplateFuncs = template.FuncMap{
+	// json - encodes a value as JSON, strings are quoted and escaped.
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// parseNotificationTemplate - parses the template of a queue configuration.
+func parseNotificationTemplate(text string) (*template.Template, error) {
+	return template.New("notification").Funcs(notificationTemplateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// renderNotificationTemplate - renders the payload of an event.
+func renderNotificationTemplate(text, eventType, key string, records []NotificationEvent) (notificationPayload, error) {
+	tmpl, err := parseNotificationTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+	data := notificationTemplateData{
+		EventType: eventType,
+		Key:       key,
+		Records:   records,
+	}
+	if len(records) > 0 {
+		data.Event = records[0]
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return notificationPayload(buf.Bytes()), nil
+}
+
+// Checks if the template of a queue configuration is valid and
+// supported by its target. Targets storing events in a fixed schema
+// like elasticsearch, redis, postgresql and mysql do not support
+// templates.
+func checkQueueTemplate(qConfig queueConfig) APIErrorCode {
+	if qConfig.Template == "" {
+		return ErrNone
+	}
+	switch unmarshalSqsARN(qConfig.QueueARN).Type {
+	case queueTypeAMQP, queueTypeMQTT, queueTypeNATS, queueTypeKafka, queueTypeWebhook:
+	default:
+		return ErrUnsupportedNotificationTemplate
+	}
+	if len(qConfig.Template) > maxNotificationTemplateSize {
+		return ErrInvalidNotificationTemplate
+	}
+	if _, err := parseNotificationTemplate(qConfig.Template); err != nil {
+		return ErrInvalidNotificationTemplate
+	}
+	return ErrNone
+}
+
+// notificationFormatter - formats notification log entries as JSON,
+// templated payloads are sent as is.
+type notificationFormatter struct {
+	logrus.JSONFormatter
+}
+
+// Format - returns the payload of the entry.
+func (f *notificationFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if payload, ok := entry.Data[notificationPayloadKey].(notificationPayload); ok {
+		return payload, nil
+	}
+	return f.JSONFormatter.Format(entry)
+}
@@ -0,0 +1,123 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Tests rendering event payloads from notification templates.
+func TestRenderNotificationTemplate(t *testing.T) {
+	records := []NotificationEvent{{
+		EventName: ObjectCreatedPut.String(),
+		S3: eventMeta{
+			Bucket: bucketMeta{Name: "images"},
+			Object: objectMeta{Key: "photos/\"cat\".jpg", Size: 1024},
+		},
+	}}
+
+	testCases := []struct {
+		template   string
+		payload    string
+		shouldPass bool
+	}{
+		{`{{.EventType}} {{.Key}}`, `s3:ObjectCreated:Put images/photos/"cat".jpg`, true},
+		{`{"bucket":{{json .Event.S3.Bucket.Name}},"key":{{json .Event.S3.Object.Key}},"size":{{.Event.S3.Object.Size}}}`, `{"bucket":"images","key":"photos/\"cat\".jpg","size":1024}`, true},
+		{`{{range .Records}}{{.EventName}}{{end}}`, `s3:ObjectCreated:Put`, true},
+		// Unknown fields.
+		{`{{.Event.Unknown}}`, ``, false},
+		// Not a template.
+		{`{{.EventType`, ``, false},
+	}
+	for i, testCase := range testCases {
+		payload, err := renderNotificationTemplate(testCase.template, ObjectCreatedPut.String(), "images/photos/\"cat\".jpg", records)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Unable to render template. %v", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected an error rendering %q", i+1, testCase.template)
+		}
+		if err == nil && string(payload) != testCase.payload {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.payload, payload)
+		}
+	}
+}
+
+// Tests validation of templates in queue configurations.
+func TestCheckQueueTemplate(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer os.RemoveAll(rootPath)
+
+	testCases := []struct {
+		queueType string
+		template  string
+		s3Error   APIErrorCode
+	}{
+		{queueTypeWebhook, "", ErrNone},
+		{queueTypeWebhook, `{"key":{{json .Key}}}`, ErrNone},
+		{queueTypeAMQP, `{{.EventType}}`, ErrNone},
+		{queueTypeWebhook, `{{.EventType`, ErrInvalidNotificationTemplate},
+		{queueTypeWebhook, strings.Repeat("a", maxNotificationTemplateSize+1), ErrInvalidNotificationTemplate},
+		{queueTypeElastic, `{{.EventType}}`, ErrUnsupportedNotificationTemplate},
+		{queueTypeRedis, "", ErrNone},
+	}
+	for i, testCase := range testCases {
+		qConfig := queueConfig{
+			QueueARN: arnSQS{Type: testCase.queueType, AccountID: "1"}.String(),
+			Template: testCase.template,
+		}
+		if s3Error := checkQueueTemplate(qConfig); s3Error != testCase.s3Error {
+			t.Errorf("Test %d: Expected %d, got %d", i+1, testCase.s3Error, s3Error)
+		}
+	}
+}
+
+// Tests that templated payloads are sent as is and other entries as JSON.
+func TestNotificationFormatter(t *testing.T) {
+	formatter := new(notificationFormatter)
+
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"EventType":            ObjectCreatedPut.String(),
+		notificationPayloadKey: notificationPayload("images/cat.jpg"),
+	})
+	data, err := formatter.Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "images/cat.jpg" {
+		t.Fatalf("Expected templated payload, got %s", data)
+	}
+
+	entry = logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"EventType": ObjectCreatedPut.String(),
+	})
+	if data, err = formatter.Format(entry); err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(data, &fields); err != nil || fields["EventType"] != ObjectCreatedPut.String() {
+		t.Fatalf("Expected JSON entry, got %s", data)
+	}
+}
@@ -201,6 +201,11 @@ func checkQueueConfig(qConfig queueConfig) APIErrorCode {
 		return s3Error
 	}
 
+	// Check if a valid template is set in queue config.
+	if s3Error := checkQueueTemplate(qConfig); s3Error != ErrNone {
+		return s3Error
+	}
+
 	// Success.
 	return ErrNone
 }
@@ -0,0 +1,173 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/pkg/policy"
+)
+
+// Bucket policies changed on the backend of a gateway are not
+// noticed, gateways cache bucket policies for this long only.
+const gatewayBucketPolicyCacheTTL = time.Minute
+
+// bucketPolicyStater - gateway layers able to stat the policy file of
+// a bucket on their backend, e.g. NAS gateways sharing a volume. Policies
+// cached by such gateways are checked against it on every request
+// instead of expiring, changes made through another gateway apply
+// immediately.
+type bucketPolicyStater interface {
+	// Returns nil if the bucket has no policy.
+	statBucketPolicy(bucket string) (os.FileInfo, error)
+}
+
+// bucketPolicyCache - bucket policies anonymous requests are evaluated
+// against, by bucket. Buckets without a policy are cached with an
+// empty policy. Servers drop cached policies when notified of a policy
+// change by a peer, gateways when the policy is changed through the
+// gateway, the policy file changed on the backend or the cached policy
+// expires.
+type bucketPolicyCache struct {
+	mu       sync.RWMutex
+	ttl      time.Duration // Cached policies never expire if zero.
+	policies map[string]cachedBucketPolicy
+
+	// Set for gateways whose backend can stat policy files.
+	stater bucketPolicyStater
+
+	// Incremented by invalidate, policies loaded while a policy was
+	// invalidated may be stale and are not cached.
+	version uint64
+}
+
+type cachedBucketPolicy struct {
+	policy policy.BucketAccessPolicy
+	loaded time.Time
+	stated bool        // Set if the policy file was stated when loaded.
+	stat   os.FileInfo // Nil if the bucket had no policy.
+}
+
+// Returns true if both stats of a policy file are of the same version.
+func isSameBucketPolicyStat(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+func newBucketPolicyCache(ttl time.Duration) *bucketPolicyCache {
+	return &bucketPolicyCache{
+		ttl:      ttl,
+		policies: make(map[string]cachedBucketPolicy),
+	}
+}
+
+// get - returns the policy of the bucket, an empty policy if the
+// bucket has no policy. The bucket is verified to exist before its
+// policy is cached.
+func (c *bucketPolicyCache) get(objAPI ObjectLayer, bucket string) (policy.BucketAccessPolicy, error) {
+	return c.load(objAPI, bucket, true)
+}
+
+// getListed - returns the policy of a bucket just returned by
+// ListBuckets, policies not cached yet are loaded without verifying
+// again that the bucket exists.
+func (c *bucketPolicyCache) getListed(objAPI ObjectLayer, bucket string) (policy.BucketAccessPolicy, error) {
+	return c.load(objAPI, bucket, false)
+}
+
+func (c *bucketPolicyCache) load(objAPI ObjectLayer, bucket string, checkExist bool) (policy.BucketAccessPolicy, error) {
+	c.mu.RLock()
+	cached, ok := c.policies[bucket]
+	version := c.version
+	c.mu.RUnlock()
+
+	// Policy files which cannot be stated expire as usual.
+	var stat os.FileInfo
+	stated := false
+	if c.stater != nil {
+		var err error
+		stat, err = c.stater.statBucketPolicy(bucket)
+		stated = err == nil
+	}
+	if ok && stated && cached.stated && isSameBucketPolicyStat(stat, cached.stat) {
+		return cached.policy, nil
+	}
+	if ok && !stated && (c.ttl == 0 || UTCNow().Sub(cached.loaded) < c.ttl) {
+		return cached.policy, nil
+	}
+
+	if checkExist {
+		if err := checkBucketExist(bucket, objAPI); err != nil {
+			return emptyBucketPolicy, err
+		}
+	}
+	// Stated before the policy is read, a policy changed meanwhile is
+	// read again by the next request.
+	cached = cachedBucketPolicy{loaded: UTCNow(), stated: stated, stat: stat}
+	p, err := objAPI.GetBucketPolicy(bucket)
+	if err != nil && !isErrBucketPolicyNotFound(err) {
+		return emptyBucketPolicy, err
+	}
+	if err == nil {
+		cached.policy = p
+	}
+
+	c.mu.Lock()
+	if c.version == version {
+		c.policies[bucket] = cached
+	}
+	c.mu.Unlock()
+	return cached.policy, nil
+}
+
+// invalidate - drops the cached policy of the bucket, it is loaded
+// again by the next anonymous request.
+func (c *bucketPolicyCache) invalidate(bucket string) {
+	c.mu.Lock()
+	delete(c.policies, bucket)
+	c.version++
+	c.mu.Unlock()
+}
+
+// gatewayPolicyCacheLayer - drops cached bucket policies changed
+// through the gateway, gateways are not notified by peers.
+type gatewayPolicyCacheLayer struct {
+	ObjectLayer
+}
+
+func newGatewayPolicyCacheLayer(objAPI ObjectLayer) ObjectLayer {
+	return &gatewayPolicyCacheLayer{ObjectLayer: objAPI}
+}
+
+func (l *gatewayPolicyCacheLayer) SetBucketPolicy(bucket string, policyInfo policy.BucketAccessPolicy) error {
+	defer globalBucketPolicyCache.invalidate(bucket)
+	return l.ObjectLayer.SetBucketPolicy(bucket, policyInfo)
+}
+
+func (l *gatewayPolicyCacheLayer) DeleteBucketPolicy(bucket string) error {
+	defer globalBucketPolicyCache.invalidate(bucket)
+	return l.ObjectLayer.DeleteBucketPolicy(bucket)
+}
+
+func (l *gatewayPolicyCacheLayer) DeleteBucket(bucket string) error {
+	defer globalBucketPolicyCache.invalidate(bucket)
+	return l.ObjectLayer.DeleteBucket(bucket)
+}
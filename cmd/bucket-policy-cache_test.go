@@ -0,0 +1,187 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/pkg/policy"
+	"github.com/minio/minio/pkg/errors"
+)
+
+// Tests caching and invalidation of bucket policies.
+func TestBucketPolicyCache(t *testing.T) {
+	ExecObjectLayerTest(t, testBucketPolicyCache)
+}
+
+func testBucketPolicyCache(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := getRandomBucketName()
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: Failed to create bucket: %v", instanceType, err)
+	}
+	bucketPolicy := policy.BucketAccessPolicy{
+		Version:    "1.0",
+		Statements: policy.SetPolicy(nil, policy.BucketPolicyReadOnly, bucket, ""),
+	}
+
+	cache := newBucketPolicyCache(0)
+	p, err := cache.get(obj, bucket)
+	if err != nil {
+		t.Fatalf("%s: Unexpected error %v", instanceType, err)
+	}
+	if !reflect.DeepEqual(p, emptyBucketPolicy) {
+		t.Fatalf("%s: Expected no policy but got %v", instanceType, p)
+	}
+
+	// Policies written without notifying peers are not noticed until
+	// the cached policy is invalidated.
+	if err = writeBucketPolicy(bucket, obj, bucketPolicy); err != nil {
+		t.Fatalf("%s: Failed to write bucket policy: %v", instanceType, err)
+	}
+	if p, _ = cache.get(obj, bucket); !reflect.DeepEqual(p, emptyBucketPolicy) {
+		t.Fatalf("%s: Expected cached empty policy but got %v", instanceType, p)
+	}
+	cache.invalidate(bucket)
+	if p, _ = cache.get(obj, bucket); !reflect.DeepEqual(p, bucketPolicy) {
+		t.Fatalf("%s: Expected %v but got %v", instanceType, bucketPolicy, p)
+	}
+
+	// Cached policies expire after the TTL.
+	cache = newBucketPolicyCache(time.Nanosecond)
+	if p, _ = cache.get(obj, bucket); !reflect.DeepEqual(p, bucketPolicy) {
+		t.Fatalf("%s: Expected %v but got %v", instanceType, bucketPolicy, p)
+	}
+	if err = removeBucketPolicy(bucket, obj); err != nil {
+		t.Fatalf("%s: Failed to remove bucket policy: %v", instanceType, err)
+	}
+	time.Sleep(time.Millisecond)
+	if p, _ = cache.get(obj, bucket); !reflect.DeepEqual(p, emptyBucketPolicy) {
+		t.Fatalf("%s: Expected expired policy to be reloaded but got %v", instanceType, p)
+	}
+
+	// Policies of missing buckets are not cached.
+	missingBucket := getRandomBucketName()
+	_, err = cache.get(obj, missingBucket)
+	if _, ok := errors.Cause(err).(BucketNotFound); !ok {
+		t.Fatalf("%s: Expected BucketNotFound but got %v", instanceType, err)
+	}
+	if _, ok := cache.policies[missingBucket]; ok {
+		t.Fatalf("%s: Expected policy of missing bucket not to be cached", instanceType)
+	}
+}
+
+// Tests that policies changed through the gateway are not served from
+// the cache.
+func TestGatewayPolicyCacheLayer(t *testing.T) {
+	ExecObjectLayerTest(t, testGatewayPolicyCacheLayer)
+}
+
+func testGatewayPolicyCacheLayer(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	defer func(cache *bucketPolicyCache) { globalBucketPolicyCache = cache }(globalBucketPolicyCache)
+	globalBucketPolicyCache = newBucketPolicyCache(gatewayBucketPolicyCacheTTL)
+
+	obj = newGatewayPolicyCacheLayer(obj)
+	bucket := getRandomBucketName()
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: Failed to create bucket: %v", instanceType, err)
+	}
+	if p, _ := globalBucketPolicyCache.get(obj, bucket); !reflect.DeepEqual(p, emptyBucketPolicy) {
+		t.Fatalf("%s: Expected no policy but got %v", instanceType, p)
+	}
+
+	bucketPolicy := policy.BucketAccessPolicy{
+		Version:    "1.0",
+		Statements: policy.SetPolicy(nil, policy.BucketPolicyReadOnly, bucket, ""),
+	}
+	if err := obj.SetBucketPolicy(bucket, bucketPolicy); err != nil {
+		t.Fatalf("%s: Failed to set bucket policy: %v", instanceType, err)
+	}
+	if p, _ := globalBucketPolicyCache.get(obj, bucket); !reflect.DeepEqual(p, bucketPolicy) {
+		t.Fatalf("%s: Expected %v but got %v", instanceType, bucketPolicy, p)
+	}
+
+	if err := obj.DeleteBucketPolicy(bucket); err != nil {
+		t.Fatalf("%s: Failed to delete bucket policy: %v", instanceType, err)
+	}
+	if p, _ := globalBucketPolicyCache.get(obj, bucket); !reflect.DeepEqual(p, emptyBucketPolicy) {
+		t.Fatalf("%s: Expected no policy but got %v", instanceType, p)
+	}
+}
+
+// nasTestObjects - reads bucket policies from disk like NAS gateways,
+// which do not cache them.
+type nasTestObjects struct {
+	*FSObjects
+}
+
+func (l nasTestObjects) GetBucketPolicy(bucket string) (policy.BucketAccessPolicy, error) {
+	return ReadBucketPolicy(bucket, l)
+}
+
+// Tests that policies changed on the backend by another gateway are
+// noticed by gateways stating the policy files.
+func TestBucketPolicyCacheStat(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	obj, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+	layer := nasTestObjects{obj.(*FSObjects)}
+
+	bucket := getRandomBucketName()
+	if err = layer.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	bucketPolicy := policy.BucketAccessPolicy{
+		Version:    "1.0",
+		Statements: policy.SetPolicy(nil, policy.BucketPolicyReadOnly, bucket, ""),
+	}
+
+	cache := newBucketPolicyCache(time.Hour)
+	cache.stater = layer
+	if p, _ := cache.get(layer, bucket); !reflect.DeepEqual(p, emptyBucketPolicy) {
+		t.Fatalf("Expected no policy but got %v", p)
+	}
+
+	// Written by another gateway, without invalidating the cache.
+	if err = writeBucketPolicy(bucket, layer, bucketPolicy); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := cache.get(layer, bucket); !reflect.DeepEqual(p, bucketPolicy) {
+		t.Fatalf("Expected %v but got %v", bucketPolicy, p)
+	}
+	if p, _ := cache.get(layer, bucket); !reflect.DeepEqual(p, bucketPolicy) {
+		t.Fatalf("Expected cached %v but got %v", bucketPolicy, p)
+	}
+
+	// Removed by another gateway.
+	if err = removeBucketPolicy(bucket, layer); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := cache.get(layer, bucket); !reflect.DeepEqual(p, emptyBucketPolicy) {
+		t.Fatalf("Expected removed policy not to be served but got %v", p)
+	}
+}
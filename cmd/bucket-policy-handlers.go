@@ -273,6 +273,18 @@ func (api objectAPIHandlers) PutBucketPolicyHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
+	// Bucket policies of managed policies are changed through them.
+	if err = checkBucketPolicyNotManaged(objAPI, bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	oldPolicy, err := getChangeLogBucketPolicy(objAPI, bucket)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	if err = objAPI.SetBucketPolicy(bucket, policyInfo); err != nil {
 		err = errors.Cause(err)
 		switch err.(type) {
@@ -284,6 +296,11 @@ func (api objectAPIHandlers) PutBucketPolicyHandler(w http.ResponseWriter, r *ht
 		}
 		return
 	}
+	if err := recordChange(objAPI, getChangeActor(r), changeTypeBucketPolicy, bucket, oldPolicy, policyInfo); err != nil {
+		errorIf(err, "Unable to record policy change of bucket %s.", bucket)
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
 
 	// Success.
 	writeSuccessNoContent(w)
@@ -315,12 +332,29 @@ func (api objectAPIHandlers) DeleteBucketPolicyHandler(w http.ResponseWriter, r
 		return
 	}
 
+	// Bucket policies of managed policies are changed through them.
+	if err = checkBucketPolicyNotManaged(objAPI, bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	oldPolicy, err := getChangeLogBucketPolicy(objAPI, bucket)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	// Delete bucket access policy, by passing an empty policy
 	// struct.
 	if err := objAPI.DeleteBucketPolicy(bucket); err != nil {
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
+	if err := recordChange(objAPI, getChangeActor(r), changeTypeBucketPolicy, bucket, oldPolicy, nil); err != nil {
+		errorIf(err, "Unable to record policy change of bucket %s.", bucket)
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
 
 	// Success.
 	writeSuccessNoContent(w)
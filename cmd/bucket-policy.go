@@ -87,11 +87,16 @@ func initBucketPolicies(objAPI ObjectLayer) (*bucketPolicies, error) {
 		return nil, errors.Cause(err)
 	}
 
+	// Loads bucket policies in parallel.
+	bps := make([]policy.BucketAccessPolicy, len(buckets))
+	pErrs := runBootTasks(bootStagePolicies, len(buckets), bootBucketConcurrency, func(index int) (pErr error) {
+		bps[index], pErr = ReadBucketPolicy(buckets[index].Name, objAPI)
+		return pErr
+	})
+
 	policies := make(map[string]policy.BucketAccessPolicy)
-	// Loads bucket policy.
-	for _, bucket := range buckets {
-		bp, pErr := ReadBucketPolicy(bucket.Name, objAPI)
-		if pErr != nil {
+	for index, bucket := range buckets {
+		if pErr := pErrs[index]; pErr != nil {
 			// net.Dial fails for rpc client or any
 			// other unexpected errors during net.Dial.
 			if !errors.IsErrIgnored(pErr, errDiskNotFound) {
@@ -102,7 +107,7 @@ func initBucketPolicies(objAPI ObjectLayer) (*bucketPolicies, error) {
 			// Continue to load other bucket policies if possible.
 			continue
 		}
-		policies[bucket.Name] = bp
+		policies[bucket.Name] = bps[index]
 	}
 
 	// Return all bucket policies.
@@ -0,0 +1,368 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+const (
+	// Recency feed of a bucket, persisted under the bucket config prefix.
+	bucketRecentObjectsConfig = "recent-objects.json"
+
+	// Maximum number of uploads remembered per bucket.
+	recentObjectsFeedSize = 1000
+
+	// Interval at which recency feeds are persisted.
+	recentObjectsSaveInterval = 5 * time.Minute
+
+	// Current version of the persisted recency feed.
+	recentObjectsVersion = "1"
+)
+
+// recentObject - a single upload recorded in the recency feed of a bucket.
+type recentObject struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ETag    string    `json:"etag"`
+	ModTime time.Time `json:"lastModified"`
+}
+
+// recentObjectsFile - persisted format of a recency feed, entries are
+// sorted newest first.
+type recentObjectsFile struct {
+	Version string         `json:"version"`
+	Entries []recentObject `json:"entries"`
+}
+
+// recentObjectsFeed - fixed size ring buffer of the latest uploads to a bucket.
+type recentObjectsFeed struct {
+	entries []recentObject
+	next    int // Slot for the next upload.
+	count   int // Number of valid entries.
+
+	loaded bool // Persisted feed has been merged in.
+	dirty  bool // Uploads not persisted yet.
+}
+
+func newRecentObjectsFeed(size int) *recentObjectsFeed {
+	return &recentObjectsFeed{entries: make([]recentObject, size)}
+}
+
+// add - records an upload, overwriting the oldest entry once the feed is full.
+func (f *recentObjectsFeed) add(entry recentObject) {
+	f.entries[f.next] = entry
+	f.next = (f.next + 1) % len(f.entries)
+	if f.count < len(f.entries) {
+		f.count++
+	}
+	f.dirty = true
+}
+
+// list - returns all entries of the feed, newest first.
+func (f *recentObjectsFeed) list() []recentObject {
+	entries := make([]recentObject, 0, f.count)
+	for i := 1; i <= f.count; i++ {
+		entries = append(entries, f.entries[(f.next-i+len(f.entries))%len(f.entries)])
+	}
+	return entries
+}
+
+// reset - replaces the content of the feed with entries sorted newest first.
+func (f *recentObjectsFeed) reset(entries []recentObject) {
+	if len(entries) > len(f.entries) {
+		entries = entries[:len(f.entries)]
+	}
+	dirty := f.dirty
+	f.next, f.count = 0, 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		f.add(entries[i])
+	}
+	f.dirty = dirty
+}
+
+// mergeRecentObjects - merges recency feeds into a single list sorted
+// newest first, only the latest upload of an object is retained.
+func mergeRecentObjects(maxEntries int, feeds ...[]recentObject) []recentObject {
+	var merged []recentObject
+	for _, feed := range feeds {
+		merged = append(merged, feed...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].ModTime.After(merged[j].ModTime)
+	})
+
+	seen := make(map[string]struct{}, len(merged))
+	entries := make([]recentObject, 0, len(merged))
+	for _, entry := range merged {
+		if _, ok := seen[entry.Name]; ok {
+			continue
+		}
+		seen[entry.Name] = struct{}{}
+		entries = append(entries, entry)
+		if len(entries) == maxEntries {
+			break
+		}
+	}
+	return entries
+}
+
+// recentObjects - recency feeds of all buckets, uploads are recorded
+// in memory and persisted periodically. Each server merges the
+// persisted feed with its own uploads, so in a distributed setup
+// uploads served by other servers show up once they are persisted.
+// Feeds are read and written outside the lock, uploads are not held
+// up by the object layer.
+type recentObjects struct {
+	sync.Mutex
+	size  int
+	feeds map[string]*recentObjectsFeed
+
+	// Serializes persisting feeds.
+	saveMu sync.Mutex
+}
+
+func newRecentObjects(size int) *recentObjects {
+	return &recentObjects{
+		size:  size,
+		feeds: make(map[string]*recentObjectsFeed),
+	}
+}
+
+// Global recency feeds, only initialized by the server.
+var globalRecentObjects *recentObjects
+
+// getFeed - returns the feed of a bucket, must be called with the lock held.
+func (r *recentObjects) getFeed(bucket string) *recentObjectsFeed {
+	feed, ok := r.feeds[bucket]
+	if !ok {
+		feed = newRecentObjectsFeed(r.size)
+		r.feeds[bucket] = feed
+	}
+	return feed
+}
+
+// Add - records an upload in the feed of a bucket.
+func (r *recentObjects) Add(bucket string, objInfo ObjectInfo) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.getFeed(bucket).add(recentObject{
+		Name:    objInfo.Name,
+		Size:    objInfo.Size,
+		ETag:    objInfo.ETag,
+		ModTime: objInfo.ModTime,
+	})
+}
+
+// Remove - drops the feed of a bucket, used when the bucket is deleted.
+func (r *recentObjects) Remove(bucket string) {
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.feeds, bucket)
+}
+
+// isLoaded - returns true if the persisted feed of a bucket has been
+// merged in.
+func (r *recentObjects) isLoaded(bucket string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.getFeed(bucket).loaded
+}
+
+// merge - merges entries read from or written to the persisted feed
+// into the feed of a bucket, uploads recorded meanwhile are kept.
+func (r *recentObjects) merge(bucket string, entries []recentObject) {
+	r.Lock()
+	defer r.Unlock()
+
+	feed := r.getFeed(bucket)
+	feed.reset(mergeRecentObjects(r.size, feed.list(), entries))
+	feed.loaded = true
+}
+
+// List - returns up to maxEntries latest uploads to a bucket, newest first.
+func (r *recentObjects) List(objAPI ObjectLayer, bucket string, maxEntries int) ([]recentObject, error) {
+	if !r.isLoaded(bucket) {
+		persisted, err := loadRecentObjects(bucket, objAPI)
+		if err != nil {
+			return nil, err
+		}
+		r.merge(bucket, persisted)
+	}
+
+	r.Lock()
+	entries := r.getFeed(bucket).list()
+	r.Unlock()
+
+	if maxEntries > 0 && len(entries) > maxEntries {
+		entries = entries[:maxEntries]
+	}
+	return entries, nil
+}
+
+// Save - persists the feeds of all buckets with new uploads. Feeds are
+// snapshot under the lock and written outside it, feeds failing to be
+// written are written again by the next save. Feeds of buckets deleted
+// meanwhile are dropped.
+func (r *recentObjects) Save(objAPI ObjectLayer) {
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+
+	r.Lock()
+	snapshots := make(map[string][]recentObject)
+	for bucket, feed := range r.feeds {
+		if feed.dirty {
+			snapshots[bucket] = feed.list()
+			feed.dirty = false
+		}
+	}
+	r.Unlock()
+
+	for bucket, snapshot := range snapshots {
+		if _, err := objAPI.GetBucketInfo(bucket); isErrBucketNotFound(err) {
+			r.Remove(bucket)
+			continue
+		}
+
+		// Merge uploads persisted by other servers.
+		persisted, err := loadRecentObjects(bucket, objAPI)
+		if err == nil {
+			snapshot = mergeRecentObjects(r.size, snapshot, persisted)
+			err = persistRecentObjects(bucket, snapshot, objAPI)
+		}
+		if err != nil {
+			errorIf(err, "Unable to save recency feed of bucket %s.", bucket)
+			r.setDirty(bucket)
+			continue
+		}
+
+		// The bucket may be deleted while the feed is written, after
+		// its feed was removed.
+		if _, err = objAPI.GetBucketInfo(bucket); isErrBucketNotFound(err) {
+			errorIf(removeRecentObjects(bucket, objAPI), "Unable to remove recency feed of deleted bucket %s.", bucket)
+			r.Remove(bucket)
+			continue
+		}
+		r.mergeSaved(bucket, snapshot)
+	}
+}
+
+// mergeSaved - merges a feed just persisted into the feed of a bucket,
+// unless the bucket was removed meanwhile.
+func (r *recentObjects) mergeSaved(bucket string, entries []recentObject) {
+	r.Lock()
+	defer r.Unlock()
+
+	if feed, ok := r.feeds[bucket]; ok {
+		feed.reset(mergeRecentObjects(r.size, feed.list(), entries))
+		feed.loaded = true
+	}
+}
+
+// setDirty - marks the feed of a bucket to be persisted again, unless
+// the bucket was removed meanwhile.
+func (r *recentObjects) setDirty(bucket string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if feed, ok := r.feeds[bucket]; ok {
+		feed.dirty = true
+	}
+}
+
+// Start a routine persisting recency feeds periodically.
+func startRecentObjectsPersistence(r *recentObjects, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		for {
+			select {
+			case <-globalServiceDoneCh:
+				// Persist recent uploads before exiting.
+				ticker.Stop()
+				if objAPI := newObjectLayerFn(); objAPI != nil {
+					r.Save(objAPI)
+				}
+				return
+			case <-ticker.C:
+				if objAPI := newObjectLayerFn(); objAPI != nil {
+					r.Save(objAPI)
+				}
+			}
+		}
+	}()
+}
+
+// Loads the persisted recency feed of a bucket, returns an
+// empty feed if none is persisted yet.
+func loadRecentObjects(bucket string, objAPI ObjectLayer) ([]recentObject, error) {
+	rcPath := path.Join(bucketConfigPrefix, bucket, bucketRecentObjectsConfig)
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, rcPath, 0, -1, &buffer, "")
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if buffer.Len() == 0 {
+		return nil, nil
+	}
+
+	rcFile := recentObjectsFile{}
+	if err = json.Unmarshal(buffer.Bytes(), &rcFile); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return rcFile.Entries, nil
+}
+
+// Persists the recency feed of a bucket to object layer.
+func persistRecentObjects(bucket string, entries []recentObject, objAPI ObjectLayer) error {
+	buf, err := json.Marshal(recentObjectsFile{
+		Version: recentObjectsVersion,
+		Entries: entries,
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	rcPath := path.Join(bucketConfigPrefix, bucket, bucketRecentObjectsConfig)
+	hashReader, err := hash.NewReader(bytes.NewReader(buf), int64(len(buf)), "", getSHA256Hash(buf))
+	if err != nil {
+		return err
+	}
+	_, err = objAPI.PutObject(minioMetaBucket, rcPath, hashReader, nil)
+	return err
+}
+
+// Removes the persisted recency feed of a bucket, only used during DeleteBucket.
+func removeRecentObjects(bucket string, objAPI ObjectLayer) error {
+	rcPath := path.Join(bucketConfigPrefix, bucket, bucketRecentObjectsConfig)
+
+	return objAPI.DeleteObject(minioMetaBucket, rcPath)
+}
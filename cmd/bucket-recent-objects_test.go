@@ -0,0 +1,148 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Tests that the feed retains only the latest uploads, newest first.
+func TestRecentObjectsFeed(t *testing.T) {
+	feed := newRecentObjectsFeed(3)
+	if entries := feed.list(); len(entries) != 0 {
+		t.Fatalf("Expected empty feed, got %v", entries)
+	}
+
+	for i := 1; i <= 5; i++ {
+		feed.add(recentObject{Name: fmt.Sprintf("object%d", i)})
+	}
+
+	entries := feed.list()
+	expected := []string{"object5", "object4", "object3"}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, name := range expected {
+		if entries[i].Name != name {
+			t.Errorf("Entry %d: Expected %s, got %s", i+1, name, entries[i].Name)
+		}
+	}
+
+	// Reset retains the order of entries.
+	feed.reset(entries[:2])
+	if entries = feed.list(); len(entries) != 2 || entries[0].Name != "object5" || entries[1].Name != "object4" {
+		t.Fatalf("Unexpected entries after reset %v", entries)
+	}
+}
+
+// Tests merging recency feeds of multiple servers.
+func TestMergeRecentObjects(t *testing.T) {
+	now := UTCNow()
+	local := []recentObject{
+		{Name: "a", ModTime: now},
+		{Name: "b", ModTime: now.Add(-2 * time.Minute)},
+	}
+	persisted := []recentObject{
+		{Name: "c", ModTime: now.Add(-1 * time.Minute)},
+		{Name: "a", ModTime: now.Add(-3 * time.Minute)},
+		{Name: "d", ModTime: now.Add(-4 * time.Minute)},
+	}
+
+	testCases := []struct {
+		maxEntries int
+		expected   []string
+	}{
+		{10, []string{"a", "c", "b", "d"}},
+		{2, []string{"a", "c"}},
+	}
+
+	for i, testCase := range testCases {
+		entries := mergeRecentObjects(testCase.maxEntries, local, persisted)
+		if len(entries) != len(testCase.expected) {
+			t.Fatalf("Test %d: Expected %d entries, got %d", i+1, len(testCase.expected), len(entries))
+		}
+		for j, name := range testCase.expected {
+			if entries[j].Name != name {
+				t.Errorf("Test %d: Entry %d: Expected %s, got %s", i+1, j+1, name, entries[j].Name)
+			}
+		}
+	}
+}
+
+// Wrapper for calling recency feed tests for both XL multiple disks and single node setup.
+func TestRecentObjectsPersistence(t *testing.T) {
+	ExecObjectLayerTest(t, testRecentObjectsPersistence)
+}
+
+// Tests that persisted recency feeds are merged by other servers.
+func testRecentObjectsPersistence(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	now := UTCNow()
+	server1 := newRecentObjects(10)
+	server1.Add(bucket, ObjectInfo{Name: "object1", Size: 1, ModTime: now.Add(-time.Minute)})
+	server1.Add(bucket, ObjectInfo{Name: "object2", Size: 2, ModTime: now})
+	server1.Save(obj)
+
+	server2 := newRecentObjects(10)
+	server2.Add(bucket, ObjectInfo{Name: "object3", Size: 3, ModTime: now.Add(time.Minute)})
+	entries, err := server2.List(obj, bucket, 2)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(entries) != 2 || entries[0].Name != "object3" || entries[1].Name != "object2" {
+		t.Fatalf("%s: Unexpected entries %v", instanceType, entries)
+	}
+
+	// Saving merges the uploads of both servers.
+	server2.Save(obj)
+	persisted, err := loadRecentObjects(bucket, obj)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(persisted) != 3 {
+		t.Fatalf("%s: Expected 3 persisted entries, got %v", instanceType, persisted)
+	}
+
+	if err = removeRecentObjects(bucket, obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if persisted, err = loadRecentObjects(bucket, obj); err != nil || len(persisted) != 0 {
+		t.Fatalf("%s: Expected no persisted entries, got %v, %v", instanceType, persisted, err)
+	}
+
+	// Feeds of deleted buckets are not saved again.
+	server1.Add(bucket, ObjectInfo{Name: "object4", Size: 4, ModTime: now})
+	if err = obj.DeleteBucket(bucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	server1.Save(obj)
+	if persisted, err = loadRecentObjects(bucket, obj); err != nil || len(persisted) != 0 {
+		t.Fatalf("%s: Expected no persisted entries of a deleted bucket, got %v, %v", instanceType, persisted, err)
+	}
+	server1.Lock()
+	_, ok := server1.feeds[bucket]
+	server1.Unlock()
+	if ok {
+		t.Fatalf("%s: Expected the feed of a deleted bucket to be dropped", instanceType)
+	}
+}
@@ -0,0 +1,483 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+const (
+	// Status of renaming a prefix.
+	renamePrefixRunning  = "running"
+	renamePrefixFinished = "finished"
+	renamePrefixFailed   = "failed"
+
+	// Progress of renames is persisted under the bucket config
+	// prefix, one file per renamed prefix.
+	renamePrefixConfigDir = "renames"
+)
+
+// Time to wait for the lock of a rename, it is held by the server
+// running the rename until it stops.
+var renamePrefixLockTimeout = newDynamicTimeout(time.Second, time.Second)
+
+// renamePrefixStatus - progress of renaming a prefix. Objects up to
+// Marker were moved or skipped when the progress was last saved.
+// Renames of Restricted requests only move the objects the request
+// may access, they are not resumed on restart.
+type renamePrefixStatus struct {
+	Bucket         string
+	Prefix         string
+	Target         string
+	Status         string
+	StartTime      time.Time
+	EndTime        time.Time
+	ObjectsMoved   int64
+	BytesMoved     int64
+	ObjectsSkipped int64
+	Error          string
+	Marker         string
+	Restricted     bool
+	EventInfo      bgEventInfo
+}
+
+// renamePrefixAccessFunc - checks the request starting a rename is
+// allowed the action on an object, see checkRequestObjectAccess.
+type renamePrefixAccessFunc func(object, action string) APIErrorCode
+
+// renamePrefixState - prefixes being renamed by this server, the
+// progress of all renames is persisted to resume them on restart.
+type renamePrefixState struct {
+	sync.Mutex
+	renames map[string]*renamePrefixStatus
+}
+
+func newRenamePrefixState() *renamePrefixState {
+	return &renamePrefixState{renames: make(map[string]*renamePrefixStatus)}
+}
+
+// Global state of renamed prefixes.
+var globalRenamePrefixState = newRenamePrefixState()
+
+// Start - starts moving all objects of prefix to target in the
+// background unless already running on any server. Stopped and failed
+// renames to the same target resume after their saved marker. Objects
+// checkAccess denies are left in place, nil allows all objects.
+func (s *renamePrefixState) Start(objAPI ObjectLayer, bucket, prefix, target string, eventInfo bgEventInfo,
+	checkAccess renamePrefixAccessFunc) (renamePrefixStatus, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	key := pathJoin(bucket, prefix)
+	if status, ok := s.renames[key]; ok && status.Status == renamePrefixRunning {
+		return *status, nil
+	}
+
+	renameLock := globalNSMutex.NewNSLock(minioMetaBucket, getRenamePrefixLockPath(bucket, prefix))
+	if err := renameLock.GetLock(renamePrefixLockTimeout); err != nil {
+		// Another server is running the rename.
+		status, ok, lerr := loadRenamePrefixStatus(objAPI, bucket, prefix)
+		if lerr != nil {
+			return renamePrefixStatus{}, lerr
+		}
+		if ok && status.Status == renamePrefixRunning {
+			return status, nil
+		}
+		return renamePrefixStatus{}, err
+	}
+
+	saved, ok, err := loadRenamePrefixStatus(objAPI, bucket, prefix)
+	if err != nil {
+		renameLock.Unlock()
+		return renamePrefixStatus{}, err
+	}
+	status := &renamePrefixStatus{
+		Bucket:    bucket,
+		Prefix:    prefix,
+		Target:    target,
+		StartTime: UTCNow(),
+	}
+	if ok && saved.Status != renamePrefixFinished && saved.Target == target {
+		status = &saved
+	}
+	status.Status = renamePrefixRunning
+	status.EndTime = time.Time{}
+	status.Error = ""
+	status.Restricted = checkAccess != nil
+	status.EventInfo = eventInfo
+	if err = saveRenamePrefixStatus(objAPI, *status); err != nil {
+		renameLock.Unlock()
+		return renamePrefixStatus{}, err
+	}
+
+	s.renames[key] = status
+	go s.run(objAPI, renameLock, *status, checkAccess)
+	return *status, nil
+}
+
+// Resume - starts again all renames which were running when servers
+// stopped, renames resumed by other servers are left to them. Restricted
+// renames fail instead, they are resumed by starting them again.
+func (s *renamePrefixState) Resume(objAPI ObjectLayer) error {
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		return err
+	}
+	for _, bucketInfo := range buckets {
+		statuses, err := listRenamePrefixStatuses(objAPI, bucketInfo.Name)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.Status != renamePrefixRunning {
+				continue
+			}
+			if status.Restricted {
+				errorIf(stopRenamePrefix(objAPI, status.Bucket, status.Prefix), "Unable to stop renaming prefix %s/%s.", status.Bucket, status.Prefix)
+				continue
+			}
+			_, err = s.Start(objAPI, status.Bucket, status.Prefix, status.Target, status.EventInfo, nil)
+			errorIf(err, "Unable to resume renaming prefix %s/%s to %s.", status.Bucket, status.Prefix, status.Target)
+		}
+	}
+	return nil
+}
+
+// Get - returns the progress of renaming prefix, false if it was
+// never renamed.
+func (s *renamePrefixState) Get(objAPI ObjectLayer, bucket, prefix string) (renamePrefixStatus, bool, error) {
+	s.Lock()
+	if status, ok := s.renames[pathJoin(bucket, prefix)]; ok && status.Status == renamePrefixRunning {
+		defer s.Unlock()
+		return *status, true, nil
+	}
+	s.Unlock()
+
+	// Renames run by other servers and before restarts.
+	return loadRenamePrefixStatus(objAPI, bucket, prefix)
+}
+
+// update - applies fn to the progress of renaming prefix.
+func (s *renamePrefixState) update(bucket, prefix string, fn func(status *renamePrefixStatus)) renamePrefixStatus {
+	s.Lock()
+	defer s.Unlock()
+
+	status, ok := s.renames[pathJoin(bucket, prefix)]
+	if !ok {
+		return renamePrefixStatus{}
+	}
+	fn(status)
+	return *status
+}
+
+func (s *renamePrefixState) run(objAPI ObjectLayer, renameLock RWLocker, status renamePrefixStatus, checkAccess renamePrefixAccessFunc) {
+	defer renameLock.Unlock()
+
+	bucket, prefix, target := status.Bucket, status.Prefix, status.Target
+	err := renamePrefix(objAPI, bucket, prefix, target, status.Marker, status.EventInfo, checkAccess, func(fn func(*renamePrefixStatus)) {
+		s.update(bucket, prefix, fn)
+	}, func(marker string) error {
+		return saveRenamePrefixStatus(objAPI, s.update(bucket, prefix, func(status *renamePrefixStatus) {
+			status.Marker = marker
+		}))
+	})
+	errorIf(err, "Unable to rename prefix %s/%s to %s.", bucket, prefix, target)
+	status = s.update(bucket, prefix, func(status *renamePrefixStatus) {
+		status.EndTime = UTCNow()
+		if err != nil {
+			status.Status = renamePrefixFailed
+			status.Error = errors.Cause(err).Error()
+			return
+		}
+		status.Status = renamePrefixFinished
+	})
+	errorIf(saveRenamePrefixStatus(objAPI, status), "Unable to save progress of renaming prefix %s/%s.", bucket, prefix)
+}
+
+// stopRenamePrefix - fails a rename left running by a stopped server,
+// unless another server is running it.
+func stopRenamePrefix(objAPI ObjectLayer, bucket, prefix string) error {
+	renameLock := globalNSMutex.NewNSLock(minioMetaBucket, getRenamePrefixLockPath(bucket, prefix))
+	if err := renameLock.GetLock(renamePrefixLockTimeout); err != nil {
+		// Another server is running the rename.
+		return nil
+	}
+	defer renameLock.Unlock()
+
+	status, ok, err := loadRenamePrefixStatus(objAPI, bucket, prefix)
+	if err != nil || !ok || status.Status != renamePrefixRunning {
+		return err
+	}
+	status.Status = renamePrefixFailed
+	status.EndTime = UTCNow()
+	status.Error = errRenamePrefixStopped.Error()
+	return saveRenamePrefixStatus(objAPI, status)
+}
+
+// Returns the path of the persisted progress of renaming prefix,
+// prefixes are hashed since they may be nested.
+func getRenamePrefixPath(bucket, prefix string) string {
+	return path.Join(bucketConfigPrefix, bucket, renamePrefixConfigDir, getSHA256Hash([]byte(prefix))+".json")
+}
+
+// Returns the path locked by the server renaming prefix, the progress
+// itself is written while the lock is held.
+func getRenamePrefixLockPath(bucket, prefix string) string {
+	return path.Join(bucketConfigPrefix, bucket, renamePrefixConfigDir, getSHA256Hash([]byte(prefix))+".lock")
+}
+
+// Persists the progress of renaming a prefix to object layer.
+func saveRenamePrefixStatus(objAPI ObjectLayer, status renamePrefixStatus) error {
+	buf, err := json.Marshal(status)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	hashReader, err := hash.NewReader(bytes.NewReader(buf), int64(len(buf)), "", getSHA256Hash(buf))
+	if err != nil {
+		return err
+	}
+	_, err = objAPI.PutObject(minioMetaBucket, getRenamePrefixPath(status.Bucket, status.Prefix), hashReader, nil)
+	return err
+}
+
+// Loads the persisted progress of renaming prefix, false if it was
+// never renamed.
+func loadRenamePrefixStatus(objAPI ObjectLayer, bucket, prefix string) (renamePrefixStatus, bool, error) {
+	return readRenamePrefixStatus(objAPI, getRenamePrefixPath(bucket, prefix))
+}
+
+func readRenamePrefixStatus(objAPI ObjectLayer, statusPath string) (renamePrefixStatus, bool, error) {
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, statusPath, 0, -1, &buffer, "")
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return renamePrefixStatus{}, false, nil
+		}
+		return renamePrefixStatus{}, false, err
+	}
+
+	var status renamePrefixStatus
+	if err = json.Unmarshal(buffer.Bytes(), &status); err != nil {
+		return renamePrefixStatus{}, false, errors.Trace(err)
+	}
+	return status, true, nil
+}
+
+// Returns the persisted progress of all renames of bucket.
+func listRenamePrefixStatuses(objAPI ObjectLayer, bucket string) ([]renamePrefixStatus, error) {
+	var statuses []renamePrefixStatus
+	renamesPrefix := path.Join(bucketConfigPrefix, bucket, renamePrefixConfigDir) + slashSeparator
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(minioMetaBucket, renamesPrefix, marker, "", maxObjectList)
+		if err != nil {
+			return nil, err
+		}
+		for _, objInfo := range result.Objects {
+			status, ok, err := readRenamePrefixStatus(objAPI, objInfo.Name)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				statuses = append(statuses, status)
+			}
+		}
+		if !result.IsTruncated || len(result.Objects) == 0 {
+			return statuses, nil
+		}
+		marker = result.Objects[len(result.Objects)-1].Name
+	}
+}
+
+// Removes the persisted progress of all renames of bucket, only used
+// during DeleteBucket.
+func removeRenamePrefixStatuses(bucket string, objAPI ObjectLayer) error {
+	statuses, err := listRenamePrefixStatuses(objAPI, bucket)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if err = objAPI.DeleteObject(minioMetaBucket, getRenamePrefixPath(bucket, status.Prefix)); err != nil && !isErrObjectNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isRenamePrefixValid - returns true if objects of prefix can be moved
+// to target, both must be valid and neither may contain the other,
+// otherwise moved objects would be listed and moved again.
+func isRenamePrefixValid(prefix, target string) bool {
+	if prefix == "" || target == "" {
+		return false
+	}
+	if !IsValidObjectPrefix(prefix) || !IsValidObjectPrefix(target) {
+		return false
+	}
+	return !hasPrefix(prefix, target) && !hasPrefix(target, prefix)
+}
+
+// renamePrefix - moves all objects of prefix after marker to target by
+// copying them on the server and deleting the originals. Objects which
+// cannot be read or deleted, like quarantined and locked objects, and
+// objects checkAccess denies reading, deleting or writing to target are
+// left in place. Renaming stops when the server stops accepting writes
+// and is retried by starting it again, checkpoint saves the marker of
+// each listed page to resume after it.
+//
+// Renaming is not atomic, until it finishes objects are found under
+// both prefixes. An object copied but not deleted when the server
+// stops is listed, copied and deleted again when resumed.
+func renamePrefix(objAPI ObjectLayer, bucket, prefix, target, marker string, eventInfo bgEventInfo,
+	checkAccess renamePrefixAccessFunc, update func(func(*renamePrefixStatus)), checkpoint func(marker string) error) error {
+	for {
+		if err := checkServerWritable(); err != nil {
+			return err
+		}
+
+		result, err := objAPI.ListObjects(bucket, prefix, marker, "", maxObjectList)
+		if err != nil {
+			return err
+		}
+		for _, objInfo := range result.Objects {
+			dstObject := target + strings.TrimPrefix(objInfo.Name, prefix)
+			if !isRenamePrefixObjectAllowed(checkAccess, objInfo.Name, dstObject) {
+				update(func(status *renamePrefixStatus) {
+					status.ObjectsSkipped++
+				})
+				continue
+			}
+			dstInfo, err := moveObject(objAPI, bucket, objInfo.Name, dstObject)
+			switch errors.Cause(err).(type) {
+			case ObjectQuarantined, ObjectLocked:
+				update(func(status *renamePrefixStatus) {
+					status.ObjectsSkipped++
+				})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			// Notify object created and deleted events.
+			eventNotify(eventData{
+				Type:      ObjectCreatedCopy,
+				Bucket:    bucket,
+				ObjInfo:   dstInfo,
+				ReqParams: eventInfo.ReqParams,
+				UserAgent: eventInfo.UserAgent,
+				Host:      eventInfo.Host,
+				Port:      eventInfo.Port,
+			})
+			eventNotify(eventData{
+				Type:   ObjectRemovedDelete,
+				Bucket: bucket,
+				ObjInfo: ObjectInfo{
+					Name: objInfo.Name,
+				},
+				ReqParams: eventInfo.ReqParams,
+				UserAgent: eventInfo.UserAgent,
+				Host:      eventInfo.Host,
+				Port:      eventInfo.Port,
+			})
+
+			size := objInfo.Size
+			update(func(status *renamePrefixStatus) {
+				status.ObjectsMoved++
+				status.BytesMoved += size
+			})
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextMarker
+		if marker == "" && len(result.Objects) > 0 {
+			marker = result.Objects[len(result.Objects)-1].Name
+		}
+		if err = checkpoint(marker); err != nil {
+			return err
+		}
+	}
+}
+
+// isRenamePrefixObjectAllowed - returns true if srcObject may be read
+// and deleted, and dstObject written.
+func isRenamePrefixObjectAllowed(checkAccess renamePrefixAccessFunc, srcObject, dstObject string) bool {
+	if checkAccess == nil {
+		return true
+	}
+	return checkAccess(srcObject, "s3:GetObject") == ErrNone &&
+		checkAccess(srcObject, "s3:DeleteObject") == ErrNone &&
+		checkAccess(dstObject, "s3:PutObject") == ErrNone
+}
+
+// moveObject - copies an object within bucket on the server keeping its
+// metadata, then deletes the original. Encrypted objects are copied as
+// is since their keys do not depend on the object name.
+func moveObject(objAPI ObjectLayer, bucket, srcObject, dstObject string) (objInfo ObjectInfo, err error) {
+	srcInfo, err := objAPI.GetObjectInfo(bucket, srcObject)
+	if err != nil {
+		return objInfo, err
+	}
+
+	// Objects pending validation cannot be read.
+	if isObjectQuarantined(srcInfo.UserDefined) {
+		return objInfo, errors.Trace(ObjectQuarantined{Bucket: bucket, Object: srcObject})
+	}
+
+	// Locked objects cannot be deleted nor overwritten.
+	if err = checkObjectLock(objAPI, bucket, srcObject, nil); err != nil {
+		return objInfo, err
+	}
+	if err = checkObjectLock(objAPI, bucket, dstObject, nil); err != nil {
+		return objInfo, err
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeReader.Close()
+	srcInfo.Writer = pipeWriter
+
+	// Make sure to remove saved etag if any, CopyObject calculates a new one.
+	delete(srcInfo.UserDefined, "etag")
+
+	srcInfo.Reader, err = hash.NewReader(pipeReader, srcInfo.Size, "", "")
+	if err != nil {
+		pipeWriter.CloseWithError(err)
+		return objInfo, err
+	}
+
+	objInfo, err = objAPI.CopyObject(bucket, srcObject, bucket, dstObject, srcInfo)
+	if err != nil {
+		return objInfo, err
+	}
+	errorIf(attestObject(objAPI, bucket, dstObject, objInfo.ETag), "Unable to attest object %s/%s.", bucket, dstObject)
+
+	if err = objAPI.DeleteObject(bucket, srcObject); err != nil && !isErrObjectNotFound(err) {
+		return objInfo, err
+	}
+	removeObjectAttestation(objAPI, bucket, srcObject)
+	return objInfo, nil
+}
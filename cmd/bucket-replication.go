@@ -0,0 +1,682 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	miniogo "github.com/minio/minio-go"
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+const (
+	// Replication config of a bucket, persisted under the bucket config prefix.
+	bucketReplicationConfig = "replication.json"
+
+	// Current version of replication configs and queue entries.
+	bucketReplicationVersion = "1"
+
+	// Pending changes of replicated buckets are queued under this
+	// prefix of minioMetaBucket, one object per change named by time.
+	replicationQueuePrefix = "replication"
+
+	// Changes failing to be replicated are moved under this prefix and
+	// retried with a backoff, so that they do not hold up later changes.
+	replicationRetryPrefix = "replication-retry"
+
+	// Changes failing replicationMaxAttempts times are moved under this
+	// prefix, where they are kept but no longer retried.
+	replicationFailedPrefix = "replication-failed"
+
+	// Interval at which queues are replicated when no changes are
+	// made, failed changes are first retried after this interval.
+	replicationInterval = 10 * time.Second
+
+	// Maximum delay before a failed change is retried, and the number
+	// of attempts after which it is given up.
+	replicationMaxBackoff  = time.Hour
+	replicationMaxAttempts = 10
+
+	// Operations replicated to the target.
+	replicationOpPut    = "put"
+	replicationOpDelete = "delete"
+)
+
+// Queues are replicated by a single server at a time, other servers
+// skip queues they cannot lock at once.
+var replicationLockTimeout = newDynamicTimeout(time.Second, time.Second)
+
+// replicationConfig - remote S3 target a bucket is replicated to.
+type replicationConfig struct {
+	Version      string `json:"version"`
+	Endpoint     string `json:"endpoint"` // host[:port] of the target.
+	Secure       bool   `json:"secure"`
+	AccessKey    string `json:"accessKey"`
+	SecretKey    string `json:"secretKey"`
+	TargetBucket string `json:"targetBucket"`
+}
+
+// Validate - checks if all fields of the config are set.
+func (c replicationConfig) Validate() error {
+	if c.Endpoint == "" || strings.Contains(c.Endpoint, "/") {
+		return fmt.Errorf("Invalid replication endpoint %q", c.Endpoint)
+	}
+	if c.AccessKey == "" || c.SecretKey == "" {
+		return fmt.Errorf("Replication credentials are missing")
+	}
+	if !IsValidBucketName(c.TargetBucket) {
+		return fmt.Errorf("Invalid replication target bucket %q", c.TargetBucket)
+	}
+	return nil
+}
+
+// Returns a client of the replication target.
+func newReplicationClient(c replicationConfig) (*miniogo.Client, error) {
+	client, err := miniogo.New(c.Endpoint, c.AccessKey, c.SecretKey, c.Secure)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	client.SetCustomTransport(NewCustomHTTPTransport())
+	return client, nil
+}
+
+// checkReplicationTarget - checks if the target bucket of a config
+// exists and is accessible with its credentials.
+func checkReplicationTarget(c replicationConfig) error {
+	client, err := newReplicationClient(c)
+	if err != nil {
+		return err
+	}
+	ok, err := client.BucketExists(c.TargetBucket)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !ok {
+		return errors.Trace(fmt.Errorf("Replication target bucket %s does not exist", c.TargetBucket))
+	}
+	return nil
+}
+
+// replicationEntry - change of an object queued for replication, with
+// the failed attempts to replicate it and when it is retried.
+type replicationEntry struct {
+	Version  string    `json:"version"`
+	Time     time.Time `json:"time"`
+	Bucket   string    `json:"bucket"`
+	Object   string    `json:"object"`
+	Op       string    `json:"op"`
+	Attempts int       `json:"attempts,omitempty"`
+	RetryAt  time.Time `json:"retryAt,omitempty"`
+}
+
+// replicationStats - changes of a bucket replicated by this server.
+type replicationStats struct {
+	Replicated     uint64    `json:"replicated"`
+	Skipped        uint64    `json:"skipped"`
+	Failures       uint64    `json:"failures"`
+	LastReplicated time.Time `json:"lastReplicated"`
+	LastError      string    `json:"lastError,omitempty"`
+	LastSkipped    string    `json:"lastSkipped,omitempty"`
+}
+
+// bucketReplicationStatus - replication config of a bucket without
+// its secret key, the pending, retried and given up changes and
+// statistics.
+type bucketReplicationStatus struct {
+	Bucket        string           `json:"bucket"`
+	Endpoint      string           `json:"endpoint"`
+	Secure        bool             `json:"secure"`
+	AccessKey     string           `json:"accessKey"`
+	TargetBucket  string           `json:"targetBucket"`
+	Pending       int              `json:"pending"`
+	Retrying      int              `json:"retrying"`
+	Failed        int              `json:"failed"`
+	OldestPending time.Time        `json:"oldestPending"`
+	Lag           time.Duration    `json:"lag"`
+	Stats         replicationStats `json:"stats"`
+}
+
+// bucketReplication - replication configs of all buckets, changes of
+// replicated buckets are queued in the object layer and replicated
+// in the background, so that they survive restarts.
+type bucketReplication struct {
+	sync.RWMutex
+	configs *bucketConfigCache
+	stats   map[string]*replicationStats
+	kickCh  chan struct{}
+}
+
+func newBucketReplication() *bucketReplication {
+	return &bucketReplication{
+		configs: newBucketConfigCache(bucketReplicationConfig, func() interface{} { return &replicationConfig{} }),
+		stats:   make(map[string]*replicationStats),
+		kickCh:  make(chan struct{}, 1),
+	}
+}
+
+// Global replication of buckets, only initialized by the server.
+var globalBucketReplication *bucketReplication
+
+// Returns the prefix of the queue of a bucket.
+func getReplicationQueuePath(bucket string) string {
+	return path.Join(replicationQueuePrefix, bucket) + slashSeparator
+}
+
+// Returns the prefix of the retried changes of a bucket.
+func getReplicationRetryPath(bucket string) string {
+	return path.Join(replicationRetryPrefix, bucket) + slashSeparator
+}
+
+// Returns the prefix of the given up changes of a bucket.
+func getReplicationFailedPath(bucket string) string {
+	return path.Join(replicationFailedPrefix, bucket) + slashSeparator
+}
+
+// Persists the replication config of a bucket to object layer.
+func saveReplicationConfig(bucket string, cfg replicationConfig, objAPI ObjectLayer) error {
+	cfg.Version = bucketReplicationVersion
+	return saveBucketConfig(bucket, bucketReplicationConfig, cfg, objAPI)
+}
+
+// Removes the replication config and the queued, retried and given
+// up changes of a bucket.
+func removeReplicationConfig(bucket string, objAPI ObjectLayer) error {
+	if err := removeBucketConfig(bucket, bucketReplicationConfig, objAPI); err != nil {
+		return err
+	}
+	for _, prefix := range []string{getReplicationQueuePath(bucket), getReplicationRetryPath(bucket), getReplicationFailedPath(bucket)} {
+		if err := removeReplicationEntries(objAPI, prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Removes all changes under prefix.
+func removeReplicationEntries(objAPI ObjectLayer, prefix string) error {
+	for {
+		result, err := objAPI.ListObjects(minioMetaBucket, prefix, "", "", maxObjectList)
+		if err != nil {
+			return err
+		}
+		for _, objInfo := range result.Objects {
+			if err = objAPI.DeleteObject(minioMetaBucket, objInfo.Name); err != nil && !isErrObjectNotFound(err) {
+				return err
+			}
+		}
+		if !result.IsTruncated || len(result.Objects) == 0 {
+			return nil
+		}
+	}
+}
+
+// Init - loads the replication configs of all buckets.
+func (r *bucketReplication) Init(objAPI ObjectLayer) error {
+	if err := r.configs.Init(objAPI); err != nil {
+		return err
+	}
+	r.Lock()
+	r.kick()
+	r.Unlock()
+	return nil
+}
+
+// Refresh - loads the replication config of a bucket after it was changed
+// by any server.
+func (r *bucketReplication) Refresh(objAPI ObjectLayer, bucket string) error {
+	if err := r.configs.Refresh(objAPI, bucket); err != nil {
+		return err
+	}
+	r.Lock()
+	r.kick()
+	r.Unlock()
+	return nil
+}
+
+// Remove - drops the replication config of a bucket, used when the
+// bucket is deleted.
+func (r *bucketReplication) Remove(bucket string) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.configs.Remove(bucket)
+	delete(r.stats, bucket)
+}
+
+// Get - returns the replication config of a bucket, false if the
+// bucket is not replicated.
+func (r *bucketReplication) Get(bucket string) (replicationConfig, bool) {
+	cfg, ok := r.configs.get(bucket)
+	if !ok {
+		return replicationConfig{}, false
+	}
+	return *cfg.(*replicationConfig), true
+}
+
+// Wakes up the replication routine, must be called with the lock held.
+func (r *bucketReplication) kick() {
+	select {
+	case r.kickCh <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue - queues a change of an object of a replicated bucket,
+// other changes are ignored.
+func (r *bucketReplication) Enqueue(objAPI ObjectLayer, event eventData) error {
+	var op string
+	switch {
+	case isObjectCreatedEvent(event.Type):
+		op = replicationOpPut
+	case event.Type == ObjectRemovedDelete:
+		op = replicationOpDelete
+	default:
+		return nil
+	}
+	if _, ok := r.Get(event.Bucket); !ok {
+		return nil
+	}
+
+	now := UTCNow()
+	entryPath := path.Join(replicationQueuePrefix, event.Bucket, newTimeOrderedID(now)+".json")
+	err := saveReplicationEntry(objAPI, entryPath, replicationEntry{
+		Version: bucketReplicationVersion,
+		Time:    now,
+		Bucket:  event.Bucket,
+		Object:  event.ObjInfo.Name,
+		Op:      op,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.Lock()
+	r.kick()
+	r.Unlock()
+	return nil
+}
+
+// Returns the statistics of a bucket, must be called with the lock held.
+func (r *bucketReplication) getStats(bucket string) *replicationStats {
+	stats, ok := r.stats[bucket]
+	if !ok {
+		stats = &replicationStats{}
+		r.stats[bucket] = stats
+	}
+	return stats
+}
+
+// Records the result of replicating a change of an object, the last
+// object skipped is kept so that skips are noticed.
+func (r *bucketReplication) record(bucket, object string, skipped bool, err error) {
+	r.Lock()
+	defer r.Unlock()
+
+	stats := r.getStats(bucket)
+	switch {
+	case err != nil:
+		stats.Failures++
+		stats.LastError = errors.Cause(err).Error()
+	case skipped:
+		stats.Skipped++
+		stats.LastSkipped = object
+	default:
+		stats.Replicated++
+		stats.LastReplicated = UTCNow()
+		stats.LastError = ""
+	}
+}
+
+// Status - returns the replication status of a bucket, false if the
+// bucket is not replicated.
+func (r *bucketReplication) Status(objAPI ObjectLayer, bucket string) (status bucketReplicationStatus, ok bool, err error) {
+	cfg, ok := r.Get(bucket)
+	r.RLock()
+	var stats replicationStats
+	if s, found := r.stats[bucket]; found {
+		stats = *s
+	}
+	r.RUnlock()
+	if !ok {
+		return status, false, nil
+	}
+
+	status = bucketReplicationStatus{
+		Bucket:       bucket,
+		Endpoint:     cfg.Endpoint,
+		Secure:       cfg.Secure,
+		AccessKey:    cfg.AccessKey,
+		TargetBucket: cfg.TargetBucket,
+		Stats:        stats,
+	}
+	queued, oldestQueued, err := countReplicationEntries(objAPI, getReplicationQueuePath(bucket))
+	if err != nil {
+		return status, true, err
+	}
+	status.Retrying, status.OldestPending, err = countReplicationEntries(objAPI, getReplicationRetryPath(bucket))
+	if err != nil {
+		return status, true, err
+	}
+	if status.Failed, _, err = countReplicationEntries(objAPI, getReplicationFailedPath(bucket)); err != nil {
+		return status, true, err
+	}
+	status.Pending = queued + status.Retrying
+	if queued > 0 && (status.Retrying == 0 || oldestQueued.Before(status.OldestPending)) {
+		status.OldestPending = oldestQueued
+	}
+	if status.Pending > 0 {
+		status.Lag = UTCNow().Sub(status.OldestPending)
+	}
+	return status, true, nil
+}
+
+// Returns the number of changes under prefix and the time of the
+// oldest of them, entries are named by the time of their change.
+func countReplicationEntries(objAPI ObjectLayer, prefix string) (count int, oldest time.Time, err error) {
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(minioMetaBucket, prefix, marker, "", maxObjectList)
+		if err != nil {
+			return count, oldest, err
+		}
+		if count == 0 && len(result.Objects) > 0 {
+			entry, err := loadReplicationEntry(objAPI, result.Objects[0].Name)
+			if err != nil {
+				return count, oldest, err
+			}
+			oldest = entry.Time
+		}
+		count += len(result.Objects)
+		if !result.IsTruncated || len(result.Objects) == 0 {
+			return count, oldest, nil
+		}
+		marker = result.Objects[len(result.Objects)-1].Name
+	}
+}
+
+// List - returns the replication status of all replicated buckets,
+// sorted by bucket.
+func (r *bucketReplication) List(objAPI ObjectLayer) ([]bucketReplicationStatus, error) {
+	configs := r.configs.list()
+	buckets := make([]string, 0, len(configs))
+	for bucket := range configs {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+
+	statuses := []bucketReplicationStatus{}
+	for _, bucket := range buckets {
+		status, ok, err := r.Status(objAPI, bucket)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+// Loads a queued change.
+func loadReplicationEntry(objAPI ObjectLayer, entryPath string) (entry replicationEntry, err error) {
+	var buffer bytes.Buffer
+	if err = objAPI.GetObject(minioMetaBucket, entryPath, 0, -1, &buffer, ""); err != nil {
+		return entry, err
+	}
+	if err = json.Unmarshal(buffer.Bytes(), &entry); err != nil {
+		return entry, errors.Trace(err)
+	}
+	return entry, nil
+}
+
+// Persists a change at entryPath.
+func saveReplicationEntry(objAPI ObjectLayer, entryPath string, entry replicationEntry) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	hashReader, err := hash.NewReader(bytes.NewReader(buf), int64(len(buf)), "", getSHA256Hash(buf))
+	if err != nil {
+		return err
+	}
+	_, err = objAPI.PutObject(minioMetaBucket, entryPath, hashReader, nil)
+	return err
+}
+
+// Returns the delay before a change which failed attempts times is
+// retried, doubled with every attempt up to replicationMaxBackoff.
+func getReplicationBackoff(attempts int) time.Duration {
+	backoff := replicationInterval
+	for i := 1; i < attempts && backoff < replicationMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > replicationMaxBackoff {
+		backoff = replicationMaxBackoff
+	}
+	return backoff
+}
+
+// failReplicationEntry - moves a change at entryPath which failed to
+// be replicated to the retried changes of its bucket, or to the given
+// up changes once it failed replicationMaxAttempts times.
+func failReplicationEntry(objAPI ObjectLayer, entryPath string, entry replicationEntry, now time.Time) error {
+	entry.Attempts++
+	newPath := path.Join(getReplicationRetryPath(entry.Bucket), path.Base(entryPath))
+	if entry.Attempts >= replicationMaxAttempts {
+		newPath = path.Join(getReplicationFailedPath(entry.Bucket), path.Base(entryPath))
+	} else {
+		entry.RetryAt = now.Add(getReplicationBackoff(entry.Attempts))
+	}
+	if err := saveReplicationEntry(objAPI, newPath, entry); err != nil {
+		return err
+	}
+	if newPath == entryPath {
+		return nil
+	}
+	return objAPI.DeleteObject(minioMetaBucket, entryPath)
+}
+
+// Returns the metadata of an object sent to the target, internal
+// metadata is not replicated.
+func getReplicationMetadata(objInfo ObjectInfo) map[string]string {
+	metadata := make(map[string]string, len(objInfo.UserDefined))
+	for k, v := range objInfo.UserDefined {
+		if strings.HasPrefix(k, ReservedMetadataPrefix) {
+			continue
+		}
+		metadata[k] = v
+	}
+	return metadata
+}
+
+// replicateEntry - applies a queued change to the target, the current
+// state of the object is replicated so that retried changes do not
+// undo later ones. Returns true if the change cannot be replicated and
+// is skipped.
+func replicateEntry(objAPI ObjectLayer, client *miniogo.Client, cfg replicationConfig, entry replicationEntry) (skipped bool, err error) {
+	switch entry.Op {
+	case replicationOpDelete:
+		// Uploaded again since, the put queued as well copies it.
+		if _, err = objAPI.GetObjectInfo(entry.Bucket, entry.Object); err == nil {
+			return false, nil
+		} else if !isErrObjectNotFound(err) && !isErrBucketNotFound(err) {
+			return false, err
+		}
+		if err = client.RemoveObject(cfg.TargetBucket, entry.Object); err != nil {
+			if miniogo.ToErrorResponse(err).Code == "NoSuchKey" {
+				return false, nil
+			}
+			return false, errors.Trace(err)
+		}
+		return false, nil
+	case replicationOpPut:
+	default:
+		return true, nil
+	}
+
+	objInfo, err := objAPI.GetObjectInfo(entry.Bucket, entry.Object)
+	if err != nil {
+		// Removed since, the delete is queued as well.
+		if isErrObjectNotFound(err) || isErrBucketNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// Objects encrypted with customer keys cannot be read without them.
+	// Objects encrypted with SSE-S3 are decrypted, and encrypted again
+	// by the target with its own keys.
+	metadata := getReplicationMetadata(objInfo)
+	if objAPI.IsEncryptionSupported() && objInfo.IsEncrypted() {
+		if !isSSES3Encrypted(objInfo.UserDefined) {
+			return true, nil
+		}
+		if _, ok := metadata[SSEHeader]; !ok {
+			metadata[SSEHeader] = SSEAlgorithmAES256
+		}
+	}
+	size, err := getWebObjectSize(objAPI, objInfo)
+	if err != nil {
+		return false, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		// Fails if the object is replaced while being copied, the
+		// queued put of the new object copies it again.
+		pw.CloseWithError(getWebObject(objAPI, entry.Bucket, objInfo, pw))
+	}()
+	_, err = client.PutObject(cfg.TargetBucket, entry.Object, pr, size, miniogo.PutObjectOptions{
+		UserMetadata: metadata,
+	})
+	pr.Close()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return false, nil
+}
+
+// replicateBucket - replicates the queued changes of a bucket in the
+// order they were made, and the retried changes due. Changes failing
+// to be replicated are moved to the retried changes.
+func (r *bucketReplication) replicateBucket(objAPI ObjectLayer, bucket string, cfg replicationConfig) {
+	queuePath := getReplicationQueuePath(bucket)
+	queueLock := globalNSMutex.NewNSLock(minioMetaBucket, queuePath)
+	if queueLock.GetLock(replicationLockTimeout) != nil {
+		// Replicated by another server.
+		return
+	}
+	defer queueLock.Unlock()
+
+	client, err := newReplicationClient(cfg)
+	if err != nil {
+		r.record(bucket, "", false, err)
+		return
+	}
+	now := UTCNow()
+	for {
+		// Replicated and failed changes leave the queue.
+		result, err := objAPI.ListObjects(minioMetaBucket, queuePath, "", "", maxObjectList)
+		if err != nil {
+			errorIf(err, "Unable to list replication queue of bucket %s.", bucket)
+			return
+		}
+		for _, objInfo := range result.Objects {
+			if err = r.replicateEntryAt(objAPI, client, cfg, objInfo.Name, now); err != nil {
+				errorIf(err, "Unable to update replication queue entry %s.", objInfo.Name)
+				return
+			}
+		}
+		if !result.IsTruncated || len(result.Objects) == 0 {
+			break
+		}
+	}
+
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(minioMetaBucket, getReplicationRetryPath(bucket), marker, "", maxObjectList)
+		if err != nil {
+			errorIf(err, "Unable to list retried replication changes of bucket %s.", bucket)
+			return
+		}
+		for _, objInfo := range result.Objects {
+			if err = r.replicateEntryAt(objAPI, client, cfg, objInfo.Name, now); err != nil {
+				errorIf(err, "Unable to update replication retry entry %s.", objInfo.Name)
+				return
+			}
+		}
+		if !result.IsTruncated || len(result.Objects) == 0 {
+			return
+		}
+		marker = result.Objects[len(result.Objects)-1].Name
+	}
+}
+
+// replicateEntryAt - replicates the change at entryPath if it is due,
+// removes it once replicated and moves it to the retried changes if it
+// fails.
+func (r *bucketReplication) replicateEntryAt(objAPI ObjectLayer, client *miniogo.Client, cfg replicationConfig, entryPath string, now time.Time) error {
+	entry, err := loadReplicationEntry(objAPI, entryPath)
+	if err != nil {
+		return err
+	}
+	if entry.RetryAt.After(now) {
+		return nil
+	}
+	skipped, err := replicateEntry(objAPI, client, cfg, entry)
+	r.record(entry.Bucket, entry.Object, skipped, err)
+	if err != nil {
+		return failReplicationEntry(objAPI, entryPath, entry, now)
+	}
+	return objAPI.DeleteObject(minioMetaBucket, entryPath)
+}
+
+// replicate - replicates the queued changes of all replicated buckets.
+func (r *bucketReplication) replicate(objAPI ObjectLayer) {
+	for bucket, cfg := range r.configs.list() {
+		r.replicateBucket(objAPI, bucket, *cfg.(*replicationConfig))
+	}
+}
+
+// Start - starts a routine replicating queued changes as they are
+// made, and retrying failed ones every interval.
+func (r *bucketReplication) Start(interval time.Duration, doneCh chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-doneCh:
+				return
+			case <-r.kickCh:
+			case <-ticker.C:
+			}
+			if objAPI := newObjectLayerFn(); objAPI != nil {
+				r.replicate(objAPI)
+			}
+		}
+	}()
+}
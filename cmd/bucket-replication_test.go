@@ -0,0 +1,346 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+// Tests validating replication configs.
+func TestReplicationConfigValidate(t *testing.T) {
+	testCases := []struct {
+		cfg     replicationConfig
+		success bool
+	}{
+		{replicationConfig{Endpoint: "localhost:9000", AccessKey: "access", SecretKey: "secret", TargetBucket: "bucket"}, true},
+		{replicationConfig{Endpoint: "", AccessKey: "access", SecretKey: "secret", TargetBucket: "bucket"}, false},
+		{replicationConfig{Endpoint: "http://localhost:9000", AccessKey: "access", SecretKey: "secret", TargetBucket: "bucket"}, false},
+		{replicationConfig{Endpoint: "localhost:9000", SecretKey: "secret", TargetBucket: "bucket"}, false},
+		{replicationConfig{Endpoint: "localhost:9000", AccessKey: "access", SecretKey: "secret", TargetBucket: "a"}, false},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.cfg.Validate()
+		if testCase.success && err != nil {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if !testCase.success && err == nil {
+			t.Errorf("Test %d: Expected error", i+1)
+		}
+	}
+}
+
+// Tests replicating queued uploads and deletions to a remote server.
+func TestBucketReplication(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	initNSLock(false)
+
+	// Only the S3 API of the target is needed.
+	target := UnstartedTestServer(t, "FS")
+	target.Server.Config.Handler = initTestAPIEndPoints(target.Obj, nil)
+	target.Server.Start()
+	defer target.Stop()
+
+	obj, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+
+	bucket, targetBucket := "bucket", "replica"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err = target.Obj.MakeBucketWithLocation(targetBucket, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(target.Server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := replicationConfig{
+		Endpoint:     u.Host,
+		AccessKey:    target.AccessKey,
+		SecretKey:    target.SecretKey,
+		TargetBucket: targetBucket,
+	}
+	if err = checkReplicationTarget(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err = saveReplicationConfig(bucket, cfg, obj); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newBucketReplication()
+	if err = r.Init(obj); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Get(bucket); !ok {
+		t.Fatal("Expected bucket to be replicated")
+	}
+
+	data := []byte("hello, world")
+	objInfo, err := obj.PutObject(bucket, "object", mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""),
+		map[string]string{"X-Amz-Meta-Color": "blue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Enqueue(obj, eventData{Type: ObjectCreatedPut, Bucket: bucket, ObjInfo: objInfo}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes of buckets not replicated are not queued.
+	if err = r.Enqueue(obj, eventData{Type: ObjectCreatedPut, Bucket: "other", ObjInfo: objInfo}); err != nil {
+		t.Fatal(err)
+	}
+
+	status, ok, err := r.Status(obj, bucket)
+	if err != nil || !ok {
+		t.Fatalf("Unable to get replication status %v", err)
+	}
+	if status.Pending != 1 || status.OldestPending.IsZero() {
+		t.Fatalf("Expected 1 pending change, got %d since %s", status.Pending, status.OldestPending)
+	}
+
+	r.replicate(obj)
+	var buffer bytes.Buffer
+	if err = target.Obj.GetObject(targetBucket, "object", 0, -1, &buffer, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("Expected %q to be replicated, got %q", data, buffer.Bytes())
+	}
+	replica, err := target.Obj.GetObjectInfo(targetBucket, "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replica.UserDefined["X-Amz-Meta-Color"] != "blue" {
+		t.Fatalf("Expected metadata to be replicated, got %v", replica.UserDefined)
+	}
+
+	if err = obj.DeleteObject(bucket, "object"); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Enqueue(obj, eventData{Type: ObjectRemovedDelete, Bucket: bucket, ObjInfo: ObjectInfo{Name: "object"}}); err != nil {
+		t.Fatal(err)
+	}
+	r.replicate(obj)
+	if _, err = target.Obj.GetObjectInfo(targetBucket, "object"); !isErrObjectNotFound(err) {
+		t.Fatalf("Expected replica to be deleted, got %v", err)
+	}
+
+	status, _, err = r.Status(obj, bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Pending != 0 || status.Lag != 0 {
+		t.Fatalf("Expected no pending changes, got %d with lag %s", status.Pending, status.Lag)
+	}
+	if status.Stats.Replicated != 2 || status.Stats.Failures != 0 {
+		t.Fatalf("Unexpected replication stats %+v", status.Stats)
+	}
+
+	// Objects encrypted with SSE-S3 are decrypted and encrypted again
+	// by the target, objects encrypted with SSE-C are skipped.
+	defer func(kms KMS) { globalKMS = kms }(globalKMS)
+	globalKMS = newTestKMS()
+	metadata := map[string]string{SSEHeader: SSEAlgorithmAES256}
+	reader, err := newKMSEncryptReader(bytes.NewReader(data), globalKMS, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo, err = obj.PutObject(bucket, "sse-s3", mustGetHashReader(t, reader, encryptedSize(int64(len(data))), "", ""), metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Enqueue(obj, eventData{Type: ObjectCreatedPut, Bucket: bucket, ObjInfo: objInfo}); err != nil {
+		t.Fatal(err)
+	}
+	metadata = make(map[string]string)
+	if reader, err = newEncryptReader(bytes.NewReader(data), make([]byte, 32), metadata); err != nil {
+		t.Fatal(err)
+	}
+	if objInfo, err = obj.PutObject(bucket, "sse-c", mustGetHashReader(t, reader, encryptedSize(int64(len(data))), "", ""), metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Enqueue(obj, eventData{Type: ObjectCreatedPut, Bucket: bucket, ObjInfo: objInfo}); err != nil {
+		t.Fatal(err)
+	}
+	r.replicate(obj)
+
+	if replica, err = target.Obj.GetObjectInfo(targetBucket, "sse-s3"); err != nil {
+		t.Fatal(err)
+	}
+	if !isSSES3Encrypted(replica.UserDefined) {
+		t.Fatalf("Expected replica to be encrypted with SSE-S3, got %v", replica.UserDefined)
+	}
+	buffer.Reset()
+	if err = getWebObject(target.Obj, targetBucket, replica, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("Expected %q to be replicated, got %q", data, buffer.Bytes())
+	}
+	if _, err = target.Obj.GetObjectInfo(targetBucket, "sse-c"); !isErrObjectNotFound(err) {
+		t.Fatalf("Expected SSE-C object to be skipped, got %v", err)
+	}
+
+	if status, _, err = r.Status(obj, bucket); err != nil {
+		t.Fatal(err)
+	}
+	if status.Stats.Replicated != 3 || status.Stats.Skipped != 1 || status.Stats.LastSkipped != "sse-c" {
+		t.Fatalf("Unexpected replication stats %+v", status.Stats)
+	}
+}
+
+// Tests the delay before a failed change is retried.
+func TestGetReplicationBackoff(t *testing.T) {
+	testCases := []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{1, replicationInterval},
+		{2, 2 * replicationInterval},
+		{4, 8 * replicationInterval},
+		{100, replicationMaxBackoff},
+	}
+	for i, testCase := range testCases {
+		if backoff := getReplicationBackoff(testCase.attempts); backoff != testCase.backoff {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.backoff, backoff)
+		}
+	}
+}
+
+// Tests that failed changes are retried without holding up later
+// changes and given up after replicationMaxAttempts failures.
+func TestBucketReplicationRetry(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	initNSLock(false)
+
+	obj, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing listens on the endpoint.
+	cfg := replicationConfig{
+		Endpoint:     "127.0.0.1:1",
+		AccessKey:    "access",
+		SecretKey:    "secretkey",
+		TargetBucket: "replica",
+	}
+	if err = saveReplicationConfig(bucket, cfg, obj); err != nil {
+		t.Fatal(err)
+	}
+	r := newBucketReplication()
+	if err = r.Refresh(obj, bucket); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, object := range []string{"object1", "object2"} {
+		if err = r.Enqueue(obj, eventData{Type: ObjectRemovedDelete, Bucket: bucket, ObjInfo: ObjectInfo{Name: object}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.replicate(obj)
+
+	status, _, err := r.Status(obj, bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Pending != 2 || status.Retrying != 2 {
+		t.Fatalf("Expected 2 retried changes, got %d pending and %d retrying", status.Pending, status.Retrying)
+	}
+	// A failed change does not hold up the next one.
+	if status.Stats.Failures != 2 || status.Stats.LastError == "" {
+		t.Fatalf("Unexpected replication stats %+v", status.Stats)
+	}
+
+	// Retried changes are not due yet.
+	r.replicate(obj)
+	if status, _, err = r.Status(obj, bucket); err != nil {
+		t.Fatal(err)
+	}
+	if status.Stats.Failures != 2 {
+		t.Fatalf("Expected retried changes to wait for their backoff, got %+v", status.Stats)
+	}
+
+	// Changes are given up after failing replicationMaxAttempts times.
+	result, err := obj.ListObjects(minioMetaBucket, getReplicationRetryPath(bucket), "", "", maxObjectList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, objInfo := range result.Objects {
+		entry, err := loadReplicationEntry(obj, objInfo.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Attempts != 1 {
+			t.Fatalf("Expected 1 attempt, got %d", entry.Attempts)
+		}
+		entry.Attempts = replicationMaxAttempts - 1
+		if err = failReplicationEntry(obj, objInfo.Name, entry, UTCNow()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if status, _, err = r.Status(obj, bucket); err != nil {
+		t.Fatal(err)
+	}
+	if status.Pending != 0 || status.Failed != 2 {
+		t.Fatalf("Expected 2 given up changes, got %d pending and %d failed", status.Pending, status.Failed)
+	}
+
+	// Removing replication drops the queue.
+	if err = removeReplicationConfig(bucket, obj); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Refresh(obj, bucket); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Get(bucket); ok {
+		t.Fatal("Expected bucket not to be replicated")
+	}
+	for _, prefix := range []string{getReplicationQueuePath(bucket), getReplicationRetryPath(bucket), getReplicationFailedPath(bucket)} {
+		result, err = obj.ListObjects(minioMetaBucket, prefix, "", "", maxObjectList)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Objects) != 0 {
+			t.Fatalf("Expected no entries under %s, got %d", prefix, len(result.Objects))
+		}
+	}
+}
@@ -0,0 +1,616 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	miniogo "github.com/minio/minio-go"
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+const (
+	// Tiering config of a bucket, persisted under the bucket config prefix.
+	bucketTieringConfig = "tiering.json"
+
+	// Current version of tiering configs.
+	bucketTieringVersion = "1"
+
+	// Interval at which tiered buckets are scanned for objects to
+	// transition or restore.
+	tieringInterval = time.Hour
+
+	// Stubs left in place of transitioned objects are marked by these
+	// internal metadata keys, holding the key of the copy on the remote
+	// tier and the size, ETag and modification time of the object.
+	tierRemoteKey = ReservedMetadataPrefix + "Tier-Remote-Key"
+	tierSize      = ReservedMetadataPrefix + "Tier-Size"
+	tierETag      = ReservedMetadataPrefix + "Tier-Etag"
+	tierModTime   = ReservedMetadataPrefix + "Tier-Mod-Time"
+)
+
+// Buckets are scanned by a single server at a time, other servers skip
+// buckets they cannot lock at once.
+var tieringLockTimeout = newDynamicTimeout(time.Second, time.Second)
+
+// newTierObjectLock - returns the lock readers and writers of an object
+// of a tiered bucket hold. It is taken in the meta bucket since the
+// object layer locks the object itself while reading or writing it, and
+// distributed locks of the same name cannot be taken twice.
+func newTierObjectLock(bucket, object string) RWLocker {
+	return globalNSMutex.NewNSLock(minioMetaBucket, path.Join("tiering", bucket, object))
+}
+
+// tieringRule - objects under Prefix are transitioned Days after they
+// were last modified.
+type tieringRule struct {
+	Prefix string `json:"prefix"`
+	Days   int    `json:"days"`
+}
+
+// tieringConfig - remote S3 target objects of a bucket are transitioned
+// to and the rules selecting them.
+type tieringConfig struct {
+	Version      string        `json:"version"`
+	Endpoint     string        `json:"endpoint"` // host[:port] of the target.
+	Secure       bool          `json:"secure"`
+	AccessKey    string        `json:"accessKey"`
+	SecretKey    string        `json:"secretKey"`
+	TargetBucket string        `json:"targetBucket"`
+	Rules        []tieringRule `json:"rules"`
+}
+
+// Validate - checks if all fields of the config are set. A config
+// without rules restores all transitioned objects.
+func (c tieringConfig) Validate() error {
+	if c.Endpoint == "" || strings.Contains(c.Endpoint, "/") {
+		return fmt.Errorf("Invalid tiering endpoint %q", c.Endpoint)
+	}
+	if c.AccessKey == "" || c.SecretKey == "" {
+		return fmt.Errorf("Tiering credentials are missing")
+	}
+	if !IsValidBucketName(c.TargetBucket) {
+		return fmt.Errorf("Invalid tiering target bucket %q", c.TargetBucket)
+	}
+	for _, rule := range c.Rules {
+		if rule.Days < 1 {
+			return fmt.Errorf("Invalid number of days %d for prefix %q", rule.Days, rule.Prefix)
+		}
+	}
+	return nil
+}
+
+// sameTarget - returns true if both configs transition objects to the
+// same remote bucket.
+func (c tieringConfig) sameTarget(o tieringConfig) bool {
+	return c.Endpoint == o.Endpoint && c.TargetBucket == o.TargetBucket
+}
+
+// isCold - returns true if an object last modified at modTime matches
+// a rule at now.
+func (c tieringConfig) isCold(object string, modTime, now time.Time) bool {
+	for _, rule := range c.Rules {
+		if strings.HasPrefix(object, rule.Prefix) && !modTime.After(now.AddDate(0, 0, -rule.Days)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTiered - returns true if an object matches the prefix of any rule,
+// regardless of its age.
+func (c tieringConfig) isTiered(object string) bool {
+	for _, rule := range c.Rules {
+		if strings.HasPrefix(object, rule.Prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns a client of the remote tier.
+func newTieringClient(c tieringConfig) (*miniogo.Client, error) {
+	client, err := miniogo.New(c.Endpoint, c.AccessKey, c.SecretKey, c.Secure)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	client.SetCustomTransport(NewCustomHTTPTransport())
+	return client, nil
+}
+
+// checkTieringTarget - checks if the target bucket of a config exists
+// and is accessible with its credentials.
+func checkTieringTarget(c tieringConfig) error {
+	client, err := newTieringClient(c)
+	if err != nil {
+		return err
+	}
+	ok, err := client.BucketExists(c.TargetBucket)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !ok {
+		return errors.Trace(fmt.Errorf("Tiering target bucket %s does not exist", c.TargetBucket))
+	}
+	return nil
+}
+
+// isTierStub - returns true if the object metadata marks a stub of a
+// transitioned object.
+func isTierStub(metadata map[string]string) bool {
+	_, ok := metadata[tierRemoteKey]
+	return ok
+}
+
+// getTierStubInfo - returns the info of the object a stub was left for,
+// internal keys of the stub are removed from its metadata.
+func getTierStubInfo(stubInfo ObjectInfo) ObjectInfo {
+	objInfo := stubInfo
+	objInfo.UserDefined = make(map[string]string, len(stubInfo.UserDefined))
+	for k, v := range stubInfo.UserDefined {
+		switch k {
+		case tierRemoteKey, tierSize, tierETag, tierModTime:
+			continue
+		}
+		objInfo.UserDefined[k] = v
+	}
+	objInfo.Size, _ = strconv.ParseInt(stubInfo.UserDefined[tierSize], 10, 64)
+	objInfo.ETag = stubInfo.UserDefined[tierETag]
+	objInfo.ModTime, _ = time.Parse(time.RFC3339Nano, stubInfo.UserDefined[tierModTime])
+	return objInfo
+}
+
+// setTierStub - marks metadata as the stub of an object transitioned
+// to remoteKey.
+func setTierStub(metadata map[string]string, objInfo ObjectInfo, remoteKey string) {
+	metadata[tierRemoteKey] = remoteKey
+	metadata[tierSize] = strconv.FormatInt(objInfo.Size, 10)
+	metadata[tierETag] = objInfo.ETag
+	metadata[tierModTime] = objInfo.ModTime.UTC().Format(time.RFC3339Nano)
+}
+
+// tieringStats - objects of a bucket transitioned and restored by this
+// server, Tiered is counted by the last scan of the bucket.
+type tieringStats struct {
+	Tiered            int       `json:"tiered"`
+	TieredBytes       int64     `json:"tieredBytes"`
+	Transitioned      uint64    `json:"transitioned"`
+	TransitionedBytes uint64    `json:"transitionedBytes"`
+	Restored          uint64    `json:"restored"`
+	Failures          uint64    `json:"failures"`
+	LastScan          time.Time `json:"lastScan"`
+	LastError         string    `json:"lastError,omitempty"`
+}
+
+// bucketTieringStatus - tiering config of a bucket without its secret
+// key and statistics.
+type bucketTieringStatus struct {
+	Bucket       string        `json:"bucket"`
+	Endpoint     string        `json:"endpoint"`
+	Secure       bool          `json:"secure"`
+	AccessKey    string        `json:"accessKey"`
+	TargetBucket string        `json:"targetBucket"`
+	Rules        []tieringRule `json:"rules"`
+	Stats        tieringStats  `json:"stats"`
+}
+
+// bucketTiering - tiering configs of all buckets. Cold objects of
+// tiered buckets are copied to the remote tier in the background and
+// replaced by empty stubs, reads of stubs are proxied to the remote
+// tier by the tiering object layer.
+type bucketTiering struct {
+	sync.RWMutex
+	configs *bucketConfigCache
+	stats   map[string]*tieringStats
+	kickCh  chan struct{}
+}
+
+func newBucketTiering() *bucketTiering {
+	return &bucketTiering{
+		configs: newBucketConfigCache(bucketTieringConfig, func() interface{} { return &tieringConfig{} }),
+		stats:   make(map[string]*tieringStats),
+		kickCh:  make(chan struct{}, 1),
+	}
+}
+
+// Global tiering of buckets, only initialized by the server.
+var globalBucketTiering *bucketTiering
+
+// Persists the tiering config of a bucket to object layer.
+func saveTieringConfig(bucket string, cfg tieringConfig, objAPI ObjectLayer) error {
+	cfg.Version = bucketTieringVersion
+	return saveBucketConfig(bucket, bucketTieringConfig, cfg, objAPI)
+}
+
+// Init - loads the tiering configs of all buckets.
+func (t *bucketTiering) Init(objAPI ObjectLayer) error {
+	if err := t.configs.Init(objAPI); err != nil {
+		return err
+	}
+	t.Lock()
+	t.kick()
+	t.Unlock()
+	return nil
+}
+
+// Refresh - loads the tiering config of a bucket after it was changed
+// by any server.
+func (t *bucketTiering) Refresh(objAPI ObjectLayer, bucket string) error {
+	if err := t.configs.Refresh(objAPI, bucket); err != nil {
+		return err
+	}
+	t.Lock()
+	t.kick()
+	t.Unlock()
+	return nil
+}
+
+// Remove - drops the tiering config of a bucket, used when the bucket
+// is deleted.
+func (t *bucketTiering) Remove(bucket string) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.configs.Remove(bucket)
+	delete(t.stats, bucket)
+}
+
+// Get - returns the tiering config of a bucket, false if the bucket is
+// not tiered.
+func (t *bucketTiering) Get(bucket string) (tieringConfig, bool) {
+	cfg, ok := t.configs.get(bucket)
+	if !ok {
+		return tieringConfig{}, false
+	}
+	return *cfg.(*tieringConfig), true
+}
+
+// Wakes up the tiering routine, must be called with the lock held.
+func (t *bucketTiering) kick() {
+	select {
+	case t.kickCh <- struct{}{}:
+	default:
+	}
+}
+
+// Returns the statistics of a bucket, must be called with the lock held.
+func (t *bucketTiering) getStats(bucket string) *tieringStats {
+	stats, ok := t.stats[bucket]
+	if !ok {
+		stats = &tieringStats{}
+		t.stats[bucket] = stats
+	}
+	return stats
+}
+
+// Records the result of transitioning or restoring an object of a
+// bucket.
+func (t *bucketTiering) record(bucket string, transitioned, restored bool, size int64, err error) {
+	t.Lock()
+	defer t.Unlock()
+
+	stats := t.getStats(bucket)
+	switch {
+	case err != nil:
+		stats.Failures++
+		stats.LastError = errors.Cause(err).Error()
+	case transitioned:
+		stats.Transitioned++
+		stats.TransitionedBytes += uint64(size)
+	case restored:
+		stats.Restored++
+	}
+}
+
+// Status - returns the tiering status of a bucket, false if the bucket
+// is not tiered.
+func (t *bucketTiering) Status(bucket string) (status bucketTieringStatus, ok bool) {
+	cfg, ok := t.Get(bucket)
+	if !ok {
+		return status, false
+	}
+
+	t.RLock()
+	defer t.RUnlock()
+	status = bucketTieringStatus{
+		Bucket:       bucket,
+		Endpoint:     cfg.Endpoint,
+		Secure:       cfg.Secure,
+		AccessKey:    cfg.AccessKey,
+		TargetBucket: cfg.TargetBucket,
+		Rules:        cfg.Rules,
+	}
+	if stats, found := t.stats[bucket]; found {
+		status.Stats = *stats
+	}
+	return status, true
+}
+
+// List - returns the tiering status of all tiered buckets, sorted by
+// bucket.
+func (t *bucketTiering) List() []bucketTieringStatus {
+	configs := t.configs.list()
+	buckets := make([]string, 0, len(configs))
+	for bucket := range configs {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+
+	statuses := []bucketTieringStatus{}
+	for _, bucket := range buckets {
+		if status, ok := t.Status(bucket); ok {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// hasTierStubs - returns true if any object of a bucket is transitioned,
+// objAPI must not translate stubs.
+func hasTierStubs(objAPI ObjectLayer, bucket string) (bool, error) {
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			return false, err
+		}
+		for _, objInfo := range result.Objects {
+			if isTierStub(objInfo.UserDefined) {
+				return true, nil
+			}
+		}
+		if !result.IsTruncated || len(result.Objects) == 0 {
+			return false, nil
+		}
+		marker = result.Objects[len(result.Objects)-1].Name
+	}
+}
+
+// canTransition - returns true if the content of an object can be
+// moved to the remote tier at now.
+func canTransition(objInfo ObjectInfo, now time.Time) bool {
+	switch {
+	case objInfo.IsDir || objInfo.Size == 0 || hasSuffix(objInfo.Name, slashSeparator):
+		return false
+	case objInfo.IsEncrypted() && !isSSES3Encrypted(objInfo.UserDefined):
+		// Objects encrypted with SSE-S3 are moved as they are stored,
+		// their copies stay encrypted with their data keys. Objects
+		// encrypted with SSE-C stay local.
+		return false
+	case isObjectQuarantined(objInfo.UserDefined):
+		return false
+	case getObjectLock(objInfo.UserDefined).isLocked(now, false):
+		// Locked objects cannot be replaced by stubs.
+		return false
+	}
+	return true
+}
+
+// getTieringPutOptions - returns the content type and user metadata of
+// an object sent with its copy to the remote tier, stubs keep all of
+// its metadata.
+func getTieringPutOptions(objInfo ObjectInfo) miniogo.PutObjectOptions {
+	opts := miniogo.PutObjectOptions{
+		UserMetadata: make(map[string]string),
+		ContentType:  objInfo.ContentType,
+	}
+	for k, v := range objInfo.UserDefined {
+		if strings.HasPrefix(strings.ToLower(k), "x-amz-meta-") {
+			opts.UserMetadata[k] = v
+		}
+	}
+	return opts
+}
+
+// transitionObject - copies an object to the remote tier and replaces
+// it by a stub, unless it is changed while being copied. objAPI must
+// not translate stubs.
+func transitionObject(objAPI ObjectLayer, client *miniogo.Client, cfg tieringConfig, objInfo ObjectInfo) (transitioned bool, err error) {
+	remoteKey := path.Join(objInfo.Bucket, mustGetUUID())
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(objAPI.GetObject(objInfo.Bucket, objInfo.Name, 0, objInfo.Size, pw, objInfo.ETag))
+	}()
+	_, err = client.PutObject(cfg.TargetBucket, remoteKey, pr, objInfo.Size, getTieringPutOptions(objInfo))
+	pr.Close()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
+	// Readers and writers of the object hold its tiering lock in
+	// tiered buckets, the stub replaces the object only if it is
+	// unchanged.
+	objectLock := newTierObjectLock(objInfo.Bucket, objInfo.Name)
+	if err = objectLock.GetLock(globalObjectTimeout); err != nil {
+		removeTieredCopy(client, cfg, remoteKey)
+		return false, err
+	}
+	defer objectLock.Unlock()
+
+	current, err := objAPI.GetObjectInfo(objInfo.Bucket, objInfo.Name)
+	if err != nil || current.ETag != objInfo.ETag || isTierStub(current.UserDefined) {
+		removeTieredCopy(client, cfg, remoteKey)
+		if isErrObjectNotFound(err) {
+			err = nil
+		}
+		return false, err
+	}
+
+	metadata := make(map[string]string, len(current.UserDefined)+4)
+	for k, v := range current.UserDefined {
+		metadata[k] = v
+	}
+	delete(metadata, "etag")
+	setTierStub(metadata, current, remoteKey)
+	hashReader, err := hash.NewReader(bytes.NewReader(nil), 0, "", "")
+	if err != nil {
+		removeTieredCopy(client, cfg, remoteKey)
+		return false, err
+	}
+	if _, err = objAPI.PutObject(objInfo.Bucket, objInfo.Name, hashReader, metadata); err != nil {
+		removeTieredCopy(client, cfg, remoteKey)
+		return false, err
+	}
+	return true, nil
+}
+
+// restoreObject - copies a transitioned object back from the remote
+// tier, replacing its stub. objAPI must not translate stubs.
+func restoreObject(objAPI ObjectLayer, client *miniogo.Client, cfg tieringConfig, stubInfo ObjectInfo) (restored bool, err error) {
+	objectLock := newTierObjectLock(stubInfo.Bucket, stubInfo.Name)
+	if err = objectLock.GetLock(globalObjectTimeout); err != nil {
+		return false, err
+	}
+	defer objectLock.Unlock()
+
+	current, err := objAPI.GetObjectInfo(stubInfo.Bucket, stubInfo.Name)
+	if err != nil || current.UserDefined[tierRemoteKey] != stubInfo.UserDefined[tierRemoteKey] {
+		// Replaced or removed since it was listed.
+		if isErrObjectNotFound(err) {
+			err = nil
+		}
+		return false, err
+	}
+
+	objInfo := getTierStubInfo(current)
+	remoteKey := current.UserDefined[tierRemoteKey]
+	reader, _, err := miniogo.Core{Client: client}.GetObject(cfg.TargetBucket, remoteKey, miniogo.GetObjectOptions{})
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	defer reader.Close()
+	hashReader, err := hash.NewReader(reader, objInfo.Size, "", "")
+	if err != nil {
+		return false, err
+	}
+	delete(objInfo.UserDefined, "etag")
+	if _, err = objAPI.PutObject(objInfo.Bucket, objInfo.Name, hashReader, objInfo.UserDefined); err != nil {
+		return false, err
+	}
+	removeTieredCopy(client, cfg, remoteKey)
+	return true, nil
+}
+
+// removeTieredCopy - removes a copy from the remote tier, failures
+// leave an orphaned copy behind and are only logged.
+func removeTieredCopy(client *miniogo.Client, cfg tieringConfig, remoteKey string) {
+	if err := client.RemoveObject(cfg.TargetBucket, remoteKey); err != nil {
+		errorIf(errors.Trace(err), "Unable to remove %s from tiering target bucket %s.", remoteKey, cfg.TargetBucket)
+	}
+}
+
+// transitionBucket - transitions objects of a bucket matching a rule
+// at now and restores transitioned objects not matching any rule
+// anymore. objAPI must not translate stubs.
+func (t *bucketTiering) transitionBucket(objAPI ObjectLayer, bucket string, cfg tieringConfig, now time.Time) {
+	bucketLock := globalNSMutex.NewNSLock(minioMetaBucket, path.Join("tiering", bucket))
+	if bucketLock.GetLock(tieringLockTimeout) != nil {
+		// Scanned by another server.
+		return
+	}
+	defer bucketLock.Unlock()
+
+	client, err := newTieringClient(cfg)
+	if err != nil {
+		t.record(bucket, false, false, 0, err)
+		return
+	}
+	var tiered int
+	var tieredBytes int64
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			errorIf(err, "Unable to list objects of tiered bucket %s.", bucket)
+			return
+		}
+		for _, objInfo := range result.Objects {
+			switch {
+			case isTierStub(objInfo.UserDefined):
+				if cfg.isTiered(objInfo.Name) {
+					tiered++
+					tieredBytes += getTierStubInfo(objInfo).Size
+					continue
+				}
+				restored, err := restoreObject(objAPI, client, cfg, objInfo)
+				t.record(bucket, false, restored, 0, err)
+				if err != nil {
+					tiered++
+					tieredBytes += getTierStubInfo(objInfo).Size
+				}
+			case cfg.isCold(objInfo.Name, objInfo.ModTime, now) && canTransition(objInfo, now):
+				transitioned, err := transitionObject(objAPI, client, cfg, objInfo)
+				t.record(bucket, transitioned, false, objInfo.Size, err)
+				if transitioned {
+					tiered++
+					tieredBytes += objInfo.Size
+				}
+			}
+		}
+		if !result.IsTruncated || len(result.Objects) == 0 {
+			break
+		}
+		marker = result.Objects[len(result.Objects)-1].Name
+	}
+
+	t.Lock()
+	stats := t.getStats(bucket)
+	stats.Tiered, stats.TieredBytes = tiered, tieredBytes
+	stats.LastScan = now
+	t.Unlock()
+}
+
+// transition - scans all tiered buckets at now. Nothing is moved in
+// WORM mode, objects cannot be replaced by stubs or restored.
+func (t *bucketTiering) transition(objAPI ObjectLayer, now time.Time) {
+	if globalWORMEnabled {
+		return
+	}
+	for bucket, cfg := range t.configs.list() {
+		t.transitionBucket(objAPI, bucket, *cfg.(*tieringConfig), now)
+	}
+}
+
+// Start - starts a routine scanning tiered buckets every interval and
+// when their configs are changed. Stubs are only visible below the
+// object layer middlewares, which are bypassed.
+func (t *bucketTiering) Start(interval time.Duration, doneCh chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-doneCh:
+				return
+			case <-t.kickCh:
+			case <-ticker.C:
+			}
+			if objAPI := newObjectLayerFn(); objAPI != nil {
+				t.transition(unwrapObjectLayer(objAPI), UTCNow())
+			}
+		}
+	}()
+}
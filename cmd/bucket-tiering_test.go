@@ -0,0 +1,344 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+// Tests validating tiering configs.
+func TestTieringConfigValidate(t *testing.T) {
+	rules := []tieringRule{{Prefix: "logs/", Days: 30}}
+	testCases := []struct {
+		cfg     tieringConfig
+		success bool
+	}{
+		{tieringConfig{Endpoint: "localhost:9000", AccessKey: "access", SecretKey: "secret", TargetBucket: "bucket", Rules: rules}, true},
+		{tieringConfig{Endpoint: "localhost:9000", AccessKey: "access", SecretKey: "secret", TargetBucket: "bucket"}, true},
+		{tieringConfig{Endpoint: "http://localhost:9000", AccessKey: "access", SecretKey: "secret", TargetBucket: "bucket", Rules: rules}, false},
+		{tieringConfig{Endpoint: "localhost:9000", AccessKey: "access", TargetBucket: "bucket", Rules: rules}, false},
+		{tieringConfig{Endpoint: "localhost:9000", AccessKey: "access", SecretKey: "secret", TargetBucket: "a", Rules: rules}, false},
+		{tieringConfig{Endpoint: "localhost:9000", AccessKey: "access", SecretKey: "secret", TargetBucket: "bucket",
+			Rules: []tieringRule{{Prefix: "logs/", Days: 0}}}, false},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.cfg.Validate()
+		if testCase.success && err != nil {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if !testCase.success && err == nil {
+			t.Errorf("Test %d: Expected error", i+1)
+		}
+	}
+}
+
+// Tests matching objects against the rules of a config.
+func TestTieringConfigIsCold(t *testing.T) {
+	cfg := tieringConfig{Rules: []tieringRule{{Prefix: "logs/", Days: 30}, {Prefix: "tmp/", Days: 1}}}
+	now := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		object  string
+		modTime time.Time
+		cold    bool
+	}{
+		{"logs/a", now.AddDate(0, 0, -30), true},
+		{"logs/a", now.AddDate(0, 0, -29), false},
+		{"tmp/a", now.Add(-25 * time.Hour), true},
+		{"data/a", now.AddDate(-1, 0, 0), false},
+	}
+	for i, testCase := range testCases {
+		if cold := cfg.isCold(testCase.object, testCase.modTime, now); cold != testCase.cold {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.cold, cold)
+		}
+	}
+	if !cfg.isTiered("logs/new") || cfg.isTiered("data/a") {
+		t.Error("Unexpected prefix match")
+	}
+}
+
+// Starts a server serving the remote tier, returns a config of a
+// bucket using it and the object layer of the server.
+func prepareTieringTarget(t *testing.T) (TestServer, tieringConfig) {
+	// Only the S3 API of the target is needed.
+	target := UnstartedTestServer(t, "FS")
+	target.Server.Config.Handler = initTestAPIEndPoints(target.Obj, nil)
+	target.Server.Start()
+
+	if err := target.Obj.MakeBucketWithLocation("cold", ""); err != nil {
+		target.Stop()
+		t.Fatal(err)
+	}
+	u, err := url.Parse(target.Server.URL)
+	if err != nil {
+		target.Stop()
+		t.Fatal(err)
+	}
+	return target, tieringConfig{
+		Endpoint:     u.Host,
+		AccessKey:    target.AccessKey,
+		SecretKey:    target.SecretKey,
+		TargetBucket: "cold",
+		Rules:        []tieringRule{{Prefix: "archive/", Days: 1}},
+	}
+}
+
+// Returns the number of objects on the remote tier.
+func countTieredObjects(t *testing.T, target TestServer) int {
+	result, err := target.Obj.ListObjects("cold", "", "", "", maxObjectList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(result.Objects)
+}
+
+// Tests moving cold objects to the remote tier and back.
+func TestBucketTiering(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	initNSLock(false)
+
+	target, cfg := prepareTieringTarget(t)
+	defer target.Stop()
+
+	obj, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello, world")
+	var infos []ObjectInfo
+	for _, object := range []string{"archive/object", "object"} {
+		objInfo, err := obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""),
+			map[string]string{"X-Amz-Meta-Color": "blue", "content-type": "text/plain"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		infos = append(infos, objInfo)
+	}
+
+	if err = checkTieringTarget(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err = saveTieringConfig(bucket, cfg, obj); err != nil {
+		t.Fatal(err)
+	}
+	tiering := newBucketTiering()
+	if err = tiering.Init(obj); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tiering.Get(bucket); !ok {
+		t.Fatal("Expected bucket to be tiered")
+	}
+
+	// Objects are not moved before they are cold.
+	tiering.transition(obj, UTCNow())
+	if n := countTieredObjects(t, target); n != 0 {
+		t.Fatalf("Expected no tiered objects, got %d", n)
+	}
+
+	// Objects are not moved in WORM mode.
+	globalWORMEnabled = true
+	tiering.transition(obj, UTCNow().AddDate(0, 0, 2))
+	globalWORMEnabled = false
+	if n := countTieredObjects(t, target); n != 0 {
+		t.Fatalf("Expected no tiered objects in WORM mode, got %d", n)
+	}
+
+	tiering.transition(obj, UTCNow().AddDate(0, 0, 2))
+	if n := countTieredObjects(t, target); n != 1 {
+		t.Fatalf("Expected 1 tiered object, got %d", n)
+	}
+	stubInfo, err := obj.GetObjectInfo(bucket, "archive/object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isTierStub(stubInfo.UserDefined) || stubInfo.Size != 0 {
+		t.Fatalf("Expected an empty stub, got %d bytes with %v", stubInfo.Size, stubInfo.UserDefined)
+	}
+	objInfo := getTierStubInfo(stubInfo)
+	if objInfo.Size != infos[0].Size || objInfo.ETag != infos[0].ETag || !objInfo.ModTime.Equal(infos[0].ModTime) {
+		t.Fatalf("Expected info of the object %+v, got %+v", infos[0], objInfo)
+	}
+	if objInfo.UserDefined["X-Amz-Meta-Color"] != "blue" || objInfo.ContentType != "text/plain" {
+		t.Fatalf("Expected metadata of the object, got %v", objInfo.UserDefined)
+	}
+	if objInfo, err = obj.GetObjectInfo(bucket, "object"); err != nil || isTierStub(objInfo.UserDefined) {
+		t.Fatalf("Expected object not to be moved, got %v", err)
+	}
+
+	status, ok := tiering.Status(bucket)
+	if !ok {
+		t.Fatal("Expected tiering status")
+	}
+	if status.Stats.Tiered != 1 || status.Stats.TieredBytes != int64(len(data)) || status.Stats.Transitioned != 1 || status.Stats.Failures != 0 {
+		t.Fatalf("Unexpected tiering stats %+v", status.Stats)
+	}
+	if inUse, err := hasTierStubs(obj, bucket); err != nil || !inUse {
+		t.Fatalf("Expected tiered objects, got %v", err)
+	}
+
+	// Objects not matching any rule are moved back.
+	cfg.Rules = nil
+	if err = saveTieringConfig(bucket, cfg, obj); err != nil {
+		t.Fatal(err)
+	}
+	if err = tiering.Refresh(obj, bucket); err != nil {
+		t.Fatal(err)
+	}
+	tiering.transition(obj, UTCNow().AddDate(0, 0, 2))
+	if n := countTieredObjects(t, target); n != 0 {
+		t.Fatalf("Expected no tiered objects, got %d", n)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "archive/object", 0, -1, &buffer, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("Expected %q to be restored, got %q", data, buffer.Bytes())
+	}
+	if objInfo, err = obj.GetObjectInfo(bucket, "archive/object"); err != nil || isTierStub(objInfo.UserDefined) {
+		t.Fatalf("Expected object to be restored, got %v", err)
+	}
+	if objInfo.UserDefined["X-Amz-Meta-Color"] != "blue" {
+		t.Fatalf("Expected metadata to be restored, got %v", objInfo.UserDefined)
+	}
+	if inUse, err := hasTierStubs(obj, bucket); err != nil || inUse {
+		t.Fatalf("Expected no tiered objects, got %v", err)
+	}
+	if status, _ = tiering.Status(bucket); status.Stats.Tiered != 0 || status.Stats.Restored != 1 {
+		t.Fatalf("Unexpected tiering stats %+v", status.Stats)
+	}
+
+	if err = removeBucketConfig(bucket, bucketTieringConfig, obj); err != nil {
+		t.Fatal(err)
+	}
+	if err = tiering.Refresh(obj, bucket); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok = tiering.Get(bucket); ok {
+		t.Fatal("Expected bucket not to be tiered")
+	}
+}
+
+// Tests that objects encrypted with SSE-S3 are moved as they are
+// stored, and objects encrypted with SSE-C are not moved.
+func TestBucketTieringEncrypted(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+	defer func(kms KMS) { globalKMS = kms }(globalKMS)
+	globalKMS = newTestKMS()
+
+	initNSLock(false)
+
+	target, cfg := prepareTieringTarget(t)
+	defer target.Stop()
+
+	obj, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello, world")
+	metadata := map[string]string{SSEHeader: SSEAlgorithmAES256}
+	reader, err := newKMSEncryptReader(bytes.NewReader(data), globalKMS, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = obj.PutObject(bucket, "archive/sse-s3", mustGetHashReader(t, reader, encryptedSize(int64(len(data))), "", ""), metadata); err != nil {
+		t.Fatal(err)
+	}
+	metadata = make(map[string]string)
+	if reader, err = newEncryptReader(bytes.NewReader(data), make([]byte, 32), metadata); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = obj.PutObject(bucket, "archive/sse-c", mustGetHashReader(t, reader, encryptedSize(int64(len(data))), "", ""), metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = saveTieringConfig(bucket, cfg, obj); err != nil {
+		t.Fatal(err)
+	}
+	tiering := newBucketTiering()
+	if err = tiering.Init(obj); err != nil {
+		t.Fatal(err)
+	}
+	tiering.transition(obj, UTCNow().AddDate(0, 0, 2))
+	if n := countTieredObjects(t, target); n != 1 {
+		t.Fatalf("Expected 1 tiered object, got %d", n)
+	}
+	stubInfo, err := obj.GetObjectInfo(bucket, "archive/sse-s3")
+	if err != nil || !isTierStub(stubInfo.UserDefined) {
+		t.Fatalf("Expected SSE-S3 object to be moved, got %v", err)
+	}
+	result, err := target.Obj.ListObjects("cold", "", "", "", maxObjectList)
+	if err != nil || len(result.Objects) != 1 {
+		t.Fatalf("Expected 1 tiered object, got %v", err)
+	}
+	var buffer bytes.Buffer
+	if err = target.Obj.GetObject("cold", result.Objects[0].Name, 0, -1, &buffer, ""); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buffer.Bytes(), data) {
+		t.Fatal("Expected the tiered copy to stay encrypted")
+	}
+	if objInfo, err := obj.GetObjectInfo(bucket, "archive/sse-c"); err != nil || isTierStub(objInfo.UserDefined) {
+		t.Fatalf("Expected SSE-C object not to be moved, got %v", err)
+	}
+
+	// Restored objects are decrypted with their data keys.
+	cfg.Rules = nil
+	if err = saveTieringConfig(bucket, cfg, obj); err != nil {
+		t.Fatal(err)
+	}
+	if err = tiering.Refresh(obj, bucket); err != nil {
+		t.Fatal(err)
+	}
+	tiering.transition(obj, UTCNow().AddDate(0, 0, 2))
+	objInfo, err := obj.GetObjectInfo(bucket, "archive/sse-s3")
+	if err != nil || isTierStub(objInfo.UserDefined) {
+		t.Fatalf("Expected SSE-S3 object to be restored, got %v", err)
+	}
+	buffer.Reset()
+	if err = getWebObject(obj, bucket, objInfo, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("Expected %q to be restored, got %q", data, buffer.Bytes())
+	}
+}
@@ -0,0 +1,431 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+// Interval between crawls of all buckets updating the usage index.
+const usageCrawlInterval = time.Hour
+
+// Maximum number of prefixes of a bucket kept in the usage index,
+// prefixes found once the index of a bucket is full are not indexed.
+const usageMaxPrefixes = 10000
+
+// Buckets are crawled by a single server at a time, other servers skip
+// the crawl they cannot lock at once and load the persisted usage.
+var usageCrawlLockTimeout = newDynamicTimeout(time.Second, time.Second)
+
+// Path locked by the server crawling all buckets.
+const usageCrawlLockPath = "usage-crawler.lock"
+
+const (
+	// Statistics of a bucket, persisted under the bucket config prefix.
+	bucketStatsConfig = "stats.json"
+
+	// Current version of the persisted statistics.
+	bucketStatsVersion = "2"
+
+	// Maximum number of top-level prefixes kept in the statistics.
+	bucketStatsMaxPrefixes = 100
+)
+
+// Upper bounds of the age ranges objects are counted in, objects older
+// than the last bound are counted in one more range.
+var bucketStatsAgeRanges = []time.Duration{
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+	30 * 24 * time.Hour,
+	90 * 24 * time.Hour,
+	365 * 24 * time.Hour,
+}
+
+// prefixUsage - number and total size of the objects of a prefix.
+type prefixUsage struct {
+	Objects int64 `json:"objects"`
+	Size    int64 `json:"size"`
+}
+
+// prefixStats - usage of a top-level prefix of a bucket.
+type prefixStats struct {
+	Prefix string `json:"prefix"`
+	prefixUsage
+}
+
+// bucketStats - statistics of a bucket for capacity planning, computed
+// while crawling the bucket and persisted to outlive restarts.
+type bucketStats struct {
+	Version     string    `json:"version"`
+	LastUpdated time.Time `json:"lastUpdated"`
+
+	prefixUsage
+
+	// Modification times of the oldest and the newest object.
+	FirstObjectTime time.Time `json:"firstObjectTime"`
+	LastObjectTime  time.Time `json:"lastObjectTime"`
+
+	// Usage of the objects by age when the bucket was crawled, one
+	// entry per range of bucketStatsAgeRanges and one for older objects.
+	Ages []prefixUsage `json:"ages"`
+
+	// Largest top-level prefixes, by size.
+	TopPrefixes []prefixStats `json:"topPrefixes"`
+
+	// Usage of every indexed prefix ending with a slash, served by the
+	// servers not crawling the bucket themselves. PrefixesTruncated is
+	// set when some prefixes were not indexed.
+	Prefixes          map[string]prefixUsage `json:"prefixes"`
+	PrefixesTruncated bool                   `json:"prefixesTruncated,omitempty"`
+}
+
+func newBucketStats() bucketStats {
+	return bucketStats{
+		Version: bucketStatsVersion,
+		Ages:    make([]prefixUsage, len(bucketStatsAgeRanges)+1),
+	}
+}
+
+// addObject - adds an object to the statistics, its age is counted
+// relative to now.
+func (s *bucketStats) addObject(modTime time.Time, size int64, now time.Time) {
+	s.Objects++
+	s.Size += size
+	if s.FirstObjectTime.IsZero() || modTime.Before(s.FirstObjectTime) {
+		s.FirstObjectTime = modTime
+	}
+	if modTime.After(s.LastObjectTime) {
+		s.LastObjectTime = modTime
+	}
+
+	age := now.Sub(modTime)
+	i := sort.Search(len(bucketStatsAgeRanges), func(i int) bool {
+		return age < bucketStatsAgeRanges[i]
+	})
+	s.Ages[i].Objects++
+	s.Ages[i].Size += size
+}
+
+// setTopPrefixes - keeps the largest top-level prefixes of the usage of
+// a bucket.
+func (s *bucketStats) setTopPrefixes(prefixes map[string]prefixUsage) {
+	s.TopPrefixes = nil
+	for prefix, usage := range prefixes {
+		if prefix == "" || strings.Index(prefix, slashSeparator) != len(prefix)-1 {
+			continue
+		}
+		s.TopPrefixes = append(s.TopPrefixes, prefixStats{Prefix: prefix, prefixUsage: usage})
+	}
+	sort.Slice(s.TopPrefixes, func(i, j int) bool {
+		if s.TopPrefixes[i].Size != s.TopPrefixes[j].Size {
+			return s.TopPrefixes[i].Size > s.TopPrefixes[j].Size
+		}
+		return s.TopPrefixes[i].Prefix < s.TopPrefixes[j].Prefix
+	})
+	if len(s.TopPrefixes) > bucketStatsMaxPrefixes {
+		s.TopPrefixes = s.TopPrefixes[:bucketStatsMaxPrefixes]
+	}
+}
+
+// bucketUsage - usage of every prefix of a bucket ending with a slash,
+// the empty prefix holds the usage of the whole bucket. At most
+// usageMaxPrefixes prefixes are kept, truncated is set once a prefix
+// was left out.
+type bucketUsage struct {
+	lastUpdate time.Time
+	prefixes   map[string]prefixUsage
+	truncated  bool
+	stats      bucketStats
+}
+
+// Returns the usage of a bucket as of its persisted statistics.
+func newBucketUsage(stats bucketStats) *bucketUsage {
+	return &bucketUsage{
+		lastUpdate: stats.LastUpdated,
+		prefixes:   stats.Prefixes,
+		truncated:  stats.PrefixesTruncated,
+		stats:      stats,
+	}
+}
+
+// add - adds an object to the usage of all its parent prefixes which
+// are indexed.
+func (u *bucketUsage) add(object string, size int64) {
+	prefix := ""
+	for {
+		usage, ok := u.prefixes[prefix]
+		if !ok && len(u.prefixes) >= usageMaxPrefixes {
+			// Deeper prefixes of the object are not indexed either.
+			u.truncated = true
+			return
+		}
+		usage.Objects++
+		usage.Size += size
+		u.prefixes[prefix] = usage
+
+		i := strings.Index(object[len(prefix):], slashSeparator)
+		if i < 0 {
+			return
+		}
+		prefix = object[:len(prefix)+i+1]
+	}
+}
+
+// usageCrawler - index of object counts and sizes per prefix, built by
+// periodically listing all buckets. A single server crawls the buckets
+// and persists their usage, the others load it. The index lags behind
+// uploads and deletes by up to usageCrawlInterval.
+type usageCrawler struct {
+	sync.RWMutex
+	buckets map[string]*bucketUsage
+}
+
+func newUsageCrawler() *usageCrawler {
+	return &usageCrawler{buckets: make(map[string]*bucketUsage)}
+}
+
+// Global usage index, only initialized by the server.
+var globalUsageCrawler *usageCrawler
+
+// crawlBucket - lists all objects of a bucket and returns their usage.
+func crawlBucket(objAPI ObjectLayer, bucket string) (*bucketUsage, error) {
+	usage := &bucketUsage{
+		prefixes: make(map[string]prefixUsage),
+		stats:    newBucketStats(),
+	}
+	now := UTCNow()
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			return nil, err
+		}
+		for _, objInfo := range result.Objects {
+			usage.add(objInfo.Name, objInfo.Size)
+			usage.stats.addObject(objInfo.ModTime, objInfo.Size, now)
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+		if marker == "" && len(result.Objects) > 0 {
+			marker = result.Objects[len(result.Objects)-1].Name
+		}
+	}
+	usage.lastUpdate = UTCNow()
+	usage.stats.LastUpdated = usage.lastUpdate
+	usage.stats.setTopPrefixes(usage.prefixes)
+	usage.stats.Prefixes = usage.prefixes
+	usage.stats.PrefixesTruncated = usage.truncated
+	return usage, nil
+}
+
+// Crawl - updates the usage of all buckets, each as soon as it is
+// crawled, and persists their statistics. A single server crawls at a
+// time, the others load the usage persisted by it. Buckets crawled less
+// than usageCrawlInterval ago, e.g. by another server, are not crawled
+// again. Buckets failing to be crawled keep their previous usage.
+func (c *usageCrawler) Crawl(objAPI ObjectLayer) error {
+	crawlLock := globalNSMutex.NewNSLock(minioMetaBucket, usageCrawlLockPath)
+	crawling := crawlLock.GetLock(usageCrawlLockTimeout) == nil
+	if crawling {
+		defer crawlLock.Unlock()
+	}
+
+	bucketsInfo, err := objAPI.ListBuckets()
+	if err != nil {
+		return err
+	}
+
+	buckets := make(map[string]bool, len(bucketsInfo))
+	for _, bucketInfo := range bucketsInfo {
+		buckets[bucketInfo.Name] = true
+
+		stats, ok, err := loadBucketStats(bucketInfo.Name, objAPI)
+		if err != nil {
+			errorIf(err, "Unable to load statistics of bucket %s.", bucketInfo.Name)
+			continue
+		}
+		// Statistics of older versions do not carry the usage of
+		// prefixes.
+		ok = ok && stats.Version == bucketStatsVersion
+		// Leave some slack for timers of servers started at about the
+		// same time.
+		if !crawling || ok && UTCNow().Sub(stats.LastUpdated) < usageCrawlInterval-usageCrawlInterval/10 {
+			if ok {
+				c.Lock()
+				c.buckets[bucketInfo.Name] = newBucketUsage(stats)
+				c.Unlock()
+			}
+			continue
+		}
+
+		usage, err := crawlBucket(objAPI, bucketInfo.Name)
+		if err != nil {
+			errorIf(err, "Unable to crawl bucket %s.", bucketInfo.Name)
+			continue
+		}
+		errorIf(c.update(objAPI, bucketInfo.Name, usage), "Unable to persist statistics of bucket %s.", bucketInfo.Name)
+	}
+
+	// Drop the usage of buckets deleted meanwhile.
+	c.Lock()
+	for bucket := range c.buckets {
+		if !buckets[bucket] {
+			delete(c.buckets, bucket)
+		}
+	}
+	c.Unlock()
+	return nil
+}
+
+// update - sets and persists the usage of a crawled bucket. The usage
+// of a bucket deleted while it was crawled is dropped, deleting a
+// bucket removes its usage before or after it is written.
+func (c *usageCrawler) update(objAPI ObjectLayer, bucket string, usage *bucketUsage) error {
+	if _, err := objAPI.GetBucketInfo(bucket); err != nil {
+		if isErrBucketNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	c.Lock()
+	c.buckets[bucket] = usage
+	c.Unlock()
+	err := persistBucketStats(bucket, usage.stats, objAPI)
+
+	if _, berr := objAPI.GetBucketInfo(bucket); isErrBucketNotFound(berr) {
+		c.Remove(bucket)
+		return removeBucketStats(bucket, objAPI)
+	}
+	return err
+}
+
+// Remove - drops the usage of a bucket, used when the bucket is deleted.
+func (c *usageCrawler) Remove(bucket string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.buckets, bucket)
+}
+
+// Summary - returns the usage of a prefix ending with a slash, or of
+// the whole bucket for the empty prefix, and when it was last updated.
+// Returns errUsageNotReady if the bucket was not crawled yet, and
+// errPrefixNotIndexed for prefixes left out of a full index.
+func (c *usageCrawler) Summary(bucket, prefix string) (prefixUsage, time.Time, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	usage, ok := c.buckets[bucket]
+	if !ok {
+		return prefixUsage{}, time.Time{}, errUsageNotReady
+	}
+	summary, ok := usage.prefixes[prefix]
+	if !ok && usage.truncated {
+		return summary, usage.lastUpdate, errPrefixNotIndexed
+	}
+	return summary, usage.lastUpdate, nil
+}
+
+// Stats - returns the statistics of a bucket, false if the bucket was
+// not crawled yet.
+func (c *usageCrawler) Stats(bucket string) (bucketStats, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	usage, ok := c.buckets[bucket]
+	if !ok {
+		return bucketStats{}, false
+	}
+	return usage.stats, true
+}
+
+// Start a routine crawling all buckets periodically.
+func startUsageCrawler(c *usageCrawler, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if objAPI := newObjectLayerFn(); objAPI != nil {
+				errorIf(c.Crawl(objAPI), "Unable to crawl buckets.")
+			}
+			select {
+			case <-globalServiceDoneCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Loads the persisted statistics of a bucket, returns false if none
+// are persisted yet.
+func loadBucketStats(bucket string, objAPI ObjectLayer) (bucketStats, bool, error) {
+	statsPath := path.Join(bucketConfigPrefix, bucket, bucketStatsConfig)
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, statsPath, 0, -1, &buffer, "")
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return bucketStats{}, false, nil
+		}
+		return bucketStats{}, false, err
+	}
+	if buffer.Len() == 0 {
+		return bucketStats{}, false, nil
+	}
+
+	stats := bucketStats{}
+	if err = json.Unmarshal(buffer.Bytes(), &stats); err != nil {
+		return bucketStats{}, false, errors.Trace(err)
+	}
+	return stats, true, nil
+}
+
+// Persists the statistics of a bucket to object layer.
+func persistBucketStats(bucket string, stats bucketStats, objAPI ObjectLayer) error {
+	buf, err := json.Marshal(stats)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	statsPath := path.Join(bucketConfigPrefix, bucket, bucketStatsConfig)
+	hashReader, err := hash.NewReader(bytes.NewReader(buf), int64(len(buf)), "", getSHA256Hash(buf))
+	if err != nil {
+		return err
+	}
+	_, err = objAPI.PutObject(minioMetaBucket, statsPath, hashReader, nil)
+	return err
+}
+
+// Removes the persisted statistics of a bucket, only used during DeleteBucket.
+func removeBucketStats(bucket string, objAPI ObjectLayer) error {
+	statsPath := path.Join(bucketConfigPrefix, bucket, bucketStatsConfig)
+
+	return objAPI.DeleteObject(minioMetaBucket, statsPath)
+}
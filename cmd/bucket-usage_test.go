@@ -0,0 +1,211 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Tests that objects are added to the usage of all their parent prefixes.
+func TestBucketUsageAdd(t *testing.T) {
+	usage := &bucketUsage{prefixes: make(map[string]prefixUsage)}
+	usage.add("a.jpg", 1)
+	usage.add("photos/b.jpg", 2)
+	usage.add("photos/2018/c.jpg", 4)
+	usage.add("photos/2018/", 0)
+
+	expected := map[string]prefixUsage{
+		"":             {Objects: 4, Size: 7},
+		"photos/":      {Objects: 3, Size: 6},
+		"photos/2018/": {Objects: 2, Size: 4},
+	}
+	if !reflect.DeepEqual(usage.prefixes, expected) {
+		t.Fatalf("Expected %v, got %v", expected, usage.prefixes)
+	}
+	if usage.truncated {
+		t.Fatal("Expected usage not to be truncated")
+	}
+}
+
+// Tests that prefixes beyond usageMaxPrefixes are not indexed, while
+// their objects still count for the indexed parents.
+func TestBucketUsageAddTruncated(t *testing.T) {
+	usage := &bucketUsage{prefixes: make(map[string]prefixUsage)}
+	for i := 0; i < usageMaxPrefixes; i++ {
+		usage.add(fmt.Sprintf("dir%d/object", i), 1)
+	}
+	if len(usage.prefixes) != usageMaxPrefixes || !usage.truncated {
+		t.Fatalf("Expected %d prefixes and truncated usage, got %d, %v", usageMaxPrefixes, len(usage.prefixes), usage.truncated)
+	}
+	usage.add("dir0/sub/object", 1)
+	if _, ok := usage.prefixes["dir0/sub/"]; ok {
+		t.Error("Expected prefix beyond the maximum not to be indexed")
+	}
+	if usage.prefixes[""].Objects != usageMaxPrefixes+1 || usage.prefixes["dir0/"].Objects != 2 {
+		t.Errorf("Expected objects to be counted in indexed prefixes, got %v, %v", usage.prefixes[""], usage.prefixes["dir0/"])
+	}
+}
+
+// Tests that objects are counted in the range of their age.
+func TestBucketStatsAddObject(t *testing.T) {
+	now := UTCNow()
+	day := 24 * time.Hour
+	stats := newBucketStats()
+	stats.addObject(now.Add(-time.Hour), 1, now)
+	stats.addObject(now.Add(-400*day), 2, now)
+	stats.addObject(now.Add(-30*day), 4, now)
+	stats.addObject(now.Add(-2*day), 8, now)
+
+	expected := []prefixUsage{{1, 1}, {1, 8}, {0, 0}, {1, 4}, {0, 0}, {1, 2}}
+	if !reflect.DeepEqual(stats.Ages, expected) {
+		t.Errorf("Expected %v, got %v", expected, stats.Ages)
+	}
+	if stats.Objects != 4 || stats.Size != 15 {
+		t.Errorf("Expected 4 objects of 15 bytes, got %v", stats.prefixUsage)
+	}
+	if !stats.FirstObjectTime.Equal(now.Add(-400*day)) || !stats.LastObjectTime.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Unexpected first and last object times %v, %v", stats.FirstObjectTime, stats.LastObjectTime)
+	}
+}
+
+// Tests that the largest top-level prefixes are kept.
+func TestBucketStatsSetTopPrefixes(t *testing.T) {
+	stats := newBucketStats()
+	stats.setTopPrefixes(map[string]prefixUsage{
+		"":           {Objects: 5, Size: 15},
+		"a/":         {Objects: 1, Size: 4},
+		"a/b/":       {Objects: 1, Size: 4},
+		"c/":         {Objects: 2, Size: 10},
+		"d/":         {Objects: 1, Size: 4},
+		"e/f/g/h/i/": {Objects: 1, Size: 100},
+	})
+
+	expected := []prefixStats{
+		{Prefix: "c/", prefixUsage: prefixUsage{Objects: 2, Size: 10}},
+		{Prefix: "a/", prefixUsage: prefixUsage{Objects: 1, Size: 4}},
+		{Prefix: "d/", prefixUsage: prefixUsage{Objects: 1, Size: 4}},
+	}
+	if !reflect.DeepEqual(stats.TopPrefixes, expected) {
+		t.Errorf("Expected %v, got %v", expected, stats.TopPrefixes)
+	}
+}
+
+// Wrapper for calling usage crawler tests for both XL multiple disks and single node setup.
+func TestUsageCrawler(t *testing.T) {
+	ExecObjectLayerTest(t, testUsageCrawler)
+}
+
+// Tests crawling buckets into the usage index.
+func testUsageCrawler(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	data := []byte("hello")
+	for _, object := range []string{"object1", "dir/object2", "dir/sub/object3"} {
+		_, err := obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+
+	crawler := newUsageCrawler()
+	if _, _, err := crawler.Summary(bucket, ""); err != errUsageNotReady {
+		t.Fatalf("%s: Expected %v before crawling, got %v", instanceType, errUsageNotReady, err)
+	}
+	if err := crawler.Crawl(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	testCases := []struct {
+		prefix string
+		usage  prefixUsage
+	}{
+		{"", prefixUsage{Objects: 3, Size: 15}},
+		{"dir/", prefixUsage{Objects: 2, Size: 10}},
+		{"dir/sub/", prefixUsage{Objects: 1, Size: 5}},
+		{"unknown/", prefixUsage{}},
+	}
+	for i, testCase := range testCases {
+		usage, lastUpdate, err := crawler.Summary(bucket, testCase.prefix)
+		if err != nil || lastUpdate.IsZero() {
+			t.Fatalf("%s: Test %d: Expected bucket to be crawled", instanceType, i+1)
+		}
+		if usage != testCase.usage {
+			t.Errorf("%s: Test %d: Expected %v, got %v", instanceType, i+1, testCase.usage, usage)
+		}
+	}
+
+	stats, ok := crawler.Stats(bucket)
+	if !ok || stats.Objects != 3 || len(stats.TopPrefixes) != 1 || stats.TopPrefixes[0].Prefix != "dir/" {
+		t.Fatalf("%s: Unexpected statistics %v", instanceType, stats)
+	}
+	persisted, ok, err := loadBucketStats(bucket, obj)
+	if err != nil || !ok {
+		t.Fatalf("%s: Expected statistics to be persisted, got %v", instanceType, err)
+	}
+	if persisted.Objects != stats.Objects || !persisted.LastUpdated.Equal(stats.LastUpdated) ||
+		!reflect.DeepEqual(persisted.Ages, stats.Ages) || !reflect.DeepEqual(persisted.TopPrefixes, stats.TopPrefixes) {
+		t.Errorf("%s: Expected persisted statistics %v, got %v", instanceType, stats, persisted)
+	}
+
+	// Other servers load the usage persisted by the crawling one
+	// instead of crawling the bucket again.
+	other := newUsageCrawler()
+	crawlLock := globalNSMutex.NewNSLock(minioMetaBucket, usageCrawlLockPath)
+	if err = crawlLock.GetLock(usageCrawlLockTimeout); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	err = other.Crawl(obj)
+	crawlLock.Unlock()
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if usage, lastUpdate, err := other.Summary(bucket, "dir/"); err != nil || usage != testCases[1].usage || !lastUpdate.Equal(stats.LastUpdated) {
+		t.Fatalf("%s: Expected persisted usage %v, got %v, %v", instanceType, testCases[1].usage, usage, err)
+	}
+
+	// Usage of buckets deleted while they were crawled is not re-added.
+	usage, err := crawlBucket(obj, bucket)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	for _, object := range []string{"object1", "dir/object2", "dir/sub/object3"} {
+		if err = obj.DeleteObject(bucket, object); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+	if err = obj.DeleteBucket(bucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	// Done by deleteBucketMetadata for the global crawler.
+	crawler.Remove(bucket)
+	_ = removeBucketStats(bucket, obj)
+	if err = crawler.update(obj, bucket, usage); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, _, err = crawler.Summary(bucket, ""); err != errUsageNotReady {
+		t.Fatalf("%s: Expected no usage of deleted bucket, got %v", instanceType, err)
+	}
+	if _, ok, err = loadBucketStats(bucket, obj); ok || err != nil {
+		t.Fatalf("%s: Expected no persisted statistics of deleted bucket, got %v", instanceType, err)
+	}
+}
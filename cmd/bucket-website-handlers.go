@@ -0,0 +1,155 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/errors"
+)
+
+// Maximum size of website configurations sent by clients.
+const maxWebsiteConfigSize = 64 * 1024
+
+// PutBucketWebsiteHandler - PUT /bucket?website
+// ----------
+// Sets the index and error documents of the static website hosted by
+// the bucket, anonymous GET requests of the bucket are served as
+// website requests from then on.
+func (api objectAPIHandlers) PutBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := mux.Vars(r)["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+	if globalBucketWebsite == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:PutBucketWebsite", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	var websiteConfiguration WebsiteConfiguration
+	if err := xmlDecoder(r.Body, &websiteConfiguration, maxWebsiteConfigSize); err != nil {
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+	cfg, err := parseWebsiteConfiguration(websiteConfiguration)
+	if err != nil {
+		if _, ok := errors.Cause(err).(NotImplemented); ok {
+			writeErrorResponse(w, ErrNotImplemented, r.URL)
+			return
+		}
+		writeErrorResponse(w, ErrInvalidWebsiteConfiguration, r.URL)
+		return
+	}
+
+	if err = saveWebsiteConfig(bucket, cfg, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	globalBucketWebsite.Set(bucket, cfg)
+
+	// Notify all peers (including self) to reload the config.
+	S3PeersUpdateBucketConfig(bucket, bucketWebsiteConfig)
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetBucketWebsiteHandler - GET /bucket?website
+// ----------
+// Returns the website configuration of the bucket.
+func (api objectAPIHandlers) GetBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := mux.Vars(r)["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+	if globalBucketWebsite == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetBucketWebsite", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	cfg, ok := globalBucketWebsite.Get(bucket)
+	if !ok {
+		writeErrorResponse(w, ErrNoSuchWebsiteConfiguration, r.URL)
+		return
+	}
+	writeSuccessResponseXML(w, encodeResponse(cfg.toWebsiteConfiguration()))
+}
+
+// DeleteBucketWebsiteHandler - DELETE /bucket?website
+// ----------
+// Removes the website configuration of the bucket, the bucket stops
+// hosting a website.
+func (api objectAPIHandlers) DeleteBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := mux.Vars(r)["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+	if globalBucketWebsite == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:DeleteBucketWebsite", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	if err := removeBucketConfig(bucket, bucketWebsiteConfig, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	globalBucketWebsite.Remove(bucket)
+
+	// Notify all peers (including self) to reload the config.
+	S3PeersUpdateBucketConfig(bucket, bucketWebsiteConfig)
+
+	writeSuccessNoContent(w)
+}
@@ -0,0 +1,226 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// Website config of a bucket, persisted under the bucket config prefix.
+	bucketWebsiteConfig = "website.json"
+
+	// Current version of website configs.
+	bucketWebsiteVersion = "1"
+)
+
+// WebsiteConfiguration - website configuration of a bucket, set and
+// returned by the website subresource. Redirects and routing rules
+// are not supported.
+type WebsiteConfiguration struct {
+	XMLName               xml.Name        `xml:"WebsiteConfiguration"`
+	IndexDocument         *IndexDocument  `xml:"IndexDocument,omitempty"`
+	ErrorDocument         *ErrorDocument  `xml:"ErrorDocument,omitempty"`
+	RedirectAllRequestsTo *websiteUnknown `xml:"RedirectAllRequestsTo,omitempty"`
+	RoutingRules          *websiteUnknown `xml:"RoutingRules,omitempty"`
+}
+
+// IndexDocument - document served for requests of the bucket root
+// and of prefixes, the suffix is appended to the requested prefix.
+type IndexDocument struct {
+	Suffix string `xml:"Suffix"`
+}
+
+// ErrorDocument - document served for requests of missing objects.
+type ErrorDocument struct {
+	Key string `xml:"Key"`
+}
+
+// Unsupported elements of website configurations, only decoded to
+// reject configurations with them.
+type websiteUnknown struct {
+	Inner []byte `xml:",innerxml"`
+}
+
+// websiteConfig - website config of a bucket, only buckets hosting a
+// static website have one.
+type websiteConfig struct {
+	Version       string `json:"version"`
+	IndexDocument string `json:"indexDocument"`
+	ErrorDocument string `json:"errorDocument,omitempty"`
+}
+
+// Validate - checks the index and error documents of the config.
+func (c websiteConfig) Validate() error {
+	if c.IndexDocument == "" || strings.Contains(c.IndexDocument, slashSeparator) {
+		return fmt.Errorf("Index document suffix must be non-empty and not contain slashes")
+	}
+	if c.ErrorDocument != "" && !IsValidObjectName(c.ErrorDocument) {
+		return fmt.Errorf("Invalid error document key %q", c.ErrorDocument)
+	}
+	return nil
+}
+
+// parseWebsiteConfiguration - converts a website configuration set by
+// a client into the config of a bucket.
+func parseWebsiteConfiguration(websiteConfiguration WebsiteConfiguration) (cfg websiteConfig, err error) {
+	if websiteConfiguration.RedirectAllRequestsTo != nil || websiteConfiguration.RoutingRules != nil {
+		return cfg, NotImplemented{}
+	}
+	if websiteConfiguration.IndexDocument == nil {
+		return cfg, fmt.Errorf("Index document is required")
+	}
+	cfg.IndexDocument = websiteConfiguration.IndexDocument.Suffix
+	if websiteConfiguration.ErrorDocument != nil {
+		cfg.ErrorDocument = websiteConfiguration.ErrorDocument.Key
+		if cfg.ErrorDocument == "" {
+			return cfg, fmt.Errorf("Error document needs a key")
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// toWebsiteConfiguration - converts the config of a bucket into the
+// website configuration returned to clients.
+func (c websiteConfig) toWebsiteConfiguration() WebsiteConfiguration {
+	websiteConfiguration := WebsiteConfiguration{IndexDocument: &IndexDocument{Suffix: c.IndexDocument}}
+	if c.ErrorDocument != "" {
+		websiteConfiguration.ErrorDocument = &ErrorDocument{Key: c.ErrorDocument}
+	}
+	return websiteConfiguration
+}
+
+// Persists the website config of a bucket to object layer.
+func saveWebsiteConfig(bucket string, cfg websiteConfig, objAPI ObjectLayer) error {
+	cfg.Version = bucketWebsiteVersion
+	return saveBucketConfig(bucket, bucketWebsiteConfig, cfg, objAPI)
+}
+
+// bucketWebsite - website configs of all buckets hosting a website.
+type bucketWebsite struct {
+	*bucketConfigCache
+}
+
+func newBucketWebsite() *bucketWebsite {
+	return &bucketWebsite{newBucketConfigCache(bucketWebsiteConfig, func() interface{} { return &websiteConfig{} })}
+}
+
+// Global website configs of buckets, only initialized by the server,
+// gateways do not support website hosting.
+var globalBucketWebsite *bucketWebsite
+
+// Set - sets the website config of a bucket on this server.
+func (b *bucketWebsite) Set(bucket string, cfg websiteConfig) {
+	b.set(bucket, &cfg)
+}
+
+// Get - returns the website config of a bucket, false if the bucket
+// does not host a website.
+func (b *bucketWebsite) Get(bucket string) (websiteConfig, bool) {
+	if b == nil {
+		return websiteConfig{}, false
+	}
+	cfg, ok := b.get(bucket)
+	if !ok {
+		return websiteConfig{}, false
+	}
+	return *cfg.(*websiteConfig), true
+}
+
+// getWebsiteConfigForRequest - returns the website config of the bucket
+// if the request is served as a website request. Only anonymous GET
+// requests are, signed requests keep the behavior of the S3 API.
+func getWebsiteConfigForRequest(r *http.Request, bucket string) (websiteConfig, bool) {
+	if r.Method != http.MethodGet || getRequestAuthType(r) != authTypeAnonymous {
+		return websiteConfig{}, false
+	}
+	return globalBucketWebsite.Get(bucket)
+}
+
+// getWebsiteDocumentInfo - returns the info of a document of a website,
+// anonymous requests must be allowed to read it by the bucket policy.
+func getWebsiteDocumentInfo(r *http.Request, objAPI ObjectLayer, bucket, object string) (ObjectInfo, APIErrorCode) {
+	resource := "/" + bucket + "/" + object
+	if s3Error := enforceBucketPolicy(bucket, "s3:GetObject", resource,
+		r.Referer(), getSourceIPAddress(r), r.URL.Query()); s3Error != ErrNone {
+		return ObjectInfo{}, s3Error
+	}
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return objInfo, toAPIErrorCode(err)
+	}
+	// Documents encrypted with SSE-C cannot be decrypted for anonymous
+	// requests, documents encrypted with SSE-S3 are decrypted.
+	if objAPI.IsEncryptionSupported() && objInfo.IsEncrypted() && !isSSES3Encrypted(objInfo.UserDefined) {
+		return objInfo, ErrSSEEncryptedObject
+	}
+	return objInfo, ErrNone
+}
+
+// writeWebsiteDocument - writes a document of a website with the
+// status code.
+func writeWebsiteDocument(w http.ResponseWriter, r *http.Request, objAPI ObjectLayer, objInfo ObjectInfo, statusCode int) {
+	headerInfo := objInfo
+	if objAPI.IsEncryptionSupported() && isSSES3Encrypted(objInfo.UserDefined) {
+		var err error
+		if headerInfo.Size, err = objInfo.DecryptedSize(); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		headerInfo.UserDefined = make(map[string]string, len(objInfo.UserDefined))
+		for k, v := range objInfo.UserDefined {
+			headerInfo.UserDefined[k] = v
+		}
+		removeSSES3Metadata(headerInfo.UserDefined)
+	}
+	setObjectHeaders(w, headerInfo, nil)
+	w.WriteHeader(statusCode)
+	err := getWebObject(objAPI, objInfo.Bucket, objInfo, w)
+	errorIf(err, "Unable to write website document %s/%s to client.", objInfo.Bucket, objInfo.Name)
+}
+
+// serveWebsiteIndexDocument - serves the index document of a prefix of
+// a website, the prefix is empty for the bucket root.
+func serveWebsiteIndexDocument(w http.ResponseWriter, r *http.Request, objAPI ObjectLayer, bucket, prefix string, cfg websiteConfig) {
+	objInfo, s3Error := getWebsiteDocumentInfo(r, objAPI, bucket, prefix+cfg.IndexDocument)
+	if s3Error == ErrNoSuchKey {
+		serveWebsiteErrorDocument(w, r, objAPI, bucket, cfg)
+		return
+	}
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+	writeWebsiteDocument(w, r, objAPI, objInfo, http.StatusOK)
+}
+
+// serveWebsiteErrorDocument - serves the error document of a website
+// for a missing object with status 404, the usual error response if
+// the website has no error document or it cannot be read.
+func serveWebsiteErrorDocument(w http.ResponseWriter, r *http.Request, objAPI ObjectLayer, bucket string, cfg websiteConfig) {
+	if cfg.ErrorDocument != "" {
+		objInfo, s3Error := getWebsiteDocumentInfo(r, objAPI, bucket, cfg.ErrorDocument)
+		if s3Error == ErrNone {
+			writeWebsiteDocument(w, r, objAPI, objInfo, http.StatusNotFound)
+			return
+		}
+	}
+	writeErrorResponse(w, errAllowableObjectNotFound(bucket, r), r.URL)
+}
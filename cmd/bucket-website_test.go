@@ -0,0 +1,206 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/minio/minio-go/pkg/policy"
+	"github.com/minio/minio/pkg/auth"
+)
+
+// Tests converting website configurations of clients.
+func TestParseWebsiteConfiguration(t *testing.T) {
+	testCases := []struct {
+		config   string
+		expected websiteConfig
+		success  bool
+	}{
+		{`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument></WebsiteConfiguration>`,
+			websiteConfig{IndexDocument: "index.html"}, true},
+		{`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><ErrorDocument><Key>errors/404.html</Key></ErrorDocument></WebsiteConfiguration>`,
+			websiteConfig{IndexDocument: "index.html", ErrorDocument: "errors/404.html"}, true},
+		{`<WebsiteConfiguration></WebsiteConfiguration>`, websiteConfig{}, false},
+		{`<WebsiteConfiguration><IndexDocument><Suffix></Suffix></IndexDocument></WebsiteConfiguration>`, websiteConfig{}, false},
+		{`<WebsiteConfiguration><IndexDocument><Suffix>a/index.html</Suffix></IndexDocument></WebsiteConfiguration>`, websiteConfig{}, false},
+		{`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><ErrorDocument></ErrorDocument></WebsiteConfiguration>`, websiteConfig{}, false},
+		{`<WebsiteConfiguration><RedirectAllRequestsTo><HostName>example.com</HostName></RedirectAllRequestsTo></WebsiteConfiguration>`, websiteConfig{}, false},
+	}
+	for i, testCase := range testCases {
+		var websiteConfiguration WebsiteConfiguration
+		if err := xml.Unmarshal([]byte(testCase.config), &websiteConfiguration); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		cfg, err := parseWebsiteConfiguration(websiteConfiguration)
+		if testCase.success != (err == nil) {
+			t.Fatalf("Test %d: Expected success %v but got %v", i+1, testCase.success, err)
+		}
+		if testCase.success && cfg != testCase.expected {
+			t.Fatalf("Test %d: Expected %+v but got %+v", i+1, testCase.expected, cfg)
+		}
+	}
+}
+
+// Tests the website APIs and the index and error documents served to
+// anonymous requests.
+func TestAPIBucketWebsiteHandlers(t *testing.T) {
+	defer func() { globalBucketWebsite = nil }()
+	defer func(kms KMS) { globalKMS = kms }(globalKMS)
+	ExecObjectLayerAPITest(t, testAPIBucketWebsiteHandlers, []string{"BucketWebsite", "GetObject", "ListObjectsV1"})
+}
+
+func testAPIBucketWebsiteHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	globalBucketWebsite = newBucketWebsite()
+
+	doRequest := func(method, urlStr string, body []byte, anonymous bool) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		var req *http.Request
+		var err error
+		if anonymous {
+			req, err = newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		} else {
+			req, err = newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader(body), credentials.AccessKey, credentials.SecretKey)
+		}
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	expectResponse := func(rec *httptest.ResponseRecorder, status int, body, msg string) {
+		if rec.Code != status {
+			t.Fatalf("%s: %s: Expected the response status to be `%d`, but instead found `%d`: %s", instanceType, msg, status, rec.Code, rec.Body.String())
+		}
+		if body != "" && rec.Body.String() != body {
+			t.Fatalf("%s: %s: Expected the response body to be %q, but instead found %q", instanceType, msg, body, rec.Body.String())
+		}
+	}
+
+	for object, content := range map[string]string{
+		"index.html":      "root index",
+		"docs/index.html": "docs index",
+		"docs/page.html":  "docs page",
+		"404.html":        "not found",
+	} {
+		if _, err := obj.PutObject(bucketName, object, mustGetHashReader(t, bytes.NewReader([]byte(content)), int64(len(content)), "", ""), nil); err != nil {
+			t.Fatalf("%s: Failed to put object %s: %v", instanceType, object, err)
+		}
+	}
+	bucketPolicy := policy.BucketAccessPolicy{
+		Version:    "1.0",
+		Statements: policy.SetPolicy(nil, policy.BucketPolicyReadOnly, bucketName, ""),
+	}
+	if err := obj.SetBucketPolicy(bucketName, bucketPolicy); err != nil {
+		t.Fatalf("%s: Failed to set bucket policy: %v", instanceType, err)
+	}
+
+	// Without a website config the bucket is listed.
+	expectResponse(doRequest("GET", getBucketWebsiteURL("", bucketName), nil, false), http.StatusNotFound, "", "get config")
+	rec := doRequest("GET", getListObjectsV1URL("", bucketName, ""), nil, true)
+	expectResponse(rec, http.StatusOK, "", "list")
+	if !bytes.Contains(rec.Body.Bytes(), []byte("<ListBucketResult")) {
+		t.Fatalf("%s: Expected a listing but got %s", instanceType, rec.Body.String())
+	}
+
+	// Invalid and unsupported configs are rejected.
+	expectResponse(doRequest("PUT", getBucketWebsiteURL("", bucketName),
+		[]byte(`<WebsiteConfiguration><IndexDocument><Suffix>a/b</Suffix></IndexDocument></WebsiteConfiguration>`), false),
+		http.StatusBadRequest, "", "invalid config")
+	expectResponse(doRequest("PUT", getBucketWebsiteURL("", bucketName),
+		[]byte(`<WebsiteConfiguration><RoutingRules></RoutingRules></WebsiteConfiguration>`), false),
+		http.StatusNotImplemented, "", "routing rules")
+
+	config := []byte(`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument>` +
+		`<ErrorDocument><Key>404.html</Key></ErrorDocument></WebsiteConfiguration>`)
+	expectResponse(doRequest("PUT", getBucketWebsiteURL("", bucketName), config, true), http.StatusForbidden, "", "anonymous put config")
+	expectResponse(doRequest("PUT", getBucketWebsiteURL("", bucketName), config, false), http.StatusOK, "", "put config")
+	rec = doRequest("GET", getBucketWebsiteURL("", bucketName), nil, false)
+	expectResponse(rec, http.StatusOK, "", "get config")
+	var websiteConfiguration WebsiteConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &websiteConfiguration); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if cfg, err := parseWebsiteConfiguration(websiteConfiguration); err != nil || cfg != (websiteConfig{IndexDocument: "index.html", ErrorDocument: "404.html"}) {
+		t.Fatalf("%s: Unexpected config %+v, %v", instanceType, cfg, err)
+	}
+
+	// Anonymous requests are served the index and error documents.
+	expectResponse(doRequest("GET", getListObjectsV1URL("", bucketName, ""), nil, true), http.StatusOK, "root index", "root")
+	expectResponse(doRequest("GET", getGetObjectURL("", bucketName, "docs/"), nil, true), http.StatusOK, "docs index", "prefix")
+	expectResponse(doRequest("GET", getGetObjectURL("", bucketName, "docs/page.html"), nil, true), http.StatusOK, "docs page", "object")
+	expectResponse(doRequest("GET", getGetObjectURL("", bucketName, "docs/missing.html"), nil, true), http.StatusNotFound, "not found", "missing object")
+	expectResponse(doRequest("GET", getGetObjectURL("", bucketName, "other/"), nil, true), http.StatusNotFound, "not found", "missing index")
+
+	// Documents encrypted with SSE-S3 are decrypted, documents encrypted
+	// with SSE-C are not served.
+	globalKMS = newTestKMS()
+	encrypted := []byte("encrypted index")
+	metadata := map[string]string{SSEHeader: SSEAlgorithmAES256}
+	reader, err := newKMSEncryptReader(bytes.NewReader(encrypted), globalKMS, metadata)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = obj.PutObject(bucketName, "sse-s3/index.html", mustGetHashReader(t, reader, encryptedSize(int64(len(encrypted))), "", ""), metadata); err != nil {
+		t.Fatalf("%s: Failed to put object: %v", instanceType, err)
+	}
+	rec = doRequest("GET", getGetObjectURL("", bucketName, "sse-s3/"), nil, true)
+	expectResponse(rec, http.StatusOK, string(encrypted), "SSE-S3 index")
+	if rec.Header().Get("Content-Length") != strconv.Itoa(len(encrypted)) || rec.Header().Get(ServerSideEncryptionKMSSealedKey) != "" {
+		t.Fatalf("%s: Unexpected headers of SSE-S3 index %v", instanceType, rec.Header())
+	}
+	metadata = make(map[string]string)
+	if reader, err = newEncryptReader(bytes.NewReader(encrypted), make([]byte, 32), metadata); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = obj.PutObject(bucketName, "sse-c/index.html", mustGetHashReader(t, reader, encryptedSize(int64(len(encrypted))), "", ""), metadata); err != nil {
+		t.Fatalf("%s: Failed to put object: %v", instanceType, err)
+	}
+	expectResponse(doRequest("GET", getGetObjectURL("", bucketName, "sse-c/"), nil, true), http.StatusBadRequest, "", "SSE-C index")
+
+	// Signed requests keep the behavior of the S3 API.
+	rec = doRequest("GET", getListObjectsV1URL("", bucketName, ""), nil, false)
+	expectResponse(rec, http.StatusOK, "", "signed list")
+	if !bytes.Contains(rec.Body.Bytes(), []byte("<ListBucketResult")) {
+		t.Fatalf("%s: Expected a listing but got %s", instanceType, rec.Body.String())
+	}
+	rec = doRequest("GET", getGetObjectURL("", bucketName, "docs/missing.html"), nil, false)
+	expectResponse(rec, http.StatusNotFound, "", "signed missing object")
+	if bytes.Contains(rec.Body.Bytes(), []byte("not found")) {
+		t.Fatalf("%s: Expected an error response but got the error document", instanceType)
+	}
+
+	// Documents not readable by anonymous requests are not served.
+	bucketPolicy.Statements = policy.SetPolicy(nil, policy.BucketPolicyReadOnly, bucketName, "docs")
+	if err := obj.SetBucketPolicy(bucketName, bucketPolicy); err != nil {
+		t.Fatalf("%s: Failed to set bucket policy: %v", instanceType, err)
+	}
+	expectResponse(doRequest("GET", getListObjectsV1URL("", bucketName, ""), nil, true), http.StatusForbidden, "", "root denied")
+	expectResponse(doRequest("GET", getGetObjectURL("", bucketName, "docs/missing.html"), nil, true), http.StatusForbidden, "", "error document denied")
+
+	// Removing the config stops serving the website.
+	expectResponse(doRequest("DELETE", getBucketWebsiteURL("", bucketName), nil, false), http.StatusNoContent, "", "delete config")
+	expectResponse(doRequest("GET", getBucketWebsiteURL("", bucketName), nil, false), http.StatusNotFound, "", "get deleted config")
+	rec = doRequest("GET", getListObjectsV1URL("", bucketName, ""), nil, true)
+	expectResponse(rec, http.StatusForbidden, "", "list after delete")
+}
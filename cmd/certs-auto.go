@@ -0,0 +1,227 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	// Environment variables configuring automatic certificates.
+	acmeDirectoryURLEnv = "MINIO_ACME_DIRECTORY"
+	acmeEmailEnv        = "MINIO_ACME_EMAIL"
+	acmeHTTPAddressEnv  = "MINIO_ACME_HTTP_ADDRESS"
+
+	// Address serving HTTP-01 challenges, the CA always connects to port 80.
+	defaultACMEHTTPAddress = ":80"
+
+	// Certificates are renewed when they expire within this duration.
+	certsAutoRenewBefore = 30 * 24 * time.Hour
+)
+
+// certsAutoManager - obtains and renews the server certificates using
+// ACME, renewed certificates are served without restarting.
+type certsAutoManager struct {
+	domains []string
+	manager *autocert.Manager
+}
+
+// newCertsAutoManager - initializes a manager obtaining certificates
+// for domains, the account key is created on first use.
+func newCertsAutoManager(domains []string, agreeTOS bool) (*certsAutoManager, error) {
+	accountKey, err := loadACMEAccountKey(getACMEAccountKeyFile())
+	if err != nil {
+		return nil, err
+	}
+
+	directoryURL := os.Getenv(acmeDirectoryURLEnv)
+	if directoryURL == "" {
+		directoryURL = autocert.DefaultACMEDirectory
+	}
+
+	manager := &autocert.Manager{
+		Cache:       autocert.DirCache(filepath.Dir(getACMEAccountKeyFile())),
+		HostPolicy:  autocert.HostWhitelist(domains...),
+		RenewBefore: certsAutoRenewBefore,
+		Email:       os.Getenv(acmeEmailEnv),
+		Client: &acme.Client{
+			Key:          accountKey,
+			DirectoryURL: directoryURL,
+			HTTPClient:   &http.Client{Transport: NewCustomHTTPTransport()},
+		},
+	}
+	if agreeTOS {
+		manager.Prompt = autocert.AcceptTOS
+	}
+
+	return &certsAutoManager{
+		domains: domains,
+		manager: manager,
+	}, nil
+}
+
+// Global automatic certificates manager, only set with --certs-auto.
+var globalCertsAuto *certsAutoManager
+
+// GetCertificate - returns the certificate of the requested domain,
+// used as tls.Config.GetCertificate to hot-swap renewed certificates.
+// Clients not sending a server name are served the certificate of
+// the first domain.
+func (m *certsAutoManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if hello == nil {
+		hello = certsAutoClientHello(m.domains[0])
+	} else if hello.ServerName == "" {
+		h := *hello
+		h.ServerName = m.domains[0]
+		hello = &h
+	}
+	return m.manager.GetCertificate(hello)
+}
+
+// certsAutoClientHello - returns a hello for domain accepting ECDSA
+// certificates.
+func certsAutoClientHello(domain string) *tls.ClientHelloInfo {
+	return &tls.ClientHelloInfo{
+		ServerName:   domain,
+		CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+	}
+}
+
+// startChallengeServer - serves HTTP-01 challenges for the CA on addr,
+// all other requests are answered with 404 (not found).
+func (m *certsAutoManager) startChallengeServer(addr string) {
+	handler := m.manager.HTTPHandler(http.HandlerFunc(http.NotFound))
+	go func() {
+		errorIf(http.ListenAndServe(addr, handler), "Unable to serve ACME challenges on %s", addr)
+	}()
+}
+
+// publicCerts - returns the certificate chain served for the
+// first domain.
+func (m *certsAutoManager) publicCerts() ([]*x509.Certificate, error) {
+	cert, err := m.GetCertificate(nil)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for _, der := range cert.Certificate {
+		x509Cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, x509Cert)
+	}
+	return certs, nil
+}
+
+// loadACMEAccountKey - loads the ACME account key, a new key is
+// generated and saved if none exists.
+func loadACMEAccountKey(keyFile string) (*ecdsa.PrivateKey, error) {
+	return loadECPrivateKey(keyFile)
+}
+
+// loadECPrivateKey - loads a PEM encoded EC private key, a new P-256
+// key is generated and saved if none exists.
+func loadECPrivateKey(keyFile string) (*ecdsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(keyFile)
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("Could not read PEM block from file %s", keyFile)
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, err
+	}
+	if err = writeFileAtomic(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// writeFileAtomic - writes data to a temporary file renamed to filename.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	tmpFile := filename + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile, filename); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return nil
+}
+
+// initCertsAuto - initializes automatic certificates for the domains,
+// blocks until a certificate is available for each of them. Obtained
+// certificates are cached under the certs directory and renewed in
+// the background before they expire.
+func initCertsAuto(domains []string, agreeTOS bool) (*certsAutoManager, error) {
+	if len(domains) == 0 {
+		return nil, errors.New("--certs-auto requires MINIO_DOMAIN to be set")
+	}
+	if !agreeTOS {
+		return nil, errors.New("--certs-auto requires --certs-auto-agree-tos to agree to the terms of service of the certificate authority")
+	}
+
+	m, err := newCertsAutoManager(domains, agreeTOS)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := os.Getenv(acmeHTTPAddressEnv)
+	if addr == "" {
+		addr = defaultACMEHTTPAddress
+	}
+	m.startChallengeServer(addr)
+
+	// Certificates obtained earlier are loaded from the cache.
+	for _, domain := range domains {
+		if _, err = m.manager.GetCertificate(certsAutoClientHello(domain)); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,138 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Tests that the ACME account key is created once and reused.
+func TestLoadACMEAccountKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-acme-account-key")
+	if err != nil {
+		t.Fatalf("Unable create temp directory. %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, certsACMEDir, acmeAccountKeyFile)
+	key1, err := loadACMEAccountKey(keyFile)
+	if err != nil {
+		t.Fatalf("Unable to create account key. %v", err)
+	}
+	key2, err := loadACMEAccountKey(keyFile)
+	if err != nil {
+		t.Fatalf("Unable to load account key. %v", err)
+	}
+	if key1.D.Cmp(key2.D) != 0 {
+		t.Fatal("Expected the saved account key to be reused")
+	}
+
+	if err = ioutil.WriteFile(keyFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = loadACMEAccountKey(keyFile); err == nil {
+		t.Fatal("Expected an error loading an invalid account key")
+	}
+}
+
+// Tests serving cached certificates, also to clients without a server name.
+func TestCertsAutoGetCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-certs-auto")
+	if err != nil {
+		t.Fatalf("Unable create temp directory. %v", err)
+	}
+	defer os.RemoveAll(dir)
+	setConfigDir(dir)
+
+	domains := []string{"minio.example.com", "s3.example.com"}
+	m, err := newCertsAutoManager(domains, true)
+	if err != nil {
+		t.Fatalf("Unable to initialize manager. %v", err)
+	}
+
+	// Nothing is cached yet, obtaining a certificate for a domain
+	// not managed fails without contacting the CA.
+	if _, err = m.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"}); err == nil {
+		t.Fatal("Expected an error for a domain not managed")
+	}
+
+	// Cache a certificate for each domain as obtained from the CA.
+	for i, domain := range domains {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(i + 1)),
+			DNSNames:     []string{domain},
+			NotBefore:    UTCNow().Add(-time.Hour),
+			NotAfter:     UTCNow().Add(90 * 24 * time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+		if err = m.manager.Cache.Put(context.Background(), domain, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		hello  *tls.ClientHelloInfo
+		domain string
+	}{
+		{nil, domains[0]},
+		{&tls.ClientHelloInfo{CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}}, domains[0]},
+		{certsAutoClientHello(domains[0]), domains[0]},
+		{certsAutoClientHello(domains[1]), domains[1]},
+	}
+	for i, testCase := range testCases {
+		cert, err := m.GetCertificate(testCase.hello)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to get certificate. %v", i+1, err)
+		}
+		if err = cert.Leaf.VerifyHostname(testCase.domain); err != nil {
+			t.Errorf("Test %d: Expected the certificate of %s. %v", i+1, testCase.domain, err)
+		}
+	}
+
+	certs, err := m.publicCerts()
+	if err != nil {
+		t.Fatalf("Unable to get public certificates. %v", err)
+	}
+	if len(certs) != 1 || certs[0].VerifyHostname(domains[0]) != nil {
+		t.Errorf("Expected the certificate of %s", domains[0])
+	}
+}
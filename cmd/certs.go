@@ -96,6 +96,37 @@ func getRootCAs(certsCAsDir string) (*x509.CertPool, error) {
 	return rootCAs, nil
 }
 
+// getAdminClientCAs - loads the CA certificates verifying client
+// certificates of admin requests, returns nil if none are installed.
+// Unlike getRootCAs the system cert pool is never trusted.
+func getAdminClientCAs(adminCAsDir string) (*x509.CertPool, error) {
+	fis, err := ioutil.ReadDir(adminCAsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(fis) == 0 {
+		return nil, nil
+	}
+
+	clientCAs := x509.NewCertPool()
+	for _, fi := range fis {
+		caFile := filepath.Join(adminCAsDir, fi.Name())
+		caCert, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if !clientCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("Could not read CA certificates from file %s", caFile)
+		}
+	}
+
+	return clientCAs, nil
+}
+
 // load an X509 key pair (private key , certificate) from the provided
 // paths. The private key may be encrypted and is decrypted using the
 // ENV_VAR: MINIO_CERT_PASSWD.
@@ -506,3 +506,62 @@ OncDtuIpK8O7OyfHP3+MBpUFG4P6Ctn7RVcZe9fQweTpfAy18G+loVzuUeOD
 		shouldFail: false,
 	},
 }
+
+func TestGetAdminClientCAs(t *testing.T) {
+	emptydir, err := ioutil.TempDir("", "test-get-admin-client-cas")
+	if err != nil {
+		t.Fatalf("Unable create temp directory. %v", emptydir)
+	}
+	defer os.RemoveAll(emptydir)
+
+	dir1, err := ioutil.TempDir("", "test-get-admin-client-cas")
+	if err != nil {
+		t.Fatalf("Unable create temp directory. %v", dir1)
+	}
+	defer os.RemoveAll(dir1)
+	if err = ioutil.WriteFile(filepath.Join(dir1, "empty-file"), []byte{}, 0644); err != nil {
+		t.Fatalf("Unable create test file. %v", err)
+	}
+
+	dir2, err := ioutil.TempDir("", "test-get-admin-client-cas")
+	if err != nil {
+		t.Fatalf("Unable create temp directory. %v", dir2)
+	}
+	defer os.RemoveAll(dir2)
+	caCert, _, err := generateTLSCertKey("127.0.0.1")
+	if err != nil {
+		t.Fatalf("Unable generate CA certificate. %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir2, "ca.crt"), caCert, 0644); err != nil {
+		t.Fatalf("Unable create test file. %v", err)
+	}
+
+	testCases := []struct {
+		adminCAsDir  string
+		expectedErr  error
+		expectedPool bool
+	}{
+		{"nonexistent-dir", nil, false},
+		{emptydir, nil, false},
+		{dir1, fmt.Errorf("Could not read CA certificates from file %s", filepath.Join(dir1, "empty-file")), false},
+		{dir2, nil, true},
+	}
+
+	for i, testCase := range testCases {
+		clientCAs, err := getAdminClientCAs(testCase.adminCAsDir)
+
+		if testCase.expectedErr == nil {
+			if err != nil {
+				t.Fatalf("Test %d: error: expected = <nil>, got = %v", i+1, err)
+			}
+		} else if err == nil {
+			t.Fatalf("Test %d: error: expected = %v, got = <nil>", i+1, testCase.expectedErr)
+		} else if testCase.expectedErr.Error() != err.Error() {
+			t.Fatalf("Test %d: error: expected = %v, got = %v", i+1, testCase.expectedErr, err)
+		}
+
+		if (clientCAs != nil) != testCase.expectedPool {
+			t.Fatalf("Test %d: expected CA pool %v, got %v", i+1, testCase.expectedPool, clientCAs != nil)
+		}
+	}
+}
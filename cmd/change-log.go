@@ -0,0 +1,326 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+const (
+	// Change log entries are stored under this prefix of
+	// minioMetaBucket, one object per change named by time.
+	changeLogPrefix = "changelog"
+
+	// Current version of change log entries.
+	changeLogEntryVersion = "1"
+
+	// Maximum number of change log entries returned at once.
+	maxChangeLogEntries = 1000
+
+	// Types of recorded changes.
+	changeTypeBucketPolicy = "bucket-policy"
+	changeTypeCredentials  = "credentials"
+	changeTypeConfig       = "config"
+	changeTypeReplication  = "bucket-replication"
+	changeTypeTiering      = "bucket-tiering"
+	changeTypeMetadata     = "metadata-restore"
+
+	// Replaces values of secret keys and passwords in diffs.
+	redactedValue = "*REDACTED*"
+)
+
+// changeLogDiff - value of a single key before and after a change,
+// keys are paths into the JSON document like "Statement[0].Effect".
+type changeLogDiff struct {
+	Key string `json:"key"`
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// changeLogEntry - record of a single change of a bucket policy,
+// the credentials or the server configuration.
+type changeLogEntry struct {
+	Version string          `json:"version"`
+	ID      string          `json:"id"`
+	Time    time.Time       `json:"time"`
+	Actor   auditCaller     `json:"actor"`
+	Type    string          `json:"type"`
+	Bucket  string          `json:"bucket,omitempty"`
+	Diff    []changeLogDiff `json:"diff"`
+}
+
+// listChangeLogResult - page of change log entries returned by the
+// admin API.
+type listChangeLogResult struct {
+	Entries     []changeLogEntry `json:"entries"`
+	IsTruncated bool             `json:"isTruncated"`
+	NextMarker  string           `json:"nextMarker,omitempty"`
+}
+
+// Change log is only recorded by the server, gateways have no
+// minioMetaBucket to keep it in.
+var globalIsChangeLog = false
+
+// Maximum number of change log entries read to list changes of a
+// bucket at once.
+var maxChangeLogScan = 10000
+
+// getChangeActor - returns who sent a request changing the server,
+// browser requests are signed by the current credentials.
+func getChangeActor(r *http.Request) auditCaller {
+	accessKey := getRequestAccessKey(r)
+	if accessKey == "" && isHTTPRequestValid(r) {
+		accessKey = globalServerConfig.GetCredential().AccessKey
+	}
+	return auditCaller{
+		AccessKey:  accessKey,
+		RemoteHost: getSourceIPAddress(r),
+		UserAgent:  r.UserAgent(),
+	}
+}
+
+// flattenJSON - adds all leaf values of a decoded JSON document to
+// values keyed by their path.
+func flattenJSON(key string, v interface{}, values map[string]string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range v {
+			if key != "" {
+				k = key + "." + k
+			}
+			flattenJSON(k, elem, values)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			flattenJSON(fmt.Sprintf("%s[%d]", key, i), elem, values)
+		}
+	case nil:
+	default:
+		data, _ := json.Marshal(v)
+		values[key] = string(data)
+	}
+}
+
+// isSecretKey - returns true if values of key must not be recorded.
+// URLs and connection strings of notification targets carry their
+// credentials, they are not recorded either.
+func isSecretKey(key string) bool {
+	if i := strings.LastIndex(key, "."); i != -1 {
+		key = key[i+1:]
+	}
+	if i := strings.Index(key, "["); i != -1 {
+		key = key[:i]
+	}
+	key = strings.ToLower(key)
+	switch key {
+	case "url", "dsnstring", "connectionstring":
+		return true
+	}
+	return strings.Contains(key, "secret") || strings.Contains(key, "password") ||
+		strings.Contains(key, "token")
+}
+
+// redactURLCredentials - returns the JSON encoded value with the user
+// info of a URL in it redacted, other values are returned as is.
+func redactURLCredentials(value string) string {
+	var s string
+	if err := json.Unmarshal([]byte(value), &s); err != nil || !strings.Contains(s, "://") {
+		return value
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return value
+	}
+	// User info is escaped by URL, redacted value is inserted as is.
+	u.User = nil
+	s = strings.Replace(u.String(), "://", "://"+redactedValue+"@", 1)
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// diffJSON - returns the keys which differ between the JSON encodings
+// of before and after, sorted by key. Values of secrets are redacted.
+func diffJSON(before, after interface{}) ([]changeLogDiff, error) {
+	flatten := func(v interface{}) (map[string]string, error) {
+		values := make(map[string]string)
+		if v == nil {
+			return values, nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var doc interface{}
+		if err = json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		flattenJSON("", doc, values)
+		return values, nil
+	}
+	oldValues, err := flatten(before)
+	if err != nil {
+		return nil, err
+	}
+	newValues, err := flatten(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := []changeLogDiff{}
+	for key, oldValue := range oldValues {
+		if newValue, ok := newValues[key]; !ok || newValue != oldValue {
+			diff = append(diff, changeLogDiff{Key: key, Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range newValues {
+		if _, ok := oldValues[key]; !ok {
+			diff = append(diff, changeLogDiff{Key: key, New: newValue})
+		}
+	}
+	for i := range diff {
+		if !isSecretKey(diff[i].Key) {
+			diff[i].Old = redactURLCredentials(diff[i].Old)
+			diff[i].New = redactURLCredentials(diff[i].New)
+			continue
+		}
+		if diff[i].Old != "" {
+			diff[i].Old = redactedValue
+		}
+		if diff[i].New != "" {
+			diff[i].New = redactedValue
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool { return diff[i].Key < diff[j].Key })
+	return diff, nil
+}
+
+// getChangeLogBucketPolicy - returns the policy of bucket before it is
+// changed, nil if bucket has no policy or changes are not recorded.
+func getChangeLogBucketPolicy(objAPI ObjectLayer, bucket string) (interface{}, error) {
+	if !globalIsChangeLog {
+		return nil, nil
+	}
+	policyInfo, err := objAPI.GetBucketPolicy(bucket)
+	if err != nil {
+		if _, ok := errors.Cause(err).(PolicyNotFound); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return policyInfo, nil
+}
+
+// Returns the path of a change log entry, entries are named by the
+// time they are recorded to list them in order.
+func getChangeLogEntryPath(id string) string {
+	return path.Join(changeLogPrefix, id+".json")
+}
+
+// recordChange - appends a change from before to after to the change
+// log, does nothing for gateways or unchanged values. Entries are
+// never overwritten nor removed. Callers fail the change if it cannot
+// be recorded.
+func recordChange(objAPI ObjectLayer, actor auditCaller, changeType, bucket string, before, after interface{}) error {
+	if !globalIsChangeLog {
+		return nil
+	}
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	diff, err := diffJSON(before, after)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+
+	now := UTCNow()
+	entry := changeLogEntry{
+		Version: changeLogEntryVersion,
+		ID:      newTimeOrderedID(now),
+		Time:    now,
+		Actor:   actor,
+		Type:    changeType,
+		Bucket:  bucket,
+		Diff:    diff,
+	}
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	hashReader, err := hash.NewReader(bytes.NewReader(buf), int64(len(buf)), "", getSHA256Hash(buf))
+	if err != nil {
+		return err
+	}
+	_, err = objAPI.PutObject(minioMetaBucket, getChangeLogEntryPath(entry.ID), hashReader, nil)
+	return err
+}
+
+// listChangeLog - returns up to maxEntries change log entries recorded
+// after the entry with ID marker, only changes of bucket unless empty.
+// At most maxChangeLogScan entries are read, a page of changes of a
+// bucket may hold fewer entries, even none, and still be truncated.
+// Listing continues after nextMarker unless it is empty.
+func listChangeLog(objAPI ObjectLayer, bucket, marker string, maxEntries int) (entries []changeLogEntry, nextMarker string, err error) {
+	entries = []changeLogEntry{}
+	if marker != "" {
+		marker = getChangeLogEntryPath(marker)
+	}
+	scanned := 0
+	for {
+		result, err := objAPI.ListObjects(minioMetaBucket, changeLogPrefix+slashSeparator, marker, "", maxObjectList)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, objInfo := range result.Objects {
+			if len(entries) == maxEntries || scanned == maxChangeLogScan {
+				return entries, nextMarker, nil
+			}
+			var buffer bytes.Buffer
+			if err = objAPI.GetObject(minioMetaBucket, objInfo.Name, 0, -1, &buffer, ""); err != nil {
+				return nil, "", err
+			}
+			var entry changeLogEntry
+			if err = json.Unmarshal(buffer.Bytes(), &entry); err != nil {
+				return nil, "", errors.Trace(err)
+			}
+			scanned++
+			nextMarker = entry.ID
+			if bucket != "" && entry.Bucket != bucket {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		if !result.IsTruncated || len(result.Objects) == 0 {
+			return entries, "", nil
+		}
+		marker = result.Objects[len(result.Objects)-1].Name
+	}
+}
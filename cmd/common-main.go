@@ -106,11 +106,37 @@ func handleCommonEnvVars() {
 		fatalIf(err, "error opening file %s", traceFile)
 	}
 
-	globalDomainName = os.Getenv("MINIO_DOMAIN")
-	if globalDomainName != "" {
+	var err error
+	globalAuditLogger, err = initAuditLogger()
+	fatalIf(err, "Unable to initialize audit logging.")
+
+	if domains := os.Getenv("MINIO_DOMAIN"); domains != "" {
+		globalDomainNames, err = parseDomainNames(domains)
+		fatalIf(err, "Invalid value set in environment variable MINIO_DOMAIN.")
 		globalIsEnvDomainName = true
 	}
 
+	// URL prefix when hosted behind a reverse proxy.
+	handleURLPrefixEnv()
+
+	// Limits of S3 API requests.
+	handleAPIThrottleEnv()
+
+	// Support of signature V2.
+	handleSignatureV2Env()
+
+	// Checks of POST policy form fields.
+	handlePostPolicyEnv()
+
+	// Minimum throughput of uploads and downloads.
+	handleHTTPThroughputEnv()
+
+	// Authentication of Prometheus metrics.
+	handlePrometheusEnv()
+
+	// Expiry of browser tokens.
+	handleBrowserTokenEnv()
+
 	// In place update is true by default if the MINIO_UPDATE is not set
 	// or is not set to 'off', if MINIO_UPDATE is set to 'off' then
 	// in-place update is off.
@@ -120,6 +146,18 @@ func handleCommonEnvVars() {
 	if globalIsXL {
 		var err error
 
+		// Number of parts read at once by GetObject.
+		handleXLReadAheadEnv()
+
+		// Erasure coding and disk write parallelism of PutObject.
+		handleXLParallelismEnv()
+
+		// Size up to which objects are stored inline in `xl.json`.
+		handleXLInlineThresholdEnv()
+
+		// Size and expiry of the object metadata cache.
+		handleXLMetadataCacheEnv()
+
 		// Check for environment variables and parse into storageClass struct
 		if ssc := os.Getenv(standardStorageClassEnv); ssc != "" {
 			globalStandardStorageClass, err = parseStorageClass(ssc)
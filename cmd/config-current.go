@@ -20,7 +20,9 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/minio/minio/pkg/auth"
@@ -37,9 +39,9 @@ import (
 // 6. Make changes in config-current_test.go for any test change
 
 // Config version
-const serverConfigVersion = "22"
+const serverConfigVersion = "26"
 
-type serverConfig = serverConfigV22
+type serverConfig = serverConfigV26
 
 var (
 	// globalServerConfig server config.
@@ -102,10 +104,23 @@ func (s *serverConfig) GetBrowser() bool {
 	return bool(s.Browser)
 }
 
-// Save config.
+// Save config. Settings overridden by the environment are saved with
+// their values in the config file.
 func (s *serverConfig) Save() error {
+	srvCfg := s
+	if environ := os.Environ(); hasConfigEnv(environ) {
+		fileCfg := newServerConfig()
+		if _, err := quick.Load(getConfigFile(), fileCfg); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		var err error
+		if srvCfg, err = withoutConfigEnv(s, fileCfg, environ); err != nil {
+			return err
+		}
+	}
+
 	// Save config file.
-	return quick.Save(getConfigFile(), s)
+	return quick.Save(getConfigFile(), srvCfg)
 }
 
 // Returns the string describing a difference with the given
@@ -125,6 +140,12 @@ func (s *serverConfig) ConfigDiff(t *serverConfig) string {
 		return "Domain configuration differs"
 	case s.StorageClass != t.StorageClass:
 		return "StorageClass configuration differs"
+	case !reflect.DeepEqual(s.Pools, t.Pools):
+		return "Pools configuration differs"
+	case s.KMS != t.KMS:
+		return "KMS configuration differs"
+	case !reflect.DeepEqual(s.Identity, t.Identity):
+		return "Identity configuration differs"
 	case !reflect.DeepEqual(s.Notify.AMQP, t.Notify.AMQP):
 		return "AMQP Notification configuration differs"
 	case !reflect.DeepEqual(s.Notify.NATS, t.Notify.NATS):
@@ -208,7 +229,7 @@ func newConfig() error {
 	}
 
 	if globalIsEnvDomainName {
-		srvCfg.Domain = globalDomainName
+		srvCfg.Domain = strings.Join(globalDomainNames, ",")
 	}
 
 	if globalIsStorageClass {
@@ -302,6 +323,12 @@ func getValidConfig() (*serverConfig, error) {
 		return nil, err
 	}
 
+	// Settings from the environment take precedence over the file,
+	// they are validated along with it.
+	if err = applyConfigEnv(srvCfg, os.Environ()); err != nil {
+		return nil, err
+	}
+
 	// Validate credential fields only when
 	// they are not set via the environment
 
@@ -310,6 +337,26 @@ func getValidConfig() (*serverConfig, error) {
 		return nil, errors.New("invalid credential in config file " + configFile)
 	}
 
+	// Validate domain field
+	if _, err = parseDomainNames(srvCfg.Domain); err != nil {
+		return nil, fmt.Errorf("%v in config file %s", err, configFile)
+	}
+
+	// Validate KMS field
+	if err = srvCfg.KMS.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Validate pools field
+	if err = srvCfg.Pools.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Validate identity field
+	if err = srvCfg.Identity.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Validate notify field
 	if err = srvCfg.Notify.Validate(); err != nil {
 		return nil, err
@@ -340,7 +387,7 @@ func loadConfig() error {
 	}
 
 	if globalIsEnvDomainName {
-		srvCfg.Domain = globalDomainName
+		srvCfg.Domain = strings.Join(globalDomainNames, ",")
 	}
 
 	if globalIsStorageClass {
@@ -360,11 +407,12 @@ func loadConfig() error {
 		globalServerRegion = globalServerConfig.GetRegion()
 	}
 	if !globalIsEnvDomainName {
-		globalDomainName = globalServerConfig.Domain
+		globalDomainNames, _ = parseDomainNames(globalServerConfig.Domain)
 	}
 	if !globalIsStorageClass {
 		globalStandardStorageClass, globalRRStorageClass = globalServerConfig.GetStorageClass()
 	}
+	globalStoragePools = globalServerConfig.Pools
 	globalServerConfigMu.Unlock()
 
 	return nil
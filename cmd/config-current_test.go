@@ -301,6 +301,33 @@ func TestValidateConfig(t *testing.T) {
 
 		// Test 27 - Test MQTT
 		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "notify": { "mqtt": { "1": { "enable": true, "broker": "",  "topic": "", "qos": 0, "clientId": "", "username": "", "password": ""}}}}`, false},
+
+		// Test 28 - Test Vault without AppRole
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "kms": { "vault": { "enable": true, "endpoint": "https://vault.example.com:8200", "auth": { "type": "approle", "approle": { "id": "", "secret": "" } }, "key": "minio" }}}`, false},
+
+		// Test 29 - Test valid Vault
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "kms": { "vault": { "enable": true, "endpoint": "https://vault.example.com:8200", "auth": { "type": "approle", "approle": { "id": "role", "secret": "secret" } }, "key": "minio" }}}`, true},
+
+		// Test 30 - Test LDAP with an invalid server URL
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "identity": { "ldap": { "enable": true, "serverURL": "https://ldap.example.com", "usernameFormat": "uid=%s,dc=example,dc=com" }}}`, false},
+
+		// Test 31 - Test LDAP with an invalid group search filter
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "identity": { "ldap": { "enable": true, "serverURL": "ldaps://ldap.example.com", "usernameFormat": "uid=%s,dc=example,dc=com", "groupSearchBaseDN": "dc=example,dc=com", "groupSearchFilter": "member=%d", "groupNameAttribute": "cn" }}}`, false},
+
+		// Test 32 - Test valid LDAP and group policies
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "identity": { "ldap": { "enable": true, "serverURL": "ldaps://ldap.example.com", "usernameFormat": "uid=%s,dc=example,dc=com", "groupSearchBaseDN": "dc=example,dc=com", "groupSearchFilter": "(member=%d)", "groupNameAttribute": "cn" }, "groupPolicies": [{ "group": "dev", "policy": "readwrite", "bucket": "dev-*" }]}}`, true},
+
+		// Test 33 - Test OpenID without client ID
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "identity": { "openid": { "enable": true, "issuerURL": "https://accounts.example.com" }}}`, false},
+
+		// Test 34 - Test valid OpenID
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "identity": { "openid": { "enable": true, "issuerURL": "https://accounts.example.com", "clientID": "minio" }}}`, true},
+
+		// Test 35 - Test empty pools
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "pools": { "sets": {}, "standard": "", "rrs": "", "buckets": [] }}`, true},
+
+		// Test 36 - Test pools without erasure coding
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "pools": { "sets": { "ssd": [1] }, "standard": "ssd" }}`, false},
 	}
 
 	for i, testCase := range testCases {
@@ -397,6 +424,24 @@ func TestConfigDiff(t *testing.T) {
 			&serverConfig{Notify: notifier{MQTT: map[string]mqttNotify{"1": {Enable: false}}}},
 			"MQTT Notification configuration differs",
 		},
+		// 16
+		{
+			&serverConfig{KMS: kmsConfig{Vault: vaultConfig{Enable: true}}},
+			&serverConfig{KMS: kmsConfig{Vault: vaultConfig{Enable: false}}},
+			"KMS configuration differs",
+		},
+		// 17
+		{
+			&serverConfig{Identity: identityConfig{LDAP: ldapConfig{Enable: true}}},
+			&serverConfig{Identity: identityConfig{LDAP: ldapConfig{Enable: false}}},
+			"Identity configuration differs",
+		},
+		// 18
+		{
+			&serverConfig{Pools: storagePoolsConfig{Standard: "ssd"}},
+			&serverConfig{Pools: storagePoolsConfig{Standard: "hdd"}},
+			"Pools configuration differs",
+		},
 	}
 
 	for i, testCase := range testCases {
@@ -37,11 +37,23 @@ const (
 	// Directory contains all CA certificates other than system defaults for HTTPS.
 	certsCADir = "CAs"
 
+	// Directory contains CA certificates verifying client certificates of admin requests.
+	certsAdminCADir = "admin-CAs"
+
 	// Public certificate file for HTTPS.
 	publicCertFile = "public.crt"
 
 	// Private key file for HTTPS.
 	privateKeyFile = "private.key"
+
+	// Directory contains the ACME account of automatically managed certificates.
+	certsACMEDir = "acme"
+
+	// ACME account key file.
+	acmeAccountKeyFile = "account.key"
+
+	// Host key file of the SFTP server.
+	sftpHostKeyFile = "sftp-host.key"
 )
 
 // ConfigDir - configuration directory with locking.
@@ -75,6 +87,11 @@ func (config *ConfigDir) GetCADir() string {
 	return filepath.Join(config.getCertsDir(), certsCADir)
 }
 
+// GetAdminCADir - returns admin client certificate CA directory.
+func (config *ConfigDir) GetAdminCADir() string {
+	return filepath.Join(config.getCertsDir(), certsAdminCADir)
+}
+
 // Create - creates configuration directory tree.
 func (config *ConfigDir) Create() error {
 	return os.MkdirAll(config.GetCADir(), 0700)
@@ -95,6 +112,16 @@ func (config *ConfigDir) GetPrivateKeyFile() string {
 	return filepath.Join(config.getCertsDir(), privateKeyFile)
 }
 
+// GetACMEAccountKeyFile - returns absolute path of ACME account.key file.
+func (config *ConfigDir) GetACMEAccountKeyFile() string {
+	return filepath.Join(config.getCertsDir(), certsACMEDir, acmeAccountKeyFile)
+}
+
+// GetSFTPHostKeyFile - returns absolute path of sftp-host.key file.
+func (config *ConfigDir) GetSFTPHostKeyFile() string {
+	return filepath.Join(config.getCertsDir(), sftpHostKeyFile)
+}
+
 func mustGetDefaultConfigDir() string {
 	homeDir, err := homedir.Dir()
 	fatalIf(err, "Unable to get home directory.")
@@ -116,6 +143,10 @@ func getCADir() string {
 	return configDir.GetCADir()
 }
 
+func getAdminCADir() string {
+	return configDir.GetAdminCADir()
+}
+
 func createConfigDir() error {
 	return configDir.Create()
 }
@@ -131,3 +162,11 @@ func getPublicCertFile() string {
 func getPrivateKeyFile() string {
 	return configDir.GetPrivateKeyFile()
 }
+
+func getACMEAccountKeyFile() string {
+	return configDir.GetACMEAccountKeyFile()
+}
+
+func getSFTPHostKeyFile() string {
+	return configDir.GetSFTPHostKeyFile()
+}
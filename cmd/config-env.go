@@ -0,0 +1,300 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Settings under these top-level keys of the configuration file are
+// overridden by environment variables named after the JSON keys of
+// the setting, upper cased and joined by underscores after MINIO_,
+// e.g. MINIO_NOTIFY_WEBHOOK_1_ENDPOINT for the endpoint of webhook
+// "1". The other top-level settings have dedicated variables, e.g.
+// MINIO_REGION.
+var configEnvKeys = []string{"pools", "kms", "identity", "notify"}
+
+const configEnvPrefix = "MINIO_"
+
+var errUnknownConfigEnv = errors.New("unknown configuration setting")
+
+// getConfigEnvKey - returns the top-level key of the settings the
+// environment variable overrides, empty if none.
+func getConfigEnvKey(name string) string {
+	for _, key := range configEnvKeys {
+		prefix := configEnvPrefix + strings.ToUpper(key)
+		if name == prefix || strings.HasPrefix(name, prefix+"_") {
+			return key
+		}
+	}
+	return ""
+}
+
+// isConfigEnv - returns true if the environment variable overrides a
+// setting of the configuration file.
+func isConfigEnv(name string) bool {
+	return getConfigEnvKey(name) != ""
+}
+
+// hasConfigEnv - returns true if any environment variable overrides a
+// setting of the configuration file.
+func hasConfigEnv(environ []string) bool {
+	for _, env := range environ {
+		if i := strings.IndexByte(env, '='); i >= 0 && isConfigEnv(env[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutConfigEnv - returns a copy of the configuration whose settings
+// overridden by the environment are set back to the ones of fileCfg,
+// the configuration file, so that saving it does not persist the values
+// of the environment. Other settings, e.g. of the same notification
+// target, keep the values set at runtime.
+func withoutConfigEnv(srvCfg, fileCfg *serverConfig, environ []string) (*serverConfig, error) {
+	data, err := json.Marshal(srvCfg)
+	if err != nil {
+		return nil, err
+	}
+	saved := new(serverConfig)
+	if err = json.Unmarshal(data, saved); err != nil {
+		return nil, err
+	}
+	for _, env := range environ {
+		i := strings.IndexByte(env, '=')
+		if i < 0 || !isConfigEnv(env[:i]) {
+			continue
+		}
+		path := strings.TrimPrefix(env[:i], configEnvPrefix)
+		if err = restoreConfigEnv(reflect.ValueOf(saved).Elem(), reflect.ValueOf(fileCfg).Elem(), path); err != nil {
+			return nil, fmt.Errorf("Environment variable %s does not name a configuration setting", env[:i])
+		}
+	}
+	return saved, nil
+}
+
+// restoreConfigEnv - sets the setting of saved named by path, as for
+// setConfigEnv, back to the value of the same setting of file. Entries
+// of maps missing from file are removed once all their settings are
+// restored.
+func restoreConfigEnv(saved, file reflect.Value, path string) error {
+	switch saved.Kind() {
+	case reflect.Struct:
+		t := saved.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := strings.ToUpper(strings.Split(field.Tag.Get("json"), ",")[0])
+			if field.PkgPath != "" || key == "" || key == "-" {
+				continue
+			}
+			if path == key {
+				saved.Field(i).Set(file.Field(i))
+				return nil
+			}
+			if strings.HasPrefix(path, key+"_") {
+				return restoreConfigEnv(saved.Field(i), file.Field(i), path[len(key)+1:])
+			}
+		}
+	case reflect.Map:
+		t := saved.Type()
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+		if t.Elem().Kind() != reflect.Struct {
+			key := reflect.ValueOf(path).Convert(t.Key())
+			setConfigEnvEntry(saved, key, file.MapIndex(key))
+			return nil
+		}
+
+		for i := strings.IndexByte(path, '_'); i > 0; {
+			key := reflect.ValueOf(path[:i]).Convert(t.Key())
+			elem := reflect.New(t.Elem()).Elem()
+			if existing := saved.MapIndex(key); existing.IsValid() {
+				elem.Set(existing)
+			}
+			fileElem := reflect.New(t.Elem()).Elem()
+			fileEntry := file.MapIndex(key)
+			if fileEntry.IsValid() {
+				fileElem.Set(fileEntry)
+			}
+			err := restoreConfigEnv(elem, fileElem, path[i+1:])
+			if err == nil {
+				if !fileEntry.IsValid() && reflect.DeepEqual(elem.Interface(), reflect.Zero(t.Elem()).Interface()) {
+					// Entry only set by the environment.
+					setConfigEnvEntry(saved, key, reflect.Value{})
+				} else {
+					setConfigEnvEntry(saved, key, elem)
+				}
+				return nil
+			}
+			if err != errUnknownConfigEnv {
+				return err
+			}
+			j := strings.IndexByte(path[i+1:], '_')
+			if j < 0 {
+				break
+			}
+			i += j + 1
+		}
+	}
+	return errUnknownConfigEnv
+}
+
+// setConfigEnvEntry - sets the entry of a map, removes it if elem is
+// the zero Value.
+func setConfigEnvEntry(m, key, elem reflect.Value) {
+	if m.IsNil() {
+		if !elem.IsValid() {
+			return
+		}
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	m.SetMapIndex(key, elem)
+}
+
+// applyConfigEnv - overrides the settings of the configuration with
+// the environment variables named after them, fails on variables of
+// no setting and on values invalid for the type of the setting.
+func applyConfigEnv(srvCfg *serverConfig, environ []string) error {
+	for _, env := range environ {
+		i := strings.IndexByte(env, '=')
+		if i < 0 || !isConfigEnv(env[:i]) {
+			continue
+		}
+		name, value := env[:i], env[i+1:]
+
+		err := setConfigEnv(reflect.ValueOf(srvCfg).Elem(), strings.TrimPrefix(name, configEnvPrefix), value)
+		if err == errUnknownConfigEnv {
+			return fmt.Errorf("Environment variable %s does not name a configuration setting", name)
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid value of environment variable %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// setConfigEnv - sets the setting of v named by path, the upper cased
+// JSON keys of the setting joined by underscores, to value.
+func setConfigEnv(v reflect.Value, path, value string) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := strings.ToUpper(strings.Split(field.Tag.Get("json"), ",")[0])
+			if field.PkgPath != "" || key == "" || key == "-" {
+				continue
+			}
+			if path == key {
+				return setConfigEnvValue(v.Field(i), value)
+			}
+			if strings.HasPrefix(path, key+"_") {
+				return setConfigEnv(v.Field(i), path[len(key)+1:], value)
+			}
+		}
+	case reflect.Map:
+		t := v.Type()
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		if t.Elem().Kind() != reflect.Struct {
+			// Entries of a map of values are named by their key.
+			elem := reflect.New(t.Elem()).Elem()
+			if err := setConfigEnvValue(elem, value); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(path).Convert(t.Key()), elem)
+			return nil
+		}
+
+		// Settings of entries of a map of structs, e.g. notification
+		// targets, are named by the key of the entry followed by the
+		// setting. The shortest key naming a setting is used, keys are
+		// case sensitive.
+		for i := strings.IndexByte(path, '_'); i > 0; {
+			key := reflect.ValueOf(path[:i]).Convert(t.Key())
+			elem := reflect.New(t.Elem()).Elem()
+			if existing := v.MapIndex(key); existing.IsValid() {
+				elem.Set(existing)
+			}
+			err := setConfigEnv(elem, path[i+1:], value)
+			if err == nil {
+				v.SetMapIndex(key, elem)
+				return nil
+			}
+			if err != errUnknownConfigEnv {
+				return err
+			}
+			j := strings.IndexByte(path[i+1:], '_')
+			if j < 0 {
+				break
+			}
+			i += j + 1
+		}
+	}
+	return errUnknownConfigEnv
+}
+
+// setConfigEnvValue - sets a setting to the value of an environment
+// variable. Booleans are on or off, lists comma separated or in JSON,
+// other settings in JSON like in the configuration file.
+func setConfigEnvValue(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+		return nil
+	case reflect.Bool:
+		switch value {
+		case "on", "true":
+			v.SetBool(true)
+		case "off", "false":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("`%s` must be on or off", value)
+		}
+		return nil
+	case reflect.Slice:
+		if strings.HasPrefix(strings.TrimSpace(value), "[") {
+			break
+		}
+		if v.Type().Elem().Kind() == reflect.String {
+			list := reflect.MakeSlice(v.Type(), 0, 0)
+			for _, elem := range strings.Split(value, ",") {
+				list = reflect.Append(list, reflect.ValueOf(strings.TrimSpace(elem)).Convert(v.Type().Elem()))
+			}
+			v.Set(list)
+			return nil
+		}
+		value = "[" + value + "]"
+	}
+
+	ptr := reflect.New(v.Type())
+	if err := json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+		return fmt.Errorf("`%s` is not a valid %s: %v", value, v.Type(), err)
+	}
+	v.Set(ptr.Elem())
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/minio/minio/pkg/auth"
+)
+
+// Tests overriding settings of the configuration from the environment.
+func TestApplyConfigEnv(t *testing.T) {
+	srvCfg := &serverConfig{}
+	srvCfg.Notify.Webhook = webhookConfigs{"1": {Enable: false, Endpoint: "http://localhost:8080"}}
+	environ := []string{
+		"MINIO_REGION=us-west-1",
+		"PATH=/usr/bin",
+		"MINIO_NOTIFY_WEBHOOK_1_ENABLE=on",
+		"MINIO_NOTIFY_KAFKA_logs_ENABLE=on",
+		"MINIO_NOTIFY_KAFKA_logs_BROKERS=kafka1:9092, kafka2:9092",
+		"MINIO_NOTIFY_KAFKA_logs_TOPIC=minio",
+		"MINIO_NOTIFY_NATS_2_STREAMING_MAXPUBACKSINFLIGHT=10",
+		"MINIO_NOTIFY_AMQP_A_B_DELIVERYMODE=2",
+		"MINIO_KMS_VAULT_AUTH_APPROLE_ID=role",
+		"MINIO_POOLS_SETS_ssd=1,2",
+		"MINIO_POOLS_STANDARD=ssd",
+		`MINIO_IDENTITY_GROUPPOLICIES=[{"group":"dev","policy":"readwrite","bucket":"dev-*"}]`,
+	}
+	if err := applyConfigEnv(srvCfg, environ); err != nil {
+		t.Fatal(err)
+	}
+
+	if srvCfg.Region != "" {
+		t.Errorf("Expected the region to be left to its dedicated variable, got %s", srvCfg.Region)
+	}
+	if webhook := srvCfg.Notify.Webhook["1"]; !webhook.Enable || webhook.Endpoint != "http://localhost:8080" {
+		t.Errorf("Unexpected webhook %+v", webhook)
+	}
+	expectedKafka := kafkaNotify{Enable: true, Brokers: []string{"kafka1:9092", "kafka2:9092"}, Topic: "minio"}
+	if kafka := srvCfg.Notify.Kafka["logs"]; !reflect.DeepEqual(kafka, expectedKafka) {
+		t.Errorf("Expected kafka %+v, got %+v", expectedKafka, kafka)
+	}
+	if nats := srvCfg.Notify.NATS["2"]; nats.Streaming.MaxPubAcksInflight != 10 {
+		t.Errorf("Unexpected NATS %+v", nats)
+	}
+	if amqp := srvCfg.Notify.AMQP["A_B"]; amqp.DeliveryMode != 2 {
+		t.Errorf("Unexpected AMQP %+v", srvCfg.Notify.AMQP)
+	}
+	if srvCfg.KMS.Vault.Auth.AppRole.ID != "role" {
+		t.Errorf("Unexpected KMS %+v", srvCfg.KMS)
+	}
+	expectedPools := storagePoolsConfig{Sets: map[string][]int{"ssd": {1, 2}}, Standard: "ssd"}
+	if !reflect.DeepEqual(srvCfg.Pools, expectedPools) {
+		t.Errorf("Expected pools %+v, got %+v", expectedPools, srvCfg.Pools)
+	}
+	expectedPolicies := []groupPolicyConfig{{Group: "dev", Policy: "readwrite", Bucket: "dev-*"}}
+	if !reflect.DeepEqual(srvCfg.Identity.GroupPolicies, expectedPolicies) {
+		t.Errorf("Expected group policies %+v, got %+v", expectedPolicies, srvCfg.Identity.GroupPolicies)
+	}
+
+	for _, env := range []string{
+		"MINIO_NOTIFY_WEBHOOK_1_ENDPIONT=http://localhost",
+		"MINIO_NOTIFY_WEBHOOK=http://localhost",
+		"MINIO_NOTIFY_WEBHOOK_1_ENABLE=yes",
+		"MINIO_NOTIFY_NATS_1_PINGINTERVAL=often",
+		"MINIO_KMS_VAULT_UNKNOWN=value",
+		"MINIO_IDENTITY_GROUPPOLICIES={",
+	} {
+		if err := applyConfigEnv(&serverConfig{}, []string{env}); err == nil {
+			t.Errorf("%s: Expected to fail", env)
+		}
+	}
+}
+
+// Tests settings from the environment take precedence over the configuration file.
+func TestGetValidConfigEnv(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer os.RemoveAll(rootPath)
+
+	os.Setenv("MINIO_NOTIFY_WEBHOOK_1_ENABLE", "on")
+	os.Setenv("MINIO_NOTIFY_WEBHOOK_1_ENDPOINT", "http://localhost:8080/minio")
+	defer os.Unsetenv("MINIO_NOTIFY_WEBHOOK_1_ENABLE")
+	defer os.Unsetenv("MINIO_NOTIFY_WEBHOOK_1_ENDPOINT")
+
+	srvCfg, err := getValidConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if webhook := srvCfg.Notify.Webhook["1"]; !webhook.Enable || webhook.Endpoint != "http://localhost:8080/minio" {
+		t.Errorf("Unexpected webhook %+v", webhook)
+	}
+
+	// Invalid settings from the environment are rejected.
+	os.Setenv("MINIO_NOTIFY_WEBHOOK_1_ENDPOINT", "")
+	if _, err = getValidConfig(); err == nil {
+		t.Error("Expected an invalid webhook to fail")
+	}
+}
+
+// Tests settings from the environment are not saved to the configuration file.
+func TestSaveConfigEnv(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer os.RemoveAll(rootPath)
+
+	os.Setenv("MINIO_NOTIFY_WEBHOOK_1_ENABLE", "on")
+	os.Setenv("MINIO_NOTIFY_WEBHOOK_1_ENDPOINT", "http://localhost:8080/minio")
+	defer os.Unsetenv("MINIO_NOTIFY_WEBHOOK_1_ENABLE")
+	defer os.Unsetenv("MINIO_NOTIFY_WEBHOOK_1_ENDPOINT")
+
+	srvCfg, err := getValidConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := auth.MustGetNewCredentials()
+	srvCfg.SetCredential(creds)
+	// Runtime changes to other settings of the section are saved.
+	runtimeWebhook := webhookNotify{Enable: true, Endpoint: "http://localhost:8081/minio"}
+	srvCfg.Notify.Webhook["2"] = runtimeWebhook
+	if err = srvCfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if webhook := srvCfg.Notify.Webhook["1"]; !webhook.Enable {
+		t.Errorf("Expected the settings in memory to be kept, got %+v", webhook)
+	}
+
+	os.Unsetenv("MINIO_NOTIFY_WEBHOOK_1_ENABLE")
+	os.Unsetenv("MINIO_NOTIFY_WEBHOOK_1_ENDPOINT")
+	if srvCfg, err = getValidConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if srvCfg.Credential != creds {
+		t.Errorf("Expected credentials %v to be saved, got %v", creds, srvCfg.Credential)
+	}
+	if webhook, ok := srvCfg.Notify.Webhook["1"]; ok && webhook.Enable {
+		t.Errorf("Expected the webhook of the environment not to be saved, got %+v", webhook)
+	}
+	if webhook := srvCfg.Notify.Webhook["2"]; webhook != runtimeWebhook {
+		t.Errorf("Expected webhook %+v to be saved, got %+v", runtimeWebhook, webhook)
+	}
+}
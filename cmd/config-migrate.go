@@ -162,6 +162,26 @@ func migrateConfig() error {
 		if err = migrateV21ToV22(); err != nil {
 			return err
 		}
+		fallthrough
+	case "22":
+		if err = migrateV22ToV23(); err != nil {
+			return err
+		}
+		fallthrough
+	case "23":
+		if err = migrateV23ToV24(); err != nil {
+			return err
+		}
+		fallthrough
+	case "24":
+		if err = migrateV24ToV25(); err != nil {
+			return err
+		}
+		fallthrough
+	case "25":
+		if err = migrateV25ToV26(); err != nil {
+			return err
+		}
 	case serverConfigVersion:
 		// No migration needed. this always points to current version.
 		err = nil
@@ -1719,7 +1739,7 @@ func migrateV21ToV22() error {
 	srvConfig := &serverConfigV22{
 		Notify: notifier{},
 	}
-	srvConfig.Version = serverConfigVersion
+	srvConfig.Version = "22"
 	srvConfig.Credential = cv21.Credential
 	srvConfig.Region = cv21.Region
 	if srvConfig.Region == "" {
@@ -1807,3 +1827,166 @@ func migrateV21ToV22() error {
 	log.Printf(configMigrateMSGTemplate, configFile, cv21.Version, srvConfig.Version)
 	return nil
 }
+
+func migrateV22ToV23() error {
+	configFile := getConfigFile()
+
+	cv22 := &serverConfigV22{}
+	_, err := quick.Load(configFile, cv22)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to load config version ‘22’. %v", err)
+	}
+	if cv22.Version != "22" {
+		return nil
+	}
+
+	// Copy over fields from V22 into V23 config struct, SSE-S3
+	// stays disabled until a KMS is configured.
+	srvConfig := &serverConfigV23{
+		Version:      "23",
+		Credential:   cv22.Credential,
+		Region:       cv22.Region,
+		Browser:      cv22.Browser,
+		Domain:       cv22.Domain,
+		StorageClass: cv22.StorageClass,
+		KMS:          kmsConfig{},
+		Notify:       cv22.Notify,
+	}
+	if srvConfig.Region == "" {
+		// Region needs to be set for AWS Signature Version 4.
+		srvConfig.Region = globalMinioDefaultRegion
+	}
+
+	if err = quick.Save(configFile, srvConfig); err != nil {
+		return fmt.Errorf("Failed to migrate config from ‘%s’ to ‘%s’. %v", cv22.Version, srvConfig.Version, err)
+	}
+
+	log.Printf(configMigrateMSGTemplate, configFile, cv22.Version, srvConfig.Version)
+	return nil
+}
+
+func migrateV23ToV24() error {
+	configFile := getConfigFile()
+
+	cv23 := &serverConfigV23{}
+	_, err := quick.Load(configFile, cv23)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to load config version ‘23’. %v", err)
+	}
+	if cv23.Version != "23" {
+		return nil
+	}
+
+	// Copy over fields from V23 into V24 config struct, temporary
+	// credentials stay disabled until an identity provider is
+	// configured.
+	srvConfig := &serverConfigV24{
+		Version:      "24",
+		Credential:   cv23.Credential,
+		Region:       cv23.Region,
+		Browser:      cv23.Browser,
+		Domain:       cv23.Domain,
+		StorageClass: cv23.StorageClass,
+		KMS:          cv23.KMS,
+		Identity:     identityConfigV24{},
+		Notify:       cv23.Notify,
+	}
+	if srvConfig.Region == "" {
+		// Region needs to be set for AWS Signature Version 4.
+		srvConfig.Region = globalMinioDefaultRegion
+	}
+
+	if err = quick.Save(configFile, srvConfig); err != nil {
+		return fmt.Errorf("Failed to migrate config from ‘%s’ to ‘%s’. %v", cv23.Version, srvConfig.Version, err)
+	}
+
+	log.Printf(configMigrateMSGTemplate, configFile, cv23.Version, srvConfig.Version)
+	return nil
+}
+
+func migrateV24ToV25() error {
+	configFile := getConfigFile()
+
+	cv24 := &serverConfigV24{}
+	_, err := quick.Load(configFile, cv24)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to load config version ‘24’. %v", err)
+	}
+	if cv24.Version != "24" {
+		return nil
+	}
+
+	// Copy over fields from V24 into V25 config struct, OpenID
+	// Connect stays disabled until a provider is configured.
+	srvConfig := &serverConfigV25{
+		Version:      "25",
+		Credential:   cv24.Credential,
+		Region:       cv24.Region,
+		Browser:      cv24.Browser,
+		Domain:       cv24.Domain,
+		StorageClass: cv24.StorageClass,
+		KMS:          cv24.KMS,
+		Identity: identityConfig{
+			LDAP:          cv24.Identity.LDAP,
+			GroupPolicies: cv24.Identity.GroupPolicies,
+		},
+		Notify: cv24.Notify,
+	}
+	if srvConfig.Region == "" {
+		// Region needs to be set for AWS Signature Version 4.
+		srvConfig.Region = globalMinioDefaultRegion
+	}
+
+	if err = quick.Save(configFile, srvConfig); err != nil {
+		return fmt.Errorf("Failed to migrate config from ‘%s’ to ‘%s’. %v", cv24.Version, srvConfig.Version, err)
+	}
+
+	log.Printf(configMigrateMSGTemplate, configFile, cv24.Version, srvConfig.Version)
+	return nil
+}
+
+func migrateV25ToV26() error {
+	configFile := getConfigFile()
+
+	cv25 := &serverConfigV25{}
+	_, err := quick.Load(configFile, cv25)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to load config version ‘25’. %v", err)
+	}
+	if cv25.Version != "25" {
+		return nil
+	}
+
+	// Copy over fields from V25 into V26 config struct, objects are
+	// placed on all erasure sets until pools are configured.
+	srvConfig := &serverConfigV26{
+		Version:      serverConfigVersion,
+		Credential:   cv25.Credential,
+		Region:       cv25.Region,
+		Browser:      cv25.Browser,
+		Domain:       cv25.Domain,
+		StorageClass: cv25.StorageClass,
+		KMS:          cv25.KMS,
+		Identity:     cv25.Identity,
+		Notify:       cv25.Notify,
+	}
+	if srvConfig.Region == "" {
+		// Region needs to be set for AWS Signature Version 4.
+		srvConfig.Region = globalMinioDefaultRegion
+	}
+
+	if err = quick.Save(configFile, srvConfig); err != nil {
+		return fmt.Errorf("Failed to migrate config from ‘%s’ to ‘%s’. %v", cv25.Version, srvConfig.Version, err)
+	}
+
+	log.Printf(configMigrateMSGTemplate, configFile, cv25.Version, srvConfig.Version)
+	return nil
+}
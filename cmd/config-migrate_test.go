@@ -131,6 +131,18 @@ func TestServerConfigMigrateInexistentConfig(t *testing.T) {
 	if err := migrateV20ToV21(); err != nil {
 		t.Fatal("migrate v20 to v21 should succeed when no config file is found")
 	}
+	if err := migrateV22ToV23(); err != nil {
+		t.Fatal("migrate v22 to v23 should succeed when no config file is found")
+	}
+	if err := migrateV23ToV24(); err != nil {
+		t.Fatal("migrate v23 to v24 should succeed when no config file is found")
+	}
+	if err := migrateV24ToV25(); err != nil {
+		t.Fatal("migrate v24 to v25 should succeed when no config file is found")
+	}
+	if err := migrateV25ToV26(); err != nil {
+		t.Fatal("migrate v25 to v26 should succeed when no config file is found")
+	}
 }
 
 // Test if a config migration from v2 to v21 is successfully done
@@ -263,6 +275,18 @@ func TestServerConfigMigrateFaultyConfig(t *testing.T) {
 	if err := migrateV20ToV21(); err == nil {
 		t.Fatal("migrateConfigV20ToV21() should fail with a corrupted json")
 	}
+	if err := migrateV22ToV23(); err == nil {
+		t.Fatal("migrateConfigV22ToV23() should fail with a corrupted json")
+	}
+	if err := migrateV23ToV24(); err == nil {
+		t.Fatal("migrateConfigV23ToV24() should fail with a corrupted json")
+	}
+	if err := migrateV24ToV25(); err == nil {
+		t.Fatal("migrateConfigV24ToV25() should fail with a corrupted json")
+	}
+	if err := migrateV25ToV26(); err == nil {
+		t.Fatal("migrateConfigV25ToV26() should fail with a corrupted json")
+	}
 }
 
 // Test if all migrate code returns error with corrupted config files
@@ -548,10 +548,83 @@ type serverConfigV21 struct {
 
 // serverConfigV22 is just like version '21' with added support
 // for StorageClass.
+type serverConfigV22 struct {
+	Version string `json:"version"`
+
+	// S3 API configuration.
+	Credential auth.Credentials `json:"credential"`
+	Region     string           `json:"region"`
+	Browser    BrowserFlag      `json:"browser"`
+	Domain     string           `json:"domain"`
+
+	// Storage class configuration
+	StorageClass storageClassConfig `json:"storageclass"`
+
+	// Notification queue configuration.
+	Notify notifier `json:"notify"`
+}
+
+// serverConfigV23 is just like version '22' with added support
+// for KMS.
+type serverConfigV23 struct {
+	Version string `json:"version"`
+
+	// S3 API configuration.
+	Credential auth.Credentials `json:"credential"`
+	Region     string           `json:"region"`
+	Browser    BrowserFlag      `json:"browser"`
+	Domain     string           `json:"domain"`
+
+	// Storage class configuration
+	StorageClass storageClassConfig `json:"storageclass"`
+
+	// KMS configuration sealing data keys of SSE-S3 objects.
+	KMS kmsConfig `json:"kms"`
+
+	// Notification queue configuration.
+	Notify notifier `json:"notify"`
+}
+
+// serverConfigV24 is just like version '23' with added support
+// for identity providers issuing temporary credentials.
 //
 // IMPORTANT NOTE: When updating this struct make sure that
 // serverConfig.ConfigDiff() is updated as necessary.
-type serverConfigV22 struct {
+type serverConfigV24 struct {
+	Version string `json:"version"`
+
+	// S3 API configuration.
+	Credential auth.Credentials `json:"credential"`
+	Region     string           `json:"region"`
+	Browser    BrowserFlag      `json:"browser"`
+	Domain     string           `json:"domain"`
+
+	// Storage class configuration
+	StorageClass storageClassConfig `json:"storageclass"`
+
+	// KMS configuration sealing data keys of SSE-S3 objects.
+	KMS kmsConfig `json:"kms"`
+
+	// Identity provider configuration of temporary credentials.
+	Identity identityConfigV24 `json:"identity"`
+
+	// Notification queue configuration.
+	Notify notifier `json:"notify"`
+}
+
+// identityConfigV24 - identity configuration of version '24', LDAP
+// only.
+type identityConfigV24 struct {
+	LDAP          ldapConfig          `json:"ldap"`
+	GroupPolicies []groupPolicyConfig `json:"groupPolicies"`
+}
+
+// serverConfigV25 is just like version '24' with added support
+// for OpenID Connect providers.
+//
+// IMPORTANT NOTE: When updating this struct make sure that
+// serverConfig.ConfigDiff() is updated as necessary.
+type serverConfigV25 struct {
 	Version string `json:"version"`
 
 	// S3 API configuration.
@@ -563,6 +636,42 @@ type serverConfigV22 struct {
 	// Storage class configuration
 	StorageClass storageClassConfig `json:"storageclass"`
 
+	// KMS configuration sealing data keys of SSE-S3 objects.
+	KMS kmsConfig `json:"kms"`
+
+	// Identity provider configuration of temporary credentials.
+	Identity identityConfig `json:"identity"`
+
+	// Notification queue configuration.
+	Notify notifier `json:"notify"`
+}
+
+// serverConfigV26 is just like version '25' with added support for
+// placing objects on labeled pools of erasure sets.
+//
+// IMPORTANT NOTE: When updating this struct make sure that
+// serverConfig.ConfigDiff() is updated as necessary.
+type serverConfigV26 struct {
+	Version string `json:"version"`
+
+	// S3 API configuration.
+	Credential auth.Credentials `json:"credential"`
+	Region     string           `json:"region"`
+	Browser    BrowserFlag      `json:"browser"`
+	Domain     string           `json:"domain"`
+
+	// Storage class configuration
+	StorageClass storageClassConfig `json:"storageclass"`
+
+	// Labeled pools of erasure sets objects are placed on.
+	Pools storagePoolsConfig `json:"pools"`
+
+	// KMS configuration sealing data keys of SSE-S3 objects.
+	KMS kmsConfig `json:"kms"`
+
+	// Identity provider configuration of temporary credentials.
+	Identity identityConfig `json:"identity"`
+
 	// Notification queue configuration.
 	Notify notifier `json:"notify"`
 }
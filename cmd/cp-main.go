@@ -0,0 +1,779 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	miniogo "github.com/minio/minio-go"
+)
+
+var cpFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "recursive, r",
+		Usage: "Copy directories and prefixes recursively.",
+	},
+	cli.IntFlag{
+		Name:  "parallel",
+		Value: 4,
+		Usage: "Number of files copied at once.",
+	},
+	cli.IntFlag{
+		Name:  "part-parallel",
+		Value: 4,
+		Usage: "Number of parts of a file uploaded at once.",
+	},
+	cli.StringFlag{
+		Name:  "part-size",
+		Value: "16MiB",
+		Usage: "Size of the parts files larger than it are uploaded in, between 5MiB and 5GiB.",
+	},
+	cli.BoolFlag{
+		Name:  "insecure",
+		Usage: "Do not verify the TLS certificate of the server.",
+	},
+}
+
+var cpCmd = cli.Command{
+	Name:   "cp",
+	Usage:  "Copy files to and from a server.",
+	Flags:  append(cpFlags, globalFlags...),
+	Action: mainCp,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} {{if .VisibleFlags}}[FLAGS] {{end}}SOURCE TARGET
+
+SOURCE, TARGET:
+  One of them is a local path, the other one a URL of a bucket, an
+  object or a prefix on a server, http(s)://HOST[:PORT]/BUCKET[/OBJECT].
+  Files already copied are skipped, interrupted copies are resumed and
+  checksums of copied files are verified.
+
+{{if .VisibleFlags}}
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}{{end}}
+ENVIRONMENT VARIABLES:
+  ACCESS:
+     MINIO_ACCESS_KEY: Access key of the server, requests are anonymous if it is not set.
+     MINIO_SECRET_KEY: Secret key of the server.
+
+EXAMPLES:
+  1. Upload a file.
+      $ export MINIO_ACCESS_KEY=minio
+      $ export MINIO_SECRET_KEY=miniostorage
+      $ {{.HelpName}} backup.tar.gz https://minio.example.com:9000/backups/
+
+  2. Upload the files of a directory below a prefix.
+      $ {{.HelpName}} --recursive /mnt/export https://minio.example.com:9000/archive/2018
+
+  3. Download the objects below a prefix, 16 files at once.
+      $ {{.HelpName}} --recursive --parallel 16 https://minio.example.com:9000/archive/2018 /mnt/import
+`,
+}
+
+const (
+	// Metadata of objects uploaded in parts, the part size is needed
+	// to verify the checksum of the object when it is downloaded.
+	cpPartSizeMeta = "X-Amz-Meta-Minio-Cp-Part-Size"
+
+	// Suffix of files being downloaded, they are renamed once the
+	// download is complete and resumed otherwise.
+	cpPartFileSuffix = ".part.minio"
+)
+
+var errCpChecksumMismatch = errors.New("checksum mismatch")
+
+// cpURL - bucket and object, or prefix, on a server.
+type cpURL struct {
+	Endpoint string // host[:port]
+	Secure   bool
+	Bucket   string
+	Object   string
+}
+
+// isCpURL - returns true if the argument is a URL on a server rather
+// than a local path.
+func isCpURL(arg string) bool {
+	return strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://")
+}
+
+func parseCpURL(arg string) (cpURL, error) {
+	u, err := url.Parse(arg)
+	if err != nil {
+		return cpURL{}, err
+	}
+	if u.Host == "" {
+		return cpURL{}, fmt.Errorf("URL %q has no host", arg)
+	}
+	bucket, object := path2BucketAndObject(u.Path)
+	if !IsValidBucketName(bucket) {
+		return cpURL{}, fmt.Errorf("URL %q has no valid bucket name", arg)
+	}
+	return cpURL{
+		Endpoint: u.Host,
+		Secure:   u.Scheme == "https",
+		Bucket:   bucket,
+		Object:   object,
+	}, nil
+}
+
+// cpJob - a file uploaded to or downloaded from an object.
+type cpJob struct {
+	path   string
+	object string
+	upload bool
+}
+
+// cpClient - copies files to and from a bucket.
+type cpClient struct {
+	// Bytes sent and received, excluding data already copied. Kept
+	// first for 64-bit alignment of atomic operations.
+	transferred int64
+
+	core         *miniogo.Core
+	bucket       string
+	partSize     int64
+	partParallel int
+}
+
+func newCpClient(target cpURL, accessKey, secretKey string, insecure bool, partSize int64, partParallel int) (*cpClient, error) {
+	core, err := miniogo.NewCore(target.Endpoint, accessKey, secretKey, target.Secure)
+	if err != nil {
+		return nil, err
+	}
+	core.SetAppInfo("minio-cp", Version)
+	if insecure {
+		core.SetCustomTransport(&http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		})
+	}
+	return &cpClient{
+		core:         core,
+		bucket:       target.Bucket,
+		partSize:     partSize,
+		partParallel: partParallel,
+	}, nil
+}
+
+// getPartSize - returns the size of the parts size bytes are uploaded
+// in, large enough for the maximum number of parts.
+func (c *cpClient) getPartSize(size int64) int64 {
+	partSize := c.partSize
+	if minSize := (size + globalMaxPartID - 1) / globalMaxPartID; minSize > partSize {
+		partSize = (minSize + humanize.MiByte - 1) / humanize.MiByte * humanize.MiByte
+	}
+	return partSize
+}
+
+// getUploadJobs - returns the files uploaded from src, the files of a
+// directory are uploaded below the target prefix.
+func getUploadJobs(src string, target cpURL, recursive bool) ([]cpJob, error) {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		object := target.Object
+		if object == "" || hasSuffix(object, slashSeparator) {
+			object += filepath.Base(src)
+		}
+		return []cpJob{{path: src, object: object, upload: true}}, nil
+	}
+	if !recursive {
+		return nil, fmt.Errorf("%q is a directory, use --recursive to copy it", src)
+	}
+
+	prefix := target.Object
+	if prefix != "" && !hasSuffix(prefix, slashSeparator) {
+		prefix += slashSeparator
+	}
+	var jobs []cpJob
+	err = filepath.Walk(src, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, filePath)
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, cpJob{path: filePath, object: prefix + filepath.ToSlash(rel), upload: true})
+		return nil
+	})
+	return jobs, err
+}
+
+// getDownloadJobs - returns the objects downloaded to dst, the objects
+// below a prefix are downloaded into the directory dst.
+func (c *cpClient) getDownloadJobs(source cpURL, dst string, recursive bool) ([]cpJob, error) {
+	if !recursive {
+		if source.Object == "" || hasSuffix(source.Object, slashSeparator) {
+			return nil, fmt.Errorf("%q is a prefix, use --recursive to copy it", source.Bucket+slashSeparator+source.Object)
+		}
+		filePath := dst
+		if fi, err := os.Stat(dst); (err == nil && fi.IsDir()) || hasSuffix(dst, string(os.PathSeparator)) {
+			filePath = filepath.Join(dst, path.Base(source.Object))
+		}
+		return []cpJob{{path: filePath, object: source.Object}}, nil
+	}
+
+	prefix := source.Object
+	if prefix != "" && !hasSuffix(prefix, slashSeparator) {
+		prefix += slashSeparator
+	}
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	var jobs []cpJob
+	for object := range c.core.Client.ListObjectsV2(c.bucket, prefix, true, doneCh) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		// Skip directory markers.
+		if hasSuffix(object.Key, slashSeparator) {
+			continue
+		}
+		rel := filepath.FromSlash(strings.TrimPrefix(object.Key, prefix))
+		if filepath.IsAbs(rel) || strings.HasPrefix(filepath.Clean(rel), "..") {
+			return nil, fmt.Errorf("object %q cannot be downloaded below %q", object.Key, dst)
+		}
+		jobs = append(jobs, cpJob{path: filepath.Join(dst, rel), object: object.Key})
+	}
+	return jobs, nil
+}
+
+// cpETagHasher - computes the ETag of data uploaded in parts of
+// partSize, the MD5 of the data if partSize is zero.
+type cpETagHasher struct {
+	partSize int64
+	partLen  int64
+	part     hash.Hash
+	parts    []CompletePart
+}
+
+func newCpETagHasher(partSize int64) *cpETagHasher {
+	return &cpETagHasher{partSize: partSize, part: md5.New()}
+}
+
+func (h *cpETagHasher) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		chunk := p
+		if h.partSize > 0 && int64(len(chunk)) > h.partSize-h.partLen {
+			chunk = chunk[:h.partSize-h.partLen]
+		}
+		h.part.Write(chunk)
+		h.partLen += int64(len(chunk))
+		p = p[len(chunk):]
+		if h.partSize > 0 && h.partLen == h.partSize {
+			h.endPart()
+		}
+	}
+	return n, nil
+}
+
+func (h *cpETagHasher) endPart() {
+	h.parts = append(h.parts, CompletePart{
+		PartNumber: len(h.parts) + 1,
+		ETag:       hex.EncodeToString(h.part.Sum(nil)),
+	})
+	h.part.Reset()
+	h.partLen = 0
+}
+
+// ETag - returns the ETag of all data written, no data may be written
+// afterwards.
+func (h *cpETagHasher) ETag() string {
+	if h.partSize == 0 {
+		return hex.EncodeToString(h.part.Sum(nil))
+	}
+	if h.partLen > 0 {
+		h.endPart()
+	}
+	etag, _ := getCompleteMultipartMD5(h.parts)
+	return etag
+}
+
+// getCpFileETag - returns the ETag of the file uploaded in parts of
+// partSize.
+func getCpFileETag(filePath string, partSize int64) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hasher := newCpETagHasher(partSize)
+	if _, err = io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hasher.ETag(), nil
+}
+
+// getCpObjectPartSize - returns the size of the parts the object was
+// uploaded in, zero if it was uploaded at once. Returns false if the
+// checksum of the object cannot be verified, as it is encrypted or was
+// uploaded in parts by another client.
+func getCpObjectPartSize(info miniogo.ObjectInfo) (int64, bool) {
+	if info.Metadata.Get(SSEHeader) != "" || info.Metadata.Get(SSECustomerAlgorithm) != "" {
+		return 0, false
+	}
+	if !strings.Contains(info.ETag, "-") {
+		return 0, true
+	}
+	partSize, err := strconv.ParseInt(info.Metadata.Get(cpPartSizeMeta), 10, 64)
+	if err != nil || partSize <= 0 {
+		return 0, false
+	}
+	return partSize, true
+}
+
+// statObject - returns the info of the object, false if there is none.
+func (c *cpClient) statObject(object string) (miniogo.ObjectInfo, bool, error) {
+	info, err := c.core.StatObject(c.bucket, object, miniogo.StatObjectOptions{})
+	if err != nil {
+		if miniogo.ToErrorResponse(err).Code == "NoSuchKey" {
+			return info, false, nil
+		}
+		return info, false, err
+	}
+	return info, true, nil
+}
+
+// upload - uploads the file, files larger than the part size in parts.
+// Returns true if the object already has the content of the file.
+func (c *cpClient) upload(job cpJob) (bool, error) {
+	fi, err := os.Stat(job.path)
+	if err != nil {
+		return false, err
+	}
+	size := fi.Size()
+
+	info, ok, err := c.statObject(job.object)
+	if err != nil {
+		return false, err
+	}
+	if ok && info.Size == size {
+		if partSize, verify := getCpObjectPartSize(info); verify {
+			etag, err := getCpFileETag(job.path, partSize)
+			if err != nil || etag == info.ETag {
+				return err == nil, err
+			}
+		}
+	}
+
+	partSize := c.getPartSize(size)
+	if size <= partSize {
+		return false, c.putObject(job)
+	}
+	return false, c.putMultipartObject(job, size, partSize)
+}
+
+func (c *cpClient) putObject(job cpJob) error {
+	data, err := ioutil.ReadFile(job.path)
+	if err != nil {
+		return err
+	}
+	md5Sum := getMD5Sum(data)
+	info, err := c.core.PutObject(c.bucket, job.object, bytes.NewReader(data), int64(len(data)),
+		base64.StdEncoding.EncodeToString(md5Sum), getSHA256Hash(data), nil)
+	if err != nil {
+		return err
+	}
+	atomic.AddInt64(&c.transferred, int64(len(data)))
+	if info.ETag != hex.EncodeToString(md5Sum) {
+		return errCpChecksumMismatch
+	}
+	return nil
+}
+
+// findUpload - returns the latest incomplete upload of the object in
+// parts of partSize and its uploaded parts, to resume it. Uploads with
+// parts of other sizes are not resumed.
+func (c *cpClient) findUpload(object string, size, partSize int64) (string, map[int]miniogo.ObjectPart, error) {
+	result, err := c.core.ListMultipartUploads(c.bucket, object, "", "", "", maxUploadsList)
+	if err != nil {
+		return "", nil, err
+	}
+	var upload miniogo.ObjectMultipartInfo
+	for _, u := range result.Uploads {
+		if u.Key == object && (upload.UploadID == "" || u.Initiated.After(upload.Initiated)) {
+			upload = u
+		}
+	}
+	if upload.UploadID == "" {
+		return "", nil, nil
+	}
+
+	parts := make(map[int]miniogo.ObjectPart)
+	partNumberMarker := 0
+	for {
+		result, err := c.core.ListObjectParts(c.bucket, object, upload.UploadID, partNumberMarker, maxPartsList)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, part := range result.ObjectParts {
+			offset := int64(part.PartNumber-1) * partSize
+			if offset >= size || part.Size != getPartLength(offset, size, partSize) {
+				return "", nil, nil
+			}
+			part.ETag = strings.Trim(part.ETag, "\"")
+			parts[part.PartNumber] = part
+		}
+		if !result.IsTruncated {
+			return upload.UploadID, parts, nil
+		}
+		partNumberMarker = result.NextPartNumberMarker
+	}
+}
+
+// getPartLength - returns the length of the part at offset.
+func getPartLength(offset, size, partSize int64) int64 {
+	if offset+partSize > size {
+		return size - offset
+	}
+	return partSize
+}
+
+// putMultipartObject - uploads the file in parts, part-parallel parts
+// at once. Incomplete uploads are kept and resumed by the next call,
+// parts already uploaded with the same content are not uploaded again.
+func (c *cpClient) putMultipartObject(job cpJob, size, partSize int64) error {
+	uploadID, uploaded, err := c.findUpload(job.object, size, partSize)
+	if err != nil {
+		return err
+	}
+	if uploadID == "" {
+		opts := miniogo.PutObjectOptions{
+			UserMetadata: map[string]string{cpPartSizeMeta: strconv.FormatInt(partSize, 10)},
+		}
+		if uploadID, err = c.core.NewMultipartUpload(c.bucket, job.object, opts); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(job.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	partsCount := int((size + partSize - 1) / partSize)
+	parts := make([]CompletePart, partsCount)
+	var mu sync.Mutex
+	var partErr error
+	getErr := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return partErr
+	}
+
+	partCh := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < c.partParallel && i < partsCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, partSize)
+			for partNumber := range partCh {
+				etag, err := c.putPart(f, job.object, uploadID, partNumber, size, partSize, buf, uploaded)
+				if err != nil {
+					mu.Lock()
+					if partErr == nil {
+						partErr = err
+					}
+					mu.Unlock()
+					continue
+				}
+				parts[partNumber-1] = CompletePart{PartNumber: partNumber, ETag: etag}
+			}
+		}()
+	}
+	for partNumber := 1; partNumber <= partsCount && getErr() == nil; partNumber++ {
+		partCh <- partNumber
+	}
+	close(partCh)
+	wg.Wait()
+	if err = getErr(); err != nil {
+		return err
+	}
+
+	completeParts := make([]miniogo.CompletePart, partsCount)
+	for i, part := range parts {
+		completeParts[i] = miniogo.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag}
+	}
+	if err = c.core.CompleteMultipartUpload(c.bucket, job.object, uploadID, completeParts); err != nil {
+		return err
+	}
+
+	etag, err := getCompleteMultipartMD5(parts)
+	if err != nil {
+		return err
+	}
+	info, _, err := c.statObject(job.object)
+	if err != nil {
+		return err
+	}
+	if info.ETag != etag {
+		return errCpChecksumMismatch
+	}
+	return nil
+}
+
+// putPart - uploads the part of the file unless it is uploaded already,
+// returns the MD5 of the part.
+func (c *cpClient) putPart(f *os.File, object, uploadID string, partNumber int, size, partSize int64,
+	buf []byte, uploaded map[int]miniogo.ObjectPart) (string, error) {
+	offset := int64(partNumber-1) * partSize
+	data := buf[:getPartLength(offset, size, partSize)]
+	if _, err := f.ReadAt(data, offset); err != nil {
+		return "", err
+	}
+	md5Sum := getMD5Sum(data)
+	etag := hex.EncodeToString(md5Sum)
+	if part, ok := uploaded[partNumber]; ok && part.ETag == etag {
+		return etag, nil
+	}
+
+	part, err := c.core.PutObjectPart(c.bucket, object, uploadID, partNumber, bytes.NewReader(data), int64(len(data)),
+		base64.StdEncoding.EncodeToString(md5Sum), getSHA256Hash(data))
+	if err != nil {
+		return "", err
+	}
+	atomic.AddInt64(&c.transferred, int64(len(data)))
+	if part.ETag != etag {
+		return "", errCpChecksumMismatch
+	}
+	return etag, nil
+}
+
+// download - downloads the object to a part file renamed once the
+// download is complete and verified. Part files left by interrupted
+// downloads are resumed. Returns true if the file already has the
+// content of the object.
+func (c *cpClient) download(job cpJob) (bool, error) {
+	info, err := c.core.StatObject(c.bucket, job.object, miniogo.StatObjectOptions{})
+	if err != nil {
+		return false, err
+	}
+	partSize, verify := getCpObjectPartSize(info)
+	if fi, err := os.Stat(job.path); err == nil && fi.Size() == info.Size && verify {
+		etag, err := getCpFileETag(job.path, partSize)
+		if err != nil || etag == info.ETag {
+			return err == nil, err
+		}
+	}
+
+	if err = os.MkdirAll(filepath.Dir(job.path), 0755); err != nil {
+		return false, err
+	}
+	partPath := job.path + cpPartFileSuffix
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return false, err
+	}
+	err = c.downloadTo(f, job.object, info, partSize, verify)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == errCpChecksumMismatch {
+		os.Remove(partPath)
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, os.Rename(partPath, job.path)
+}
+
+// downloadTo - downloads the object to the end of the part file f, the
+// part file is only resumed if the checksum of the object is verified.
+func (c *cpClient) downloadTo(f *os.File, object string, info miniogo.ObjectInfo, partSize int64, verify bool) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := fi.Size()
+	if !verify || offset > info.Size {
+		if err = f.Truncate(0); err != nil {
+			return err
+		}
+		offset = 0
+	}
+
+	hasher := newCpETagHasher(partSize)
+	if _, err = io.Copy(hasher, io.NewSectionReader(f, 0, offset)); err != nil {
+		return err
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	if offset < info.Size {
+		var opts miniogo.GetObjectOptions
+		if err = opts.SetMatchETag(info.ETag); err != nil {
+			return err
+		}
+		if offset > 0 {
+			if err = opts.SetRange(offset, 0); err != nil {
+				return err
+			}
+		}
+		reader, _, err := c.core.GetObject(c.bucket, object, opts)
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(io.MultiWriter(f, hasher), reader)
+		reader.Close()
+		atomic.AddInt64(&c.transferred, n)
+		if err != nil {
+			return err
+		}
+	}
+
+	if verify && hasher.ETag() != info.ETag {
+		return errCpChecksumMismatch
+	}
+	return nil
+}
+
+// describe - returns the source and target of the job.
+func (c *cpClient) describe(job cpJob) string {
+	if job.upload {
+		return fmt.Sprintf("‘%s’ -> ‘%s’", job.path, c.bucket+slashSeparator+job.object)
+	}
+	return fmt.Sprintf("‘%s’ -> ‘%s’", c.bucket+slashSeparator+job.object, job.path)
+}
+
+// run - copies the files of all jobs, parallel files at once. Returns
+// the number of files which could not be copied.
+func (c *cpClient) run(jobs []cpJob, parallel int) int {
+	start := UTCNow()
+	var mu sync.Mutex
+	var copied, skipped, failed int
+
+	jobCh := make(chan cpJob)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				var same bool
+				var err error
+				if job.upload {
+					same, err = c.upload(job)
+				} else {
+					same, err = c.download(job)
+				}
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					failed++
+					log.Printf("Unable to copy %s: %v\n", c.describe(job), err)
+				case same:
+					skipped++
+				default:
+					copied++
+					log.Println(c.describe(job))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, job := range jobs {
+		jobCh <- job
+	}
+	close(jobCh)
+	wg.Wait()
+
+	log.Printf("Copied %d files (%s) in %s, skipped %d files already copied, %d files failed.\n",
+		copied, humanize.IBytes(uint64(atomic.LoadInt64(&c.transferred))),
+		UTCNow().Sub(start).Round(time.Second), skipped, failed)
+	return failed
+}
+
+func mainCp(ctx *cli.Context) {
+	if len(ctx.Args()) != 2 {
+		cli.ShowCommandHelpAndExit(ctx, "cp", 1)
+	}
+	if ctx.Bool("quiet") || ctx.GlobalBool("quiet") {
+		log.EnableQuiet()
+	}
+
+	src, dst := ctx.Args().Get(0), ctx.Args().Get(1)
+	if isCpURL(src) == isCpURL(dst) {
+		fatalIf(errInvalidArgument, "One of ‘%s’ and ‘%s’ must be a local path and the other one a URL.", src, dst)
+	}
+	remote := dst
+	if isCpURL(src) {
+		remote = src
+	}
+	target, err := parseCpURL(remote)
+	fatalIf(err, "Invalid URL ‘%s’.", remote)
+
+	partSize, err := humanize.ParseBytes(ctx.String("part-size"))
+	fatalIf(err, "Invalid part size ‘%s’.", ctx.String("part-size"))
+	if partSize < globalMinPartSize || partSize > globalMaxPartSize {
+		fatalIf(errInvalidArgument, "Part size ‘%s’ must be between 5MiB and 5GiB.", ctx.String("part-size"))
+	}
+	parallel, partParallel := ctx.Int("parallel"), ctx.Int("part-parallel")
+	if parallel <= 0 || partParallel <= 0 {
+		fatalIf(errInvalidArgument, "Number of files and parts copied at once must be positive.")
+	}
+
+	client, err := newCpClient(target, os.Getenv("MINIO_ACCESS_KEY"), os.Getenv("MINIO_SECRET_KEY"),
+		ctx.Bool("insecure"), int64(partSize), partParallel)
+	fatalIf(err, "Unable to initialize client for ‘%s’.", remote)
+
+	var jobs []cpJob
+	if isCpURL(dst) {
+		jobs, err = getUploadJobs(src, target, ctx.Bool("recursive"))
+	} else {
+		jobs, err = client.getDownloadJobs(target, dst, ctx.Bool("recursive"))
+	}
+	fatalIf(err, "Unable to list files to copy from ‘%s’.", src)
+
+	if client.run(jobs, parallel) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,329 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	humanize "github.com/dustin/go-humanize"
+	miniogo "github.com/minio/minio-go"
+)
+
+// Tests parsing URLs of buckets and objects.
+func TestParseCpURL(t *testing.T) {
+	testCases := []struct {
+		arg      string
+		expected cpURL
+		success  bool
+	}{
+		{"http://localhost:9000/bucket", cpURL{"localhost:9000", false, "bucket", ""}, true},
+		{"https://minio.example.com/bucket/dir/object", cpURL{"minio.example.com", true, "bucket", "dir/object"}, true},
+		{"https://minio.example.com/bucket/prefix/", cpURL{"minio.example.com", true, "bucket", "prefix/"}, true},
+		{"http://localhost:9000/", cpURL{}, false},
+		{"http:///bucket", cpURL{}, false},
+	}
+	for i, testCase := range testCases {
+		u, err := parseCpURL(testCase.arg)
+		if testCase.success != (err == nil) {
+			t.Fatalf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if u != testCase.expected {
+			t.Errorf("Test %d: Expected %+v, got %+v", i+1, testCase.expected, u)
+		}
+	}
+}
+
+// Tests ETags are computed like the ETags of objects uploaded at once
+// and in parts.
+func TestCpETagHasher(t *testing.T) {
+	data := bytes.Repeat([]byte("abcdefghij"), 100)
+
+	hasher := newCpETagHasher(0)
+	hasher.Write(data[:7])
+	hasher.Write(data[7:])
+	if etag := hasher.ETag(); etag != getMD5Hash(data) {
+		t.Errorf("Expected the MD5 of the data, got %s", etag)
+	}
+
+	for _, partSize := range []int64{100, 300, 1000} {
+		var parts []CompletePart
+		for offset := int64(0); offset < int64(len(data)); offset += partSize {
+			length := getPartLength(offset, int64(len(data)), partSize)
+			parts = append(parts, CompletePart{
+				PartNumber: len(parts) + 1,
+				ETag:       getMD5Hash(data[offset : offset+length]),
+			})
+		}
+		expected, err := getCompleteMultipartMD5(parts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hasher = newCpETagHasher(partSize)
+		for i := 0; i < len(data); i += 33 {
+			hasher.Write(data[i:minInt(i+33, len(data))])
+		}
+		if etag := hasher.ETag(); etag != expected {
+			t.Errorf("Part size %d: Expected %s, got %s", partSize, expected, etag)
+		}
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Starts a test server with a bucket, returns a client of the bucket
+// uploading in parts of 5MiB.
+func newTestCpClient(t *testing.T) (TestServer, *cpClient) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	// Only the S3 API of the server is needed.
+	server := UnstartedTestServer(t, "FS")
+	server.Server.Config.Handler = initTestAPIEndPoints(server.Obj, nil)
+	server.Server.Start()
+	if err = server.Obj.MakeBucketWithLocation("bucket", ""); err != nil {
+		server.Stop()
+		t.Fatal(err)
+	}
+	target, err := parseCpURL(server.Server.URL + "/bucket")
+	if err != nil {
+		server.Stop()
+		t.Fatal(err)
+	}
+	client, err := newCpClient(target, server.AccessKey, server.SecretKey, false, globalMinPartSize, 2)
+	if err != nil {
+		server.Stop()
+		t.Fatal(err)
+	}
+	return server, client
+}
+
+// Tests uploading a directory and downloading it again, files already
+// copied are skipped.
+func TestCpUploadDownload(t *testing.T) {
+	server, client := newTestCpClient(t)
+	defer server.Stop()
+
+	srcDir, err := ioutil.TempDir("", "minio-cp-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(srcDir)
+	files := map[string][]byte{
+		"small.txt":      []byte("hello, world"),
+		"empty":          {},
+		"dir/large.bin":  bytes.Repeat([]byte("0123456789abcdef"), 11*humanize.MiByte/16+3),
+		"dir/sub/medium": bytes.Repeat([]byte("m"), 5*humanize.MiByte),
+	}
+	for name, data := range files {
+		filePath := filepath.Join(srcDir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filePath, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	target := cpURL{Bucket: "bucket", Object: "backup"}
+	if _, err = getUploadJobs(srcDir, target, false); err == nil {
+		t.Fatal("Expected directories to be copied only recursively")
+	}
+	jobs, err := getUploadJobs(srcDir, target, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed := client.run(jobs, 2); failed != 0 {
+		t.Fatalf("Expected all uploads to succeed, %d failed", failed)
+	}
+	for name, data := range files {
+		info, err := server.Obj.GetObjectInfo("bucket", "backup/"+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size != int64(len(data)) {
+			t.Errorf("%s: Expected size %d, got %d", name, len(data), info.Size)
+		}
+	}
+	info, err := server.Obj.GetObjectInfo("bucket", "backup/dir/large.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.UserDefined[cpPartSizeMeta] != "5242880" {
+		t.Errorf("Expected the part size in the metadata, got %v", info.UserDefined)
+	}
+
+	// Nothing is uploaded again.
+	client.transferred = 0
+	if failed := client.run(jobs, 2); failed != 0 || client.transferred != 0 {
+		t.Fatalf("Expected all uploads to be skipped, %d failed and %d bytes transferred", failed, client.transferred)
+	}
+
+	dstDir, err := ioutil.TempDir("", "minio-cp-dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dstDir)
+	if jobs, err = client.getDownloadJobs(target, dstDir, true); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, job := range jobs {
+		names = append(names, job.object)
+	}
+	sort.Strings(names)
+	expected := []string{"backup/dir/large.bin", "backup/dir/sub/medium", "backup/empty", "backup/small.txt"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected objects %v, got %v", expected, names)
+	}
+	for i := range names {
+		if names[i] != expected[i] {
+			t.Fatalf("Expected objects %v, got %v", expected, names)
+		}
+	}
+
+	client.transferred = 0
+	if failed := client.run(jobs, 2); failed != 0 {
+		t.Fatalf("Expected all downloads to succeed, %d failed", failed)
+	}
+	for name, data := range files {
+		downloaded, err := ioutil.ReadFile(filepath.Join(dstDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(downloaded, data) {
+			t.Errorf("%s: Downloaded data differs", name)
+		}
+	}
+
+	// Nothing is downloaded again.
+	client.transferred = 0
+	if failed := client.run(jobs, 2); failed != 0 || client.transferred != 0 {
+		t.Fatalf("Expected all downloads to be skipped, %d failed and %d bytes transferred", failed, client.transferred)
+	}
+
+	// A single object is downloaded into a directory.
+	if jobs, err = client.getDownloadJobs(cpURL{Bucket: "bucket", Object: "backup/small.txt"}, dstDir, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].path != filepath.Join(dstDir, "small.txt") {
+		t.Errorf("Unexpected jobs %+v", jobs)
+	}
+	if _, err = client.getDownloadJobs(cpURL{Bucket: "bucket", Object: "backup/"}, dstDir, false); err == nil {
+		t.Error("Expected prefixes to be copied only recursively")
+	}
+}
+
+// Tests interrupted uploads and downloads are resumed.
+func TestCpResume(t *testing.T) {
+	server, client := newTestCpClient(t)
+	defer server.Stop()
+
+	dir, err := ioutil.TempDir("", "minio-cp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	partSize := int64(globalMinPartSize)
+	data := bytes.Repeat([]byte("0123456789abcdef"), int(3*partSize/16)+5)
+	size := int64(len(data))
+	filePath := filepath.Join(dir, "file")
+	if err = ioutil.WriteFile(filePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload interrupted after the second part.
+	opts := miniogo.PutObjectOptions{UserMetadata: map[string]string{cpPartSizeMeta: "5242880"}}
+	uploadID, err := client.core.NewMultipartUpload("bucket", "object", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part := data[partSize : 2*partSize]
+	if _, err = client.core.PutObjectPart("bucket", "object", uploadID, 2, bytes.NewReader(part), partSize,
+		base64.StdEncoding.EncodeToString(getMD5Sum(part)), getSHA256Hash(part)); err != nil {
+		t.Fatal(err)
+	}
+
+	job := cpJob{path: filePath, object: "object", upload: true}
+	if _, err = client.upload(job); err != nil {
+		t.Fatal(err)
+	}
+	if client.transferred != size-partSize {
+		t.Errorf("Expected %d bytes to be uploaded, got %d", size-partSize, client.transferred)
+	}
+	var buf bytes.Buffer
+	if err = server.Obj.GetObject("bucket", "object", 0, size, &buf, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("Uploaded data differs")
+	}
+
+	// Download interrupted after 3MiB.
+	dstPath := filepath.Join(dir, "downloaded")
+	if err = ioutil.WriteFile(dstPath+cpPartFileSuffix, data[:3*humanize.MiByte], 0644); err != nil {
+		t.Fatal(err)
+	}
+	client.transferred = 0
+	job = cpJob{path: dstPath, object: "object"}
+	if _, err = client.download(job); err != nil {
+		t.Fatal(err)
+	}
+	if client.transferred != size-3*humanize.MiByte {
+		t.Errorf("Expected %d bytes to be downloaded, got %d", size-3*humanize.MiByte, client.transferred)
+	}
+	downloaded, err := ioutil.ReadFile(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("Downloaded data differs")
+	}
+	if _, err = os.Stat(dstPath + cpPartFileSuffix); !os.IsNotExist(err) {
+		t.Errorf("Expected the part file to be renamed, got %v", err)
+	}
+
+	// Corrupt part files are detected and removed.
+	os.Remove(dstPath)
+	if err = ioutil.WriteFile(dstPath+cpPartFileSuffix, bytes.Repeat([]byte("x"), humanize.MiByte), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.download(job); err != errCpChecksumMismatch {
+		t.Fatalf("Expected a checksum mismatch, got %v", err)
+	}
+	if _, err = os.Stat(dstPath + cpPartFileSuffix); !os.IsNotExist(err) {
+		t.Errorf("Expected the part file to be removed, got %v", err)
+	}
+	if _, err = client.download(job); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -43,6 +43,11 @@ var (
 
 	// Additional Minio errors for SSE-C requests.
 	errObjectTampered = errors.New("The requested object was modified and may be compromised")
+
+	// Errors for SSE-S3 requests.
+	errKMSNotConfigured        = errors.New("Server side encryption specified but KMS is not configured")
+	errInvalidEncryptionMethod = errors.New("The encryption method specified is not supported")
+	errKMSKeyIDNotSupported    = errors.New("Choosing the KMS key is not supported, objects are sealed by the master key configured for the server")
 )
 
 const (
@@ -61,6 +66,18 @@ const (
 	SSECopyCustomerKeyMD5 = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key-MD5"
 )
 
+const (
+	// SSEHeader is the AWS SSE-S3 HTTP header key requesting encryption with server managed keys.
+	SSEHeader = "X-Amz-Server-Side-Encryption"
+	// SSEKMSKeyID is the AWS SSE-KMS HTTP header key choosing the KMS master key.
+	SSEKMSKeyID = "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"
+
+	// SSEAlgorithmAES256 and SSEAlgorithmKMS are the valid SSE-S3 header values,
+	// both seal the data key of the object with the master key of the KMS.
+	SSEAlgorithmAES256 = "AES256"
+	SSEAlgorithmKMS    = "aws:kms"
+)
+
 const (
 	// SSECustomerKeySize is the size of valid client provided encryption keys in bytes.
 	// Currently AWS supports only AES256. So the SSE-C key size is fixed to 32 bytes.
@@ -125,6 +142,10 @@ const (
 	// ServerSideEncryptionSealedKey is the sealed object encryption key. The sealed key can be decrypted
 	// by the key encryption key derived from the client provided key and the server-side-encryption IV.
 	ServerSideEncryptionSealedKey = ReservedMetadataPrefix + "Server-Side-Encryption-Sealed-Key"
+
+	// ServerSideEncryptionKMSSealedKey is the data key of an SSE-S3 object sealed by the master key
+	// of the KMS. The data key takes the place of the client provided key of SSE-C objects.
+	ServerSideEncryptionKMSSealedKey = ReservedMetadataPrefix + "Server-Side-Encryption-Kms-Sealed-Key"
 )
 
 // SSESealAlgorithmDareSha256 specifies DARE as authenticated en/decryption scheme and SHA256 as cryptographic
@@ -143,6 +164,39 @@ func IsSSECopyCustomerRequest(header http.Header) bool {
 	return header.Get(SSECopyCustomerAlgorithm) != "" || header.Get(SSECopyCustomerKey) != "" || header.Get(SSECopyCustomerKeyMD5) != ""
 }
 
+// IsSSES3Request returns true if the given HTTP header
+// requests server-side-encryption with keys managed by the KMS.
+func IsSSES3Request(header http.Header) bool {
+	_, ok := header[SSEHeader]
+	return ok
+}
+
+// isSSES3Encrypted returns true if the object metadata holds a data key
+// sealed by the KMS.
+func isSSES3Encrypted(metadata map[string]string) bool {
+	_, ok := metadata[ServerSideEncryptionKMSSealedKey]
+	return ok
+}
+
+// ParseSSES3Request validates the SSE-S3 header fields of the provided
+// request. SSE-S3 requests need no secure connection, keys never leave
+// the server.
+func ParseSSES3Request(header http.Header) error {
+	if IsSSECustomerRequest(header) {
+		return errInvalidEncryptionMethod
+	}
+	if algorithm := header.Get(SSEHeader); algorithm != SSEAlgorithmAES256 && algorithm != SSEAlgorithmKMS {
+		return errInvalidEncryptionMethod
+	}
+	if _, ok := header[SSEKMSKeyID]; ok {
+		return errKMSKeyIDNotSupported
+	}
+	if globalKMS == nil {
+		return errKMSNotConfigured
+	}
+	return nil
+}
+
 // ParseSSECopyCustomerRequest parses the SSE-C header fields of the provided request.
 // It returns the client provided key on success.
 func ParseSSECopyCustomerRequest(r *http.Request) (key []byte, err error) {
@@ -331,6 +385,62 @@ func newEncryptReader(content io.Reader, key []byte, metadata map[string]string)
 	return reader, nil
 }
 
+// newKMSEncryptReader encrypts the content with a new data key generated
+// by the KMS, the data key sealed by the KMS is saved as object metadata.
+func newKMSEncryptReader(content io.Reader, kms KMS, metadata map[string]string) (io.Reader, error) {
+	key, sealedKey, err := kms.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := newEncryptReader(content, key, metadata)
+	if err != nil {
+		return nil, err
+	}
+	metadata[ServerSideEncryptionKMSSealedKey] = sealedKey
+	return reader, nil
+}
+
+// rotateKMSKey seals the object encryption key with a new data key
+// generated by the KMS, sealed by the latest version of the master key.
+// The content of the object is not re-encrypted.
+func rotateKMSKey(kms KMS, metadata map[string]string) error {
+	if kms == nil {
+		return errKMSNotConfigured
+	}
+	oldKey, err := kms.UnsealKey(metadata[ServerSideEncryptionKMSSealedKey])
+	if err != nil {
+		return err
+	}
+	newKey, sealedKey, err := kms.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if err = rotateKey(oldKey, newKey, metadata); err != nil {
+		if err == errSSEKeyMismatch {
+			// The KMS unsealed a data key of a different object.
+			err = errObjectTampered
+		}
+		return err
+	}
+	metadata[ServerSideEncryptionKMSSealedKey] = sealedKey
+	return nil
+}
+
+// EncryptSSES3Request encrypts the client provided content with a data key
+// managed by the KMS. It also marks the object as server-side-encrypted
+// and saves the requested algorithm, returned along with the object.
+func EncryptSSES3Request(content io.Reader, r *http.Request, metadata map[string]string) (io.Reader, error) {
+	if err := ParseSSES3Request(r.Header); err != nil {
+		return nil, err
+	}
+	reader, err := newKMSEncryptReader(content, globalKMS, metadata)
+	if err != nil {
+		return nil, err
+	}
+	metadata[SSEHeader] = r.Header.Get(SSEHeader)
+	return reader, nil
+}
+
 // EncryptRequest takes the client provided content and encrypts the data
 // with the client provided key. It also marks the object as client-side-encrypted
 // and sets the correct headers.
@@ -395,6 +505,38 @@ func newDecryptWriter(client io.Writer, key []byte, seqNumber uint32, metadata m
 	return writer, nil
 }
 
+// newKMSDecryptWriter decrypts the object with the data key unsealed by
+// the KMS. It also removes the server-side-encryption metadata from the
+// object, except for the algorithm returned along with the object.
+func newKMSDecryptWriter(client io.Writer, kms KMS, seqNumber uint32, metadata map[string]string) (io.WriteCloser, error) {
+	if kms == nil {
+		return nil, errKMSNotConfigured
+	}
+	key, err := kms.UnsealKey(metadata[ServerSideEncryptionKMSSealedKey])
+	if err != nil {
+		return nil, err
+	}
+	writer, err := newDecryptWriter(client, key, seqNumber, metadata)
+	if err == errSSEKeyMismatch {
+		// The KMS unsealed a data key of a different object.
+		return nil, errObjectTampered
+	}
+	if err != nil {
+		return nil, err
+	}
+	delete(metadata, ServerSideEncryptionKMSSealedKey)
+	return writer, nil
+}
+
+// removeSSES3Metadata removes the server-side-encryption metadata of an
+// SSE-S3 object which must not be returned to clients.
+func removeSSES3Metadata(metadata map[string]string) {
+	delete(metadata, ServerSideEncryptionIV)
+	delete(metadata, ServerSideEncryptionSealAlgorithm)
+	delete(metadata, ServerSideEncryptionSealedKey)
+	delete(metadata, ServerSideEncryptionKMSSealedKey)
+}
+
 // DecryptRequestWithSequenceNumber decrypts the object with the client provided key. It also removes
 // the client-side-encryption metadata from the object and sets the correct headers.
 func DecryptRequestWithSequenceNumber(client io.Writer, r *http.Request, seqNumber uint32, metadata map[string]string) (io.WriteCloser, error) {
@@ -417,6 +559,9 @@ func getStartOffset(offset, length int64) (seqNumber uint32, startOffset int64,
 	seqNumber = uint32(offset / (64 * 1024))
 	startOffset = int64(seqNumber) * (64*1024 + 32)
 
+	// Ranges starting within a package span one more package when
+	// they end beyond the boundary of the last full package.
+	length += offset % (64 * 1024)
 	rlength = (length / (64 * 1024)) * (64*1024 + 32)
 	if length%(64*1024) > 0 {
 		rlength += 64*1024 + 32
@@ -446,11 +591,16 @@ func (o *ObjectInfo) DecryptedSize() (int64, error) {
 	if !o.IsEncrypted() {
 		panic("cannot compute decrypted size of an object which is not encrypted")
 	}
-	if o.Size == 0 {
-		return o.Size, nil
+	return decryptedSize(o.Size)
+}
+
+// decryptedSize returns the size of encrypted content of encSize bytes after decryption.
+func decryptedSize(encSize int64) (int64, error) {
+	if encSize == 0 {
+		return encSize, nil
 	}
-	size := (o.Size / (32 + 64*1024)) * (64 * 1024)
-	if mod := o.Size % (32 + 64*1024); mod > 0 {
+	size := (encSize / (32 + 64*1024)) * (64 * 1024)
+	if mod := encSize % (32 + 64*1024); mod > 0 {
 		if mod < 33 {
 			return -1, errObjectTampered // object is not 0 size but smaller than the smallest valid encrypted object
 		}
@@ -463,16 +613,21 @@ func (o *ObjectInfo) DecryptedSize() (int64, error) {
 // An encrypted object is always larger than a plain object
 // except for zero size objects.
 func (o *ObjectInfo) EncryptedSize() int64 {
-	size := (o.Size / (64 * 1024)) * (32 + 64*1024)
-	if mod := o.Size % (64 * 1024); mod > 0 {
-		size += mod + 32
+	return encryptedSize(o.Size)
+}
+
+// encryptedSize returns the size of content of size bytes after encryption.
+func encryptedSize(size int64) int64 {
+	encSize := (size / (64 * 1024)) * (32 + 64*1024)
+	if mod := size % (64 * 1024); mod > 0 {
+		encSize += mod + 32
 	}
-	return size
+	return encSize
 }
 
 // DecryptCopyObjectInfo tries to decrypt the provided object if it is encrypted.
-// It fails if the object is encrypted and the HTTP headers don't contain
-// SSE-C headers or the object is not encrypted but SSE-C headers are provided. (AWS behavior)
+// It fails if the object is encrypted with SSE-C and the HTTP headers don't contain
+// SSE-C headers or the object is not encrypted with SSE-C but SSE-C headers are provided. (AWS behavior)
 // DecryptObjectInfo returns 'ErrNone' if the object is not encrypted or the
 // decryption succeeded.
 //
@@ -485,7 +640,13 @@ func DecryptCopyObjectInfo(info *ObjectInfo, headers http.Header) (apiErr APIErr
 	if apiErr, encrypted = ErrNone, info.IsEncrypted(); !encrypted && IsSSECopyCustomerRequest(headers) {
 		apiErr = ErrInvalidEncryptionParameters
 	} else if encrypted {
-		if !IsSSECopyCustomerRequest(headers) {
+		// Objects encrypted with SSE-S3 are decrypted by the server.
+		if isSSES3Encrypted(info.UserDefined) {
+			if IsSSECopyCustomerRequest(headers) {
+				apiErr = ErrInvalidEncryptionParameters
+				return
+			}
+		} else if !IsSSECopyCustomerRequest(headers) {
 			apiErr = ErrSSEEncryptedObject
 			return
 		}
@@ -498,8 +659,8 @@ func DecryptCopyObjectInfo(info *ObjectInfo, headers http.Header) (apiErr APIErr
 }
 
 // DecryptObjectInfo tries to decrypt the provided object if it is encrypted.
-// It fails if the object is encrypted and the HTTP headers don't contain
-// SSE-C headers or the object is not encrypted but SSE-C headers are provided. (AWS behavior)
+// It fails if the object is encrypted with SSE-C and the HTTP headers don't contain
+// SSE-C headers or the object is not encrypted with SSE-C but SSE-C headers are provided. (AWS behavior)
 // DecryptObjectInfo returns 'ErrNone' if the object is not encrypted or the
 // decryption succeeded.
 //
@@ -512,7 +673,13 @@ func DecryptObjectInfo(info *ObjectInfo, headers http.Header) (apiErr APIErrorCo
 	if apiErr, encrypted = ErrNone, info.IsEncrypted(); !encrypted && IsSSECustomerRequest(headers) {
 		apiErr = ErrInvalidEncryptionParameters
 	} else if encrypted {
-		if !IsSSECustomerRequest(headers) {
+		// Objects encrypted with SSE-S3 are decrypted by the server.
+		if isSSES3Encrypted(info.UserDefined) {
+			if IsSSECustomerRequest(headers) {
+				apiErr = ErrInvalidEncryptionParameters
+				return
+			}
+		} else if !IsSSECustomerRequest(headers) {
 			apiErr = ErrSSEEncryptedObject
 			return
 		}
@@ -32,6 +32,9 @@ const (
 	serverCommandLineArgsMax = 32
 )
 
+// Argument separating the zones of a server on the command line.
+const zoneArgSeparator = "+"
+
 // Endpoint set represents parsed ellipses values, also provides
 // methods to get the sets of endpoints.
 type endpointSet struct {
@@ -249,3 +252,72 @@ func createServerEndpoints(serverAddr string, args ...string) (string, EndpointL
 
 	return serverAddr, endpoints, setupType, len(setArgs), len(setArgs[0]), nil
 }
+
+// zoneEndpoints - endpoints of a zone, erasure sets formatted together.
+// Servers grow by adding zones, existing zones keep their format.
+type zoneEndpoints struct {
+	endpoints    EndpointList
+	setCount     int
+	drivesPerSet int
+}
+
+// createServerZones - validates and creates the endpoints of the zones
+// of a server, the zones are separated by "+" arguments on the command
+// line. Servers without separators have a single zone.
+func createServerZones(serverAddr string, args ...string) (string, EndpointList, []zoneEndpoints, SetupType, error) {
+	var zoneArgs [][]string
+	start := 0
+	for i, arg := range args {
+		if arg == zoneArgSeparator {
+			zoneArgs = append(zoneArgs, args[start:i])
+			start = i + 1
+		}
+	}
+	zoneArgs = append(zoneArgs, args[start:])
+
+	if len(zoneArgs) == 1 {
+		serverAddr, endpoints, setupType, setCount, drivesPerSet, err := createServerEndpoints(serverAddr, args...)
+		if err != nil {
+			return serverAddr, nil, nil, -1, err
+		}
+		return serverAddr, endpoints, []zoneEndpoints{{endpoints, setCount, drivesPerSet}}, setupType, nil
+	}
+
+	var setArgs [][]string
+	zones := make([]zoneEndpoints, len(zoneArgs))
+	for i, zargs := range zoneArgs {
+		zoneSetArgs, err := getAllSets(zargs...)
+		if err != nil {
+			return serverAddr, nil, nil, -1, err
+		}
+		if len(zoneSetArgs) == 1 && len(zoneSetArgs[0]) == 1 {
+			return serverAddr, nil, nil, -1, fmt.Errorf("Zone (%s) has a single disk, zones are only supported in erasure coding mode", zargs)
+		}
+		zones[i].setCount, zones[i].drivesPerSet = len(zoneSetArgs), len(zoneSetArgs[0])
+		setArgs = append(setArgs, zoneSetArgs...)
+	}
+
+	uniqueArgs := set.NewStringSet()
+	for _, sargs := range setArgs {
+		for _, arg := range sargs {
+			if uniqueArgs.Contains(arg) {
+				return serverAddr, nil, nil, -1, fmt.Errorf("Input args (%s) are in more than one zone", arg)
+			}
+			uniqueArgs.Add(arg)
+		}
+	}
+
+	serverAddr, endpoints, setupType, err := CreateEndpoints(serverAddr, setArgs...)
+	if err != nil {
+		return serverAddr, nil, nil, -1, err
+	}
+
+	// Endpoints are created in the order of the zones.
+	remaining := endpoints
+	for i := range zones {
+		count := zones[i].setCount * zones[i].drivesPerSet
+		zones[i].endpoints, remaining = remaining[:count], remaining[count:]
+	}
+
+	return serverAddr, endpoints, zones, setupType, nil
+}
@@ -26,31 +26,73 @@ import (
 // CreateFile creates a new bitrot encoded file spread over all available disks. CreateFile will create
 // the file at the given volume and path. It will read from src until an io.EOF occurs. The given algorithm will
 // be used to protect the erasure encoded file.
+// Up to globalXLWriteDepth blocks are queued for every disk, so reading and encoding the next blocks overlaps
+// writing the previous ones and fast disks do not wait for slow ones after every block. Every queued block
+// needs its own buffer, the given buffer is used for the first one.
 func (s *ErasureStorage) CreateFile(src io.Reader, volume, path string, buffer []byte, algorithm BitrotAlgorithm, writeQuorum int) (f ErasureFileInfo, err error) {
 	if !algorithm.Available() {
 		return f, errors.Trace(errBitrotHashAlgoInvalid)
 	}
+	writeDepth := globalXLWriteDepth
+	if writeDepth < 1 {
+		writeDepth = 1
+	}
 	f.Checksums = make([][]byte, len(s.disks))
 	hashers := make([]hash.Hash, len(s.disks))
 	for i := range hashers {
 		hashers[i] = algorithm.New()
 	}
-	errChans, errs := make([]chan error, len(s.disks)), make([]error, len(s.disks))
-	for i := range errChans {
-		errChans[i] = make(chan error, 1) // create buffered channel to let finished go-routines die early
+
+	writers := make([]*erasureDiskWriter, len(s.disks))
+	for i := range writers {
+		writers[i] = newErasureDiskWriter(s.disks[i], volume, path, hashers[i], writeDepth)
 	}
+	defer func() {
+		for _, w := range writers {
+			w.Close()
+		}
+	}()
 
-	var blocks [][]byte
+	// Waits until all disks wrote the oldest queued block.
+	errs := make([]error, len(s.disks))
+	var queued []int
+	collect := func() error {
+		for i, w := range writers {
+			errs[i] = <-w.errCh
+		}
+		if err := reduceWriteQuorumErrs(errs, objectOpIgnoredErrs, writeQuorum); err != nil {
+			return err
+		}
+		s.disks = evalDisks(s.disks, errs)
+		f.Size += int64(queued[0])
+		queued = queued[1:]
+		return nil
+	}
+
+	buffers := make([][]byte, writeDepth)
+	buffers[0] = buffer
 	var n = len(buffer)
-	for n == len(buffer) {
-		n, err = io.ReadFull(src, buffer)
+	for block := 0; n == len(buffer); block++ {
+		if len(queued) == writeDepth {
+			if err = collect(); err != nil {
+				return f, err
+			}
+		}
+		buf := buffers[block%writeDepth]
+		if buf == nil {
+			buf = make([]byte, len(buffer), cap(buffer))
+			buffers[block%writeDepth] = buf
+		}
+
+		var blocks [][]byte
+		n, err = io.ReadFull(src, buf)
 		if n == 0 && err == io.EOF {
-			if f.Size != 0 { // don't write empty block if we have written to the disks
+			if block != 0 { // don't write empty block if we have written to the disks
 				break
 			}
 			blocks = make([][]byte, len(s.disks)) // write empty block
 		} else if err == nil || (n > 0 && err == io.ErrUnexpectedEOF) {
-			blocks, err = s.ErasureEncode(buffer[:n])
+			blocks, err = s.ErasureEncode(buf[:n])
 			if err != nil {
 				return f, err
 			}
@@ -58,17 +100,15 @@ func (s *ErasureStorage) CreateFile(src io.Reader, volume, path string, buffer [
 			return f, errors.Trace(err)
 		}
 
-		for i := range errChans { // span workers
-			go erasureAppendFile(s.disks[i], volume, path, hashers[i], blocks[i], errChans[i])
+		for i, w := range writers {
+			w.blockCh <- blocks[i]
 		}
-		for i := range errChans { // what until all workers are finished
-			errs[i] = <-errChans[i]
-		}
-		if err = reduceWriteQuorumErrs(errs, objectOpIgnoredErrs, writeQuorum); err != nil {
+		queued = append(queued, n)
+	}
+	for len(queued) > 0 {
+		if err = collect(); err != nil {
 			return f, err
 		}
-		s.disks = evalDisks(s.disks, errs)
-		f.Size += int64(n)
 	}
 
 	f.Algorithm = algorithm
@@ -81,18 +121,48 @@ func (s *ErasureStorage) CreateFile(src io.Reader, volume, path string, buffer [
 	return f, nil
 }
 
+// erasureDiskWriter appends the blocks queued for a disk in order, sending the write error (or nil) of
+// every block over errCh. Once a block failed all following blocks fail as well.
+type erasureDiskWriter struct {
+	blockCh chan []byte
+	errCh   chan error
+	doneCh  chan struct{}
+}
+
+func newErasureDiskWriter(disk StorageAPI, volume, path string, hash hash.Hash, depth int) *erasureDiskWriter {
+	w := &erasureDiskWriter{
+		blockCh: make(chan []byte, depth),
+		errCh:   make(chan error, depth), // buffered to let the writer finish early
+		doneCh:  make(chan struct{}),
+	}
+	go func() {
+		defer close(w.doneCh)
+		var err error
+		for buf := range w.blockCh {
+			if err == nil {
+				err = erasureAppendFile(disk, volume, path, hash, buf)
+			}
+			w.errCh <- err
+		}
+	}()
+	return w
+}
+
+// Close stops the writer once all queued blocks are written.
+func (w *erasureDiskWriter) Close() {
+	close(w.blockCh)
+	<-w.doneCh
+}
+
 // erasureAppendFile appends the content of buf to the file on the given disk and updates computes
-// the hash of the written data. It sends the write error (or nil) over the error channel.
-func erasureAppendFile(disk StorageAPI, volume, path string, hash hash.Hash, buf []byte, errChan chan<- error) {
+// the hash of the written data. It returns the write error (or nil).
+func erasureAppendFile(disk StorageAPI, volume, path string, hash hash.Hash, buf []byte) error {
 	if disk == OfflineDisk {
-		errChan <- errors.Trace(errDiskNotFound)
-		return
+		return errors.Trace(errDiskNotFound)
 	}
-	err := disk.AppendFile(volume, path, buf)
-	if err != nil {
-		errChan <- err
-		return
+	if err := disk.AppendFile(volume, path, buf); err != nil {
+		return err
 	}
 	hash.Write(buf)
-	errChan <- err
+	return nil
 }
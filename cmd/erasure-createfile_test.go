@@ -117,6 +117,84 @@ func TestErasureCreateFile(t *testing.T) {
 	}
 }
 
+// failAfterDisk fails all appends after the first n.
+type failAfterDisk struct {
+	StorageAPI
+	n int
+}
+
+func (a *failAfterDisk) AppendFile(volume string, path string, buf []byte) error {
+	if a.n == 0 {
+		return errFaultyDisk
+	}
+	a.n--
+	return a.StorageAPI.AppendFile(volume, path, buf)
+}
+
+// Tests writing files with several blocks queued for every disk.
+func TestErasureCreateFileWriteDepth(t *testing.T) {
+	defer func(writeDepth int) { globalXLWriteDepth = writeDepth }(globalXLWriteDepth)
+
+	const dataBlocks, parityBlocks, blockSize = 4, 4, oneMiByte
+	data := make([]byte, 5*oneMiByte+oneMiByte/2)
+	if _, err := io.ReadFull(rand.Reader, data); err != nil {
+		t.Fatalf("failed to generate random test data: %v", err)
+	}
+
+	for _, writeDepth := range []int{1, 2, 4, 8} {
+		globalXLWriteDepth = writeDepth
+
+		setup, err := newErasureTestSetup(dataBlocks, parityBlocks, blockSize)
+		if err != nil {
+			t.Fatalf("Depth %d: failed to create test setup: %v", writeDepth, err)
+		}
+		storage, err := NewErasureStorage(setup.disks, dataBlocks, parityBlocks, blockSize)
+		if err != nil {
+			setup.Remove()
+			t.Fatalf("Depth %d: failed to create ErasureStorage: %v", writeDepth, err)
+		}
+
+		// Disks failing midway are left out of the file.
+		storage.disks[0] = &failAfterDisk{StorageAPI: storage.disks[0], n: 2}
+		storage.disks[5] = badDisk{nil}
+		buffer := make([]byte, blockSize, 2*blockSize)
+		file, err := storage.CreateFile(bytes.NewReader(data), "testbucket", "object", buffer, DefaultBitrotAlgorithm, dataBlocks+1)
+		if err != nil {
+			setup.Remove()
+			t.Fatalf("Depth %d: should pass but failed with: %v", writeDepth, err)
+		}
+		if file.Size != int64(len(data)) {
+			t.Errorf("Depth %d: invalid number of bytes written: got: #%d want #%d", writeDepth, file.Size, len(data))
+		}
+		if file.Checksums[0] != nil || file.Checksums[5] != nil {
+			t.Errorf("Depth %d: expected failed disks to have no checksums", writeDepth)
+		}
+
+		storage, err = NewErasureStorage(setup.disks, dataBlocks, parityBlocks, blockSize)
+		if err != nil {
+			setup.Remove()
+			t.Fatalf("Depth %d: failed to create ErasureStorage: %v", writeDepth, err)
+		}
+		storage.disks[0], storage.disks[5] = OfflineDisk, OfflineDisk
+		var writer bytes.Buffer
+		if _, err = storage.ReadFile(&writer, "testbucket", "object", 0, file.Size, file.Size, file.Checksums, file.Algorithm, blockSize); err != nil {
+			t.Errorf("Depth %d: failed to read file: %v", writeDepth, err)
+		} else if !bytes.Equal(writer.Bytes(), data) {
+			t.Errorf("Depth %d: read data does not match written data", writeDepth)
+		}
+
+		// Writing fails once too many disks failed.
+		for i := 1; i < 4; i++ {
+			storage.disks[i] = &failAfterDisk{StorageAPI: setup.disks[i], n: 3}
+		}
+		storage.disks[0], storage.disks[5] = setup.disks[0], setup.disks[5]
+		if _, err = storage.CreateFile(bytes.NewReader(data), "testbucket", "object2", buffer, DefaultBitrotAlgorithm, dataBlocks+2); err == nil {
+			t.Errorf("Depth %d: should fail but it passed", writeDepth)
+		}
+		setup.Remove()
+	}
+}
+
 // Benchmarks
 
 func benchmarkErasureWrite(data, parity, dataDown, parityDown int, size int64, b *testing.B) {
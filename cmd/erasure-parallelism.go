@@ -0,0 +1,63 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"strconv"
+)
+
+const (
+	// Environment variable setting the number of goroutines erasure
+	// coding a single block.
+	xlEncodeGoroutinesEnv = "MINIO_XL_ENCODE_GOROUTINES"
+
+	// Environment variable setting the number of blocks queued for
+	// every disk while writing.
+	xlWriteDepthEnv = "MINIO_XL_WRITE_DEPTH"
+
+	// Blocks queued for every disk by default, the next block is
+	// read and encoded while the previous one is written.
+	defaultXLWriteDepth = 2
+)
+
+// Parses a positive number of goroutines or blocks.
+func parseXLParallelism(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, errors.New("value must be positive")
+	}
+	return n, nil
+}
+
+// Sets erasure coding and disk write parallelism from the environment.
+func handleXLParallelismEnv() {
+	if value := os.Getenv(xlEncodeGoroutinesEnv); value != "" {
+		n, err := parseXLParallelism(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", xlEncodeGoroutinesEnv)
+		globalXLEncodeGoroutines = n
+	}
+	if value := os.Getenv(xlWriteDepthEnv); value != "" {
+		n, err := parseXLParallelism(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", xlWriteDepthEnv)
+		globalXLWriteDepth = n
+	}
+}
@@ -66,10 +66,11 @@ func (s ErasureStorage) ReadFile(writer io.Writer, volume, path string, offset,
 				blocks[i] = blocks[i][:chunksize]
 			}
 		}
-		err = s.readConcurrent(volume, path, blockOffset, blocks, verifiers, errChans)
+		healRequired, err := s.readConcurrent(volume, path, blockOffset, blocks, verifiers, errChans)
 		if err != nil {
 			return f, errors.Trace(errXLReadQuorum)
 		}
+		f.HealRequired = f.HealRequired || healRequired
 
 		writeLength := blocksize - startOffset
 		if length < writeLength {
@@ -105,8 +106,8 @@ func erasureCountMissingBlocks(blocks [][]byte, limit int) int {
 }
 
 // readConcurrent reads all requested data concurrently from the disks into blocks. It returns an error if
-// too many disks failed while reading.
-func (s *ErasureStorage) readConcurrent(volume, path string, offset int64, blocks [][]byte, verifiers []*BitrotVerifier, errChans []chan error) (err error) {
+// too many disks failed while reading and reports whether blocks were missing or corrupted on any disk.
+func (s *ErasureStorage) readConcurrent(volume, path string, offset int64, blocks [][]byte, verifiers []*BitrotVerifier, errChans []chan error) (healRequired bool, err error) {
 	errs := make([]error, len(s.disks))
 
 	erasureReadBlocksConcurrent(s.disks[:s.dataBlocks], volume, path, offset, blocks[:s.dataBlocks], verifiers[:s.dataBlocks], errs[:s.dataBlocks], errChans[:s.dataBlocks])
@@ -115,7 +116,7 @@ func (s *ErasureStorage) readConcurrent(volume, path string, offset int64, block
 	if mustReconstruct {
 		requiredReads := s.dataBlocks + missingDataBlocks
 		if requiredReads > s.dataBlocks+s.parityBlocks {
-			return errXLReadQuorum
+			return false, errXLReadQuorum
 		}
 		erasureReadBlocksConcurrent(s.disks[s.dataBlocks:requiredReads], volume, path, offset, blocks[s.dataBlocks:requiredReads], verifiers[s.dataBlocks:requiredReads], errs[s.dataBlocks:requiredReads], errChans[s.dataBlocks:requiredReads])
 		if erasureCountMissingBlocks(blocks, requiredReads) > 0 {
@@ -123,14 +124,34 @@ func (s *ErasureStorage) readConcurrent(volume, path string, offset int64, block
 		}
 	}
 	if err = reduceReadQuorumErrs(errs, []error{}, s.dataBlocks); err != nil {
-		return err
+		return false, err
 	}
 	if mustReconstruct {
 		if err = s.ErasureDecodeDataBlocks(blocks); err != nil {
-			return err
+			return false, err
 		}
 	}
-	return nil
+	for _, err = range errs {
+		if isErrHealRequired(err) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isErrHealRequired - returns true if reading a block failed because
+// it is missing or corrupted on the disk, unlike offline or faulty
+// disks healing restores such blocks.
+func isErrHealRequired(err error) bool {
+	switch errors.Cause(err).(type) {
+	case hashMismatchError:
+		return true
+	}
+	switch errors.Cause(err) {
+	case errFileNotFound, errVolumeNotFound, io.EOF, io.ErrUnexpectedEOF:
+		return true
+	}
+	return false
 }
 
 // erasureReadBlocksConcurrent reads all data from each disk to each data block in parallel.
@@ -32,6 +32,9 @@ type ErasureFileInfo struct {
 	Size      int64
 	Algorithm BitrotAlgorithm
 	Checksums [][]byte
+
+	// Set by ReadFile if the file is missing or corrupted on some disks.
+	HealRequired bool
 }
 
 // ErasureStorage represents an array of disks.
@@ -46,7 +49,11 @@ type ErasureStorage struct {
 // the disks.
 func NewErasureStorage(disks []StorageAPI, dataBlocks, parityBlocks int, blockSize int64) (s ErasureStorage, err error) {
 	shardsize := (int(blockSize) + dataBlocks - 1) / dataBlocks
-	erasure, err := reedsolomon.New(dataBlocks, parityBlocks, reedsolomon.WithAutoGoroutines(shardsize))
+	goroutines := reedsolomon.WithAutoGoroutines(shardsize)
+	if globalXLEncodeGoroutines > 0 {
+		goroutines = reedsolomon.WithMaxGoroutines(globalXLEncodeGoroutines)
+	}
+	erasure, err := reedsolomon.New(dataBlocks, parityBlocks, goroutines)
 	if err != nil {
 		return s, errors.Tracef("failed to create erasure coding: %v", err)
 	}
@@ -294,11 +294,20 @@ func eventNotifyForBucketNotifications(eventType, objectName, bucketName string,
 		if eventMatch && ruleMatch {
 			targetLog := globalEventNotifier.GetExternalTarget(qConfig.QueueARN)
 			if targetLog != nil {
-				targetLog.WithFields(logrus.Fields{
+				fields := logrus.Fields{
 					"Key":       path.Join(bucketName, objectName),
 					"EventType": eventType,
 					"Records":   nEvent,
-				}).Info()
+				}
+				if qConfig.Template != "" {
+					payload, err := renderNotificationTemplate(qConfig.Template, eventType, path.Join(bucketName, objectName), nEvent)
+					if err != nil {
+						errorIf(err, "Unable to render notification template for %s.", qConfig.QueueARN)
+						continue
+					}
+					fields[notificationPayloadKey] = payload
+				}
+				targetLog.WithFields(fields).Info()
 			}
 		}
 	}
@@ -332,6 +341,23 @@ func eventNotifyForBucketListeners(eventType, objectName, bucketName string,
 // eventNotify notifies an event to relevant targets based on their
 // bucket configuration (notifications and listeners).
 func eventNotify(event eventData) {
+	// Record uploads in the recency feed of the bucket.
+	if globalRecentObjects != nil && isObjectCreatedEvent(event.Type) {
+		globalRecentObjects.Add(event.Bucket, event.ObjInfo)
+	}
+
+	// Keep the search index of the bucket up to date.
+	if globalObjectIndex != nil {
+		globalObjectIndex.Update(event)
+	}
+
+	// Queue changes of replicated buckets.
+	if globalBucketReplication != nil {
+		if objAPI := newObjectLayerFn(); objAPI != nil {
+			errorIf(globalBucketReplication.Enqueue(objAPI, event), "Unable to queue replication of %s/%s.", event.Bucket, event.ObjInfo.Name)
+		}
+	}
+
 	if globalEventNotifier == nil {
 		return
 	}
@@ -535,17 +561,24 @@ func loadAllBucketNotifications(objAPI ObjectLayer) (map[string]*notificationCon
 		return nil, nil, err
 	}
 
-	nConfigs := make(map[string]*notificationConfig)
-	lConfigs := make(map[string][]listenerConfig)
-
-	// Loads all bucket notifications.
-	for _, bucket := range buckets {
+	// Loads all bucket notifications in parallel.
+	nConfigList := make([]*notificationConfig, len(buckets))
+	lConfigList := make([][]listenerConfig, len(buckets))
+	errs := runBootTasks(bootStageNotifications, len(buckets), bootBucketConcurrency, func(index int) (lErr error) {
 		// Load persistent notification and listener configurations
 		// a given bucket name.
-		nConfigs[bucket.Name], lConfigs[bucket.Name], err = loadNotificationAndListenerConfig(bucket.Name, objAPI)
-		if err != nil {
-			return nil, nil, err
+		nConfigList[index], lConfigList[index], lErr = loadNotificationAndListenerConfig(buckets[index].Name, objAPI)
+		return lErr
+	})
+
+	nConfigs := make(map[string]*notificationConfig)
+	lConfigs := make(map[string][]listenerConfig)
+	for index, bucket := range buckets {
+		if errs[index] != nil {
+			return nil, nil, errs[index]
 		}
+		nConfigs[bucket.Name] = nConfigList[index]
+		lConfigs[bucket.Name] = lConfigList[index]
 	}
 
 	// Success.
@@ -568,6 +601,7 @@ func addQueueTarget(queueTargets map[string]*logrus.Logger,
 	// Using accountID we can now initialize a new AMQP logrus instance.
 	logger, err := newTargetFunc(accountID)
 	if err == nil {
+		instrumentQueueTarget(globalNotifyTargetMetrics, queueARN, logger)
 		queueTargets[queueARN] = logger
 	}
 
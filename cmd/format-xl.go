@@ -237,47 +237,25 @@ func shouldInitXLDisks(errs []error) bool {
 
 // loadFormatXLAll - load all format config from all input disks in parallel.
 func loadFormatXLAll(endpoints EndpointList) ([]*formatXLV2, []error) {
-	// Initialize sync waitgroup.
-	var wg = &sync.WaitGroup{}
+	// Initialize format configs.
+	var formats = make([]*formatXLV2, len(endpoints))
 
-	bootstrapDisks := make([]StorageAPI, len(endpoints))
-	for i, endpoint := range endpoints {
-		disk, err := newStorageAPI(endpoint)
+	// Connect to each disk and load its format in parallel, remote
+	// disks are slow to connect to in large clusters.
+	sErrs := runBootTasks(bootStageLoadFormat, len(endpoints), 0, func(index int) error {
+		disk, err := newStorageAPI(endpoints[index])
 		if err != nil {
-			continue
+			return errDiskNotFound
 		}
-		bootstrapDisks[i] = disk
-	}
-
-	// Initialize list of errors.
-	var sErrs = make([]error, len(bootstrapDisks))
-
-	// Initialize format configs.
-	var formats = make([]*formatXLV2, len(bootstrapDisks))
-
-	// Load format from each disk in parallel
-	for index, disk := range bootstrapDisks {
-		if disk == nil {
-			sErrs[index] = errDiskNotFound
-			continue
+		format, lErr := loadFormatXL(disk)
+		if lErr != nil {
+			// close the internal connection, to avoid fd leaks.
+			disk.Close()
+			return lErr
 		}
-		wg.Add(1)
-		// Launch go-routine per disk.
-		go func(index int, disk StorageAPI) {
-			defer wg.Done()
-			format, lErr := loadFormatXL(disk)
-			if lErr != nil {
-				// close the internal connection, to avoid fd leaks.
-				disk.Close()
-				sErrs[index] = lErr
-				return
-			}
-			formats[index] = format
-		}(index, disk)
-	}
-
-	// Wait for all go-routines to finish.
-	wg.Wait()
+		formats[index] = format
+		return nil
+	})
 
 	// Return all formats and nil
 	return formats, sErrs
@@ -59,6 +59,9 @@ type fsMetaV1 struct {
 	// Metadata map for current object `fs.json`.
 	Meta  map[string]string `json:"meta,omitempty"`
 	Parts []objectPartInfo  `json:"parts,omitempty"`
+	// Bucket of a multipart upload, only set in the `fs.json` of
+	// uploads so that they are removed with their bucket.
+	Bucket string `json:"bucket,omitempty"`
 }
 
 // IsValid - tells if the format is sane by validating the version
@@ -121,6 +124,11 @@ func (m fsMetaV1) ToObjectInfo(bucket, object string, fi os.FileInfo) ObjectInfo
 	// response headers. e.g, X-Minio-* or X-Amz-*.
 	objInfo.UserDefined = cleanMetadata(m.Meta)
 
+	// Parts are only saved for multipart uploads.
+	if len(m.Parts) > 0 {
+		objInfo.Parts = m.Parts
+	}
+
 	// Success..
 	return objInfo
 }
@@ -189,6 +197,9 @@ func (m *fsMetaV1) ReadFrom(lk *lock.LockedFile) (n int64, err error) {
 	// obtain metadata.
 	m.Meta = parseFSMetaMap(fsMetaBuf)
 
+	// obtain parts of multipart uploads.
+	m.Parts = parseXLParts(fsMetaBuf)
+
 	// obtain minio release date.
 	m.Minio.Release = parseFSRelease(fsMetaBuf)
 
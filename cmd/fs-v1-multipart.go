@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -31,6 +32,7 @@ import (
 
 	"github.com/minio/minio/pkg/errors"
 	mioutil "github.com/minio/minio/pkg/ioutil"
+	"github.com/minio/minio/pkg/lock"
 
 	"github.com/minio/minio/pkg/hash"
 )
@@ -119,6 +121,41 @@ func (fs *FSObjects) backgroundAppend(bucket, object, uploadID string) {
 	}
 }
 
+// Removes the file the parts of uploadID are appended to in the background.
+func (fs *FSObjects) removeAppendFile(uploadID string) {
+	fs.appendFileMapMu.Lock()
+	file := fs.appendFileMap[uploadID]
+	delete(fs.appendFileMap, uploadID)
+	fs.appendFileMapMu.Unlock()
+
+	if file != nil {
+		file.Lock()
+		defer file.Unlock()
+		fsRemoveFile(file.filePath)
+	}
+}
+
+// Holds a write lock on the upload, waits for parts being uploaded by
+// this or any other server sharing the fs path. Returns InvalidUploadID
+// if the upload does not exist or was completed or aborted meanwhile.
+func (fs *FSObjects) lockUploadID(bucket, object, uploadID string) (*lock.LockedFile, error) {
+	uploadIDMetaPath := pathJoin(fs.getUploadIDDir(bucket, object, uploadID), fsMetaJSONFile)
+	wlk, err := fs.rwPool.Write(uploadIDMetaPath)
+	if err == nil {
+		// Uploads are removed while locked, check if it still exists.
+		if _, err = fsStatFile(uploadIDMetaPath); err != nil {
+			wlk.Close()
+		}
+	}
+	if err != nil {
+		if errors.Cause(err) == errFileNotFound || errors.Cause(err) == errFileAccessDenied {
+			return nil, errors.Trace(InvalidUploadID{UploadID: uploadID})
+		}
+		return nil, toObjectErr(errors.Trace(err), bucket, object)
+	}
+	return wlk, nil
+}
+
 // ListMultipartUploads - lists all the uploadIDs for the specified object.
 // We do not support prefix based listing.
 func (fs *FSObjects) ListMultipartUploads(bucket, object, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (result ListMultipartsInfo, e error) {
@@ -223,6 +260,7 @@ func (fs *FSObjects) NewMultipartUpload(bucket, object string, meta map[string]s
 	// Initialize fs.json values.
 	fsMeta := newFSMetaV1()
 	fsMeta.Meta = meta
+	fsMeta.Bucket = bucket
 
 	fsMetaBytes, err := json.Marshal(fsMeta)
 	if err != nil {
@@ -262,7 +300,7 @@ func (fs *FSObjects) CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject,
 		return pi, toObjectErr(err, dstBucket, dstObject)
 	}
 
-	partInfo, err := fs.PutObjectPart(dstBucket, dstObject, uploadID, partID, hashReader)
+	partInfo, err := fs.PutObjectPart(context.Background(), dstBucket, dstObject, uploadID, partID, hashReader)
 	if err != nil {
 		return pi, toObjectErr(err, dstBucket, dstObject)
 	}
@@ -277,7 +315,7 @@ func (fs *FSObjects) CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject,
 // an ongoing multipart transaction. Internally incoming data is
 // written to '.minio.sys/tmp' location and safely renamed to
 // '.minio.sys/multipart' for reach parts.
-func (fs *FSObjects) PutObjectPart(bucket, object, uploadID string, partID int, data *hash.Reader) (pi PartInfo, e error) {
+func (fs *FSObjects) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, data *hash.Reader) (pi PartInfo, e error) {
 	if err := checkPutObjectPartArgs(bucket, object, fs); err != nil {
 		return pi, toObjectErr(errors.Trace(err), bucket)
 	}
@@ -293,14 +331,18 @@ func (fs *FSObjects) PutObjectPart(bucket, object, uploadID string, partID int,
 
 	uploadIDDir := fs.getUploadIDDir(bucket, object, uploadID)
 
-	// Just check if the uploadID exists to avoid copy if it doesn't.
-	_, err := fsStatFile(pathJoin(uploadIDDir, fsMetaJSONFile))
+	// Hold a read lock on the upload until the part is saved, servers
+	// sharing the fs path cannot complete or abort it meanwhile. Also
+	// checks if the uploadID exists to avoid copy if it doesn't.
+	uploadIDMetaPath := pathJoin(uploadIDDir, fsMetaJSONFile)
+	_, err := fs.rwPool.Open(uploadIDMetaPath)
 	if err != nil {
 		if errors.Cause(err) == errFileNotFound || errors.Cause(err) == errFileAccessDenied {
 			return pi, errors.Trace(InvalidUploadID{UploadID: uploadID})
 		}
 		return pi, toObjectErr(err, bucket, object)
 	}
+	defer fs.rwPool.Close(uploadIDMetaPath)
 
 	bufSize := int64(readSizeV1)
 	if size := data.Size(); size > 0 && bufSize > size {
@@ -309,7 +351,8 @@ func (fs *FSObjects) PutObjectPart(bucket, object, uploadID string, partID int,
 	buf := make([]byte, bufSize)
 
 	tmpPartPath := pathJoin(fs.fsPath, minioMetaTmpBucket, fs.fsUUID, uploadID+"."+mustGetUUID()+"."+strconv.Itoa(partID))
-	bytesWritten, err := fsCreateFile(tmpPartPath, data, buf, data.Size())
+	// Stop writing the part once the client disconnects.
+	bytesWritten, err := fsCreateFile(tmpPartPath, contextReader{ctx, data}, buf, data.Size())
 	if err != nil {
 		fsRemoveFile(tmpPartPath)
 		return pi, toObjectErr(err, minioMetaTmpBucket, tmpPartPath)
@@ -460,7 +503,7 @@ func (fs *FSObjects) ListObjectParts(bucket, object, uploadID string, partNumber
 // md5sums of all the parts.
 //
 // Implements S3 compatible Complete multipart API.
-func (fs *FSObjects) CompleteMultipartUpload(bucket string, object string, uploadID string, parts []CompletePart) (oi ObjectInfo, e error) {
+func (fs *FSObjects) CompleteMultipartUpload(ctx context.Context, bucket string, object string, uploadID string, parts []CompletePart) (oi ObjectInfo, e error) {
 	if err := checkCompleteMultipartArgs(bucket, object, fs); err != nil {
 		return oi, toObjectErr(err)
 	}
@@ -475,14 +518,11 @@ func (fs *FSObjects) CompleteMultipartUpload(bucket string, object string, uploa
 	}
 
 	uploadIDDir := fs.getUploadIDDir(bucket, object, uploadID)
-	// Just check if the uploadID exists to avoid copy if it doesn't.
-	_, err := fsStatFile(pathJoin(uploadIDDir, fsMetaJSONFile))
+	uploadIDLk, err := fs.lockUploadID(bucket, object, uploadID)
 	if err != nil {
-		if errors.Cause(err) == errFileNotFound || errors.Cause(err) == errFileAccessDenied {
-			return oi, errors.Trace(InvalidUploadID{UploadID: uploadID})
-		}
-		return oi, toObjectErr(err, bucket, object)
+		return oi, err
 	}
+	defer uploadIDLk.Close()
 
 	// Calculate s3 compatible md5sum for complete multipart.
 	s3MD5, err := getCompleteMultipartMD5(parts)
@@ -492,6 +532,9 @@ func (fs *FSObjects) CompleteMultipartUpload(bucket string, object string, uploa
 
 	partSize := int64(-1) // Used later to ensure that all parts sizes are same.
 
+	// Parts of the object saved in `fs.json`.
+	objectParts := make([]objectPartInfo, len(parts))
+
 	// Validate all parts and then commit to disk.
 	for i, part := range parts {
 		partPath := pathJoin(uploadIDDir, fs.encodePartFile(part.PartNumber, part.ETag))
@@ -503,6 +546,12 @@ func (fs *FSObjects) CompleteMultipartUpload(bucket string, object string, uploa
 			}
 			return oi, errors.Trace(err)
 		}
+		objectParts[i] = objectPartInfo{
+			Number: part.PartNumber,
+			Name:   fmt.Sprintf("part.%d", part.PartNumber),
+			ETag:   part.ETag,
+			Size:   fi.Size(),
+		}
 		if partSize == -1 {
 			partSize = fi.Size()
 		}
@@ -546,6 +595,12 @@ func (fs *FSObjects) CompleteMultipartUpload(bucket string, object string, uploa
 	delete(fs.appendFileMap, uploadID)
 	fs.appendFileMapMu.Unlock()
 
+	// Remove the append file unless renamed to the object, the parts
+	// are appended again if the upload is completed later.
+	defer func() {
+		fsRemoveFile(appendFilePath)
+	}()
+
 	if file != nil {
 		file.Lock()
 		defer file.Unlock()
@@ -569,6 +624,9 @@ func (fs *FSObjects) CompleteMultipartUpload(bucket string, object string, uploa
 	if appendFallback {
 		fsRemoveFile(file.filePath)
 		for _, part := range parts {
+			if err = checkContext(ctx); err != nil {
+				return oi, err
+			}
 			partPath := pathJoin(uploadIDDir, fs.encodePartFile(part.PartNumber, part.ETag))
 			err = mioutil.AppendFile(appendFilePath, partPath)
 			if err != nil {
@@ -583,6 +641,18 @@ func (fs *FSObjects) CompleteMultipartUpload(bucket string, object string, uploa
 		return oi, err
 	}
 	defer destLock.Unlock()
+
+	// Keep the upload if the client disconnected while waiting for
+	// the lock, it can be completed again.
+	if err = checkContext(ctx); err != nil {
+		return oi, err
+	}
+
+	// Locked objects cannot be overwritten.
+	if err = fs.checkObjectLocked(bucket, object); err != nil {
+		return oi, err
+	}
+
 	fsMetaPath := pathJoin(fs.fsPath, minioMetaBucket, bucketMetaPrefix, bucket, object, fsMetaJSONFile)
 	metaFile, err := fs.rwPool.Create(fsMetaPath)
 	if err != nil {
@@ -605,6 +675,8 @@ func (fs *FSObjects) CompleteMultipartUpload(bucket string, object string, uploa
 		fsMeta.Meta = make(map[string]string)
 	}
 	fsMeta.Meta["etag"] = s3MD5
+	fsMeta.Parts = objectParts
+	fsMeta.Bucket = ""
 	if _, err = fsMeta.WriteTo(metaFile); err != nil {
 		return oi, toObjectErr(errors.Trace(err), bucket, object)
 	}
@@ -643,19 +715,14 @@ func (fs *FSObjects) AbortMultipartUpload(bucket, object, uploadID string) error
 		return toObjectErr(errors.Trace(err), bucket)
 	}
 
-	fs.appendFileMapMu.Lock()
-	delete(fs.appendFileMap, uploadID)
-	fs.appendFileMapMu.Unlock()
-
 	uploadIDDir := fs.getUploadIDDir(bucket, object, uploadID)
-	// Just check if the uploadID exists to avoid copy if it doesn't.
-	_, err := fsStatFile(pathJoin(uploadIDDir, fsMetaJSONFile))
+	uploadIDLk, err := fs.lockUploadID(bucket, object, uploadID)
+	fs.removeAppendFile(uploadID)
 	if err != nil {
-		if errors.Cause(err) == errFileNotFound || errors.Cause(err) == errFileAccessDenied {
-			return errors.Trace(InvalidUploadID{UploadID: uploadID})
-		}
-		return toObjectErr(errors.Trace(err), bucket, object)
+		return err
 	}
+	defer uploadIDLk.Close()
+
 	// Ignore the error returned as Windows fails to remove directory if a file in it
 	// is Open()ed by the backgroundAppend()
 	fsRemoveAll(uploadIDDir)
@@ -663,6 +730,38 @@ func (fs *FSObjects) AbortMultipartUpload(bucket, object, uploadID string) error
 	return nil
 }
 
+// Removes all multipart uploads of a bucket, uploads are kept in
+// directories named by the hash of their object and record their
+// bucket in their `fs.json`.
+func (fs *FSObjects) removeBucketUploads(bucket string) {
+	multipartDir := pathJoin(fs.fsPath, minioMetaMultipartBucket)
+	entries, err := readDir(multipartDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		uploadIDs, err := readDir(pathJoin(multipartDir, entry))
+		if err != nil {
+			continue
+		}
+		for _, uploadID := range uploadIDs {
+			uploadIDDir := pathJoin(multipartDir, entry, uploadID)
+			fsMetaBuf, err := ioutil.ReadFile(pathJoin(uploadIDDir, fsMetaJSONFile))
+			if err != nil {
+				continue
+			}
+			var fsMeta fsMetaV1
+			if err = json.Unmarshal(fsMetaBuf, &fsMeta); err != nil || fsMeta.Bucket != bucket {
+				continue
+			}
+			fs.removeAppendFile(strings.TrimSuffix(uploadID, slashSeparator))
+			fsRemoveAll(uploadIDDir)
+		}
+		// Removed only once all uploads of the object are gone.
+		fsRemoveDir(pathJoin(multipartDir, entry))
+	}
+}
+
 // Removes multipart uploads if any older than `expiry` duration
 // on all buckets for every `cleanupInterval`, this function is
 // blocking and should be run in a go-routine.
@@ -690,8 +789,20 @@ func (fs *FSObjects) cleanupStaleMultipartUploads(cleanupInterval, expiry time.D
 					if err != nil {
 						continue
 					}
-					if now.Sub(fi.ModTime()) > expiry {
-						fsRemoveAll(pathJoin(fs.fsPath, minioMetaMultipartBucket, entry, uploadID))
+					if now.Sub(fi.ModTime()) <= expiry {
+						continue
+					}
+					// Skip uploads with parts being uploaded or being
+					// completed by this or any other server.
+					uploadIDDir := pathJoin(fs.fsPath, minioMetaMultipartBucket, entry, uploadID)
+					wlk, err := lock.TryLockedOpenFile(pathJoin(uploadIDDir, fsMetaJSONFile), os.O_RDWR, 0)
+					if err == lock.ErrAlreadyLocked {
+						continue
+					}
+					fs.removeAppendFile(strings.TrimSuffix(uploadID, slashSeparator))
+					fsRemoveAll(uploadIDDir)
+					if err == nil {
+						wlk.Close()
 					}
 				}
 			}
@@ -18,12 +18,14 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/lock"
 )
 
 // Tests cleanup multipart uploads for filesystem backend.
@@ -121,7 +123,7 @@ func TestPutObjectPartFaultyDisk(t *testing.T) {
 	sha256sum := ""
 
 	fs.fsPath = filepath.Join(globalTestTmpDir, "minio-"+nextSuffix())
-	_, err = fs.PutObjectPart(bucketName, objectName, uploadID, 1, mustGetHashReader(t, bytes.NewReader(data), dataLen, md5Hex, sha256sum))
+	_, err = fs.PutObjectPart(context.Background(), bucketName, objectName, uploadID, 1, mustGetHashReader(t, bytes.NewReader(data), dataLen, md5Hex, sha256sum))
 	if !isSameType(errors.Cause(err), BucketNotFound{}) {
 		t.Fatal("Unexpected error ", err)
 	}
@@ -152,7 +154,7 @@ func TestCompleteMultipartUploadFaultyDisk(t *testing.T) {
 
 	parts := []CompletePart{{PartNumber: 1, ETag: md5Hex}}
 	fs.fsPath = filepath.Join(globalTestTmpDir, "minio-"+nextSuffix())
-	if _, err := fs.CompleteMultipartUpload(bucketName, objectName, uploadID, parts); err != nil {
+	if _, err := fs.CompleteMultipartUpload(context.Background(), bucketName, objectName, uploadID, parts); err != nil {
 		if !isSameType(errors.Cause(err), BucketNotFound{}) {
 			t.Fatal("Unexpected error ", err)
 		}
@@ -182,13 +184,13 @@ func TestCompleteMultipartUpload(t *testing.T) {
 
 	md5Hex := getMD5Hash(data)
 
-	if _, err := fs.PutObjectPart(bucketName, objectName, uploadID, 1, mustGetHashReader(t, bytes.NewReader(data), 5, md5Hex, "")); err != nil {
+	if _, err := fs.PutObjectPart(context.Background(), bucketName, objectName, uploadID, 1, mustGetHashReader(t, bytes.NewReader(data), 5, md5Hex, "")); err != nil {
 		t.Fatal("Unexpected error ", err)
 	}
 
 	parts := []CompletePart{{PartNumber: 1, ETag: md5Hex}}
 
-	if _, err := fs.CompleteMultipartUpload(bucketName, objectName, uploadID, parts); err != nil {
+	if _, err := fs.CompleteMultipartUpload(context.Background(), bucketName, objectName, uploadID, parts); err != nil {
 		t.Fatal("Unexpected error ", err)
 	}
 }
@@ -216,13 +218,72 @@ func TestAbortMultipartUpload(t *testing.T) {
 
 	md5Hex := getMD5Hash(data)
 
-	if _, err := fs.PutObjectPart(bucketName, objectName, uploadID, 1, mustGetHashReader(t, bytes.NewReader(data), 5, md5Hex, "")); err != nil {
+	if _, err := fs.PutObjectPart(context.Background(), bucketName, objectName, uploadID, 1, mustGetHashReader(t, bytes.NewReader(data), 5, md5Hex, "")); err != nil {
 		t.Fatal("Unexpected error ", err)
 	}
 	time.Sleep(time.Second) // Without Sleep on windows, the fs.AbortMultipartUpload() fails with "The process cannot access the file because it is being used by another process."
+	fs.appendFileMapMu.Lock()
+	file := fs.appendFileMap[uploadID]
+	fs.appendFileMapMu.Unlock()
 	if err := fs.AbortMultipartUpload(bucketName, objectName, uploadID); err != nil {
 		t.Fatal("Unexpected error ", err)
 	}
+
+	// Parts appended in the background are removed too.
+	if file == nil {
+		t.Fatal("Expected parts to be appended in the background")
+	}
+	if _, err := fsStatFile(file.filePath); errors.Cause(err) != errFileNotFound {
+		t.Fatal("Expected append file to be removed, got ", err)
+	}
+}
+
+// TestAbortMultipartUploadLocked - test AbortMultipartUpload waits for
+// parts being uploaded by other servers sharing the disk.
+func TestAbortMultipartUploadLocked(t *testing.T) {
+	disk := filepath.Join(globalTestTmpDir, "minio-"+nextSuffix())
+	defer os.RemoveAll(disk)
+	obj := initFSObjects(disk, t)
+
+	fs := obj.(*FSObjects)
+	bucketName := "bucket"
+	objectName := "object"
+
+	if err := obj.MakeBucketWithLocation(bucketName, ""); err != nil {
+		t.Fatal("Cannot create bucket, err: ", err)
+	}
+
+	uploadID, err := fs.NewMultipartUpload(bucketName, objectName, nil)
+	if err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	// Lock the upload like another server uploading a part.
+	rlk, err := lock.RLockedOpenFile(pathJoin(fs.getUploadIDDir(bucketName, objectName, uploadID), fsMetaJSONFile))
+	if err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fs.AbortMultipartUpload(bucketName, objectName, uploadID)
+	}()
+	select {
+	case err = <-errCh:
+		t.Fatal("Expected abort to wait for the part, got ", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	rlk.Close()
+	if err = <-errCh; err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+	if err = fs.AbortMultipartUpload(bucketName, objectName, uploadID); err == nil {
+		t.Fatal("Expected upload to be aborted")
+	}
+	if _, ok := errors.Cause(err).(InvalidUploadID); !ok {
+		t.Fatal("Unexpected error ", err)
+	}
 }
 
 // TestListMultipartUploadsFaultyDisk - test ListMultipartUploads with faulty disks
@@ -49,6 +49,9 @@ type FSObjects struct {
 	// This value shouldn't be touched, once initialized.
 	fsFormatRlk *lock.RLockedFile // Is a read lock on `format.json`.
 
+	// Is a write lock on the temporary directory of fsUUID.
+	fsTmpLk *lock.LockedFile
+
 	// FS rw pool.
 	rwPool *fsIOPool
 
@@ -72,6 +75,119 @@ type fsAppendFile struct {
 	filePath string     // Absolute path of the file in the temp location.
 }
 
+// Name of the file locked by every server sharing the fs path in its
+// temporary directory for its life time.
+const fsTmpLockFile = "tmp.lock"
+
+// Holds a write lock on the temporary directory of fsUUID, servers
+// sharing the fs path remove it once the lock is not held anymore.
+func lockTmpDirFS(fsPath, fsUUID string) (*lock.LockedFile, error) {
+	return lock.LockedOpenFile(pathJoin(fsPath, minioMetaTmpBucket, fsUUID, fsTmpLockFile), os.O_RDWR|os.O_CREATE, 0666)
+}
+
+// Returns the total size of the files in entryPath and the latest
+// modification time of entryPath or any entry within.
+func fsTmpEntryUsage(entryPath string) (size int64, modTime time.Time, err error) {
+	err = filepath.Walk(entryPath, func(_ string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			size += fi.Size()
+		}
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+		return nil
+	})
+	return size, modTime, err
+}
+
+// Removes temporary directories left behind by servers sharing the fs
+// path which stopped without cleaning up. Directories without a lock
+// file may belong to older servers still running, they are only
+// removed once left unmodified for expiry. Returns the number of
+// directories removed and their size.
+func removeStaleTmpDirsFS(fsPath, fsUUID string, expiry time.Duration) (removed, reclaimed uint64) {
+	metaTmpPath := pathJoin(fsPath, minioMetaTmpBucket)
+	entries, err := readDir(metaTmpPath)
+	if err != nil {
+		errorIf(err, "Unable to read directory %s", metaTmpPath)
+		return 0, 0
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		if !hasSuffix(entry, slashSeparator) || entry == fsUUID+slashSeparator {
+			continue
+		}
+		tmpDir := pathJoin(metaTmpPath, entry)
+		size, modTime, err := fsTmpEntryUsage(tmpDir)
+		if err != nil {
+			continue
+		}
+		lk, err := lock.TryLockedOpenFile(pathJoin(tmpDir, fsTmpLockFile), os.O_RDWR, 0)
+		if err == lock.ErrAlreadyLocked {
+			// Still held by a running server.
+			continue
+		}
+		if err != nil && now.Sub(modTime) < expiry {
+			// Not locked at all and possibly still in use.
+			continue
+		}
+		if err == nil {
+			lk.Close()
+		}
+		if err = fsRemoveAll(tmpDir); err != nil {
+			errorIf(err, "Unable to remove stale temporary directory %s", tmpDir)
+			continue
+		}
+		removed++
+		reclaimed += uint64(size)
+	}
+	return removed, reclaimed
+}
+
+// sweepTmp - removes entries of the temporary directory of this server
+// left unmodified for expiry, and temporary directories of servers
+// which stopped without cleaning up.
+func (fs *FSObjects) sweepTmp(expiry time.Duration) {
+	removed, reclaimed := removeStaleTmpDirsFS(fs.fsPath, fs.fsUUID, expiry)
+
+	// Files appended to in the background are kept for the life
+	// time of their upload, however long it is idle.
+	appendFiles := make(map[string]bool)
+	fs.appendFileMapMu.Lock()
+	for _, file := range fs.appendFileMap {
+		appendFiles[file.filePath] = true
+	}
+	fs.appendFileMapMu.Unlock()
+
+	tmpDir := pathJoin(fs.fsPath, minioMetaTmpBucket, fs.fsUUID)
+	entries, err := readDir(tmpDir)
+	if err != nil {
+		errorIf(err, "Unable to read directory %s", tmpDir)
+		return
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		entryPath := pathJoin(tmpDir, entry)
+		if entry == fsTmpLockFile || appendFiles[entryPath] {
+			continue
+		}
+		size, modTime, err := fsTmpEntryUsage(entryPath)
+		if err != nil || now.Sub(modTime) < expiry {
+			continue
+		}
+		if err = fsRemoveAll(entryPath); err != nil {
+			errorIf(err, "Unable to remove temporary entry %s", entryPath)
+			continue
+		}
+		removed++
+		reclaimed += uint64(size)
+	}
+	globalTmpSweepStats.record(removed, reclaimed)
+}
+
 // Initializes meta volume on all the fs path.
 func initMetaVolumeFS(fsPath, fsUUID string) error {
 	// This happens for the first time, but keep this here since this
@@ -145,6 +261,12 @@ func NewFSObjectLayer(fsPath string) (ObjectLayer, error) {
 		return nil, err
 	}
 
+	tmpLk, err := lockTmpDirFS(fsPath, fsUUID)
+	if err != nil {
+		rlk.Close()
+		return nil, fmt.Errorf("Unable to lock temporary directory, %s", err)
+	}
+
 	// Initialize fs objects.
 	fs := &FSObjects{
 		fsPath: fsPath,
@@ -162,6 +284,7 @@ func NewFSObjectLayer(fsPath string) (ObjectLayer, error) {
 	// shared backend mode for FS, remote servers do not migrate
 	// or cause changes on backend format.
 	fs.fsFormatRlk = rlk
+	fs.fsTmpLk = tmpLk
 
 	// Initialize and load bucket policies.
 	fs.bucketPolicies, err = initBucketPolicies(fs)
@@ -174,6 +297,11 @@ func NewFSObjectLayer(fsPath string) (ObjectLayer, error) {
 		return nil, fmt.Errorf("Unable to initialize event notification. %s", err)
 	}
 
+	// Remove temporary files left behind by crashes, then keep
+	// sweeping for files left by failed uploads.
+	fs.sweepTmp(tmpSweepExpiry)
+	go sweepTmpRoutine(fs.sweepTmp, tmpSweepInterval, tmpSweepExpiry, globalServiceDoneCh)
+
 	go fs.cleanupStaleMultipartUploads(multipartCleanupInterval, multipartExpiry, globalServiceDoneCh)
 
 	// Return successfully initialized object layer.
@@ -183,6 +311,7 @@ func NewFSObjectLayer(fsPath string) (ObjectLayer, error) {
 // Shutdown - should be called when process shuts down.
 func (fs *FSObjects) Shutdown() error {
 	fs.fsFormatRlk.Close()
+	fs.fsTmpLk.Close()
 
 	// Cleanup and delete tmp uuid.
 	return fsRemoveAll(pathJoin(fs.fsPath, minioMetaTmpBucket, fs.fsUUID))
@@ -338,10 +467,7 @@ func (fs *FSObjects) DeleteBucket(bucket string) error {
 	}
 
 	// Cleanup all the previously incomplete multiparts.
-	minioMetaMultipartBucketDir := pathJoin(fs.fsPath, minioMetaMultipartBucket, bucket)
-	if err = fsRemoveAll(minioMetaMultipartBucketDir); err != nil {
-		return toObjectErr(err, bucket)
-	}
+	fs.removeBucketUploads(bucket)
 
 	// Cleanup all the bucket metadata.
 	minioMetadataBucketDir := pathJoin(fs.fsPath, minioMetaBucket, bucketMetaPrefix, bucket)
@@ -399,6 +525,7 @@ func (fs *FSObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject strin
 		// Save objects' metadata in `fs.json`.
 		fsMeta := newFSMetaV1()
 		fsMeta.Meta = srcInfo.UserDefined
+		fsMeta.Parts = srcInfo.Parts
 		if _, err = fsMeta.WriteTo(wlk); err != nil {
 			return oi, toObjectErr(err, srcBucket, srcObject)
 		}
@@ -488,12 +615,18 @@ func (fs *FSObjects) getObject(bucket, object string, offset int64, length int64
 		}
 	}
 
+	fsMetaMap, err := fs.getObjectMetaMap(bucket, object)
+	if err != nil {
+		return err
+	}
+
+	// Objects pending validation cannot be read.
+	if isObjectQuarantined(fsMetaMap) {
+		return errors.Trace(ObjectQuarantined{Bucket: bucket, Object: object})
+	}
+
 	if etag != "" {
-		objEtag, perr := fs.getObjectETag(bucket, object)
-		if perr != nil {
-			return toObjectErr(errors.Trace(perr), bucket, object)
-		}
-		if objEtag != etag {
+		if extractETag(fsMetaMap) != etag {
 			return toObjectErr(errors.Trace(InvalidETag{}), bucket, object)
 		}
 	}
@@ -576,6 +709,14 @@ func (fs *FSObjects) getObjectInfo(bucket, object string) (oi ObjectInfo, e erro
 	return fsMeta.ToObjectInfo(bucket, object, fi), nil
 }
 
+// checkObjectLocked - fails if the existing object is protected from
+// overwrites and deletes, must be called with the object locked.
+func (fs *FSObjects) checkObjectLocked(bucket, object string) error {
+	return checkObjectLockAtRest(bucket, object, func() (ObjectInfo, error) {
+		return fs.getObjectInfo(bucket, object)
+	})
+}
+
 // GetObjectInfo - reads object metadata and replies back ObjectInfo.
 func (fs *FSObjects) GetObjectInfo(bucket, object string) (oi ObjectInfo, e error) {
 	// Lock the object before reading.
@@ -646,6 +787,11 @@ func (fs *FSObjects) putObject(bucket string, object string, data *hash.Reader,
 		return ObjectInfo{}, toObjectErr(err, bucket)
 	}
 
+	// Locked objects cannot be overwritten.
+	if err = fs.checkObjectLocked(bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
+
 	fsMeta := newFSMetaV1()
 	fsMeta.Meta = metadata
 
@@ -774,6 +920,11 @@ func (fs *FSObjects) DeleteObject(bucket, object string) error {
 		return toObjectErr(err, bucket)
 	}
 
+	// Locked objects cannot be deleted.
+	if err := fs.checkObjectLocked(bucket, object); err != nil {
+		return err
+	}
+
 	minioMetaBucketDir := pathJoin(fs.fsPath, minioMetaBucket)
 	fsMetaPath := pathJoin(minioMetaBucketDir, bucketMetaPrefix, bucket, object, fsMetaJSONFile)
 	if bucket != minioMetaBucket {
@@ -802,6 +953,19 @@ func (fs *FSObjects) DeleteObject(bucket, object string) error {
 	return nil
 }
 
+// DeleteObjects - deletes objects of a bucket one after another, returns
+// the error of every object.
+func (fs *FSObjects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	if _, err := fs.statBucketDir(bucket); err != nil {
+		return nil, toObjectErr(err, bucket)
+	}
+	errs := make([]error, len(objects))
+	for i, object := range objects {
+		errs[i] = fs.DeleteObject(bucket, object)
+	}
+	return errs, nil
+}
+
 // Returns function "listDir" of the type listDirFunc.
 // isLeaf - is used by listDir function to check if an entry
 // is a leaf or non-leaf entry.
@@ -820,9 +984,9 @@ func (fs *FSObjects) listDirFactory(isLeaf isLeafFunc) listDirFunc {
 	return listDir
 }
 
-// getObjectETag is a helper function, which returns only the md5sum
-// of the file on the disk.
-func (fs *FSObjects) getObjectETag(bucket, entry string) (string, error) {
+// getObjectMetaMap - returns the metadata saved in `fs.json` of an
+// object, nil if the object has no `fs.json`.
+func (fs *FSObjects) getObjectMetaMap(bucket, entry string) (map[string]string, error) {
 	fsMetaPath := pathJoin(fs.fsPath, minioMetaBucket, bucketMetaPrefix, bucket, entry, fsMetaJSONFile)
 
 	// Read `fs.json` to perhaps contend with
@@ -830,12 +994,12 @@ func (fs *FSObjects) getObjectETag(bucket, entry string) (string, error) {
 	rlk, err := fs.rwPool.Open(fsMetaPath)
 	// Ignore if `fs.json` is not available, this is true for pre-existing data.
 	if err != nil && err != errFileNotFound {
-		return "", toObjectErr(errors.Trace(err), bucket, entry)
+		return nil, toObjectErr(errors.Trace(err), bucket, entry)
 	}
 
 	// If file is not found, we don't need to proceed forward.
 	if err == errFileNotFound {
-		return "", nil
+		return nil, nil
 	}
 
 	// Read from fs metadata only if it exists.
@@ -844,29 +1008,29 @@ func (fs *FSObjects) getObjectETag(bucket, entry string) (string, error) {
 	// Fetch the size of the underlying file.
 	fi, err := rlk.LockedFile.Stat()
 	if err != nil {
-		return "", toObjectErr(errors.Trace(err), bucket, entry)
+		return nil, toObjectErr(errors.Trace(err), bucket, entry)
 	}
 
 	// `fs.json` can be empty due to previously failed
 	// PutObject() transaction, if we arrive at such
 	// a situation we just ignore and continue.
 	if fi.Size() == 0 {
-		return "", nil
+		return nil, nil
 	}
 
 	// Wrap the locked file in a ReadAt() backend section reader to
 	// make sure the underlying offsets don't move.
 	fsMetaBuf, err := ioutil.ReadAll(io.NewSectionReader(rlk.LockedFile, 0, fi.Size()))
 	if err != nil {
-		return "", errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 
 	// Check if FS metadata is valid, if not return error.
 	if !isFSMetaValid(parseFSVersion(fsMetaBuf), parseFSFormat(fsMetaBuf)) {
-		return "", toObjectErr(errors.Trace(errCorruptedFormat), bucket, entry)
+		return nil, toObjectErr(errors.Trace(errCorruptedFormat), bucket, entry)
 	}
 
-	return extractETag(parseFSMetaMap(fsMetaBuf)), nil
+	return parseFSMetaMap(fsMetaBuf), nil
 }
 
 // ListObjects - list all objects at prefix upto maxKeys., optionally delimited by '/'. Maintains the list pool
@@ -937,8 +1101,9 @@ func (fs *FSObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKe
 			}, nil
 		}
 
-		var etag string
-		etag, err = fs.getObjectETag(bucket, entry)
+		// Metadata is listed as well, for content-type.
+		var fsMeta fsMetaV1
+		fsMeta.Meta, err = fs.getObjectMetaMap(bucket, entry)
 		objectLock.RUnlock()
 		if err != nil {
 			return ObjectInfo{}, err
@@ -952,14 +1117,7 @@ func (fs *FSObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKe
 		}
 
 		// Success.
-		return ObjectInfo{
-			Name:    entry,
-			Bucket:  bucket,
-			Size:    fi.Size(),
-			ModTime: fi.ModTime(),
-			IsDir:   fi.IsDir(),
-			ETag:    etag,
-		}, nil
+		return fsMeta.ToObjectInfo(bucket, entry, fi), nil
 	}
 
 	heal := false // true only for xl.ListObjectsHeal()
@@ -1071,6 +1229,19 @@ func (fs *FSObjects) GetBucketPolicy(bucket string) (policy.BucketAccessPolicy,
 	return policy, nil
 }
 
+// statBucketPolicy - stats the policy file of the bucket, returns nil
+// if the bucket has no policy.
+func (fs *FSObjects) statBucketPolicy(bucket string) (os.FileInfo, error) {
+	fi, err := fsStatFile(pathJoin(fs.fsPath, minioMetaBucket, bucketConfigPrefix, bucket, bucketPolicyConfig))
+	if err != nil {
+		if errors.Cause(err) == errFileNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return fi, nil
+}
+
 // DeleteBucketPolicy deletes all policies on bucket
 func (fs *FSObjects) DeleteBucketPolicy(bucket string) error {
 	return persistAndNotifyBucketPolicyChange(bucket, true, emptyBucketPolicy, fs)
@@ -1078,7 +1249,11 @@ func (fs *FSObjects) DeleteBucketPolicy(bucket string) error {
 
 // ListObjectsV2 lists all blobs in bucket filtered by prefix
 func (fs *FSObjects) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, fetchOwner bool, startAfter string) (result ListObjectsV2Info, err error) {
-	loi, err := fs.ListObjects(bucket, prefix, continuationToken, delimiter, maxKeys)
+	marker := continuationToken
+	if marker == "" {
+		marker = startAfter
+	}
+	loi, err := fs.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
 	if err != nil {
 		return result, err
 	}
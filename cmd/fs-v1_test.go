@@ -19,9 +19,11 @@ package cmd
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/minio/minio/pkg/errors"
 )
@@ -157,6 +159,97 @@ func TestFSShutdown(t *testing.T) {
 	}
 }
 
+// TestFSRemoveStaleTmpDirs - tests that servers sharing a disk remove
+// temporary directories of servers which stopped without cleaning up.
+func TestFSRemoveStaleTmpDirs(t *testing.T) {
+	disk := filepath.Join(globalTestTmpDir, "minio-"+nextSuffix())
+	defer os.RemoveAll(disk)
+
+	// A server still running on the disk.
+	running := initFSObjects(disk, t).(*FSObjects)
+	defer running.Shutdown()
+
+	metaTmpPath := pathJoin(disk, minioMetaTmpBucket)
+	staleDir := pathJoin(metaTmpPath, mustGetUUID())
+	unlockedDir := pathJoin(metaTmpPath, mustGetUUID())
+	for _, path := range []string{pathJoin(staleDir, fsTmpLockFile), pathJoin(staleDir, "part.1"), pathJoin(unlockedDir, "part.1")} {
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("data"), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	obj, err := NewFSObjectLayer(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Shutdown()
+
+	if _, err = os.Stat(staleDir); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", staleDir, err)
+	}
+	for _, dir := range []string{unlockedDir, pathJoin(metaTmpPath, running.fsUUID)} {
+		if _, err = os.Stat(dir); err != nil {
+			t.Errorf("Expected %s to be kept, got %v", dir, err)
+		}
+	}
+}
+
+// Tests sweeping expired files from the temporary directories.
+func TestFSSweepTmp(t *testing.T) {
+	disk := filepath.Join(globalTestTmpDir, "minio-"+nextSuffix())
+	defer os.RemoveAll(disk)
+
+	fs := initFSObjects(disk, t).(*FSObjects)
+	defer fs.Shutdown()
+
+	metaTmpPath := pathJoin(disk, minioMetaTmpBucket)
+	tmpDir := pathJoin(metaTmpPath, fs.fsUUID)
+	oldFile := pathJoin(tmpDir, mustGetUUID())
+	newFile := pathJoin(tmpDir, mustGetUUID())
+	appendFile := pathJoin(tmpDir, mustGetUUID())
+	unlockedDir := pathJoin(metaTmpPath, mustGetUUID())
+	old := time.Now().Add(-2 * tmpSweepExpiry)
+	for _, path := range []string{oldFile, newFile, appendFile, pathJoin(unlockedDir, "part.1")} {
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("data"), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if path == newFile {
+			continue
+		}
+		for _, p := range []string{path, filepath.Dir(path)} {
+			if err := os.Chtimes(p, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	fs.appendFileMap["upload"] = &fsAppendFile{filePath: appendFile}
+
+	before := globalTmpSweepStats.toServerTmpSweepStats()
+	fs.sweepTmp(tmpSweepExpiry)
+	after := globalTmpSweepStats.toServerTmpSweepStats()
+
+	for _, path := range []string{oldFile, unlockedDir} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", path, err)
+		}
+	}
+	for _, path := range []string{newFile, appendFile, pathJoin(tmpDir, fsTmpLockFile)} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be kept, got %v", path, err)
+		}
+	}
+	if after.RemovedEntries-before.RemovedEntries != 2 || after.ReclaimedBytes-before.ReclaimedBytes != 8 {
+		t.Errorf("Expected 2 entries of 8 bytes removed, got %d of %d bytes",
+			after.RemovedEntries-before.RemovedEntries, after.ReclaimedBytes-before.ReclaimedBytes)
+	}
+}
+
 // TestFSGetBucketInfo - test GetBucketInfo with healty and faulty disks
 func TestFSGetBucketInfo(t *testing.T) {
 	// Prepare for testing
@@ -0,0 +1,572 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	errors2 "github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+// Files uploaded over FTP and SFTP land directly in buckets: the root
+// directory lists the buckets, directories below are prefixes, files
+// are objects. Uploads fire the same events as S3 uploads. Users log
+// in with the server credentials, or with temporary credentials, the
+// access key as user name and the session token as password, allowed
+// the managed policies granted only.
+
+const (
+	// Environment variables setting the addresses of the FTP and SFTP
+	// servers, both are off unless set, e.g. ":8021".
+	ftpAddressEnv  = "MINIO_FTP_ADDRESS"
+	sftpAddressEnv = "MINIO_SFTP_ADDRESS"
+
+	// Environment variable setting the range of ports of passive FTP
+	// data connections, e.g. "30000-30100". Any free port by default.
+	ftpPassivePortsEnv = "MINIO_FTP_PASSIVE_PORTS"
+
+	// Connections idle for this long are closed.
+	ftpIdleTimeout = 5 * time.Minute
+)
+
+var (
+	errFTPNotFound     = errors.New("no such file or directory")
+	errFTPNotEmpty     = errors.New("directory not empty")
+	errFTPUnsupported  = errors.New("operation not supported")
+	errFTPAccessDenied = errors.New("permission denied")
+)
+
+// Addresses the FTP and SFTP servers listen on, set by the environment.
+var (
+	globalFTPAddr  string
+	globalSFTPAddr string
+
+	// Ports of passive FTP data connections, any free port if zero.
+	globalFTPPassivePortMin int
+	globalFTPPassivePortMax int
+)
+
+// Parses a range of ports, e.g. "30000-30100".
+func parsePortRange(value string) (min, max int, err error) {
+	bounds := strings.SplitN(value, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("port range must be of the form min-max")
+	}
+	if min, err = strconv.Atoi(strings.TrimSpace(bounds[0])); err != nil {
+		return 0, 0, err
+	}
+	if max, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+		return 0, 0, err
+	}
+	if min <= 0 || max > 65535 || min > max {
+		return 0, 0, fmt.Errorf("invalid port range %d-%d", min, max)
+	}
+	return min, max, nil
+}
+
+// Sets the addresses of the FTP and SFTP servers from the environment.
+func handleFTPEnv() {
+	globalFTPAddr = os.Getenv(ftpAddressEnv)
+	globalSFTPAddr = os.Getenv(sftpAddressEnv)
+	for env, addr := range map[string]string{ftpAddressEnv: globalFTPAddr, sftpAddressEnv: globalSFTPAddr} {
+		if addr != "" {
+			_, _, err := net.SplitHostPort(addr)
+			fatalIf(err, "Invalid value set in environment variable %s.", env)
+		}
+	}
+	if value := os.Getenv(ftpPassivePortsEnv); value != "" {
+		var err error
+		globalFTPPassivePortMin, globalFTPPassivePortMax, err = parsePortRange(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", ftpPassivePortsEnv)
+	}
+}
+
+// startFTPServers - starts the FTP and SFTP servers enabled, they stop
+// when the server is stopped.
+func startFTPServers() error {
+	if globalFTPAddr != "" {
+		l, err := net.Listen("tcp", globalFTPAddr)
+		if err != nil {
+			return err
+		}
+		go serveFTP(l, globalServiceDoneCh)
+	}
+	if globalSFTPAddr != "" {
+		hostKey, err := loadSFTPHostKey(getSFTPHostKeyFile())
+		if err != nil {
+			return err
+		}
+		l, err := net.Listen("tcp", globalSFTPAddr)
+		if err != nil {
+			return err
+		}
+		go serveSFTP(l, hostKey, globalServiceDoneCh)
+	}
+	return nil
+}
+
+// ftpUser - user logged in over FTP or SFTP, temporary credentials are
+// allowed the policies granted only.
+type ftpUser struct {
+	accessKey string
+	claims    *sessionClaims // nil for the server credentials.
+}
+
+// ftpLogin - authenticates a user by the server credentials, or by the
+// access key and session token of temporary credentials.
+func ftpLogin(user, password string) (*ftpUser, error) {
+	cred := globalServerConfig.GetCredential()
+	if user == cred.AccessKey {
+		if subtle.ConstantTimeCompare([]byte(password), []byte(cred.SecretKey)) != 1 {
+			return nil, errAuthentication
+		}
+		return &ftpUser{accessKey: user}, nil
+	}
+	claims, errCode := parseSessionToken(password)
+	if errCode != ErrNone || claims.Subject != user {
+		return nil, errAuthentication
+	}
+	return &ftpUser{accessKey: user, claims: claims}, nil
+}
+
+// ftpFileInfo - a bucket, a prefix or an object as a file.
+type ftpFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+func (fi ftpFileInfo) Name() string       { return fi.name }
+func (fi ftpFileInfo) Size() int64        { return fi.size }
+func (fi ftpFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi ftpFileInfo) IsDir() bool        { return fi.isDir }
+func (fi ftpFileInfo) Sys() interface{}   { return nil }
+
+func (fi ftpFileInfo) Mode() os.FileMode {
+	if fi.isDir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// ftpDriver - file operations of a logged in user translated onto the
+// object layer. Paths are absolute, "/bucket/prefix/object".
+type ftpDriver struct {
+	objAPI     ObjectLayer
+	user       *ftpUser
+	remoteAddr string
+	userAgent  string
+}
+
+// Splits a path into a bucket and an object name.
+func splitFTPPath(p string) (bucket, object string) {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if i := strings.Index(p, "/"); i >= 0 {
+		return p[:i], p[i+1:]
+	}
+	return p, ""
+}
+
+// isAllowed - returns whether the user is allowed the action on the
+// object, the bucket if object is empty.
+func (d *ftpDriver) isAllowed(action, bucket, object string) bool {
+	if d.user.claims == nil {
+		return true
+	}
+	host, _, err := net.SplitHostPort(d.remoteAddr)
+	if err != nil {
+		host = d.remoteAddr
+	}
+	resource := bucket
+	if object != "" {
+		resource = bucket + "/" + object
+	}
+	allowed, err := d.user.claims.isAllowed(d.objAPI, action, bucket, resource, getConditionKeyMap("", host, nil))
+	errorIf(err, "Unable to check the policies of %s.", d.user.accessKey)
+	return err == nil && allowed
+}
+
+// notify - fires an event of an object written or deleted.
+func (d *ftpDriver) notify(eventType EventName, bucket string, objInfo ObjectInfo) {
+	host, port, err := net.SplitHostPort(d.remoteAddr)
+	if err != nil {
+		host, port = "", ""
+	}
+	eventNotify(eventData{
+		Type:      eventType,
+		Bucket:    bucket,
+		ObjInfo:   objInfo,
+		ReqParams: map[string]string{"sourceIPAddress": d.remoteAddr},
+		UserAgent: d.userAgent,
+		Host:      host,
+		Port:      port,
+	})
+}
+
+// Returns the size of the content of an object, objects encrypted with
+// SSE-S3 are decrypted.
+func (d *ftpDriver) getObjectSize(objInfo ObjectInfo) int64 {
+	size, err := getWebObjectSize(d.objAPI, objInfo)
+	if err != nil {
+		return objInfo.Size
+	}
+	return size
+}
+
+// Stat - returns the file at a path, a bucket or a prefix is a
+// directory.
+func (d *ftpDriver) Stat(p string) (os.FileInfo, error) {
+	bucket, object := splitFTPPath(p)
+	if bucket == "" {
+		return ftpFileInfo{name: "/", isDir: true}, nil
+	}
+	if !d.isAllowed("s3:ListBucket", bucket, "") {
+		return nil, errFTPAccessDenied
+	}
+	if object == "" {
+		bucketInfo, err := d.objAPI.GetBucketInfo(bucket)
+		if err != nil {
+			return nil, err
+		}
+		return ftpFileInfo{name: bucket, modTime: bucketInfo.Created, isDir: true}, nil
+	}
+	objInfo, err := d.objAPI.GetObjectInfo(bucket, object)
+	if err == nil {
+		return ftpFileInfo{name: path.Base(object), size: d.getObjectSize(objInfo), modTime: objInfo.ModTime}, nil
+	}
+	if !isErrObjectNotFound(err) {
+		return nil, err
+	}
+	result, err := d.objAPI.ListObjects(bucket, object+"/", "", "/", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Objects) == 0 && len(result.Prefixes) == 0 {
+		return nil, errFTPNotFound
+	}
+	return ftpFileInfo{name: path.Base(object), isDir: true}, nil
+}
+
+// ReadDir - returns the files of a directory, the buckets allowed to
+// be listed at the root.
+func (d *ftpDriver) ReadDir(p string) ([]os.FileInfo, error) {
+	bucket, object := splitFTPPath(p)
+	if bucket == "" {
+		buckets, err := d.objAPI.ListBuckets()
+		if err != nil {
+			return nil, err
+		}
+		var files []os.FileInfo
+		for _, bucketInfo := range buckets {
+			if d.isAllowed("s3:ListBucket", bucketInfo.Name, "") {
+				files = append(files, ftpFileInfo{name: bucketInfo.Name, modTime: bucketInfo.Created, isDir: true})
+			}
+		}
+		return files, nil
+	}
+	if !d.isAllowed("s3:ListBucket", bucket, "") {
+		return nil, errFTPAccessDenied
+	}
+
+	prefix := ""
+	if object != "" {
+		prefix = object + "/"
+	}
+	var files []os.FileInfo
+	marker := ""
+	for {
+		result, err := d.objAPI.ListObjects(bucket, prefix, marker, "/", maxObjectList)
+		if err != nil {
+			return nil, err
+		}
+		for _, objInfo := range result.Objects {
+			// Skip the object marking the directory itself.
+			if objInfo.Name == prefix {
+				continue
+			}
+			files = append(files, ftpFileInfo{
+				name:    strings.TrimPrefix(objInfo.Name, prefix),
+				size:    d.getObjectSize(objInfo),
+				modTime: objInfo.ModTime,
+			})
+		}
+		for _, dir := range result.Prefixes {
+			files = append(files, ftpFileInfo{name: strings.TrimSuffix(strings.TrimPrefix(dir, prefix), "/"), isDir: true})
+		}
+		if !result.IsTruncated || result.NextMarker == "" {
+			break
+		}
+		marker = result.NextMarker
+	}
+	if len(files) == 0 && object != "" {
+		// Only existing directories are empty.
+		if _, err := d.Stat(p); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// GetObject - writes length bytes of the object at a path from offset
+// to the writer, objects encrypted with SSE-S3 are decrypted. Objects
+// encrypted with SSE-C are written as they are stored.
+func (d *ftpDriver) GetObject(p string, offset, length int64, writer io.Writer) error {
+	objInfo, err := d.GetObjectInfo(p)
+	if err != nil {
+		return err
+	}
+	return d.getObject(objInfo, offset, length, writer)
+}
+
+// GetObjectInfo - returns the object at a path the user is allowed to
+// read.
+func (d *ftpDriver) GetObjectInfo(p string) (ObjectInfo, error) {
+	bucket, object := splitFTPPath(p)
+	if object == "" {
+		return ObjectInfo{}, errFTPUnsupported
+	}
+	if !d.isAllowed("s3:GetObject", bucket, object) {
+		return ObjectInfo{}, errFTPAccessDenied
+	}
+	return d.objAPI.GetObjectInfo(bucket, object)
+}
+
+// getObject - writes length bytes of an object from offset to the
+// writer, up to the end of the object if length is negative.
+func (d *ftpDriver) getObject(objInfo ObjectInfo, offset, length int64, writer io.Writer) error {
+	size := d.getObjectSize(objInfo)
+	if offset > size {
+		return errors2.Trace(InvalidRange{offset, length, size})
+	}
+	if length < 0 || offset+length > size {
+		length = size - offset
+	}
+	if !d.objAPI.IsEncryptionSupported() || !isSSES3Encrypted(objInfo.UserDefined) {
+		return d.objAPI.GetObject(objInfo.Bucket, objInfo.Name, offset, length, writer, objInfo.ETag)
+	}
+
+	// Decryption removes the sealed key from the metadata.
+	objInfo.UserDefined = copyObjectMetadata(objInfo.UserDefined)
+	r := &http.Request{Header: make(http.Header)}
+	writer, offset, length, err := getObjectWriter(writer, r, d.objAPI, objInfo, offset, length)
+	if err != nil {
+		return err
+	}
+	if err = d.objAPI.GetObject(objInfo.Bucket, objInfo.Name, offset, length, writer, objInfo.ETag); err != nil {
+		return err
+	}
+	if closer, ok := writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// PutObject - uploads size bytes read from the reader to the object at
+// a path, as a PUT request would.
+func (d *ftpDriver) PutObject(p string, reader io.Reader, size int64) error {
+	bucket, object := splitFTPPath(p)
+	if object == "" || hasSuffix(object, slashSeparator) {
+		return errFTPUnsupported
+	}
+	if err := checkServerWritable(); err != nil {
+		return err
+	}
+	if !d.isAllowed("s3:PutObject", bucket, object) {
+		return errFTPAccessDenied
+	}
+	if isMaxObjectSize(size) {
+		return errors2.Trace(ObjectTooLarge{})
+	}
+	if err := checkObjectLock(d.objAPI, bucket, object, nil); err != nil {
+		return err
+	}
+
+	metadata := make(map[string]string)
+	applyMetadataDefaults(bucket, object, metadata)
+	hashReader, err := hash.NewReader(reader, size, "", "")
+	if err != nil {
+		return err
+	}
+	objInfo, err := d.objAPI.PutObject(bucket, object, hashReader, metadata)
+	if err != nil {
+		return err
+	}
+	errorIf(attestObject(d.objAPI, bucket, object, objInfo.ETag), "Unable to attest object %s/%s.", bucket, object)
+	d.notify(ObjectCreatedPut, bucket, objInfo)
+	return nil
+}
+
+// Remove - deletes the object at a path.
+func (d *ftpDriver) Remove(p string) error {
+	bucket, object := splitFTPPath(p)
+	if object == "" {
+		return errFTPUnsupported
+	}
+	if err := checkServerWritable(); err != nil {
+		return err
+	}
+	if !d.isAllowed("s3:DeleteObject", bucket, object) {
+		return errFTPAccessDenied
+	}
+	if _, err := d.objAPI.GetObjectInfo(bucket, object); err != nil {
+		return err
+	}
+	if err := checkObjectLock(d.objAPI, bucket, object, nil); err != nil {
+		return err
+	}
+	if err := d.objAPI.DeleteObject(bucket, object); err != nil {
+		return err
+	}
+	removeObjectAttestation(d.objAPI, bucket, object)
+	d.notify(ObjectRemovedDelete, bucket, ObjectInfo{Name: object})
+	return nil
+}
+
+// Mkdir - creates a bucket at the root, or a directory as an empty
+// object whose name ends with a slash.
+func (d *ftpDriver) Mkdir(p string) error {
+	bucket, object := splitFTPPath(p)
+	if bucket == "" {
+		return errFTPUnsupported
+	}
+	if err := checkServerWritable(); err != nil {
+		return err
+	}
+	if object == "" {
+		// Temporary credentials cannot administer buckets.
+		if d.user.claims != nil {
+			return errFTPAccessDenied
+		}
+		return d.objAPI.MakeBucketWithLocation(bucket, globalServerConfig.GetRegion())
+	}
+	if !d.isAllowed("s3:PutObject", bucket, object+"/") {
+		return errFTPAccessDenied
+	}
+	hashReader, err := hash.NewReader(strings.NewReader(""), 0, "", "")
+	if err != nil {
+		return err
+	}
+	_, err = d.objAPI.PutObject(bucket, object+"/", hashReader, nil)
+	return err
+}
+
+// Rmdir - deletes an empty bucket at the root, or an empty directory.
+func (d *ftpDriver) Rmdir(p string) error {
+	bucket, object := splitFTPPath(p)
+	if bucket == "" {
+		return errFTPUnsupported
+	}
+	if err := checkServerWritable(); err != nil {
+		return err
+	}
+	if object == "" {
+		if d.user.claims != nil {
+			return errFTPAccessDenied
+		}
+		return d.objAPI.DeleteBucket(bucket)
+	}
+	if !d.isAllowed("s3:DeleteObject", bucket, object+"/") {
+		return errFTPAccessDenied
+	}
+	result, err := d.objAPI.ListObjects(bucket, object+"/", "", "/", 2)
+	if err != nil {
+		return err
+	}
+	for _, objInfo := range result.Objects {
+		if objInfo.Name != object+"/" {
+			return errFTPNotEmpty
+		}
+	}
+	if len(result.Prefixes) > 0 {
+		return errFTPNotEmpty
+	}
+	// Empty directories are not listed by every object layer.
+	if len(result.Objects) == 0 {
+		if _, err = d.objAPI.GetObjectInfo(bucket, object+"/"); err != nil {
+			return err
+		}
+	}
+	// Deleted directories are reported as not found by XL.
+	if err = d.objAPI.DeleteObject(bucket, object+"/"); err != nil && !isErrObjectNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Rename - moves an object within its bucket.
+func (d *ftpDriver) Rename(oldPath, newPath string) error {
+	bucket, srcObject := splitFTPPath(oldPath)
+	dstBucket, dstObject := splitFTPPath(newPath)
+	if srcObject == "" || dstObject == "" || bucket != dstBucket {
+		return errFTPUnsupported
+	}
+	if err := checkServerWritable(); err != nil {
+		return err
+	}
+	if !d.isAllowed("s3:GetObject", bucket, srcObject) ||
+		!d.isAllowed("s3:DeleteObject", bucket, srcObject) ||
+		!d.isAllowed("s3:PutObject", bucket, dstObject) {
+		return errFTPAccessDenied
+	}
+	objInfo, err := moveObject(d.objAPI, bucket, srcObject, dstObject)
+	if err != nil {
+		return err
+	}
+	d.notify(ObjectCreatedCopy, bucket, objInfo)
+	d.notify(ObjectRemovedDelete, bucket, ObjectInfo{Name: srcObject})
+	return nil
+}
+
+// isFTPNotFound - returns whether an error of a file operation is due
+// to a missing file.
+func isFTPNotFound(err error) bool {
+	err = errors2.Cause(err)
+	switch err.(type) {
+	case BucketNotFound, ObjectNotFound, BucketNameInvalid, ObjectNameInvalid:
+		return true
+	}
+	return err == errFTPNotFound
+}
+
+// isFTPAccessDenied - returns whether a file operation was denied.
+func isFTPAccessDenied(err error) bool {
+	err = errors2.Cause(err)
+	switch err.(type) {
+	case ObjectLocked, ObjectQuarantined:
+		return true
+	}
+	return err == errFTPAccessDenied || err == errServerReadOnly || err == errServerMaintenance
+}
+
+// isFTPTooLarge - returns whether an uploaded file exceeds the maximum
+// size of an object.
+func isFTPTooLarge(err error) bool {
+	_, ok := errors2.Cause(err).(ObjectTooLarge)
+	return ok
+}
@@ -0,0 +1,163 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Tests parsing ranges of passive FTP ports.
+func TestParsePortRange(t *testing.T) {
+	testCases := []struct {
+		value    string
+		min, max int
+		success  bool
+	}{
+		{"30000-30100", 30000, 30100, true},
+		{"21-21", 21, 21, true},
+		{" 1 - 2 ", 1, 2, true},
+		{"30000", 0, 0, false},
+		{"30100-30000", 0, 0, false},
+		{"0-10", 0, 0, false},
+		{"1-65536", 0, 0, false},
+		{"a-b", 0, 0, false},
+	}
+	for i, testCase := range testCases {
+		min, max, err := parsePortRange(testCase.value)
+		if (err == nil) != testCase.success {
+			t.Errorf("Test %d: Expected success %v, got %v", i+1, testCase.success, err)
+			continue
+		}
+		if min != testCase.min || max != testCase.max {
+			t.Errorf("Test %d: Expected %d-%d, got %d-%d", i+1, testCase.min, testCase.max, min, max)
+		}
+	}
+}
+
+// Tests logging in with the server credentials.
+func TestFTPLogin(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	cred := globalServerConfig.GetCredential()
+	user, err := ftpLogin(cred.AccessKey, cred.SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.accessKey != cred.AccessKey || user.claims != nil {
+		t.Fatalf("Unexpected user %+v", user)
+	}
+	if _, err = ftpLogin(cred.AccessKey, "wrong-password"); err != errAuthentication {
+		t.Fatalf("Expected %v, got %v", errAuthentication, err)
+	}
+	if _, err = ftpLogin("unknown", "not-a-session-token"); err != errAuthentication {
+		t.Fatalf("Expected %v, got %v", errAuthentication, err)
+	}
+}
+
+// Wrapper for calling FTP driver tests for both XL multiple disks and single node setup.
+func TestFTPDriver(t *testing.T) {
+	ExecObjectLayerTest(t, testFTPDriver)
+}
+
+// Tests file operations translated onto the object layer.
+func testFTPDriver(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	d := &ftpDriver{
+		objAPI:     obj,
+		user:       &ftpUser{accessKey: globalServerConfig.GetCredential().AccessKey},
+		remoteAddr: "127.0.0.1:50000",
+		userAgent:  "FTP",
+	}
+
+	if err := d.Mkdir("/bucket"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err := d.Mkdir("/bucket/docs"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	data := "hello, world"
+	if err := d.PutObject("/bucket/docs/hello.txt", strings.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	files, err := d.ReadDir("/")
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(files) != 1 || files[0].Name() != "bucket" || !files[0].IsDir() {
+		t.Fatalf("%s: Unexpected buckets %v", instanceType, files)
+	}
+	files, err = d.ReadDir("/bucket/docs")
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(files) != 1 || files[0].Name() != "hello.txt" || files[0].Size() != int64(len(data)) {
+		t.Fatalf("%s: Unexpected files %v", instanceType, files)
+	}
+	fi, err := d.Stat("/bucket/docs")
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !fi.IsDir() {
+		t.Fatalf("%s: Expected a directory", instanceType)
+	}
+	if _, err = d.Stat("/bucket/missing"); !isFTPNotFound(err) {
+		t.Fatalf("%s: Expected not found, got %v", instanceType, err)
+	}
+
+	var buf bytes.Buffer
+	if err = d.GetObject("/bucket/docs/hello.txt", 7, -1, &buf); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if buf.String() != "world" {
+		t.Fatalf("%s: Expected %q, got %q", instanceType, "world", buf.String())
+	}
+
+	if err = d.Rmdir("/bucket/docs"); err != errFTPNotEmpty {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, errFTPNotEmpty, err)
+	}
+	if err = d.Rename("/bucket/docs/hello.txt", "/bucket/hello.txt"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = d.Stat("/bucket/hello.txt"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = d.Rename("/bucket/hello.txt", "/other/hello.txt"); err != errFTPUnsupported {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, errFTPUnsupported, err)
+	}
+	if err = d.Mkdir("/bucket/empty"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = d.Rmdir("/bucket/empty"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = d.Remove("/bucket/hello.txt"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = d.Remove("/bucket/hello.txt"); !isFTPNotFound(err) {
+		t.Fatalf("%s: Expected not found, got %v", instanceType, err)
+	}
+	if err = d.Rmdir("/bucket"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+}
@@ -0,0 +1,607 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FTP server, RFC 959, with the extensions of RFC 2389, RFC 2428 and
+// RFC 3659 FTP clients expect, and with explicit TLS of RFC 4217 if
+// the server has a certificate. Only passive data connections are
+// supported. Passwords are not accepted in clear text once TLS is
+// available, the server credentials never.
+
+const (
+	// Time a passive data connection is waited for.
+	ftpDataConnTimeout = 30 * time.Second
+
+	// Maximum length of a command line.
+	maxFTPCommandSize = 4096
+)
+
+// serveFTP - serves FTP connections accepted by the listener until
+// doneCh is closed.
+func serveFTP(l net.Listener, doneCh chan struct{}) {
+	tlsConfig := getFTPTLSConfig()
+	go func() {
+		<-doneCh
+		l.Close()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-doneCh:
+			default:
+				errorIf(err, "Unable to accept FTP connections.")
+			}
+			return
+		}
+		ftpConn := newFTPConn(conn)
+		ftpConn.tlsConfig = tlsConfig
+		go ftpConn.serve()
+	}
+}
+
+// getFTPTLSConfig - returns the TLS config of AUTH TLS with the
+// certificate of the server, nil if the server has none.
+func getFTPTLSConfig() *tls.Config {
+	if !globalIsSSL {
+		return nil
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch {
+	case globalCertsAuto != nil:
+		tlsConfig.GetCertificate = globalCertsAuto.GetCertificate
+	case globalTLSCertificate != nil:
+		tlsConfig.Certificates = []tls.Certificate{*globalTLSCertificate}
+	default:
+		return nil
+	}
+	return tlsConfig
+}
+
+// ftpConn - control connection of an FTP client.
+type ftpConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	tlsConfig *tls.Config // Nil if AUTH TLS is not available.
+	secure    bool        // Set once the control connection uses TLS.
+	protData  bool        // Set by PROT P, data connections use TLS.
+
+	user   string     // Sent with USER.
+	driver *ftpDriver // Set once logged in.
+	cwd    string
+
+	pasv       net.Listener // Passive data connection listener.
+	restOffset int64        // Offset of the next RETR set by REST.
+	renameFrom string       // Path of the next RNTO set by RNFR.
+}
+
+func newFTPConn(conn net.Conn) *ftpConn {
+	return &ftpConn{
+		conn:   conn,
+		reader: bufio.NewReaderSize(conn, maxFTPCommandSize),
+		cwd:    "/",
+	}
+}
+
+func (c *ftpConn) reply(code int, message string) error {
+	_, err := fmt.Fprintf(c.conn, "%d %s\r\n", code, message)
+	return err
+}
+
+// replyError - replies with the reply code of an error of a file
+// operation.
+func (c *ftpConn) replyError(err error) error {
+	switch {
+	case isFTPNotFound(err):
+		return c.reply(550, "No such file or directory.")
+	case isFTPAccessDenied(err):
+		return c.reply(550, "Permission denied.")
+	case isFTPTooLarge(err):
+		return c.reply(552, "File exceeds the maximum object size.")
+	case err == errFTPUnsupported || err == errFTPNotEmpty:
+		return c.reply(550, strings.ToUpper(err.Error()[:1])+err.Error()[1:]+".")
+	}
+	errorIf(err, "Unable to serve FTP request of %s.", c.conn.RemoteAddr())
+	return c.reply(451, "Requested action aborted, local error in processing.")
+}
+
+// resolve - returns the absolute path of a path relative to the
+// current directory.
+func (c *ftpConn) resolve(p string) string {
+	if strings.HasPrefix(p, "/") {
+		return path.Clean(p)
+	}
+	return path.Join(c.cwd, p)
+}
+
+func (c *ftpConn) close() {
+	if c.pasv != nil {
+		c.pasv.Close()
+	}
+	c.conn.Close()
+}
+
+// serve - reads and answers commands until the client quits.
+func (c *ftpConn) serve() {
+	defer c.close()
+
+	if c.reply(220, "Minio FTP server ready.") != nil {
+		return
+	}
+	for {
+		c.conn.SetDeadline(UTCNow().Add(ftpIdleTimeout))
+		line, isPrefix, err := c.reader.ReadLine()
+		if err != nil {
+			return
+		}
+		if isPrefix {
+			c.reply(500, "Command line too long.")
+			return
+		}
+		command, arg := string(line), ""
+		if i := strings.IndexByte(command, ' '); i >= 0 {
+			command, arg = command[:i], command[i+1:]
+		}
+		quit, err := c.handle(strings.ToUpper(command), arg)
+		if quit || err != nil {
+			return
+		}
+	}
+}
+
+// handle - answers a command, returns true once the client quits.
+func (c *ftpConn) handle(command, arg string) (bool, error) {
+	switch command {
+	case "QUIT":
+		c.reply(221, "Goodbye.")
+		return true, nil
+	case "NOOP":
+		return false, c.reply(200, "OK.")
+	case "SYST":
+		return false, c.reply(215, "UNIX Type: L8")
+	case "FEAT":
+		features := " EPSV\r\n MDTM\r\n MLST type*;size*;modify*;\r\n PASV\r\n REST STREAM\r\n SIZE\r\n UTF8\r\n"
+		if c.tlsConfig != nil {
+			features = " AUTH TLS\r\n PBSZ\r\n PROT\r\n" + features
+		}
+		_, err := fmt.Fprint(c.conn, "211-Features:\r\n"+features+"211 End\r\n")
+		return false, err
+	case "OPTS":
+		if strings.EqualFold(arg, "UTF8 ON") {
+			return false, c.reply(200, "UTF8 mode enabled.")
+		}
+		return false, c.reply(501, "Option not understood.")
+	case "USER":
+		c.user, c.driver = arg, nil
+		return false, c.reply(331, "Password required.")
+	case "PASS":
+		return false, c.login(arg)
+	case "AUTH":
+		return false, c.authTLS(arg)
+	case "PBSZ":
+		if !c.secure {
+			return false, c.reply(503, "Send AUTH TLS first.")
+		}
+		return false, c.reply(200, "PBSZ=0")
+	case "PROT":
+		if !c.secure {
+			return false, c.reply(503, "Send AUTH TLS first.")
+		}
+		switch strings.ToUpper(arg) {
+		case "P":
+			c.protData = true
+		case "C":
+			c.protData = false
+		default:
+			return false, c.reply(504, "Only PROT P and PROT C are supported.")
+		}
+		return false, c.reply(200, "Protection level set.")
+	case "PORT", "EPRT":
+		return false, c.reply(502, "Command not implemented, use PASV or EPSV.")
+	}
+
+	if c.driver == nil {
+		return false, c.reply(530, "Please login with USER and PASS.")
+	}
+
+	switch command {
+	case "PWD", "XPWD":
+		return false, c.reply(257, strconv.Quote(c.cwd)+" is the current directory.")
+	case "CWD", "XCWD":
+		return false, c.changeDir(c.resolve(arg))
+	case "CDUP", "XCUP":
+		return false, c.changeDir(path.Dir(c.cwd))
+	case "TYPE":
+		return false, c.reply(200, "Type set.")
+	case "MODE":
+		if strings.ToUpper(arg) != "S" {
+			return false, c.reply(504, "Only stream mode is supported.")
+		}
+		return false, c.reply(200, "Mode set to S.")
+	case "STRU":
+		if strings.ToUpper(arg) != "F" {
+			return false, c.reply(504, "Only file structure is supported.")
+		}
+		return false, c.reply(200, "Structure set to F.")
+	case "PASV", "EPSV":
+		return false, c.passive(command == "EPSV")
+	case "LIST", "NLST", "MLSD":
+		return false, c.list(command, arg)
+	case "MLST":
+		return false, c.mlst(c.resolve(arg))
+	case "SIZE", "MDTM":
+		return false, c.stat(command, c.resolve(arg))
+	case "REST":
+		offset, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || offset < 0 {
+			return false, c.reply(501, "Invalid offset.")
+		}
+		c.restOffset = offset
+		return false, c.reply(350, "Restarting at "+arg+".")
+	case "RETR":
+		return false, c.retrieve(c.resolve(arg))
+	case "STOR":
+		return false, c.store(c.resolve(arg))
+	case "DELE":
+		if err := c.driver.Remove(c.resolve(arg)); err != nil {
+			return false, c.replyError(err)
+		}
+		return false, c.reply(250, "File deleted.")
+	case "MKD", "XMKD":
+		p := c.resolve(arg)
+		if err := c.driver.Mkdir(p); err != nil {
+			return false, c.replyError(err)
+		}
+		return false, c.reply(257, strconv.Quote(p)+" created.")
+	case "RMD", "XRMD":
+		if err := c.driver.Rmdir(c.resolve(arg)); err != nil {
+			return false, c.replyError(err)
+		}
+		return false, c.reply(250, "Directory removed.")
+	case "RNFR":
+		p := c.resolve(arg)
+		if _, err := c.driver.Stat(p); err != nil {
+			return false, c.replyError(err)
+		}
+		c.renameFrom = p
+		return false, c.reply(350, "Ready for RNTO.")
+	case "RNTO":
+		from := c.renameFrom
+		c.renameFrom = ""
+		if from == "" {
+			return false, c.reply(503, "Send RNFR first.")
+		}
+		if err := c.driver.Rename(from, c.resolve(arg)); err != nil {
+			return false, c.replyError(err)
+		}
+		return false, c.reply(250, "File renamed.")
+	case "ABOR":
+		return false, c.reply(226, "No transfer to abort.")
+	}
+	return false, c.reply(502, "Command not implemented.")
+}
+
+// authTLS - upgrades the control connection to TLS, the user has to
+// log in again.
+func (c *ftpConn) authTLS(mechanism string) error {
+	if c.tlsConfig == nil {
+		return c.reply(502, "TLS is not available, the server has no certificate.")
+	}
+	if c.secure {
+		return c.reply(503, "Already using TLS.")
+	}
+	switch strings.ToUpper(mechanism) {
+	case "TLS", "TLS-C", "SSL":
+	default:
+		return c.reply(504, "Only AUTH TLS is supported.")
+	}
+	if err := c.reply(234, "AUTH TLS successful."); err != nil {
+		return err
+	}
+	conn := tls.Server(c.conn, c.tlsConfig)
+	if err := conn.Handshake(); err != nil {
+		return err
+	}
+	c.conn = conn
+	c.reader = bufio.NewReaderSize(conn, maxFTPCommandSize)
+	c.secure = true
+	c.user, c.driver = "", nil
+	return nil
+}
+
+// login - authenticates the user sent with USER by the password.
+// Passwords are refused on plain control connections if TLS is
+// available, and for the server credentials in any case.
+func (c *ftpConn) login(password string) error {
+	if c.user == "" {
+		return c.reply(503, "Send USER first.")
+	}
+	if !c.secure && (c.tlsConfig != nil || c.user == globalServerConfig.GetCredential().AccessKey) {
+		return c.reply(530, "Login requires TLS, send AUTH TLS first.")
+	}
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return c.reply(421, "Server not initialized, please try again.")
+	}
+	user, err := ftpLogin(c.user, password)
+	if err != nil {
+		return c.reply(530, "Login incorrect.")
+	}
+	c.driver = &ftpDriver{
+		objAPI:     objAPI,
+		user:       user,
+		remoteAddr: c.conn.RemoteAddr().String(),
+		userAgent:  "FTP",
+	}
+	c.cwd = "/"
+	return c.reply(230, "Login successful.")
+}
+
+func (c *ftpConn) changeDir(p string) error {
+	fi, err := c.driver.Stat(p)
+	if err != nil {
+		return c.replyError(err)
+	}
+	if !fi.IsDir() {
+		return c.reply(550, "Not a directory.")
+	}
+	c.cwd = p
+	return c.reply(250, "Directory changed to "+p+".")
+}
+
+// listenPassive - listens for a data connection on the address of the
+// control connection, on a port of the passive port range if set.
+func (c *ftpConn) listenPassive() (net.Listener, error) {
+	host, _, err := net.SplitHostPort(c.conn.LocalAddr().String())
+	if err != nil {
+		return nil, err
+	}
+	if globalFTPPassivePortMin == 0 {
+		return net.Listen("tcp", net.JoinHostPort(host, "0"))
+	}
+	ports := globalFTPPassivePortMax - globalFTPPassivePortMin + 1
+	start := rand.Intn(ports)
+	for i := 0; i < ports; i++ {
+		port := globalFTPPassivePortMin + (start+i)%ports
+		if l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port))); err == nil {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("no free passive port in %d-%d", globalFTPPassivePortMin, globalFTPPassivePortMax)
+}
+
+// passive - opens a passive data connection listener, replies with its
+// port, and with its IPv4 address unless extended.
+func (c *ftpConn) passive(extended bool) error {
+	if c.pasv != nil {
+		c.pasv.Close()
+		c.pasv = nil
+	}
+	l, err := c.listenPassive()
+	if err != nil {
+		errorIf(err, "Unable to listen for FTP data connections.")
+		return c.reply(425, "Cannot open data connection.")
+	}
+	addr := l.Addr().(*net.TCPAddr)
+	if extended {
+		c.pasv = l
+		return c.reply(229, fmt.Sprintf("Entering Extended Passive Mode (|||%d|)", addr.Port))
+	}
+	ip := addr.IP.To4()
+	if ip == nil {
+		l.Close()
+		return c.reply(522, "Use EPSV for IPv6 connections.")
+	}
+	c.pasv = l
+	return c.reply(227, fmt.Sprintf("Entering Passive Mode (%d,%d,%d,%d,%d,%d)", ip[0], ip[1], ip[2], ip[3], addr.Port>>8, addr.Port&0xff))
+}
+
+// openData - accepts the data connection of a transfer, replies with an
+// error if the client did not enter passive mode.
+func (c *ftpConn) openData() (net.Conn, error) {
+	if c.pasv == nil {
+		return nil, c.reply(425, "Use PASV or EPSV first.")
+	}
+	l := c.pasv
+	c.pasv = nil
+	defer l.Close()
+
+	if tl, ok := l.(*net.TCPListener); ok {
+		tl.SetDeadline(UTCNow().Add(ftpDataConnTimeout))
+	}
+	conn, err := l.Accept()
+	if err != nil {
+		return nil, c.reply(425, "Cannot open data connection.")
+	}
+	conn.SetDeadline(UTCNow().Add(ftpIdleTimeout))
+	if c.protData {
+		conn = tls.Server(conn, c.tlsConfig)
+	}
+	return conn, nil
+}
+
+// transfer - sends a file or a listing on a data connection.
+func (c *ftpConn) transfer(send func(w io.Writer) error) error {
+	data, err := c.openData()
+	if data == nil {
+		return err
+	}
+	if err = c.reply(150, "Opening data connection."); err != nil {
+		data.Close()
+		return err
+	}
+	w := bufio.NewWriter(data)
+	err = send(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	data.Close()
+	if err != nil {
+		return c.replyError(err)
+	}
+	return c.reply(226, "Transfer complete.")
+}
+
+// Formats a file as a line of `ls -l`, as listed by FTP and SFTP.
+func formatFTPListLine(fi os.FileInfo) string {
+	modTime := fi.ModTime()
+	timeFormat := "Jan _2 15:04"
+	if UTCNow().Sub(modTime) > 180*24*time.Hour {
+		timeFormat = "Jan _2  2006"
+	}
+	return fmt.Sprintf("%s 1 minio minio %12d %s %s", fi.Mode(), fi.Size(), modTime.Format(timeFormat), fi.Name())
+}
+
+// Formats the facts of a file as listed by MLSD and MLST.
+func formatFTPFacts(fi os.FileInfo) string {
+	fileType := "file"
+	if fi.IsDir() {
+		fileType = "dir"
+	}
+	return fmt.Sprintf("type=%s;size=%d;modify=%s; %s", fileType, fi.Size(), fi.ModTime().UTC().Format("20060102150405"), fi.Name())
+}
+
+// list - sends the files of a directory, or a file, for LIST, NLST and
+// MLSD. Options of ls sent by clients are ignored.
+func (c *ftpConn) list(command, arg string) error {
+	var args []string
+	for _, field := range strings.Fields(arg) {
+		if !strings.HasPrefix(field, "-") {
+			args = append(args, field)
+		}
+	}
+	p := c.cwd
+	if len(args) > 0 {
+		p = c.resolve(strings.Join(args, " "))
+	}
+	fi, err := c.driver.Stat(p)
+	if err != nil {
+		return c.replyError(err)
+	}
+	files := []os.FileInfo{fi}
+	if fi.IsDir() {
+		if files, err = c.driver.ReadDir(p); err != nil {
+			return c.replyError(err)
+		}
+	} else if command == "MLSD" {
+		return c.reply(501, "Not a directory.")
+	}
+	return c.transfer(func(w io.Writer) error {
+		for _, fi := range files {
+			var line string
+			switch command {
+			case "LIST":
+				line = formatFTPListLine(fi)
+			case "NLST":
+				line = fi.Name()
+			case "MLSD":
+				line = formatFTPFacts(fi)
+			}
+			if _, err := io.WriteString(w, line+"\r\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// mlst - replies with the facts of a file on the control connection.
+func (c *ftpConn) mlst(p string) error {
+	fi, err := c.driver.Stat(p)
+	if err != nil {
+		return c.replyError(err)
+	}
+	_, err = fmt.Fprintf(c.conn, "250-Listing %s\r\n %s\r\n250 End\r\n", p, formatFTPFacts(fi))
+	return err
+}
+
+// stat - replies with the size or the modification time of a file.
+func (c *ftpConn) stat(command, p string) error {
+	fi, err := c.driver.Stat(p)
+	if err != nil {
+		return c.replyError(err)
+	}
+	if fi.IsDir() {
+		return c.reply(550, "Not a file.")
+	}
+	if command == "SIZE" {
+		return c.reply(213, strconv.FormatInt(fi.Size(), 10))
+	}
+	return c.reply(213, fi.ModTime().UTC().Format("20060102150405"))
+}
+
+// retrieve - sends a file from the offset set by REST.
+func (c *ftpConn) retrieve(p string) error {
+	offset := c.restOffset
+	c.restOffset = 0
+	objInfo, err := c.driver.GetObjectInfo(p)
+	if err != nil {
+		return c.replyError(err)
+	}
+	return c.transfer(func(w io.Writer) error {
+		return c.driver.getObject(objInfo, offset, -1, w)
+	})
+}
+
+// store - receives a file, spooled to a temporary file since the size
+// of an object must be known before it is uploaded.
+func (c *ftpConn) store(p string) error {
+	c.restOffset = 0
+	data, err := c.openData()
+	if data == nil {
+		return err
+	}
+	defer data.Close()
+	if err = c.reply(150, "Ready to receive data."); err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile("", "minio-ftp-")
+	if err != nil {
+		return c.replyError(err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	size, err := io.Copy(tmpFile, io.LimitReader(data, globalMaxObjectSize+1))
+	if err != nil {
+		return c.reply(426, "Connection closed, transfer aborted.")
+	}
+	if _, err = tmpFile.Seek(0, io.SeekStart); err != nil {
+		return c.replyError(err)
+	}
+	if err = c.driver.PutObject(p, tmpFile, size); err != nil {
+		return c.replyError(err)
+	}
+	return c.reply(226, "Transfer complete.")
+}
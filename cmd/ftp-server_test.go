@@ -0,0 +1,261 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ftpTestClient - minimal FTP client of the tests.
+type ftpTestClient struct {
+	t *testing.T
+	*textproto.Conn
+	tlsConfig *tls.Config // Set once data connections use TLS.
+}
+
+// cmd - sends a command, returns the message of the expected reply.
+func (c *ftpTestClient) cmd(expectCode int, format string, args ...interface{}) string {
+	id, err := c.Cmd(format, args...)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.StartResponse(id)
+	defer c.EndResponse(id)
+	_, message, err := c.ReadResponse(expectCode)
+	if err != nil {
+		c.t.Fatalf("%s: %v", format, err)
+	}
+	return message
+}
+
+// data - opens a passive data connection.
+func (c *ftpTestClient) data() net.Conn {
+	message := c.cmd(229, "EPSV")
+	port := strings.TrimSuffix(message[strings.Index(message, "|||")+3:], "|)")
+	conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", port))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if c.tlsConfig != nil {
+		return tls.Client(conn, c.tlsConfig)
+	}
+	return conn
+}
+
+// Starts an FTP server on the loopback interface, returns a client
+// connected to it and its control connection.
+func startFTPTestServer(t *testing.T, doneCh chan struct{}) (*ftpTestClient, net.Conn) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go serveFTP(l, doneCh)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &ftpTestClient{t: t, Conn: textproto.NewConn(conn)}
+	if _, _, err = c.ReadResponse(220); err != nil {
+		t.Fatal(err)
+	}
+	return c, conn
+}
+
+// transfer - sends a command transferring data, returns the data
+// received.
+func (c *ftpTestClient) transfer(upload string, format string, args ...interface{}) string {
+	conn := c.data()
+	c.cmd(150, format, args...)
+	var received []byte
+	var err error
+	if upload != "" {
+		_, err = io.WriteString(conn, upload)
+	} else {
+		received, err = ioutil.ReadAll(conn)
+	}
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	conn.Close()
+	if _, _, err = c.ReadResponse(226); err != nil {
+		c.t.Fatal(err)
+	}
+	return string(received)
+}
+
+// Tests serving a bucket over FTP.
+func TestFTPServer(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	obj, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer resetGlobalObjectAPI()
+
+	certPEM, keyPEM, err := generateTLSCertKey("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalIsSSL, globalTLSCertificate = true, &cert
+	defer func() {
+		globalIsSSL, globalTLSCertificate = false, nil
+	}()
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	c, conn := startFTPTestServer(t, doneCh)
+	defer c.Close()
+
+	// Passwords are only accepted over TLS.
+	cred := globalServerConfig.GetCredential()
+	c.cmd(530, "PWD")
+	c.cmd(331, "USER %s", cred.AccessKey)
+	c.cmd(530, "PASS %s", cred.SecretKey)
+	c.cmd(503, "PROT P")
+	c.cmd(234, "AUTH TLS")
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	c.Conn = textproto.NewConn(tls.Client(conn, tlsConfig))
+	c.cmd(200, "PBSZ 0")
+	c.cmd(200, "PROT P")
+	c.tlsConfig = tlsConfig
+
+	c.cmd(331, "USER %s", cred.AccessKey)
+	c.cmd(530, "PASS wrong-password")
+	c.cmd(331, "USER %s", cred.AccessKey)
+	c.cmd(230, "PASS %s", cred.SecretKey)
+
+	c.cmd(257, "MKD bucket")
+	c.cmd(250, "CWD /bucket")
+	if pwd := c.cmd(257, "PWD"); !strings.HasPrefix(pwd, `"/bucket"`) {
+		t.Fatalf("Unexpected directory %s", pwd)
+	}
+	data := "hello, world"
+	c.transfer(data, "STOR hello.txt")
+	if size := c.cmd(213, "SIZE hello.txt"); size != strconv.Itoa(len(data)) {
+		t.Fatalf("Expected size %d, got %s", len(data), size)
+	}
+	if names := c.transfer("", "NLST"); names != "hello.txt\r\n" {
+		t.Fatalf("Unexpected names %q", names)
+	}
+	if list := c.transfer("", "LIST -la"); !strings.HasSuffix(list, " hello.txt\r\n") || !strings.HasPrefix(list, "-rw-r--r--") {
+		t.Fatalf("Unexpected listing %q", list)
+	}
+	if received := c.transfer("", "RETR /bucket/hello.txt"); received != data {
+		t.Fatalf("Expected %q, got %q", data, received)
+	}
+	c.cmd(350, "REST 7")
+	if received := c.transfer("", "RETR hello.txt"); received != "world" {
+		t.Fatalf("Expected %q, got %q", "world", received)
+	}
+
+	c.cmd(350, "RNFR hello.txt")
+	c.cmd(250, "RNTO greeting.txt")
+	c.cmd(550, "SIZE hello.txt")
+	c.cmd(250, "DELE greeting.txt")
+	c.cmd(550, "DELE greeting.txt")
+	c.cmd(250, "CDUP")
+	c.cmd(250, "RMD bucket")
+	c.cmd(221, "QUIT")
+}
+
+// Tests the server credentials are refused over plain FTP without TLS.
+func TestFTPServerPlain(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	c, _ := startFTPTestServer(t, doneCh)
+	defer c.Close()
+
+	cred := globalServerConfig.GetCredential()
+	c.cmd(502, "AUTH TLS")
+	c.cmd(331, "USER %s", cred.AccessKey)
+	c.cmd(530, "PASS %s", cred.SecretKey)
+	c.cmd(530, "PWD")
+	c.cmd(221, "QUIT")
+}
+
+// Tests the passive port of FTP data connections is in the range set.
+func TestFTPPassivePorts(t *testing.T) {
+	defer func() {
+		globalFTPPassivePortMin, globalFTPPassivePortMax = 0, 0
+	}()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	globalFTPPassivePortMin, globalFTPPassivePortMax = port, port
+
+	server, client := net.Pipe()
+	defer client.Close()
+	c := newFTPConn(&ftpTestConn{Conn: server, addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}})
+	pasv, err := c.listenPassive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pasv.Close()
+	if pasvPort := pasv.Addr().(*net.TCPAddr).Port; pasvPort != port {
+		t.Fatalf("Expected port %d, got %d", port, pasvPort)
+	}
+	if _, err = c.listenPassive(); err == nil {
+		t.Fatal("Expected an error once all passive ports are used")
+	}
+}
+
+// ftpTestConn - control connection with a local TCP address.
+type ftpTestConn struct {
+	net.Conn
+	addr net.Addr
+}
+
+func (c *ftpTestConn) LocalAddr() net.Addr {
+	return c.addr
+}
+
+func (c *ftpTestConn) SetDeadline(t time.Time) error {
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+// Environment variable turning on the verification of writes to
+// backends with eventual consistency, "on" or "off".
+const gatewayVerifyWritesEnv = "MINIO_GATEWAY_VERIFY_WRITES"
+
+const (
+	// Written objects are looked up this many times at most before
+	// the write fails.
+	gatewayVerifyAttempts = 10
+
+	// Lookups are retried with exponentially increasing delays of
+	// at least unit and at most cap.
+	gatewayVerifyRetryUnit = 100 * time.Millisecond
+	gatewayVerifyRetryCap  = time.Second
+)
+
+// Turns on the verification of writes from the environment.
+func handleGatewayVerifyWritesEnv() {
+	switch value := os.Getenv(gatewayVerifyWritesEnv); value {
+	case "", "off":
+	case "on":
+		globalGatewayVerifyWrites = true
+	default:
+		fatalIf(fmt.Errorf("invalid value"), "Unknown value ‘%s’ in %s environment variable.", value, gatewayVerifyWritesEnv)
+	}
+}
+
+// gatewayConsistencyLayer - acknowledges writes only once the backend
+// returns the written object, for backends with eventual consistency
+// where an object may not be visible right after it was written.
+type gatewayConsistencyLayer struct {
+	ObjectLayer
+	attempts int
+}
+
+func newGatewayConsistencyLayer(objAPI ObjectLayer) ObjectLayer {
+	return &gatewayConsistencyLayer{
+		ObjectLayer: objAPI,
+		attempts:    gatewayVerifyAttempts,
+	}
+}
+
+// Looks up a written object until the backend returns it with the
+// ETag of the write, errGatewayWriteNotVisible if it does not after
+// all attempts.
+func (l *gatewayConsistencyLayer) verifyWrite(bucket, object string, objInfo ObjectInfo) error {
+	etag := canonicalizeETag(objInfo.ETag)
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	for attempt := range newRetryTimerWithJitter(gatewayVerifyRetryUnit, gatewayVerifyRetryCap, NoJitter, doneCh) {
+		info, err := l.ObjectLayer.GetObjectInfo(bucket, object)
+		if err == nil && (etag == "" || canonicalizeETag(info.ETag) == etag) {
+			return nil
+		}
+		if err != nil && !isErrObjectNotFound(err) {
+			return err
+		}
+		if attempt+1 >= l.attempts {
+			break
+		}
+	}
+	return errors.Trace(errGatewayWriteNotVisible)
+}
+
+// PutObject - writes the object and waits for it to be visible.
+func (l *gatewayConsistencyLayer) PutObject(bucket, object string, data *hash.Reader, metadata map[string]string) (ObjectInfo, error) {
+	objInfo, err := l.ObjectLayer.PutObject(bucket, object, data, metadata)
+	if err != nil {
+		return objInfo, err
+	}
+	return objInfo, l.verifyWrite(bucket, object, objInfo)
+}
+
+// CopyObject - copies the object and waits for the copy to be visible.
+func (l *gatewayConsistencyLayer) CopyObject(srcBucket, srcObject, destBucket, destObject string, srcInfo ObjectInfo) (ObjectInfo, error) {
+	objInfo, err := l.ObjectLayer.CopyObject(srcBucket, srcObject, destBucket, destObject, srcInfo)
+	if err != nil {
+		return objInfo, err
+	}
+	return objInfo, l.verifyWrite(destBucket, destObject, objInfo)
+}
+
+// CompleteMultipartUpload - completes the upload and waits for the
+// object to be visible.
+func (l *gatewayConsistencyLayer) CompleteMultipartUpload(ctx context.Context, bucket, object, uploadID string, uploadedParts []CompletePart) (ObjectInfo, error) {
+	objInfo, err := l.ObjectLayer.CompleteMultipartUpload(ctx, bucket, object, uploadID, uploadedParts)
+	if err != nil {
+		return objInfo, err
+	}
+	return objInfo, l.verifyWrite(bucket, object, objInfo)
+}
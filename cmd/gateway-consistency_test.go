@@ -0,0 +1,105 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/minio/minio/pkg/errors"
+)
+
+// eventualObjectLayer - returns written objects only after a number
+// of lookups, the previous version of the object until then.
+type eventualObjectLayer struct {
+	ObjectLayer
+	lookups  int
+	previous *ObjectInfo
+}
+
+func (l *eventualObjectLayer) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
+	if l.lookups > 0 {
+		l.lookups--
+		if l.previous != nil {
+			return *l.previous, nil
+		}
+		return ObjectInfo{}, errors.Trace(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	return l.ObjectLayer.GetObjectInfo(bucket, object)
+}
+
+// Tests writes are acknowledged once the backend returns them.
+func TestGatewayConsistencyLayer(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("unable initialize config file, %s", err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	initNSLock(false)
+	fs, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+
+	backend := &eventualObjectLayer{ObjectLayer: fs}
+	obj := newGatewayConsistencyLayer(backend)
+	obj.(*gatewayConsistencyLayer).attempts = 3
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	put := func(content string) (ObjectInfo, error) {
+		return obj.PutObject(bucket, "object", mustGetHashReader(t, bytes.NewReader([]byte(content)), int64(len(content)), "", ""), nil)
+	}
+
+	// Visible after some lookups.
+	backend.lookups = 2
+	previous, err := put("hello")
+	if err != nil {
+		t.Fatalf("Expected the write to be verified, got %v", err)
+	}
+	if backend.lookups != 0 {
+		t.Errorf("Expected all lookups to be done, %d left", backend.lookups)
+	}
+
+	// The previous version is returned longer than the attempts.
+	backend.lookups, backend.previous = 3, &previous
+	if _, err = put("world"); errors.Cause(err) != errGatewayWriteNotVisible {
+		t.Errorf("Expected the write not to be visible, got %v", err)
+	}
+	if toAPIErrorCode(err) != ErrSlowDown {
+		t.Errorf("Expected clients to slow down, got %v", toAPIErrorCode(err))
+	}
+
+	// Copies are verified too.
+	backend.lookups, backend.previous = 2, nil
+	srcInfo, err := fs.GetObjectInfo(bucket, "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	srcInfo.Writer = pipeWriter
+	srcInfo.Reader = mustGetHashReader(t, pipeReader, srcInfo.Size, "", "")
+	if _, err = obj.CopyObject(bucket, "object", bucket, "copy", srcInfo); err != nil {
+		t.Errorf("Expected the copy to be verified, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio/pkg/errors"
+)
+
+// Tests parsing master keys of gateway encryption.
+func TestParseGatewayEncryptionKey(t *testing.T) {
+	testCases := []struct {
+		value   string
+		success bool
+	}{
+		{strings.Repeat("ab", 32), true},
+		{strings.Repeat("ab", 16), false},
+		{strings.Repeat("ab", 33), false},
+		{strings.Repeat("zz", 32), false},
+	}
+	for i, testCase := range testCases {
+		key, err := parseGatewayEncryptionKey(testCase.value)
+		if testCase.success && (err != nil || len(key) != SSECustomerKeySize) {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if !testCase.success && err == nil {
+			t.Errorf("Test %d: Expected %q to fail", i+1, testCase.value)
+		}
+	}
+}
+
+// Tests gateway encryption requires multipart uploads to be turned off.
+func TestCheckGatewayEncryptionMultipart(t *testing.T) {
+	for _, value := range []string{"", "on"} {
+		if err := checkGatewayEncryptionMultipart(value); err == nil {
+			t.Errorf("Expected %q to fail", value)
+		}
+	}
+	if err := checkGatewayEncryptionMultipart("off"); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+// Tests objects are encrypted before they reach the backend and
+// decrypted on the way back.
+func TestGatewayEncryptionLayer(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("unable initialize config file, %s", err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	initNSLock(false)
+	backend, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+
+	key := make([]byte, SSECustomerKeySize)
+	if _, err = rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	obj := newGatewayEncryptionLayer(backend, key)
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Spans several packages of 64KiB.
+	data := make([]byte, 3*64*1024+100)
+	if _, err = rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	metadata := map[string]string{"X-Amz-Meta-Color": "blue"}
+	objInfo, err := obj.PutObject(bucket, "object", mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Size != int64(len(data)) {
+		t.Fatalf("Expected size %d, got %d", len(data), objInfo.Size)
+	}
+	etag := objInfo.ETag
+
+	// The backend only sees encrypted content.
+	var encrypted bytes.Buffer
+	if err = backend.GetObject(bucket, "object", 0, encryptedSize(int64(len(data))), &encrypted, ""); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(encrypted.Bytes(), data[:1024]) {
+		t.Fatal("Expected the backend not to see plain content")
+	}
+
+	objInfo, err = obj.GetObjectInfo(bucket, "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Size != int64(len(data)) {
+		t.Fatalf("Expected size %d, got %d", len(data), objInfo.Size)
+	}
+	if objInfo.UserDefined["X-Amz-Meta-Color"] != "blue" || isGatewayEncrypted(objInfo.UserDefined) {
+		t.Fatalf("Unexpected metadata %v", objInfo.UserDefined)
+	}
+	if objInfo.ETag != etag {
+		t.Fatalf("Expected ETag %s, got %s", etag, objInfo.ETag)
+	}
+
+	testCases := []struct {
+		offset, length int64
+	}{
+		{0, int64(len(data))},
+		{0, 1},
+		{64*1024 - 1, 2},
+		{100, 2 * 64 * 1024},
+		{3 * 64 * 1024, 100},
+		{int64(len(data)) - 1, 1},
+		{int64(len(data)), 0},
+	}
+	for i, testCase := range testCases {
+		var buf bytes.Buffer
+		if err = obj.GetObject(bucket, "object", testCase.offset, testCase.length, &buf, etag); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if !bytes.Equal(buf.Bytes(), data[testCase.offset:testCase.offset+testCase.length]) {
+			t.Errorf("Test %d: Content of range differs", i+1)
+		}
+	}
+	if err = obj.GetObject(bucket, "object", 0, int64(len(data))+1, &bytes.Buffer{}, ""); err == nil {
+		t.Error("Expected range beyond the object to fail")
+	}
+
+	// Copies remain readable.
+	if _, err = obj.CopyObject(bucket, "object", bucket, "copy", objInfo); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = obj.GetObject(bucket, "copy", 0, int64(len(data)), &buf, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Error("Content of copy differs")
+	}
+
+	// Objects stored without encryption are served as they are.
+	plain := []byte("stored without encryption")
+	if _, err = backend.PutObject(bucket, "plain", mustGetHashReader(t, bytes.NewReader(plain), int64(len(plain)), "", ""), nil); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err = obj.GetObject(bucket, "plain", 0, int64(len(plain)), &buf, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), plain) {
+		t.Error("Content of object stored without encryption differs")
+	}
+
+	// Listings decrypt the sizes of encrypted objects only, with the
+	// same ETag as returned by other calls. The second listing is
+	// served from the cache.
+	for _, layer := range []ObjectLayer{newGatewayEncryptionLayer(backend, key), obj} {
+		result, err := layer.ListObjects(bucket, "", "", "", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, listed := range result.Objects {
+			expected := int64(len(data))
+			if listed.Name == "plain" {
+				expected = int64(len(plain))
+			}
+			if listed.Size != expected {
+				t.Errorf("Expected listed size of %s %d, got %d", listed.Name, expected, listed.Size)
+			}
+			if listed.Name == "object" && listed.ETag != etag {
+				t.Errorf("Expected listed ETag %s, got %s", etag, listed.ETag)
+			}
+		}
+	}
+
+	// Objects cannot be read with a different master key.
+	otherKey := make([]byte, SSECustomerKeySize)
+	if err = newGatewayEncryptionLayer(backend, otherKey).GetObject(bucket, "object", 0, 1, &bytes.Buffer{}, ""); errors.Cause(err) != errObjectTampered {
+		t.Errorf("Expected %v, got %v", errObjectTampered, err)
+	}
+
+	if _, err = obj.NewMultipartUpload(bucket, "object", nil); err == nil {
+		t.Error("Expected multipart uploads not to be implemented")
+	}
+}
@@ -0,0 +1,419 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/hash"
+)
+
+// Environment variables turning on the journal of recent writes merged
+// into listings, "on" or "off", and setting how long writes are kept.
+const (
+	gatewayListJournalEnv       = "MINIO_GATEWAY_LIST_JOURNAL"
+	gatewayListJournalExpiryEnv = "MINIO_GATEWAY_LIST_JOURNAL_EXPIRY"
+)
+
+const (
+	defaultGatewayListJournalExpiry = time.Minute
+
+	// Maximum number of writes kept, the oldest are dropped first.
+	maxGatewayListJournalRecords = 100000
+)
+
+// Turns on the journal of recent writes from the environment.
+func handleGatewayListJournalEnv() {
+	switch value := os.Getenv(gatewayListJournalEnv); value {
+	case "", "off":
+		return
+	case "on":
+	default:
+		fatalIf(fmt.Errorf("invalid value"), "Unknown value ‘%s’ in %s environment variable.", value, gatewayListJournalEnv)
+	}
+
+	expiry := defaultGatewayListJournalExpiry
+	if value := os.Getenv(gatewayListJournalExpiryEnv); value != "" {
+		var err error
+		expiry, err = time.ParseDuration(value)
+		if err == nil && expiry <= 0 {
+			err = fmt.Errorf("expiry must be positive")
+		}
+		fatalIf(err, "Invalid value ‘%s’ in %s environment variable.", value, gatewayListJournalExpiryEnv)
+	}
+
+	globalGatewayListJournalExpiry = expiry
+}
+
+// gatewayJournalEntry - latest write of an object, the object written
+// or a delete.
+type gatewayJournalEntry struct {
+	objInfo ObjectInfo
+	deleted bool
+	time    time.Time
+}
+
+// gatewayJournalRecord - a write in order of recording.
+type gatewayJournalRecord struct {
+	bucket, object string
+	time           time.Time
+}
+
+// gatewayListJournal - recent writes per bucket and object, kept until
+// they expire.
+type gatewayListJournal struct {
+	mu      sync.Mutex
+	expiry  time.Duration
+	buckets map[string]map[string]gatewayJournalEntry
+	records []gatewayJournalRecord
+
+	// Last keys of the pages continuation tokens of the backend
+	// continue after, tokens may be opaque to the gateway.
+	tokens map[string]gatewayTokenMarker
+}
+
+// gatewayTokenMarker - last key of the page before a continuation token.
+type gatewayTokenMarker struct {
+	marker string
+	time   time.Time
+}
+
+func newGatewayListJournal(expiry time.Duration) *gatewayListJournal {
+	return &gatewayListJournal{
+		expiry:  expiry,
+		buckets: make(map[string]map[string]gatewayJournalEntry),
+		tokens:  make(map[string]gatewayTokenMarker),
+	}
+}
+
+// prune - drops expired writes, and the oldest ones beyond the maximum
+// number of writes kept. Must be called with the lock held.
+func (j *gatewayListJournal) prune(now time.Time) {
+	for len(j.records) > 0 {
+		record := j.records[0]
+		if now.Sub(record.time) < j.expiry && len(j.records) <= maxGatewayListJournalRecords {
+			return
+		}
+		// Overwritten entries are dropped with their latest record.
+		if objects := j.buckets[record.bucket]; objects != nil && objects[record.object].time.Equal(record.time) {
+			delete(objects, record.object)
+			if len(objects) == 0 {
+				delete(j.buckets, record.bucket)
+			}
+		}
+		j.records = j.records[1:]
+	}
+}
+
+// record - records a write of an object, or a delete if deleted is set.
+func (j *gatewayListJournal) record(bucket, object string, objInfo ObjectInfo, deleted bool) {
+	now := UTCNow()
+	objInfo.Bucket, objInfo.Name = bucket, object
+	if objInfo.ModTime.IsZero() {
+		objInfo.ModTime = now
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	objects, ok := j.buckets[bucket]
+	if !ok {
+		objects = make(map[string]gatewayJournalEntry)
+		j.buckets[bucket] = objects
+	}
+	objects[object] = gatewayJournalEntry{objInfo: objInfo, deleted: deleted, time: now}
+	j.records = append(j.records, gatewayJournalRecord{bucket, object, now})
+	j.prune(now)
+}
+
+// removeBucket - drops the writes of a deleted bucket.
+func (j *gatewayListJournal) removeBucket(bucket string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	delete(j.buckets, bucket)
+}
+
+// entries - returns the recent writes of objects below prefix.
+func (j *gatewayListJournal) entries(bucket, prefix string) map[string]gatewayJournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.prune(UTCNow())
+	entries := make(map[string]gatewayJournalEntry)
+	for object, entry := range j.buckets[bucket] {
+		if strings.HasPrefix(object, prefix) {
+			entries[object] = entry
+		}
+	}
+	return entries
+}
+
+// lastListKey - returns the last object or prefix of a page of a
+// listing, later pages start after it.
+func lastListKey(objects []ObjectInfo, prefixes []string) string {
+	var last string
+	for _, objInfo := range objects {
+		if objInfo.Name > last {
+			last = objInfo.Name
+		}
+	}
+	for _, p := range prefixes {
+		if p > last {
+			last = p
+		}
+	}
+	return last
+}
+
+// setTokenMarker - remembers the last key of the page a continuation
+// token of the backend continues after.
+func (j *gatewayListJournal) setTokenMarker(token, marker string) {
+	now := UTCNow()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.tokens) >= maxGatewayListJournalRecords {
+		for t, m := range j.tokens {
+			if now.Sub(m.time) >= j.expiry {
+				delete(j.tokens, t)
+			}
+		}
+		if len(j.tokens) >= maxGatewayListJournalRecords {
+			j.tokens = make(map[string]gatewayTokenMarker)
+		}
+	}
+	j.tokens[token] = gatewayTokenMarker{marker, now}
+}
+
+// tokenMarker - returns the last key of the page before a continuation
+// token, false if it is unknown or expired.
+func (j *gatewayListJournal) tokenMarker(token string) (string, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	m, ok := j.tokens[token]
+	if !ok || UTCNow().Sub(m.time) >= j.expiry {
+		return "", false
+	}
+	return m.marker, true
+}
+
+// merge - merges the recent writes below prefix into a page of a
+// listing after marker. Objects deleted recently are removed and
+// objects written recently replace stale ones. Objects written recently
+// and missing from the page are added as objects or common prefixes if
+// they sort before the end of a truncated page, anywhere after marker
+// otherwise.
+func (j *gatewayListJournal) merge(bucket, prefix, marker, delimiter string, objects []ObjectInfo,
+	prefixes []string, isTruncated bool) ([]ObjectInfo, []string) {
+	entries := j.entries(bucket, prefix)
+	if len(entries) == 0 {
+		return objects, prefixes
+	}
+
+	last := lastListKey(objects, prefixes)
+	listed := make(map[string]bool)
+	for _, objInfo := range objects {
+		listed[objInfo.Name] = true
+	}
+	for _, p := range prefixes {
+		listed[p] = true
+	}
+
+	merged := make([]ObjectInfo, 0, len(objects))
+	for _, objInfo := range objects {
+		entry, ok := entries[objInfo.Name]
+		switch {
+		case !ok:
+		case entry.deleted:
+			continue
+		case canonicalizeETag(entry.objInfo.ETag) != canonicalizeETag(objInfo.ETag):
+			objInfo = entry.objInfo
+		}
+		merged = append(merged, objInfo)
+	}
+
+	for object, entry := range entries {
+		if entry.deleted {
+			continue
+		}
+		key := object
+		if delimiter != "" {
+			if i := strings.Index(object[len(prefix):], delimiter); i >= 0 {
+				key = object[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if listed[key] || key <= marker || (isTruncated && key > last) {
+			continue
+		}
+		listed[key] = true
+		if key == object {
+			merged = append(merged, entry.objInfo)
+		} else {
+			prefixes = append(prefixes, key)
+		}
+	}
+
+	sort.Slice(merged, func(i, k int) bool { return merged[i].Name < merged[k].Name })
+	sort.Strings(prefixes)
+	return merged, prefixes
+}
+
+// gatewayListJournalLayer - merges writes acknowledged recently into
+// listings, for backends with eventual consistency which may list
+// objects only some time after they were written or deleted. Only
+// writes through this gateway are known.
+type gatewayListJournalLayer struct {
+	ObjectLayer
+	journal *gatewayListJournal
+}
+
+func newGatewayListJournalLayer(objAPI ObjectLayer, expiry time.Duration) ObjectLayer {
+	return &gatewayListJournalLayer{
+		ObjectLayer: objAPI,
+		journal:     newGatewayListJournal(expiry),
+	}
+}
+
+// PutObject - writes the object and records the write.
+func (l *gatewayListJournalLayer) PutObject(bucket, object string, data *hash.Reader, metadata map[string]string) (ObjectInfo, error) {
+	objInfo, err := l.ObjectLayer.PutObject(bucket, object, data, metadata)
+	if err == nil {
+		l.journal.record(bucket, object, objInfo, false)
+	}
+	return objInfo, err
+}
+
+// CopyObject - copies the object and records the write of the copy.
+func (l *gatewayListJournalLayer) CopyObject(srcBucket, srcObject, destBucket, destObject string, srcInfo ObjectInfo) (ObjectInfo, error) {
+	objInfo, err := l.ObjectLayer.CopyObject(srcBucket, srcObject, destBucket, destObject, srcInfo)
+	if err == nil {
+		l.journal.record(destBucket, destObject, objInfo, false)
+	}
+	return objInfo, err
+}
+
+// CompleteMultipartUpload - completes the upload and records the write
+// of the object.
+func (l *gatewayListJournalLayer) CompleteMultipartUpload(ctx context.Context, bucket, object, uploadID string, uploadedParts []CompletePart) (ObjectInfo, error) {
+	objInfo, err := l.ObjectLayer.CompleteMultipartUpload(ctx, bucket, object, uploadID, uploadedParts)
+	if err == nil {
+		l.journal.record(bucket, object, objInfo, false)
+	}
+	return objInfo, err
+}
+
+// DeleteObject - deletes the object and records the delete.
+func (l *gatewayListJournalLayer) DeleteObject(bucket, object string) error {
+	err := l.ObjectLayer.DeleteObject(bucket, object)
+	if err == nil {
+		l.journal.record(bucket, object, ObjectInfo{}, true)
+	}
+	return err
+}
+
+// DeleteObjects - deletes the objects and records the deletes which
+// succeeded.
+func (l *gatewayListJournalLayer) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	errs, err := l.ObjectLayer.DeleteObjects(bucket, objects)
+	if err != nil {
+		return errs, err
+	}
+	for i, object := range objects {
+		if i < len(errs) && errs[i] == nil {
+			l.journal.record(bucket, object, ObjectInfo{}, true)
+		}
+	}
+	return errs, nil
+}
+
+// DeleteBucket - deletes the bucket and drops its recent writes.
+func (l *gatewayListJournalLayer) DeleteBucket(bucket string) error {
+	err := l.ObjectLayer.DeleteBucket(bucket)
+	if err == nil {
+		l.journal.removeBucket(bucket)
+	}
+	return err
+}
+
+// ListObjects - lists objects merged with the recent writes. Pages
+// holding more than maxKeys entries after merging are cut.
+func (l *gatewayListJournalLayer) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	result, err := l.ObjectLayer.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err != nil || maxKeys <= 0 {
+		return result, err
+	}
+	result.Objects, result.Prefixes = l.journal.merge(bucket, prefix, marker, delimiter,
+		result.Objects, result.Prefixes, result.IsTruncated)
+
+	if len(result.Objects)+len(result.Prefixes) <= maxKeys {
+		return result, nil
+	}
+	// Keep the first maxKeys objects and prefixes, in order.
+	var objects []ObjectInfo
+	var prefixes []string
+	var next string
+	for len(objects)+len(prefixes) < maxKeys {
+		if len(prefixes) == len(result.Prefixes) ||
+			(len(objects) < len(result.Objects) && result.Objects[len(objects)].Name < result.Prefixes[len(prefixes)]) {
+			next = result.Objects[len(objects)].Name
+			objects = append(objects, result.Objects[len(objects)])
+		} else {
+			next = result.Prefixes[len(prefixes)]
+			prefixes = append(prefixes, next)
+		}
+	}
+	result.Objects, result.Prefixes = objects, prefixes
+	result.IsTruncated = true
+	result.NextMarker = next
+	return result, nil
+}
+
+// ListObjectsV2 - lists objects merged with the recent writes. Pages
+// are not cut as tokens cannot be made up for the backend, they may
+// hold more than maxKeys entries after merging.
+func (l *gatewayListJournalLayer) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int,
+	fetchOwner bool, startAfter string) (ListObjectsV2Info, error) {
+	result, err := l.ObjectLayer.ListObjectsV2(bucket, prefix, continuationToken, delimiter, maxKeys, fetchOwner, startAfter)
+	if err != nil || maxKeys <= 0 {
+		return result, err
+	}
+
+	marker := startAfter
+	if continuationToken != "" {
+		var ok bool
+		if marker, ok = l.journal.tokenMarker(continuationToken); !ok {
+			// Page after an unknown page, recent writes after
+			// marker might have been listed before.
+			return result, nil
+		}
+	}
+	if result.IsTruncated && result.NextContinuationToken != "" {
+		l.journal.setTokenMarker(result.NextContinuationToken, lastListKey(result.Objects, result.Prefixes))
+	}
+	result.Objects, result.Prefixes = l.journal.merge(bucket, prefix, marker, delimiter,
+		result.Objects, result.Prefixes, result.IsTruncated)
+	return result, nil
+}
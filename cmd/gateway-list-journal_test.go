@@ -0,0 +1,190 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// staleListObjectLayer - lists objects as a backend with eventual
+// consistency, without hidden objects and with deleted ghosts.
+type staleListObjectLayer struct {
+	ObjectLayer
+	hidden map[string]bool
+	ghosts []ObjectInfo
+}
+
+func (l *staleListObjectLayer) stale(objects []ObjectInfo) []ObjectInfo {
+	var listed []ObjectInfo
+	for _, objInfo := range objects {
+		if !l.hidden[objInfo.Name] {
+			listed = append(listed, objInfo)
+		}
+	}
+	return append(listed, l.ghosts...)
+}
+
+func (l *staleListObjectLayer) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	result, err := l.ObjectLayer.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	result.Objects = l.stale(result.Objects)
+	return result, err
+}
+
+func (l *staleListObjectLayer) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int,
+	fetchOwner bool, startAfter string) (ListObjectsV2Info, error) {
+	result, err := l.ObjectLayer.ListObjectsV2(bucket, prefix, continuationToken, delimiter, maxKeys, fetchOwner, startAfter)
+	result.Objects = l.stale(result.Objects)
+	return result, err
+}
+
+// Tests recent writes are merged into listings of a backend with
+// eventual consistency.
+func TestGatewayListJournalLayer(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("unable initialize config file, %s", err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	initNSLock(false)
+	fs, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+
+	backend := &staleListObjectLayer{ObjectLayer: fs, hidden: map[string]bool{}}
+	obj := newGatewayListJournalLayer(backend, time.Minute)
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"a/1", "b", "c", "d/1"} {
+		if _, err = obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader([]byte("hello")), 5, "", ""), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ghost, err := obj.GetObjectInfo(bucket, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = obj.DeleteObject(bucket, "c"); err != nil {
+		t.Fatal(err)
+	}
+	backend.hidden["b"] = true
+	backend.hidden["d/1"] = true
+	backend.ghosts = []ObjectInfo{ghost}
+
+	names := func(objects []ObjectInfo) []string {
+		var list []string
+		for _, objInfo := range objects {
+			list = append(list, objInfo.Name)
+		}
+		return list
+	}
+
+	result, err := obj.ListObjects(bucket, "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(result.Objects); !reflect.DeepEqual(got, []string{"a/1", "b", "d/1"}) || result.IsTruncated {
+		t.Fatalf("Unexpected listing %v, truncated %v", got, result.IsTruncated)
+	}
+
+	result, err = obj.ListObjects(bucket, "", "", "/", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(result.Objects); !reflect.DeepEqual(got, []string{"b"}) ||
+		!reflect.DeepEqual(result.Prefixes, []string{"a/", "d/"}) {
+		t.Fatalf("Unexpected listing %v, prefixes %v", got, result.Prefixes)
+	}
+
+	// Pages are cut to maxKeys, the next one starts after the cut.
+	result, err = obj.ListObjects(bucket, "", "", "/", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(result.Objects); !reflect.DeepEqual(got, []string{"b"}) ||
+		!reflect.DeepEqual(result.Prefixes, []string{"a/"}) || !result.IsTruncated || result.NextMarker != "b" {
+		t.Fatalf("Unexpected listing %v, prefixes %v, truncated %v, next marker %s", got, result.Prefixes,
+			result.IsTruncated, result.NextMarker)
+	}
+	result, err = obj.ListObjects(bucket, "", "b", "/", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(result.Objects); len(got) != 0 || !reflect.DeepEqual(result.Prefixes, []string{"d/"}) || result.IsTruncated {
+		t.Fatalf("Unexpected listing %v, prefixes %v, truncated %v", got, result.Prefixes, result.IsTruncated)
+	}
+
+	resultV2, err := obj.ListObjectsV2(bucket, "", "", "", 1000, false, "a/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(resultV2.Objects); !reflect.DeepEqual(got, []string{"b", "d/1"}) {
+		t.Fatalf("Unexpected listing %v", got)
+	}
+
+	// Dropped with the bucket.
+	if err = obj.DeleteObject(bucket, "a/1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"b", "d/1"} {
+		if err = fs.DeleteObject(bucket, object); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = obj.DeleteBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	if entries := obj.(*gatewayListJournalLayer).journal.entries(bucket, ""); len(entries) != 0 {
+		t.Fatalf("Expected no writes of deleted bucket, got %v", entries)
+	}
+}
+
+// Tests writes are merged into listings until they expire.
+func TestGatewayListJournalExpiry(t *testing.T) {
+	journal := newGatewayListJournal(100 * time.Millisecond)
+	journal.record("bucket", "object", ObjectInfo{ETag: "etag"}, false)
+
+	objects, _ := journal.merge("bucket", "", "", "", nil, nil, false)
+	if len(objects) != 1 || objects[0].Name != "object" || objects[0].Bucket != "bucket" {
+		t.Fatalf("Expected the recent write to be listed, got %v", objects)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if objects, _ = journal.merge("bucket", "", "", "", nil, nil, false); len(objects) != 0 {
+		t.Fatalf("Expected the expired write not to be listed, got %v", objects)
+	}
+	if len(journal.buckets) != 0 || len(journal.records) != 0 {
+		t.Fatalf("Expected the expired write to be dropped, got %v", journal.buckets)
+	}
+
+	journal.setTokenMarker("token", "marker")
+	if marker, ok := journal.tokenMarker("token"); !ok || marker != "marker" {
+		t.Fatalf("Expected marker of token, got %s", marker)
+	}
+	if _, ok := journal.tokenMarker("unknown"); ok {
+		t.Fatal("Expected no marker of unknown token")
+	}
+}
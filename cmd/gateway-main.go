@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/url"
 	"os"
@@ -137,6 +138,18 @@ func StartGateway(ctx *cli.Context, gw Gateway) {
 	// Handle common env vars.
 	handleCommonEnvVars()
 
+	// Handle gateway encryption env vars.
+	handleGatewayEncryptionEnv()
+
+	// Handle gateway write verification env vars.
+	handleGatewayVerifyWritesEnv()
+
+	// Handle gateway parallel upload env vars.
+	handleGatewayParallelUploadEnv()
+
+	// Handle gateway listing journal env vars.
+	handleGatewayListJournalEnv()
+
 	// Validate if we have access, secret set through environment.
 	if !globalIsEnvCreds {
 		errorIf(fmt.Errorf("Access and secret keys not set"), "Access and Secret keys should be set through ENVs for backend [%s]", gatewayName)
@@ -157,13 +170,58 @@ func StartGateway(ctx *cli.Context, gw Gateway) {
 	globalPublicCerts, globalRootCAs, globalTLSCertificate, globalIsSSL, err = getSSLConfig()
 	fatalIf(err, "Invalid SSL certificate file")
 
+	// Load CA certificates verifying client certificates of admin requests.
+	globalAdminClientCAs, err = getAdminClientCAs(getAdminCADir())
+	fatalIf(err, "Invalid admin client CA certificate file")
+	if globalAdminClientCAs != nil && !globalIsSSL {
+		fatalIf(errInvalidArgument, "Admin client certificates require HTTPS, no certificates found in %s", getConfigDir())
+	}
+
 	// Set system resources to maximum.
 	errorIf(setMaxResources(), "Unable to change resource limit")
 
 	initNSLock(false) // Enable local namespace lock.
 
+	// Server info is served for this gateway only.
+	globalGatewayName = gatewayName
+	initGlobalAdminPeers(EndpointList{})
+
 	newObject, err := gw.NewGatewayLayer(globalServerConfig.GetCredential())
 	fatalIf(err, "Unable to initialize gateway layer")
+	gatewayLayer := newObject
+
+	// Upload large objects to the backend in parallel parts.
+	if globalGatewayParallelUploadPartSize > 0 {
+		newObject = newGatewayParallelUploadLayer(newObject, globalGatewayParallelUploadPartSize,
+			globalGatewayParallelUploadConcurrency, globalGatewayParallelUploadMaxMemory)
+	}
+
+	// Verify writes against the backend itself, before encryption.
+	if globalGatewayVerifyWrites {
+		newObject = newGatewayConsistencyLayer(newObject)
+	}
+
+	// Encrypt objects before they are sent to the backend.
+	if globalGatewayEncryptionKey != nil {
+		newObject = newGatewayEncryptionLayer(newObject, globalGatewayEncryptionKey)
+	}
+
+	// Merge recent writes into listings, as they are seen by clients.
+	if globalGatewayListJournalExpiry > 0 {
+		newObject = newGatewayListJournalLayer(newObject, globalGatewayListJournalExpiry)
+	}
+
+	// Cache bucket policies of anonymous requests, which would be
+	// fetched from the backend for every request otherwise. Backends
+	// shared by several gateways are checked for changed policies.
+	globalBucketPolicyCache = newBucketPolicyCache(gatewayBucketPolicyCacheTTL)
+	if stater, ok := gatewayLayer.(bucketPolicyStater); ok {
+		globalBucketPolicyCache.stater = stater
+	}
+	newObject = newGatewayPolicyCacheLayer(newObject)
+
+	// Registered middlewares see calls before the gateway layers.
+	newObject = wrapObjectLayer(newObject)
 
 	router := mux.NewRouter().SkipClean(true)
 
@@ -171,6 +229,9 @@ func StartGateway(ctx *cli.Context, gw Gateway) {
 	if globalIsBrowserEnabled {
 		fatalIf(registerWebRouter(router), "Unable to configure web browser")
 	}
+	registerPrometheusRouter(router)
+	registerHealthRouter(router)
+	registerGatewayAdminRouter(router)
 	registerAPIRouter(router)
 
 	var handlerFns = []HandlerFunc{
@@ -198,10 +259,30 @@ func StartGateway(ctx *cli.Context, gw Gateway) {
 		// routes them accordingly. Client receives a HTTP error for
 		// invalid/unsupported signatures.
 		setAuthHandler,
+		// Rejects uploads asking for quarantine, which gateways
+		// cannot enforce.
+		filterQuarantineHeader,
+		// Throttles S3 API requests per client IP and in total.
+		setAPIThrottleHandler,
+		// Strips the URL prefix when hosted behind a reverse proxy.
+		setURLPrefixHandler,
 		// Add new handlers here.
+
+		// Publishes request traces to admin trace clients.
+		setTraceHandler,
+		// Audit handler comes last to record every request,
+		// including those rejected by the handlers above.
+		setAuditHandler,
 	}
 
 	globalHTTPServer = miniohttp.NewServer([]string{gatewayAddr}, registerHandlers(router, handlerFns...), globalTLSCertificate)
+	setHTTPServerThroughput(globalHTTPServer)
+
+	// Request client certificates, only admin requests require them.
+	if globalAdminClientCAs != nil {
+		globalHTTPServer.TLSConfig.ClientCAs = globalAdminClientCAs
+		globalHTTPServer.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
 
 	// Start server, automatically configures TLS if certs are available.
 	go func() {
@@ -215,6 +296,10 @@ func StartGateway(ctx *cli.Context, gw Gateway) {
 	globalObjectAPI = newObject
 	globalObjLayerMutex.Unlock()
 
+	// Gateways are ready to serve requests once started.
+	globalBootTime = UTCNow()
+	globalBootProgress.finish()
+
 	// Prints the formatted startup message once object layer is initialized.
 	if !quietFlag {
 		mode := globalMinioModeGatewayPrefix + gatewayName
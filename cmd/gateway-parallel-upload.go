@@ -0,0 +1,265 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+// Environment variables turning on parallel uploads of large objects
+// to the backend, "on" or "off", and setting the size of the parts,
+// the number of parts uploaded concurrently and the bytes of parts
+// held in memory by all uploads.
+const (
+	gatewayParallelUploadEnv            = "MINIO_GATEWAY_PARALLEL_UPLOAD"
+	gatewayParallelUploadPartSizeEnv    = "MINIO_GATEWAY_PARALLEL_UPLOAD_PART_SIZE"
+	gatewayParallelUploadConcurrencyEnv = "MINIO_GATEWAY_PARALLEL_UPLOAD_CONCURRENCY"
+	gatewayParallelUploadMaxMemoryEnv   = "MINIO_GATEWAY_PARALLEL_UPLOAD_MAX_MEMORY"
+)
+
+const (
+	defaultGatewayParallelUploadPartSize    = 64 * humanize.MiByte
+	defaultGatewayParallelUploadConcurrency = 4
+	defaultGatewayParallelUploadMaxMemory   = humanize.GiByte
+)
+
+// Turns on parallel uploads from the environment.
+func handleGatewayParallelUploadEnv() {
+	switch value := os.Getenv(gatewayParallelUploadEnv); value {
+	case "", "off":
+		return
+	case "on":
+	default:
+		fatalIf(fmt.Errorf("invalid value"), "Unknown value ‘%s’ in %s environment variable.", value, gatewayParallelUploadEnv)
+	}
+
+	partSize := uint64(defaultGatewayParallelUploadPartSize)
+	if value := os.Getenv(gatewayParallelUploadPartSizeEnv); value != "" {
+		var err error
+		partSize, err = humanize.ParseBytes(value)
+		if err == nil && (partSize < globalMinPartSize || partSize > globalMaxPartSize) {
+			err = fmt.Errorf("part size must be between %s and %s",
+				humanize.IBytes(globalMinPartSize), humanize.IBytes(globalMaxPartSize))
+		}
+		fatalIf(err, "Invalid value ‘%s’ in %s environment variable.", value, gatewayParallelUploadPartSizeEnv)
+	}
+
+	concurrency := defaultGatewayParallelUploadConcurrency
+	if value := os.Getenv(gatewayParallelUploadConcurrencyEnv); value != "" {
+		var err error
+		concurrency, err = strconv.Atoi(value)
+		if err == nil && concurrency <= 0 {
+			err = fmt.Errorf("concurrency must be positive")
+		}
+		fatalIf(err, "Invalid value ‘%s’ in %s environment variable.", value, gatewayParallelUploadConcurrencyEnv)
+	}
+
+	maxMemory := uint64(defaultGatewayParallelUploadMaxMemory)
+	if maxMemory < partSize {
+		maxMemory = partSize
+	}
+	if value := os.Getenv(gatewayParallelUploadMaxMemoryEnv); value != "" {
+		var err error
+		maxMemory, err = humanize.ParseBytes(value)
+		if err == nil && maxMemory < partSize {
+			err = fmt.Errorf("max memory must be at least the part size %s", humanize.IBytes(partSize))
+		}
+		fatalIf(err, "Invalid value ‘%s’ in %s environment variable.", value, gatewayParallelUploadMaxMemoryEnv)
+	}
+
+	globalGatewayParallelUploadPartSize = int64(partSize)
+	globalGatewayParallelUploadConcurrency = concurrency
+	globalGatewayParallelUploadMaxMemory = int64(maxMemory)
+}
+
+// gatewayUploadBuffers - bytes of parts held in memory by all parallel
+// uploads. Parts wait for others to be uploaded once max bytes are
+// held, a part larger than max is only held alone.
+type gatewayUploadBuffers struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	used int64
+	max  int64
+}
+
+func newGatewayUploadBuffers(max int64) *gatewayUploadBuffers {
+	b := &gatewayUploadBuffers{max: max}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// acquire - waits until size more bytes may be held in memory.
+func (b *gatewayUploadBuffers) acquire(size int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.used > 0 && b.used+size > b.max {
+		b.cond.Wait()
+	}
+	b.used += size
+}
+
+// release - hands back size bytes of an uploaded part.
+func (b *gatewayUploadBuffers) release(size int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= size
+	b.cond.Broadcast()
+}
+
+// gatewayParallelUploadLayer - uploads objects larger than a part to
+// the backend as multipart uploads with several parts in flight, to
+// make use of links to the backend with a high latency. Incoming data
+// is read in order, at most concurrency parts of an upload and
+// maxMemory bytes of parts of all uploads are held in memory.
+type gatewayParallelUploadLayer struct {
+	ObjectLayer
+	partSize    int64
+	concurrency int
+	buffers     *gatewayUploadBuffers
+}
+
+func newGatewayParallelUploadLayer(objAPI ObjectLayer, partSize int64, concurrency int, maxMemory int64) ObjectLayer {
+	return &gatewayParallelUploadLayer{
+		ObjectLayer: objAPI,
+		partSize:    partSize,
+		concurrency: concurrency,
+		buffers:     newGatewayUploadBuffers(maxMemory),
+	}
+}
+
+// getPartSize - returns the size of the parts size bytes are uploaded
+// in, large enough for the maximum number of parts.
+func (l *gatewayParallelUploadLayer) getPartSize(size int64) int64 {
+	partSize := l.partSize
+	if minSize := (size + globalMaxPartID - 1) / globalMaxPartID; minSize > partSize {
+		partSize = (minSize + humanize.MiByte - 1) / humanize.MiByte * humanize.MiByte
+	}
+	return partSize
+}
+
+// PutObject - uploads objects larger than a part as multipart uploads,
+// smaller objects and objects of backends without multipart support
+// are uploaded with a single request. Objects uploaded in parts are
+// returned with the ETag the backend reports for them, the multipart
+// ETag reads and listings of the object return as well.
+func (l *gatewayParallelUploadLayer) PutObject(bucket, object string, data *hash.Reader, metadata map[string]string) (ObjectInfo, error) {
+	size := data.Size()
+	partSize := l.getPartSize(size)
+	if size <= partSize {
+		return l.ObjectLayer.PutObject(bucket, object, data, metadata)
+	}
+
+	uploadID, err := l.ObjectLayer.NewMultipartUpload(bucket, object, metadata)
+	if err != nil {
+		if _, ok := errors.Cause(err).(NotImplemented); ok {
+			return l.ObjectLayer.PutObject(bucket, object, data, metadata)
+		}
+		return ObjectInfo{}, err
+	}
+
+	parts, err := l.putParts(bucket, object, uploadID, data, size, partSize)
+	if err == nil {
+		// All content was read, verify the checksums of the client.
+		err = data.Verify()
+	}
+	if err != nil {
+		errorIf(l.ObjectLayer.AbortMultipartUpload(bucket, object, uploadID),
+			"Unable to abort upload %s of %s/%s", uploadID, bucket, object)
+		return ObjectInfo{}, err
+	}
+	if _, err = l.ObjectLayer.CompleteMultipartUpload(context.Background(), bucket, object, uploadID, parts); err != nil {
+		return ObjectInfo{}, err
+	}
+	return l.ObjectLayer.GetObjectInfo(bucket, object)
+}
+
+// putParts - reads data in parts of partSize and uploads up to
+// concurrency parts at the same time, returns the uploaded parts.
+func (l *gatewayParallelUploadLayer) putParts(bucket, object, uploadID string, data io.Reader, size, partSize int64) ([]CompletePart, error) {
+	partsCount := int((size + partSize - 1) / partSize)
+	parts := make([]CompletePart, partsCount)
+
+	var mu sync.Mutex
+	var partErr error
+	setErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if partErr == nil {
+			partErr = err
+		}
+	}
+	getErr := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return partErr
+	}
+
+	// Slots and memory are handed back once their part is uploaded,
+	// which limits the parts read ahead.
+	slots := make(chan struct{}, l.concurrency)
+
+	var wg sync.WaitGroup
+	for partNumber := 1; partNumber <= partsCount && getErr() == nil; partNumber++ {
+		slots <- struct{}{}
+		offset := int64(partNumber-1) * partSize
+		partLength := getPartLength(offset, size, partSize)
+		l.buffers.acquire(partLength)
+		part := make([]byte, partLength)
+		if _, err := io.ReadFull(data, part); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				err = IncompleteBody{Bucket: bucket, Object: object}
+			}
+			l.buffers.release(partLength)
+			<-slots
+			setErr(err)
+			break
+		}
+
+		wg.Add(1)
+		go func(partNumber int, part []byte) {
+			defer wg.Done()
+			defer func() {
+				l.buffers.release(int64(len(part)))
+				<-slots
+			}()
+
+			reader, err := hash.NewReader(bytes.NewReader(part), int64(len(part)), "", "")
+			if err != nil {
+				setErr(err)
+				return
+			}
+			info, err := l.ObjectLayer.PutObjectPart(context.Background(), bucket, object, uploadID, partNumber, reader)
+			if err != nil {
+				setErr(err)
+				return
+			}
+			parts[partNumber-1] = CompletePart{PartNumber: partNumber, ETag: info.ETag}
+		}(partNumber, part)
+	}
+	wg.Wait()
+	return parts, getErr()
+}
@@ -0,0 +1,154 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+// partCountingObjectLayer - records the number of parts uploaded at
+// the same time.
+type partCountingObjectLayer struct {
+	ObjectLayer
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (l *partCountingObjectLayer) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, data *hash.Reader) (PartInfo, error) {
+	l.mu.Lock()
+	l.inFlight++
+	if l.inFlight > l.maxInFlight {
+		l.maxInFlight = l.inFlight
+	}
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.inFlight--
+		l.mu.Unlock()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	return l.ObjectLayer.PutObjectPart(ctx, bucket, object, uploadID, partID, data)
+}
+
+// Tests uploading large objects to the backend in parallel parts.
+func TestGatewayParallelUploadLayer(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("unable initialize config file, %s", err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	initNSLock(false)
+	fs, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+
+	backend := &partCountingObjectLayer{ObjectLayer: fs}
+	obj := newGatewayParallelUploadLayer(backend, globalMinPartSize, 2, 4*globalMinPartSize)
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Objects of up to a part are uploaded with a single request.
+	small := []byte("hello")
+	objInfo, err := obj.PutObject(bucket, "small", mustGetHashReader(t, bytes.NewReader(small), int64(len(small)), "", ""), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.ETag != getMD5Hash(small) {
+		t.Errorf("Expected a single part object, got ETag %s", objInfo.ETag)
+	}
+
+	// Larger objects are uploaded in parts, two at a time.
+	data := bytes.Repeat([]byte("a"), 12*humanize.MiByte)
+	objInfo, err = obj.PutObject(bucket, "large", mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), getMD5Hash(data), ""),
+		map[string]string{"content-type": "text/plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(objInfo.ETag, "-3") {
+		t.Errorf("Expected an object of 3 parts, got ETag %s", objInfo.ETag)
+	}
+	if backend.maxInFlight != 2 {
+		t.Errorf("Expected 2 parts in flight, got %d", backend.maxInFlight)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "large", 0, -1, &buffer, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Error("Expected the uploaded content to match")
+	}
+	info, err := obj.GetObjectInfo(bucket, "large")
+	if err != nil || info.ContentType != "text/plain" {
+		t.Errorf("Expected the metadata to be kept, got %v, %v", info.ContentType, err)
+	}
+	if info.ETag != objInfo.ETag {
+		t.Errorf("Expected the ETag %s of the upload to be read, got %s", objInfo.ETag, info.ETag)
+	}
+
+	// Parts of all uploads held in memory are limited.
+	backend.maxInFlight = 0
+	limited := newGatewayParallelUploadLayer(backend, globalMinPartSize, 2, globalMinPartSize)
+	if _, err = limited.PutObject(bucket, "limited", mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil); err != nil {
+		t.Fatal(err)
+	}
+	if backend.maxInFlight != 1 {
+		t.Errorf("Expected 1 part in flight, got %d", backend.maxInFlight)
+	}
+
+	// Content not matching the checksums of the client, or shorter
+	// than announced, is not stored and the upload is aborted.
+	reader := mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), getMD5Hash(small), "")
+	if _, err = obj.PutObject(bucket, "bad-digest", reader, nil); err == nil {
+		t.Error("Expected the checksum mismatch to fail the upload")
+	}
+	reader = mustGetHashReader(t, bytes.NewReader(data), int64(len(data))+1, "", "")
+	if _, err = obj.PutObject(bucket, "short", reader, nil); err == nil {
+		t.Error("Expected the short body to fail the upload")
+	} else if _, ok := errors.Cause(err).(IncompleteBody); !ok {
+		t.Errorf("Expected IncompleteBody, got %v", err)
+	}
+	for _, object := range []string{"bad-digest", "short"} {
+		if _, err = obj.GetObjectInfo(bucket, object); !isErrObjectNotFound(err) {
+			t.Errorf("Expected %s not to be stored, got %v", object, err)
+		}
+	}
+	uploads, err := obj.ListMultipartUploads(bucket, "", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploads.Uploads) != 0 {
+		t.Errorf("Expected failed uploads to be aborted, got %v", uploads.Uploads)
+	}
+}
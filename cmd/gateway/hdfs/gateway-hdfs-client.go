@@ -0,0 +1,186 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hdfs
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+
+	"github.com/colinmarc/hdfs"
+	hdfsproto "github.com/colinmarc/hdfs/protocol/hadoop_hdfs"
+	"github.com/colinmarc/hdfs/rpc"
+	"github.com/golang/protobuf/proto"
+	"github.com/minio/minio/pkg/errors"
+)
+
+// hdfsClient - file system operations used by the gateway, paths are
+// absolute HDFS paths. Errors are traced *os.PathError or
+// *rpc.NamenodeError values.
+type hdfsClient interface {
+	// Stat - returns the status of a file or directory.
+	Stat(p string) (os.FileInfo, error)
+
+	// ReadDir - returns the statuses of all entries of a directory,
+	// sorted by name.
+	ReadDir(p string) ([]os.FileInfo, error)
+
+	// MkdirAll - creates a directory and all its missing parents.
+	MkdirAll(p string) error
+
+	// Create - creates a file with the bytes read from reader and
+	// all its missing parents, the file must not exist.
+	Create(ctx context.Context, p string, reader io.Reader) error
+
+	// Open - returns length bytes of a file starting at offset, or
+	// all bytes after offset if length is negative.
+	Open(p string, offset, length int64) (io.ReadCloser, error)
+
+	// Delete - deletes a file or a directory, non empty directories
+	// only if recursive. Returns false if nothing was deleted.
+	Delete(p string, recursive bool) (bool, error)
+
+	// Rename - moves src to dst replacing any existing file, the
+	// parent directory of dst has to exist.
+	Rename(src, dst string) error
+
+	// Concat - appends sources to target in order and deletes them,
+	// all files have to be in the same directory.
+	Concat(target string, sources []string) error
+}
+
+// hdfsNativeClient - hdfsClient talking the native HDFS protocol to
+// the namenode and the datanodes. Operations the hdfs package does not
+// offer are sent to the namenode directly.
+type hdfsNativeClient struct {
+	client   *hdfs.Client
+	namenode *rpc.NamenodeConnection
+}
+
+// Connects to the namenode at address, requests are sent as user
+// using simple authentication. Without user, HADOOP_USER_NAME or the
+// current user is used like by the hadoop command.
+func newHDFSClient(address, user string) (*hdfsNativeClient, error) {
+	if user == "" {
+		var err error
+		if user, err = hdfs.Username(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	namenode, err := rpc.NewNamenodeConnectionWithOptions(rpc.NamenodeConnectionOptions{
+		Addresses: []string{address},
+		User:      user,
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	client, err := hdfs.NewClient(hdfs.ClientOptions{
+		Addresses: []string{address},
+		Namenode:  namenode,
+		User:      user,
+	})
+	if err != nil {
+		namenode.Close()
+		return nil, errors.Trace(err)
+	}
+	return &hdfsNativeClient{client: client, namenode: namenode}, nil
+}
+
+func (c *hdfsNativeClient) Stat(p string) (os.FileInfo, error) {
+	fi, err := c.client.Stat(p)
+	return fi, errors.Trace(err)
+}
+
+func (c *hdfsNativeClient) ReadDir(p string) ([]os.FileInfo, error) {
+	fis, err := c.client.ReadDir(p)
+	return fis, errors.Trace(err)
+}
+
+func (c *hdfsNativeClient) MkdirAll(p string) error {
+	return errors.Trace(c.client.MkdirAll(p, 0755))
+}
+
+func (c *hdfsNativeClient) Create(ctx context.Context, p string, reader io.Reader) error {
+	if err := c.client.MkdirAll(path.Dir(p), 0755); err != nil {
+		return errors.Trace(err)
+	}
+	writer, err := c.client.Create(p)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err = io.Copy(writer, reader); err == nil {
+		// Do not complete the file if the client is gone.
+		err = ctx.Err()
+	}
+	if err != nil {
+		writer.Close()
+		return errors.Trace(err)
+	}
+	return errors.Trace(writer.Close())
+}
+
+func (c *hdfsNativeClient) Open(p string, offset, length int64) (io.ReadCloser, error) {
+	reader, err := c.client.Open(p)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if reader.Stat().IsDir() {
+		reader.Close()
+		return nil, errors.Trace(&os.PathError{Op: "open", Path: p, Err: os.ErrNotExist})
+	}
+	if _, err = reader.Seek(offset, io.SeekStart); err != nil {
+		reader.Close()
+		return nil, errors.Trace(err)
+	}
+	if length < 0 {
+		return reader, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(reader, length), reader}, nil
+}
+
+// Delete - the hdfs package only deletes recursively, so the request
+// is sent to the namenode.
+func (c *hdfsNativeClient) Delete(p string, recursive bool) (bool, error) {
+	req := &hdfsproto.DeleteRequestProto{
+		Src:       proto.String(p),
+		Recursive: proto.Bool(recursive),
+	}
+	resp := &hdfsproto.DeleteResponseProto{}
+	if err := c.namenode.Execute("delete", req, resp); err != nil {
+		return false, errors.Trace(err)
+	}
+	return resp.GetResult(), nil
+}
+
+func (c *hdfsNativeClient) Rename(src, dst string) error {
+	return errors.Trace(c.client.Rename(src, dst))
+}
+
+// Concat - the hdfs package does not concatenate files, so the
+// request is sent to the namenode.
+func (c *hdfsNativeClient) Concat(target string, sources []string) error {
+	req := &hdfsproto.ConcatRequestProto{
+		Trg:  proto.String(target),
+		Srcs: sources,
+	}
+	resp := &hdfsproto.ConcatResponseProto{}
+	return errors.Trace(c.namenode.Execute("concat", req, resp))
+}
@@ -0,0 +1,938 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hdfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/colinmarc/hdfs/rpc"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	minio "github.com/minio/minio/cmd"
+	"github.com/minio/minio/pkg/auth"
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+const (
+	hdfsBackend = "hdfs"
+
+	// Path where multipart uploads are saved in every bucket.
+	hdfsMinioMultipartPathV1 = minio.GatewayMinioSysTmp + "multipart/v1"
+
+	// Path where objects are written before being renamed.
+	hdfsMinioTmpPath = minio.GatewayMinioSysTmp + "tmp"
+
+	// Multipart meta file.
+	hdfsMinioMultipartMeta = "hdfs.json"
+
+	// hdfs.json version number
+	hdfsMinioMultipartMetaCurrentVersion = "1"
+
+	// Every 24 hours we scan minio.sys.tmp to delete expired multiparts in minio.sys.tmp
+	hdfsCleanupInterval = time.Hour * 24
+
+	// The cleanup routine deletes files older than 2 weeks in minio.sys.tmp
+	hdfsMultipartExpiry = time.Hour * 24 * 14
+)
+
+var (
+	// Unknown version of hdfs.json.
+	errHDFSFormat = fmt.Errorf("Unknown format")
+)
+
+func init() {
+	const hdfsGatewayTemplate = `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} {{if .VisibleFlags}}[FLAGS]{{end}} NAMENODE
+{{if .VisibleFlags}}
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}{{end}}
+NAMENODE:
+  RPC address of the HDFS namenode, like namenode:8020 or hdfs://namenode:8020
+
+ENVIRONMENT VARIABLES:
+  ACCESS:
+     MINIO_ACCESS_KEY: Custom username or access key of minimum 3 characters in length.
+     MINIO_SECRET_KEY: Custom password or secret key of minimum 8 characters in length.
+
+  BROWSER:
+     MINIO_BROWSER: To disable web browser access, set this value to "off".
+
+  UPDATE:
+     MINIO_UPDATE: To turn off in-place upgrades, set this value to "off".
+
+  HDFS:
+     HDFS_USER: HDFS user to access files as, using simple authentication. (HADOOP_USER_NAME or the current user)
+     HDFS_ROOT: HDFS directory whose sub-directories are served as buckets. (/)
+
+EXAMPLES:
+  1. Start minio gateway server for HDFS backend.
+      $ export MINIO_ACCESS_KEY=accesskey
+      $ export MINIO_SECRET_KEY=secretkey
+      $ export HDFS_USER=hdfs
+      $ {{.HelpName}} namenode:8020
+
+  2. Start minio gateway server for HDFS backend serving the home directory of a user.
+      $ export MINIO_ACCESS_KEY=accesskey
+      $ export MINIO_SECRET_KEY=secretkey
+      $ export HDFS_USER=analytics
+      $ export HDFS_ROOT=/user/analytics
+      $ {{.HelpName}} namenode:8020
+
+`
+
+	minio.RegisterGatewayCommand(cli.Command{
+		Name:               hdfsBackend,
+		Usage:              "Hadoop Distributed File System (HDFS).",
+		Action:             hdfsGatewayMain,
+		CustomHelpTemplate: hdfsGatewayTemplate,
+		HideHelpCommand:    true,
+	})
+}
+
+// Handler for 'minio gateway hdfs' command line.
+func hdfsGatewayMain(ctx *cli.Context) {
+	// Validate gateway arguments.
+	host := strings.TrimPrefix(ctx.Args().First(), "hdfs://")
+	if host == "" {
+		cli.ShowCommandHelpAndExit(ctx, hdfsBackend, 1)
+	}
+	// Validate gateway arguments.
+	minio.FatalIf(minio.ValidateGatewayArguments(ctx.GlobalString("address"), host), "Invalid argument")
+
+	minio.StartGateway(ctx, &HDFS{host})
+}
+
+// HDFS implements Gateway.
+type HDFS struct {
+	host string
+}
+
+// Name implements Gateway interface.
+func (g *HDFS) Name() string {
+	return hdfsBackend
+}
+
+// NewGatewayLayer returns hdfs gateway layer, implements ObjectLayer interface to
+// talk to the namenode and the datanodes with the native HDFS protocol.
+func (g *HDFS) NewGatewayLayer(creds auth.Credentials) (minio.ObjectLayer, error) {
+	root := os.Getenv("HDFS_ROOT")
+	if root == "" {
+		root = "/"
+	}
+	if !path.IsAbs(root) {
+		return nil, fmt.Errorf("HDFS root %s is not an absolute path", root)
+	}
+
+	client, err := newHDFSClient(g.host, os.Getenv("HDFS_USER"))
+	if err != nil {
+		return nil, err
+	}
+
+	l := &hdfsObjects{
+		client: client,
+		root:   path.Clean(root),
+	}
+	go l.CleanupHDFSMinioSysTmp()
+	return l, nil
+}
+
+// Production - hdfs gateway is not production ready.
+func (g *HDFS) Production() bool {
+	return false
+}
+
+// Stored in hdfs.json, keeps the upload to complete it.
+type hdfsMultipartMetaV1 struct {
+	Version string `json:"version"` // Version number
+	Bucket  string `json:"bucket"`  // Bucket name
+	Object  string `json:"object"`  // Object name
+}
+
+// hdfsObjects - Implements Object layer for HDFS.
+type hdfsObjects struct {
+	minio.GatewayUnsupported
+	client hdfsClient
+	root   string
+}
+
+// Returns the HDFS path of a bucket.
+func (l *hdfsObjects) bucketPath(bucket string) string {
+	return path.Join(l.root, bucket)
+}
+
+// Returns the HDFS path of an object.
+func (l *hdfsObjects) objectPath(bucket, object string) string {
+	return path.Join(l.root, bucket, object)
+}
+
+// Returns the HDFS path of the directory of a multipart upload.
+func (l *hdfsObjects) uploadIDPath(bucket, uploadID string) string {
+	return path.Join(l.root, bucket, hdfsMinioMultipartPathV1, uploadID)
+}
+
+// Returns the name of a part file in the directory of its upload.
+func hdfsPartName(partNumber int, etag string) string {
+	return fmt.Sprintf("%05d.%s", partNumber, etag)
+}
+
+// Returns the part number and etag of a part file, false if the file
+// is no part.
+func hdfsParsePartName(name string) (int, string, bool) {
+	i := strings.Index(name, ".")
+	if i < 0 {
+		return 0, "", false
+	}
+	partNumber, err := strconv.Atoi(name[:i])
+	if err != nil {
+		return 0, "", false
+	}
+	return partNumber, name[i+1:], true
+}
+
+// Returns the modification time of a file.
+func hdfsModTime(fi os.FileInfo) time.Time {
+	return fi.ModTime().UTC()
+}
+
+// Returns the ETag of a file. HDFS keeps no checksum of whole files
+// which is cheap to read, so ETags are derived from the size and the
+// modification time. They are suffixed like multipart ETags so that
+// clients do not compare them to the MD5 sum of the data.
+func hdfsETag(fi os.FileInfo) string {
+	mtime := fi.ModTime().UnixNano() / int64(time.Millisecond)
+	sum := md5.Sum([]byte(fmt.Sprintf("%d.%d", fi.Size(), mtime)))
+	return hex.EncodeToString(sum[:]) + "-1"
+}
+
+// Returns the object info of a file.
+func hdfsToObjectInfo(bucket, object string, fi os.FileInfo) minio.ObjectInfo {
+	return minio.ObjectInfo{
+		Bucket:  bucket,
+		Name:    object,
+		ModTime: hdfsModTime(fi),
+		Size:    fi.Size(),
+		ETag:    hdfsETag(fi),
+		IsDir:   fi.IsDir(),
+	}
+}
+
+// hdfsToObjectError - converts HDFS errors to object layer errors,
+// params are the bucket and object names if any.
+func hdfsToObjectError(err error, params ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	e, ok := err.(*errors.Error)
+	if !ok {
+		// Code should be fixed if this function is called without doing errors.Trace()
+		// Else handling different situations in this function makes this function complicated.
+		minio.ErrorIf(err, "Expected type *Error")
+		return err
+	}
+
+	bucket := ""
+	object := ""
+	if len(params) >= 1 {
+		bucket = params[0]
+	}
+	if len(params) == 2 {
+		object = params[1]
+	}
+
+	switch hdfsException(e.Cause) {
+	case "AccessControlException", "SecurityException", "ParentNotDirectoryException":
+		err = minio.PrefixAccessDenied{Bucket: bucket, Object: object}
+	case "FileNotFoundException":
+		if object != "" {
+			err = minio.ObjectNotFound{Bucket: bucket, Object: object}
+		} else {
+			err = minio.BucketNotFound{Bucket: bucket}
+		}
+	case "FileAlreadyExistsException":
+		if object != "" {
+			err = minio.ObjectExistsAsDirectory{Bucket: bucket, Object: object}
+		} else {
+			err = minio.BucketExists{Bucket: bucket}
+		}
+	case "PathIsNotEmptyDirectoryException":
+		err = minio.BucketNotEmpty{Bucket: bucket}
+	case "DSQuotaExceededException", "NSQuotaExceededException", "QuotaExceededException":
+		err = minio.StorageFull{}
+	default:
+		return e
+	}
+
+	e.Cause = err
+	return e
+}
+
+// Returns the Java class name without its package of the exception
+// behind an HDFS error, errors the hdfs package translated are mapped
+// back to their exceptions.
+func hdfsException(err error) string {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+	switch err {
+	case os.ErrNotExist:
+		return "FileNotFoundException"
+	case os.ErrPermission:
+		return "AccessControlException"
+	case os.ErrExist:
+		return "FileAlreadyExistsException"
+	}
+	if nnErr, ok := err.(*rpc.NamenodeError); ok {
+		return nnErr.Exception[strings.LastIndex(nnErr.Exception, ".")+1:]
+	}
+	return ""
+}
+
+// Returns true if err is an HDFS file not found error.
+func isHDFSNotFound(err error) bool {
+	return hdfsException(errors.Cause(err)) == "FileNotFoundException"
+}
+
+// Shutdown - save any gateway metadata to disk
+// if necessary and reload upon next restart.
+func (l *hdfsObjects) Shutdown() error {
+	return nil
+}
+
+// StorageInfo - Not relevant to HDFS backend.
+func (l *hdfsObjects) StorageInfo() (si minio.StorageInfo) {
+	return si
+}
+
+// MakeBucketWithLocation - Create a new directory in the HDFS root.
+func (l *hdfsObjects) MakeBucketWithLocation(bucket, location string) error {
+	if !minio.IsValidBucketName(bucket) {
+		return errors.Trace(minio.BucketNameInvalid{Bucket: bucket})
+	}
+	if _, err := l.client.Stat(l.bucketPath(bucket)); err == nil {
+		return errors.Trace(minio.BucketExists{Bucket: bucket})
+	} else if !isHDFSNotFound(err) {
+		return hdfsToObjectError(err, bucket)
+	}
+	return hdfsToObjectError(l.client.MkdirAll(l.bucketPath(bucket)), bucket)
+}
+
+// GetBucketInfo - Get directory metadata.
+func (l *hdfsObjects) GetBucketInfo(bucket string) (bi minio.BucketInfo, e error) {
+	fi, err := l.client.Stat(l.bucketPath(bucket))
+	if err != nil {
+		return bi, hdfsToObjectError(err, bucket)
+	}
+	if !fi.IsDir() {
+		return bi, errors.Trace(minio.BucketNotFound{Bucket: bucket})
+	}
+	return minio.BucketInfo{
+		Name:    bucket,
+		Created: hdfsModTime(fi),
+	}, nil
+}
+
+// ListBuckets - Lists all directories in the HDFS root, directories
+// which are no valid bucket names are skipped.
+func (l *hdfsObjects) ListBuckets() (buckets []minio.BucketInfo, err error) {
+	entries, err := l.client.ReadDir(l.root)
+	if err != nil {
+		return nil, hdfsToObjectError(err)
+	}
+	for _, fi := range entries {
+		if !fi.IsDir() || !minio.IsValidBucketName(fi.Name()) {
+			continue
+		}
+		buckets = append(buckets, minio.BucketInfo{
+			Name:    fi.Name(),
+			Created: hdfsModTime(fi),
+		})
+	}
+	return buckets, nil
+}
+
+// DeleteBucket - Delete a directory in the HDFS root if it is empty,
+// ongoing multipart uploads are removed too.
+func (l *hdfsObjects) DeleteBucket(bucket string) error {
+	entries, err := l.client.ReadDir(l.bucketPath(bucket))
+	if err != nil {
+		return hdfsToObjectError(err, bucket)
+	}
+	for _, fi := range entries {
+		if fi.Name()+"/" != minio.GatewayMinioSysTmp {
+			return errors.Trace(minio.BucketNotEmpty{Bucket: bucket})
+		}
+	}
+	_, err = l.client.Delete(l.bucketPath(bucket), true)
+	return hdfsToObjectError(err, bucket)
+}
+
+// hdfsWalker - lists objects of a bucket in the order of their keys.
+type hdfsWalker struct {
+	l         *hdfsObjects
+	bucket    string
+	prefix    string
+	marker    string
+	recursive bool
+	maxKeys   int
+	result    minio.ListObjectsInfo
+}
+
+// walk - lists the entries of dir, a directory of the bucket ending
+// with a slash or empty for the bucket itself. Keys of directories
+// end with a slash and are sorted accordingly, like in S3. Returns
+// true once maxKeys entries are found and there are more.
+func (w *hdfsWalker) walk(dir string) (bool, error) {
+	entries, err := w.l.client.ReadDir(w.l.objectPath(w.bucket, dir))
+	if err != nil {
+		return false, err
+	}
+	keys := make([]string, 0, len(entries))
+	fis := make(map[string]os.FileInfo, len(entries))
+	for _, fi := range entries {
+		// Listing a file returns the file itself.
+		if fi.Name() == "" {
+			continue
+		}
+		key := dir + fi.Name()
+		if fi.IsDir() {
+			key += "/"
+		}
+		// We don't return our metadata prefix.
+		if key == minio.GatewayMinioSysTmp {
+			continue
+		}
+		if !strings.HasPrefix(key, w.prefix) {
+			continue
+		}
+		keys = append(keys, key)
+		fis[key] = fi
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fi := fis[key]
+		isDir := fi.IsDir()
+		if isDir && w.recursive {
+			// Skip directories whose keys are all before the marker.
+			if key <= w.marker && !strings.HasPrefix(w.marker, key) {
+				continue
+			}
+			truncated, err := w.walk(key)
+			if truncated || err != nil {
+				return truncated, err
+			}
+			continue
+		}
+		if key <= w.marker {
+			continue
+		}
+		if len(w.result.Objects)+len(w.result.Prefixes) == w.maxKeys {
+			return true, nil
+		}
+		if isDir {
+			w.result.Prefixes = append(w.result.Prefixes, key)
+		} else {
+			w.result.Objects = append(w.result.Objects, hdfsToObjectInfo(w.bucket, key, fi))
+		}
+		w.result.NextMarker = key
+	}
+	return false, nil
+}
+
+// ListObjects - Lists all objects of a bucket filtered by prefix and
+// marker, by listing the directories of the bucket.
+func (l *hdfsObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (loi minio.ListObjectsInfo, err error) {
+	if delimiter != "" && delimiter != "/" {
+		return loi, errors.Trace(minio.UnsupportedDelimiter{Delimiter: delimiter})
+	}
+	if maxKeys == 0 {
+		return loi, nil
+	}
+
+	w := &hdfsWalker{
+		l:         l,
+		bucket:    bucket,
+		prefix:    prefix,
+		marker:    marker,
+		recursive: delimiter == "",
+		maxKeys:   maxKeys,
+	}
+
+	// Start listing at the directory containing prefix.
+	dir := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = prefix[:i+1]
+	}
+	truncated, err := w.walk(dir)
+	if err != nil {
+		if dir == "" || !isHDFSNotFound(err) {
+			return loi, hdfsToObjectError(err, bucket)
+		}
+		// Prefix does not exist, make sure the bucket does.
+		if _, err = l.GetBucketInfo(bucket); err != nil {
+			return loi, err
+		}
+		return loi, nil
+	}
+
+	w.result.IsTruncated = truncated
+	if !truncated {
+		w.result.NextMarker = ""
+	}
+	return w.result, nil
+}
+
+// ListObjectsV2 - Lists all objects of a bucket filtered by prefix and
+// continuationToken, keys are used as continuation tokens.
+func (l *hdfsObjects) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, fetchOwner bool, startAfter string) (result minio.ListObjectsV2Info, err error) {
+	marker := continuationToken
+	if marker == "" {
+		marker = startAfter
+	}
+
+	resultV1, err := l.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err != nil {
+		return result, err
+	}
+
+	result.Objects = resultV1.Objects
+	result.Prefixes = resultV1.Prefixes
+	result.ContinuationToken = continuationToken
+	result.NextContinuationToken = resultV1.NextMarker
+	result.IsTruncated = resultV1.IsTruncated
+	return result, nil
+}
+
+// GetObject - Reads an object from HDFS. Supports additional parameters like
+// offset and length which are synonymous with HTTP Range requests.
+//
+// startOffset indicates the starting read location of the object.  length
+// indicates the total length of the object.
+func (l *hdfsObjects) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer, etag string) error {
+	if startOffset < 0 {
+		return errors.Trace(minio.InvalidRange{})
+	}
+	if length == 0 {
+		return nil
+	}
+
+	reader, err := l.client.Open(l.objectPath(bucket, object), startOffset, length)
+	if err != nil {
+		return hdfsToObjectError(err, bucket, object)
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(writer, reader)
+	return errors.Trace(err)
+}
+
+// GetObjectInfo - reads the file status and replies back minio.ObjectInfo.
+func (l *hdfsObjects) GetObjectInfo(bucket, object string) (objInfo minio.ObjectInfo, err error) {
+	fi, err := l.client.Stat(l.objectPath(bucket, object))
+	if err != nil {
+		return objInfo, hdfsToObjectError(err, bucket, object)
+	}
+	// Directories are only objects if requested with a trailing slash.
+	if fi.IsDir() != strings.HasSuffix(object, "/") {
+		return objInfo, errors.Trace(minio.ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	return hdfsToObjectInfo(bucket, object, fi), nil
+}
+
+// Writes data to a temporary file of bucket, returns its path. The
+// file is removed on failure.
+func (l *hdfsObjects) writeTmpFile(ctx context.Context, bucket string, data *hash.Reader) (string, error) {
+	tmpPath := path.Join(l.bucketPath(bucket), hdfsMinioTmpPath, minio.MustGetUUID())
+	err := l.client.Create(ctx, tmpPath, data)
+	if err == nil {
+		err = errors.Trace(data.Verify())
+	}
+	if err != nil {
+		l.client.Delete(tmpPath, false)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+// Renames a file to an object, creating the parent directories of the
+// object.
+func (l *hdfsObjects) renameToObject(src, bucket, object string) error {
+	objectPath := l.objectPath(bucket, object)
+	if err := l.client.MkdirAll(path.Dir(objectPath)); err != nil {
+		return hdfsToObjectError(err, bucket, object)
+	}
+	return hdfsToObjectError(l.client.Rename(src, objectPath), bucket, object)
+}
+
+// PutObject - Create a new file with the incoming data. The data is
+// written to a temporary file first which is renamed to the object,
+// readers never see partially written objects. Metadata is not kept.
+func (l *hdfsObjects) PutObject(bucket, object string, data *hash.Reader, metadata map[string]string) (objInfo minio.ObjectInfo, err error) {
+	if _, err = l.GetBucketInfo(bucket); err != nil {
+		return objInfo, err
+	}
+
+	// Objects ending with a slash are directories.
+	if strings.HasSuffix(object, "/") {
+		if err = l.client.MkdirAll(l.objectPath(bucket, object)); err != nil {
+			return objInfo, hdfsToObjectError(err, bucket, object)
+		}
+		return l.GetObjectInfo(bucket, object)
+	}
+
+	tmpPath, err := l.writeTmpFile(context.Background(), bucket, data)
+	if err != nil {
+		return objInfo, hdfsToObjectError(err, bucket, object)
+	}
+	if err = l.renameToObject(tmpPath, bucket, object); err != nil {
+		l.client.Delete(tmpPath, false)
+		return objInfo, err
+	}
+	return l.GetObjectInfo(bucket, object)
+}
+
+// CopyObject - Copies a file by reading and writing it, HDFS cannot
+// copy files on the namenode.
+func (l *hdfsObjects) CopyObject(srcBucket, srcObject, destBucket, destObject string, srcInfo minio.ObjectInfo) (objInfo minio.ObjectInfo, err error) {
+	// Metadata is not kept, copying an object to itself changes nothing.
+	if srcBucket == destBucket && srcObject == destObject {
+		return l.GetObjectInfo(srcBucket, srcObject)
+	}
+
+	go func() {
+		if gerr := l.GetObject(srcBucket, srcObject, 0, srcInfo.Size, srcInfo.Writer, srcInfo.ETag); gerr != nil {
+			if gerr = srcInfo.Writer.Close(); gerr != nil {
+				minio.ErrorIf(gerr, "Unable to read %s/%s.", srcBucket, srcObject)
+			}
+			return
+		}
+		// Close writer explicitly signalling we wrote all data.
+		if gerr := srcInfo.Writer.Close(); gerr != nil {
+			minio.ErrorIf(gerr, "Unable to read %s/%s.", srcBucket, srcObject)
+		}
+	}()
+	return l.PutObject(destBucket, destObject, srcInfo.Reader, srcInfo.UserDefined)
+}
+
+// DeleteObject - Delete a file, then parent directories left empty up
+// to the bucket so that they are not listed as prefixes.
+func (l *hdfsObjects) DeleteObject(bucket, object string) error {
+	deleted, err := l.client.Delete(l.objectPath(bucket, object), false)
+	if err != nil {
+		return hdfsToObjectError(err, bucket, object)
+	}
+	if !deleted {
+		return errors.Trace(minio.ObjectNotFound{Bucket: bucket, Object: object})
+	}
+
+	bucketPath := l.bucketPath(bucket)
+	for dir := path.Dir(l.objectPath(bucket, object)); dir != bucketPath && strings.HasPrefix(dir, bucketPath); dir = path.Dir(dir) {
+		// Directories which are not empty fail to be deleted.
+		if deleted, err = l.client.Delete(dir, false); err != nil || !deleted {
+			break
+		}
+	}
+	return nil
+}
+
+// DeleteObjects - deletes a list of objects one after another.
+func (l *hdfsObjects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	errs := make([]error, len(objects))
+	for idx, object := range objects {
+		errs[idx] = l.DeleteObject(bucket, object)
+	}
+	return errs, nil
+}
+
+// NewMultipartUpload - upload object in multiple parts, parts are kept
+// as files in the directory of the upload.
+func (l *hdfsObjects) NewMultipartUpload(bucket string, object string, metadata map[string]string) (uploadID string, err error) {
+	if _, err = l.GetBucketInfo(bucket); err != nil {
+		return "", err
+	}
+
+	uploadID = minio.MustGetUUID()
+	buf, err := json.Marshal(hdfsMultipartMetaV1{
+		hdfsMinioMultipartMetaCurrentVersion,
+		bucket,
+		object,
+	})
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	metaPath := path.Join(l.uploadIDPath(bucket, uploadID), hdfsMinioMultipartMeta)
+	if err = l.client.Create(context.Background(), metaPath, bytes.NewReader(buf)); err != nil {
+		return "", hdfsToObjectError(err, bucket, object)
+	}
+	return uploadID, nil
+}
+
+// ListMultipartUploads - lists all multipart uploads.
+func (l *hdfsObjects) ListMultipartUploads(bucket string, prefix string, keyMarker string, uploadIDMarker string, delimiter string, maxUploads int) (minio.ListMultipartsInfo, error) {
+	return minio.ListMultipartsInfo{
+		KeyMarker:      keyMarker,
+		UploadIDMarker: uploadIDMarker,
+		MaxUploads:     maxUploads,
+		Prefix:         prefix,
+		Delimiter:      delimiter,
+	}, nil
+}
+
+// Reads hdfs.json of an upload, returns an object layer compatible
+// error upon any error.
+func (l *hdfsObjects) getMultipartMeta(bucket, object, uploadID string) (meta hdfsMultipartMetaV1, err error) {
+	reader, err := l.client.Open(path.Join(l.uploadIDPath(bucket, uploadID), hdfsMinioMultipartMeta), 0, -1)
+	if err != nil {
+		if isHDFSNotFound(err) {
+			return meta, errors.Trace(minio.InvalidUploadID{UploadID: uploadID})
+		}
+		return meta, hdfsToObjectError(err, bucket, object)
+	}
+	defer reader.Close()
+
+	if err = json.NewDecoder(reader).Decode(&meta); err != nil {
+		return meta, errors.Trace(err)
+	}
+	if meta.Version != hdfsMinioMultipartMetaCurrentVersion {
+		return meta, errors.Trace(errHDFSFormat)
+	}
+	// Validate if the hdfs.json stores valid entries for the bucket and key.
+	if meta.Bucket != bucket || meta.Object != object {
+		return meta, errors.Trace(minio.InvalidUploadID{UploadID: uploadID})
+	}
+	return meta, nil
+}
+
+// PutObjectPart puts a part of object in bucket, writing stops once
+// the client disconnects.
+func (l *hdfsObjects) PutObjectPart(ctx context.Context, bucket string, object string, uploadID string, partID int, data *hash.Reader) (pi minio.PartInfo, err error) {
+	if _, err = l.getMultipartMeta(bucket, object, uploadID); err != nil {
+		return pi, err
+	}
+
+	tmpPath, err := l.writeTmpFile(ctx, bucket, data)
+	if err != nil {
+		return pi, hdfsToObjectError(err, bucket, object)
+	}
+	etag := hex.EncodeToString(data.MD5Current())
+	partPath := path.Join(l.uploadIDPath(bucket, uploadID), hdfsPartName(partID, etag))
+	if err = l.client.Rename(tmpPath, partPath); err != nil {
+		l.client.Delete(tmpPath, false)
+		return pi, hdfsToObjectError(err, bucket, object)
+	}
+	return minio.PartInfo{
+		PartNumber:   partID,
+		ETag:         etag,
+		LastModified: minio.UTCNow(),
+		Size:         data.Size(),
+	}, nil
+}
+
+// Returns the latest uploaded file of every part of an upload, sorted
+// by part number.
+func (l *hdfsObjects) listParts(bucket, object, uploadID string) ([]minio.PartInfo, error) {
+	entries, err := l.client.ReadDir(l.uploadIDPath(bucket, uploadID))
+	if err != nil {
+		return nil, hdfsToObjectError(err, bucket, object)
+	}
+	latest := make(map[int]os.FileInfo)
+	for _, fi := range entries {
+		partNumber, _, ok := hdfsParsePartName(fi.Name())
+		if !ok {
+			continue
+		}
+		if last, ok := latest[partNumber]; !ok || !fi.ModTime().Before(last.ModTime()) {
+			latest[partNumber] = fi
+		}
+	}
+
+	parts := make([]minio.PartInfo, 0, len(latest))
+	for partNumber, fi := range latest {
+		_, etag, _ := hdfsParsePartName(fi.Name())
+		parts = append(parts, minio.PartInfo{
+			PartNumber:   partNumber,
+			LastModified: hdfsModTime(fi),
+			ETag:         etag,
+			Size:         fi.Size(),
+		})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	return parts, nil
+}
+
+// ListObjectParts returns all object parts for specified object in specified bucket
+func (l *hdfsObjects) ListObjectParts(bucket string, object string, uploadID string, partNumberMarker int, maxParts int) (lpi minio.ListPartsInfo, err error) {
+	if _, err = l.getMultipartMeta(bucket, object, uploadID); err != nil {
+		return lpi, err
+	}
+	parts, err := l.listParts(bucket, object, uploadID)
+	if err != nil {
+		return lpi, err
+	}
+
+	lpi = minio.ListPartsInfo{
+		Bucket:           bucket,
+		Object:           object,
+		UploadID:         uploadID,
+		PartNumberMarker: partNumberMarker,
+		MaxParts:         maxParts,
+	}
+	for _, part := range parts {
+		if part.PartNumber <= partNumberMarker {
+			continue
+		}
+		if len(lpi.Parts) == maxParts {
+			lpi.IsTruncated = true
+			break
+		}
+		lpi.Parts = append(lpi.Parts, part)
+		lpi.NextPartNumberMarker = part.PartNumber
+	}
+	return lpi, nil
+}
+
+// AbortMultipartUpload aborts a ongoing multipart upload
+func (l *hdfsObjects) AbortMultipartUpload(bucket string, object string, uploadID string) error {
+	if _, err := l.getMultipartMeta(bucket, object, uploadID); err != nil {
+		return err
+	}
+	_, err := l.client.Delete(l.uploadIDPath(bucket, uploadID), true)
+	return hdfsToObjectError(err, bucket, object)
+}
+
+// CompleteMultipartUpload completes ongoing multipart upload and
+// finalizes object. The parts are concatenated into the first part
+// on the namenode which is renamed to the object, HDFS 2.7 or later
+// is required to concatenate parts not filling whole blocks.
+func (l *hdfsObjects) CompleteMultipartUpload(ctx context.Context, bucket string, object string, uploadID string, uploadedParts []minio.CompletePart) (objInfo minio.ObjectInfo, err error) {
+	if _, err = l.getMultipartMeta(bucket, object, uploadID); err != nil {
+		return objInfo, err
+	}
+
+	uploadIDPath := l.uploadIDPath(bucket, uploadID)
+	var partPaths []string
+	for i, uploadedPart := range uploadedParts {
+		partPath := path.Join(uploadIDPath, hdfsPartName(uploadedPart.PartNumber, minio.CanonicalizeETag(uploadedPart.ETag)))
+		fi, err := l.client.Stat(partPath)
+		if err != nil {
+			if isHDFSNotFound(err) {
+				return objInfo, errors.Trace(minio.InvalidPart{})
+			}
+			return objInfo, hdfsToObjectError(err, bucket, object)
+		}
+		// Error out if parts except last part sizing < 5MiB.
+		if i < len(uploadedParts)-1 && fi.Size() < 5*humanize.MiByte {
+			return objInfo, errors.Trace(minio.PartTooSmall{
+				PartNumber: uploadedPart.PartNumber,
+				PartSize:   fi.Size(),
+				PartETag:   uploadedPart.ETag,
+			})
+		}
+		// Empty files cannot be concatenated, only the last part
+		// may be empty.
+		if fi.Size() > 0 {
+			partPaths = append(partPaths, partPath)
+		}
+	}
+
+	// Keep the upload if the client disconnected before the object is
+	// created, it can be completed again.
+	select {
+	case <-ctx.Done():
+		return objInfo, errors.Trace(ctx.Err())
+	default:
+	}
+
+	var target string
+	switch len(partPaths) {
+	case 0:
+		target = path.Join(uploadIDPath, minio.MustGetUUID())
+		if err = l.client.Create(ctx, target, bytes.NewReader(nil)); err != nil {
+			return objInfo, hdfsToObjectError(err, bucket, object)
+		}
+	case 1:
+		target = partPaths[0]
+	default:
+		target = partPaths[0]
+		if err = l.client.Concat(target, partPaths[1:]); err != nil {
+			return objInfo, hdfsToObjectError(err, bucket, object)
+		}
+	}
+
+	if err = l.renameToObject(target, bucket, object); err != nil {
+		return objInfo, err
+	}
+	if _, err = l.client.Delete(uploadIDPath, true); err != nil {
+		minio.ErrorIf(err, "Unable to remove multipart upload %s of %s/%s.", uploadID, bucket, object)
+	}
+	return l.GetObjectInfo(bucket, object)
+}
+
+// Removes files and directories in dir of a bucket's minio.sys.tmp
+// which were not modified for hdfsMultipartExpiry.
+func (l *hdfsObjects) cleanupMinioSysTmpDir(dir string) {
+	entries, err := l.client.ReadDir(dir)
+	if err != nil {
+		if !isHDFSNotFound(err) {
+			minio.ErrorIf(err, "Unable to list %s during purging of old files in minio.sys.tmp", dir)
+		}
+		return
+	}
+	for _, fi := range entries {
+		if time.Since(hdfsModTime(fi)) > hdfsMultipartExpiry {
+			// Delete files older than 2 weeks.
+			if _, err = l.client.Delete(path.Join(dir, fi.Name()), true); err != nil {
+				minio.ErrorIf(err, "Unable to delete %s/%s during purging of old files in minio.sys.tmp", dir, fi.Name())
+			}
+		}
+	}
+}
+
+// CleanupHDFSMinioSysTmp - removes old multipart uploads and temporary
+// files in minio.sys.tmp of all buckets.
+func (l *hdfsObjects) CleanupHDFSMinioSysTmp() {
+	for {
+		buckets, err := l.ListBuckets()
+		if err != nil {
+			minio.ErrorIf(err, "Bucket listing error during purging of old files in minio.sys.tmp")
+		}
+		for _, bucket := range buckets {
+			l.cleanupMinioSysTmpDir(path.Join(l.bucketPath(bucket.Name), hdfsMinioMultipartPathV1))
+			l.cleanupMinioSysTmpDir(path.Join(l.bucketPath(bucket.Name), hdfsMinioTmpPath))
+		}
+		// Run the cleanup loop every 1 day.
+		time.Sleep(hdfsCleanupInterval)
+	}
+}
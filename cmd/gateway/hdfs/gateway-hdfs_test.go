@@ -0,0 +1,450 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hdfs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/colinmarc/hdfs/rpc"
+	humanize "github.com/dustin/go-humanize"
+	minio "github.com/minio/minio/cmd"
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+// fakeHDFSFile - file or directory of fakeHDFS.
+type fakeHDFSFile struct {
+	dir   bool
+	data  []byte
+	mtime int64
+}
+
+// fakeHDFSFileInfo - os.FileInfo of a fakeHDFSFile.
+type fakeHDFSFileInfo struct {
+	name string
+	file *fakeHDFSFile
+}
+
+func (fi fakeHDFSFileInfo) Name() string {
+	return fi.name
+}
+
+func (fi fakeHDFSFileInfo) Size() int64 {
+	if fi.file.dir {
+		return 0
+	}
+	return int64(len(fi.file.data))
+}
+
+func (fi fakeHDFSFileInfo) Mode() os.FileMode {
+	if fi.file.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (fi fakeHDFSFileInfo) ModTime() time.Time {
+	return time.Unix(0, fi.file.mtime*int64(time.Millisecond))
+}
+
+func (fi fakeHDFSFileInfo) IsDir() bool {
+	return fi.file.dir
+}
+
+func (fi fakeHDFSFileInfo) Sys() interface{} {
+	return nil
+}
+
+// fakeHDFS - in memory hdfsClient, failing like the namenode does.
+type fakeHDFS struct {
+	mu    sync.Mutex
+	files map[string]*fakeHDFSFile
+	clock int64
+}
+
+func newFakeHDFS() *fakeHDFS {
+	return &fakeHDFS{files: map[string]*fakeHDFSFile{"/": {dir: true}}}
+}
+
+func fakeHDFSNotFound(op, p string) error {
+	return errors.Trace(&os.PathError{Op: op, Path: p, Err: os.ErrNotExist})
+}
+
+func fakeHDFSException(method, exception string) error {
+	return errors.Trace(&rpc.NamenodeError{Method: method, Exception: exception})
+}
+
+func (f *fakeHDFS) mkdirs(p string) {
+	for ; p != "/"; p = path.Dir(p) {
+		if _, ok := f.files[p]; !ok {
+			f.clock++
+			f.files[p] = &fakeHDFSFile{dir: true, mtime: f.clock}
+		}
+	}
+}
+
+func (f *fakeHDFS) Stat(p string) (os.FileInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file := f.files[p]
+	if file == nil {
+		return nil, fakeHDFSNotFound("stat", p)
+	}
+	return fakeHDFSFileInfo{path.Base(p), file}, nil
+}
+
+func (f *fakeHDFS) ReadDir(p string) ([]os.FileInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.files[p] == nil {
+		return nil, fakeHDFSNotFound("readdir", p)
+	}
+	fis := []os.FileInfo{}
+	for name, child := range f.files {
+		if name != "/" && path.Dir(name) == p {
+			fis = append(fis, fakeHDFSFileInfo{path.Base(name), child})
+		}
+	}
+	sort.Slice(fis, func(i, j int) bool { return fis[i].Name() < fis[j].Name() })
+	return fis, nil
+}
+
+func (f *fakeHDFS) MkdirAll(p string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mkdirs(p)
+	return nil
+}
+
+func (f *fakeHDFS) Create(ctx context.Context, p string, reader io.Reader) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.files[p] != nil {
+		return fakeHDFSException("create", "org.apache.hadoop.fs.FileAlreadyExistsException")
+	}
+	f.mkdirs(path.Dir(p))
+	f.clock++
+	f.files[p] = &fakeHDFSFile{data: data, mtime: f.clock}
+	return nil
+}
+
+func (f *fakeHDFS) Open(p string, offset, length int64) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file := f.files[p]
+	if file == nil || file.dir {
+		return nil, fakeHDFSNotFound("open", p)
+	}
+	data := file.data[offset:]
+	if length >= 0 {
+		data = data[:length]
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (f *fakeHDFS) Delete(p string, recursive bool) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.files[p] == nil {
+		return false, nil
+	}
+	for name := range f.files {
+		if strings.HasPrefix(name, p+"/") {
+			if !recursive {
+				return false, fakeHDFSException("delete", "org.apache.hadoop.fs.PathIsNotEmptyDirectoryException")
+			}
+			delete(f.files, name)
+		}
+	}
+	delete(f.files, p)
+	return true, nil
+}
+
+func (f *fakeHDFS) Rename(src, dst string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file := f.files[src]
+	if file == nil || file.dir || f.files[path.Dir(dst)] == nil {
+		return fakeHDFSNotFound("rename", src)
+	}
+	delete(f.files, src)
+	f.files[dst] = file
+	return nil
+}
+
+func (f *fakeHDFS) Concat(target string, sources []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file := f.files[target]
+	for _, source := range sources {
+		src := f.files[source]
+		if file == nil || src == nil || path.Dir(source) != path.Dir(target) {
+			return fakeHDFSException("concat", "org.apache.hadoop.HadoopIllegalArgumentException")
+		}
+		file.data = append(file.data, src.data...)
+		delete(f.files, source)
+	}
+	return nil
+}
+
+// Returns an hdfs gateway layer backed by a fake HDFS.
+func newTestHDFSObjects(t *testing.T) (*hdfsObjects, *fakeHDFS) {
+	fs := newFakeHDFS()
+	l := &hdfsObjects{
+		client: fs,
+		root:   "/",
+	}
+	return l, fs
+}
+
+func mustGetHashReader(t *testing.T, data []byte) *hash.Reader {
+	r, err := hash.NewReader(bytes.NewReader(data), int64(len(data)), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// Tests translation of HDFS errors to object layer errors.
+func TestHDFSToObjectError(t *testing.T) {
+	testCases := []struct {
+		params      []string
+		hdfsErr     error
+		expectedErr error
+	}{
+		{
+			[]string{}, nil, nil,
+		},
+		{
+			[]string{}, fmt.Errorf("Not *Error"), fmt.Errorf("Not *Error"),
+		},
+		{
+			[]string{"bucket"},
+			errors.Trace(&os.PathError{Op: "stat", Path: "/bucket", Err: os.ErrNotExist}),
+			minio.BucketNotFound{Bucket: "bucket"},
+		},
+		{
+			[]string{"bucket", "object"},
+			errors.Trace(&os.PathError{Op: "open", Path: "/bucket/object", Err: os.ErrNotExist}),
+			minio.ObjectNotFound{Bucket: "bucket", Object: "object"},
+		},
+		{
+			[]string{"bucket", "object"},
+			errors.Trace(&rpc.NamenodeError{Exception: "java.io.FileNotFoundException"}),
+			minio.ObjectNotFound{Bucket: "bucket", Object: "object"},
+		},
+		{
+			[]string{"bucket", "object"},
+			errors.Trace(&os.PathError{Op: "mkdir", Path: "/bucket/object", Err: os.ErrPermission}),
+			minio.PrefixAccessDenied{Bucket: "bucket", Object: "object"},
+		},
+		{
+			[]string{"bucket", "object"},
+			errors.Trace(&rpc.NamenodeError{Exception: "org.apache.hadoop.fs.ParentNotDirectoryException"}),
+			minio.PrefixAccessDenied{Bucket: "bucket", Object: "object"},
+		},
+		{
+			[]string{"bucket"},
+			errors.Trace(&os.PathError{Op: "mkdir", Path: "/bucket", Err: os.ErrExist}),
+			minio.BucketExists{Bucket: "bucket"},
+		},
+		{
+			[]string{"bucket", "object"},
+			errors.Trace(&rpc.NamenodeError{Exception: "org.apache.hadoop.fs.FileAlreadyExistsException"}),
+			minio.ObjectExistsAsDirectory{Bucket: "bucket", Object: "object"},
+		},
+		{
+			[]string{"bucket"},
+			errors.Trace(&rpc.NamenodeError{Exception: "org.apache.hadoop.fs.PathIsNotEmptyDirectoryException"}),
+			minio.BucketNotEmpty{Bucket: "bucket"},
+		},
+		{
+			[]string{"bucket", "object"},
+			errors.Trace(&rpc.NamenodeError{Exception: "org.apache.hadoop.hdfs.protocol.DSQuotaExceededException"}),
+			minio.StorageFull{},
+		},
+		{
+			[]string{"bucket", "object"},
+			errors.Trace(&rpc.NamenodeError{Exception: "org.apache.hadoop.ipc.StandbyException"}),
+			&rpc.NamenodeError{Exception: "org.apache.hadoop.ipc.StandbyException"},
+		},
+	}
+
+	for i, testCase := range testCases {
+		actualErr := hdfsToObjectError(testCase.hdfsErr, testCase.params...)
+		if actualErr != nil {
+			if e, ok := actualErr.(*errors.Error); ok {
+				actualErr = e.Cause
+			}
+		}
+		if !reflect.DeepEqual(actualErr, testCase.expectedErr) {
+			t.Errorf("Test %d: Expected %#v, got %#v", i+1, testCase.expectedErr, actualErr)
+		}
+	}
+}
+
+// Tests writing, reading and listing objects.
+func TestHDFSObjects(t *testing.T) {
+	l, _ := newTestHDFSObjects(t)
+
+	bucket := "bucket"
+	if err := l.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.MakeBucketWithLocation(bucket, ""); !reflect.DeepEqual(errors.Cause(err), minio.BucketExists{Bucket: bucket}) {
+		t.Fatalf("Expected BucketExists, got %v", err)
+	}
+
+	for _, object := range []string{"a/b", "a/c/d", "a-b", "e"} {
+		data := []byte("data of " + object)
+		objInfo, err := l.PutObject(bucket, object, mustGetHashReader(t, data), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if objInfo.Size != int64(len(data)) {
+			t.Errorf("%s: Expected size %d, got %d", object, len(data), objInfo.Size)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := l.GetObject(bucket, "a/c/d", 8, 3, &buffer, ""); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != "a/c" {
+		t.Errorf("Expected a/c, got %s", buffer.String())
+	}
+	if _, err := l.GetObjectInfo(bucket, "a"); !reflect.DeepEqual(errors.Cause(err), minio.ObjectNotFound{Bucket: bucket, Object: "a"}) {
+		t.Errorf("Expected ObjectNotFound for a directory, got %v", err)
+	}
+
+	testCases := []struct {
+		prefix, marker, delimiter string
+		maxKeys                   int
+		objects, prefixes         []string
+		isTruncated               bool
+	}{
+		// Directories are sorted with a trailing slash, after "a-b".
+		{"", "", "", 10, []string{"a-b", "a/b", "a/c/d", "e"}, nil, false},
+		{"", "", "/", 10, []string{"a-b", "e"}, []string{"a/"}, false},
+		{"a/", "", "/", 10, []string{"a/b"}, []string{"a/c/"}, false},
+		{"", "", "", 2, []string{"a-b", "a/b"}, nil, true},
+		{"", "a/b", "", 2, []string{"a/c/d", "e"}, nil, false},
+		{"a/c", "", "", 10, []string{"a/c/d"}, nil, false},
+		{"x/", "", "", 10, nil, nil, false},
+	}
+	for i, testCase := range testCases {
+		result, err := l.ListObjects(bucket, testCase.prefix, testCase.marker, testCase.delimiter, testCase.maxKeys)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		var objects []string
+		for _, objInfo := range result.Objects {
+			objects = append(objects, objInfo.Name)
+		}
+		if !reflect.DeepEqual(objects, testCase.objects) || !reflect.DeepEqual(result.Prefixes, testCase.prefixes) {
+			t.Errorf("Test %d: Expected %v %v, got %v %v", i+1, testCase.objects, testCase.prefixes, objects, result.Prefixes)
+		}
+		if result.IsTruncated != testCase.isTruncated {
+			t.Errorf("Test %d: Expected truncated %v, got %v", i+1, testCase.isTruncated, result.IsTruncated)
+		}
+	}
+
+	if err := l.DeleteBucket(bucket); !reflect.DeepEqual(errors.Cause(err), minio.BucketNotEmpty{Bucket: bucket}) {
+		t.Errorf("Expected BucketNotEmpty, got %v", err)
+	}
+	for _, object := range []string{"a/b", "a/c/d", "a-b", "e"} {
+		if err := l.DeleteObject(bucket, object); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Parent directories are removed with the last object.
+	if err := l.DeleteBucket(bucket); err != nil {
+		t.Errorf("Expected empty bucket to be deleted, got %v", err)
+	}
+}
+
+// Tests multipart uploads concatenating parts on the namenode.
+func TestHDFSMultipartUpload(t *testing.T) {
+	l, fs := newTestHDFSObjects(t)
+
+	bucket, object := "bucket", "dir/object"
+	if err := l.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := l.NewMultipartUpload(bucket, object, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parts []minio.CompletePart
+	var data []byte
+	for i, size := range []int{5 * humanize.MiByte, 5 * humanize.MiByte, 10} {
+		partData := bytes.Repeat([]byte{byte('a' + i)}, size)
+		pi, err := l.PutObjectPart(context.Background(), bucket, object, uploadID, i+1, mustGetHashReader(t, partData))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, minio.CompletePart{PartNumber: pi.PartNumber, ETag: pi.ETag})
+		data = append(data, partData...)
+	}
+
+	lpi, err := l.ListObjectParts(bucket, object, uploadID, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lpi.Parts) != 1 || lpi.Parts[0].PartNumber != 2 || !lpi.IsTruncated {
+		t.Errorf("Unexpected parts listed %#v", lpi)
+	}
+
+	// Parts smaller than 5MiB are only allowed last.
+	if _, err = l.CompleteMultipartUpload(context.Background(), bucket, object, uploadID, []minio.CompletePart{parts[2], parts[0]}); err == nil {
+		t.Fatal("Expected an error completing with a small part first")
+	}
+	if _, err = l.CompleteMultipartUpload(context.Background(), bucket, object, uploadID, []minio.CompletePart{{PartNumber: 4, ETag: parts[0].ETag}}); !reflect.DeepEqual(errors.Cause(err), minio.InvalidPart{}) {
+		t.Fatalf("Expected InvalidPart, got %v", err)
+	}
+
+	objInfo, err := l.CompleteMultipartUpload(context.Background(), bucket, object, uploadID, parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Size != int64(len(data)) {
+		t.Errorf("Expected size %d, got %d", len(data), objInfo.Size)
+	}
+	if !bytes.Equal(fs.files["/bucket/dir/object"].data, data) {
+		t.Error("Unexpected data of completed object")
+	}
+	if _, err = l.ListObjectParts(bucket, object, uploadID, 0, 10); !reflect.DeepEqual(errors.Cause(err), minio.InvalidUploadID{UploadID: uploadID}) {
+		t.Errorf("Expected upload to be removed, got %v", err)
+	}
+}
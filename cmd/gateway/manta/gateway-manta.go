@@ -588,3 +588,12 @@ func (t *tritonObjects) DeleteObject(bucket, object string) error {
 
 	return nil
 }
+
+// DeleteObjects - deletes a list of objects one after another.
+func (t *tritonObjects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	errs := make([]error, len(objects))
+	for idx, object := range objects {
+		errs[idx] = t.DeleteObject(bucket, object)
+	}
+	return errs, nil
+}
@@ -56,6 +56,11 @@ EXAMPLES:
       $ export MINIO_ACCESS_KEY=accesskey
       $ export MINIO_SECRET_KEY=secretkey
       $ {{.HelpName}} /shared/nasvol
+
+  2. Start more minio gateway servers serving the same NAS backend.
+      $ export MINIO_ACCESS_KEY=accesskey
+      $ export MINIO_SECRET_KEY=secretkey
+      $ {{.HelpName}} --address :9001 /shared/nasvol
 `
 
 	minio.RegisterGatewayCommand(cli.Command{
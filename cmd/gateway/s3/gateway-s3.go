@@ -17,10 +17,15 @@
 package s3
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"os"
+	"strings"
 
 	"github.com/minio/cli"
 	miniogo "github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/credentials"
 	"github.com/minio/minio-go/pkg/policy"
 	"github.com/minio/minio-go/pkg/s3utils"
 	"github.com/minio/minio/pkg/auth"
@@ -32,6 +37,14 @@ import (
 
 const (
 	s3Backend = "s3"
+
+	// Environment variables configuring the region and the signature
+	// version, "v2" or "v4", of requests to the S3 backend.
+	s3RegionEnv    = "MINIO_GATEWAY_S3_REGION"
+	s3SignatureEnv = "MINIO_GATEWAY_S3_SIGNATURE"
+
+	s3SignatureV2 = "v2"
+	s3SignatureV4 = "v4"
 )
 
 func init() {
@@ -58,6 +71,19 @@ ENVIRONMENT VARIABLES:
   UPDATE:
      MINIO_UPDATE: To turn off in-place upgrades, set this value to "off".
 
+  ENCRYPTION:
+     MINIO_GATEWAY_ENCRYPTION_KEY: Hex encoded 32 byte master key to encrypt objects with before they are sent to S3 storage.
+     MINIO_GATEWAY_ENCRYPTION_MULTIPART: Must be set to "off" with a master key, multipart uploads are rejected.
+
+  CONSISTENCY:
+     MINIO_GATEWAY_VERIFY_WRITES: To acknowledge writes only once S3 storage returns the written object, set this value to "on".
+     MINIO_GATEWAY_LIST_JOURNAL: To list objects written or deleted through the gateway right away, set this value to "on".
+     MINIO_GATEWAY_LIST_JOURNAL_EXPIRY: How long writes are merged into listings, "1m" by default.
+
+  UPSTREAM:
+     MINIO_GATEWAY_S3_REGION: Region requests to S3 storage are signed for, detected if not set.
+     MINIO_GATEWAY_S3_SIGNATURE: Signature version of requests to S3 storage, "v2" or "v4", detected if not set.
+
 EXAMPLES:
   1. Start minio gateway server for AWS S3 backend.
       $ export MINIO_ACCESS_KEY=accesskey
@@ -68,6 +94,12 @@ EXAMPLES:
       $ export MINIO_ACCESS_KEY=Q3AM3UQ867SPQQA43P2F
       $ export MINIO_SECRET_KEY=zuf+tfteSlswRu7BJ86wekitnifILbZam1KYY3TG
       $ {{.HelpName}} https://play.minio.io:9000
+
+  3. Start minio gateway server for S3 backend only speaking signature v2.
+      $ export MINIO_ACCESS_KEY=accesskey
+      $ export MINIO_SECRET_KEY=secretkey
+      $ export MINIO_GATEWAY_S3_SIGNATURE=v2
+      $ {{.HelpName}} https://s3.example.com
 `
 
 	minio.RegisterGatewayCommand(cli.Command{
@@ -86,12 +118,26 @@ func s3GatewayMain(ctx *cli.Context) {
 	// Validate gateway arguments.
 	minio.FatalIf(minio.ValidateGatewayArguments(ctx.GlobalString("address"), host), "Invalid argument")
 
-	minio.StartGateway(ctx, &S3{host})
+	signature := strings.ToLower(os.Getenv(s3SignatureEnv))
+	if signature != "" && signature != s3SignatureV2 && signature != s3SignatureV4 {
+		minio.FatalIf(fmt.Errorf("invalid value"), "Unknown value ‘%s’ in %s environment variable.", signature, s3SignatureEnv)
+	}
+
+	minio.StartGateway(ctx, &S3{
+		host:      host,
+		region:    os.Getenv(s3RegionEnv),
+		signature: signature,
+	})
 }
 
 // S3 implements Gateway.
 type S3 struct {
 	host string
+
+	// Region and signature version of requests to the backend,
+	// detected by probing the backend if empty.
+	region    string
+	signature string
 }
 
 // Name implements Gateway interface.
@@ -119,8 +165,13 @@ func (g *S3) NewGatewayLayer(creds auth.Credentials) (minio.ObjectLayer, error)
 		endpoint = "s3.amazonaws.com"
 	}
 
+	region, signature, err := probeS3Backend(endpoint, creds, secure, g.region, g.signature)
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize minio client object.
-	client, err := miniogo.NewCore(endpoint, creds.AccessKey, creds.SecretKey, secure)
+	client, err := newS3Client(endpoint, creds, secure, region, signature)
 	if err != nil {
 		return nil, err
 	}
@@ -130,6 +181,55 @@ func (g *S3) NewGatewayLayer(creds auth.Credentials) (minio.ObjectLayer, error)
 	}, nil
 }
 
+// newS3Client returns a client signing requests to the backend with
+// the signature version for the region. Without a region the region
+// of each bucket is looked up by the client.
+func newS3Client(endpoint string, creds auth.Credentials, secure bool, region, signature string) (*miniogo.Core, error) {
+	c := credentials.NewStaticV4(creds.AccessKey, creds.SecretKey, "")
+	if signature == s3SignatureV2 {
+		c = credentials.NewStaticV2(creds.AccessKey, creds.SecretKey, "")
+	}
+	client, err := miniogo.NewWithCredentials(endpoint, c, secure, region)
+	if err != nil {
+		return nil, err
+	}
+	return &miniogo.Core{Client: client}, nil
+}
+
+// probeS3Backend detects the region and signature version of requests
+// to the backend, unless configured, by listing buckets. Backends
+// rejecting signature v4 are probed with signature v2. The region is
+// taken from backends rejecting requests signed for another region.
+// Signature v4 is used if nothing is detected.
+func probeS3Backend(endpoint string, creds auth.Credentials, secure bool, region, signature string) (string, string, error) {
+	if signature == s3SignatureV2 || (signature == s3SignatureV4 && region != "") {
+		return region, signature, nil
+	}
+
+	client, err := newS3Client(endpoint, creds, secure, region, s3SignatureV4)
+	if err != nil {
+		return "", "", err
+	}
+	_, err = client.ListBuckets()
+	errResp := miniogo.ToErrorResponse(err)
+	switch {
+	case err == nil:
+	case errResp.Code == "AuthorizationHeaderMalformed" && errResp.Region != "" && region == "":
+		region = errResp.Region
+	case errResp.Code != "" && signature == "":
+		// Rejected by the backend, perhaps for signature v4.
+		if client, err = newS3Client(endpoint, creds, secure, region, s3SignatureV2); err != nil {
+			return "", "", err
+		}
+		if _, err = client.ListBuckets(); err == nil {
+			return region, s3SignatureV2, nil
+		}
+	default:
+		minio.ErrorIf(err, "Unable to detect the signature version and region of %s", endpoint)
+	}
+	return region, s3SignatureV4, nil
+}
+
 // Production - s3 gateway is not production ready.
 func (g *S3) Production() bool {
 	return false
@@ -313,6 +413,15 @@ func (l *s3Objects) DeleteObject(bucket string, object string) error {
 	return nil
 }
 
+// DeleteObjects - deletes a list of objects one after another.
+func (l *s3Objects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	errs := make([]error, len(objects))
+	for idx, object := range objects {
+		errs[idx] = l.DeleteObject(bucket, object)
+	}
+	return errs, nil
+}
+
 // ListMultipartUploads lists all multipart uploads.
 func (l *s3Objects) ListMultipartUploads(bucket string, prefix string, keyMarker string, uploadIDMarker string, delimiter string, maxUploads int) (lmi minio.ListMultipartsInfo, e error) {
 	result, err := l.Client.ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
@@ -335,7 +444,7 @@ func (l *s3Objects) NewMultipartUpload(bucket string, object string, metadata ma
 }
 
 // PutObjectPart puts a part of object in bucket
-func (l *s3Objects) PutObjectPart(bucket string, object string, uploadID string, partID int, data *hash.Reader) (pi minio.PartInfo, e error) {
+func (l *s3Objects) PutObjectPart(ctx context.Context, bucket string, object string, uploadID string, partID int, data *hash.Reader) (pi minio.PartInfo, e error) {
 	info, err := l.Client.PutObjectPart(bucket, object, uploadID, partID, data, data.Size(), data.MD5Base64String(), data.SHA256HexString())
 	if err != nil {
 		return pi, minio.ErrorRespToObjectError(errors.Trace(err), bucket, object)
@@ -379,7 +488,7 @@ func (l *s3Objects) AbortMultipartUpload(bucket string, object string, uploadID
 }
 
 // CompleteMultipartUpload completes ongoing multipart upload and finalizes object
-func (l *s3Objects) CompleteMultipartUpload(bucket string, object string, uploadID string, uploadedParts []minio.CompletePart) (oi minio.ObjectInfo, e error) {
+func (l *s3Objects) CompleteMultipartUpload(ctx context.Context, bucket string, object string, uploadID string, uploadedParts []minio.CompletePart) (oi minio.ObjectInfo, e error) {
 	err := l.Client.CompleteMultipartUpload(bucket, object, uploadID, minio.ToMinioClientCompleteParts(uploadedParts))
 	if err != nil {
 		return oi, minio.ErrorRespToObjectError(errors.Trace(err), bucket, object)
@@ -18,9 +18,13 @@ package s3
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	miniogo "github.com/minio/minio-go"
+	"github.com/minio/minio/pkg/auth"
 	"github.com/minio/minio/pkg/errors"
 	"github.com/minio/minio/pkg/hash"
 
@@ -125,3 +129,66 @@ func TestS3ToObjectError(t *testing.T) {
 		}
 	}
 }
+
+// Returns a backend accepting requests only if accept returns true
+// for their authorization header, rejected requests get the error.
+func newTestS3Backend(accept func(authorization string) bool, code, region string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		if !accept(r.Header.Get("Authorization")) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "<Error><Code>%s</Code><Region>%s</Region></Error>", code, region)
+			return
+		}
+		fmt.Fprint(w, "<ListAllMyBucketsResult><Buckets></Buckets></ListAllMyBucketsResult>")
+	}))
+}
+
+func TestProbeS3Backend(t *testing.T) {
+	creds := auth.Credentials{AccessKey: "accesskey", SecretKey: "secretkey"}
+	isV2 := func(authorization string) bool {
+		return strings.HasPrefix(authorization, "AWS ")
+	}
+	isV4 := func(authorization string) bool {
+		return strings.HasPrefix(authorization, "AWS4-HMAC-SHA256")
+	}
+	isEUWest := func(authorization string) bool {
+		return isV4(authorization) && strings.Contains(authorization, "/eu-west-1/s3/")
+	}
+
+	closed := newTestS3Backend(isV4, "", "")
+	closed.Close()
+
+	testCases := []struct {
+		backend           *httptest.Server
+		region, signature string
+		expectedRegion    string
+		expectedSignature string
+	}{
+		// Signature v4 backends.
+		{newTestS3Backend(isV4, "SignatureDoesNotMatch", ""), "", "", "", s3SignatureV4},
+		// Signature v2 backends are detected.
+		{newTestS3Backend(isV2, "SignatureDoesNotMatch", ""), "", "", "", s3SignatureV2},
+		{newTestS3Backend(isV2, "SignatureDoesNotMatch", ""), "", s3SignatureV4, "", s3SignatureV4},
+		// Regions are detected, configured regions are kept.
+		{newTestS3Backend(isEUWest, "AuthorizationHeaderMalformed", "eu-west-1"), "", "", "eu-west-1", s3SignatureV4},
+		{newTestS3Backend(isEUWest, "AuthorizationHeaderMalformed", "eu-west-1"), "", s3SignatureV4, "eu-west-1", s3SignatureV4},
+		{newTestS3Backend(isEUWest, "AuthorizationHeaderMalformed", "eu-west-1"), "eu-west-1", "", "eu-west-1", s3SignatureV4},
+		// Configured settings are not probed.
+		{closed, "us-west-2", s3SignatureV4, "us-west-2", s3SignatureV4},
+		{closed, "", s3SignatureV2, "", s3SignatureV2},
+		// Unreachable backends default to signature v4.
+		{closed, "", "", "", s3SignatureV4},
+	}
+	for i, testCase := range testCases {
+		endpoint := strings.TrimPrefix(testCase.backend.URL, "http://")
+		region, signature, err := probeS3Backend(endpoint, creds, false, testCase.region, testCase.signature)
+		testCase.backend.Close()
+		if err != nil {
+			t.Fatalf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if region != testCase.expectedRegion || signature != testCase.expectedSignature {
+			t.Errorf("Test %d: Expected %s %s, got %s %s", i+1, testCase.expectedRegion, testCase.expectedSignature, region, signature)
+		}
+	}
+}
@@ -392,13 +392,14 @@ func (s *siaObjects) ListObjects(bucket string, prefix string, marker string, de
 	// FIXME(harsha) - No paginated output supported for Sia backend right now, only prefix
 	// based filtering. Once list renter files API supports paginated output we can support
 	// paginated results here as well - until then Listing is an expensive operation.
+	// Objects up to the marker are skipped.
 	for _, sObj := range siaObjs {
 		name := strings.TrimPrefix(sObj.SiaPath, path.Join(root, bucket)+"/")
 		// Skip the file created specially when bucket was created.
 		if name == hex.EncodeToString(sha256sum[:]) {
 			continue
 		}
-		if strings.HasPrefix(name, prefix) {
+		if strings.HasPrefix(name, prefix) && name > marker {
 			loi.Objects = append(loi.Objects, minio.ObjectInfo{
 				Bucket: bucket,
 				Name:   name,
@@ -410,6 +411,24 @@ func (s *siaObjects) ListObjects(bucket string, prefix string, marker string, de
 	return loi, nil
 }
 
+// ListObjectsV2 - lists objects of a bucket filtered by prefix, the
+// Sia backend returns all of them at once.
+func (s *siaObjects) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, fetchOwner bool, startAfter string) (result minio.ListObjectsV2Info, err error) {
+	marker := continuationToken
+	if marker == "" {
+		marker = startAfter
+	}
+
+	resultV1, err := s.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err != nil {
+		return result, err
+	}
+	result.Objects = resultV1.Objects
+	result.Prefixes = resultV1.Prefixes
+	result.ContinuationToken = continuationToken
+	return result, nil
+}
+
 func (s *siaObjects) GetObject(bucket string, object string, startOffset int64, length int64, writer io.Writer, etag string) error {
 	dstFile := path.Join(s.TempDir, minio.MustGetUUID())
 	defer os.Remove(dstFile)
@@ -535,6 +554,15 @@ func (s *siaObjects) DeleteObject(bucket string, object string) error {
 	return post(s.Address, "/renter/delete/"+siaObj, "", s.password)
 }
 
+// DeleteObjects - deletes a list of objects one after another.
+func (s *siaObjects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	errs := make([]error, len(objects))
+	for idx, object := range objects {
+		errs[idx] = s.DeleteObject(bucket, object)
+	}
+	return errs, nil
+}
+
 // siaObjectInfo represents object info stored on Sia
 type siaObjectInfo struct {
 	SiaPath        string  `json:"siapath"`
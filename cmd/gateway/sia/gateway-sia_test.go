@@ -17,6 +17,9 @@
 package sia
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -30,3 +33,37 @@ func TestSianon2xx(t *testing.T) {
 		}
 	}
 }
+
+// Tests that ListObjectsV2 skips objects up to start-after or the
+// continuation token.
+func TestSiaListObjectsV2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"files":[{"siapath":"minio/bucket/a"},{"siapath":"minio/bucket/b"},{"siapath":"minio/bucket/c"}]}`))
+	}))
+	defer server.Close()
+
+	s := &siaObjects{Address: strings.TrimPrefix(server.URL, "http://"), RootDir: "minio"}
+	testCases := []struct {
+		continuationToken string
+		startAfter        string
+		expected          []string
+	}{
+		{"", "", []string{"a", "b", "c"}},
+		{"", "a", []string{"b", "c"}},
+		{"b", "a", []string{"c"}},
+		{"", "c", nil},
+	}
+	for i, testCase := range testCases {
+		result, err := s.ListObjectsV2("bucket", "", testCase.continuationToken, "", 1000, false, testCase.startAfter)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		var names []string
+		for _, obj := range result.Objects {
+			names = append(names, obj.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(testCase.expected, ",") {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, names)
+		}
+	}
+}
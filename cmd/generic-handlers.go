@@ -19,9 +19,12 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
@@ -263,7 +266,7 @@ func setReservedBucketHandler(h http.Handler) http.Handler {
 
 func (h minioReservedBucketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch {
-	case guessIsRPCReq(r), guessIsBrowserReq(r), isAdminReq(r):
+	case guessIsRPCReq(r), guessIsBrowserReq(r), isAdminReq(r), isPrometheusReq(r), isHealthReq(r), isSTSReq(r):
 		// Allow access to reserved buckets
 	default:
 		// For all other requests reject access to reserved
@@ -277,6 +280,62 @@ func (h minioReservedBucketHandler) ServeHTTP(w http.ResponseWriter, r *http.Req
 	h.handler.ServeHTTP(w, r)
 }
 
+// Rejects write requests when the server is read-only or in maintenance.
+type serverModeHandler struct {
+	handler http.Handler
+}
+
+func setServerModeHandler(h http.Handler) http.Handler {
+	return serverModeHandler{h}
+}
+
+// setMaintenanceMode - turns maintenance mode on or off.
+func setMaintenanceMode(enable bool) {
+	var mode int32
+	if enable {
+		mode = 1
+	}
+	atomic.StoreInt32(&globalMaintenanceMode, mode)
+}
+
+// isMaintenanceMode - returns true if maintenance mode is on.
+func isMaintenanceMode() bool {
+	return atomic.LoadInt32(&globalMaintenanceMode) == 1
+}
+
+// checkServerWritable - returns an error if write requests
+// are not allowed in the current server mode.
+func checkServerWritable() error {
+	if globalIsReadOnly {
+		return errServerReadOnly
+	}
+	if isMaintenanceMode() {
+		return errServerMaintenance
+	}
+	return nil
+}
+
+// isWriteReq - returns true if the request may modify buckets or objects.
+func isWriteReq(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+func (h serverModeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Admin, browser and RPC requests are served under the reserved
+	// bucket, browser handlers check the server mode themselves.
+	if isWriteReq(r) && !strings.HasPrefix(r.URL.Path, minioReservedBucketPath+"/") {
+		if err := checkServerWritable(); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
 type timeValidityHandler struct {
 	handler http.Handler
 }
@@ -340,13 +399,24 @@ func (h timeValidityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// or in the future, reject request otherwise.
 		curTime := UTCNow()
 		if curTime.Sub(amzDate) > globalMaxSkewTime || amzDate.Sub(curTime) > globalMaxSkewTime {
-			writeErrorResponse(w, ErrRequestTimeTooSkewed, r.URL)
+			writeRequestTimeTooSkewedResponse(w, amzDate, curTime, r.URL)
 			return
 		}
 	}
 	h.handler.ServeHTTP(w, r)
 }
 
+// writeRequestTimeTooSkewedResponse - writes RequestTimeTooSkewed error
+// response, the message carries the request and the server time so
+// that clients can tell a clock problem apart from a signature problem.
+func writeRequestTimeTooSkewedResponse(w http.ResponseWriter, requestTime, serverTime time.Time, reqURL *url.URL) {
+	apiError := getAPIError(ErrRequestTimeTooSkewed)
+	errorResponse := getAPIErrorResponse(apiError, reqURL.Path)
+	errorResponse.Message = fmt.Sprintf("%s Request time %s, server time %s, maximum allowed skew %s. Please synchronize the client clock (e.g. using NTP).",
+		apiError.Description, requestTime.Format(iso8601Format), serverTime.Format(iso8601Format), globalMaxSkewTime)
+	writeResponse(w, apiError.HTTPStatusCode, encodeResponse(errorResponse), mimeXML)
+}
+
 type resourceHandler struct {
 	handler http.Handler
 }
@@ -361,6 +431,14 @@ var defaultAllowableHTTPMethods = []string{
 	http.MethodOptions,
 }
 
+// corsHandler - answers cross origin requests of buckets with a CORS
+// configuration according to its rules, all other cross origin
+// requests are allowed from any origin.
+type corsHandler struct {
+	handler         http.Handler
+	wildcardHandler http.Handler
+}
+
 // setCorsHandler handler for CORS (Cross Origin Resource Sharing)
 func setCorsHandler(h http.Handler) http.Handler {
 	commonS3Headers := []string{"Content-Length", "Content-Type", "Connection",
@@ -373,7 +451,25 @@ func setCorsHandler(h http.Handler) http.Handler {
 		ExposedHeaders:   commonS3Headers,
 		AllowCredentials: true,
 	})
-	return c.Handler(h)
+	return corsHandler{handler: h, wildcardHandler: c.Handler(h)}
+}
+
+func (h corsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Origin") == "" {
+		h.wildcardHandler.ServeHTTP(w, r)
+		return
+	}
+	cfg, ok := globalBucketCORS.Get(getCORSRequestBucket(r))
+	if !ok {
+		h.wildcardHandler.ServeHTTP(w, r)
+		return
+	}
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		serveCORSPreflight(w, r, cfg)
+		return
+	}
+	setCORSResponseHeaders(w, r, cfg)
+	h.handler.ServeHTTP(w, r)
 }
 
 // setIgnoreResourcesHandler -
@@ -407,7 +503,6 @@ func ignoreNotImplementedObjectResources(req *http.Request) bool {
 // List of not implemented bucket queries
 var notimplementedBucketResourceNames = map[string]bool{
 	"acl":            true,
-	"cors":           true,
 	"lifecycle":      true,
 	"logging":        true,
 	"replication":    true,
@@ -415,7 +510,6 @@ var notimplementedBucketResourceNames = map[string]bool{
 	"versions":       true,
 	"requestPayment": true,
 	"versioning":     true,
-	"website":        true,
 }
 
 // List of not implemented object queries
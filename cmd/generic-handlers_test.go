@@ -18,6 +18,8 @@ package cmd
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"os"
 	"strconv"
 	"testing"
 )
@@ -179,3 +181,88 @@ func TestContainsReservedMetadata(t *testing.T) {
 		}
 	}
 }
+
+// Tests that write requests are rejected in read-only and maintenance mode.
+func TestServerModeHandler(t *testing.T) {
+	defer func(readOnly bool) {
+		globalIsReadOnly = readOnly
+		setMaintenanceMode(false)
+	}(globalIsReadOnly)
+
+	handler := setServerModeHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		method      string
+		path        string
+		readOnly    bool
+		maintenance bool
+		statusCode  int
+	}{
+		{http.MethodPut, "/bucket/object", false, false, http.StatusOK},
+		{http.MethodGet, "/bucket/object", true, false, http.StatusOK},
+		{http.MethodHead, "/bucket/object", false, true, http.StatusOK},
+		{http.MethodPut, "/bucket/object", true, false, http.StatusForbidden},
+		{http.MethodDelete, "/bucket/object", true, false, http.StatusForbidden},
+		{http.MethodPost, "/bucket?delete", false, true, http.StatusServiceUnavailable},
+		{http.MethodPut, "/bucket", false, true, http.StatusServiceUnavailable},
+		// Admin, browser and RPC requests are not rejected.
+		{http.MethodPut, "/minio/admin/v1/maintenance", false, true, http.StatusOK},
+		{http.MethodPost, "/minio/webrpc", true, false, http.StatusOK},
+	}
+
+	for i, testCase := range testCases {
+		globalIsReadOnly = testCase.readOnly
+		setMaintenanceMode(testCase.maintenance)
+
+		req, err := http.NewRequest(testCase.method, "http://localhost:9000"+testCase.path, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != testCase.statusCode {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, testCase.statusCode, rec.Code)
+		}
+	}
+}
+
+// Tests rejecting requests of not implemented resources.
+func TestIgnoreResourcesHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed: %v", err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	handler := setIgnoreResourcesHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		method     string
+		path       string
+		statusCode int
+	}{
+		{http.MethodGet, "/bucket?acl", http.StatusNotImplemented},
+		{http.MethodPut, "/bucket?lifecycle", http.StatusNotImplemented},
+		{http.MethodGet, "/bucket/object?torrent", http.StatusNotImplemented},
+		{http.MethodGet, "/bucket?policy", http.StatusOK},
+		{http.MethodPut, "/bucket?website", http.StatusOK},
+		{http.MethodPut, "/bucket?cors", http.StatusOK},
+		{http.MethodGet, "/bucket/object", http.StatusOK},
+	}
+
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, "http://localhost:9000"+testCase.path, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != testCase.statusCode {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, testCase.statusCode, rec.Code)
+		}
+	}
+}
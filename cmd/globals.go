@@ -19,6 +19,7 @@ package cmd
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"net"
 	"os"
 	"runtime"
 	"time"
@@ -80,6 +81,10 @@ var (
 	// Indicates set drive count.
 	globalXLSetDriveCount int
 
+	// Zones of the server in the order of the command line, only set
+	// if the server has more than one zone.
+	globalXLZones []zoneEndpoints
+
 	// Indicates if the running minio server is distributed setup.
 	globalIsDistXL = false
 
@@ -89,6 +94,43 @@ var (
 	// This flag is set to 'true' by default
 	globalIsBrowserEnabled = true
 
+	// This flag is set to 'true' by default, requests signed with
+	// signature V2 are rejected when MINIO_SIGNATURE_V2 is set to "off".
+	globalIsSignatureV2 = true
+
+	// This flag is set to 'true' when MINIO_POST_POLICY_STRICT_METADATA
+	// is set to "on", POST policy uploads with metadata fields the policy
+	// has no condition on are rejected.
+	globalIsPostPolicyStrictMetadata = false
+
+	// This flag is set to 'true' when --read-only is passed, only
+	// read requests are served.
+	globalIsReadOnly = false
+
+	// This flag is set to 'true' when --certs-auto is passed, TLS
+	// certificates are obtained and renewed using ACME.
+	globalIsCertsAuto = false
+
+	// This flag is set to 'true' when --certs-auto-agree-tos is passed,
+	// the operator agreed to the terms of service of the ACME CA.
+	globalCertsAutoAgreeTOS = false
+
+	// This flag is set to 'true' when --worm is passed or MINIO_WORM
+	// is set to "on", existing objects cannot be overwritten or deleted.
+	globalWORMEnabled = false
+
+	// This flag is set to 'true' when MINIO_OBJECT_ATTESTATION is
+	// set to "on", signed manifests are saved for every new object.
+	globalIsObjectAttestation = false
+
+	// This flag is set to 'true' when MINIO_OBJECT_INDEX is set to
+	// "on", object names and metadata are indexed to be searched.
+	globalIsObjectIndex = false
+
+	// Set to 1 when maintenance mode is turned on via admin API,
+	// write requests are rejected with SlowDown. Accessed atomically.
+	globalMaintenanceMode int32
+
 	// This flag is set to 'true' when MINIO_BROWSER env is set.
 	globalIsEnvBrowser = false
 
@@ -107,6 +149,40 @@ var (
 	// Maximum size of internal objects parts
 	globalPutPartSize = int64(64 * 1024 * 1024)
 
+	// Number of parts of multipart objects read at once by GetObject
+	// on erasure coded backends, can be set via MINIO_XL_READAHEAD.
+	globalXLReadAhead = defaultXLReadAhead
+
+	// Number of goroutines erasure coding a single block, 0 adjusts
+	// it to the number of CPUs. Can be set via MINIO_XL_ENCODE_GOROUTINES.
+	globalXLEncodeGoroutines = 0
+
+	// Number of blocks queued for every disk while writing erasure
+	// coded files, can be set via MINIO_XL_WRITE_DEPTH.
+	globalXLWriteDepth = defaultXLWriteDepth
+
+	// Objects up to this size are stored inline in `xl.json` instead
+	// of part files, can be set via MINIO_XL_INLINE_THRESHOLD.
+	globalXLInlineThreshold int64 = defaultXLInlineThreshold
+
+	// Number of objects whose metadata is cached in memory on erasure
+	// coded backends, 0 turns off the cache. Can be set via
+	// MINIO_XL_METADATA_CACHE_SIZE.
+	globalXLMetadataCacheSize = 0
+
+	// Cached object metadata expires after this, can be set via
+	// MINIO_XL_METADATA_CACHE_EXPIRY.
+	globalXLMetadataCacheExpiry = defaultXLMetadataCacheExpiry
+
+	// Bucket metadata snapshots are saved to, backups are disabled
+	// when empty. Set via MINIO_METADATA_BACKUP_BUCKET.
+	globalMetadataBackupBucket = ""
+
+	// Interval between metadata snapshots and the number of snapshots
+	// kept, set via MINIO_METADATA_BACKUP_INTERVAL and _KEEP.
+	globalMetadataBackupInterval = defaultMetadataBackupInterval
+	globalMetadataBackupKeep     = defaultMetadataBackupKeep
+
 	// Minio local server address (in `host:port` format)
 	globalMinioAddr = ""
 	// Minio default port, can be changed through command line.
@@ -120,6 +196,10 @@ var (
 	// CA root certificates, a nil value means system certs pool will be used
 	globalRootCAs *x509.CertPool
 
+	// CA certificates verifying client certificates of admin
+	// requests, a nil value means client certificates are not required.
+	globalAdminClientCAs *x509.CertPool
+
 	// IsSSL indicates if the server is configured with SSL.
 	globalIsSSL bool
 
@@ -146,14 +226,102 @@ var (
 	// Global HTTP request statisitics
 	globalHTTPStats = newHTTPStats()
 
+	// Delivery metrics of notification targets.
+	globalNotifyTargetMetrics = newNotifyTargetMetrics()
+
+	// Set to true if Prometheus metrics are served without
+	// authentication, can be set via MINIO_PROMETHEUS_AUTH_TYPE.
+	globalIsPrometheusPublic bool
+
 	// Time when object layer was initialized on start up.
 	globalBootTime time.Time
 
+	// Progress of the server startup, served by the health endpoint.
+	globalBootProgress = newBootProgress()
+
 	globalActiveCred  auth.Credentials
 	globalPublicCerts []*x509.Certificate
 
 	globalIsEnvDomainName bool
-	globalDomainName      string // Root domain for virtual host style requests
+	globalDomainNames     []string // Root domains for virtual host style requests, longest first
+
+	// URL prefix the server is hosted under behind a reverse proxy,
+	// can be set via MINIO_URL_PREFIX.
+	globalURLPrefix string
+
+	// Limits of S3 API requests, 0 is unlimited. Can be set via
+	// MINIO_API_REQUESTS_MAX, MINIO_API_REQUESTS_DEADLINE,
+	// MINIO_API_REQUESTS_PER_IP and MINIO_API_REQUESTS_PER_IP_BURST.
+	globalAPIRequestsMax        int
+	globalAPIRequestsDeadline   = defaultAPIRequestsDeadline
+	globalAPIRequestsPerIP      float64
+	globalAPIRequestsPerIPBurst int
+
+	// Reverse proxies trusted to report the IP of clients throttled
+	// per IP. Can be set via MINIO_API_TRUSTED_PROXIES.
+	globalAPITrustedProxies []*net.IPNet
+
+	// Minimum throughput in bytes per second of request bodies and
+	// responses, 0 disables it, and the duration over which it is
+	// enforced. Can be set via MINIO_HTTP_MIN_THROUGHPUT and
+	// MINIO_HTTP_THROUGHPUT_WINDOW.
+	globalHTTPMinThroughput    int64
+	globalHTTPThroughputWindow = miniohttp.DefaultThroughputWindow
+
+	// Master key gateways encrypt objects with before they are sent
+	// to the backend, can be set via MINIO_GATEWAY_ENCRYPTION_KEY.
+	globalGatewayEncryptionKey []byte
+
+	// Set to verify writes to gateway backends are visible before
+	// they are acknowledged, via MINIO_GATEWAY_VERIFY_WRITES.
+	globalGatewayVerifyWrites bool
+
+	// How long writes through gateways are merged into listings, 0 if
+	// not at all, via MINIO_GATEWAY_LIST_JOURNAL and
+	// MINIO_GATEWAY_LIST_JOURNAL_EXPIRY.
+	globalGatewayListJournalExpiry time.Duration
+
+	// Size of the parts, number of parts in flight and bytes of parts
+	// held in memory gateways upload large objects with, set via
+	// MINIO_GATEWAY_PARALLEL_UPLOAD. Zero if objects are uploaded with
+	// a single request.
+	globalGatewayParallelUploadPartSize    int64
+	globalGatewayParallelUploadConcurrency int
+	globalGatewayParallelUploadMaxMemory   int64
+
+	// Name of the gateway backend, empty unless running as a gateway.
+	globalGatewayName string
+
+	// KMS sealing data keys of objects encrypted with SSE-S3, nil
+	// unless configured in the kms section of the config.
+	globalKMS KMS
+
+	// Identity provider authenticating users for temporary
+	// credentials, nil unless configured in the identity section
+	// of the config.
+	globalIdentityProvider IdentityProvider
+
+	// OpenID Connect provider whose ID tokens log users into the
+	// browser and are exchanged for temporary credentials, nil unless
+	// configured in the identity section of the config.
+	globalOpenIDProvider *openIDProvider
+
+	// Managed policies granted to temporary credentials.
+	globalSessionPolicies = newSessionPolicyCache()
+
+	// Expiry of browser access and refresh tokens. Can be set via
+	// MINIO_BROWSER_TOKEN_EXPIRY and MINIO_BROWSER_REFRESH_EXPIRY.
+	globalBrowserTokenExpiry   = defaultJWTExpiry
+	globalBrowserRefreshExpiry = defaultBrowserRefreshExpiry
+
+	// Revoked browser tokens.
+	globalBrowserTokenRevocations = newBrowserTokenRevocationCache()
+
+	// Keys signing and verifying object manifests.
+	globalAttestationKeys = newAttestationKeyCache()
+
+	// Bucket policies anonymous requests are evaluated against.
+	globalBucketPolicyCache = newBucketPolicyCache(0)
 
 	globalListingTimeout   = newDynamicTimeout( /*30*/ 600*time.Second /*5*/, 600*time.Second) // timeout for listing related ops
 	globalObjectTimeout    = newDynamicTimeout( /*1*/ 10*time.Minute /*10*/, 600*time.Second)  // timeout for Object API related ops
@@ -170,6 +338,8 @@ var (
 	globalRRStorageClass storageClass
 	// Set to store standard storage class
 	globalStandardStorageClass storageClass
+	// Set to store the labeled pools of erasure sets
+	globalStoragePools storagePoolsConfig
 
 	// Current RPC version
 	globalRPCAPIVersion = semVersion{2, 0, 0}
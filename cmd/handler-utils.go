@@ -17,13 +17,16 @@
 package cmd
 
 import (
+	"fmt"
 	"io"
 	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 
+	"github.com/minio/minio-go/pkg/set"
 	"github.com/minio/minio/pkg/errors"
 	httptracer "github.com/minio/minio/pkg/handlers"
 )
@@ -141,13 +144,21 @@ func extractMetadataFromHeader(header http.Header) (map[string]string, error) {
 			}
 		}
 	}
+
+	// Save quarantine status requested by a validation hook, if any.
+	if err := extractQuarantineFromHeader(header, metadata); err != nil {
+		return nil, err
+	}
 	return metadata, nil
 }
 
 // The Query string for the redirect URL the client is
-// redirected on successful upload.
-func getRedirectPostRawQuery(objInfo ObjectInfo) string {
-	redirectValues := make(url.Values)
+// redirected on successful upload, redirectValues are the
+// query params of the redirect URL given in the form.
+func getRedirectPostRawQuery(redirectValues url.Values, objInfo ObjectInfo) string {
+	if redirectValues == nil {
+		redirectValues = make(url.Values)
+	}
 	redirectValues.Set("bucket", objInfo.Bucket)
 	redirectValues.Set("key", objInfo.Name)
 	redirectValues.Set("etag", "\""+objInfo.ETag+"\"")
@@ -250,6 +261,7 @@ func extractPostPolicyFormValues(form *multipart.Form) (filePart io.ReadCloser,
 
 // Log headers and body.
 func httpTraceAll(f http.HandlerFunc) http.HandlerFunc {
+	f = accessStatsAPI(auditAPI(f))
 	if globalHTTPTraceFile == nil {
 		return f
 	}
@@ -258,15 +270,44 @@ func httpTraceAll(f http.HandlerFunc) http.HandlerFunc {
 
 // Log only the headers.
 func httpTraceHdrs(f http.HandlerFunc) http.HandlerFunc {
+	f = accessStatsAPI(auditAPI(f))
 	if globalHTTPTraceFile == nil {
 		return f
 	}
 	return httptracer.TraceReqHandlerFunc(f, globalHTTPTraceFile, false)
 }
 
-// Returns "/bucketName/objectName" for path-style or virtual-host-style requests.
-func getResource(path string, host string, domain string) (string, error) {
-	if domain == "" {
+// parseDomainNames - parses a comma separated list of root domains for
+// virtual host style requests, ordered longest first so that requests
+// to a subdomain of another domain match the subdomain.
+func parseDomainNames(value string) ([]string, error) {
+	var domains []string
+	seen := set.NewStringSet()
+	for _, domain := range strings.Split(value, ",") {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain == "" {
+			continue
+		}
+		if strings.ContainsAny(domain, "/:*@ ") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+			return nil, fmt.Errorf("invalid domain ‘%s’", domain)
+		}
+		if seen.Contains(domain) {
+			continue
+		}
+		seen.Add(domain)
+		domains = append(domains, domain)
+	}
+	sort.SliceStable(domains, func(i, j int) bool {
+		return len(domains[i]) > len(domains[j])
+	})
+	return domains, nil
+}
+
+// Returns "/bucketName/objectName" for path-style or virtual-host-style
+// requests, the bucket of virtual-host-style requests is the subdomain
+// of the first domain the host belongs to.
+func getResource(path string, host string, domains []string) (string, error) {
+	if len(domains) == 0 {
 		return path, nil
 	}
 	// If virtual-host-style is enabled construct the "resource" properly.
@@ -278,11 +319,14 @@ func getResource(path string, host string, domain string) (string, error) {
 			return "", err
 		}
 	}
-	if !strings.HasSuffix(host, "."+domain) {
-		return path, nil
+	host = strings.ToLower(host)
+	for _, domain := range domains {
+		if strings.HasSuffix(host, "."+domain) {
+			bucket := strings.TrimSuffix(host, "."+domain)
+			return slashSeparator + pathJoin(bucket, path), nil
+		}
 	}
-	bucket := strings.TrimSuffix(host, "."+domain)
-	return slashSeparator + pathJoin(bucket, path), nil
+	return path, nil
 }
 
 // If none of the http routes match respond with MethodNotAllowed
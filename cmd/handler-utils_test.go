@@ -21,11 +21,13 @@ import (
 	"encoding/xml"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
 
+	router "github.com/gorilla/mux"
 	"github.com/minio/minio/pkg/errors"
 )
 
@@ -198,15 +200,18 @@ func TestGetResource(t *testing.T) {
 	testCases := []struct {
 		p                string
 		host             string
-		domain           string
+		domains          []string
 		expectedResource string
 	}{
-		{"/a/b/c", "test.mydomain.com", "mydomain.com", "/test/a/b/c"},
-		{"/a/b/c", "test.mydomain.com", "notmydomain.com", "/a/b/c"},
-		{"/a/b/c", "test.mydomain.com", "", "/a/b/c"},
+		{"/a/b/c", "test.mydomain.com", []string{"mydomain.com"}, "/test/a/b/c"},
+		{"/a/b/c", "test.mydomain.com", []string{"notmydomain.com"}, "/a/b/c"},
+		{"/a/b/c", "test.mydomain.com", nil, "/a/b/c"},
+		{"/a/b/c", "test.mydomain.com:9000", []string{"other.com", "mydomain.com"}, "/test/a/b/c"},
+		{"/a/b/c", "test.s3.mydomain.com", []string{"s3.mydomain.com", "mydomain.com"}, "/test/a/b/c"},
+		{"/a/b/c", "mydomain.com", []string{"mydomain.com"}, "/a/b/c"},
 	}
 	for i, test := range testCases {
-		gotResource, err := getResource(test.p, test.host, test.domain)
+		gotResource, err := getResource(test.p, test.host, test.domains)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -215,3 +220,64 @@ func TestGetResource(t *testing.T) {
 		}
 	}
 }
+
+// Tests parsing lists of domains for virtual host style requests.
+func TestParseDomainNames(t *testing.T) {
+	testCases := []struct {
+		value   string
+		domains []string
+		success bool
+	}{
+		{"", nil, true},
+		{"mydomain.com", []string{"mydomain.com"}, true},
+		{"mydomain.com, S3.MyDomain.com,mydomain.com,", []string{"s3.mydomain.com", "mydomain.com"}, true},
+		{"a.com,bb.com,c.com", []string{"bb.com", "a.com", "c.com"}, true},
+		{"mydomain.com:9000", nil, false},
+		{"*.mydomain.com", nil, false},
+		{"http://mydomain.com", nil, false},
+		{".mydomain.com", nil, false},
+	}
+	for i, testCase := range testCases {
+		domains, err := parseDomainNames(testCase.value)
+		if testCase.success != (err == nil) {
+			t.Fatalf("Test %d: Expected success %v, got %v", i+1, testCase.success, err)
+		}
+		if !reflect.DeepEqual(domains, testCase.domains) {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.domains, domains)
+		}
+	}
+}
+
+// Tests that virtual host style requests to any of the domains are
+// routed to the bucket of the subdomain.
+func TestVirtualHostRouting(t *testing.T) {
+	defer func(domains []string) { globalDomainNames = domains }(globalDomainNames)
+	globalDomainNames = []string{"s3.mydomain.com", "mydomain.com"}
+
+	apiRouter := router.NewRouter().SkipClean(true)
+	registerAPIRouter(apiRouter)
+
+	testCases := []struct {
+		host   string
+		path   string
+		bucket string
+		object string
+	}{
+		{"bucket.mydomain.com", "/object", "bucket", "object"},
+		{"bucket.s3.mydomain.com", "/dir/object", "bucket", "dir/object"},
+		{"my.bucket.mydomain.com:9000", "/object", "my.bucket", "object"},
+		{"mydomain.com", "/bucket/object", "bucket", "object"},
+		{"localhost:9000", "/bucket/object", "bucket", "object"},
+	}
+	for i, testCase := range testCases {
+		req := httptest.NewRequest(http.MethodGet, testCase.path, nil)
+		req.Host = testCase.host
+		var match router.RouteMatch
+		if !apiRouter.Match(req, &match) {
+			t.Fatalf("Test %d: Expected %s%s to match a route", i+1, testCase.host, testCase.path)
+		}
+		if match.Vars["bucket"] != testCase.bucket || match.Vars["object"] != testCase.object {
+			t.Errorf("Test %d: Expected bucket %s and object %s, got %v", i+1, testCase.bucket, testCase.object, match.Vars)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	router "github.com/gorilla/mux"
+)
+
+const (
+	// Paths of the liveness and readiness checks of load balancers,
+	// served without authentication.
+	healthLivePath  = minioReservedBucketPath + "/health/live"
+	healthReadyPath = minioReservedBucketPath + "/health/ready"
+
+	// Gateways not answering within this time are not ready.
+	healthReadyGatewayTimeout = 5 * time.Second
+)
+
+// errGatewayBackendTimeout - the gateway backend did not answer in time.
+var errGatewayBackendTimeout = errors.New("Backend did not respond in time")
+
+// registerHealthRouter - registers the startup progress, liveness and
+// readiness endpoints.
+func registerHealthRouter(mux *router.Router) {
+	mux.Methods(http.MethodGet).Path(bootHealthPath).HandlerFunc(bootHealthHandler)
+	mux.Methods(http.MethodGet, http.MethodHead).Path(healthLivePath).HandlerFunc(liveHealthHandler)
+	mux.Methods(http.MethodGet, http.MethodHead).Path(healthReadyPath).HandlerFunc(readyHealthHandler)
+}
+
+// isHealthReq - returns true if the request is for a health endpoint.
+func isHealthReq(r *http.Request) bool {
+	switch r.URL.Path {
+	case bootHealthPath, healthLivePath, healthReadyPath:
+		return true
+	}
+	return false
+}
+
+// liveHealthHandler - replies with status 200 as long as the server
+// process serves requests, servers failing it should be restarted.
+func liveHealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// readyHealthHandler - replies with status 200 if the server is ready
+// to serve requests, 503 otherwise. Servers are ready once started,
+// with enough disks online for write quorum on every erasure set, or
+// with the backend reachable in gateway mode.
+func readyHealthHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil || globalBootProgress.Status().Stage != bootStageReady || !isObjectLayerReady(objectAPI) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// isObjectLayerReady - returns true if the object layer is able to
+// serve requests. Gateways are checked by listing the buckets of the
+// backend.
+func isObjectLayerReady(objectAPI ObjectLayer) bool {
+	switch l := unwrapObjectLayer(objectAPI).(type) {
+	case *FSObjects:
+		return true
+	case *xlObjects:
+		return l.isWriteQuorumOnline()
+	case *xlSets:
+		return l.isWriteQuorumOnline()
+	case *xlZones:
+		return l.isWriteQuorumOnline()
+	}
+	return checkGatewayBackend(objectAPI) == nil
+}
+
+// checkGatewayBackend - returns an error unless the backend of the
+// gateway lists its buckets in time.
+func checkGatewayBackend(objectAPI ObjectLayer) error {
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := objectAPI.ListBuckets()
+		errCh <- err
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(healthReadyGatewayTimeout):
+		return errGatewayBackendTimeout
+	}
+}
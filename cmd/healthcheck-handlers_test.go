@@ -0,0 +1,105 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	router "github.com/gorilla/mux"
+)
+
+// healthTestGateway - a gateway whose backend fails listing buckets
+// with err.
+type healthTestGateway struct {
+	ObjectLayer
+	err error
+}
+
+func (g healthTestGateway) ListBuckets() ([]BucketInfo, error) {
+	return nil, g.err
+}
+
+func (g healthTestGateway) StorageInfo() StorageInfo {
+	return StorageInfo{}
+}
+
+// Tests the liveness and readiness endpoints.
+func TestHealthHandlers(t *testing.T) {
+	defer func(p *bootProgress) { globalBootProgress = p }(globalBootProgress)
+	globalBootProgress = newBootProgress()
+	defer func(objectAPI ObjectLayer) { globalObjectAPI = objectAPI }(globalObjectAPI)
+	globalObjectAPI = nil
+
+	mux := router.NewRouter()
+	registerHealthRouter(mux)
+	handler := setReservedBucketHandler(mux)
+
+	check := func(method, path string, expectedCode int) {
+		t.Helper()
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != expectedCode {
+			t.Errorf("%s %s: Expected status %d, got %d", method, path, expectedCode, rec.Code)
+		}
+	}
+
+	// Servers are live while starting, but not ready.
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		check(method, healthLivePath, http.StatusOK)
+		check(method, healthReadyPath, http.StatusServiceUnavailable)
+	}
+
+	fsDir, err := getRandomDisks(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDir)
+	globalObjectAPI = initFSObjects(fsDir[0], t)
+	check(http.MethodGet, healthReadyPath, http.StatusServiceUnavailable)
+	globalBootProgress.finish()
+	check(http.MethodGet, healthReadyPath, http.StatusOK)
+
+	// Erasure sets are ready with write quorum.
+	objLayer, xlDirs, err := initTestXLObjLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(xlDirs)
+	check(http.MethodGet, healthReadyPath, http.StatusOK)
+	xlSets := objLayer.(*xlSets)
+	for i := 0; i < 7; i++ {
+		xlSets.xlDisks[0][i] = nil
+	}
+	check(http.MethodGet, healthReadyPath, http.StatusOK)
+	xlSets.xlDisks[0][7] = nil
+	check(http.MethodGet, healthReadyPath, http.StatusServiceUnavailable)
+
+	// Gateways are ready with their backend reachable.
+	globalObjectAPI = healthTestGateway{}
+	check(http.MethodGet, healthReadyPath, http.StatusOK)
+	globalObjectAPI = healthTestGateway{err: errors.New("connection refused")}
+	check(http.MethodGet, healthReadyPath, http.StatusServiceUnavailable)
+	check(http.MethodGet, healthLivePath, http.StatusOK)
+
+}
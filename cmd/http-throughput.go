@@ -0,0 +1,81 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	miniohttp "github.com/minio/minio/pkg/http"
+)
+
+const (
+	// Environment variable setting the minimum throughput in bytes
+	// per second of request bodies and responses, e.g. "1KiB".
+	httpMinThroughputEnv = "MINIO_HTTP_MIN_THROUGHPUT"
+
+	// Environment variable setting the duration over which the
+	// minimum throughput is enforced.
+	httpThroughputWindowEnv = "MINIO_HTTP_THROUGHPUT_WINDOW"
+)
+
+// Parses a minimum throughput in bytes per second, 0 disables it.
+func parseHTTPMinThroughput(value string) (int64, error) {
+	minThroughput, err := humanize.ParseBytes(value)
+	if err != nil {
+		return 0, err
+	}
+	if minThroughput > 1<<40 {
+		return 0, errors.New("minimum throughput is too large")
+	}
+	return int64(minThroughput), nil
+}
+
+// Parses the duration over which the minimum throughput is enforced.
+func parseHTTPThroughputWindow(value string) (time.Duration, error) {
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if window < time.Second {
+		return 0, errors.New("throughput window must be at least one second")
+	}
+	return window, nil
+}
+
+// Sets the minimum throughput of requests from the environment.
+// Uploads and downloads stalled below it fail once the window ends,
+// releasing the locks and temporary data held by their requests.
+func handleHTTPThroughputEnv() {
+	var err error
+	if value := os.Getenv(httpMinThroughputEnv); value != "" {
+		globalHTTPMinThroughput, err = parseHTTPMinThroughput(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", httpMinThroughputEnv)
+	}
+	if value := os.Getenv(httpThroughputWindowEnv); value != "" {
+		globalHTTPThroughputWindow, err = parseHTTPThroughputWindow(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", httpThroughputWindowEnv)
+	}
+}
+
+// Applies the minimum throughput of requests to the HTTP server.
+func setHTTPServerThroughput(server *miniohttp.Server) {
+	server.MinThroughput = globalHTTPMinThroughput
+	server.ThroughputWindow = globalHTTPThroughputWindow
+}
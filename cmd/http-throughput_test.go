@@ -0,0 +1,45 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests parsing the minimum throughput of requests.
+func TestParseHTTPThroughput(t *testing.T) {
+	for _, value := range []string{"-1", "fast", "2PiB"} {
+		if _, err := parseHTTPMinThroughput(value); err == nil {
+			t.Errorf("Expected minimum throughput %q to fail", value)
+		}
+	}
+	if minThroughput, err := parseHTTPMinThroughput("1KiB"); err != nil || minThroughput != 1024 {
+		t.Errorf("Expected 1024, got %d, %v", minThroughput, err)
+	}
+	if minThroughput, err := parseHTTPMinThroughput("0"); err != nil || minThroughput != 0 {
+		t.Errorf("Expected 0, got %d, %v", minThroughput, err)
+	}
+	for _, value := range []string{"0s", "500ms", "forever"} {
+		if _, err := parseHTTPThroughputWindow(value); err == nil {
+			t.Errorf("Expected throughput window %q to fail", value)
+		}
+	}
+	if window, err := parseHTTPThroughputWindow("30s"); err != nil || window != 30*time.Second {
+		t.Errorf("Expected 30s, got %s, %v", window, err)
+	}
+}
@@ -19,6 +19,7 @@ package cmd
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -132,3 +133,57 @@ func parseRequestRange(rangeString string, resourceSize int64) (hrange *httpRang
 
 	return &httpRange{offsetBegin, offsetEnd, resourceSize}, nil
 }
+
+// Maximum number of ranges of a request, requests with more ranges are
+// served the whole object.
+const maxRequestRanges = 100
+
+// parseRequestRanges - parses a range header of one or more ranges
+// separated by commas, e.g. "bytes=0-99,200-299". Ranges beyond the
+// end of the resource are ignored, errInvalidRange is returned when no
+// range is satisfiable. Overlapping and adjacent ranges are merged so
+// that a response never holds more bytes than the resource itself.
+func parseRequestRanges(rangeString string, resourceSize int64) (hranges []*httpRange, err error) {
+	if !strings.HasPrefix(rangeString, byteRangePrefix) {
+		return nil, fmt.Errorf("'%s' does not start with '%s'", rangeString, byteRangePrefix)
+	}
+
+	specs := strings.Split(strings.TrimPrefix(rangeString, byteRangePrefix), ",")
+	if len(specs) > maxRequestRanges {
+		return nil, fmt.Errorf("'%s' has more than %d ranges", rangeString, maxRequestRanges)
+	}
+	for _, spec := range specs {
+		hrange, err := parseRequestRange(byteRangePrefix+strings.TrimSpace(spec), resourceSize)
+		if err == errInvalidRange {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		hranges = append(hranges, hrange)
+	}
+	if len(hranges) == 0 {
+		return nil, errInvalidRange
+	}
+	return mergeRequestRanges(hranges), nil
+}
+
+// mergeRequestRanges - sorts ranges by their first byte and coalesces
+// ranges which overlap or are adjacent.
+func mergeRequestRanges(hranges []*httpRange) []*httpRange {
+	sort.Slice(hranges, func(i, j int) bool {
+		return hranges[i].offsetBegin < hranges[j].offsetBegin
+	})
+	merged := hranges[:1]
+	for _, hrange := range hranges[1:] {
+		last := merged[len(merged)-1]
+		if hrange.offsetBegin > last.offsetEnd+1 {
+			merged = append(merged, hrange)
+			continue
+		}
+		if hrange.offsetEnd > last.offsetEnd {
+			last.offsetEnd = hrange.offsetEnd
+		}
+	}
+	return merged
+}
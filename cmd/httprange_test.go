@@ -16,7 +16,10 @@
 
 package cmd
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 // Test parseRequestRange()
 func TestParseRequestRange(t *testing.T) {
@@ -88,3 +91,50 @@ func TestParseRequestRange(t *testing.T) {
 		}
 	}
 }
+
+// Test parseRequestRanges()
+func TestParseRequestRanges(t *testing.T) {
+	testCases := []struct {
+		rangeString string
+		ranges      [][2]int64
+		err         error
+	}{
+		{"bytes=2-5", [][2]int64{{2, 5}}, nil},
+		{"bytes=0-0,-1", [][2]int64{{0, 0}, {9, 9}}, nil},
+		{"bytes=0-1, 4-5,8-", [][2]int64{{0, 1}, {4, 5}, {8, 9}}, nil},
+		{"bytes=2-5,20-30", [][2]int64{{2, 5}}, nil},
+		{"bytes=20-30,10-", nil, errInvalidRange},
+		{"bytes=4-5,0-1", [][2]int64{{0, 1}, {4, 5}}, nil},
+		{"bytes=0-4,2-6,8-9", [][2]int64{{0, 6}, {8, 9}}, nil},
+		{"bytes=0-1,2-3", [][2]int64{{0, 3}}, nil},
+		{"bytes=0-,0-,0-", [][2]int64{{0, 9}}, nil},
+		{"bytes=1-2,-9,3-3", [][2]int64{{1, 9}}, nil},
+	}
+	for i, testCase := range testCases {
+		hranges, err := parseRequestRanges(testCase.rangeString, 10)
+		if err != testCase.err {
+			t.Fatalf("Test %d: expected: %v, got: %v", i+1, testCase.err, err)
+		}
+		if len(hranges) != len(testCase.ranges) {
+			t.Fatalf("Test %d: expected %d ranges, got %d", i+1, len(testCase.ranges), len(hranges))
+		}
+		for j, hrange := range hranges {
+			if hrange.offsetBegin != testCase.ranges[j][0] || hrange.offsetEnd != testCase.ranges[j][1] {
+				t.Fatalf("Test %d: expected: %v, got: %s", i+1, testCase.ranges[j], hrange)
+			}
+		}
+	}
+
+	// Test invalid range strings.
+	invalidRangeStrings := []string{
+		"bytes=0-1,",
+		"bytes=0-1,5-2",
+		"0-1,2-3",
+		"bytes=" + strings.Repeat("0-1,", maxRequestRanges) + "0-1",
+	}
+	for _, rangeString := range invalidRangeStrings {
+		if _, err := parseRequestRanges(rangeString, 10); err == nil || err == errInvalidRange {
+			t.Fatalf("expected: a parse error, got: %v", err)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	ldap "gopkg.in/ldap.v2"
+)
+
+// errInvalidIdentity - the user name or password is wrong.
+var errInvalidIdentity = errors.New("invalid username or password")
+
+// errNoGroupPolicy - no policy is mapped to the groups of the user.
+var errNoGroupPolicy = errors.New("No policy is mapped to the groups of the user")
+
+// Identity - user authenticated by an identity provider, with the
+// groups the user is a member of.
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+// IdentityProvider - authenticates users against an external
+// directory, authenticated users are issued temporary credentials
+// with the policies mapped from their groups.
+type IdentityProvider interface {
+	// Authenticate - checks the password of a user and returns the
+	// identity, errInvalidIdentity if the user name or password is
+	// wrong.
+	Authenticate(username, password string) (Identity, error)
+}
+
+// identityConfig - configuration of the identity provider and of the
+// policies granted to its groups.
+type identityConfig struct {
+	LDAP          ldapConfig          `json:"ldap"`
+	OpenID        openIDConfig        `json:"openid"`
+	GroupPolicies []groupPolicyConfig `json:"groupPolicies"`
+}
+
+// groupPolicyConfig - managed policy granted to the members of a
+// group on the buckets matching a pattern, e.g. builds-*.
+type groupPolicyConfig struct {
+	Group  string `json:"group"`
+	Policy string `json:"policy"`
+	Bucket string `json:"bucket"`
+}
+
+// Validate - validates the identity configuration.
+func (c identityConfig) Validate() error {
+	if err := c.LDAP.Validate(); err != nil {
+		return err
+	}
+	if err := c.OpenID.Validate(); err != nil {
+		return err
+	}
+	for _, p := range c.GroupPolicies {
+		if p.Group == "" || p.Bucket == "" {
+			return errors.New("Group and bucket of group policies cannot be empty")
+		}
+		if _, ok := builtInManagedPolicies[p.Policy]; !ok && !validManagedPolicyNameRegexp.MatchString(p.Policy) {
+			return fmt.Errorf("Invalid managed policy name `%s` in group policies", p.Policy)
+		}
+	}
+	return nil
+}
+
+// getGrants - returns the policies granted to the groups, a group
+// given as a DN also matches by the value of its first RDN, e.g.
+// CN=engineers,OU=Groups,DC=example,DC=com matches engineers.
+func (c identityConfig) getGrants(groups []string) []sessionGrant {
+	var grants []sessionGrant
+	for _, p := range c.GroupPolicies {
+		for _, group := range groups {
+			if strings.EqualFold(p.Group, group) || strings.EqualFold(p.Group, getFirstRDNValue(group)) {
+				grants = append(grants, sessionGrant{Policy: p.Policy, Bucket: p.Bucket})
+				break
+			}
+		}
+	}
+	return grants
+}
+
+// Returns the value of the first RDN of a DN, empty if it is no DN.
+func getFirstRDNValue(dn string) string {
+	eq := strings.IndexByte(dn, '=')
+	if eq < 0 {
+		return ""
+	}
+	value := dn[eq+1:]
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',', '+':
+			return value[:i]
+		}
+	}
+	return value
+}
+
+// newIdentityProvider - returns the configured identity provider, nil
+// if temporary credentials are disabled.
+func newIdentityProvider(config identityConfig) (IdentityProvider, error) {
+	if !config.LDAP.Enable {
+		return nil, nil
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return newLDAPIdentityProvider(config.LDAP), nil
+}
+
+// ldapConfig - LDAP directory, e.g. Active Directory, users are
+// authenticated by binding as the name of usernameFormat, %s standing
+// for the user name. Groups are the values of groupNameAttribute of
+// the entries found by groupSearchFilter, %s standing for the user
+// name and %d for the name bound with.
+type ldapConfig struct {
+	Enable             bool   `json:"enable"`
+	ServerURL          string `json:"serverURL"`
+	TLSSkipVerify      bool   `json:"tlsSkipVerify"`
+	UsernameFormat     string `json:"usernameFormat"`
+	GroupSearchBaseDN  string `json:"groupSearchBaseDN"`
+	GroupSearchFilter  string `json:"groupSearchFilter"`
+	GroupNameAttribute string `json:"groupNameAttribute"`
+}
+
+// LDAP requests time out after this long.
+const ldapRequestTimeout = 10 * time.Second
+
+// Validate - validates the LDAP configuration.
+func (l ldapConfig) Validate() error {
+	if !l.Enable {
+		return nil
+	}
+	if _, _, err := l.getServerAddr(); err != nil {
+		return err
+	}
+	if !strings.Contains(l.UsernameFormat, "%s") {
+		return fmt.Errorf("LDAP username format `%s` must contain %%s", l.UsernameFormat)
+	}
+	if l.GroupSearchFilter != "" {
+		if l.GroupSearchBaseDN == "" || l.GroupNameAttribute == "" {
+			return errors.New("LDAP group search base DN and group name attribute cannot be empty")
+		}
+		if _, err := ldap.CompileFilter(l.getGroupSearchFilter("user", "user")); err != nil {
+			return fmt.Errorf("LDAP group search filter `%s` invalid: %v", l.GroupSearchFilter, err)
+		}
+	}
+	return nil
+}
+
+// Returns the host:port of the server URL, ldap:// or ldaps://, and
+// whether to connect over TLS.
+func (l ldapConfig) getServerAddr() (addr string, secure bool, err error) {
+	u, err := checkURL(l.ServerURL)
+	if err != nil {
+		return "", false, err
+	}
+	switch u.Scheme {
+	case "ldap":
+		addr = u.Host
+	case "ldaps":
+		addr, secure = u.Host, true
+	default:
+		return "", false, fmt.Errorf("LDAP server URL `%s` must be an ldap or ldaps URL", l.ServerURL)
+	}
+	if u.Port() == "" {
+		port := "389"
+		if secure {
+			port = "636"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+	if u.Hostname() == "" || (u.Path != "" && u.Path != "/") {
+		return "", false, fmt.Errorf("LDAP server URL `%s` must hold the host only", (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String())
+	}
+	return addr, secure, nil
+}
+
+// Returns the group search filter of a user bound as bindName.
+func (l ldapConfig) getGroupSearchFilter(username, bindName string) string {
+	return strings.NewReplacer("%s", ldap.EscapeFilter(username), "%d", ldap.EscapeFilter(bindName)).Replace(l.GroupSearchFilter)
+}
+
+// Escapes a value for use in an attribute value of a distinguished
+// name as in RFC 4514, e.g. uid=%s,ou=people.
+func ldapEscapeDN(value string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case strings.IndexByte(`,+"\<>;=`, c) >= 0,
+			i == 0 && (c == ' ' || c == '#'),
+			i == len(value)-1 && c == ' ':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == 0:
+			buf.WriteString(`\00`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// ldapIdentityProvider - authenticates users by binding to an LDAP
+// server with their password and searches their groups.
+type ldapIdentityProvider struct {
+	config ldapConfig
+}
+
+func newLDAPIdentityProvider(config ldapConfig) *ldapIdentityProvider {
+	return &ldapIdentityProvider{config: config}
+}
+
+// Connects to the LDAP server.
+func (l *ldapIdentityProvider) dial() (*ldap.Conn, error) {
+	addr, secure, err := l.config.getServerAddr()
+	if err != nil {
+		return nil, err
+	}
+	// Dialed here, ldap.Dial only times out after ldap.DefaultTimeout.
+	dialer := &net.Dialer{Timeout: ldapRequestTimeout}
+	var netConn net.Conn
+	if secure {
+		host, _, _ := net.SplitHostPort(addr)
+		netConn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{
+			ServerName:         host,
+			RootCAs:            globalRootCAs,
+			InsecureSkipVerify: l.config.TLSSkipVerify,
+		})
+	} else {
+		netConn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn := ldap.NewConn(netConn, secure)
+	conn.SetTimeout(ldapRequestTimeout)
+	conn.Start()
+	return conn, nil
+}
+
+// Authenticate - binds as the user and searches the groups of the
+// user with its own permissions.
+func (l *ldapIdentityProvider) Authenticate(username, password string) (Identity, error) {
+	// Binds with empty passwords are unauthenticated binds, which
+	// succeed on most servers.
+	if username == "" || password == "" {
+		return Identity{}, errInvalidIdentity
+	}
+	conn, err := l.dial()
+	if err != nil {
+		return Identity{}, err
+	}
+	defer conn.Close()
+
+	bindName := strings.Replace(l.config.UsernameFormat, "%s", ldapEscapeDN(username), -1)
+	if err = conn.Bind(bindName, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return Identity{}, errInvalidIdentity
+		}
+		return Identity{}, err
+	}
+
+	identity := Identity{User: username}
+	if l.config.GroupSearchFilter == "" {
+		return identity, nil
+	}
+	result, err := conn.Search(ldap.NewSearchRequest(
+		l.config.GroupSearchBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		l.config.getGroupSearchFilter(username, bindName),
+		[]string{l.config.GroupNameAttribute}, nil,
+	))
+	if err != nil {
+		return Identity{}, err
+	}
+	for _, entry := range result.Entries {
+		identity.Groups = append(identity.Groups, entry.GetAttributeValues(l.config.GroupNameAttribute)...)
+	}
+	return identity, nil
+}
@@ -0,0 +1,218 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+
+	ber "gopkg.in/asn1-ber.v1"
+	ldap "gopkg.in/ldap.v2"
+)
+
+// Tests validating the identity configuration.
+func TestIdentityConfigValidate(t *testing.T) {
+	valid := identityConfig{
+		LDAP: ldapConfig{
+			Enable:             true,
+			ServerURL:          "ldaps://ad.example.com",
+			UsernameFormat:     "%s@example.com",
+			GroupSearchBaseDN:  "dc=example,dc=com",
+			GroupSearchFilter:  "(&(objectClass=user)(userPrincipalName=%d))",
+			GroupNameAttribute: "memberOf",
+		},
+		GroupPolicies: []groupPolicyConfig{{Group: "dev", Policy: "readwrite", Bucket: "dev-*"}},
+	}
+	testCases := []struct {
+		config  func(identityConfig) identityConfig
+		success bool
+	}{
+		{func(c identityConfig) identityConfig { return c }, true},
+		{func(c identityConfig) identityConfig { return identityConfig{} }, true},
+		{func(c identityConfig) identityConfig { c.LDAP.ServerURL = "ldap://ad.example.com:3268"; return c }, true},
+		{func(c identityConfig) identityConfig { c.LDAP.GroupSearchFilter = ""; return c }, true},
+		{func(c identityConfig) identityConfig { c.LDAP.ServerURL = ""; return c }, false},
+		{func(c identityConfig) identityConfig { c.LDAP.ServerURL = "https://ad.example.com"; return c }, false},
+		{func(c identityConfig) identityConfig { c.LDAP.ServerURL = "ldap://ad.example.com/dc=com"; return c }, false},
+		{func(c identityConfig) identityConfig { c.LDAP.UsernameFormat = "uid=john"; return c }, false},
+		{func(c identityConfig) identityConfig { c.LDAP.GroupSearchFilter = "(member=%d"; return c }, false},
+		{func(c identityConfig) identityConfig { c.LDAP.GroupNameAttribute = ""; return c }, false},
+		{func(c identityConfig) identityConfig {
+			c.OpenID = openIDConfig{Enable: true, IssuerURL: "ldap://example.com"}
+			return c
+		}, false},
+		{func(c identityConfig) identityConfig {
+			c.OpenID = openIDConfig{Enable: true, IssuerURL: "https://example.com"}
+			return c
+		}, false},
+		{func(c identityConfig) identityConfig {
+			c.OpenID = openIDConfig{Enable: true, IssuerURL: "https://example.com/realms/minio", ClientID: "minio"}
+			return c
+		}, true},
+		{func(c identityConfig) identityConfig { c.GroupPolicies[0].Policy = "no/such"; return c }, false},
+		{func(c identityConfig) identityConfig { c.GroupPolicies[0].Bucket = ""; return c }, false},
+	}
+	for i, testCase := range testCases {
+		config := valid
+		config.GroupPolicies = append([]groupPolicyConfig(nil), valid.GroupPolicies...)
+		err := testCase.config(config).Validate()
+		if testCase.success && err != nil {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if !testCase.success && err == nil {
+			t.Errorf("Test %d: Expected to fail", i+1)
+		}
+	}
+}
+
+// Tests mapping groups to the policies granted.
+func TestIdentityConfigGetGrants(t *testing.T) {
+	config := identityConfig{GroupPolicies: []groupPolicyConfig{
+		{Group: "dev", Policy: "readwrite", Bucket: "dev-*"},
+		{Group: "Auditors", Policy: "readonly", Bucket: "*"},
+		{Group: "ops", Policy: "readwrite", Bucket: "ops"},
+	}}
+	testCases := []struct {
+		groups   []string
+		expected []sessionGrant
+	}{
+		{nil, nil},
+		{[]string{"other"}, nil},
+		{[]string{"dev"}, []sessionGrant{{Policy: "readwrite", Bucket: "dev-*"}}},
+		{[]string{"CN=auditors,OU=Groups,DC=example,DC=com", "cn=dev\\,old,dc=com"}, []sessionGrant{{Policy: "readonly", Bucket: "*"}}},
+		{[]string{"ops", "dev"}, []sessionGrant{{Policy: "readwrite", Bucket: "dev-*"}, {Policy: "readwrite", Bucket: "ops"}}},
+	}
+	for i, testCase := range testCases {
+		if grants := config.getGrants(testCase.groups); !reflect.DeepEqual(grants, testCase.expected) {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, grants)
+		}
+	}
+}
+
+// Starts a fake LDAP server of a single user, returns its URL.
+func newTestLDAPServer(t *testing.T) (serverURL string, stop func()) {
+	str := func(value string) *ber.Packet {
+		return ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "")
+	}
+	constructed := func(class ber.Class, tag ber.Tag, children ...*ber.Packet) *ber.Packet {
+		packet := ber.Encode(class, ber.TypeConstructed, tag, nil, "")
+		for _, child := range children {
+			packet.AppendChild(child)
+		}
+		return packet
+	}
+	result := func(tag ber.Tag, code int64) *ber.Packet {
+		return constructed(ber.ClassApplication, tag,
+			ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""), str(""), str(""))
+	}
+	group := func(cn string) *ber.Packet {
+		return constructed(ber.ClassApplication, ldap.ApplicationSearchResultEntry, str("cn="+cn+",ou=groups,dc=example,dc=com"),
+			constructed(ber.ClassUniversal, ber.TagSequence, constructed(ber.ClassUniversal, ber.TagSequence, str("cn"),
+				constructed(ber.ClassUniversal, ber.TagSet, str(cn)))))
+	}
+	filter, err := ldap.CompileFilter("(&(objectClass=groupOfNames)(member=uid=john,ou=people,dc=example,dc=com))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		for {
+			msg, err := ber.ReadPacket(conn)
+			if err != nil {
+				return
+			}
+			var responses []*ber.Packet
+			switch op := msg.Children[1]; op.Tag {
+			case ldap.ApplicationBindRequest:
+				code := int64(ldap.LDAPResultInvalidCredentials)
+				if op.Children[1].Data.String() == "uid=john,ou=people,dc=example,dc=com" && op.Children[2].Data.String() == "secret" {
+					code = ldap.LDAPResultSuccess
+				}
+				responses = append(responses, result(ldap.ApplicationBindResponse, code))
+			case ldap.ApplicationSearchRequest:
+				if bytes.Equal(op.Children[6].Bytes(), filter.Bytes()) {
+					responses = append(responses, group("dev"), group("ops"))
+				}
+				responses = append(responses, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+			default:
+				return
+			}
+			for _, resp := range responses {
+				conn.Write(constructed(ber.ClassUniversal, ber.TagSequence, msg.Children[0], resp).Bytes())
+			}
+		}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return "ldap://" + listener.Addr().String(), func() { listener.Close() }
+}
+
+// Tests authenticating users and searching their groups.
+func TestLDAPIdentityProvider(t *testing.T) {
+	serverURL, stop := newTestLDAPServer(t)
+	config := identityConfig{LDAP: ldapConfig{
+		Enable:             true,
+		ServerURL:          serverURL,
+		UsernameFormat:     "uid=%s,ou=people,dc=example,dc=com",
+		GroupSearchBaseDN:  "ou=groups,dc=example,dc=com",
+		GroupSearchFilter:  "(&(objectClass=groupOfNames)(member=%d))",
+		GroupNameAttribute: "cn",
+	}}
+	provider, err := newIdentityProvider(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := provider.Authenticate("john", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.User != "john" || !reflect.DeepEqual(identity.Groups, []string{"dev", "ops"}) {
+		t.Errorf("Unexpected identity %+v", identity)
+	}
+
+	for _, credentials := range [][2]string{{"john", "wrong"}, {"john", ""}, {"", "secret"}, {"john,ou=people", "secret"}} {
+		if _, err = provider.Authenticate(credentials[0], credentials[1]); err != errInvalidIdentity {
+			t.Errorf("%v: Expected invalid identity, got %v", credentials, err)
+		}
+	}
+
+	// Unreachable servers are no invalid identity.
+	stop()
+	if _, err = provider.Authenticate("john", "secret"); err == nil || err == errInvalidIdentity {
+		t.Errorf("Expected a connection error, got %v", err)
+	}
+
+	if provider, err = newIdentityProvider(identityConfig{}); provider != nil || err != nil {
+		t.Errorf("Expected no identity provider, got %v, %v", provider, err)
+	}
+}
@@ -30,7 +30,8 @@ import (
 const (
 	jwtAlgorithm = "Bearer"
 
-	// Default JWT token for web handlers is one day.
+	// Default JWT token for web handlers is one day, can be set via
+	// MINIO_BROWSER_TOKEN_EXPIRY.
 	defaultJWTExpiry = 24 * time.Hour
 
 	// Inter-node JWT token expiry is 100 years approx.
@@ -47,25 +48,54 @@ var (
 	errNoAuthToken          = errors.New("JWT token missing")
 )
 
-func authenticateJWT(accessKey, secretKey string, expiry time.Duration) (string, error) {
+// checkServerCredentials - checks the credentials are the server
+// credentials.
+func checkServerCredentials(accessKey, secretKey string) error {
 	passedCredential, err := auth.CreateCredentials(accessKey, secretKey)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	serverCred := globalServerConfig.GetCredential()
 
 	if serverCred.AccessKey != passedCredential.AccessKey {
-		return "", errInvalidAccessKeyID
+		return errInvalidAccessKeyID
 	}
 
 	if !serverCred.Equal(passedCredential) {
-		return "", errAuthentication
+		return errAuthentication
+	}
+	return nil
+}
+
+func authenticateJWT(accessKey, secretKey string, expiry time.Duration) (string, error) {
+	if err := checkServerCredentials(accessKey, secretKey); err != nil {
+		return "", err
 	}
+	return newAuthToken("", expiry)
+}
+
+// authClaims - claims of a token of the server credentials. Parent is
+// the ID of the browser refresh token the token was issued with, if any.
+type authClaims struct {
+	Parent string `json:"parent,omitempty"`
+	jwtgo.StandardClaims
+}
 
-	jwt := jwtgo.NewWithClaims(jwtgo.SigningMethodHS512, jwtgo.StandardClaims{
-		ExpiresAt: UTCNow().Add(expiry).Unix(),
-		Subject:   accessKey,
+// newAuthToken - returns a token of the server credentials valid for
+// expiry, the caller must have authenticated the credentials. The token
+// is revoked along with the browser refresh token with the ID parent.
+func newAuthToken(parent string, expiry time.Duration) (string, error) {
+	serverCred := globalServerConfig.GetCredential()
+	now := UTCNow()
+	jwt := jwtgo.NewWithClaims(jwtgo.SigningMethodHS512, authClaims{
+		Parent: parent,
+		StandardClaims: jwtgo.StandardClaims{
+			Id:        mustGetUUID(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(expiry).Unix(),
+			Subject:   serverCred.AccessKey,
+		},
 	})
 	return jwt.SignedString([]byte(serverCred.SecretKey))
 }
@@ -75,7 +105,7 @@ func authenticateNode(accessKey, secretKey string) (string, error) {
 }
 
 func authenticateWeb(accessKey, secretKey string) (string, error) {
-	return authenticateJWT(accessKey, secretKey, defaultJWTExpiry)
+	return authenticateJWT(accessKey, secretKey, globalBrowserTokenExpiry)
 }
 
 func authenticateURL(accessKey, secretKey string) (string, error) {
@@ -113,9 +143,9 @@ func isHTTPRequestValid(req *http.Request) bool {
 
 // Check if the request is authenticated.
 // Returns nil if the request is authenticated. errNoAuthToken if token missing.
-// Returns errAuthentication for all other errors.
+// Returns errAuthentication for all other errors, revoked tokens included.
 func webRequestAuthenticate(req *http.Request) error {
-	var claims jwtgo.StandardClaims
+	var claims authClaims
 	jwtToken, err := jwtreq.ParseFromRequestWithClaims(req, jwtreq.AuthorizationHeaderExtractor, &claims, keyFuncCallback)
 	if err != nil {
 		if err == jwtreq.ErrNoTokenInRequest {
@@ -132,5 +162,30 @@ func webRequestAuthenticate(req *http.Request) error {
 	if !jwtToken.Valid {
 		return errAuthentication
 	}
+	if globalBrowserTokenRevocations.isRevoked(newObjectLayerFn(), claims.Subject, claims.Id, claims.Parent, claims.IssuedAt) {
+		return errAuthentication
+	}
 	return nil
 }
+
+// webRequestAuthenticateUser - like webRequestAuthenticate, also
+// authenticates users logged in with OpenID Connect, whose session
+// claims are returned. Claims are nil for the server credentials.
+func webRequestAuthenticateUser(req *http.Request) (*sessionClaims, error) {
+	authErr := webRequestAuthenticate(req)
+	if authErr != errAuthentication {
+		return nil, authErr
+	}
+	token, err := jwtreq.AuthorizationHeaderExtractor.ExtractToken(req)
+	if err != nil {
+		return nil, authErr
+	}
+	claims, errCode := parseSessionToken(token)
+	if errCode != ErrNone {
+		return nil, authErr
+	}
+	if globalBrowserTokenRevocations.isRevoked(newObjectLayerFn(), claims.User, claims.Id, claims.Parent, claims.IssuedAt) {
+		return nil, errAuthentication
+	}
+	return claims, nil
+}
@@ -0,0 +1,273 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KMS seals and unseals the data keys of objects encrypted with SSE-S3
+// by a master key it never discloses.
+type KMS interface {
+	// GenerateKey returns a new data key along with the data key
+	// sealed by the latest version of the master key.
+	GenerateKey() (key []byte, sealedKey string, err error)
+
+	// UnsealKey returns the data key sealed by any version of the
+	// master key, master keys are rotated without re-encrypting
+	// objects.
+	UnsealKey(sealedKey string) (key []byte, err error)
+}
+
+// kmsConfig - configuration of the KMS sealing data keys of objects
+// encrypted with SSE-S3.
+type kmsConfig struct {
+	Vault vaultConfig `json:"vault"`
+}
+
+// Validate - validates the KMS configuration.
+func (k kmsConfig) Validate() error {
+	return k.Vault.Validate()
+}
+
+// newKMS - returns the configured KMS, nil if SSE-S3 is disabled.
+func newKMS(config kmsConfig) (KMS, error) {
+	if !config.Vault.Enable {
+		return nil, nil
+	}
+	if err := config.Vault.Validate(); err != nil {
+		return nil, err
+	}
+	vault := newVaultKMS(config.Vault)
+	// Fail early when Vault cannot be reached or rejects our role.
+	if _, err := vault.getToken(); err != nil {
+		return nil, err
+	}
+	return vault, nil
+}
+
+// vaultConfig - HashiCorp Vault with the transit secrets engine
+// mounted at /transit, authenticated by AppRole.
+type vaultConfig struct {
+	Enable   bool      `json:"enable"`
+	Endpoint string    `json:"endpoint"`
+	Auth     vaultAuth `json:"auth"`
+	Key      string    `json:"key"`
+}
+
+type vaultAuth struct {
+	Type    string       `json:"type"`
+	AppRole vaultAppRole `json:"approle"`
+}
+
+type vaultAppRole struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+// Vault requests time out after this long.
+const vaultRequestTimeout = 10 * time.Second
+
+// Validate - validates the Vault configuration.
+func (v vaultConfig) Validate() error {
+	if !v.Enable {
+		return nil
+	}
+	u, err := checkURL(v.Endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("Vault endpoint `%s` must be an http or https URL", v.Endpoint)
+	}
+	if v.Auth.Type != "approle" {
+		return fmt.Errorf("Vault auth type `%s` is not supported, only `approle`", v.Auth.Type)
+	}
+	if v.Auth.AppRole.ID == "" || v.Auth.AppRole.Secret == "" {
+		return errors.New("Vault AppRole id and secret cannot be empty")
+	}
+	if v.Key == "" {
+		return errors.New("Vault key name cannot be empty")
+	}
+	return nil
+}
+
+// vaultKMS - seals data keys with a named key of the Vault transit
+// secrets engine. Vault keeps all versions of a rotated key and
+// sealed data keys name the version they are sealed with.
+type vaultKMS struct {
+	config vaultConfig
+	client *http.Client
+
+	// Client token of the AppRole login, renewed by logging in
+	// again before its lease expires. Tokens without lease have
+	// no expiry.
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func newVaultKMS(config vaultConfig) *vaultKMS {
+	return &vaultKMS{
+		config: config,
+		client: &http.Client{
+			Timeout: vaultRequestTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{RootCAs: globalRootCAs},
+			},
+		},
+	}
+}
+
+// errVaultPermissionDenied - the client token is expired or revoked.
+var errVaultPermissionDenied = errors.New("Vault: permission denied")
+
+// Sends a request to the Vault API at path and decodes the response
+// into out.
+func (v *vaultKMS) do(path, token string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(v.config.Endpoint, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return errVaultPermissionDenied
+	}
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&vaultErr) != nil || len(vaultErr.Errors) == 0 {
+			return fmt.Errorf("Vault: %s", resp.Status)
+		}
+		return fmt.Errorf("Vault: %s", strings.Join(vaultErr.Errors, ", "))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Returns the client token, logging in with the AppRole when there is
+// none or half of its lease has passed.
+func (v *vaultKMS) getToken() (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.token != "" && (v.tokenExpiry.IsZero() || UTCNow().Before(v.tokenExpiry)) {
+		return v.token, nil
+	}
+
+	login := map[string]string{
+		"role_id":   v.config.Auth.AppRole.ID,
+		"secret_id": v.config.Auth.AppRole.Secret,
+	}
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int64  `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := v.do("/v1/auth/approle/login", "", login, &resp); err != nil {
+		return "", err
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", errors.New("Vault: AppRole login returned no client token")
+	}
+	v.token = resp.Auth.ClientToken
+	v.tokenExpiry = time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		v.tokenExpiry = UTCNow().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second / 2)
+	}
+	return v.token, nil
+}
+
+// Sends a request authenticated by the client token, logging in again
+// once if the token was revoked.
+func (v *vaultKMS) request(path string, in, out interface{}) error {
+	token, err := v.getToken()
+	if err != nil {
+		return err
+	}
+	if err = v.do(path, token, in, out); err != errVaultPermissionDenied {
+		return err
+	}
+
+	v.mu.Lock()
+	v.token = ""
+	v.mu.Unlock()
+	if token, err = v.getToken(); err != nil {
+		return err
+	}
+	return v.do(path, token, in, out)
+}
+
+// GenerateKey - returns a new data key of 256 bits generated by Vault.
+func (v *vaultKMS) GenerateKey() ([]byte, string, error) {
+	var resp struct {
+		Data struct {
+			Plaintext  string `json:"plaintext"`
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	if err := v.request("/v1/transit/datakey/plaintext/"+v.config.Key, map[string]int{"bits": 256}, &resp); err != nil {
+		return nil, "", err
+	}
+	key, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil || len(key) != SSECustomerKeySize || resp.Data.Ciphertext == "" {
+		return nil, "", errors.New("Vault: invalid data key")
+	}
+	return key, resp.Data.Ciphertext, nil
+}
+
+// UnsealKey - returns the data key decrypted by Vault.
+func (v *vaultKMS) UnsealKey(sealedKey string) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := v.request("/v1/transit/decrypt/"+v.config.Key, map[string]string{"ciphertext": sealedKey}, &resp); err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil || len(key) != SSECustomerKeySize {
+		return nil, errors.New("Vault: invalid data key")
+	}
+	return key, nil
+}
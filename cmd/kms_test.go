@@ -0,0 +1,225 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testKMS - KMS sealing data keys in memory, keys are rotated by
+// adding a new version.
+type testKMS struct {
+	mu       sync.Mutex
+	versions [][]byte
+}
+
+func newTestKMS() *testKMS {
+	kms := &testKMS{}
+	kms.Rotate()
+	return kms
+}
+
+func (k *testKMS) Rotate() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	master := make([]byte, SSECustomerKeySize)
+	rand.Read(master)
+	k.versions = append(k.versions, master)
+}
+
+func xorKey(key, master []byte) []byte {
+	sealed := make([]byte, len(key))
+	for i := range key {
+		sealed[i] = key[i] ^ master[i]
+	}
+	return sealed
+}
+
+func (k *testKMS) GenerateKey() ([]byte, string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key := make([]byte, SSECustomerKeySize)
+	rand.Read(key)
+	sealed := xorKey(key, k.versions[len(k.versions)-1])
+	return key, fmt.Sprintf("test:v%d:%s", len(k.versions), base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+func (k *testKMS) UnsealKey(sealedKey string) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	var version int
+	var encoded string
+	if _, err := fmt.Sscanf(strings.Replace(sealedKey, ":", " ", -1), "test v%d %s", &version, &encoded); err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || version < 1 || version > len(k.versions) || len(sealed) != SSECustomerKeySize {
+		return nil, errors.New("invalid sealed key")
+	}
+	return xorKey(sealed, k.versions[version-1]), nil
+}
+
+// Returns a fake Vault server serving AppRole logins and the transit
+// secrets engine, tokens issued before revoke is called are rejected.
+func newTestVaultServer(t *testing.T, kms *testKMS) (server *httptest.Server, revoke func()) {
+	var mu sync.Mutex
+	var logins int
+	token := func() string {
+		return fmt.Sprintf("token-%d", logins)
+	}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Unable to decode Vault request: %v", err)
+		}
+		if r.URL.Path == "/v1/auth/approle/login" {
+			if req["role_id"] != "role" || req["secret_id"] != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["invalid secret id"]}`))
+				return
+			}
+			logins++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"auth": map[string]interface{}{"client_token": token(), "lease_duration": 3600},
+			})
+			return
+		}
+		if r.Header.Get("X-Vault-Token") != token() {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/transit/datakey/plaintext/minio":
+			key, sealedKey, _ := kms.GenerateKey()
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{"plaintext": base64.StdEncoding.EncodeToString(key), "ciphertext": sealedKey},
+			})
+		case "/v1/transit/decrypt/minio":
+			key, err := kms.UnsealKey(req["ciphertext"].(string))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["invalid ciphertext"]}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{"plaintext": base64.StdEncoding.EncodeToString(key)},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	revoke = func() {
+		mu.Lock()
+		logins++
+		mu.Unlock()
+	}
+	return server, revoke
+}
+
+// Tests validating the Vault configuration.
+func TestVaultConfigValidate(t *testing.T) {
+	valid := vaultConfig{
+		Enable:   true,
+		Endpoint: "https://vault.example.com:8200",
+		Auth:     vaultAuth{Type: "approle", AppRole: vaultAppRole{ID: "role", Secret: "secret"}},
+		Key:      "minio",
+	}
+	testCases := []struct {
+		config  func(vaultConfig) vaultConfig
+		success bool
+	}{
+		{func(c vaultConfig) vaultConfig { return c }, true},
+		{func(c vaultConfig) vaultConfig { return vaultConfig{} }, true},
+		{func(c vaultConfig) vaultConfig { c.Endpoint = ""; return c }, false},
+		{func(c vaultConfig) vaultConfig { c.Endpoint = "ftp://vault.example.com"; return c }, false},
+		{func(c vaultConfig) vaultConfig { c.Auth.Type = "token"; return c }, false},
+		{func(c vaultConfig) vaultConfig { c.Auth.AppRole.Secret = ""; return c }, false},
+		{func(c vaultConfig) vaultConfig { c.Key = ""; return c }, false},
+	}
+	for i, testCase := range testCases {
+		err := testCase.config(valid).Validate()
+		if testCase.success && err != nil {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if !testCase.success && err == nil {
+			t.Errorf("Test %d: Expected to fail", i+1)
+		}
+	}
+}
+
+// Tests data keys are generated and unsealed by Vault.
+func TestVaultKMS(t *testing.T) {
+	server, revoke := newTestVaultServer(t, newTestKMS())
+	defer server.Close()
+
+	config := vaultConfig{
+		Enable:   true,
+		Endpoint: server.URL,
+		Auth:     vaultAuth{Type: "approle", AppRole: vaultAppRole{ID: "role", Secret: "secret"}},
+		Key:      "minio",
+	}
+	kms, err := newKMS(kmsConfig{Vault: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, sealedKey, err := kms.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsealed, err := kms.UnsealKey(sealedKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, unsealed) {
+		t.Fatal("Expected the unsealed data key to match the generated one")
+	}
+
+	// Logs in again when the token is revoked.
+	revoke()
+	if unsealed, err = kms.UnsealKey(sealedKey); err != nil || !bytes.Equal(key, unsealed) {
+		t.Fatalf("Expected to unseal after logging in again, got %v", err)
+	}
+
+	if _, err = kms.UnsealKey("vault:v1:invalid"); err == nil || !strings.Contains(err.Error(), "invalid ciphertext") {
+		t.Errorf("Expected the error of Vault, got %v", err)
+	}
+
+	config.Auth.AppRole.Secret = "wrong"
+	if _, err = newKMS(kmsConfig{Vault: config}); err == nil {
+		t.Error("Expected the AppRole login to fail")
+	}
+
+	// SSE-S3 is disabled without KMS.
+	if kms, err = newKMS(kmsConfig{}); kms != nil || err != nil {
+		t.Errorf("Expected no KMS, got %v, %v", kms, err)
+	}
+}
@@ -23,9 +23,10 @@ type LockRPCClient struct {
 	*AuthRPCClient
 }
 
-// newLockRPCClient returns new lock RPC client object.
+// newLockRPCClient returns new lock RPC client object, clients of the
+// same lock server share their connection.
 func newLockRPCClient(config authConfig) *LockRPCClient {
-	return &LockRPCClient{newAuthRPCClient(config)}
+	return &LockRPCClient{globalRPCClientPool.get(config)}
 }
 
 // RLock calls read lock RPC.
@@ -36,6 +36,16 @@ func (l *localLocker) removeEntryIfExists(nlrip nameLockRequesterInfoPair) {
 	}
 }
 
+// renewEntryIfExists renews the lease of a lock entry, if it still exists in map.
+func (l *localLocker) renewEntryIfExists(nlrip nameLockRequesterInfoPair) {
+	for idx, entry := range l.lockMap[nlrip.name] {
+		if entry.uid == nlrip.lri.uid {
+			l.lockMap[nlrip.name][idx].timeLastValid = UTCNow()
+			return
+		}
+	}
+}
+
 // removeEntry either, based on the uid of the lock message, removes a single entry from the
 // lockRequesterInfo array or the whole array from the map (in case of a write lock or last read lock)
 func (l *localLocker) removeEntry(name, uid string, lri *[]lockRequesterInfo) bool {
@@ -39,6 +39,10 @@ const (
 
 	// Lock validity check interval.
 	lockValidityCheckInterval = 2 * time.Minute // 2 minutes.
+
+	// Lock lease duration, a lock whose owner could not confirm it
+	// as active for this long is considered stale and is released.
+	lockLeaseDuration = 10 * time.Minute // 10 minutes.
 )
 
 // lockRequesterInfo stores various info from the client for each lock that is requested.
@@ -49,6 +53,7 @@ type lockRequesterInfo struct {
 	uid             string    // UID to uniquely identify request of client.
 	timestamp       time.Time // Timestamp set at the time of initialization.
 	timeLastCheck   time.Time // Timestamp for last check of validity of lock.
+	timeLastValid   time.Time // Timestamp when the owner last confirmed the lock as active.
 }
 
 // isWriteLock returns whether the lock is a write or read lock.
@@ -134,6 +139,7 @@ func (l *localLocker) Lock(args dsync.LockArgs) (reply bool, err error) {
 				uid:             args.UID,
 				timestamp:       UTCNow(),
 				timeLastCheck:   UTCNow(),
+				timeLastValid:   UTCNow(),
 			},
 		}
 	}
@@ -170,6 +176,7 @@ func (l *localLocker) RLock(args dsync.LockArgs) (reply bool, err error) {
 		uid:             args.UID,
 		timestamp:       UTCNow(),
 		timeLastCheck:   UTCNow(),
+		timeLastValid:   UTCNow(),
 	}
 	if lri, ok := l.lockMap[args.Resource]; ok {
 		if reply = !isWriteLock(lri); reply {
@@ -299,7 +306,9 @@ type nameLockRequesterInfoPair struct {
 // - server at client down
 // - some network error (and server is up normally)
 //
-// We will ignore the error, and we will retry later to get a resolve on this lock
+// We will ignore the error, and we will retry later to get a resolve on this lock.
+// If the original server could not be reached for longer than lockLeaseDuration,
+// it is presumed to have crashed and the lock is released.
 func (l *lockServer) lockMaintenance(interval time.Duration) {
 	l.ll.mutex.Lock()
 	// Get list of long lived locks to check for staleness.
@@ -309,7 +318,7 @@ func (l *lockServer) lockMaintenance(interval time.Duration) {
 	serverCred := globalServerConfig.GetCredential()
 	// Validate if long lived locks are indeed clean.
 	for _, nlrip := range nlripLongLived {
-		// Initialize client based on the long live locks.
+		// Get the client of the server holding the long lived lock.
 		c := newLockRPCClient(authConfig{
 			accessKey:       serverCred.AccessKey,
 			secretKey:       serverCred.SecretKey,
@@ -320,21 +329,30 @@ func (l *lockServer) lockMaintenance(interval time.Duration) {
 		})
 
 		// Call back to original server verify whether the lock is still active (based on name & uid)
-		expired, _ := c.Expired(dsync.LockArgs{
+		expired, err := c.Expired(dsync.LockArgs{
 			UID:      nlrip.lri.uid,
 			Resource: nlrip.name,
 		})
 
-		// Close the connection regardless of the call response.
-		c.AuthRPCClient.Close()
+		if err != nil {
+			// Original server is unreachable, release the lock only
+			// once its lease has lapsed.
+			expired = UTCNow().Sub(nlrip.lri.timeLastValid) >= lockLeaseDuration
+			if expired {
+				errorIf(err, "Releasing lock on %s held by unreachable server %s for more than %s",
+					nlrip.name, nlrip.lri.node, lockLeaseDuration)
+			}
+		}
 
-		// For successful response, verify if lock is indeed active or stale.
+		l.ll.mutex.Lock()
 		if expired {
 			// The lock is no longer active at server that originated the lock
 			// So remove the lock from the map.
-			l.ll.mutex.Lock()
 			l.ll.removeEntryIfExists(nlrip) // Purge the stale entry if it exists.
-			l.ll.mutex.Unlock()
+		} else if err == nil {
+			// Lock is confirmed active, renew its lease.
+			l.ll.renewEntryIfExists(nlrip)
 		}
+		l.ll.mutex.Unlock()
 	}
 }
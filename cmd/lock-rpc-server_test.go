@@ -21,6 +21,7 @@ import (
 	"runtime"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/minio/dsync"
 )
@@ -435,6 +436,52 @@ func TestLockRpcServerExpired(t *testing.T) {
 	}
 }
 
+// Test that lock maintenance releases locks of unreachable owners
+// only after their lease has lapsed.
+func TestLockRpcServerLockMaintenanceLease(t *testing.T) {
+	testPath, locker, _ := createLockTestServer(t)
+	defer os.RemoveAll(testPath)
+
+	// Nothing listens on port 1, the owner is unreachable.
+	newEntry := func(uid string, lastValid time.Time) []lockRequesterInfo {
+		return []lockRequesterInfo{{
+			writer:          true,
+			node:            "127.0.0.1:1",
+			serviceEndpoint: "rpc-path",
+			uid:             uid,
+			timestamp:       lastValid,
+			timeLastCheck:   lastValid,
+			timeLastValid:   lastValid,
+		}}
+	}
+	locker.ll.lockMap["bucket/lapsed"] = newEntry("0123-4567", UTCNow().Add(-2*lockLeaseDuration))
+	locker.ll.lockMap["bucket/leased"] = newEntry("89ab-cdef", UTCNow().Add(-lockLeaseDuration/2))
+
+	locker.lockMaintenance(0)
+
+	if _, ok := locker.ll.lockMap["bucket/lapsed"]; ok {
+		t.Errorf("Expected lock with lapsed lease to be released")
+	}
+	if _, ok := locker.ll.lockMap["bucket/leased"]; !ok {
+		t.Errorf("Expected lock with active lease to be retained")
+	}
+
+	// Lock leases are listed per bucket and prefix.
+	currentLockServer := globalLockServer
+	defer func() { globalLockServer = currentLockServer }()
+	globalLockServer = locker
+
+	if leases := listLockLeases("bucket", "lea", 0); len(leases) != 1 || leases[0].Object != "leased" {
+		t.Errorf("Expected lease on bucket/leased, got %#v", leases)
+	}
+	if leases := listLockLeases("bucket", "lapsed", 0); len(leases) != 0 {
+		t.Errorf("Expected no leases, got %#v", leases)
+	}
+	if leases := listLockLeases("bucket", "", lockLeaseDuration); len(leases) != 0 {
+		t.Errorf("Expected no leases older than %s, got %#v", lockLeaseDuration, leases)
+	}
+}
+
 // Test initialization of lock server.
 func TestLockServerInit(t *testing.T) {
 	if runtime.GOOS == globalWindowsOSName {
@@ -16,7 +16,10 @@
 
 package cmd
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // SystemLockState - Structure to fill the lock state of entire object storage.
 // That is the total locks held, total calls blocked on locks and state of all the locks for the entire system.
@@ -69,3 +72,63 @@ func listLocksInfo(bucket, prefix string, duration time.Duration) []VolumeLockIn
 	locksInfo, _ := newObjectLayerFn().ListLocks(bucket, prefix, duration)
 	return locksInfo
 }
+
+// LockLeaseInfo - Structure to contain a lock granted by a lock server, along
+// with its owner and the last time the owner confirmed it as active.
+type LockLeaseInfo struct {
+	Bucket        string    `json:"bucket"`
+	Object        string    `json:"object"`
+	Writer        bool      `json:"writer"`
+	Owner         string    `json:"owner"`         // Network address of the server holding the lock.
+	UID           string    `json:"uid"`           // UID to uniquely identify the lock request.
+	Since         time.Time `json:"since"`         // Time when the lock was granted.
+	LastValidated time.Time `json:"lastValidated"` // Time when the owner last confirmed the lock.
+	LockServer    string    `json:"lockServer"`    // Lock server which granted the lock.
+}
+
+// listLockLeases - Fetches locks granted by the local lock server on bucket,
+// matching prefix held for longer than duration.
+func listLockLeases(bucket, prefix string, duration time.Duration) []LockLeaseInfo {
+	leases := []LockLeaseInfo{}
+	// Lock server is only initialized in distributed setup.
+	if globalLockServer == nil {
+		return leases
+	}
+
+	ll := &globalLockServer.ll
+	ll.mutex.Lock()
+	defer ll.mutex.Unlock()
+
+	timeNow := UTCNow()
+	for resource, lri := range ll.lockMap {
+		// Resource names are of the form <bucket>/<object>.
+		resourceBucket, resourceObject := resource, ""
+		if idx := strings.Index(resource, slashSeparator); idx >= 0 {
+			resourceBucket, resourceObject = resource[:idx], resource[idx+1:]
+		}
+		if resourceBucket != bucket {
+			continue
+		}
+		// N B empty prefix matches all objects.
+		if !hasPrefix(resourceObject, prefix) {
+			continue
+		}
+		for _, entry := range lri {
+			// filter locks that were held for longer than duration.
+			if timeNow.Sub(entry.timestamp) < duration {
+				continue
+			}
+			leases = append(leases, LockLeaseInfo{
+				Bucket:        resourceBucket,
+				Object:        resourceObject,
+				Writer:        entry.writer,
+				Owner:         entry.node,
+				UID:           entry.uid,
+				Since:         entry.timestamp,
+				LastValidated: entry.timeLastValid,
+				LockServer:    ll.serverAddr,
+			})
+		}
+	}
+	return leases
+}
@@ -103,6 +103,7 @@ func newApp(name string) *cli.App {
 	// Register all commands.
 	registerCommand(serverCmd)
 	registerCommand(gatewayCmd)
+	registerCommand(cpCmd)
 	registerCommand(updateCmd)
 	registerCommand(versionCmd)
 
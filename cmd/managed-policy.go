@@ -0,0 +1,520 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/pkg/policy"
+	"github.com/minio/minio-go/pkg/set"
+	errors2 "github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+const (
+	// Managed policies are stored under this prefix of minioMetaBucket,
+	// one object per policy holding all its versions.
+	managedPolicyPrefix = "config/policies"
+
+	// Managed policy attached to a bucket, persisted under the bucket
+	// config prefix.
+	bucketManagedPolicyConfig = "managed-policy.json"
+
+	// Variable standing for the bucket a managed policy is attached to
+	// in the resources of its statements.
+	managedPolicyBucketVar = "${bucket}"
+
+	// Maximum number of versions kept of a managed policy, the oldest
+	// versions are deleted first.
+	maxManagedPolicyVersions = 5
+)
+
+var (
+	errManagedPolicyNotFound = errors.New("managed policy not found")
+	errManagedPolicyBuiltIn  = errors.New("built-in managed policies cannot be changed")
+	errManagedPolicyInUse    = errors.New("managed policy is attached to buckets")
+	errInvalidManagedPolicy  = errors.New("invalid managed policy")
+	errBucketPolicyManaged   = errors.New("bucket policy is set by an attached managed policy")
+)
+
+// Names of managed policies, no slashes so they map to a single object.
+var validManagedPolicyNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
+
+// managedPolicyVersion - statements of a version of a managed policy,
+// resources refer to the bucket the policy is attached to as ${bucket}.
+type managedPolicyVersion struct {
+	Version    int                `json:"version"`
+	Created    time.Time          `json:"created"`
+	Statements []policy.Statement `json:"statements"`
+}
+
+// managedPolicy - named policy attached to many buckets, the default
+// version applies to all of them.
+type managedPolicy struct {
+	Name           string                 `json:"name"`
+	BuiltIn        bool                   `json:"builtIn,omitempty"`
+	DefaultVersion int                    `json:"defaultVersion"`
+	Versions       []managedPolicyVersion `json:"versions"`
+}
+
+// managedPolicyInfo - managed policy listed by the admin API, with the
+// buckets it is attached to.
+type managedPolicyInfo struct {
+	Name           string   `json:"name"`
+	BuiltIn        bool     `json:"builtIn,omitempty"`
+	DefaultVersion int      `json:"defaultVersion"`
+	Versions       []int    `json:"versions"`
+	Buckets        []string `json:"buckets"`
+}
+
+// bucketManagedPolicy - managed policy attached to a bucket.
+type bucketManagedPolicy struct {
+	Name string `json:"name"`
+}
+
+// Built-in managed policies, generated from the canned bucket policies
+// except for diagnostics, which lists objects and incomplete uploads
+// of the bucket without access to their content.
+var builtInManagedPolicies = map[string][]policy.Statement{
+	"readonly":  policy.SetPolicy(nil, policy.BucketPolicyReadOnly, managedPolicyBucketVar, ""),
+	"writeonly": policy.SetPolicy(nil, policy.BucketPolicyWriteOnly, managedPolicyBucketVar, ""),
+	"readwrite": policy.SetPolicy(nil, policy.BucketPolicyReadWrite, managedPolicyBucketVar, ""),
+	"diagnostics": {
+		{
+			Actions:   set.CreateStringSet("s3:GetBucketLocation", "s3:ListBucket", "s3:ListBucketMultipartUploads"),
+			Effect:    "Allow",
+			Principal: policy.User{AWS: set.CreateStringSet("*")},
+			Resources: set.CreateStringSet(bucketARNPrefix + managedPolicyBucketVar),
+		},
+		{
+			Actions:   set.CreateStringSet("s3:ListMultipartUploadParts"),
+			Effect:    "Allow",
+			Principal: policy.User{AWS: set.CreateStringSet("*")},
+			Resources: set.CreateStringSet(bucketARNPrefix + managedPolicyBucketVar + "/*"),
+		},
+	},
+}
+
+// Returns the built-in managed policy of the name.
+func getBuiltInManagedPolicy(name string) (managedPolicy, bool) {
+	statements, ok := builtInManagedPolicies[name]
+	if !ok {
+		return managedPolicy{}, false
+	}
+	return managedPolicy{
+		Name:           name,
+		BuiltIn:        true,
+		DefaultVersion: 1,
+		Versions: []managedPolicyVersion{{
+			Version:    1,
+			Statements: statements,
+		}},
+	}, true
+}
+
+// Returns the version of the managed policy, 0 is the default version.
+func (p managedPolicy) getVersion(version int) (managedPolicyVersion, bool) {
+	if version == 0 {
+		version = p.DefaultVersion
+	}
+	for _, v := range p.Versions {
+		if v.Version == version {
+			return v, true
+		}
+	}
+	return managedPolicyVersion{}, false
+}
+
+// Returns the bucket policy of a managed policy attached to the bucket.
+func renderManagedPolicy(statements []policy.Statement, bucket string) (policy.BucketAccessPolicy, error) {
+	buf, err := json.Marshal(statements)
+	if err != nil {
+		return policy.BucketAccessPolicy{}, err
+	}
+	buf = bytes.Replace(buf, []byte(managedPolicyBucketVar), []byte(bucket), -1)
+	bucketPolicy := policy.BucketAccessPolicy{Version: "2012-10-17"}
+	if err = json.Unmarshal(buf, &bucketPolicy.Statements); err != nil {
+		return policy.BucketAccessPolicy{}, err
+	}
+	return bucketPolicy, nil
+}
+
+// Validates the statements of a managed policy, all resources must be
+// in the bucket the policy is attached to.
+func validateManagedPolicy(statements []policy.Statement) error {
+	if len(statements) == 0 {
+		return errInvalidManagedPolicy
+	}
+	for _, statement := range statements {
+		for resource := range statement.Resources {
+			rest := strings.TrimPrefix(resource, bucketARNPrefix+managedPolicyBucketVar)
+			if rest == resource || (rest != "" && !hasPrefix(rest, slashSeparator)) {
+				return errInvalidManagedPolicy
+			}
+		}
+	}
+	// Check nesting and actions against a valid bucket name.
+	bucketPolicy, err := renderManagedPolicy(statements, minioReservedBucket)
+	if err != nil {
+		return errInvalidManagedPolicy
+	}
+	if checkBucketPolicyResources(minioReservedBucket, bucketPolicy) != ErrNone {
+		return errInvalidManagedPolicy
+	}
+	return nil
+}
+
+// Returns errBucketPolicyManaged if a managed policy is attached to the
+// bucket, its bucket policy is only changed through the managed policy
+// so that it does not drift from it.
+func checkBucketPolicyNotManaged(objAPI ObjectLayer, bucket string) error {
+	attached, err := loadManagedPolicyJSON(objAPI, getBucketManagedPolicyPath(bucket), &bucketManagedPolicy{})
+	if err != nil {
+		return err
+	}
+	if attached {
+		return errBucketPolicyManaged
+	}
+	return nil
+}
+
+// Returns the path of a managed policy in minioMetaBucket.
+func getManagedPolicyPath(name string) string {
+	return pathJoin(managedPolicyPrefix, name+".json")
+}
+
+// Returns the path of the managed policy attached to a bucket.
+func getBucketManagedPolicyPath(bucket string) string {
+	return pathJoin(bucketConfigPrefix, bucket, bucketManagedPolicyConfig)
+}
+
+// Saves a JSON document to minioMetaBucket.
+func saveManagedPolicyJSON(objAPI ObjectLayer, objPath string, v interface{}) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return errors2.Trace(err)
+	}
+	hashReader, err := hash.NewReader(bytes.NewReader(buf), int64(len(buf)), "", getSHA256Hash(buf))
+	if err != nil {
+		return err
+	}
+	_, err = objAPI.PutObject(minioMetaBucket, objPath, hashReader, nil)
+	return err
+}
+
+// Reads a JSON document from minioMetaBucket, returns false if missing.
+func loadManagedPolicyJSON(objAPI ObjectLayer, objPath string, v interface{}) (bool, error) {
+	var buffer bytes.Buffer
+	if err := objAPI.GetObject(minioMetaBucket, objPath, 0, -1, &buffer, ""); err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := json.Unmarshal(buffer.Bytes(), v); err != nil {
+		return false, errors2.Trace(err)
+	}
+	return true, nil
+}
+
+// Loads a managed policy, built-in policies included.
+func loadManagedPolicy(objAPI ObjectLayer, name string) (managedPolicy, error) {
+	if p, ok := getBuiltInManagedPolicy(name); ok {
+		return p, nil
+	}
+	var p managedPolicy
+	if !validManagedPolicyNameRegexp.MatchString(name) {
+		return p, errManagedPolicyNotFound
+	}
+	ok, err := loadManagedPolicyJSON(objAPI, getManagedPolicyPath(name), &p)
+	if err != nil {
+		return p, err
+	}
+	if !ok {
+		return p, errManagedPolicyNotFound
+	}
+	return p, nil
+}
+
+// Returns the buckets attached to each managed policy.
+func getManagedPolicyBuckets(objAPI ObjectLayer) (map[string][]string, error) {
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		return nil, err
+	}
+	attached := make(map[string][]string)
+	for _, bucket := range buckets {
+		var cfg bucketManagedPolicy
+		ok, err := loadManagedPolicyJSON(objAPI, getBucketManagedPolicyPath(bucket.Name), &cfg)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			attached[cfg.Name] = append(attached[cfg.Name], bucket.Name)
+		}
+	}
+	return attached, nil
+}
+
+// Lists built-in and stored managed policies, sorted by name.
+func listManagedPolicies(objAPI ObjectLayer) ([]managedPolicyInfo, error) {
+	attached, err := getManagedPolicyBuckets(objAPI)
+	if err != nil {
+		return nil, err
+	}
+	var policies []managedPolicy
+	for name := range builtInManagedPolicies {
+		p, _ := getBuiltInManagedPolicy(name)
+		policies = append(policies, p)
+	}
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(minioMetaBucket, managedPolicyPrefix+slashSeparator, marker, slashSeparator, maxObjectList)
+		if err != nil {
+			return nil, err
+		}
+		for _, objInfo := range result.Objects {
+			name := strings.TrimSuffix(strings.TrimPrefix(objInfo.Name, managedPolicyPrefix+slashSeparator), ".json")
+			p, err := loadManagedPolicy(objAPI, name)
+			if err != nil {
+				if err == errManagedPolicyNotFound {
+					continue
+				}
+				return nil, err
+			}
+			policies = append(policies, p)
+		}
+		if !result.IsTruncated || result.NextMarker == "" {
+			break
+		}
+		marker = result.NextMarker
+	}
+
+	infos := make([]managedPolicyInfo, 0, len(policies))
+	for _, p := range policies {
+		info := managedPolicyInfo{
+			Name:           p.Name,
+			BuiltIn:        p.BuiltIn,
+			DefaultVersion: p.DefaultVersion,
+			Buckets:        attached[p.Name],
+		}
+		for _, v := range p.Versions {
+			info.Versions = append(info.Versions, v.Version)
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Applies the default version of a managed policy to the bucket and
+// notifies peers, returns the bucket policy before the change.
+func applyManagedPolicy(objAPI ObjectLayer, p managedPolicy, bucket string) (oldPolicy, newPolicy interface{}, err error) {
+	v, ok := p.getVersion(0)
+	if !ok {
+		return nil, nil, errManagedPolicyNotFound
+	}
+	bucketPolicy, err := renderManagedPolicy(v.Statements, bucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	if oldPolicy, err = getChangeLogBucketPolicy(objAPI, bucket); err != nil {
+		return nil, nil, err
+	}
+	if err = objAPI.SetBucketPolicy(bucket, bucketPolicy); err != nil {
+		return nil, nil, err
+	}
+	return oldPolicy, bucketPolicy, nil
+}
+
+// Applies a changed managed policy to all buckets it is attached to,
+// changes are recorded in the change log.
+func reapplyManagedPolicy(objAPI ObjectLayer, actor auditCaller, p managedPolicy) error {
+	attached, err := getManagedPolicyBuckets(objAPI)
+	if err != nil {
+		return err
+	}
+	for _, bucket := range attached[p.Name] {
+		oldPolicy, newPolicy, err := applyManagedPolicy(objAPI, p, bucket)
+		if err != nil {
+			return err
+		}
+		if err = recordChange(objAPI, actor, changeTypeBucketPolicy, bucket, oldPolicy, newPolicy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Saves a new version of a managed policy as its default version and
+// applies it to all buckets it is attached to.
+func putManagedPolicy(objAPI ObjectLayer, actor auditCaller, name string, statements []policy.Statement) (managedPolicy, error) {
+	if _, ok := builtInManagedPolicies[name]; ok {
+		return managedPolicy{}, errManagedPolicyBuiltIn
+	}
+	if err := validateManagedPolicy(statements); err != nil {
+		return managedPolicy{}, err
+	}
+
+	policyLock := globalNSMutex.NewNSLock(minioMetaBucket, managedPolicyPrefix)
+	if err := policyLock.GetLock(globalOperationTimeout); err != nil {
+		return managedPolicy{}, err
+	}
+	defer policyLock.Unlock()
+
+	p, err := loadManagedPolicy(objAPI, name)
+	if err == errManagedPolicyNotFound {
+		p, err = managedPolicy{Name: name}, nil
+	}
+	if err != nil {
+		return p, err
+	}
+
+	version := 1
+	if len(p.Versions) > 0 {
+		version = p.Versions[len(p.Versions)-1].Version + 1
+	}
+	p.Versions = append(p.Versions, managedPolicyVersion{
+		Version:    version,
+		Created:    UTCNow(),
+		Statements: statements,
+	})
+	p.DefaultVersion = version
+	if len(p.Versions) > maxManagedPolicyVersions {
+		p.Versions = p.Versions[len(p.Versions)-maxManagedPolicyVersions:]
+	}
+
+	if err = saveManagedPolicyJSON(objAPI, getManagedPolicyPath(name), p); err != nil {
+		return p, err
+	}
+	return p, reapplyManagedPolicy(objAPI, actor, p)
+}
+
+// Makes an existing version the default version of a managed policy,
+// e.g. to roll back a change, and applies it to all attached buckets.
+func setManagedPolicyVersion(objAPI ObjectLayer, actor auditCaller, name string, version int) (managedPolicy, error) {
+	if _, ok := builtInManagedPolicies[name]; ok {
+		return managedPolicy{}, errManagedPolicyBuiltIn
+	}
+
+	policyLock := globalNSMutex.NewNSLock(minioMetaBucket, managedPolicyPrefix)
+	if err := policyLock.GetLock(globalOperationTimeout); err != nil {
+		return managedPolicy{}, err
+	}
+	defer policyLock.Unlock()
+
+	p, err := loadManagedPolicy(objAPI, name)
+	if err != nil {
+		return p, err
+	}
+	if _, ok := p.getVersion(version); !ok || version == 0 {
+		return p, errManagedPolicyNotFound
+	}
+	p.DefaultVersion = version
+	if err = saveManagedPolicyJSON(objAPI, getManagedPolicyPath(name), p); err != nil {
+		return p, err
+	}
+	return p, reapplyManagedPolicy(objAPI, actor, p)
+}
+
+// Deletes a managed policy which is not attached to any bucket.
+func removeManagedPolicy(objAPI ObjectLayer, name string) error {
+	if _, ok := builtInManagedPolicies[name]; ok {
+		return errManagedPolicyBuiltIn
+	}
+
+	policyLock := globalNSMutex.NewNSLock(minioMetaBucket, managedPolicyPrefix)
+	if err := policyLock.GetLock(globalOperationTimeout); err != nil {
+		return err
+	}
+	defer policyLock.Unlock()
+
+	if _, err := loadManagedPolicy(objAPI, name); err != nil {
+		return err
+	}
+	attached, err := getManagedPolicyBuckets(objAPI)
+	if err != nil {
+		return err
+	}
+	if len(attached[name]) > 0 {
+		return errManagedPolicyInUse
+	}
+	return objAPI.DeleteObject(minioMetaBucket, getManagedPolicyPath(name))
+}
+
+// Attaches a managed policy to the bucket, replacing its bucket policy
+// with the default version of the managed policy.
+func attachManagedPolicy(objAPI ObjectLayer, actor auditCaller, name, bucket string) error {
+	policyLock := globalNSMutex.NewNSLock(minioMetaBucket, managedPolicyPrefix)
+	if err := policyLock.GetLock(globalOperationTimeout); err != nil {
+		return err
+	}
+	defer policyLock.Unlock()
+
+	p, err := loadManagedPolicy(objAPI, name)
+	if err != nil {
+		return err
+	}
+	if err = saveManagedPolicyJSON(objAPI, getBucketManagedPolicyPath(bucket), bucketManagedPolicy{Name: name}); err != nil {
+		return err
+	}
+	oldPolicy, newPolicy, err := applyManagedPolicy(objAPI, p, bucket)
+	if err != nil {
+		return err
+	}
+	if err = recordChange(objAPI, actor, changeTypeBucketPolicy, bucket, oldPolicy, newPolicy); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Detaches the managed policy of the bucket, its bucket policy is
+// removed. Returns errManagedPolicyNotFound if none is attached.
+func detachManagedPolicy(objAPI ObjectLayer, actor auditCaller, bucket string) error {
+	policyLock := globalNSMutex.NewNSLock(minioMetaBucket, managedPolicyPrefix)
+	if err := policyLock.GetLock(globalOperationTimeout); err != nil {
+		return err
+	}
+	defer policyLock.Unlock()
+
+	if err := objAPI.DeleteObject(minioMetaBucket, getBucketManagedPolicyPath(bucket)); err != nil {
+		if isErrObjectNotFound(err) {
+			return errManagedPolicyNotFound
+		}
+		return err
+	}
+	oldPolicy, err := getChangeLogBucketPolicy(objAPI, bucket)
+	if err != nil {
+		return err
+	}
+	if err = objAPI.DeleteBucketPolicy(bucket); err != nil {
+		if _, ok := errors2.Cause(err).(BucketPolicyNotFound); ok {
+			return nil
+		}
+		return err
+	}
+	if err = recordChange(objAPI, actor, changeTypeBucketPolicy, bucket, oldPolicy, nil); err != nil {
+		return err
+	}
+	return nil
+}
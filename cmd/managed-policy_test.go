@@ -0,0 +1,170 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minio/minio-go/pkg/policy"
+)
+
+// Tests validating statements of managed policies.
+func TestValidateManagedPolicy(t *testing.T) {
+	for name := range builtInManagedPolicies {
+		p, _ := getBuiltInManagedPolicy(name)
+		if err := validateManagedPolicy(p.Versions[0].Statements); err != nil {
+			t.Errorf("Built-in policy %s: Unexpected error %v", name, err)
+		}
+	}
+
+	testCases := []struct {
+		statements []policy.Statement
+		valid      bool
+	}{
+		{policy.SetPolicy(nil, policy.BucketPolicyReadOnly, managedPolicyBucketVar, "public"), true},
+		{policy.SetPolicy(nil, policy.BucketPolicyReadOnly, "mybucket", ""), false},
+		{policy.SetPolicy(nil, policy.BucketPolicyReadOnly, managedPolicyBucketVar+"x", ""), false},
+		{nil, false},
+	}
+	for i, testCase := range testCases {
+		err := validateManagedPolicy(testCase.statements)
+		if testCase.valid && err != nil {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if !testCase.valid && err != errInvalidManagedPolicy {
+			t.Errorf("Test %d: Expected policy to be invalid, got %v", i+1, err)
+		}
+	}
+}
+
+// Wrapper for calling managed policy tests for both XL multiple disks and single node setup.
+func TestManagedPolicies(t *testing.T) {
+	initNSLock(false)
+	ExecObjectLayerTest(t, testManagedPolicies)
+}
+
+// Tests versioning managed policies and applying them to attached buckets.
+func testManagedPolicies(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	for _, bucket := range []string{"bucket1", "bucket2"} {
+		if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+	actor := auditCaller{AccessKey: "minio"}
+
+	// Returns the statements expected on bucket for a policy applied to prefix.
+	expectedStatements := func(bucketPolicy policy.BucketPolicy, bucket, prefix string) []policy.Statement {
+		return policy.SetPolicy(nil, bucketPolicy, bucket, prefix)
+	}
+	checkBucketPolicy := func(bucket string, expected []policy.Statement) {
+		bp, err := ReadBucketPolicy(bucket, obj)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		if !reflect.DeepEqual(bp.Statements, expected) {
+			t.Fatalf("%s: Expected policy %v on %s, got %v", instanceType, expected, bucket, bp.Statements)
+		}
+	}
+
+	if _, err := putManagedPolicy(obj, actor, "readonly", nil); err != errManagedPolicyBuiltIn {
+		t.Fatalf("%s: Expected built-in policy not to change, got %v", instanceType, err)
+	}
+
+	v1 := policy.SetPolicy(nil, policy.BucketPolicyReadOnly, managedPolicyBucketVar, "public")
+	p, err := putManagedPolicy(obj, actor, "downloads", v1)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if p.DefaultVersion != 1 {
+		t.Fatalf("%s: Expected version 1, got %d", instanceType, p.DefaultVersion)
+	}
+	for _, bucket := range []string{"bucket1", "bucket2"} {
+		if err = attachManagedPolicy(obj, actor, "downloads", bucket); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		checkBucketPolicy(bucket, expectedStatements(policy.BucketPolicyReadOnly, bucket, "public"))
+	}
+
+	// A new version applies to all attached buckets.
+	v2 := policy.SetPolicy(nil, policy.BucketPolicyReadWrite, managedPolicyBucketVar, "public")
+	if p, err = putManagedPolicy(obj, actor, "downloads", v2); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if p.DefaultVersion != 2 || len(p.Versions) != 2 {
+		t.Fatalf("%s: Expected 2 versions, got %v", instanceType, p)
+	}
+	for _, bucket := range []string{"bucket1", "bucket2"} {
+		checkBucketPolicy(bucket, expectedStatements(policy.BucketPolicyReadWrite, bucket, "public"))
+	}
+
+	// Rolling back applies the old version again.
+	if _, err = setManagedPolicyVersion(obj, actor, "downloads", 1); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	checkBucketPolicy("bucket2", expectedStatements(policy.BucketPolicyReadOnly, "bucket2", "public"))
+	if _, err = setManagedPolicyVersion(obj, actor, "downloads", 3); err != errManagedPolicyNotFound {
+		t.Fatalf("%s: Expected missing version to fail, got %v", instanceType, err)
+	}
+
+	policies, err := listManagedPolicies(obj)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	expected := []managedPolicyInfo{
+		{Name: "diagnostics", BuiltIn: true, DefaultVersion: 1, Versions: []int{1}},
+		{Name: "downloads", DefaultVersion: 1, Versions: []int{1, 2}, Buckets: []string{"bucket1", "bucket2"}},
+		{Name: "readonly", BuiltIn: true, DefaultVersion: 1, Versions: []int{1}},
+		{Name: "readwrite", BuiltIn: true, DefaultVersion: 1, Versions: []int{1}},
+		{Name: "writeonly", BuiltIn: true, DefaultVersion: 1, Versions: []int{1}},
+	}
+	if !reflect.DeepEqual(policies, expected) {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, expected, policies)
+	}
+
+	// Bucket policies of attached buckets cannot be overwritten.
+	if err = checkBucketPolicyNotManaged(obj, "bucket1"); err != errBucketPolicyManaged {
+		t.Fatalf("%s: Expected bucket policy to be managed, got %v", instanceType, err)
+	}
+
+	// Attached policies cannot be removed.
+	if err = removeManagedPolicy(obj, "downloads"); err != errManagedPolicyInUse {
+		t.Fatalf("%s: Expected policy in use, got %v", instanceType, err)
+	}
+	if err = attachManagedPolicy(obj, actor, "writeonly", "bucket1"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	checkBucketPolicy("bucket1", expectedStatements(policy.BucketPolicyWriteOnly, "bucket1", ""))
+	if err = detachManagedPolicy(obj, actor, "bucket2"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = ReadBucketPolicy("bucket2", obj); err == nil {
+		t.Fatalf("%s: Expected bucket policy of bucket2 to be removed", instanceType)
+	}
+	if err = checkBucketPolicyNotManaged(obj, "bucket2"); err != nil {
+		t.Fatalf("%s: Expected bucket policy not to be managed, got %v", instanceType, err)
+	}
+	if err = detachManagedPolicy(obj, actor, "bucket2"); err != errManagedPolicyNotFound {
+		t.Fatalf("%s: Expected no policy attached, got %v", instanceType, err)
+	}
+	if err = removeManagedPolicy(obj, "downloads"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = loadManagedPolicy(obj, "downloads"); err != errManagedPolicyNotFound {
+		t.Fatalf("%s: Expected policy to be removed, got %v", instanceType, err)
+	}
+}
@@ -0,0 +1,613 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+const (
+	// Environment variable naming the bucket metadata snapshots are
+	// saved to, backups are disabled when it is not set.
+	metadataBackupBucketEnv = "MINIO_METADATA_BACKUP_BUCKET"
+
+	// Environment variable setting the interval between snapshots.
+	metadataBackupIntervalEnv = "MINIO_METADATA_BACKUP_INTERVAL"
+
+	// Environment variable setting the number of snapshots kept.
+	metadataBackupKeepEnv = "MINIO_METADATA_BACKUP_KEEP"
+
+	// Snapshots are saved under this prefix of the backup bucket.
+	metadataBackupPrefix = "minio-metadata/"
+
+	// Snapshots are named by the time they are taken.
+	metadataBackupTimeFormat = "20060102T150405Z"
+
+	// Current version of snapshot manifests.
+	metadataBackupVersion = "1"
+
+	// Manifest of a snapshot, listing the buckets at the time.
+	metadataBackupManifest = "manifest.json"
+
+	// Copies of the format.json of every disk are saved under this
+	// path of a snapshot, named by disk.
+	metadataBackupFormatPath = "format"
+
+	defaultMetadataBackupInterval = time.Hour
+	defaultMetadataBackupKeep     = 24
+
+	// Field of the bucket configs carrying remote credentials, left
+	// out of snapshots.
+	metadataBackupSecretKeyField = "secretKey"
+
+	// Metadata of a snapshot object holding the ID of the attestation
+	// key signing it and its signature, hex encoded. Reserved metadata
+	// cannot be set by clients.
+	metadataBackupKeyIDKey     = ReservedMetadataPrefix + "Metadata-Backup-Key-Id"
+	metadataBackupSignatureKey = ReservedMetadataPrefix + "Metadata-Backup-Signature"
+)
+
+// Bucket configs carrying the credentials of a remote target, their
+// secret keys are left out of snapshots.
+var metadataBackupRedactedConfigs = map[string]bool{
+	bucketReplicationConfig: true,
+	bucketTieringConfig:     true,
+}
+
+// Returns true for the bucket configs restored from a snapshot, other
+// files found in a snapshot are never written.
+func isMetadataBackupRestoredConfig(configFile string) bool {
+	switch configFile {
+	case bucketPolicyConfig, bucketNotificationConfig, bucketManagedPolicyConfig:
+		return true
+	}
+	for _, cachedConfigFile := range cachedBucketConfigFiles {
+		if configFile == cachedConfigFile {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshots are taken by a single server at a time, other servers skip
+// the snapshot they cannot lock at once.
+var metadataBackupLockTimeout = newDynamicTimeout(time.Second, time.Second)
+
+// metadataBackupBucket - bucket listed in the manifest of a snapshot.
+type metadataBackupBucket struct {
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+}
+
+// metadataBackupManifestInfo - manifest of a snapshot.
+type metadataBackupManifestInfo struct {
+	Version string                 `json:"version"`
+	Time    time.Time              `json:"time"`
+	Server  string                 `json:"server"`
+	Buckets []metadataBackupBucket `json:"buckets"`
+}
+
+// metadataBackupInfo - snapshot saved in the backup bucket.
+type metadataBackupInfo struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// metadataBackupStatus - backup settings and the saved snapshots,
+// oldest first.
+type metadataBackupStatus struct {
+	Bucket   string               `json:"bucket"`
+	Interval time.Duration        `json:"interval"`
+	Keep     int                  `json:"keep"`
+	Backups  []metadataBackupInfo `json:"backups"`
+}
+
+// metadataRestoreResult - buckets created and files restored from a
+// snapshot, and configs skipped as their secret keys are unknown.
+type metadataRestoreResult struct {
+	Name           string   `json:"name"`
+	BucketsCreated []string `json:"bucketsCreated"`
+	FilesRestored  []string `json:"filesRestored"`
+	FilesSkipped   []string `json:"filesSkipped"`
+}
+
+// Sets up periodic metadata backups from the environment.
+func handleMetadataBackupEnv() {
+	bucket := os.Getenv(metadataBackupBucketEnv)
+	if bucket == "" {
+		return
+	}
+	if !IsValidBucketName(bucket) {
+		fatalIf(errInvalidArgument, "Invalid bucket name set in environment variable %s.", metadataBackupBucketEnv)
+	}
+	globalMetadataBackupBucket = bucket
+
+	if value := os.Getenv(metadataBackupIntervalEnv); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err == nil && interval < time.Minute {
+			err = fmt.Errorf("interval must be at least a minute")
+		}
+		fatalIf(err, "Invalid value set in environment variable %s.", metadataBackupIntervalEnv)
+		globalMetadataBackupInterval = interval
+	}
+	if value := os.Getenv(metadataBackupKeepEnv); value != "" {
+		keep, err := strconv.Atoi(value)
+		if err == nil && keep <= 0 {
+			err = fmt.Errorf("value must be positive")
+		}
+		fatalIf(err, "Invalid value set in environment variable %s.", metadataBackupKeepEnv)
+		globalMetadataBackupKeep = keep
+	}
+}
+
+// Returns the name of the snapshot taken at the time.
+func getMetadataBackupName(t time.Time) string {
+	return metadataBackupPrefix + t.UTC().Format(metadataBackupTimeFormat) + ".zip"
+}
+
+// Returns the time a snapshot was taken from its name.
+func parseMetadataBackupName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, metadataBackupPrefix) || !strings.HasSuffix(name, ".zip") {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(metadataBackupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, metadataBackupPrefix), ".zip"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Reads the format.json of every disk of the object layer, keyed by
+// disk. Unreachable disks are left out.
+func readFormatFiles(objAPI ObjectLayer) map[string][]byte {
+	formats := make(map[string][]byte)
+	readSetsFormatFiles := func(s *xlSets) {
+		for i := range s.sets {
+			for _, disk := range s.GetDisks(i)() {
+				if disk == nil {
+					continue
+				}
+				buf, err := disk.ReadAll(minioMetaBucket, formatConfigFile)
+				if err != nil {
+					continue
+				}
+				formats[disk.String()] = buf
+			}
+		}
+	}
+	switch obj := unwrapObjectLayer(objAPI).(type) {
+	case *xlSets:
+		readSetsFormatFiles(obj)
+	case *xlZones:
+		for _, zone := range obj.zones {
+			readSetsFormatFiles(zone)
+		}
+	case *FSObjects:
+		buf, err := ioutil.ReadFile(pathJoin(obj.fsPath, minioMetaBucket, formatConfigFile))
+		if err == nil {
+			formats[obj.fsPath] = buf
+		}
+	}
+	return formats
+}
+
+// Returns the path of the format.json of a disk in a snapshot.
+func getMetadataBackupFormatPath(disk string) string {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':':
+			return '_'
+		}
+		return r
+	}, strings.Trim(disk, "/"))
+	return path.Join(metadataBackupFormatPath, name+".json")
+}
+
+// Returns the names of the config files of a bucket in minioMetaBucket,
+// FS keeps the metadata of objects in sub-directories, left out.
+func listBucketConfigFiles(objAPI ObjectLayer, bucket string) ([]string, error) {
+	var names []string
+	prefix := path.Join(bucketConfigPrefix, bucket) + slashSeparator
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(minioMetaBucket, prefix, marker, slashSeparator, maxObjectList)
+		if err != nil {
+			return nil, err
+		}
+		for _, objInfo := range result.Objects {
+			names = append(names, objInfo.Name)
+		}
+		if !result.IsTruncated {
+			return names, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// Removes the secret key from a bucket config saved to a snapshot.
+func redactMetadataBackupConfig(data []byte) ([]byte, error) {
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, errors.Trace(err)
+	}
+	delete(config, metadataBackupSecretKeyField)
+	data, err := json.Marshal(config)
+	return data, errors.Trace(err)
+}
+
+// Sets the secret key of a bucket config restored from a snapshot to
+// the one of the current config of the bucket, returns false when the
+// current config does not exist or is of another access key.
+func unredactMetadataBackupConfig(objAPI ObjectLayer, name string, data []byte) ([]byte, bool, error) {
+	var current bytes.Buffer
+	if err := objAPI.GetObject(minioMetaBucket, name, 0, -1, &current, ""); err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	var restored, config map[string]json.RawMessage
+	if err := json.Unmarshal(data, &restored); err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	if err := json.Unmarshal(current.Bytes(), &config); err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	if !bytes.Equal(restored["accessKey"], config["accessKey"]) {
+		return nil, false, nil
+	}
+	restored[metadataBackupSecretKeyField] = config[metadataBackupSecretKeyField]
+	data, err := json.Marshal(restored)
+	return data, true, errors.Trace(err)
+}
+
+// Writes a file to a snapshot archive.
+func writeMetadataBackupFile(archive *zip.Writer, name string, modTime time.Time, data []byte) error {
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	header.SetModTime(modTime)
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = writer.Write(data)
+	return errors.Trace(err)
+}
+
+// createMetadataBackup - takes a snapshot of the format.json of every
+// disk and the configs of all buckets, and saves it as a zip archive
+// to the backup bucket. The server config is left out as it carries
+// the server credentials, as are the secret keys of remote targets.
+func createMetadataBackup(objAPI ObjectLayer, backupBucket string) (metadataBackupInfo, error) {
+	now := UTCNow()
+	bucketsInfo, err := objAPI.ListBuckets()
+	if err != nil {
+		return metadataBackupInfo{}, err
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	manifest := metadataBackupManifestInfo{
+		Version: metadataBackupVersion,
+		Time:    now,
+		Server:  globalMinioAddr,
+	}
+	for _, bucketInfo := range bucketsInfo {
+		manifest.Buckets = append(manifest.Buckets, metadataBackupBucket{
+			Name:    bucketInfo.Name,
+			Created: bucketInfo.Created,
+		})
+		names, err := listBucketConfigFiles(objAPI, bucketInfo.Name)
+		if err != nil {
+			return metadataBackupInfo{}, err
+		}
+		for _, name := range names {
+			var data bytes.Buffer
+			if err = objAPI.GetObject(minioMetaBucket, name, 0, -1, &data, ""); err != nil {
+				// Configs removed in the meantime are left out.
+				if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+					continue
+				}
+				return metadataBackupInfo{}, err
+			}
+			config := data.Bytes()
+			if metadataBackupRedactedConfigs[path.Base(name)] {
+				if config, err = redactMetadataBackupConfig(config); err != nil {
+					return metadataBackupInfo{}, err
+				}
+			}
+			if err = writeMetadataBackupFile(archive, name, now, config); err != nil {
+				return metadataBackupInfo{}, err
+			}
+		}
+	}
+
+	for disk, data := range readFormatFiles(objAPI) {
+		if err = writeMetadataBackupFile(archive, getMetadataBackupFormatPath(disk), now, data); err != nil {
+			return metadataBackupInfo{}, err
+		}
+	}
+
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return metadataBackupInfo{}, errors.Trace(err)
+	}
+	if err = writeMetadataBackupFile(archive, metadataBackupManifest, now, manifestBytes); err != nil {
+		return metadataBackupInfo{}, err
+	}
+	if err = archive.Close(); err != nil {
+		return metadataBackupInfo{}, errors.Trace(err)
+	}
+
+	key, signature, err := signAttestationPayload(objAPI, buffer.Bytes())
+	if err != nil {
+		return metadataBackupInfo{}, err
+	}
+	metadata := map[string]string{
+		"content-type":             "application/zip",
+		metadataBackupKeyIDKey:     key.ID,
+		metadataBackupSignatureKey: hex.EncodeToString(signature),
+	}
+
+	name := getMetadataBackupName(now)
+	hashReader, err := hash.NewReader(&buffer, int64(buffer.Len()), "", "")
+	if err != nil {
+		return metadataBackupInfo{}, err
+	}
+	objInfo, err := objAPI.PutObject(backupBucket, name, hashReader, metadata)
+	if err != nil {
+		return metadataBackupInfo{}, err
+	}
+	return metadataBackupInfo{Name: name, Time: now, Size: objInfo.Size}, nil
+}
+
+// listMetadataBackups - returns the snapshots saved in the backup
+// bucket, oldest first.
+func listMetadataBackups(objAPI ObjectLayer, backupBucket string) ([]metadataBackupInfo, error) {
+	var backups []metadataBackupInfo
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(backupBucket, metadataBackupPrefix, marker, slashSeparator, maxObjectList)
+		if err != nil {
+			return nil, err
+		}
+		for _, objInfo := range result.Objects {
+			t, ok := parseMetadataBackupName(objInfo.Name)
+			if !ok {
+				continue
+			}
+			backups = append(backups, metadataBackupInfo{Name: objInfo.Name, Time: t, Size: objInfo.Size})
+		}
+		if !result.IsTruncated || len(result.Objects) == 0 {
+			return backups, nil
+		}
+		marker = result.Objects[len(result.Objects)-1].Name
+	}
+}
+
+// pruneMetadataBackups - deletes the oldest snapshots beyond keep.
+func pruneMetadataBackups(objAPI ObjectLayer, backupBucket string, keep int) error {
+	backups, err := listMetadataBackups(objAPI, backupBucket)
+	if err != nil {
+		return err
+	}
+	for len(backups) > keep {
+		if err = objAPI.DeleteObject(backupBucket, backups[0].Name); err != nil && !isErrObjectNotFound(err) {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// runMetadataBackup - takes a snapshot unless another server took one
+// during the last interval, and prunes old snapshots.
+func runMetadataBackup(objAPI ObjectLayer, backupBucket string, interval time.Duration, keep int) error {
+	backupLock := globalNSMutex.NewNSLock(backupBucket, metadataBackupPrefix)
+	if backupLock.GetLock(metadataBackupLockTimeout) != nil {
+		return nil
+	}
+	defer backupLock.Unlock()
+
+	backups, err := listMetadataBackups(objAPI, backupBucket)
+	if err != nil {
+		return err
+	}
+	// Leave some slack for timers of servers started at about the same time.
+	if len(backups) > 0 && UTCNow().Sub(backups[len(backups)-1].Time) < interval-interval/10 {
+		return nil
+	}
+	if _, err = createMetadataBackup(objAPI, backupBucket); err != nil {
+		return err
+	}
+	return pruneMetadataBackups(objAPI, backupBucket, keep)
+}
+
+// Start a routine taking metadata snapshots periodically.
+func startMetadataBackup(backupBucket string, interval time.Duration, keep int) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if objAPI := newObjectLayerFn(); objAPI != nil {
+				errorIf(runMetadataBackup(objAPI, backupBucket, interval, keep),
+					"Unable to back up metadata to bucket %s.", backupBucket)
+			}
+			select {
+			case <-globalServiceDoneCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Returns the bucket of a bucket config path in a snapshot, false for
+// other paths and for configs not restored.
+func getMetadataBackupConfigBucket(name string) (string, bool) {
+	if !strings.HasPrefix(name, bucketConfigPrefix+slashSeparator) {
+		return "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(name, bucketConfigPrefix+slashSeparator), slashSeparator)
+	if len(parts) != 2 || !isMetadataBackupRestoredConfig(parts[1]) {
+		return "", false
+	}
+	return parts[0], IsValidBucketName(parts[0])
+}
+
+// Verifies that a snapshot was signed by the deployment, snapshots
+// written to the backup bucket by clients are rejected.
+func verifyMetadataBackup(objAPI ObjectLayer, objInfo ObjectInfo, data []byte) error {
+	signature, err := hex.DecodeString(objInfo.UserDefined[metadataBackupSignatureKey])
+	if err != nil || len(signature) == 0 {
+		return errMetadataBackupInvalid
+	}
+	if _, err = verifyAttestationPayload(objAPI, objInfo.UserDefined[metadataBackupKeyIDKey], data, signature); err != nil {
+		if err == errAttestationInvalid {
+			return errMetadataBackupInvalid
+		}
+		return err
+	}
+	return nil
+}
+
+// restoreMetadataBackup - recreates buckets missing since a snapshot
+// was taken and restores the configs of all buckets of the snapshot.
+// Only snapshots signed by the deployment are restored, and only the
+// known bucket configs in them.
+// Listener configs are not restored as their listeners are gone, the
+// format.json copies are only kept for manual recovery. Configs of
+// remote targets are only restored over configs of the same access key,
+// whose secret key is kept.
+func restoreMetadataBackup(objAPI ObjectLayer, backupBucket, name string) (metadataRestoreResult, error) {
+	result := metadataRestoreResult{Name: name}
+
+	objInfo, err := objAPI.GetObjectInfo(backupBucket, name)
+	if err != nil {
+		return result, err
+	}
+	var buffer bytes.Buffer
+	if err = objAPI.GetObject(backupBucket, name, 0, objInfo.Size, &buffer, objInfo.ETag); err != nil {
+		return result, err
+	}
+	if err = verifyMetadataBackup(objAPI, objInfo, buffer.Bytes()); err != nil {
+		return result, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	readFile := func(file *zip.File) ([]byte, error) {
+		reader, err := file.Open()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		defer reader.Close()
+		data, err := ioutil.ReadAll(reader)
+		return data, errors.Trace(err)
+	}
+
+	manifestFile, ok := files[metadataBackupManifest]
+	if !ok {
+		return result, errors.Trace(fmt.Errorf("Snapshot %s has no manifest", name))
+	}
+	manifestBytes, err := readFile(manifestFile)
+	if err != nil {
+		return result, err
+	}
+	var manifest metadataBackupManifestInfo
+	if err = json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return result, errors.Trace(err)
+	}
+	if manifest.Version != metadataBackupVersion {
+		return result, errors.Trace(fmt.Errorf("Unsupported snapshot version %s", manifest.Version))
+	}
+
+	for _, bucket := range manifest.Buckets {
+		if _, err = objAPI.GetBucketInfo(bucket.Name); err == nil {
+			continue
+		} else if !isErrBucketNotFound(err) {
+			return result, err
+		}
+		if err = objAPI.MakeBucketWithLocation(bucket.Name, globalServerConfig.GetRegion()); err != nil {
+			return result, err
+		}
+		result.BucketsCreated = append(result.BucketsCreated, bucket.Name)
+	}
+
+	restoredBuckets := make(map[string]struct{})
+	for _, file := range archive.File {
+		bucket, ok := getMetadataBackupConfigBucket(file.Name)
+		if !ok {
+			continue
+		}
+		data, err := readFile(file)
+		if err != nil {
+			return result, err
+		}
+		if metadataBackupRedactedConfigs[path.Base(file.Name)] {
+			var ok bool
+			if data, ok, err = unredactMetadataBackupConfig(objAPI, file.Name, data); err != nil {
+				return result, err
+			}
+			if !ok {
+				result.FilesSkipped = append(result.FilesSkipped, file.Name)
+				continue
+			}
+		}
+		hashReader, err := hash.NewReader(bytes.NewReader(data), int64(len(data)), "", getSHA256Hash(data))
+		if err != nil {
+			return result, err
+		}
+		if _, err = objAPI.PutObject(minioMetaBucket, file.Name, hashReader, nil); err != nil {
+			return result, err
+		}
+		result.FilesRestored = append(result.FilesRestored, file.Name)
+		restoredBuckets[bucket] = struct{}{}
+	}
+
+	// Notify all peers (including self) to reload the restored configs.
+	for bucket := range restoredBuckets {
+		S3PeersUpdateBucketPolicy(bucket)
+		if ncfg, err := loadNotificationConfig(bucket, objAPI); err == nil {
+			S3PeersUpdateBucketNotification(bucket, ncfg)
+		}
+		for _, configFile := range cachedBucketConfigFiles {
+			S3PeersUpdateBucketConfig(bucket, configFile)
+		}
+	}
+	return result, nil
+}
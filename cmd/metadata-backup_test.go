@@ -0,0 +1,282 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/pkg/policy"
+)
+
+// Tests snapshot names round trip to the time they were taken.
+func TestMetadataBackupName(t *testing.T) {
+	now := time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+	name := getMetadataBackupName(now)
+	if name != "minio-metadata/20180304T050607Z.zip" {
+		t.Fatalf("Unexpected snapshot name %s", name)
+	}
+	parsed, ok := parseMetadataBackupName(name)
+	if !ok || !parsed.Equal(now) {
+		t.Fatalf("Expected %s, got %s", now, parsed)
+	}
+
+	for _, name := range []string{"", "minio-metadata/", "minio-metadata/latest.zip", "other/20180304T050607Z.zip"} {
+		if _, ok := parseMetadataBackupName(name); ok {
+			t.Errorf("Expected %q not to be a snapshot name", name)
+		}
+	}
+}
+
+// Tests the format.json paths of disks in a snapshot.
+func TestMetadataBackupFormatPath(t *testing.T) {
+	testCases := []struct {
+		disk     string
+		expected string
+	}{
+		{"/mnt/disk1", "format/mnt_disk1.json"},
+		{"http://server1:9000/mnt/disk1", "format/http___server1_9000_mnt_disk1.json"},
+		{"C:\\disk1", "format/C__disk1.json"},
+	}
+	for i, testCase := range testCases {
+		if actual := getMetadataBackupFormatPath(testCase.disk); actual != testCase.expected {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expected, actual)
+		}
+	}
+}
+
+// Tests only known bucket configs are restored from a snapshot.
+func TestMetadataBackupConfigBucket(t *testing.T) {
+	testCases := []struct {
+		name     string
+		bucket   string
+		restored bool
+	}{
+		{"buckets/bucket/policy.json", "bucket", true},
+		{"buckets/bucket/notification.xml", "bucket", true},
+		{"buckets/bucket/website.json", "bucket", true},
+		{"buckets/bucket/listener.json", "", false},
+		{"buckets/bucket/stats.json", "", false},
+		{"buckets/bucket/other.json", "", false},
+		{"buckets/bucket/dir/policy.json", "", false},
+		{"buckets/policy.json", "", false},
+		{"config/config.json", "", false},
+		{"manifest.json", "", false},
+	}
+	for i, testCase := range testCases {
+		bucket, ok := getMetadataBackupConfigBucket(testCase.name)
+		if ok != testCase.restored || bucket != testCase.bucket {
+			t.Errorf("Test %d: Expected %q, %v, got %q, %v", i+1, testCase.bucket, testCase.restored, bucket, ok)
+		}
+	}
+}
+
+// Wrapper for calling metadata backup tests for both XL multiple disks and single node setup.
+func TestMetadataBackup(t *testing.T) {
+	ExecObjectLayerTest(t, testMetadataBackup)
+}
+
+// Tests taking, pruning and restoring metadata snapshots.
+func testMetadataBackup(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	backupBucket := "backup"
+	for _, bucket := range []string{backupBucket, "bucket1", "bucket2"} {
+		if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+	data := []byte("hello")
+	if _, err := obj.PutObject("bucket1", "object", mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	bp := policy.BucketAccessPolicy{
+		Version:    "2012-10-17",
+		Statements: policy.SetPolicy(nil, policy.BucketPolicyReadOnly, "bucket1", ""),
+	}
+	if err := writeBucketPolicy("bucket1", obj, bp); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	backup, err := createMetadataBackup(obj, backupBucket)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	backups, err := listMetadataBackups(obj, backupBucket)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(backups) != 1 || backups[0].Name != backup.Name || backups[0].Size != backup.Size {
+		t.Fatalf("%s: Expected snapshot %v, got %v", instanceType, backup, backups)
+	}
+
+	// Lose the policy and a bucket.
+	if err = removeBucketPolicy("bucket1", obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = obj.DeleteBucket("bucket2"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	result, err := restoreMetadataBackup(obj, backupBucket, backup.Name)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !reflect.DeepEqual(result.BucketsCreated, []string{"bucket2"}) {
+		t.Fatalf("%s: Expected bucket2 to be created, got %v", instanceType, result.BucketsCreated)
+	}
+	if !reflect.DeepEqual(result.FilesRestored, []string{"buckets/bucket1/policy.json"}) {
+		t.Fatalf("%s: Expected policy of bucket1 to be restored, got %v", instanceType, result.FilesRestored)
+	}
+	restored, err := ReadBucketPolicy("bucket1", obj)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !reflect.DeepEqual(restored, bp) {
+		t.Fatalf("%s: Expected policy %v, got %v", instanceType, bp, restored)
+	}
+	if _, err = obj.GetBucketInfo("bucket2"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	if _, err = restoreMetadataBackup(obj, backupBucket, getMetadataBackupName(UTCNow().Add(time.Hour))); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected missing snapshot to fail with object not found, got %v", instanceType, err)
+	}
+
+	// Snapshots not signed by the deployment are rejected, even when
+	// copied from a signed one.
+	var buffer bytes.Buffer
+	if err = obj.GetObject(backupBucket, backup.Name, 0, -1, &buffer, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	unsigned := getMetadataBackupName(UTCNow().Add(-3 * time.Hour))
+	if _, err = obj.PutObject(backupBucket, unsigned, mustGetHashReader(t, bytes.NewReader(buffer.Bytes()), int64(buffer.Len()), "", ""), nil); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = restoreMetadataBackup(obj, backupBucket, unsigned); err != errMetadataBackupInvalid {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, errMetadataBackupInvalid, err)
+	}
+
+	// Snapshots beyond the number kept are deleted, oldest first.
+	for _, name := range []string{getMetadataBackupName(UTCNow().Add(-2 * time.Hour)), getMetadataBackupName(UTCNow().Add(-time.Hour))} {
+		if _, err = obj.PutObject(backupBucket, name, mustGetHashReader(t, nil, 0, "", ""), nil); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+	if err = pruneMetadataBackups(obj, backupBucket, 2); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if backups, err = listMetadataBackups(obj, backupBucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(backups) != 2 || backups[1].Name != backup.Name {
+		t.Fatalf("%s: Expected the 2 latest snapshots to be kept, got %v", instanceType, backups)
+	}
+
+	// No new snapshot is taken within the interval of the last one.
+	if err = runMetadataBackup(obj, backupBucket, time.Hour, 2); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if backups, err = listMetadataBackups(obj, backupBucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(backups) != 2 || backups[1].Name != backup.Name {
+		t.Fatalf("%s: Expected no new snapshot, got %v", instanceType, backups)
+	}
+}
+
+func TestMetadataBackupRemoteCredentials(t *testing.T) {
+	ExecObjectLayerTest(t, testMetadataBackupRemoteCredentials)
+}
+
+// Tests secret keys of remote targets are left out of snapshots and
+// kept by restores.
+func testMetadataBackupRemoteCredentials(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	backupBucket := "backup"
+	for _, bucket := range []string{backupBucket, "bucket"} {
+		if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+	cfg := replicationConfig{Endpoint: "localhost:9000", AccessKey: "access", SecretKey: "target-secret", TargetBucket: "target"}
+	if err := saveReplicationConfig("bucket", cfg, obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	backup, err := createMetadataBackup(obj, backupBucket)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(backupBucket, backup.Name, 0, -1, &buffer, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		data, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		if bytes.Contains(data, []byte(cfg.SecretKey)) {
+			t.Fatalf("%s: Expected the secret key to be left out of %s", instanceType, file.Name)
+		}
+	}
+
+	// The secret key of the current config is kept.
+	changed := cfg
+	changed.TargetBucket = "other"
+	if err = saveReplicationConfig("bucket", changed, obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	result, err := restoreMetadataBackup(obj, backupBucket, backup.Name)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !reflect.DeepEqual(result.FilesRestored, []string{getBucketConfigPath("bucket", bucketReplicationConfig)}) || len(result.FilesSkipped) != 0 {
+		t.Fatalf("%s: Expected the replication config to be restored, got %v %v", instanceType, result.FilesRestored, result.FilesSkipped)
+	}
+	var restored replicationConfig
+	if _, err = loadBucketConfig("bucket", bucketReplicationConfig, &restored, obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	cfg.Version = bucketReplicationVersion
+	if restored != cfg {
+		t.Fatalf("%s: Expected config %v, got %v", instanceType, cfg, restored)
+	}
+
+	// Configs of another access key are skipped.
+	changed.AccessKey = "other-access"
+	if err = saveReplicationConfig("bucket", changed, obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if result, err = restoreMetadataBackup(obj, backupBucket, backup.Name); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.FilesRestored) != 0 || !reflect.DeepEqual(result.FilesSkipped, []string{getBucketConfigPath("bucket", bucketReplicationConfig)}) {
+		t.Fatalf("%s: Expected the replication config to be skipped, got %v %v", instanceType, result.FilesRestored, result.FilesSkipped)
+	}
+}
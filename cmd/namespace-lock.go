@@ -376,6 +376,65 @@ func (li *lockInstance) RUnlock() {
 	li.ns.unlock(li.volume, li.path, li.opsID, readLock)
 }
 
+// multiLockInstance - locks several paths of a volume at once, for
+// operations on batches of objects.
+type multiLockInstance struct {
+	ns     *nsLockMap
+	volume string
+	paths  []string
+	opsID  string
+}
+
+// NewNSLocks - returns a lock instance for the given paths of a
+// volume. Paths are locked in sorted order so that batches sharing
+// paths cannot deadlock each other, duplicate paths are locked once.
+func (n *nsLockMap) NewNSLocks(volume string, paths []string) RWLocker {
+	paths = set.CreateStringSet(paths...).ToSlice()
+	return &multiLockInstance{n, volume, paths, getOpsID()}
+}
+
+// Takes locks of all the paths, until the timeout is exhausted in
+// total. Locks already taken are released if one of them times out.
+func (mi *multiLockInstance) lock(timeout *dynamicTimeout, lockSource string, readLock bool) (timedOutErr error) {
+	start := UTCNow()
+	deadline := start.Add(timeout.Timeout())
+	for i, path := range mi.paths {
+		if !mi.ns.lock(mi.volume, path, lockSource, mi.opsID, readLock, deadline.Sub(UTCNow())) {
+			for _, locked := range mi.paths[:i] {
+				mi.ns.unlock(mi.volume, locked, mi.opsID, readLock)
+			}
+			timeout.LogFailure()
+			return OperationTimedOut{Path: path}
+		}
+	}
+	timeout.LogSuccess(UTCNow().Sub(start))
+	return nil
+}
+
+// Lock - block until write locks of all paths are taken or timeout has occurred.
+func (mi *multiLockInstance) GetLock(timeout *dynamicTimeout) (timedOutErr error) {
+	return mi.lock(timeout, getSource(), false)
+}
+
+// Unlock - release write locks of all paths.
+func (mi *multiLockInstance) Unlock() {
+	for _, path := range mi.paths {
+		mi.ns.unlock(mi.volume, path, mi.opsID, false)
+	}
+}
+
+// RLock - block until read locks of all paths are taken or timeout has occurred.
+func (mi *multiLockInstance) GetRLock(timeout *dynamicTimeout) (timedOutErr error) {
+	return mi.lock(timeout, getSource(), true)
+}
+
+// RUnlock - release read locks of all paths.
+func (mi *multiLockInstance) RUnlock() {
+	for _, path := range mi.paths {
+		mi.ns.unlock(mi.volume, path, mi.opsID, true)
+	}
+}
+
 func getSource() string {
 	var funcName string
 	pc, filename, lineNum, ok := runtime.Caller(2)
@@ -200,6 +200,41 @@ func TestNamespaceLockTimedOut(t *testing.T) {
 	globalNSMutex.RUnlock("my-bucket", "my-object", "nop")
 }
 
+// Tests locking several paths at once.
+func TestNamespaceLocks(t *testing.T) {
+	isDistXL := false
+	initNSLock(isDistXL)
+
+	locks := globalNSMutex.NewNSLocks("my-bucket", []string{"b", "a", "b"})
+	if err := locks.GetLock(newDynamicTimeout(60*time.Second, time.Second)); err != nil {
+		t.Fatalf("Failed to acquire locks: %v", err)
+	}
+
+	// Paths of the batch cannot be locked by others.
+	if globalNSMutex.Lock("my-bucket", "a", "def", time.Second) {
+		t.Fatalf("Should not have acquired lock")
+	}
+
+	// A batch sharing a path times out, releasing the locks it took.
+	other := globalNSMutex.NewNSLocks("my-bucket", []string{"0", "b"})
+	if err := other.GetLock(newDynamicTimeout(time.Second, time.Second)); err == nil {
+		t.Fatalf("Should not have acquired locks")
+	}
+	if !globalNSMutex.Lock("my-bucket", "0", "ghi", time.Second) {
+		t.Fatalf("Failed to acquire lock released by timed out batch")
+	}
+	globalNSMutex.Unlock("my-bucket", "0", "ghi")
+
+	locks.Unlock()
+	if !globalNSMutex.Lock("my-bucket", "a", "klm", time.Second) {
+		t.Fatalf("Failed to acquire lock after unlock")
+	}
+	globalNSMutex.Unlock("my-bucket", "a", "klm")
+	if len(globalNSMutex.lockMap) != 0 {
+		t.Fatalf("Expected lock map to be empty, got %d entries", len(globalNSMutex.lockMap))
+	}
+}
+
 // Tests functionality to forcefully unlock locks.
 func TestNamespaceForceUnlockTest(t *testing.T) {
 	isDistXL := false
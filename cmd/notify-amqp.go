@@ -19,12 +19,15 @@ package cmd
 import (
 	"io/ioutil"
 	"net"
+	"strings"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/streadway/amqp"
 )
 
+var amqpErrFunc = newNotificationErrorFactory("AMQP")
+
 // amqpNotify - represents logrus compatible AMQP hook.
 // All fields represent AMQP configuration details.
 type amqpNotify struct {
@@ -46,9 +49,27 @@ func (a *amqpNotify) Validate() error {
 	if !a.Enable {
 		return nil
 	}
-	if _, err := checkURL(a.URL); err != nil {
+	u, err := checkURL(a.URL)
+	if err != nil {
 		return err
 	}
+	if u.Scheme != "amqp" && u.Scheme != "amqps" {
+		return amqpErrFunc("Unsupported scheme of URL `%s`, expected amqp or amqps.", a.URL)
+	}
+	// Events are published to the default exchange unless an exchange
+	// is named, which is then declared with its type.
+	if a.Exchange != "" {
+		switch a.ExchangeType {
+		case "direct", "fanout", "topic", "headers":
+		default:
+			if !strings.HasPrefix(a.ExchangeType, "x-") {
+				return amqpErrFunc("Unknown exchange type `%s`, expected direct, fanout, topic, headers or a plugin type x-*.", a.ExchangeType)
+			}
+		}
+	}
+	if a.DeliveryMode > amqp.Persistent {
+		return amqpErrFunc("Delivery mode must be 0 or 1 for transient or 2 for persistent messages.")
+	}
 	return nil
 }
 
@@ -94,8 +115,8 @@ func newAMQPNotify(accountID string) (*logrus.Logger, error) {
 	// Add a amqp hook.
 	amqpLog.Hooks.Add(amqpC)
 
-	// Set default JSON formatter.
-	amqpLog.Formatter = new(logrus.JSONFormatter)
+	// Set JSON formatter, templated payloads are sent as is.
+	amqpLog.Formatter = new(notificationFormatter)
 
 	// Successfully enabled all AMQPs.
 	return amqpLog, nil
@@ -149,17 +170,20 @@ func (q *amqpConn) Fire(entry *logrus.Entry) error {
 	}
 	defer ch.Close()
 
-	err = ch.ExchangeDeclare(
-		q.params.Exchange,
-		q.params.ExchangeType,
-		q.params.Durable,
-		q.params.AutoDeleted,
-		q.params.Internal,
-		q.params.NoWait,
-		nil,
-	)
-	if err != nil {
-		return err
+	// The default exchange always exists and cannot be declared.
+	if q.params.Exchange != "" {
+		err = ch.ExchangeDeclare(
+			q.params.Exchange,
+			q.params.ExchangeType,
+			q.params.Durable,
+			q.params.AutoDeleted,
+			q.params.Internal,
+			q.params.NoWait,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
 	}
 
 	body, err := entry.String()
@@ -141,6 +141,11 @@ func (q elasticClient) Fire(entry *logrus.Entry) (err error) {
 		if eventMatch(entryStr, []string{"s3:ObjectRemoved:*"}) {
 			_, err = q.Client.Delete().Index(q.params.Index).
 				Type("event").Id(keyStr).Do(context.Background())
+			// Objects created before the index was set up have
+			// no document to purge.
+			if elastic.IsNotFound(err) {
+				err = nil
+			}
 			break
 		} // else we update elastic index or create a new one.
 		_, err = q.Client.Index().Index(q.params.Index).
@@ -155,6 +160,9 @@ func (q elasticClient) Fire(entry *logrus.Entry) (err error) {
 		if !ok {
 			return esErrFunc("Unable to extract event time due to conversion error of entry.Data[\"Records\"]=%v", entry.Data["Records"])
 		}
+		if len(events) == 0 {
+			return nil
+		}
 		var eventTime time.Time
 		eventTime, err = time.Parse(timeFormatAMZ, events[0].EventTime)
 		if err != nil {
@@ -103,7 +103,7 @@ func newKafkaNotify(accountID string) (*logrus.Logger, error) {
 	// Configure kafkaConn object as a Hook in logrus.
 	kafkaLog := logrus.New()
 	kafkaLog.Out = ioutil.Discard
-	kafkaLog.Formatter = new(logrus.JSONFormatter)
+	kafkaLog.Formatter = new(notificationFormatter)
 	kafkaLog.Hooks.Add(kc)
 
 	return kafkaLog, nil
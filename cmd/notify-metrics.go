@@ -0,0 +1,137 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// ServerNotifyTargetStats holds delivery metrics of a notification target
+// on this server. Events are delivered while the request is served and
+// are not retried, events failing to be delivered are dropped.
+type ServerNotifyTargetStats struct {
+	ARN string `json:"arn"`
+
+	// Events being delivered right now.
+	InFlight int64 `json:"inFlight"`
+
+	Sent   uint64 `json:"sent"`
+	Failed uint64 `json:"failed"`
+
+	// Total and last time taken to deliver events, including
+	// failed deliveries.
+	TotalLatency time.Duration `json:"totalLatency"`
+	LastLatency  time.Duration `json:"lastLatency"`
+
+	LastSent   time.Time `json:"lastSent"`
+	LastFailed time.Time `json:"lastFailed"`
+	LastError  string    `json:"lastError,omitempty"`
+}
+
+// notifyTargetMetrics - delivery metrics of all notification targets
+// loaded on this server, by target ARN.
+type notifyTargetMetrics struct {
+	mu      sync.Mutex
+	targets map[string]*ServerNotifyTargetStats
+}
+
+func newNotifyTargetMetrics() *notifyTargetMetrics {
+	return &notifyTargetMetrics{targets: make(map[string]*ServerNotifyTargetStats)}
+}
+
+// Returns the metrics of the target arn, metrics are kept when targets
+// are loaded again.
+func (m *notifyTargetMetrics) target(arn string) *ServerNotifyTargetStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats, ok := m.targets[arn]
+	if !ok {
+		stats = &ServerNotifyTargetStats{ARN: arn}
+		m.targets[arn] = stats
+	}
+	return stats
+}
+
+func (m *notifyTargetMetrics) begin(stats *ServerNotifyTargetStats) {
+	m.mu.Lock()
+	stats.InFlight++
+	m.mu.Unlock()
+}
+
+func (m *notifyTargetMetrics) end(stats *ServerNotifyTargetStats, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats.InFlight--
+	stats.TotalLatency += latency
+	stats.LastLatency = latency
+	now := UTCNow()
+	if err != nil {
+		stats.Failed++
+		stats.LastFailed = now
+		stats.LastError = err.Error()
+		return
+	}
+	stats.Sent++
+	stats.LastSent = now
+}
+
+// List - returns the metrics of all targets sorted by ARN.
+func (m *notifyTargetMetrics) List() []ServerNotifyTargetStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	targets := make([]ServerNotifyTargetStats, 0, len(m.targets))
+	for _, stats := range m.targets {
+		targets = append(targets, *stats)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].ARN < targets[j].ARN
+	})
+	return targets
+}
+
+// notifyMetricsHook - records the delivery metrics of events sent by
+// the hook of a notification target.
+type notifyMetricsHook struct {
+	logrus.Hook
+	metrics *notifyTargetMetrics
+	stats   *ServerNotifyTargetStats
+}
+
+func (h notifyMetricsHook) Fire(entry *logrus.Entry) error {
+	h.metrics.begin(h.stats)
+	start := UTCNow()
+	err := h.Hook.Fire(entry)
+	h.metrics.end(h.stats, UTCNow().Sub(start), err)
+	return err
+}
+
+// instrumentQueueTarget - records the delivery metrics of the
+// notification target arn sending events through logger.
+func instrumentQueueTarget(metrics *notifyTargetMetrics, arn string, logger *logrus.Logger) {
+	stats := metrics.target(arn)
+	for level, hooks := range logger.Hooks {
+		instrumented := make([]logrus.Hook, len(hooks))
+		for i, hook := range hooks {
+			instrumented[i] = notifyMetricsHook{hook, metrics, stats}
+		}
+		logger.Hooks[level] = instrumented
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// testNotifyHook - notification target hook failing with err.
+type testNotifyHook struct {
+	err error
+}
+
+func (h *testNotifyHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.InfoLevel}
+}
+
+func (h *testNotifyHook) Fire(entry *logrus.Entry) error {
+	return h.err
+}
+
+// Tests delivery metrics are recorded for events sent to targets.
+func TestNotifyTargetMetrics(t *testing.T) {
+	metrics := newNotifyTargetMetrics()
+
+	hook := &testNotifyHook{}
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	logger.Hooks.Add(hook)
+	instrumentQueueTarget(metrics, "arn:minio:sqs:us-east-1:1:webhook", logger)
+
+	logger.Info("event")
+	logger.Info("event")
+	hook.err = errors.New("connection refused")
+	logger.Info("event")
+
+	// Targets loaded again keep their metrics.
+	instrumentQueueTarget(metrics, "arn:minio:sqs:us-east-1:1:webhook", logrus.New())
+	metrics.target("arn:minio:sqs:us-east-1:1:amqp")
+
+	targets := metrics.List()
+	if len(targets) != 2 {
+		t.Fatalf("Expected 2 targets, got %d", len(targets))
+	}
+	if targets[0].ARN != "arn:minio:sqs:us-east-1:1:amqp" || targets[0].Sent != 0 {
+		t.Errorf("Unexpected metrics %+v", targets[0])
+	}
+	stats := targets[1]
+	if stats.Sent != 2 || stats.Failed != 1 || stats.InFlight != 0 {
+		t.Errorf("Unexpected metrics %+v", stats)
+	}
+	if stats.LastError != "connection refused" || stats.LastFailed.IsZero() || stats.LastSent.IsZero() {
+		t.Errorf("Unexpected metrics %+v", stats)
+	}
+}
+
+// Tests metrics are served in the Prometheus text format.
+func TestPrometheusMetricsHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("unable initialize config file, %s", err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	defer func(metrics *notifyTargetMetrics, public bool) {
+		globalNotifyTargetMetrics = metrics
+		globalIsPrometheusPublic = public
+	}(globalNotifyTargetMetrics, globalIsPrometheusPublic)
+
+	globalNotifyTargetMetrics = newNotifyTargetMetrics()
+	stats := globalNotifyTargetMetrics.target("arn:minio:sqs:us-east-1:1:webhook")
+	globalNotifyTargetMetrics.begin(stats)
+	globalNotifyTargetMetrics.end(stats, 0, errors.New("timeout"))
+
+	globalIsPrometheusPublic = false
+	req := httptest.NewRequest(http.MethodGet, prometheusMetricsPath, nil)
+	rec := httptest.NewRecorder()
+	prometheusMetricsHandler(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	creds := globalServerConfig.GetCredential()
+	token, err := authenticateWeb(creds.AccessKey, creds.SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	prometheusMetricsHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	globalIsPrometheusPublic = true
+	req = httptest.NewRequest(http.MethodGet, prometheusMetricsPath, nil)
+	rec = httptest.NewRecorder()
+	prometheusMetricsHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	for _, line := range []string{
+		"# TYPE minio_notify_target_failed_total counter",
+		`minio_notify_target_failed_total{arn="arn:minio:sqs:us-east-1:1:webhook"} 1`,
+		`minio_notify_target_sent_total{arn="arn:minio:sqs:us-east-1:1:webhook"} 0`,
+		`minio_notify_target_delivery_seconds_count{arn="arn:minio:sqs:us-east-1:1:webhook"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), line+"\n") {
+			t.Errorf("Expected metrics to contain %q, got\n%s", line, rec.Body.String())
+		}
+	}
+}
@@ -25,6 +25,13 @@ import (
 	MQTT "github.com/eclipse/paho.mqtt.golang"
 )
 
+var (
+	mqttErrFunc = newNotificationErrorFactory("MQTT")
+
+	errMQTTTopic = mqttErrFunc("Topic was not specified in the configuration.")
+	errMQTTQoS   = mqttErrFunc("QoS must be 0, 1 or 2.")
+)
+
 type mqttNotify struct {
 	Enable   bool   `json:"enable"`
 	Broker   string `json:"broker"`
@@ -39,9 +46,21 @@ func (m *mqttNotify) Validate() error {
 	if !m.Enable {
 		return nil
 	}
-	if _, err := checkURL(m.Broker); err != nil {
+	u, err := checkURL(m.Broker)
+	if err != nil {
 		return err
 	}
+	switch u.Scheme {
+	case "tcp", "ssl", "tls", "ws", "wss":
+	default:
+		return mqttErrFunc("Unsupported scheme of broker `%s`, expected tcp, ssl, tls, ws or wss.", m.Broker)
+	}
+	if m.Topic == "" {
+		return errMQTTTopic
+	}
+	if m.QoS < 0 || m.QoS > 2 {
+		return errMQTTQoS
+	}
 	return nil
 }
 
@@ -88,8 +107,8 @@ func newMQTTNotify(accountID string) (*logrus.Logger, error) {
 	// Add a mqtt hook.
 	mqttLog.Hooks.Add(mqttC)
 
-	// Set default JSON formatter
-	mqttLog.Formatter = new(logrus.JSONFormatter)
+	// Set JSON formatter, templated payloads are sent as is.
+	mqttLog.Formatter = new(notificationFormatter)
 
 	// successfully enabled all MQTTs
 	return mqttLog, nil
@@ -0,0 +1,46 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import "testing"
+
+// Tests validating MQTT endpoints.
+func TestMQTTNotifyValidate(t *testing.T) {
+	testCases := []struct {
+		config  mqttNotify
+		success bool
+	}{
+		{mqttNotify{}, true},
+		{mqttNotify{Enable: true, Broker: "tcp://localhost:1883", Topic: "minio", QoS: 1}, true},
+		{mqttNotify{Enable: true, Broker: "ssl://broker.example.com:8883", Topic: "minio/events", QoS: 2}, true},
+		{mqttNotify{Enable: true, Broker: "wss://broker.example.com/mqtt", Topic: "minio"}, true},
+		{mqttNotify{Enable: true, Broker: "", Topic: "minio"}, false},
+		{mqttNotify{Enable: true, Broker: "http://localhost:1883", Topic: "minio"}, false},
+		{mqttNotify{Enable: true, Broker: "tcp://localhost:1883"}, false},
+		{mqttNotify{Enable: true, Broker: "tcp://localhost:1883", Topic: "minio", QoS: 3}, false},
+		{mqttNotify{Enable: true, Broker: "tcp://localhost:1883", Topic: "minio", QoS: -1}, false},
+	}
+	for i, testCase := range testCases {
+		err := testCase.config.Validate()
+		if testCase.success && err != nil {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if !testCase.success && err == nil {
+			t.Errorf("Test %d: Expected to fail", i+1)
+		}
+	}
+}
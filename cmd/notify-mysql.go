@@ -26,12 +26,12 @@
 // A table with a specific structure (column names, column types, and
 // primary key/uniqueness constraint) is used. The user may set the
 // table name in the configuration. A sample SQL command that creates
-// a command with the required structure is:
+// a table with the required structure is:
 //
 //     CREATE TABLE myminio (
 //         key_name VARCHAR(2048),
-//         value JSONB,
-//         PRIMARY KEY (key_name),
+//         value JSON,
+//         PRIMARY KEY (key_name)
 //     );
 //
 // MySQL's "INSERT ... ON DUPLICATE ..." feature (UPSERT) is used
@@ -47,8 +47,8 @@
 // commant that creates a table with the required structure is:
 //
 // CREATE TABLE myminio (
-//     event_time TIMESTAMP WITH TIME ZONE NOT NULL,
-//     event_data JSONB
+//     event_time DATETIME NOT NULL,
+//     event_data JSON
 // );
 
 package cmd
@@ -171,9 +171,12 @@ func dialMySQL(msql mySQLNotify) (mc mySQLConn, e error) {
 			dsnStr, err)
 	}
 
+	setSQLConnPool(db)
+
 	// ping to check that server is actually reachable.
 	err = db.Ping()
 	if err != nil {
+		db.Close()
 		return mc, mysqlErrFunc(
 			"Ping to server failed with: %v", err)
 	}
@@ -306,7 +309,7 @@ func (myC mySQLConn) Fire(entry *logrus.Entry) error {
 		// eventTime is taken from the first entry in the
 		// records.
 		events, ok := entry.Data["Records"].([]NotificationEvent)
-		if !ok {
+		if !ok || len(events) == 0 {
 			return mysqlErrFunc("unable to extract event time due to conversion error of entry.Data[\"Records\"]=%v", entry.Data["Records"])
 		}
 		eventTime, err := time.Parse(timeFormatAMZ, events[0].EventTime)
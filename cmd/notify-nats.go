@@ -154,8 +154,8 @@ func newNATSNotify(accountID string) (*logrus.Logger, error) {
 	// Add a nats hook.
 	natsLog.Hooks.Add(natsC)
 
-	// Set default JSON formatter.
-	natsLog.Formatter = new(logrus.JSONFormatter)
+	// Set JSON formatter, templated payloads are sent as is.
+	natsLog.Formatter = new(notificationFormatter)
 
 	// Successfully enabled all NATSs.
 	return natsLog, nil
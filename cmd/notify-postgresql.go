@@ -96,6 +96,13 @@ VALUES ($1, $2);`
 
 	// Query to check if a table already exists.
 	tableExists = `SELECT 1 FROM %s;`
+
+	// Events are written to SQL targets concurrently on a pool of
+	// at most this many connections, of which some are kept open
+	// between bursts of events and closed after their lifetime.
+	sqlMaxOpenConns    = 16
+	sqlMaxIdleConns    = 4
+	sqlConnMaxLifetime = 30 * time.Minute
 )
 
 var (
@@ -184,9 +191,12 @@ func dialPostgreSQL(pgN postgreSQLNotify) (pc pgConn, e error) {
 			connStr, err)
 	}
 
+	setSQLConnPool(db)
+
 	// ping to check that server is actually reachable.
 	err = db.Ping()
 	if err != nil {
+		db.Close()
 		return pc, pgErrFunc("Ping to server failed with: %v",
 			err)
 	}
@@ -241,6 +251,13 @@ func dialPostgreSQL(pgN postgreSQLNotify) (pc pgConn, e error) {
 	return pgConn{connStr, pgN.Table, pgN.Format, stmts, db}, nil
 }
 
+// setSQLConnPool - bounds the connection pool of a SQL target.
+func setSQLConnPool(db *sql.DB) {
+	db.SetMaxOpenConns(sqlMaxOpenConns)
+	db.SetMaxIdleConns(sqlMaxIdleConns)
+	db.SetConnMaxLifetime(sqlConnMaxLifetime)
+}
+
 func newPostgreSQLNotify(accountID string) (*logrus.Logger, error) {
 	pgNotify := globalServerConfig.Notify.GetPostgreSQLByID(accountID)
 
@@ -322,7 +339,7 @@ func (pgC pgConn) Fire(entry *logrus.Entry) error {
 		// eventTime is taken from the first entry in the
 		// records.
 		events, ok := entry.Data["Records"].([]NotificationEvent)
-		if !ok {
+		if !ok || len(events) == 0 {
 			return pgErrFunc("unable to extract event time due to conversion error of entry.Data[\"Records\"]=%v", entry.Data["Records"])
 		}
 		eventTime, err := time.Parse(timeFormatAMZ, events[0].EventTime)
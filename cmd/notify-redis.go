@@ -95,20 +95,34 @@ func dialRedis(rNotify redisNotify) (*redis.Pool, error) {
 		},
 	}
 
-	// Test if connection with REDIS can be established.
+	// Test if connection with REDIS can be established, release
+	// the pool if it cannot be used.
+	if err := checkRedisKey(rPool, rNotify); err != nil {
+		rPool.Close()
+		return nil, err
+	}
+
+	// Return pool.
+	return rPool, nil
+}
+
+// checkRedisKey - checks the connection to the server and that the key
+// is of the type of the format, a hash keyed by object name for the
+// namespace format and a list of events for the access format.
+func checkRedisKey(rPool *redis.Pool, rNotify redisNotify) error {
 	rConn := rPool.Get()
 	defer rConn.Close()
 
 	// Check connection.
 	_, err := rConn.Do("PING")
 	if err != nil {
-		return nil, redisErrFunc("Error connecting to server: %v", err)
+		return redisErrFunc("Error connecting to server: %v", err)
 	}
 
 	// Test that Key is of desired type
 	reply, err := redis.String(rConn.Do("TYPE", rNotify.Key))
 	if err != nil {
-		return nil, redisErrFunc("Error getting type of Key=%s: %v",
+		return redisErrFunc("Error getting type of Key=%s: %v",
 			rNotify.Key, err)
 	}
 	if reply != "none" {
@@ -117,14 +131,12 @@ func dialRedis(rNotify redisNotify) (*redis.Pool, error) {
 			expectedType = "list"
 		}
 		if reply != expectedType {
-			return nil, redisErrFunc(
+			return redisErrFunc(
 				"Key=%s has type %s, but we expect it to be a %s",
 				rNotify.Key, reply, expectedType)
 		}
 	}
-
-	// Return pool.
-	return rPool, nil
+	return nil
 }
 
 func newRedisNotify(accountID string) (*logrus.Logger, error) {
@@ -198,6 +210,9 @@ func (r redisConn) Fire(entry *logrus.Entry) error {
 		if !ok {
 			return redisErrFunc("unable to extract event time due to conversion error of entry.Data[\"Records\"]=%v", entry.Data["Records"])
 		}
+		if len(events) == 0 {
+			return nil
+		}
 		eventTime := events[0].EventTime
 
 		listEntry := []interface{}{eventTime, entry.Data["Records"]}
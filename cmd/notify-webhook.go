@@ -159,8 +159,8 @@ func newWebhookNotify(accountID string) (*logrus.Logger, error) {
 	notifyLog := logrus.New()
 	notifyLog.Out = ioutil.Discard
 
-	// Set default JSON formatter.
-	notifyLog.Formatter = new(logrus.JSONFormatter)
+	// Set JSON formatter, templated payloads are sent as is.
+	notifyLog.Formatter = new(notificationFormatter)
 
 	notifyLog.Hooks.Add(conn)
 
@@ -102,6 +102,46 @@ func deleteBucketMetadata(bucket string, objAPI ObjectLayer) {
 
 	// Notify all peers (including self) to update in-memory state
 	S3PeersUpdateBucketListener(bucket, []listenerConfig{})
+
+	// Delete recency feed, if present - ignore any errors.
+	if globalRecentObjects != nil {
+		globalRecentObjects.Remove(bucket)
+	}
+	_ = removeRecentObjects(bucket, objAPI)
+
+	// Drop the search index of the bucket.
+	if globalObjectIndex != nil {
+		globalObjectIndex.Remove(bucket)
+	}
+
+	// Delete the configs cached by all servers and the replication
+	// queue, if present - ignore any errors.
+	_ = removeReplicationConfig(bucket, objAPI)
+	for _, configFile := range cachedBucketConfigFiles {
+		_ = removeBucketConfig(bucket, configFile, objAPI)
+		if configs := getBucketConfigs(configFile); configs != nil {
+			configs.Remove(bucket)
+		}
+		S3PeersUpdateBucketConfig(bucket, configFile)
+	}
+
+	// Delete progress of renamed prefixes, if present - ignore any errors.
+	_ = removeRenamePrefixStatuses(bucket, objAPI)
+
+	// Detach managed policy, if present - ignore any errors.
+	_ = objAPI.DeleteObject(minioMetaBucket, getBucketManagedPolicyPath(bucket))
+
+	// Drop usage of the bucket and its statistics, if present - ignore any errors.
+	if globalUsageCrawler != nil {
+		globalUsageCrawler.Remove(bucket)
+	}
+	_ = removeBucketStats(bucket, objAPI)
+
+	// Drop access statistics of the bucket, persisted ones are dropped
+	// when persisted next.
+	if globalAccessStats != nil {
+		globalAccessStats.Remove(bucket)
+	}
 }
 
 // House keeping code for FS/XL and distributed Minio setup.
@@ -101,6 +101,10 @@ type ObjectInfo struct {
 	// User-Defined metadata
 	UserDefined map[string]string
 
+	// List of individual parts, only set for objects uploaded
+	// with multipart by backends which keep track of them.
+	Parts []objectPartInfo `json:"-"`
+
 	// Implements writer and reader used by CopyObject API
 	Writer       io.WriteCloser `json:"-"`
 	Reader       *hash.Reader   `json:"-"`
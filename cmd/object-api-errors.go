@@ -179,6 +179,21 @@ func (e ObjectExistsAsDirectory) Error() string {
 	return "Object exists on : " + e.Bucket + " as directory " + e.Object
 }
 
+// ObjectQuarantined object is pending validation and cannot be read.
+type ObjectQuarantined GenericError
+
+func (e ObjectQuarantined) Error() string {
+	return "Object is quarantined pending validation: " + e.Bucket + "#" + e.Object
+}
+
+// ObjectLocked object is protected by the WORM mode or its object lock
+// and cannot be overwritten or deleted.
+type ObjectLocked GenericError
+
+func (e ObjectLocked) Error() string {
+	return "Object is locked: " + e.Bucket + "#" + e.Object
+}
+
 //PrefixAccessDenied object access is denied.
 type PrefixAccessDenied GenericError
 
@@ -413,3 +428,13 @@ func isErrObjectNotFound(err error) bool {
 	}
 	return false
 }
+
+// isErrBucketNotFound - Check if error type is BucketNotFound.
+func isErrBucketNotFound(err error) bool {
+	err = errors.Cause(err)
+	switch err.(type) {
+	case BucketNotFound:
+		return true
+	}
+	return false
+}
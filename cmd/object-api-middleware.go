@@ -0,0 +1,80 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import "fmt"
+
+// ObjectLayerMiddleware - wraps an object layer, returning an object
+// layer which intercepts some of its calls, for example to scan uploads
+// for viruses in PutObject. Middlewares usually embed the wrapped
+// object layer and only implement the calls they intercept. Internal
+// configuration in the meta bucket is read and written through
+// middlewares as well.
+type ObjectLayerMiddleware func(ObjectLayer) ObjectLayer
+
+type namedObjectLayerMiddleware struct {
+	name       string
+	middleware ObjectLayerMiddleware
+}
+
+// Middlewares registered so far, in the order of registration.
+var objectLayerMiddlewares []namedObjectLayerMiddleware
+
+// RegisterObjectLayerMiddleware - registers a middleware wrapping the
+// object layer of servers and gateways once it is initialized, meant
+// to be called from init functions of files compiled into the binary.
+// The first middleware registered sees calls first. Panics if a
+// middleware with the same name is already registered.
+func RegisterObjectLayerMiddleware(name string, middleware ObjectLayerMiddleware) {
+	if middleware == nil {
+		panic("RegisterObjectLayerMiddleware: middleware is nil")
+	}
+	for _, registered := range objectLayerMiddlewares {
+		if registered.name == name {
+			panic(fmt.Sprintf("RegisterObjectLayerMiddleware: middleware %s registered twice", name))
+		}
+	}
+	objectLayerMiddlewares = append(objectLayerMiddlewares, namedObjectLayerMiddleware{name, middleware})
+}
+
+// middlewareObjectLayer - object layer wrapped by middlewares, keeps
+// the object layer they wrap for code depending on its type.
+type middlewareObjectLayer struct {
+	ObjectLayer
+	backend ObjectLayer
+}
+
+// wrapObjectLayer - wraps objAPI by all registered middlewares, returns
+// objAPI unchanged if none are registered.
+func wrapObjectLayer(objAPI ObjectLayer) ObjectLayer {
+	if len(objectLayerMiddlewares) == 0 {
+		return objAPI
+	}
+	wrapped := objAPI
+	for i := len(objectLayerMiddlewares) - 1; i >= 0; i-- {
+		wrapped = objectLayerMiddlewares[i].middleware(wrapped)
+	}
+	return &middlewareObjectLayer{ObjectLayer: wrapped, backend: objAPI}
+}
+
+// unwrapObjectLayer - returns the object layer wrapped by middlewares.
+func unwrapObjectLayer(objAPI ObjectLayer) ObjectLayer {
+	if wrapped, ok := objAPI.(*middlewareObjectLayer); ok {
+		return wrapped.backend
+	}
+	return objAPI
+}
@@ -0,0 +1,122 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/minio/minio/pkg/hash"
+)
+
+var errTestMiddlewareRejected = errors.New("rejected by middleware")
+
+// testMiddlewareLayer - stamps uploads with metadata and records the
+// order in which middlewares see them. Uploads of objects named
+// "reject" are rejected.
+type testMiddlewareLayer struct {
+	ObjectLayer
+	name  string
+	calls *[]string
+}
+
+func (l *testMiddlewareLayer) PutObject(bucket, object string, data *hash.Reader, metadata map[string]string) (ObjectInfo, error) {
+	*l.calls = append(*l.calls, l.name)
+	if object == "reject" {
+		return ObjectInfo{}, errTestMiddlewareRejected
+	}
+	metadata["x-amz-meta-"+l.name] = "true"
+	return l.ObjectLayer.PutObject(bucket, object, data, metadata)
+}
+
+// Tests that registered middlewares wrap the object layer in the order
+// of registration.
+func TestWrapObjectLayer(t *testing.T) {
+	defer func(middlewares []namedObjectLayerMiddleware) {
+		objectLayerMiddlewares = middlewares
+	}(objectLayerMiddlewares)
+	objectLayerMiddlewares = nil
+
+	obj, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+
+	if wrapObjectLayer(obj) != obj {
+		t.Fatal("Expected the object layer to be unchanged without middlewares")
+	}
+
+	var calls []string
+	for _, name := range []string{"first", "second"} {
+		name := name
+		RegisterObjectLayerMiddleware(name, func(objAPI ObjectLayer) ObjectLayer {
+			return &testMiddlewareLayer{ObjectLayer: objAPI, name: name, calls: &calls}
+		})
+	}
+	wrapped := wrapObjectLayer(obj)
+	if unwrapObjectLayer(wrapped) != obj {
+		t.Fatal("Expected the wrapped object layer to be unwrapped")
+	}
+
+	if err = wrapped.MakeBucketWithLocation("bucket", ""); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello")
+	if _, err = wrapped.PutObject("bucket", "object", mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""),
+		map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(calls, []string{"first", "second"}) {
+		t.Fatalf("Expected middlewares to be called in order of registration, got %v", calls)
+	}
+	objInfo, err := obj.GetObjectInfo("bucket", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.UserDefined["x-amz-meta-first"] != "true" || objInfo.UserDefined["x-amz-meta-second"] != "true" {
+		t.Fatalf("Expected metadata stamped by both middlewares, got %v", objInfo.UserDefined)
+	}
+
+	if _, err = wrapped.PutObject("bucket", "reject", mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""),
+		map[string]string{}); err != errTestMiddlewareRejected {
+		t.Fatalf("Expected upload to be rejected, got %v", err)
+	}
+	if _, err = obj.GetObjectInfo("bucket", "reject"); !isErrObjectNotFound(err) {
+		t.Fatalf("Expected rejected object not to be stored, got %v", err)
+	}
+}
+
+// Tests that registering a middleware twice panics.
+func TestRegisterObjectLayerMiddlewareTwice(t *testing.T) {
+	defer func(middlewares []namedObjectLayerMiddleware) {
+		objectLayerMiddlewares = middlewares
+	}(objectLayerMiddlewares)
+	objectLayerMiddlewares = nil
+
+	middleware := func(objAPI ObjectLayer) ObjectLayer { return objAPI }
+	RegisterObjectLayerMiddleware("test", middleware)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected registering a middleware twice to panic")
+		}
+	}()
+	RegisterObjectLayerMiddleware("test", middleware)
+}
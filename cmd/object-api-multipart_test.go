@@ -18,6 +18,9 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strings"
@@ -129,6 +132,61 @@ func testObjectAbortMultipartUpload(obj ObjectLayer, instanceType string, t Test
 	}
 }
 
+// Wrapper for calling multipart cancellation tests for both XL multiple disks and single node setup.
+func TestObjectMultipartCanceled(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectMultipartCanceled)
+}
+
+// Tests uploading and completing parts stop once the client disconnects.
+func testObjectMultipartCanceled(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "minio-bucket"
+	object := "minio-object"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	data := []byte("abcd")
+	md5Hex := getMD5Hash(data)
+	_, err = obj.PutObjectPart(ctx, bucket, object, uploadID, 1, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), md5Hex, ""))
+	if errors.Cause(err) != errClientDisconnected {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, errClientDisconnected, err)
+	}
+	result, err := obj.ListObjectParts(bucket, object, uploadID, 0, 10)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Parts) != 0 {
+		t.Fatalf("%s: Expected no parts, got %d", instanceType, len(result.Parts))
+	}
+
+	if _, err = obj.PutObjectPart(context.Background(), bucket, object, uploadID, 1, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), md5Hex, "")); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	parts := []CompletePart{{PartNumber: 1, ETag: md5Hex}}
+	if _, err = obj.CompleteMultipartUpload(ctx, bucket, object, uploadID, parts); errors.Cause(err) != errClientDisconnected {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, errClientDisconnected, err)
+	}
+
+	// The upload is kept and can be completed again.
+	if _, err = obj.CompleteMultipartUpload(context.Background(), bucket, object, uploadID, parts); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, object, 0, int64(len(data)), &buffer, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Unexpected object data", instanceType)
+	}
+}
+
 // Wrapper for calling isUploadIDExists tests for both XL multiple disks and single node setup.
 func TestObjectAPIIsUploadIDExists(t *testing.T) {
 	ExecObjectLayerTest(t, testObjectAPIIsUploadIDExists)
@@ -220,7 +278,7 @@ func testPutObjectPartDiskNotFound(obj ObjectLayer, instanceType string, disks [
 	sha256sum := ""
 	// Iterating over creatPartCases to generate multipart chunks.
 	for _, testCase := range createPartCases {
-		_, err = obj.PutObjectPart(testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
+		_, err = obj.PutObjectPart(context.Background(), testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
 		if err != nil {
 			t.Fatalf("%s : %s", instanceType, err.Error())
 		}
@@ -234,7 +292,7 @@ func testPutObjectPartDiskNotFound(obj ObjectLayer, instanceType string, disks [
 
 	// Object part upload should fail with quorum not available.
 	testCase := createPartCases[len(createPartCases)-1]
-	_, err = obj.PutObjectPart(testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
+	_, err = obj.PutObjectPart(context.Background(), testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
 	if err == nil {
 		t.Fatalf("Test %s: expected to fail but passed instead", instanceType)
 	}
@@ -353,7 +411,7 @@ func testObjectAPIPutObjectPart(obj ObjectLayer, instanceType string, t TestErrH
 
 	// Validate all the test cases.
 	for i, testCase := range testCases {
-		actualInfo, actualErr := obj.PutObjectPart(testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, testCase.inputSHA256))
+		actualInfo, actualErr := obj.PutObjectPart(context.Background(), testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, testCase.inputSHA256))
 		// All are test cases above are expected to fail.
 		if actualErr != nil && testCase.shouldPass {
 			t.Errorf("Test %d: %s: Expected to pass, but failed with: <ERROR> %s.", i+1, instanceType, actualErr.Error())
@@ -487,7 +545,7 @@ func testListMultipartUploads(obj ObjectLayer, instanceType string, t TestErrHan
 	sha256sum := ""
 	// Iterating over creatPartCases to generate multipart chunks.
 	for _, testCase := range createPartCases {
-		_, err := obj.PutObjectPart(testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
+		_, err := obj.PutObjectPart(context.Background(), testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
 		if err != nil {
 			t.Fatalf("%s : %s", instanceType, err.Error())
 		}
@@ -1307,7 +1365,7 @@ func testListObjectPartsDiskNotFound(obj ObjectLayer, instanceType string, disks
 	sha256sum := ""
 	// Iterating over creatPartCases to generate multipart chunks.
 	for _, testCase := range createPartCases {
-		_, err := obj.PutObjectPart(testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
+		_, err := obj.PutObjectPart(context.Background(), testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
 		if err != nil {
 			t.Fatalf("%s : %s", instanceType, err.Error())
 		}
@@ -1548,7 +1606,7 @@ func testListObjectParts(obj ObjectLayer, instanceType string, t TestErrHandler)
 	sha256sum := ""
 	// Iterating over creatPartCases to generate multipart chunks.
 	for _, testCase := range createPartCases {
-		_, err := obj.PutObjectPart(testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
+		_, err := obj.PutObjectPart(context.Background(), testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
 		if err != nil {
 			t.Fatalf("%s : %s", instanceType, err.Error())
 		}
@@ -1798,7 +1856,7 @@ func testObjectCompleteMultipartUpload(obj ObjectLayer, instanceType string, t T
 	sha256sum := ""
 	// Iterating over creatPartCases to generate multipart chunks.
 	for _, part := range parts {
-		_, err = obj.PutObjectPart(part.bucketName, part.objName, part.uploadID, part.PartID, mustGetHashReader(t, bytes.NewBufferString(part.inputReaderData), part.intputDataSize, part.inputMd5, sha256sum))
+		_, err = obj.PutObjectPart(context.Background(), part.bucketName, part.objName, part.uploadID, part.PartID, mustGetHashReader(t, bytes.NewBufferString(part.inputReaderData), part.intputDataSize, part.inputMd5, sha256sum))
 		if err != nil {
 			t.Fatalf("%s : %s", instanceType, err)
 		}
@@ -1901,7 +1959,7 @@ func testObjectCompleteMultipartUpload(obj ObjectLayer, instanceType string, t T
 	}
 
 	for i, testCase := range testCases {
-		actualResult, actualErr := obj.CompleteMultipartUpload(testCase.bucket, testCase.object, testCase.uploadID, testCase.parts)
+		actualResult, actualErr := obj.CompleteMultipartUpload(context.Background(), testCase.bucket, testCase.object, testCase.uploadID, testCase.parts)
 		if actualErr != nil && testCase.shouldPass {
 			t.Errorf("Test %d: %s: Expected to pass, but failed with: <ERROR> %s", i+1, instanceType, actualErr)
 		}
@@ -1925,6 +1983,63 @@ func testObjectCompleteMultipartUpload(obj ObjectLayer, instanceType string, t T
 	}
 }
 
+// Wrapper for calling the multipart ETag tests for both XL multiple disks and single node setup.
+func TestObjectMultipartETag(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectMultipartETag)
+}
+
+// Tests that objects uploaded in parts have the ETag computed by S3,
+// the md5sum of the md5sums of all parts followed by the number of
+// parts, which clients recompute to verify downloads.
+func testObjectMultipartETag(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket, object := "minio-bucket", "minio-object"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+
+	contents := [][]byte{
+		bytes.Repeat([]byte("a"), 5*humanize.MiByte),
+		bytes.Repeat([]byte("b"), 5*humanize.MiByte),
+		[]byte("c"),
+	}
+	var parts []CompletePart
+	var md5s []byte
+	for i, content := range contents {
+		sum := md5.Sum(content)
+		md5s = append(md5s, sum[:]...)
+		partInfo, err := obj.PutObjectPart(context.Background(), bucket, object, uploadID, i+1,
+			mustGetHashReader(t, bytes.NewReader(content), int64(len(content)), "", ""))
+		if err != nil {
+			t.Fatalf("%s : %s", instanceType, err)
+		}
+		if partInfo.ETag != hex.EncodeToString(sum[:]) {
+			t.Fatalf("%s: Expected the ETag of part %d to be its md5sum but found %s", instanceType, i+1, partInfo.ETag)
+		}
+		parts = append(parts, CompletePart{PartNumber: i + 1, ETag: partInfo.ETag})
+	}
+	sum := md5.Sum(md5s)
+	expectedETag := fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), len(contents))
+
+	objInfo, err := obj.CompleteMultipartUpload(context.Background(), bucket, object, uploadID, parts)
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+	if objInfo.ETag != expectedETag {
+		t.Fatalf("%s: Expected ETag %s but found %s", instanceType, expectedETag, objInfo.ETag)
+	}
+	if objInfo, err = obj.GetObjectInfo(bucket, object); err != nil || objInfo.ETag != expectedETag {
+		t.Fatalf("%s: Expected ETag %s but found %s: %v", instanceType, expectedETag, objInfo.ETag, err)
+	}
+	result, err := obj.ListObjects(bucket, "", "", "", 10)
+	if err != nil || len(result.Objects) != 1 || result.Objects[0].ETag != expectedETag {
+		t.Fatalf("%s: Expected ETag %s in listing but found %+v: %v", instanceType, expectedETag, result.Objects, err)
+	}
+}
+
 // Benchmarks for ObjectLayer.PutObjectPart().
 // The intent is to benchmark PutObjectPart for various sizes ranging from few bytes to 100MB.
 // Also each of these Benchmarks are run both XL and FS backends.
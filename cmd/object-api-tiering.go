@@ -0,0 +1,334 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"io"
+
+	miniogo "github.com/minio/minio-go"
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+func init() {
+	RegisterObjectLayerMiddleware("tiering", newTieringObjectLayer)
+}
+
+// tieringObjectLayer - hides the stubs of transitioned objects of
+// tiered buckets, their info is the info of the transitioned object
+// and their content is read from the remote tier. Calls on buckets
+// which are not tiered are passed through.
+//
+// Reads and writes of objects of tiered buckets hold the tiering lock
+// of the object, so that objects are not replaced by stubs or restored
+// while being read, and copies on the remote tier are removed with the
+// stubs they were left for.
+type tieringObjectLayer struct {
+	ObjectLayer
+}
+
+func newTieringObjectLayer(objAPI ObjectLayer) ObjectLayer {
+	return &tieringObjectLayer{objAPI}
+}
+
+// Returns the tiering config of a bucket, false if it is not tiered.
+func getBucketTiering(bucket string) (tieringConfig, bool) {
+	if globalBucketTiering == nil {
+		return tieringConfig{}, false
+	}
+	return globalBucketTiering.Get(bucket)
+}
+
+// getTierStub - returns the info of an object and true if it is a stub.
+func (l *tieringObjectLayer) getTierStub(bucket, object string) (ObjectInfo, bool) {
+	objInfo, err := l.ObjectLayer.GetObjectInfo(bucket, object)
+	if err != nil {
+		return objInfo, false
+	}
+	return objInfo, isTierStub(objInfo.UserDefined)
+}
+
+// getTieredObject - reads length bytes at startOffset of the object a
+// stub was left for from the remote tier, the whole object is read
+// from startOffset if length is negative.
+func getTieredObject(cfg tieringConfig, stubInfo ObjectInfo, startOffset, length int64, writer io.Writer) error {
+	size := getTierStubInfo(stubInfo).Size
+	if length < 0 {
+		length = size - startOffset
+	}
+	if startOffset < 0 || length < 0 || startOffset+length > size {
+		return errors.Trace(InvalidRange{startOffset, length, size})
+	}
+	if length == 0 {
+		return nil
+	}
+
+	client, err := newTieringClient(cfg)
+	if err != nil {
+		return err
+	}
+	opts := miniogo.GetObjectOptions{}
+	if err = opts.SetRange(startOffset, startOffset+length-1); err != nil {
+		return errors.Trace(err)
+	}
+	reader, _, err := miniogo.Core{Client: client}.GetObject(cfg.TargetBucket, stubInfo.UserDefined[tierRemoteKey], opts)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer reader.Close()
+	if _, err = io.CopyN(writer, reader, length); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// replaceObject - replaces an object of a tiered bucket by calling fn
+// with the tiering lock of the object held, the copy of the replaced
+// object is removed from the remote tier unless the new object still
+// refers to it.
+func (l *tieringObjectLayer) replaceObject(cfg tieringConfig, bucket, object string, fn func() (ObjectInfo, error)) (ObjectInfo, error) {
+	objectLock := newTierObjectLock(bucket, object)
+	if err := objectLock.GetLock(globalObjectTimeout); err != nil {
+		return ObjectInfo{}, err
+	}
+	defer objectLock.Unlock()
+
+	stubInfo, isStub := l.getTierStub(bucket, object)
+	objInfo, err := fn()
+	if err != nil {
+		return objInfo, err
+	}
+	remoteKey := stubInfo.UserDefined[tierRemoteKey]
+	if isStub && objInfo.UserDefined[tierRemoteKey] != remoteKey {
+		client, cerr := newTieringClient(cfg)
+		if cerr != nil {
+			errorIf(cerr, "Unable to remove %s from tiering target bucket %s.", remoteKey, cfg.TargetBucket)
+		} else {
+			removeTieredCopy(client, cfg, remoteKey)
+		}
+	}
+	if isTierStub(objInfo.UserDefined) {
+		objInfo = getTierStubInfo(objInfo)
+	}
+	return objInfo, nil
+}
+
+// ListObjects - lists objects, stubs are listed as the objects they
+// were left for.
+func (l *tieringObjectLayer) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	result, err := l.ObjectLayer.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err != nil {
+		return result, err
+	}
+	if _, ok := getBucketTiering(bucket); ok {
+		for i, objInfo := range result.Objects {
+			if isTierStub(objInfo.UserDefined) {
+				result.Objects[i] = getTierStubInfo(objInfo)
+			}
+		}
+	}
+	return result, nil
+}
+
+// ListObjectsV2 - lists objects, stubs are listed as the objects they
+// were left for.
+func (l *tieringObjectLayer) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, fetchOwner bool, startAfter string) (ListObjectsV2Info, error) {
+	result, err := l.ObjectLayer.ListObjectsV2(bucket, prefix, continuationToken, delimiter, maxKeys, fetchOwner, startAfter)
+	if err != nil {
+		return result, err
+	}
+	if _, ok := getBucketTiering(bucket); ok {
+		for i, objInfo := range result.Objects {
+			if isTierStub(objInfo.UserDefined) {
+				result.Objects[i] = getTierStubInfo(objInfo)
+			}
+		}
+	}
+	return result, nil
+}
+
+// GetObjectInfo - returns the info of an object, or of the object a
+// stub was left for.
+func (l *tieringObjectLayer) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
+	objInfo, err := l.ObjectLayer.GetObjectInfo(bucket, object)
+	if err != nil {
+		return objInfo, err
+	}
+	if _, ok := getBucketTiering(bucket); ok && isTierStub(objInfo.UserDefined) {
+		objInfo = getTierStubInfo(objInfo)
+	}
+	return objInfo, nil
+}
+
+// GetObject - reads an object, transitioned objects are read from the
+// remote tier.
+func (l *tieringObjectLayer) GetObject(bucket, object string, startOffset, length int64, writer io.Writer, etag string) error {
+	cfg, ok := getBucketTiering(bucket)
+	if !ok {
+		return l.ObjectLayer.GetObject(bucket, object, startOffset, length, writer, etag)
+	}
+
+	objectLock := newTierObjectLock(bucket, object)
+	if err := objectLock.GetRLock(globalObjectTimeout); err != nil {
+		return err
+	}
+	defer objectLock.RUnlock()
+
+	stubInfo, isStub := l.getTierStub(bucket, object)
+	if !isStub {
+		return l.ObjectLayer.GetObject(bucket, object, startOffset, length, writer, etag)
+	}
+	if etag != "" && etag != getTierStubInfo(stubInfo).ETag {
+		return toObjectErr(errors.Trace(InvalidETag{}), bucket, object)
+	}
+	return getTieredObject(cfg, stubInfo, startOffset, length, writer)
+}
+
+// PutObject - creates an object, the copy of a transitioned object it
+// replaces is removed from the remote tier.
+func (l *tieringObjectLayer) PutObject(bucket, object string, data *hash.Reader, metadata map[string]string) (ObjectInfo, error) {
+	cfg, ok := getBucketTiering(bucket)
+	if !ok {
+		return l.ObjectLayer.PutObject(bucket, object, data, metadata)
+	}
+	return l.replaceObject(cfg, bucket, object, func() (ObjectInfo, error) {
+		return l.ObjectLayer.PutObject(bucket, object, data, metadata)
+	})
+}
+
+// CopyObject - copies an object, transitioned objects are read from the
+// remote tier. Metadata of a transitioned object is updated in its stub.
+func (l *tieringObjectLayer) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, srcInfo ObjectInfo) (ObjectInfo, error) {
+	srcCfg, srcTiered := getBucketTiering(srcBucket)
+	dstCfg, dstTiered := getBucketTiering(dstBucket)
+	if !srcTiered && !dstTiered {
+		return l.ObjectLayer.CopyObject(srcBucket, srcObject, dstBucket, dstObject, srcInfo)
+	}
+	cpSrcDstSame := isStringEqual(pathJoin(srcBucket, srcObject), pathJoin(dstBucket, dstObject))
+
+	copyObject := func() (ObjectInfo, error) {
+		if !srcTiered {
+			return l.ObjectLayer.CopyObject(srcBucket, srcObject, dstBucket, dstObject, srcInfo)
+		}
+		if !cpSrcDstSame {
+			objectLock := newTierObjectLock(srcBucket, srcObject)
+			if err := objectLock.GetRLock(globalObjectTimeout); err != nil {
+				return ObjectInfo{}, err
+			}
+			defer objectLock.RUnlock()
+		}
+		stubInfo, isStub := l.getTierStub(srcBucket, srcObject)
+		if !isStub {
+			return l.ObjectLayer.CopyObject(srcBucket, srcObject, dstBucket, dstObject, srcInfo)
+		}
+		if cpSrcDstSame && srcInfo.metadataOnly {
+			metadata := make(map[string]string, len(srcInfo.UserDefined)+4)
+			for k, v := range srcInfo.UserDefined {
+				metadata[k] = v
+			}
+			setTierStub(metadata, getTierStubInfo(stubInfo), stubInfo.UserDefined[tierRemoteKey])
+			srcInfo.UserDefined = metadata
+			return l.ObjectLayer.CopyObject(srcBucket, srcObject, dstBucket, dstObject, srcInfo)
+		}
+
+		// Content is read through srcInfo.Writer and written from
+		// srcInfo.Reader, as by the object layer.
+		go func() {
+			if gerr := getTieredObject(srcCfg, stubInfo, 0, srcInfo.Size, srcInfo.Writer); gerr != nil {
+				errorIf(gerr, "Unable to read the object %s/%s.", srcBucket, srcObject)
+			}
+			srcInfo.Writer.Close()
+		}()
+		return l.ObjectLayer.PutObject(dstBucket, dstObject, srcInfo.Reader, srcInfo.UserDefined)
+	}
+
+	if !dstTiered {
+		return copyObject()
+	}
+	return l.replaceObject(dstCfg, dstBucket, dstObject, copyObject)
+}
+
+// CopyObjectPart - copies a part of an object, transitioned objects are
+// read from the remote tier.
+func (l *tieringObjectLayer) CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject, uploadID string, partID int,
+	startOffset int64, length int64, srcInfo ObjectInfo) (PartInfo, error) {
+	cfg, ok := getBucketTiering(srcBucket)
+	if !ok {
+		return l.ObjectLayer.CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject, uploadID, partID, startOffset, length, srcInfo)
+	}
+
+	objectLock := newTierObjectLock(srcBucket, srcObject)
+	if err := objectLock.GetRLock(globalObjectTimeout); err != nil {
+		return PartInfo{}, err
+	}
+	defer objectLock.RUnlock()
+
+	stubInfo, isStub := l.getTierStub(srcBucket, srcObject)
+	if !isStub {
+		return l.ObjectLayer.CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject, uploadID, partID, startOffset, length, srcInfo)
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(getTieredObject(cfg, stubInfo, startOffset, length, pipeWriter))
+	}()
+	defer pipeReader.Close()
+	hashReader, err := hash.NewReader(pipeReader, length, "", "")
+	if err != nil {
+		return PartInfo{}, toObjectErr(err, dstBucket, dstObject)
+	}
+	return l.ObjectLayer.PutObjectPart(context.Background(), dstBucket, dstObject, uploadID, partID, hashReader)
+}
+
+// DeleteObject - deletes an object, the copy of a transitioned object
+// is removed from the remote tier.
+func (l *tieringObjectLayer) DeleteObject(bucket, object string) error {
+	cfg, ok := getBucketTiering(bucket)
+	if !ok {
+		return l.ObjectLayer.DeleteObject(bucket, object)
+	}
+	_, err := l.replaceObject(cfg, bucket, object, func() (ObjectInfo, error) {
+		return ObjectInfo{}, l.ObjectLayer.DeleteObject(bucket, object)
+	})
+	return err
+}
+
+// DeleteObjects - deletes objects one by one in tiered buckets, copies
+// of transitioned objects are removed from the remote tier.
+func (l *tieringObjectLayer) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	if _, ok := getBucketTiering(bucket); !ok {
+		return l.ObjectLayer.DeleteObjects(bucket, objects)
+	}
+	errs := make([]error, len(objects))
+	for i, object := range objects {
+		errs[i] = l.DeleteObject(bucket, object)
+	}
+	return errs, nil
+}
+
+// CompleteMultipartUpload - completes an upload, the copy of a
+// transitioned object it replaces is removed from the remote tier.
+func (l *tieringObjectLayer) CompleteMultipartUpload(ctx context.Context, bucket, object, uploadID string, uploadedParts []CompletePart) (ObjectInfo, error) {
+	cfg, ok := getBucketTiering(bucket)
+	if !ok {
+		return l.ObjectLayer.CompleteMultipartUpload(ctx, bucket, object, uploadID, uploadedParts)
+	}
+	return l.replaceObject(cfg, bucket, object, func() (ObjectInfo, error) {
+		return l.ObjectLayer.CompleteMultipartUpload(ctx, bucket, object, uploadID, uploadedParts)
+	})
+}
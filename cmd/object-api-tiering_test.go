@@ -0,0 +1,253 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/minio/dsync"
+	"github.com/minio/minio/pkg/errors"
+)
+
+// Tests reading and changing moved objects through the tiering object
+// layer.
+func TestTieringObjectLayer(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	initNSLock(false)
+
+	target, cfg := prepareTieringTarget(t)
+	defer target.Stop()
+
+	obj, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello, world")
+	for _, object := range []string{"archive/a", "archive/b", "archive/c"} {
+		if _, err = obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""),
+			map[string]string{"X-Amz-Meta-Color": "blue"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = saveTieringConfig(bucket, cfg, obj); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(tiering *bucketTiering) {
+		globalBucketTiering = tiering
+	}(globalBucketTiering)
+	globalBucketTiering = newBucketTiering()
+	if err = globalBucketTiering.Init(obj); err != nil {
+		t.Fatal(err)
+	}
+	globalBucketTiering.transition(obj, UTCNow().AddDate(0, 0, 2))
+	if n := countTieredObjects(t, target); n != 3 {
+		t.Fatalf("Expected 3 tiered objects, got %d", n)
+	}
+
+	tiered := newTieringObjectLayer(obj)
+	objInfo, err := tiered.GetObjectInfo(bucket, "archive/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Size != int64(len(data)) || isTierStub(objInfo.UserDefined) {
+		t.Fatalf("Expected info of the moved object, got %d bytes with %v", objInfo.Size, objInfo.UserDefined)
+	}
+	result, err := tiered.ListObjects(bucket, "archive/", "", "", maxObjectList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 3 || result.Objects[0].Size != int64(len(data)) || result.Objects[0].ETag != objInfo.ETag {
+		t.Fatalf("Expected moved objects to be listed, got %+v", result.Objects)
+	}
+
+	var buffer bytes.Buffer
+	if err = tiered.GetObject(bucket, "archive/a", 7, -1, &buffer, objInfo.ETag); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != "world" {
+		t.Fatalf("Expected %q, got %q", "world", buffer.String())
+	}
+	if err = tiered.GetObject(bucket, "archive/a", 0, -1, &buffer, "wrong-etag"); errors.Cause(err) != (InvalidETag{}) {
+		t.Fatalf("Expected invalid ETag, got %v", err)
+	}
+
+	// Metadata updates are applied to the stub.
+	objInfo.UserDefined["X-Amz-Meta-Color"] = "red"
+	objInfo.metadataOnly = true
+	if _, err = tiered.CopyObject(bucket, "archive/a", bucket, "archive/a", objInfo); err != nil {
+		t.Fatal(err)
+	}
+	stubInfo, err := obj.GetObjectInfo(bucket, "archive/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isTierStub(stubInfo.UserDefined) || stubInfo.UserDefined["X-Amz-Meta-Color"] != "red" {
+		t.Fatalf("Expected metadata of the stub to be updated, got %v", stubInfo.UserDefined)
+	}
+
+	// Copies read the content from the remote tier.
+	srcInfo, err := tiered.GetObjectInfo(bucket, "archive/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	srcInfo.Writer = pipeWriter
+	srcInfo.Reader = mustGetHashReader(t, pipeReader, srcInfo.Size, "", "")
+	if _, err = tiered.CopyObject(bucket, "archive/b", bucket, "copy", srcInfo); err != nil {
+		t.Fatal(err)
+	}
+	buffer.Reset()
+	if err = obj.GetObject(bucket, "copy", 0, -1, &buffer, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("Expected %q to be copied, got %q", data, buffer.Bytes())
+	}
+
+	// Copies on the remote tier are removed with their stubs.
+	if _, err = tiered.PutObject(bucket, "archive/b", mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = tiered.DeleteObject(bucket, "archive/c"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countTieredObjects(t, target); n != 1 {
+		t.Fatalf("Expected 1 tiered object, got %d", n)
+	}
+}
+
+// Tests writing objects of a tiered bucket in distributed XL, where the
+// locks of the tiering layer and of the object layer are taken from the
+// same lockers.
+func TestTieringObjectLayerDistXL(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	target, cfg := prepareTieringTarget(t)
+	defer target.Stop()
+
+	obj, fsDirs, err := prepareXL16()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	// Lock through dsync on four lockers like distributed servers do,
+	// conflicting locks time out after a second.
+	defer func(ds *dsync.Dsync, isDistXL bool, timeout *dynamicTimeout) {
+		globalDsync, globalIsDistXL, globalObjectTimeout = ds, isDistXL, timeout
+		initNSLock(isDistXL)
+	}(globalDsync, globalIsDistXL, globalObjectTimeout)
+	lockers := make([]dsync.NetLocker, 4)
+	for i := range lockers {
+		lockers[i] = &localLocker{
+			serverAddr:      fmt.Sprintf("server%d:9000", i+1),
+			serviceEndpoint: lockServicePath,
+			lockMap:         make(map[string][]lockRequesterInfo),
+		}
+	}
+	if globalDsync, err = dsync.New(lockers, 0); err != nil {
+		t.Fatal(err)
+	}
+	globalIsDistXL = true
+	globalObjectTimeout = newDynamicTimeout(time.Second, time.Second)
+	initNSLock(true)
+	obj.(*xlObjects).nsMutex = newNSLock(true)
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello, world")
+	for _, object := range []string{"archive/a", "archive/b"} {
+		if _, err = obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = saveTieringConfig(bucket, cfg, obj); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(tiering *bucketTiering) {
+		globalBucketTiering = tiering
+	}(globalBucketTiering)
+	globalBucketTiering = newBucketTiering()
+	if err = globalBucketTiering.Init(obj); err != nil {
+		t.Fatal(err)
+	}
+	globalBucketTiering.transition(obj, UTCNow().AddDate(0, 0, 2))
+	if n := countTieredObjects(t, target); n != 2 {
+		t.Fatalf("Expected 2 tiered objects, got %d", n)
+	}
+
+	tiered := newTieringObjectLayer(obj)
+	if _, err = tiered.PutObject(bucket, "archive/a", mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	srcInfo, err := tiered.GetObjectInfo(bucket, "archive/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	srcInfo.Writer = pipeWriter
+	srcInfo.Reader = mustGetHashReader(t, pipeReader, srcInfo.Size, "", "")
+	if _, err = tiered.CopyObject(bucket, "archive/b", bucket, "copy", srcInfo); err != nil {
+		t.Fatal(err)
+	}
+	if err = tiered.DeleteObject(bucket, "copy"); err != nil {
+		t.Fatal(err)
+	}
+
+	uploadID, err := tiered.NewMultipartUpload(bucket, "archive/b", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partInfo, err := tiered.PutObjectPart(context.Background(), bucket, "archive/b", uploadID, 1,
+		mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tiered.CompleteMultipartUpload(context.Background(), bucket, "archive/b", uploadID,
+		[]CompletePart{{PartNumber: 1, ETag: partInfo.ETag}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := countTieredObjects(t, target); n != 0 {
+		t.Fatalf("Expected no tiered objects, got %d", n)
+	}
+}
@@ -17,8 +17,10 @@
 package cmd
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"path"
 	"runtime"
 	"strings"
@@ -281,3 +283,28 @@ type byBucketName []BucketInfo
 func (d byBucketName) Len() int           { return len(d) }
 func (d byBucketName) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
 func (d byBucketName) Less(i, j int) bool { return d[i].Name < d[j].Name }
+
+// checkContext - returns errClientDisconnected once the client sending
+// the request with context ctx disconnected.
+func checkContext(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return errors.Trace(errClientDisconnected)
+	default:
+		return nil
+	}
+}
+
+// contextReader - fails reads once the client sending the request with
+// context ctx disconnected.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := checkContext(r.ctx); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
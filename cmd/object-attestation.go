@@ -0,0 +1,462 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	// Signed manifests are stored under this prefix of minioMetaBucket.
+	attestationsPrefix = "attestations"
+
+	// Current version of object manifests.
+	objectManifestVersion = "1"
+
+	// Signature algorithm of object manifests.
+	attestationAlgorithm = "Ed25519"
+
+	// Attestation keys, persisted under minioMetaBucket.
+	attestationKeysPath = "config/attestation-keys.json"
+
+	// Attestation keys are cached for this long, keys rotated by
+	// another server sign new manifests within as long.
+	attestationKeysCacheTTL = time.Minute
+)
+
+// objectManifestPart - checksum of a single part of a multipart object.
+type objectManifestPart struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// objectManifest - checksum and metadata of an object at commit time.
+type objectManifest struct {
+	Version         string               `json:"version"`
+	Bucket          string               `json:"bucket"`
+	Object          string               `json:"object"`
+	ETag            string               `json:"etag"`
+	Size            int64                `json:"size"`
+	ContentType     string               `json:"contentType,omitempty"`
+	ContentEncoding string               `json:"contentEncoding,omitempty"`
+	ModTime         time.Time            `json:"lastModified"`
+	Metadata        map[string]string    `json:"metadata,omitempty"`
+	Parts           []objectManifestPart `json:"parts,omitempty"`
+	SignedAt        time.Time            `json:"signedAt"`
+}
+
+// newObjectManifest - returns the manifest of an object.
+func newObjectManifest(objInfo ObjectInfo) objectManifest {
+	m := objectManifest{
+		Version:         objectManifestVersion,
+		Bucket:          objInfo.Bucket,
+		Object:          objInfo.Name,
+		ETag:            objInfo.ETag,
+		Size:            objInfo.Size,
+		ContentType:     objInfo.ContentType,
+		ContentEncoding: objInfo.ContentEncoding,
+		ModTime:         objInfo.ModTime.UTC(),
+	}
+	for k, v := range objInfo.UserDefined {
+		// Internal metadata like sealed encryption keys or the
+		// quarantine status is not part of the provenance.
+		if hasPrefix(k, ReservedMetadataPrefix) {
+			continue
+		}
+		if m.Metadata == nil {
+			m.Metadata = make(map[string]string)
+		}
+		m.Metadata[k] = v
+	}
+	for _, part := range objInfo.Parts {
+		m.Parts = append(m.Parts, objectManifestPart{part.Number, part.ETag, part.Size})
+	}
+	return m
+}
+
+// matches - returns true if both manifests describe the same object
+// content and metadata, the signing time is ignored.
+func (m objectManifest) matches(other objectManifest) bool {
+	m.SignedAt, other.SignedAt = time.Time{}, time.Time{}
+	b1, err1 := json.Marshal(m)
+	b2, err2 := json.Marshal(other)
+	return err1 == nil && err2 == nil && bytes.Equal(b1, b2)
+}
+
+// SignedObjectManifest - manifest of an object signed with the
+// attestation key of the server. Manifest holds the base64 encoded
+// JSON manifest exactly as signed, KeyID the ID of the signing key.
+type SignedObjectManifest struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"keyId"`
+	PublicKey string `json:"publicKey"`
+	Manifest  string `json:"manifest"`
+	Signature string `json:"signature"`
+}
+
+// AttestationKey - public key verifying signed object manifests, the
+// current key signs new manifests.
+type AttestationKey struct {
+	KeyID     string    `json:"keyId"`
+	Algorithm string    `json:"algorithm"`
+	PublicKey string    `json:"publicKey"`
+	Created   time.Time `json:"created"`
+	Current   bool      `json:"current"`
+}
+
+// attestationKey - persisted attestation key, the private key of
+// rotated keys is removed, their public key still verifies manifests
+// signed before.
+type attestationKey struct {
+	ID         string             `json:"id"`
+	PublicKey  ed25519.PublicKey  `json:"publicKey"`
+	PrivateKey ed25519.PrivateKey `json:"privateKey,omitempty"`
+	Created    time.Time          `json:"created"`
+}
+
+// attestationKeys - all attestation keys of a deployment.
+type attestationKeys struct {
+	Current string           `json:"current"`
+	Keys    []attestationKey `json:"keys"`
+}
+
+// Returns the key with the ID.
+func (k attestationKeys) get(id string) (attestationKey, bool) {
+	for _, key := range k.Keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return attestationKey{}, false
+}
+
+// Returns the ID of a public key, a prefix of its hash.
+func getAttestationKeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Returns a new attestation key.
+func newAttestationKey() (attestationKey, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return attestationKey{}, errors.Trace(err)
+	}
+	return attestationKey{
+		ID:         getAttestationKeyID(publicKey),
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+		Created:    UTCNow(),
+	}, nil
+}
+
+// Loads the attestation keys, none if no manifest was signed yet.
+func loadAttestationKeys(objAPI ObjectLayer) (attestationKeys, error) {
+	var k attestationKeys
+	_, err := loadManagedPolicyJSON(objAPI, attestationKeysPath, &k)
+	return k, err
+}
+
+// updateAttestationKeys - adds a new current key if rotate is set or
+// if there is no current key yet, removes the private key of the old
+// one and saves the keys.
+func updateAttestationKeys(objAPI ObjectLayer, rotate bool) (attestationKeys, error) {
+	keysLock := globalNSMutex.NewNSLock(minioMetaBucket, attestationKeysPath)
+	if err := keysLock.GetLock(globalOperationTimeout); err != nil {
+		return attestationKeys{}, err
+	}
+	defer keysLock.Unlock()
+
+	k, err := loadAttestationKeys(objAPI)
+	if err != nil {
+		return k, err
+	}
+	if k.Current != "" && !rotate {
+		// Created by another server meanwhile.
+		return k, nil
+	}
+
+	key, err := newAttestationKey()
+	if err != nil {
+		return k, err
+	}
+	for i := range k.Keys {
+		k.Keys[i].PrivateKey = nil
+	}
+	k.Keys = append(k.Keys, key)
+	k.Current = key.ID
+	err = saveManagedPolicyJSON(objAPI, attestationKeysPath, k)
+	return k, err
+}
+
+// rotateAttestationKey - signs new manifests with a new key, manifests
+// signed before still verify with the public key of the old one.
+func rotateAttestationKey(objAPI ObjectLayer) error {
+	k, err := updateAttestationKeys(objAPI, true)
+	if err != nil {
+		return err
+	}
+	globalAttestationKeys.set(k)
+	return nil
+}
+
+// attestationKeyCache - attestation keys, reloaded once they are cached
+// for longer than attestationKeysCacheTTL.
+type attestationKeyCache struct {
+	mu     sync.Mutex
+	keys   attestationKeys
+	loaded time.Time
+}
+
+func newAttestationKeyCache() *attestationKeyCache {
+	return &attestationKeyCache{}
+}
+
+func (c *attestationKeyCache) set(k attestationKeys) {
+	c.mu.Lock()
+	c.keys = k
+	c.loaded = UTCNow()
+	c.mu.Unlock()
+}
+
+// get - returns the attestation keys, reloading them if expired or if
+// reload is set, e.g. for a manifest signed with a key rotated by
+// another server. The first key is created if there is none yet.
+func (c *attestationKeyCache) get(objAPI ObjectLayer, reload bool) (attestationKeys, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys.Current != "" && !reload && UTCNow().Sub(c.loaded) < attestationKeysCacheTTL {
+		return c.keys, nil
+	}
+	k, err := loadAttestationKeys(objAPI)
+	if err != nil {
+		return c.keys, err
+	}
+	if k.Current == "" {
+		if k, err = updateAttestationKeys(objAPI, false); err != nil {
+			return c.keys, err
+		}
+	}
+	c.keys = k
+	c.loaded = UTCNow()
+	return k, nil
+}
+
+// getAttestationPublicKeys - returns the public keys verifying
+// manifests, the current key included.
+func getAttestationPublicKeys(objAPI ObjectLayer) ([]AttestationKey, error) {
+	k, err := globalAttestationKeys.get(objAPI, false)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]AttestationKey, 0, len(k.Keys))
+	for _, key := range k.Keys {
+		keys = append(keys, AttestationKey{
+			KeyID:     key.ID,
+			Algorithm: attestationAlgorithm,
+			PublicKey: base64.StdEncoding.EncodeToString(key.PublicKey),
+			Created:   key.Created,
+			Current:   key.ID == k.Current,
+		})
+	}
+	return keys, nil
+}
+
+// signAttestationPayload - signs the payload with the current
+// attestation key, returns the ID of the key and the signature.
+func signAttestationPayload(objAPI ObjectLayer, payload []byte) (attestationKey, []byte, error) {
+	k, err := globalAttestationKeys.get(objAPI, false)
+	if err != nil {
+		return attestationKey{}, nil, err
+	}
+	key, ok := k.get(k.Current)
+	if !ok || len(key.PrivateKey) != ed25519.PrivateKeySize {
+		return attestationKey{}, nil, errors.Trace(errAttestationInvalid)
+	}
+	return key, ed25519.Sign(key.PrivateKey, payload), nil
+}
+
+// verifyAttestationPayload - verifies the signature of a payload with
+// the attestation key of the ID, returns the key. Payloads signed with
+// a key not known to the server are invalid.
+func verifyAttestationPayload(objAPI ObjectLayer, keyID string, payload, signature []byte) (attestationKey, error) {
+	k, err := globalAttestationKeys.get(objAPI, false)
+	if err != nil {
+		return attestationKey{}, err
+	}
+	key, ok := k.get(keyID)
+	if !ok {
+		// Possibly rotated by another server since the keys were
+		// loaded.
+		if k, err = globalAttestationKeys.get(objAPI, true); err != nil {
+			return attestationKey{}, err
+		}
+		if key, ok = k.get(keyID); !ok {
+			return attestationKey{}, errAttestationInvalid
+		}
+	}
+	if !ed25519.Verify(key.PublicKey, payload, signature) {
+		return attestationKey{}, errAttestationInvalid
+	}
+	return key, nil
+}
+
+// signObjectManifest - signs the manifest with the current attestation
+// key.
+func signObjectManifest(objAPI ObjectLayer, m objectManifest) (SignedObjectManifest, error) {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return SignedObjectManifest{}, errors.Trace(err)
+	}
+	key, signature, err := signAttestationPayload(objAPI, payload)
+	if err != nil {
+		return SignedObjectManifest{}, err
+	}
+	return SignedObjectManifest{
+		Algorithm: attestationAlgorithm,
+		KeyID:     key.ID,
+		PublicKey: base64.StdEncoding.EncodeToString(key.PublicKey),
+		Manifest:  base64.StdEncoding.EncodeToString(payload),
+		Signature: base64.StdEncoding.EncodeToString(signature),
+	}, nil
+}
+
+// verifyObjectManifest - verifies the signature of a manifest with the
+// attestation key of its key ID and returns its content. The public key
+// embedded in the manifest is not trusted, manifests signed with a key
+// not known to the server are invalid.
+func verifyObjectManifest(objAPI ObjectLayer, signed SignedObjectManifest) (objectManifest, error) {
+	var m objectManifest
+	if signed.Algorithm != attestationAlgorithm {
+		return m, errAttestationInvalid
+	}
+	payload, err := base64.StdEncoding.DecodeString(signed.Manifest)
+	if err != nil {
+		return m, errAttestationInvalid
+	}
+	signature, err := base64.StdEncoding.DecodeString(signed.Signature)
+	if err != nil {
+		return m, errAttestationInvalid
+	}
+	key, err := verifyAttestationPayload(objAPI, signed.KeyID, payload, signature)
+	if err != nil {
+		return m, err
+	}
+	if signed.PublicKey != base64.StdEncoding.EncodeToString(key.PublicKey) {
+		return m, errAttestationInvalid
+	}
+	if err = json.Unmarshal(payload, &m); err != nil {
+		return m, errAttestationInvalid
+	}
+	return m, nil
+}
+
+// Returns the path of the manifest of an object, object names are
+// hashed to keep the namespace flat.
+func getObjectAttestationPath(bucket, object string) string {
+	return path.Join(attestationsPrefix, bucket, getSHA256Hash([]byte(object))+".json")
+}
+
+// attestObject - signs and saves the manifest of a committed object,
+// does nothing unless attestation is enabled.
+func attestObject(objAPI ObjectLayer, bucket, object, etag string) error {
+	if !globalIsObjectAttestation {
+		return nil
+	}
+
+	// Object info returned by writes is not exactly what is read
+	// back later, sign what readers see instead.
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return err
+	}
+	if objInfo.ETag != etag {
+		// Overwritten meanwhile, attested by the other upload.
+		return nil
+	}
+
+	m := newObjectManifest(objInfo)
+	m.SignedAt = UTCNow()
+	signed, err := signObjectManifest(objAPI, m)
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(signed)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	hashReader, err := hash.NewReader(bytes.NewReader(buf), int64(len(buf)), "", getSHA256Hash(buf))
+	if err != nil {
+		return err
+	}
+	_, err = objAPI.PutObject(minioMetaBucket, getObjectAttestationPath(objInfo.Bucket, objInfo.Name), hashReader, nil)
+	return err
+}
+
+// getObjectAttestation - returns the signed manifest of an object, a
+// manifest not matching the current object is not returned.
+func getObjectAttestation(objAPI ObjectLayer, objInfo ObjectInfo) (SignedObjectManifest, error) {
+	var signed SignedObjectManifest
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, getObjectAttestationPath(objInfo.Bucket, objInfo.Name), 0, -1, &buffer, "")
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			return signed, errNoSuchAttestation
+		}
+		return signed, err
+	}
+	if err = json.Unmarshal(buffer.Bytes(), &signed); err != nil {
+		return signed, errors.Trace(err)
+	}
+
+	m, err := verifyObjectManifest(objAPI, signed)
+	if err != nil {
+		return signed, err
+	}
+	// Manifests of overwritten or modified objects are stale.
+	if !m.matches(newObjectManifest(objInfo)) {
+		return signed, errNoSuchAttestation
+	}
+	return signed, nil
+}
+
+// removeObjectAttestation - removes the manifest of a deleted object.
+func removeObjectAttestation(objAPI ObjectLayer, bucket, object string) {
+	if !globalIsObjectAttestation {
+		return
+	}
+	err := objAPI.DeleteObject(minioMetaBucket, getObjectAttestationPath(bucket, object))
+	if err != nil && !isErrObjectNotFound(err) {
+		errorIf(err, "Unable to remove manifest of %s/%s.", bucket, object)
+	}
+}
@@ -0,0 +1,208 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/minio/minio/pkg/auth"
+	"github.com/minio/minio/pkg/errors"
+	"golang.org/x/crypto/ed25519"
+)
+
+// Tests that signed manifests verify and tampering is detected.
+func TestSignObjectManifest(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer os.RemoveAll(rootPath)
+
+	initNSLock(false)
+	obj, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+	globalAttestationKeys = newAttestationKeyCache()
+	defer func() { globalAttestationKeys = newAttestationKeyCache() }()
+
+	m := newObjectManifest(ObjectInfo{
+		Bucket:      "bucket",
+		Name:        "object",
+		ETag:        "d41d8cd98f00b204e9800998ecf8427e",
+		ContentType: "application/octet-stream",
+		ModTime:     UTCNow(),
+		UserDefined: map[string]string{
+			"X-Amz-Meta-Study":     "trial-42",
+			ObjectQuarantineStatus: quarantinePending,
+		},
+	})
+	if len(m.Metadata) != 1 {
+		t.Fatalf("Expected internal metadata to be excluded, got %v", m.Metadata)
+	}
+	m.SignedAt = UTCNow()
+
+	signed, err := signObjectManifest(obj, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := getAttestationPublicKeys(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || !keys[0].Current || signed.KeyID != keys[0].KeyID || signed.PublicKey != keys[0].PublicKey {
+		t.Fatalf("Expected manifest to be signed with the attestation key, got %#v, keys %#v", signed, keys)
+	}
+	verified, err := verifyObjectManifest(obj, signed)
+	if err != nil {
+		t.Fatalf("Unable to verify manifest: %v", err)
+	}
+	if !verified.matches(m) {
+		t.Fatalf("Expected %#v, got %#v", m, verified)
+	}
+
+	// Tampered manifest.
+	tampered := signed
+	payload, _ := base64.StdEncoding.DecodeString(signed.Manifest)
+	tampered.Manifest = base64.StdEncoding.EncodeToString(bytes.Replace(payload, []byte("trial-42"), []byte("trial-43"), 1))
+	if _, err = verifyObjectManifest(obj, tampered); err != errAttestationInvalid {
+		t.Fatalf("Expected %v, got %v", errAttestationInvalid, err)
+	}
+
+	// Manifests signed with another key, embedding it, are invalid.
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := signed
+	forged.Manifest = base64.StdEncoding.EncodeToString(payload)
+	forged.PublicKey = base64.StdEncoding.EncodeToString(publicKey)
+	forged.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, payload))
+	if _, err = verifyObjectManifest(obj, forged); err != errAttestationInvalid {
+		t.Fatalf("Expected %v, got %v", errAttestationInvalid, err)
+	}
+	forged.KeyID = getAttestationKeyID(publicKey)
+	if _, err = verifyObjectManifest(obj, forged); err != errAttestationInvalid {
+		t.Fatalf("Expected %v, got %v", errAttestationInvalid, err)
+	}
+
+	// The key is persisted, changing the credentials or reloading the
+	// keys does not invalidate manifests.
+	globalServerConfig.SetCredential(auth.MustGetNewCredentials())
+	globalAttestationKeys = newAttestationKeyCache()
+	if _, err = verifyObjectManifest(obj, signed); err != nil {
+		t.Fatalf("Expected manifest to verify after the credentials changed, got %v", err)
+	}
+
+	// Manifests signed before the key was rotated still verify, new
+	// manifests are signed with the new key.
+	if err = rotateAttestationKey(obj); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = verifyObjectManifest(obj, signed); err != nil {
+		t.Fatalf("Expected manifest to verify after the key was rotated, got %v", err)
+	}
+	rotated, err := signObjectManifest(obj, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.KeyID == signed.KeyID {
+		t.Fatalf("Expected manifest to be signed with the new key")
+	}
+	if _, err = verifyObjectManifest(obj, rotated); err != nil {
+		t.Fatalf("Unable to verify manifest: %v", err)
+	}
+	k, err := loadAttestationKeys(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old, ok := k.get(signed.KeyID); !ok || len(old.PrivateKey) != 0 || k.Current != rotated.KeyID {
+		t.Fatalf("Expected the private key of the rotated key to be removed, got %#v", k)
+	}
+
+	// Keys rotated by another server are picked up for verification.
+	stale := newAttestationKeyCache()
+	stale.set(attestationKeys{Current: signed.KeyID, Keys: k.Keys[:1]})
+	globalAttestationKeys = stale
+	if _, err = verifyObjectManifest(obj, rotated); err != nil {
+		t.Fatalf("Expected manifest signed with a key rotated by another server to verify, got %v", err)
+	}
+}
+
+// Wrapper for calling attestation tests for both XL multiple disks and single node setup.
+func TestObjectAttestation(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectAttestation)
+}
+
+// Tests that manifests are saved at commit time and only returned
+// while they match the object.
+func testObjectAttestation(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	globalIsObjectAttestation = true
+	defer func() { globalIsObjectAttestation = false }()
+	globalAttestationKeys = newAttestationKeyCache()
+	defer func() { globalAttestationKeys = newAttestationKeyCache() }()
+
+	bucket, object := "bucket", "object"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	putObject := func(data string) ObjectInfo {
+		objInfo, err := obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader([]byte(data)), int64(len(data)), "", ""), map[string]string{"X-Amz-Meta-Study": "trial-42"})
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		return objInfo
+	}
+
+	objInfo := putObject("hello")
+	if err := attestObject(obj, bucket, object, objInfo.ETag); err != nil {
+		t.Fatalf("%s: Unable to attest object: %v", instanceType, err)
+	}
+
+	objInfo, err := obj.GetObjectInfo(bucket, object)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	signed, err := getObjectAttestation(obj, objInfo)
+	if err != nil {
+		t.Fatalf("%s: Unable to fetch manifest: %v", instanceType, err)
+	}
+	m, err := verifyObjectManifest(obj, signed)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if m.ETag != objInfo.ETag || m.Size != objInfo.Size || m.Metadata["X-Amz-Meta-Study"] != "trial-42" {
+		t.Fatalf("%s: Unexpected manifest %#v", instanceType, m)
+	}
+
+	// Overwritten without attestation, the old manifest is stale.
+	objInfo = putObject("hello world")
+	if _, err = getObjectAttestation(obj, objInfo); errors.Cause(err) != errNoSuchAttestation {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, errNoSuchAttestation, err)
+	}
+
+	// Removed along with the object.
+	removeObjectAttestation(obj, bucket, object)
+	if _, err = getObjectAttestation(obj, objInfo); errors.Cause(err) != errNoSuchAttestation {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, errNoSuchAttestation, err)
+	}
+}
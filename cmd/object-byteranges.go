@@ -0,0 +1,158 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+
+	"github.com/minio/minio/pkg/ioutil"
+)
+
+// getObjectWriter - returns the writer length bytes of the object at
+// startOffset are written to, decrypting encrypted objects, and the
+// offset and length of the stored object to read. The writer must be
+// closed once the object is read if it is an io.Closer.
+func getObjectWriter(writer io.Writer, r *http.Request, objAPI ObjectLayer, objInfo ObjectInfo, startOffset, length int64) (io.Writer, int64, int64, error) {
+	if !objAPI.IsEncryptionSupported() {
+		return writer, startOffset, length, nil
+	}
+	sseS3 := isSSES3Encrypted(objInfo.UserDefined)
+	if !sseS3 && !IsSSECustomerRequest(r.Header) {
+		return writer, startOffset, length, nil
+	}
+
+	// Response writer should be limited early on for decryption upto required length,
+	// additionally also skipping mod(offset)64KiB boundaries.
+	writer = ioutil.LimitedWriter(writer, startOffset%(64*1024), length)
+
+	var sequenceNumber uint32
+	sequenceNumber, startOffset, length = getStartOffset(startOffset, length)
+	if startOffset+length > objInfo.EncryptedSize() {
+		length = objInfo.EncryptedSize() - startOffset
+	}
+
+	var err error
+	if sseS3 {
+		writer, err = newKMSDecryptWriter(writer, globalKMS, sequenceNumber, objInfo.UserDefined)
+	} else {
+		writer, err = DecryptRequestWithSequenceNumber(writer, r, sequenceNumber, objInfo.UserDefined)
+	}
+	return writer, startOffset, length, err
+}
+
+// Returns a copy of the metadata of an object, decrypting an object
+// removes the sealed keys from its metadata.
+func copyObjectMetadata(metadata map[string]string) map[string]string {
+	m := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		m[k] = v
+	}
+	return m
+}
+
+// checkObjectByteRanges - returns the object info the ranges of an
+// object are read with. The key of encrypted objects is checked before
+// the response is sent, removing the sealed keys from the metadata of
+// objInfo sent to the client as getObjectWriter does.
+func checkObjectByteRanges(r *http.Request, objAPI ObjectLayer, objInfo ObjectInfo) (ObjectInfo, error) {
+	rangesInfo := objInfo
+	rangesInfo.UserDefined = copyObjectMetadata(objInfo.UserDefined)
+	_, _, _, err := getObjectWriter(new(countWriter), r, objAPI, objInfo, 0, 0)
+	return rangesInfo, err
+}
+
+// Returns the headers of the part of a multipart/byteranges response
+// holding a range.
+func getByteRangePartHeader(contentType string, hrange *httpRange) textproto.MIMEHeader {
+	header := make(textproto.MIMEHeader)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	header.Set("Content-Range", hrange.String())
+	return header
+}
+
+// countWriter - counts the bytes written to it.
+type countWriter int64
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	*w += countWriter(len(p))
+	return len(p), nil
+}
+
+// Returns the size of a multipart/byteranges response of the ranges.
+func getByteRangesSize(boundary, contentType string, hranges []*httpRange) (int64, error) {
+	var size countWriter
+	mw := multipart.NewWriter(&size)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return 0, err
+	}
+	for _, hrange := range hranges {
+		if _, err := mw.CreatePart(getByteRangePartHeader(contentType, hrange)); err != nil {
+			return 0, err
+		}
+		size += countWriter(hrange.getLength())
+	}
+	if err := mw.Close(); err != nil {
+		return 0, err
+	}
+	return int64(size), nil
+}
+
+// writeObjectByteRanges - writes the ranges of an object as a
+// multipart/byteranges response, each range is read from the object
+// layer on its own. The object headers must be set already, the
+// content type of the object is sent in the header of every part.
+// objInfo is returned by checkObjectByteRanges.
+func writeObjectByteRanges(w http.ResponseWriter, r *http.Request, objAPI ObjectLayer, bucket, object string, objInfo ObjectInfo, hranges []*httpRange) error {
+	contentType := w.Header().Get("Content-Type")
+	mw := multipart.NewWriter(w)
+	size, err := getByteRangesSize(mw.Boundary(), contentType, hranges)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.WriteHeader(http.StatusPartialContent)
+
+	for _, hrange := range hranges {
+		part, err := mw.CreatePart(getByteRangePartHeader(contentType, hrange))
+		if err != nil {
+			return err
+		}
+		rangeInfo := objInfo
+		rangeInfo.UserDefined = copyObjectMetadata(objInfo.UserDefined)
+		writer, startOffset, length, err := getObjectWriter(part, r, objAPI, rangeInfo, hrange.offsetBegin, hrange.getLength())
+		if err != nil {
+			return err
+		}
+		if err = objAPI.GetObject(bucket, object, startOffset, length, writer, objInfo.ETag); err != nil {
+			return err
+		}
+		if closer, ok := writer.(io.Closer); ok {
+			if err = closer.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	return mw.Close()
+}
@@ -17,6 +17,8 @@
 package cmd
 
 import (
+	"encoding/xml"
+	"io"
 	"net"
 	"net/http"
 	"strings"
@@ -226,15 +228,66 @@ func isETagEqual(left, right string) bool {
 	return canonicalizeETag(left) == canonicalizeETag(right)
 }
 
+// parseCompleteMultipartUpload - decodes the parts of a complete
+// multipart upload request one at a time while reading the request,
+// failing at the first invalid part instead of after reading the
+// whole list. ETags of the parts are canonicalized.
+func parseCompleteMultipartUpload(r io.Reader) ([]CompletePart, APIErrorCode) {
+	var parts []CompletePart
+	decoder := xml.NewDecoder(r)
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrMalformedXML
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			// Parts are direct children of the root element.
+			if depth != 1 || t.Name.Local != "Part" {
+				depth++
+				continue
+			}
+			var part CompletePart
+			if err = decoder.DecodeElement(&part, &t); err != nil {
+				return nil, ErrMalformedXML
+			}
+			if part.PartNumber < 1 || isMaxPartID(part.PartNumber) {
+				return nil, ErrInvalidPart
+			}
+			if len(parts) > 0 && part.PartNumber < parts[len(parts)-1].PartNumber {
+				return nil, ErrInvalidPartOrder
+			}
+			part.ETag = canonicalizeETag(part.ETag)
+			parts = append(parts, part)
+		case xml.EndElement:
+			depth--
+		}
+	}
+	if len(parts) == 0 {
+		return nil, ErrMalformedXML
+	}
+	return parts, ErrNone
+}
+
 // deleteObject is a convenient wrapper to delete an object, this
 // is a common function to be called from object handlers and
 // web handlers.
 func deleteObject(obj ObjectLayer, bucket, object string, r *http.Request) (err error) {
+	// Locked objects cannot be deleted.
+	if err = checkObjectLock(obj, bucket, object, r); err != nil {
+		return err
+	}
 
 	// Proceed to delete the object.
 	if err = obj.DeleteObject(bucket, object); err != nil {
 		return err
 	}
+	removeObjectAttestation(obj, bucket, object)
 
 	// Get host and port from Request.RemoteAddr.
 	host, port, _ := net.SplitHostPort(r.RemoteAddr)
@@ -18,14 +18,12 @@ package cmd
 
 import (
 	"encoding/hex"
-	"encoding/xml"
+	"encoding/json"
 	"fmt"
 	"io"
-	goioutil "io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
-	"sort"
 	"strconv"
 
 	mux "github.com/gorilla/mux"
@@ -103,6 +101,13 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Website requests of prefixes are served the index document.
+	website, isWebsite := getWebsiteConfigForRequest(r, bucket)
+	if isWebsite && hasSuffix(object, slashSeparator) {
+		serveWebsiteIndexDocument(w, r, objectAPI, bucket, object, website)
+		return
+	}
+
 	if s3Error := checkRequestAuthType(r, bucket, "s3:GetObject", globalServerConfig.GetRegion()); s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
@@ -112,6 +117,10 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 	if err != nil {
 		apiErr := toAPIErrorCode(err)
 		if apiErr == ErrNoSuchKey {
+			if isWebsite {
+				serveWebsiteErrorDocument(w, r, objectAPI, bucket, website)
+				return
+			}
 			apiErr = errAllowableObjectNotFound(bucket, r)
 		}
 		writeErrorResponse(w, apiErr, r.URL)
@@ -125,11 +134,13 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	// Get request range.
+	// Get request range, several ranges are sent as a
+	// multipart/byteranges response.
 	var hrange *httpRange
+	var hranges []*httpRange
 	rangeHeader := r.Header.Get("Range")
 	if rangeHeader != "" {
-		if hrange, err = parseRequestRange(rangeHeader, objInfo.Size); err != nil {
+		if hranges, err = parseRequestRanges(rangeHeader, objInfo.Size); err != nil {
 			// Handle only errInvalidRange
 			// Ignore other parse error and treat it as regular Get request like Amazon S3.
 			if err == errInvalidRange {
@@ -140,6 +151,9 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 			// log the error.
 			errorIf(err, "Invalid request range")
 		}
+		if len(hranges) == 1 {
+			hrange = hranges[0]
+		}
 	}
 
 	// Validate pre-conditions if any.
@@ -147,6 +161,27 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if objectAPI.IsEncryptionSupported() && !isSSES3Encrypted(objInfo.UserDefined) && IsSSECustomerRequest(r.Header) {
+		w.Header().Set(SSECustomerAlgorithm, r.Header.Get(SSECustomerAlgorithm))
+		w.Header().Set(SSECustomerKeyMD5, r.Header.Get(SSECustomerKeyMD5))
+	}
+
+	if len(hranges) > 1 {
+		rangesInfo, err := checkObjectByteRanges(r, objectAPI, objInfo)
+		if err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		setObjectHeaders(w, objInfo, nil)
+		setHeadGetRespHeaders(w, r.URL.Query())
+		if err = writeObjectByteRanges(w, r, objectAPI, bucket, object, rangesInfo, hranges); err != nil {
+			errorIf(err, "Unable to write to client.")
+			return
+		}
+		notifyObjectAccessedGet(r, bucket, objInfo)
+		return
+	}
+
 	// Get the object.
 	var startOffset int64
 	length := objInfo.Size
@@ -155,29 +190,10 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		length = hrange.getLength()
 	}
 
-	var writer io.Writer
-	writer = w
-	if objectAPI.IsEncryptionSupported() {
-		if IsSSECustomerRequest(r.Header) {
-			// Response writer should be limited early on for decryption upto required length,
-			// additionally also skipping mod(offset)64KiB boundaries.
-			writer = ioutil.LimitedWriter(writer, startOffset%(64*1024), length)
-
-			var sequenceNumber uint32
-			sequenceNumber, startOffset, length = getStartOffset(startOffset, length)
-			if length > objInfo.EncryptedSize() {
-				length = objInfo.EncryptedSize()
-			}
-
-			writer, err = DecryptRequestWithSequenceNumber(writer, r, sequenceNumber, objInfo.UserDefined)
-			if err != nil {
-				writeErrorResponse(w, toAPIErrorCode(err), r.URL)
-				return
-			}
-
-			w.Header().Set(SSECustomerAlgorithm, r.Header.Get(SSECustomerAlgorithm))
-			w.Header().Set(SSECustomerKeyMD5, r.Header.Get(SSECustomerKeyMD5))
-		}
+	writer, startOffset, length, err := getObjectWriter(w, r, objectAPI, objInfo, startOffset, length)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
 	}
 
 	setObjectHeaders(w, objInfo, hrange)
@@ -201,13 +217,17 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		}
 	}
 
+	notifyObjectAccessedGet(r, bucket, objInfo)
+}
+
+// Notifies an object was accessed via a GET request.
+func notifyObjectAccessedGet(r *http.Request, bucket string, objInfo ObjectInfo) {
 	// Get host and port from Request.RemoteAddr.
 	host, port, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
 		host, port = "", ""
 	}
 
-	// Notify object accessed via a GET request.
 	eventNotify(eventData{
 		Type:      ObjectAccessedGet,
 		Bucket:    bucket,
@@ -219,6 +239,97 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 	})
 }
 
+// GetObjectPartsHandler - GET Object parts, a Minio extension
+// ----------
+// This implementation of the GET operation returns the ETag and size
+// of every part of a completed multipart object, allowing clients to
+// verify very large objects piecewise after download.
+func (api objectAPIHandlers) GetObjectPartsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetObject", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		apiErr := toAPIErrorCode(err)
+		if apiErr == ErrNoSuchKey {
+			apiErr = errAllowableObjectNotFound(bucket, r)
+		}
+		writeErrorResponse(w, apiErr, r.URL)
+		return
+	}
+
+	// Checksums of encrypted parts do not match the decrypted content.
+	if objectAPI.IsEncryptionSupported() && objInfo.IsEncrypted() {
+		writeErrorResponse(w, ErrSSEEncryptedObject, r.URL)
+		return
+	}
+
+	response := generateObjectPartsResponse(objInfo)
+	encodedSuccessResponse := encodeResponse(response)
+
+	// Write success response.
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+// GetObjectAttestationHandler - GET Object attestation, a Minio extension
+// ----------
+// This implementation of the GET operation returns the manifest of the
+// object's checksum and metadata signed by the server at commit time.
+func (api objectAPIHandlers) GetObjectAttestationHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetObject", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		apiErr := toAPIErrorCode(err)
+		if apiErr == ErrNoSuchKey {
+			apiErr = errAllowableObjectNotFound(bucket, r)
+		}
+		writeErrorResponse(w, apiErr, r.URL)
+		return
+	}
+
+	signed, err := getObjectAttestation(objectAPI, objInfo)
+	if err != nil {
+		errorIf(err, "Unable to fetch manifest of %s/%s.", bucket, object)
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	encodedSuccessResponse, err := json.Marshal(signed)
+	if err != nil {
+		writeErrorResponse(w, ErrInternalError, r.URL)
+		return
+	}
+
+	// Write success response.
+	writeSuccessResponseJSON(w, encodedSuccessResponse)
+}
+
 // HeadObjectHandler - HEAD Object
 // -----------
 // The HEAD operation retrieves metadata from an object without returning the object itself.
@@ -253,6 +364,9 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		if apiErr, encrypted := DecryptObjectInfo(&objInfo, r.Header); apiErr != ErrNone {
 			writeErrorResponse(w, apiErr, r.URL)
 			return
+		} else if encrypted && isSSES3Encrypted(objInfo.UserDefined) {
+			// The data key is not needed to return the metadata.
+			removeSSES3Metadata(objInfo.UserDefined)
 		} else if encrypted {
 			if _, err = DecryptRequest(w, r, objInfo.UserDefined); err != nil {
 				writeErrorResponse(w, ErrSSEEncryptedObject, r.URL)
@@ -306,7 +420,17 @@ func getCpObjMetadataFromHeader(header http.Header, userMeta map[string]string)
 	// if x-amz-metadata-directive says REPLACE then
 	// we extract metadata from the input headers.
 	if isMetadataReplace(header) {
-		return extractMetadataFromHeader(header)
+		metadata, err := extractMetadataFromHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		// Quarantine status can only be cleared through the admin API.
+		if status, ok := userMeta[ObjectQuarantineStatus]; ok {
+			metadata[ObjectQuarantineStatus] = status
+		}
+		// Object lock can only be changed through its subresources.
+		setObjectLock(metadata, getObjectLock(userMeta))
+		return metadata, nil
 	}
 
 	// if x-amz-metadata-directive says COPY then we
@@ -355,12 +479,26 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// The source is read with the credentials of the request.
+	if s3Error := checkRequestObjectAccess(r, srcBucket, srcObject, "s3:GetObject"); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
 	// Check if metadata directive is valid.
 	if !isMetadataDirectiveValid(r.Header) {
 		writeErrorResponse(w, ErrInvalidMetadataDirective, r.URL)
 		return
 	}
 
+	// Validate storage class metadata if present
+	if _, ok := r.Header[amzStorageClassCanonical]; ok {
+		if !isValidStorageClassMeta(r.Header.Get(amzStorageClassCanonical)) {
+			writeErrorResponse(w, ErrInvalidStorageClass, r.URL)
+			return
+		}
+	}
+
 	cpSrcDstSame := isStringEqual(pathJoin(srcBucket, srcObject), pathJoin(dstBucket, dstObject))
 	srcInfo, err := objectAPI.GetObjectInfo(srcBucket, srcObject)
 	if err != nil {
@@ -398,10 +536,22 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 	var writer io.WriteCloser = pipeWriter
 	var reader io.Reader = pipeReader
 	var encMetadata = make(map[string]string)
+	// Size of the content saved at the destination, differs from the
+	// size of the source when it is encrypted on the way.
+	dstSize := srcInfo.Size
 	if objectAPI.IsEncryptionSupported() {
 		var oldKey, newKey []byte
 		sseCopyC := IsSSECopyCustomerRequest(r.Header)
 		sseC := IsSSECustomerRequest(r.Header)
+		sseS3Copy := isSSES3Encrypted(srcInfo.UserDefined)
+		sseS3 := IsSSES3Request(r.Header)
+		if sseS3 {
+			if err = ParseSSES3Request(r.Header); err != nil {
+				pipeReader.CloseWithError(err)
+				writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+				return
+			}
+		}
 		if sseCopyC {
 			oldKey, err = ParseSSECopyCustomerRequest(r)
 			if err != nil {
@@ -430,13 +580,35 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 				writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 				return
 			}
+		} else if sseS3Copy && !sseC && cpSrcDstSame {
+			// Only the metadata of the object is replaced, it
+			// stays encrypted with the same object key.
+			for _, k := range []string{ServerSideEncryptionIV, ServerSideEncryptionSealAlgorithm,
+				ServerSideEncryptionSealedKey, ServerSideEncryptionKMSSealedKey, SSEHeader} {
+				encMetadata[k] = srcInfo.UserDefined[k]
+			}
+			if sseS3 {
+				// Copying an object onto itself seals its object
+				// key with a new data key and the latest master key.
+				if err = rotateKMSKey(globalKMS, encMetadata); err != nil {
+					pipeWriter.CloseWithError(err)
+					writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+					return
+				}
+				encMetadata[SSEHeader] = r.Header.Get(SSEHeader)
+			}
 		} else {
-			if sseCopyC {
+			if sseCopyC || sseS3Copy {
 				// Source is encrypted make sure to save the encrypted size.
 				if srcInfo.IsEncrypted() {
 					srcInfo.Size = srcInfo.EncryptedSize()
 				}
-				writer, err = newDecryptWriter(pipeWriter, oldKey, 0, srcInfo.UserDefined)
+				if sseS3Copy {
+					writer, err = newKMSDecryptWriter(pipeWriter, globalKMS, 0, srcInfo.UserDefined)
+					delete(srcInfo.UserDefined, SSEHeader)
+				} else {
+					writer, err = newDecryptWriter(pipeWriter, oldKey, 0, srcInfo.UserDefined)
+				}
 				if err != nil {
 					pipeWriter.CloseWithError(err)
 					writeErrorResponse(w, toAPIErrorCode(err), r.URL)
@@ -447,22 +619,41 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 				// if source and destination are same objects.
 				srcInfo.metadataOnly = false
 			}
-			if sseC {
-				reader, err = newEncryptReader(pipeReader, newKey, encMetadata)
+			if sseC || sseS3 {
+				if sseS3 {
+					reader, err = newKMSEncryptReader(pipeReader, globalKMS, encMetadata)
+					encMetadata[SSEHeader] = r.Header.Get(SSEHeader)
+				} else {
+					reader, err = newEncryptReader(pipeReader, newKey, encMetadata)
+				}
 				if err != nil {
 					pipeReader.CloseWithError(err)
 					writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 					return
 				}
+				dstSize = encryptedSize(dstSize)
 				// We are not only copying just metadata instead
 				// we are creating a new object at this point, even
 				// if source and destination are same objects.
 				srcInfo.metadataOnly = false
 			}
 		}
+	} else if IsSSES3Request(r.Header) {
+		pipeReader.CloseWithError(errKMSNotConfigured)
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
 	}
 	srcInfo.Writer = writer
 
+	// Objects pending validation cannot be read, only their
+	// metadata can be updated.
+	if !srcInfo.metadataOnly && isObjectQuarantined(srcInfo.UserDefined) {
+		pipeReader.CloseWithError(ObjectQuarantined{Bucket: srcBucket, Object: srcObject})
+		writeErrorResponse(w, ErrObjectQuarantined, r.URL)
+		return
+	}
+
+	srcStorageClass, srcEncrypted := getStorageClass(srcInfo.UserDefined), srcInfo.IsEncrypted()
 	srcInfo.UserDefined, err = getCpObjMetadataFromHeader(r.Header, srcInfo.UserDefined)
 	if err != nil {
 		pipeReader.CloseWithError(err)
@@ -471,19 +662,54 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Copies are stored with the storage class requested.
+	if _, ok := r.Header[amzStorageClassCanonical]; ok {
+		srcInfo.UserDefined[amzStorageClass] = r.Header.Get(amzStorageClassCanonical)
+	}
+
+	// Objects copied onto themselves with another storage class are
+	// rewritten with its parity on erasure coded disks, unless they are
+	// encrypted or pending validation, whose content is kept as is.
+	storageClassChanged := getStorageClass(srcInfo.UserDefined) != srcStorageClass
+	if globalIsXL && srcInfo.metadataOnly && storageClassChanged &&
+		!srcEncrypted && !isObjectQuarantined(srcInfo.UserDefined) {
+		srcInfo.metadataOnly = false
+	}
+
 	// We need to preserve the encryption headers set in EncryptRequest,
 	// so we do not want to override them, copy them instead.
 	for k, v := range encMetadata {
 		srcInfo.UserDefined[k] = v
 	}
 
+	// Copies get the object lock requested or the default retention
+	// of the bucket, copies onto the object itself keep its lock.
+	if !cpSrcDstSame {
+		if s3Error := extractObjectLockFromHeader(r.Header, dstBucket, srcInfo.UserDefined); s3Error != ErrNone {
+			pipeReader.CloseWithError(fmt.Errorf("invalid object lock"))
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+	}
+
+	// Locked objects cannot be overwritten, only their metadata can
+	// be updated unless in WORM mode.
+	if !srcInfo.metadataOnly || globalWORMEnabled {
+		if err = checkObjectLock(objectAPI, dstBucket, dstObject, r); err != nil {
+			pipeReader.CloseWithError(err)
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+
 	// Make sure to remove saved etag if any, CopyObject calculates a new one.
 	delete(srcInfo.UserDefined, "etag")
 
 	// Check if x-amz-metadata-directive was not set to REPLACE and source,
 	// desination are same objects. Apply this restriction also when
 	// metadataOnly is true indicating that we are not overwriting the object.
-	if !isMetadataReplace(r.Header) && srcInfo.metadataOnly {
+	// Changing the storage class is allowed without it.
+	if !isMetadataReplace(r.Header) && srcInfo.metadataOnly && !storageClassChanged {
 		pipeReader.CloseWithError(fmt.Errorf("invalid copy dest"))
 		// If x-amz-metadata-directive is not set to REPLACE then we need
 		// to error out if source and destination are same.
@@ -491,7 +717,7 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	hashReader, err := hash.NewReader(reader, srcInfo.Size, "", "") // do not try to verify encrypted content
+	hashReader, err := hash.NewReader(reader, dstSize, "", "") // do not try to verify encrypted content
 	if err != nil {
 		pipeReader.CloseWithError(err)
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
@@ -506,6 +732,7 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
+	errorIf(attestObject(objectAPI, dstBucket, dstObject, objInfo.ETag), "Unable to attest object %s/%s.", dstBucket, dstObject)
 
 	pipeReader.Close()
 
@@ -597,6 +824,11 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponse(w, ErrInternalError, r.URL)
 		return
 	}
+	if s3Error := extractObjectLockFromHeader(r.Header, bucket, metadata); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+	applyMetadataDefaults(bucket, object, metadata)
 	if rAuthType == authTypeStreamingSigned {
 		if contentEncoding, ok := metadata["content-encoding"]; ok {
 			contentEncoding = trimAwsChunkedContentEncoding(contentEncoding)
@@ -644,6 +876,10 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			writeErrorResponse(w, s3Err, r.URL)
 			return
 		}
+		if s3Err = enforceSessionPolicy(r, bucket, "s3:PutObject"); s3Err != ErrNone {
+			writeErrorResponse(w, s3Err, r.URL)
+			return
+		}
 	case authTypeSignedV2, authTypePresignedV2:
 		s3Err = isReqAuthenticatedV2(r)
 		if s3Err != ErrNone {
@@ -651,6 +887,10 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			writeErrorResponse(w, s3Err, r.URL)
 			return
 		}
+		if s3Err = enforceSessionPolicy(r, bucket, "s3:PutObject"); s3Err != ErrNone {
+			writeErrorResponse(w, s3Err, r.URL)
+			return
+		}
 
 	case authTypePresigned, authTypeSigned:
 		if s3Err = reqSignatureV4Verify(r, globalServerConfig.GetRegion()); s3Err != ErrNone {
@@ -658,20 +898,39 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			writeErrorResponse(w, s3Err, r.URL)
 			return
 		}
+		if s3Err = enforceSessionPolicy(r, bucket, "s3:PutObject"); s3Err != ErrNone {
+			writeErrorResponse(w, s3Err, r.URL)
+			return
+		}
 		if !skipContentSha256Cksum(r) {
 			sha256hex = getContentSha256Cksum(r)
 		}
 	}
 
+	// Locked objects cannot be overwritten.
+	if err = checkObjectLock(objectAPI, bucket, object, r); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	hashReader, err := hash.NewReader(reader, size, md5hex, sha256hex)
 	if err != nil {
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
 
+	if IsSSES3Request(r.Header) && !objectAPI.IsEncryptionSupported() {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
 	if objectAPI.IsEncryptionSupported() {
-		if IsSSECustomerRequest(r.Header) && !hasSuffix(object, slashSeparator) { // handle SSE-C requests
-			reader, err = EncryptRequest(hashReader, r, metadata)
+		if sseS3 := IsSSES3Request(r.Header); (sseS3 || IsSSECustomerRequest(r.Header)) && !hasSuffix(object, slashSeparator) { // handle SSE-C and SSE-S3 requests
+			if sseS3 {
+				reader, err = EncryptSSES3Request(hashReader, r, metadata)
+			} else {
+				reader, err = EncryptRequest(hashReader, r, metadata)
+			}
 			if err != nil {
 				writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 				return
@@ -690,6 +949,7 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
+	errorIf(attestObject(objectAPI, bucket, object, objInfo.ETag), "Unable to attest object %s/%s.", bucket, object)
 
 	w.Header().Set("ETag", "\""+objInfo.ETag+"\"")
 	if objectAPI.IsEncryptionSupported() {
@@ -697,6 +957,9 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			w.Header().Set(SSECustomerAlgorithm, r.Header.Get(SSECustomerAlgorithm))
 			w.Header().Set(SSECustomerKeyMD5, r.Header.Get(SSECustomerKeyMD5))
 		}
+		if IsSSES3Request(r.Header) && !hasSuffix(object, slashSeparator) {
+			w.Header().Set(SSEHeader, r.Header.Get(SSEHeader))
+		}
 	}
 
 	writeSuccessResponseHeadersOnly(w)
@@ -747,8 +1010,8 @@ func (api objectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 		}
 	}
 
-	if IsSSECustomerRequest(r.Header) { // handle SSE-C requests
-		// SSE-C is not implemented for multipart operations yet
+	if IsSSECustomerRequest(r.Header) || IsSSES3Request(r.Header) { // handle SSE-C and SSE-S3 requests
+		// SSE-C and SSE-S3 are not implemented for multipart operations yet
 		writeErrorResponse(w, ErrNotImplemented, r.URL)
 		return
 	}
@@ -760,6 +1023,18 @@ func (api objectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 		writeErrorResponse(w, ErrInternalError, r.URL)
 		return
 	}
+	if s3Error := extractObjectLockFromHeader(r.Header, bucket, metadata); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+	applyMetadataDefaults(bucket, object, metadata)
+
+	// Locked objects cannot be overwritten, fail before any part is
+	// uploaded.
+	if err = checkObjectLock(objectAPI, bucket, object, r); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
 
 	uploadID, err := objectAPI.NewMultipartUpload(bucket, object, metadata)
 	if err != nil {
@@ -811,6 +1086,12 @@ func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// The source is read with the credentials of the request.
+	if s3Error := checkRequestObjectAccess(r, srcBucket, srcObject, "s3:GetObject"); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
 	uploadID := r.URL.Query().Get("uploadId")
 	partIDString := r.URL.Query().Get("partNumber")
 
@@ -832,6 +1113,13 @@ func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// Parts are never encrypted, encrypted objects cannot be
+	// copied into them yet.
+	if objectAPI.IsEncryptionSupported() && srcInfo.IsEncrypted() {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
 	// Get request range.
 	var hrange *httpRange
 	rangeHeader := r.Header.Get("x-amz-copy-source-range")
@@ -980,6 +1268,10 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
+		if s3Error = enforceSessionPolicy(r, bucket, "s3:PutObject"); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
 	case authTypeSignedV2, authTypePresignedV2:
 		s3Error := isReqAuthenticatedV2(r)
 		if s3Error != ErrNone {
@@ -987,12 +1279,20 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
+		if s3Error = enforceSessionPolicy(r, bucket, "s3:PutObject"); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
 	case authTypePresigned, authTypeSigned:
 		if s3Error := reqSignatureV4Verify(r, globalServerConfig.GetRegion()); s3Error != ErrNone {
 			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
+		if s3Error := enforceSessionPolicy(r, bucket, "s3:PutObject"); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
 
 		if !skipContentSha256Cksum(r) {
 			sha256hex = getContentSha256Cksum(r)
@@ -1006,7 +1306,7 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	partInfo, err := objectAPI.PutObjectPart(bucket, object, uploadID, partID, hashReader)
+	partInfo, err := objectAPI.PutObjectPart(r.Context(), bucket, object, uploadID, partID, hashReader)
 	if err != nil {
 		// Verify if the underlying error is signature mismatch.
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
@@ -1103,35 +1403,40 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 	// Get upload id.
 	uploadID, _, _, _ := getObjectResources(r.URL.Query())
 
-	completeMultipartBytes, err := goioutil.ReadAll(r.Body)
-	if err != nil {
-		writeErrorResponse(w, ErrInternalError, r.URL)
-		return
-	}
-	complMultipartUpload := &CompleteMultipartUpload{}
-	if err = xml.Unmarshal(completeMultipartBytes, complMultipartUpload); err != nil {
-		writeErrorResponse(w, ErrMalformedXML, r.URL)
-		return
-	}
-	if len(complMultipartUpload.Parts) == 0 {
-		writeErrorResponse(w, ErrMalformedXML, r.URL)
-		return
-	}
-	if !sort.IsSorted(CompletedParts(complMultipartUpload.Parts)) {
-		writeErrorResponse(w, ErrInvalidPartOrder, r.URL)
+	// Parts are validated while the list is read.
+	completeParts, s3Error := parseCompleteMultipartUpload(r.Body)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
 
-	// Complete parts.
-	var completeParts []CompletePart
-	for _, part := range complMultipartUpload.Parts {
-		part.ETag = canonicalizeETag(part.ETag)
-		completeParts = append(completeParts, part)
+	// Locked objects cannot be overwritten.
+	if err := checkObjectLock(objectAPI, bucket, object, r); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
 	}
 
-	objInfo, err := objectAPI.CompleteMultipartUpload(bucket, object, uploadID, completeParts)
+	// Completing uploads of many parts takes a while, keep the
+	// connection alive meanwhile.
+	doneCh := make(chan struct{})
+	startedCh := keepCompleteMultipartAlive(w, globalSNSConnAlive, doneCh)
+	objInfo, err := objectAPI.CompleteMultipartUpload(r.Context(), bucket, object, uploadID, completeParts)
+	close(doneCh)
+	started := <-startedCh
 	if err != nil {
 		err = errors.Cause(err)
+		if started {
+			// Errors are reported in the body of the started response.
+			var encodedErrorResponse []byte
+			if oErr, ok := err.(PartTooSmall); ok {
+				_, encodedErrorResponse = encodePartSmallErrorResponse(r, oErr)
+			} else {
+				apiError := getAPIError(toAPIErrorCode(err))
+				encodedErrorResponse = encodeResponse(getAPIErrorResponse(apiError, r.URL.Path))
+			}
+			writeCompleteMultipartBody(w, encodedErrorResponse)
+			return
+		}
 		switch oErr := err.(type) {
 		case PartTooSmall:
 			// Write part too small error.
@@ -1143,21 +1448,23 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 		return
 	}
 
+	errorIf(attestObject(objectAPI, bucket, object, objInfo.ETag), "Unable to attest object %s/%s.", bucket, object)
+
 	// Get object location.
 	location := getLocation(r)
 	// Generate complete multipart response.
 	response := generateCompleteMultpartUploadResponse(bucket, object, location, objInfo.ETag)
 	encodedSuccessResponse := encodeResponse(response)
-	if err != nil {
-		writeErrorResponse(w, ErrInternalError, r.URL)
-		return
-	}
 
-	// Set etag.
-	w.Header().Set("ETag", "\""+objInfo.ETag+"\"")
+	if started {
+		writeCompleteMultipartBody(w, encodedSuccessResponse)
+	} else {
+		// Set etag.
+		w.Header().Set("ETag", "\""+objInfo.ETag+"\"")
 
-	// Write success response.
-	writeSuccessResponseXML(w, encodedSuccessResponse)
+		// Write success response.
+		writeSuccessResponseXML(w, encodedSuccessResponse)
+	}
 
 	// Get host and port from Request.RemoteAddr.
 	host, port, err := net.SplitHostPort(r.RemoteAddr)
@@ -1199,8 +1506,12 @@ func (api objectAPIHandlers) DeleteObjectHandler(w http.ResponseWriter, r *http.
 	// http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectDELETE.html
 	// Ignore delete object errors while replying to client, since we are
 	// suppposed to reply only 204. Additionally log the error for
-	// investigation.
+	// investigation. Locked objects are reported as access denied.
 	if err := deleteObject(objectAPI, bucket, object, r); err != nil {
+		if _, ok := errors.Cause(err).(ObjectLocked); ok {
+			writeErrorResponse(w, ErrObjectLocked, r.URL)
+			return
+		}
 		errorIf(err, "Unable to delete an object %s", pathJoin(bucket, object))
 	}
 	writeSuccessNoContent(w)
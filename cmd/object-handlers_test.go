@@ -18,16 +18,27 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/xml"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/minio/minio/pkg/auth"
@@ -191,6 +202,121 @@ func testAPIHeadObjectHandler(obj ObjectLayer, instanceType, bucketName string,
 	ExecObjectLayerAPINilTest(t, nilBucket, nilObject, instanceType, apiRouter, nilReq)
 }
 
+// Wrapper for calling GetObjectParts API handler tests for both XL multiple disks and FS single drive setup.
+func TestAPIGetObjectPartsHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIGetObjectPartsHandler, []string{"GetObjectParts"})
+}
+
+func testAPIGetObjectPartsHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+	// Upload an object in two parts.
+	multipartObject := "multipart-object"
+	uploadID, err := obj.NewMultipartUpload(bucketName, multipartObject, nil)
+	if err != nil {
+		t.Fatalf("Minio %s : <ERROR>  %s", instanceType, err)
+	}
+	partsData := [][]byte{generateBytesData(5 * humanize.MiByte), []byte("hello")}
+	var completeParts []CompletePart
+	for i, data := range partsData {
+		md5hex := getMD5Hash(data)
+		_, err = obj.PutObjectPart(context.Background(), bucketName, multipartObject, uploadID, i+1, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), md5hex, ""))
+		if err != nil {
+			t.Fatalf("Minio %s : %s.", instanceType, err)
+		}
+		completeParts = append(completeParts, CompletePart{PartNumber: i + 1, ETag: md5hex})
+	}
+	multipartInfo, err := obj.CompleteMultipartUpload(context.Background(), bucketName, multipartObject, uploadID, completeParts)
+	if err != nil {
+		t.Fatalf("Minio %s : %s.", instanceType, err)
+	}
+
+	// Upload a regular object.
+	regularObject := "regular-object"
+	regularInfo, err := obj.PutObject(bucketName, regularObject, mustGetHashReader(t, bytes.NewReader([]byte("hello")), int64(len("hello")), "", ""), nil)
+	if err != nil {
+		t.Fatalf("Minio %s : %s.", instanceType, err)
+	}
+
+	testCases := []struct {
+		objectName         string
+		accessKey          string
+		expectedRespStatus int
+		expectedResponse   ObjectPartsResponse
+	}{
+		// Test case - 1.
+		// Parts of a multipart object are listed in order.
+		{
+			objectName:         multipartObject,
+			accessKey:          credentials.AccessKey,
+			expectedRespStatus: http.StatusOK,
+			expectedResponse: ObjectPartsResponse{
+				Bucket: bucketName,
+				Key:    multipartObject,
+				ETag:   "\"" + multipartInfo.ETag + "\"",
+				Size:   multipartInfo.Size,
+				Parts: []ObjectPart{
+					{1, "\"" + completeParts[0].ETag + "\"", int64(len(partsData[0]))},
+					{2, "\"" + completeParts[1].ETag + "\"", int64(len(partsData[1]))},
+				},
+			},
+		},
+		// Test case - 2.
+		// A regular object is reported as a single part.
+		{
+			objectName:         regularObject,
+			accessKey:          credentials.AccessKey,
+			expectedRespStatus: http.StatusOK,
+			expectedResponse: ObjectPartsResponse{
+				Bucket: bucketName,
+				Key:    regularObject,
+				ETag:   "\"" + regularInfo.ETag + "\"",
+				Size:   regularInfo.Size,
+				Parts:  []ObjectPart{{1, "\"" + regularInfo.ETag + "\"", regularInfo.Size}},
+			},
+		},
+		// Test case - 3.
+		// Non-existent object.
+		{
+			objectName:         "abcd",
+			accessKey:          credentials.AccessKey,
+			expectedRespStatus: http.StatusNotFound,
+		},
+		// Test case - 4.
+		// Invalid access key.
+		{
+			objectName:         multipartObject,
+			accessKey:          "Invalid-AccessID",
+			expectedRespStatus: http.StatusForbidden,
+		},
+	}
+
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("GET", getObjectPartsURL("", bucketName, testCase.objectName),
+			0, nil, testCase.accessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request for Get Object Parts: <ERROR> %v", i+1, instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		var response ObjectPartsResponse
+		if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Test %d: %s: Failed to parse the response: <ERROR> %v", i+1, instanceType, err)
+		}
+		response.XMLName = xml.Name{}
+		if !reflect.DeepEqual(response, testCase.expectedResponse) {
+			t.Errorf("Test %d: %s: Expected %#v, got %#v", i+1, instanceType, testCase.expectedResponse, response)
+		}
+	}
+}
+
 // Wrapper for calling GetObject API handler tests for both XL multiple disks and FS single drive setup.
 func TestAPIGetObjectHandler(t *testing.T) {
 	defer DetectTestLeak(t)()
@@ -464,6 +590,118 @@ func testAPIGetObjectHandler(obj ObjectLayer, instanceType, bucketName string, a
 	ExecObjectLayerAPINilTest(t, nilBucket, nilObject, instanceType, apiRouter, nilReq)
 }
 
+// Reads the parts of a multipart/byteranges response, returns the
+// content and the Content-Range header of every part.
+func readByteRangesResponse(t *testing.T, rec *httptest.ResponseRecorder) (contents [][]byte, contentRanges []string) {
+	mediaType, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Expected a multipart/byteranges response, got %q, %v", rec.Header().Get("Content-Type"), err)
+	}
+	if rec.Header().Get("Content-Length") != strconv.Itoa(rec.Body.Len()) {
+		t.Fatalf("Expected Content-Length %s to match the body of %d bytes", rec.Header().Get("Content-Length"), rec.Body.Len())
+	}
+	mr := multipart.NewReader(rec.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return contents, contentRanges
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, content)
+		contentRanges = append(contentRanges, part.Header.Get("Content-Range"))
+	}
+}
+
+// Wrapper for calling GetObject API handler tests of several ranges for both XL multiple disks and FS single drive setup.
+func TestAPIGetObjectByteRangesHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIGetObjectByteRangesHandler, []string{"GetObject"})
+}
+
+func testAPIGetObjectByteRangesHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+	objectName := "test-object"
+	data := generateBytesData(6 * humanize.MiByte)
+	if _, err := obj.PutObject(bucketName, objectName, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""),
+		map[string]string{"content-type": "video/mp4"}); err != nil {
+		t.Fatalf("%s: Failed to upload the object: <ERROR> %v", instanceType, err)
+	}
+	size := len(data)
+
+	testCases := []struct {
+		byteRange     string
+		ranges        [][2]int
+		expectedCode  int
+		expectedWhole bool
+	}{
+		// Test case - 1.
+		// Ranges from the start, the middle and the end of the object.
+		{byteRange: "bytes=0-99,5242880-5243903,-10", ranges: [][2]int{{0, 99}, {5242880, 5243903}, {size - 10, size - 1}}, expectedCode: http.StatusPartialContent},
+		// Test case - 2.
+		// Whitespace between ranges, overlapping ranges are merged.
+		{byteRange: "bytes=10-19, 15-24", ranges: [][2]int{{10, 24}}, expectedCode: http.StatusPartialContent},
+		// Test case - 3.
+		// Ranges beyond the end of the object are ignored.
+		{byteRange: "bytes=100-199,10000000-", ranges: [][2]int{{100, 199}}, expectedCode: http.StatusPartialContent},
+		// Test case - 4.
+		// No satisfiable range.
+		{byteRange: "bytes=10000000-,20000000-", expectedCode: http.StatusRequestedRangeNotSatisfiable},
+		// Test case - 5.
+		// Invalid ranges are ignored like a single invalid range.
+		{byteRange: "bytes=0-9,abc", expectedCode: http.StatusOK, expectedWhole: true},
+		// Test case - 6.
+		// Ranges are sorted, repeated ranges never exceed the object size.
+		{byteRange: "bytes=-10,0-99,50-149,0-99", ranges: [][2]int{{0, 149}, {size - 10, size - 1}}, expectedCode: http.StatusPartialContent},
+	}
+
+	for i, testCase := range testCases {
+		req, err := newTestSignedRequestV4("GET", getGetObjectURL("", bucketName, objectName),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d: Failed to create HTTP request for Get Object: <ERROR> %v", i+1, err)
+		}
+		req.Header.Set("Range", testCase.byteRange)
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedCode {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedCode, rec.Code)
+		}
+		if testCase.expectedWhole && !bytes.Equal(rec.Body.Bytes(), data) {
+			t.Errorf("Test %d: %s: Expected the whole object", i+1, instanceType)
+		}
+		if rec.Code != http.StatusPartialContent {
+			continue
+		}
+
+		// A single range is sent as it is.
+		if len(testCase.ranges) == 1 {
+			r := testCase.ranges[0]
+			if !bytes.Equal(rec.Body.Bytes(), data[r[0]:r[1]+1]) || rec.Header().Get("Content-Range") != fmt.Sprintf("bytes %d-%d/%d", r[0], r[1], size) {
+				t.Errorf("Test %d: %s: Unexpected content of range %v", i+1, instanceType, r)
+			}
+			continue
+		}
+		contents, contentRanges := readByteRangesResponse(t, rec)
+		if len(contents) != len(testCase.ranges) {
+			t.Fatalf("Test %d: %s: Expected %d parts, got %d", i+1, instanceType, len(testCase.ranges), len(contents))
+		}
+		for j, r := range testCase.ranges {
+			if !bytes.Equal(contents[j], data[r[0]:r[1]+1]) {
+				t.Errorf("Test %d: %s: Content of range %v differs", i+1, instanceType, r)
+			}
+			if expected := fmt.Sprintf("bytes %d-%d/%d", r[0], r[1], size); contentRanges[j] != expected {
+				t.Errorf("Test %d: %s: Expected Content-Range %s, got %s", i+1, instanceType, expected, contentRanges[j])
+			}
+		}
+	}
+}
+
 // Wrapper for calling PutObject API handler tests using streaming signature v4 for both XL multiple disks and FS single drive setup.
 func TestAPIPutObjectStreamSigV4Handler(t *testing.T) {
 	defer DetectTestLeak(t)()
@@ -778,6 +1016,223 @@ func testAPIPutObjectStreamSigV4Handler(obj ObjectLayer, instanceType, bucketNam
 	}
 }
 
+// Wrapper for calling PutObject and PutObjectPart API handler tests
+// using streaming signature v4 with trailing checksums for both XL
+// multiple disks and FS single drive setup.
+func TestAPIPutObjectStreamSigV4TrailerHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIPutObjectStreamSigV4TrailerHandler, []string{"PutObjectPart", "PutObject"})
+}
+
+func testAPIPutObjectStreamSigV4TrailerHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	data := bytes.Repeat([]byte("abcd"), 17*humanize.KiByte)
+	crc32Sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc32Sum, crc32.ChecksumIEEE(data))
+	crc32c := base64.StdEncoding.EncodeToString(crc32Sum)
+	sha256Sum := sha256.Sum256(data)
+	sha256c := base64.StdEncoding.EncodeToString(sha256Sum[:])
+	md5Sum := md5.Sum([]byte("not the data"))
+	badMD5 := base64.StdEncoding.EncodeToString(md5Sum[:])
+
+	testCases := []struct {
+		objectName   string
+		trailer      string
+		checksum     string
+		contentMD5   string
+		badSignature bool
+		expectedErr  APIErrorCode
+	}{
+		// Test case - 1.
+		// Valid CRC32 trailer.
+		{objectName: "crc32", trailer: "x-amz-checksum-crc32", checksum: crc32c, expectedErr: ErrNone},
+		// Test case - 2.
+		// Valid SHA256 trailer, the header name is case insensitive.
+		{objectName: "sha256", trailer: "X-Amz-Checksum-Sha256", checksum: sha256c, expectedErr: ErrNone},
+		// Test case - 3.
+		// Checksum of other data.
+		{objectName: "bad-checksum", trailer: "x-amz-checksum-sha256", checksum: crc32c, expectedErr: ErrContentChecksumMismatch},
+		// Test case - 4.
+		// Valid checksum with a tampered trailer signature.
+		{objectName: "bad-signature", trailer: "x-amz-checksum-crc32", checksum: crc32c, badSignature: true, expectedErr: ErrSignatureDoesNotMatch},
+		// Test case - 5.
+		// Unsupported checksum.
+		{objectName: "bad-trailer", trailer: "x-amz-checksum-md5", checksum: crc32c, expectedErr: ErrInvalidChecksumTrailer},
+		// Test case - 6.
+		// Valid checksum with a wrong Content-MD5.
+		{objectName: "bad-md5", trailer: "x-amz-checksum-crc32", checksum: crc32c, contentMD5: badMD5, expectedErr: ErrBadDigest},
+	}
+
+	for i, testCase := range testCases {
+		uploadID, err := obj.NewMultipartUpload(bucketName, testCase.objectName, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create multipart upload: <ERROR> %v", i+1, instanceType, err)
+		}
+		urls := []string{
+			getPutObjectURL("", bucketName, testCase.objectName),
+			getPutObjectPartURL("", bucketName, testCase.objectName, uploadID, "1"),
+		}
+		for _, urlStr := range urls {
+			req, err := newTestStreamingSignedTrailerRequest("PUT", urlStr, int64(len(data)), 64*humanize.KiByte,
+				bytes.NewReader(data), credentials.AccessKey, credentials.SecretKey, testCase.trailer, testCase.checksum)
+			if err != nil {
+				t.Fatalf("Test %d: Failed to create HTTP request: <ERROR> %v", i+1, err)
+			}
+			if testCase.contentMD5 != "" {
+				req.Header.Set("Content-Md5", testCase.contentMD5)
+			}
+			if testCase.badSignature {
+				// Tamper the last character of the trailer signature.
+				body, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				idx := len(body) - len("\r\n\r\n") - 1
+				if body[idx] == '0' {
+					body[idx] = '1'
+				} else {
+					body[idx] = '0'
+				}
+				req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+
+			rec := httptest.NewRecorder()
+			apiRouter.ServeHTTP(rec, req)
+			if testCase.expectedErr == ErrNone {
+				if rec.Code != http.StatusOK {
+					t.Errorf("Test %d: %s: %s expected to succeed, but failed with %d: %s", i+1, instanceType, urlStr, rec.Code, rec.Body)
+
+				}
+				continue
+			}
+			var errXML APIErrorResponse
+			if err = xml.Unmarshal(rec.Body.Bytes(), &errXML); err != nil {
+				t.Fatalf("Test %d: %s: Failed to unmarshal error response: <ERROR> %v", i+1, instanceType, err)
+			}
+			expectedErr := getAPIError(testCase.expectedErr)
+			if rec.Code != expectedErr.HTTPStatusCode || errXML.Code != expectedErr.Code {
+				t.Errorf("Test %d: %s: %s expected to fail with %s, but failed with %d %s", i+1, instanceType,
+					urlStr, expectedErr.Code, rec.Code, errXML.Code)
+			}
+		}
+
+		// Uploads failing verification must not leave objects or parts behind.
+		_, err = obj.GetObjectInfo(bucketName, testCase.objectName)
+		if testCase.expectedErr == ErrNone && err != nil {
+			t.Errorf("Test %d: %s: Expected object to be uploaded: <ERROR> %v", i+1, instanceType, err)
+		}
+		if testCase.expectedErr != ErrNone && err == nil {
+			t.Errorf("Test %d: %s: Expected object not to be uploaded", i+1, instanceType)
+		}
+		parts, err := obj.ListObjectParts(bucketName, testCase.objectName, uploadID, 0, maxPartsList)
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to list parts: <ERROR> %v", i+1, instanceType, err)
+		}
+		if testCase.expectedErr == ErrNone && len(parts.Parts) != 1 {
+			t.Errorf("Test %d: %s: Expected part to be uploaded", i+1, instanceType)
+		}
+		if testCase.expectedErr != ErrNone && len(parts.Parts) != 0 {
+			t.Errorf("Test %d: %s: Expected part not to be uploaded, found %d parts", i+1, instanceType, len(parts.Parts))
+		}
+	}
+}
+
+// Wrapper for calling PutObject and PutObjectPart API handler tests
+// using unsigned aws-chunked payloads for both XL multiple disks and
+// FS single drive setup.
+func TestAPIPutObjectStreamUnsignedTrailerHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIPutObjectStreamUnsignedTrailerHandler, []string{"PutObjectPart", "PutObject"})
+}
+
+func testAPIPutObjectStreamUnsignedTrailerHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	data := bytes.Repeat([]byte("abcd"), 17*humanize.KiByte)
+	crc32Sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc32Sum, crc32.ChecksumIEEE(data))
+	crc32c := base64.StdEncoding.EncodeToString(crc32Sum)
+
+	testCases := []struct {
+		objectName  string
+		trailer     string
+		checksum    string
+		secretKey   string
+		expectedErr APIErrorCode
+	}{
+		// Test case - 1.
+		// Valid CRC32 trailer.
+		{objectName: "crc32", trailer: "x-amz-checksum-crc32", checksum: crc32c, secretKey: credentials.SecretKey, expectedErr: ErrNone},
+		// Test case - 2.
+		// No trailer.
+		{objectName: "no-trailer", secretKey: credentials.SecretKey, expectedErr: ErrNone},
+		// Test case - 3.
+		// Checksum of other data.
+		{objectName: "bad-checksum", trailer: "x-amz-checksum-crc32", checksum: "AAAAAA==", secretKey: credentials.SecretKey, expectedErr: ErrContentChecksumMismatch},
+		// Test case - 4.
+		// Headers signed with a wrong secret key.
+		{objectName: "bad-signature", trailer: "x-amz-checksum-crc32", checksum: crc32c, secretKey: "badsecretkey", expectedErr: ErrSignatureDoesNotMatch},
+		// Test case - 5.
+		// Unsupported checksum.
+		{objectName: "bad-trailer", trailer: "x-amz-checksum-md5", checksum: crc32c, secretKey: credentials.SecretKey, expectedErr: ErrInvalidChecksumTrailer},
+	}
+
+	for i, testCase := range testCases {
+		uploadID, err := obj.NewMultipartUpload(bucketName, testCase.objectName, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create multipart upload: <ERROR> %v", i+1, instanceType, err)
+		}
+		urls := []string{
+			getPutObjectURL("", bucketName, testCase.objectName),
+			getPutObjectPartURL("", bucketName, testCase.objectName, uploadID, "1"),
+		}
+		for _, urlStr := range urls {
+			req, err := newTestStreamingUnsignedTrailerRequest("PUT", urlStr, 64*humanize.KiByte, data,
+				credentials.AccessKey, testCase.secretKey, testCase.trailer, testCase.checksum)
+			if err != nil {
+				t.Fatalf("Test %d: Failed to create HTTP request: <ERROR> %v", i+1, err)
+			}
+
+			rec := httptest.NewRecorder()
+			apiRouter.ServeHTTP(rec, req)
+			if testCase.expectedErr == ErrNone {
+				if rec.Code != http.StatusOK {
+					t.Errorf("Test %d: %s: %s expected to succeed, but failed with %d: %s", i+1, instanceType, urlStr, rec.Code, rec.Body)
+				}
+				continue
+			}
+			var errXML APIErrorResponse
+			if err = xml.Unmarshal(rec.Body.Bytes(), &errXML); err != nil {
+				t.Fatalf("Test %d: %s: Failed to unmarshal error response: <ERROR> %v", i+1, instanceType, err)
+			}
+			expectedErr := getAPIError(testCase.expectedErr)
+			if rec.Code != expectedErr.HTTPStatusCode || errXML.Code != expectedErr.Code {
+				t.Errorf("Test %d: %s: %s expected to fail with %s, but failed with %d %s", i+1, instanceType,
+					urlStr, expectedErr.Code, rec.Code, errXML.Code)
+			}
+		}
+
+		// Objects are stored decoded, without the aws-chunked encoding.
+		if testCase.expectedErr == ErrNone {
+			var buf bytes.Buffer
+			if err = obj.GetObject(bucketName, testCase.objectName, 0, int64(len(data)), &buf, ""); err != nil {
+				t.Fatalf("Test %d: %s: Failed to read object: <ERROR> %v", i+1, instanceType, err)
+			}
+			if !bytes.Equal(buf.Bytes(), data) {
+				t.Errorf("Test %d: %s: Uploaded object does not match the data sent", i+1, instanceType)
+			}
+			objInfo, err := obj.GetObjectInfo(bucketName, testCase.objectName)
+			if err != nil {
+				t.Fatalf("Test %d: %s: Failed to stat object: <ERROR> %v", i+1, instanceType, err)
+			}
+			if objInfo.ContentEncoding != "" {
+				t.Errorf("Test %d: %s: Expected no content encoding, got %s", i+1, instanceType, objInfo.ContentEncoding)
+			}
+		}
+	}
+}
+
 // Wrapper for calling PutObject API handler tests for both XL multiple disks and FS single drive setup.
 func TestAPIPutObjectHandler(t *testing.T) {
 	defer DetectTestLeak(t)()
@@ -1129,7 +1584,7 @@ func testAPICopyObjectPartHandlerSanity(obj ObjectLayer, instanceType, bucketNam
 		})
 	}
 
-	result, err := obj.CompleteMultipartUpload(bucketName, testObject, uploadID, parts)
+	result, err := obj.CompleteMultipartUpload(context.Background(), bucketName, testObject, uploadID, parts)
 	if err != nil {
 		t.Fatalf("Test: %s complete multipart upload failed: <ERROR> %v", instanceType, err)
 	}
@@ -1864,9 +2319,9 @@ func testAPICopyObjectHandler(obj ObjectLayer, instanceType, bucketName string,
 	// Its necessary to set the "X-Amz-Copy-Source" header for the request to be accepted by the handler.
 	anonReq.Header.Set("X-Amz-Copy-Source", url.QueryEscape("/"+bucketName+"/"+anonObject))
 	// ExecObjectLayerAPIAnonTest - Calls the HTTP API handler using the anonymous request, validates the ErrAccessDeniedResponse,
-	// sets the bucket policy using the policy statement generated from `getWriteOnlyObjectStatement` so that the
-	// unsigned request goes through and its validated again.
-	ExecObjectLayerAPIAnonTest(t, obj, "TestAPICopyObjectHandler", bucketName, newCopyAnonObject, instanceType, apiRouter, anonReq, getWriteOnlyObjectStatement)
+	// sets the bucket policy using the policy statement generated from `getReadWriteObjectStatement` so that the
+	// unsigned request, which reads the source and writes the copy, goes through and its validated again.
+	ExecObjectLayerAPIAnonTest(t, obj, "TestAPICopyObjectHandler", bucketName, newCopyAnonObject, instanceType, apiRouter, anonReq, getReadWriteObjectStatement)
 
 	// HTTP request to test the case of `objectLayer` being set to `nil`.
 	// There is no need to use an existing bucket or valid input for creating the request,
@@ -2168,7 +2623,7 @@ func testAPICompleteMultipartHandler(obj ObjectLayer, instanceType, bucketName s
 	}
 	// Iterating over creatPartCases to generate multipart chunks.
 	for _, part := range parts {
-		_, err = obj.PutObjectPart(part.bucketName, part.objName, part.uploadID, part.PartID,
+		_, err = obj.PutObjectPart(context.Background(), part.bucketName, part.objName, part.uploadID, part.PartID,
 			mustGetHashReader(t, bytes.NewBufferString(part.inputReaderData), part.intputDataSize, part.inputMd5, ""))
 		if err != nil {
 			t.Fatalf("%s : %s", instanceType, err)
@@ -2523,7 +2978,7 @@ func testAPIAbortMultipartHandler(obj ObjectLayer, instanceType, bucketName stri
 	}
 	// Iterating over createPartCases to generate multipart chunks.
 	for _, part := range parts {
-		_, err = obj.PutObjectPart(part.bucketName, part.objName, part.uploadID, part.PartID,
+		_, err = obj.PutObjectPart(context.Background(), part.bucketName, part.objName, part.uploadID, part.PartID,
 			mustGetHashReader(t, bytes.NewBufferString(part.inputReaderData), part.intputDataSize, part.inputMd5, ""))
 		if err != nil {
 			t.Fatalf("%s : %s", instanceType, err)
@@ -3373,7 +3828,7 @@ func testAPIListObjectPartsHandler(obj ObjectLayer, instanceType, bucketName str
 	uploadIDCopy := uploadID
 
 	// create an object Part, will be used to test list object parts.
-	_, err = obj.PutObjectPart(bucketName, testObject, uploadID, 1, mustGetHashReader(t, bytes.NewReader([]byte("hello")), int64(len("hello")), "5d41402abc4b2a76b9719d911017c592", ""))
+	_, err = obj.PutObjectPart(context.Background(), bucketName, testObject, uploadID, 1, mustGetHashReader(t, bytes.NewReader([]byte("hello")), int64(len("hello")), "5d41402abc4b2a76b9719d911017c592", ""))
 	if err != nil {
 		t.Fatalf("Minio %s : %s.", instanceType, err)
 	}
@@ -3616,3 +4071,249 @@ func TestGetSourceIPAddress(t *testing.T) {
 		}
 	}
 }
+
+// Tests objects are encrypted with data keys sealed by the KMS when
+// SSE-S3 is requested.
+func TestAPISSES3Object(t *testing.T) {
+	defer func(kms KMS) { globalKMS = kms }(globalKMS)
+	ExecObjectLayerAPITest(t, testAPISSES3Object, []string{"CopyObject", "PutObject", "GetObject", "HeadObject", "NewMultipart"})
+}
+
+func testAPISSES3Object(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	kms := newTestKMS()
+	globalKMS = kms
+
+	// Sends a signed request with the given headers.
+	request := func(method, urlStr string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Minio %s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("Minio %s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	// Checks the object is returned decrypted along with the algorithm.
+	checkObject := func(objectName, algorithm string, content []byte) {
+		rec := request("GET", getGetObjectURL("", bucketName, objectName), nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Minio %s: Expected status 200 reading %s, got %d: %s", instanceType, objectName, rec.Code, rec.Body.String())
+		}
+		if !bytes.Equal(rec.Body.Bytes(), content) {
+			t.Errorf("Minio %s: Content of %s differs", instanceType, objectName)
+		}
+		if got := rec.Header().Get(SSEHeader); got != algorithm {
+			t.Errorf("Minio %s: Expected %s header %q, got %q", instanceType, objectName, algorithm, got)
+		}
+		for k := range rec.Header() {
+			if strings.HasPrefix(k, ReservedMetadataPrefix) {
+				t.Errorf("Minio %s: Unexpected internal metadata %s", instanceType, k)
+			}
+		}
+	}
+
+	// Spans several packages of 64KiB.
+	content := bytes.Repeat([]byte("0123456789abcdef"), 9000)
+	objectName := "encrypted"
+
+	rec := request("PUT", getPutObjectURL("", bucketName, objectName), content, map[string]string{SSEHeader: SSEAlgorithmAES256})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Minio %s: Expected status 200, got %d: %s", instanceType, rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get(SSEHeader) != SSEAlgorithmAES256 {
+		t.Errorf("Minio %s: Expected the algorithm to be returned", instanceType)
+	}
+
+	// The backend only holds encrypted content and sealed keys.
+	objInfo, err := obj.GetObjectInfo(bucketName, objectName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Size != encryptedSize(int64(len(content))) || !isSSES3Encrypted(objInfo.UserDefined) {
+		t.Fatalf("Minio %s: Expected the object to be encrypted, got %v", instanceType, objInfo.UserDefined)
+	}
+	var stored bytes.Buffer
+	if err = obj.GetObject(bucketName, objectName, 0, objInfo.Size, &stored, ""); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stored.Bytes(), content[:1024]) {
+		t.Fatalf("Minio %s: Expected the backend not to hold plain content", instanceType)
+	}
+
+	checkObject(objectName, SSEAlgorithmAES256, content)
+
+	// Ranges are decrypted too.
+	for _, r := range [][2]int{{65530, 131080}, {70000, len(content) - 1}} {
+		rec = request("GET", getGetObjectURL("", bucketName, objectName), nil, map[string]string{"Range": fmt.Sprintf("bytes=%d-%d", r[0], r[1])})
+		if rec.Code != http.StatusPartialContent || !bytes.Equal(rec.Body.Bytes(), content[r[0]:r[1]+1]) {
+			t.Errorf("Minio %s: Expected the range %v to be decrypted, got status %d", instanceType, r, rec.Code)
+		}
+	}
+	rec = request("GET", getGetObjectURL("", bucketName, objectName), nil, map[string]string{"Range": "bytes=65530-70000,131080-"})
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("Minio %s: Expected status 206, got %d", instanceType, rec.Code)
+	}
+	if contents, _ := readByteRangesResponse(t, rec); len(contents) != 2 || !bytes.Equal(contents[0], content[65530:70001]) || !bytes.Equal(contents[1], content[131080:]) {
+		t.Errorf("Minio %s: Expected several ranges to be decrypted", instanceType)
+	}
+
+	rec = request("HEAD", getHeadObjectURL("", bucketName, objectName), nil, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Length") != strconv.Itoa(len(content)) {
+		t.Errorf("Minio %s: Expected HEAD to return the decrypted size, got %d, %s", instanceType, rec.Code, rec.Header().Get("Content-Length"))
+	}
+	for k := range rec.Header() {
+		if strings.HasPrefix(k, ReservedMetadataPrefix) {
+			t.Errorf("Minio %s: Unexpected internal metadata %s", instanceType, k)
+		}
+	}
+
+	// SSE-C keys are not applicable to SSE-S3 objects.
+	rec = request("GET", getGetObjectURL("", bucketName, objectName), nil, map[string]string{SSECustomerAlgorithm: SSECustomerAlgorithmAES256})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Minio %s: Expected status 400, got %d", instanceType, rec.Code)
+	}
+
+	// Copies are decrypted unless encryption is requested.
+	rec = request("PUT", getCopyObjectURL("", bucketName, "plain-copy"), nil, map[string]string{"X-Amz-Copy-Source": bucketName + "/" + objectName})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Minio %s: Expected status 200, got %d: %s", instanceType, rec.Code, rec.Body.String())
+	}
+	checkObject("plain-copy", "", content)
+	rec = request("PUT", getCopyObjectURL("", bucketName, "encrypted-copy"), nil, map[string]string{
+		"X-Amz-Copy-Source": bucketName + "/plain-copy",
+		SSEHeader:           SSEAlgorithmKMS,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Minio %s: Expected status 200, got %d: %s", instanceType, rec.Code, rec.Body.String())
+	}
+	checkObject("encrypted-copy", SSEAlgorithmKMS, content)
+
+	// Copying an object onto itself seals its object key with the
+	// rotated master key.
+	kms.Rotate()
+	rec = request("PUT", getCopyObjectURL("", bucketName, objectName), nil, map[string]string{
+		"X-Amz-Copy-Source":        bucketName + "/" + objectName,
+		"X-Amz-Metadata-Directive": "REPLACE",
+		SSEHeader:                  SSEAlgorithmAES256,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Minio %s: Expected status 200, got %d: %s", instanceType, rec.Code, rec.Body.String())
+	}
+	if objInfo, err = obj.GetObjectInfo(bucketName, objectName); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(objInfo.UserDefined[ServerSideEncryptionKMSSealedKey], "test:v2:") {
+		t.Errorf("Minio %s: Expected the data key to be sealed by the latest master key, got %s", instanceType, objInfo.UserDefined[ServerSideEncryptionKMSSealedKey])
+	}
+	checkObject(objectName, SSEAlgorithmAES256, content)
+
+	// Replacing the metadata keeps the object encrypted.
+	rec = request("PUT", getCopyObjectURL("", bucketName, objectName), nil, map[string]string{
+		"X-Amz-Copy-Source":        bucketName + "/" + objectName,
+		"X-Amz-Metadata-Directive": "REPLACE",
+		"X-Amz-Meta-Color":         "blue",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Minio %s: Expected status 200, got %d: %s", instanceType, rec.Code, rec.Body.String())
+	}
+	checkObject(objectName, SSEAlgorithmAES256, content)
+
+	testCases := []struct {
+		method, url string
+		headers     map[string]string
+		status      int
+	}{
+		{"PUT", getPutObjectURL("", bucketName, "invalid"), map[string]string{SSEHeader: "DES"}, http.StatusBadRequest},
+		{"PUT", getPutObjectURL("", bucketName, "invalid"), map[string]string{SSEHeader: SSEAlgorithmKMS, SSEKMSKeyID: "my-key"}, http.StatusNotImplemented},
+		{"POST", getNewMultipartURL("", bucketName, "invalid"), map[string]string{SSEHeader: SSEAlgorithmAES256}, http.StatusNotImplemented},
+	}
+	for i, testCase := range testCases {
+		if rec = request(testCase.method, testCase.url, nil, testCase.headers); rec.Code != testCase.status {
+			t.Errorf("Test %d: Minio %s: Expected status %d, got %d", i+1, instanceType, testCase.status, rec.Code)
+		}
+	}
+
+	// SSE-S3 fails without KMS.
+	globalKMS = nil
+	if rec = request("PUT", getPutObjectURL("", bucketName, "invalid"), content, map[string]string{SSEHeader: SSEAlgorithmAES256}); rec.Code != http.StatusNotImplemented {
+		t.Errorf("Minio %s: Expected status 501, got %d", instanceType, rec.Code)
+	}
+}
+
+// Tests decoding and validating the parts of complete multipart upload requests.
+func TestParseCompleteMultipartUpload(t *testing.T) {
+	testCases := []struct {
+		body          string
+		expectedParts []CompletePart
+		expectedErr   APIErrorCode
+	}{
+		{`<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>"a"</ETag></Part><Part><PartNumber>2</PartNumber><ETag>b</ETag></Part></CompleteMultipartUpload>`,
+			[]CompletePart{{1, "a"}, {2, "b"}}, ErrNone},
+		{`<CompleteMultipartUpload xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Part><ETag>a</ETag><PartNumber>3</PartNumber></Part></CompleteMultipartUpload>`,
+			[]CompletePart{{3, "a"}}, ErrNone},
+		{`<CompleteMultipartUpload><Part><PartNumber>2</PartNumber><ETag>a</ETag></Part><Part><PartNumber>1</PartNumber><ETag>b</ETag></Part></CompleteMultipartUpload>`,
+			nil, ErrInvalidPartOrder},
+		{`<CompleteMultipartUpload><Part><PartNumber>0</PartNumber><ETag>a</ETag></Part></CompleteMultipartUpload>`,
+			nil, ErrInvalidPart},
+		{`<CompleteMultipartUpload><Part><PartNumber>10001</PartNumber><ETag>a</ETag></Part></CompleteMultipartUpload>`,
+			nil, ErrInvalidPart},
+		{`<CompleteMultipartUpload><Other><Part><PartNumber>1</PartNumber></Part></Other></CompleteMultipartUpload>`,
+			nil, ErrMalformedXML},
+		{`<CompleteMultipartUpload><Part><PartNumber>1</PartNumber>`, nil, ErrMalformedXML},
+		{`<CompleteMultipartUpload></CompleteMultipartUpload>`, nil, ErrMalformedXML},
+		{``, nil, ErrMalformedXML},
+	}
+	for i, testCase := range testCases {
+		parts, err := parseCompleteMultipartUpload(strings.NewReader(testCase.body))
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if !reflect.DeepEqual(parts, testCase.expectedParts) {
+			t.Errorf("Test %d: Expected parts %v, got %v", i+1, testCase.expectedParts, parts)
+		}
+	}
+}
+
+// Tests that long multipart completions send whitespace before the response body.
+func TestKeepCompleteMultipartAlive(t *testing.T) {
+	encodedResponse := encodeResponse(generateCompleteMultpartUploadResponse("bucket", "object", "/bucket/object", "etag"))
+
+	// Fast completions do not start the response.
+	rec := httptest.NewRecorder()
+	doneCh := make(chan struct{})
+	startedCh := keepCompleteMultipartAlive(rec, time.Hour, doneCh)
+	close(doneCh)
+	if <-startedCh || rec.Body.Len() != 0 {
+		t.Fatalf("Expected the response not to be started, got %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	doneCh = make(chan struct{})
+	startedCh = keepCompleteMultipartAlive(rec, time.Millisecond, doneCh)
+	time.Sleep(50 * time.Millisecond)
+	close(doneCh)
+	if !<-startedCh {
+		t.Fatal("Expected the response to be started")
+	}
+	writeCompleteMultipartBody(rec, encodedResponse)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, xml.Header+" ") {
+		t.Errorf("Expected the XML header followed by whitespace, got %q", body)
+	}
+	var response CompleteMultipartUploadResponse
+	if err := xml.Unmarshal(rec.Body.Bytes(), &response); err != nil || response.ETag != "etag" {
+		t.Errorf("Unexpected response %q, %v", body, err)
+	}
+}
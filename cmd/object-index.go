@@ -0,0 +1,333 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/wildcard"
+)
+
+const (
+	// Environment variable turning on the index of object names and
+	// metadata searched by the admin API and the browser, "on" or "off".
+	objectIndexEnv = "MINIO_OBJECT_INDEX"
+
+	// In a distributed setup the index of a bucket is rebuilt once it
+	// is older than this, to pick up writes served by other servers.
+	objectIndexRebuildInterval = 15 * time.Minute
+
+	// Maximum number of objects returned by a single search.
+	maxObjectSearchKeys = 1000
+
+	// Prefix of the keys of user metadata.
+	userMetadataPrefix = "X-Amz-Meta-"
+)
+
+var errObjectIndexDisabled = errors.New("object index is not enabled, set MINIO_OBJECT_INDEX=on to search objects")
+
+// Global index of object names and metadata, only initialized by the
+// server if enabled by MINIO_OBJECT_INDEX.
+var globalObjectIndex *objectIndex
+
+// Turns on the object index from the environment.
+func handleObjectIndexEnv() {
+	switch value := os.Getenv(objectIndexEnv); value {
+	case "", "off":
+		globalIsObjectIndex = false
+	case "on":
+		globalIsObjectIndex = true
+	default:
+		fatalIf(fmt.Errorf("invalid value"), "Unknown value ‘%s’ in %s environment variable.", value, objectIndexEnv)
+	}
+}
+
+// indexedObject - name and metadata of an object in the index.
+type indexedObject struct {
+	Name        string            `json:"name"`
+	Size        int64             `json:"size"`
+	ETag        string            `json:"etag"`
+	ModTime     time.Time         `json:"lastModified"`
+	ContentType string            `json:"contentType"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// Returns the key of user metadata, with or without the x-amz-meta-
+// prefix, as stored in the index.
+func getUserMetadataKey(key string) string {
+	key = http.CanonicalHeaderKey(key)
+	if !strings.HasPrefix(key, userMetadataPrefix) {
+		key = userMetadataPrefix + key
+	}
+	return key
+}
+
+func newIndexedObject(objInfo ObjectInfo) indexedObject {
+	obj := indexedObject{
+		Name:        objInfo.Name,
+		Size:        objInfo.Size,
+		ETag:        objInfo.ETag,
+		ModTime:     objInfo.ModTime,
+		ContentType: objInfo.ContentType,
+	}
+	for k, v := range objInfo.UserDefined {
+		if k = http.CanonicalHeaderKey(k); strings.HasPrefix(k, userMetadataPrefix) {
+			if obj.Metadata == nil {
+				obj.Metadata = make(map[string]string)
+			}
+			obj.Metadata[k] = v
+		}
+	}
+	return obj
+}
+
+// objectSearchQuery - filters of a search, all filters must match.
+// The content type and metadata values are wildcard patterns, e.g.
+// "image/*". Metadata keys are user metadata keys, with or without the
+// x-amz-meta- prefix.
+type objectSearchQuery struct {
+	Prefix      string            `json:"prefix"`
+	Suffix      string            `json:"suffix"`
+	ContentType string            `json:"contentType"`
+	Metadata    map[string]string `json:"metadata"`
+
+	// Objects sorted after the marker are returned, up to maxKeys.
+	Marker  string `json:"marker"`
+	MaxKeys int    `json:"maxKeys"`
+}
+
+// match - returns whether the object matches all filters.
+func (q objectSearchQuery) match(obj indexedObject) bool {
+	if !strings.HasPrefix(obj.Name, q.Prefix) || !strings.HasSuffix(obj.Name, q.Suffix) {
+		return false
+	}
+	if q.ContentType != "" && !wildcard.MatchSimple(q.ContentType, obj.ContentType) {
+		return false
+	}
+	for k, pattern := range q.Metadata {
+		v, ok := obj.Metadata[getUserMetadataKey(k)]
+		if !ok || !wildcard.MatchSimple(pattern, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// objectSearchResult - objects matching a search, sorted by name. More
+// objects are found after NextMarker if the result is truncated.
+type objectSearchResult struct {
+	Objects     []indexedObject `json:"objects"`
+	IsTruncated bool            `json:"isTruncated"`
+	NextMarker  string          `json:"nextMarker,omitempty"`
+}
+
+// bucketObjectIndex - objects of a bucket, built by listing the bucket
+// once and updated with the writes served by this server.
+type bucketObjectIndex struct {
+	// Held while the bucket is listed, searches wait for the index.
+	buildMu sync.Mutex
+
+	mu      sync.Mutex
+	objects map[string]indexedObject // nil until built.
+	built   time.Time
+
+	// Writes served while the bucket is listed, applied to the
+	// listed objects as they may be missed by the listing.
+	building bool
+	pending  []objectIndexUpdate
+}
+
+// objectIndexUpdate - a write to an object, a put or a delete.
+type objectIndexUpdate struct {
+	name    string
+	obj     indexedObject
+	deleted bool
+}
+
+func (u objectIndexUpdate) apply(objects map[string]indexedObject) {
+	if u.deleted {
+		delete(objects, u.name)
+	} else {
+		objects[u.name] = u.obj
+	}
+}
+
+func (b *bucketObjectIndex) update(u objectIndexUpdate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.building {
+		b.pending = append(b.pending, u)
+	}
+	if b.objects != nil {
+		u.apply(b.objects)
+	}
+}
+
+// needsBuild - returns whether the bucket must be listed before it is
+// searched.
+func (b *bucketObjectIndex) needsBuild() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.objects == nil {
+		return true
+	}
+	return globalIsDistXL && UTCNow().Sub(b.built) >= objectIndexRebuildInterval
+}
+
+// build - lists all objects of the bucket into the index unless it was
+// built by a concurrent search meanwhile.
+func (b *bucketObjectIndex) build(objAPI ObjectLayer, bucket string) error {
+	b.buildMu.Lock()
+	defer b.buildMu.Unlock()
+
+	if !b.needsBuild() {
+		return nil
+	}
+
+	b.mu.Lock()
+	b.building = true
+	b.pending = nil
+	b.mu.Unlock()
+
+	objects := make(map[string]indexedObject)
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			b.mu.Lock()
+			b.building = false
+			b.pending = nil
+			b.mu.Unlock()
+			return err
+		}
+		for _, objInfo := range result.Objects {
+			objects[objInfo.Name] = newIndexedObject(objInfo)
+		}
+		if !result.IsTruncated || result.NextMarker == "" {
+			break
+		}
+		marker = result.NextMarker
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, u := range b.pending {
+		u.apply(objects)
+	}
+	b.objects = objects
+	b.built = UTCNow()
+	b.building = false
+	b.pending = nil
+	return nil
+}
+
+// search - returns the objects matching the query, sorted by name.
+func (b *bucketObjectIndex) search(q objectSearchQuery) objectSearchResult {
+	b.mu.Lock()
+	var objects []indexedObject
+	for name, obj := range b.objects {
+		if name > q.Marker && q.match(obj) {
+			objects = append(objects, obj)
+		}
+	}
+	b.mu.Unlock()
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name < objects[j].Name
+	})
+
+	maxKeys := q.MaxKeys
+	if maxKeys <= 0 || maxKeys > maxObjectSearchKeys {
+		maxKeys = maxObjectSearchKeys
+	}
+	result := objectSearchResult{Objects: objects}
+	if len(objects) > maxKeys {
+		result.Objects = objects[:maxKeys]
+		result.IsTruncated = true
+		result.NextMarker = objects[maxKeys-1].Name
+	}
+	return result
+}
+
+// objectIndex - indexes of all buckets, built on the first search of a
+// bucket. Each server indexes the writes it serves, in a distributed
+// setup indexes are rebuilt periodically to pick up writes served by
+// other servers.
+type objectIndex struct {
+	sync.Mutex
+	buckets map[string]*bucketObjectIndex
+}
+
+func newObjectIndex() *objectIndex {
+	return &objectIndex{buckets: make(map[string]*bucketObjectIndex)}
+}
+
+func (idx *objectIndex) getBucket(bucket string) *bucketObjectIndex {
+	idx.Lock()
+	defer idx.Unlock()
+
+	b, ok := idx.buckets[bucket]
+	if !ok {
+		b = &bucketObjectIndex{}
+		idx.buckets[bucket] = b
+	}
+	return b
+}
+
+// Update - applies a put or a delete of an object to the index of its
+// bucket.
+func (idx *objectIndex) Update(event eventData) {
+	var u objectIndexUpdate
+	switch {
+	case isObjectCreatedEvent(event.Type):
+		u = objectIndexUpdate{name: event.ObjInfo.Name, obj: newIndexedObject(event.ObjInfo)}
+	case event.Type == ObjectRemovedDelete:
+		u = objectIndexUpdate{name: event.ObjInfo.Name, deleted: true}
+	default:
+		return
+	}
+	idx.getBucket(event.Bucket).update(u)
+}
+
+// Remove - drops the index of a bucket, used when the bucket is deleted.
+func (idx *objectIndex) Remove(bucket string) {
+	idx.Lock()
+	defer idx.Unlock()
+
+	delete(idx.buckets, bucket)
+}
+
+// Search - returns the objects of a bucket matching the query, the
+// bucket is listed if it was not indexed yet.
+func (idx *objectIndex) Search(objAPI ObjectLayer, bucket string, q objectSearchQuery) (objectSearchResult, error) {
+	b := idx.getBucket(bucket)
+	if b.needsBuild() {
+		if err := b.build(objAPI, bucket); err != nil {
+			return objectSearchResult{}, err
+		}
+	}
+	return b.search(q), nil
+}
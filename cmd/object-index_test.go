@@ -0,0 +1,142 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Tests matching indexed objects against search filters.
+func TestObjectSearchQueryMatch(t *testing.T) {
+	obj := newIndexedObject(ObjectInfo{
+		Name:        "photos/2018/beach.jpg",
+		ContentType: "image/jpeg",
+		UserDefined: map[string]string{
+			"X-Amz-Meta-Camera": "Nikon D750",
+			"content-type":      "image/jpeg",
+		},
+	})
+	if len(obj.Metadata) != 1 {
+		t.Fatalf("Expected only user metadata to be indexed, got %v", obj.Metadata)
+	}
+
+	testCases := []struct {
+		query objectSearchQuery
+		match bool
+	}{
+		{objectSearchQuery{}, true},
+		{objectSearchQuery{Prefix: "photos/"}, true},
+		{objectSearchQuery{Prefix: "videos/"}, false},
+		{objectSearchQuery{Suffix: ".jpg"}, true},
+		{objectSearchQuery{Suffix: ".png"}, false},
+		{objectSearchQuery{ContentType: "image/*"}, true},
+		{objectSearchQuery{ContentType: "video/*"}, false},
+		{objectSearchQuery{Metadata: map[string]string{"camera": "Nikon*"}}, true},
+		{objectSearchQuery{Metadata: map[string]string{"X-Amz-Meta-Camera": "Nikon D750"}}, true},
+		{objectSearchQuery{Metadata: map[string]string{"camera": "Canon*"}}, false},
+		{objectSearchQuery{Metadata: map[string]string{"lens": "*"}}, false},
+		{objectSearchQuery{Prefix: "photos/", ContentType: "image/png"}, false},
+	}
+	for i, testCase := range testCases {
+		if match := testCase.query.match(obj); match != testCase.match {
+			t.Errorf("Test %d: Expected match %v, got %v", i+1, testCase.match, match)
+		}
+	}
+}
+
+// Wrapper for calling object index tests for both XL multiple disks and single node setup.
+func TestObjectIndex(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectIndex)
+}
+
+// Tests searching objects indexed by listing a bucket and by writes.
+func testObjectIndex(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	objects := []struct {
+		name     string
+		metadata map[string]string
+	}{
+		{"docs/report.pdf", map[string]string{"content-type": "application/pdf"}},
+		{"photos/beach.jpg", map[string]string{"content-type": "image/jpeg", "X-Amz-Meta-Camera": "Nikon"}},
+		{"photos/city.jpg", map[string]string{"content-type": "image/jpeg", "X-Amz-Meta-Camera": "Canon"}},
+	}
+	for _, object := range objects {
+		data := []byte(object.name)
+		if _, err := obj.PutObject(bucket, object.name, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), object.metadata); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+
+	idx := newObjectIndex()
+	result, err := idx.Search(obj, bucket, objectSearchQuery{ContentType: "image/*"})
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Objects) != 2 || result.Objects[0].Name != "photos/beach.jpg" || result.Objects[1].Name != "photos/city.jpg" {
+		t.Fatalf("%s: Unexpected objects %v", instanceType, result.Objects)
+	}
+	result, err = idx.Search(obj, bucket, objectSearchQuery{Metadata: map[string]string{"camera": "Nikon"}})
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].Name != "photos/beach.jpg" {
+		t.Fatalf("%s: Unexpected objects %v", instanceType, result.Objects)
+	}
+
+	// Writes are searched without listing the bucket again.
+	idx.Update(eventData{
+		Type:   ObjectCreatedPut,
+		Bucket: bucket,
+		ObjInfo: ObjectInfo{
+			Name:        "photos/forest.jpg",
+			ContentType: "image/jpeg",
+			UserDefined: map[string]string{"X-Amz-Meta-Camera": "Nikon"},
+		},
+	})
+	idx.Update(eventData{
+		Type:    ObjectRemovedDelete,
+		Bucket:  bucket,
+		ObjInfo: ObjectInfo{Name: "photos/beach.jpg"},
+	})
+	result, err = idx.Search(obj, bucket, objectSearchQuery{Suffix: ".jpg"})
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Objects) != 2 || result.Objects[0].Name != "photos/city.jpg" || result.Objects[1].Name != "photos/forest.jpg" {
+		t.Fatalf("%s: Unexpected objects %v", instanceType, result.Objects)
+	}
+
+	// Results are paged by marker.
+	result, err = idx.Search(obj, bucket, objectSearchQuery{MaxKeys: 2})
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Objects) != 2 || !result.IsTruncated || result.NextMarker != "photos/city.jpg" {
+		t.Fatalf("%s: Unexpected result %+v", instanceType, result)
+	}
+	result, err = idx.Search(obj, bucket, objectSearchQuery{Marker: result.NextMarker, MaxKeys: 2})
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Objects) != 1 || result.IsTruncated || result.Objects[0].Name != "photos/forest.jpg" {
+		t.Fatalf("%s: Unexpected result %+v", instanceType, result)
+	}
+}
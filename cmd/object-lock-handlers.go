@@ -0,0 +1,305 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Maximum size of object lock configurations, retentions and legal
+// holds sent by clients.
+const maxObjectLockRequestSize = 64 * 1024
+
+// PutBucketObjectLockConfigHandler - PUT /bucket?object-lock
+// ----------
+// Enables object lock for the bucket and sets its default retention.
+// Once enabled, object lock cannot be disabled, only the default
+// retention can be changed or removed.
+func (api objectAPIHandlers) PutBucketObjectLockConfigHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := mux.Vars(r)["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+	if globalBucketObjectLock == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:PutBucketObjectLockConfiguration", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	var lockConfig ObjectLockConfiguration
+	if err := xmlDecoder(r.Body, &lockConfig, maxObjectLockRequestSize); err != nil {
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+	cfg, err := parseObjectLockConfiguration(lockConfig)
+	if err != nil {
+		writeErrorResponse(w, ErrInvalidObjectLockConfiguration, r.URL)
+		return
+	}
+
+	if err = saveObjectLockConfig(bucket, cfg, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	globalBucketObjectLock.Set(bucket, cfg)
+
+	// Notify all peers (including self) to reload the config.
+	S3PeersUpdateBucketConfig(bucket, bucketObjectLockConfig)
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetBucketObjectLockConfigHandler - GET /bucket?object-lock
+// ----------
+// Returns the object lock configuration of the bucket.
+func (api objectAPIHandlers) GetBucketObjectLockConfigHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := mux.Vars(r)["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+	if globalBucketObjectLock == nil {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetBucketObjectLockConfiguration", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	cfg, ok := globalBucketObjectLock.Get(bucket)
+	if !ok {
+		writeErrorResponse(w, ErrObjectLockConfigurationNotFound, r.URL)
+		return
+	}
+	writeSuccessResponseXML(w, encodeResponse(cfg.toObjectLockConfiguration()))
+}
+
+// Returns the info of an object of a bucket with object lock enabled,
+// for the retention and legal hold handlers.
+func getObjectLockInfo(objectAPI ObjectLayer, bucket, object string) (ObjectInfo, APIErrorCode) {
+	if globalBucketObjectLock == nil {
+		return ObjectInfo{}, ErrNotImplemented
+	}
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		return ObjectInfo{}, toAPIErrorCode(err)
+	}
+	if _, ok := globalBucketObjectLock.Get(bucket); !ok {
+		return ObjectInfo{}, ErrMissingObjectLockConfiguration
+	}
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, toAPIErrorCode(err)
+	}
+	return objInfo, ErrNone
+}
+
+// PutObjectRetentionHandler - PUT /bucket/object?retention
+// ----------
+// Sets the retention of an object. Compliance retention can only be
+// extended, governance retention shortened or removed by requests
+// allowed to bypass it.
+func (api objectAPIHandlers) PutObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:PutObjectRetention", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	var retention ObjectRetention
+	if err := xmlDecoder(r.Body, &retention, maxObjectLockRequestSize); err != nil {
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+	now := UTCNow()
+	lock, s3Error := parseRetention(retention.Mode, retention.RetainUntilDate, now)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	objInfo, s3Error := getObjectLockInfo(objectAPI, bucket, object)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	existing := getObjectLock(objInfo.UserDefined)
+	if existing.isRetained(now) {
+		shortened := lock.Mode == "" || lock.RetainUntil.Before(existing.RetainUntil)
+		if existing.Mode == retentionCompliance && (shortened || lock.Mode != retentionCompliance) {
+			writeErrorResponse(w, ErrObjectLocked, r.URL)
+			return
+		}
+		if existing.Mode == retentionGovernance && shortened && !isGovernanceBypassed(r, bucket) {
+			writeErrorResponse(w, ErrObjectLocked, r.URL)
+			return
+		}
+	}
+	lock.LegalHold = existing.LegalHold
+
+	if _, err := setObjectLockMetadata(objectAPI, objInfo, lock); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetObjectRetentionHandler - GET /bucket/object?retention
+// ----------
+// Returns the retention of an object.
+func (api objectAPIHandlers) GetObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetObjectRetention", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	objInfo, s3Error := getObjectLockInfo(objectAPI, bucket, object)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	lock := getObjectLock(objInfo.UserDefined)
+	if lock.Mode == "" {
+		writeErrorResponse(w, ErrNoSuchObjectLockConfiguration, r.URL)
+		return
+	}
+	writeSuccessResponseXML(w, encodeResponse(ObjectRetention{
+		Mode:            lock.Mode,
+		RetainUntilDate: lock.RetainUntil.UTC().Format(time.RFC3339),
+	}))
+}
+
+// PutObjectLegalHoldHandler - PUT /bucket/object?legal-hold
+// ----------
+// Places or removes a legal hold of an object, objects under legal
+// hold cannot be overwritten or deleted regardless of their retention.
+func (api objectAPIHandlers) PutObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:PutObjectLegalHold", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	var legalHold ObjectLegalHold
+	if err := xmlDecoder(r.Body, &legalHold, maxObjectLockRequestSize); err != nil {
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+	if legalHold.Status != legalHoldOn && legalHold.Status != legalHoldOff {
+		writeErrorResponse(w, ErrInvalidObjectRetention, r.URL)
+		return
+	}
+
+	objInfo, s3Error := getObjectLockInfo(objectAPI, bucket, object)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	lock := getObjectLock(objInfo.UserDefined)
+	lock.LegalHold = legalHold.Status == legalHoldOn
+	if _, err := setObjectLockMetadata(objectAPI, objInfo, lock); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetObjectLegalHoldHandler - GET /bucket/object?legal-hold
+// ----------
+// Returns the legal hold status of an object.
+func (api objectAPIHandlers) GetObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetObjectLegalHold", globalServerConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	objInfo, s3Error := getObjectLockInfo(objectAPI, bucket, object)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	status := legalHoldOff
+	if getObjectLock(objInfo.UserDefined).LegalHold {
+		status = legalHoldOn
+	}
+	writeSuccessResponseXML(w, encodeResponse(ObjectLegalHold{Status: status}))
+}
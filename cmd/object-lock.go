@@ -0,0 +1,418 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+)
+
+const (
+	// Object lock config of a bucket, persisted under the bucket config prefix.
+	bucketObjectLockConfig = "object-lock.json"
+
+	// Current version of object lock configs.
+	bucketObjectLockVersion = "1"
+
+	// Environment variable turning on the WORM mode, "on" or "off".
+	wormEnv = "MINIO_WORM"
+)
+
+// Object lock request headers, the object lock of an object is saved
+// in its metadata under the same names and returned by GET and HEAD.
+const (
+	amzObjectLockMode            = "X-Amz-Object-Lock-Mode"
+	amzObjectLockRetainUntilDate = "X-Amz-Object-Lock-Retain-Until-Date"
+	amzObjectLockLegalHold       = "X-Amz-Object-Lock-Legal-Hold"
+	amzBypassGovernanceRetention = "X-Amz-Bypass-Governance-Retention"
+	amzBucketObjectLockEnabled   = "X-Amz-Bucket-Object-Lock-Enabled"
+)
+
+// Retention modes, objects under governance retention are deleted
+// by users allowed to bypass it, objects under compliance retention
+// by nobody until the retention expires.
+const (
+	retentionGovernance = "GOVERNANCE"
+	retentionCompliance = "COMPLIANCE"
+)
+
+// Legal hold status values.
+const (
+	legalHoldOn  = "ON"
+	legalHoldOff = "OFF"
+)
+
+// Value of ObjectLockEnabled of buckets with object lock.
+const objectLockEnabled = "Enabled"
+
+// Turns on the WORM mode from the environment.
+func handleWORMEnv() {
+	switch value := os.Getenv(wormEnv); value {
+	case "", "off":
+	case "on":
+		globalWORMEnabled = true
+	default:
+		fatalIf(fmt.Errorf("invalid value"), "Unknown value ‘%s’ in %s environment variable.", value, wormEnv)
+	}
+}
+
+// DefaultRetention - retention of objects uploaded without one.
+type DefaultRetention struct {
+	Mode  string `xml:"Mode"`
+	Days  int    `xml:"Days,omitempty"`
+	Years int    `xml:"Years,omitempty"`
+}
+
+// ObjectLockRule - default retention of a bucket.
+type ObjectLockRule struct {
+	DefaultRetention DefaultRetention `xml:"DefaultRetention"`
+}
+
+// ObjectLockConfiguration - object lock configuration of a bucket,
+// set and returned by the object-lock subresource.
+type ObjectLockConfiguration struct {
+	XMLName           xml.Name        `xml:"ObjectLockConfiguration"`
+	ObjectLockEnabled string          `xml:"ObjectLockEnabled"`
+	Rule              *ObjectLockRule `xml:"Rule,omitempty"`
+}
+
+// ObjectRetention - retention of an object, set and returned by the
+// retention subresource.
+type ObjectRetention struct {
+	XMLName         xml.Name `xml:"Retention"`
+	Mode            string   `xml:"Mode,omitempty"`
+	RetainUntilDate string   `xml:"RetainUntilDate,omitempty"`
+}
+
+// ObjectLegalHold - legal hold of an object, set and returned by the
+// legal-hold subresource.
+type ObjectLegalHold struct {
+	XMLName xml.Name `xml:"LegalHold"`
+	Status  string   `xml:"Status"`
+}
+
+// objectLockConfig - object lock config of a bucket, only buckets
+// with object lock enabled have one and it cannot be removed.
+type objectLockConfig struct {
+	Version string `json:"version"`
+	Mode    string `json:"mode,omitempty"`
+	Days    int    `json:"days,omitempty"`
+	Years   int    `json:"years,omitempty"`
+}
+
+// Validate - checks the default retention of the config, if any.
+func (c objectLockConfig) Validate() error {
+	if c.Mode == "" {
+		if c.Days != 0 || c.Years != 0 {
+			return fmt.Errorf("Default retention needs a mode")
+		}
+		return nil
+	}
+	if c.Mode != retentionGovernance && c.Mode != retentionCompliance {
+		return fmt.Errorf("Unknown retention mode %q", c.Mode)
+	}
+	if c.Days < 0 || c.Years < 0 || (c.Days == 0) == (c.Years == 0) {
+		return fmt.Errorf("Default retention needs either days or years")
+	}
+	return nil
+}
+
+// retainUntil - returns the end of the default retention of objects
+// uploaded at now, the zero time if there is none.
+func (c objectLockConfig) retainUntil(now time.Time) time.Time {
+	if c.Mode == "" {
+		return time.Time{}
+	}
+	return now.AddDate(c.Years, 0, c.Days)
+}
+
+// parseObjectLockConfiguration - converts an object lock configuration
+// set by a client into the config of a bucket.
+func parseObjectLockConfiguration(lockConfig ObjectLockConfiguration) (cfg objectLockConfig, err error) {
+	if lockConfig.ObjectLockEnabled != objectLockEnabled {
+		return cfg, fmt.Errorf("Object lock cannot be disabled")
+	}
+	if lockConfig.Rule != nil {
+		cfg.Mode = lockConfig.Rule.DefaultRetention.Mode
+		cfg.Days = lockConfig.Rule.DefaultRetention.Days
+		cfg.Years = lockConfig.Rule.DefaultRetention.Years
+		if cfg.Mode == "" {
+			return cfg, fmt.Errorf("Default retention needs a mode")
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// toObjectLockConfiguration - converts the config of a bucket into
+// the object lock configuration returned to clients.
+func (c objectLockConfig) toObjectLockConfiguration() ObjectLockConfiguration {
+	lockConfig := ObjectLockConfiguration{ObjectLockEnabled: objectLockEnabled}
+	if c.Mode != "" {
+		lockConfig.Rule = &ObjectLockRule{DefaultRetention{Mode: c.Mode, Days: c.Days, Years: c.Years}}
+	}
+	return lockConfig
+}
+
+// Persists the object lock config of a bucket to object layer.
+func saveObjectLockConfig(bucket string, cfg objectLockConfig, objAPI ObjectLayer) error {
+	cfg.Version = bucketObjectLockVersion
+	return saveBucketConfig(bucket, bucketObjectLockConfig, cfg, objAPI)
+}
+
+// bucketObjectLock - object lock configs of all buckets with object
+// lock enabled.
+type bucketObjectLock struct {
+	*bucketConfigCache
+}
+
+func newBucketObjectLock() *bucketObjectLock {
+	return &bucketObjectLock{newBucketConfigCache(bucketObjectLockConfig, func() interface{} { return &objectLockConfig{} })}
+}
+
+// Global object lock configs of buckets, only initialized by the
+// server, gateways do not support object lock.
+var globalBucketObjectLock *bucketObjectLock
+
+// Set - sets the object lock config of a bucket on this server.
+func (l *bucketObjectLock) Set(bucket string, cfg objectLockConfig) {
+	l.set(bucket, &cfg)
+}
+
+// Get - returns the object lock config of a bucket, false if object
+// lock is not enabled for the bucket.
+func (l *bucketObjectLock) Get(bucket string) (objectLockConfig, bool) {
+	if l == nil {
+		return objectLockConfig{}, false
+	}
+	cfg, ok := l.get(bucket)
+	if !ok {
+		return objectLockConfig{}, false
+	}
+	return *cfg.(*objectLockConfig), true
+}
+
+// isObjectLockActive - returns true if existing objects of the bucket
+// may be protected from overwrites and deletes, by the WORM mode or
+// the object lock of the bucket.
+func isObjectLockActive(bucket string) bool {
+	if isMinioMetaBucketName(bucket) {
+		return false
+	}
+	if globalWORMEnabled {
+		return true
+	}
+	_, ok := globalBucketObjectLock.Get(bucket)
+	return ok
+}
+
+// objectLock - object lock of an object saved in its metadata.
+type objectLock struct {
+	Mode        string
+	RetainUntil time.Time
+	LegalHold   bool
+}
+
+// getObjectLock - returns the object lock saved in object metadata.
+func getObjectLock(metadata map[string]string) (lock objectLock) {
+	lock.Mode = metadata[amzObjectLockMode]
+	lock.RetainUntil, _ = time.Parse(time.RFC3339, metadata[amzObjectLockRetainUntilDate])
+	lock.LegalHold = metadata[amzObjectLockLegalHold] == legalHoldOn
+	return lock
+}
+
+// isRetained - returns true if the retention of the object did not
+// expire at now.
+func (lock objectLock) isRetained(now time.Time) bool {
+	return lock.Mode != "" && now.Before(lock.RetainUntil)
+}
+
+// isLocked - returns true if the object cannot be overwritten or
+// deleted at now, governance retention is bypassed if allowed.
+func (lock objectLock) isLocked(now time.Time, bypassGovernance bool) bool {
+	if lock.LegalHold {
+		return true
+	}
+	if !lock.isRetained(now) {
+		return false
+	}
+	return lock.Mode == retentionCompliance || !bypassGovernance
+}
+
+// setObjectLock - saves the object lock into object metadata,
+// replacing the one saved before.
+func setObjectLock(metadata map[string]string, lock objectLock) {
+	delete(metadata, amzObjectLockMode)
+	delete(metadata, amzObjectLockRetainUntilDate)
+	delete(metadata, amzObjectLockLegalHold)
+	if lock.Mode != "" {
+		metadata[amzObjectLockMode] = lock.Mode
+		metadata[amzObjectLockRetainUntilDate] = lock.RetainUntil.UTC().Format(time.RFC3339)
+	}
+	if lock.LegalHold {
+		metadata[amzObjectLockLegalHold] = legalHoldOn
+	}
+}
+
+// parseRetention - parses the retention mode and date of an object,
+// both set or both empty.
+func parseRetention(mode, retainUntilDate string, now time.Time) (lock objectLock, s3Err APIErrorCode) {
+	if mode == "" && retainUntilDate == "" {
+		return lock, ErrNone
+	}
+	if mode != retentionGovernance && mode != retentionCompliance {
+		return lock, ErrInvalidObjectRetention
+	}
+	retainUntil, err := time.Parse(time.RFC3339, retainUntilDate)
+	if err != nil {
+		return lock, ErrInvalidObjectRetention
+	}
+	if !now.Before(retainUntil) {
+		return lock, ErrPastObjectLockRetainDate
+	}
+	lock.Mode, lock.RetainUntil = mode, retainUntil
+	return lock, ErrNone
+}
+
+// parseLegalHold - parses the legal hold status of an object.
+func parseLegalHold(status string) (bool, APIErrorCode) {
+	switch status {
+	case legalHoldOn:
+		return true, ErrNone
+	case legalHoldOff, "":
+		return false, ErrNone
+	}
+	return false, ErrInvalidObjectRetention
+}
+
+// getObjectLockFromHeader - returns the object lock of an object
+// uploaded to the bucket, set by the object lock headers or else by
+// the default retention of the bucket. Object lock headers are only
+// allowed for buckets with object lock enabled.
+func getObjectLockFromHeader(header http.Header, bucket string) (lock objectLock, s3Err APIErrorCode) {
+	mode := header.Get(amzObjectLockMode)
+	retainUntilDate := header.Get(amzObjectLockRetainUntilDate)
+	legalHold := header.Get(amzObjectLockLegalHold)
+
+	cfg, ok := globalBucketObjectLock.Get(bucket)
+	if !ok {
+		if mode != "" || retainUntilDate != "" || legalHold != "" {
+			return lock, ErrMissingObjectLockConfiguration
+		}
+		return lock, ErrNone
+	}
+
+	now := UTCNow()
+	if lock, s3Err = parseRetention(mode, retainUntilDate, now); s3Err != ErrNone {
+		return lock, s3Err
+	}
+	if lock.Mode == "" && cfg.Mode != "" {
+		lock.Mode, lock.RetainUntil = cfg.Mode, cfg.retainUntil(now)
+	}
+	lock.LegalHold, s3Err = parseLegalHold(legalHold)
+	return lock, s3Err
+}
+
+// isGovernanceBypassed - returns true if the request asks to bypass
+// governance retention and is signed by a user allowed to.
+func isGovernanceBypassed(r *http.Request, bucket string) bool {
+	if r == nil {
+		return false
+	}
+	if bypass, _ := strconv.ParseBool(r.Header.Get(amzBypassGovernanceRetention)); !bypass {
+		return false
+	}
+	switch getRequestAuthType(r) {
+	case authTypeSigned, authTypePresigned, authTypeSignedV2, authTypePresignedV2, authTypeStreamingSigned:
+		return enforceSessionPolicy(r, bucket, "s3:BypassGovernanceRetention") == ErrNone
+	}
+	return false
+}
+
+// checkObjectLock - fails with ObjectLocked if the object exists and
+// the WORM mode or its object lock keeps it from being overwritten or
+// deleted by the request, which may be nil for requests of the server.
+func checkObjectLock(objAPI ObjectLayer, bucket, object string, r *http.Request) error {
+	if !isObjectLockActive(bucket) {
+		return nil
+	}
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if globalWORMEnabled || getObjectLock(objInfo.UserDefined).isLocked(UTCNow(), isGovernanceBypassed(r, bucket)) {
+		return errors.Trace(ObjectLocked{Bucket: bucket, Object: object})
+	}
+	return nil
+}
+
+// checkObjectLockAtRest - fails with ObjectLocked if the object exists
+// and the WORM mode, its legal hold or its retention keep it from
+// being overwritten or deleted. Called by the object layers with the
+// object locked, governance retention is enforced by the handlers
+// since only they know if the request may bypass it.
+func checkObjectLockAtRest(bucket, object string, getObjectInfo func() (ObjectInfo, error)) error {
+	if !isObjectLockActive(bucket) {
+		return nil
+	}
+	objInfo, err := getObjectInfo()
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if globalWORMEnabled || getObjectLock(objInfo.UserDefined).isLocked(UTCNow(), true) {
+		return errors.Trace(ObjectLocked{Bucket: bucket, Object: object})
+	}
+	return nil
+}
+
+// setObjectLockMetadata - updates the object lock of an object by
+// rewriting its metadata in-place.
+func setObjectLockMetadata(objAPI ObjectLayer, objInfo ObjectInfo, lock objectLock) (ObjectInfo, error) {
+	metadata := make(map[string]string, len(objInfo.UserDefined))
+	for k, v := range objInfo.UserDefined {
+		metadata[k] = v
+	}
+	// Only metadata is rewritten, retain the etag of the object.
+	metadata["etag"] = objInfo.ETag
+	setObjectLock(metadata, lock)
+
+	objInfo.UserDefined = metadata
+	objInfo.metadataOnly = true
+	return objAPI.CopyObject(objInfo.Bucket, objInfo.Name, objInfo.Bucket, objInfo.Name, objInfo)
+}
+
+// extractObjectLockFromHeader - saves the object lock of an object
+// uploaded to the bucket into metadata, see getObjectLockFromHeader.
+func extractObjectLockFromHeader(header http.Header, bucket string, metadata map[string]string) APIErrorCode {
+	lock, s3Err := getObjectLockFromHeader(header, bucket)
+	if s3Err != ErrNone {
+		return s3Err
+	}
+	setObjectLock(metadata, lock)
+	return ErrNone
+}
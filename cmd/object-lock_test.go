@@ -0,0 +1,335 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/auth"
+	"github.com/minio/minio/pkg/errors"
+)
+
+// Tests converting object lock configurations of clients.
+func TestParseObjectLockConfiguration(t *testing.T) {
+	rule := func(mode string, days, years int) *ObjectLockRule {
+		return &ObjectLockRule{DefaultRetention{Mode: mode, Days: days, Years: years}}
+	}
+	testCases := []struct {
+		config   ObjectLockConfiguration
+		expected objectLockConfig
+		success  bool
+	}{
+		{ObjectLockConfiguration{ObjectLockEnabled: "Enabled"}, objectLockConfig{}, true},
+		{ObjectLockConfiguration{ObjectLockEnabled: "Enabled", Rule: rule("GOVERNANCE", 30, 0)}, objectLockConfig{Mode: "GOVERNANCE", Days: 30}, true},
+		{ObjectLockConfiguration{ObjectLockEnabled: "Enabled", Rule: rule("COMPLIANCE", 0, 7)}, objectLockConfig{Mode: "COMPLIANCE", Years: 7}, true},
+		{ObjectLockConfiguration{}, objectLockConfig{}, false},
+		{ObjectLockConfiguration{ObjectLockEnabled: "Disabled"}, objectLockConfig{}, false},
+		{ObjectLockConfiguration{ObjectLockEnabled: "Enabled", Rule: rule("", 30, 0)}, objectLockConfig{}, false},
+		{ObjectLockConfiguration{ObjectLockEnabled: "Enabled", Rule: rule("LEGAL", 30, 0)}, objectLockConfig{}, false},
+		{ObjectLockConfiguration{ObjectLockEnabled: "Enabled", Rule: rule("GOVERNANCE", 0, 0)}, objectLockConfig{}, false},
+		{ObjectLockConfiguration{ObjectLockEnabled: "Enabled", Rule: rule("GOVERNANCE", 30, 1)}, objectLockConfig{}, false},
+		{ObjectLockConfiguration{ObjectLockEnabled: "Enabled", Rule: rule("GOVERNANCE", -1, 0)}, objectLockConfig{}, false},
+	}
+	for i, testCase := range testCases {
+		cfg, err := parseObjectLockConfiguration(testCase.config)
+		if testCase.success && err != nil {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if !testCase.success && err == nil {
+			t.Errorf("Test %d: Expected to fail", i+1)
+		}
+		if testCase.success && cfg != testCase.expected {
+			t.Errorf("Test %d: Expected %+v, got %+v", i+1, testCase.expected, cfg)
+		}
+	}
+}
+
+// Tests objects locked by their retention and legal hold.
+func TestObjectLockIsLocked(t *testing.T) {
+	now := UTCNow()
+	future, past := now.Add(time.Hour), now.Add(-time.Hour)
+	testCases := []struct {
+		lock     objectLock
+		locked   bool
+		bypassed bool
+	}{
+		{objectLock{}, false, false},
+		{objectLock{Mode: retentionGovernance, RetainUntil: future}, true, false},
+		{objectLock{Mode: retentionGovernance, RetainUntil: past}, false, false},
+		{objectLock{Mode: retentionCompliance, RetainUntil: future}, true, true},
+		{objectLock{Mode: retentionCompliance, RetainUntil: past}, false, false},
+		{objectLock{Mode: retentionGovernance, RetainUntil: past, LegalHold: true}, true, true},
+	}
+	for i, testCase := range testCases {
+		if locked := testCase.lock.isLocked(now, false); locked != testCase.locked {
+			t.Errorf("Test %d: Expected locked %v, got %v", i+1, testCase.locked, locked)
+		}
+		if locked := testCase.lock.isLocked(now, true); locked != testCase.bypassed {
+			t.Errorf("Test %d: Expected locked %v with bypass, got %v", i+1, testCase.bypassed, locked)
+		}
+
+		// Locks are saved into and read from metadata unchanged.
+		metadata := map[string]string{amzObjectLockMode: "GOVERNANCE", "content-type": "text/plain"}
+		setObjectLock(metadata, testCase.lock)
+		if lock := getObjectLock(metadata); !lock.RetainUntil.Equal(testCase.lock.RetainUntil.Truncate(time.Second)) ||
+			lock.Mode != testCase.lock.Mode || lock.LegalHold != testCase.lock.LegalHold {
+			t.Errorf("Test %d: Expected %+v, got %+v", i+1, testCase.lock, lock)
+		}
+		if metadata["content-type"] != "text/plain" {
+			t.Errorf("Test %d: Expected other metadata to be kept", i+1)
+		}
+	}
+}
+
+// Tests the object lock of uploads from request headers.
+func TestGetObjectLockFromHeader(t *testing.T) {
+	defer func() { globalBucketObjectLock = nil }()
+	globalBucketObjectLock = newBucketObjectLock()
+	globalBucketObjectLock.Set("locked", objectLockConfig{})
+	globalBucketObjectLock.Set("default", objectLockConfig{Mode: retentionCompliance, Days: 1})
+
+	future := UTCNow().Add(time.Hour).Format(time.RFC3339)
+	header := func(kv ...string) http.Header {
+		h := make(http.Header)
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+	testCases := []struct {
+		bucket      string
+		header      http.Header
+		mode        string
+		legalHold   bool
+		expectedErr APIErrorCode
+	}{
+		{"other", header(), "", false, ErrNone},
+		{"other", header(amzObjectLockLegalHold, "ON"), "", false, ErrMissingObjectLockConfiguration},
+		{"locked", header(), "", false, ErrNone},
+		{"locked", header(amzObjectLockMode, "GOVERNANCE", amzObjectLockRetainUntilDate, future), "GOVERNANCE", false, ErrNone},
+		{"locked", header(amzObjectLockLegalHold, "ON"), "", true, ErrNone},
+		{"locked", header(amzObjectLockMode, "GOVERNANCE"), "", false, ErrInvalidObjectRetention},
+		{"locked", header(amzObjectLockMode, "LEGAL", amzObjectLockRetainUntilDate, future), "", false, ErrInvalidObjectRetention},
+		{"locked", header(amzObjectLockMode, "GOVERNANCE", amzObjectLockRetainUntilDate, "2000-01-01T00:00:00Z"), "", false, ErrPastObjectLockRetainDate},
+		{"locked", header(amzObjectLockLegalHold, "yes"), "", false, ErrInvalidObjectRetention},
+		{"default", header(), "COMPLIANCE", false, ErrNone},
+		{"default", header(amzObjectLockMode, "GOVERNANCE", amzObjectLockRetainUntilDate, future), "GOVERNANCE", false, ErrNone},
+	}
+	for i, testCase := range testCases {
+		lock, s3Err := getObjectLockFromHeader(testCase.header, testCase.bucket)
+		if s3Err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, s3Err)
+			continue
+		}
+		if s3Err == ErrNone && (lock.Mode != testCase.mode || lock.LegalHold != testCase.legalHold) {
+			t.Errorf("Test %d: Unexpected object lock %+v", i+1, lock)
+		}
+	}
+
+	// Default retention starts at upload.
+	lock, _ := getObjectLockFromHeader(header(), "default")
+	if until := UTCNow().AddDate(0, 0, 1); lock.RetainUntil.After(until) || lock.RetainUntil.Before(until.Add(-time.Minute)) {
+		t.Errorf("Expected default retention of a day, got %v", lock.RetainUntil)
+	}
+}
+
+// Tests locked objects are protected by the object layers.
+func TestObjectLockObjectLayer(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectLockObjectLayer)
+}
+
+func testObjectLockObjectLayer(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	defer func() { globalBucketObjectLock, globalWORMEnabled = nil, false }()
+	globalBucketObjectLock = newBucketObjectLock()
+
+	bucket := "bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	globalBucketObjectLock.Set(bucket, objectLockConfig{})
+
+	put := func(object string, metadata map[string]string) error {
+		_, err := obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader([]byte("data")), 4, "", ""), metadata)
+		return err
+	}
+	isLocked := func(err error) bool {
+		_, ok := errors.Cause(err).(ObjectLocked)
+		return ok
+	}
+
+	future := UTCNow().Add(time.Hour).Format(time.RFC3339)
+	objects := map[string]map[string]string{
+		"compliance": {amzObjectLockMode: retentionCompliance, amzObjectLockRetainUntilDate: future},
+		"legal-hold": {amzObjectLockLegalHold: legalHoldOn},
+		"governance": {amzObjectLockMode: retentionGovernance, amzObjectLockRetainUntilDate: future},
+		"expired":    {amzObjectLockMode: retentionCompliance, amzObjectLockRetainUntilDate: "2000-01-01T00:00:00Z"},
+	}
+	for object, metadata := range objects {
+		if err := put(object, metadata); err != nil {
+			t.Fatalf("%s: %s: %v", instanceType, object, err)
+		}
+	}
+
+	for _, object := range []string{"compliance", "legal-hold"} {
+		if err := put(object, nil); !isLocked(err) {
+			t.Errorf("%s: %s: Expected overwrite to fail, got %v", instanceType, object, err)
+		}
+		if err := obj.DeleteObject(bucket, object); !isLocked(err) {
+			t.Errorf("%s: %s: Expected delete to fail, got %v", instanceType, object, err)
+		}
+		uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		part, err := obj.PutObjectPart(context.Background(), bucket, object, uploadID, 1, mustGetHashReader(t, bytes.NewReader([]byte("data")), 4, "", ""))
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		if _, err = obj.CompleteMultipartUpload(context.Background(), bucket, object, uploadID, []CompletePart{{PartNumber: 1, ETag: part.ETag}}); !isLocked(err) {
+			t.Errorf("%s: %s: Expected multipart overwrite to fail, got %v", instanceType, object, err)
+		}
+	}
+
+	// Governance retention is enforced by the handlers.
+	for _, object := range []string{"governance", "expired"} {
+		if err := obj.DeleteObject(bucket, object); err != nil {
+			t.Errorf("%s: %s: Unexpected error %v", instanceType, object, err)
+		}
+	}
+
+	// Only new objects are written in WORM mode.
+	globalWORMEnabled = true
+	if err := put("new", nil); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err := put("new", nil); !isLocked(err) {
+		t.Errorf("%s: Expected overwrite to fail in WORM mode, got %v", instanceType, err)
+	}
+	if err := obj.DeleteObject(bucket, "new"); !isLocked(err) {
+		t.Errorf("%s: Expected delete to fail in WORM mode, got %v", instanceType, err)
+	}
+}
+
+// Tests the object lock APIs and the protection of locked objects
+// from S3 requests.
+func TestAPIObjectLockHandlers(t *testing.T) {
+	defer func() { globalBucketObjectLock = nil }()
+	ExecObjectLayerAPITest(t, testAPIObjectLockHandlers, []string{"BucketObjectLock", "ObjectRetention", "ObjectLegalHold", "PutObject", "DeleteObject"})
+}
+
+func testAPIObjectLockHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	globalBucketObjectLock = newBucketObjectLock()
+
+	doRequest := func(method, urlStr string, body []byte, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader(body), credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	expectStatus := func(rec *httptest.ResponseRecorder, status int, msg string) {
+		if rec.Code != status {
+			t.Fatalf("%s: %s: Expected the response status to be `%d`, but instead found `%d`: %s", instanceType, msg, status, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Object lock is not enabled yet.
+	expectStatus(doRequest("GET", getBucketObjectLockURL("", bucketName), nil, nil), http.StatusNotFound, "get config")
+	expectStatus(doRequest("PUT", getPutObjectURL("", bucketName, "object"), []byte("hello"),
+		http.Header{amzObjectLockLegalHold: {"ON"}}), http.StatusBadRequest, "put with lock")
+
+	// Enable object lock with a default governance retention.
+	config := []byte(`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled>` +
+		`<Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Days>1</Days></DefaultRetention></Rule></ObjectLockConfiguration>`)
+	expectStatus(doRequest("PUT", getBucketObjectLockURL("", bucketName), config, nil), http.StatusOK, "put config")
+	rec := doRequest("GET", getBucketObjectLockURL("", bucketName), nil, nil)
+	expectStatus(rec, http.StatusOK, "get config")
+	var lockConfig ObjectLockConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &lockConfig); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if lockConfig.Rule == nil || lockConfig.Rule.DefaultRetention != (DefaultRetention{Mode: "GOVERNANCE", Days: 1}) {
+		t.Fatalf("%s: Unexpected config %+v", instanceType, lockConfig)
+	}
+	expectStatus(doRequest("PUT", getBucketObjectLockURL("", bucketName),
+		[]byte(`<ObjectLockConfiguration><ObjectLockEnabled>Disabled</ObjectLockEnabled></ObjectLockConfiguration>`), nil),
+		http.StatusBadRequest, "disable")
+
+	// New objects get the default retention.
+	expectStatus(doRequest("PUT", getPutObjectURL("", bucketName, "object"), []byte("hello"), nil), http.StatusOK, "put")
+	rec = doRequest("GET", getObjectRetentionURL("", bucketName, "object"), nil, nil)
+	expectStatus(rec, http.StatusOK, "get retention")
+	var retention ObjectRetention
+	if err := xml.Unmarshal(rec.Body.Bytes(), &retention); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if retention.Mode != retentionGovernance {
+		t.Fatalf("%s: Unexpected retention %+v", instanceType, retention)
+	}
+
+	// Governance retention is only bypassed on request.
+	expectStatus(doRequest("DELETE", getDeleteObjectURL("", bucketName, "object"), nil, nil), http.StatusForbidden, "delete")
+	expectStatus(doRequest("PUT", getPutObjectURL("", bucketName, "object"), []byte("world"), nil), http.StatusForbidden, "overwrite")
+	expectStatus(doRequest("PUT", getObjectRetentionURL("", bucketName, "object"), []byte(`<Retention/>`), nil), http.StatusForbidden, "remove retention")
+	expectStatus(doRequest("PUT", getObjectRetentionURL("", bucketName, "object"), []byte(`<Retention/>`),
+		http.Header{amzBypassGovernanceRetention: {"true"}}), http.StatusOK, "bypass retention")
+
+	// Compliance retention can only be extended.
+	until := UTCNow().Add(time.Hour)
+	compliance := func(until time.Time) []byte {
+		return []byte(`<Retention><Mode>COMPLIANCE</Mode><RetainUntilDate>` + until.Format(time.RFC3339) + `</RetainUntilDate></Retention>`)
+	}
+	expectStatus(doRequest("PUT", getObjectRetentionURL("", bucketName, "object"), compliance(until), nil), http.StatusOK, "compliance")
+	expectStatus(doRequest("PUT", getObjectRetentionURL("", bucketName, "object"), compliance(until.Add(-time.Minute)),
+		http.Header{amzBypassGovernanceRetention: {"true"}}), http.StatusForbidden, "shorten compliance")
+	expectStatus(doRequest("PUT", getObjectRetentionURL("", bucketName, "object"), compliance(until.Add(time.Hour)), nil), http.StatusOK, "extend compliance")
+	expectStatus(doRequest("DELETE", getDeleteObjectURL("", bucketName, "object"), nil,
+		http.Header{amzBypassGovernanceRetention: {"true"}}), http.StatusForbidden, "delete compliance")
+	expectStatus(doRequest("PUT", getObjectRetentionURL("", bucketName, "object"),
+		[]byte(`<Retention><Mode>COMPLIANCE</Mode><RetainUntilDate>2000-01-01T00:00:00Z</RetainUntilDate></Retention>`), nil),
+		http.StatusBadRequest, "past retention")
+
+	// Legal holds protect objects without retention.
+	header := http.Header{amzObjectLockMode: {retentionGovernance}, amzObjectLockRetainUntilDate: {until.Format(time.RFC3339)}}
+	expectStatus(doRequest("PUT", getPutObjectURL("", bucketName, "held"), []byte("hello"), header), http.StatusOK, "put held")
+	expectStatus(doRequest("PUT", getObjectLegalHoldURL("", bucketName, "held"), []byte(`<LegalHold><Status>ON</Status></LegalHold>`), nil), http.StatusOK, "legal hold")
+	expectStatus(doRequest("PUT", getObjectRetentionURL("", bucketName, "held"), []byte(`<Retention/>`),
+		http.Header{amzBypassGovernanceRetention: {"true"}}), http.StatusOK, "remove retention")
+	rec = doRequest("GET", getObjectLegalHoldURL("", bucketName, "held"), nil, nil)
+	expectStatus(rec, http.StatusOK, "get legal hold")
+	var legalHold ObjectLegalHold
+	if err := xml.Unmarshal(rec.Body.Bytes(), &legalHold); err != nil || legalHold.Status != legalHoldOn {
+		t.Fatalf("%s: Unexpected legal hold %+v, %v", instanceType, legalHold, err)
+	}
+	expectStatus(doRequest("GET", getObjectRetentionURL("", bucketName, "held"), nil, nil), http.StatusNotFound, "no retention")
+	expectStatus(doRequest("DELETE", getDeleteObjectURL("", bucketName, "held"), nil, nil), http.StatusForbidden, "delete held")
+	expectStatus(doRequest("PUT", getObjectLegalHoldURL("", bucketName, "held"), []byte(`<LegalHold><Status>OFF</Status></LegalHold>`), nil), http.StatusOK, "release")
+	expectStatus(doRequest("DELETE", getDeleteObjectURL("", bucketName, "held"), nil, nil), http.StatusNoContent, "delete released")
+}
@@ -0,0 +1,111 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/minio/minio/pkg/errors"
+)
+
+const (
+	// minioQuarantine is the request header a validation hook (e.g.
+	// an anti-virus scanner) sets at upload time to keep the object
+	// from being downloaded until it is cleared via the admin API.
+	minioQuarantine = "X-Minio-Quarantine"
+
+	// ObjectQuarantineStatus is the reserved metadata key holding
+	// the quarantine status of an object.
+	ObjectQuarantineStatus = ReservedMetadataPrefix + "Quarantine"
+)
+
+// Quarantine status values.
+const (
+	quarantinePending = "pending"
+	quarantineCleared = "cleared"
+)
+
+// quarantineHeaderHandler - rejects requests asking for quarantine in
+// gateways. Gateways neither keep the quarantine status nor refuse to
+// serve objects pending validation, and do not serve the admin API
+// clearing them.
+type quarantineHeaderHandler struct {
+	http.Handler
+}
+
+func filterQuarantineHeader(h http.Handler) http.Handler {
+	return quarantineHeaderHandler{h}
+}
+
+func (h quarantineHeaderHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, ok := r.Header[minioQuarantine]; ok {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+	h.Handler.ServeHTTP(w, r)
+}
+
+// extractQuarantineFromHeader - saves the quarantine status requested
+// through the request header into metadata. Only "pending" may be set
+// at upload time, clearing requires the admin API.
+func extractQuarantineFromHeader(header http.Header, metadata map[string]string) error {
+	status := header.Get(minioQuarantine)
+	if status == "" {
+		return nil
+	}
+	if status != quarantinePending {
+		return errors.Trace(errInvalidArgument)
+	}
+	metadata[ObjectQuarantineStatus] = quarantinePending
+	return nil
+}
+
+// isObjectQuarantined - returns true if the object metadata marks the
+// object as pending validation.
+func isObjectQuarantined(metadata map[string]string) bool {
+	return metadata[ObjectQuarantineStatus] == quarantinePending
+}
+
+// setObjectQuarantine - updates the quarantine status of an object by
+// rewriting its metadata in-place.
+func setObjectQuarantine(objectAPI ObjectLayer, bucket, object, status string) (objInfo ObjectInfo, err error) {
+	if status != quarantinePending && status != quarantineCleared {
+		return objInfo, errors.Trace(errInvalidArgument)
+	}
+
+	objInfo, err = objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return objInfo, err
+	}
+
+	metadata := make(map[string]string, len(objInfo.UserDefined))
+	for k, v := range objInfo.UserDefined {
+		metadata[k] = v
+	}
+	// Only metadata is rewritten, retain the etag of the object.
+	metadata["etag"] = objInfo.ETag
+
+	if status == quarantineCleared {
+		delete(metadata, ObjectQuarantineStatus)
+	} else {
+		metadata[ObjectQuarantineStatus] = quarantinePending
+	}
+
+	objInfo.UserDefined = metadata
+	objInfo.metadataOnly = true
+	return objectAPI.CopyObject(bucket, object, bucket, object, objInfo)
+}
@@ -0,0 +1,144 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/minio/minio/pkg/errors"
+)
+
+// Tests quarantine status extraction from request headers.
+func TestExtractQuarantineFromHeader(t *testing.T) {
+	testCases := []struct {
+		status     string
+		quarantine bool
+		shouldFail bool
+	}{
+		{"", false, false},
+		{quarantinePending, true, false},
+		{quarantineCleared, false, true},
+		{"unknown", false, true},
+	}
+	for i, testCase := range testCases {
+		header := http.Header{}
+		if testCase.status != "" {
+			header.Set(minioQuarantine, testCase.status)
+		}
+		metadata := make(map[string]string)
+		err := extractQuarantineFromHeader(header, metadata)
+		if testCase.shouldFail && err == nil {
+			t.Errorf("Test %d: Expected to fail", i+1)
+		}
+		if !testCase.shouldFail && err != nil {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if isObjectQuarantined(metadata) != testCase.quarantine {
+			t.Errorf("Test %d: Expected quarantine %v", i+1, testCase.quarantine)
+		}
+	}
+}
+
+// Tests that gateways reject requests asking for quarantine.
+func TestFilterQuarantineHeader(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	handler := filterQuarantineHeader(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	testCases := []struct {
+		header     http.Header
+		statusCode int
+	}{
+		{http.Header{}, http.StatusOK},
+		{http.Header{"X-Amz-Meta-Color": []string{"blue"}}, http.StatusOK},
+		{http.Header{minioQuarantine: []string{quarantinePending}}, http.StatusNotImplemented},
+		{http.Header{minioQuarantine: []string{""}}, http.StatusNotImplemented},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("PUT", "/bucket/object", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header = testCase.header
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != testCase.statusCode {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, testCase.statusCode, rec.Code)
+		}
+	}
+}
+
+// Wrapper for calling quarantine tests for both XL multiple disks and single node setup.
+func TestObjectQuarantine(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectQuarantine)
+}
+
+// Tests that quarantined objects cannot be read until cleared.
+func testObjectQuarantine(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket, object := "bucket", "object"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	data := []byte("hello, world")
+	metadata := map[string]string{ObjectQuarantineStatus: quarantinePending}
+	if _, err := obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), metadata); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	// Reading a quarantined object must fail.
+	err := obj.GetObject(bucket, object, 0, int64(len(data)), ioutil.Discard, "")
+	if _, ok := errors.Cause(err).(ObjectQuarantined); !ok {
+		t.Fatalf("%s: Expected ObjectQuarantined, got %v", instanceType, err)
+	}
+
+	// Metadata is still available.
+	if _, err = obj.GetObjectInfo(bucket, object); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	if _, err = setObjectQuarantine(obj, bucket, object, quarantineCleared); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	var buf bytes.Buffer
+	if err = obj.GetObject(bucket, object, 0, int64(len(data)), &buf, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("%s: Expected %s, got %s", instanceType, data, buf.Bytes())
+	}
+
+	// Quarantine can be set again after upload.
+	if _, err = setObjectQuarantine(obj, bucket, object, quarantinePending); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	err = obj.GetObject(bucket, object, 0, int64(len(data)), ioutil.Discard, "")
+	if _, ok := errors.Cause(err).(ObjectQuarantined); !ok {
+		t.Fatalf("%s: Expected ObjectQuarantined, got %v", instanceType, err)
+	}
+}
@@ -0,0 +1,311 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+)
+
+// errInvalidIDToken - the ID token is not signed by the OpenID provider,
+// expired or issued to another client.
+var errInvalidIDToken = errors.New("invalid ID token")
+
+// errOpenIDProvider - the OpenID provider cannot be reached.
+var errOpenIDProvider = errors.New("The OpenID provider could not be reached")
+
+const (
+	// Requests to the OpenID provider time out after this long.
+	openIDRequestTimeout = 10 * time.Second
+
+	// Signing keys of the OpenID provider are fetched again when a
+	// token names an unknown key, at most once per this interval.
+	openIDKeysRefreshInterval = time.Minute
+
+	// Claims naming the user and the groups of the user by default.
+	defaultOpenIDUsernameClaim = "sub"
+	defaultOpenIDGroupsClaim   = "groups"
+)
+
+// openIDConfig - OpenID Connect provider, e.g. Keycloak or Dex, issuing
+// ID tokens to clientID. Users are named by usernameClaim, the values
+// of groupsClaim are their groups.
+type openIDConfig struct {
+	Enable        bool   `json:"enable"`
+	IssuerURL     string `json:"issuerURL"`
+	ClientID      string `json:"clientID"`
+	UsernameClaim string `json:"usernameClaim"`
+	GroupsClaim   string `json:"groupsClaim"`
+}
+
+// Validate - validates the OpenID configuration.
+func (o openIDConfig) Validate() error {
+	if !o.Enable {
+		return nil
+	}
+	u, err := checkURL(o.IssuerURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("OpenID issuer URL `%s` must be an http or https URL", o.IssuerURL)
+	}
+	if o.ClientID == "" {
+		return errors.New("OpenID client ID cannot be empty")
+	}
+	return nil
+}
+
+// openIDProvider - validates ID tokens with the signing keys published
+// by the OpenID provider, found by discovery.
+type openIDProvider struct {
+	config openIDConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+// newOpenIDProvider - returns the configured OpenID provider, nil if
+// OpenID Connect is disabled.
+func newOpenIDProvider(config openIDConfig) (*openIDProvider, error) {
+	if !config.Enable {
+		return nil, nil
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = defaultOpenIDUsernameClaim
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = defaultOpenIDGroupsClaim
+	}
+	return &openIDProvider{
+		config: config,
+		client: &http.Client{
+			Timeout: openIDRequestTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{RootCAs: globalRootCAs},
+			},
+		},
+	}, nil
+}
+
+// Validate - verifies an ID token and returns the identity of the user,
+// errInvalidIDToken if it is invalid.
+func (o *openIDProvider) Validate(token string) (Identity, error) {
+	var fetchErr error
+	keyFunc := func(t *jwtgo.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwtgo.SigningMethodRSA, *jwtgo.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("Unexpected signing method: %v", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		key, err := o.getKey(kid)
+		if err != nil {
+			fetchErr = err
+		}
+		return key, err
+	}
+
+	claims := jwtgo.MapClaims{}
+	if _, err := jwtgo.ParseWithClaims(token, claims, keyFunc); err != nil {
+		if fetchErr != nil {
+			return Identity{}, fetchErr
+		}
+		return Identity{}, errInvalidIDToken
+	}
+	if !claims.VerifyIssuer(o.config.IssuerURL, true) ||
+		!claims.VerifyExpiresAt(UTCNow().Unix(), true) ||
+		!contains(getClaimValues(claims, "aud"), o.config.ClientID) {
+		return Identity{}, errInvalidIDToken
+	}
+
+	users := getClaimValues(claims, o.config.UsernameClaim)
+	if len(users) != 1 || users[0] == "" {
+		return Identity{}, errInvalidIDToken
+	}
+	return Identity{User: users[0], Groups: getClaimValues(claims, o.config.GroupsClaim)}, nil
+}
+
+// Returns the values of a string or string array claim.
+func getClaimValues(claims jwtgo.MapClaims, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, value := range v {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// Returns the signing key of an ID token, the keys are fetched again
+// if the provider rotated its keys.
+func (o *openIDProvider) getKey(kid string) (interface{}, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if key, ok := o.keys[kid]; ok {
+		return key, nil
+	}
+	if UTCNow().Sub(o.keysFetched) < openIDKeysRefreshInterval {
+		return nil, errInvalidIDToken
+	}
+	keys, err := o.fetchKeys()
+	if err != nil {
+		return nil, err
+	}
+	o.keys, o.keysFetched = keys, UTCNow()
+	if key, ok := o.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, errInvalidIDToken
+}
+
+// Fetches the signing keys from the JWKS URI of the discovery document
+// of the issuer.
+func (o *openIDProvider) fetchKeys() (map[string]interface{}, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := o.get(strings.TrimSuffix(o.config.IssuerURL, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.Issuer != o.config.IssuerURL {
+		return nil, fmt.Errorf("OpenID issuer `%s` of the discovery document differs from `%s`", discovery.Issuer, o.config.IssuerURL)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := o.get(discovery.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{}, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped, tokens signed
+		// with them are invalid.
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// Gets a JSON document of the OpenID provider.
+func (o *openIDProvider) get(url string, out interface{}) error {
+	resp, err := o.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, resp.Body)
+		return fmt.Errorf("OpenID provider returned %s for %s", resp.Status, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// jsonWebKey - public key of a JWKS (RFC 7517), RSA or EC.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil || len(b) == 0 {
+			return nil, errors.New("invalid key parameter")
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
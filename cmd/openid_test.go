@@ -0,0 +1,187 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+)
+
+// testOpenIDIssuer - OpenID provider publishing the public keys of its
+// signing keys by discovery.
+type testOpenIDIssuer struct {
+	server *httptest.Server
+
+	mu   sync.Mutex
+	keys map[string]interface{}
+}
+
+func newTestOpenIDIssuer(t TestErrHandler) *testOpenIDIssuer {
+	issuer := &testOpenIDIssuer{keys: make(map[string]interface{})}
+	issuer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer":   issuer.server.URL,
+				"jwks_uri": issuer.server.URL + "/keys",
+			})
+		case "/keys":
+			issuer.mu.Lock()
+			defer issuer.mu.Unlock()
+			encode := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+			var keys []jsonWebKey
+			for kid, key := range issuer.keys {
+				switch key := key.(type) {
+				case *rsa.PrivateKey:
+					keys = append(keys, jsonWebKey{Kty: "RSA", Kid: kid, Use: "sig", N: encode(key.N), E: encode(big.NewInt(int64(key.E)))})
+				case *ecdsa.PrivateKey:
+					keys = append(keys, jsonWebKey{Kty: "EC", Kid: kid, Crv: "P-256", X: encode(key.X), Y: encode(key.Y)})
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	issuer.addKey(t, "rsa1", false)
+	return issuer
+}
+
+// Adds a new RSA or EC signing key.
+func (i *testOpenIDIssuer) addKey(t TestErrHandler, kid string, ec bool) {
+	var key interface{}
+	var err error
+	if ec {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	} else {
+		key, err = rsa.GenerateKey(rand.Reader, 1024)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.mu.Lock()
+	i.keys[kid] = key
+	i.mu.Unlock()
+}
+
+// Returns an ID token of the user signed with the key kid, claims
+// override the defaults.
+func (i *testOpenIDIssuer) sign(t TestErrHandler, kid string, claims jwtgo.MapClaims) string {
+	i.mu.Lock()
+	key := i.keys[kid]
+	i.mu.Unlock()
+	mapClaims := jwtgo.MapClaims{
+		"iss": i.server.URL,
+		"aud": "minio",
+		"sub": "john",
+		"exp": UTCNow().Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		mapClaims[k] = v
+	}
+	method := jwtgo.SigningMethod(jwtgo.SigningMethodRS256)
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		method = jwtgo.SigningMethodES256
+	}
+	token := jwtgo.NewWithClaims(method, mapClaims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func (i *testOpenIDIssuer) newProvider(t TestErrHandler) *openIDProvider {
+	provider, err := newOpenIDProvider(openIDConfig{Enable: true, IssuerURL: i.server.URL, ClientID: "minio"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+// Tests validating ID tokens.
+func TestOpenIDProvider(t *testing.T) {
+	issuer := newTestOpenIDIssuer(t)
+	defer issuer.server.Close()
+	issuer.addKey(t, "ec1", true)
+	provider := issuer.newProvider(t)
+
+	testCases := []struct {
+		token    string
+		identity Identity
+		success  bool
+	}{
+		{issuer.sign(t, "rsa1", nil), Identity{User: "john"}, true},
+		{issuer.sign(t, "ec1", jwtgo.MapClaims{"groups": []string{"dev", "ops"}}), Identity{User: "john", Groups: []string{"dev", "ops"}}, true},
+		{issuer.sign(t, "rsa1", jwtgo.MapClaims{"aud": []string{"other", "minio"}, "groups": "dev"}), Identity{User: "john", Groups: []string{"dev"}}, true},
+		{issuer.sign(t, "rsa1", jwtgo.MapClaims{"aud": "other"}), Identity{}, false},
+		{issuer.sign(t, "rsa1", jwtgo.MapClaims{"iss": "https://other.example.com"}), Identity{}, false},
+		{issuer.sign(t, "rsa1", jwtgo.MapClaims{"exp": UTCNow().Add(-time.Minute).Unix()}), Identity{}, false},
+		{issuer.sign(t, "rsa1", jwtgo.MapClaims{"exp": nil}), Identity{}, false},
+		{issuer.sign(t, "rsa1", jwtgo.MapClaims{"sub": ""}), Identity{}, false},
+		{issuer.sign(t, "rsa1", nil)[:20], Identity{}, false},
+	}
+	for i, testCase := range testCases {
+		identity, err := provider.Validate(testCase.token)
+		if testCase.success && (err != nil || !reflect.DeepEqual(identity, testCase.identity)) {
+			t.Errorf("Test %d: Expected %+v, got %+v, %v", i+1, testCase.identity, identity, err)
+		}
+		if !testCase.success && err != errInvalidIDToken {
+			t.Errorf("Test %d: Expected an invalid ID token, got %v", i+1, err)
+		}
+	}
+
+	// Tokens signed with the HMAC of a public key are invalid.
+	hmacToken := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, jwtgo.MapClaims{"iss": issuer.server.URL, "aud": "minio", "sub": "john"})
+	hmacToken.Header["kid"] = "rsa1"
+	signed, _ := hmacToken.SignedString([]byte("secret"))
+	if _, err := provider.Validate(signed); err != errInvalidIDToken {
+		t.Errorf("Expected an HMAC token to be invalid, got %v", err)
+	}
+
+	// Rotated keys are fetched again, at most once per interval.
+	issuer.addKey(t, "rsa2", false)
+	token := issuer.sign(t, "rsa2", nil)
+	if _, err := provider.Validate(token); err != errInvalidIDToken {
+		t.Errorf("Expected keys not to be fetched again yet, got %v", err)
+	}
+	provider.keysFetched = provider.keysFetched.Add(-openIDKeysRefreshInterval)
+	if _, err := provider.Validate(token); err != nil {
+		t.Errorf("Expected the rotated key to be fetched, got %v", err)
+	}
+
+	// Unreachable providers are no invalid tokens.
+	issuer.server.Close()
+	provider = issuer.newProvider(t)
+	if _, err := provider.Validate(token); err == nil || err == errInvalidIDToken {
+		t.Errorf("Expected a connection error, got %v", err)
+	}
+}
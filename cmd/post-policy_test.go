@@ -26,6 +26,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -418,6 +419,74 @@ func testPostPolicyBucketHandler(obj ObjectLayer, instanceType string, t TestErr
 
 }
 
+// Wrapper for calling TestPostPolicyBucketHandlerStrictMetadata tests for both XL multiple disks and single node setup.
+func TestPostPolicyBucketHandlerStrictMetadata(t *testing.T) {
+	ExecObjectLayerTest(t, testPostPolicyBucketHandlerStrictMetadata)
+}
+
+// testPostPolicyBucketHandlerStrictMetadata tests that metadata form fields
+// need a policy condition when MINIO_POST_POLICY_STRICT_METADATA is "on".
+func testPostPolicyBucketHandlerStrictMetadata(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Initializing config.json failed")
+	}
+	defer os.RemoveAll(root)
+
+	// Register event notifier.
+	err = initEventNotifier(obj)
+	if err != nil {
+		t.Fatalf("Initializing event notifiers failed")
+	}
+
+	globalIsPostPolicyStrictMetadata = true
+	defer func() { globalIsPostPolicyStrictMetadata = false }()
+
+	// get random bucket name.
+	bucketName := getRandomBucketName()
+
+	// Register the API end points with XL/FS object layer.
+	apiRouter := initTestAPIEndPoints(obj, []string{"PostPolicy"})
+
+	credentials := globalServerConfig.GetCredential()
+
+	curTime := UTCNow()
+	curTimePlus5Min := curTime.Add(time.Minute * 5)
+
+	if err = obj.MakeBucketWithLocation(bucketName, ""); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	dates := []interface{}{curTimePlus5Min.Format(expirationDateFormat), curTime.Format(iso8601DateFormat), curTime.Format(yyyymmdd)}
+	policy := `{"expiration": "%s","conditions":[["eq", "$bucket", "` + bucketName + `"], ["starts-with", "$key", "test/"], ["eq", "$x-amz-algorithm", "AWS4-HMAC-SHA256"], ["eq", "$x-amz-date", "%s"], ["eq", "$x-amz-credential", "` + credentials.AccessKey + `/%s/us-east-1/s3/aws4_request"]`
+
+	// The request always sends the x-amz-meta-uuid field.
+	testCases := []struct {
+		policy             string
+		expectedRespStatus int
+	}{
+		// Metadata not covered by the policy.
+		{policy + `]}`, http.StatusForbidden},
+		// Metadata covered by the policy.
+		{policy + `, ["eq", "$x-amz-meta-uuid", "1234"]]}`, http.StatusNoContent},
+		// Metadata not matching the policy.
+		{policy + `, ["eq", "$x-amz-meta-uuid", "5678"]]}`, http.StatusForbidden},
+	}
+
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req, perr := newPostRequestV4Generic("", bucketName, "test", []byte("Hello, World"), credentials.AccessKey,
+			credentials.SecretKey, "us-east-1", curTime, []byte(fmt.Sprintf(testCase.policy, dates...)), nil, false, false)
+		if perr != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request for PostPolicyHandler: <ERROR> %v", i+1, instanceType, perr)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Errorf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+	}
+}
+
 // Wrapper for calling TestPostPolicyBucketHandlerRedirect tests for both XL multiple disks and single node setup.
 func TestPostPolicyBucketHandlerRedirect(t *testing.T) {
 	ExecObjectLayerTest(t, testPostPolicyBucketHandlerRedirect)
@@ -447,7 +516,7 @@ func testPostPolicyBucketHandlerRedirect(obj ObjectLayer, instanceType string, t
 	targetObj := keyName + "/upload.txt"
 
 	// The url of success_action_redirect field
-	redirectURL, err := url.Parse("http://www.google.com")
+	redirectURL, err := url.Parse("http://www.google.com/uploaded?app=test")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -499,8 +568,12 @@ func testPostPolicyBucketHandlerRedirect(obj ObjectLayer, instanceType string, t
 		t.Error("Unexpected error: ", err)
 	}
 
-	redirectURL.RawQuery = getRedirectPostRawQuery(info)
+	// Query params of the redirect URL are kept.
+	redirectURL.RawQuery = getRedirectPostRawQuery(redirectURL.Query(), info)
 	expectedLocation := redirectURL.String()
+	if !strings.Contains(expectedLocation, "app=test") {
+		t.Errorf("Expected location %s to keep the query of the redirect URL", expectedLocation)
+	}
 
 	// Check the new location url
 	if rec.HeaderMap.Get("Location") != expectedLocation {
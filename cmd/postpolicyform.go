@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -52,6 +53,22 @@ const (
 	policyCondContentLength = "content-length-range"
 )
 
+// Environment variable rejecting metadata form fields not covered by
+// a condition of the POST policy.
+const postPolicyStrictMetadataEnv = "MINIO_POST_POLICY_STRICT_METADATA"
+
+// Turns the check of metadata form fields on if
+// MINIO_POST_POLICY_STRICT_METADATA is set to "on".
+func handlePostPolicyEnv() {
+	switch value := os.Getenv(postPolicyStrictMetadataEnv); value {
+	case "", "off":
+	case "on":
+		globalIsPostPolicyStrictMetadata = true
+	default:
+		fatalIf(fmt.Errorf("invalid value"), "Unknown value ‘%s’ in %s environment variable.", value, postPolicyStrictMetadataEnv)
+	}
+}
+
 // toString - Safely convert interface to string without causing panic.
 func toString(val interface{}) string {
 	switch v := val.(type) {
@@ -103,8 +120,9 @@ type contentLengthRange struct {
 type PostPolicyForm struct {
 	Expiration time.Time // Expiration date and time of the POST policy.
 	Conditions struct {  // Conditional policy structure.
-		Policies map[string]struct {
+		Policies []struct {
 			Operator string
+			Key      string
 			Value    string
 		}
 		ContentLengthRange contentLengthRange
@@ -132,11 +150,6 @@ func parsePostPolicyForm(policy string) (ppf PostPolicyForm, e error) {
 	if err != nil {
 		return ppf, err
 	}
-	parsedPolicy.Conditions.Policies = make(map[string]struct {
-		Operator string
-		Value    string
-	})
-
 	// Parse conditions.
 	for _, val := range rawPolicy.Conditions {
 		switch condt := val.(type) {
@@ -148,13 +161,15 @@ func parsePostPolicyForm(policy string) (ppf PostPolicyForm, e error) {
 				}
 				// {"acl": "public-read" } is an alternate way to indicate - [ "eq", "$acl", "public-read" ]
 				// In this case we will just collapse this into "eq" for all use cases.
-				parsedPolicy.Conditions.Policies["$"+strings.ToLower(k)] = struct {
+				parsedPolicy.Conditions.Policies = append(parsedPolicy.Conditions.Policies, struct {
 					Operator string
+					Key      string
 					Value    string
 				}{
 					Operator: policyCondEqual,
+					Key:      "$" + strings.ToLower(k),
 					Value:    toString(v),
-				}
+				})
 			}
 		case []interface{}: // Handle array types.
 			if len(condt) != 3 { // Return error if we have insufficient elements.
@@ -169,13 +184,15 @@ func parsePostPolicyForm(policy string) (ppf PostPolicyForm, e error) {
 					}
 				}
 				operator, matchType, value := toLowerString(condt[0]), toLowerString(condt[1]), toString(condt[2])
-				parsedPolicy.Conditions.Policies[matchType] = struct {
+				parsedPolicy.Conditions.Policies = append(parsedPolicy.Conditions.Policies, struct {
 					Operator string
+					Key      string
 					Value    string
 				}{
 					Operator: operator,
+					Key:      matchType,
 					Value:    value,
-				}
+				})
 			case policyCondContentLength:
 				min, err := toInteger(condt[1])
 				if err != nil {
@@ -228,8 +245,10 @@ func checkPostPolicy(formValues http.Header, postPolicyForm PostPolicyForm) APIE
 	// Flag to indicate if all policies conditions are satisfied
 	condPassed := true
 
-	// Iterate over policy conditions and check them against received form fields
-	for cond, v := range postPolicyForm.Conditions.Policies {
+	// Iterate over policy conditions and check them against received form fields,
+	// a field may be restricted by several conditions which all need to pass.
+	for _, v := range postPolicyForm.Conditions.Policies {
+		cond := v.Key
 		// Form fields names are in canonical format, convert conditions names
 		// to canonical for simplification purpose, so `$key` will become `Key`
 		formCanonicalName := http.CanonicalHeaderKey(strings.TrimPrefix(cond, "$"))
@@ -256,5 +275,27 @@ func checkPostPolicy(formValues http.Header, postPolicyForm PostPolicyForm) APIE
 		}
 	}
 
+	// Metadata fields are saved with the object, with strict checks only
+	// accept those the policy has a condition on so that browsers cannot
+	// add their own.
+	if !globalIsPostPolicyStrictMetadata {
+		return ErrNone
+	}
+	for key := range formValues {
+		if !strings.HasPrefix(key, "X-Amz-Meta-") {
+			continue
+		}
+		covered := false
+		for _, v := range postPolicyForm.Conditions.Policies {
+			if http.CanonicalHeaderKey(strings.TrimPrefix(v.Key, "$")) == key {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return ErrAccessDenied
+		}
+	}
+
 	return ErrNone
 }
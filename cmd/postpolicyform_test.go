@@ -20,6 +20,7 @@ import (
 	"encoding/base64"
 	"net/http"
 	"testing"
+	"time"
 
 	minio "github.com/minio/minio-go"
 )
@@ -101,3 +102,73 @@ func TestPostPolicyForm(t *testing.T) {
 		}
 	}
 }
+
+// Tests that all conditions on a form field need to be satisfied.
+func TestPostPolicyFormRepeatedConditions(t *testing.T) {
+	policy := `{"expiration": "` + UTCNow().Add(time.Hour).Format(expirationDateFormat) + `", "conditions": [` +
+		`["starts-with", "$key", "uploads/"], ["starts-with", "$key", "uploads/images/"], ` +
+		`["starts-with", "$content-type", "image/"], ["content-length-range", 1, 1024]]}`
+	postPolicyForm, err := parsePostPolicyForm(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postPolicyForm.Conditions.Policies) != 3 {
+		t.Fatalf("Expected 3 conditions, got %v", postPolicyForm.Conditions.Policies)
+	}
+	if lengthRange := postPolicyForm.Conditions.ContentLengthRange; !lengthRange.Valid || lengthRange.Min != 1 || lengthRange.Max != 1024 {
+		t.Fatalf("Unexpected content length range %+v", lengthRange)
+	}
+
+	testCases := []struct {
+		key         string
+		contentType string
+		errCode     APIErrorCode
+	}{
+		{"uploads/images/photo.jpg", "image/jpeg", ErrNone},
+		{"uploads/docs/photo.jpg", "image/jpeg", ErrAccessDenied},
+		{"uploads/images/index.html", "text/html", ErrAccessDenied},
+	}
+	for i, testCase := range testCases {
+		formValues := make(http.Header)
+		formValues.Set("Key", testCase.key)
+		formValues.Set("Content-Type", testCase.contentType)
+		if errCode := checkPostPolicy(formValues, postPolicyForm); errCode != testCase.errCode {
+			t.Errorf("Test %d: Expected %d, got %d", i+1, testCase.errCode, errCode)
+		}
+	}
+}
+
+// Tests that metadata fields without a condition are only rejected with
+// strict metadata checks.
+func TestPostPolicyFormStrictMetadata(t *testing.T) {
+	policy := `{"expiration": "` + UTCNow().Add(time.Hour).Format(expirationDateFormat) + `", "conditions": [` +
+		`["starts-with", "$key", "uploads/"], ["eq", "$x-amz-meta-uuid", "1234"]]}`
+	postPolicyForm, err := parsePostPolicyForm(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { globalIsPostPolicyStrictMetadata = false }()
+	testCases := []struct {
+		strict  bool
+		extra   string
+		errCode APIErrorCode
+	}{
+		{false, "", ErrNone},
+		{false, "extra", ErrNone},
+		{true, "", ErrNone},
+		{true, "extra", ErrAccessDenied},
+	}
+	for i, testCase := range testCases {
+		globalIsPostPolicyStrictMetadata = testCase.strict
+		formValues := make(http.Header)
+		formValues.Set("Key", "uploads/photo.jpg")
+		formValues.Set("X-Amz-Meta-Uuid", "1234")
+		if testCase.extra != "" {
+			formValues.Set("X-Amz-Meta-Extra", testCase.extra)
+		}
+		if errCode := checkPostPolicy(formValues, postPolicyForm); errCode != testCase.errCode {
+			t.Errorf("Test %d: Expected %d, got %d", i+1, testCase.errCode, errCode)
+		}
+	}
+}
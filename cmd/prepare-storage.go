@@ -45,19 +45,24 @@ var printEndpointError = func() func(Endpoint, error) {
 	}
 }()
 
+// Migrates the format of all local disks in parallel.
 func formatXLMigrateLocalEndpoints(endpoints EndpointList) error {
-	for _, endpoint := range endpoints {
+	errs := runBootTasks(bootStageMigrateFormat, len(endpoints), 0, func(index int) error {
+		endpoint := endpoints[index]
 		if !endpoint.IsLocal {
-			continue
+			return nil
 		}
 		formatPath := pathJoin(endpoint.Path, minioMetaBucket, formatConfigFile)
 		if _, err := os.Stat(formatPath); err != nil {
 			if os.IsNotExist(err) {
-				continue
+				return nil
 			}
 			return err
 		}
-		if err := formatXLMigrate(endpoint.Path); err != nil {
+		return formatXLMigrate(endpoint.Path)
+	})
+	for _, err := range errs {
+		if err != nil {
 			return err
 		}
 	}
@@ -129,11 +134,11 @@ func waitForFormatXL(firstDisk bool, endpoints EndpointList, setCount, disksPerS
 						return nil, fmt.Errorf("%s format error: %s", endpoints[i], err)
 					}
 				}
-				if len(format.XL.Sets) != globalXLSetCount {
-					return nil, fmt.Errorf("Current backend format is inconsistent with input args (%s), Expected set count %d, got %d", endpoints, len(format.XL.Sets), globalXLSetCount)
+				if len(format.XL.Sets) != setCount {
+					return nil, fmt.Errorf("Current backend format is inconsistent with input args (%s), Expected set count %d, got %d", endpoints, len(format.XL.Sets), setCount)
 				}
-				if len(format.XL.Sets[0]) != globalXLSetDriveCount {
-					return nil, fmt.Errorf("Current backend format is inconsistent with input args (%s), Expected drive count per set %d, got %d", endpoints, len(format.XL.Sets[0]), globalXLSetDriveCount)
+				if len(format.XL.Sets[0]) != disksPerSet {
+					return nil, fmt.Errorf("Current backend format is inconsistent with input args (%s), Expected drive count per set %d, got %d", endpoints, len(format.XL.Sets[0]), disksPerSet)
 				}
 				return format, nil
 			}
@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	router "github.com/gorilla/mux"
+)
+
+const (
+	// Path metrics are served at in the Prometheus text format.
+	prometheusMetricsPath = minioReservedBucketPath + "/prometheus/metrics"
+
+	// Environment variable setting how Prometheus authenticates,
+	// "jwt" by default or "public".
+	prometheusAuthTypeEnv = "MINIO_PROMETHEUS_AUTH_TYPE"
+)
+
+// Sets how Prometheus authenticates from the environment.
+func handlePrometheusEnv() {
+	switch authType := os.Getenv(prometheusAuthTypeEnv); authType {
+	case "", "jwt":
+		globalIsPrometheusPublic = false
+	case "public":
+		globalIsPrometheusPublic = true
+	default:
+		fatalIf(fmt.Errorf("unknown auth type %s", authType), "Invalid value set in environment variable %s.", prometheusAuthTypeEnv)
+	}
+}
+
+// isPrometheusReq - returns true if the request is for Prometheus metrics.
+func isPrometheusReq(r *http.Request) bool {
+	return r.URL.Path == prometheusMetricsPath
+}
+
+// registerPrometheusRouter - registers the Prometheus metrics endpoint.
+func registerPrometheusRouter(mux *router.Router) {
+	mux.Methods(http.MethodGet).Path(prometheusMetricsPath).HandlerFunc(prometheusMetricsHandler)
+}
+
+// Escapes label values of the Prometheus text format.
+var prometheusLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// prometheusMetrics - writes metrics in the Prometheus text format.
+type prometheusMetrics struct {
+	bytes.Buffer
+}
+
+// Writes the help and type lines of a metric.
+func (m *prometheusMetrics) describe(name, metricType, help string) {
+	fmt.Fprintf(&m.Buffer, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// Writes a sample of a metric labelled with the target ARN.
+func (m *prometheusMetrics) sample(name, arn string, value interface{}) {
+	fmt.Fprintf(&m.Buffer, "%s{arn=\"%s\"} %v\n", name, prometheusLabelReplacer.Replace(arn), value)
+}
+
+// Writes delivery metrics of notification targets.
+func (m *prometheusMetrics) writeNotifyTargetMetrics(targets []ServerNotifyTargetStats) {
+	m.describe("minio_notify_target_in_flight", "gauge", "Events being delivered to the notification target.")
+	for _, target := range targets {
+		m.sample("minio_notify_target_in_flight", target.ARN, target.InFlight)
+	}
+	m.describe("minio_notify_target_sent_total", "counter", "Events delivered to the notification target.")
+	for _, target := range targets {
+		m.sample("minio_notify_target_sent_total", target.ARN, target.Sent)
+	}
+	m.describe("minio_notify_target_failed_total", "counter", "Events failing to be delivered to the notification target, which are dropped.")
+	for _, target := range targets {
+		m.sample("minio_notify_target_failed_total", target.ARN, target.Failed)
+	}
+	m.describe("minio_notify_target_delivery_seconds", "summary", "Time taken to deliver events to the notification target.")
+	for _, target := range targets {
+		m.sample("minio_notify_target_delivery_seconds_sum", target.ARN, target.TotalLatency.Seconds())
+		m.sample("minio_notify_target_delivery_seconds_count", target.ARN, target.Sent+target.Failed)
+	}
+	m.describe("minio_notify_target_last_delivery_seconds", "gauge", "Time taken by the last delivery to the notification target.")
+	for _, target := range targets {
+		m.sample("minio_notify_target_last_delivery_seconds", target.ARN, target.LastLatency.Seconds())
+	}
+}
+
+// prometheusMetricsHandler - serves metrics of this server in the
+// Prometheus text format. Requests authenticate with the JWT of the
+// browser unless metrics are public.
+func prometheusMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	if !globalIsPrometheusPublic && !isHTTPRequestValid(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var metrics prometheusMetrics
+	metrics.writeNotifyTargetMetrics(globalNotifyTargetMetrics.List())
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	w.Write(metrics.Bytes())
+}
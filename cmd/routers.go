@@ -73,6 +73,15 @@ func configureServerHandler(endpoints EndpointList) (http.Handler, error) {
 	// Add Admin router.
 	registerAdminRouter(mux)
 
+	// Add Prometheus router.
+	registerPrometheusRouter(mux)
+
+	// Add startup progress and health check router.
+	registerHealthRouter(mux)
+
+	// Add STS router issuing temporary credentials.
+	registerSTSRouter(mux)
+
 	// Register web router when its enabled.
 	if globalIsBrowserEnabled {
 		if err := registerWebRouter(mux); err != nil {
@@ -101,6 +110,8 @@ func configureServerHandler(endpoints EndpointList) (http.Handler, error) {
 		setBrowserRedirectHandler,
 		// Validates if incoming request is for restricted buckets.
 		setReservedBucketHandler,
+		// Rejects write requests in read-only and maintenance mode.
+		setServerModeHandler,
 		// Adds cache control for all browser requests.
 		setBrowserCacheControlHandler,
 		// Validates all incoming requests to have a valid date header.
@@ -117,7 +128,17 @@ func configureServerHandler(endpoints EndpointList) (http.Handler, error) {
 		// filters HTTP headers which are treated as metadata and are reserved
 		// for internal use only.
 		filterReservedMetadata,
+		// Throttles S3 API requests per client IP and in total.
+		setAPIThrottleHandler,
+		// Strips the URL prefix when hosted behind a reverse proxy.
+		setURLPrefixHandler,
 		// Add new handlers here.
+
+		// Publishes request traces to admin trace clients.
+		setTraceHandler,
+		// Audit handler comes last to record every request,
+		// including those rejected by the handlers above.
+		setAuditHandler,
 	}
 
 	// Register rest of the handlers.
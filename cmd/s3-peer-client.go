@@ -179,4 +179,37 @@ func S3PeersUpdateBucketPolicy(bucket string) {
 			globalS3Peers[idx].addr, err,
 		)
 	}
+	// Drop the cached policy of this server even if it is not
+	// among the peers or failed to update.
+	globalBucketPolicyCache.invalidate(bucket)
+}
+
+// S3PeersUpdateBucketConfig - Sends update bucket config request to
+// all peers, which reload the config file of the bucket. Currently we
+// log an error and continue.
+func S3PeersUpdateBucketConfig(bucket, configFile string) {
+	setBCPArgs := &SetBucketConfigPeerArgs{Bucket: bucket, ConfigFile: configFile}
+	errs := globalS3Peers.SendUpdate(nil, setBCPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket config %s to %s - %v",
+			configFile, globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersInvalidateObjectMetadata - Sends changed objects of a bucket
+// to all peers, which drop their cached info. Currently we log an
+// error and continue, cached info expires eventually.
+func S3PeersInvalidateObjectMetadata(bucket string, objects []string) {
+	invOMPArgs := &InvalidateObjectMetadataPeerArgs{Bucket: bucket, Objects: objects}
+	errs := globalS3Peers.SendUpdate(nil, invOMPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending invalidate object metadata to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
 }
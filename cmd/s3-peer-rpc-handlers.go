@@ -113,3 +113,57 @@ func (s3 *s3PeerAPIHandlers) SetBucketPolicyPeer(args *SetBucketPolicyPeerArgs,
 
 	return s3.bms.UpdateBucketPolicy(args)
 }
+
+// SetBucketConfigPeerArgs - Arguments collection for
+// SetBucketConfigPeer RPC call
+type SetBucketConfigPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Config file of the bucket changed, one of cachedBucketConfigFiles.
+	ConfigFile string
+}
+
+// BucketUpdate - implements bucket config updates, peers reload the
+// config file of the bucket.
+func (s *SetBucketConfigPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketConfig(s)
+}
+
+// tell receiving server to reload a config file of a bucket
+func (s3 *s3PeerAPIHandlers) SetBucketConfigPeer(args *SetBucketConfigPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketConfig(args)
+}
+
+// InvalidateObjectMetadataPeerArgs - Arguments collection for
+// InvalidateObjectMetadataPeer RPC call
+type InvalidateObjectMetadataPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Objects changed, all objects of the bucket if empty.
+	Objects []string
+}
+
+// BucketUpdate - implements object metadata invalidation, peers drop
+// the cached info of the objects.
+func (s *InvalidateObjectMetadataPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.InvalidateObjectMetadata(s)
+}
+
+// tell receiving server to drop cached info of changed objects
+func (s3 *s3PeerAPIHandlers) InvalidateObjectMetadataPeer(args *InvalidateObjectMetadataPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.InvalidateObjectMetadata(args)
+}
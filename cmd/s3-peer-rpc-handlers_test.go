@@ -99,6 +99,13 @@ func (s *TestRPCS3PeerSuite) testS3PeerRPC(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// Check object metadata invalidation call works.
+	IOMPArgs := InvalidateObjectMetadataPeerArgs{Bucket: "bucket", Objects: []string{"object"}}
+	err = client.Call("S3.InvalidateObjectMetadataPeer", &IOMPArgs, &AuthRPCReply{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	// Check event send event call works.
 	evArgs := EventArgs{Event: nil, Arn: "localhost:9000"}
 	err = client.Call("S3.Event", &evArgs, &AuthRPCReply{})
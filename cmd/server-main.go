@@ -17,10 +17,12 @@
 package cmd
 
 import (
+	"crypto/tls"
 	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"syscall"
 
 	"github.com/minio/cli"
@@ -35,6 +37,22 @@ var serverFlags = []cli.Flag{
 		Value: ":" + globalMinioPort,
 		Usage: "Bind to a specific ADDRESS:PORT, ADDRESS can be an IP or hostname.",
 	},
+	cli.BoolFlag{
+		Name:  "read-only",
+		Usage: "Serve only GET, HEAD and LIST requests, reject all writes.",
+	},
+	cli.BoolFlag{
+		Name:  "certs-auto",
+		Usage: "Obtain and renew TLS certificates for MINIO_DOMAIN automatically from Let's Encrypt.",
+	},
+	cli.BoolFlag{
+		Name:  "certs-auto-agree-tos",
+		Usage: "Agree to the terms of service of the certificate authority, required by --certs-auto.",
+	},
+	cli.BoolFlag{
+		Name:  "worm",
+		Usage: "Write once read many, reject overwrites and deletes of existing objects.",
+	},
 }
 
 var serverCmd = cli.Command{
@@ -48,13 +66,16 @@ var serverCmd = cli.Command{
 USAGE:
   {{.HelpName}} {{if .VisibleFlags}}[FLAGS] {{end}}DIR1 [DIR2..]
   {{.HelpName}} {{if .VisibleFlags}}[FLAGS] {{end}}DIR{1...64}
+  {{.HelpName}} {{if .VisibleFlags}}[FLAGS] {{end}}DIR{1...64} + DIR{65...128}
 
 DIR:
   DIR points to a directory on a filesystem. When you want to combine
   multiple drives into a single large system, pass one directory per
   filesystem separated by space. You may also use a '...' convention
   to abbreviate the directory arguments. Remote directories in a
-  distributed setup are encoded as HTTP(s) URIs.
+  distributed setup are encoded as HTTP(s) URIs. Capacity is added to
+  an erasure coded setup as a new zone, the directories of each zone
+  are separated by a '+' argument.
 
 {{if .VisibleFlags}}
 FLAGS:
@@ -67,6 +88,24 @@ ENVIRONMENT VARIABLES:
 
   BROWSER:
      MINIO_BROWSER: To disable web browser access, set this value to "off".
+     MINIO_BROWSER_TOKEN_EXPIRY: Time browser tokens are valid for. By default it is "24h".
+     MINIO_BROWSER_REFRESH_EXPIRY: Time browsers stay logged in by refreshing their tokens. By default it is "168h".
+
+  URL PREFIX:
+     MINIO_URL_PREFIX: Path the server is hosted under behind a reverse proxy, e.g. "/storage".
+
+  SIGNATURE:
+     MINIO_SIGNATURE_V2: To reject requests signed with signature V2, set this value to "off". By default it is "on".
+     MINIO_POST_POLICY_STRICT_METADATA: To reject POST policy uploads with x-amz-meta-* fields the policy has no condition on, set this value to "on". By default it is "off".
+
+  THROTTLE:
+     MINIO_API_REQUESTS_MAX: Maximum number of S3 API requests served at once. By default it is unlimited.
+     MINIO_API_REQUESTS_DEADLINE: Time requests wait for their turn before SlowDown is returned. By default it is "10s".
+     MINIO_API_REQUESTS_PER_IP: Sustained rate of S3 API requests per second of a single client IP. By default it is unlimited.
+     MINIO_API_REQUESTS_PER_IP_BURST: Number of requests a single client IP may send at once. By default it is the rate rounded up.
+
+  PROMETHEUS:
+     MINIO_PROMETHEUS_AUTH_TYPE: To serve Prometheus metrics without authentication, set this value to "public". By default it is "jwt".
 
   REGION:
      MINIO_REGION: To set custom region. By default it is "us-east-1".
@@ -74,6 +113,54 @@ ENVIRONMENT VARIABLES:
   UPDATE:
      MINIO_UPDATE: To turn off in-place upgrades, set this value to "off".
 
+  READAHEAD:
+     MINIO_XL_READAHEAD: Number of parts of multipart objects read at once from erasure coded disks. By default it is 4, set to 0 to read parts one by one.
+
+  METADATA CACHE:
+     MINIO_XL_METADATA_CACHE_SIZE: Number of objects whose metadata is cached in memory to serve HEAD requests without reading all disks. By default it is 0, caching is off.
+     MINIO_XL_METADATA_CACHE_EXPIRY: Time cached metadata is served for at most, in case a peer failed to notify a change. By default it is "1m".
+
+  PARALLELISM:
+     MINIO_XL_ENCODE_GOROUTINES: Number of goroutines erasure coding a single block. By default it is adjusted to the number of CPUs.
+     MINIO_XL_WRITE_DEPTH: Number of blocks queued for every erasure coded disk while writing an object. By default it is 2, set to 1 to write blocks one by one.
+
+  STORAGE CLASS:
+     MINIO_STORAGE_CLASS_STANDARD: Parity of standard storage class objects on erasure coded disks, e.g. "EC:4". By default it is half the disks of an erasure set.
+     MINIO_STORAGE_CLASS_RRS: Parity of reduced redundancy storage class objects, e.g. "EC:2". By default it is 2.
+
+  AUDIT:
+     MINIO_AUDIT_LOG_FILE: Path of a file to write a JSON audit record of every request to.
+     MINIO_AUDIT_LOG_FILE_MAX_SIZE: Size at which the audit log file is rotated. By default it is "100MiB".
+     MINIO_AUDIT_LOG_WEBHOOK: URL of an HTTP endpoint to post a JSON audit record of every request to.
+
+  ATTESTATION:
+     MINIO_OBJECT_ATTESTATION: To save a signed manifest of every new object, set this value to "on".
+
+  WORM:
+     MINIO_WORM: To reject overwrites and deletes of existing objects, set this value to "on". Same as --worm.
+
+  METADATA BACKUP:
+     MINIO_METADATA_BACKUP_BUCKET: Bucket to periodically save snapshots of disk formats and bucket configs to.
+     MINIO_METADATA_BACKUP_INTERVAL: Interval between snapshots. By default it is "1h".
+     MINIO_METADATA_BACKUP_KEEP: Number of snapshots kept, older ones are deleted. By default it is 24.
+
+  INTEGRITY SCAN:
+     MINIO_INTEGRITY_SCAN: To periodically verify the shards of all objects and report damaged ones, set this value to "on".
+     MINIO_INTEGRITY_SCAN_INTERVAL: Interval between scans. By default it is "24h".
+
+  SEARCH:
+     MINIO_OBJECT_INDEX: To index object names and metadata to be searched by the admin API and the browser, set this value to "on".
+
+  FTP:
+     MINIO_FTP_ADDRESS: Address of a plain FTP server serving the buckets, e.g. ":8021". Passwords are sent in clear text.
+     MINIO_FTP_PASSIVE_PORTS: Range of ports of passive FTP data connections, e.g. "30000-30100". By default any free port.
+     MINIO_SFTP_ADDRESS: Address of an SFTP server serving the buckets, e.g. ":8022".
+
+  CERTIFICATES:
+     MINIO_ACME_EMAIL: Contact email registered with Let's Encrypt when --certs-auto is passed.
+     MINIO_ACME_DIRECTORY: Directory URL of an alternate ACME certificate authority.
+     MINIO_ACME_HTTP_ADDRESS: Address serving ACME HTTP challenges. By default it is ":80".
+
 EXAMPLES:
   1. Start minio server on "/home/shared" directory.
       $ {{.HelpName}} /home/shared
@@ -99,6 +186,21 @@ EXAMPLES:
       $ export MINIO_ACCESS_KEY=minio
       $ export MINIO_SECRET_KEY=miniostorage
       $ {{.HelpName}} http://node{1...8}.example.com/mnt/export/{1...8}
+
+  7. Start minio server serving only read requests on "/home/shared" directory.
+      $ {{.HelpName}} --read-only /home/shared
+
+  8. Start minio server with certificates obtained from Let's Encrypt for "minio.example.com".
+      $ export MINIO_DOMAIN=minio.example.com
+      $ {{.HelpName}} --certs-auto --certs-auto-agree-tos --address :443 /home/shared
+
+  9. Start minio server on "/home/shared" directory keeping all objects once written.
+      $ {{.HelpName}} --worm /home/shared
+
+  10. Add a second zone of 8 nodes with 8 drives each to the setup of example 6. Run following command on all the 16 nodes.
+      $ export MINIO_ACCESS_KEY=minio
+      $ export MINIO_SECRET_KEY=miniostorage
+      $ {{.HelpName}} http://node{1...8}.example.com/mnt/export/{1...8} + http://node{9...16}.example.com/mnt/export/{1...8}
 `,
 }
 
@@ -117,10 +219,27 @@ func serverHandleCmdArgs(ctx *cli.Context) {
 		fatalIf(errInvalidArgument, "Invalid total number of arguments (%d) passed, supported upto 32 unique arguments", len(ctx.Args()))
 	}
 
-	globalMinioAddr, globalEndpoints, setupType, globalXLSetCount, globalXLSetDriveCount, err = createServerEndpoints(serverAddr, ctx.Args()...)
+	var zones []zoneEndpoints
+	globalMinioAddr, globalEndpoints, zones, setupType, err = createServerZones(serverAddr, ctx.Args()...)
 	fatalIf(err, "Invalid command line arguments server=‘%s’, args=%s", serverAddr, ctx.Args())
 
+	// Storage classes are validated against the smallest erasure sets
+	// of all zones.
+	for _, zone := range zones {
+		globalXLSetCount += zone.setCount
+		if globalXLSetDriveCount == 0 || zone.drivesPerSet < globalXLSetDriveCount {
+			globalXLSetDriveCount = zone.drivesPerSet
+		}
+	}
+	if len(zones) > 1 {
+		globalXLZones = zones
+	}
+
 	globalMinioHost, globalMinioPort = mustSplitHostPort(globalMinioAddr)
+	globalIsReadOnly = ctx.Bool("read-only")
+	globalIsCertsAuto = ctx.Bool("certs-auto")
+	globalCertsAutoAgreeTOS = ctx.Bool("certs-auto-agree-tos")
+	globalWORMEnabled = ctx.Bool("worm")
 	if runtime.GOOS == "darwin" {
 		// On macOS, if a process already listens on LOCALIPADDR:PORT, net.Listen() falls back
 		// to IPv6 address ie minio will start listening on IPv6 address whereas another
@@ -146,6 +265,22 @@ func serverHandleEnvVars() {
 		globalServerRegion = serverRegion
 	}
 
+	globalIsObjectAttestation = strings.EqualFold(os.Getenv("MINIO_OBJECT_ATTESTATION"), "on")
+
+	// Write once read many mode, also turned on by --worm.
+	handleWORMEnv()
+
+	// Periodic snapshots of disk formats and bucket configs.
+	handleMetadataBackupEnv()
+
+	// Periodic scans of the integrity of all objects.
+	handleIntegrityScanEnv()
+
+	// Index of object names and metadata.
+	handleObjectIndexEnv()
+
+	// FTP and SFTP servers.
+	handleFTPEnv()
 }
 
 // serverMain handler called for 'minio server' command.
@@ -185,8 +320,44 @@ func serverMain(ctx *cli.Context) {
 	// Check and load SSL certificates.
 	var err error
 	globalPublicCerts, globalRootCAs, globalTLSCertificate, globalIsSSL, err = getSSLConfig()
+	if globalIsCertsAuto && err != nil {
+		// Invalid certificates are not served, new ones are obtained below.
+		errorIf(err, "Invalid SSL certificate file")
+		globalRootCAs, err = getRootCAs(getCADir())
+	}
 	fatalIf(err, "Invalid SSL certificate file")
 
+	// Obtain certificates automatically, this blocks until one is available.
+	if globalIsCertsAuto {
+		globalCertsAuto, err = initCertsAuto(globalDomainNames, globalCertsAutoAgreeTOS)
+		fatalIf(err, "Unable to obtain certificate for %s", strings.Join(globalDomainNames, ", "))
+
+		globalTLSCertificate, _ = globalCertsAuto.GetCertificate(nil)
+		globalPublicCerts, err = globalCertsAuto.publicCerts()
+		fatalIf(err, "Invalid SSL certificate")
+		globalIsSSL = true
+	}
+
+	// Load CA certificates verifying client certificates of admin requests.
+	globalAdminClientCAs, err = getAdminClientCAs(getAdminCADir())
+	fatalIf(err, "Invalid admin client CA certificate file")
+	if globalAdminClientCAs != nil && !globalIsSSL {
+		fatalIf(errInvalidArgument, "Admin client certificates require HTTPS, no certificates found in %s", getConfigDir())
+	}
+
+	// Connect to the KMS sealing data keys of SSE-S3 objects, Vault
+	// may be served with certificates of the CAs loaded above.
+	globalKMS, err = newKMS(globalServerConfig.KMS)
+	fatalIf(err, "Unable to initialize KMS")
+
+	// Authenticate users for temporary credentials, LDAP servers and
+	// OpenID providers may also be served with certificates of the
+	// CAs loaded above.
+	globalIdentityProvider, err = newIdentityProvider(globalServerConfig.Identity)
+	fatalIf(err, "Unable to initialize identity provider")
+	globalOpenIDProvider, err = newOpenIDProvider(globalServerConfig.Identity.OpenID)
+	fatalIf(err, "Unable to initialize OpenID provider")
+
 	// Is distributed setup, error out if no certificates are found for HTTPS endpoints.
 	if globalIsDistXL && globalEndpoints.IsHTTPS() && !globalIsSSL {
 		fatalIf(errInvalidArgument, "No certificates found for HTTPS endpoints (%s)", globalEndpoints)
@@ -232,15 +403,33 @@ func serverMain(ctx *cli.Context) {
 	globalHTTPServer = miniohttp.NewServer([]string{globalMinioAddr}, handler, globalTLSCertificate)
 	globalHTTPServer.ReadTimeout = globalConnReadTimeout
 	globalHTTPServer.WriteTimeout = globalConnWriteTimeout
+	setHTTPServerThroughput(globalHTTPServer)
 	globalHTTPServer.UpdateBytesReadFunc = globalConnStats.incInputBytes
 	globalHTTPServer.UpdateBytesWrittenFunc = globalConnStats.incOutputBytes
 	globalHTTPServer.ErrorLogFunc = errorIf
+
+	// Serve renewed certificates without restarting.
+	if globalCertsAuto != nil {
+		globalHTTPServer.TLSConfig.Certificates = nil
+		globalHTTPServer.TLSConfig.GetCertificate = globalCertsAuto.GetCertificate
+	}
+
+	// Request client certificates, only admin requests require them.
+	if globalAdminClientCAs != nil {
+		globalHTTPServer.TLSConfig.ClientCAs = globalAdminClientCAs
+		globalHTTPServer.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
 	go func() {
 		globalHTTPServerErrorCh <- globalHTTPServer.Start()
 	}()
 
 	signal.Notify(globalOSSignalCh, os.Interrupt, syscall.SIGTERM)
 
+	// Cache object metadata, changes are sent to all peers.
+	if globalIsXL && globalXLMetadataCacheSize > 0 {
+		globalXLMetadataCache = newXLMetadataCache(globalXLMetadataCacheSize, globalXLMetadataCacheExpiry)
+	}
+
 	newObject, err := newObjectLayer(globalEndpoints)
 	if err != nil {
 		errorIf(err, "Initializing object layer failed")
@@ -249,16 +438,96 @@ func serverMain(ctx *cli.Context) {
 		os.Exit(1)
 	}
 
+	// Registered middlewares see all calls to the object layer.
+	newObject = wrapObjectLayer(newObject)
+
+	// Record recently uploaded objects of each bucket.
+	globalRecentObjects = newRecentObjects(recentObjectsFeedSize)
+	startRecentObjectsPersistence(globalRecentObjects, recentObjectsSaveInterval)
+
+	// Index object names and metadata to be searched.
+	if globalIsObjectIndex {
+		globalObjectIndex = newObjectIndex()
+	}
+
+	// Heal objects found missing or corrupted on some disks by reads.
+	globalHealOnRead = newHealOnReadQueue(healOnReadQueueSize)
+	globalHealOnRead.Start(globalServiceDoneCh)
+
+	// Enforce object lock configs of buckets.
+	globalBucketObjectLock = newBucketObjectLock()
+	fatalIf(globalBucketObjectLock.Init(newObject), "Unable to initialize bucket object lock")
+
+	// Serve static websites hosted by buckets.
+	globalBucketWebsite = newBucketWebsite()
+	fatalIf(globalBucketWebsite.Init(newObject), "Unable to initialize bucket website")
+
+	// Answer cross origin requests of buckets by their CORS rules.
+	globalBucketCORS = newBucketCORS()
+	fatalIf(globalBucketCORS.Init(newObject), "Unable to initialize bucket CORS")
+
+	// Set default headers of uploads by the metadata defaults of buckets.
+	globalBucketMetadataDefaults = newBucketMetadataDefaults()
+	fatalIf(globalBucketMetadataDefaults.Init(newObject), "Unable to initialize bucket metadata defaults")
+
+	// Replicate changes of buckets to their remote targets.
+	globalBucketReplication = newBucketReplication()
+	fatalIf(globalBucketReplication.Init(newObject), "Unable to initialize bucket replication")
+	globalBucketReplication.Start(replicationInterval, globalServiceDoneCh)
+
+	// Move cold objects of buckets to their remote tiers.
+	globalBucketTiering = newBucketTiering()
+	fatalIf(globalBucketTiering.Init(newObject), "Unable to initialize bucket tiering")
+	globalBucketTiering.Start(tieringInterval, globalServiceDoneCh)
+
+	// Move all objects off the zones being decommissioned.
+	if z, ok := unwrapObjectLayer(newObject).(*xlZones); ok {
+		z.StartDecommissionRoutine(globalServiceDoneCh)
+	}
+
+	// Record changes of bucket policies, credentials and config.
+	globalIsChangeLog = true
+
+	// Count requests and bytes transferred per bucket.
+	globalAccessStats = newAccessStats()
+	errorIf(globalAccessStats.Load(newObject), "Unable to load access statistics.")
+	startAccessStatsPersister(globalAccessStats, accessStatsPersistInterval)
+
 	globalObjLayerMutex.Lock()
 	globalObjectAPI = newObject
 	globalObjLayerMutex.Unlock()
 
+	// Resume renaming prefixes stopped by a restart.
+	go func() {
+		errorIf(globalRenamePrefixState.Resume(newObject), "Unable to resume renaming prefixes.")
+	}()
+
+	// Index object counts and sizes per prefix of all buckets.
+	globalUsageCrawler = newUsageCrawler()
+	startUsageCrawler(globalUsageCrawler, usageCrawlInterval)
+
+	// Report objects with missing or corrupted shards of erasure sets.
+	if zones := getIntegrityScanZones(newObject); len(zones) > 0 && globalIntegrityScanInterval > 0 {
+		startIntegrityScanner(newObject, zones, globalIntegrityScanInterval)
+	}
+
+	// Save snapshots of metadata to the backup bucket.
+	if globalMetadataBackupBucket != "" {
+		startMetadataBackup(globalMetadataBackupBucket, globalMetadataBackupInterval, globalMetadataBackupKeep)
+	}
+
+	// Serve the buckets over FTP and SFTP.
+	if globalFTPAddr != "" || globalSFTPAddr != "" {
+		fatalIf(startFTPServers(), "Unable to start FTP and SFTP servers.")
+	}
+
 	// Prints the formatted startup message once object layer is initialized.
 	apiEndpoints := getAPIEndpoints(globalMinioAddr)
 	printStartupMessage(apiEndpoints)
 
 	// Set uptime time after object layer has initialized.
 	globalBootTime = UTCNow()
+	globalBootProgress.finish()
 
 	handleSignals()
 }
@@ -273,6 +542,10 @@ func newObjectLayer(endpoints EndpointList) (newObject ObjectLayer, err error) {
 		return NewFSObjectLayer(endpoints[0].Path)
 	}
 
+	if len(globalXLZones) > 1 {
+		return newXLZones(globalXLZones)
+	}
+
 	format, err := waitForFormatXL(endpoints[0].IsLocal, endpoints, globalXLSetCount, globalXLSetDriveCount)
 	if err != nil {
 		return nil, err
@@ -1698,7 +1698,7 @@ func (s *TestSuiteCommon) TestListObjectsHandler(c *check) {
 	getContent, err = ioutil.ReadAll(response.Body)
 	c.Assert(err, nil)
 	c.Assert(strings.Contains(string(getContent), "<Key>bar</Key>"), true)
-	c.Assert(strings.Contains(string(getContent), "<Owner><ID></ID><DisplayName></DisplayName></Owner>"), true)
+	c.Assert(strings.Contains(string(getContent), "<Owner>"), false)
 
 	// create listObjectsV2 request with valid parameters and fetch-owner activated
 	request, err = newTestSignedRequest("GET", getListObjectsV2URL(s.endPoint, bucketName, "1000", "true"),
@@ -1757,6 +1757,23 @@ func (s *TestSuiteCommon) TestListObjectsHandlerErrors(c *check) {
 	// validating the error response.
 	verifyError(c, response, "InvalidArgument", "Argument maxKeys must be an integer between 0 and 2147483647", http.StatusBadRequest)
 
+	// create listObjectsV2 requests with an invalid continuation token and encoding type.
+	for _, testCase := range []struct {
+		key, value, description string
+	}{
+		{"continuation-token", "not-a-token", "The continuation token provided is incorrect"},
+		{"encoding-type", "base64", "Invalid Encoding Method specified in Request"},
+	} {
+		queryValue := url.Values{}
+		queryValue.Set("list-type", "2")
+		queryValue.Set(testCase.key, testCase.value)
+		request, err = newTestSignedRequest("GET", makeTestTargetURL(s.endPoint, bucketName, "", queryValue),
+			0, nil, s.accessKey, s.secretKey, s.signer)
+		c.Assert(err, nil)
+		response, err = client.Do(request)
+		c.Assert(err, nil)
+		verifyError(c, response, "InvalidArgument", testCase.description, http.StatusBadRequest)
+	}
 }
 
 // TestPutBucketErrors - request for non valid bucket operation
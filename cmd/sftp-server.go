@@ -0,0 +1,705 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	errors2 "github.com/minio/minio/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTP server, version 3 of the SSH file transfer protocol as
+// implemented by OpenSSH, served as the "sftp" subsystem of SSH
+// sessions. Users log in with passwords only.
+
+const (
+	sftpProtocolVersion = 3
+
+	// Maximum size of a packet, OpenSSH clients send 32KiB writes.
+	maxSFTPPacketSize = 256 * 1024
+
+	// Maximum length of data read by a single request.
+	maxSFTPReadSize = 64 * 1024
+
+	// Number of files sent by a single directory read.
+	sftpReadDirCount = 100
+)
+
+// Packet types.
+const (
+	sftpPacketInit     = 1
+	sftpPacketVersion  = 2
+	sftpPacketOpen     = 3
+	sftpPacketClose    = 4
+	sftpPacketRead     = 5
+	sftpPacketWrite    = 6
+	sftpPacketLstat    = 7
+	sftpPacketFstat    = 8
+	sftpPacketSetstat  = 9
+	sftpPacketFsetstat = 10
+	sftpPacketOpendir  = 11
+	sftpPacketReaddir  = 12
+	sftpPacketRemove   = 13
+	sftpPacketMkdir    = 14
+	sftpPacketRmdir    = 15
+	sftpPacketRealpath = 16
+	sftpPacketStat     = 17
+	sftpPacketRename   = 18
+	sftpPacketStatus   = 101
+	sftpPacketHandle   = 102
+	sftpPacketData     = 103
+	sftpPacketName     = 104
+	sftpPacketAttrs    = 105
+)
+
+// Status codes.
+const (
+	sftpStatusOK               = 0
+	sftpStatusEOF              = 1
+	sftpStatusNoSuchFile       = 2
+	sftpStatusPermissionDenied = 3
+	sftpStatusFailure          = 4
+	sftpStatusBadMessage       = 5
+	sftpStatusOpUnsupported    = 8
+)
+
+// Flags of file attributes.
+const (
+	sftpAttrSize        = 0x1
+	sftpAttrPermissions = 0x4
+	sftpAttrACModTime   = 0x8
+)
+
+// Flags of opened files.
+const (
+	sftpOpenRead   = 0x1
+	sftpOpenWrite  = 0x2
+	sftpOpenAppend = 0x4
+)
+
+var errSFTPBadMessage = errors.New("bad message")
+
+// loadSFTPHostKey - loads the host key of the SFTP server, a new key is
+// generated and saved if none exists.
+func loadSFTPHostKey(keyFile string) (ssh.Signer, error) {
+	key, err := loadECPrivateKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSignerFromKey(key)
+}
+
+// serveSFTP - serves SSH connections accepted by the listener until
+// doneCh is closed.
+func serveSFTP(l net.Listener, hostKey ssh.Signer, doneCh chan struct{}) {
+	go func() {
+		<-doneCh
+		l.Close()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-doneCh:
+			default:
+				errorIf(err, "Unable to accept SFTP connections.")
+			}
+			return
+		}
+		go serveSFTPConn(&idleTimeoutConn{Conn: conn, timeout: ftpIdleTimeout}, hostKey)
+	}
+}
+
+// idleTimeoutConn - closes a connection idle for longer than timeout.
+type idleTimeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *idleTimeoutConn) Read(b []byte) (int, error) {
+	c.Conn.SetReadDeadline(UTCNow().Add(c.timeout))
+	return c.Conn.Read(b)
+}
+
+func (c *idleTimeoutConn) Write(b []byte) (int, error) {
+	c.Conn.SetWriteDeadline(UTCNow().Add(c.timeout))
+	return c.Conn.Write(b)
+}
+
+// serveSFTPConn - authenticates an SSH connection and serves the SFTP
+// subsystem of its sessions.
+func serveSFTPConn(conn net.Conn, hostKey ssh.Signer) {
+	defer conn.Close()
+
+	var driver *ftpDriver
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			objAPI := newObjectLayerFn()
+			if objAPI == nil {
+				return nil, errServerNotInitialized
+			}
+			user, err := ftpLogin(meta.User(), string(password))
+			if err != nil {
+				return nil, err
+			}
+			driver = &ftpDriver{
+				objAPI:     objAPI,
+				user:       user,
+				remoteAddr: meta.RemoteAddr().String(),
+				userAgent:  string(meta.ClientVersion()),
+			}
+			return nil, nil
+		},
+		ServerVersion: "SSH-2.0-Minio",
+	}
+	config.AddHostKey(hostKey)
+
+	// Failed handshakes and logins are not logged, as clients
+	// probing the port are common.
+	sconn, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	defer sconn.Close()
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go serveSFTPChannel(channel, requests, driver)
+	}
+}
+
+// serveSFTPChannel - serves the SFTP subsystem of a session, other
+// requests such as shells are refused.
+func serveSFTPChannel(channel ssh.Channel, requests <-chan *ssh.Request, driver *ftpDriver) {
+	started := false
+	for req := range requests {
+		// The payload of a subsystem request is its name as an
+		// SSH string.
+		ok := !started && req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+		req.Reply(ok, nil)
+		if ok {
+			started = true
+			go func() {
+				newSFTPSession(driver, channel).serve()
+				channel.Close()
+			}()
+		}
+	}
+}
+
+// sftpBuffer - decodes the fields of a request packet.
+type sftpBuffer []byte
+
+func (b *sftpBuffer) uint32() (uint32, error) {
+	if len(*b) < 4 {
+		return 0, errSFTPBadMessage
+	}
+	v := binary.BigEndian.Uint32(*b)
+	*b = (*b)[4:]
+	return v, nil
+}
+
+func (b *sftpBuffer) uint64() (uint64, error) {
+	if len(*b) < 8 {
+		return 0, errSFTPBadMessage
+	}
+	v := binary.BigEndian.Uint64(*b)
+	*b = (*b)[8:]
+	return v, nil
+}
+
+func (b *sftpBuffer) string() (string, error) {
+	n, err := b.uint32()
+	if err != nil || uint32(len(*b)) < n {
+		return "", errSFTPBadMessage
+	}
+	s := string((*b)[:n])
+	*b = (*b)[n:]
+	return s, nil
+}
+
+// sftpPacket - encodes the fields of a response packet.
+type sftpPacket []byte
+
+func newSFTPPacket(packetType byte, id uint32) sftpPacket {
+	// The length is set when the packet is sent.
+	p := sftpPacket{0, 0, 0, 0, packetType}
+	if packetType != sftpPacketVersion {
+		p = p.uint32(id)
+	}
+	return p
+}
+
+func (p sftpPacket) uint32(v uint32) sftpPacket {
+	return append(p, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func (p sftpPacket) uint64(v uint64) sftpPacket {
+	return p.uint32(uint32(v >> 32)).uint32(uint32(v))
+}
+
+func (p sftpPacket) string(s string) sftpPacket {
+	return append(p.uint32(uint32(len(s))), s...)
+}
+
+// attrs - appends the attributes of a file.
+func (p sftpPacket) attrs(fi os.FileInfo) sftpPacket {
+	mode := uint32(fi.Mode().Perm())
+	if fi.IsDir() {
+		mode |= 0040000
+	} else {
+		mode |= 0100000
+	}
+	modTime := uint32(fi.ModTime().Unix())
+	return p.uint32(sftpAttrSize | sftpAttrPermissions | sftpAttrACModTime).
+		uint64(uint64(fi.Size())).
+		uint32(mode).
+		uint32(modTime).
+		uint32(modTime)
+}
+
+// sftpHandle - a file or a directory opened by a client.
+type sftpHandle interface {
+	Close() error
+}
+
+// sftpReadHandle - an object opened for reading. Reads are sequential
+// as clients read files from start to end, the object is read again
+// from the offset requested otherwise.
+type sftpReadHandle struct {
+	driver  *ftpDriver
+	objInfo ObjectInfo
+	size    int64
+
+	reader *io.PipeReader
+	offset int64
+}
+
+func (h *sftpReadHandle) ReadAt(b []byte, offset int64) (int, error) {
+	if offset >= h.size {
+		return 0, io.EOF
+	}
+	if h.reader == nil || offset != h.offset {
+		h.Close()
+		pr, pw := io.Pipe()
+		go func(offset int64) {
+			pw.CloseWithError(h.driver.getObject(h.objInfo, offset, -1, pw))
+		}(offset)
+		h.reader, h.offset = pr, offset
+	}
+	n, err := io.ReadFull(h.reader, b)
+	h.offset += int64(n)
+	if err == io.ErrUnexpectedEOF || (err == io.EOF && n > 0) {
+		err = nil
+	}
+	return n, err
+}
+
+func (h *sftpReadHandle) Close() error {
+	if h.reader != nil {
+		h.reader.Close()
+		h.reader = nil
+	}
+	return nil
+}
+
+// sftpWriteHandle - a file opened for writing, spooled to a temporary
+// file uploaded once closed, as the size of an object must be known
+// before it is uploaded.
+type sftpWriteHandle struct {
+	driver *ftpDriver
+	path   string
+	file   *os.File
+}
+
+func (h *sftpWriteHandle) Close() error {
+	defer os.Remove(h.file.Name())
+	defer h.file.Close()
+
+	fi, err := h.file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = h.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return h.driver.PutObject(h.path, h.file, fi.Size())
+}
+
+// sftpDirHandle - a directory opened for reading, its files are listed
+// when opened.
+type sftpDirHandle struct {
+	files []os.FileInfo
+}
+
+func (h *sftpDirHandle) Close() error {
+	return nil
+}
+
+// sftpSession - the SFTP subsystem of a session, requests are served
+// in order.
+type sftpSession struct {
+	driver     *ftpDriver
+	rw         io.ReadWriter
+	handles    map[string]sftpHandle
+	lastHandle uint64
+}
+
+func newSFTPSession(driver *ftpDriver, rw io.ReadWriter) *sftpSession {
+	return &sftpSession{
+		driver:  driver,
+		rw:      rw,
+		handles: make(map[string]sftpHandle),
+	}
+}
+
+func (s *sftpSession) send(p sftpPacket) error {
+	binary.BigEndian.PutUint32(p, uint32(len(p)-4))
+	_, err := s.rw.Write(p)
+	return err
+}
+
+// sendStatus - replies with the status code of an error.
+func (s *sftpSession) sendStatus(id uint32, err error) error {
+	code, message := uint32(sftpStatusOK), "OK"
+	switch {
+	case err == nil:
+	case err == io.EOF:
+		code, message = sftpStatusEOF, "EOF"
+	case err == errSFTPBadMessage:
+		code, message = sftpStatusBadMessage, err.Error()
+	case isFTPNotFound(err):
+		code, message = sftpStatusNoSuchFile, errFTPNotFound.Error()
+	case isFTPAccessDenied(err):
+		code, message = sftpStatusPermissionDenied, errFTPAccessDenied.Error()
+	case err == errFTPUnsupported:
+		code, message = sftpStatusOpUnsupported, err.Error()
+	case err == errFTPNotEmpty:
+		code, message = sftpStatusFailure, err.Error()
+	case isFTPTooLarge(err):
+		code, message = sftpStatusFailure, "file too large"
+	default:
+		errorIf(err, "Unable to serve SFTP request of %s.", s.driver.remoteAddr)
+		code, message = sftpStatusFailure, "failure"
+	}
+	return s.send(newSFTPPacket(sftpPacketStatus, id).uint32(code).string(message).string(""))
+}
+
+// serve - reads and answers requests until the session is closed,
+// files still open are discarded.
+func (s *sftpSession) serve() {
+	defer func() {
+		for _, h := range s.handles {
+			if wh, ok := h.(*sftpWriteHandle); ok {
+				wh.file.Close()
+				os.Remove(wh.file.Name())
+			} else {
+				h.Close()
+			}
+		}
+	}()
+
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(s.rw, header[:]); err != nil {
+			return
+		}
+		length := binary.BigEndian.Uint32(header[:])
+		if length == 0 || length > maxSFTPPacketSize {
+			return
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(s.rw, data); err != nil {
+			return
+		}
+		if err := s.handle(data[0], sftpBuffer(data[1:])); err != nil {
+			return
+		}
+	}
+}
+
+// handle - answers a request, an error is returned if the reply could
+// not be sent.
+func (s *sftpSession) handle(packetType byte, b sftpBuffer) error {
+	if packetType == sftpPacketInit {
+		return s.send(newSFTPPacket(sftpPacketVersion, 0).uint32(sftpProtocolVersion))
+	}
+	id, err := b.uint32()
+	if err != nil {
+		return err
+	}
+
+	switch packetType {
+	case sftpPacketRealpath:
+		p, err := b.string()
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		p = path.Clean("/" + p)
+		fi := ftpFileInfo{name: p, isDir: true}
+		return s.send(newSFTPPacket(sftpPacketName, id).uint32(1).string(p).string(formatFTPListLine(fi)).attrs(fi))
+	case sftpPacketStat, sftpPacketLstat:
+		p, err := b.string()
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		fi, err := s.driver.Stat(p)
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		return s.send(newSFTPPacket(sftpPacketAttrs, id).attrs(fi))
+	case sftpPacketFstat:
+		return s.fstat(id, b)
+	case sftpPacketSetstat, sftpPacketFsetstat:
+		// Times and permissions of objects cannot be changed, as
+		// clients set them after uploads they are ignored.
+		return s.sendStatus(id, nil)
+	case sftpPacketOpendir:
+		p, err := b.string()
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		files, err := s.driver.ReadDir(p)
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		return s.sendHandle(id, &sftpDirHandle{files: files})
+	case sftpPacketReaddir:
+		return s.readDir(id, b)
+	case sftpPacketOpen:
+		return s.open(id, b)
+	case sftpPacketRead:
+		return s.read(id, b)
+	case sftpPacketWrite:
+		return s.write(id, b)
+	case sftpPacketClose:
+		handle, err := b.string()
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		h, ok := s.handles[handle]
+		if !ok {
+			return s.sendStatus(id, errSFTPBadMessage)
+		}
+		delete(s.handles, handle)
+		return s.sendStatus(id, h.Close())
+	case sftpPacketRemove, sftpPacketMkdir, sftpPacketRmdir:
+		p, err := b.string()
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		switch packetType {
+		case sftpPacketRemove:
+			err = s.driver.Remove(p)
+		case sftpPacketMkdir:
+			err = s.driver.Mkdir(p)
+		case sftpPacketRmdir:
+			err = s.driver.Rmdir(p)
+		}
+		return s.sendStatus(id, err)
+	case sftpPacketRename:
+		oldPath, err := b.string()
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		newPath, err := b.string()
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		return s.sendStatus(id, s.driver.Rename(oldPath, newPath))
+	}
+	return s.sendStatus(id, errFTPUnsupported)
+}
+
+func (s *sftpSession) sendHandle(id uint32, h sftpHandle) error {
+	s.lastHandle++
+	handle := strconv.FormatUint(s.lastHandle, 10)
+	s.handles[handle] = h
+	return s.send(newSFTPPacket(sftpPacketHandle, id).string(handle))
+}
+
+// getHandle - returns the handle named by the request.
+func (s *sftpSession) getHandle(b *sftpBuffer) (sftpHandle, error) {
+	handle, err := b.string()
+	if err != nil {
+		return nil, err
+	}
+	h, ok := s.handles[handle]
+	if !ok {
+		return nil, errSFTPBadMessage
+	}
+	return h, nil
+}
+
+func (s *sftpSession) fstat(id uint32, b sftpBuffer) error {
+	h, err := s.getHandle(&b)
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	var fi os.FileInfo
+	switch h := h.(type) {
+	case *sftpReadHandle:
+		fi = ftpFileInfo{name: path.Base(h.objInfo.Name), size: h.size, modTime: h.objInfo.ModTime}
+	case *sftpWriteHandle:
+		if fi, err = h.file.Stat(); err != nil {
+			return s.sendStatus(id, err)
+		}
+	default:
+		fi = ftpFileInfo{name: ".", isDir: true}
+	}
+	return s.send(newSFTPPacket(sftpPacketAttrs, id).attrs(fi))
+}
+
+// readDir - sends the next files of a directory, EOF once all were
+// sent.
+func (s *sftpSession) readDir(id uint32, b sftpBuffer) error {
+	h, err := s.getHandle(&b)
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	dh, ok := h.(*sftpDirHandle)
+	if !ok {
+		return s.sendStatus(id, errSFTPBadMessage)
+	}
+	if len(dh.files) == 0 {
+		return s.sendStatus(id, io.EOF)
+	}
+	files := dh.files
+	if len(files) > sftpReadDirCount {
+		files = files[:sftpReadDirCount]
+	}
+	dh.files = dh.files[len(files):]
+
+	p := newSFTPPacket(sftpPacketName, id).uint32(uint32(len(files)))
+	for _, fi := range files {
+		p = p.string(fi.Name()).string(formatFTPListLine(fi)).attrs(fi)
+	}
+	return s.send(p)
+}
+
+// open - opens an object for reading, or a new object for writing.
+// Objects cannot be appended to nor opened for reading and writing.
+func (s *sftpSession) open(id uint32, b sftpBuffer) error {
+	p, err := b.string()
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	pflags, err := b.uint32()
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+
+	switch {
+	case pflags&sftpOpenWrite == 0:
+		objInfo, err := s.driver.GetObjectInfo(p)
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		return s.sendHandle(id, &sftpReadHandle{
+			driver:  s.driver,
+			objInfo: objInfo,
+			size:    s.driver.getObjectSize(objInfo),
+		})
+	case pflags&(sftpOpenRead|sftpOpenAppend) != 0:
+		return s.sendStatus(id, errFTPUnsupported)
+	}
+
+	bucket, object := splitFTPPath(p)
+	if object == "" {
+		return s.sendStatus(id, errFTPUnsupported)
+	}
+	if !s.driver.isAllowed("s3:PutObject", bucket, object) {
+		return s.sendStatus(id, errFTPAccessDenied)
+	}
+	file, err := ioutil.TempFile("", "minio-sftp-")
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	return s.sendHandle(id, &sftpWriteHandle{driver: s.driver, path: p, file: file})
+}
+
+func (s *sftpSession) read(id uint32, b sftpBuffer) error {
+	h, err := s.getHandle(&b)
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	offset, err := b.uint64()
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	length, err := b.uint32()
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	rh, ok := h.(*sftpReadHandle)
+	if !ok {
+		return s.sendStatus(id, errSFTPBadMessage)
+	}
+	if length > maxSFTPReadSize {
+		length = maxSFTPReadSize
+	}
+	data := make([]byte, length)
+	n, err := rh.ReadAt(data, int64(offset))
+	if n == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return s.sendStatus(id, err)
+	}
+	return s.send(newSFTPPacket(sftpPacketData, id).string(string(data[:n])))
+}
+
+func (s *sftpSession) write(id uint32, b sftpBuffer) error {
+	h, err := s.getHandle(&b)
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	offset, err := b.uint64()
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	data, err := b.string()
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	wh, ok := h.(*sftpWriteHandle)
+	if !ok {
+		return s.sendStatus(id, errSFTPBadMessage)
+	}
+	if int64(offset)+int64(len(data)) > globalMaxObjectSize {
+		return s.sendStatus(id, errors2.Trace(ObjectTooLarge{}))
+	}
+	_, err = wh.file.WriteAt([]byte(data), int64(offset))
+	return s.sendStatus(id, err)
+}
@@ -0,0 +1,250 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sftpTestClient - sends raw SFTP requests of the tests.
+type sftpTestClient struct {
+	t  *testing.T
+	w  io.Writer
+	r  io.Reader
+	id uint32
+}
+
+// request - sends a request, returns the type and the fields of the
+// reply.
+func (c *sftpTestClient) request(packetType byte, fields func(p sftpPacket) sftpPacket) (byte, sftpBuffer) {
+	c.id++
+	p := fields(newSFTPPacket(packetType, c.id))
+	binary.BigEndian.PutUint32(p, uint32(len(p)-4))
+	if _, err := c.w.Write(p); err != nil {
+		c.t.Fatal(err)
+	}
+
+	var header [4]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		c.t.Fatal(err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		c.t.Fatal(err)
+	}
+	b := sftpBuffer(data[1:])
+	if packetType == sftpPacketInit {
+		return data[0], b
+	}
+	if id, _ := b.uint32(); id != c.id {
+		c.t.Fatalf("Expected reply to %d, got %d", c.id, id)
+	}
+	return data[0], b
+}
+
+// status - sends a request, fails unless the expected status is
+// replied.
+func (c *sftpTestClient) status(expectCode uint32, packetType byte, fields func(p sftpPacket) sftpPacket) {
+	replyType, b := c.request(packetType, fields)
+	if replyType != sftpPacketStatus {
+		c.t.Fatalf("Expected a status, got packet type %d", replyType)
+	}
+	if code, _ := b.uint32(); code != expectCode {
+		message, _ := b.string()
+		c.t.Fatalf("Expected status %d, got %d: %s", expectCode, code, message)
+	}
+}
+
+// open - opens a file or a directory, returns its handle.
+func (c *sftpTestClient) open(packetType byte, fields func(p sftpPacket) sftpPacket) string {
+	replyType, b := c.request(packetType, fields)
+	if replyType != sftpPacketHandle {
+		code, _ := b.uint32()
+		c.t.Fatalf("Expected a handle, got packet type %d status %d", replyType, code)
+	}
+	handle, _ := b.string()
+	return handle
+}
+
+// Tests serving a bucket over SFTP.
+func TestSFTPServer(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	obj, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+	if err = obj.MakeBucketWithLocation("bucket", ""); err != nil {
+		t.Fatal(err)
+	}
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer resetGlobalObjectAPI()
+
+	hostKey, err := loadSFTPHostKey(filepath.Join(rootPath, sftpHostKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	go serveSFTP(l, hostKey, doneCh)
+
+	cred := globalServerConfig.GetCredential()
+	config := &ssh.ClientConfig{
+		User:            cred.AccessKey,
+		Auth:            []ssh.AuthMethod{ssh.Password("wrong-password")},
+		HostKeyCallback: ssh.FixedHostKey(hostKey.PublicKey()),
+	}
+	if _, err = ssh.Dial("tcp", l.Addr().String(), config); err == nil {
+		t.Fatal("Expected login with a wrong password to fail")
+	}
+	config.Auth = []ssh.AuthMethod{ssh.Password(cred.SecretKey)}
+	client, err := ssh.Dial("tcp", l.Addr().String(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	if err = session.Shell(); err == nil {
+		t.Fatal("Expected shells to be refused")
+	}
+	w, err := session.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = session.RequestSubsystem("sftp"); err != nil {
+		t.Fatal(err)
+	}
+	c := &sftpTestClient{t: t, w: w, r: r}
+
+	// Init and version packets carry no request id.
+	replyType, b := c.request(sftpPacketInit, func(p sftpPacket) sftpPacket {
+		return sftpPacket{0, 0, 0, 0, sftpPacketInit}.uint32(sftpProtocolVersion)
+	})
+	if version, _ := b.uint32(); replyType != sftpPacketVersion || version != sftpProtocolVersion {
+		t.Fatalf("Expected version %d, got packet type %d version %d", sftpProtocolVersion, replyType, version)
+	}
+
+	data := "hello, world"
+	handle := c.open(sftpPacketOpen, func(p sftpPacket) sftpPacket {
+		return p.string("/bucket/hello.txt").uint32(sftpOpenWrite | 0x8 | 0x10).uint32(0)
+	})
+	c.status(sftpStatusOK, sftpPacketWrite, func(p sftpPacket) sftpPacket {
+		return p.string(handle).uint64(7).string(data[7:])
+	})
+	c.status(sftpStatusOK, sftpPacketWrite, func(p sftpPacket) sftpPacket {
+		return p.string(handle).uint64(0).string(data[:7])
+	})
+	c.status(sftpStatusOK, sftpPacketClose, func(p sftpPacket) sftpPacket {
+		return p.string(handle)
+	})
+
+	replyType, b = c.request(sftpPacketStat, func(p sftpPacket) sftpPacket {
+		return p.string("/bucket/hello.txt")
+	})
+	if replyType != sftpPacketAttrs {
+		t.Fatalf("Expected attributes, got packet type %d", replyType)
+	}
+	if flags, _ := b.uint32(); flags&sftpAttrSize == 0 {
+		t.Fatalf("Expected the size in flags %x", flags)
+	}
+	if size, _ := b.uint64(); size != uint64(len(data)) {
+		t.Fatalf("Expected size %d, got %d", len(data), size)
+	}
+
+	handle = c.open(sftpPacketOpen, func(p sftpPacket) sftpPacket {
+		return p.string("/bucket/hello.txt").uint32(sftpOpenRead).uint32(0)
+	})
+	for _, offset := range []uint64{7, 0} {
+		replyType, b = c.request(sftpPacketRead, func(p sftpPacket) sftpPacket {
+			return p.string(handle).uint64(offset).uint32(32 * 1024)
+		})
+		if replyType != sftpPacketData {
+			t.Fatalf("Expected data, got packet type %d", replyType)
+		}
+		if received, _ := b.string(); received != data[offset:] {
+			t.Fatalf("Expected %q, got %q", data[offset:], received)
+		}
+	}
+	c.status(sftpStatusEOF, sftpPacketRead, func(p sftpPacket) sftpPacket {
+		return p.string(handle).uint64(uint64(len(data))).uint32(32 * 1024)
+	})
+	c.status(sftpStatusOK, sftpPacketClose, func(p sftpPacket) sftpPacket {
+		return p.string(handle)
+	})
+
+	handle = c.open(sftpPacketOpendir, func(p sftpPacket) sftpPacket {
+		return p.string("/bucket")
+	})
+	replyType, b = c.request(sftpPacketReaddir, func(p sftpPacket) sftpPacket {
+		return p.string(handle)
+	})
+	if replyType != sftpPacketName {
+		t.Fatalf("Expected names, got packet type %d", replyType)
+	}
+	if count, _ := b.uint32(); count != 1 {
+		t.Fatalf("Expected 1 file, got %d", count)
+	}
+	if name, _ := b.string(); name != "hello.txt" {
+		t.Fatalf("Expected hello.txt, got %s", name)
+	}
+	c.status(sftpStatusEOF, sftpPacketReaddir, func(p sftpPacket) sftpPacket {
+		return p.string(handle)
+	})
+	c.status(sftpStatusOK, sftpPacketClose, func(p sftpPacket) sftpPacket {
+		return p.string(handle)
+	})
+
+	c.status(sftpStatusOK, sftpPacketRename, func(p sftpPacket) sftpPacket {
+		return p.string("/bucket/hello.txt").string("/bucket/greeting.txt")
+	})
+	c.status(sftpStatusNoSuchFile, sftpPacketStat, func(p sftpPacket) sftpPacket {
+		return p.string("/bucket/hello.txt")
+	})
+	c.status(sftpStatusOK, sftpPacketRemove, func(p sftpPacket) sftpPacket {
+		return p.string("/bucket/greeting.txt")
+	})
+	c.status(sftpStatusOpUnsupported, 20, func(p sftpPacket) sftpPacket {
+		return p.string("/bucket/link").string("/bucket/target")
+	})
+}
@@ -24,16 +24,33 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/minio/minio/pkg/auth"
 )
 
 // Signature and API related constants.
 const (
 	signV2Algorithm = "AWS"
+
+	// Environment variable turning signature V2 on or off.
+	signatureV2Env = "MINIO_SIGNATURE_V2"
 )
 
+// Turns signature V2 off if MINIO_SIGNATURE_V2 is set to "off".
+func handleSignatureV2Env() {
+	switch value := os.Getenv(signatureV2Env); value {
+	case "", "on":
+	case "off":
+		globalIsSignatureV2 = false
+	default:
+		fatalIf(fmt.Errorf("invalid value"), "Unknown value ‘%s’ in %s environment variable.", value, signatureV2Env)
+	}
+}
+
 // AWS S3 Signature V2 calculation rule is give here:
 // http://docs.aws.amazon.com/AmazonS3/latest/dev/RESTAuthentication.html#RESTAuthenticationStringToSign
 
@@ -41,11 +58,13 @@ const (
 // The list should be alphabetically sorted
 var resourceList = []string{
 	"acl",
+	"cors",
 	"delete",
 	"lifecycle",
 	"location",
 	"logging",
 	"notification",
+	"object-lock",
 	"partNumber",
 	"policy",
 	"requestPayment",
@@ -55,6 +74,8 @@ var resourceList = []string{
 	"response-content-language",
 	"response-content-type",
 	"response-expires",
+	"restore",
+	"tagging",
 	"torrent",
 	"uploadId",
 	"uploads",
@@ -65,10 +86,10 @@ var resourceList = []string{
 }
 
 func doesPolicySignatureV2Match(formValues http.Header) APIErrorCode {
-	cred := globalServerConfig.GetCredential()
 	accessKey := formValues.Get("AWSAccessKeyId")
-	if accessKey != cred.AccessKey {
-		return ErrInvalidAccessKeyID
+	cred, errCode := getSigningCredential(accessKey, formValues.Get(amzSecurityToken))
+	if errCode != ErrNone {
+		return errCode
 	}
 	policy := formValues.Get("Policy")
 	signature := formValues.Get("Signature")
@@ -96,9 +117,6 @@ func unescapeQueries(encodedQuery string) (unescapedQueries []string, err error)
 //     - http://docs.aws.amazon.com/AmazonS3/latest/dev/RESTAuthentication.html#RESTAuthenticationQueryStringAuth
 // returns ErrNone if matches. S3 errors otherwise.
 func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
-	// Access credentials.
-	cred := globalServerConfig.GetCredential()
-
 	// r.RequestURI will have raw encoded URI as sent by the client.
 	tokens := strings.SplitN(r.RequestURI, "?", 2)
 	encodedResource := tokens[0]
@@ -115,6 +133,13 @@ func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
 		err             error
 	)
 
+	// Query params of x-amz- headers, like the session token of
+	// temporary credentials, are signed as headers.
+	headers := make(http.Header)
+	for key, values := range r.Header {
+		headers[key] = values
+	}
+
 	var unescapedQueries []string
 	unescapedQueries, err = unescapeQueries(encodedQuery)
 	if err != nil {
@@ -135,6 +160,10 @@ func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
 		case "Expires":
 			expires = keyval[1]
 		default:
+			if strings.HasPrefix(strings.ToLower(keyval[0]), "x-amz-") {
+				headers.Add(keyval[0], keyval[1])
+				continue
+			}
 			filteredQueries = append(filteredQueries, query)
 		}
 	}
@@ -144,9 +173,9 @@ func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
 		return ErrInvalidQueryParams
 	}
 
-	// Validate if access key id same.
-	if accessKey != cred.AccessKey {
-		return ErrInvalidAccessKeyID
+	cred, errCode := getSigningCredential(accessKey, getReqSessionToken(r))
+	if errCode != ErrNone {
+		return errCode
 	}
 
 	// Make sure the request has not expired.
@@ -160,12 +189,12 @@ func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
 		return ErrExpiredPresignRequest
 	}
 
-	encodedResource, err = getResource(encodedResource, r.Host, globalDomainName)
+	encodedResource, err = getResource(encodedResource, r.Host, globalDomainNames)
 	if err != nil {
 		return ErrInvalidRequest
 	}
 
-	expectedSignature := preSignatureV2(r.Method, encodedResource, strings.Join(filteredQueries, "&"), r.Header, expires)
+	expectedSignature := preSignatureV2(r.Method, encodedResource, strings.Join(filteredQueries, "&"), headers, expires, cred.SecretKey)
 	if !compareSignatureV2(gotSignature, expectedSignature) {
 		return ErrSignatureDoesNotMatch
 	}
@@ -193,41 +222,37 @@ func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
 //     - http://docs.aws.amazon.com/AmazonS3/latest/dev/auth-request-sig-v2.html
 // returns true if matches, false otherwise. if error is not nil then it is always false
 
-func validateV2AuthHeader(v2Auth string) APIErrorCode {
+func validateV2AuthHeader(v2Auth, sessionToken string) (auth.Credentials, APIErrorCode) {
 	if v2Auth == "" {
-		return ErrAuthHeaderEmpty
+		return auth.Credentials{}, ErrAuthHeaderEmpty
 	}
 	// Verify if the header algorithm is supported or not.
 	if !strings.HasPrefix(v2Auth, signV2Algorithm) {
-		return ErrSignatureVersionNotSupported
+		return auth.Credentials{}, ErrSignatureVersionNotSupported
 	}
 
 	// below is V2 Signed Auth header format, splitting on `space` (after the `AWS` string).
 	// Authorization = "AWS" + " " + AWSAccessKeyId + ":" + Signature
 	authFields := strings.Split(v2Auth, " ")
 	if len(authFields) != 2 {
-		return ErrMissingFields
+		return auth.Credentials{}, ErrMissingFields
 	}
 
 	// Then will be splitting on ":", this will seprate `AWSAccessKeyId` and `Signature` string.
 	keySignFields := strings.Split(strings.TrimSpace(authFields[1]), ":")
 	if len(keySignFields) != 2 {
-		return ErrMissingFields
+		return auth.Credentials{}, ErrMissingFields
 	}
 
-	// Access credentials.
-	cred := globalServerConfig.GetCredential()
-	if keySignFields[0] != cred.AccessKey {
-		return ErrInvalidAccessKeyID
-	}
-
-	return ErrNone
+	// Access credentials, temporary ones are verified with the session token.
+	return getSigningCredential(keySignFields[0], sessionToken)
 }
 
 func doesSignV2Match(r *http.Request) APIErrorCode {
 	v2Auth := r.Header.Get("Authorization")
 
-	if apiError := validateV2AuthHeader(v2Auth); apiError != ErrNone {
+	cred, apiError := validateV2AuthHeader(v2Auth, getReqSessionToken(r))
+	if apiError != ErrNone {
 		return apiError
 	}
 
@@ -244,17 +269,17 @@ func doesSignV2Match(r *http.Request) APIErrorCode {
 		return ErrInvalidQueryParams
 	}
 
-	encodedResource, err = getResource(encodedResource, r.Host, globalDomainName)
+	encodedResource, err = getResource(encodedResource, r.Host, globalDomainNames)
 	if err != nil {
 		return ErrInvalidRequest
 	}
 
-	prefix := fmt.Sprintf("%s %s:", signV2Algorithm, globalServerConfig.GetCredential().AccessKey)
+	prefix := fmt.Sprintf("%s %s:", signV2Algorithm, cred.AccessKey)
 	if !strings.HasPrefix(v2Auth, prefix) {
 		return ErrSignatureDoesNotMatch
 	}
 	v2Auth = v2Auth[len(prefix):]
-	expectedAuth := signatureV2(r.Method, encodedResource, strings.Join(unescapedQueries, "&"), r.Header)
+	expectedAuth := signatureV2(r.Method, encodedResource, strings.Join(unescapedQueries, "&"), r.Header, cred.SecretKey)
 	if !compareSignatureV2(v2Auth, expectedAuth) {
 		return ErrSignatureDoesNotMatch
 	}
@@ -268,17 +293,15 @@ func calculateSignatureV2(stringToSign string, secret string) string {
 }
 
 // Return signature-v2 for the presigned request.
-func preSignatureV2(method string, encodedResource string, encodedQuery string, headers http.Header, expires string) string {
-	cred := globalServerConfig.GetCredential()
+func preSignatureV2(method string, encodedResource string, encodedQuery string, headers http.Header, expires string, secretKey string) string {
 	stringToSign := getStringToSignV2(method, encodedResource, encodedQuery, headers, expires)
-	return calculateSignatureV2(stringToSign, cred.SecretKey)
+	return calculateSignatureV2(stringToSign, secretKey)
 }
 
 // Return the signature v2 of a given request.
-func signatureV2(method string, encodedResource string, encodedQuery string, headers http.Header) string {
-	cred := globalServerConfig.GetCredential()
+func signatureV2(method string, encodedResource string, encodedQuery string, headers http.Header, secretKey string) string {
 	stringToSign := getStringToSignV2(method, encodedResource, encodedQuery, headers, "")
-	signature := calculateSignatureV2(stringToSign, cred.SecretKey)
+	signature := calculateSignatureV2(stringToSign, secretKey)
 	return signature
 }
 
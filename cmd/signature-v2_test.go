@@ -23,6 +23,7 @@ import (
 	"os"
 	"sort"
 	"testing"
+	"time"
 )
 
 // Tests for 'func TestResourceListSorting(t *testing.T)'.
@@ -217,7 +218,7 @@ func TestValidateV2AuthHeader(t *testing.T) {
 	for i, testCase := range testCases {
 		t.Run(fmt.Sprintf("Case %d AuthStr \"%s\".", i+1, testCase.authString), func(t *testing.T) {
 
-			actualErrCode := validateV2AuthHeader(testCase.authString)
+			_, actualErrCode := validateV2AuthHeader(testCase.authString, "")
 
 			if testCase.expectedError != actualErrCode {
 				t.Errorf("Expected the error code to be %v, got %v.", testCase.expectedError, actualErrCode)
@@ -256,3 +257,126 @@ func TestDoesPolicySignatureV2Match(t *testing.T) {
 		}
 	}
 }
+
+// Tests requests signed with temporary credentials.
+func TestSignatureV2SessionCredentials(t *testing.T) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal("Unable to initialize test config.")
+	}
+	defer os.RemoveAll(root)
+
+	sessionCred, sessionToken, _, err := newSessionCredentials("alice", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherToken, _, err := newSessionCredentials("bob", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		token    string
+		expected APIErrorCode
+	}{
+		{sessionToken, ErrNone},
+		{otherToken, ErrInvalidAccessKeyID},
+		{"", ErrInvalidAccessKeyID},
+	}
+	for i, testCase := range testCases {
+		// Signed with the authorization header.
+		req, err := http.NewRequest(http.MethodGet, "http://host/bucket/object?acl", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Date", UTCNow().Format(http.TimeFormat))
+		if testCase.token != "" {
+			req.Header.Set(amzSecurityToken, testCase.token)
+		}
+		if err = signRequestV2(req, sessionCred.AccessKey, sessionCred.SecretKey); err != nil {
+			t.Fatal(err)
+		}
+		req.RequestURI = req.URL.RequestURI()
+		if errCode := doesSignV2Match(req); errCode != testCase.expected {
+			t.Errorf("(%d) expected to get %s, instead got %s", i+1, niceError(testCase.expected), niceError(errCode))
+		}
+
+		// Presigned, the session token in the query is signed as a header.
+		req, err = http.NewRequest(http.MethodGet, "http://host/bucket/object", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if testCase.token != "" {
+			req.URL.RawQuery = url.Values{amzSecurityToken: []string{testCase.token}}.Encode()
+			req.Header.Set(amzSecurityToken, testCase.token)
+		}
+		if err = preSignV2(req, sessionCred.AccessKey, sessionCred.SecretKey, 60); err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Del(amzSecurityToken)
+		req.RequestURI = req.URL.RequestURI()
+		if errCode := doesPresignV2SignatureMatch(req); errCode != testCase.expected {
+			t.Errorf("(%d) expected to get %s, instead got %s", i+1, niceError(testCase.expected), niceError(errCode))
+		}
+	}
+
+	// Temporary credentials are allowed their granted policies only.
+	req, err := http.NewRequest(http.MethodGet, "http://host/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Date", UTCNow().Format(http.TimeFormat))
+	req.Header.Set(amzSecurityToken, sessionToken)
+	if err = signRequestV2(req, sessionCred.AccessKey, sessionCred.SecretKey); err != nil {
+		t.Fatal(err)
+	}
+	req.RequestURI = req.URL.RequestURI()
+	if errCode := checkRequestAuthType(req, "", "", globalMinioDefaultRegion); errCode != ErrAccessDenied {
+		t.Errorf("Expected to get %s, instead got %s", niceError(ErrAccessDenied), niceError(errCode))
+	}
+}
+
+// Tests requests signed with signature V2 are rejected when it is
+// turned off.
+func TestSignatureV2Disabled(t *testing.T) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal("Unable to initialize test config.")
+	}
+	defer os.RemoveAll(root)
+
+	defer func() { globalIsSignatureV2 = true }()
+	cred := globalServerConfig.GetCredential()
+
+	for _, enabled := range []bool{true, false} {
+		globalIsSignatureV2 = enabled
+		expected := ErrNone
+		if !enabled {
+			expected = ErrSignatureVersionNotSupported
+		}
+
+		req, err := http.NewRequest(http.MethodGet, "http://host/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Date", UTCNow().Format(http.TimeFormat))
+		if err = signRequestV2(req, cred.AccessKey, cred.SecretKey); err != nil {
+			t.Fatal(err)
+		}
+		req.RequestURI = req.URL.RequestURI()
+		if errCode := checkRequestAuthType(req, "", "", globalMinioDefaultRegion); errCode != expected {
+			t.Errorf("Expected to get %s, instead got %s", niceError(expected), niceError(errCode))
+		}
+		if isSupportedS3AuthType(getRequestAuthType(req)) != enabled {
+			t.Errorf("Expected signature V2 support to be %t", enabled)
+		}
+
+		formValues := make(http.Header)
+		formValues.Set("Awsaccesskeyid", cred.AccessKey)
+		formValues.Set("Signature", calculateSignatureV2("policy", cred.SecretKey))
+		formValues.Set("Policy", "policy")
+		if errCode := doesPolicySignatureMatch(formValues); errCode != expected {
+			t.Errorf("Expected to get %s, instead got %s", niceError(expected), niceError(errCode))
+		}
+	}
+}
@@ -38,6 +38,10 @@ func TestDoesPolicySignatureMatch(t *testing.T) {
 	credentialTemplate := "%s/%s/%s/s3/aws4_request"
 	now := UTCNow()
 	accessKey := globalServerConfig.GetCredential().AccessKey
+	sessionCred, sessionToken, _, err := newSessionCredentials("alice", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	testCases := []struct {
 		form     http.Header
@@ -80,6 +84,45 @@ func TestDoesPolicySignatureMatch(t *testing.T) {
 			},
 			expected: ErrNone,
 		},
+		// (4) It should succeed if signed with temporary credentials and their session token.
+		{
+			form: http.Header{
+				"X-Amz-Credential": []string{
+					fmt.Sprintf(credentialTemplate, sessionCred.AccessKey, now.Format(yyyymmdd), globalMinioDefaultRegion),
+				},
+				"X-Amz-Date": []string{now.Format(iso8601Format)},
+				"X-Amz-Signature": []string{
+					getSignature(getSigningKey(sessionCred.SecretKey, now, globalMinioDefaultRegion), "policy"),
+				},
+				"X-Amz-Security-Token": []string{sessionToken},
+				"Policy":               []string{"policy"},
+			},
+			expected: ErrNone,
+		},
+		// (5) It should fail if signed with temporary credentials without their session token.
+		{
+			form: http.Header{
+				"X-Amz-Credential": []string{
+					fmt.Sprintf(credentialTemplate, sessionCred.AccessKey, now.Format(yyyymmdd), globalMinioDefaultRegion),
+				},
+				"X-Amz-Date": []string{now.Format(iso8601Format)},
+				"X-Amz-Signature": []string{
+					getSignature(getSigningKey(sessionCred.SecretKey, now, globalMinioDefaultRegion), "policy"),
+				},
+				"Policy": []string{"policy"},
+			},
+			expected: ErrInvalidAccessKeyID,
+		},
+		// (6) It should succeed with a V2 signature of temporary credentials.
+		{
+			form: http.Header{
+				"Awsaccesskeyid":       []string{sessionCred.AccessKey},
+				"Signature":            []string{calculateSignatureV2("policy", sessionCred.SecretKey)},
+				"X-Amz-Security-Token": []string{sessionToken},
+				"Policy":               []string{"policy"},
+			},
+			expected: ErrNone,
+		},
 	}
 
 	// Run each test case individually.
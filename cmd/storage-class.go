@@ -75,6 +75,15 @@ func isValidStorageClassMeta(sc string) bool {
 	return sc == reducedRedundancyStorageClass || sc == standardStorageClass
 }
 
+// getStorageClass - returns the storage class of an object by its
+// metadata, objects without one are of the standard storage class.
+func getStorageClass(metadata map[string]string) string {
+	if sc := metadata[amzStorageClass]; isValidStorageClassMeta(sc) {
+		return sc
+	}
+	return standardStorageClass
+}
+
 func (sc *storageClass) UnmarshalText(b []byte) error {
 	scStr := string(b)
 	if scStr == "" {
@@ -19,8 +19,13 @@ package cmd
 import (
 	"bytes"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"testing"
+
+	"github.com/minio/minio/pkg/auth"
 )
 
 func TestParseStorageClass(t *testing.T) {
@@ -342,3 +347,88 @@ func TestIsValidStorageClassMeta(t *testing.T) {
 		}
 	}
 }
+
+// Tests the storage class of objects by their metadata.
+func TestGetStorageClass(t *testing.T) {
+	tests := []struct {
+		metadata map[string]string
+		want     string
+	}{
+		{nil, standardStorageClass},
+		{map[string]string{amzStorageClass: reducedRedundancyStorageClass}, reducedRedundancyStorageClass},
+		{map[string]string{amzStorageClass: standardStorageClass}, standardStorageClass},
+		{map[string]string{amzStorageClass: "GLACIER"}, standardStorageClass},
+	}
+	for i, tt := range tests {
+		if got := getStorageClass(tt.metadata); got != tt.want {
+			t.Errorf("Test %d, Expected storage class %s, got %s", i+1, tt.want, got)
+		}
+	}
+}
+
+// Tests changing the storage class of objects by copying them onto
+// themselves.
+func TestCopyObjectStorageClass(t *testing.T) {
+	defer func() { globalIsXL = false }()
+	ExecObjectLayerAPITest(t, testCopyObjectStorageClass, []string{"CopyObject"})
+}
+
+func testCopyObjectStorageClass(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+
+	resetGlobalStorageEnvs()
+	xl, isXL := obj.(*xlObjects)
+	globalIsXL = isXL
+
+	object := "object"
+	data := []byte("hello, world")
+	if _, err := obj.PutObject(bucketName, object, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	testCases := []struct {
+		storageClass string
+		status       int
+		parity       int
+	}{
+		{"GLACIER", http.StatusBadRequest, 8},
+		{reducedRedundancyStorageClass, http.StatusOK, defaultRRSParity},
+		// Copies without a change need the REPLACE metadata directive.
+		{reducedRedundancyStorageClass, http.StatusBadRequest, defaultRRSParity},
+		{standardStorageClass, http.StatusOK, 8},
+	}
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("PUT", getCopyObjectURL("", bucketName, object), 0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Test %d: %v", instanceType, i+1, err)
+		}
+		req.Header.Set("X-Amz-Copy-Source", url.QueryEscape(pathJoin(bucketName, object)))
+		req.Header.Set(amzStorageClassCanonical, testCase.storageClass)
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.status {
+			t.Fatalf("%s: Test %d: Expected the response status to be `%d`, but instead found `%d`: %s", instanceType, i+1, testCase.status, rec.Code, rec.Body.String())
+		}
+
+		objInfo, err := obj.GetObjectInfo(bucketName, object)
+		if err != nil {
+			t.Fatalf("%s: Test %d: %v", instanceType, i+1, err)
+		}
+		if testCase.status == http.StatusOK && getStorageClass(objInfo.UserDefined) != testCase.storageClass {
+			t.Errorf("%s: Test %d: Expected storage class %s, got %v", instanceType, i+1, testCase.storageClass, objInfo.UserDefined)
+		}
+		if !isXL {
+			continue
+		}
+		// The object is rewritten with the parity of its storage class.
+		metaArr, _ := readAllXLMetadata(xl.storageDisks, bucketName, object)
+		if parity := metaArr[0].Erasure.ParityBlocks; parity != testCase.parity {
+			t.Errorf("%s: Test %d: Expected parity %d, got %d", instanceType, i+1, testCase.parity, parity)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := obj.GetObject(bucketName, object, 0, -1, &buffer, ""); err != nil || !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Expected the content of the object, got %q: %v", instanceType, buffer.Bytes(), err)
+	}
+}
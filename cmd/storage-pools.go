@@ -0,0 +1,125 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/minio/minio/pkg/wildcard"
+)
+
+// storagePoolsConfig - erasure sets labeled as pools, e.g. ssd and hdd,
+// by set number counting from 1 in the order of the command line.
+// Objects of the standard and reduced redundancy storage classes are
+// written to the pools standard and rrs, objects of the buckets
+// matching a pattern without a storage class to the bucket's pool.
+type storagePoolsConfig struct {
+	Sets     map[string][]int   `json:"sets"`
+	Standard string             `json:"standard"`
+	RRS      string             `json:"rrs"`
+	Buckets  []bucketPoolConfig `json:"buckets"`
+}
+
+// bucketPoolConfig - pool of the buckets matching a pattern, e.g.
+// archive-*.
+type bucketPoolConfig struct {
+	Bucket string `json:"bucket"`
+	Pool   string `json:"pool"`
+}
+
+// isEmpty - returns true if no pool is configured.
+func (p storagePoolsConfig) isEmpty() bool {
+	return len(p.Sets) == 0 && p.Standard == "" && p.RRS == "" && len(p.Buckets) == 0
+}
+
+// Validate - validates the pools against the erasure sets of the
+// server.
+func (p storagePoolsConfig) Validate() error {
+	if p.isEmpty() {
+		return nil
+	}
+	if !globalIsXL {
+		return errors.New("Storage pools are only allowed in erasure coding mode")
+	}
+	if len(globalXLZones) > 1 {
+		return errors.New("Storage pools are not supported on servers with more than one zone")
+	}
+
+	pools := make(map[int]string)
+	for label, sets := range p.Sets {
+		if label == "" || len(sets) == 0 {
+			return errors.New("Storage pools must be labeled and have at least one erasure set")
+		}
+		for _, set := range sets {
+			if set < 1 || set > globalXLSetCount {
+				return fmt.Errorf("Erasure set %d of storage pool `%s` should be between 1 and %d", set, label, globalXLSetCount)
+			}
+			if other, ok := pools[set]; ok {
+				return fmt.Errorf("Erasure set %d cannot be in both storage pools `%s` and `%s`", set, other, label)
+			}
+			pools[set] = label
+		}
+	}
+
+	isPool := func(label string) bool {
+		_, ok := p.Sets[label]
+		return label == "" || ok
+	}
+	if !isPool(p.Standard) {
+		return fmt.Errorf("Unknown storage pool `%s` of the standard storage class", p.Standard)
+	}
+	if !isPool(p.RRS) {
+		return fmt.Errorf("Unknown storage pool `%s` of the reduced redundancy storage class", p.RRS)
+	}
+	for _, b := range p.Buckets {
+		if b.Bucket == "" || b.Pool == "" {
+			return errors.New("Bucket and pool of bucket pools cannot be empty")
+		}
+		if !isPool(b.Pool) {
+			return fmt.Errorf("Unknown storage pool `%s` of buckets `%s`", b.Pool, b.Bucket)
+		}
+	}
+	return nil
+}
+
+// getPool - returns the pool an object of the bucket written with the
+// storage class is placed on, "" for all erasure sets. Objects of the
+// reduced redundancy storage class go to its pool, other objects to
+// the first matching bucket pool or else the standard pool.
+func (p storagePoolsConfig) getPool(bucket, storageClass string) string {
+	if storageClass == reducedRedundancyStorageClass && p.RRS != "" {
+		return p.RRS
+	}
+	for _, b := range p.Buckets {
+		if wildcard.MatchSimple(b.Bucket, bucket) {
+			return b.Pool
+		}
+	}
+	return p.Standard
+}
+
+// getLabels - returns the labels of all pools, sorted.
+func (p storagePoolsConfig) getLabels() []string {
+	labels := make([]string, 0, len(p.Sets))
+	for label := range p.Sets {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
@@ -0,0 +1,90 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import "testing"
+
+// Tests validating the storage pools configuration.
+func TestStoragePoolsConfigValidate(t *testing.T) {
+	defer func(isXL bool, setCount int) {
+		globalIsXL, globalXLSetCount = isXL, setCount
+	}(globalIsXL, globalXLSetCount)
+	globalIsXL, globalXLSetCount = true, 4
+
+	testCases := []struct {
+		config  storagePoolsConfig
+		isXL    bool
+		success bool
+	}{
+		{storagePoolsConfig{}, false, true},
+		{storagePoolsConfig{Sets: map[string][]int{"ssd": {1}}}, false, false},
+		{storagePoolsConfig{Sets: map[string][]int{"ssd": {1, 2}, "hdd": {3, 4}}, Standard: "ssd", RRS: "hdd", Buckets: []bucketPoolConfig{{Bucket: "archive-*", Pool: "hdd"}}}, true, true},
+		{storagePoolsConfig{Sets: map[string][]int{"ssd": {1}}}, true, true},
+		{storagePoolsConfig{Sets: map[string][]int{"ssd": {}}}, true, false},
+		{storagePoolsConfig{Sets: map[string][]int{"": {1}}}, true, false},
+		{storagePoolsConfig{Sets: map[string][]int{"ssd": {0}}}, true, false},
+		{storagePoolsConfig{Sets: map[string][]int{"ssd": {5}}}, true, false},
+		{storagePoolsConfig{Sets: map[string][]int{"ssd": {1, 2}, "hdd": {2}}}, true, false},
+		{storagePoolsConfig{Sets: map[string][]int{"ssd": {1}}, Standard: "hdd"}, true, false},
+		{storagePoolsConfig{Sets: map[string][]int{"ssd": {1}}, RRS: "hdd"}, true, false},
+		{storagePoolsConfig{Sets: map[string][]int{"ssd": {1}}, Buckets: []bucketPoolConfig{{Bucket: "archive-*", Pool: "hdd"}}}, true, false},
+		{storagePoolsConfig{Sets: map[string][]int{"ssd": {1}}, Buckets: []bucketPoolConfig{{Pool: "ssd"}}}, true, false},
+	}
+	for i, testCase := range testCases {
+		globalIsXL = testCase.isXL
+		err := testCase.config.Validate()
+		if testCase.success && err != nil {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if !testCase.success && err == nil {
+			t.Errorf("Test %d: Expected to fail", i+1)
+		}
+	}
+}
+
+// Tests choosing the pool of objects by bucket and storage class.
+func TestStoragePoolsConfigGetPool(t *testing.T) {
+	config := storagePoolsConfig{
+		Sets:     map[string][]int{"ssd": {1, 2}, "hdd": {3, 4}, "nvme": {5}},
+		Standard: "ssd",
+		RRS:      "hdd",
+		Buckets: []bucketPoolConfig{
+			{Bucket: "archive-*", Pool: "hdd"},
+			{Bucket: "db", Pool: "nvme"},
+		},
+	}
+	testCases := []struct {
+		config       storagePoolsConfig
+		bucket       string
+		storageClass string
+		pool         string
+	}{
+		{config, "bucket", "", "ssd"},
+		{config, "bucket", standardStorageClass, "ssd"},
+		{config, "bucket", reducedRedundancyStorageClass, "hdd"},
+		{config, "archive-2018", "", "hdd"},
+		{config, "db", "", "nvme"},
+		{config, "db", reducedRedundancyStorageClass, "hdd"},
+		{storagePoolsConfig{Sets: config.Sets}, "bucket", reducedRedundancyStorageClass, ""},
+		{storagePoolsConfig{}, "bucket", "", ""},
+	}
+	for i, testCase := range testCases {
+		if pool := testCase.config.getPool(testCase.bucket, testCase.storageClass); pool != testCase.pool {
+			t.Errorf("Test %d: Expected pool `%s`, got `%s`", i+1, testCase.pool, pool)
+		}
+	}
+}
@@ -21,14 +21,19 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"hash"
+	"hash/crc32"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/auth"
 	sha256 "github.com/minio/sha256-simd"
 )
 
@@ -38,13 +43,63 @@ const (
 	streamingContentSHA256   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
 	signV4ChunkedAlgorithm   = "AWS4-HMAC-SHA256-PAYLOAD"
 	streamingContentEncoding = "aws-chunked"
+
+	// Streaming signature followed by a signed trailing checksum.
+	streamingContentSHA256Trailer = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	signV4TrailerAlgorithm        = "AWS4-HMAC-SHA256-TRAILER"
+	amzTrailer                    = "X-Amz-Trailer"
+	amzTrailerSignature           = "x-amz-trailer-signature"
+
+	// Unsigned chunks followed by an unsigned trailing checksum, sent
+	// by newer AWS SDKs over TLS. Only the request headers are signed.
+	streamingUnsignedPayloadTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
 )
 
-// getChunkSignature - get chunk signature.
-func getChunkSignature(seedSignature string, region string, date time.Time, hashedChunk string) string {
-	// Access credentials.
-	cred := globalServerConfig.GetCredential()
+// Checksums which can be sent as trailer of a streaming upload.
+const (
+	amzChecksumCRC32  = "x-amz-checksum-crc32"
+	amzChecksumCRC32C = "x-amz-checksum-crc32c"
+	amzChecksumSHA1   = "x-amz-checksum-sha1"
+	amzChecksumSHA256 = "x-amz-checksum-sha256"
+)
+
+// Checksum sent in the trailer does not match the uploaded data.
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// newTrailerChecksum - returns the hash computing the checksum named
+// by the x-amz-trailer header, nil if the checksum is not supported.
+func newTrailerChecksum(trailer string) hash.Hash {
+	switch strings.ToLower(strings.TrimSpace(trailer)) {
+	case amzChecksumCRC32:
+		return crc32.NewIEEE()
+	case amzChecksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case amzChecksumSHA1:
+		return sha1.New()
+	case amzChecksumSHA256:
+		return sha256.New()
+	}
+	return nil
+}
+
+// getTrailerSignature - get signature of the trailing headers, which
+// are chained to the signature of the last chunk.
+func getTrailerSignature(cred auth.Credentials, seedSignature string, region string, date time.Time, trailer string) string {
+	// Calculate string to sign.
+	stringToSign := signV4TrailerAlgorithm + "\n" +
+		date.Format(iso8601Format) + "\n" +
+		getScope(date, region) + "\n" +
+		seedSignature + "\n" +
+		getSHA256Hash([]byte(trailer))
+
+	// Get hmac signing key.
+	signingKey := getSigningKey(cred.SecretKey, date, region)
 
+	return getSignature(signingKey, stringToSign)
+}
+
+// getChunkSignature - get chunk signature.
+func getChunkSignature(cred auth.Credentials, seedSignature string, region string, date time.Time, hashedChunk string) string {
 	// Calculate string to sign.
 	stringToSign := signV4ChunkedAlgorithm + "\n" +
 		date.Format(iso8601Format) + "\n" +
@@ -66,10 +121,7 @@ func getChunkSignature(seedSignature string, region string, date time.Time, hash
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
 // returns signature, error otherwise if the signature mismatches or any other
 // error while parsing and validating.
-func calculateSeedSignature(r *http.Request) (signature string, region string, date time.Time, errCode APIErrorCode) {
-	// Access credentials.
-	cred := globalServerConfig.GetCredential()
-
+func calculateSeedSignature(r *http.Request) (cred auth.Credentials, signature string, region string, date time.Time, errCode APIErrorCode) {
 	// Configured region.
 	confRegion := globalServerConfig.GetRegion()
 
@@ -82,25 +134,26 @@ func calculateSeedSignature(r *http.Request) (signature string, region string, d
 	// Parse signature version '4' header.
 	signV4Values, errCode := parseSignV4(v4Auth)
 	if errCode != ErrNone {
-		return "", "", time.Time{}, errCode
+		return cred, "", "", time.Time{}, errCode
 	}
 
-	// Payload streaming.
-	payload := streamingContentSHA256
-
 	// Payload for STREAMING signature should be 'STREAMING-AWS4-HMAC-SHA256-PAYLOAD'
-	if payload != req.Header.Get("X-Amz-Content-Sha256") {
-		return "", "", time.Time{}, ErrContentSHA256Mismatch
+	// or 'STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER'.
+	payload := req.Header.Get("X-Amz-Content-Sha256")
+	if payload != streamingContentSHA256 && payload != streamingContentSHA256Trailer {
+		return cred, "", "", time.Time{}, ErrContentSHA256Mismatch
 	}
 
 	// Extract all the signed headers along with its values.
 	extractedSignedHeaders, errCode := extractSignedHeaders(signV4Values.SignedHeaders, r)
 	if errCode != ErrNone {
-		return "", "", time.Time{}, errCode
+		return cred, "", "", time.Time{}, errCode
 	}
-	// Verify if the access key id matches.
-	if signV4Values.Credential.accessKey != cred.AccessKey {
-		return "", "", time.Time{}, ErrInvalidAccessKeyID
+	// Access credentials of the access key, temporary credentials
+	// are signed with their session token.
+	cred, errCode = getSigningCredential(signV4Values.Credential.accessKey, req.Header.Get(amzSecurityToken))
+	if errCode != ErrNone {
+		return cred, "", "", time.Time{}, errCode
 	}
 
 	// Verify if region is valid.
@@ -108,28 +161,28 @@ func calculateSeedSignature(r *http.Request) (signature string, region string, d
 	// Should validate region, only if region is set. Some operations
 	// do not need region validated for example GetBucketLocation.
 	if !isValidRegion(region, confRegion) {
-		return "", "", time.Time{}, ErrInvalidRegion
+		return cred, "", "", time.Time{}, ErrInvalidRegion
 	}
 
 	// Extract date, if not present throw error.
 	var dateStr string
 	if dateStr = req.Header.Get(http.CanonicalHeaderKey("x-amz-date")); dateStr == "" {
 		if dateStr = r.Header.Get("Date"); dateStr == "" {
-			return "", "", time.Time{}, ErrMissingDateHeader
+			return cred, "", "", time.Time{}, ErrMissingDateHeader
 		}
 	}
 	// Parse date header.
 	var err error
 	date, err = time.Parse(iso8601Format, dateStr)
 	if err != nil {
-		return "", "", time.Time{}, ErrMalformedDate
+		return cred, "", "", time.Time{}, ErrMalformedDate
 	}
 
 	// Query string.
 	queryStr := req.URL.Query().Encode()
 
 	// Get canonical request.
-	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, payload, queryStr, req.URL.Path, req.Method)
+	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, payload, queryStr, getSignedURLPath(&req), req.Method)
 
 	// Get string to sign from canonical request.
 	stringToSign := getStringToSign(canonicalRequest, date, signV4Values.Credential.getScope())
@@ -142,11 +195,11 @@ func calculateSeedSignature(r *http.Request) (signature string, region string, d
 
 	// Verify if signature match.
 	if !compareSignatureV4(newSignature, signV4Values.Signature) {
-		return "", "", time.Time{}, ErrSignatureDoesNotMatch
+		return cred, "", "", time.Time{}, ErrSignatureDoesNotMatch
 	}
 
 	// Return caculated signature.
-	return newSignature, region, date, ErrNone
+	return cred, newSignature, region, date, ErrNone
 }
 
 const maxLineLength = 4 * humanize.KiByte // assumed <= bufio.defaultBufSize 4KiB
@@ -160,20 +213,51 @@ var errMalformedEncoding = errors.New("malformed chunked encoding")
 // newSignV4ChunkedReader returns a new s3ChunkedReader that translates the data read from r
 // out of HTTP "chunked" format before returning it.
 // The s3ChunkedReader returns io.EOF when the final 0-length chunk is read.
+// When the final chunk is followed by a trailing checksum, the checksum
+// and the trailer signature are verified before io.EOF is returned.
+// Unsigned payloads only have their request headers verified, their
+// chunks and trailer are decoded without signatures.
 //
 // NewChunkedReader is not needed by normal applications. The http package
 // automatically decodes chunking when reading response bodies.
 func newSignV4ChunkedReader(req *http.Request) (io.ReadCloser, APIErrorCode) {
-	seedSignature, region, seedDate, errCode := calculateSeedSignature(req)
+	var trailer string
+	var checksum hash.Hash
+	payload := req.Header.Get("X-Amz-Content-Sha256")
+	if payload == streamingContentSHA256Trailer || payload == streamingUnsignedPayloadTrailer {
+		trailer = strings.ToLower(strings.TrimSpace(req.Header.Get(amzTrailer)))
+		checksum = newTrailerChecksum(trailer)
+		// Unsigned payloads may be sent without a trailer.
+		if checksum == nil && (payload == streamingContentSHA256Trailer || trailer != "") {
+			return nil, ErrInvalidChecksumTrailer
+		}
+	}
+	if payload == streamingUnsignedPayloadTrailer {
+		if errCode := doesSignatureMatch(payload, req, globalServerConfig.GetRegion()); errCode != ErrNone {
+			return nil, errCode
+		}
+		return &s3ChunkedReader{
+			reader:            bufio.NewReader(req.Body),
+			unsigned:          true,
+			chunkSHA256Writer: sha256.New(),
+			trailer:           trailer,
+			checksum:          checksum,
+			state:             readChunkHeader,
+		}, ErrNone
+	}
+	cred, seedSignature, region, seedDate, errCode := calculateSeedSignature(req)
 	if errCode != ErrNone {
 		return nil, errCode
 	}
 	return &s3ChunkedReader{
+		cred:              cred,
 		reader:            bufio.NewReader(req.Body),
 		seedSignature:     seedSignature,
 		seedDate:          seedDate,
 		region:            region,
 		chunkSHA256Writer: sha256.New(),
+		trailer:           trailer,
+		checksum:          checksum,
 		state:             readChunkHeader,
 	}, ErrNone
 }
@@ -181,14 +265,18 @@ func newSignV4ChunkedReader(req *http.Request) (io.ReadCloser, APIErrorCode) {
 // Represents the overall state that is required for decoding a
 // AWS Signature V4 chunked reader.
 type s3ChunkedReader struct {
+	cred              auth.Credentials
 	reader            *bufio.Reader
 	seedSignature     string
 	seedDate          time.Time
 	region            string
+	unsigned          bool // Chunks and trailer are not signed.
 	state             chunkState
 	lastChunk         bool
 	chunkSignature    string
 	chunkSHA256Writer hash.Hash // Calculates sha256 of chunk data.
+	trailer           string    // Checksum sent after the final chunk, if any.
+	checksum          hash.Hash // Calculates the trailing checksum of all data.
 	n                 uint64    // Unread bytes in chunk
 	err               error
 }
@@ -220,6 +308,7 @@ const (
 	readChunkTrailer
 	readChunk
 	verifyChunk
+	verifyTrailer
 	eofChunk
 )
 
@@ -234,6 +323,8 @@ func (cs chunkState) String() string {
 		stateString = "readChunk"
 	case verifyChunk:
 		stateString = "verifyChunk"
+	case verifyTrailer:
+		stateString = "verifyTrailer"
 	case eofChunk:
 		stateString = "eofChunk"
 
@@ -256,6 +347,11 @@ func (cr *s3ChunkedReader) Read(buf []byte) (n int, err error) {
 			if cr.n == 0 && cr.err == io.EOF {
 				cr.state = readChunkTrailer
 				cr.lastChunk = true
+				if cr.checksum != nil {
+					// Trailing headers follow the final chunk
+					// instead of a CRLF.
+					cr.state = verifyChunk
+				}
 				continue
 			}
 			if cr.err != nil {
@@ -291,6 +387,9 @@ func (cr *s3ChunkedReader) Read(buf []byte) (n int, err error) {
 
 			// Calculate sha256.
 			cr.chunkSHA256Writer.Write(rbuf[:n0])
+			if cr.checksum != nil {
+				cr.checksum.Write(rbuf[:n0])
+			}
 			// Update the bytes read into request buffer so far.
 			n += n0
 			buf = buf[n0:]
@@ -303,30 +402,92 @@ func (cr *s3ChunkedReader) Read(buf []byte) (n int, err error) {
 				continue
 			}
 		case verifyChunk:
-			// Calculate the hashed chunk.
-			hashedChunk := hex.EncodeToString(cr.chunkSHA256Writer.Sum(nil))
-			// Calculate the chunk signature.
-			newSignature := getChunkSignature(cr.seedSignature, cr.region, cr.seedDate, hashedChunk)
-			if !compareSignatureV4(cr.chunkSignature, newSignature) {
-				// Chunk signature doesn't match we return signature does not match.
-				cr.err = errSignatureMismatch
-				return 0, cr.err
+			if !cr.unsigned {
+				// Calculate the hashed chunk.
+				hashedChunk := hex.EncodeToString(cr.chunkSHA256Writer.Sum(nil))
+				// Calculate the chunk signature.
+				newSignature := getChunkSignature(cr.cred, cr.seedSignature, cr.region, cr.seedDate, hashedChunk)
+				if !compareSignatureV4(cr.chunkSignature, newSignature) {
+					// Chunk signature doesn't match we return signature does not match.
+					cr.err = errSignatureMismatch
+					return 0, cr.err
+				}
+				// Newly calculated signature becomes the seed for the next chunk
+				// this follows the chaining.
+				cr.seedSignature = newSignature
 			}
-			// Newly calculated signature becomes the seed for the next chunk
-			// this follows the chaining.
-			cr.seedSignature = newSignature
 			cr.chunkSHA256Writer.Reset()
-			if cr.lastChunk {
+			switch {
+			case cr.lastChunk && cr.checksum != nil:
+				cr.state = verifyTrailer
+			case cr.lastChunk:
 				cr.state = eofChunk
-			} else {
+			default:
 				cr.state = readChunkHeader
 			}
+		case verifyTrailer:
+			if cr.err = cr.verifyTrailer(); cr.err != nil {
+				return 0, cr.err
+			}
+			cr.state = eofChunk
 		case eofChunk:
 			return n, io.EOF
 		}
 	}
 }
 
+// verifyTrailer - reads the trailing headers sent after the final
+// chunk, verifies their signature and that the trailing checksum
+// matches the data read. Unsigned trailers have no signature line.
+//
+//	x-amz-checksum-crc32:sOO8/Q==\r\n
+//	x-amz-trailer-signature:<signature>\r\n
+//	\r\n
+func (cr *s3ChunkedReader) verifyTrailer() error {
+	var trailer, checksum, signature string
+	for {
+		line, err := cr.reader.ReadSlice('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			} else if err == bufio.ErrBufferFull {
+				err = errLineTooLong
+			}
+			return err
+		}
+		line = trimTrailingWhitespace(line)
+		if len(line) == 0 {
+			break
+		}
+		kv := strings.SplitN(string(line), ":", 2)
+		if len(kv) != 2 {
+			return errMalformedEncoding
+		}
+		switch strings.ToLower(kv[0]) {
+		case cr.trailer:
+			trailer, checksum = kv[0], strings.TrimSpace(kv[1])
+		case amzTrailerSignature:
+			signature = strings.TrimSpace(kv[1])
+		default:
+			return errMalformedEncoding
+		}
+	}
+	if checksum == "" || (signature == "") != cr.unsigned {
+		return errMalformedEncoding
+	}
+
+	if !cr.unsigned {
+		newSignature := getTrailerSignature(cr.cred, cr.seedSignature, cr.region, cr.seedDate, trailer+":"+checksum+"\n")
+		if !compareSignatureV4(signature, newSignature) {
+			return errSignatureMismatch
+		}
+	}
+	if base64.StdEncoding.EncodeToString(cr.checksum.Sum(nil)) != checksum {
+		return errChecksumMismatch
+	}
+	return nil
+}
+
 // readCRLF - check if reader only has '\r\n' CRLF character.
 // returns malformed encoding if it doesn't.
 func readCRLF(reader io.Reader) error {
@@ -0,0 +1,209 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"time"
+
+	router "github.com/gorilla/mux"
+)
+
+const (
+	// Path of the STS API issuing temporary credentials, actions are
+	// posted as form values as to the AWS STS API.
+	stsPath = minioReservedBucketPath + "/sts"
+
+	// Action authenticating users against the LDAP directory.
+	stsActionLDAPIdentity = "AssumeRoleWithLDAPIdentity"
+
+	// Action authenticating users by an ID token of the OpenID
+	// provider.
+	stsActionWebIdentity = "AssumeRoleWithWebIdentity"
+
+	// Range and default of the validity of temporary credentials.
+	minSTSDuration     = 15 * time.Minute
+	maxSTSDuration     = 12 * time.Hour
+	defaultSTSDuration = time.Hour
+)
+
+// STSCredentials - temporary credentials issued by the STS API.
+type STSCredentials struct {
+	AccessKeyID     string    `xml:"AccessKeyId"`
+	SecretAccessKey string    `xml:"SecretAccessKey"`
+	SessionToken    string    `xml:"SessionToken"`
+	Expiration      time.Time `xml:"Expiration"`
+}
+
+// AssumeRoleWithLDAPIdentityResponse - response of the
+// AssumeRoleWithLDAPIdentity action.
+type AssumeRoleWithLDAPIdentityResponse struct {
+	XMLName xml.Name `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleWithLDAPIdentityResponse"`
+	Result  struct {
+		Credentials STSCredentials `xml:"Credentials"`
+	} `xml:"AssumeRoleWithLDAPIdentityResult"`
+}
+
+// AssumeRoleWithWebIdentityResponse - response of the
+// AssumeRoleWithWebIdentity action.
+type AssumeRoleWithWebIdentityResponse struct {
+	XMLName xml.Name `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleWithWebIdentityResponse"`
+	Result  struct {
+		SubjectFromWebIdentityToken string         `xml:"SubjectFromWebIdentityToken"`
+		Credentials                 STSCredentials `xml:"Credentials"`
+	} `xml:"AssumeRoleWithWebIdentityResult"`
+}
+
+// registerSTSRouter - registers the STS API.
+func registerSTSRouter(mux *router.Router) {
+	mux.Methods(http.MethodPost).Path(stsPath).HandlerFunc(stsHandler)
+}
+
+// isSTSReq - returns true if the request is for the STS API.
+func isSTSReq(r *http.Request) bool {
+	return r.URL.Path == stsPath
+}
+
+// Returns the validity of temporary credentials requested by the
+// DurationSeconds form value.
+func getSTSDuration(value string) (time.Duration, APIErrorCode) {
+	if value == "" {
+		return defaultSTSDuration, ErrNone
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, ErrSTSInvalidDuration
+	}
+	duration := time.Duration(seconds) * time.Second
+	if duration < minSTSDuration || duration > maxSTSDuration {
+		return 0, ErrSTSInvalidDuration
+	}
+	return duration, ErrNone
+}
+
+// stsHandler - authenticates a user against the identity provider of
+// the action and issues temporary credentials with the managed policies
+// mapped from the groups of the user. Requests are anonymous, the user
+// name and password or the ID token are the credentials.
+//
+// POST /minio/sts
+// Action=AssumeRoleWithLDAPIdentity&LDAPUsername=..&LDAPPassword=..&DurationSeconds=..
+// Action=AssumeRoleWithWebIdentity&WebIdentityToken=..&DurationSeconds=..
+func stsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeErrorResponse(w, ErrRequestBodyParse, r.URL)
+		return
+	}
+	action := r.PostForm.Get("Action")
+	if action != stsActionLDAPIdentity && action != stsActionWebIdentity {
+		writeErrorResponse(w, ErrSTSInvalidAction, r.URL)
+		return
+	}
+	duration, errCode := getSTSDuration(r.PostForm.Get("DurationSeconds"))
+	if errCode != ErrNone {
+		writeErrorResponse(w, errCode, r.URL)
+		return
+	}
+
+	var identity Identity
+	if action == stsActionLDAPIdentity {
+		identity, errCode = stsAuthenticateLDAP(r)
+	} else {
+		identity, errCode = stsAuthenticateWebIdentity(r)
+	}
+	if errCode != ErrNone {
+		writeErrorResponse(w, errCode, r.URL)
+		return
+	}
+
+	grants := globalServerConfig.Identity.getGrants(identity.Groups)
+	if len(grants) == 0 {
+		writeErrorResponse(w, ErrSTSNoPolicy, r.URL)
+		return
+	}
+
+	cred, token, expiry, err := newSessionCredentials(identity.User, grants, duration)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	credentials := STSCredentials{
+		AccessKeyID:     cred.AccessKey,
+		SecretAccessKey: cred.SecretKey,
+		SessionToken:    token,
+		Expiration:      expiry,
+	}
+
+	if action == stsActionLDAPIdentity {
+		var response AssumeRoleWithLDAPIdentityResponse
+		response.Result.Credentials = credentials
+		writeSuccessResponseXML(w, encodeResponse(response))
+		return
+	}
+	var response AssumeRoleWithWebIdentityResponse
+	response.Result.SubjectFromWebIdentityToken = identity.User
+	response.Result.Credentials = credentials
+	writeSuccessResponseXML(w, encodeResponse(response))
+}
+
+// Authenticates the user of an AssumeRoleWithLDAPIdentity request.
+func stsAuthenticateLDAP(r *http.Request) (Identity, APIErrorCode) {
+	if globalIdentityProvider == nil {
+		return Identity{}, ErrSTSNotEnabled
+	}
+
+	// Passwords are accepted in the body only, never in the URL.
+	username, password := r.PostForm.Get("LDAPUsername"), r.PostForm.Get("LDAPPassword")
+	if username == "" || password == "" {
+		return Identity{}, ErrSTSMissingParameter
+	}
+
+	identity, err := globalIdentityProvider.Authenticate(username, password)
+	if err != nil {
+		if err == errInvalidIdentity {
+			return Identity{}, ErrSTSInvalidIdentity
+		}
+		errorIf(err, "Unable to authenticate %s with the identity provider.", username)
+		return Identity{}, ErrSTSIdentityProviderError
+	}
+	return identity, ErrNone
+}
+
+// Authenticates the user of an AssumeRoleWithWebIdentity request by the
+// ID token issued by the OpenID provider.
+func stsAuthenticateWebIdentity(r *http.Request) (Identity, APIErrorCode) {
+	if globalOpenIDProvider == nil {
+		return Identity{}, ErrSTSNotEnabled
+	}
+
+	token := r.PostForm.Get("WebIdentityToken")
+	if token == "" {
+		return Identity{}, ErrSTSMissingParameter
+	}
+
+	identity, err := globalOpenIDProvider.Validate(token)
+	if err != nil {
+		if err == errInvalidIDToken {
+			return Identity{}, ErrSTSInvalidIdentityToken
+		}
+		errorIf(err, "Unable to validate ID token with the OpenID provider.")
+		return Identity{}, ErrSTSIdentityProviderError
+	}
+	return identity, ErrNone
+}
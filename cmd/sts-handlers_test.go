@@ -0,0 +1,338 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	router "github.com/gorilla/mux"
+	miniogo "github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/credentials"
+	"github.com/minio/minio/pkg/auth"
+)
+
+// testIdentityProvider - identity provider of fixed users.
+type testIdentityProvider map[string]Identity
+
+func (p testIdentityProvider) Authenticate(username, password string) (Identity, error) {
+	if password == "unreachable" {
+		return Identity{}, errors.New("connection refused")
+	}
+	identity, ok := p[username]
+	if !ok || password != "secret" {
+		return Identity{}, errInvalidIdentity
+	}
+	return identity, nil
+}
+
+// Posts an STS form to the handler.
+func postSTSForm(handler http.Handler, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, stsPath, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// Tests issuing temporary credentials and enforcing the policies
+// granted to them.
+func TestSTSHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	server := UnstartedTestServer(t, "FS")
+	mux := router.NewRouter().SkipClean(true)
+	registerSTSRouter(mux)
+	registerAPIRouter(mux)
+	server.Server.Config.Handler = mux
+	server.Server.Start()
+	defer server.Stop()
+
+	for _, bucket := range []string{"dev-builds", "prod"} {
+		if err = server.Obj.MakeBucketWithLocation(bucket, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer func() { globalIdentityProvider = nil }()
+	globalIdentityProvider = testIdentityProvider{
+		"john":  {User: "john", Groups: []string{"cn=dev,ou=groups,dc=example,dc=com"}},
+		"alice": {User: "alice", Groups: []string{"auditors"}},
+		"bob":   {User: "bob", Groups: []string{"sales"}},
+	}
+	globalServerConfig.Identity.GroupPolicies = []groupPolicyConfig{
+		{Group: "dev", Policy: "readwrite", Bucket: "dev-*"},
+		{Group: "auditors", Policy: "readonly", Bucket: "*"},
+	}
+
+	form := func(username, password, duration string) url.Values {
+		return url.Values{
+			"Action":          {stsActionLDAPIdentity},
+			"LDAPUsername":    {username},
+			"LDAPPassword":    {password},
+			"DurationSeconds": {duration},
+		}
+	}
+	invalidAction := form("john", "secret", "")
+	invalidAction.Set("Action", "AssumeRole")
+	testCases := []struct {
+		form       url.Values
+		statusCode int
+		errorCode  string
+	}{
+		{invalidAction, http.StatusBadRequest, "InvalidAction"},
+		{form("john", "", ""), http.StatusBadRequest, "MissingParameter"},
+		{form("john", "secret", "60"), http.StatusBadRequest, "InvalidParameterValue"},
+		{form("john", "secret", "1h"), http.StatusBadRequest, "InvalidParameterValue"},
+		{form("john", "wrong", ""), http.StatusForbidden, "AccessDenied"},
+		{form("bob", "secret", ""), http.StatusForbidden, "AccessDenied"},
+		{form("john", "unreachable", ""), http.StatusServiceUnavailable, "IDPCommunicationError"},
+	}
+	for i, testCase := range testCases {
+		rec := postSTSForm(mux, testCase.form)
+		if rec.Code != testCase.statusCode || !strings.Contains(rec.Body.String(), "<Code>"+testCase.errorCode+"</Code>") {
+			t.Errorf("Test %d: Expected %d %s, got %d %s", i+1, testCase.statusCode, testCase.errorCode, rec.Code, rec.Body.String())
+		}
+	}
+
+	assumeRole := func(username string) *miniogo.Client {
+		rec := postSTSForm(mux, form(username, "secret", "3600"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected to succeed, got %d %s", username, rec.Code, rec.Body.String())
+		}
+		var response AssumeRoleWithLDAPIdentityResponse
+		if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		cred := response.Result.Credentials
+		if cred.SessionToken == "" || cred.Expiration.Sub(UTCNow()) > time.Hour {
+			t.Fatalf("%s: Unexpected credentials %+v", username, cred)
+		}
+		u, _ := url.Parse(server.Server.URL)
+		client, err := miniogo.NewWithCredentials(u.Host, credentials.NewStaticV4(cred.AccessKeyID, cred.SecretAccessKey, cred.SessionToken), false, globalMinioDefaultRegion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+	isAccessDenied := func(err error) bool {
+		return miniogo.ToErrorResponse(err).Code == "AccessDenied"
+	}
+
+	// Developers write their buckets only.
+	john := assumeRole("john")
+	data := []byte("hello")
+	if _, err = john.PutObject("dev-builds", "object", bytes.NewReader(data), int64(len(data)), miniogo.PutObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = john.PutObject("prod", "object", bytes.NewReader(data), int64(len(data)), miniogo.PutObjectOptions{}); !isAccessDenied(err) {
+		t.Errorf("Expected access denied on prod, got %v", err)
+	}
+	if _, err = john.StatObject("prod", "object", miniogo.StatObjectOptions{}); err == nil {
+		t.Error("Expected stat on prod to fail")
+	}
+	if _, err = server.Obj.PutObject("prod", "secret", mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = (miniogo.Core{Client: john}).CopyObject("prod", "secret", "dev-builds", "copy", nil); !isAccessDenied(err) {
+		t.Errorf("Expected access denied copying from prod, got %v", err)
+	}
+	if buckets, err := john.ListBuckets(); err != nil || len(buckets) != 1 || buckets[0].Name != "dev-builds" {
+		t.Errorf("Expected only dev-builds listed, got %v %v", buckets, err)
+	}
+	removeObjects := func(client *miniogo.Client, bucket string, objects ...string) (errs []error) {
+		objectsCh := make(chan string, len(objects))
+		for _, object := range objects {
+			objectsCh <- object
+		}
+		close(objectsCh)
+		for removeErr := range client.RemoveObjects(bucket, objectsCh) {
+			errs = append(errs, removeErr.Err)
+		}
+		return errs
+	}
+	if _, err = john.PutObject("dev-builds", "tmp", bytes.NewReader(data), int64(len(data)), miniogo.PutObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if errs := removeObjects(john, "dev-builds", "tmp"); len(errs) != 0 {
+		t.Errorf("Expected deleting multiple objects to succeed, got %v", errs)
+	}
+	if _, err = john.StatObject("dev-builds", "tmp", miniogo.StatObjectOptions{}); err == nil {
+		t.Error("Expected deleted object not to be found")
+	}
+	if errs := removeObjects(john, "prod", "secret"); len(errs) != 1 || !isAccessDenied(errs[0]) {
+		t.Errorf("Expected access denied deleting multiple objects on prod, got %v", errs)
+	}
+
+	// Auditors read everything and write nothing.
+	alice := assumeRole("alice")
+	if _, err = alice.PutObject("dev-builds", "other", bytes.NewReader(data), int64(len(data)), miniogo.PutObjectOptions{}); !isAccessDenied(err) {
+		t.Errorf("Expected access denied writing, got %v", err)
+	}
+	if err = alice.RemoveObject("dev-builds", "object"); !isAccessDenied(err) {
+		t.Errorf("Expected access denied removing, got %v", err)
+	}
+	if buckets, err := alice.ListBuckets(); err != nil || len(buckets) != 2 {
+		t.Errorf("Expected all buckets listed, got %v %v", buckets, err)
+	}
+	object, err := alice.GetObject("dev-builds", "object", miniogo.GetObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read, err := ioutil.ReadAll(object); err != nil || !bytes.Equal(read, data) {
+		t.Errorf("Unexpected object %q, %v", read, err)
+	}
+	for object := range alice.ListObjectsV2("dev-builds", "", true, nil) {
+		if object.Err != nil || object.Key != "object" {
+			t.Errorf("Unexpected object %+v", object)
+		}
+	}
+
+	// Presigned URLs carry the session token.
+	presignedURL, err := alice.PresignedGetObject("dev-builds", "object", time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(presignedURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected presigned GET to succeed, got %d", resp.StatusCode)
+	}
+
+	// Session tokens are bound to their access key and the server key.
+	_, token, _, err := newSessionCredentials("alice", []sessionGrant{{Policy: "readonly", Bucket: "*"}}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(server.Server.URL)
+	rootCred := globalServerConfig.GetCredential()
+	for _, creds := range []*credentials.Credentials{
+		credentials.NewStaticV4(rootCred.AccessKey, rootCred.SecretKey, token),
+		credentials.NewStaticV4(rootCred.AccessKey, rootCred.SecretKey, token[:len(token)-2]),
+	} {
+		client, err := miniogo.NewWithCredentials(u.Host, creds, false, globalMinioDefaultRegion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = client.StatObject("dev-builds", "object", miniogo.StatObjectOptions{}); err == nil {
+			t.Error("Expected a session token of another access key to fail")
+		}
+	}
+
+	if _, errCode := parseSessionToken(token); errCode != ErrNone {
+		t.Errorf("Expected a valid token, got %v", errCode)
+	}
+	_, expired, _, err := newSessionCredentials("alice", nil, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, errCode := parseSessionToken(expired); errCode != ErrExpiredSessionToken {
+		t.Errorf("Expected an expired token, got %v", errCode)
+	}
+
+	// All tokens are revoked with the server credentials.
+	globalServerConfig.SetCredential(auth.MustGetNewCredentials())
+	if _, errCode := parseSessionToken(token); errCode != ErrInvalidSessionToken {
+		t.Errorf("Expected an invalid token, got %v", errCode)
+	}
+
+	globalIdentityProvider = nil
+	if rec := postSTSForm(mux, form("john", "secret", "")); rec.Code != http.StatusNotImplemented {
+		t.Errorf("Expected STS to be disabled, got %d", rec.Code)
+	}
+}
+
+// Tests exchanging ID tokens of the OpenID provider for temporary
+// credentials.
+func TestSTSWebIdentity(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	issuer := newTestOpenIDIssuer(t)
+	defer issuer.server.Close()
+	defer func() { globalOpenIDProvider = nil }()
+	globalOpenIDProvider = issuer.newProvider(t)
+	globalServerConfig.Identity.GroupPolicies = []groupPolicyConfig{{Group: "dev", Policy: "readwrite", Bucket: "dev-*"}}
+
+	mux := router.NewRouter().SkipClean(true)
+	registerSTSRouter(mux)
+	form := func(token string) url.Values {
+		return url.Values{"Action": {stsActionWebIdentity}, "WebIdentityToken": {token}}
+	}
+
+	testCases := []struct {
+		form       url.Values
+		statusCode int
+		errorCode  string
+	}{
+		{form(""), http.StatusBadRequest, "MissingParameter"},
+		{form(issuer.sign(t, "rsa1", jwtgo.MapClaims{"aud": "other"})), http.StatusBadRequest, "InvalidIdentityToken"},
+		{form(issuer.sign(t, "rsa1", nil)), http.StatusForbidden, "AccessDenied"},
+	}
+	for i, testCase := range testCases {
+		rec := postSTSForm(mux, testCase.form)
+		if rec.Code != testCase.statusCode || !strings.Contains(rec.Body.String(), "<Code>"+testCase.errorCode+"</Code>") {
+			t.Errorf("Test %d: Expected %d %s, got %d %s", i+1, testCase.statusCode, testCase.errorCode, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := postSTSForm(mux, form(issuer.sign(t, "rsa1", jwtgo.MapClaims{"groups": []string{"dev"}})))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected to succeed, got %d %s", rec.Code, rec.Body.String())
+	}
+	var response AssumeRoleWithWebIdentityResponse
+	if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Result.SubjectFromWebIdentityToken != "john" {
+		t.Errorf("Unexpected subject %s", response.Result.SubjectFromWebIdentityToken)
+	}
+	cred := response.Result.Credentials
+	claims, errCode := parseSessionToken(cred.SessionToken)
+	if errCode != ErrNone {
+		t.Fatalf("Expected a valid session token, got %v", errCode)
+	}
+	if claims.Subject != cred.AccessKeyID || !reflect.DeepEqual(claims.Grants, []sessionGrant{{Policy: "readwrite", Bucket: "dev-*"}}) {
+		t.Errorf("Unexpected claims %+v", claims)
+	}
+
+	globalOpenIDProvider = nil
+	if rec = postSTSForm(mux, form("token")); rec.Code != http.StatusNotImplemented {
+		t.Errorf("Expected web identities to be disabled, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,298 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/minio/minio-go/pkg/policy"
+	"github.com/minio/minio/pkg/auth"
+	"github.com/minio/minio/pkg/wildcard"
+)
+
+// Temporary credentials are stateless: the session token is a JWT
+// signed with a key derived from the server secret key, naming the
+// access key and the policies granted, and the secret key is derived
+// from the access key. Any server of a cluster verifies them without
+// shared state, they are all revoked when the server credentials are
+// changed.
+
+// Header and query parameter of the session token of requests signed
+// with temporary credentials.
+const amzSecurityToken = "X-Amz-Security-Token"
+
+// Managed policies granted to temporary credentials are cached for this
+// long, changed policies apply to issued credentials within as long.
+const sessionPolicyCacheTTL = time.Minute
+
+// sessionGrant - managed policy granted to temporary credentials on the
+// buckets matching a pattern.
+type sessionGrant struct {
+	Policy string `json:"policy"`
+	Bucket string `json:"bucket"`
+}
+
+// sessionClaims - claims of a session token, the subject is the access
+// key of the temporary credentials. Parent is the ID of the browser
+// refresh token the session token was issued with, if any.
+type sessionClaims struct {
+	User   string         `json:"user"`
+	Grants []sessionGrant `json:"grants"`
+	Parent string         `json:"parent,omitempty"`
+	jwtgo.StandardClaims
+}
+
+// Returns an HMAC of data keyed by the server secret key.
+func getServerHMAC(data string) []byte {
+	mac := hmac.New(sha256.New, []byte(globalServerConfig.GetCredential().SecretKey))
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// Returns the secret key of temporary credentials.
+func getSessionSecretKey(accessKey string) string {
+	// 30 bytes encode to 40 characters, as long as generated secret keys.
+	return base64.StdEncoding.EncodeToString(getServerHMAC("sts-secret-key:" + accessKey)[:30])
+}
+
+// Returns the key session tokens are signed with, which must not sign
+// JWTs of the browser or of other servers.
+func sessionKeyFunc(token *jwtgo.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwtgo.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	}
+	return getServerHMAC("sts-session-token"), nil
+}
+
+// newSessionCredentials - returns temporary credentials of a user with
+// the policies granted, valid for duration.
+func newSessionCredentials(user string, grants []sessionGrant, duration time.Duration) (cred auth.Credentials, token string, expiry time.Time, err error) {
+	return newChildSessionCredentials(user, grants, "", duration)
+}
+
+// newChildSessionCredentials - like newSessionCredentials, the
+// credentials are revoked along with the browser refresh token with the
+// ID parent.
+func newChildSessionCredentials(user string, grants []sessionGrant, parent string, duration time.Duration) (cred auth.Credentials, token string, expiry time.Time, err error) {
+	accessKey := auth.MustGetNewCredentials().AccessKey
+	expiry = UTCNow().Add(duration)
+	key, err := sessionKeyFunc(&jwtgo.Token{Method: jwtgo.SigningMethodHS512})
+	if err != nil {
+		return cred, "", expiry, err
+	}
+	token, err = jwtgo.NewWithClaims(jwtgo.SigningMethodHS512, sessionClaims{
+		User:   user,
+		Grants: grants,
+		Parent: parent,
+		StandardClaims: jwtgo.StandardClaims{
+			Id:        mustGetUUID(),
+			Subject:   accessKey,
+			IssuedAt:  UTCNow().Unix(),
+			ExpiresAt: expiry.Unix(),
+		},
+	}).SignedString(key)
+	if err != nil {
+		return cred, "", expiry, err
+	}
+	cred = auth.Credentials{AccessKey: accessKey, SecretKey: getSessionSecretKey(accessKey)}
+	return cred, token, expiry, nil
+}
+
+// parseSessionToken - verifies a session token and returns its claims.
+func parseSessionToken(token string) (*sessionClaims, APIErrorCode) {
+	claims := &sessionClaims{}
+	if _, err := jwtgo.ParseWithClaims(token, claims, sessionKeyFunc); err != nil {
+		if e, ok := err.(*jwtgo.ValidationError); ok && e.Errors&jwtgo.ValidationErrorExpired != 0 {
+			return nil, ErrExpiredSessionToken
+		}
+		return nil, ErrInvalidSessionToken
+	}
+	return claims, ErrNone
+}
+
+// getReqSessionToken - returns the session token of a request signed
+// with temporary credentials, empty otherwise.
+func getReqSessionToken(r *http.Request) string {
+	if token := r.Header.Get(amzSecurityToken); token != "" {
+		return token
+	}
+	return r.URL.Query().Get(amzSecurityToken)
+}
+
+// getSigningCredential - returns the credentials of the access key a
+// request is signed with, temporary credentials are verified with the
+// session token of the request.
+func getSigningCredential(accessKey, sessionToken string) (auth.Credentials, APIErrorCode) {
+	if sessionToken == "" {
+		cred := globalServerConfig.GetCredential()
+		if accessKey != cred.AccessKey {
+			return auth.Credentials{}, ErrInvalidAccessKeyID
+		}
+		return cred, ErrNone
+	}
+	claims, errCode := parseSessionToken(sessionToken)
+	if errCode != ErrNone {
+		return auth.Credentials{}, errCode
+	}
+	if claims.Subject != accessKey {
+		return auth.Credentials{}, ErrInvalidAccessKeyID
+	}
+	return auth.Credentials{AccessKey: accessKey, SecretKey: getSessionSecretKey(accessKey)}, ErrNone
+}
+
+// enforceSessionPolicy - checks the policies granted to the temporary
+// credentials of a signed request allow the action on the resource of
+// the request. Requests signed with the server credentials are allowed
+// everything, temporary credentials are allowed no actions outside of
+// buckets and no administration of buckets.
+func enforceSessionPolicy(r *http.Request, bucket, action string) APIErrorCode {
+	token := getReqSessionToken(r)
+	if token == "" {
+		return ErrNone
+	}
+	resource, err := getResource(r.URL.Path, r.Host, globalDomainNames)
+	if err != nil {
+		return ErrInternalError
+	}
+	conditions := getConditionKeyMap(r.Referer(), getSourceIPAddress(r), r.URL.Query())
+	return enforceSessionTokenPolicy(token, action, bucket, strings.TrimPrefix(resource, "/"), conditions)
+}
+
+// enforceSessionTokenPolicy - checks the policies granted to the
+// temporary credentials of a session token allow the action on the
+// resource, bucket/object. Used for session tokens not sent in headers
+// or query of requests, like the ones of POST policy forms.
+func enforceSessionTokenPolicy(token, action, bucket, resource string, conditions policy.ConditionKeyMap) APIErrorCode {
+	claims, errCode := parseSessionToken(token)
+	if errCode != ErrNone {
+		return errCode
+	}
+	if bucket == "" || action == "" {
+		return ErrAccessDenied
+	}
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return ErrServerNotInitialized
+	}
+	allowed, err := claims.isAllowed(objAPI, action, bucket, resource, conditions)
+	if err != nil {
+		return ErrInternalError
+	}
+	if !allowed {
+		return ErrAccessDenied
+	}
+	return ErrNone
+}
+
+// isAllowed - returns whether a policy granted on the bucket allows the
+// action on the resource, bucket/object.
+func (c *sessionClaims) isAllowed(objAPI ObjectLayer, action, bucket, resource string, conditions policy.ConditionKeyMap) (bool, error) {
+	arn := bucketARNPrefix + strings.TrimSuffix(resource, "/")
+	for _, grant := range c.Grants {
+		if !wildcard.MatchSimple(grant.Bucket, bucket) {
+			continue
+		}
+		statements, err := globalSessionPolicies.get(objAPI, grant.Policy)
+		if err != nil {
+			errorIf(err, "Unable to load managed policy %s.", grant.Policy)
+			return false, err
+		}
+		bucketPolicy, err := renderManagedPolicy(statements, bucket)
+		if err != nil {
+			return false, err
+		}
+		if bucketPolicyEvalStatements(action, arn, conditions, bucketPolicy.Statements) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// grantsAccess - returns whether a policy granted on the bucket allows
+// any action on the bucket or on some of its objects.
+func (c *sessionClaims) grantsAccess(objAPI ObjectLayer, bucket string, conditions policy.ConditionKeyMap) (bool, error) {
+	for _, grant := range c.Grants {
+		if !wildcard.MatchSimple(grant.Bucket, bucket) {
+			continue
+		}
+		statements, err := globalSessionPolicies.get(objAPI, grant.Policy)
+		if err != nil {
+			errorIf(err, "Unable to load managed policy %s.", grant.Policy)
+			return false, err
+		}
+		bucketPolicy, err := renderManagedPolicy(statements, bucket)
+		if err != nil {
+			return false, err
+		}
+		if bucketPolicyGrantsAccess(bucket, conditions, bucketPolicy.Statements) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sessionPolicyCache - statements of the default versions of managed
+// policies granted to temporary credentials.
+type sessionPolicyCache struct {
+	mu       sync.Mutex
+	policies map[string]cachedSessionPolicy
+}
+
+type cachedSessionPolicy struct {
+	statements []policy.Statement
+	loaded     time.Time
+}
+
+func newSessionPolicyCache() *sessionPolicyCache {
+	return &sessionPolicyCache{policies: make(map[string]cachedSessionPolicy)}
+}
+
+// get - returns the statements of the managed policy, none if the
+// policy does not exist.
+func (c *sessionPolicyCache) get(objAPI ObjectLayer, name string) ([]policy.Statement, error) {
+	c.mu.Lock()
+	cached, ok := c.policies[name]
+	c.mu.Unlock()
+	if ok && UTCNow().Sub(cached.loaded) < sessionPolicyCacheTTL {
+		return cached.statements, nil
+	}
+
+	cached = cachedSessionPolicy{loaded: UTCNow()}
+	p, err := loadManagedPolicy(objAPI, name)
+	switch err {
+	case nil:
+		if version, ok := p.getVersion(0); ok {
+			cached.statements = version.Statements
+		}
+	case errManagedPolicyNotFound:
+	default:
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.policies[name] = cached
+	c.mu.Unlock()
+	return cached.statements, nil
+}
@@ -926,6 +926,102 @@ func newTestStreamingSignedRequest(method, urlStr string, contentLength, chunkSi
 	return req, err
 }
 
+// Returns new HTTP request object signed with streaming signature v4,
+// the final chunk is followed by the given trailing checksum signed
+// with the signature of the final chunk.
+func newTestStreamingSignedTrailerRequest(method, urlStr string, contentLength, chunkSize int64, body io.ReadSeeker, accessKey, secretKey, trailer, checksum string) (*http.Request, error) {
+	req, err := newTestStreamingRequest(method, urlStr, contentLength, chunkSize, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-amz-content-sha256", "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER")
+	req.Header.Set("x-amz-trailer", trailer)
+
+	currTime := UTCNow()
+	signature, err := signStreamingRequest(req, accessKey, secretKey, currTime)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err = assembleStreamingChunks(req, body, chunkSize, secretKey, signature, currTime)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// The final chunk "0;chunk-signature=<signature>\r\n\r\n" is
+	// followed by the trailer instead of the last CRLF.
+	stream = stream[:len(stream)-2]
+	signature = string(stream[len(stream)-66 : len(stream)-2])
+	trailerLine := trailer + ":" + checksum + "\n"
+
+	scope := strings.Join([]string{
+		currTime.Format(yyyymmdd),
+		globalServerConfig.GetRegion(),
+		"s3",
+		"aws4_request",
+	}, "/")
+	stringToSign := "AWS4-HMAC-SHA256-TRAILER" + "\n"
+	stringToSign = stringToSign + currTime.Format(iso8601Format) + "\n"
+	stringToSign = stringToSign + scope + "\n"
+	stringToSign = stringToSign + signature + "\n"
+	stringToSign = stringToSign + getSHA256Hash([]byte(trailerLine))
+
+	date := sumHMAC([]byte("AWS4"+secretKey), []byte(currTime.Format(yyyymmdd)))
+	region := sumHMAC(date, []byte(globalServerConfig.GetRegion()))
+	service := sumHMAC(region, []byte("s3"))
+	signingKey := sumHMAC(service, []byte("aws4_request"))
+	trailerSignature := hex.EncodeToString(sumHMAC(signingKey, []byte(stringToSign)))
+
+	stream = append(stream, []byte(trailer+":"+checksum+"\r\n")...)
+	stream = append(stream, []byte("x-amz-trailer-signature:"+trailerSignature+"\r\n")...)
+	stream = append(stream, []byte("\r\n")...)
+	req.Body = ioutil.NopCloser(bytes.NewReader(stream))
+	return req, nil
+}
+
+// Returns new HTTP request object with unsigned aws-chunked payload,
+// the final chunk is followed by the given unsigned trailing checksum,
+// no trailer is sent if trailer is empty. Only the headers are signed.
+func newTestStreamingUnsignedTrailerRequest(method, urlStr string, chunkSize int64, data []byte, accessKey, secretKey, trailer, checksum string) (*http.Request, error) {
+	dataLength := int64(len(data))
+	var stream []byte
+	for len(data) > 0 {
+		n := int64(len(data))
+		if n > chunkSize {
+			n = chunkSize
+		}
+		stream = append(stream, []byte(fmt.Sprintf("%x\r\n", n))...)
+		stream = append(stream, data[:n]...)
+		stream = append(stream, []byte("\r\n")...)
+		data = data[n:]
+	}
+	stream = append(stream, []byte("0\r\n")...)
+	if trailer != "" {
+		stream = append(stream, []byte(trailer+":"+checksum+"\r\n")...)
+	}
+	stream = append(stream, []byte("\r\n")...)
+
+	req, err := newTestStreamingRequest(method, urlStr, dataLength, chunkSize, bytes.NewReader(stream))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-amz-content-sha256", "STREAMING-UNSIGNED-PAYLOAD-TRAILER")
+	req.Header.Set("content-length", strconv.Itoa(len(stream)))
+	req.ContentLength = int64(len(stream))
+	if trailer != "" {
+		req.Header.Set("x-amz-trailer", trailer)
+	}
+
+	if _, err = signStreamingRequest(req, accessKey, secretKey, UTCNow()); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
 // preSignV4 presign the request, in accordance with
 // http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html.
 func preSignV4(req *http.Request, accessKeyID, secretAccessKey string, expires int64) error {
@@ -1488,6 +1584,13 @@ func getHeadObjectURL(endPoint, bucketName, objectName string) string {
 	return makeTestTargetURL(endPoint, bucketName, objectName, url.Values{})
 }
 
+// return url to be used while fetching the parts of an object.
+func getObjectPartsURL(endPoint, bucketName, objectName string) string {
+	queryValues := url.Values{}
+	queryValues.Set("parts", "")
+	return makeTestTargetURL(endPoint, bucketName, objectName, queryValues)
+}
+
 // return url to be used while copying the object.
 func getCopyObjectURL(endPoint, bucketName, objectName string) string {
 	return makeTestTargetURL(endPoint, bucketName, objectName, url.Values{})
@@ -1548,6 +1651,91 @@ func getDeleteMultipleObjectsURL(endPoint, bucketName string) string {
 	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
 }
 
+// return URL for fetching the summary of a prefix.
+func getPrefixSummaryURL(endPoint, bucketName, prefix string) string {
+	queryValue := url.Values{}
+	queryValue.Set("summary", "")
+	queryValue.Set("prefix", prefix)
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for the object lock config of a bucket.
+func getBucketObjectLockURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("object-lock", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for the website config of a bucket.
+func getBucketWebsiteURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("website", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for the CORS config of a bucket.
+func getBucketCorsURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("cors", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for the metadata defaults of a bucket.
+func getBucketMetadataDefaultsURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("metadata-defaults", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for the retention of an object.
+func getObjectRetentionURL(endPoint, bucketName, objectName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("retention", "")
+	return makeTestTargetURL(endPoint, bucketName, objectName, queryValue)
+}
+
+// return URL for the legal hold of an object.
+func getObjectLegalHoldURL(endPoint, bucketName, objectName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("legal-hold", "")
+	return makeTestTargetURL(endPoint, bucketName, objectName, queryValue)
+}
+
+// return URL for fetching the statistics of a bucket.
+func getBucketStatsURL(endPoint, bucketName, maxPrefixes string) string {
+	queryValue := url.Values{}
+	queryValue.Set("stats", "")
+	if maxPrefixes != "" {
+		queryValue.Set("max-prefixes", maxPrefixes)
+	}
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for fetching a download manifest.
+func getDownloadManifestURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("download-manifest", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for reading several objects at once.
+func getGetObjectsURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("get-objects", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for renaming a prefix, or fetching its progress.
+func getRenamePrefixURL(endPoint, bucketName, prefix, target string) string {
+	queryValue := url.Values{}
+	queryValue.Set("rename", "")
+	queryValue.Set("prefix", prefix)
+	if target != "" {
+		queryValue.Set("target", target)
+	}
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
 // return URL For fetching location of the bucket.
 func getBucketLocationURL(endPoint, bucketName string) string {
 	queryValue := url.Values{}
@@ -1900,8 +2088,8 @@ func ExecObjectLayerAPIAnonTest(t *testing.T, obj ObjectLayer, testName, bucketN
 	// expectedHTTPStatus returns 204 (http.StatusNoContent) on success.
 	if testName == "TestAPIDeleteObjectHandler" || testName == "TestAPIAbortMultipartHandler" {
 		expectedHTTPStatus = http.StatusNoContent
-	} else if strings.Contains(testName, "BucketPolicyHandler") || testName == "ListBucketsHandler" {
-		// BucketPolicyHandlers and `ListBucketsHandler` doesn't support anonymous request, policy changes should allow unsigned requests.
+	} else if strings.Contains(testName, "BucketPolicyHandler") {
+		// BucketPolicyHandlers doesn't support anonymous request, policy changes should allow unsigned requests.
 		expectedHTTPStatus = http.StatusForbidden
 	} else {
 		// other API handlers return 200OK on success.
@@ -2176,9 +2364,63 @@ func registerBucketLevelFunc(bucket *router.Router, api objectAPIHandlers, apiFu
 		case "HeadBucket":
 			// Register HeadBucket handler.
 			bucket.Methods("HEAD").HandlerFunc(api.HeadBucketHandler)
+		case "ListObjectsV2":
+			// Register ListObjectsV2 handler.
+			bucket.Methods("GET").HandlerFunc(api.ListObjectsV2Handler).Queries("list-type", "2")
+		case "ListObjectsV1":
+			// Register ListObjectsV1 handler.
+			bucket.Methods("GET").HandlerFunc(api.ListObjectsV1Handler)
+		case "ListObjectsFast":
+			// Register ListObjectsFast handler.
+			bucket.Methods("GET").HandlerFunc(api.ListObjectsFastHandler).Queries("fast-list", "")
 		case "DeleteMultipleObjects":
 			// Register DeleteMultipleObjects handler.
 			bucket.Methods("POST").HandlerFunc(api.DeleteMultipleObjectsHandler).Queries("delete", "")
+		case "PrefixSummary":
+			// Register GetPrefixSummary and HeadPrefixSummary handlers.
+			bucket.Methods("GET").HandlerFunc(api.GetPrefixSummaryHandler).Queries("summary", "")
+			bucket.Methods("HEAD").HandlerFunc(api.HeadPrefixSummaryHandler).Queries("summary", "")
+		case "BucketStats":
+			// Register GetBucketStats handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketStatsHandler).Queries("stats", "")
+		case "BucketObjectLock":
+			// Register Get and Put bucket object lock config handlers.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketObjectLockConfigHandler).Queries("object-lock", "")
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketObjectLockConfigHandler).Queries("object-lock", "")
+		case "BucketWebsite":
+			// Register Get, Put and Delete bucket website handlers.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketWebsiteHandler).Queries("website", "")
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketWebsiteHandler).Queries("website", "")
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketWebsiteHandler).Queries("website", "")
+		case "BucketCors":
+			// Register Get, Put and Delete bucket CORS handlers.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketCorsHandler).Queries("cors", "")
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketCorsHandler).Queries("cors", "")
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketCorsHandler).Queries("cors", "")
+		case "BucketMetadataDefaults":
+			// Register Get, Put and Delete bucket metadata defaults handlers.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketMetadataDefaultsHandler).Queries("metadata-defaults", "")
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketMetadataDefaultsHandler).Queries("metadata-defaults", "")
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketMetadataDefaultsHandler).Queries("metadata-defaults", "")
+		case "ObjectRetention":
+			// Register Get and Put object retention handlers.
+			bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectRetentionHandler).Queries("retention", "")
+			bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectRetentionHandler).Queries("retention", "")
+		case "ObjectLegalHold":
+			// Register Get and Put object legal hold handlers.
+			bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectLegalHoldHandler).Queries("legal-hold", "")
+			bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectLegalHoldHandler).Queries("legal-hold", "")
+		case "DownloadManifest":
+			// Register DownloadManifest and GetBucketArchive handlers.
+			bucket.Methods("POST").HandlerFunc(api.DownloadManifestHandler).Queries("download-manifest", "")
+			bucket.Methods("GET").HandlerFunc(api.GetBucketArchiveHandler).Queries("archive", "")
+		case "GetObjects":
+			// Register GetObjects handler.
+			bucket.Methods("POST").HandlerFunc(api.GetObjectsHandler).Queries("get-objects", "")
+		case "RenamePrefix":
+			// Register RenamePrefix and GetRenamePrefix handlers.
+			bucket.Methods("POST").HandlerFunc(api.RenamePrefixHandler).Queries("rename", "")
+			bucket.Methods("GET").HandlerFunc(api.GetRenamePrefixHandler).Queries("rename", "")
 		case "NewMultipart":
 			// Register New Multipart upload handler.
 			bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.NewMultipartUploadHandler).Queries("uploads", "")
@@ -2188,6 +2430,9 @@ func registerBucketLevelFunc(bucket *router.Router, api objectAPIHandlers, apiFu
 		case "PutObjectPart":
 			// Register PutObjectPart handler.
 			bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectPartHandler).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
+		case "GetObjectParts":
+			// Register GetObjectParts handler.
+			bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectPartsHandler).Queries("parts", "")
 		case "ListObjectParts":
 			// Register ListObjectParts handler.
 			bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.ListObjectPartsHandler).Queries("uploadId", "{uploadId:.*}")
@@ -0,0 +1,151 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Interval between sweeps of the temporary upload area.
+	tmpSweepInterval = time.Hour
+
+	// Entries of the temporary upload area are only removed once
+	// neither they nor any file within were modified for this long,
+	// uploads in progress keep modifying their files.
+	tmpSweepExpiry = 24 * time.Hour
+)
+
+// tmpSweepStats - counts entries removed from the temporary upload
+// area, left behind by crashed servers or failed uploads.
+type tmpSweepStats struct {
+	sweeps         uint64
+	removedEntries uint64
+	reclaimedBytes uint64
+}
+
+// Statistics of all sweeps of this server.
+var globalTmpSweepStats = &tmpSweepStats{}
+
+// Records a sweep which removed entries of size bytes in total.
+func (s *tmpSweepStats) record(entries, bytes uint64) {
+	atomic.AddUint64(&s.sweeps, 1)
+	atomic.AddUint64(&s.removedEntries, entries)
+	atomic.AddUint64(&s.reclaimedBytes, bytes)
+}
+
+// Return statistics of the sweeps of the temporary upload area.
+func (s *tmpSweepStats) toServerTmpSweepStats() ServerTmpSweepStats {
+	return ServerTmpSweepStats{
+		Sweeps:         atomic.LoadUint64(&s.sweeps),
+		RemovedEntries: atomic.LoadUint64(&s.removedEntries),
+		ReclaimedBytes: atomic.LoadUint64(&s.reclaimedBytes),
+	}
+}
+
+// Returns the total size of entry in the temporary upload area of disk
+// and the latest modification time of any file within. Empty
+// directories are reported with a zero modification time.
+func statTmpEntry(disk StorageAPI, entry string) (size int64, modTime time.Time, err error) {
+	if !hasSuffix(entry, slashSeparator) {
+		fi, err := disk.StatFile(minioMetaTmpBucket, entry)
+		if err != nil {
+			return 0, modTime, err
+		}
+		return fi.Size, fi.ModTime, nil
+	}
+
+	entries, err := disk.ListDir(minioMetaTmpBucket, entry)
+	if err != nil {
+		return 0, modTime, err
+	}
+	for _, child := range entries {
+		childSize, childModTime, err := statTmpEntry(disk, pathJoin(entry, child))
+		if err != nil {
+			return 0, modTime, err
+		}
+		size += childSize
+		if childModTime.After(modTime) {
+			modTime = childModTime
+		}
+	}
+	return size, modTime, nil
+}
+
+// sweepTmpDisk - removes entries in the temporary upload area of disk
+// left unmodified for expiry. Returns the number of entries removed
+// and their size.
+func sweepTmpDisk(disk StorageAPI, expiry time.Duration) (removed, reclaimed uint64) {
+	entries, err := disk.ListDir(minioMetaTmpBucket, "")
+	if err != nil {
+		return 0, 0
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		size, modTime, err := statTmpEntry(disk, entry)
+		if err != nil || modTime.IsZero() || now.Sub(modTime) < expiry {
+			continue
+		}
+		if hasSuffix(entry, slashSeparator) {
+			err = cleanupDir(disk, minioMetaTmpBucket, entry)
+		} else {
+			err = disk.DeleteFile(minioMetaTmpBucket, entry)
+		}
+		if err != nil {
+			errorIf(err, "Unable to remove %s from %s on %s", entry, minioMetaTmpBucket, disk)
+			continue
+		}
+		removed++
+		reclaimed += uint64(size)
+	}
+	return removed, reclaimed
+}
+
+// sweepTmp - sweeps the temporary upload area of all local disks of
+// all sets once.
+func (s *xlSets) sweepTmp(expiry time.Duration) {
+	var removed, reclaimed uint64
+	for i := 0; i < s.setCount; i++ {
+		// Remote disks are swept by their own servers.
+		for _, disk := range s.GetDisks(i)() {
+			if disk == nil || isRemoteDisk(disk) {
+				continue
+			}
+			entries, bytes := sweepTmpDisk(disk, expiry)
+			removed += entries
+			reclaimed += bytes
+		}
+	}
+	globalTmpSweepStats.record(removed, reclaimed)
+}
+
+// sweepTmpRoutine - sweeps the temporary upload area every interval
+// until doneCh is closed.
+func sweepTmpRoutine(sweep func(time.Duration), interval, expiry time.Duration, doneCh chan struct{}) {
+	ticker := time.NewTicker(interval)
+	for {
+		select {
+		case <-doneCh:
+			// Stop the timer.
+			ticker.Stop()
+			return
+		case <-ticker.C:
+			sweep(expiry)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Tests removing expired entries from the temporary upload area of a disk.
+func TestSweepTmpDisk(t *testing.T) {
+	disk, diskPath, err := newPosixTestSetup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(diskPath)
+
+	if err = disk.MakeVol(minioMetaTmpBucket); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * tmpSweepExpiry)
+	for _, name := range []string{"old/part.1", "old/xl.json", "active/part.1", "active/part.2", "old-file"} {
+		if err = disk.AppendFile(minioMetaTmpBucket, name, []byte("data")); err != nil {
+			t.Fatal(err)
+		}
+		if name == "active/part.2" {
+			continue
+		}
+		if err = os.Chtimes(filepath.Join(diskPath, minioMetaTmpBucket, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, reclaimed := sweepTmpDisk(disk, tmpSweepExpiry)
+	if removed != 2 || reclaimed != 12 {
+		t.Errorf("Expected 2 entries of 12 bytes removed, got %d of %d bytes", removed, reclaimed)
+	}
+	entries, err := disk.ListDir(minioMetaTmpBucket, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Directories with a recently modified file are kept as a whole.
+	if len(entries) != 1 || entries[0] != "active/" {
+		t.Errorf("Expected only active/ to be kept, got %v", entries)
+	}
+	if _, err = disk.StatFile(minioMetaTmpBucket, "active/part.1"); err != nil {
+		t.Errorf("Expected active/part.1 to be kept, got %v", err)
+	}
+}
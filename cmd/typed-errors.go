@@ -45,6 +45,42 @@ var errServerNotInitialized = errors.New("Server not initialized, please try aga
 // errRPCAPIVersionUnsupported - unsupported rpc API version.
 var errRPCAPIVersionUnsupported = errors.New("Unsupported rpc API version")
 
+// errServerReadOnly - server was started with --read-only.
+var errServerReadOnly = errors.New("Server is in read-only mode, write requests are not allowed")
+
+// errServerMaintenance - server is in maintenance mode.
+var errServerMaintenance = errors.New("Server is in maintenance mode, write requests are not allowed, please try again later")
+
+// errNoSuchAttestation - object has no signed manifest matching its content.
+var errNoSuchAttestation = errors.New("The object has no signed manifest")
+
+// errAttestationInvalid - signature of a stored manifest does not verify.
+var errAttestationInvalid = errors.New("The signed manifest of the object is invalid")
+
+// errMetadataBackupInvalid - metadata snapshot is not signed by the
+// deployment or its signature does not verify.
+var errMetadataBackupInvalid = errors.New("The metadata snapshot is not signed by this deployment")
+
+// errUsageNotReady - bucket was not crawled into the usage index yet.
+var errUsageNotReady = errors.New("The bucket was not crawled yet")
+
+// errPrefixNotIndexed - prefix was left out of the full usage index of
+// its bucket.
+var errPrefixNotIndexed = errors.New("The prefix is not indexed")
+
+// errRenamePrefixStopped - a rename started with temporary credentials
+// was running when the server stopped, it is not resumed since access
+// to the objects is checked against the request starting it.
+var errRenamePrefixStopped = errors.New("The rename was stopped by a server restart, start it again to resume")
+
+// errClientDisconnected - client closed the connection before the
+// request was done.
+var errClientDisconnected = errors.New("Client disconnected before the request was done")
+
+// errGatewayWriteNotVisible - the backend of the gateway does not
+// return a written object yet.
+var errGatewayWriteNotVisible = errors.New("The written object is not visible in the backend yet, please try again later")
+
 // errServerTimeMismatch - server times are too far apart.
 var errServerTimeMismatch = errors.New("Server times are too far apart")
 
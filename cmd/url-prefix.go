@@ -0,0 +1,97 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Environment variable setting the URL prefix the server is hosted
+// under behind a reverse proxy, e.g. "/storage".
+const urlPrefixEnv = "MINIO_URL_PREFIX"
+
+// Path segments of a URL prefix, restricted to characters which need
+// no escaping so that the prefix is the same in raw and decoded paths.
+var validURLPrefixRegexp = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// Parses a URL prefix, a trailing slash is ignored.
+func parseURLPrefix(value string) (string, error) {
+	prefix := strings.TrimSuffix(value, slashSeparator)
+	if !validURLPrefixRegexp.MatchString(prefix) {
+		return "", errors.New("URL prefix must be an absolute path of unreserved characters")
+	}
+	if prefix == minioReservedBucketPath || hasPrefix(prefix, minioReservedBucketPath+slashSeparator) {
+		return "", errors.New("URL prefix cannot start with the reserved path " + minioReservedBucketPath)
+	}
+	return prefix, nil
+}
+
+// Sets the URL prefix from the environment.
+func handleURLPrefixEnv() {
+	if value := os.Getenv(urlPrefixEnv); value != "" {
+		prefix, err := parseURLPrefix(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", urlPrefixEnv)
+		globalURLPrefix = prefix
+	}
+}
+
+// Key of the path of a request before the URL prefix was stripped in
+// the request context.
+type urlPrefixContextKey struct{}
+
+// Returns the path of the request as sent by the client, which is
+// the path clients sign, including the URL prefix if stripped.
+func getSignedURLPath(r *http.Request) string {
+	if urlPath, ok := r.Context().Value(urlPrefixContextKey{}).(string); ok {
+		return urlPath
+	}
+	return r.URL.Path
+}
+
+// Strips the URL prefix from incoming paths so the server routes them
+// as if hosted at the root. Requests without the prefix, such as
+// internode RPC calls, are passed on unchanged.
+type urlPrefixHandler struct {
+	handler http.Handler
+}
+
+func setURLPrefixHandler(h http.Handler) http.Handler {
+	return urlPrefixHandler{handler: h}
+}
+
+func (h urlPrefixHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := globalURLPrefix
+	if prefix == "" || (r.URL.Path != prefix && !hasPrefix(r.URL.Path, prefix+slashSeparator)) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), urlPrefixContextKey{}, r.URL.Path))
+	r.URL.Path = strings.TrimPrefix(r.URL.Path, prefix)
+	if r.URL.Path == "" {
+		r.URL.Path = slashSeparator
+	}
+	if r.URL.RawPath != "" {
+		r.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, prefix)
+	}
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,128 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// Tests parsing URL prefixes.
+func TestParseURLPrefix(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected string
+		success  bool
+	}{
+		{"/storage", "/storage", true},
+		{"/storage/", "/storage", true},
+		{"/a/b-c/d_e.f~", "/a/b-c/d_e.f~", true},
+		{"", "", false},
+		{"/", "", false},
+		{"storage", "", false},
+		{"/storage//s3", "", false},
+		{"/stor age", "", false},
+		{"/stor%20age", "", false},
+		{"/minio", "", false},
+		{"/minio/storage", "", false},
+		{"/minios", "/minios", true},
+	}
+	for i, testCase := range testCases {
+		prefix, err := parseURLPrefix(testCase.value)
+		if testCase.success && err != nil {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if !testCase.success && err == nil {
+			t.Errorf("Test %d: Expected %q to fail", i+1, testCase.value)
+		}
+		if prefix != testCase.expected {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expected, prefix)
+		}
+	}
+}
+
+// Tests requests signed with the URL prefix are routed without it and
+// pass signature verification.
+func TestURLPrefixHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("unable initialize config file, %s", err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	globalURLPrefix = "/storage"
+	defer func() { globalURLPrefix = "" }()
+
+	cred := globalServerConfig.GetCredential()
+	testCases := []struct {
+		url          string
+		expectedPath string
+	}{
+		{"http://127.0.0.1:9000/storage/bucket/object", "/bucket/object"},
+		{"http://127.0.0.1:9000/storage", "/"},
+		{"http://127.0.0.1:9000/storage/", "/"},
+		{"http://127.0.0.1:9000/bucket/object", "/bucket/object"},
+		{"http://127.0.0.1:9000/storagebucket/object", "/storagebucket/object"},
+	}
+	for i, testCase := range testCases {
+		reqV4, err := newTestSignedRequestV4("GET", testCase.url, 0, nil, cred.AccessKey, cred.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		reqV2, err := newTestSignedRequestV2("GET", testCase.url, 0, nil, cred.AccessKey, cred.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		for _, req := range []*http.Request{reqV4, reqV2} {
+			// Set as by the server for incoming requests.
+			req.RequestURI = req.URL.RequestURI()
+			var path string
+			var s3Error APIErrorCode
+			handler := setURLPrefixHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				if isRequestSignatureV4(r) {
+					s3Error = isReqAuthenticated(r, globalServerConfig.GetRegion())
+				} else {
+					s3Error = isReqAuthenticatedV2(r)
+				}
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			if path != testCase.expectedPath {
+				t.Errorf("Test %d: Expected path %s, got %s", i+1, testCase.expectedPath, path)
+			}
+			if s3Error != ErrNone {
+				t.Errorf("Test %d: Expected signature to match, got %v", i+1, s3Error)
+			}
+		}
+	}
+
+	// Presigned URLs issued by the server include the prefix.
+	presigned := getPresignedLocation(presignedGet("127.0.0.1:9000", "bucket", "object", 60))
+	req, err := http.NewRequest("GET", presigned, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s3Error APIErrorCode
+	setURLPrefixHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s3Error = doesPresignedSignatureMatch(unsignedPayload, r, globalServerConfig.GetRegion())
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	if s3Error != ErrNone {
+		t.Errorf("Expected presigned URL %s to be valid, got %v", presigned, s3Error)
+	}
+}
@@ -226,6 +226,12 @@ func UTCNow() time.Time {
 	return time.Now().UTC()
 }
 
+// newTimeOrderedID - returns a unique ID of something happening at t,
+// IDs sort in the order of their times.
+func newTimeOrderedID(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15-04-05.000000000Z") + "-" + mustGetUUID()
+}
+
 // GenETag - generate UUID based ETag
 func GenETag() string {
 	return ToS3ETag(getMD5Hash([]byte(mustGetUUID())))
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"runtime"
@@ -110,6 +111,39 @@ func (web *webAPIHandlers) StorageInfo(r *http.Request, args *AuthRPCArgs, reply
 	return nil
 }
 
+// GetBucketAccessStatsArgs - bucket to return the access statistics
+// of, all buckets if empty.
+type GetBucketAccessStatsArgs struct {
+	BucketName string `json:"bucketName"`
+}
+
+// GetBucketAccessStatsRep - access statistics of buckets summed over all
+// servers.
+type GetBucketAccessStatsRep struct {
+	Buckets       []bucketAccessStats `json:"buckets"`
+	FailedServers []string            `json:"failedServers"`
+	UIVersion     string              `json:"uiVersion"`
+}
+
+// GetBucketAccessStats - web call to gather the requests served for
+// buckets and the bytes they transferred.
+func (web *webAPIHandlers) GetBucketAccessStats(r *http.Request, args *GetBucketAccessStatsArgs, reply *GetBucketAccessStatsRep) error {
+	if web.ObjectAPI() == nil || globalAccessStats == nil {
+		return toJSONError(errServerNotInitialized)
+	}
+	if !isHTTPRequestValid(r) {
+		return toJSONError(errAuthentication)
+	}
+	if args.BucketName != "" && isReservedOrInvalidBucket(args.BucketName) {
+		return toJSONError(errInvalidBucketName)
+	}
+	report := getAccessStatsReport(globalAdminPeers, args.BucketName)
+	reply.Buckets = report.Buckets
+	reply.FailedServers = report.FailedServers
+	reply.UIVersion = browser.UIVersion
+	return nil
+}
+
 // MakeBucketArgs - make bucket args.
 type MakeBucketArgs struct {
 	BucketName string `json:"bucketName"`
@@ -124,6 +158,9 @@ func (web *webAPIHandlers) MakeBucket(r *http.Request, args *MakeBucketArgs, rep
 	if !isHTTPRequestValid(r) {
 		return toJSONError(errAuthentication)
 	}
+	if err := checkServerWritable(); err != nil {
+		return toJSONError(err)
+	}
 
 	// Check if bucket is a reserved bucket name or invalid.
 	if isReservedOrInvalidBucket(args.BucketName) {
@@ -152,6 +189,9 @@ func (web *webAPIHandlers) DeleteBucket(r *http.Request, args *RemoveBucketArgs,
 	if !isHTTPRequestValid(r) {
 		return toJSONError(errAuthentication)
 	}
+	if err := checkServerWritable(); err != nil {
+		return toJSONError(err)
+	}
 
 	err := objectAPI.DeleteBucket(args.BucketName)
 	if err != nil {
@@ -182,7 +222,7 @@ func (web *webAPIHandlers) ListBuckets(r *http.Request, args *WebGenericArgs, re
 	if objectAPI == nil {
 		return toJSONError(errServerNotInitialized)
 	}
-	authErr := webRequestAuthenticate(r)
+	claims, authErr := webRequestAuthenticateUser(r)
 	if authErr != nil {
 		return toJSONError(authErr)
 	}
@@ -191,6 +231,11 @@ func (web *webAPIHandlers) ListBuckets(r *http.Request, args *WebGenericArgs, re
 		return toJSONError(err)
 	}
 	for _, bucket := range buckets {
+		// Users logged in with OpenID Connect see the buckets they
+		// may list only.
+		if claims != nil && !isWebActionAllowed(claims, objectAPI, "s3:ListBucket", bucket.Name, "") {
+			continue
+		}
 		reply.Buckets = append(reply.Buckets, WebBucketInfo{
 			Name:         bucket.Name,
 			CreationDate: bucket.Created,
@@ -200,13 +245,19 @@ func (web *webAPIHandlers) ListBuckets(r *http.Request, args *WebGenericArgs, re
 	return nil
 }
 
-// ListObjectsArgs - list object args.
+// ListObjectsArgs - list object args, objects are listed recursively
+// without folders if recursive is set.
 type ListObjectsArgs struct {
 	BucketName string `json:"bucketName"`
 	Prefix     string `json:"prefix"`
 	Marker     string `json:"marker"`
+	Recursive  bool   `json:"recursive"`
 }
 
+// Maximum number of objects listed by a single recursive ListObjects
+// call of the browser, collected from several pages of the object layer.
+const maxWebListObjects = 10 * maxObjectList
+
 // ListObjectsRep - list objects response.
 type ListObjectsRep struct {
 	Objects     []WebObjectInfo `json:"objects"`
@@ -226,6 +277,8 @@ type WebObjectInfo struct {
 	Size int64 `json:"size"`
 	// ContentType is mime type of the object.
 	ContentType string `json:"contentType"`
+	// ETag of the object.
+	ETag string `json:"etag,omitempty"`
 }
 
 // ListObjects - list objects api.
@@ -238,11 +291,15 @@ func (web *webAPIHandlers) ListObjects(r *http.Request, args *ListObjectsArgs, r
 	prefix := args.Prefix + "test" // To test if GetObject/PutObject with the specified prefix is allowed.
 	readable := isBucketActionAllowed("s3:GetObject", args.BucketName, prefix, objectAPI)
 	writable := isBucketActionAllowed("s3:PutObject", args.BucketName, prefix, objectAPI)
-	authErr := webRequestAuthenticate(r)
+	claims, authErr := webRequestAuthenticateUser(r)
+	if claims != nil {
+		readable = isWebActionAllowed(claims, objectAPI, "s3:ListBucket", args.BucketName, "")
+		writable = isWebActionAllowed(claims, objectAPI, "s3:PutObject", args.BucketName, prefix)
+	}
 	switch {
 	case authErr == errAuthentication:
 		return toJSONError(authErr)
-	case authErr == nil:
+	case authErr == nil && claims == nil:
 		break
 	case readable && writable:
 		reply.Writable = true
@@ -255,26 +312,114 @@ func (web *webAPIHandlers) ListObjects(r *http.Request, args *ListObjectsArgs, r
 	default:
 		return errAuthentication
 	}
-	lo, err := objectAPI.ListObjects(args.BucketName, args.Prefix, args.Marker, slashSeparator, 1000)
+	delimiter := slashSeparator
+	if args.Recursive {
+		delimiter = ""
+	}
+
+	// Recursive listings collect pages until enough objects are
+	// listed, the browser renders large prefixes with a few calls.
+	// Folders are listed a page at a time.
+	marker := args.Marker
+	for {
+		lo, err := objectAPI.ListObjects(args.BucketName, args.Prefix, marker, delimiter, maxObjectList)
+		if err != nil {
+			return &json2.Error{Message: err.Error()}
+		}
+		reply.NextMarker = lo.NextMarker
+		reply.IsTruncated = lo.IsTruncated
+		for _, obj := range lo.Objects {
+			reply.Objects = append(reply.Objects, WebObjectInfo{
+				Key:          obj.Name,
+				LastModified: obj.ModTime,
+				Size:         obj.Size,
+				ContentType:  obj.ContentType,
+				ETag:         obj.ETag,
+			})
+		}
+		for _, prefix := range lo.Prefixes {
+			reply.Objects = append(reply.Objects, WebObjectInfo{
+				Key: prefix,
+			})
+		}
+		if !args.Recursive || !lo.IsTruncated || lo.NextMarker == "" || len(reply.Objects) >= maxWebListObjects {
+			break
+		}
+		marker = lo.NextMarker
+	}
+
+	return nil
+}
+
+// SearchObjectsArgs - args to search the objects of a bucket by name,
+// content type and user metadata, e.g. {"color": "red"}. The content
+// type and metadata values are wildcard patterns.
+type SearchObjectsArgs struct {
+	BucketName  string            `json:"bucketName"`
+	Prefix      string            `json:"prefix"`
+	Suffix      string            `json:"suffix"`
+	ContentType string            `json:"contentType"`
+	Metadata    map[string]string `json:"metadata"`
+	Marker      string            `json:"marker"`
+}
+
+// SearchObjectsRep - objects found, sorted by name.
+type SearchObjectsRep struct {
+	Objects     []WebObjectInfo `json:"objects"`
+	NextMarker  string          `json:"nextmarker"`
+	IsTruncated bool            `json:"istruncated"`
+	UIVersion   string          `json:"uiVersion"`
+}
+
+// SearchObjects - searches the objects of a bucket in the object index,
+// users need to be allowed to list the bucket. Searches by content type
+// or metadata reveal more than names, their results are limited to the
+// objects users are allowed to get.
+func (web *webAPIHandlers) SearchObjects(r *http.Request, args *SearchObjectsArgs, reply *SearchObjectsRep) error {
+	reply.UIVersion = browser.UIVersion
+	objectAPI := web.ObjectAPI()
+	if objectAPI == nil {
+		return toJSONError(errServerNotInitialized)
+	}
+	claims, authErr := webRequestAuthenticateUser(r)
+	if authErr != nil {
+		return toJSONError(authErr)
+	}
+	if claims != nil && !isWebActionAllowed(claims, objectAPI, "s3:ListBucket", args.BucketName, "") {
+		return toJSONError(errAuthentication)
+	}
+	if globalObjectIndex == nil {
+		return toJSONError(errObjectIndexDisabled)
+	}
+	if _, err := objectAPI.GetBucketInfo(args.BucketName); err != nil {
+		return toJSONError(err, args.BucketName)
+	}
+
+	result, err := globalObjectIndex.Search(objectAPI, args.BucketName, objectSearchQuery{
+		Prefix:      args.Prefix,
+		Suffix:      args.Suffix,
+		ContentType: args.ContentType,
+		Metadata:    args.Metadata,
+		Marker:      args.Marker,
+	})
 	if err != nil {
-		return &json2.Error{Message: err.Error()}
+		return toJSONError(err, args.BucketName)
 	}
-	reply.NextMarker = lo.NextMarker
-	reply.IsTruncated = lo.IsTruncated
-	for _, obj := range lo.Objects {
+	reply.NextMarker = result.NextMarker
+	reply.IsTruncated = result.IsTruncated
+	filtered := claims != nil && (args.ContentType != "" || len(args.Metadata) > 0)
+	for _, obj := range result.Objects {
+		if filtered && !isWebActionAllowed(claims, objectAPI, "s3:GetObject", args.BucketName, obj.Name) {
+			continue
+		}
 		reply.Objects = append(reply.Objects, WebObjectInfo{
 			Key:          obj.Name,
 			LastModified: obj.ModTime,
 			Size:         obj.Size,
 			ContentType:  obj.ContentType,
+			ETag:         obj.ETag,
 		})
 	}
-	for _, prefix := range lo.Prefixes {
-		reply.Objects = append(reply.Objects, WebObjectInfo{
-			Key: prefix,
-		})
-	}
-
 	return nil
 }
 
@@ -299,20 +444,33 @@ func (web *webAPIHandlers) RemoveObject(r *http.Request, args *RemoveObjectArgs,
 	if objectAPI == nil {
 		return toJSONError(errServerNotInitialized)
 	}
-	if !isHTTPRequestValid(r) {
+	claims, authErr := webRequestAuthenticateUser(r)
+	if authErr != nil {
 		return toJSONError(errAuthentication)
 	}
+	if err := checkServerWritable(); err != nil {
+		return toJSONError(err)
+	}
 
 	if args.BucketName == "" || len(args.Objects) == 0 {
 		return toJSONError(errInvalidArgument)
 	}
 
+	// Users logged in with OpenID Connect remove objects their
+	// policies allow only, the first denied object stops removal.
+	removeObject := func(object string) error {
+		if claims != nil && !isWebActionAllowed(claims, objectAPI, "s3:DeleteObject", args.BucketName, object) {
+			return errAuthentication
+		}
+		return deleteObject(objectAPI, args.BucketName, object, r)
+	}
+
 	var err error
 next:
 	for _, objectName := range args.Objects {
 		// If not a directory, remove the object.
 		if !hasSuffix(objectName, slashSeparator) && objectName != "" {
-			if err = deleteObject(objectAPI, args.BucketName, objectName, r); err != nil {
+			if err = removeObject(objectName); err != nil {
 				break next
 			}
 			continue
@@ -328,7 +486,7 @@ next:
 			}
 			marker = lo.NextMarker
 			for _, obj := range lo.Objects {
-				err = deleteObject(objectAPI, args.BucketName, obj.Name, r)
+				err = removeObject(obj.Name)
 				if err != nil {
 					break next
 				}
@@ -354,23 +512,123 @@ type LoginArgs struct {
 	Password string `json:"password" form:"password"`
 }
 
-// LoginRep - login reply.
+// LoginRep - login reply, the refresh token is exchanged for new
+// tokens with RefreshToken.
 type LoginRep struct {
-	Token     string `json:"token"`
-	UIVersion string `json:"uiVersion"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	UIVersion    string `json:"uiVersion"`
 }
 
 // Login - user login handler.
 func (web *webAPIHandlers) Login(r *http.Request, args *LoginArgs, reply *LoginRep) error {
-	token, err := authenticateWeb(args.Username, args.Password)
+	if err := checkServerCredentials(args.Username, args.Password); err != nil {
+		// Make sure to log errors related to browser login,
+		// for security and auditing reasons.
+		errorIf(err, "Unable to login request from %s", r.RemoteAddr)
+		return toJSONError(err)
+	}
+	refreshToken, id, err := newBrowserRefreshToken(args.Username, nil, globalBrowserRefreshExpiry)
+	if err != nil {
+		return toJSONError(err)
+	}
+	token, err := newAuthToken(id, globalBrowserTokenExpiry)
+	if err != nil {
+		return toJSONError(err)
+	}
+
+	reply.Token = token
+	reply.RefreshToken = refreshToken
+	reply.UIVersion = browser.UIVersion
+	return nil
+}
+
+// RefreshTokenArgs - arguments of RefreshToken.
+type RefreshTokenArgs struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// RefreshToken - returns a new token of the user of a refresh token,
+// the request is anonymous as the token may have expired. The refresh
+// token is returned as is, it stays valid until it expires.
+func (web *webAPIHandlers) RefreshToken(r *http.Request, args *RefreshTokenArgs, reply *LoginRep) error {
+	claims, err := parseBrowserRefreshToken(web.ObjectAPI(), args.RefreshToken)
+	if err != nil {
+		errorIf(err, "Unable to refresh token of request from %s", r.RemoteAddr)
+		return toJSONError(err)
+	}
+
+	var token string
+	if len(claims.Grants) == 0 {
+		token, err = newAuthToken(claims.Id, globalBrowserTokenExpiry)
+	} else {
+		_, token, _, err = newChildSessionCredentials(claims.Subject, claims.Grants, claims.Id, globalBrowserTokenExpiry)
+	}
+	if err != nil {
+		return toJSONError(err)
+	}
+	reply.Token = token
+	reply.RefreshToken = args.RefreshToken
+	reply.UIVersion = browser.UIVersion
+	return nil
+}
+
+// LoginOpenIDArgs - login arguments of OpenID Connect users.
+type LoginOpenIDArgs struct {
+	IDToken string `json:"idToken"`
+}
+
+// LoginOpenID - logs in a user with an ID token of the OpenID provider,
+// the user is allowed the policies mapped from the groups of the user.
+func (web *webAPIHandlers) LoginOpenID(r *http.Request, args *LoginOpenIDArgs, reply *LoginRep) error {
+	if globalOpenIDProvider == nil {
+		return toJSONError(NotImplemented{})
+	}
+	identity, err := globalOpenIDProvider.Validate(args.IDToken)
 	if err != nil {
 		// Make sure to log errors related to browser login,
 		// for security and auditing reasons.
 		errorIf(err, "Unable to login request from %s", r.RemoteAddr)
+		if err != errInvalidIDToken {
+			err = errOpenIDProvider
+		}
 		return toJSONError(err)
 	}
+	grants := globalServerConfig.Identity.getGrants(identity.Groups)
+	if len(grants) == 0 {
+		return toJSONError(errNoGroupPolicy)
+	}
 
+	refreshToken, id, err := newBrowserRefreshToken(identity.User, grants, globalBrowserRefreshExpiry)
+	if err != nil {
+		return toJSONError(err)
+	}
+	_, token, _, err := newChildSessionCredentials(identity.User, grants, id, globalBrowserTokenExpiry)
+	if err != nil {
+		return toJSONError(err)
+	}
 	reply.Token = token
+	reply.RefreshToken = refreshToken
+	reply.UIVersion = browser.UIVersion
+	return nil
+}
+
+// OpenIDConfigRep - OpenID provider the browser gets ID tokens from.
+type OpenIDConfigRep struct {
+	Enabled   bool   `json:"enabled"`
+	IssuerURL string `json:"issuerURL"`
+	ClientID  string `json:"clientID"`
+	UIVersion string `json:"uiVersion"`
+}
+
+// GetOpenIDConfig - returns the OpenID provider, the request is
+// anonymous as the browser is not logged in yet.
+func (web *webAPIHandlers) GetOpenIDConfig(r *http.Request, args *WebGenericArgs, reply *OpenIDConfigRep) error {
+	if globalOpenIDProvider != nil {
+		reply.Enabled = true
+		reply.IssuerURL = globalOpenIDProvider.config.IssuerURL
+		reply.ClientID = globalOpenIDProvider.config.ClientID
+	}
 	reply.UIVersion = browser.UIVersion
 	return nil
 }
@@ -401,9 +659,10 @@ type SetAuthArgs struct {
 
 // SetAuthReply - reply for SetAuth
 type SetAuthReply struct {
-	Token       string            `json:"token"`
-	UIVersion   string            `json:"uiVersion"`
-	PeerErrMsgs map[string]string `json:"peerErrMsgs"`
+	Token        string            `json:"token"`
+	RefreshToken string            `json:"refreshToken,omitempty"`
+	UIVersion    string            `json:"uiVersion"`
+	PeerErrMsgs  map[string]string `json:"peerErrMsgs"`
 }
 
 // SetAuth - Set accessKey and secretKey credentials.
@@ -422,6 +681,9 @@ func (web *webAPIHandlers) SetAuth(r *http.Request, args *SetAuthArgs, reply *Se
 		return toJSONError(err)
 	}
 
+	// Browser requests are signed by the credentials being changed.
+	actor := getChangeActor(r)
+
 	// Acquire lock before updating global configuration.
 	globalServerConfigMu.Lock()
 	defer globalServerConfigMu.Unlock()
@@ -456,10 +718,12 @@ func (web *webAPIHandlers) SetAuth(r *http.Request, args *SetAuthArgs, reply *Se
 		// server logs.
 		return toJSONError(fmt.Errorf("unexpected error(s) occurred - please check minio server logs"))
 	}
+	if err := recordChange(web.ObjectAPI(), actor, changeTypeCredentials, "", prevCred, creds); err != nil {
+		return toJSONError(err)
+	}
 
 	// As we have updated access/secret key, generate new auth token.
-	token, err := authenticateWeb(creds.AccessKey, creds.SecretKey)
-	if err != nil {
+	if err = checkServerCredentials(creds.AccessKey, creds.SecretKey); err != nil {
 		// Did we have peer errors?
 		if len(errsMap) > 0 {
 			err = fmt.Errorf(
@@ -470,8 +734,18 @@ func (web *webAPIHandlers) SetAuth(r *http.Request, args *SetAuthArgs, reply *Se
 
 		return toJSONError(err)
 	}
+	// Refresh tokens of the previous credentials are invalid now.
+	refreshToken, id, err := newBrowserRefreshToken(creds.AccessKey, nil, globalBrowserRefreshExpiry)
+	if err != nil {
+		return toJSONError(err)
+	}
+	token, err := newAuthToken(id, globalBrowserTokenExpiry)
+	if err != nil {
+		return toJSONError(err)
+	}
 
 	reply.Token = token
+	reply.RefreshToken = refreshToken
 	reply.UIVersion = browser.UIVersion
 	return nil
 }
@@ -503,10 +777,23 @@ type URLTokenReply struct {
 
 // CreateURLToken creates a URL token (short-lived) for GET requests.
 func (web *webAPIHandlers) CreateURLToken(r *http.Request, args *WebGenericArgs, reply *URLTokenReply) error {
-	if !isHTTPRequestValid(r) {
+	claims, authErr := webRequestAuthenticateUser(r)
+	if authErr != nil {
 		return toJSONError(errAuthentication)
 	}
 
+	// Users logged in with OpenID Connect get a short-lived session
+	// token with their own policies.
+	if claims != nil {
+		_, token, _, err := newChildSessionCredentials(claims.User, claims.Grants, claims.Parent, defaultURLJWTExpiry)
+		if err != nil {
+			return toJSONError(err)
+		}
+		reply.Token = token
+		reply.UIVersion = browser.UIVersion
+		return nil
+	}
+
 	creds := globalServerConfig.GetCredential()
 
 	token, err := authenticateURL(creds.AccessKey, creds.SecretKey)
@@ -526,16 +813,24 @@ func (web *webAPIHandlers) Upload(w http.ResponseWriter, r *http.Request) {
 		writeWebErrorResponse(w, errServerNotInitialized)
 		return
 	}
+	if err := checkServerWritable(); err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
 
 	vars := mux.Vars(r)
 	bucket := vars["bucket"]
 	object := vars["object"]
 
-	authErr := webRequestAuthenticate(r)
+	claims, authErr := webRequestAuthenticateUser(r)
 	if authErr == errAuthentication {
 		writeWebErrorResponse(w, errAuthentication)
 		return
 	}
+	if claims != nil && !isWebActionAllowed(claims, objectAPI, "s3:PutObject", bucket, object) {
+		writeWebErrorResponse(w, errAuthentication)
+		return
+	}
 	if authErr != nil && !isBucketActionAllowed("s3:PutObject", bucket, object, objectAPI) {
 		writeWebErrorResponse(w, errAuthentication)
 		return
@@ -554,6 +849,17 @@ func (web *webAPIHandlers) Upload(w http.ResponseWriter, r *http.Request) {
 		writeErrorResponse(w, ErrInternalError, r.URL)
 		return
 	}
+	if s3Error := extractObjectLockFromHeader(r.Header, bucket, metadata); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+	applyMetadataDefaults(bucket, object, metadata)
+
+	// Locked objects cannot be overwritten.
+	if err = checkObjectLock(objectAPI, bucket, object, r); err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
 
 	hashReader, err := hash.NewReader(r.Body, size, "", "")
 	if err != nil {
@@ -566,6 +872,7 @@ func (web *webAPIHandlers) Upload(w http.ResponseWriter, r *http.Request) {
 		writeWebErrorResponse(w, err)
 		return
 	}
+	errorIf(attestObject(objectAPI, bucket, object, objInfo.ETag), "Unable to attest object %s/%s.", bucket, object)
 
 	// Notify object created event.
 	eventNotify(eventData{
@@ -589,20 +896,74 @@ func (web *webAPIHandlers) Download(w http.ResponseWriter, r *http.Request) {
 	object := vars["object"]
 	token := r.URL.Query().Get("token")
 
-	if !isAuthTokenValid(token) && !isBucketActionAllowed("s3:GetObject", bucket, object, objectAPI) {
+	if !isURLTokenActionAllowed(token, objectAPI, "s3:GetObject", bucket, object) && !isBucketActionAllowed("s3:GetObject", bucket, object, objectAPI) {
 		writeWebErrorResponse(w, errAuthentication)
 		return
 	}
 
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
+
 	// Add content disposition.
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", path.Base(object)))
 
-	if err := objectAPI.GetObject(bucket, object, 0, -1, w, ""); err != nil {
+	if err = getWebObject(objectAPI, bucket, objInfo, w); err != nil {
 		/// No need to print error, response writer already written to.
 		return
 	}
 }
 
+// isWebActionAllowed - returns whether the policies granted to a user
+// logged in with OpenID Connect allow the action on the object.
+func isWebActionAllowed(claims *sessionClaims, objectAPI ObjectLayer, action, bucket, object string) bool {
+	allowed, err := claims.isAllowed(objectAPI, action, bucket, path.Join(bucket, object), nil)
+	return err == nil && allowed
+}
+
+// isURLTokenActionAllowed - returns whether a URL token allows the
+// action on the object, tokens of the server credentials allow all
+// actions.
+func isURLTokenActionAllowed(token string, objectAPI ObjectLayer, action, bucket, object string) bool {
+	if claims, errCode := parseSessionToken(token); errCode == ErrNone {
+		return isWebActionAllowed(claims, objectAPI, action, bucket, object)
+	}
+	return isAuthTokenValid(token)
+}
+
+// getWebObject - writes the content of the object to the writer, objects
+// encrypted with SSE-S3 are decrypted. Objects encrypted with SSE-C are
+// written as they are stored, the browser has no customer key.
+func getWebObject(objectAPI ObjectLayer, bucket string, objInfo ObjectInfo, writer io.Writer) error {
+	if !objectAPI.IsEncryptionSupported() || !isSSES3Encrypted(objInfo.UserDefined) {
+		return objectAPI.GetObject(bucket, objInfo.Name, 0, objInfo.Size, writer, objInfo.ETag)
+	}
+	metadata := make(map[string]string, len(objInfo.UserDefined))
+	for k, v := range objInfo.UserDefined {
+		metadata[k] = v
+	}
+	decWriter, err := newKMSDecryptWriter(writer, globalKMS, 0, metadata)
+	if err != nil {
+		return err
+	}
+	if err = objectAPI.GetObject(bucket, objInfo.Name, 0, objInfo.Size, decWriter, objInfo.ETag); err != nil {
+		decWriter.Close()
+		return err
+	}
+	return decWriter.Close()
+}
+
+// getWebObjectSize - returns the size of the content written by
+// getWebObject.
+func getWebObjectSize(objectAPI ObjectLayer, objInfo ObjectInfo) (int64, error) {
+	if !objectAPI.IsEncryptionSupported() || !isSSES3Encrypted(objInfo.UserDefined) {
+		return objInfo.Size, nil
+	}
+	return objInfo.DecryptedSize()
+}
+
 // DownloadZipArgs - Argument for downloading a bunch of files as a zip file.
 // JSON will look like:
 // '{"bucketname":"testbucket","prefix":"john/pics/","objects":["hawaii/","maldives/","sanjose.jpg"]}'
@@ -631,12 +992,14 @@ func (web *webAPIHandlers) DownloadZip(w http.ResponseWriter, r *http.Request) {
 	}
 
 	token := r.URL.Query().Get("token")
-	if !isAuthTokenValid(token) {
-		for _, object := range args.Objects {
-			if !isBucketActionAllowed("s3:GetObject", args.BucketName, pathJoin(args.Prefix, object), objectAPI) {
-				writeWebErrorResponse(w, errAuthentication)
-				return
-			}
+	isAllowed := func(object string) bool {
+		return isURLTokenActionAllowed(token, objectAPI, "s3:GetObject", args.BucketName, object) ||
+			isBucketActionAllowed("s3:GetObject", args.BucketName, object, objectAPI)
+	}
+	for _, object := range args.Objects {
+		if !isAllowed(pathJoin(args.Prefix, object)) {
+			writeWebErrorResponse(w, errAuthentication)
+			return
 		}
 	}
 
@@ -644,54 +1007,128 @@ func (web *webAPIHandlers) DownloadZip(w http.ResponseWriter, r *http.Request) {
 	defer archive.Close()
 
 	for _, object := range args.Objects {
-		// Writes compressed object file to the response.
-		zipit := func(objectName string) error {
-			info, err := objectAPI.GetObjectInfo(args.BucketName, objectName)
-			if err != nil {
-				return err
-			}
-			header := &zip.FileHeader{
-				Name:               strings.TrimPrefix(objectName, args.Prefix),
-				Method:             zip.Deflate,
-				UncompressedSize64: uint64(info.Size),
-				UncompressedSize:   uint32(info.Size),
-			}
-			writer, err := archive.CreateHeader(header)
-			if err != nil {
-				writeWebErrorResponse(w, errUnexpected)
-				return err
-			}
-			return objectAPI.GetObject(args.BucketName, objectName, 0, info.Size, writer, "")
-		}
-
 		if !hasSuffix(object, slashSeparator) {
 			// If not a directory, compress the file and write it to response.
-			err := zipit(pathJoin(args.Prefix, object))
-			if err != nil {
+			if err := zipWebObject(archive, objectAPI, args.BucketName, pathJoin(args.Prefix, object), args.Prefix); err != nil {
 				return
 			}
 			continue
 		}
 
-		// For directories, list the contents recursively and write the objects as compressed
-		// date to the response writer.
-		marker := ""
-		for {
-			lo, err := objectAPI.ListObjects(args.BucketName, pathJoin(args.Prefix, object), marker, "", 1000)
-			if err != nil {
-				return
-			}
-			marker = lo.NextMarker
-			for _, obj := range lo.Objects {
-				err = zipit(obj.Name)
-				if err != nil {
-					return
-				}
+		// For directories, write all objects below them.
+		if err := zipWebPrefix(archive, objectAPI, args.BucketName, pathJoin(args.Prefix, object), args.Prefix, isAllowed); err != nil {
+			return
+		}
+	}
+}
+
+// DownloadPrefixZip - streams a zip archive of all objects below a
+// prefix, the archive is named after the last element of the prefix.
+// Objects are stored relative to the parent of the prefix, as when the
+// folder is selected in the browser.
+func (web *webAPIHandlers) DownloadPrefixZip(w http.ResponseWriter, r *http.Request) {
+	objectAPI := web.ObjectAPI()
+	if objectAPI == nil {
+		writeWebErrorResponse(w, errServerNotInitialized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	prefix := vars["prefix"]
+	if prefix != "" && !hasSuffix(prefix, slashSeparator) {
+		prefix += slashSeparator
+	}
+	token := r.URL.Query().Get("token")
+	isAllowed := func(object string) bool {
+		return isURLTokenActionAllowed(token, objectAPI, "s3:GetObject", bucket, object) ||
+			isBucketActionAllowed("s3:GetObject", bucket, object, objectAPI)
+	}
+
+	if !isAllowed(prefix) {
+		writeWebErrorResponse(w, errAuthentication)
+		return
+	}
+
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
+
+	name := bucket
+	parent := ""
+	if prefix != "" {
+		name = path.Base(prefix)
+		if parent = path.Dir(strings.TrimSuffix(prefix, slashSeparator)); parent == "." {
+			parent = ""
+		} else {
+			parent += slashSeparator
+		}
+	}
+
+	// Add content disposition.
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.zip\"", name))
+	w.Header().Set("Content-Type", "application/zip")
+
+	archive := zip.NewWriter(w)
+	defer archive.Close()
+
+	// No need to print error, response writer already written to.
+	zipWebPrefix(archive, objectAPI, bucket, prefix, parent, isAllowed)
+}
+
+// zipWebObject - compresses the object into the archive, stored under
+// its name without trimPrefix. Objects pending validation are left out
+// before their entry is written, they cannot be read.
+func zipWebObject(archive *zip.Writer, objectAPI ObjectLayer, bucket, object, trimPrefix string) error {
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return err
+	}
+	if isObjectQuarantined(objInfo.UserDefined) {
+		return nil
+	}
+	size, err := getWebObjectSize(objectAPI, objInfo)
+	if err != nil {
+		return err
+	}
+	header := &zip.FileHeader{
+		Name:               strings.TrimPrefix(object, trimPrefix),
+		Method:             zip.Deflate,
+		UncompressedSize64: uint64(size),
+		UncompressedSize:   uint32(size),
+	}
+	header.SetModTime(objInfo.ModTime)
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	return getWebObject(objectAPI, bucket, objInfo, writer)
+}
+
+// zipWebPrefix - lists the objects below the prefix recursively and
+// compresses them into the archive. Objects isAllowed denies reading,
+// e.g. by a policy denying a part of the prefix, and objects pending
+// validation are left out.
+func zipWebPrefix(archive *zip.Writer, objectAPI ObjectLayer, bucket, prefix, trimPrefix string, isAllowed func(object string) bool) error {
+	marker := ""
+	for {
+		lo, err := objectAPI.ListObjects(bucket, prefix, marker, "", maxObjectList)
+		if err != nil {
+			return err
+		}
+		marker = lo.NextMarker
+		for _, obj := range lo.Objects {
+			if !isAllowed(obj.Name) {
+				continue
 			}
-			if !lo.IsTruncated {
-				break
+			if err = zipWebObject(archive, objectAPI, bucket, obj.Name, trimPrefix); err != nil {
+				return err
 			}
 		}
+		if !lo.IsTruncated {
+			return nil
+		}
 	}
 }
 
@@ -795,6 +1232,9 @@ func (web *webAPIHandlers) SetBucketPolicy(r *http.Request, args *SetBucketPolic
 	if !isHTTPRequestValid(r) {
 		return toJSONError(errAuthentication)
 	}
+	if err := checkServerWritable(); err != nil {
+		return toJSONError(err)
+	}
 
 	bucketP := policy.BucketPolicy(args.Policy)
 	if !bucketP.IsValidBucketPolicy() {
@@ -803,7 +1243,16 @@ func (web *webAPIHandlers) SetBucketPolicy(r *http.Request, args *SetBucketPolic
 		}
 	}
 
-	var policyInfo, err = objectAPI.GetBucketPolicy(args.BucketName)
+	if err := checkBucketPolicyNotManaged(objectAPI, args.BucketName); err != nil {
+		return toJSONError(err, args.BucketName)
+	}
+
+	oldPolicy, err := getChangeLogBucketPolicy(objectAPI, args.BucketName)
+	if err != nil {
+		return toJSONError(err, args.BucketName)
+	}
+
+	policyInfo, err := objectAPI.GetBucketPolicy(args.BucketName)
 	if err != nil {
 		if _, ok := errors.Cause(err).(PolicyNotFound); !ok {
 			return toJSONError(err, args.BucketName)
@@ -817,6 +1266,9 @@ func (web *webAPIHandlers) SetBucketPolicy(r *http.Request, args *SetBucketPolic
 		if err = objectAPI.DeleteBucketPolicy(args.BucketName); err != nil {
 			return toJSONError(err, args.BucketName)
 		}
+		if err := recordChange(objectAPI, getChangeActor(r), changeTypeBucketPolicy, args.BucketName, oldPolicy, nil); err != nil {
+			return toJSONError(err, args.BucketName)
+		}
 		return nil
 	}
 
@@ -824,6 +1276,129 @@ func (web *webAPIHandlers) SetBucketPolicy(r *http.Request, args *SetBucketPolic
 	if err := objectAPI.SetBucketPolicy(args.BucketName, policyInfo); err != nil {
 		return toJSONError(err, args.BucketName)
 	}
+	if err := recordChange(objectAPI, getChangeActor(r), changeTypeBucketPolicy, args.BucketName, oldPolicy, policyInfo); err != nil {
+		return toJSONError(err, args.BucketName)
+	}
+
+	return nil
+}
+
+// GetBucketPolicyDocumentArgs - get bucket policy document args.
+type GetBucketPolicyDocumentArgs struct {
+	BucketName string `json:"bucketName"`
+}
+
+// GetBucketPolicyDocumentRep - get bucket policy document reply.
+type GetBucketPolicyDocumentRep struct {
+	UIVersion string `json:"uiVersion"`
+	// Bucket policy JSON, empty if the bucket has no policy.
+	Policy string `json:"policy"`
+}
+
+// GetBucketPolicyDocument - get the full bucket policy JSON, including
+// statements which do not map to canned policies.
+func (web *webAPIHandlers) GetBucketPolicyDocument(r *http.Request, args *GetBucketPolicyDocumentArgs, reply *GetBucketPolicyDocumentRep) error {
+	objectAPI := web.ObjectAPI()
+	if objectAPI == nil {
+		return toJSONError(errServerNotInitialized)
+	}
+
+	if !isHTTPRequestValid(r) {
+		return toJSONError(errAuthentication)
+	}
+
+	if _, err := objectAPI.GetBucketInfo(args.BucketName); err != nil {
+		return toJSONError(err, args.BucketName)
+	}
+
+	reply.UIVersion = browser.UIVersion
+	policyInfo, err := objectAPI.GetBucketPolicy(args.BucketName)
+	if err != nil {
+		if _, ok := errors.Cause(err).(PolicyNotFound); ok {
+			return nil
+		}
+		return toJSONError(err, args.BucketName)
+	}
+	data, err := json.MarshalIndent(policyInfo, "", "  ")
+	if err != nil {
+		return toJSONError(err)
+	}
+	reply.Policy = string(data)
+	return nil
+}
+
+// SetBucketPolicyDocumentArgs - set bucket policy document args.
+type SetBucketPolicyDocumentArgs struct {
+	BucketName string `json:"bucketName"`
+	// Bucket policy JSON, empty to remove the bucket policy.
+	Policy string `json:"policy"`
+}
+
+// parseWebBucketPolicy - parses and validates a bucket policy JSON
+// edited in the browser, errors describe what is wrong with it.
+func parseWebBucketPolicy(bucket, policyJSON string) (policy.BucketAccessPolicy, error) {
+	var policyInfo policy.BucketAccessPolicy
+	if len(policyJSON) > maxAccessPolicySize {
+		return policyInfo, fmt.Errorf("Bucket policy cannot be larger than %s", humanize.IBytes(maxAccessPolicySize))
+	}
+	if err := parseBucketPolicy(strings.NewReader(policyJSON), &policyInfo); err != nil {
+		return policyInfo, fmt.Errorf("Invalid bucket policy: %v", err)
+	}
+	if s3Error := checkBucketPolicyResources(bucket, policyInfo); s3Error != ErrNone {
+		return policyInfo, fmt.Errorf("Invalid bucket policy: %s", getAPIError(s3Error).Description)
+	}
+	return policyInfo, nil
+}
+
+// SetBucketPolicyDocument - set the full bucket policy JSON, e.g. with
+// statements for many prefixes or with conditions.
+func (web *webAPIHandlers) SetBucketPolicyDocument(r *http.Request, args *SetBucketPolicyDocumentArgs, reply *WebGenericRep) error {
+	objectAPI := web.ObjectAPI()
+	reply.UIVersion = browser.UIVersion
+
+	if objectAPI == nil {
+		return toJSONError(errServerNotInitialized)
+	}
+
+	if !isHTTPRequestValid(r) {
+		return toJSONError(errAuthentication)
+	}
+	if err := checkServerWritable(); err != nil {
+		return toJSONError(err)
+	}
+
+	if _, err := objectAPI.GetBucketInfo(args.BucketName); err != nil {
+		return toJSONError(err, args.BucketName)
+	}
+	if err := checkBucketPolicyNotManaged(objectAPI, args.BucketName); err != nil {
+		return toJSONError(err, args.BucketName)
+	}
+
+	oldPolicy, err := getChangeLogBucketPolicy(objectAPI, args.BucketName)
+	if err != nil {
+		return toJSONError(err, args.BucketName)
+	}
+
+	if strings.TrimSpace(args.Policy) == "" {
+		if err = objectAPI.DeleteBucketPolicy(args.BucketName); err != nil {
+			return toJSONError(err, args.BucketName)
+		}
+		if err := recordChange(objectAPI, getChangeActor(r), changeTypeBucketPolicy, args.BucketName, oldPolicy, nil); err != nil {
+			return toJSONError(err, args.BucketName)
+		}
+		return nil
+	}
+
+	policyInfo, err := parseWebBucketPolicy(args.BucketName, args.Policy)
+	if err != nil {
+		return &json2.Error{Message: err.Error()}
+	}
+	if err = objectAPI.SetBucketPolicy(args.BucketName, policyInfo); err != nil {
+		return toJSONError(err, args.BucketName)
+	}
+	if err := recordChange(objectAPI, getChangeActor(r), changeTypeBucketPolicy, args.BucketName, oldPolicy, policyInfo); err != nil {
+		return toJSONError(err, args.BucketName)
+	}
 
 	return nil
 }
@@ -866,8 +1441,114 @@ func (web *webAPIHandlers) PresignedGet(r *http.Request, args *PresignedGetArgs,
 	return nil
 }
 
+// Maximum number of objects shared by a single PresignedGetObjects call.
+const maxWebSharedObjects = maxObjectList
+
+// PresignedGetObjectsArgs - presigned-get API args for sharing many
+// objects at once.
+// JSON will look like:
+// '{"host":"localhost:9000","bucket":"testbucket","prefix":"john/pics/","objects":["hawaii/","sanjose.jpg"],"expiry":3600}'
+type PresignedGetObjectsArgs struct {
+	// Host header required for signed headers.
+	HostName string `json:"host"`
+
+	// Bucket name of the objects to be presigned.
+	BucketName string `json:"bucket"`
+
+	// Current directory in the browser-ui.
+	Prefix string `json:"prefix"`
+
+	// Objects or sub-directories below the prefix, sub-directories
+	// share all objects below them.
+	Objects []string `json:"objects"`
+
+	// Expiry in seconds.
+	Expiry int64 `json:"expiry"`
+}
+
+// PresignedObjectURL - presigned URL of a shared object.
+type PresignedObjectURL struct {
+	Object string `json:"object"`
+	URL    string `json:"url"`
+}
+
+// PresignedGetObjectsRep - presigned-get URLs reply.
+type PresignedGetObjectsRep struct {
+	UIVersion string `json:"uiVersion"`
+	// Presigned URLs of the objects, in the order they are listed.
+	URLs []PresignedObjectURL `json:"urls"`
+}
+
+// PresignedGetObjects - returns presigned-Get urls of all selected objects.
+func (web *webAPIHandlers) PresignedGetObjects(r *http.Request, args *PresignedGetObjectsArgs, reply *PresignedGetObjectsRep) error {
+	objectAPI := web.ObjectAPI()
+	if objectAPI == nil {
+		return toJSONError(errServerNotInitialized)
+	}
+	if !isHTTPRequestValid(r) {
+		return toJSONError(errAuthentication)
+	}
+
+	if args.BucketName == "" || len(args.Objects) == 0 {
+		return &json2.Error{
+			Message: "Bucket and Objects are mandatory arguments.",
+		}
+	}
+
+	tooMany := &json2.Error{
+		Message: fmt.Sprintf("Cannot share more than %d objects at once.", maxWebSharedObjects),
+	}
+	share := func(object string) error {
+		if len(reply.URLs) == maxWebSharedObjects {
+			return tooMany
+		}
+		reply.URLs = append(reply.URLs, PresignedObjectURL{
+			Object: object,
+			URL:    presignedGet(args.HostName, args.BucketName, object, args.Expiry),
+		})
+		return nil
+	}
+	for _, object := range args.Objects {
+		if !hasSuffix(object, slashSeparator) {
+			if err := share(pathJoin(args.Prefix, object)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// For directories, share all objects below them.
+		marker := ""
+		for {
+			lo, err := objectAPI.ListObjects(args.BucketName, pathJoin(args.Prefix, object), marker, "", maxObjectList)
+			if err != nil {
+				return toJSONError(err, args.BucketName)
+			}
+			marker = lo.NextMarker
+			for _, obj := range lo.Objects {
+				if hasSuffix(obj.Name, slashSeparator) {
+					continue
+				}
+				if err = share(obj.Name); err != nil {
+					return err
+				}
+			}
+			if !lo.IsTruncated {
+				break
+			}
+		}
+	}
+	reply.UIVersion = browser.UIVersion
+	return nil
+}
+
 // Returns presigned url for GET method.
 func presignedGet(host, bucket, object string, expiry int64) string {
+	return presignedURL(host, bucket, object, url.Values{}, expiry)
+}
+
+// Returns presigned url for GET method carrying additional query
+// parameters, which are covered by the signature.
+func presignedURL(host, bucket, object string, queryValues url.Values, expiry int64) string {
 	cred := globalServerConfig.GetCredential()
 	region := globalServerConfig.GetRegion()
 
@@ -882,26 +1563,29 @@ func presignedGet(host, bucket, object string, expiry int64) string {
 	if expiry < 604800 && expiry > 0 {
 		expiryStr = strconv.FormatInt(expiry, 10)
 	}
-	query := strings.Join([]string{
-		"X-Amz-Algorithm=" + signV4Algorithm,
-		"X-Amz-Credential=" + strings.Replace(credential, "/", "%2F", -1),
-		"X-Amz-Date=" + dateStr,
-		"X-Amz-Expires=" + expiryStr,
-		"X-Amz-SignedHeaders=host",
-	}, "&")
+	query := make(url.Values)
+	for k, v := range queryValues {
+		query[k] = v
+	}
+	query.Set("X-Amz-Algorithm", signV4Algorithm)
+	query.Set("X-Amz-Credential", credential)
+	query.Set("X-Amz-Date", dateStr)
+	query.Set("X-Amz-Expires", expiryStr)
+	query.Set("X-Amz-SignedHeaders", "host")
+	encodedQuery := query.Encode()
 
-	path := "/" + path.Join(bucket, object)
+	path := globalURLPrefix + "/" + path.Join(bucket, object)
 
 	// "host" is the only header required to be signed for Presigned URLs.
 	extractedSignedHeaders := make(http.Header)
 	extractedSignedHeaders.Set("host", host)
-	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, unsignedPayload, query, path, "GET")
+	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, unsignedPayload, encodedQuery, path, "GET")
 	stringToSign := getStringToSign(canonicalRequest, date, getScope(date, region))
 	signingKey := getSigningKey(secretKey, date, region)
 	signature := getSignature(signingKey, stringToSign)
 
 	// Construct the final presigned URL.
-	return host + getURLEncodedName(path) + "?" + query + "&" + "X-Amz-Signature=" + signature
+	return host + getURLEncodedName(path) + "?" + encodedQuery + "&" + "X-Amz-Signature=" + signature
 }
 
 // toJSONError converts regular errors into more user friendly
@@ -960,6 +1644,10 @@ func toWebAPIError(err error) APIError {
 			HTTPStatusCode: http.StatusServiceUnavailable,
 			Description:    err.Error(),
 		}
+	} else if err == errServerReadOnly {
+		return getAPIError(ErrServerReadOnly)
+	} else if err == errServerMaintenance {
+		return getAPIError(ErrSlowDown)
 	} else if err == auth.ErrInvalidAccessKeyLength {
 		return APIError{
 			Code:           "AccessDenied",
@@ -972,6 +1660,18 @@ func toWebAPIError(err error) APIError {
 			HTTPStatusCode: http.StatusForbidden,
 			Description:    err.Error(),
 		}
+	} else if err == errInvalidIDToken || err == errNoGroupPolicy || err == errInvalidRefreshToken {
+		return APIError{
+			Code:           "AccessDenied",
+			HTTPStatusCode: http.StatusForbidden,
+			Description:    err.Error(),
+		}
+	} else if err == errOpenIDProvider {
+		return APIError{
+			Code:           "IDPCommunicationError",
+			HTTPStatusCode: http.StatusServiceUnavailable,
+			Description:    err.Error(),
+		}
 	} else if err == errInvalidAccessKeyID {
 		return APIError{
 			Code:           "AccessDenied",
@@ -1002,6 +1702,14 @@ func toWebAPIError(err error) APIError {
 			HTTPStatusCode: http.StatusBadRequest,
 			Description:    err.Error(),
 		}
+	} else if err == errBucketPolicyManaged {
+		return getAPIError(ErrBucketPolicyManaged)
+	} else if err == errObjectIndexDisabled {
+		return APIError{
+			Code:           "NotImplemented",
+			HTTPStatusCode: http.StatusNotImplemented,
+			Description:    err.Error(),
+		}
 	}
 	// Convert error type to api error code.
 	switch err.(type) {
@@ -1019,6 +1727,8 @@ func toWebAPIError(err error) APIError {
 		return getAPIError(ErrIncompleteBody)
 	case ObjectExistsAsDirectory:
 		return getAPIError(ErrObjectExistsAsDirectory)
+	case ObjectLocked:
+		return getAPIError(ErrObjectLocked)
 	case ObjectNotFound:
 		return getAPIError(ErrNoSuchKey)
 	case ObjectNameInvalid:
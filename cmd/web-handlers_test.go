@@ -33,6 +33,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	jwtgo "github.com/dgrijalva/jwt-go"
 	humanize "github.com/dustin/go-humanize"
@@ -173,6 +174,195 @@ func testLoginWebHandler(obj ObjectLayer, instanceType string, t TestErrHandler)
 	}
 }
 
+// Wrapper for calling RefreshToken Web Handler
+func TestWebHandlerRefreshToken(t *testing.T) {
+	ExecObjectLayerTest(t, testRefreshTokenWebHandler)
+}
+
+// testRefreshTokenWebHandler - Test refresh tokens of a login are
+// exchanged for new tokens until the user is revoked.
+func testRefreshTokenWebHandler(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	apiRouter := initTestWebRPCEndPoint(obj)
+	globalBrowserTokenRevocations = newBrowserTokenRevocationCache()
+	defer func() { globalBrowserTokenRevocations = newBrowserTokenRevocationCache() }()
+	credentials := globalServerConfig.GetCredential()
+
+	call := func(method, authorization string, args, reply interface{}) error {
+		rec := httptest.NewRecorder()
+		req, err := newTestWebRPCRequest("Web."+method, authorization, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return getTestWebRPCResponse(rec, reply)
+	}
+
+	loginReply := &LoginRep{}
+	if err := call("Login", "", LoginArgs{Username: credentials.AccessKey, Password: credentials.SecretKey}, loginReply); err != nil {
+		t.Fatal(err)
+	}
+	if loginReply.RefreshToken == "" {
+		t.Fatal("Expected a refresh token")
+	}
+	if err := call("RefreshToken", "", RefreshTokenArgs{RefreshToken: loginReply.Token}, &LoginRep{}); err == nil {
+		t.Fatal("Expected refreshing with a token to fail")
+	}
+	refreshReply := &LoginRep{}
+	if err := call("RefreshToken", "", RefreshTokenArgs{RefreshToken: loginReply.RefreshToken}, refreshReply); err != nil {
+		t.Fatal(err)
+	}
+	if refreshReply.Token == "" || refreshReply.RefreshToken != loginReply.RefreshToken {
+		t.Fatalf("Unexpected refresh reply %+v", refreshReply)
+	}
+	if err := call("ListBuckets", refreshReply.Token, WebGenericArgs{}, &ListBucketsRep{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Revoked tokens are rejected, new logins are not. Tokens issued
+	// within the second of the revocation are revoked as well.
+	if err := revokeBrowserTokens(obj, credentials.AccessKey, ""); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	if err := call("ListBuckets", refreshReply.Token, WebGenericArgs{}, &ListBucketsRep{}); err == nil {
+		t.Error("Expected revoked token to be rejected")
+	}
+	if err := call("RefreshToken", "", RefreshTokenArgs{RefreshToken: loginReply.RefreshToken}, &LoginRep{}); err == nil {
+		t.Error("Expected revoked refresh token to be rejected")
+	}
+	token, err := getWebRPCToken(apiRouter, credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := call("ListBuckets", token, WebGenericArgs{}, &ListBucketsRep{}); err != nil {
+		t.Error(err)
+	}
+}
+
+// Wrapper for calling LoginOpenID Web Handler
+func TestWebHandlerLoginOpenID(t *testing.T) {
+	ExecObjectLayerTest(t, testLoginOpenIDWebHandler)
+}
+
+// testLoginOpenIDWebHandler - Test users logged in with OpenID Connect
+// are allowed the policies mapped from their groups only.
+func testLoginOpenIDWebHandler(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	apiRouter := initTestWebRPCEndPoint(obj)
+	issuer := newTestOpenIDIssuer(t)
+	defer issuer.server.Close()
+	defer func() { globalOpenIDProvider = nil }()
+	globalServerConfig.Identity.GroupPolicies = []groupPolicyConfig{{Group: "dev", Policy: "readwrite", Bucket: "dev-*"}}
+
+	call := func(method, authorization string, args, reply interface{}) error {
+		rec := httptest.NewRecorder()
+		req, err := newTestWebRPCRequest("Web."+method, authorization, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return getTestWebRPCResponse(rec, reply)
+	}
+	idToken := issuer.sign(t, "rsa1", jwtgo.MapClaims{"groups": []string{"dev"}})
+
+	configReply := &OpenIDConfigRep{}
+	if err := call("GetOpenIDConfig", "", WebGenericArgs{}, configReply); err != nil || configReply.Enabled {
+		t.Fatalf("Expected OpenID Connect to be disabled, got %+v, %v", configReply, err)
+	}
+	if err := call("LoginOpenID", "", LoginOpenIDArgs{IDToken: idToken}, &LoginRep{}); err == nil {
+		t.Fatal("Expected login to fail without OpenID provider")
+	}
+
+	globalOpenIDProvider = issuer.newProvider(t)
+	if err := call("GetOpenIDConfig", "", WebGenericArgs{}, configReply); err != nil || configReply.IssuerURL != issuer.server.URL || configReply.ClientID != "minio" {
+		t.Fatalf("Unexpected OpenID configuration %+v, %v", configReply, err)
+	}
+	for _, token := range []string{issuer.sign(t, "rsa1", jwtgo.MapClaims{"aud": "other", "groups": "dev"}), issuer.sign(t, "rsa1", nil)} {
+		if err := call("LoginOpenID", "", LoginOpenIDArgs{IDToken: token}, &LoginRep{}); err == nil {
+			t.Fatal("Expected login to fail")
+		}
+	}
+	loginReply := &LoginRep{}
+	if err := call("LoginOpenID", "", LoginOpenIDArgs{IDToken: idToken}, loginReply); err != nil {
+		t.Fatal(err)
+	}
+	authorization := loginReply.Token
+
+	for _, bucket := range []string{"dev-builds", "prod"} {
+		if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+			t.Fatal(err)
+		}
+		content := []byte("hello")
+		if _, err := obj.PutObject(bucket, "object", mustGetHashReader(t, bytes.NewReader(content), int64(len(content)), "", ""), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bucketsReply := &ListBucketsRep{}
+	if err := call("ListBuckets", authorization, WebGenericArgs{}, bucketsReply); err != nil {
+		t.Fatal(err)
+	}
+	if len(bucketsReply.Buckets) != 1 || bucketsReply.Buckets[0].Name != "dev-builds" {
+		t.Errorf("Expected dev-builds only, got %+v", bucketsReply.Buckets)
+	}
+	objectsReply := &ListObjectsRep{}
+	if err := call("ListObjects", authorization, ListObjectsArgs{BucketName: "dev-builds"}, objectsReply); err != nil || len(objectsReply.Objects) != 1 || !objectsReply.Writable {
+		t.Errorf("Unexpected objects %+v, %v", objectsReply, err)
+	}
+	if err := call("ListObjects", authorization, ListObjectsArgs{BucketName: "prod"}, &ListObjectsRep{}); err == nil {
+		t.Error("Expected listing prod to fail")
+	}
+	if err := call("RemoveObject", authorization, RemoveObjectArgs{BucketName: "prod", Objects: []string{"object"}}, &WebGenericRep{}); err == nil {
+		t.Error("Expected removing from prod to fail")
+	}
+	if err := call("MakeBucket", authorization, MakeBucketArgs{BucketName: "dev-new"}, &WebGenericRep{}); err == nil {
+		t.Error("Expected making buckets to fail")
+	}
+	if err := call("GetAuth", authorization, WebGenericArgs{}, &GetAuthReply{}); err == nil {
+		t.Error("Expected getting the server credentials to fail")
+	}
+
+	upload := func(bucket string) int {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("PUT", "/minio/upload/"+bucket+"/uploaded", bytes.NewReader([]byte("data")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+authorization)
+		apiRouter.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := upload("dev-builds"); code != http.StatusOK {
+		t.Errorf("Expected upload to dev-builds to succeed, got %d", code)
+	}
+	if code := upload("prod"); code != http.StatusForbidden {
+		t.Errorf("Expected upload to prod to be denied, got %d", code)
+	}
+
+	urlTokenReply := &URLTokenReply{}
+	if err := call("CreateURLToken", authorization, WebGenericArgs{}, urlTokenReply); err != nil {
+		t.Fatal(err)
+	}
+	download := func(bucket string) int {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/minio/download/"+bucket+"/object?token="+urlTokenReply.Token, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := download("dev-builds"); code != http.StatusOK {
+		t.Errorf("Expected download from dev-builds to succeed, got %d", code)
+	}
+	if code := download("prod"); code != http.StatusForbidden {
+		t.Errorf("Expected download from prod to be denied, got %d", code)
+	}
+
+	if err := call("RemoveObject", authorization, RemoveObjectArgs{BucketName: "dev-builds", Objects: []string{"object", "uploaded"}}, &WebGenericRep{}); err != nil {
+		t.Error(err)
+	}
+}
+
 // Wrapper for calling StorageInfo Web Handler
 func TestWebHandlerStorageInfo(t *testing.T) {
 	ExecObjectLayerTest(t, testStorageInfoWebHandler)
@@ -213,6 +403,47 @@ func testStorageInfoWebHandler(obj ObjectLayer, instanceType string, t TestErrHa
 	}
 }
 
+// Wrapper for calling GetBucketAccessStats Web Handler
+func TestWebHandlerGetBucketAccessStats(t *testing.T) {
+	ExecObjectLayerTest(t, testGetBucketAccessStatsWebHandler)
+}
+
+// testGetBucketAccessStatsWebHandler - Test GetBucketAccessStats web handler
+func testGetBucketAccessStatsWebHandler(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	apiRouter := initTestWebRPCEndPoint(obj)
+	credentials := globalServerConfig.GetCredential()
+
+	authorization, err := getWebRPCToken(apiRouter, credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatal("Cannot authenticate")
+	}
+
+	globalMinioAddr = "127.0.0.1:9000"
+	initGlobalAdminPeers(mustGetNewEndpointList("http://127.0.0.1:9000/d1"))
+	defer func(a *accessStats) { globalAccessStats = a }(globalAccessStats)
+	globalAccessStats = newAccessStats()
+	globalAccessStats.Record("bucket1", http.MethodGet, http.StatusOK, 0, 10)
+	globalAccessStats.Record("bucket2", http.MethodGet, http.StatusOK, 0, 20)
+
+	rec := httptest.NewRecorder()
+	args := &GetBucketAccessStatsArgs{BucketName: "bucket2"}
+	reply := &GetBucketAccessStatsRep{}
+	req, err := newTestWebRPCRequest("Web.GetBucketAccessStats", authorization, args)
+	if err != nil {
+		t.Fatalf("Failed to create HTTP request: <ERROR> %v", err)
+	}
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the response status to be 200, but instead found `%d`", rec.Code)
+	}
+	if err = getTestWebRPCResponse(rec, &reply); err != nil {
+		t.Fatalf("Failed %v", err)
+	}
+	if len(reply.Buckets) != 1 || reply.Buckets[0].Bucket != "bucket2" || reply.Buckets[0].BytesSent != 20 {
+		t.Fatalf("Unexpected access statistics %+v", reply.Buckets)
+	}
+}
+
 // Wrapper for calling ServerInfo Web Handler
 func TestWebHandlerServerInfo(t *testing.T) {
 	ExecObjectLayerTest(t, testServerInfoWebHandler)
@@ -531,6 +762,39 @@ func testListObjectsWebHandler(obj ObjectLayer, instanceType string, t TestErrHa
 		t.Fatal(err)
 	}
 	verifyReply(reply)
+	if reply.Objects[0].ETag != metadata["etag"] {
+		t.Fatalf("Expected etag %s, got %s", metadata["etag"], reply.Objects[0].ETag)
+	}
+
+	// Recursive ListObjects should list nested objects without folders.
+	_, err = obj.PutObject(bucketName, "dir/nested", mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil)
+	if err != nil {
+		t.Fatalf("Was not able to upload an object, %v", err)
+	}
+	for _, recursive := range []bool{false, true} {
+		req, rerr := newTestWebRPCRequest("Web.ListObjects", authorization, ListObjectsArgs{BucketName: bucketName, Recursive: recursive})
+		if rerr != nil {
+			t.Fatalf("Failed to create HTTP request: <ERROR> %v", rerr)
+		}
+		rec = httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		listReply := &ListObjectsRep{}
+		if err = getTestWebRPCResponse(rec, &listReply); err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for _, object := range listReply.Objects {
+			keys = append(keys, object.Key)
+		}
+		expected := "object,dir/"
+		if recursive {
+			expected = "dir/nested,object"
+		}
+		if strings.Join(keys, ",") != expected {
+			t.Fatalf("Expected %s listing recursive=%v, got %v", expected, recursive, keys)
+		}
+	}
+	rec = httptest.NewRecorder()
 
 	// Unauthenticated ListObjects should fail.
 	err, _ = test("")
@@ -553,6 +817,93 @@ func testListObjectsWebHandler(obj ObjectLayer, instanceType string, t TestErrHa
 	verifyReply(reply)
 }
 
+// Wrapper for calling SearchObjects Web Handler
+func TestWebHandlerSearchObjects(t *testing.T) {
+	ExecObjectLayerTest(t, testSearchObjectsWebHandler)
+}
+
+// testSearchObjectsWebHandler - Test SearchObjects web handler
+func testSearchObjectsWebHandler(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	apiRouter := initTestWebRPCEndPoint(obj)
+	globalObjectIndex = newObjectIndex()
+	defer func() { globalObjectIndex = nil }()
+
+	bucketName := getRandomBucketName()
+	if err := obj.MakeBucketWithLocation(bucketName, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	for _, objectName := range []string{"public/red.txt", "private/red.txt"} {
+		data := []byte("hello")
+		metadata := map[string]string{"X-Amz-Meta-Color": "red", "content-type": "text/plain"}
+		if _, err := obj.PutObject(bucketName, objectName, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), metadata); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+
+	// Users allowed to list the bucket and to get public objects only.
+	statements := []policy.Statement{
+		{
+			Actions:   set.CreateStringSet("s3:ListBucket"),
+			Effect:    "Allow",
+			Principal: policy.User{AWS: set.CreateStringSet("*")},
+			Resources: set.CreateStringSet(bucketARNPrefix + managedPolicyBucketVar),
+		},
+		{
+			Actions:   set.CreateStringSet("s3:GetObject"),
+			Effect:    "Allow",
+			Principal: policy.User{AWS: set.CreateStringSet("*")},
+			Resources: set.CreateStringSet(bucketARNPrefix + managedPolicyBucketVar + "/public/*"),
+		},
+	}
+	if _, err := putManagedPolicy(obj, auditCaller{AccessKey: "minio"}, "list-public", statements); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	_, userToken, _, err := newSessionCredentials("searcher", []sessionGrant{{Policy: "list-public", Bucket: bucketName}}, time.Hour)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	rootToken, err := authenticateWeb(globalServerConfig.GetCredential().AccessKey, globalServerConfig.GetCredential().SecretKey)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	search := func(authorization string, args SearchObjectsArgs) []string {
+		rec := httptest.NewRecorder()
+		req, err := newTestWebRPCRequest("Web.SearchObjects", authorization, args)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		reply := &SearchObjectsRep{}
+		if err = getTestWebRPCResponse(rec, reply); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		var names []string
+		for _, object := range reply.Objects {
+			names = append(names, object.Key)
+		}
+		return names
+	}
+
+	testCases := []struct {
+		authorization string
+		args          SearchObjectsArgs
+		expected      []string
+	}{
+		// Names are searched by users allowed to list the bucket.
+		{userToken, SearchObjectsArgs{BucketName: bucketName, Suffix: ".txt"}, []string{"private/red.txt", "public/red.txt"}},
+		// Metadata and content types of objects users cannot get are not revealed.
+		{userToken, SearchObjectsArgs{BucketName: bucketName, Metadata: map[string]string{"color": "red"}}, []string{"public/red.txt"}},
+		{userToken, SearchObjectsArgs{BucketName: bucketName, ContentType: "text/*"}, []string{"public/red.txt"}},
+		{rootToken, SearchObjectsArgs{BucketName: bucketName, Metadata: map[string]string{"color": "red"}}, []string{"private/red.txt", "public/red.txt"}},
+	}
+	for i, testCase := range testCases {
+		if names := search(testCase.authorization, testCase.args); !reflect.DeepEqual(names, testCase.expected) {
+			t.Errorf("%s: Test %d: Expected %v, got %v", instanceType, i+1, testCase.expected, names)
+		}
+	}
+}
+
 // Wrapper for calling RemoveObject Web Handler
 func TestWebHandlerRemoveObject(t *testing.T) {
 	ExecObjectLayerTest(t, testRemoveObjectWebHandler)
@@ -1120,6 +1471,143 @@ func testWebHandlerDownloadZip(obj ObjectLayer, instanceType string, t TestErrHa
 	}
 }
 
+// Wrapper for calling DownloadPrefixZip handler
+func TestWebHandlerDownloadPrefixZip(t *testing.T) {
+	defer func(kms KMS) { globalKMS = kms }(globalKMS)
+	ExecObjectLayerTest(t, testWebHandlerDownloadPrefixZip)
+}
+
+func testWebHandlerDownloadPrefixZip(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	apiRouter := initTestWebRPCEndPoint(obj)
+	credentials := globalServerConfig.GetCredential()
+	globalKMS = newTestKMS()
+
+	bucket := getRandomBucketName()
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+
+	objects := map[string]string{
+		"a/one":     "aaaaaaaaaaaaaa",
+		"a/b/two":   "bbbbbbbbbbbbbb",
+		"a/b/three": "cccccccccccccc",
+		"four":      "dddddddddddddd",
+	}
+	for object, content := range objects {
+		if _, err := obj.PutObject(bucket, object, mustGetHashReader(t, strings.NewReader(content), int64(len(content)), "", ""), nil); err != nil {
+			t.Fatalf("%s : %s", instanceType, err)
+		}
+	}
+
+	// Objects encrypted with SSE-S3 are decrypted.
+	encrypted := "eeeeeeeeeeeeee"
+	metadata := make(map[string]string)
+	reader, err := newKMSEncryptReader(strings.NewReader(encrypted), globalKMS, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = obj.PutObject(bucket, "a/b/encrypted", mustGetHashReader(t, reader, encryptedSize(int64(len(encrypted))), "", ""), metadata); err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+	objects["a/b/encrypted"] = encrypted
+
+	// Objects pending validation are left out.
+	pending := "pppppppppppppp"
+	if _, err = obj.PutObject(bucket, "a/b/pending", mustGetHashReader(t, strings.NewReader(pending), int64(len(pending)), "", ""),
+		map[string]string{ObjectQuarantineStatus: quarantinePending}); err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+
+	test := func(prefix, token string) (*httptest.ResponseRecorder, map[string]string) {
+		rec := httptest.NewRecorder()
+		req, rerr := http.NewRequest("GET", "/minio/zip/"+bucket+"/"+prefix+"?token="+token, nil)
+		if rerr != nil {
+			t.Fatalf("Cannot create download request, %v", rerr)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			return rec, nil
+		}
+
+		data := rec.Body.Bytes()
+		zipReader, rerr := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+		files := make(map[string]string)
+		for _, file := range zipReader.File {
+			fileReader, rerr := file.Open()
+			if rerr != nil {
+				t.Fatal(rerr)
+			}
+			content, rerr := ioutil.ReadAll(fileReader)
+			if rerr != nil {
+				t.Fatal(rerr)
+			}
+			files[file.Name] = string(content)
+		}
+		return rec, files
+	}
+
+	if rec, _ := test("a/b/", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected to receive authentication error, got %d", instanceType, rec.Code)
+	}
+
+	testCases := []struct {
+		prefix   string
+		filename string
+		files    map[string]string
+	}{
+		{"a/b/", "b.zip", map[string]string{
+			"b/two":       objects["a/b/two"],
+			"b/three":     objects["a/b/three"],
+			"b/encrypted": encrypted,
+		}},
+		// Prefixes without trailing slash are folders too.
+		{"a", "a.zip", map[string]string{
+			"a/one":         objects["a/one"],
+			"a/b/two":       objects["a/b/two"],
+			"a/b/three":     objects["a/b/three"],
+			"a/b/encrypted": encrypted,
+		}},
+		// The whole bucket is archived without prefix.
+		{"", bucket + ".zip", objects},
+	}
+	for i, testCase := range testCases {
+		token, err := authenticateURL(credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatal("Cannot authenticate")
+		}
+		rec, files := test(testCase.prefix, token)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Test %d: %s: Expected status 200, got %d", i+1, instanceType, rec.Code)
+		}
+		if disposition := rec.Header().Get("Content-Disposition"); disposition != fmt.Sprintf("attachment; filename=\"%s\"", testCase.filename) {
+			t.Errorf("Test %d: %s: Unexpected content disposition %s", i+1, instanceType, disposition)
+		}
+		if !reflect.DeepEqual(files, testCase.files) {
+			t.Errorf("Test %d: %s: Expected files %v, got %v", i+1, instanceType, testCase.files, files)
+		}
+	}
+
+	// Anonymous archives leave out objects the bucket policy denies.
+	deny := getReadOnlyObjectStatement(bucket, "a/b/")
+	deny.Effect = "Deny"
+	bucketPolicy := policy.BucketAccessPolicy{Version: "1.0", Statements: []policy.Statement{getReadOnlyObjectStatement(bucket, ""), deny}}
+	if err = obj.SetBucketPolicy(bucket, bucketPolicy); err != nil {
+		t.Fatalf("%s: Failed to set bucket policy: <ERROR> %v", instanceType, err)
+	}
+	globalBucketPolicyCache.invalidate(bucket)
+	defer globalBucketPolicyCache.invalidate(bucket)
+	rec, files := test("a/", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status 200, got %d", instanceType, rec.Code)
+	}
+	if expected := map[string]string{"a/one": objects["a/one"]}; !reflect.DeepEqual(files, expected) {
+		t.Errorf("%s: Expected files %v, got %v", instanceType, expected, files)
+	}
+}
+
 // Wrapper for calling PresignedGet handler
 func TestWebHandlerPresignedGetHandler(t *testing.T) {
 	ExecObjectLayerTest(t, testWebPresignedGetHandler)
@@ -1224,6 +1712,91 @@ func testWebPresignedGetHandler(obj ObjectLayer, instanceType string, t TestErrH
 	}
 }
 
+// Wrapper for calling PresignedGetObjects handler
+func TestWebHandlerPresignedGetObjectsHandler(t *testing.T) {
+	ExecObjectLayerTest(t, testWebPresignedGetObjectsHandler)
+}
+
+func testWebPresignedGetObjectsHandler(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	// Register the API end points with XL/FS object layer.
+	apiRouter := initTestWebRPCEndPoint(obj)
+	credentials := globalServerConfig.GetCredential()
+
+	authorization, err := getWebRPCToken(apiRouter, credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatal("Cannot authenticate")
+	}
+
+	bucketName := getRandomBucketName()
+	if err = obj.MakeBucketWithLocation(bucketName, ""); err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+	objects := map[string][]byte{
+		"pics/hawaii/one.jpg": bytes.Repeat([]byte("a"), 10),
+		"pics/hawaii/two.jpg": bytes.Repeat([]byte("b"), 20),
+		"pics/sanjose.jpg":    bytes.Repeat([]byte("c"), 30),
+		"pics/maldives.jpg":   bytes.Repeat([]byte("d"), 40),
+	}
+	for object, data := range objects {
+		if _, err = obj.PutObject(bucketName, object, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil); err != nil {
+			t.Fatalf("Was not able to upload an object, %v", err)
+		}
+	}
+
+	presign := func(args PresignedGetObjectsArgs) (*PresignedGetObjectsRep, error) {
+		rec := httptest.NewRecorder()
+		req, rerr := newTestWebRPCRequest("Web.PresignedGetObjects", authorization, args)
+		if rerr != nil {
+			t.Fatalf("Failed to create HTTP request: <ERROR> %v", rerr)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected the response status to be 200, but instead found `%d`", rec.Code)
+		}
+		reply := &PresignedGetObjectsRep{}
+		return reply, getTestWebRPCResponse(rec, &reply)
+	}
+
+	reply, err := presign(PresignedGetObjectsArgs{
+		BucketName: bucketName,
+		Prefix:     "pics/",
+		Objects:    []string{"hawaii/", "sanjose.jpg"},
+		Expiry:     1000,
+	})
+	if err != nil {
+		t.Fatalf("Failed, %v", err)
+	}
+	var shared []string
+	for _, presigned := range reply.URLs {
+		shared = append(shared, presigned.Object)
+	}
+	if expected := []string{"pics/hawaii/one.jpg", "pics/hawaii/two.jpg", "pics/sanjose.jpg"}; !reflect.DeepEqual(shared, expected) {
+		t.Fatalf("Expected shared objects %v, got %v", expected, shared)
+	}
+
+	// All URLs serve their object.
+	getRouter := initTestAPIEndPoints(obj, []string{"GetObject"})
+	for _, presigned := range reply.URLs {
+		arec := httptest.NewRecorder()
+		req, rerr := newTestRequest("GET", presigned.URL, 0, nil)
+		if rerr != nil {
+			t.Fatal("Failed to initialized a new request", rerr)
+		}
+		req.Header.Del("x-amz-content-sha256")
+		getRouter.ServeHTTP(arec, req)
+		if arec.Code != http.StatusOK {
+			t.Fatalf("Expected the response status to be 200, but instead found `%d`", arec.Code)
+		}
+		if !bytes.Equal(arec.Body.Bytes(), objects[presigned.Object]) {
+			t.Fatalf("Read data of %s is not equal to what was expected", presigned.Object)
+		}
+	}
+
+	if _, err = presign(PresignedGetObjectsArgs{BucketName: bucketName}); err == nil || err.Error() != "Bucket and Objects are mandatory arguments." {
+		t.Fatalf("Unexpected, expected `Bucket and Objects are mandatory arguments`, got %v", err)
+	}
+}
+
 // Wrapper for calling GetBucketPolicy Handler
 func TestWebHandlerGetBucketPolicyHandler(t *testing.T) {
 	ExecObjectLayerTest(t, testWebGetBucketPolicyHandler)
@@ -1465,6 +2038,114 @@ func testWebSetBucketPolicyHandler(obj ObjectLayer, instanceType string, t TestE
 	}
 }
 
+// Wrapper for calling Get/SetBucketPolicyDocument tests for both XL multiple disks and single node setup.
+func TestWebHandlerBucketPolicyDocumentHandler(t *testing.T) {
+	ExecObjectLayerTest(t, testWebBucketPolicyDocumentHandler)
+}
+
+// testWebBucketPolicyDocumentHandler - Test Get/SetBucketPolicyDocument web handlers
+func testWebBucketPolicyDocumentHandler(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	// Register the API end points with XL/FS object layer.
+	apiRouter := initTestWebRPCEndPoint(obj)
+	credentials := globalServerConfig.GetCredential()
+
+	authorization, err := getWebRPCToken(apiRouter, credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatal("Cannot authenticate")
+	}
+
+	bucketName := getRandomBucketName()
+	if err = obj.MakeBucketWithLocation(bucketName, ""); err != nil {
+		t.Fatal("Unexpected error: ", err)
+	}
+
+	getPolicy := func() string {
+		rec := httptest.NewRecorder()
+		reply := &GetBucketPolicyDocumentRep{}
+		req, rerr := newTestWebRPCRequest("Web.GetBucketPolicyDocument", authorization,
+			&GetBucketPolicyDocumentArgs{BucketName: bucketName})
+		if rerr != nil {
+			t.Fatalf("Failed to create HTTP request: <ERROR> %v", rerr)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rerr = getTestWebRPCResponse(rec, &reply); rerr != nil {
+			t.Fatalf("Unexpected error: %v", rerr)
+		}
+		return reply.Policy
+	}
+
+	if policyJSON := getPolicy(); policyJSON != "" {
+		t.Fatalf("Expected no policy, got %s", policyJSON)
+	}
+
+	prefixPolicy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},` +
+		`"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::` + bucketName + `/public/*"]},` +
+		`{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:PutObject"],` +
+		`"Resource":["arn:aws:s3:::` + bucketName + `/uploads/*"],"Condition":{"IpAddress":{"aws:SourceIp":["10.0.0.0/8"]}}}]}`
+
+	testCases := []struct {
+		bucketName  string
+		policy      string
+		expectedErr string
+	}{
+		// Prefix-level policy with conditions.
+		{bucketName, prefixPolicy, ""},
+		// Invalid JSON.
+		{bucketName, `{"Version":`, "Invalid bucket policy"},
+		// Unknown action.
+		{bucketName, strings.Replace(prefixPolicy, "s3:GetObject", "s3:Unknown", 1), "Invalid bucket policy"},
+		// Resources of other buckets.
+		{bucketName, strings.Replace(prefixPolicy, bucketName+"/public", "other/public", 1), "Invalid bucket policy"},
+		// Nested resources.
+		{bucketName, strings.Replace(prefixPolicy, "/uploads/*", "/public/private/*", 1), "Invalid bucket policy"},
+		// Too large.
+		{bucketName, prefixPolicy + strings.Repeat(" ", maxAccessPolicySize), "cannot be larger"},
+		// Unknown bucket.
+		{"unknown-bucket", prefixPolicy, "does not exist"},
+		// Empty policy removes the policy.
+		{bucketName, "", ""},
+	}
+
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		reply := &WebGenericRep{}
+		args := &SetBucketPolicyDocumentArgs{BucketName: testCase.bucketName, Policy: testCase.policy}
+		req, rerr := newTestWebRPCRequest("Web.SetBucketPolicyDocument", authorization, args)
+		if rerr != nil {
+			t.Fatalf("Test %d: Failed to create HTTP request: <ERROR> %v", i+1, rerr)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Test %d: Expected the response status to be 200, but instead found `%d`", i+1, rec.Code)
+		}
+		rerr = getTestWebRPCResponse(rec, &reply)
+		if testCase.expectedErr == "" && rerr != nil {
+			t.Fatalf("Test %d: Should succeed but it didn't, %v", i+1, rerr)
+		}
+		if testCase.expectedErr != "" && (rerr == nil || !strings.Contains(rerr.Error(), testCase.expectedErr)) {
+			t.Fatalf("Test %d: Expected error `%s`, got %v", i+1, testCase.expectedErr, rerr)
+		}
+
+		// The policy set first is kept by the invalid ones.
+		if i == len(testCases)-2 {
+			var policyInfo policy.BucketAccessPolicy
+			if err = json.Unmarshal([]byte(getPolicy()), &policyInfo); err != nil {
+				t.Fatal("Unexpected error: ", err)
+			}
+			if len(policyInfo.Statements) != 2 {
+				t.Fatalf("Expected the prefix policy to be kept, got %+v", policyInfo)
+			}
+			if !policyInfo.Statements[0].Resources.Contains(bucketARNPrefix + bucketName + "/public/*") {
+				t.Errorf("Expected the prefix public to be shared, got %+v", policyInfo.Statements[0])
+			}
+		}
+	}
+
+	if policyJSON := getPolicy(); policyJSON != "" {
+		t.Fatalf("Expected the policy to be removed, got %s", policyJSON)
+	}
+}
+
 // TestWebCheckAuthorization - Test Authorization for all web handlers
 func TestWebCheckAuthorization(t *testing.T) {
 	// Prepare XL backend
@@ -1494,6 +2175,7 @@ func TestWebCheckAuthorization(t *testing.T) {
 		"ListBuckets", "ListObjects", "RemoveObject",
 		"GenerateAuth", "SetAuth", "GetAuth",
 		"GetBucketPolicy", "SetBucketPolicy", "ListAllBucketPolicies",
+		"GetBucketPolicyDocument", "SetBucketPolicyDocument",
 		"PresignedGet",
 	}
 	for _, rpcCall := range webRPCs {
@@ -1581,7 +2263,8 @@ func TestWebObjectLayerNotReady(t *testing.T) {
 	// Check if web rpc calls return Server not initialized. ServerInfo, GenerateAuth,
 	// SetAuth and GetAuth are not concerned
 	webRPCs := []string{"StorageInfo", "MakeBucket", "ListBuckets", "ListObjects", "RemoveObject",
-		"GetBucketPolicy", "SetBucketPolicy", "ListAllBucketPolicies"}
+		"GetBucketPolicy", "SetBucketPolicy", "ListAllBucketPolicies",
+		"GetBucketPolicyDocument", "SetBucketPolicyDocument"}
 	for _, rpcCall := range webRPCs {
 		args := &AuthRPCArgs{
 			Version: globalRPCAPIVersion,
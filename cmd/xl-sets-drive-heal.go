@@ -0,0 +1,198 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+)
+
+const (
+	// Status of healing the set of a replaced drive.
+	driveHealRunning  = "running"
+	driveHealFinished = "finished"
+	driveHealFailed   = "failed"
+)
+
+// driveHealStatus - progress of healing the erasure set of a fresh
+// drive which replaced a failed one.
+type driveHealStatus struct {
+	Endpoint       string    `json:"endpoint"`
+	Set            int       `json:"set"`
+	Status         string    `json:"status"`
+	StartTime      time.Time `json:"startTime"`
+	EndTime        time.Time `json:"endTime"`
+	BucketsHealed  int64     `json:"bucketsHealed"`
+	ObjectsScanned int64     `json:"objectsScanned"`
+	ObjectsHealed  int64     `json:"objectsHealed"`
+	ObjectsFailed  int64     `json:"objectsFailed"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// driveHealState - drives replaced while this server is running, the
+// progress of healing their sets is kept until restarted.
+type driveHealState struct {
+	sync.Mutex
+	drives map[string]*driveHealStatus
+}
+
+func newDriveHealState() *driveHealState {
+	return &driveHealState{drives: make(map[string]*driveHealStatus)}
+}
+
+// Global state of replaced drives.
+var globalDriveHealState = newDriveHealState()
+
+// Start - starts healing set onto the drive of endpoint in the
+// background unless already running.
+func (s *driveHealState) Start(set *xlObjects, endpoint string, setIndex int) driveHealStatus {
+	s.Lock()
+	defer s.Unlock()
+
+	if status, ok := s.drives[endpoint]; ok && status.Status == driveHealRunning {
+		return *status
+	}
+	status := &driveHealStatus{
+		Endpoint:  endpoint,
+		Set:       setIndex,
+		Status:    driveHealRunning,
+		StartTime: UTCNow(),
+	}
+	s.drives[endpoint] = status
+	go s.run(set, endpoint)
+	return *status
+}
+
+// List - returns the progress of healing all replaced drives, ordered
+// by their endpoints.
+func (s *driveHealState) List() []driveHealStatus {
+	s.Lock()
+	defer s.Unlock()
+
+	heals := make([]driveHealStatus, 0, len(s.drives))
+	for _, status := range s.drives {
+		heals = append(heals, *status)
+	}
+	sort.Slice(heals, func(i, j int) bool {
+		return heals[i].Endpoint < heals[j].Endpoint
+	})
+	return heals
+}
+
+// update - applies fn to the progress of healing the drive of endpoint.
+func (s *driveHealState) update(endpoint string, fn func(status *driveHealStatus)) {
+	s.Lock()
+	defer s.Unlock()
+
+	if status, ok := s.drives[endpoint]; ok {
+		fn(status)
+	}
+}
+
+func (s *driveHealState) run(set *xlObjects, endpoint string) {
+	err := healErasureSet(set, func(fn func(*driveHealStatus)) {
+		s.update(endpoint, fn)
+	})
+	errorIf(err, "Unable to heal the set of replaced drive %s.", endpoint)
+	s.update(endpoint, func(status *driveHealStatus) {
+		status.EndTime = UTCNow()
+		if err != nil {
+			status.Status = driveHealFailed
+			status.Error = errors.Cause(err).Error()
+			return
+		}
+		status.Status = driveHealFinished
+	})
+}
+
+// healErasureSet - heals all buckets and objects of set, including the
+// configuration of the buckets. Objects which cannot be healed are
+// counted and skipped, healing stops when the server stops accepting
+// writes.
+func healErasureSet(set *xlObjects, update func(func(*driveHealStatus))) error {
+	buckets, _, err := listAllBuckets(set.getDisks())
+	if err != nil {
+		return err
+	}
+
+	// Configuration of buckets is healed as well.
+	prefixes := map[string]string{
+		minioMetaBucket: bucketConfigPrefix,
+	}
+	for _, bucket := range buckets {
+		if isMinioMetaBucketName(bucket.Name) {
+			continue
+		}
+		if err = checkServerWritable(); err != nil {
+			return err
+		}
+		if _, err = set.HealBucket(bucket.Name, false); err != nil {
+			return err
+		}
+		update(func(status *driveHealStatus) {
+			status.BucketsHealed++
+		})
+		prefixes[bucket.Name] = ""
+	}
+
+	names := make([]string, 0, len(prefixes))
+	for bucket := range prefixes {
+		names = append(names, bucket)
+	}
+	sort.Strings(names)
+	for _, bucket := range names {
+		if err = healSetObjects(set, bucket, prefixes[bucket], update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// healSetObjects - heals all objects of set under prefix of bucket.
+func healSetObjects(set *xlObjects, bucket, prefix string, update func(func(*driveHealStatus))) error {
+	isLeaf := func(bucket, entry string) bool {
+		return set.isObject(bucket, strings.TrimSuffix(entry, slashSeparator))
+	}
+	listDir := listDirSetsHealFactory(isLeaf, set.getLoadBalancedDisks())
+
+	endWalkCh := make(chan struct{})
+	defer close(endWalkCh)
+	walkResultCh := startTreeWalk(bucket, prefix, "", true, listDir, nil, endWalkCh)
+	for walkResult := range walkResultCh {
+		if walkResult.err != nil {
+			return walkResult.err
+		}
+		if err := checkServerWritable(); err != nil {
+			return err
+		}
+		_, err := set.HealObject(bucket, walkResult.entry, false)
+		errorIf(err, "Unable to heal %s/%s.", bucket, walkResult.entry)
+		update(func(status *driveHealStatus) {
+			status.ObjectsScanned++
+			if err != nil {
+				status.ObjectsFailed++
+				return
+			}
+			status.ObjectsHealed++
+		})
+	}
+	return nil
+}
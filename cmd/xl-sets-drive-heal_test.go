@@ -0,0 +1,114 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Tests formatting a fresh disk which replaced a failed disk, and
+// healing its set onto it.
+func TestFormatReplacedDisks(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+	resetGlobalStorageEnvs()
+
+	disks, err := getRandomDisks(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	endpoints := mustGetNewEndpointList(disks...)
+	format, err := waitForFormatXL(true, endpoints, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := newXLSets(endpoints, format, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := obj.(*xlSets)
+
+	if err = obj.MakeBucketWithLocation("bucket", ""); err != nil {
+		t.Fatal(err)
+	}
+	const count = 10
+	for i := 0; i < count; i++ {
+		content := []byte(fmt.Sprintf("content-%d", i))
+		if _, err = obj.PutObject("bucket", fmt.Sprintf("dir/object-%d", i),
+			mustGetHashReader(t, bytes.NewReader(content), int64(len(content)), "", ""), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Replace the second disk by an empty one.
+	if err = os.RemoveAll(disks[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(disks[1], 0755); err != nil {
+		t.Fatal(err)
+	}
+	if !s.isUnformatted(endpoints[1]) {
+		t.Fatal("Expected the replaced disk to be unformatted")
+	}
+	if s.isUnformatted(endpoints[0]) {
+		t.Fatal("Expected the other disks to be formatted")
+	}
+
+	s.formatReplacedDisks()
+	if s.isUnformatted(endpoints[1]) {
+		t.Fatal("Expected the replaced disk to be formatted")
+	}
+	newFormat, err := loadFormatXL(s.getEndpointDisk(endpoints[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i, j, err := findDiskIndex(s.format, newFormat); err != nil || i != 0 || j != 1 {
+		t.Fatalf("Expected the replaced disk in position 0, 1 but got %d, %d: %v", i, j, err)
+	}
+
+	var status driveHealStatus
+	for deadline := time.Now().Add(30 * time.Second); ; {
+		heals := globalDriveHealState.List()
+		if len(heals) != 1 || heals[0].Endpoint != endpoints[1].String() {
+			t.Fatalf("Unexpected drive heals %+v", heals)
+		}
+		status = heals[0]
+		if status.Status != driveHealRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status.Status != driveHealFinished || status.Set != 0 || status.BucketsHealed != 1 ||
+		status.ObjectsHealed != count || status.ObjectsFailed != 0 {
+		t.Fatalf("Unexpected drive heal %+v", status)
+	}
+	for i := 0; i < count; i++ {
+		if _, err = os.Stat(filepath.Join(disks[1], "bucket", "dir", fmt.Sprintf("object-%d", i), xlMetaJSONFile)); err != nil {
+			t.Fatalf("Expected object-%d on the replaced disk: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+	"github.com/minio/minio/pkg/madmin"
+)
+
+const (
+	// Environment variables enabling the integrity scanner and setting
+	// the interval between its passes.
+	integrityScanEnv         = "MINIO_INTEGRITY_SCAN"
+	integrityScanIntervalEnv = "MINIO_INTEGRITY_SCAN_INTERVAL"
+
+	// Default interval between the passes of the integrity scanner.
+	defaultIntegrityScanInterval = 24 * time.Hour
+
+	// The report of the last pass of every server is persisted below
+	// this prefix of the meta bucket, in a file named after the server.
+	integrityScanPrefix = "config/integrity-scan"
+
+	// Current version of the persisted reports.
+	integrityScanVersion = "1"
+
+	// Maximum number of objects kept in the report of a server, further
+	// objects are counted but not listed.
+	maxIntegrityScanObjects = 100000
+
+	// Formats integrity reports are exported in.
+	integrityReportCSV  = "csv"
+	integrityReportJSON = "json"
+)
+
+// Interval between the passes of the integrity scanner, zero unless
+// enabled by MINIO_INTEGRITY_SCAN.
+var globalIntegrityScanInterval time.Duration
+
+func handleIntegrityScanEnv() {
+	switch value := os.Getenv(integrityScanEnv); value {
+	case "", "off":
+		return
+	case "on":
+		globalIntegrityScanInterval = defaultIntegrityScanInterval
+	default:
+		fatalIf(fmt.Errorf("invalid value"), "Unknown value ‘%s’ in %s environment variable.", value, integrityScanEnv)
+	}
+
+	if value := os.Getenv(integrityScanIntervalEnv); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err == nil && interval < time.Minute {
+			err = fmt.Errorf("interval must be at least a minute")
+		}
+		fatalIf(err, "Invalid value set in environment variable %s.", integrityScanIntervalEnv)
+		globalIntegrityScanInterval = interval
+	}
+}
+
+// integrityScanObject - object found with shards missing, corrupted by
+// bitrot or on offline disks when scanned.
+type integrityScanObject struct {
+	Bucket   string    `json:"bucket"`
+	Object   string    `json:"object"`
+	Zone     int       `json:"zone"`
+	Set      int       `json:"set"`
+	ScanTime time.Time `json:"scanTime"`
+
+	// Number of shards missing, corrupted and on offline disks.
+	Missing int `json:"missing"`
+	Corrupt int `json:"corrupt"`
+	Offline int `json:"offline"`
+
+	DataBlocks   int `json:"dataBlocks"`
+	ParityBlocks int `json:"parityBlocks"`
+
+	// Number of shards which can still be lost before the object
+	// cannot be read, negative for objects which cannot be read.
+	Redundancy int `json:"redundancy"`
+
+	// Error checking the object, for example if too few disks have it.
+	Error string `json:"error,omitempty"`
+}
+
+// integrityScanReport - objects found below full redundancy by the last
+// pass of the integrity scanner of a server over its erasure sets.
+type integrityScanReport struct {
+	Version        string                `json:"version"`
+	Server         string                `json:"server"`
+	StartTime      time.Time             `json:"startTime"`
+	EndTime        time.Time             `json:"endTime"`
+	ObjectsScanned int64                 `json:"objectsScanned"`
+	ObjectsFound   int64                 `json:"objectsFound"`
+	Objects        []integrityScanObject `json:"objects"`
+}
+
+// add - adds an object found below full redundancy to the report.
+func (r *integrityScanReport) add(object integrityScanObject) {
+	r.ObjectsFound++
+	if len(r.Objects) < maxIntegrityScanObjects {
+		r.Objects = append(r.Objects, object)
+	}
+}
+
+// newIntegrityScanObject - returns the integrity of an object from the
+// dry run of healing it, false if all shards of the object are intact.
+func newIntegrityScanObject(setIndex int, bucket, object string, result madmin.HealResultItem, err error) (integrityScanObject, bool) {
+	scanObject := integrityScanObject{
+		Bucket:       bucket,
+		Object:       object,
+		Set:          setIndex,
+		ScanTime:     UTCNow(),
+		DataBlocks:   result.DataBlocks,
+		ParityBlocks: result.ParityBlocks,
+	}
+	var available int
+	for _, drive := range result.Before.Drives {
+		switch drive.State {
+		case madmin.DriveStateOk:
+			available++
+		case madmin.DriveStateMissing:
+			scanObject.Missing++
+		case madmin.DriveStateCorrupt:
+			scanObject.Corrupt++
+		case madmin.DriveStateOffline:
+			scanObject.Offline++
+		}
+	}
+	scanObject.Redundancy = available - result.DataBlocks
+	if err != nil {
+		scanObject.Error = errors.Cause(err).Error()
+		return scanObject, true
+	}
+	return scanObject, available < result.DataBlocks+result.ParityBlocks
+}
+
+// scanSetIntegrity - checks the shards of all objects of set, reading
+// and verifying them against their bitrot checksums, and adds objects
+// below full redundancy to report. Objects deleted while scanned are
+// skipped, scanning stops when the server stops.
+func scanSetIntegrity(set *xlObjects, zoneIndex, setIndex int, report *integrityScanReport) error {
+	buckets, _, err := listAllBuckets(set.getDisks())
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(buckets))
+	for bucket := range buckets {
+		names = append(names, bucket)
+	}
+	sort.Strings(names)
+
+	isLeaf := func(bucket, entry string) bool {
+		return set.isObject(bucket, strings.TrimSuffix(entry, slashSeparator))
+	}
+	for _, bucket := range names {
+		listDir := listDirSetsHealFactory(isLeaf, set.getLoadBalancedDisks())
+		endWalkCh := make(chan struct{})
+		walkResultCh := startTreeWalk(bucket, "", "", true, listDir, nil, endWalkCh)
+		for walkResult := range walkResultCh {
+			if walkResult.err != nil {
+				close(endWalkCh)
+				return walkResult.err
+			}
+			select {
+			case <-globalServiceDoneCh:
+				close(endWalkCh)
+				return errServerNotInitialized
+			default:
+			}
+
+			result, err := set.HealObject(bucket, walkResult.entry, true)
+			if isErrObjectNotFound(err) {
+				continue
+			}
+			report.ObjectsScanned++
+			if scanObject, ok := newIntegrityScanObject(setIndex, bucket, walkResult.entry, result, err); ok {
+				scanObject.Zone = zoneIndex
+				report.add(scanObject)
+			}
+		}
+		close(endWalkCh)
+	}
+	return nil
+}
+
+// getLocalIntegritySets - returns the indices of the erasure sets
+// scanned by this server, the sets whose first disk is local so that
+// every set is scanned by a single server.
+func getLocalIntegritySets(s *xlSets) []int {
+	var sets []int
+	for i := range s.sets {
+		if s.endpoints[i*s.drivesPerSet].IsLocal {
+			sets = append(sets, i)
+		}
+	}
+	return sets
+}
+
+// getIntegrityScanZones - returns the erasure sets of every zone of the
+// object layer, none for object layers without erasure sets.
+func getIntegrityScanZones(objAPI ObjectLayer) []*xlSets {
+	switch obj := unwrapObjectLayer(objAPI).(type) {
+	case *xlSets:
+		return []*xlSets{obj}
+	case *xlZones:
+		return obj.zones
+	}
+	return nil
+}
+
+// scanIntegrity - scans the erasure sets of this server in all zones
+// and returns the report of the pass.
+func scanIntegrity(zones []*xlSets) (integrityScanReport, error) {
+	report := integrityScanReport{
+		Version:   integrityScanVersion,
+		Server:    GetLocalPeer(globalEndpoints),
+		StartTime: UTCNow(),
+	}
+	for zoneIndex, s := range zones {
+		for _, setIndex := range getLocalIntegritySets(s) {
+			if err := scanSetIntegrity(s.sets[setIndex], zoneIndex, setIndex, &report); err != nil {
+				return report, err
+			}
+		}
+	}
+	report.EndTime = UTCNow()
+	return report, nil
+}
+
+// getIntegrityScanPath - returns the path of the report of a server in
+// the meta bucket.
+func getIntegrityScanPath(server string) string {
+	return path.Join(integrityScanPrefix, strings.Replace(server, ":", "_", -1)+".json")
+}
+
+// Persists the report of the last pass of this server to object layer.
+func saveIntegrityScanReport(objAPI ObjectLayer, report integrityScanReport) error {
+	buf, err := json.Marshal(report)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	hashReader, err := hash.NewReader(bytes.NewReader(buf), int64(len(buf)), "", getSHA256Hash(buf))
+	if err != nil {
+		return err
+	}
+	_, err = objAPI.PutObject(minioMetaBucket, getIntegrityScanPath(report.Server), hashReader, nil)
+	return err
+}
+
+// Loads the report persisted at reportPath, returns false if it does
+// not exist.
+func loadIntegrityScanReport(objAPI ObjectLayer, reportPath string) (integrityScanReport, bool, error) {
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, reportPath, 0, -1, &buffer, "")
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return integrityScanReport{}, false, nil
+		}
+		return integrityScanReport{}, false, err
+	}
+	report := integrityScanReport{}
+	if err = json.Unmarshal(buffer.Bytes(), &report); err != nil {
+		return integrityScanReport{}, false, errors.Trace(err)
+	}
+	return report, true, nil
+}
+
+// loadIntegrityScanReports - loads the reports of the last pass of all
+// servers, ordered by server.
+func loadIntegrityScanReports(objAPI ObjectLayer) ([]integrityScanReport, error) {
+	var reports []integrityScanReport
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(minioMetaBucket, integrityScanPrefix+slashSeparator, marker, "", maxObjectList)
+		if err != nil {
+			return nil, err
+		}
+		for _, objInfo := range result.Objects {
+			report, ok, err := loadIntegrityScanReport(objAPI, objInfo.Name)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				reports = append(reports, report)
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Server < reports[j].Server
+	})
+	return reports, nil
+}
+
+// Start a routine scanning the erasure sets of this server in all zones
+// periodically, the first pass starts once the interval passed since
+// the last pass persisted by this server.
+func startIntegrityScanner(objAPI ObjectLayer, zones []*xlSets, interval time.Duration) {
+	var localSets int
+	for _, s := range zones {
+		localSets += len(getLocalIntegritySets(s))
+	}
+	if localSets == 0 {
+		return
+	}
+	go func() {
+		var wait time.Duration
+		last, ok, err := loadIntegrityScanReport(objAPI, getIntegrityScanPath(GetLocalPeer(globalEndpoints)))
+		errorIf(err, "Unable to load the last integrity scan report.")
+		if ok {
+			wait = last.EndTime.Add(interval).Sub(UTCNow())
+		}
+		for {
+			if wait > 0 {
+				select {
+				case <-globalServiceDoneCh:
+					return
+				case <-time.After(wait):
+				}
+			}
+			report, err := scanIntegrity(zones)
+			if err != nil {
+				errorIf(err, "Unable to scan the integrity of objects.")
+			} else {
+				errorIf(saveIntegrityScanReport(objAPI, report), "Unable to persist the integrity scan report.")
+			}
+			wait = interval
+		}
+	}()
+}
+
+// Columns of integrity reports exported as CSV.
+var integrityReportCSVHeader = []string{
+	"server", "bucket", "object", "set", "scan_time", "missing", "corrupt",
+	"offline", "data_blocks", "parity_blocks", "redundancy", "error", "zone",
+}
+
+// writeIntegrityReport - writes the objects of all reports to w, as CSV
+// with a header or as JSON lines.
+func writeIntegrityReport(w io.Writer, format string, reports []integrityScanReport) error {
+	if format == integrityReportJSON {
+		encoder := json.NewEncoder(w)
+		for _, report := range reports {
+			for _, object := range report.Objects {
+				if err := encoder.Encode(struct {
+					Server string `json:"server"`
+					integrityScanObject
+				}{report.Server, object}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(integrityReportCSVHeader); err != nil {
+		return err
+	}
+	for _, report := range reports {
+		for _, object := range report.Objects {
+			if err := csvWriter.Write([]string{
+				report.Server,
+				object.Bucket,
+				object.Object,
+				strconv.Itoa(object.Set),
+				object.ScanTime.Format(time.RFC3339),
+				strconv.Itoa(object.Missing),
+				strconv.Itoa(object.Corrupt),
+				strconv.Itoa(object.Offline),
+				strconv.Itoa(object.DataBlocks),
+				strconv.Itoa(object.ParityBlocks),
+				strconv.Itoa(object.Redundancy),
+				object.Error,
+				strconv.Itoa(object.Zone),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,165 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Tests that the integrity scanner reports objects with missing and
+// corrupted shards, and that its persisted report is exported.
+func TestScanIntegrity(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+	resetGlobalStorageEnvs()
+
+	// Keep data in part files to corrupt them.
+	defer func(threshold int64) { globalXLInlineThreshold = threshold }(globalXLInlineThreshold)
+	globalXLInlineThreshold = 0
+
+	disks, err := getRandomDisks(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	endpoints := mustGetNewEndpointList(disks...)
+	format, err := waitForFormatXL(true, endpoints, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := newXLSets(endpoints, format, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := obj.(*xlSets)
+
+	if err = obj.MakeBucketWithLocation("bucket", ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"intact", "dir/missing", "corrupt"} {
+		content := bytes.Repeat([]byte(object), 1000)
+		if _, err = obj.PutObject("bucket", object,
+			mustGetHashReader(t, bytes.NewReader(content), int64(len(content)), "", ""), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = os.RemoveAll(filepath.Join(disks[0], "bucket", "dir", "missing")); err != nil {
+		t.Fatal(err)
+	}
+	partPath := filepath.Join(disks[1], "bucket", "corrupt", "part.1")
+	data, err := ioutil.ReadFile(partPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[0] ^= 0xff
+	if err = ioutil.WriteFile(partPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := scanIntegrity(getIntegrityScanZones(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ObjectsScanned != 3 || report.ObjectsFound != 2 || len(report.Objects) != 2 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	found := make(map[string]integrityScanObject)
+	for _, object := range report.Objects {
+		found[object.Object] = object
+	}
+	if object := found["dir/missing"]; object.Missing != 1 || object.Corrupt != 0 || object.Redundancy != 1 {
+		t.Fatalf("Unexpected missing object %+v", object)
+	}
+	if object := found["corrupt"]; object.Corrupt != 1 || object.Missing != 0 || object.Redundancy != 1 {
+		t.Fatalf("Unexpected corrupt object %+v", object)
+	}
+
+	if _, err = loadIntegrityScanReports(obj); err != nil {
+		t.Fatal(err)
+	}
+	if err = saveIntegrityScanReport(obj, report); err != nil {
+		t.Fatal(err)
+	}
+	reports, err := loadIntegrityScanReports(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Server != report.Server || len(reports[0].Objects) != 2 {
+		t.Fatalf("Unexpected persisted reports %+v", reports)
+	}
+}
+
+// Tests exporting reports as CSV and as JSON lines.
+func TestWriteIntegrityReport(t *testing.T) {
+	reports := []integrityScanReport{
+		{Server: "server1:9000", Objects: []integrityScanObject{
+			{Bucket: "bucket", Object: "a,b", Missing: 1, DataBlocks: 2, ParityBlocks: 2, Redundancy: 1},
+		}},
+		{Server: "server2:9000", Objects: []integrityScanObject{
+			{Bucket: "bucket", Object: "c", Zone: 1, Set: 1, Error: "Read failed. Insufficient number of disks online"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeIntegrityReport(&buf, integrityReportCSV, reports); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != strings.Join(integrityReportCSVHeader, ",") {
+		t.Fatalf("Unexpected CSV report %v", records)
+	}
+	if records[1][0] != "server1:9000" || records[1][2] != "a,b" || records[1][5] != "1" || records[1][10] != "1" {
+		t.Fatalf("Unexpected CSV record %v", records[1])
+	}
+	if records[2][3] != "1" || records[2][11] == "" || records[2][12] != "1" {
+		t.Fatalf("Unexpected CSV record %v", records[2])
+	}
+
+	buf.Reset()
+	if err = writeIntegrityReport(&buf, integrityReportJSON, reports); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 JSON lines, got %q", buf.String())
+	}
+	var line struct {
+		Server string `json:"server"`
+		integrityScanObject
+	}
+	if err = json.Unmarshal([]byte(lines[1]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Server != "server2:9000" || line.Object != "c" || line.Error == "" {
+		t.Fatalf("Unexpected JSON line %+v", line)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"container/list"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Environment variable setting the number of objects whose
+	// metadata is cached in memory, 0 turns off the cache.
+	xlMetadataCacheSizeEnv = "MINIO_XL_METADATA_CACHE_SIZE"
+
+	// Environment variable setting how long metadata is cached.
+	xlMetadataCacheExpiryEnv = "MINIO_XL_METADATA_CACHE_EXPIRY"
+
+	// Cached metadata expires after this by default, it bounds how
+	// long a server serves stale metadata when a peer failed to
+	// notify it of a change.
+	defaultXLMetadataCacheExpiry = time.Minute
+)
+
+// Global cache of object metadata, only initialized by the server if
+// enabled by MINIO_XL_METADATA_CACHE_SIZE.
+var globalXLMetadataCache *xlMetadataCache
+
+// Parses the number of objects whose metadata is cached.
+func parseXLMetadataCacheSize(value string) (int, error) {
+	size, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if size < 0 {
+		return 0, errors.New("metadata cache size cannot be negative")
+	}
+	return size, nil
+}
+
+// Parses how long metadata is cached.
+func parseXLMetadataCacheExpiry(value string) (time.Duration, error) {
+	expiry, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if expiry <= 0 {
+		return 0, errors.New("metadata cache expiry must be positive")
+	}
+	return expiry, nil
+}
+
+// Sets the size and expiry of the metadata cache from the environment.
+func handleXLMetadataCacheEnv() {
+	if value := os.Getenv(xlMetadataCacheSizeEnv); value != "" {
+		size, err := parseXLMetadataCacheSize(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", xlMetadataCacheSizeEnv)
+		globalXLMetadataCacheSize = size
+	}
+	if value := os.Getenv(xlMetadataCacheExpiryEnv); value != "" {
+		expiry, err := parseXLMetadataCacheExpiry(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", xlMetadataCacheExpiryEnv)
+		globalXLMetadataCacheExpiry = expiry
+	}
+}
+
+// xlMetadataCache - least recently used cache of the object info of
+// objects, served by GetObjectInfo instead of reading `xl.json` from
+// all disks of the erasure set. Every change of an object drops its
+// cached info on all servers, see invalidateXLMetadata. With several
+// zones it also tells which zone holds an object. A nil cache caches
+// nothing.
+type xlMetadataCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	expiry     time.Duration
+	entries    map[string]*list.Element
+	lru        *list.List // Most recently used entries first.
+
+	// Incremented by invalidate, object info read while an object
+	// was invalidated may be stale and is not cached.
+	version uint64
+}
+
+type xlMetadataCacheEntry struct {
+	key     string
+	owner   *xlSets
+	objInfo ObjectInfo
+	cached  time.Time
+}
+
+func newXLMetadataCache(maxEntries int, expiry time.Duration) *xlMetadataCache {
+	return &xlMetadataCache{
+		maxEntries: maxEntries,
+		expiry:     expiry,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Object info of directories and of the meta buckets is not cached.
+func isXLMetadataCached(bucket, object string) bool {
+	return !isMinioMetaBucketName(bucket) && !hasSuffix(object, slashSeparator)
+}
+
+// Returns a copy of objInfo not sharing its user defined metadata,
+// callers are free to change the object info they are given.
+func copyCachedObjectInfo(objInfo ObjectInfo) ObjectInfo {
+	if objInfo.UserDefined != nil {
+		userDefined := make(map[string]string, len(objInfo.UserDefined))
+		for k, v := range objInfo.UserDefined {
+			userDefined[k] = v
+		}
+		objInfo.UserDefined = userDefined
+	}
+	return objInfo
+}
+
+// get - returns the cached info of the object read through owner, a
+// zone caches the info of the objects it holds only. On a miss the
+// returned version is passed to add once the info is read.
+func (c *xlMetadataCache) get(owner *xlSets, bucket, object string) (objInfo ObjectInfo, version uint64, ok bool) {
+	if c == nil || !isXLMetadataCached(bucket, object) {
+		return objInfo, 0, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[pathJoin(bucket, object)]
+	if !ok {
+		return objInfo, c.version, false
+	}
+	entry := elem.Value.(*xlMetadataCacheEntry)
+	if entry.owner != owner {
+		return objInfo, c.version, false
+	}
+	if UTCNow().Sub(entry.cached) >= c.expiry {
+		c.remove(elem)
+		return objInfo, c.version, false
+	}
+	c.lru.MoveToFront(elem)
+	return copyCachedObjectInfo(entry.objInfo), 0, true
+}
+
+// getOwner - returns the zone whose info of the object is cached, nil
+// if none is.
+func (c *xlMetadataCache) getOwner(bucket, object string) *xlSets {
+	if c == nil || !isXLMetadataCached(bucket, object) {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[pathJoin(bucket, object)]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*xlMetadataCacheEntry)
+	if UTCNow().Sub(entry.cached) >= c.expiry {
+		c.remove(elem)
+		return nil
+	}
+	return entry.owner
+}
+
+// add - caches objInfo read through owner, unless an object was
+// invalidated since version was returned by get. Evicts the least
+// recently used entry once the cache is full.
+func (c *xlMetadataCache) add(version uint64, owner *xlSets, objInfo ObjectInfo) {
+	if c == nil || !isXLMetadataCached(objInfo.Bucket, objInfo.Name) {
+		return
+	}
+	key := pathJoin(objInfo.Bucket, objInfo.Name)
+	entry := &xlMetadataCacheEntry{
+		key:     key,
+		owner:   owner,
+		objInfo: copyCachedObjectInfo(objInfo),
+		cached:  UTCNow(),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version != version {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// invalidate - drops the cached info of objects of the bucket, of all
+// objects of the bucket if none are given.
+func (c *xlMetadataCache) invalidate(bucket string, objects []string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	if len(objects) == 0 {
+		for elem := c.lru.Front(); elem != nil; {
+			next := elem.Next()
+			if objInfo := elem.Value.(*xlMetadataCacheEntry).objInfo; objInfo.Bucket == bucket {
+				c.remove(elem)
+			}
+			elem = next
+		}
+		return
+	}
+	for _, object := range objects {
+		if elem, ok := c.entries[pathJoin(bucket, object)]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+// Removes an entry, must be called with the lock held.
+func (c *xlMetadataCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*xlMetadataCacheEntry).key)
+}
+
+// invalidateXLMetadata - drops the cached info of objects of the bucket
+// just changed on this server and all peers. Called once the change
+// succeeded and before it is acknowledged, so that reads served by any
+// server afterwards see it. Info read before is not cached by get and
+// add.
+func invalidateXLMetadata(bucket string, objects ...string) {
+	if globalXLMetadataCache == nil || isMinioMetaBucketName(bucket) || len(objects) == 0 {
+		return
+	}
+	globalXLMetadataCache.invalidate(bucket, objects)
+	S3PeersInvalidateObjectMetadata(bucket, objects)
+}
+
+// invalidateXLBucketMetadata - drops the cached info of all objects of
+// a bucket just deleted, like invalidateXLMetadata.
+func invalidateXLBucketMetadata(bucket string) {
+	if globalXLMetadataCache == nil || isMinioMetaBucketName(bucket) {
+		return
+	}
+	globalXLMetadataCache.invalidate(bucket, nil)
+	S3PeersInvalidateObjectMetadata(bucket, nil)
+}
@@ -0,0 +1,259 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Tests eviction, expiry and invalidation of cached object info.
+func TestXLMetadataCache(t *testing.T) {
+	owner, other := &xlSets{}, &xlSets{}
+	objInfo := func(object string) ObjectInfo {
+		return ObjectInfo{Bucket: "bucket", Name: object, UserDefined: map[string]string{"content-type": "text/plain"}}
+	}
+	cached := func(c *xlMetadataCache, owner *xlSets, object string) bool {
+		_, _, ok := c.get(owner, "bucket", object)
+		return ok
+	}
+
+	c := newXLMetadataCache(2, time.Hour)
+	_, version, _ := c.get(owner, "bucket", "a")
+	c.add(version, owner, objInfo("a"))
+	c.add(version, owner, objInfo("b"))
+	if !cached(c, owner, "a") || !cached(c, owner, "b") {
+		t.Fatal("Expected objects to be cached")
+	}
+	if cached(c, other, "a") {
+		t.Fatal("Expected object cached by another zone to be missed")
+	}
+	if c.getOwner("bucket", "a") != owner || c.getOwner("bucket", "d") != nil {
+		t.Fatal("Expected the zone caching an object to be returned")
+	}
+
+	// Cached info is copied, callers may change it.
+	info, _, _ := c.get(owner, "bucket", "a")
+	info.UserDefined["content-type"] = "changed"
+	if info, _, _ = c.get(owner, "bucket", "a"); info.UserDefined["content-type"] != "text/plain" {
+		t.Fatalf("Expected cached info to be unchanged, got %v", info.UserDefined)
+	}
+
+	// The least recently used object is evicted.
+	c.add(version, owner, objInfo("c"))
+	if cached(c, owner, "b") || !cached(c, owner, "a") || !cached(c, owner, "c") {
+		t.Fatal("Expected the least recently used object to be evicted")
+	}
+
+	// Info read while an object was invalidated is not cached.
+	_, version, _ = c.get(owner, "bucket", "d")
+	c.invalidate("bucket", []string{"a"})
+	c.add(version, owner, objInfo("d"))
+	if cached(c, owner, "a") || cached(c, owner, "d") {
+		t.Fatal("Expected invalidated and stale objects not to be cached")
+	}
+	c.invalidate("bucket", nil)
+	if cached(c, owner, "c") {
+		t.Fatal("Expected all objects of the bucket to be invalidated")
+	}
+
+	// Directories and the meta buckets are not cached.
+	_, version, _ = c.get(owner, "bucket", "dir/")
+	c.add(version, owner, objInfo("dir/"))
+	c.add(version, owner, ObjectInfo{Bucket: minioMetaBucket, Name: "config.json"})
+	if cached(c, owner, "dir/") {
+		t.Fatal("Expected directories not to be cached")
+	}
+	if _, _, ok := c.get(owner, minioMetaBucket, "config.json"); ok {
+		t.Fatal("Expected the meta bucket not to be cached")
+	}
+
+	// Cached info expires.
+	c = newXLMetadataCache(2, time.Nanosecond)
+	_, version, _ = c.get(owner, "bucket", "a")
+	c.add(version, owner, objInfo("a"))
+	time.Sleep(time.Millisecond)
+	if cached(c, owner, "a") {
+		t.Fatal("Expected cached info to expire")
+	}
+	c.add(version, owner, objInfo("a"))
+	time.Sleep(time.Millisecond)
+	if c.getOwner("bucket", "a") != nil {
+		t.Fatal("Expected the owner of expired info not to be returned")
+	}
+
+	// A nil cache caches nothing.
+	var nilCache *xlMetadataCache
+	nilCache.add(0, owner, objInfo("a"))
+	nilCache.invalidate("bucket", nil)
+	if cached(nilCache, owner, "a") || nilCache.getOwner("bucket", "a") != nil {
+		t.Fatal("Expected nil cache to cache nothing")
+	}
+}
+
+// Tests object info is served from the cache until objects change.
+func TestXLSetsMetadataCache(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	obj, fsDirs, err := prepareXL32()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	globalXLMetadataCache = newXLMetadataCache(100, time.Hour)
+	defer func() { globalXLMetadataCache = nil }()
+
+	if err = obj.MakeBucketWithLocation("bucket", ""); err != nil {
+		t.Fatal(err)
+	}
+	putObject := func(object, content string) {
+		if _, err := obj.PutObject("bucket", object, mustGetHashReader(t, bytes.NewReader([]byte(content)), int64(len(content)), "", ""), nil); err != nil {
+			t.Fatalf("Unable to put %s: %v", object, err)
+		}
+	}
+	// Removes the object from the disks behind the back of the cache.
+	removeFromDisks := func(object string) {
+		for _, dir := range fsDirs {
+			if err := os.RemoveAll(filepath.Join(dir, "bucket", object)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	getSize := func(object string) (int64, error) {
+		objInfo, err := obj.GetObjectInfo("bucket", object)
+		return objInfo.Size, err
+	}
+
+	putObject("object", "content")
+	if size, err := getSize("object"); err != nil || size != int64(len("content")) {
+		t.Fatalf("Unexpected size %d, error %v", size, err)
+	}
+	removeFromDisks("object")
+	if size, err := getSize("object"); err != nil || size != int64(len("content")) {
+		t.Fatalf("Expected cached info, got size %d, error %v", size, err)
+	}
+
+	// Overwriting and deleting objects invalidates their info.
+	putObject("object", "updated content")
+	if size, err := getSize("object"); err != nil || size != int64(len("updated content")) {
+		t.Fatalf("Expected info of the new object, got size %d, error %v", size, err)
+	}
+	if err = obj.DeleteObject("bucket", "object"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = getSize("object"); !isErrObjectNotFound(err) {
+		t.Fatalf("Expected ObjectNotFound, got %v", err)
+	}
+
+	putObject("batch", "content")
+	if _, err = getSize("batch"); err != nil {
+		t.Fatal(err)
+	}
+	errs, err := obj.DeleteObjects("bucket", []string{"batch"})
+	if err != nil || errs[0] != nil {
+		t.Fatalf("Unable to delete objects: %v %v", err, errs)
+	}
+	if _, err = getSize("batch"); !isErrObjectNotFound(err) {
+		t.Fatalf("Expected ObjectNotFound, got %v", err)
+	}
+
+	// Info of deleted buckets is dropped.
+	putObject("object", "content")
+	if _, err = getSize("object"); err != nil {
+		t.Fatal(err)
+	}
+	removeFromDisks("object")
+	if err = obj.DeleteBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = getSize("object"); err == nil {
+		t.Fatal("Expected objects of deleted buckets not to be found")
+	}
+}
+
+// Tests the zone holding an object is taken from the cache.
+func TestXLZonesMetadataCache(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	disks, err := getRandomDisks(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	obj, err := newXLZones([]zoneEndpoints{
+		{mustGetNewEndpointList(disks[:4]...), 1, 4},
+		{mustGetNewEndpointList(disks[4:]...), 1, 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := obj.(*xlZones)
+
+	globalXLMetadataCache = newXLMetadataCache(100, time.Hour)
+	defer func() { globalXLMetadataCache = nil }()
+
+	if err = obj.MakeBucketWithLocation("bucket", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Put the object on the zone it is not placed on, so that a probe
+	// of the zones would not find it once it is removed from the disks.
+	index := 0
+	if z.getHashedZone("bucket", "object") == z.zones[0] {
+		index = 1
+	}
+	content := []byte("content")
+	if _, err = z.zones[index].PutObject("bucket", "object", mustGetHashReader(t, bytes.NewReader(content), int64(len(content)), "", ""), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = obj.GetObjectInfo("bucket", "object"); err != nil {
+		t.Fatal(err)
+	}
+	for _, disk := range disks[index*4 : index*4+4] {
+		if err = os.RemoveAll(filepath.Join(disk, "bucket", "object")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if zone, err := z.getObjectZone("bucket", "object"); err != nil || zone != z.zones[index] {
+		t.Fatal("Expected the zone caching the object")
+	}
+	if objInfo, err := obj.GetObjectInfo("bucket", "object"); err != nil || objInfo.Size != int64(len(content)) {
+		t.Fatalf("Expected cached info, got size %d, error %v", objInfo.Size, err)
+	}
+
+	// Failed changes keep the cached info.
+	badMD5 := "d41d8cd98f00b204e9800998ecf8427e"
+	if _, err = obj.PutObject("bucket", "object", mustGetHashReader(t, bytes.NewReader(content), int64(len(content)), badMD5, ""), nil); err == nil {
+		t.Fatal("Expected an upload with a bad MD5 to fail")
+	}
+	if zone, err := z.getObjectZone("bucket", "object"); err != nil || zone != z.zones[index] {
+		t.Fatal("Expected a failed change not to invalidate the cached info")
+	}
+}
@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"hash/crc32"
 	"io"
@@ -64,6 +65,9 @@ type xlSets struct {
 	// Distribution algorithm of choice.
 	distributionAlgo string
 
+	// Labeled pools of erasure sets objects are placed on.
+	pools storagePoolsConfig
+
 	// Variable represents bucket policies in memory.
 	bucketPolicies *bucketPolicies
 
@@ -71,8 +75,9 @@ type xlSets struct {
 	listPool *treeWalkPool
 }
 
-// isConnected - checks if the endpoint is connected or not.
-func (s *xlSets) isConnected(endpoint Endpoint) bool {
+// getEndpointDisk - returns the disk of the endpoint in the set
+// topology, nil if not placed.
+func (s *xlSets) getEndpointDisk(endpoint Endpoint) StorageAPI {
 	s.xlDisksMu.RLock()
 	defer s.xlDisksMu.RUnlock()
 
@@ -84,10 +89,27 @@ func (s *xlSets) isConnected(endpoint Endpoint) bool {
 			if s.xlDisks[i][j].String() != endpoint.String() {
 				continue
 			}
-			return s.xlDisks[i][j].IsOnline()
+			return s.xlDisks[i][j]
 		}
 	}
-	return false
+	return nil
+}
+
+// isConnected - checks if the endpoint is connected or not.
+func (s *xlSets) isConnected(endpoint Endpoint) bool {
+	disk := s.getEndpointDisk(endpoint)
+	return disk != nil && disk.IsOnline()
+}
+
+// isUnformatted - checks if the disk of a connected endpoint was
+// replaced by a fresh disk.
+func (s *xlSets) isUnformatted(endpoint Endpoint) bool {
+	disk := s.getEndpointDisk(endpoint)
+	if disk == nil {
+		return false
+	}
+	_, err := loadFormatXL(disk)
+	return err == errUnformattedDisk
 }
 
 // Initializes a new StorageAPI from the endpoint argument, returns
@@ -142,30 +164,81 @@ func (s *xlSets) monitorAndConnectEndpoints(doneCh chan struct{}, monitorInterva
 			ticker.Stop()
 			return
 		case <-ticker.C:
+			var unformatted bool
 			for _, endpoint := range s.endpoints {
 				if s.isConnected(endpoint) {
+					// Local disks stay connected when a failed
+					// disk is replaced, they are found empty.
+					if endpoint.IsLocal && s.isUnformatted(endpoint) {
+						unformatted = true
+					}
 					continue
 				}
-				disk, format, err := connectEndpoint(endpoint)
-				if err != nil {
-					printEndpointError(endpoint, err)
+				_, err := s.connectDisk(endpoint)
+				if err == errUnformattedDisk {
+					unformatted = true
 					continue
 				}
-				s.formatMu.RLock()
-				i, j, err := findDiskIndex(s.format, format)
-				s.formatMu.RUnlock()
 				if err != nil {
 					printEndpointError(endpoint, err)
-					continue
 				}
-				s.xlDisksMu.Lock()
-				s.xlDisks[i][j] = disk
-				s.xlDisksMu.Unlock()
+			}
+			if unformatted {
+				s.formatReplacedDisks()
 			}
 		}
 	}
 }
 
+// connectDisk - connects to the endpoint and places its disk in the
+// set topology, returns the index of its set.
+func (s *xlSets) connectDisk(endpoint Endpoint) (int, error) {
+	disk, format, err := connectEndpoint(endpoint)
+	if err != nil {
+		return -1, err
+	}
+	s.formatMu.RLock()
+	i, j, err := findDiskIndex(s.format, format)
+	s.formatMu.RUnlock()
+	if err != nil {
+		disk.Close()
+		return -1, err
+	}
+
+	s.xlDisksMu.Lock()
+	defer s.xlDisksMu.Unlock()
+	// A replaced disk may take another position of the set.
+	for k, oldDisk := range s.xlDisks[i] {
+		if oldDisk != nil && oldDisk.String() == endpoint.String() {
+			oldDisk.Close()
+			s.xlDisks[i][k] = nil
+		}
+	}
+	s.xlDisks[i][j] = disk
+	return i, nil
+}
+
+// formatReplacedDisks - writes the format of fresh disks which replaced
+// failed disks, and starts healing the objects of their sets onto them.
+func (s *xlSets) formatReplacedDisks() {
+	res, err := s.HealFormat(false)
+	if err != nil {
+		errorIf(err, "Unable to format replaced disks.")
+		return
+	}
+	for k, drive := range res.Before.Drives {
+		if drive.State != madmin.DriveStateMissing || res.After.Drives[k].State != madmin.DriveStateOk {
+			continue
+		}
+		setIndex, err := s.connectDisk(s.endpoints[k])
+		if err != nil {
+			printEndpointError(s.endpoints[k], err)
+			continue
+		}
+		globalDriveHealState.Start(s.sets[setIndex], s.endpoints[k].String(), setIndex)
+	}
+}
+
 // GetDisks returns a closure for a given set, which provides list of disks per set.
 func (s *xlSets) GetDisks(setIndex int) func() []StorageAPI {
 	return func() []StorageAPI {
@@ -177,10 +250,41 @@ func (s *xlSets) GetDisks(setIndex int) func() []StorageAPI {
 	}
 }
 
+// isWriteQuorumOnline - returns true if enough disks of every set are
+// online for write quorum.
+func (s *xlSets) isWriteQuorumOnline() bool {
+	for _, set := range s.sets {
+		if !set.isWriteQuorumOnline() {
+			return false
+		}
+	}
+	return true
+}
+
 const defaultMonitorConnectEndpointInterval = time.Second * 10 // Set to 10 secs.
 
 // Initialize new set of erasure coded sets.
 func newXLSets(endpoints EndpointList, format *formatXLV2, setCount int, drivesPerSet int) (ObjectLayer, error) {
+	s := initXLSets(endpoints, format, setCount, drivesPerSet)
+
+	// Initialize and load bucket policies.
+	var err error
+	s.bucketPolicies, err = initBucketPolicies(s)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize a new event notifier.
+	if err := initEventNotifier(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Initializes the erasure coded sets and connects to their disks,
+// bucket policies and notifications are left to the caller.
+func initXLSets(endpoints EndpointList, format *formatXLV2, setCount int, drivesPerSet int) *xlSets {
 
 	// Initialize the XL sets instance.
 	s := &xlSets{
@@ -192,6 +296,7 @@ func newXLSets(endpoints EndpointList, format *formatXLV2, setCount int, drivesP
 		format:             format,
 		disksConnectDoneCh: make(chan struct{}),
 		distributionAlgo:   format.XL.DistributionAlgo,
+		pools:              globalStoragePools,
 		listPool:           newTreeWalkPool(globalLookupTimeout),
 	}
 
@@ -207,36 +312,35 @@ func newXLSets(endpoints EndpointList, format *formatXLV2, setCount int, drivesP
 		}
 	}
 
-	for _, endpoint := range endpoints {
-		disk, nformat, err := connectEndpoint(endpoint)
-		if err != nil {
-			errorIf(err, "Unable to connect to endpoint %s", endpoint)
+	// Connect to all disks in parallel, disks are placed in their set
+	// once all are connected.
+	disks := make([]StorageAPI, len(endpoints))
+	formats := make([]*formatXLV2, len(endpoints))
+	errs := runBootTasks(bootStageConnectDisks, len(endpoints), 0, func(index int) (err error) {
+		disks[index], formats[index], err = connectEndpoint(endpoints[index])
+		return err
+	})
+	for index, endpoint := range endpoints {
+		if errs[index] != nil {
+			errorIf(errs[index], "Unable to connect to endpoint %s", endpoint)
 			continue
 		}
-		i, j, err := findDiskIndex(format, nformat)
+		i, j, err := findDiskIndex(format, formats[index])
 		if err != nil {
 			errorIf(err, "Unable to find the endpoint %s in reference format", endpoint)
 			continue
 		}
-		s.xlDisks[i][j] = disk
-	}
-
-	// Initialize and load bucket policies.
-	var err error
-	s.bucketPolicies, err = initBucketPolicies(s)
-	if err != nil {
-		return nil, err
-	}
-
-	// Initialize a new event notifier.
-	if err := initEventNotifier(s); err != nil {
-		return nil, err
+		s.xlDisks[i][j] = disks[index]
 	}
 
 	// Start the disk monitoring and connect routine.
 	go s.monitorAndConnectEndpoints(globalServiceDoneCh, defaultMonitorConnectEndpointInterval)
 
-	return s, nil
+	// The temporary upload area is cleared upon start, keep sweeping
+	// it for files left by failed uploads.
+	go sweepTmpRoutine(s.sweepTmp, tmpSweepInterval, tmpSweepExpiry, globalServiceDoneCh)
+
+	return s
 }
 
 // StorageInfo - combines output of StorageInfo across all erasure coded object sets.
@@ -401,6 +505,110 @@ func (s *xlSets) getHashedSet(input string) (set *xlObjects) {
 	return s.sets[hashKey(s.distributionAlgo, input, len(s.sets))]
 }
 
+// Returns always a same erasure coded set of the pool for a given
+// input, hashed among all sets if the pool is "".
+func (s *xlSets) getHashedPoolSet(pool, input string) (set *xlObjects) {
+	sets := s.pools.Sets[pool]
+	if len(sets) == 0 {
+		return s.getHashedSet(input)
+	}
+	return s.sets[sets[hashKey(s.distributionAlgo, input, len(sets))]-1]
+}
+
+// Returns the erasure coded set an object is written to, in the pool
+// of its bucket and storage class.
+func (s *xlSets) getPlacedSet(bucket, object string, metadata map[string]string) (set *xlObjects) {
+	return s.getHashedPoolSet(s.pools.getPool(bucket, metadata[amzStorageClass]), object)
+}
+
+// Returns the erasure coded sets an object may be on, the set it is
+// written to without a storage class first. Objects are looked up in
+// every pool and among all sets, they stay readable when pools change.
+func (s *xlSets) getObjectSets(bucket, object string) (sets []*xlObjects) {
+	sets = append(sets, s.getPlacedSet(bucket, object, nil))
+	for _, pool := range append(s.pools.getLabels(), "") {
+		set := s.getHashedPoolSet(pool, object)
+		found := false
+		for _, other := range sets {
+			found = found || other == set
+		}
+		if !found {
+			sets = append(sets, set)
+		}
+	}
+	return sets
+}
+
+// Returns the info of the newest copy of an object and the erasure
+// coded set holding it, among the sets the object may be on. A set
+// which cannot be read with quorum may hold a newer copy, its error is
+// returned instead of treating the object as absent there.
+func (s *xlSets) getNewestObject(bucket, object string) (objInfo ObjectInfo, set *xlObjects, err error) {
+	sets := s.getObjectSets(bucket, object)
+	for _, other := range sets {
+		info, oerr := other.getObjectInfo(bucket, object)
+		if oerr != nil {
+			if isErrObjectNotFound(oerr) {
+				continue
+			}
+			return objInfo, nil, oerr
+		}
+		if set == nil || info.ModTime.After(objInfo.ModTime) {
+			objInfo, set = info, other
+		}
+	}
+	if set == nil {
+		return objInfo, sets[0], errors.Trace(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	return objInfo, set, nil
+}
+
+// Returns the erasure coded set holding the newest copy of an object,
+// the set it is written to without a storage class if none does.
+func (s *xlSets) getObjectSet(bucket, object string) (set *xlObjects, err error) {
+	sets := s.getObjectSets(bucket, object)
+	if len(sets) == 1 {
+		return sets[0], nil
+	}
+	_, set, err = s.getNewestObject(bucket, object)
+	if err != nil && !isErrObjectNotFound(err) {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Returns the erasure coded set holding a multipart upload, the set
+// objects are written to without a storage class if none does.
+func (s *xlSets) getUploadSet(bucket, object, uploadID string) (set *xlObjects) {
+	sets := s.getObjectSets(bucket, object)
+	if len(sets) > 1 {
+		for _, set = range sets {
+			if set.isUploadIDExists(bucket, object, uploadID) {
+				return set
+			}
+		}
+	}
+	return sets[0]
+}
+
+// Deletes the copies of an object written to another erasure coded set
+// before, when it moved to another pool. Copies written at or after
+// modTime, the mtime of the copy on set, are newer and kept. Copies
+// which cannot be removed fail the write, they would be read instead
+// of the new object once they are newer.
+func (s *xlSets) deleteOtherObjects(bucket, object string, set *xlObjects, modTime time.Time) error {
+	for _, other := range s.getObjectSets(bucket, object) {
+		if other == set {
+			continue
+		}
+		if err := other.deleteOlderObject(bucket, object, modTime); err != nil {
+			errorIf(err, "Unable to delete the previous copy of %s/%s", bucket, object)
+			return err
+		}
+	}
+	return nil
+}
+
 // GetBucketInfo - returns bucket info from one of the erasure coded set.
 func (s *xlSets) GetBucketInfo(bucket string) (bucketInfo BucketInfo, err error) {
 	return s.getHashedSet(bucket).GetBucketInfo(bucket)
@@ -408,7 +616,11 @@ func (s *xlSets) GetBucketInfo(bucket string) (bucketInfo BucketInfo, err error)
 
 // ListObjectsV2 lists all objects in bucket filtered by prefix
 func (s *xlSets) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, fetchOwner bool, startAfter string) (result ListObjectsV2Info, err error) {
-	loi, err := s.ListObjects(bucket, prefix, continuationToken, delimiter, maxKeys)
+	marker := continuationToken
+	if marker == "" {
+		marker = startAfter
+	}
+	loi, err := s.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
 	if err != nil {
 		return result, err
 	}
@@ -469,6 +681,20 @@ func (s *xlSets) IsEncryptionSupported() bool {
 // even if one of the sets fail to delete buckets, we proceed to
 // undo a successful operation.
 func (s *xlSets) DeleteBucket(bucket string) error {
+	if err := s.deleteBucket(bucket); err != nil {
+		return err
+	}
+	invalidateXLBucketMetadata(bucket)
+
+	// Delete all bucket metadata.
+	deleteBucketMetadata(bucket, s)
+
+	// Success.
+	return nil
+}
+
+// Deletes a bucket on all sets, leaving its metadata.
+func (s *xlSets) deleteBucket(bucket string) error {
 	g := errgroup.WithNErrs(len(s.sets))
 
 	// Delete buckets in parallel across all sets.
@@ -491,10 +717,6 @@ func (s *xlSets) DeleteBucket(bucket string) error {
 		}
 	}
 
-	// Delete all bucket metadata.
-	deleteBucketMetadata(bucket, s)
-
-	// Success.
 	return nil
 }
 
@@ -525,36 +747,156 @@ func (s *xlSets) ListBuckets() (buckets []BucketInfo, err error) {
 
 // --- Object Operations ---
 
-// GetObject - reads an object from the hashedSet based on the object name.
+// GetObject - reads an object from the hashedSet holding its newest copy.
 func (s *xlSets) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer, etag string) error {
-	return s.getHashedSet(object).GetObject(bucket, object, startOffset, length, writer, etag)
+	set, err := s.getObjectSet(bucket, object)
+	if err != nil {
+		return err
+	}
+	return set.GetObject(bucket, object, startOffset, length, writer, etag)
 }
 
-// PutObject - writes an object to hashedSet based on the object name.
+// PutObject - writes an object to hashedSet based on the object name,
+// in the pool of its bucket and storage class.
 func (s *xlSets) PutObject(bucket string, object string, data *hash.Reader, metadata map[string]string) (objInfo ObjectInfo, err error) {
-	return s.getHashedSet(object).PutObject(bucket, object, data, metadata)
+	set := s.getPlacedSet(bucket, object, metadata)
+	objInfo, err = set.PutObject(bucket, object, data, metadata)
+	if err != nil {
+		return objInfo, err
+	}
+	err = s.deleteOtherObjects(bucket, object, set, objInfo.ModTime)
+	invalidateXLMetadata(bucket, object)
+	return objInfo, err
 }
 
-// GetObjectInfo - reads object metadata from the hashedSet based on the object name.
+// GetObjectInfo - reads object metadata from the hashedSet holding its
+// newest copy, or from the metadata cache if enabled.
 func (s *xlSets) GetObjectInfo(bucket, object string) (objInfo ObjectInfo, err error) {
-	return s.getHashedSet(object).GetObjectInfo(bucket, object)
+	objInfo, version, ok := globalXLMetadataCache.get(s, bucket, object)
+	if ok {
+		return objInfo, nil
+	}
+	set, err := s.getObjectSet(bucket, object)
+	if err != nil {
+		return objInfo, err
+	}
+	objInfo, err = set.GetObjectInfo(bucket, object)
+	if err == nil {
+		globalXLMetadataCache.add(version, s, objInfo)
+	}
+	return objInfo, err
 }
 
-// DeleteObject - deletes an object from the hashedSet based on the object name.
+// DeleteObject - deletes an object from every hashedSet holding a copy,
+// an older copy left behind would be read instead.
 func (s *xlSets) DeleteObject(bucket string, object string) (err error) {
-	return s.getHashedSet(object).DeleteObject(bucket, object)
+	deleted := false
+	for _, set := range s.getObjectSets(bucket, object) {
+		if derr := set.DeleteObject(bucket, object); derr != nil {
+			if isErrObjectNotFound(derr) {
+				err = derr
+				continue
+			}
+			if deleted {
+				invalidateXLMetadata(bucket, object)
+			}
+			return derr
+		}
+		deleted = true
+	}
+	if !deleted {
+		return err
+	}
+	invalidateXLMetadata(bucket, object)
+	return nil
+}
+
+// DeleteObjects - deletes objects grouped by the hashedSet holding
+// them, the sets delete their batches in parallel. Objects which may be
+// on several sets, when pools are configured, are deleted one by one.
+func (s *xlSets) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	errs := make([]error, len(objects))
+
+	// Indexes of the objects held by every set.
+	setIndexes := make(map[*xlObjects][]int)
+	for i, object := range objects {
+		sets := s.getObjectSets(bucket, object)
+		if len(sets) > 1 {
+			errs[i] = s.DeleteObject(bucket, object)
+			continue
+		}
+		setIndexes[sets[0]] = append(setIndexes[sets[0]], i)
+	}
+
+	var mu sync.Mutex
+	var batchErr error
+	var deleted []string
+	var wg sync.WaitGroup
+	for set, indexes := range setIndexes {
+		wg.Add(1)
+		go func(set *xlObjects, indexes []int) {
+			defer wg.Done()
+			setObjects := make([]string, len(indexes))
+			for j, i := range indexes {
+				setObjects[j] = objects[i]
+			}
+			setErrs, err := set.DeleteObjects(bucket, setObjects)
+			if err != nil {
+				mu.Lock()
+				batchErr = err
+				mu.Unlock()
+				return
+			}
+			mu.Lock()
+			for j, i := range indexes {
+				errs[i] = setErrs[j]
+				if setErrs[j] == nil {
+					deleted = append(deleted, objects[i])
+				}
+			}
+			mu.Unlock()
+		}(set, indexes)
+	}
+	wg.Wait()
+
+	invalidateXLMetadata(bucket, deleted...)
+
+	if batchErr != nil {
+		return nil, batchErr
+	}
+	return errs, nil
 }
 
 // CopyObject - copies objects from one hashedSet to another hashedSet, on server side.
 func (s *xlSets) CopyObject(srcBucket, srcObject, destBucket, destObject string, srcInfo ObjectInfo) (objInfo ObjectInfo, err error) {
-	srcSet := s.getHashedSet(srcObject)
-	destSet := s.getHashedSet(destObject)
+	srcSet, err := s.getObjectSet(srcBucket, srcObject)
+	if err != nil {
+		return objInfo, err
+	}
+	destSet := s.getPlacedSet(destBucket, destObject, srcInfo.UserDefined)
 
-	// Check if this request is only metadata update.
+	// Check if this request is only metadata update, objects moving
+	// to another pool are copied.
 	cpSrcDstSame := isStringEqual(pathJoin(srcBucket, srcObject), pathJoin(destBucket, destObject))
-	if cpSrcDstSame && srcInfo.metadataOnly {
-		return srcSet.CopyObject(srcBucket, srcObject, destBucket, destObject, srcInfo)
+	if cpSrcDstSame && srcInfo.metadataOnly && srcSet == destSet {
+		objInfo, err = srcSet.CopyObject(srcBucket, srcObject, destBucket, destObject, srcInfo)
+		if err != nil {
+			return objInfo, err
+		}
+	} else {
+		objInfo, err = s.copyObject(srcSet, destSet, srcBucket, srcObject, destBucket, destObject, srcInfo, cpSrcDstSame)
+		if err != nil {
+			return objInfo, err
+		}
+		err = s.deleteOtherObjects(destBucket, destObject, destSet, objInfo.ModTime)
 	}
+	invalidateXLMetadata(destBucket, destObject)
+	return objInfo, err
+}
+
+// Copies an object between hashedSets, locks the destination and the
+// source if they are different objects.
+func (s *xlSets) copyObject(srcSet, destSet *xlObjects, srcBucket, srcObject, destBucket, destObject string, srcInfo ObjectInfo, cpSrcDstSame bool) (objInfo ObjectInfo, err error) {
 
 	// Hold write lock on destination since in both cases
 	// - if source and destination are same
@@ -693,7 +1035,12 @@ func (s *xlSets) ListObjects(bucket, prefix, marker, delimiter string, maxKeys i
 			entry = strings.TrimSuffix(entry, slashSeparator)
 			// Verify if we are at the leaf, a leaf is where we
 			// see `xl.json` inside a directory.
-			return s.getHashedSet(entry).isObject(bucket, entry)
+			for _, set := range s.getObjectSets(bucket, entry) {
+				if set.isObject(bucket, entry) {
+					return true
+				}
+			}
+			return false
 		}
 
 		var setDisks = make([][]StorageAPI, len(s.sets))
@@ -727,7 +1074,7 @@ func (s *xlSets) ListObjects(bucket, prefix, marker, delimiter string, maxKeys i
 		} else {
 			// Set the Mode to a "regular" file.
 			var err error
-			objInfo, err = s.getHashedSet(entry).getObjectInfo(bucket, entry)
+			objInfo, _, err = s.getNewestObject(bucket, entry)
 			if err != nil {
 				// Ignore errFileNotFound as the object might have got
 				// deleted in the interim period of listing and getObjectInfo(),
@@ -768,20 +1115,31 @@ func (s *xlSets) ListObjects(bucket, prefix, marker, delimiter string, maxKeys i
 func (s *xlSets) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (result ListMultipartsInfo, err error) {
 	// In list multipart uploads we are going to treat input prefix as the object,
 	// this means that we are not supporting directory navigation.
-	return s.getHashedSet(prefix).ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
+	// Uploads are listed from the first hashedSet having any.
+	for _, set := range s.getObjectSets(bucket, prefix) {
+		result, err = set.ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
+		if err != nil || len(result.Uploads) > 0 {
+			break
+		}
+	}
+	return result, err
 }
 
-// Initiate a new multipart upload on a hashedSet based on object name.
+// Initiate a new multipart upload on a hashedSet based on object name,
+// in the pool of its bucket and storage class.
 func (s *xlSets) NewMultipartUpload(bucket, object string, metadata map[string]string) (uploadID string, err error) {
-	return s.getHashedSet(object).NewMultipartUpload(bucket, object, metadata)
+	return s.getPlacedSet(bucket, object, metadata).NewMultipartUpload(bucket, object, metadata)
 }
 
 // Copies a part of an object from source hashedSet to destination hashedSet.
 func (s *xlSets) CopyObjectPart(srcBucket, srcObject, destBucket, destObject string, uploadID string, partID int,
 	startOffset int64, length int64, srcInfo ObjectInfo) (partInfo PartInfo, err error) {
 
-	srcSet := s.getHashedSet(srcObject)
-	destSet := s.getHashedSet(destObject)
+	srcSet, err := s.getObjectSet(srcBucket, srcObject)
+	if err != nil {
+		return partInfo, err
+	}
+	destSet := s.getUploadSet(destBucket, destObject, uploadID)
 
 	// Initialize pipe to stream from source.
 	pipeReader, pipeWriter := io.Pipe()
@@ -803,7 +1161,7 @@ func (s *xlSets) CopyObjectPart(srcBucket, srcObject, destBucket, destObject str
 		return partInfo, toObjectErr(errors.Trace(err), destBucket, destObject)
 	}
 
-	partInfo, err = destSet.PutObjectPart(destBucket, destObject, uploadID, partID, hashReader)
+	partInfo, err = destSet.PutObjectPart(context.Background(), destBucket, destObject, uploadID, partID, hashReader)
 	if err != nil {
 		pipeReader.CloseWithError(err)
 		return partInfo, err
@@ -816,23 +1174,30 @@ func (s *xlSets) CopyObjectPart(srcBucket, srcObject, destBucket, destObject str
 }
 
 // PutObjectPart - writes part of an object to hashedSet based on the object name.
-func (s *xlSets) PutObjectPart(bucket, object, uploadID string, partID int, data *hash.Reader) (info PartInfo, err error) {
-	return s.getHashedSet(object).PutObjectPart(bucket, object, uploadID, partID, data)
+func (s *xlSets) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, data *hash.Reader) (info PartInfo, err error) {
+	return s.getUploadSet(bucket, object, uploadID).PutObjectPart(ctx, bucket, object, uploadID, partID, data)
 }
 
 // ListObjectParts - lists all uploaded parts to an object in hashedSet.
 func (s *xlSets) ListObjectParts(bucket, object, uploadID string, partNumberMarker int, maxParts int) (result ListPartsInfo, err error) {
-	return s.getHashedSet(object).ListObjectParts(bucket, object, uploadID, partNumberMarker, maxParts)
+	return s.getUploadSet(bucket, object, uploadID).ListObjectParts(bucket, object, uploadID, partNumberMarker, maxParts)
 }
 
 // Aborts an in-progress multipart operation on hashedSet based on the object name.
 func (s *xlSets) AbortMultipartUpload(bucket, object, uploadID string) error {
-	return s.getHashedSet(object).AbortMultipartUpload(bucket, object, uploadID)
+	return s.getUploadSet(bucket, object, uploadID).AbortMultipartUpload(bucket, object, uploadID)
 }
 
 // CompleteMultipartUpload - completes a pending multipart transaction, on hashedSet based on object name.
-func (s *xlSets) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []CompletePart) (objInfo ObjectInfo, err error) {
-	return s.getHashedSet(object).CompleteMultipartUpload(bucket, object, uploadID, uploadedParts)
+func (s *xlSets) CompleteMultipartUpload(ctx context.Context, bucket, object, uploadID string, uploadedParts []CompletePart) (objInfo ObjectInfo, err error) {
+	set := s.getUploadSet(bucket, object, uploadID)
+	objInfo, err = set.CompleteMultipartUpload(ctx, bucket, object, uploadID, uploadedParts)
+	if err != nil {
+		return objInfo, err
+	}
+	err = s.deleteOtherObjects(bucket, object, set, objInfo.ModTime)
+	invalidateXLMetadata(bucket, object)
+	return objInfo, err
 }
 
 /*
@@ -1122,9 +1487,14 @@ func (s *xlSets) HealBucket(bucket string, dryRun bool) (results []madmin.HealRe
 	return results, nil
 }
 
-// HealObject - heals inconsistent object on a hashedSet based on object name.
+// HealObject - heals inconsistent object on the hashedSet holding its
+// newest copy.
 func (s *xlSets) HealObject(bucket, object string, dryRun bool) (madmin.HealResultItem, error) {
-	return s.getHashedSet(object).HealObject(bucket, object, dryRun)
+	set, err := s.getObjectSet(bucket, object)
+	if err != nil {
+		return madmin.HealResultItem{}, err
+	}
+	return set.HealObject(bucket, object, dryRun)
 }
 
 // Lists all buckets which need healing.
@@ -1243,7 +1613,12 @@ func (s *xlSets) listObjectsHeal(bucket, prefix, marker, delimiter string, maxKe
 			entry = strings.TrimSuffix(entry, slashSeparator)
 			// Verify if we are at the leaf, a leaf is where we
 			// see `xl.json` inside a directory.
-			return s.getHashedSet(entry).isObject(bucket, entry)
+			for _, set := range s.getObjectSets(bucket, entry) {
+				if set.isObject(bucket, entry) {
+					return true
+				}
+			}
+			return false
 		}
 
 		var setDisks = make([][]StorageAPI, len(s.sets))
@@ -1278,7 +1653,7 @@ func (s *xlSets) listObjectsHeal(bucket, prefix, marker, delimiter string, maxKe
 			objInfo.IsDir = true
 		} else {
 			var err error
-			objInfo, err = s.getHashedSet(entry).getObjectInfo(bucket, entry)
+			objInfo, _, err = s.getNewestObject(bucket, entry)
 			if err != nil {
 				// Ignore errFileNotFound
 				if errors.Cause(err) == errFileNotFound {
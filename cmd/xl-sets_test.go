@@ -17,6 +17,8 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -189,3 +191,155 @@ func TestHashedLayer(t *testing.T) {
 		}
 	}
 }
+
+// TestXLSetsPools - tests objects are placed on the pools of their
+// bucket and storage class, and found when they move.
+func TestXLSetsPools(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	var objs []*xlObjects
+	for i := 0; i < 4; i++ {
+		obj, fsDirs, err := prepareXL16()
+		if err != nil {
+			t.Fatal("Unable to initialize 'XL' object layer.", err)
+		}
+		for _, dir := range fsDirs {
+			defer os.RemoveAll(dir)
+		}
+		for _, bucket := range []string{"bucket", "archive"} {
+			if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+		objs = append(objs, obj.(*xlObjects))
+	}
+
+	sets := &xlSets{
+		sets:             objs,
+		distributionAlgo: "CRCMOD",
+		listPool:         newTreeWalkPool(globalLookupTimeout),
+	}
+	put := func(bucket, object string, metadata map[string]string) {
+		content := []byte("hello")
+		if _, err := sets.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader(content), int64(len(content)), "", ""), metadata); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Returns the sets the object is on, counting from 1.
+	placement := func(bucket, object string) (found []int) {
+		for i, obj := range objs {
+			if obj.isObject(bucket, object) {
+				found = append(found, i+1)
+			}
+		}
+		return found
+	}
+
+	// Objects written before pools are configured are hashed among all sets.
+	put("bucket", "object", nil)
+	if found := placement("bucket", "object"); len(found) != 1 || objs[found[0]-1] != sets.getHashedSet("object") {
+		t.Fatalf("Expected object on its hashed set, found on %v", found)
+	}
+
+	sets.pools = storagePoolsConfig{
+		Sets:     map[string][]int{"ssd": {1, 2}, "hdd": {3, 4}},
+		Standard: "ssd",
+		RRS:      "hdd",
+		Buckets:  []bucketPoolConfig{{Bucket: "archive*", Pool: "hdd"}},
+	}
+	inPool := func(found []int, pool ...int) bool {
+		return len(found) == 1 && (found[0] == pool[0] || found[0] == pool[1])
+	}
+
+	if _, err = sets.GetObjectInfo("bucket", "object"); err != nil {
+		t.Fatalf("Expected objects to be found after configuring pools, got %v", err)
+	}
+	put("bucket", "object", nil)
+	if found := placement("bucket", "object"); !inPool(found, 1, 2) {
+		t.Errorf("Expected a standard object on ssd only, found on %v", found)
+	}
+	put("bucket", "object", map[string]string{amzStorageClass: reducedRedundancyStorageClass})
+	if found := placement("bucket", "object"); !inPool(found, 3, 4) {
+		t.Errorf("Expected a reduced redundancy object on hdd only, found on %v", found)
+	}
+	objInfo, err := sets.GetObjectInfo("bucket", "object")
+	if err != nil || objInfo.UserDefined[amzStorageClass] != reducedRedundancyStorageClass {
+		t.Errorf("Unexpected object info %+v, %v", objInfo, err)
+	}
+	put("archive", "object", nil)
+	if found := placement("archive", "object"); !inPool(found, 3, 4) {
+		t.Errorf("Expected an archive object on hdd only, found on %v", found)
+	}
+
+	uploadID, err := sets.NewMultipartUpload("bucket", "multipart", map[string]string{amzStorageClass: reducedRedundancyStorageClass})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := bytes.Repeat([]byte("a"), 5)
+	partInfo, err := sets.PutObjectPart(context.Background(), "bucket", "multipart", uploadID, 1, mustGetHashReader(t, bytes.NewReader(content), int64(len(content)), "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploads, err := sets.ListMultipartUploads("bucket", "multipart", "", "", "", 10)
+	if err != nil || len(uploads.Uploads) != 1 {
+		t.Errorf("Expected the upload to be listed, got %+v, %v", uploads, err)
+	}
+	if _, err = sets.CompleteMultipartUpload(context.Background(), "bucket", "multipart", uploadID, []CompletePart{{PartNumber: 1, ETag: partInfo.ETag}}); err != nil {
+		t.Fatal(err)
+	}
+	if found := placement("bucket", "multipart"); !inPool(found, 3, 4) {
+		t.Errorf("Expected a reduced redundancy multipart object on hdd only, found on %v", found)
+	}
+
+	result, err := sets.ListObjects("bucket", "", "", "", 10)
+	if err != nil || len(result.Objects) != 2 {
+		t.Errorf("Expected 2 objects to be listed, got %+v, %v", result, err)
+	}
+	for _, object := range []string{"object", "multipart"} {
+		if err = sets.DeleteObject("bucket", object); err != nil {
+			t.Error(err)
+		}
+		if found := placement("bucket", object); len(found) != 0 {
+			t.Errorf("Expected %s to be deleted, found on %v", object, found)
+		}
+	}
+
+	// A newer copy written on another set is read instead of the copy
+	// on the set of the object's pool.
+	put("bucket", "object", nil)
+	hdd := sets.getHashedPoolSet("hdd", "object")
+	newer := []byte("hello world")
+	if _, err = hdd.PutObject("bucket", "object", mustGetHashReader(t, bytes.NewReader(newer), int64(len(newer)), "", ""), nil); err != nil {
+		t.Fatal(err)
+	}
+	if objInfo, err = sets.GetObjectInfo("bucket", "object"); err != nil || objInfo.Size != int64(len(newer)) {
+		t.Errorf("Expected the newest copy to be read, got %+v, %v", objInfo, err)
+	}
+
+	// A set which cannot be read may hold a newer copy, it is not
+	// treated as holding none.
+	getDisks := hdd.getDisks
+	hdd.getDisks = func() []StorageAPI { return make([]StorageAPI, len(getDisks())) }
+	if _, err = sets.GetObjectInfo("bucket", "object"); err == nil {
+		t.Errorf("Expected reading an object with a copy on an offline set to fail")
+	}
+	if _, err = sets.PutObject("bucket", "object", mustGetHashReader(t, bytes.NewReader(content), int64(len(content)), "", ""), nil); err == nil {
+		t.Errorf("Expected a write failing to remove the copy on an offline set to fail")
+	}
+	hdd.getDisks = getDisks
+
+	// Deleting removes every copy.
+	if err = sets.DeleteObject("bucket", "object"); err != nil {
+		t.Error(err)
+	}
+	if found := placement("bucket", "object"); len(found) != 0 {
+		t.Errorf("Expected object to be deleted, found on %v", found)
+	}
+	if err = sets.DeleteObject("bucket", "object"); !isErrObjectNotFound(err) {
+		t.Errorf("Expected %v, got %v", ObjectNotFound{Bucket: "bucket", Object: "object"}, err)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"sync"
+
+	"go.uber.org/atomic"
+)
+
+// Objects waiting to be healed after reads found them missing or
+// corrupted on some disks, objects are dropped while the queue is full
+// and queued again by the next read.
+const healOnReadQueueSize = 1000
+
+// healOnReadItem - object queued for healing.
+type healOnReadItem struct {
+	xl     xlObjects
+	bucket string
+	object string
+}
+
+// healOnReadQueue - heals objects in the background which reads found
+// missing or corrupted on some disks, instead of waiting for the next
+// heal of the bucket.
+type healOnReadQueue struct {
+	// Counters first to keep them 64-bit aligned.
+	queued  atomic.Uint64
+	healed  atomic.Uint64
+	failed  atomic.Uint64
+	dropped atomic.Uint64
+
+	mutex   sync.Mutex
+	pending map[string]struct{}
+	queue   chan healOnReadItem
+}
+
+func newHealOnReadQueue(size int) *healOnReadQueue {
+	return &healOnReadQueue{
+		pending: make(map[string]struct{}),
+		queue:   make(chan healOnReadItem, size),
+	}
+}
+
+// Global queue of objects healed on read, nil unless started by the server.
+var globalHealOnRead *healOnReadQueue
+
+// Queue - queues object for healing without blocking, objects already
+// waiting to be healed are not queued again.
+func (q *healOnReadQueue) Queue(xl xlObjects, bucket, object string) {
+	key := pathJoin(bucket, object)
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if _, ok := q.pending[key]; ok {
+		return
+	}
+	select {
+	case q.queue <- healOnReadItem{xl: xl, bucket: bucket, object: object}:
+		q.pending[key] = struct{}{}
+		q.queued.Inc()
+	default:
+		q.dropped.Inc()
+	}
+}
+
+// heal - heals a queued object.
+func (q *healOnReadQueue) heal(item healOnReadItem) {
+	_, err := item.xl.HealObject(item.bucket, item.object, false)
+	if err != nil {
+		errorIf(err, "Unable to heal %s/%s on read.", item.bucket, item.object)
+		q.failed.Inc()
+	} else {
+		q.healed.Inc()
+	}
+
+	q.mutex.Lock()
+	delete(q.pending, pathJoin(item.bucket, item.object))
+	q.mutex.Unlock()
+}
+
+// Start - heals queued objects one at a time until doneCh is closed.
+func (q *healOnReadQueue) Start(doneCh <-chan struct{}) {
+	go func() {
+		for {
+			select {
+			case item := <-q.queue:
+				q.heal(item)
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+}
+
+// Return heal on read stats.
+func (q *healOnReadQueue) toServerHealOnReadStats() ServerHealOnReadStats {
+	if q == nil {
+		return ServerHealOnReadStats{}
+	}
+	return ServerHealOnReadStats{
+		Queued:  q.queued.Load(),
+		Healed:  q.healed.Load(),
+		Failed:  q.failed.Load(),
+		Dropped: q.dropped.Load(),
+	}
+}
+
+// queueHealOnRead - queues object for healing if the server heals on
+// read and accepts writes.
+func queueHealOnRead(xl xlObjects, bucket, object string) {
+	if globalHealOnRead == nil || checkServerWritable() != nil {
+		return
+	}
+	globalHealOnRead.Queue(xl, bucket, object)
+}
+
+// isXLMetaHealRequired - returns true if `xl.json` of an object is
+// missing, corrupted or outdated on any disk which is online.
+func isXLMetaHealRequired(onlineDisks []StorageAPI, errs []error) bool {
+	for i, err := range errs {
+		if isErrHealRequired(err) {
+			return true
+		}
+		// Disks with outdated metadata are not online.
+		if err == nil && onlineDisks[i] == nil {
+			return true
+		}
+	}
+	return false
+}
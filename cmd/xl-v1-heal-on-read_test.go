@@ -0,0 +1,135 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests that objects read with missing parts or metadata are queued
+// for healing and healed.
+func TestHealOnReadXL(t *testing.T) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	nDisks := 16
+	fsDirs, err := getRandomDisks(nDisks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	obj, _, err := initObjectLayer(mustGetNewEndpointList(fsDirs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	globalHealOnRead = newHealOnReadQueue(healOnReadQueueSize)
+	defer func() { globalHealOnRead = nil }()
+
+	bucket := "bucket"
+	object := "object"
+	data := bytes.Repeat([]byte("a"), 1024*1024)
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("Failed to make a bucket - %v", err)
+	}
+	_, err = obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil)
+	if err != nil {
+		t.Fatalf("Failed to put an object - %v", err)
+	}
+
+	getObject := func() {
+		if gerr := obj.GetObject(bucket, object, 0, int64(len(data)), ioutil.Discard, ""); gerr != nil {
+			t.Fatalf("Failed to get an object - %v", gerr)
+		}
+	}
+
+	// Healthy objects are not queued.
+	getObject()
+	if stats := globalHealOnRead.toServerHealOnReadStats(); stats.Queued != 0 {
+		t.Fatalf("Expected no objects queued, got %#v", stats)
+	}
+
+	// Remove the part of the first data block, which is always read.
+	xl := obj.(*xlObjects)
+	xlMeta, err := readXLMeta(xl.storageDisks[0], bucket, object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dataDisk StorageAPI
+	for index, blockIndex := range xlMeta.Erasure.Distribution {
+		if blockIndex == 1 {
+			dataDisk = xl.storageDisks[index]
+		}
+	}
+	if err = dataDisk.DeleteFile(bucket, filepath.Join(object, "part.1")); err != nil {
+		t.Fatalf("Failed to delete a file - %v", err)
+	}
+
+	// Objects already queued are not queued again.
+	getObject()
+	getObject()
+	if stats := globalHealOnRead.toServerHealOnReadStats(); stats.Queued != 1 {
+		t.Fatalf("Expected one object queued, got %#v", stats)
+	}
+
+	globalHealOnRead.heal(<-globalHealOnRead.queue)
+	if stats := globalHealOnRead.toServerHealOnReadStats(); stats.Healed != 1 || stats.Failed != 0 {
+		t.Fatalf("Expected one object healed, got %#v", stats)
+	}
+	if _, err = dataDisk.StatFile(bucket, filepath.Join(object, "part.1")); err != nil {
+		t.Fatalf("Expected the part to be healed - %v", err)
+	}
+
+	// Missing metadata is healed as well.
+	if err = xl.storageDisks[0].DeleteFile(bucket, filepath.Join(object, xlMetaJSONFile)); err != nil {
+		t.Fatalf("Failed to delete a file - %v", err)
+	}
+	getObject()
+	if stats := globalHealOnRead.toServerHealOnReadStats(); stats.Queued != 2 {
+		t.Fatalf("Expected two objects queued, got %#v", stats)
+	}
+
+	// Objects are not queued while the server does not accept writes.
+	globalHealOnRead.heal(<-globalHealOnRead.queue)
+	if err = dataDisk.DeleteFile(bucket, filepath.Join(object, "part.1")); err != nil {
+		t.Fatalf("Failed to delete a file - %v", err)
+	}
+	globalIsReadOnly = true
+	getObject()
+	globalIsReadOnly = false
+	if stats := globalHealOnRead.toServerHealOnReadStats(); stats.Queued != 2 {
+		t.Fatalf("Expected no objects queued in read-only mode, got %#v", stats)
+	}
+}
+
+// Tests that objects are dropped while the heal on read queue is full.
+func TestHealOnReadQueueFull(t *testing.T) {
+	q := newHealOnReadQueue(1)
+	q.Queue(xlObjects{}, "bucket", "object1")
+	q.Queue(xlObjects{}, "bucket", "object2")
+	if stats := q.toServerHealOnReadStats(); stats.Queued != 1 || stats.Dropped != 1 {
+		t.Fatalf("Unexpected stats %#v", stats)
+	}
+}
@@ -162,6 +162,11 @@ func disksWithAllParts(onlineDisks []StorageAPI, partsMetadata []xlMetaV1, errs
 			continue
 		}
 
+		// Data of small objects is verified in `xl.json`.
+		if len(partsMetadata[i].Data) > 0 {
+			onlineDisk = &xlInlineDisk{StorageAPI: onlineDisk, data: partsMetadata[i].Data}
+		}
+
 		// disk has a valid xl.json but may not have all the
 		// parts. This is considered an outdated disk, since
 		// it needs healing too.
@@ -189,7 +194,7 @@ func disksWithAllParts(onlineDisks []StorageAPI, partsMetadata []xlMetaV1, errs
 
 		if dataErrs[i] == nil {
 			// All parts verified, mark it as all data available.
-			availableDisks[i] = onlineDisk
+			availableDisks[i] = onlineDisks[i]
 		}
 	}
 
@@ -190,6 +190,10 @@ func TestListOnlineDisks(t *testing.T) {
 		},
 	}
 
+	// Part files are tampered with below, store the object in them.
+	defer func(threshold int64) { globalXLInlineThreshold = threshold }(globalXLInlineThreshold)
+	globalXLInlineThreshold = 0
+
 	bucket := "bucket"
 	object := "object"
 	data := bytes.Repeat([]byte("a"), 1024)
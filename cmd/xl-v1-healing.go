@@ -394,6 +394,13 @@ func healObject(storageDisks []StorageAPI, bucket string, object string,
 	// outDatedDisks[index]
 	checksumInfos := make([][]ChecksumInfo, len(outDatedDisks))
 
+	// Data of small objects is read from and healed into `xl.json`.
+	healDisks := outDatedDisks
+	if len(latestMeta.Data) > 0 {
+		latestDisks = newXLInlineDisks(latestDisks, partsMetadata)
+		healDisks = newXLInlineDisks(outDatedDisks, nil)
+	}
+
 	// Heal each part. erasureHealFile() will write the healed
 	// part to .minio/tmp/uuid/ which needs to be renamed later to
 	// the final location.
@@ -416,7 +423,7 @@ func healObject(storageDisks []StorageAPI, bucket string, object string,
 			}
 		}
 		// Heal the part file.
-		file, hErr := storage.HealFile(outDatedDisks, bucket, pathJoin(object, partName),
+		file, hErr := storage.HealFile(healDisks, bucket, pathJoin(object, partName),
 			erasure.BlockSize, minioMetaTmpBucket, pathJoin(tmpID, partName), partSize,
 			algorithm, checksums)
 		if hErr != nil {
@@ -432,6 +439,7 @@ func healObject(storageDisks []StorageAPI, bucket string, object string,
 			// a healed part checksum had a write error.
 			if file.Checksums[i] == nil {
 				outDatedDisks[i] = nil
+				healDisks[i] = nil
 				disksToHealCount--
 				continue
 			}
@@ -453,6 +461,7 @@ func healObject(storageDisks []StorageAPI, bucket string, object string,
 		}
 		partsMetadata[index] = latestMeta
 		partsMetadata[index].Erasure.Checksums = checksumInfos[index]
+		partsMetadata[index].Data = inlineData(healDisks[index])
 	}
 
 	// Generate and write `xl.json` generated from other disks.
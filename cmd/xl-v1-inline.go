@@ -0,0 +1,138 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	// Environment variable setting the size up to which objects are
+	// stored inline in `xl.json`, 0 turns it off.
+	xlInlineThresholdEnv = "MINIO_XL_INLINE_THRESHOLD"
+
+	// Objects up to this size are stored inline by default.
+	defaultXLInlineThreshold = 128 * humanize.KiByte
+
+	// Inlined data is read along with every `xl.json`, by listings
+	// and stats as well, keep it small.
+	maxXLInlineThreshold = 128 * humanize.KiByte
+)
+
+// Parses the size up to which objects are stored inline.
+func parseXLInlineThreshold(value string) (int64, error) {
+	threshold, err := humanize.ParseBytes(value)
+	if err != nil {
+		return 0, err
+	}
+	if threshold > maxXLInlineThreshold {
+		return 0, fmt.Errorf("threshold cannot exceed %s", humanize.IBytes(maxXLInlineThreshold))
+	}
+	return int64(threshold), nil
+}
+
+// Sets the size up to which objects are stored inline from the environment.
+func handleXLInlineThresholdEnv() {
+	if value := os.Getenv(xlInlineThresholdEnv); value != "" {
+		threshold, err := parseXLInlineThreshold(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", xlInlineThresholdEnv)
+		globalXLInlineThreshold = threshold
+	}
+}
+
+// isXLInline - reports whether an object of size is stored inline.
+// Empty objects keep their empty part file.
+func isXLInline(size int64) bool {
+	return size > 0 && size <= globalXLInlineThreshold
+}
+
+// xlInlineDisk - a disk whose part files are kept in memory, the erasure
+// coded data of an object stored inline in its `xl.json` on that disk.
+// Appended data is collected to be stored inline, reads are served from
+// the inlined data. All other calls go to the disk.
+type xlInlineDisk struct {
+	StorageAPI
+	data []byte
+}
+
+// newXLInlineDisks - wraps all online disks, the data of each disk is
+// taken from the metadata at the same index.
+func newXLInlineDisks(disks []StorageAPI, metaArr []xlMetaV1) []StorageAPI {
+	inlineDisks := make([]StorageAPI, len(disks))
+	for i, disk := range disks {
+		if disk == OfflineDisk {
+			continue
+		}
+		var data []byte
+		if metaArr != nil {
+			data = metaArr[i].Data
+		}
+		inlineDisks[i] = &xlInlineDisk{StorageAPI: disk, data: data}
+	}
+	return inlineDisks
+}
+
+// inlineData - returns the data appended to an inline disk.
+func inlineData(disk StorageAPI) []byte {
+	if inlineDisk, ok := disk.(*xlInlineDisk); ok {
+		return inlineDisk.data
+	}
+	return nil
+}
+
+// PrepareFile - nothing to allocate for inlined data.
+func (d *xlInlineDisk) PrepareFile(volume, path string, length int64) error {
+	return nil
+}
+
+// AppendFile - collects data to be stored inline.
+func (d *xlInlineDisk) AppendFile(volume, path string, buf []byte) error {
+	d.data = append(d.data, buf...)
+	return nil
+}
+
+// ReadFile - reads inlined data at offset, verifying all of it on the
+// first read like reading a part file does.
+func (d *xlInlineDisk) ReadFile(volume, path string, offset int64, buffer []byte, verifier *BitrotVerifier) (int64, error) {
+	if len(d.data) == 0 {
+		return 0, errFileNotFound
+	}
+	if verifier != nil && !verifier.IsVerified() {
+		if _, err := verifier.Write(d.data); err != nil {
+			return 0, err
+		}
+		if !verifier.Verify() {
+			return 0, hashMismatchError{hex.EncodeToString(verifier.sum), hex.EncodeToString(verifier.Sum(nil))}
+		}
+	}
+	if offset > int64(len(d.data)) {
+		return 0, io.EOF
+	}
+	n := copy(buffer, d.data[offset:])
+	if n < len(buffer) {
+		if n == 0 {
+			return 0, io.EOF
+		}
+		return int64(n), io.ErrUnexpectedEOF
+	}
+	return int64(n), nil
+}
@@ -240,6 +240,9 @@ type xlMetaV1 struct {
 	Meta map[string]string `json:"meta,omitempty"`
 	// Captures all the individual object `xl.json`.
 	Parts []objectPartInfo `json:"parts,omitempty"`
+	// Erasure coded data of small objects for this disk, stored
+	// in place of the part file.
+	Data []byte `json:"data,omitempty"`
 }
 
 // XL metadata constants.
@@ -250,6 +253,10 @@ const (
 	// XL meta version.
 	xlMetaVersion100 = "1.0.0"
 
+	// XL meta version of objects stored inline, older servers reject
+	// it instead of looking for part files which do not exist.
+	xlMetaVersionInline = "1.0.2"
+
 	// XL meta format string.
 	xlMetaFormat = "xl"
 
@@ -282,7 +289,7 @@ func (m xlMetaV1) IsValid() bool {
 // Verifies if the backend format metadata is sane by validating
 // the version string and format style.
 func isXLMetaFormatValid(version, format string) bool {
-	return ((version == xlMetaVersion || version == xlMetaVersion100) &&
+	return ((version == xlMetaVersion || version == xlMetaVersion100 || version == xlMetaVersionInline) &&
 		format == xlMetaFormat)
 }
 
@@ -312,6 +319,12 @@ func (m xlMetaV1) ToObjectInfo(bucket, object string) ObjectInfo {
 	// response headers. e.g, X-Minio-* or X-Amz-*.
 	objInfo.UserDefined = cleanMetadata(m.Meta)
 
+	// Parts of a regular upload have no etag, only
+	// report parts of multipart uploads.
+	if len(m.Parts) > 0 && m.Parts[0].ETag != "" {
+		objInfo.Parts = m.Parts
+	}
+
 	// Success.
 	return objInfo
 }
@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"os"
 	"path"
@@ -151,7 +152,7 @@ func testXLReadMetaParts(obj ObjectLayer, instanceType string, disks []string, t
 	sha256sum := ""
 	// Iterating over creatPartCases to generate multipart chunks.
 	for _, testCase := range createPartCases {
-		_, perr := obj.PutObjectPart(testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
+		_, perr := obj.PutObjectPart(context.Background(), testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, mustGetHashReader(t, bytes.NewBufferString(testCase.inputReaderData), testCase.intputDataSize, testCase.inputMd5, sha256sum))
 		if perr != nil {
 			t.Fatalf("%s : %s", instanceType, perr)
 		}
@@ -382,6 +383,7 @@ func TestIsXLMetaFormatValid(t *testing.T) {
 		{4, xlMetaVersion100, "hello", false},
 		{5, xlMetaVersion, xlMetaFormat, true},
 		{6, xlMetaVersion100, xlMetaFormat, true},
+		{7, xlMetaVersionInline, xlMetaFormat, true},
 	}
 	for _, tt := range tests {
 		if got := isXLMetaFormatValid(tt.version, tt.format); got != tt.want {
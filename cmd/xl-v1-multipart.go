@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -617,7 +618,7 @@ func (xl xlObjects) CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject, u
 		return pi, toObjectErr(err, dstBucket, dstObject)
 	}
 
-	partInfo, err := xl.PutObjectPart(dstBucket, dstObject, uploadID, partID, hashReader)
+	partInfo, err := xl.PutObjectPart(context.Background(), dstBucket, dstObject, uploadID, partID, hashReader)
 	if err != nil {
 		return pi, toObjectErr(err, dstBucket, dstObject)
 	}
@@ -634,7 +635,7 @@ func (xl xlObjects) CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject, u
 // of the multipart transaction.
 //
 // Implements S3 compatible Upload Part API.
-func (xl xlObjects) PutObjectPart(bucket, object, uploadID string, partID int, data *hash.Reader) (pi PartInfo, e error) {
+func (xl xlObjects) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, data *hash.Reader) (pi PartInfo, e error) {
 	if err := checkPutObjectPartArgs(bucket, object, xl); err != nil {
 		return pi, err
 	}
@@ -721,7 +722,9 @@ func (xl xlObjects) PutObjectPart(bucket, object, uploadID string, partID int, d
 	buffer := xl.bp.Get()
 	defer xl.bp.Put(buffer)
 
-	file, err := storage.CreateFile(data, minioMetaTmpBucket, tmpPartPath, buffer, DefaultBitrotAlgorithm, writeQuorum)
+	// Stop writing the part once the client disconnects, the
+	// temporary part is removed on return.
+	file, err := storage.CreateFile(contextReader{ctx, data}, minioMetaTmpBucket, tmpPartPath, buffer, DefaultBitrotAlgorithm, writeQuorum)
 	if err != nil {
 		return pi, toObjectErr(err, bucket, object)
 	}
@@ -914,7 +917,7 @@ func (xl xlObjects) ListObjectParts(bucket, object, uploadID string, partNumberM
 // md5sums of all the parts.
 //
 // Implements S3 compatible Complete multipart API.
-func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, uploadID string, parts []CompletePart) (oi ObjectInfo, e error) {
+func (xl xlObjects) CompleteMultipartUpload(ctx context.Context, bucket string, object string, uploadID string, parts []CompletePart) (oi ObjectInfo, e error) {
 	if err := checkCompleteMultipartArgs(bucket, object, xl); err != nil {
 		return oi, err
 	}
@@ -924,6 +927,12 @@ func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, upload
 		return oi, err
 	}
 	defer destLock.Unlock()
+
+	// Locked objects cannot be overwritten.
+	if err := xl.checkObjectLocked(bucket, object); err != nil {
+		return oi, err
+	}
+
 	// Hold lock so that
 	//
 	// 1) no one aborts this multipart upload
@@ -1051,6 +1060,12 @@ func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, upload
 		partsMetadata[index].Parts = xlMeta.Parts
 	}
 
+	// Keep the upload if the client disconnected while waiting for
+	// the locks, it can be completed again.
+	if err = checkContext(ctx); err != nil {
+		return oi, err
+	}
+
 	// Write unique `xl.json` for each disk.
 	if onlineDisks, err = writeUniqueXLMetadata(onlineDisks, minioMetaTmpBucket, tempUploadIDPath, partsMetadata, writeQuorum); err != nil {
 		return oi, toObjectErr(err, minioMetaTmpBucket, tempUploadIDPath)
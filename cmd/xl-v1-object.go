@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/minio/minio/pkg/errors"
 	"github.com/minio/minio/pkg/hash"
@@ -107,17 +108,15 @@ func (xl xlObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string
 	// Reorder online disks based on erasure distribution order.
 	onlineDisks = shuffleDisks(onlineDisks, xlMeta.Erasure.Distribution)
 
-	// Length of the file to read.
-	length := xlMeta.Stat.Size
-
 	// Check if this request is only metadata update.
-	if cpSrcDstSame {
+	if cpSrcDstSame && srcInfo.metadataOnly {
 		xlMeta.Meta = srcInfo.UserDefined
-		partsMetadata := make([]xlMetaV1, len(xl.getDisks()))
-		// Update `xl.json` content on each disks.
-		for index := range partsMetadata {
-			partsMetadata[index] = xlMeta
+		// Update `xl.json` content on each disks, each disk
+		// retains its own erasure index and checksums.
+		for index := range metaArr {
+			metaArr[index].Meta = srcInfo.UserDefined
 		}
+		partsMetadata := shufflePartsMetadata(metaArr, xlMeta.Erasure.Distribution)
 
 		tempObj := mustGetUUID()
 
@@ -132,32 +131,27 @@ func (xl xlObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string
 		return xlMeta.ToObjectInfo(srcBucket, srcObject), nil
 	}
 
-	// Initialize pipe.
-	pipeReader, pipeWriter := io.Pipe()
-
+	// Content is read through srcInfo.Writer and written from
+	// srcInfo.Reader, which may decrypt or encrypt it on the way.
 	go func() {
-		var startOffset int64 // Read the whole file.
-		if gerr := xl.getObject(srcBucket, srcObject, startOffset, length, pipeWriter, srcInfo.ETag); gerr != nil {
-			errorIf(gerr, "Unable to read %s of the object `%s/%s`.", srcBucket, srcObject)
-			pipeWriter.CloseWithError(toObjectErr(gerr, srcBucket, srcObject))
+		if gerr := xl.getObject(srcBucket, srcObject, 0, srcInfo.Size, srcInfo.Writer, srcInfo.ETag); gerr != nil {
+			if gerr = srcInfo.Writer.Close(); gerr != nil {
+				errorIf(gerr, "Unable to read the object %s/%s.", srcBucket, srcObject)
+			}
+			return
+		}
+		// Close writer explicitly signalling we wrote all data.
+		if gerr := srcInfo.Writer.Close(); gerr != nil {
+			errorIf(gerr, "Unable to read the object %s/%s.", srcBucket, srcObject)
 			return
 		}
-		pipeWriter.Close() // Close writer explicitly signalling we wrote all data.
 	}()
 
-	hashReader, err := hash.NewReader(pipeReader, length, "", "")
-	if err != nil {
-		return oi, toObjectErr(errors.Trace(err), dstBucket, dstObject)
-	}
-
-	objInfo, err := xl.putObject(dstBucket, dstObject, hashReader, srcInfo.UserDefined)
+	objInfo, err := xl.putObject(dstBucket, dstObject, srcInfo.Reader, srcInfo.UserDefined)
 	if err != nil {
 		return oi, toObjectErr(err, dstBucket, dstObject)
 	}
 
-	// Explicitly close the reader.
-	pipeReader.Close()
-
 	return objInfo, nil
 }
 
@@ -222,12 +216,25 @@ func (xl xlObjects) getObject(bucket, object string, startOffset int64, length i
 		return err
 	}
 
+	// Objects pending validation cannot be read.
+	if isObjectQuarantined(xlMeta.Meta) {
+		return errors.Trace(ObjectQuarantined{Bucket: bucket, Object: object})
+	}
+
+	// Heal the object once read if it is missing or corrupted on any disk.
+	healRequired := isXLMetaHealRequired(onlineDisks, errs)
+
 	// Reorder online disks based on erasure distribution order.
 	onlineDisks = shuffleDisks(onlineDisks, xlMeta.Erasure.Distribution)
 
 	// Reorder parts metadata based on erasure distribution order.
 	metaArr = shufflePartsMetadata(metaArr, xlMeta.Erasure.Distribution)
 
+	// Data of small objects is read from `xl.json` of every disk.
+	if len(xlMeta.Data) > 0 {
+		onlineDisks = newXLInlineDisks(onlineDisks, metaArr)
+	}
+
 	// For negative length read everything.
 	if length < 0 {
 		length = xlMeta.Stat.Size - startOffset
@@ -256,14 +263,11 @@ func (xl xlObjects) getObject(bucket, object string, startOffset int64, length i
 		return errors.Trace(InvalidRange{startOffset, length, xlMeta.Stat.Size})
 	}
 
-	var totalBytesRead int64
-	storage, err := NewErasureStorage(onlineDisks, xlMeta.Erasure.DataBlocks, xlMeta.Erasure.ParityBlocks, xlMeta.Erasure.BlockSize)
-	if err != nil {
-		return toObjectErr(err, bucket, object)
-	}
-	checksums := make([][]byte, len(storage.disks))
+	// Collect the ranges of all parts to read.
+	var parts []xlPartRead
+	var totalLength int64
 	for ; partIndex <= lastPartIndex; partIndex++ {
-		if length == totalBytesRead {
+		if length == totalLength {
 			break
 		}
 		// Save the current part name and size.
@@ -272,33 +276,44 @@ func (xl xlObjects) getObject(bucket, object string, startOffset int64, length i
 
 		readSize := partSize - partOffset
 		// readSize should be adjusted so that we don't write more data than what was requested.
-		if readSize > (length - totalBytesRead) {
-			readSize = length - totalBytesRead
+		if readSize > (length - totalLength) {
+			readSize = length - totalLength
 		}
 
 		// Get the checksums of the current part.
-		var algorithm BitrotAlgorithm
-		for index, disk := range storage.disks {
+		part := xlPartRead{
+			name:      partName,
+			size:      partSize,
+			offset:    partOffset,
+			length:    readSize,
+			checksums: make([][]byte, len(onlineDisks)),
+		}
+		for index, disk := range onlineDisks {
 			if disk == OfflineDisk {
 				continue
 			}
 			checksumInfo := metaArr[index].Erasure.GetChecksumInfo(partName)
-			algorithm = checksumInfo.Algorithm
-			checksums[index] = checksumInfo.Hash
-		}
-
-		file, err := storage.ReadFile(writer, bucket, pathJoin(object, partName), partOffset, readSize, partSize, checksums, algorithm, xlMeta.Erasure.BlockSize)
-		if err != nil {
-			return toObjectErr(err, bucket, object)
+			part.algorithm = checksumInfo.Algorithm
+			part.checksums[index] = checksumInfo.Hash
 		}
-
-		// Track total bytes read from disk and written to the client.
-		totalBytesRead += file.Size
+		parts = append(parts, part)
+		totalLength += readSize
 
 		// partOffset will be valid only for the first part, hence reset it to 0 for
 		// the remaining parts.
 		partOffset = 0
-	} // End of read all parts loop.
+	} // End of collect all parts loop.
+
+	// Read all parts, multipart objects are read several parts at once.
+	_, partsHealRequired, err := readXLParts(writer, onlineDisks, xlMeta, bucket, object, parts, globalXLReadAhead)
+	if err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	healRequired = healRequired || partsHealRequired
+
+	if healRequired {
+		queueHealOnRead(xl, bucket, object)
+	}
 
 	// Return success.
 	return nil
@@ -413,6 +428,11 @@ func (xl xlObjects) getObjectInfo(bucket, object string) (objInfo ObjectInfo, er
 	// response headers. e.g, X-Minio-* or X-Amz-*.
 	objInfo.UserDefined = cleanMetadata(xlMeta.Meta)
 
+	// Only report parts of multipart uploads.
+	if len(xlMeta.Parts) > 0 && xlMeta.Parts[0].ETag != "" {
+		objInfo.Parts = xlMeta.Parts
+	}
+
 	// Success.
 	return objInfo, nil
 }
@@ -523,8 +543,24 @@ func (xl xlObjects) PutObject(bucket string, object string, data *hash.Reader, m
 	return xl.putObject(bucket, object, data, metadata)
 }
 
+// checkObjectLocked - fails if the existing object is protected from
+// overwrites and deletes, must be called with the object locked.
+func (xl xlObjects) checkObjectLocked(bucket, object string) error {
+	return checkObjectLockAtRest(bucket, object, func() (ObjectInfo, error) {
+		if !xl.isObject(bucket, object) {
+			return ObjectInfo{}, errors.Trace(ObjectNotFound{Bucket: bucket, Object: object})
+		}
+		return xl.getObjectInfo(bucket, object)
+	})
+}
+
 // putObject wrapper for xl PutObject
 func (xl xlObjects) putObject(bucket string, object string, data *hash.Reader, metadata map[string]string) (objInfo ObjectInfo, err error) {
+	// Locked objects cannot be overwritten.
+	if err = xl.checkObjectLocked(bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
+
 	uniqueID := mustGetUUID()
 	tempObj := uniqueID
 
@@ -604,7 +640,15 @@ func (xl xlObjects) putObject(bucket string, object string, data *hash.Reader, m
 	// Total size of the written object
 	var sizeWritten int64
 
-	storage, err := NewErasureStorage(onlineDisks, xlMeta.Erasure.DataBlocks, xlMeta.Erasure.ParityBlocks, xlMeta.Erasure.BlockSize)
+	// Small objects are erasure coded in memory and stored inline in
+	// `xl.json`, saving the write of a part file on every disk.
+	inline := isXLInline(data.Size())
+	storageDisks := onlineDisks
+	if inline {
+		storageDisks = newXLInlineDisks(onlineDisks, nil)
+	}
+
+	storage, err := NewErasureStorage(storageDisks, xlMeta.Erasure.DataBlocks, xlMeta.Erasure.ParityBlocks, xlMeta.Erasure.BlockSize)
 	if err != nil {
 		return ObjectInfo{}, toObjectErr(err, bucket, object)
 	}
@@ -630,7 +674,7 @@ func (xl xlObjects) putObject(bucket string, object string, data *hash.Reader, m
 		// Hint the filesystem to pre-allocate one continuous large block.
 		// This is only an optimization.
 		var curPartReader io.Reader
-		if curPartSize > 0 {
+		if curPartSize > 0 && !inline {
 			pErr := xl.prepareFile(minioMetaTmpBucket, tempErasureObj, curPartSize, storage.disks, xlMeta.Erasure.BlockSize, xlMeta.Erasure.DataBlocks, writeQuorum)
 			if pErr != nil {
 				return ObjectInfo{}, toObjectErr(pErr, bucket, object)
@@ -672,6 +716,9 @@ func (xl xlObjects) putObject(bucket string, object string, data *hash.Reader, m
 	// Save additional erasureMetadata.
 	modTime := UTCNow()
 	metadata["etag"] = hex.EncodeToString(data.MD5Current())
+	if etag, movedTime, ok := popMovedObjectMetadata(metadata); ok {
+		metadata["etag"], modTime = etag, movedTime
+	}
 
 	// Guess content-type from the extension if possible.
 	if metadata["content-type"] == "" {
@@ -704,6 +751,10 @@ func (xl xlObjects) putObject(bucket string, object string, data *hash.Reader, m
 		partsMetadata[index].Meta = metadata
 		partsMetadata[index].Stat.Size = sizeWritten
 		partsMetadata[index].Stat.ModTime = modTime
+		if inline {
+			partsMetadata[index].Version = xlMetaVersionInline
+			partsMetadata[index].Data = inlineData(storage.disks[index])
+		}
 	}
 
 	// Write unique `xl.json` for each disk.
@@ -820,6 +871,11 @@ func (xl xlObjects) DeleteObject(bucket, object string) (err error) {
 		return errors.Trace(ObjectNotFound{bucket, object})
 	} // else proceed to delete the object.
 
+	// Locked objects cannot be deleted.
+	if err = xl.checkObjectLocked(bucket, object); err != nil {
+		return err
+	}
+
 	// Delete the object on all disks.
 	if err = xl.deleteObject(bucket, object); err != nil {
 		return toObjectErr(err, bucket, object)
@@ -829,9 +885,171 @@ func (xl xlObjects) DeleteObject(bucket, object string) (err error) {
 	return nil
 }
 
+// deleteOlderObject - deletes the object unless it was written at or
+// after modTime, its mtime is checked under the object lock so that a
+// copy written concurrently is not lost. Used to delete copies of an
+// object written on another zone or erasure coded set.
+func (xl xlObjects) deleteOlderObject(bucket, object string, modTime time.Time) error {
+	objectLock := xl.nsMutex.NewNSLock(bucket, object)
+	if err := objectLock.GetLock(globalOperationTimeout); err != nil {
+		return err
+	}
+	defer objectLock.Unlock()
+
+	objInfo, err := xl.getObjectInfo(bucket, object)
+	if err != nil {
+		// A copy which cannot be read with quorum may be newer.
+		if isErrObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !objInfo.ModTime.Before(modTime) {
+		return nil
+	}
+	if err = xl.checkObjectLocked(bucket, object); err != nil {
+		return err
+	}
+	if err = xl.deleteObject(bucket, object); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	return nil
+}
+
+// DeleteObjects - deletes a batch of objects, returns the error of
+// every object. All objects are locked at once and every disk reads
+// and deletes the objects in turn, instead of a set of routines per
+// object.
+func (xl xlObjects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	errs := make([]error, len(objects))
+	var lockPaths []string
+	for i, object := range objects {
+		if errs[i] = checkDelObjArgs(bucket, object); errs[i] == nil {
+			lockPaths = append(lockPaths, object)
+		}
+	}
+	if len(lockPaths) == 0 {
+		return errs, nil
+	}
+
+	// Acquire write locks of all the objects before deleting them.
+	objectLocks := xl.nsMutex.NewNSLocks(bucket, lockPaths)
+	if err := objectLocks.GetLock(globalOperationTimeout); err != nil {
+		return nil, err
+	}
+	defer objectLocks.Unlock()
+
+	// Directories are deleted on their own, as DeleteObject does.
+	for i, object := range objects {
+		if errs[i] == nil && hasSuffix(object, slashSeparator) {
+			if err := xl.deleteObject(bucket, object); err != nil {
+				errs[i] = toObjectErr(err, bucket, object)
+			}
+		}
+	}
+
+	disks := xl.getDisks()
+
+	// Read `xl.json` of all the objects, every disk in its own routine.
+	metaArrs := make([][]xlMetaV1, len(objects))
+	metaErrs := make([][]error, len(objects))
+	for i := range objects {
+		metaArrs[i] = make([]xlMetaV1, len(disks))
+		metaErrs[i] = make([]error, len(disks))
+	}
+	xl.forEachDisk(disks, func(index int, disk StorageAPI) {
+		for i, object := range objects {
+			if errs[i] != nil {
+				continue
+			}
+			if disk == nil {
+				metaErrs[i][index] = errDiskNotFound
+				continue
+			}
+			metaArrs[i][index], metaErrs[i][index] = readXLMeta(disk, bucket, object)
+		}
+	})
+
+	writeQuorums := make([]int, len(objects))
+	for i, object := range objects {
+		if errs[i] != nil {
+			continue
+		}
+		if !isXLMetaFound(metaErrs[i]) {
+			errs[i] = errors.Trace(ObjectNotFound{bucket, object})
+			continue
+		}
+		var err error
+		if _, writeQuorums[i], err = objectQuorumFromMeta(xl, metaArrs[i], metaErrs[i]); err != nil {
+			errs[i] = toObjectErr(err, bucket, object)
+			continue
+		}
+		// Locked objects cannot be deleted.
+		errs[i] = xl.checkObjectLocked(bucket, object)
+	}
+
+	// Delete the objects, every disk in its own routine.
+	delErrs := make([][]error, len(objects))
+	for i := range objects {
+		delErrs[i] = make([]error, len(disks))
+	}
+	xl.forEachDisk(disks, func(index int, disk StorageAPI) {
+		for i, object := range objects {
+			if errs[i] != nil {
+				continue
+			}
+			if disk == nil {
+				delErrs[i][index] = errors.Trace(errDiskNotFound)
+				continue
+			}
+			if err := cleanupDir(disk, bucket, object); err != nil && errors.Cause(err) != errVolumeNotFound {
+				delErrs[i][index] = err
+			}
+		}
+	})
+
+	for i, object := range objects {
+		if errs[i] != nil {
+			continue
+		}
+		if err := reduceWriteQuorumErrs(delErrs[i], objectOpIgnoredErrs, writeQuorums[i]); err != nil {
+			errs[i] = toObjectErr(err, bucket, object)
+		}
+	}
+	return errs, nil
+}
+
+// Runs fn for every disk in its own routine, waits for all of them.
+func (xl xlObjects) forEachDisk(disks []StorageAPI, fn func(index int, disk StorageAPI)) {
+	var wg sync.WaitGroup
+	for index, disk := range disks {
+		wg.Add(1)
+		go func(index int, disk StorageAPI) {
+			defer wg.Done()
+			fn(index, disk)
+		}(index, disk)
+	}
+	wg.Wait()
+}
+
+// Returns true if `xl.json` was read on any disk, or failed for
+// reasons other than the object not being there. Mirrors isObject.
+func isXLMetaFound(errs []error) bool {
+	for _, err := range errs {
+		if err == nil || !errors.IsErrIgnored(err, xlTreeWalkIgnoredErrs...) {
+			return true
+		}
+	}
+	return false
+}
+
 // ListObjectsV2 lists all blobs in bucket filtered by prefix
 func (xl xlObjects) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, fetchOwner bool, startAfter string) (result ListObjectsV2Info, err error) {
-	loi, err := xl.ListObjects(bucket, prefix, continuationToken, delimiter, maxKeys)
+	marker := continuationToken
+	if marker == "" {
+		marker = startAfter
+	}
+	loi, err := xl.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
 	if err != nil {
 		return result, err
 	}
@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -54,12 +55,12 @@ func TestRepeatPutObjectPart(t *testing.T) {
 	}
 	fiveMBBytes := bytes.Repeat([]byte("a"), 5*humanize.MiByte)
 	md5Hex := getMD5Hash(fiveMBBytes)
-	_, err = objLayer.PutObjectPart("bucket1", "mpartObj1", uploadID, 1, mustGetHashReader(t, bytes.NewReader(fiveMBBytes), 5*humanize.MiByte, md5Hex, ""))
+	_, err = objLayer.PutObjectPart(context.Background(), "bucket1", "mpartObj1", uploadID, 1, mustGetHashReader(t, bytes.NewReader(fiveMBBytes), 5*humanize.MiByte, md5Hex, ""))
 	if err != nil {
 		t.Fatal(err)
 	}
 	// PutObjectPart should succeed even if part already exists. ref: https://github.com/minio/minio/issues/1930
-	_, err = objLayer.PutObjectPart("bucket1", "mpartObj1", uploadID, 1, mustGetHashReader(t, bytes.NewReader(fiveMBBytes), 5*humanize.MiByte, md5Hex, ""))
+	_, err = objLayer.PutObjectPart(context.Background(), "bucket1", "mpartObj1", uploadID, 1, mustGetHashReader(t, bytes.NewReader(fiveMBBytes), 5*humanize.MiByte, md5Hex, ""))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -162,7 +163,62 @@ func TestXLDeleteObjectDiskNotFound(t *testing.T) {
 	removeRoots(fsDirs)
 }
 
+func TestXLDeleteObjects(t *testing.T) {
+	// Reset global storage class flags
+	resetGlobalStorageEnvs()
+	obj, fsDirs, err := prepareXL16()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(*xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	objects := []string{"obj1", "dir/obj2", "doesnotexist", "", "obj1"}
+	for _, object := range objects[:2] {
+		_, err = obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader([]byte("abcd")), int64(len("abcd")), "", ""), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// for a 16 disk setup, quorum is 9. Deletes succeed with disks
+	// missing as long as quorum is available.
+	for i := range xl.storageDisks[:7] {
+		xl.storageDisks[i] = newNaughtyDisk(xl.storageDisks[i], nil, errFaultyDisk)
+	}
+
+	errs, err := obj.DeleteObjects(bucket, objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedErrs := []error{
+		nil,
+		nil,
+		ObjectNotFound{Bucket: bucket, Object: "doesnotexist"},
+		ObjectNameInvalid{Bucket: bucket, Object: ""},
+		nil,
+	}
+	for i, expectedErr := range expectedErrs {
+		if actualErr := errors.Cause(errs[i]); actualErr != expectedErr {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, expectedErr, actualErr)
+		}
+	}
+	for _, object := range objects[:2] {
+		if _, err = obj.GetObjectInfo(bucket, object); !isErrObjectNotFound(err) {
+			t.Errorf("Expected %s to be deleted, got %v", object, err)
+		}
+	}
+}
+
 func TestGetObjectNoQuorum(t *testing.T) {
+	// Disks fail while reading part files, store the object in them.
+	defer func(threshold int64) { globalXLInlineThreshold = threshold }(globalXLInlineThreshold)
+	globalXLInlineThreshold = 0
+
 	// Create an instance of xl backend.
 	obj, fsDirs, err := prepareXL16()
 	if err != nil {
@@ -264,6 +320,76 @@ func TestPutObjectNoQuorum(t *testing.T) {
 	removeRoots(fsDirs)
 }
 
+// Tests that copying an object onto itself only updates the metadata
+// and each disk keeps its own erasure index and checksums.
+func TestXLCopyObjectMetadataOnly(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Failed to initialize test config %v", err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	obj, fsDirs, err := prepareXL16()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(*xlObjects)
+
+	bucket := "bucket"
+	object := "object"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 1*humanize.MiByte)
+	length := int64(len(data))
+	if _, err = rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader(data), length, "", ""), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	metaPreCopy, errs := readAllXLMetadata(xl.storageDisks, bucket, object)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Disk %d: %v", i+1, err)
+		}
+	}
+
+	srcInfo, err := obj.GetObjectInfo(bucket, object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcInfo.UserDefined["x-amz-meta-copied"] = "true"
+	srcInfo.metadataOnly = true
+	if _, err = obj.CopyObject(bucket, object, bucket, object, srcInfo); err != nil {
+		t.Fatal(err)
+	}
+
+	metaPostCopy, errs := readAllXLMetadata(xl.storageDisks, bucket, object)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Disk %d: %v", i+1, err)
+		}
+		if metaPostCopy[i].Meta["x-amz-meta-copied"] != "true" {
+			t.Errorf("Disk %d: expected the metadata to be updated", i+1)
+		}
+		if !reflect.DeepEqual(metaPostCopy[i].Erasure, metaPreCopy[i].Erasure) {
+			t.Errorf("Disk %d: expected erasure info %v, got %v", i+1, metaPreCopy[i].Erasure, metaPostCopy[i].Erasure)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, object, 0, length, &buffer, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Error("Expected the object content to be unchanged")
+	}
+}
+
 // Tests both object and bucket healing.
 func TestHealing(t *testing.T) {
 	rootPath, err := newTestConfig(globalMinioDefaultRegion)
@@ -369,3 +495,118 @@ func TestHealing(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// Tests storing small objects inline in `xl.json`, reading and healing them.
+func TestXLInlineObject(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Failed to initialize test config %v", err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	obj, fsDirs, err := prepareXL16()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(*xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	putObject := func(object string, size int) []byte {
+		data := make([]byte, size)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := obj.PutObject(bucket, object, mustGetHashReader(t, bytes.NewReader(data), int64(size), "", ""), nil); err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	hasPartFile := func(object string) bool {
+		_, err := os.Stat(path.Join(fsDirs[0], bucket, object, "part.1"))
+		return err == nil
+	}
+
+	// Objects above the threshold keep their part files.
+	putObject("large", int(globalXLInlineThreshold)+1)
+	if !hasPartFile("large") {
+		t.Fatal("Expected a part file for an object above the inline threshold")
+	}
+
+	object := "small"
+	data := putObject(object, 10*humanize.KiByte)
+	if hasPartFile(object) {
+		t.Fatal("Expected no part file for an object stored inline")
+	}
+	xlMetaPreHeal, err := readXLMeta(xl.storageDisks[0], bucket, object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(xlMetaPreHeal.Data) == 0 || xlMetaPreHeal.Version != xlMetaVersionInline {
+		t.Fatalf("Expected the data of the object in xl.json of version %s", xlMetaVersionInline)
+	}
+	if xlMetaLarge, err := readXLMeta(xl.storageDisks[0], bucket, "large"); err != nil || xlMetaLarge.Version != xlMetaVersion {
+		t.Fatalf("Expected xl.json of version %s for an object above the inline threshold, got %+v, %v", xlMetaVersion, xlMetaLarge, err)
+	}
+
+	expectContent := func(offset, length int64) {
+		var buffer bytes.Buffer
+		if err := obj.GetObject(bucket, object, offset, length, &buffer, ""); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buffer.Bytes(), data[offset:offset+length]) {
+			t.Fatalf("Unexpected content at offset %d, length %d", offset, length)
+		}
+	}
+	expectContent(0, int64(len(data)))
+	expectContent(1000, 5000)
+
+	// Remove the object from the first disk and heal it.
+	if err = os.RemoveAll(path.Join(fsDirs[0], bucket, object)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = xl.HealObject(bucket, object, false); err != nil {
+		t.Fatal(err)
+	}
+	xlMetaPostHeal, err := readXLMeta(xl.storageDisks[0], bucket, object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(xlMetaPreHeal, xlMetaPostHeal) {
+		t.Fatal("HealObject failed")
+	}
+	if hasPartFile(object) {
+		t.Fatal("Expected no part file after healing an object stored inline")
+	}
+	expectContent(0, int64(len(data)))
+}
+
+// Tests parsing the size up to which objects are stored inline.
+func TestParseXLInlineThreshold(t *testing.T) {
+	testCases := []struct {
+		value     string
+		threshold int64
+		success   bool
+	}{
+		{"0", 0, true},
+		{"128KiB", 128 * humanize.KiByte, true},
+		{"64KiB", 64 * humanize.KiByte, true},
+		{"1MiB", 0, false},
+		{"1GiB", 0, false},
+		{"-1", 0, false},
+		{"small", 0, false},
+	}
+	for i, testCase := range testCases {
+		threshold, err := parseXLInlineThreshold(testCase.value)
+		if testCase.success != (err == nil) {
+			t.Fatalf("Test %d: Expected success %v but got %v", i+1, testCase.success, err)
+		}
+		if testCase.success && threshold != testCase.threshold {
+			t.Fatalf("Test %d: Expected %d but got %d", i+1, testCase.threshold, threshold)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+)
+
+const (
+	// Environment variable setting the number of parts read at once.
+	xlReadAheadEnv = "MINIO_XL_READAHEAD"
+
+	// Parts of multipart objects read at once by default.
+	defaultXLReadAhead = 4
+)
+
+// errReadAheadCanceled - reading a part was canceled since the object
+// is no longer written to the client.
+var errReadAheadCanceled = errors.New("read ahead canceled")
+
+// Parses the number of parts read at once, 0 reads parts one by one.
+func parseXLReadAhead(value string) (int, error) {
+	readAhead, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if readAhead < 0 {
+		return 0, errors.New("read ahead cannot be negative")
+	}
+	return readAhead, nil
+}
+
+// Sets the number of parts read at once from the environment.
+func handleXLReadAheadEnv() {
+	if value := os.Getenv(xlReadAheadEnv); value != "" {
+		readAhead, err := parseXLReadAhead(value)
+		fatalIf(err, "Invalid value set in environment variable %s.", xlReadAheadEnv)
+		globalXLReadAhead = readAhead
+	}
+}
+
+// xlPartRead - range of a part read by GetObject.
+type xlPartRead struct {
+	name      string
+	size      int64
+	offset    int64
+	length    int64
+	checksums [][]byte
+	algorithm BitrotAlgorithm
+}
+
+// chunkWriter - sends a copy of every write to a channel, blocks while
+// the channel is full.
+type chunkWriter struct {
+	chunkCh chan<- []byte
+	doneCh  <-chan struct{}
+}
+
+func (w chunkWriter) Write(p []byte) (int, error) {
+	chunk := make([]byte, len(p))
+	copy(chunk, p)
+	select {
+	case w.chunkCh <- chunk:
+		return len(p), nil
+	case <-w.doneCh:
+		return 0, errReadAheadCanceled
+	}
+}
+
+// xlPartReadAhead - a part being read ahead of writing it to the client.
+type xlPartReadAhead struct {
+	chunkCh chan []byte
+
+	// Valid once chunkCh is closed.
+	file ErasureFileInfo
+	err  error
+}
+
+// readXLParts - reads the parts of an object and writes them in order
+// to writer. Multipart objects are read up to readAhead parts at once,
+// each part buffering up to one erasure block until it is written.
+// Returns the total size written and if any part was missing or
+// corrupted on some disks.
+func readXLParts(writer io.Writer, disks []StorageAPI, xlMeta xlMetaV1, bucket, object string, parts []xlPartRead, readAhead int) (totalBytesRead int64, healRequired bool, err error) {
+	if readAhead <= 1 || len(parts) <= 1 {
+		storage, err := NewErasureStorage(disks, xlMeta.Erasure.DataBlocks, xlMeta.Erasure.ParityBlocks, xlMeta.Erasure.BlockSize)
+		if err != nil {
+			return 0, false, err
+		}
+		for _, part := range parts {
+			file, err := storage.ReadFile(writer, bucket, pathJoin(object, part.name), part.offset, part.length, part.size, part.checksums, part.algorithm, xlMeta.Erasure.BlockSize)
+			if err != nil {
+				return totalBytesRead, healRequired, err
+			}
+			totalBytesRead += file.Size
+			healRequired = healRequired || file.HealRequired
+		}
+		return totalBytesRead, healRequired, nil
+	}
+
+	// Closed once writing is done to stop reading ahead.
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	// Parts being read, a slot is freed once a part is written.
+	slots := make(chan struct{}, readAhead)
+	readAheadCh := make(chan *xlPartReadAhead, readAhead)
+	go func() {
+		defer close(readAheadCh)
+		for _, part := range parts {
+			select {
+			case slots <- struct{}{}:
+			case <-doneCh:
+				return
+			}
+			p := &xlPartReadAhead{chunkCh: make(chan []byte, xlMeta.Erasure.DataBlocks)}
+			go p.read(chunkWriter{p.chunkCh, doneCh}, disks, xlMeta, bucket, object, part)
+			readAheadCh <- p
+		}
+	}()
+
+	for p := range readAheadCh {
+		for chunk := range p.chunkCh {
+			if _, err = writer.Write(chunk); err != nil {
+				return totalBytesRead, healRequired, err
+			}
+		}
+		if p.err != nil {
+			return totalBytesRead, healRequired, p.err
+		}
+		totalBytesRead += p.file.Size
+		healRequired = healRequired || p.file.HealRequired
+		<-slots
+	}
+	return totalBytesRead, healRequired, nil
+}
+
+// read - reads a part from its own erasure storage, since reads mark
+// failed disks offline in the storage.
+func (p *xlPartReadAhead) read(writer io.Writer, disks []StorageAPI, xlMeta xlMetaV1, bucket, object string, part xlPartRead) {
+	defer close(p.chunkCh)
+
+	storage, err := NewErasureStorage(disks, xlMeta.Erasure.DataBlocks, xlMeta.Erasure.ParityBlocks, xlMeta.Erasure.BlockSize)
+	if err != nil {
+		p.err = err
+		return
+	}
+	p.file, p.err = storage.ReadFile(writer, bucket, pathJoin(object, part.name), part.offset, part.length, part.size, part.checksums, part.algorithm, xlMeta.Erasure.BlockSize)
+}
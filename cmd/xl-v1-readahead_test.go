@@ -0,0 +1,143 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Tests parsing the number of parts read at once.
+func TestParseXLReadAhead(t *testing.T) {
+	testCases := []struct {
+		value      string
+		readAhead  int
+		shouldPass bool
+	}{
+		{"0", 0, true},
+		{"8", 8, true},
+		{"-1", 0, false},
+		{"many", 0, false},
+	}
+	for i, testCase := range testCases {
+		readAhead, err := parseXLReadAhead(testCase.value)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected an error parsing %s", i+1, testCase.value)
+		}
+		if err == nil && readAhead != testCase.readAhead {
+			t.Errorf("Test %d: Expected %d, got %d", i+1, testCase.readAhead, readAhead)
+		}
+	}
+}
+
+// failingWriter - fails all writes after limit bytes.
+type failingWriter struct {
+	limit int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		return 0, errors.New("write failed")
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+// Tests reading multipart objects several parts at once.
+func TestGetObjectReadAheadXL(t *testing.T) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	obj, fsDirs, err := prepareXL16()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	defer func(readAhead int) { globalXLReadAhead = readAhead }(globalXLReadAhead)
+
+	bucket := "bucket"
+	object := "object"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload an object in four parts, the last one being smaller.
+	uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	var parts []CompletePart
+	for i, size := range []int{5 * humanize.MiByte, 5 * humanize.MiByte, 5 * humanize.MiByte, 1024} {
+		partData := bytes.Repeat([]byte{byte('a' + i)}, size)
+		md5hex := getMD5Hash(partData)
+		_, err = obj.PutObjectPart(context.Background(), bucket, object, uploadID, i+1, mustGetHashReader(t, bytes.NewReader(partData), int64(size), md5hex, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, CompletePart{PartNumber: i + 1, ETag: md5hex})
+		data = append(data, partData...)
+	}
+	if _, err = obj.CompleteMultipartUpload(context.Background(), bucket, object, uploadID, parts); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		offset int64
+		length int64
+	}{
+		// Whole object.
+		{0, int64(len(data))},
+		// Within a single part.
+		{10, 100},
+		// Across all parts but the first and last bytes.
+		{1, int64(len(data)) - 2},
+		// Across two parts.
+		{5*humanize.MiByte - 10, 20},
+		// Last part only.
+		{15 * humanize.MiByte, 1024},
+	}
+	for _, readAhead := range []int{0, 2, 4} {
+		globalXLReadAhead = readAhead
+		for i, testCase := range testCases {
+			var buffer bytes.Buffer
+			if err = obj.GetObject(bucket, object, testCase.offset, testCase.length, &buffer, ""); err != nil {
+				t.Fatalf("Read ahead %d: Test %d: %v", readAhead, i+1, err)
+			}
+			if !bytes.Equal(buffer.Bytes(), data[testCase.offset:testCase.offset+testCase.length]) {
+				t.Errorf("Read ahead %d: Test %d: Unexpected data read", readAhead, i+1)
+			}
+		}
+
+		// Reading ahead stops once the client fails.
+		if err = obj.GetObject(bucket, object, 0, int64(len(data)), &failingWriter{limit: 7 * humanize.MiByte}, ""); err == nil {
+			t.Errorf("Read ahead %d: Expected an error writing the object", readAhead)
+		}
+	}
+}
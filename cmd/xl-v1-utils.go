@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"hash/crc32"
@@ -214,6 +215,15 @@ func parseXLMetaMap(xlMetaBuf []byte) map[string]string {
 	return metaMap
 }
 
+func parseXLData(xlMetaBuf []byte) ([]byte, error) {
+	// Data of objects stored inline, encoded in base64 by json.
+	dataResult := gjson.GetBytes(xlMetaBuf, "data")
+	if !dataResult.Exists() {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(dataResult.String())
+}
+
 // Constructs XLMetaV1 using `gjson` lib to retrieve each field.
 func xlMetaV1UnmarshalJSON(xlMetaBuf []byte) (xlMeta xlMetaV1, e error) {
 	// obtain version.
@@ -239,6 +249,13 @@ func xlMetaV1UnmarshalJSON(xlMetaBuf []byte) (xlMeta xlMetaV1, e error) {
 	xlMeta.Minio.Release = parseXLRelease(xlMetaBuf)
 	// parse xlMetaV1.
 	xlMeta.Meta = parseXLMetaMap(xlMetaBuf)
+	// parse the inlined data, only objects stored inline have any.
+	if xlMeta.Version == xlMetaVersionInline {
+		xlMeta.Data, err = parseXLData(xlMetaBuf)
+		if err != nil {
+			return xlMeta, errors2.Trace(err)
+		}
+	}
 
 	return xlMeta, nil
 }
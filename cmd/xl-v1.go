@@ -217,6 +217,19 @@ func getStorageInfo(disks []StorageAPI) StorageInfo {
 	return storageInfo
 }
 
+// isWriteQuorumOnline - returns true if enough disks are online for
+// write quorum.
+func (xl xlObjects) isWriteQuorumOnline() bool {
+	disks := xl.getDisks()
+	online := 0
+	for _, disk := range disks {
+		if disk != nil && disk.IsOnline() {
+			online++
+		}
+	}
+	return online >= len(disks)/2+1
+}
+
 // StorageInfo - returns underlying storage statistics.
 func (xl xlObjects) StorageInfo() StorageInfo {
 	return getStorageInfo(xl.getDisks())
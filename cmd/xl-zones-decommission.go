@@ -0,0 +1,638 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"time"
+
+	errors2 "github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+)
+
+const (
+	// Decommission state of the zones, persisted under minioMetaBucket
+	// on the first zone.
+	zonesDecommissionPath = "config/decommission.json"
+
+	// Current version of the decommission state.
+	zonesDecommissionVersion = "1"
+
+	// Objects are locked by their path below this prefix while zones
+	// are drained, an object being moved is neither read nor written.
+	zonesDecommissionLockPrefix = "decommission"
+
+	// Status of decommissioning a zone.
+	decommissionDraining = "draining"
+	decommissionComplete = "complete"
+
+	// Interval at which drained zones are scanned again for objects
+	// which failed to move or were completed by uploads meanwhile.
+	decommissionInterval = time.Minute
+
+	// ETag and modification time of an object moved off a drained
+	// zone, kept by the copy written to another zone.
+	decommissionETag    = ReservedMetadataPrefix + "Decommission-Etag"
+	decommissionModTime = ReservedMetadataPrefix + "Decommission-Mod-Time"
+)
+
+// Zones are drained by a single server at a time, other servers skip
+// draining if they cannot lock at once.
+var decommissionLockTimeout = newDynamicTimeout(time.Second, time.Second)
+
+var (
+	errInvalidDecommissionZone = errors.New("the zone does not exist or is the first zone")
+	errNoSuchDecommission      = errors.New("the zone is not decommissioned")
+)
+
+// zoneDecommission - progress of draining a zone. Bucket and Marker
+// are the last object the current pass got to, a restarted server
+// resumes from there.
+type zoneDecommission struct {
+	ID           string    `json:"id"`   // UUID of the first disk of the zone.
+	Zone         int       `json:"zone"` // Position on the command line, starting at 1.
+	Status       string    `json:"status"`
+	Bandwidth    int64     `json:"bandwidth"` // Bytes per second, 0 is unlimited.
+	StartTime    time.Time `json:"startTime"`
+	EndTime      time.Time `json:"endTime"`
+	Bucket       string    `json:"bucket"`
+	Marker       string    `json:"marker"`
+	ObjectsMoved int64     `json:"objectsMoved"`
+	BytesMoved   int64     `json:"bytesMoved"`
+	Failures     int64     `json:"failures"`
+	LastError    string    `json:"lastError,omitempty"`
+}
+
+// zonesDecommissionState - zones being drained or drained.
+type zonesDecommissionState struct {
+	Version string             `json:"version"`
+	Zones   []zoneDecommission `json:"zones"`
+}
+
+// Returns the UUID of the first disk of a zone, which identifies the
+// zone regardless of its position on the command line.
+func (s *xlSets) getZoneID() string {
+	s.formatMu.RLock()
+	defer s.formatMu.RUnlock()
+
+	return s.format.XL.Sets[0][0]
+}
+
+// Loads the decommission state of the zones, zones removed from the
+// command line are dropped and the others are given their position.
+func (z *xlZones) loadDecommission() (state zonesDecommissionState, err error) {
+	if _, err = loadManagedPolicyJSON(z, zonesDecommissionPath, &state); err != nil {
+		return state, err
+	}
+	zones := state.Zones[:0]
+	for _, d := range state.Zones {
+		if index := z.getZoneIndex(d.ID); index > 0 {
+			d.Zone = index + 1
+			zones = append(zones, d)
+		}
+	}
+	state.Zones = zones
+	return state, nil
+}
+
+// Persists the decommission state of the zones.
+func (z *xlZones) saveDecommission(state zonesDecommissionState) error {
+	state.Version = zonesDecommissionVersion
+	return saveManagedPolicyJSON(z, zonesDecommissionPath, state)
+}
+
+// Applies fn to the decommission state of the zones and persists it,
+// the state is locked across all servers meanwhile.
+func (z *xlZones) updateDecommission(fn func(state *zonesDecommissionState) error) error {
+	stateLock := globalNSMutex.NewNSLock(minioMetaBucket, zonesDecommissionPath)
+	if err := stateLock.GetLock(globalOperationTimeout); err != nil {
+		return err
+	}
+	defer stateLock.Unlock()
+
+	state, err := z.loadDecommission()
+	if err != nil {
+		return err
+	}
+	if err = fn(&state); err != nil {
+		return err
+	}
+	return z.saveDecommission(state)
+}
+
+// Returns true if any erasure set of the zone has uploads of objects of
+// bucket in progress.
+func (s *xlSets) hasMultipartUploads(bucket string) (bool, error) {
+	for _, set := range s.sets {
+		var err error
+		for _, disk := range set.getLoadBalancedDisks() {
+			if disk == nil {
+				continue
+			}
+			var entries []string
+			entries, err = disk.ListDir(minioMetaMultipartBucket, bucket)
+			if err == nil && len(entries) > 0 {
+				return true, nil
+			}
+			if err == nil || errors2.Cause(err) == errFileNotFound {
+				err = nil
+				break
+			}
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// popMovedObjectMetadata - removes the ETag and the modification time of
+// an object moved off a drained zone from its metadata and returns them,
+// ok is false for other objects.
+func popMovedObjectMetadata(metadata map[string]string) (etag string, modTime time.Time, ok bool) {
+	etag, ok = metadata[decommissionETag]
+	if !ok {
+		return "", modTime, false
+	}
+	modTime, err := time.Parse(time.RFC3339Nano, metadata[decommissionModTime])
+	delete(metadata, decommissionETag)
+	delete(metadata, decommissionModTime)
+	return etag, modTime, err == nil
+}
+
+// Returns the index of the zone with the ID, -1 if none has it.
+func (z *xlZones) getZoneIndex(id string) int {
+	for index, zone := range z.zones {
+		if zone.getZoneID() == id {
+			return index
+		}
+	}
+	return -1
+}
+
+// ReloadDecommission - loads the zones being drained after the
+// decommission state was changed by any server.
+func (z *xlZones) ReloadDecommission() error {
+	state, err := z.loadDecommission()
+	if err != nil {
+		return err
+	}
+	draining := make([]bool, len(z.zones))
+	for _, d := range state.Zones {
+		draining[d.Zone-1] = true
+	}
+
+	z.drainMu.Lock()
+	z.draining = draining
+	z.drainMu.Unlock()
+
+	if z.isDecommissioning() {
+		select {
+		case z.drainKickCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Returns true if any zone is drained.
+func (z *xlZones) isDecommissioning() bool {
+	z.drainMu.RLock()
+	defer z.drainMu.RUnlock()
+
+	for _, draining := range z.draining {
+		if draining {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if zone is drained.
+func (z *xlZones) isDrained(zone *xlSets) bool {
+	z.drainMu.RLock()
+	defer z.drainMu.RUnlock()
+
+	for index := range z.zones {
+		if z.zones[index] == zone {
+			return z.draining[index]
+		}
+	}
+	return false
+}
+
+// Returns the zones new objects are placed on, the first zone is
+// never drained.
+func (z *xlZones) getAvailableZones() []*xlSets {
+	z.drainMu.RLock()
+	defer z.drainMu.RUnlock()
+
+	zones := make([]*xlSets, 0, len(z.zones))
+	for index, zone := range z.zones {
+		if !z.draining[index] {
+			zones = append(zones, zone)
+		}
+	}
+	return zones
+}
+
+// Locks an object for writing while zones are drained, returns the
+// function unlocking it.
+func (z *xlZones) lockDrainedObject(bucket, object string) (unlock func(), err error) {
+	if isMinioMetaBucketName(bucket) || !z.isDecommissioning() {
+		return func() {}, nil
+	}
+	objectLock := globalNSMutex.NewNSLock(minioMetaBucket, pathJoin(zonesDecommissionLockPrefix, bucket, object))
+	if err = objectLock.GetLock(globalObjectTimeout); err != nil {
+		return nil, err
+	}
+	return objectLock.Unlock, nil
+}
+
+// Locks an object for reading while zones are drained, returns the
+// function unlocking it.
+func (z *xlZones) rlockDrainedObject(bucket, object string) (unlock func(), err error) {
+	if isMinioMetaBucketName(bucket) || !z.isDecommissioning() {
+		return func() {}, nil
+	}
+	objectLock := globalNSMutex.NewNSLock(minioMetaBucket, pathJoin(zonesDecommissionLockPrefix, bucket, object))
+	if err = objectLock.GetRLock(globalObjectTimeout); err != nil {
+		return nil, err
+	}
+	return objectLock.RUnlock, nil
+}
+
+// checkDrainedObjectLock - fails if an object about to be replaced on
+// another zone than the drained zone holding it is protected from
+// overwrites.
+func checkDrainedObjectLock(drained *xlSets, bucket, object string) error {
+	if drained == nil {
+		return nil
+	}
+	return checkObjectLockAtRest(bucket, object, func() (ObjectInfo, error) {
+		return drained.GetObjectInfo(bucket, object)
+	})
+}
+
+// removeDrainedObject - removes the copy of an object from a drained
+// zone once it is moved or replaced, object locks moved with the copy.
+func removeDrainedObject(drained *xlSets, bucket, object string) error {
+	if drained == nil {
+		return nil
+	}
+	set, err := drained.getObjectSet(bucket, object)
+	if err != nil {
+		return err
+	}
+	objectLock := set.nsMutex.NewNSLock(bucket, object)
+	if err := objectLock.GetLock(globalOperationTimeout); err != nil {
+		return err
+	}
+	defer objectLock.Unlock()
+
+	if err := set.deleteObject(bucket, object); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	invalidateXLMetadata(bucket, object)
+	return nil
+}
+
+// StartDecommission - starts draining the zone at index with at most
+// bandwidth bytes per second, 0 is unlimited. Draining zones are
+// resumed with the new bandwidth.
+func (z *xlZones) StartDecommission(index int, bandwidth int64) (status zoneDecommission, err error) {
+	if index < 1 || index >= len(z.zones) {
+		return status, errInvalidDecommissionZone
+	}
+	id := z.zones[index].getZoneID()
+	err = z.updateDecommission(func(state *zonesDecommissionState) error {
+		for i := range state.Zones {
+			if state.Zones[i].ID == id {
+				state.Zones[i].Bandwidth = bandwidth
+				status = state.Zones[i]
+				return nil
+			}
+		}
+		status = zoneDecommission{
+			ID:        id,
+			Zone:      index + 1,
+			Status:    decommissionDraining,
+			Bandwidth: bandwidth,
+			StartTime: UTCNow(),
+		}
+		state.Zones = append(state.Zones, status)
+		return nil
+	})
+	if err != nil {
+		return status, err
+	}
+	return status, z.ReloadDecommission()
+}
+
+// CancelDecommission - stops draining the zone at index, or releases a
+// drained zone. Objects already moved stay on the other zones.
+func (z *xlZones) CancelDecommission(index int) error {
+	if index < 1 || index >= len(z.zones) {
+		return errInvalidDecommissionZone
+	}
+	id := z.zones[index].getZoneID()
+	err := z.updateDecommission(func(state *zonesDecommissionState) error {
+		for i := range state.Zones {
+			if state.Zones[i].ID == id {
+				state.Zones = append(state.Zones[:i], state.Zones[i+1:]...)
+				return nil
+			}
+		}
+		return errNoSuchDecommission
+	})
+	if err != nil {
+		return err
+	}
+	return z.ReloadDecommission()
+}
+
+// DecommissionStatus - returns the progress of draining all zones
+// being drained or drained, sorted by zone.
+func (z *xlZones) DecommissionStatus() ([]zoneDecommission, error) {
+	state, err := z.loadDecommission()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(state.Zones, func(i, j int) bool {
+		return state.Zones[i].Zone < state.Zones[j].Zone
+	})
+	return append([]zoneDecommission{}, state.Zones...), nil
+}
+
+// Updates the progress of draining the zone with the ID, fails with
+// errNoSuchDecommission once it is cancelled.
+func (z *xlZones) updateZoneDecommission(id string, fn func(d *zoneDecommission)) error {
+	return z.updateDecommission(func(state *zonesDecommissionState) error {
+		for i := range state.Zones {
+			if state.Zones[i].ID == id {
+				fn(&state.Zones[i])
+				return nil
+			}
+		}
+		return errNoSuchDecommission
+	})
+}
+
+// moveObject - moves an object from a drained zone to the zone its
+// name hashes to, keeping its ETag and modification time. A copy on
+// another zone is replaced if it is older, newer copies written to
+// another zone meanwhile replace the copy on the drained zone, which
+// is only removed.
+func (z *xlZones) moveObject(drained *xlSets, bucket, object string) (moved bool, size int64, err error) {
+	unlock, err := z.lockDrainedObject(bucket, object)
+	if err != nil {
+		return false, 0, err
+	}
+	defer unlock()
+
+	objInfo, err := drained.GetObjectInfo(bucket, object)
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			err = nil
+		}
+		return false, 0, err
+	}
+
+	target := z.getHashedZone(bucket, object)
+	for _, zone := range z.zones {
+		if zone == drained {
+			continue
+		}
+		otherInfo, err := zone.GetObjectInfo(bucket, object)
+		if isErrObjectNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, 0, err
+		}
+		if !objInfo.ModTime.After(otherInfo.ModTime) {
+			return false, 0, removeDrainedObject(drained, bucket, object)
+		}
+		target = zone
+		break
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(drained.GetObject(bucket, object, 0, objInfo.Size, pw, objInfo.ETag))
+	}()
+	hashReader, err := hash.NewReader(pr, objInfo.Size, "", "")
+	if err != nil {
+		pr.CloseWithError(err)
+		return false, 0, err
+	}
+	metadata := make(map[string]string, len(objInfo.UserDefined))
+	for k, v := range objInfo.UserDefined {
+		metadata[k] = v
+	}
+	delete(metadata, "etag")
+	metadata[decommissionETag] = objInfo.ETag
+	metadata[decommissionModTime] = objInfo.ModTime.UTC().Format(time.RFC3339Nano)
+	_, err = target.PutObject(bucket, object, hashReader, metadata)
+	pr.CloseWithError(err)
+	if err != nil {
+		return false, 0, err
+	}
+	return true, objInfo.Size, removeDrainedObject(drained, bucket, object)
+}
+
+// Waits until moving size bytes took as long as allowed by bandwidth
+// bytes per second, or until doneCh is closed.
+func throttleDecommission(bandwidth, size int64, elapsed time.Duration, doneCh <-chan struct{}) {
+	if bandwidth <= 0 {
+		return
+	}
+	wait := time.Duration(float64(size)/float64(bandwidth)*float64(time.Second)) - elapsed
+	if wait <= 0 {
+		return
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-doneCh:
+	}
+}
+
+// drainZone - moves the objects of the zone at index from where the
+// current pass got to. A pass from the start finding no object and no
+// upload in progress left completes the decommission, the zone can
+// then be removed. Uploads started before the zone was drained complete
+// on it and are moved by the next pass.
+func (z *xlZones) drainZone(index int, doneCh <-chan struct{}) error {
+	zone := z.zones[index]
+	id := zone.getZoneID()
+
+	state, err := z.loadDecommission()
+	if err != nil {
+		return err
+	}
+	var d zoneDecommission
+	for _, d = range state.Zones {
+		if d.ID == id {
+			break
+		}
+	}
+	if d.ID != id || d.Status != decommissionDraining {
+		return nil
+	}
+	fromStart := d.Bucket == "" && d.Marker == ""
+
+	buckets, err := zone.ListBuckets()
+	if err != nil {
+		return err
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Name < buckets[j].Name
+	})
+
+	found := false
+	for _, bucket := range buckets {
+		if bucket.Name < d.Bucket {
+			continue
+		}
+		marker := ""
+		if bucket.Name == d.Bucket {
+			marker = d.Marker
+		}
+		for {
+			select {
+			case <-doneCh:
+				return nil
+			default:
+			}
+
+			result, err := zone.ListObjects(bucket.Name, "", marker, "", maxObjectList)
+			if err != nil {
+				return err
+			}
+			var objects, bytes, failures int64
+			var lastErr error
+			for _, objInfo := range result.Objects {
+				found = true
+				start := UTCNow()
+				moved, size, err := z.moveObject(zone, bucket.Name, objInfo.Name)
+				if err != nil {
+					errorIf(err, "Unable to move %s/%s off the decommissioned zone %d.", bucket.Name, objInfo.Name, index+1)
+					failures++
+					lastErr = err
+					continue
+				}
+				if moved {
+					objects++
+					bytes += size
+					throttleDecommission(d.Bandwidth, size, UTCNow().Sub(start), doneCh)
+				}
+			}
+			if len(result.Objects) > 0 {
+				marker = result.Objects[len(result.Objects)-1].Name
+			}
+			if err = z.updateZoneDecommission(id, func(d *zoneDecommission) {
+				d.Bucket, d.Marker = bucket.Name, marker
+				d.ObjectsMoved += objects
+				d.BytesMoved += bytes
+				d.Failures += failures
+				if lastErr != nil {
+					d.LastError = errors2.Cause(lastErr).Error()
+				}
+			}); err != nil {
+				return err
+			}
+			if !result.IsTruncated || len(result.Objects) == 0 {
+				break
+			}
+		}
+
+		uploads, err := zone.hasMultipartUploads(bucket.Name)
+		if err != nil {
+			return err
+		}
+		if uploads {
+			found = true
+		}
+	}
+
+	// Start the next pass from the first bucket, or mark the zone as
+	// drained.
+	return z.updateZoneDecommission(id, func(d *zoneDecommission) {
+		d.Bucket, d.Marker = "", ""
+		if fromStart && !found {
+			d.Status = decommissionComplete
+			d.EndTime = UTCNow()
+		}
+	})
+}
+
+// drainZones - drains all zones being decommissioned, unless another
+// server is draining them.
+func (z *xlZones) drainZones(doneCh <-chan struct{}) {
+	drainLock := globalNSMutex.NewNSLock(minioMetaBucket, zonesDecommissionLockPrefix)
+	if drainLock.GetLock(decommissionLockTimeout) != nil {
+		return
+	}
+	defer drainLock.Unlock()
+
+	for index := range z.zones {
+		if !z.isDrained(z.zones[index]) {
+			continue
+		}
+		if err := z.drainZone(index, doneCh); err != nil && err != errNoSuchDecommission {
+			errorIf(err, "Unable to drain the decommissioned zone %d.", index+1)
+		}
+	}
+}
+
+// StartDecommissionRoutine - starts a routine draining the zones being
+// decommissioned every decommissionInterval and when zones are added.
+func (z *xlZones) StartDecommissionRoutine(doneCh chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(decommissionInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-doneCh:
+				return
+			case <-z.drainKickCh:
+			case <-ticker.C:
+			}
+			if z.isDecommissioning() {
+				z.drainZones(doneCh)
+			}
+		}
+	}()
+}
+
+// reloadDecommission - loads the zones being drained by the object
+// layer of this server, servers with a single zone have none.
+func reloadDecommission() error {
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+	z, ok := unwrapObjectLayer(objAPI).(*xlZones)
+	if !ok {
+		return nil
+	}
+	return z.ReloadDecommission()
+}
@@ -0,0 +1,254 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// Tests throttling moved objects to a bandwidth.
+func TestThrottleDecommission(t *testing.T) {
+	start := time.Now()
+	throttleDecommission(0, 1<<20, 0, nil)
+	throttleDecommission(1<<20, 1<<20, time.Second, nil)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("Expected no wait, waited %s", elapsed)
+	}
+	start = time.Now()
+	throttleDecommission(1000, 100, 0, nil)
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("Expected to wait 100ms, waited %s", elapsed)
+	}
+}
+
+// Tests draining a zone, resuming and cancelling it.
+func TestXLZonesDecommission(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	initNSLock(false)
+
+	disks, err := getRandomDisks(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	zones := []zoneEndpoints{
+		{mustGetNewEndpointList(disks[:4]...), 1, 4},
+		{mustGetNewEndpointList(disks[4:]...), 1, 4},
+	}
+	obj, err := newXLZones(zones)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := obj.(*xlZones)
+	if err = obj.MakeBucketWithLocation("bucket", ""); err != nil {
+		t.Fatal(err)
+	}
+	putObject := func(object, content string) {
+		if _, err := obj.PutObject("bucket", object, mustGetHashReader(t, bytes.NewReader([]byte(content)), int64(len(content)), "", ""), nil); err != nil {
+			t.Fatalf("Unable to put %s: %v", object, err)
+		}
+	}
+	countObjects := func(zone *xlSets) int {
+		result, err := zone.ListObjects("bucket", "", "", "", 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(result.Objects)
+	}
+
+	const count = 20
+	for i := 0; i < count; i++ {
+		putObject(fmt.Sprintf("object-%d", i), "content")
+	}
+	// A copy on the drained zone newer than the copy on the other zone
+	// replaces it.
+	for i, content := range []string{"stale", "CONTENT"} {
+		if _, err = z.zones[i].PutObject("bucket", "newer", mustGetHashReader(t, bytes.NewReader([]byte(content)), int64(len(content)), "", ""), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	drained := countObjects(z.zones[1])
+	if drained == 0 {
+		t.Fatal("Expected objects on the second zone")
+	}
+	result, err := z.zones[1].ListObjects("bucket", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drainedInfos := result.Objects
+
+	// Uploads started before draining complete on the drained zone.
+	uploadID, err := z.zones[1].NewMultipartUpload("bucket", "upload", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first zone holds the config of the server.
+	if _, err = z.StartDecommission(0, 0); err != errInvalidDecommissionZone {
+		t.Fatalf("Expected %v, got %v", errInvalidDecommissionZone, err)
+	}
+	if _, err = z.StartDecommission(2, 0); err != errInvalidDecommissionZone {
+		t.Fatalf("Expected %v, got %v", errInvalidDecommissionZone, err)
+	}
+	status, err := z.StartDecommission(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Zone != 2 || status.Status != decommissionDraining {
+		t.Fatalf("Unexpected status %+v", status)
+	}
+
+	// New objects and overwritten objects of the drained zone are
+	// written to the other zone.
+	for i := 0; i < count; i++ {
+		putObject(fmt.Sprintf("new-%d", i), "new")
+	}
+	var overwritten string
+	for i := 0; i < count && overwritten == ""; i++ {
+		object := fmt.Sprintf("object-%d", i)
+		if set, err := z.zones[1].getObjectSet("bucket", object); err == nil && set.isObject("bucket", object) {
+			overwritten = object
+		}
+	}
+	putObject(overwritten, "updated")
+	if n := countObjects(z.zones[1]); n != drained-1 {
+		t.Fatalf("Expected %d objects on the drained zone, got %d", drained-1, n)
+	}
+
+	// Resume from a marker as after a restart, then drain the rest.
+	if err = z.updateZoneDecommission(status.ID, func(d *zoneDecommission) {
+		d.Bucket, d.Marker = "bucket", "object-5"
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err = z.drainZone(1, nil); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := z.DecommissionStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[0].Status != decommissionDraining || statuses[0].Marker != "" {
+		t.Fatalf("Expected a pass from the start to be next, got %+v", statuses)
+	}
+	if err = z.drainZone(1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := countObjects(z.zones[1]); n != 0 {
+		t.Fatalf("Expected the zone to be drained, %d objects left", n)
+	}
+	if err = z.drainZone(1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if statuses, err = z.DecommissionStatus(); err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].Status != decommissionDraining {
+		t.Fatalf("Expected the upload in progress to keep the zone draining, got %+v", statuses[0])
+	}
+	if err = obj.AbortMultipartUpload("bucket", "upload", uploadID); err != nil {
+		t.Fatal(err)
+	}
+	if err = z.drainZone(1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if statuses, err = z.DecommissionStatus(); err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].Status != decommissionComplete || statuses[0].ObjectsMoved != int64(drained-1) ||
+		statuses[0].BytesMoved != int64((drained-1)*len("content")) || statuses[0].Failures != 0 {
+		t.Fatalf("Unexpected status %+v", statuses[0])
+	}
+
+	// Moved objects keep their ETag and modification time.
+	for _, objInfo := range drainedInfos {
+		if objInfo.Name == overwritten {
+			continue
+		}
+		movedInfo, err := z.zones[0].GetObjectInfo("bucket", objInfo.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if movedInfo.ETag != objInfo.ETag || !movedInfo.ModTime.Equal(objInfo.ModTime) {
+			t.Fatalf("Expected %s to keep ETag %s and time %s, got %s and %s",
+				objInfo.Name, objInfo.ETag, objInfo.ModTime, movedInfo.ETag, movedInfo.ModTime)
+		}
+		if _, ok := movedInfo.UserDefined[decommissionETag]; ok {
+			t.Fatalf("Unexpected internal metadata %v", movedInfo.UserDefined)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err = z.zones[0].GetObject("bucket", "newer", 0, -1, &buffer, ""); err != nil || buffer.String() != "CONTENT" {
+		t.Fatalf("Expected the newer copy to be moved, got %q, %v", buffer.String(), err)
+	}
+
+	// All objects are readable from the remaining zone.
+	for i := 0; i < count; i++ {
+		object, content := fmt.Sprintf("object-%d", i), "content"
+		if object == overwritten {
+			content = "updated"
+		}
+		var buffer bytes.Buffer
+		if err = obj.GetObject("bucket", object, 0, -1, &buffer, ""); err != nil {
+			t.Fatalf("Unable to get %s: %v", object, err)
+		}
+		if buffer.String() != content {
+			t.Fatalf("Expected %s to be %q but got %q", object, content, buffer.String())
+		}
+	}
+	if n := countObjects(z.zones[0]); n != 2*count+1 {
+		t.Fatalf("Expected %d objects on the first zone, got %d", 2*count+1, n)
+	}
+
+	// A restarted server keeps the zone drained.
+	obj, err = newXLZones(zones)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z = obj.(*xlZones)
+	if !z.isDrained(z.zones[1]) || z.isDrained(z.zones[0]) {
+		t.Fatal("Expected the second zone to be drained")
+	}
+
+	// Cancelling places objects on the zone again.
+	if err = z.CancelDecommission(1); err != nil {
+		t.Fatal(err)
+	}
+	if err = z.CancelDecommission(1); err != errNoSuchDecommission {
+		t.Fatalf("Expected %v, got %v", errNoSuchDecommission, err)
+	}
+	if z.isDecommissioning() {
+		t.Fatal("Expected no zone to be drained")
+	}
+	for i := 0; i < count; i++ {
+		putObject(fmt.Sprintf("again-%d", i), "again")
+	}
+	if countObjects(z.zones[1]) == 0 {
+		t.Fatal("Expected new objects on the second zone")
+	}
+}
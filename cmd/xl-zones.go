@@ -0,0 +1,893 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/pkg/policy"
+	"github.com/minio/minio-go/pkg/set"
+	"github.com/minio/minio/pkg/errors"
+	"github.com/minio/minio/pkg/hash"
+	"github.com/minio/minio/pkg/madmin"
+	"github.com/minio/minio/pkg/sync/errgroup"
+)
+
+// xlZones implements ObjectLayer combining the erasure coded sets of
+// several zones, a server grows by adding a zone. New objects are
+// placed on a zone by hashing their names, objects already on a zone
+// stay there. Buckets exist on all zones, the config of the server and
+// of buckets is kept on the first zone. Zones being decommissioned are
+// drained of their objects and receive no new objects.
+type xlZones struct {
+	zones []*xlSets
+
+	// Bucket policies of all zones.
+	bucketPolicies *bucketPolicies
+
+	// Zones being drained or drained, loaded from the decommission
+	// state of the server.
+	drainMu  sync.RWMutex
+	draining []bool
+
+	// Wakes up the routine draining zones.
+	drainKickCh chan struct{}
+}
+
+// Initialize the zones of a server, every zone is formatted on its own.
+func newXLZones(zones []zoneEndpoints) (ObjectLayer, error) {
+	z := &xlZones{
+		zones:       make([]*xlSets, len(zones)),
+		draining:    make([]bool, len(zones)),
+		drainKickCh: make(chan struct{}, 1),
+	}
+	for i, zone := range zones {
+		format, err := waitForFormatXL(zone.endpoints[0].IsLocal, zone.endpoints, zone.setCount, zone.drivesPerSet)
+		if err != nil {
+			return nil, err
+		}
+		z.zones[i] = initXLSets(zone.endpoints, format, len(format.XL.Sets), len(format.XL.Sets[0]))
+	}
+
+	if err := z.makeMissingBuckets(); err != nil {
+		return nil, err
+	}
+
+	// Zones being decommissioned receive no new objects.
+	if err := z.ReloadDecommission(); err != nil {
+		return nil, err
+	}
+
+	// Initialize and load bucket policies, shared by all zones.
+	var err error
+	z.bucketPolicies, err = initBucketPolicies(z)
+	if err != nil {
+		return nil, err
+	}
+	for _, zone := range z.zones {
+		zone.bucketPolicies = z.bucketPolicies
+	}
+
+	// Initialize a new event notifier.
+	if err = initEventNotifier(z); err != nil {
+		return nil, err
+	}
+
+	return z, nil
+}
+
+// Creates the buckets of the first zone on the other zones, zones
+// added to a server have none of the existing buckets.
+func (z *xlZones) makeMissingBuckets() error {
+	buckets, err := z.zones[0].ListBuckets()
+	if err != nil {
+		return err
+	}
+	for _, zone := range z.zones[1:] {
+		for _, bucket := range buckets {
+			err = zone.MakeBucketWithLocation(bucket.Name, "")
+			if _, ok := errors.Cause(err).(BucketExists); err != nil && !ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the zone a new object is placed on, always the first zone
+// for the config of the server. Drained zones are skipped.
+func (z *xlZones) getHashedZone(bucket, object string) *xlSets {
+	if isMinioMetaBucketName(bucket) {
+		return z.zones[0]
+	}
+	zones := z.getAvailableZones()
+	return zones[hashKey(z.zones[0].distributionAlgo, object, len(zones))]
+}
+
+// Returns the zone holding the newest copy of an object, the zone it is
+// placed on if none does. The zone whose info of the object is cached
+// is not probed. A zone which cannot be read with quorum may hold a
+// newer copy, its error is returned instead of treating the object as
+// absent there.
+func (z *xlZones) getObjectZone(bucket, object string) (*xlSets, error) {
+	if isMinioMetaBucketName(bucket) || len(z.zones) == 1 {
+		return z.zones[0], nil
+	}
+	if owner := globalXLMetadataCache.getOwner(bucket, object); owner != nil {
+		for _, zone := range z.zones {
+			if zone == owner {
+				return zone, nil
+			}
+		}
+	}
+	var newest *xlSets
+	var modTime time.Time
+	for _, zone := range z.zones {
+		objInfo, _, err := zone.getNewestObject(bucket, object)
+		if err != nil {
+			if isErrObjectNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if newest == nil || objInfo.ModTime.After(modTime) {
+			newest, modTime = zone, objInfo.ModTime
+		}
+	}
+	if newest == nil {
+		return z.getHashedZone(bucket, object), nil
+	}
+	return newest, nil
+}
+
+// Returns the zone an object is written to, the zone holding it unless
+// that zone is drained. The drained zone holding the object is returned
+// as well, its copy is removed once the object is written.
+func (z *xlZones) getWriteZone(bucket, object string) (zone, drained *xlSets, err error) {
+	zone, err = z.getObjectZone(bucket, object)
+	if err != nil {
+		return nil, nil, err
+	}
+	if z.isDrained(zone) {
+		return z.getHashedZone(bucket, object), zone, nil
+	}
+	return zone, nil, nil
+}
+
+// Returns the zone holding a multipart upload, the zone the object is
+// placed on if none does.
+func (z *xlZones) getUploadZone(bucket, object, uploadID string) *xlSets {
+	if isMinioMetaBucketName(bucket) {
+		return z.zones[0]
+	}
+	for _, zone := range z.zones {
+		if zone.getUploadSet(bucket, object, uploadID).isUploadIDExists(bucket, object, uploadID) {
+			return zone
+		}
+	}
+	return z.getHashedZone(bucket, object)
+}
+
+// Deletes the copies of an object on other zones, left when an upload
+// completed or an object was written concurrently on another zone.
+// Copies written at or after modTime, the mtime of the copy on zone,
+// are newer and kept. Copies which cannot be removed fail the write,
+// they would be read instead of the new object once they are newer.
+func (z *xlZones) deleteOtherObjects(bucket, object string, zone *xlSets, modTime time.Time) error {
+	for _, other := range z.zones {
+		if other == zone {
+			continue
+		}
+		for _, set := range other.getObjectSets(bucket, object) {
+			if err := set.deleteOlderObject(bucket, object, modTime); err != nil {
+				errorIf(err, "Unable to delete the previous copy of %s/%s", bucket, object)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// StorageInfo - combines output of StorageInfo across all zones.
+func (z *xlZones) StorageInfo() StorageInfo {
+	var storageInfo StorageInfo
+	for i, zone := range z.zones {
+		zoneStorageInfo := zone.StorageInfo()
+		if i == 0 {
+			storageInfo.Backend = zoneStorageInfo.Backend
+		} else {
+			storageInfo.Backend.OnlineDisks += zoneStorageInfo.Backend.OnlineDisks
+			storageInfo.Backend.OfflineDisks += zoneStorageInfo.Backend.OfflineDisks
+			storageInfo.Backend.Sets = append(storageInfo.Backend.Sets, zoneStorageInfo.Backend.Sets...)
+		}
+		storageInfo.Total += zoneStorageInfo.Total
+		storageInfo.Free += zoneStorageInfo.Free
+	}
+	return storageInfo
+}
+
+// Shutdown shuts down all zones.
+func (z *xlZones) Shutdown() (err error) {
+	for _, zone := range z.zones {
+		if zerr := zone.Shutdown(); zerr != nil && err == nil {
+			err = zerr
+		}
+	}
+	return err
+}
+
+// MakeBucketWithLocation - creates a new bucket on all zones, the
+// bucket is removed again from the zones it was created on if any
+// zone fails.
+func (z *xlZones) MakeBucketWithLocation(bucket, location string) error {
+	g := errgroup.WithNErrs(len(z.zones))
+	for index := range z.zones {
+		index := index
+		g.Go(func() error {
+			return z.zones[index].MakeBucketWithLocation(bucket, location)
+		}, index)
+	}
+
+	errs := g.Wait()
+	for _, err := range errs {
+		if err != nil {
+			for index, zerr := range errs {
+				if zerr == nil {
+					errorIf(z.zones[index].deleteBucket(bucket), "Unable to undo the creation of bucket %s", bucket)
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBucketInfo - returns bucket info from the first zone.
+func (z *xlZones) GetBucketInfo(bucket string) (bucketInfo BucketInfo, err error) {
+	return z.zones[0].GetBucketInfo(bucket)
+}
+
+// ListBuckets - lists the buckets of the first zone, all zones have
+// the same buckets.
+func (z *xlZones) ListBuckets() (buckets []BucketInfo, err error) {
+	return z.zones[0].ListBuckets()
+}
+
+// DeleteBucket - deletes a bucket on all zones, the bucket is created
+// again on the zones it was deleted from if any zone fails.
+func (z *xlZones) DeleteBucket(bucket string) error {
+	g := errgroup.WithNErrs(len(z.zones))
+	for index := range z.zones {
+		index := index
+		g.Go(func() error {
+			return z.zones[index].deleteBucket(bucket)
+		}, index)
+	}
+
+	errs := g.Wait()
+	for _, err := range errs {
+		if err != nil {
+			for index, zerr := range errs {
+				if zerr == nil {
+					errorIf(z.zones[index].MakeBucketWithLocation(bucket, ""), "Unable to undo the deletion of bucket %s", bucket)
+				}
+			}
+			return err
+		}
+	}
+
+	// Delete all bucket metadata.
+	deleteBucketMetadata(bucket, z)
+
+	return nil
+}
+
+// mergeZonesListObjects - merges the listings of all zones, each with
+// the first maxKeys entries of the zone after the marker, into the
+// first maxKeys entries of the bucket. Objects on several zones are
+// listed once, with the info of the latest.
+func mergeZonesListObjects(results []ListObjectsInfo, maxKeys int) (result ListObjectsInfo) {
+	objects := make(map[string]ObjectInfo)
+	prefixes := set.NewStringSet()
+	var names []string
+	for _, zoneResult := range results {
+		result.IsTruncated = result.IsTruncated || zoneResult.IsTruncated
+		for _, objInfo := range zoneResult.Objects {
+			prevInfo, ok := objects[objInfo.Name]
+			if !ok {
+				names = append(names, objInfo.Name)
+			}
+			if !ok || objInfo.ModTime.After(prevInfo.ModTime) {
+				objects[objInfo.Name] = objInfo
+			}
+		}
+		for _, prefix := range zoneResult.Prefixes {
+			if _, ok := objects[prefix]; !ok && !prefixes.Contains(prefix) {
+				names = append(names, prefix)
+			}
+			prefixes.Add(prefix)
+		}
+	}
+
+	sort.Strings(names)
+	if len(names) > maxKeys {
+		names = names[:maxKeys]
+		result.IsTruncated = true
+	}
+	for _, name := range names {
+		if objInfo, ok := objects[name]; ok {
+			result.Objects = append(result.Objects, objInfo)
+		} else {
+			result.Prefixes = append(result.Prefixes, name)
+		}
+	}
+	if result.IsTruncated && len(names) > 0 {
+		result.NextMarker = names[len(names)-1]
+	}
+	return result
+}
+
+// ListObjects - lists the objects of all zones, merged by name.
+func (z *xlZones) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (result ListObjectsInfo, err error) {
+	if isMinioMetaBucketName(bucket) {
+		return z.zones[0].ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	}
+
+	// Over flowing count - reset to maxObjectList.
+	if maxKeys < 0 || maxKeys > maxObjectList {
+		maxKeys = maxObjectList
+	}
+
+	results := make([]ListObjectsInfo, len(z.zones))
+	for i, zone := range z.zones {
+		results[i], err = zone.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+		if err != nil {
+			return result, err
+		}
+	}
+	return mergeZonesListObjects(results, maxKeys), nil
+}
+
+// ListObjectsV2 lists all objects in bucket filtered by prefix
+func (z *xlZones) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, fetchOwner bool, startAfter string) (result ListObjectsV2Info, err error) {
+	marker := continuationToken
+	if marker == "" {
+		marker = startAfter
+	}
+	loi, err := z.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err != nil {
+		return result, err
+	}
+
+	listObjectsV2Info := ListObjectsV2Info{
+		IsTruncated:           loi.IsTruncated,
+		ContinuationToken:     continuationToken,
+		NextContinuationToken: loi.NextMarker,
+		Objects:               loi.Objects,
+		Prefixes:              loi.Prefixes,
+	}
+	return listObjectsV2Info, err
+}
+
+// SetBucketPolicy persist the new policy on the bucket.
+func (z *xlZones) SetBucketPolicy(bucket string, policy policy.BucketAccessPolicy) error {
+	return persistAndNotifyBucketPolicyChange(bucket, false, policy, z)
+}
+
+// GetBucketPolicy will return a policy on a bucket
+func (z *xlZones) GetBucketPolicy(bucket string) (policy.BucketAccessPolicy, error) {
+	// fetch bucket policy from cache.
+	bpolicy := z.bucketPolicies.GetBucketPolicy(bucket)
+	if reflect.DeepEqual(bpolicy, emptyBucketPolicy) {
+		return ReadBucketPolicy(bucket, z)
+	}
+	return bpolicy, nil
+}
+
+// DeleteBucketPolicy deletes all policies on bucket
+func (z *xlZones) DeleteBucketPolicy(bucket string) error {
+	return persistAndNotifyBucketPolicyChange(bucket, true, emptyBucketPolicy, z)
+}
+
+// RefreshBucketPolicy refreshes policy cache from disk
+func (z *xlZones) RefreshBucketPolicy(bucket string) error {
+	policy, err := ReadBucketPolicy(bucket, z)
+	if err != nil {
+		if reflect.DeepEqual(policy, emptyBucketPolicy) {
+			return z.bucketPolicies.DeleteBucketPolicy(bucket)
+		}
+		return err
+	}
+	return z.bucketPolicies.SetBucketPolicy(bucket, policy)
+}
+
+// IsNotificationSupported returns whether bucket notification is applicable for this layer.
+func (z *xlZones) IsNotificationSupported() bool {
+	return z.zones[0].IsNotificationSupported()
+}
+
+// IsEncryptionSupported returns whether server side encryption is applicable for this layer.
+func (z *xlZones) IsEncryptionSupported() bool {
+	return z.zones[0].IsEncryptionSupported()
+}
+
+// --- Object Operations ---
+
+// GetObject - reads an object from the zone holding its newest copy.
+func (z *xlZones) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer, etag string) error {
+	unlock, err := z.rlockDrainedObject(bucket, object)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	zone, err := z.getObjectZone(bucket, object)
+	if err != nil {
+		return err
+	}
+	return zone.GetObject(bucket, object, startOffset, length, writer, etag)
+}
+
+// PutObject - writes an object to the zone holding it, new objects and
+// objects of drained zones to the zone their name hashes to.
+func (z *xlZones) PutObject(bucket string, object string, data *hash.Reader, metadata map[string]string) (objInfo ObjectInfo, err error) {
+	unlock, err := z.lockDrainedObject(bucket, object)
+	if err != nil {
+		return objInfo, err
+	}
+	defer unlock()
+
+	zone, drained, err := z.getWriteZone(bucket, object)
+	if err != nil {
+		return objInfo, err
+	}
+	if err = checkDrainedObjectLock(drained, bucket, object); err != nil {
+		return objInfo, err
+	}
+	objInfo, err = zone.PutObject(bucket, object, data, metadata)
+	if err != nil {
+		return objInfo, err
+	}
+	if err = removeDrainedObject(drained, bucket, object); err != nil {
+		return objInfo, err
+	}
+	return objInfo, z.deleteOtherObjects(bucket, object, zone, objInfo.ModTime)
+}
+
+// GetObjectInfo - reads object metadata from the zone holding its
+// newest copy.
+func (z *xlZones) GetObjectInfo(bucket, object string) (objInfo ObjectInfo, err error) {
+	unlock, err := z.rlockDrainedObject(bucket, object)
+	if err != nil {
+		return objInfo, err
+	}
+	defer unlock()
+
+	zone, err := z.getObjectZone(bucket, object)
+	if err != nil {
+		return objInfo, err
+	}
+	return zone.GetObjectInfo(bucket, object)
+}
+
+// DeleteObject - deletes an object from every zone holding a copy, an
+// older copy left behind would be read instead.
+func (z *xlZones) DeleteObject(bucket string, object string) (err error) {
+	unlock, err := z.lockDrainedObject(bucket, object)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if isMinioMetaBucketName(bucket) {
+		return z.zones[0].DeleteObject(bucket, object)
+	}
+	deleted := false
+	for _, zone := range z.zones {
+		if derr := zone.DeleteObject(bucket, object); derr != nil {
+			if isErrObjectNotFound(derr) {
+				err = derr
+				continue
+			}
+			return derr
+		}
+		deleted = true
+	}
+	if !deleted {
+		return err
+	}
+	return nil
+}
+
+// DeleteObjects - deletes objects from every zone holding a copy, one
+// by one while zones are drained. An object is deleted unless a zone
+// fails to delete its copy, and not found if no zone holds one.
+func (z *xlZones) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	if isMinioMetaBucketName(bucket) {
+		return z.zones[0].DeleteObjects(bucket, objects)
+	}
+	errs := make([]error, len(objects))
+	if z.isDecommissioning() {
+		for index, object := range objects {
+			errs[index] = z.DeleteObject(bucket, object)
+		}
+		return errs, nil
+	}
+
+	notFoundErrs := make([]error, len(objects))
+	deleted := make([]bool, len(objects))
+	for _, zone := range z.zones {
+		zoneErrs, err := zone.DeleteObjects(bucket, objects)
+		if err != nil {
+			return nil, err
+		}
+		for index, zoneErr := range zoneErrs {
+			switch {
+			case zoneErr == nil:
+				deleted[index] = true
+			case isErrObjectNotFound(zoneErr):
+				notFoundErrs[index] = zoneErr
+			case errs[index] == nil:
+				errs[index] = zoneErr
+			}
+		}
+	}
+	for index := range objects {
+		if errs[index] == nil && !deleted[index] {
+			errs[index] = notFoundErrs[index]
+		}
+	}
+	return errs, nil
+}
+
+// CopyObject - copies an object within its zone, or streams it to the
+// zone holding or receiving the destination.
+func (z *xlZones) CopyObject(srcBucket, srcObject, destBucket, destObject string, srcInfo ObjectInfo) (objInfo ObjectInfo, err error) {
+	unlock, err := z.lockDrainedObject(destBucket, destObject)
+	if err != nil {
+		return objInfo, err
+	}
+	defer unlock()
+	if pathJoin(srcBucket, srcObject) != pathJoin(destBucket, destObject) {
+		runlock, rerr := z.rlockDrainedObject(srcBucket, srcObject)
+		if rerr != nil {
+			return objInfo, rerr
+		}
+		defer runlock()
+	}
+
+	srcZone, err := z.getObjectZone(srcBucket, srcObject)
+	if err != nil {
+		return objInfo, err
+	}
+	destZone, drained, err := z.getWriteZone(destBucket, destObject)
+	if err != nil {
+		return objInfo, err
+	}
+	if err = checkDrainedObjectLock(drained, destBucket, destObject); err != nil {
+		return objInfo, err
+	}
+	if srcZone == destZone {
+		objInfo, err = srcZone.CopyObject(srcBucket, srcObject, destBucket, destObject, srcInfo)
+		if err != nil {
+			return objInfo, err
+		}
+		return objInfo, z.deleteOtherObjects(destBucket, destObject, destZone, objInfo.ModTime)
+	}
+
+	go func() {
+		if gerr := srcZone.GetObject(srcBucket, srcObject, 0, srcInfo.Size, srcInfo.Writer, srcInfo.ETag); gerr != nil {
+			if gerr = srcInfo.Writer.Close(); gerr != nil {
+				errorIf(gerr, "Unable to read the object %s/%s.", srcBucket, srcObject)
+			}
+			return
+		}
+		// Close writer explicitly signalling we wrote all data.
+		if gerr := srcInfo.Writer.Close(); gerr != nil {
+			errorIf(gerr, "Unable to read the object %s/%s.", srcBucket, srcObject)
+			return
+		}
+	}()
+
+	objInfo, err = destZone.PutObject(destBucket, destObject, srcInfo.Reader, srcInfo.UserDefined)
+	if err != nil {
+		return objInfo, err
+	}
+	if err = removeDrainedObject(drained, destBucket, destObject); err != nil {
+		return objInfo, err
+	}
+	return objInfo, z.deleteOtherObjects(destBucket, destObject, destZone, objInfo.ModTime)
+}
+
+// ListMultipartUploads - lists the uploads of the object given as
+// prefix on all zones, ordered by the time they were initiated. An
+// object has uploads on several zones when it was written to another
+// zone while they were in progress.
+func (z *xlZones) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (result ListMultipartsInfo, err error) {
+	if isMinioMetaBucketName(bucket) {
+		return z.zones[0].ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
+	}
+
+	// Upload ID markers are only known to the zone of their upload,
+	// all uploads of every zone are listed and merged.
+	var uploads []MultipartInfo
+	for _, zone := range z.zones {
+		zoneMarker := ""
+		for {
+			zoneResult, err := zone.ListMultipartUploads(bucket, prefix, keyMarker, zoneMarker, delimiter, maxUploadsList)
+			if err != nil {
+				return result, err
+			}
+			uploads = append(uploads, zoneResult.Uploads...)
+			if !zoneResult.IsTruncated || len(zoneResult.Uploads) == 0 {
+				break
+			}
+			zoneMarker = zoneResult.NextUploadIDMarker
+		}
+	}
+	return mergeZonesMultipartUploads(uploads, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads), nil
+}
+
+// mergeZonesMultipartUploads - returns the first maxUploads uploads of
+// an object after the upload ID marker, ordered by the time they were
+// initiated.
+func mergeZonesMultipartUploads(uploads []MultipartInfo, object, keyMarker, uploadIDMarker, delimiter string, maxUploads int) ListMultipartsInfo {
+	sort.Slice(uploads, func(i, j int) bool {
+		if uploads[i].Initiated.Equal(uploads[j].Initiated) {
+			return uploads[i].UploadID < uploads[j].UploadID
+		}
+		return uploads[i].Initiated.Before(uploads[j].Initiated)
+	})
+	if uploadIDMarker != "" {
+		// Nothing is listed after an unknown marker, like by a
+		// single zone.
+		index := len(uploads)
+		for i, upload := range uploads {
+			if upload.UploadID == uploadIDMarker {
+				index = i + 1
+				break
+			}
+		}
+		uploads = uploads[index:]
+	}
+
+	result := ListMultipartsInfo{
+		KeyMarker:      keyMarker,
+		UploadIDMarker: uploadIDMarker,
+		MaxUploads:     maxUploads,
+		Prefix:         object,
+		Delimiter:      delimiter,
+	}
+	if maxUploads > 0 && len(uploads) > maxUploads {
+		uploads = uploads[:maxUploads]
+		result.IsTruncated = true
+		result.NextKeyMarker = object
+		result.NextUploadIDMarker = uploads[len(uploads)-1].UploadID
+	}
+	result.Uploads = uploads
+	return result
+}
+
+// Initiate a new multipart upload on the zone holding the object, new
+// objects and objects of drained zones on the zone their name hashes
+// to.
+func (z *xlZones) NewMultipartUpload(bucket, object string, metadata map[string]string) (uploadID string, err error) {
+	zone, drained, err := z.getWriteZone(bucket, object)
+	if err != nil {
+		return "", err
+	}
+	if err = checkDrainedObjectLock(drained, bucket, object); err != nil {
+		return "", err
+	}
+	return zone.NewMultipartUpload(bucket, object, metadata)
+}
+
+// Copies a part of an object to the zone holding the upload.
+func (z *xlZones) CopyObjectPart(srcBucket, srcObject, destBucket, destObject string, uploadID string, partID int,
+	startOffset int64, length int64, srcInfo ObjectInfo) (partInfo PartInfo, err error) {
+
+	unlock, err := z.rlockDrainedObject(srcBucket, srcObject)
+	if err != nil {
+		return partInfo, err
+	}
+	defer unlock()
+
+	srcZone, err := z.getObjectZone(srcBucket, srcObject)
+	if err != nil {
+		return partInfo, err
+	}
+	destZone := z.getUploadZone(destBucket, destObject, uploadID)
+	if srcZone == destZone {
+		return srcZone.CopyObjectPart(srcBucket, srcObject, destBucket, destObject, uploadID, partID, startOffset, length, srcInfo)
+	}
+
+	// Initialize pipe to stream from source.
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		if gerr := srcZone.GetObject(srcBucket, srcObject, startOffset, length, pipeWriter, srcInfo.ETag); gerr != nil {
+			errorIf(gerr, "Unable to read the object `%s/%s`.", srcBucket, srcObject)
+			pipeWriter.CloseWithError(toObjectErr(gerr, srcBucket, srcObject))
+			return
+		}
+
+		// Close writer explicitly signalling we wrote all data.
+		pipeWriter.Close()
+	}()
+
+	hashReader, err := hash.NewReader(pipeReader, length, "", "")
+	if err != nil {
+		pipeReader.CloseWithError(err)
+		return partInfo, toObjectErr(errors.Trace(err), destBucket, destObject)
+	}
+
+	partInfo, err = destZone.PutObjectPart(context.Background(), destBucket, destObject, uploadID, partID, hashReader)
+	if err != nil {
+		pipeReader.CloseWithError(err)
+		return partInfo, err
+	}
+
+	// Close the pipe
+	pipeReader.Close()
+
+	return partInfo, nil
+}
+
+// PutObjectPart - writes part of an object to the zone holding the upload.
+func (z *xlZones) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, data *hash.Reader) (info PartInfo, err error) {
+	return z.getUploadZone(bucket, object, uploadID).PutObjectPart(ctx, bucket, object, uploadID, partID, data)
+}
+
+// ListObjectParts - lists the uploaded parts from the zone holding the upload.
+func (z *xlZones) ListObjectParts(bucket, object, uploadID string, partNumberMarker int, maxParts int) (result ListPartsInfo, err error) {
+	return z.getUploadZone(bucket, object, uploadID).ListObjectParts(bucket, object, uploadID, partNumberMarker, maxParts)
+}
+
+// Aborts an in-progress multipart operation on the zone holding the upload.
+func (z *xlZones) AbortMultipartUpload(bucket, object, uploadID string) error {
+	return z.getUploadZone(bucket, object, uploadID).AbortMultipartUpload(bucket, object, uploadID)
+}
+
+// CompleteMultipartUpload - completes a pending multipart transaction
+// on the zone holding the upload.
+func (z *xlZones) CompleteMultipartUpload(ctx context.Context, bucket, object, uploadID string, uploadedParts []CompletePart) (objInfo ObjectInfo, err error) {
+	unlock, err := z.lockDrainedObject(bucket, object)
+	if err != nil {
+		return objInfo, err
+	}
+	defer unlock()
+
+	zone := z.getUploadZone(bucket, object, uploadID)
+	objInfo, err = zone.CompleteMultipartUpload(ctx, bucket, object, uploadID, uploadedParts)
+	if err != nil {
+		return objInfo, err
+	}
+	return objInfo, z.deleteOtherObjects(bucket, object, zone, objInfo.ModTime)
+}
+
+// HealFormat - heals the formats of all zones.
+func (z *xlZones) HealFormat(dryRun bool) (madmin.HealResultItem, error) {
+	res := madmin.HealResultItem{
+		Type:   madmin.HealItemMetadata,
+		Detail: "disk-format",
+	}
+	for _, zone := range z.zones {
+		zoneRes, err := zone.HealFormat(dryRun)
+		if err != nil {
+			return madmin.HealResultItem{}, err
+		}
+		res.DiskCount += zoneRes.DiskCount
+		res.SetCount += zoneRes.SetCount
+		res.Before.Drives = append(res.Before.Drives, zoneRes.Before.Drives...)
+		res.After.Drives = append(res.After.Drives, zoneRes.After.Drives...)
+	}
+	return res, nil
+}
+
+// HealBucket - heals inconsistent buckets and bucket metadata on all zones.
+func (z *xlZones) HealBucket(bucket string, dryRun bool) (results []madmin.HealResultItem, err error) {
+	for _, zone := range z.zones {
+		zoneResults, err := zone.HealBucket(bucket, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, zoneResults...)
+	}
+	return results, nil
+}
+
+// HealObject - heals inconsistent object on the zone holding its newest
+// copy.
+func (z *xlZones) HealObject(bucket, object string, dryRun bool) (madmin.HealResultItem, error) {
+	zone, err := z.getObjectZone(bucket, object)
+	if err != nil {
+		return madmin.HealResultItem{}, err
+	}
+	return zone.HealObject(bucket, object, dryRun)
+}
+
+// Lists all buckets which need healing on any zone.
+func (z *xlZones) ListBucketsHeal() ([]BucketInfo, error) {
+	listBuckets := []BucketInfo{}
+	healBuckets := set.NewStringSet()
+	for _, zone := range z.zones {
+		buckets, err := zone.ListBucketsHeal()
+		if err != nil {
+			return nil, err
+		}
+		for _, bucketInfo := range buckets {
+			if !healBuckets.Contains(bucketInfo.Name) {
+				healBuckets.Add(bucketInfo.Name)
+				listBuckets = append(listBuckets, bucketInfo)
+			}
+		}
+	}
+	return listBuckets, nil
+}
+
+// ListObjectsHeal - lists the objects needing healing on all zones,
+// merged by name.
+func (z *xlZones) ListObjectsHeal(bucket, prefix, marker, delimiter string, maxKeys int) (loi ListObjectsInfo, err error) {
+	// Over flowing count - reset to maxObjectList.
+	if maxKeys < 0 || maxKeys > maxObjectList {
+		maxKeys = maxObjectList
+	}
+
+	results := make([]ListObjectsInfo, len(z.zones))
+	for i, zone := range z.zones {
+		results[i], err = zone.ListObjectsHeal(bucket, prefix, marker, delimiter, maxKeys)
+		if err != nil {
+			return loi, err
+		}
+	}
+	return mergeZonesListObjects(results, maxKeys), nil
+}
+
+// ListLocks from all zones, aggregate them and return.
+func (z *xlZones) ListLocks(bucket, prefix string, duration time.Duration) (lockInfo []VolumeLockInfo, err error) {
+	for _, zone := range z.zones {
+		zoneLockInfo, err := zone.ListLocks(bucket, prefix, duration)
+		if err != nil {
+			return nil, err
+		}
+		lockInfo = append(lockInfo, zoneLockInfo...)
+	}
+	return lockInfo, nil
+}
+
+// Clear all requested locks on all zones.
+func (z *xlZones) ClearLocks(lockInfo []VolumeLockInfo) error {
+	for _, zone := range z.zones {
+		zone.ClearLocks(lockInfo)
+	}
+	return nil
+}
+
+// isWriteQuorumOnline - returns true if enough disks of every set of
+// all zones are online for write quorum.
+func (z *xlZones) isWriteQuorumOnline() bool {
+	for _, zone := range z.zones {
+		if !zone.isWriteQuorumOnline() {
+			return false
+		}
+	}
+	return true
+}
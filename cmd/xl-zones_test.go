@@ -0,0 +1,302 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// Tests splitting the command line into zones.
+func TestCreateServerZones(t *testing.T) {
+	testCases := []struct {
+		args    []string
+		zones   [][2]int
+		success bool
+	}{
+		{[]string{"/export{1...8}"}, [][2]int{{1, 8}}, true},
+		{[]string{"/export{1...8}", "+", "/export{9...24}"}, [][2]int{{1, 8}, {1, 16}}, true},
+		{[]string{"/export{1...4}", "+", "/export{5...8}", "+", "/export{9...12}"}, [][2]int{{1, 4}, {1, 4}, {1, 4}}, true},
+		{[]string{"/export{1...8}", "+", "/export{5...12}"}, nil, false},
+		{[]string{"/export{1...8}", "+"}, nil, false},
+		{[]string{"/export{1...8}", "+", "/export9"}, nil, false},
+		{[]string{"/export{1...8}", "+", "/export{9...11}"}, nil, false},
+	}
+	for i, testCase := range testCases {
+		_, endpoints, zones, _, err := createServerZones(":9000", testCase.args...)
+		if testCase.success != (err == nil) {
+			t.Fatalf("Test %d: Expected success %v but got %v", i+1, testCase.success, err)
+		}
+		if !testCase.success {
+			continue
+		}
+		if len(zones) != len(testCase.zones) {
+			t.Fatalf("Test %d: Expected %d zones but got %d", i+1, len(testCase.zones), len(zones))
+		}
+		count := 0
+		for j, zone := range zones {
+			if zone.setCount != testCase.zones[j][0] || zone.drivesPerSet != testCase.zones[j][1] {
+				t.Fatalf("Test %d: Expected zone %d with %d sets of %d drives but got %d of %d", i+1, j+1,
+					testCase.zones[j][0], testCase.zones[j][1], zone.setCount, zone.drivesPerSet)
+			}
+			if len(zone.endpoints) != zone.setCount*zone.drivesPerSet || zone.endpoints[0] != endpoints[count] {
+				t.Fatalf("Test %d: Unexpected endpoints %s of zone %d", i+1, zone.endpoints, j+1)
+			}
+			count += len(zone.endpoints)
+		}
+		if count != len(endpoints) {
+			t.Fatalf("Test %d: Expected %d endpoints in zones but got %d", i+1, len(endpoints), count)
+		}
+	}
+}
+
+// Tests placing, reading and listing objects of several zones, and
+// adding a zone to a server.
+func TestXLZones(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootPath)
+
+	disks, err := getRandomDisks(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	zones := []zoneEndpoints{
+		{mustGetNewEndpointList(disks[:4]...), 1, 4},
+		{mustGetNewEndpointList(disks[4:]...), 1, 4},
+	}
+
+	// Start with the first zone only.
+	obj, err := newXLZones(zones[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = obj.MakeBucketWithLocation("bucket", ""); err != nil {
+		t.Fatal(err)
+	}
+	putObject := func(obj ObjectLayer, object, content string) {
+		if _, err := obj.PutObject("bucket", object, mustGetHashReader(t, bytes.NewReader([]byte(content)), int64(len(content)), "", ""), nil); err != nil {
+			t.Fatalf("Unable to put %s: %v", object, err)
+		}
+	}
+	expectContent := func(obj ObjectLayer, object, content string) {
+		var buffer bytes.Buffer
+		if err := obj.GetObject("bucket", object, 0, int64(len(content)), &buffer, ""); err != nil {
+			t.Fatalf("Unable to get %s: %v", object, err)
+		}
+		if buffer.String() != content {
+			t.Fatalf("Expected %s to be %q but got %q", object, content, buffer.String())
+		}
+	}
+
+	const count = 20
+	for i := 0; i < count; i++ {
+		putObject(obj, fmt.Sprintf("old/object-%d", i), "old")
+	}
+
+	// Add the second zone, the bucket is created on it.
+	obj, err = newXLZones(zones)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := obj.(*xlZones)
+	if _, err = z.zones[1].GetBucketInfo("bucket"); err != nil {
+		t.Fatalf("Expected the bucket on the new zone: %v", err)
+	}
+
+	// New objects are spread over both zones, existing objects stay
+	// on the first zone when overwritten.
+	placed := make([]int, len(z.zones))
+	for i := 0; i < count; i++ {
+		object := fmt.Sprintf("new/object-%d", i)
+		putObject(obj, object, "new")
+		for j, zone := range z.zones {
+			if _, err = zone.GetObjectInfo("bucket", object); err == nil {
+				placed[j]++
+			}
+		}
+	}
+	if placed[0] == 0 || placed[1] == 0 || placed[0]+placed[1] != count {
+		t.Fatalf("Expected new objects on both zones but got %v", placed)
+	}
+	putObject(obj, "old/object-0", "updated")
+	expectContent(obj, "old/object-0", "updated")
+	if _, err = z.zones[1].GetObjectInfo("bucket", "old/object-0"); err == nil {
+		t.Fatal("Expected the overwritten object to stay on the first zone")
+	}
+
+	// Listings merge both zones.
+	result, err := obj.ListObjects("bucket", "", "", slashSeparator, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Prefixes) != 2 || result.Prefixes[0] != "new/" || result.Prefixes[1] != "old/" {
+		t.Fatalf("Unexpected prefixes %v", result.Prefixes)
+	}
+	var names []string
+	marker := ""
+	for {
+		result, err = obj.ListObjects("bucket", "new/", marker, "", 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, objInfo := range result.Objects {
+			names = append(names, objInfo.Name)
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+	if len(names) != count {
+		t.Fatalf("Expected %d objects but listed %d", count, len(names))
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Fatalf("Listing is not sorted: %v", names)
+		}
+	}
+
+	// Objects are copied and uploaded in parts across zones.
+	for i := 0; i < count; i++ {
+		object := fmt.Sprintf("new/object-%d", i)
+		srcInfo, err := obj.GetObjectInfo("bucket", object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pipeReader, pipeWriter := io.Pipe()
+		srcInfo.Writer = pipeWriter
+		srcInfo.Reader = mustGetHashReader(t, pipeReader, srcInfo.Size, "", "")
+		if _, err = obj.CopyObject("bucket", object, "bucket", "copy/"+object, srcInfo); err != nil {
+			t.Fatalf("Unable to copy %s: %v", object, err)
+		}
+		expectContent(obj, "copy/"+object, "new")
+
+		uploadID, err := obj.NewMultipartUpload("bucket", "upload/"+object, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		partInfo, err := obj.CopyObjectPart("bucket", object, "bucket", "upload/"+object, uploadID, 1, 0, srcInfo.Size, srcInfo)
+		if err != nil {
+			t.Fatalf("Unable to copy part of %s: %v", object, err)
+		}
+		if _, err = obj.CompleteMultipartUpload(context.Background(), "bucket", "upload/"+object, uploadID,
+			[]CompletePart{{PartNumber: 1, ETag: partInfo.ETag}}); err != nil {
+			t.Fatal(err)
+		}
+		expectContent(obj, "upload/"+object, "new")
+	}
+
+	// Writes go to the zone holding the newest copy and remove copies
+	// of the object written to other zones.
+	putObject(z.zones[1], "old/object-1", "stale")
+	putObject(obj, "old/object-1", "updated")
+	expectContent(obj, "old/object-1", "updated")
+	if _, err = z.zones[0].GetObjectInfo("bucket", "old/object-1"); err == nil {
+		t.Fatal("Expected the copy of the object on the first zone to be deleted")
+	}
+
+	// Copies written after the copy of the write are kept.
+	putObject(z.zones[1], "old/object-3", "newer")
+	z.deleteOtherObjects("bucket", "old/object-3", z.zones[0], UTCNow().Add(-time.Hour))
+	if _, err = z.zones[1].GetObjectInfo("bucket", "old/object-3"); err != nil {
+		t.Fatalf("Expected the newer copy on the second zone to be kept: %v", err)
+	}
+
+	// The newest copy is read, a zone which cannot be read may hold a
+	// newer copy and fails reads and writes.
+	expectContent(obj, "old/object-3", "newer")
+	var getDisks []func() []StorageAPI
+	for _, set := range z.zones[1].sets {
+		getDisks = append(getDisks, set.getDisks)
+		n := len(set.getDisks())
+		set.getDisks = func() []StorageAPI { return make([]StorageAPI, n) }
+	}
+	if _, err = obj.GetObjectInfo("bucket", "old/object-0"); err == nil {
+		t.Fatal("Expected reading an object while a zone is offline to fail")
+	}
+	content := []byte("offline")
+	if _, err = obj.PutObject("bucket", "old/object-0", mustGetHashReader(t, bytes.NewReader(content), int64(len(content)), "", ""), nil); err == nil {
+		t.Fatal("Expected writing an object while a zone is offline to fail")
+	}
+	for i, set := range z.zones[1].sets {
+		set.getDisks = getDisks[i]
+	}
+
+	// Deleting removes the copies on all zones.
+	if err = obj.DeleteObject("bucket", "old/object-3"); err != nil {
+		t.Fatal(err)
+	}
+	for _, zone := range z.zones {
+		if _, err = zone.GetObjectInfo("bucket", "old/object-3"); !isErrObjectNotFound(err) {
+			t.Fatalf("Expected all copies to be deleted, got %v", err)
+		}
+	}
+	putObject(z.zones[1], "old/object-3", "newer")
+	errs, err := obj.DeleteObjects("bucket", []string{"old/object-3", "old/object-missing"})
+	if err != nil || errs[0] != nil || !isErrObjectNotFound(errs[1]) {
+		t.Fatalf("Unexpected result of deleting objects %v, %v", errs, err)
+	}
+	for _, zone := range z.zones {
+		if _, err = zone.GetObjectInfo("bucket", "old/object-3"); !isErrObjectNotFound(err) {
+			t.Fatalf("Expected all copies to be deleted, got %v", err)
+		}
+	}
+
+	// Uploads of an object are listed from all zones.
+	var uploadIDs []string
+	for _, zone := range z.zones {
+		uploadID, err := zone.NewMultipartUpload("bucket", "old/object-2", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uploadIDs = append(uploadIDs, uploadID)
+	}
+	uploadIDMarker := ""
+	for i, uploadID := range uploadIDs {
+		uploads, err := obj.ListMultipartUploads("bucket", "old/object-2", "", uploadIDMarker, "", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(uploads.Uploads) != 1 || uploads.Uploads[0].UploadID != uploadID || uploads.IsTruncated != (i < len(uploadIDs)-1) {
+			t.Fatalf("Expected upload %s but got %+v", uploadID, uploads)
+		}
+		uploadIDMarker = uploads.NextUploadIDMarker
+	}
+	for i, zone := range z.zones {
+		if err = zone.AbortMultipartUpload("bucket", "old/object-2", uploadIDs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Deleting the bucket requires it to be empty on all zones.
+	if err = obj.DeleteBucket("bucket"); err == nil {
+		t.Fatal("Expected deleting a bucket with objects to fail")
+	}
+	if _, err = z.zones[0].GetBucketInfo("bucket"); err != nil {
+		t.Fatalf("Expected the bucket to be kept on the first zone: %v", err)
+	}
+}
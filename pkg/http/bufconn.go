@@ -19,9 +19,16 @@ package http
 import (
 	"bufio"
 	"net"
+	"sync/atomic"
 	"time"
 )
 
+// throughputWindow - bytes transferred in the current window of a connection.
+type throughputWindow struct {
+	start time.Time
+	bytes int64
+}
+
 // BufConn - is a generic stream-oriented network connection supporting buffered reader and read/write timeout.
 type BufConn struct {
 	QuirkConn
@@ -30,27 +37,91 @@ type BufConn struct {
 	writeTimeout           time.Duration // sets the write timeout in the connection.
 	updateBytesReadFunc    func(int)     // function to be called to update bytes read.
 	updateBytesWrittenFunc func(int)     // function to be called to update bytes written.
+
+	minThroughput    int64            // minimum bytes per second of limited reads and writes, 0 disables it.
+	throughputWindow time.Duration    // duration over which the minimum throughput is enforced.
+	limitReads       int32            // set while a request body is read, accessed atomically.
+	limitWrites      int32            // set while a request is handled, accessed atomically.
+	readWindow       throughputWindow // bytes read in the current window.
+	writeWindow      throughputWindow // bytes written in the current window.
+
+	closeFunc func() // function to be called on Close.
+}
+
+// deadline - returns the deadline of a read or write, timeout from now
+// or none if timeout is 0. While limited, the transfer has to keep up
+// the minimum throughput over every window, so the deadline is at most
+// the end of the current window until enough bytes were transferred in
+// it. A new window starts with the first limited read or write after
+// the current one ended.
+func (c *BufConn) deadline(w *throughputWindow, timeout time.Duration, limited bool) time.Time {
+	now := time.Now().UTC()
+	var deadline time.Time
+	if timeout != 0 {
+		deadline = now.Add(timeout)
+	}
+	if !limited || c.throughputWindow <= 0 {
+		return deadline
+	}
+
+	end := w.start.Add(c.throughputWindow)
+	if !now.Before(end) {
+		w.start, w.bytes = now, 0
+		end = now.Add(c.throughputWindow)
+	}
+	minBytes := int64(float64(c.minThroughput) * c.throughputWindow.Seconds())
+	if w.bytes < minBytes && (deadline.IsZero() || end.Before(deadline)) {
+		deadline = end
+	}
+	return deadline
 }
 
 // Sets read timeout
 func (c *BufConn) setReadTimeout() {
-	if c.readTimeout != 0 && c.canSetReadDeadline() {
+	if !c.canSetReadDeadline() {
+		return
+	}
+	if c.minThroughput > 0 {
+		// The deadline is never in the past, it is zero when there is
+		// no timeout to clear the end of a window set before.
+		c.Conn.SetReadDeadline(c.deadline(&c.readWindow, c.readTimeout, atomic.LoadInt32(&c.limitReads) != 0))
+	} else if c.readTimeout != 0 {
 		c.SetReadDeadline(time.Now().UTC().Add(c.readTimeout))
 	}
 }
 
 func (c *BufConn) setWriteTimeout() {
-	if c.writeTimeout != 0 {
+	if c.minThroughput > 0 {
+		c.SetWriteDeadline(c.deadline(&c.writeWindow, c.writeTimeout, atomic.LoadInt32(&c.limitWrites) != 0))
+	} else if c.writeTimeout != 0 {
 		c.SetWriteDeadline(time.Now().UTC().Add(c.writeTimeout))
 	}
 }
 
+// setLimitReads - enforces the minimum throughput on reads while set.
+func (c *BufConn) setLimitReads(limit bool) {
+	atomic.StoreInt32(&c.limitReads, boolToInt32(limit))
+}
+
+// setLimitWrites - enforces the minimum throughput on writes while set.
+func (c *BufConn) setLimitWrites(limit bool) {
+	atomic.StoreInt32(&c.limitWrites, boolToInt32(limit))
+}
+
+func boolToInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 // RemoveTimeout - removes all configured read and write
 // timeouts. Used by callers which control net.Conn behavior
 // themselves.
 func (c *BufConn) RemoveTimeout() {
 	c.readTimeout = 0
 	c.writeTimeout = 0
+	c.minThroughput = 0
 	// Unset read/write timeouts, since we use **bufio** it is not
 	// guaranteed that the underlying Peek/Read operation in-fact
 	// indeed performed a Read() operation on the network. With
@@ -69,6 +140,7 @@ func (c *BufConn) Peek(n int) ([]byte, error) {
 func (c *BufConn) Read(b []byte) (n int, err error) {
 	c.setReadTimeout()
 	n, err = c.bufReader.Read(b)
+	c.readWindow.bytes += int64(n)
 	if err == nil && c.updateBytesReadFunc != nil {
 		c.updateBytesReadFunc(n)
 	}
@@ -80,6 +152,7 @@ func (c *BufConn) Read(b []byte) (n int, err error) {
 func (c *BufConn) Write(b []byte) (n int, err error) {
 	c.setWriteTimeout()
 	n, err = c.Conn.Write(b)
+	c.writeWindow.bytes += int64(n)
 	if err == nil && c.updateBytesWrittenFunc != nil {
 		c.updateBytesWrittenFunc(n)
 	}
@@ -87,14 +160,25 @@ func (c *BufConn) Write(b []byte) (n int, err error) {
 	return n, err
 }
 
+// Close - closes the connection.
+func (c *BufConn) Close() error {
+	if c.closeFunc != nil {
+		c.closeFunc()
+	}
+	return c.Conn.Close()
+}
+
 // newBufConn - creates a new connection object wrapping net.Conn.
 func newBufConn(c net.Conn, readTimeout, writeTimeout time.Duration,
+	minThroughput int64, throughputWindow time.Duration,
 	updateBytesReadFunc, updateBytesWrittenFunc func(int)) *BufConn {
 	return &BufConn{
 		QuirkConn:              QuirkConn{Conn: c},
 		bufReader:              bufio.NewReader(c),
 		readTimeout:            readTimeout,
 		writeTimeout:           writeTimeout,
+		minThroughput:          minThroughput,
+		throughputWindow:       throughputWindow,
 		updateBytesReadFunc:    updateBytesReadFunc,
 		updateBytesWrittenFunc: updateBytesWrittenFunc,
 	}
@@ -48,7 +48,7 @@ func TestBuffConnReadTimeout(t *testing.T) {
 		if terr != nil {
 			t.Fatalf("failed to accept new connection. %v", terr)
 		}
-		bufconn := newBufConn(tcpConn, 1*time.Second, 1*time.Second, nil, nil)
+		bufconn := newBufConn(tcpConn, 1*time.Second, 1*time.Second, 0, 0, nil, nil)
 		defer bufconn.Close()
 
 		// Read a line
@@ -109,3 +109,61 @@ func TestBuffConnReadTimeout(t *testing.T) {
 
 	wg.Wait()
 }
+
+// Test bufconn fails limited reads stalled below the minimum throughput
+// once the throughput window ends, but not reads which keep it up or
+// are not limited.
+func TestBuffConnMinThroughput(t *testing.T) {
+	const window = 200 * time.Millisecond
+	newPipe := func() (*BufConn, net.Conn) {
+		server, client := net.Pipe()
+		return newBufConn(server, 10*time.Second, 10*time.Second, 100, window, nil, nil), client
+	}
+
+	// Stalled limited read fails with a timeout.
+	bufconn, client := newPipe()
+	go io.WriteString(client, "hello")
+	bufconn.setLimitReads(true)
+	b := make([]byte, 5)
+	if _, err := io.ReadFull(bufconn, b); err != nil {
+		t.Fatalf("failed to read from client. %v", err)
+	}
+	start := time.Now()
+	_, err := bufconn.Read(b)
+	if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*window {
+		t.Fatalf("expected stalled read to fail after the window, took %s", elapsed)
+	}
+	bufconn.Close()
+	client.Close()
+
+	// Limited reads keeping up the minimum throughput succeed.
+	bufconn, client = newPipe()
+	go func() {
+		for i := 0; i < 10; i++ {
+			io.WriteString(client, "0123456789")
+			time.Sleep(window / 4)
+		}
+	}()
+	bufconn.setLimitReads(true)
+	b = make([]byte, 100)
+	if _, err = io.ReadFull(bufconn, b); err != nil {
+		t.Fatalf("failed to read from client. %v", err)
+	}
+	bufconn.Close()
+	client.Close()
+
+	// Reads which are not limited wait up to the read timeout.
+	bufconn, client = newPipe()
+	defer bufconn.Close()
+	defer client.Close()
+	go func() {
+		time.Sleep(2 * window)
+		io.WriteString(client, "hello")
+	}()
+	if _, err = io.ReadFull(bufconn, make([]byte, 5)); err != nil {
+		t.Fatalf("failed to read from client. %v", err)
+	}
+}
@@ -75,6 +75,35 @@ type acceptResult struct {
 	err  error
 }
 
+// connMap - open connections keyed by their remote address, which is
+// the RemoteAddr of their requests.
+type connMap struct {
+	mutex sync.Mutex
+	conns map[string]*BufConn
+}
+
+// add - adds a connection until it is closed.
+func (m *connMap) add(conn *BufConn) {
+	addr := conn.RemoteAddr().String()
+	conn.closeFunc = func() {
+		m.mutex.Lock()
+		if m.conns[addr] == conn {
+			delete(m.conns, addr)
+		}
+		m.mutex.Unlock()
+	}
+	m.mutex.Lock()
+	m.conns[addr] = conn
+	m.mutex.Unlock()
+}
+
+// get - returns the connection of a remote address, nil if none.
+func (m *connMap) get(addr string) *BufConn {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.conns[addr]
+}
+
 // httpListener - HTTP listener capable of handling multiple server addresses.
 type httpListener struct {
 	mutex                  sync.Mutex         // to guard Close() method.
@@ -85,9 +114,12 @@ type httpListener struct {
 	tcpKeepAliveTimeout    time.Duration
 	readTimeout            time.Duration
 	writeTimeout           time.Duration
+	minThroughput          int64
+	throughputWindow       time.Duration
 	updateBytesReadFunc    func(int)                           // function to be called to update bytes read in BufConn.
 	updateBytesWrittenFunc func(int)                           // function to be called to update bytes written in BufConn.
 	errorLogFunc           func(error, string, ...interface{}) // function to be called on errors.
+	conns                  *connMap                            // accepted connections, only kept to enforce minThroughput.
 }
 
 // isRoutineNetErr returns true if error is due to a network timeout,
@@ -134,6 +166,7 @@ func (listener *httpListener) start() {
 		tcpConn.SetKeepAlivePeriod(listener.tcpKeepAliveTimeout)
 
 		bufconn := newBufConn(tcpConn, listener.readTimeout, listener.writeTimeout,
+			listener.minThroughput, listener.throughputWindow,
 			listener.updateBytesReadFunc, listener.updateBytesWrittenFunc)
 
 		// Peek bytes of maximum length of all HTTP methods.
@@ -184,6 +217,7 @@ func (listener *httpListener) start() {
 
 			// Check whether the connection contains HTTP request or not.
 			bufconn = newBufConn(tlsConn, listener.readTimeout, listener.writeTimeout,
+				listener.minThroughput, listener.throughputWindow,
 				listener.updateBytesReadFunc, listener.updateBytesWrittenFunc)
 
 			// Peek bytes of maximum length of all HTTP methods.
@@ -241,6 +275,9 @@ func (listener *httpListener) start() {
 func (listener *httpListener) Accept() (conn net.Conn, err error) {
 	result, ok := <-listener.acceptCh
 	if ok {
+		if bufconn, isBufConn := result.conn.(*BufConn); isBufConn && listener.conns != nil {
+			listener.conns.add(bufconn)
+		}
 		return result.conn, result.err
 	}
 
@@ -264,6 +301,15 @@ func (listener *httpListener) Close() (err error) {
 	return nil
 }
 
+// getConn - returns the accepted connection of a remote address, nil
+// if connections are not kept.
+func (listener *httpListener) getConn(addr string) *BufConn {
+	if listener.conns == nil {
+		return nil
+	}
+	return listener.conns.get(addr)
+}
+
 // Addr - net.Listener interface compatible method returns net.Addr.  In case of multiple TCP listeners, it returns '0.0.0.0' as IP address.
 func (listener *httpListener) Addr() (addr net.Addr) {
 	addr = listener.tcpListeners[0].Addr()
@@ -298,6 +344,8 @@ func newHTTPListener(serverAddrs []string,
 	tcpKeepAliveTimeout time.Duration,
 	readTimeout time.Duration,
 	writeTimeout time.Duration,
+	minThroughput int64,
+	throughputWindow time.Duration,
 	updateBytesReadFunc func(int),
 	updateBytesWrittenFunc func(int),
 	errorLogFunc func(error, string, ...interface{})) (listener *httpListener, err error) {
@@ -335,10 +383,15 @@ func newHTTPListener(serverAddrs []string,
 		tcpKeepAliveTimeout:    tcpKeepAliveTimeout,
 		readTimeout:            readTimeout,
 		writeTimeout:           writeTimeout,
+		minThroughput:          minThroughput,
+		throughputWindow:       throughputWindow,
 		updateBytesReadFunc:    updateBytesReadFunc,
 		updateBytesWrittenFunc: updateBytesWrittenFunc,
 		errorLogFunc:           errorLogFunc,
 	}
+	if minThroughput > 0 {
+		listener.conns = &connMap{conns: make(map[string]*BufConn)}
+	}
 	listener.start()
 
 	return listener, nil
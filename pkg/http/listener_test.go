@@ -225,6 +225,8 @@ func TestNewHTTPListener(t *testing.T) {
 			testCase.tcpKeepAliveTimeout,
 			testCase.readTimeout,
 			testCase.writeTimeout,
+			0,
+			time.Duration(0),
 			testCase.updateBytesReadFunc,
 			testCase.updateBytesWrittenFunc,
 			testCase.errorLogFunc,
@@ -277,6 +279,8 @@ func TestHTTPListenerStartClose(t *testing.T) {
 			time.Duration(0),
 			time.Duration(0),
 			time.Duration(0),
+			0,
+			time.Duration(0),
 			nil,
 			nil,
 			nil,
@@ -325,6 +329,8 @@ func TestHTTPListenerAddr(t *testing.T) {
 			time.Duration(0),
 			time.Duration(0),
 			time.Duration(0),
+			0,
+			time.Duration(0),
 			nil,
 			nil,
 			nil,
@@ -370,6 +376,8 @@ func TestHTTPListenerAddrs(t *testing.T) {
 			time.Duration(0),
 			time.Duration(0),
 			time.Duration(0),
+			0,
+			time.Duration(0),
 			nil,
 			nil,
 			nil,
@@ -417,6 +425,8 @@ func TestHTTPListenerAccept(t *testing.T) {
 			time.Duration(0),
 			time.Duration(0),
 			time.Duration(0),
+			0,
+			time.Duration(0),
 			nil,
 			nil,
 			nil,
@@ -502,6 +512,8 @@ func TestHTTPListenerAcceptPeekError(t *testing.T) {
 			time.Duration(0),
 			time.Duration(0),
 			time.Duration(0),
+			0,
+			time.Duration(0),
 			nil,
 			nil,
 			errorFunc,
@@ -561,6 +573,8 @@ func TestHTTPListenerAcceptTLSError(t *testing.T) {
 			time.Duration(0),
 			time.Duration(0),
 			time.Duration(0),
+			0,
+			time.Duration(0),
 			nil,
 			nil,
 			errorFunc,
@@ -633,6 +647,8 @@ func TestHTTPListenerAcceptError(t *testing.T) {
 			time.Duration(0),
 			time.Duration(0),
 			time.Duration(0),
+			0,
+			time.Duration(0),
 			nil,
 			nil,
 			errorFunc,
@@ -759,6 +775,8 @@ func TestHTTPListenerAcceptParallel(t *testing.T) {
 			time.Duration(0),
 			time.Duration(0),
 			time.Duration(0),
+			0,
+			time.Duration(0),
 			nil,
 			nil,
 			nil,
@@ -19,6 +19,7 @@ package http
 import (
 	"crypto/tls"
 	"errors"
+	"io"
 	"net/http"
 	"sync"
 	"sync/atomic"
@@ -45,6 +46,9 @@ const (
 
 	// DefaultMaxHeaderBytes - default maximum HTTP header size in bytes.
 	DefaultMaxHeaderBytes = 1 * humanize.MiByte
+
+	// DefaultThroughputWindow - default duration over which the minimum throughput is enforced.
+	DefaultThroughputWindow = 1 * time.Minute
 )
 
 // Server - extended http.Server supports multiple addresses to serve and enhanced connection handling.
@@ -53,6 +57,8 @@ type Server struct {
 	Addrs                  []string                            // addresses on which the server listens for new connection.
 	ShutdownTimeout        time.Duration                       // timeout used for graceful server shutdown.
 	TCPKeepAliveTimeout    time.Duration                       // timeout used for underneath TCP connection.
+	MinThroughput          int64                               // minimum bytes per second of request bodies and responses, 0 disables it.
+	ThroughputWindow       time.Duration                       // duration over which MinThroughput is enforced.
 	UpdateBytesReadFunc    func(int)                           // function to be called to update bytes read in bufConn.
 	UpdateBytesWrittenFunc func(int)                           // function to be called to update bytes written in bufConn.
 	ErrorLogFunc           func(error, string, ...interface{}) // function to be called on errors.
@@ -75,6 +81,8 @@ func (srv *Server) Start() (err error) {
 
 	addrs := set.CreateStringSet(srv.Addrs...).ToSlice() // copy and remove duplicates
 	tcpKeepAliveTimeout := srv.TCPKeepAliveTimeout
+	minThroughput := srv.MinThroughput
+	throughputWindow := srv.ThroughputWindow
 	updateBytesReadFunc := srv.UpdateBytesReadFunc
 	updateBytesWrittenFunc := srv.UpdateBytesWrittenFunc
 	errorLogFunc := srv.ErrorLogFunc // if srv.ErrorLogFunc holds non-synced state -> possible data race
@@ -87,6 +95,8 @@ func (srv *Server) Start() (err error) {
 		tcpKeepAliveTimeout,
 		readTimeout,
 		writeTimeout,
+		minThroughput,
+		throughputWindow,
 		updateBytesReadFunc,
 		updateBytesWrittenFunc,
 		errorLogFunc,
@@ -97,6 +107,8 @@ func (srv *Server) Start() (err error) {
 
 	// Wrap given handler to do additional
 	// * return 503 (service unavailable) if the server in shutdown.
+	// * enforce the minimum throughput while the request body is read
+	//   and the response is written.
 	wrappedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		atomic.AddInt32(&srv.requestCount, 1)
 		defer atomic.AddInt32(&srv.requestCount, -1)
@@ -107,6 +119,16 @@ func (srv *Server) Start() (err error) {
 			return
 		}
 
+		// The listener keeps connections by remote address while
+		// the minimum throughput is enforced.
+		if bufconn := listener.getConn(r.RemoteAddr); bufconn != nil {
+			bufconn.setLimitWrites(true)
+			defer bufconn.setLimitWrites(false)
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &limitedBody{ReadCloser: r.Body, conn: bufconn}
+			}
+		}
+
 		// Handle request using passed handler.
 		handler.ServeHTTP(w, r)
 	})
@@ -120,6 +142,19 @@ func (srv *Server) Start() (err error) {
 	return srv.Server.Serve(listener)
 }
 
+// limitedBody - request body enforcing the minimum throughput on reads
+// of its connection, stalled reads fail once the throughput window ends.
+type limitedBody struct {
+	io.ReadCloser
+	conn *BufConn
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	b.conn.setLimitReads(true)
+	defer b.conn.setLimitReads(false)
+	return b.ReadCloser.Read(p)
+}
+
 // Shutdown - shuts down HTTP server.
 func (srv *Server) Shutdown() error {
 	srv.listenerMutex.Lock()
@@ -192,6 +227,7 @@ func NewServer(addrs []string, handler http.Handler, certificate *tls.Certificat
 		Addrs:               addrs,
 		ShutdownTimeout:     DefaultShutdownTimeout,
 		TCPKeepAliveTimeout: DefaultTCPKeepAliveTimeout,
+		ThroughputWindow:    DefaultThroughputWindow,
 		listenerMutex:       &sync.Mutex{},
 	}
 	httpServer.Handler = handler
@@ -19,6 +19,9 @@ package http
 import (
 	"crypto/tls"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"reflect"
 	"testing"
@@ -179,3 +182,45 @@ func TestServerTLSCiphers(t *testing.T) {
 		}()
 	}
 }
+
+// Test request bodies stalled below the minimum throughput fail once
+// the throughput window ends.
+func TestServerMinThroughput(t *testing.T) {
+	addr := "127.0.0.1:" + getNextPort()
+	errCh := make(chan error, 1)
+	server := NewServer([]string{addr},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := ioutil.ReadAll(r.Body)
+			errCh <- err
+		}),
+		nil)
+	server.MinThroughput = 100
+	server.ThroughputWindow = 200 * time.Millisecond
+
+	go func() {
+		server.Start()
+	}()
+	defer server.Shutdown()
+
+	// There is no guaranteed way to know whether the HTTP server is started successfully.
+	// The only option is to connect and check.  Hence below sleep is used as workaround.
+	time.Sleep(1 * time.Second)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("unable to connect to server. %v", err)
+	}
+	defer conn.Close()
+	if _, err = io.WriteString(conn, "PUT /bucket/object HTTP/1.1\r\nHost: "+addr+"\r\nContent-Length: 1000\r\n\r\nhello"); err != nil {
+		t.Fatalf("failed to write to server. %v", err)
+	}
+
+	select {
+	case err = <-errCh:
+		if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+			t.Fatalf("expected timeout error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected stalled request body to fail")
+	}
+}
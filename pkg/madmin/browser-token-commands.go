@@ -0,0 +1,106 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package madmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// BrowserTokenGrant - managed policy granted on the buckets matching a
+// pattern, e.g. "readonly" on "reports-*".
+type BrowserTokenGrant struct {
+	Policy string `json:"policy"`
+	Bucket string `json:"bucket"`
+}
+
+// BrowserTokenRequest - user and policies of browser tokens, valid for
+// duration, the expiry of browser refresh tokens if zero.
+type BrowserTokenRequest struct {
+	User     string              `json:"user"`
+	Grants   []BrowserTokenGrant `json:"grants"`
+	Duration time.Duration       `json:"duration"`
+}
+
+// BrowserToken - tokens issued to a user, the browser logs in with the
+// refresh token until it expires or its ID is revoked.
+type BrowserToken struct {
+	ID           string    `json:"id"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// IssueBrowserToken - issues browser tokens of a user allowed the
+// policies granted only.
+func (adm *AdminClient) IssueBrowserToken(req BrowserTokenRequest) (token BrowserToken, err error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return token, err
+	}
+
+	// Execute POST on /minio/admin/v1/browser-tokens to issue tokens.
+	resp, err := adm.executeMethod("POST", requestData{
+		relPath:            "/v1/browser-tokens",
+		contentBody:        bytes.NewReader(body),
+		contentLength:      int64(len(body)),
+		contentMD5Bytes:    sumMD5(body),
+		contentSHA256Bytes: sum256(body),
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return token, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return token, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	return token, err
+}
+
+// RevokeBrowserTokens - revokes all browser tokens issued to user until
+// now if user is not empty, and the refresh token with the ID if id is
+// not empty.
+func (adm *AdminClient) RevokeBrowserTokens(user, id string) error {
+	queryVal := make(url.Values)
+	if user != "" {
+		queryVal.Set("user", user)
+	}
+	if id != "" {
+		queryVal.Set("id", id)
+	}
+
+	// Execute DELETE on /minio/admin/v1/browser-tokens to revoke tokens.
+	resp, err := adm.executeMethod("DELETE", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/browser-tokens",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
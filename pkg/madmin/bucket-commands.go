@@ -0,0 +1,419 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RecentObject - an upload recorded in the recency feed of a bucket.
+type RecentObject struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ETag    string    `json:"etag"`
+	ModTime time.Time `json:"lastModified"`
+}
+
+// ListRecentObjects - Calls List Recent Objects Management API to fetch
+// up to maxEntries latest uploads to bucket, newest first. A non-positive
+// maxEntries returns all uploads remembered by the server.
+func (adm *AdminClient) ListRecentObjects(bucket string, maxEntries int) ([]RecentObject, error) {
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+	if maxEntries > 0 {
+		queryVal.Set("max-entries", strconv.Itoa(maxEntries))
+	}
+
+	// Execute GET on /minio/admin/v1/recent-objects to list latest uploads.
+	resp, err := adm.executeMethod("GET", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/recent-objects",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	var entries []RecentObject
+	if err = json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ForceDeleteBucketStatus - progress of deleting a bucket with its
+// contents, Status is one of "running", "finished" and "failed".
+type ForceDeleteBucketStatus struct {
+	Bucket         string    `json:"bucket"`
+	Status         string    `json:"status"`
+	StartTime      time.Time `json:"startTime"`
+	EndTime        time.Time `json:"endTime"`
+	ObjectsDeleted int64     `json:"objectsDeleted"`
+	BytesDeleted   int64     `json:"bytesDeleted"`
+	UploadsAborted int64     `json:"uploadsAborted"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// ForceDeleteBucket - Calls Force Delete Bucket Management API to
+// delete all objects and incomplete uploads of bucket, then the bucket,
+// in the background. Calling it again retries a failed deletion.
+func (adm *AdminClient) ForceDeleteBucket(bucket string) (ForceDeleteBucketStatus, error) {
+	return adm.forceDeleteBucket("POST", bucket)
+}
+
+// GetForceDeleteBucketStatus - Calls Force Delete Bucket Management API
+// to fetch the progress of deleting bucket.
+func (adm *AdminClient) GetForceDeleteBucketStatus(bucket string) (ForceDeleteBucketStatus, error) {
+	return adm.forceDeleteBucket("GET", bucket)
+}
+
+func (adm *AdminClient) forceDeleteBucket(method, bucket string) (status ForceDeleteBucketStatus, err error) {
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+
+	// Execute on /minio/admin/v1/force-delete-bucket to start or
+	// fetch the progress of deleting bucket.
+	resp, err := adm.executeMethod(method, requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/force-delete-bucket",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return status, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return status, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	return status, err
+}
+
+// BucketReplicationConfig - remote S3 target a bucket is replicated to,
+// Endpoint is the host[:port] of the target.
+type BucketReplicationConfig struct {
+	Endpoint     string `json:"endpoint"`
+	Secure       bool   `json:"secure"`
+	AccessKey    string `json:"accessKey"`
+	SecretKey    string `json:"secretKey"`
+	TargetBucket string `json:"targetBucket"`
+}
+
+// BucketReplicationStats - changes of a bucket replicated by the server.
+type BucketReplicationStats struct {
+	Replicated     uint64    `json:"replicated"`
+	Skipped        uint64    `json:"skipped"`
+	Failures       uint64    `json:"failures"`
+	LastReplicated time.Time `json:"lastReplicated"`
+	LastError      string    `json:"lastError,omitempty"`
+	LastSkipped    string    `json:"lastSkipped,omitempty"`
+}
+
+// BucketReplicationStatus - replication target of a bucket, the number
+// of changes not replicated yet, retried or given up and the age of the
+// oldest pending change.
+type BucketReplicationStatus struct {
+	Bucket        string                 `json:"bucket"`
+	Endpoint      string                 `json:"endpoint"`
+	Secure        bool                   `json:"secure"`
+	AccessKey     string                 `json:"accessKey"`
+	TargetBucket  string                 `json:"targetBucket"`
+	Pending       int                    `json:"pending"`
+	Retrying      int                    `json:"retrying"`
+	Failed        int                    `json:"failed"`
+	OldestPending time.Time              `json:"oldestPending"`
+	Lag           time.Duration          `json:"lag"`
+	Stats         BucketReplicationStats `json:"stats"`
+}
+
+// SetBucketReplication - Calls Bucket Replication Management API to
+// replicate new uploads and deletions of bucket to a remote target.
+func (adm *AdminClient) SetBucketReplication(bucket string, config BucketReplicationConfig) error {
+	// No TLS?
+	if !adm.secure {
+		return fmt.Errorf("replication credentials cannot be set over an insecure connection")
+	}
+
+	body, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+
+	// Execute PUT on /minio/admin/v1/replication to set the target.
+	resp, err := adm.executeMethod("PUT", requestData{
+		queryValues:        queryVal,
+		relPath:            "/v1/replication",
+		contentBody:        bytes.NewReader(body),
+		contentLength:      int64(len(body)),
+		contentMD5Bytes:    sumMD5(body),
+		contentSHA256Bytes: sum256(body),
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
+
+// GetBucketReplicationStatus - Calls Bucket Replication Management API
+// to fetch the replication status of all replicated buckets, or only of
+// bucket if it is not empty.
+func (adm *AdminClient) GetBucketReplicationStatus(bucket string) ([]BucketReplicationStatus, error) {
+	queryVal := make(url.Values)
+	if bucket != "" {
+		queryVal.Set("bucket", bucket)
+	}
+
+	// Execute GET on /minio/admin/v1/replication to fetch the status.
+	resp, err := adm.executeMethod("GET", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/replication",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	var statuses []BucketReplicationStatus
+	if err = json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// RemoveBucketReplication - Calls Bucket Replication Management API to
+// stop replicating bucket, changes not replicated yet are dropped.
+func (adm *AdminClient) RemoveBucketReplication(bucket string) error {
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+
+	// Execute DELETE on /minio/admin/v1/replication to stop replication.
+	resp, err := adm.executeMethod("DELETE", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/replication",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
+
+// BucketTieringRule - objects under Prefix are moved to the remote tier
+// Days after they were last modified.
+type BucketTieringRule struct {
+	Prefix string `json:"prefix"`
+	Days   int    `json:"days"`
+}
+
+// BucketTieringConfig - remote S3 target cold objects of a bucket are
+// moved to, Endpoint is the host[:port] of the target. Objects on the
+// remote tier not matching any rule anymore are moved back.
+type BucketTieringConfig struct {
+	Endpoint     string              `json:"endpoint"`
+	Secure       bool                `json:"secure"`
+	AccessKey    string              `json:"accessKey"`
+	SecretKey    string              `json:"secretKey"`
+	TargetBucket string              `json:"targetBucket"`
+	Rules        []BucketTieringRule `json:"rules"`
+}
+
+// BucketTieringStats - objects of a bucket moved by the server, Tiered
+// objects were counted by the last scan of the bucket.
+type BucketTieringStats struct {
+	Tiered            int       `json:"tiered"`
+	TieredBytes       int64     `json:"tieredBytes"`
+	Transitioned      uint64    `json:"transitioned"`
+	TransitionedBytes uint64    `json:"transitionedBytes"`
+	Restored          uint64    `json:"restored"`
+	Failures          uint64    `json:"failures"`
+	LastScan          time.Time `json:"lastScan"`
+	LastError         string    `json:"lastError,omitempty"`
+}
+
+// BucketTieringStatus - remote tier and rules of a bucket.
+type BucketTieringStatus struct {
+	Bucket       string              `json:"bucket"`
+	Endpoint     string              `json:"endpoint"`
+	Secure       bool                `json:"secure"`
+	AccessKey    string              `json:"accessKey"`
+	TargetBucket string              `json:"targetBucket"`
+	Rules        []BucketTieringRule `json:"rules"`
+	Stats        BucketTieringStats  `json:"stats"`
+}
+
+// SetBucketTiering - Calls Bucket Tiering Management API to move cold
+// objects of bucket to a remote tier.
+func (adm *AdminClient) SetBucketTiering(bucket string, config BucketTieringConfig) error {
+	// No TLS?
+	if !adm.secure {
+		return fmt.Errorf("tiering credentials cannot be set over an insecure connection")
+	}
+
+	body, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+
+	// Execute PUT on /minio/admin/v1/tiering to set the remote tier.
+	resp, err := adm.executeMethod("PUT", requestData{
+		queryValues:        queryVal,
+		relPath:            "/v1/tiering",
+		contentBody:        bytes.NewReader(body),
+		contentLength:      int64(len(body)),
+		contentMD5Bytes:    sumMD5(body),
+		contentSHA256Bytes: sum256(body),
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
+
+// GetBucketTieringStatus - Calls Bucket Tiering Management API to fetch
+// the tiering status of all tiered buckets, or only of bucket if it is
+// not empty.
+func (adm *AdminClient) GetBucketTieringStatus(bucket string) ([]BucketTieringStatus, error) {
+	queryVal := make(url.Values)
+	if bucket != "" {
+		queryVal.Set("bucket", bucket)
+	}
+
+	// Execute GET on /minio/admin/v1/tiering to fetch the status.
+	resp, err := adm.executeMethod("GET", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/tiering",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	var statuses []BucketTieringStatus
+	if err = json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// RemoveBucketTiering - Calls Bucket Tiering Management API to stop
+// tiering bucket, refused while objects are on the remote tier.
+func (adm *AdminClient) RemoveBucketTiering(bucket string) error {
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+
+	// Execute DELETE on /minio/admin/v1/tiering to stop tiering.
+	resp, err := adm.executeMethod("DELETE", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/tiering",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
+
+// BucketAccessStats - requests served for a bucket and the bytes they
+// transferred. HEAD requests are counted as GET, POST requests as PUT.
+type BucketAccessStats struct {
+	Bucket        string    `json:"bucket"`
+	GET           uint64    `json:"GET"`
+	PUT           uint64    `json:"PUT"`
+	DELETE        uint64    `json:"DELETE"`
+	Errors        uint64    `json:"errors"`
+	BytesReceived uint64    `json:"bytesReceived"`
+	BytesSent     uint64    `json:"bytesSent"`
+	LastAccess    time.Time `json:"lastAccess"`
+}
+
+// AccessStats - access statistics of buckets summed over all servers,
+// servers which failed to report are listed and not counted.
+type AccessStats struct {
+	Buckets       []BucketAccessStats `json:"buckets"`
+	FailedServers []string            `json:"failedServers,omitempty"`
+}
+
+// GetAccessStats - Calls Access Statistics Management API to fetch the
+// requests served for all buckets, or only for bucket if it is not
+// empty, and the bytes they transferred.
+func (adm *AdminClient) GetAccessStats(bucket string) (stats AccessStats, err error) {
+	queryVal := make(url.Values)
+	if bucket != "" {
+		queryVal.Set("bucket", bucket)
+	}
+
+	// Execute GET on /minio/admin/v1/access-stats to fetch the statistics.
+	resp, err := adm.executeMethod("GET", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/access-stats",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return stats, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return stats, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	return stats, err
+}
@@ -24,6 +24,9 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 // NodeSummary - represents the result of an operation part of
@@ -104,3 +107,165 @@ func (adm *AdminClient) SetConfig(config io.Reader) (r SetConfigResult, err erro
 	err = json.Unmarshal(jsonBytes, &r)
 	return r, err
 }
+
+// ChangeLogCaller - identity of the client making a change.
+type ChangeLogCaller struct {
+	AccessKey  string `json:"accessKey,omitempty"`
+	RemoteHost string `json:"remoteHost,omitempty"`
+	UserAgent  string `json:"userAgent,omitempty"`
+}
+
+// ChangeLogDiff - JSON encoded value of a key before and after a
+// change, empty if the key was added or removed. Values of secret
+// keys and passwords are redacted.
+type ChangeLogDiff struct {
+	Key string `json:"key"`
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// ChangeLogEntry - a recorded change, Type is one of "bucket-policy",
+// "credentials" and "config".
+type ChangeLogEntry struct {
+	Version string          `json:"version"`
+	ID      string          `json:"id"`
+	Time    time.Time       `json:"time"`
+	Actor   ChangeLogCaller `json:"actor"`
+	Type    string          `json:"type"`
+	Bucket  string          `json:"bucket,omitempty"`
+	Diff    []ChangeLogDiff `json:"diff"`
+}
+
+// ChangeLogResult - a page of recorded changes, NextMarker lists the
+// following page when IsTruncated is set.
+type ChangeLogResult struct {
+	Entries     []ChangeLogEntry `json:"entries"`
+	IsTruncated bool             `json:"isTruncated"`
+	NextMarker  string           `json:"nextMarker,omitempty"`
+}
+
+// ListChangeLog - lists up to maxEntries recorded changes of bucket
+// policies, credentials and the server configuration after the
+// entry with ID marker, oldest first. Only changes of bucket are
+// listed unless empty, a page of them may hold fewer entries, even
+// none, and still be truncated. A non-positive maxEntries uses the
+// server default.
+func (adm *AdminClient) ListChangeLog(bucket, marker string, maxEntries int) (r ChangeLogResult, err error) {
+	queryVal := make(url.Values)
+	if bucket != "" {
+		queryVal.Set("bucket", bucket)
+	}
+	if marker != "" {
+		queryVal.Set("marker", marker)
+	}
+	if maxEntries > 0 {
+		queryVal.Set("max-entries", strconv.Itoa(maxEntries))
+	}
+
+	// Execute GET on /minio/admin/v1/change-log to list changes.
+	resp, err := adm.executeMethod("GET", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/change-log",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return r, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return r, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	return r, err
+}
+
+// MetadataBackup - metadata snapshot saved in the backup bucket.
+type MetadataBackup struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// MetadataBackupStatus - metadata backup settings of the server and
+// the snapshots saved in the backup bucket, oldest first.
+type MetadataBackupStatus struct {
+	Bucket   string           `json:"bucket"`
+	Interval time.Duration    `json:"interval"`
+	Keep     int              `json:"keep"`
+	Backups  []MetadataBackup `json:"backups"`
+}
+
+// MetadataRestoreResult - buckets created and bucket config files
+// restored from a metadata snapshot. Configs of remote targets are
+// skipped when their secret keys are unknown to the server.
+type MetadataRestoreResult struct {
+	Name           string   `json:"name"`
+	BucketsCreated []string `json:"bucketsCreated"`
+	FilesRestored  []string `json:"filesRestored"`
+	FilesSkipped   []string `json:"filesSkipped"`
+}
+
+// ListMetadataBackups - lists the metadata snapshots saved in the
+// backup bucket, oldest first.
+func (adm *AdminClient) ListMetadataBackups() (status MetadataBackupStatus, err error) {
+	// Execute GET on /minio/admin/v1/metadata-backup to list snapshots.
+	resp, err := adm.executeMethod("GET", requestData{
+		relPath: "/v1/metadata-backup",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return status, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return status, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	return status, err
+}
+
+// BackupMetadata - takes a metadata snapshot right away.
+func (adm *AdminClient) BackupMetadata() (backup MetadataBackup, err error) {
+	// Execute POST on /minio/admin/v1/metadata-backup to take a snapshot.
+	resp, err := adm.executeMethod("POST", requestData{
+		relPath: "/v1/metadata-backup",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return backup, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return backup, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&backup)
+	return backup, err
+}
+
+// RestoreMetadataBackup - recreates buckets missing since the snapshot
+// name was taken and restores the configs of its buckets.
+func (adm *AdminClient) RestoreMetadataBackup(name string) (result MetadataRestoreResult, err error) {
+	queryVal := make(url.Values)
+	queryVal.Set("name", name)
+
+	// Execute POST on /minio/admin/v1/metadata-backup/restore to
+	// restore the snapshot.
+	resp, err := adm.executeMethod("POST", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/metadata-backup/restore",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return result, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return result, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
@@ -0,0 +1,120 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ZoneDecommissionStatus - progress of moving all objects off a zone,
+// Status is one of "draining" and "complete". Zone is the position of
+// the zone on the command line starting at 1, a complete zone can be
+// removed from the command line of all servers.
+type ZoneDecommissionStatus struct {
+	ID           string    `json:"id"`
+	Zone         int       `json:"zone"`
+	Status       string    `json:"status"`
+	Bandwidth    int64     `json:"bandwidth"`
+	StartTime    time.Time `json:"startTime"`
+	EndTime      time.Time `json:"endTime"`
+	Bucket       string    `json:"bucket"`
+	Marker       string    `json:"marker"`
+	ObjectsMoved int64     `json:"objectsMoved"`
+	BytesMoved   int64     `json:"bytesMoved"`
+	Failures     int64     `json:"failures"`
+	LastError    string    `json:"lastError,omitempty"`
+}
+
+// StartDecommission - Calls Decommission Management API to move all
+// objects off zone in the background, at most bandwidth bytes per
+// second unless 0. Calling it again changes the bandwidth.
+func (adm *AdminClient) StartDecommission(zone int, bandwidth int64) (status ZoneDecommissionStatus, err error) {
+	queryVal := make(url.Values)
+	queryVal.Set("zone", strconv.Itoa(zone))
+	if bandwidth > 0 {
+		queryVal.Set("bandwidth", strconv.FormatInt(bandwidth, 10))
+	}
+
+	// Execute POST on /minio/admin/v1/decommission to start draining
+	// the zone.
+	resp, err := adm.executeMethod("POST", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/decommission",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return status, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return status, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	return status, err
+}
+
+// GetDecommissionStatus - Calls Decommission Management API to fetch
+// the progress of all zones being decommissioned.
+func (adm *AdminClient) GetDecommissionStatus() ([]ZoneDecommissionStatus, error) {
+	// Execute GET on /minio/admin/v1/decommission to fetch the status.
+	resp, err := adm.executeMethod("GET", requestData{
+		relPath: "/v1/decommission",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	var statuses []ZoneDecommissionStatus
+	if err = json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// CancelDecommission - Calls Decommission Management API to stop
+// draining zone, new objects are placed on it again.
+func (adm *AdminClient) CancelDecommission(zone int) error {
+	queryVal := make(url.Values)
+	queryVal.Set("zone", strconv.Itoa(zone))
+
+	// Execute DELETE on /minio/admin/v1/decommission to stop draining
+	// the zone.
+	resp, err := adm.executeMethod("DELETE", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/decommission",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
@@ -234,3 +234,67 @@ func (adm *AdminClient) Heal(bucket, prefix string, healOpts HealOpts,
 	}
 	return healStart, healTaskStatus, err
 }
+
+// DriveHealStatus - progress of healing the erasure set of a fresh
+// drive which replaced a failed one, Status is one of "running",
+// "finished" and "failed".
+type DriveHealStatus struct {
+	Endpoint       string    `json:"endpoint"`
+	Set            int       `json:"set"`
+	Status         string    `json:"status"`
+	StartTime      time.Time `json:"startTime"`
+	EndTime        time.Time `json:"endTime"`
+	BucketsHealed  int64     `json:"bucketsHealed"`
+	ObjectsScanned int64     `json:"objectsScanned"`
+	ObjectsHealed  int64     `json:"objectsHealed"`
+	ObjectsFailed  int64     `json:"objectsFailed"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// ListDriveHeals - Calls Heal Drives Management API to fetch the
+// progress of healing the drives replaced while the server is running.
+func (adm *AdminClient) ListDriveHeals() ([]DriveHealStatus, error) {
+	// Execute GET on /minio/admin/v1/heal-drives
+	resp, err := adm.executeMethod("GET", requestData{
+		relPath: "/v1/heal-drives",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	var heals []DriveHealStatus
+	if err = json.NewDecoder(resp.Body).Decode(&heals); err != nil {
+		return nil, err
+	}
+	return heals, nil
+}
+
+// DownloadIntegrityReport - downloads the objects found below full
+// redundancy by the last pass of the integrity scanner of every server,
+// as CSV if format is "csv" or as JSON lines if it is "json". The
+// caller must close the returned reader.
+func (adm *AdminClient) DownloadIntegrityReport(format string) (io.ReadCloser, error) {
+	queryValues := url.Values{}
+	queryValues.Set("format", format)
+
+	// Execute GET on /minio/admin/v1/integrity-report
+	resp, err := adm.executeMethod("GET", requestData{
+		relPath:     "/v1/integrity-report",
+		queryValues: queryValues,
+	})
+	if err != nil {
+		closeResponse(resp)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer closeResponse(resp)
+		return nil, httpRespToErrorResponse(resp)
+	}
+	return resp.Body, nil
+}
@@ -104,13 +104,66 @@ type ServerHTTPStats struct {
 	SuccessDELETEStats ServerHTTPMethodStats `json:"successDELETEs"`
 }
 
+// ServerHealOnReadStats holds the number of objects queued for healing
+// after reads found them missing or corrupted on some disks
+type ServerHealOnReadStats struct {
+	Queued  uint64 `json:"queued"`
+	Healed  uint64 `json:"healed"`
+	Failed  uint64 `json:"failed"`
+	Dropped uint64 `json:"dropped"`
+}
+
+// ServerTmpSweepStats holds the number of entries and bytes removed
+// from the temporary upload area, left by crashes or failed uploads
+type ServerTmpSweepStats struct {
+	Sweeps         uint64 `json:"sweeps"`
+	RemovedEntries uint64 `json:"removedEntries"`
+	ReclaimedBytes uint64 `json:"reclaimedBytes"`
+}
+
+// ServerNotifyTargetStats holds delivery metrics of a notification
+// target, events failing to be delivered are dropped
+type ServerNotifyTargetStats struct {
+	ARN          string        `json:"arn"`
+	InFlight     int64         `json:"inFlight"`
+	Sent         uint64        `json:"sent"`
+	Failed       uint64        `json:"failed"`
+	TotalLatency time.Duration `json:"totalLatency"`
+	LastLatency  time.Duration `json:"lastLatency"`
+	LastSent     time.Time     `json:"lastSent"`
+	LastFailed   time.Time     `json:"lastFailed"`
+	LastError    string        `json:"lastError,omitempty"`
+}
+
+// ServerDiskInfo holds the state and usage of a disk of the server,
+// healing is set while a running heal sequence repairs objects on it
+type ServerDiskInfo struct {
+	Endpoint string `json:"endpoint"`
+	State    string `json:"state"`
+	Total    uint64 `json:"total"`
+	Used     uint64 `json:"used"`
+	Healing  bool   `json:"healing"`
+}
+
+// ServerGatewayInfo holds the health of the backend of a gateway
+type ServerGatewayInfo struct {
+	Backend string `json:"backend"`
+	Online  bool   `json:"online"`
+	Error   string `json:"error,omitempty"`
+}
+
 // ServerInfoData holds storage, connections and other
 // information of a given server
 type ServerInfoData struct {
-	StorageInfo StorageInfo      `json:"storage"`
-	ConnStats   ServerConnStats  `json:"network"`
-	HTTPStats   ServerHTTPStats  `json:"http"`
-	Properties  ServerProperties `json:"server"`
+	StorageInfo     StorageInfo               `json:"storage"`
+	Disks           []ServerDiskInfo          `json:"disks"`
+	Gateway         *ServerGatewayInfo        `json:"gateway,omitempty"`
+	ConnStats       ServerConnStats           `json:"network"`
+	HTTPStats       ServerHTTPStats           `json:"http"`
+	HealOnReadStats ServerHealOnReadStats     `json:"healOnRead"`
+	TmpSweepStats   ServerTmpSweepStats       `json:"tmpSweep"`
+	NotifyTargets   []ServerNotifyTargetStats `json:"notifyTargets"`
+	Properties      ServerProperties          `json:"server"`
 }
 
 // ServerInfo holds server information result of one node
@@ -133,3 +133,87 @@ func (adm *AdminClient) ClearLocks(bucket, prefix string,
 
 	return getLockInfos(resp.Body)
 }
+
+// LockLeaseInfo - represents a lock granted by a lock server of a
+// distributed setup, along with its owner and lease.
+type LockLeaseInfo struct {
+	Bucket        string    `json:"bucket"`
+	Object        string    `json:"object"`
+	Writer        bool      `json:"writer"`
+	Owner         string    `json:"owner"`
+	UID           string    `json:"uid"`
+	Since         time.Time `json:"since"`
+	LastValidated time.Time `json:"lastValidated"`
+	LockServer    string    `json:"lockServer"`
+}
+
+// getLockLeases - unmarshal []LockLeaseInfo from a reader.
+func getLockLeases(body io.Reader) ([]LockLeaseInfo, error) {
+	respBytes, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var leases []LockLeaseInfo
+	if err = json.Unmarshal(respBytes, &leases); err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+// ListLockLeases - Calls List Lock Leases Management API to fetch locks
+// granted by the lock servers matching bucket, prefix and held before
+// the duration supplied.
+func (adm *AdminClient) ListLockLeases(bucket, prefix string,
+	duration time.Duration) ([]LockLeaseInfo, error) {
+
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+	queryVal.Set("prefix", prefix)
+	queryVal.Set("older-than", duration.String())
+
+	// Execute GET on /minio/admin/v1/locks/leases to list lock leases.
+	resp, err := adm.executeMethod("GET", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/locks/leases",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	return getLockLeases(resp.Body)
+}
+
+// ClearLockLeases - Calls Clear Lock Leases Management API to force
+// release locks granted by the lock servers on bucket, matching prefix
+// older than duration supplied.
+func (adm *AdminClient) ClearLockLeases(bucket, prefix string,
+	duration time.Duration) ([]LockLeaseInfo, error) {
+
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+	queryVal.Set("prefix", prefix)
+	queryVal.Set("older-than", duration.String())
+
+	// Execute DELETE on /minio/admin/v1/locks/leases to release lock leases.
+	resp, err := adm.executeMethod("DELETE", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/locks/leases",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	return getLockLeases(resp.Body)
+}
@@ -0,0 +1,190 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// QuarantineStatus - quarantine status of an object.
+type QuarantineStatus string
+
+const (
+	// QuarantinePending - object is pending validation and cannot be downloaded.
+	QuarantinePending QuarantineStatus = "pending"
+	// QuarantineCleared - object passed validation and can be downloaded.
+	QuarantineCleared QuarantineStatus = "cleared"
+)
+
+// SetObjectQuarantine - Calls Set Object Quarantine Management API to
+// mark an object as pending validation or to clear it.
+func (adm *AdminClient) SetObjectQuarantine(bucket, object string, status QuarantineStatus) error {
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+	queryVal.Set("object", object)
+	queryVal.Set("status", string(status))
+
+	// Execute PUT on /minio/admin/v1/quarantine to set quarantine status.
+	resp, err := adm.executeMethod("PUT", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/quarantine",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// AttestationKey - public key verifying signed object manifests, the
+// current key signs new manifests.
+type AttestationKey struct {
+	KeyID     string    `json:"keyId"`
+	Algorithm string    `json:"algorithm"`
+	PublicKey string    `json:"publicKey"`
+	Created   time.Time `json:"created"`
+	Current   bool      `json:"current"`
+}
+
+// GetAttestationKeys - Calls Get Attestation Key Management API to
+// fetch the public keys verifying signed object manifests, those of
+// rotated keys included.
+func (adm *AdminClient) GetAttestationKeys() ([]AttestationKey, error) {
+	var keys []AttestationKey
+
+	// Execute GET on /minio/admin/v1/attestation-key to fetch the public keys.
+	resp, err := adm.executeMethod("GET", requestData{
+		relPath: "/v1/attestation-key",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return keys, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return keys, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&keys)
+	return keys, err
+}
+
+// RotateAttestationKey - Calls Rotate Attestation Key Management API to
+// sign new object manifests with a new key.
+func (adm *AdminClient) RotateAttestationKey() error {
+	// Execute POST on /minio/admin/v1/attestation-key to rotate the key.
+	resp, err := adm.executeMethod("POST", requestData{
+		relPath: "/v1/attestation-key",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
+
+// SearchQuery - filters of an object search, all filters must match.
+// ContentType and the values of Metadata are wildcard patterns, e.g.
+// "image/*". Metadata keys are user metadata keys, with or without the
+// x-amz-meta- prefix.
+type SearchQuery struct {
+	Prefix      string
+	Suffix      string
+	ContentType string
+	Metadata    map[string]string
+
+	// Objects sorted after Marker are returned, up to MaxKeys or 1000.
+	Marker  string
+	MaxKeys int
+}
+
+// SearchObject - an object found by a search.
+type SearchObject struct {
+	Name        string            `json:"name"`
+	Size        int64             `json:"size"`
+	ETag        string            `json:"etag"`
+	ModTime     time.Time         `json:"lastModified"`
+	ContentType string            `json:"contentType"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// SearchResult - objects found by a search, sorted by name. More
+// objects are found after NextMarker if the result is truncated.
+type SearchResult struct {
+	Objects     []SearchObject `json:"objects"`
+	IsTruncated bool           `json:"isTruncated"`
+	NextMarker  string         `json:"nextMarker,omitempty"`
+}
+
+// SearchObjects - Calls Search Objects Management API to find the
+// objects of bucket matching the query in the object index of the
+// server.
+func (adm *AdminClient) SearchObjects(bucket string, query SearchQuery) (SearchResult, error) {
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+	if query.Prefix != "" {
+		queryVal.Set("prefix", query.Prefix)
+	}
+	if query.Suffix != "" {
+		queryVal.Set("suffix", query.Suffix)
+	}
+	if query.ContentType != "" {
+		queryVal.Set("content-type", query.ContentType)
+	}
+	for k, v := range query.Metadata {
+		queryVal.Add("metadata", k+":"+v)
+	}
+	if query.Marker != "" {
+		queryVal.Set("marker", query.Marker)
+	}
+	if query.MaxKeys > 0 {
+		queryVal.Set("max-entries", strconv.Itoa(query.MaxKeys))
+	}
+
+	var result SearchResult
+
+	// Execute GET on /minio/admin/v1/search to search objects.
+	resp, err := adm.executeMethod("GET", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/search",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return result, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return result, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
@@ -0,0 +1,218 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ManagedPolicyInfo - a managed policy and the buckets it is attached to.
+type ManagedPolicyInfo struct {
+	Name           string   `json:"name"`
+	BuiltIn        bool     `json:"builtIn,omitempty"`
+	DefaultVersion int      `json:"defaultVersion"`
+	Versions       []int    `json:"versions"`
+	Buckets        []string `json:"buckets"`
+}
+
+// ManagedPolicyVersion - statements of a version of a managed policy,
+// resources refer to the attached bucket as arn:aws:s3:::${bucket}.
+type ManagedPolicyVersion struct {
+	Version    int             `json:"version"`
+	Created    time.Time       `json:"created"`
+	Statements json.RawMessage `json:"statements"`
+}
+
+// ManagedPolicy - a managed policy with all its versions.
+type ManagedPolicy struct {
+	Name           string                 `json:"name"`
+	DefaultVersion int                    `json:"defaultVersion"`
+	Versions       []ManagedPolicyVersion `json:"versions"`
+}
+
+// ListManagedPolicies - Calls Managed Policy Management API to list
+// built-in and stored managed policies.
+func (adm *AdminClient) ListManagedPolicies() ([]ManagedPolicyInfo, error) {
+	// Execute GET on /minio/admin/v1/policies to list policies.
+	resp, err := adm.executeMethod("GET", requestData{
+		relPath: "/v1/policies",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	var policies []ManagedPolicyInfo
+	err = json.NewDecoder(resp.Body).Decode(&policies)
+	return policies, err
+}
+
+// GetManagedPolicy - fetches a version of the managed policy name, the
+// default version if version is 0.
+func (adm *AdminClient) GetManagedPolicy(name string, version int) (v ManagedPolicyVersion, err error) {
+	queryVal := make(url.Values)
+	queryVal.Set("name", name)
+	if version > 0 {
+		queryVal.Set("version", strconv.Itoa(version))
+	}
+
+	// Execute GET on /minio/admin/v1/policies to fetch the version.
+	resp, err := adm.executeMethod("GET", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/policies",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return v, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return v, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&v)
+	return v, err
+}
+
+// PutManagedPolicy - creates the managed policy name or adds a new
+// version to it from a bucket policy document. The new version becomes
+// the default version and applies to all buckets the policy is attached to.
+func (adm *AdminClient) PutManagedPolicy(name string, policy []byte) (p ManagedPolicy, err error) {
+	queryVal := make(url.Values)
+	queryVal.Set("name", name)
+
+	// Execute PUT on /minio/admin/v1/policies to save the policy.
+	resp, err := adm.executeMethod("PUT", requestData{
+		queryValues:        queryVal,
+		relPath:            "/v1/policies",
+		contentBody:        bytes.NewReader(policy),
+		contentLength:      int64(len(policy)),
+		contentMD5Bytes:    sumMD5(policy),
+		contentSHA256Bytes: sum256(policy),
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return p, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return p, httpRespToErrorResponse(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&p)
+	return p, err
+}
+
+// SetManagedPolicyVersion - makes an existing version the default
+// version of the managed policy name, e.g. to roll back a change.
+func (adm *AdminClient) SetManagedPolicyVersion(name string, version int) error {
+	queryVal := make(url.Values)
+	queryVal.Set("name", name)
+	queryVal.Set("version", strconv.Itoa(version))
+
+	// Execute PUT on /minio/admin/v1/policies/default to set the version.
+	resp, err := adm.executeMethod("PUT", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/policies/default",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
+
+// RemoveManagedPolicy - deletes the managed policy name, which must not
+// be attached to any bucket.
+func (adm *AdminClient) RemoveManagedPolicy(name string) error {
+	queryVal := make(url.Values)
+	queryVal.Set("name", name)
+
+	// Execute DELETE on /minio/admin/v1/policies to delete the policy.
+	resp, err := adm.executeMethod("DELETE", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/policies",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
+
+// AttachManagedPolicy - attaches the managed policy name to bucket,
+// replacing the bucket policy.
+func (adm *AdminClient) AttachManagedPolicy(name, bucket string) error {
+	queryVal := make(url.Values)
+	queryVal.Set("name", name)
+	queryVal.Set("bucket", bucket)
+
+	// Execute PUT on /minio/admin/v1/policies/attach to attach the policy.
+	resp, err := adm.executeMethod("PUT", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/policies/attach",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
+
+// DetachManagedPolicy - detaches the managed policy of bucket and
+// removes the bucket policy.
+func (adm *AdminClient) DetachManagedPolicy(bucket string) error {
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+
+	// Execute DELETE on /minio/admin/v1/policies/attach to detach the policy.
+	resp, err := adm.executeMethod("DELETE", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/policies/attach",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Profilers supported by StartProfiling.
+const (
+	ProfilerCPU   = "cpu"
+	ProfilerHeap  = "heap"
+	ProfilerBlock = "block"
+	ProfilerMutex = "mutex"
+)
+
+// StartProfiling - starts the given profilers on all Minio servers,
+// returns whether profiling was started on each server.
+func (adm *AdminClient) StartProfiling(profilers ...string) ([]NodeSummary, error) {
+	queryVal := make(url.Values)
+	queryVal.Set("profilerType", strings.Join(profilers, ","))
+
+	// Execute POST on /minio/admin/v1/profile/start to start profiling.
+	resp, err := adm.executeMethod("POST", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/profile/start",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []NodeSummary
+	if err = json.Unmarshal(respBytes, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// DownloadProfilingData - stops profiling on all Minio servers and
+// returns a zip archive with the profiles of all servers, the caller
+// must close the returned reader.
+func (adm *AdminClient) DownloadProfilingData() (io.ReadCloser, error) {
+	// Execute POST on /minio/admin/v1/profile/stop to stop profiling.
+	resp, err := adm.executeMethod("POST", requestData{
+		relPath: "/v1/profile/stop",
+	})
+	if err != nil {
+		closeResponse(resp)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer closeResponse(resp)
+		return nil, httpRespToErrorResponse(resp)
+	}
+	return resp.Body, nil
+}
@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -31,10 +32,22 @@ type ServerVersion struct {
 	CommitID string `json:"commitID"`
 }
 
+// PeerClockSkew - clock offset of a peer relative to the server
+// which answered the service status request.
+type PeerClockSkew struct {
+	Addr     string        `json:"addr"`
+	Skew     time.Duration `json:"skew"`
+	Exceeded bool          `json:"exceeded"`
+	Error    string        `json:"error,omitempty"`
+}
+
 // ServiceStatus - contains the response of service status API
 type ServiceStatus struct {
-	ServerVersion ServerVersion `json:"serverVersion"`
-	Uptime        time.Duration `json:"uptime"`
+	ServerVersion ServerVersion   `json:"serverVersion"`
+	Uptime        time.Duration   `json:"uptime"`
+	ClockSkew     []PeerClockSkew `json:"clockSkew,omitempty"`
+	ReadOnly      bool            `json:"readOnly,omitempty"`
+	Maintenance   bool            `json:"maintenance,omitempty"`
 }
 
 // ServiceStatus - Connect to a minio server and call Service Status
@@ -101,3 +114,106 @@ func (adm *AdminClient) ServiceSendAction(action ServiceActionValue) error {
 	}
 	return nil
 }
+
+// ServiceSetMaintenance - Call Set Maintenance Mode API to turn
+// maintenance mode on or off on all Minio servers. In maintenance
+// mode write requests are rejected with SlowDown.
+func (adm *AdminClient) ServiceSetMaintenance(enable bool) error {
+	queryVal := make(url.Values)
+	if enable {
+		queryVal.Set("status", "on")
+	} else {
+		queryVal.Set("status", "off")
+	}
+
+	// Execute PUT on /minio/admin/v1/maintenance to set maintenance mode.
+	resp, err := adm.executeMethod("PUT", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/maintenance",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
+
+// TraceInfo - trace of a single request served by a Minio server,
+// Err is set when tracing stopped due to an error.
+type TraceInfo struct {
+	Time          time.Time   `json:"time"`
+	Method        string      `json:"method"`
+	Path          string      `json:"path"`
+	RawQuery      string      `json:"rawQuery,omitempty"`
+	ReqHeaders    http.Header `json:"requestHeaders"`
+	StatusCode    int         `json:"statusCode"`
+	RespHeaders   http.Header `json:"responseHeaders"`
+	Latency       int64       `json:"latencyNs"`
+	BytesReceived int64       `json:"bytesReceived"`
+	BytesSent     int64       `json:"bytesSent"`
+
+	Err error `json:"-"`
+}
+
+// ServiceTrace - Call Trace API to stream traces of all requests
+// served by the Minio server until doneCh is closed. The returned
+// channel is closed when tracing stops.
+func (adm *AdminClient) ServiceTrace(doneCh <-chan struct{}) <-chan TraceInfo {
+	traceInfoCh := make(chan TraceInfo)
+	go func() {
+		defer close(traceInfoCh)
+
+		sendErr := func(err error) {
+			select {
+			case traceInfoCh <- TraceInfo{Err: err}:
+			case <-doneCh:
+			}
+		}
+
+		// Execute GET on /minio/admin/v1/trace to stream traces.
+		resp, err := adm.executeMethod("GET", requestData{relPath: "/v1/trace"})
+		defer closeResponse(resp)
+		if err != nil {
+			sendErr(err)
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			sendErr(httpRespToErrorResponse(resp))
+			return
+		}
+
+		// Unblock decoding below once the caller is done.
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		go func() {
+			select {
+			case <-doneCh:
+				resp.Body.Close()
+			case <-stopCh:
+			}
+		}()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var info TraceInfo
+			if err = dec.Decode(&info); err != nil {
+				select {
+				case <-doneCh:
+				default:
+					sendErr(err)
+				}
+				return
+			}
+			select {
+			case traceInfoCh <- info:
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+	return traceInfoCh
+}
@@ -0,0 +1,102 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SpeedTestOpts - parameters of a speed test, zero values select the
+// defaults of the server: 64MiB objects, 32 concurrent requests per
+// server and 10 seconds of uploads followed by 10 seconds of downloads.
+type SpeedTestOpts struct {
+	Size       int64
+	Concurrent int
+	Duration   time.Duration
+	// Repeat the test with twice the concurrency as long as
+	// uploads get faster.
+	Autotune bool
+}
+
+// SpeedTestStats - throughput of uploads or downloads.
+type SpeedTestStats struct {
+	ThroughputPerSec uint64 `json:"throughputPerSec"`
+	ObjectsPerSec    uint64 `json:"objectsPerSec"`
+}
+
+// ServerSpeedTest - throughput of one server.
+type ServerSpeedTest struct {
+	Addr string         `json:"addr"`
+	PUT  SpeedTestStats `json:"PUT"`
+	GET  SpeedTestStats `json:"GET"`
+	Err  string         `json:"err,omitempty"`
+}
+
+// SpeedTestResult - throughput of all servers, with autotune at the
+// concurrency of the round with the fastest uploads.
+type SpeedTestResult struct {
+	Size       int64             `json:"size"`
+	Concurrent int               `json:"concurrent"`
+	Duration   time.Duration     `json:"duration"`
+	PUT        SpeedTestStats    `json:"PUT"`
+	GET        SpeedTestStats    `json:"GET"`
+	Servers    []ServerSpeedTest `json:"servers"`
+}
+
+// SpeedTest - uploads and downloads objects on all Minio servers at the
+// same time and returns the throughput achieved. Objects are written
+// to the backend and removed afterwards.
+func (adm *AdminClient) SpeedTest(opts SpeedTestOpts) (SpeedTestResult, error) {
+	queryVal := make(url.Values)
+	if opts.Size > 0 {
+		queryVal.Set("size", strconv.FormatInt(opts.Size, 10))
+	}
+	if opts.Concurrent > 0 {
+		queryVal.Set("concurrent", strconv.Itoa(opts.Concurrent))
+	}
+	if opts.Duration > 0 {
+		queryVal.Set("duration", opts.Duration.String())
+	}
+	if opts.Autotune {
+		queryVal.Set("autotune", "true")
+	}
+
+	// Execute POST on /minio/admin/v1/speedtest
+	resp, err := adm.executeMethod("POST", requestData{
+		queryValues: queryVal,
+		relPath:     "/v1/speedtest",
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return SpeedTestResult{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return SpeedTestResult{}, httpRespToErrorResponse(resp)
+	}
+
+	var result SpeedTestResult
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return SpeedTestResult{}, err
+	}
+	return result, nil
+}